@@ -14,38 +14,78 @@
 //	-daemon           Run background daemon
 //	-tui              Launch interactive Bubbletea TUI
 //	-starship string  Output one-line Starship segment (claude|billing|infra|all)
+//	-format string    Output format for -starship: text (default) or json
+//	-starship-mtime string  Print the newest cache mtime of a -starship segment
 //	-shell string     Output shell integration script (bash|zsh|fish|ksh)
+//	-prompt-segment string  Starship segment the -shell script caches in PROMPT_PULSE_SEGMENT
 //	-config string    Path to configuration file (default: ~/.config/prompt-pulse/config.toml)
-//	-theme string     Theme override (default|gruvbox|nord|catppuccin|dracula|tokyo-night)
+//	-theme string     Theme override (default|gruvbox|nord|catppuccin|dracula|tokyo-night|solarized-light|auto)
 //	-health           Check daemon health status
+//	-ctl string       Send a control command to the daemon (status|collect|reload|shutdown|accept)
+//	-billing-check    Report which billing providers are enabled and configured
+//	-test-notification  Send a test event through every configured notification sink
+//	-export string    Dump all cached collector data as json or csv, without collecting
+//	-output string    File for -export json (default stdout); directory or .zip file for -export csv
+//	-install-service  Install and start the daemon as a systemd user unit or launchd agent
+//	-uninstall-service  Stop the daemon service and remove its unit or plist
+//	-service-status   Report whether the daemon service is loaded and running
+//	-print-only       With -install-service, print the unit or plist instead of installing it
+//	-mock-scenario string  Render from a named fixture scenario instead of collected data ("list" to show them)
 //	-diagnose         Claude diagnostics
 //	-migrate          Run v1-to-v2 config migration
+//	-init-config      Write a commented default config (-force overwrites an existing one)
 //	-man              Print man page to stdout in roff format
 //	-verbose          Enable verbose logging
+//	-log-format string  Log line format: text or json (default: log.format in config)
 //	-version          Print version and exit
 package main
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/banner"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/deploy"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/docs"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/export"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/image"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/logging"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/migrate"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/mocks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/notify"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/platform"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/shell"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/starship"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/statuspage"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/tui"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/widgets"
 )
 
 func main() {
@@ -54,22 +94,45 @@ func main() {
 		runDaemon      = flag.Bool("daemon", false, "Run background daemon")
 		runTUI         = flag.Bool("tui", false, "Launch interactive Bubbletea TUI")
 		runBanner      = flag.Bool("banner", false, "Display system status banner")
-		starshipMod    = flag.String("starship", "", "Output one-line Starship segment (claude|billing|infra|all)")
+		starshipMod    = flag.String("starship", "", "Output one-line Starship segment (claude|billing|infra|all|summary)")
+		outputFormat   = flag.String("format", "text", "Output format for -starship and -list-collectors (text|json)")
+		starshipMTime  = flag.String("starship-mtime", "", "Print the newest cache mtime (Unix ns) of a -starship segment")
 		shellType      = flag.String("shell", "", "Output shell integration script (bash|zsh|fish|ksh)")
 		themeFlag      = flag.String("theme", "", "Theme override")
 		runHealth      = flag.Bool("health", false, "Check daemon health status")
-		healthJSON     = flag.Bool("json", false, "Output health check as JSON (with -health)")
+		healthJSON     = flag.Bool("json", false, "Output health check as JSON (with -health or -ctl)")
+		ctlCommand     = flag.String("ctl", "", "Send a control command to the daemon (status|collect|reload|shutdown|accept); collect takes an optional collector name, accept a required one")
+		billingCheck   = flag.Bool("billing-check", false, "Report billing provider configuration")
+		listCollectors = flag.Bool("list-collectors", false, "List every collector with whether it is enabled, why, and its interval")
 		runDiagnose    = flag.Bool("diagnose", false, "Claude diagnostics")
 		runMigrate     = flag.Bool("migrate", false, "Run v1-to-v2 config migration")
+		initConfig     = flag.Bool("init-config", false, "Write a commented default config to -config or the standard path")
+		forceInit      = flag.Bool("force", false, "With -init-config, overwrite an existing config file")
 		showMan        = flag.Bool("man", false, "Print man page to stdout in roff format")
 		verbose        = flag.Bool("verbose", false, "Enable verbose logging")
+		logFormat      = flag.String("log-format", "", "Log line format: text or json (default: log.format in config)")
 		showVersion    = flag.Bool("version", false, "Print version and exit")
 		termWidth      = flag.Int("term-width", 0, "Terminal width override (0 = auto-detect)")
 		termHeight     = flag.Int("term-height", 0, "Terminal height override (0 = auto-detect)")
+		noBannerCache  = flag.Bool("no-banner-cache", false, "Render the banner fresh, bypassing the rendered-banner cache")
+		redetectTerm   = flag.Bool("redetect-terminal", false, "Query the terminal for its capabilities again instead of using the cached result")
 		showBanner     = flag.Bool("show-banner", false, "Show banner in shell integration")
 		daemonAutoStart = flag.Bool("daemon-autostart", false, "Auto-start daemon in shell integration")
+		promptSegment   = flag.String("prompt-segment", "", "Starship segment cached in PROMPT_PULSE_SEGMENT by shell integration")
+		testNotify      = flag.Bool("test-notification", false, "Send a test event through every configured notification sink")
+		exportFormat    = flag.String("export", "", "Dump all cached collector data (json|csv) without collecting")
+		exportOutput    = flag.String("output", "", "Write -export output to this file (json), or directory or .zip file (csv); with -render-html, the page file")
+		renderHTML      = flag.Bool("render-html", false, "Render the cached infra, Kubernetes, and billing data as a static HTML status page")
+		installSvc      = flag.Bool("install-service", false, "Install and start the daemon as a systemd user unit (Linux) or launchd agent (macOS)")
+		uninstallSvc    = flag.Bool("uninstall-service", false, "Stop the daemon service and remove its unit or plist")
+		serviceStatus   = flag.Bool("service-status", false, "Report whether the daemon service is installed, loaded, and running")
+		printOnly       = flag.Bool("print-only", false, "With -install-service, print the unit or plist instead of installing it")
+		mockScenario    = flag.String("mock-scenario", "", "Render from a named fixture scenario instead of collected data (\"list\" to show them)")
 	)
 	flag.Parse()
+	httpx.UserAgent = "prompt-pulse/" + version
+	// Each host's deploy profile sets its default collectors.
+	config.SetProfileResolver(deploy.ResolveProfile)
 
 	// ---------------------------------------------------------------
 	// Commands that don't require config
@@ -80,6 +143,10 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *installSvc || *uninstallSvc || *serviceStatus || *printOnly {
+		os.Exit(runService(*installSvc, *uninstallSvc, *printOnly, *configPath))
+	}
+
 	if *showMan {
 		mp := docs.New(os.TempDir())
 		// Generate the main prompt-pulse man page in roff format.
@@ -97,37 +164,30 @@ func main() {
 		os.Exit(0)
 	}
 
-	if *runDiagnose {
-		fmt.Println("prompt-pulse v2 diagnostics")
-		fmt.Println("===========================")
-		fmt.Println()
-		fmt.Println("Theme registry:")
-		for _, name := range theme.Names() {
-			marker := "  "
-			if name == theme.Current.Name {
-				marker = "* "
-			}
-			fmt.Printf("  %s%s\n", marker, name)
+	if *initConfig {
+		path := *configPath
+		if path == "" {
+			path = config.DefaultPath()
 		}
-		fmt.Println()
-		fmt.Println("Config search paths:")
-		home, _ := os.UserHomeDir()
-		fmt.Printf("  %s\n", filepath.Join(home, ".config", "prompt-pulse", "config.toml"))
-		fmt.Println()
-		fmt.Println("Daemon status:")
-		dcfg := daemon.DefaultConfig()
-		d, err := daemon.New(dcfg)
-		if err != nil {
-			fmt.Printf("  daemon init error: %v\n", err)
-		} else if d.IsRunning() {
-			fmt.Println("  running")
-			if health, err := d.Health(); err == nil {
-				data, _ := json.MarshalIndent(health, "  ", "  ")
-				fmt.Println("  " + string(data))
-			}
+		if err := runInitConfig(path, *forceInit); err != nil {
+			fmt.Fprintf(os.Stderr, "init-config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		os.Exit(0)
+	}
+
+	if *runDiagnose {
+		// Load the config first so its secrets are masked in everything
+		// printed: diagnostics are made to be pasted into issues.
+		var diagErr error
+		var diagCfg *config.Config
+		if *configPath != "" {
+			diagCfg, diagErr = config.LoadFromFile(*configPath)
 		} else {
-			fmt.Println("  not running")
+			diagCfg, diagErr = config.Load()
 		}
+		runDiagnostics(secretRedactor(diagCfg).Writer(os.Stdout), diagCfg, diagErr)
 		os.Exit(0)
 	}
 
@@ -155,6 +215,37 @@ func main() {
 		opts := shell.Options{
 			ShowBanner:      *showBanner,
 			DaemonAutoStart: *daemonAutoStart,
+			PromptSegment:   *promptSegment,
+		}
+		var shCfg *config.Config
+		var err error
+		if *configPath != "" {
+			shCfg, err = config.LoadFromFile(*configPath)
+		} else {
+			shCfg, err = config.Load()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+			if *promptSegment != "" {
+				os.Exit(1)
+			}
+		} else if shCfg.Collectors.Shell.Enabled {
+			// Created here so the hooks can append without a mkdir.
+			dir := filepath.Join(shCfg.General.StateDir, shellactivity.DirName)
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				fmt.Fprintf(os.Stderr, "shell activity: %v\n", err)
+			} else {
+				opts.ActivityDir = dir
+			}
+		}
+		if *promptSegment != "" {
+			scfg := starship.Config{CacheDir: shCfg.General.CacheDir}
+			if !starshipSegments(&scfg, *promptSegment, shCfg.Starship.Summary) {
+				fmt.Fprintf(os.Stderr, "unknown starship segment: %s (supported: claude, billing, infra, k8s, system, weather, all, summary)\n", *promptSegment)
+				os.Exit(1)
+			}
+			starshipKubeContext(&scfg, shCfg)
+			opts.PromptCacheFiles = starship.CacheFiles(scfg)
 		}
 		fmt.Print(shell.Generate(st, opts))
 		os.Exit(0)
@@ -217,22 +308,81 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Apply theme override from CLI flag.
-	if *themeFlag != "" {
-		theme.SetCurrent(*themeFlag)
-	} else if cfg.Theme.Name != "" {
-		theme.SetCurrent(cfg.Theme.Name)
+	// Resolve the theme once, before any display mode renders. Only the
+	// banner and TUI may query the terminal for its background.
+	theme.SetCurrent(resolveTheme(cfg, *themeFlag, *runBanner || *runTUI))
+
+	// Choose the render profile the same way. Only the banner checks
+	// stdout: starship output is always captured by the prompt, so a pipe
+	// says nothing about the terminal it ends up in.
+	render.SetCurrent(render.Detect(cfg.Display.ASCII, !*runBanner || stdoutIsTerminal(), os.Getenv))
+
+	// Log through slog in the configured format. Only the daemon writes
+	// the log file: it rotates the file by renaming it, which is safe
+	// only while one process holds it open.
+	// Secrets from the config and environment are masked in every log
+	// line and in the diagnostic commands' output.
+	redactor := secretRedactor(cfg)
+	logOpts := logging.Options{Level: cfg.General.LogLevel, Format: cfg.Log.Format, Redactor: redactor}
+	if *logFormat != "" {
+		logOpts.Format = *logFormat
+	}
+	if *verbose {
+		logOpts.Level = "debug"
+	}
+	if *runDaemon && cfg.Log.File != "" {
+		logOpts.File = cfg.Log.File
+		logOpts.Rotation = logging.Rotation{
+			MaxSize:  int64(cfg.Log.MaxSizeMB) << 20,
+			MaxAge:   cfg.Log.MaxAge.Duration,
+			MaxFiles: cfg.Log.MaxFiles,
+		}
+	}
+	logFile, logErr := logging.Setup(logOpts)
+	if logErr != nil {
+		fmt.Fprintf(os.Stderr, "logging: %v\n", logErr)
+		os.Exit(2)
+	}
+
+	// Encrypted cache entries need the cache key to be read or written.
+	// The daemon refuses to start without it rather than write plaintext.
+	if err := cache.ConfigureEncryption(cfg.Cache.Encrypt, cfg.Cache.KeyFile); err != nil {
+		if *runDaemon {
+			fmt.Fprintf(os.Stderr, "cache encryption: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "warning: cache encryption: %v\n", err)
+	}
+
+	// A mock scenario is written to a cache directory of its own, which
+	// the display modes below then read in place of the daemon's data.
+	if *mockScenario != "" {
+		dir, err := writeMockScenario(*mockScenario)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mock scenario: %v\n", err)
+			os.Exit(2)
+		}
+		if dir == "" {
+			os.Exit(0)
+		}
+		cfg.General.CacheDir = dir
+		cfg.General.StateDir = dir
 	}
 
-	_ = *verbose // reserved for future structured logging
+	// ---------------------------------------------------------------
+	// Control socket
+	// ---------------------------------------------------------------
+
+	if *ctlCommand != "" {
+		os.Exit(runControl(daemonConfig(cfg), *ctlCommand, flag.Arg(0), *healthJSON))
+	}
 
 	// ---------------------------------------------------------------
 	// Health check
 	// ---------------------------------------------------------------
 
 	if *runHealth {
-		dcfg := daemon.DefaultConfig()
-		d, err := daemon.New(dcfg)
+		d, err := daemon.New(daemonConfig(cfg))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "daemon init error: %v\n", err)
 			os.Exit(1)
@@ -269,11 +419,80 @@ func main() {
 					status = "unhealthy"
 				}
 				fmt.Printf("  %s: %s (errors: %d)\n", name, status, c.ErrorCount)
+				if c.LastError != "" {
+					fmt.Printf("    last error: %s\n", c.LastError)
+				}
+				if h := c.HTTP; h != nil {
+					last := fmt.Sprintf("status %d", h.LastStatus)
+					if h.LastError != "" {
+						last = "error: " + h.LastError
+					}
+					fmt.Printf("    http: %d requests, %d retries, last %s\n", h.Requests, h.Retries, last)
+				}
 			}
 		}
 		os.Exit(0)
 	}
 
+	// ---------------------------------------------------------------
+	// Billing provider check
+	// ---------------------------------------------------------------
+
+	if *billingCheck {
+		runBillingProviderCheck(redactor.Writer(os.Stdout), cfg)
+		os.Exit(0)
+	}
+
+	if *listCollectors {
+		if err := runListCollectors(cfg, *outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "list collectors: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *testNotify {
+		if !runTestNotification(cfg) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *exportFormat != "" {
+		if err := runExport(cfg, *exportFormat, *exportOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "export: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *renderHTML {
+		if err := runRenderHTML(cfg, *exportOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "render html: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// ---------------------------------------------------------------
+	// Starship cache mtime (shell prompt fast path)
+	// ---------------------------------------------------------------
+
+	if *starshipMTime != "" {
+		scfg := starship.Config{CacheDir: cfg.General.CacheDir}
+		if !starshipSegments(&scfg, *starshipMTime, cfg.Starship.Summary) {
+			fmt.Fprintf(os.Stderr, "unknown starship segment: %s (supported: claude, billing, infra, k8s, system, weather, all, summary)\n", *starshipMTime)
+			os.Exit(1)
+		}
+		starshipKubeContext(&scfg, cfg)
+		var ns int64
+		if t := starship.CacheMTime(scfg); !t.IsZero() {
+			ns = t.UnixNano()
+		}
+		fmt.Println(ns)
+		os.Exit(0)
+	}
+
 	// ---------------------------------------------------------------
 	// Context with signal handling
 	// ---------------------------------------------------------------
@@ -293,34 +512,46 @@ func main() {
 	// ---------------------------------------------------------------
 
 	if *starshipMod != "" {
+		th := cfg.Starship.Thresholds
 		scfg := starship.Config{
-			CacheDir: cfg.General.CacheDir,
-		}
-		switch *starshipMod {
-		case "claude":
-			scfg.ShowClaude = true
-		case "billing":
-			scfg.ShowBilling = true
-		case "infra", "tailscale":
-			scfg.ShowTailscale = true
-		case "k8s", "kubernetes":
-			scfg.ShowK8s = true
-		case "system", "sys":
-			scfg.ShowSystem = true
-		case "all":
-			scfg.ShowClaude = true
-			scfg.ShowBilling = true
-			scfg.ShowTailscale = true
-			scfg.ShowK8s = true
-			scfg.ShowSystem = true
-		default:
-			fmt.Fprintf(os.Stderr, "unknown starship segment: %s (supported: claude, billing, infra, k8s, system, all)\n", *starshipMod)
+			CacheDir:          cfg.General.CacheDir,
+			ClaudeWarnWithin:  cfg.Collectors.Claude.ForecastWarning.Duration,
+			ClaudeThresholds:  starship.Thresholds(th.Claude),
+			BillingThresholds: starship.Thresholds(th.Billing),
+			SystemThresholds:  starship.Thresholds(th.System),
+			Palette:           starship.ThemePalette(theme.Current),
+			Wrap:              cfg.Starship.Wrap,
+			ClaudeDisplay:     cfg.Starship.ClaudeDisplay,
+			Staleness:         staleness(cfg),
+			Budget:            cfg.Starship.Budget.Duration,
+			OverrunFile:       filepath.Join(cfg.General.CacheDir, starship.OverrunFileName),
+		}
+		if !starshipSegments(&scfg, *starshipMod, cfg.Starship.Summary) {
+			fmt.Fprintf(os.Stderr, "unknown starship segment: %s (supported: claude, billing, infra, k8s, system, weather, all, summary)\n", *starshipMod)
 			os.Exit(1)
 		}
+		if w := cfg.Starship.MaxWidth[*starshipMod]; w > 0 {
+			scfg.MaxWidth = w
+		}
+		starshipKubeContext(&scfg, cfg)
 
-		result := starship.Render(scfg)
-		if result != "" {
-			fmt.Print(result)
+		switch *outputFormat {
+		case "text", "":
+			write := starship.WriteTo
+			if *starshipMod == "summary" {
+				write = starship.WriteSummaryTo
+			}
+			write(context.Background(), os.Stdout, scfg)
+		case "json":
+			data, err := starship.RenderJSON(scfg)
+			if err != nil {
+				// Never break the consumer: fall back to an empty object.
+				data = []byte("{}")
+			}
+			fmt.Println(string(data))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown format: %s (supported: text, json)\n", *outputFormat)
+			os.Exit(1)
 		}
 		os.Exit(0)
 	}
@@ -337,38 +568,67 @@ func main() {
 			}
 		}()
 
-		// Determine terminal dimensions.
+		// Determine terminal dimensions: the flags override what the
+		// terminal reports. Narrow terminals get the stacked layout.
+		size := terminal.GetSize()
 		width := *termWidth
 		height := *termHeight
 		if width <= 0 {
-			width = 120 // sensible default
+			width = size.Cols
 		}
 		if height <= 0 {
-			height = 35
+			height = size.Rows
 		}
 
-		preset := banner.SelectPreset(width, height)
+		preset := banner.SelectPresetWithConfig(width, height, cfg.Banner)
+
+		// Serve the last rendered banner when nothing it depends on has
+		// changed: size (via preset), protocol, terminal, and collector
+		// data. Entries expire after one daemon poll interval.
+		caps := terminal.LoadCapabilities(terminal.CacheOptions{
+			Dir:      cfg.General.CacheDir,
+			Redetect: *redetectTerm,
+		})
+		protocol := caps.ProtocolWithOverride(cfg.Image.Protocol)
+		if !render.Current.Images {
+			protocol = terminal.ProtocolNone
+		}
+		// Output wrapped for a multiplexer is cached apart from bare output.
+		protocolKey := protocol.String()
+		if p, _ := caps.GraphicsPassthrough(protocol); p != terminal.PassthroughNone {
+			protocolKey += "+" + p.String()
+		}
+		cacheOpts := banner.CacheOptions{
+			Layout:    cfg.Banner,
+			Protocol:  protocolKey,
+			Theme:     theme.Current.Name,
+			DataStamp: banner.DataStamp(cfg.General.CacheDir),
+			TTL:       cfg.General.DaemonPollInterval.Duration,
+			Bypass:    *noBannerCache,
+		}
+		if protocol == terminal.ProtocolKitty {
+			cacheOpts.Terminal = image.TTYIdentity()
+		}
+		if cached, ok := banner.LoadCached(cfg.General.CacheDir, preset, cacheOpts); ok {
+			fmt.Print(cached)
+			os.Exit(0)
+		}
 
 		// Build widget data from cached collector data.
-		// For now, render an empty banner (collectors not wired yet).
-		data := banner.BannerData{
-			Widgets: []banner.WidgetData{
-				{
-					ID:      "status",
-					Title:   "System Status",
-					Content: fmt.Sprintf("prompt-pulse v%s (%s)", version, commit),
-					MinW:    30,
-					MinH:    3,
-				},
-			},
-		}
-
-		result, err := banner.RenderCached(cfg.General.CacheDir, data, preset)
-		if err != nil {
+		opts := banner.GenerateOptions{
+			Header:    fmt.Sprintf("prompt-pulse v%s (%s)", version, commit),
+			Staleness: staleness(cfg),
+		}
+		if health, err := daemon.ReadHealthFile(daemonConfig(cfg).HealthFile); err == nil {
+			opts.TimedOut = health.TimedOut()
+		}
+		data := banner.Generate(context.Background(), cfg, preset, opts)
+
+		// Stream the banner: the text shows while any images render.
+		if _, err := banner.WriteCached(context.Background(), os.Stdout, cfg.General.CacheDir, data, preset, cacheOpts); err != nil && !errors.Is(err, banner.ErrImagePlaceholder) {
 			fmt.Fprintf(os.Stderr, "banner render failed: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Print(result)
 		os.Exit(0)
 	}
 
@@ -386,11 +646,40 @@ func main() {
 			}
 		}()
 
-		// Create the TUI model with no widgets for now.
-		// Widget wiring to v2 collectors will be done in a follow-up.
-		model := tui.New(nil)
+		// The daemon keeps the cache current; the TUI re-reads it on a
+		// timer so a long-running dashboard does not go stale.
+		ws := []app.Widget{
+			widgets.NewClaudeWidget(),
+			widgets.NewBillingWidget(),
+			widgets.NewTailscaleWidget(),
+			widgets.NewUptimeKumaWidget(),
+			widgets.NewChecksWidget(),
+			widgets.NewDockerWidget(),
+			widgets.NewK8sWidget(),
+			widgets.NewSysMetricsWidget(),
+		}
+		if cfg.Collectors.Ollama.Enabled {
+			ws = append(ws, widgets.NewOllamaWidget())
+		}
+		if cfg.Collectors.GPU.Enabled {
+			ws = append(ws, widgets.NewGPUWidget())
+		}
+		if cfg.Collectors.Shell.Enabled {
+			ws = append(ws, widgets.NewShellActivityWidget())
+		}
+		if cfg.Events.Enabled {
+			ws = append(ws, widgets.NewEventsWidget())
+		}
+		model := tui.New(ws).WithRefresh(tui.CacheLoaderWithState(cfg.General.CacheDir, cfg.General.StateDir, staleness(cfg)), cfg.General.TUIRefreshInterval.Duration).
+			WithStaleness(cfg.General.CacheDir, staleness(cfg)).
+			WithKeymap(tui.NewKeymap(cfg.TUI.Keys)).
+			WithMouse(cfg.TUI.Mouse)
 
-		p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
+		opts := []tea.ProgramOption{tea.WithAltScreen()}
+		if cfg.TUI.Mouse {
+			opts = append(opts, tea.WithMouseCellMotion())
+		}
+		p := tea.NewProgram(model, opts...)
 		if _, err := p.Run(); err != nil {
 			fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
 			os.Exit(1)
@@ -403,19 +692,53 @@ func main() {
 	// ---------------------------------------------------------------
 
 	if *runDaemon {
-		dcfg := daemon.DefaultConfig()
-		if cfg.General.CacheDir != "" {
-			dcfg.DataDir = cfg.General.CacheDir
+		// Earlier versions kept the event log and history with the
+		// collector data; move them once, before the daemon looks for them.
+		moved, err := migrate.MigrateLayout(cfg.General.CacheDir, cfg.General.StateDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "daemon: migrate state directory: %v\n", err)
+			os.Exit(1)
+		}
+		if len(moved.Moved) > 0 {
+			slog.Info("moved daemon state", "files", moved.Moved, "to", cfg.General.StateDir)
+		}
+		for _, w := range moved.Warnings {
+			slog.Warn("daemon state not moved", "detail", w)
 		}
 
-		d, err := daemon.New(dcfg)
+		d, err := daemon.New(daemonConfig(cfg))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "daemon init failed: %v\n", err)
 			os.Exit(1)
 		}
+		// The daemon re-reads this file on SIGHUP, -ctl reload, or when it
+		// changes.
+		path := *configPath
+		if path == "" {
+			path = config.FindPath()
+		}
+		d.SetConfig(cfg, path)
+
+		// The first signal cancels ctx, and the daemon gives collector
+		// runs in flight general.shutdown_grace to finish; a second one
+		// stops waiting.
+		go func() {
+			<-ctx.Done()
+			<-sigChan
+			d.ForceShutdown()
+		}()
 
 		fmt.Fprintf(os.Stderr, "starting prompt-pulse daemon v%s\n", version)
-		if err := d.Start(ctx); err != nil && err != context.Canceled {
+		slog.Info("daemon starting", "version", version, "pid", os.Getpid())
+		err = d.Start(ctx)
+		if logFile != nil {
+			logFile.Close()
+		}
+		if errors.Is(err, daemon.ErrForcedShutdown) {
+			fmt.Fprintln(os.Stderr, "daemon: shutdown forced by a second signal")
+			os.Exit(1)
+		}
+		if err != nil && err != context.Canceled {
 			fmt.Fprintf(os.Stderr, "daemon error: %v\n", err)
 			os.Exit(1)
 		}
@@ -432,3 +755,678 @@ func main() {
 	fmt.Println()
 	flag.PrintDefaults()
 }
+
+// stdoutIsTerminal reports whether standard output is a terminal rather
+// than a file or pipe.
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// resolveTheme returns the theme name to use: the -theme flag when set,
+// otherwise the configured theme with its per-terminal overrides. "auto"
+// reads the terminal background from the per-TTY capabilities cache, and
+// queries the terminal to fill it only when probe is set.
+func resolveTheme(cfg *config.Config, flagName string, probe bool) string {
+	sel := theme.Selection{
+		Name:      cfg.Theme.Name,
+		Light:     cfg.Theme.Light,
+		Dark:      cfg.Theme.Dark,
+		Terminals: cfg.Theme.Terminals,
+	}
+	if flagName != "" {
+		sel.Name, sel.Terminals = flagName, nil
+	}
+
+	termProgram := os.Getenv("TERM_PROGRAM")
+	background := ""
+	if sel.NeedsBackground(termProgram) {
+		caps := terminal.LoadCapabilities(terminal.CacheOptions{
+			Dir:       cfg.General.CacheDir,
+			CacheOnly: !probe,
+		})
+		background = caps.Background
+	}
+	return sel.Resolve(termProgram, background)
+}
+
+// staleness returns the policy for marking and hiding old cached data:
+// stale after general.stale_after_polls daemon poll intervals, expired
+// after general.expire_after.
+func staleness(cfg *config.Config) cache.Staleness {
+	return cache.Staleness{
+		StaleAfter:  time.Duration(cfg.General.StaleAfterPolls * float64(cfg.General.DaemonPollInterval.Duration)),
+		ExpireAfter: cfg.General.ExpireAfter.Duration,
+	}
+}
+
+// daemonConfig returns the daemon configuration for cfg: collector data
+// lives in the cache directory, and the instance lock, control socket,
+// event log, and history in the state directory, when they are configured.
+func daemonConfig(cfg *config.Config) daemon.Config {
+	dcfg := daemon.DefaultConfig()
+	if cfg.General.CacheDir != "" {
+		dcfg.DataDir = cfg.General.CacheDir
+		dcfg.StateDir = cfg.General.CacheDir
+		dcfg.LockFile = filepath.Join(cfg.General.CacheDir, daemon.LockFileName)
+		dcfg.SocketPath = filepath.Join(cfg.General.CacheDir, daemon.ControlSocketName)
+	}
+	if cfg.General.StateDir != "" {
+		dcfg.StateDir = cfg.General.StateDir
+		dcfg.LockFile = filepath.Join(cfg.General.StateDir, daemon.LockFileName)
+		dcfg.SocketPath = filepath.Join(cfg.General.StateDir, daemon.ControlSocketName)
+	}
+	dcfg.Version = version
+	return dcfg
+}
+
+// runControl sends a control command to the daemon configured by dcfg and
+// prints its reply, as JSON when asJSON is set. When the daemon does not
+// answer, its instance lock says whether it is running at all. It returns
+// the process exit code.
+func runControl(dcfg daemon.Config, command, collector string, asJSON bool) int {
+	resp, err := daemon.NewIPCClient(dcfg.SocketPath).Control(daemon.ControlRequest{
+		Command:   command,
+		Collector: collector,
+	})
+	if err != nil {
+		msg := "daemon not running"
+		if info, ok := daemon.LockHolder(dcfg.LockFile); ok {
+			msg = fmt.Sprintf("daemon running (PID %d) but not reachable at %s: %v", info.PID, dcfg.SocketPath, err)
+		}
+		if asJSON {
+			data, _ := json.Marshal(daemon.ControlResponse{Error: msg})
+			fmt.Println(string(data))
+		} else {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+		return 1
+	}
+
+	if asJSON {
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printControlResponse(resp, time.Now())
+	}
+	if !resp.OK {
+		return 1
+	}
+	return 0
+}
+
+// printControlResponse prints a control reply for a person to read.
+func printControlResponse(resp *daemon.ControlResponse, now time.Time) {
+	if st := resp.Status; st != nil {
+		if st.Version != "" {
+			fmt.Printf("daemon v%s running (PID %d, uptime %s)\n", st.Version, st.PID, st.Uptime.Round(time.Second))
+		} else {
+			fmt.Printf("daemon running (PID %d, uptime %s)\n", st.PID, st.Uptime.Round(time.Second))
+		}
+		names := make([]string, 0, len(st.Collectors))
+		for name := range st.Collectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := st.Collectors[name]
+			status, last := "ok", "never"
+			if !c.Healthy {
+				status = "unhealthy"
+			}
+			if !c.LastRun.IsZero() {
+				last = now.Sub(c.LastRun).Round(time.Second).String() + " ago"
+			}
+			next := ""
+			if !c.NextRun.IsZero() {
+				next = ", next run: in " + max(c.NextRun.Sub(now), 0).Round(time.Second).String()
+				if c.ConsecutiveFailures > 0 {
+					next += fmt.Sprintf(" (backing off, %d failed in a row)", c.ConsecutiveFailures)
+				}
+			}
+			if c.TimedOut {
+				status = "timed out"
+			}
+			if c.Rejected {
+				status = "rejected, cached data kept"
+			}
+			fmt.Printf("  %s: %s (last run: %s%s, errors: %d)\n", name, status, last, next, c.ErrorCount)
+			if c.LastError != "" {
+				fmt.Printf("    last error: %s\n", c.LastError)
+			}
+		}
+		if rs := st.LastReload; rs != nil {
+			ago := now.Sub(rs.At).Round(time.Second).String() + " ago"
+			if rs.OK {
+				fmt.Printf("last reload: %s, ok\n", ago)
+			} else {
+				fmt.Printf("last reload: %s, rejected: %s\n", ago, rs.Error)
+			}
+		}
+		if b := st.LastBackfill; b != nil {
+			fmt.Printf("last backfill: %s ago, after a %s gap (%s)", now.Sub(b.At).Round(time.Second), b.Gap.Round(time.Second), b.Reason)
+			if len(b.Pending) > 0 {
+				fmt.Printf(", waiting for %s", strings.Join(b.Pending, ", "))
+			}
+			fmt.Println()
+		}
+	}
+	if len(resp.Collected) > 0 {
+		fmt.Printf("collected: %s\n", strings.Join(resp.Collected, ", "))
+	}
+	if len(resp.TimedOut) > 0 {
+		fmt.Printf("timed out: %s\n", strings.Join(resp.TimedOut, ", "))
+	}
+	if resp.Message != "" {
+		fmt.Println(resp.Message)
+	}
+	for _, c := range resp.Changes {
+		fmt.Printf("  %s\n", c)
+	}
+	if resp.Error != "" {
+		fmt.Fprintf(os.Stderr, "error: %s\n", resp.Error)
+	}
+}
+
+// runDiagnostics prints the -diagnose report to w: themes, config paths,
+// Claude accounts, the starship budget, and daemon status. cfg is nil when
+// loading it failed with cfgErr.
+func runDiagnostics(w io.Writer, cfg *config.Config, cfgErr error) {
+	fmt.Fprintln(w, "prompt-pulse v2 diagnostics")
+	fmt.Fprintln(w, "===========================")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Theme registry:")
+	for _, name := range theme.Names() {
+		marker := "  "
+		if name == theme.Current.Name {
+			marker = "* "
+		}
+		fmt.Fprintf(w, "  %s%s\n", marker, name)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Config search paths:")
+	home, _ := os.UserHomeDir()
+	fmt.Fprintf(w, "  %s\n", filepath.Join(home, ".config", "prompt-pulse", "config.toml"))
+	fmt.Fprintln(w)
+	if cfgErr != nil {
+		fmt.Fprintln(w, "Claude accounts:")
+		fmt.Fprintf(w, "  config error: %v\n", cfgErr)
+	} else {
+		if err := cache.ConfigureEncryption(cfg.Cache.Encrypt, cfg.Cache.KeyFile); err != nil {
+			fmt.Fprintf(w, "Cache encryption: %v\n\n", err)
+		}
+		runClaudeAccountCheck(w, cfg, time.Now())
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Starship budget: %s\n", cfg.Starship.Budget)
+		if o := starship.ReadOverruns(filepath.Join(cfg.General.CacheDir, starship.OverrunFileName)); o.Count > 0 {
+			fmt.Fprintf(w, "  overran %d times, last %s (%dms, left out %s)\n",
+				o.Count, o.Last.Format(time.RFC3339), o.LastElapsedMS, strings.Join(o.LastLate, ", "))
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Daemon status:")
+	dcfg := daemon.DefaultConfig()
+	if cfgErr == nil {
+		dcfg = daemonConfig(cfg)
+	}
+	d, err := daemon.New(dcfg)
+	if err != nil {
+		fmt.Fprintf(w, "  daemon init error: %v\n", err)
+	} else if info, ok := daemon.LockHolder(dcfg.LockFile); ok {
+		fmt.Fprintf(w, "  running (PID %d, started %s)\n", info.PID, info.StartedAt.Format(time.RFC3339))
+		if health, err := d.Health(); err == nil {
+			data, _ := json.MarshalIndent(health, "  ", "  ")
+			fmt.Fprintln(w, "  "+string(data))
+		}
+	} else {
+		fmt.Fprintln(w, "  not running")
+	}
+}
+
+// secretRedactor returns the redactor for logs and diagnostic output,
+// masking the secrets in cfg, which may be nil, and the environment.
+func secretRedactor(cfg *config.Config) *logging.Redactor {
+	r := logging.NewRedactor()
+	if cfg != nil {
+		r.Add(cfg.Secrets()...)
+	}
+	r.AddEnv(os.Environ())
+	return r
+}
+
+// runBillingProviderCheck prints to w each billing provider with whether
+// it is enabled and whether an API key was found in the config or
+// environment. Keys themselves are never printed.
+func runBillingProviderCheck(w io.Writer, cfg *config.Config) {
+	b := cfg.Collectors.Billing
+	providers := []struct {
+		name    string
+		enabled bool
+		key     string
+		env     string
+	}{
+		{"civo", b.Civo.Enabled, b.Civo.APIKey, "CIVO_TOKEN"},
+		{"digitalocean", b.DigitalOcean.Enabled, b.DigitalOcean.APIKey, "DIGITALOCEAN_TOKEN"},
+		{"hetzner", b.Hetzner.Enabled, b.Hetzner.APIKey, "HCLOUD_TOKEN"},
+		{"vultr", b.Vultr.Enabled, b.Vultr.APIKey, "VULTR_API_KEY"},
+	}
+
+	fmt.Fprintln(w, "Billing providers:")
+	if !b.Enabled {
+		fmt.Fprintln(w, "  (billing collector disabled)")
+	}
+	for _, p := range providers {
+		enabled := "disabled"
+		if p.enabled {
+			enabled = "enabled"
+		}
+		key := "key missing (set " + p.env + " or " + p.env + "_FILE)"
+		if p.key != "" {
+			key = "key configured"
+		}
+		fmt.Fprintf(w, "  %-13s %-8s  %s\n", p.name, enabled, key)
+	}
+}
+
+// runListCollectors prints every collector the daemon knows with whether
+// cfg enables it, why, and its effective interval, as a table or, with
+// format "json", a JSON array.
+func runListCollectors(cfg *config.Config, format string) error {
+	infos := daemon.ListCollectors(cfg)
+	switch format {
+	case "text", "":
+		if p := cfg.Profile(); p != nil {
+			fmt.Printf("Host profile: %s (from %s)\n", p.Name, p.Source)
+			if len(p.Applied) > 0 {
+				fmt.Printf("  applied:    %s\n", strings.Join(p.Applied, ", "))
+			}
+			if len(p.Overridden) > 0 {
+				fmt.Printf("  overridden: %s (set in the config)\n", strings.Join(p.Overridden, ", "))
+			}
+			if len(p.Unknown) > 0 {
+				fmt.Printf("  ignored:    %s (not a collector or display option)\n", strings.Join(p.Unknown, ", "))
+			}
+		}
+		fmt.Println("Collectors:")
+		for _, c := range infos {
+			enabled := "disabled"
+			if c.Enabled {
+				enabled = "enabled"
+			}
+			fmt.Printf("  %-11s %-8s  every %-6s %s\n", c.Name, enabled, c.Interval.Duration, c.Reason)
+		}
+	case "json":
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("unknown format: %s (supported: text, json)", format)
+	}
+	return nil
+}
+
+// runTestNotification sends a dummy event through every configured
+// notification sink, whether or not a rule uses it, and prints the outcome
+// per sink. It reports whether every sink succeeded.
+func runTestNotification(cfg *config.Config) bool {
+	sinks, err := notify.SinksFromConfig(cfg.Notifications)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notification sinks: %v\n", err)
+		return false
+	}
+	if len(sinks) == 0 {
+		fmt.Fprintln(os.Stderr, "no notification sinks configured (add a [[notifications.sink]] table)")
+		return false
+	}
+	if len(cfg.Notifications.Rules) == 0 {
+		fmt.Println("Note: no notification rules are configured, so the daemon sends no notifications.")
+	}
+
+	ev := notify.TestEvent()
+	ok := true
+	fmt.Println("Notification sinks:")
+	for _, s := range sinks {
+		if err := s.Send(context.Background(), ev); err != nil {
+			fmt.Printf("  %-13s failed: %v\n", s.Name(), err)
+			ok = false
+			continue
+		}
+		fmt.Printf("  %-13s sent\n", s.Name())
+	}
+	return ok
+}
+
+// runExport dumps the cached data of every collector in format, json or
+// csv. JSON goes to output, or stdout when it is empty; CSV needs output,
+// a directory for one file per collector or a path ending in .zip for an
+// archive of them. Nothing is collected.
+func runExport(cfg *config.Config, format, output string) error {
+	d := export.Load(cfg.General.CacheDir, staleness(cfg), time.Now())
+
+	switch format {
+	case "json":
+		if output == "" {
+			return export.WriteJSON(os.Stdout, d)
+		}
+		var buf strings.Builder
+		if err := export.WriteJSON(&buf, d); err != nil {
+			return err
+		}
+		return os.WriteFile(output, []byte(buf.String()), 0o600)
+
+	case "csv":
+		switch {
+		case output == "":
+			return fmt.Errorf("-export csv needs -output, a directory or a .zip file")
+		case strings.HasSuffix(strings.ToLower(output), ".zip"):
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			if err := export.WriteCSVZip(f, d); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		default:
+			return export.WriteCSVDir(output, d)
+		}
+	}
+	return fmt.Errorf("unknown format %q (supported: json, csv)", format)
+}
+
+// runRenderHTML renders the status page from the cache to output, or to
+// status_page.path when output is empty, or to stdout when both are.
+func runRenderHTML(cfg *config.Config, output string) error {
+	sp := cfg.StatusPage
+	page := statuspage.Build(cfg, time.Now())
+	if output == "" {
+		output = sp.Path
+	}
+	if output == "" {
+		return statuspage.Render(os.Stdout, page, sp.Template)
+	}
+	return statuspage.WriteFile(output, page, sp.Template)
+}
+
+// runInitConfig writes the commented default config to path, creating its
+// directory. An existing file is only replaced when force is set.
+func runInitConfig(path string, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists (use -force to overwrite it)", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(docs.ConfigScaffold()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeMockScenario writes the named mocks scenario to its own directory
+// under the temp dir and returns that directory. "list" prints the
+// scenarios instead and returns "".
+func writeMockScenario(name string) (string, error) {
+	if name == "list" {
+		for _, s := range mocks.Scenarios() {
+			fmt.Printf("%-18s %s\n", s.Name, s.Description)
+		}
+		return "", nil
+	}
+	s, ok := mocks.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown scenario %q (available: %s)", name, strings.Join(mocks.Names(), ", "))
+	}
+	dir := filepath.Join(os.TempDir(), "prompt-pulse-mock-"+s.Name)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := mocks.WriteToCache(dir, s, time.Now()); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// runService installs (or, with printOnly, prints), uninstalls, or reports
+// on the daemon's systemd user unit or launchd agent, and returns the
+// process exit code. The unit runs this binary with the -config given, or
+// the config file found by the default search.
+func runService(install, uninstall, printOnly bool, configPath string) int {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service: %v\n", err)
+		return 1
+	}
+	bin, err := serviceBinaryPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service: locating prompt-pulse: %v\n", err)
+		return 1
+	}
+	if configPath == "" {
+		configPath = config.FindPath()
+	}
+	if configPath != "" {
+		if abs, err := filepath.Abs(configPath); err == nil {
+			configPath = abs
+		}
+	}
+
+	f, err := platform.NewServiceFile(platform.Current(), home, platform.ServiceConfig{BinaryPath: bin, ConfigPath: configPath})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case printOnly:
+		fmt.Print(f.Content)
+	case install:
+		if err := platform.InstallService(f); err != nil {
+			fmt.Fprintf(os.Stderr, "service: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Installed %s service %s and started the daemon.\n", f.Manager, f.Path)
+	case uninstall:
+		if err := platform.UninstallService(f); err != nil {
+			fmt.Fprintf(os.Stderr, "service: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Stopped the daemon and removed %s.\n", f.Path)
+	default:
+		st, err := platform.QueryServiceStatus(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "service: %v\n", err)
+			return 1
+		}
+		installed := "not installed"
+		if st.Installed {
+			installed = "installed"
+		}
+		fmt.Printf("%s service: %s (%s)\n", f.Manager, installed, f.Path)
+		fmt.Printf("  loaded:  %v\n", st.Loaded)
+		if st.Running {
+			fmt.Printf("  running: true (PID %d)\n", st.PID)
+		} else {
+			fmt.Println("  running: false")
+		}
+		fmt.Printf("  state:   %s\n", st.Detail)
+		if !st.Running {
+			return 1
+		}
+	}
+	return 0
+}
+
+// serviceBinaryPath returns the absolute path the service should run. It
+// prefers the path prompt-pulse was invoked by, so a Homebrew or Nix
+// profile symlink keeps working across upgrades, over os.Executable, which
+// resolves to the versioned target.
+func serviceBinaryPath() (string, error) {
+	name := os.Args[0]
+	if !strings.ContainsRune(name, filepath.Separator) {
+		if p, err := exec.LookPath(name); err == nil {
+			name = p
+		}
+	}
+	if p, err := filepath.Abs(name); err == nil {
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p, nil
+		}
+	}
+	return os.Executable()
+}
+
+// runClaudeAccountCheck prints to w each configured Claude account by its
+// label with the state of its Claude Code credentials file, if any: plan
+// and when the access token expires. Tokens themselves are never printed. The
+// last collection error cached for the account, such as a rejected refresh
+// token, follows on its own line.
+func runClaudeAccountCheck(w io.Writer, cfg *config.Config, now time.Time) {
+	fmt.Fprintln(w, "Claude accounts:")
+	if len(cfg.Collectors.Claude.Accounts) == 0 {
+		fmt.Fprintln(w, "  (no accounts configured)")
+	}
+	lastErrs := make(map[string]string)
+	if data, err := cache.ReadFile(filepath.Join(cfg.General.CacheDir, "claude.json")); err == nil {
+		var r claude.UsageReport
+		if json.Unmarshal(data, &r) == nil {
+			for _, a := range r.Accounts {
+				lastErrs[a.Name] = a.Error
+			}
+		}
+	}
+	for _, a := range cfg.Collectors.Claude.Accounts {
+		runClaudeAccountLine(w, cfg, a, now)
+		if e := lastErrs[a.Name]; e != "" {
+			fmt.Fprintf(w, "  %-12s last collection: %s\n", "", e)
+		}
+	}
+}
+
+// runClaudeAccountLine prints the runClaudeAccountCheck line for a.
+func runClaudeAccountLine(w io.Writer, cfg *config.Config, a config.ClaudeAccountConfig, now time.Time) {
+	if a.Credentials == "" {
+		key := "admin key missing"
+		if a.AdminKey != "" || cfg.Collectors.Claude.AdminKey != "" {
+			key = "admin key configured"
+		}
+		fmt.Fprintf(w, "  %-12s %s\n", a.Name, key)
+		return
+	}
+	creds, err := claude.ReadCredentials(a.Credentials)
+	if err != nil {
+		fmt.Fprintf(w, "  %-12s %v\n", a.Name, err)
+		return
+	}
+	expiry := "no expiry recorded"
+	if !creds.ExpiresAt.IsZero() {
+		if d := creds.ExpiresAt.Sub(now).Round(time.Minute); d > 0 {
+			expiry = "token expires in " + strings.TrimSuffix(d.String(), "0s")
+		} else {
+			expiry = "token expired " + strings.TrimSuffix((-d).String(), "0s") + " ago (refreshed on next collection)"
+		}
+	}
+	plan := creds.Subscription
+	if plan == "" {
+		plan = "unknown plan"
+	}
+	fmt.Fprintf(w, "  %-12s %s  %s, %s\n", a.Name, a.Credentials, plan, expiry)
+}
+
+// starshipSegments enables the segments named by mod in scfg. For
+// "summary" it enables the segments listed in summary and copies its
+// layout settings for starship.RenderSummary. It returns false for an
+// unknown segment name.
+func starshipSegments(scfg *starship.Config, mod string, summary config.StarshipSummaryConfig) bool {
+	switch mod {
+	case "summary":
+		scfg.SummarySegments = summary.Segments
+		scfg.SummarySeparator = summary.Separator
+		scfg.MaxWidth = summary.MaxWidth
+		for _, name := range scfg.SummarySegments {
+			if !starship.IsSummarySegment(name) || !starshipSegments(scfg, name, summary) {
+				return false
+			}
+		}
+		if len(scfg.SummarySegments) == 0 {
+			for _, name := range starship.DefaultSummarySegments {
+				starshipSegments(scfg, name, summary)
+			}
+		}
+	case "claude":
+		scfg.ShowClaude = true
+	case "billing":
+		scfg.ShowBilling = true
+	case "infra":
+		scfg.ShowTailscale = true
+		scfg.ShowUptimeKuma = true
+		scfg.ShowChecks = true
+		scfg.ShowRemote = true
+		scfg.ShowDocker = true
+		scfg.ShowStorage = true
+	case "tailscale":
+		scfg.ShowTailscale = true
+	case "uptimekuma":
+		scfg.ShowUptimeKuma = true
+	case "checks":
+		scfg.ShowChecks = true
+	case "remote":
+		scfg.ShowRemote = true
+	case "docker":
+		scfg.ShowDocker = true
+	case "storage":
+		scfg.ShowStorage = true
+	case "k8s", "kubernetes":
+		scfg.ShowK8s = true
+	case "system", "sys":
+		scfg.ShowSystem = true
+	case "weather":
+		scfg.ShowWeather = true
+	case "all":
+		scfg.ShowClaude = true
+		scfg.ShowBilling = true
+		scfg.ShowTailscale = true
+		scfg.ShowUptimeKuma = true
+		scfg.ShowChecks = true
+		scfg.ShowRemote = true
+		scfg.ShowDocker = true
+		scfg.ShowStorage = true
+		scfg.ShowK8s = true
+		scfg.ShowSystem = true
+		scfg.ShowWeather = true
+	default:
+		return false
+	}
+	return true
+}
+
+// starshipKubeContext points the k8s segment, when enabled in scfg, at the
+// current kubectl context. Only the kubeconfig is read, never the API
+// server; the segment takes the cluster's health from the cache. The
+// segment is dropped when the Kubernetes collector is disabled.
+func starshipKubeContext(scfg *starship.Config, cfg *config.Config) {
+	if !scfg.ShowK8s {
+		return
+	}
+	kc := cfg.Collectors.Kubernetes
+	if !kc.Enabled {
+		scfg.ShowK8s = false
+		return
+	}
+	scfg.KubeconfigFiles = k8s.KubeconfigFiles(kc.Kubeconfig)
+	if name, err := k8s.CurrentContext(kc.Kubeconfig); err == nil {
+		scfg.KubeContext = name
+	}
+}
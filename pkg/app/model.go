@@ -52,6 +52,29 @@ type Widget interface {
 	HandleKey(key tea.KeyMsg) tea.Cmd
 }
 
+// MouseHandler is implemented by widgets that take mouse input. The TUI
+// passes it the events over the widget's content area, and a drag begun
+// there until the button is released, with X and Y relative to the
+// content's top-left cell as last drawn by View. Widgets without it get
+// the scroll wheel as up and down keys.
+type MouseHandler interface {
+	HandleMouse(msg tea.MouseMsg) tea.Cmd
+}
+
+// TableFilterer is implemented by widgets whose View can draw a table the
+// TUI's search narrows down. The TUI searches the table while View draws
+// one and falls back to searching widgets otherwise.
+type TableFilterer interface {
+	// FilterTable shows only the table rows with a cell containing query,
+	// ignoring case. An empty query shows every row.
+	FilterTable(query string)
+
+	// TableFilter returns the active query and how many of the rows of
+	// the table View last drew it matched, of total. ok is false when the
+	// last View drew no table to filter.
+	TableFilter() (query string, matches, total int, ok bool)
+}
+
 // AppModel is the root bubbletea Model for the prompt-pulse v2 dashboard.
 // It owns the widget registry, layout state, data store, and input routing.
 type AppModel struct {
@@ -0,0 +1,26 @@
+package banner
+
+import (
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+)
+
+// AgeSuffix classifies the cached collector data for key in cacheDir
+// against s. It returns a " (3h old)" suffix for stale data, "" for fresh
+// data, and show false when the data is missing or expired and its section
+// should not be shown. Data a daemon backfill is re-collecting is not
+// marked (see cache.EntryFreshness).
+func AgeSuffix(cacheDir, key string, s cache.Staleness, now time.Time) (suffix string, show bool) {
+	age, freshness, ok := cache.EntryFreshness(cacheDir, key, s, now)
+	if !ok {
+		return "", false
+	}
+	switch freshness {
+	case cache.Expired:
+		return "", false
+	case cache.Stale:
+		return " (" + cache.FormatAge(age) + " old)", true
+	}
+	return "", true
+}
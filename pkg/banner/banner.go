@@ -4,6 +4,8 @@
 // result to a fixed-size character grid suitable for display on shell startup.
 package banner
 
+import "gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+
 // Preset defines a named layout preset with target dimensions.
 type Preset struct {
 	Name   string
@@ -39,6 +41,9 @@ func SelectPreset(termWidth, termHeight int) Preset {
 // BannerData holds pre-collected data for all widgets.
 type BannerData struct {
 	Widgets []WidgetData
+
+	// Images are drawn after the widgets' text; see WriteTo.
+	Images []ImageSection
 }
 
 // WidgetData holds the data for a single widget to render.
@@ -53,8 +58,8 @@ type WidgetData struct {
 // Render composes all widget content into a banner string using the given preset.
 // It arranges widgets in a multi-column layout respecting minimum sizes, wraps
 // each widget in a bordered box, and places everything onto a fixed-size
-// character grid.
+// character grid. The Stacked preset stacks the status and system info
+// widgets instead. Images follow the text, as with WriteTo.
 func Render(data BannerData, preset Preset) string {
-	placements := bnArrangeWidgets(data.Widgets, preset.Width, preset.Height)
-	return bnCompose(placements, preset.Width, preset.Height)
+	return RenderWithConfig(data, preset, config.BannerConfig{})
 }
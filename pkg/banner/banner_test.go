@@ -1,13 +1,21 @@
 package banner
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // --- SelectPreset tests ---
@@ -207,6 +215,37 @@ func TestRender_ANSIContentHandledCorrectly(t *testing.T) {
 	}
 }
 
+func TestRender_PlainProfile(t *testing.T) {
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	t.Cleanup(func() { render.SetCurrent(orig) })
+
+	data := BannerData{
+		Widgets: []WidgetData{
+			{ID: "status", Title: "Status", MinW: 30, MinH: 5,
+				Content: components.Color("#4CAF50") + "✓ synced" + components.Reset() + "\n\x1b[31m⚠ disk 92%\x1b[0m\n● up ▲ 3 more"},
+			{ID: "sys", Title: "System", MinW: 30, MinH: 4, Content: "cpu ▃▅█ 71°C\n🐳 docker"},
+		},
+	}
+	result := Render(data, Standard)
+
+	for i := 0; i < len(result); i++ {
+		if result[i] > 0x7f || result[i] == 0x1b {
+			t.Fatalf("byte %#x at %d in plain banner:\n%s", result[i], i, result)
+		}
+	}
+	for _, want := range []string{"+- Status -", "[OK] synced", "[WARN] disk 92%", "* up ^ 3 more", "cpu ### 71C"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("plain banner missing %q:\n%s", want, result)
+		}
+	}
+	for i, line := range strings.Split(result, "\n") {
+		if len(line) != Standard.Width {
+			t.Errorf("line %d is %d bytes, want %d", i, len(line), Standard.Width)
+		}
+	}
+}
+
 // --- bnArrangeWidgets tests ---
 
 func TestBnArrangeWidgets_CompactSingleColumn(t *testing.T) {
@@ -564,3 +603,923 @@ func TestBnIsWaifuWidget(t *testing.T) {
 		}
 	}
 }
+
+// --- Column layout tests ---
+
+// bnTestColumnConfig is status (30%), fastfetch (auto), waifu (auto).
+func bnTestColumnConfig() config.BannerConfig {
+	return config.BannerConfig{
+		StandardMinWidth: 120,
+		WideMinWidth:     160,
+		Columns: []config.BannerColumnConfig{
+			{Name: "status", Width: 30},
+			{Name: "fastfetch"},
+			{Name: "waifu"},
+		},
+	}
+}
+
+func bnTestColumnWidgets() []WidgetData {
+	return []WidgetData{
+		{ID: "waifu", Title: "Waifu", Content: "img", MinH: 10},
+		{ID: "fastfetch", Title: "Host", Content: "nixos", MinH: 6},
+		{ID: "status", Title: "Status", Content: "ok", MinH: 4},
+	}
+}
+
+// bnPlacementX maps widget IDs to their placement X and W.
+func bnPlacementX(placements []bnPlacement) map[string][2]int {
+	m := make(map[string][2]int)
+	for _, p := range placements {
+		m[p.Widget.ID] = [2]int{p.X, p.W}
+	}
+	return m
+}
+
+func TestBnResolveColumns_Boundaries(t *testing.T) {
+	tests := []struct {
+		width int
+		want  []bnColumn
+	}{
+		{80, []bnColumn{{"status", 0, 80}}},
+		{120, []bnColumn{{"status", 0, 36}, {"fastfetch", 36, 84}}},
+		{200, []bnColumn{{"status", 0, 60}, {"fastfetch", 60, 70}, {"waifu", 130, 70}}},
+	}
+	for _, tt := range tests {
+		got, _ := bnResolveColumns(bnTestColumnConfig(), tt.width)
+		if len(got) != len(tt.want) {
+			t.Errorf("width %d: %d columns, want %d (%+v)", tt.width, len(got), len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("width %d column %d = %+v, want %+v", tt.width, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestBnArrangeColumns_Widths(t *testing.T) {
+	tests := []struct {
+		width int
+		want  map[string][2]int
+	}{
+		// One column: everything stacks at full width.
+		{80, map[string][2]int{"status": {0, 80}, "fastfetch": {0, 80}, "waifu": {0, 80}}},
+		// Two columns: waifu collapses into fastfetch's column.
+		{120, map[string][2]int{"status": {0, 36}, "fastfetch": {36, 84}, "waifu": {36, 84}}},
+		{200, map[string][2]int{"status": {0, 60}, "fastfetch": {60, 70}, "waifu": {130, 70}}},
+	}
+	for _, tt := range tests {
+		got := bnPlacementX(bnArrangeColumns(bnTestColumnWidgets(), tt.width, 50, bnTestColumnConfig()))
+		for id, want := range tt.want {
+			if got[id] != want {
+				t.Errorf("width %d: %s at x=%d w=%d, want x=%d w=%d",
+					tt.width, id, got[id][0], got[id][1], want[0], want[1])
+			}
+		}
+	}
+}
+
+func TestBnArrangeColumns_OmittedColumnTakesNoSpace(t *testing.T) {
+	cfg := config.BannerConfig{Columns: []config.BannerColumnConfig{
+		{Name: "waifu", Width: 40},
+		{Name: "status", Width: 20},
+	}}
+	got := bnPlacementX(bnArrangeColumns(bnTestColumnWidgets(), 200, 50, cfg))
+
+	if _, ok := got["fastfetch"]; ok {
+		t.Error("fastfetch widget rendered although its column is omitted")
+	}
+	// Explicit widths are scaled to fill the terminal.
+	if got["waifu"] != [2]int{0, 133} || got["status"] != [2]int{133, 67} {
+		t.Errorf("placements = %v, want waifu 0/133 and status 133/67", got)
+	}
+}
+
+func TestBnArrangeColumns_Order(t *testing.T) {
+	cfg := bnTestColumnConfig()
+	cfg.Columns[0], cfg.Columns[2] = cfg.Columns[2], cfg.Columns[0]
+	got := bnPlacementX(bnArrangeColumns(bnTestColumnWidgets(), 200, 50, cfg))
+	if !(got["waifu"][0] < got["fastfetch"][0] && got["fastfetch"][0] < got["status"][0]) {
+		t.Errorf("columns not in configured order: %v", got)
+	}
+}
+
+func TestRenderWithConfig_DefaultsToPreset(t *testing.T) {
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+	if RenderWithConfig(data, Standard, config.BannerConfig{}) != Render(data, Standard) {
+		t.Error("empty column config should render the preset layout")
+	}
+}
+
+func TestRenderWithConfig_LineWidths(t *testing.T) {
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+	for _, p := range []Preset{Compact, Standard, UltraWide} {
+		out := RenderWithConfig(data, p, bnTestColumnConfig())
+		lines := strings.Split(out, "\n")
+		if len(lines) != p.Height {
+			t.Errorf("%s: %d lines, want %d", p.Name, len(lines), p.Height)
+		}
+		for i, line := range lines {
+			if w := components.VisibleLen(line); w != p.Width {
+				t.Errorf("%s line %d width = %d, want %d", p.Name, i, w, p.Width)
+				break
+			}
+		}
+	}
+}
+
+func TestRenderCachedWithOptions_LayoutInKey(t *testing.T) {
+	dir := t.TempDir()
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+
+	if _, err := RenderCachedWithOptions(dir, data, UltraWide, CacheOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RenderCachedWithOptions(dir, data, UltraWide, CacheOptions{Layout: bnTestColumnConfig()}); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "banner-*.cache"))
+	if len(files) != 2 {
+		t.Errorf("cache files = %d, want one per layout", len(files))
+	}
+}
+
+// bnSignalWriter records writes and closes first after the first one.
+type bnSignalWriter struct {
+	writes []string
+	first  chan struct{}
+}
+
+func (w *bnSignalWriter) Write(p []byte) (int, error) {
+	if len(w.writes) == 0 {
+		close(w.first)
+	}
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestWriteTo_TextBeforeImages(t *testing.T) {
+	w := &bnSignalWriter{first: make(chan struct{})}
+	data := BannerData{
+		Widgets: bnTestColumnWidgets(),
+		Images: []ImageSection{
+			{ID: "waifu", Render: func(ctx context.Context) (string, error) {
+				// Only finishes once the text has been written.
+				select {
+				case <-w.first:
+					return "\x1b_Gimage\x1b\\", nil
+				case <-ctx.Done():
+					return "", ctx.Err()
+				}
+			}},
+			{ID: "broken", Render: func(context.Context) (string, error) {
+				return "\x1b_Gpart", errors.New("decode failed")
+			}},
+			{ID: "logo", Placeholder: "[logo]", Render: func(context.Context) (string, error) {
+				return "", errors.New("no image")
+			}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n, err := WriteTo(ctx, w, data, Standard, config.BannerConfig{})
+	if !errors.Is(err, ErrImagePlaceholder) || !strings.Contains(err.Error(), "broken: decode failed") || !strings.Contains(err.Error(), "logo") {
+		t.Errorf("WriteTo() error = %v, want placeholders for broken and logo", err)
+	}
+	want := []string{Render(BannerData{Widgets: data.Widgets}, Standard), "\x1b_Gimage\x1b\\", bnImagePlaceholder, "[logo]"}
+	if strings.Join(w.writes, "|") != strings.Join(want, "|") {
+		t.Errorf("writes = %q, want the text, the image, and two placeholders", w.writes)
+	}
+	if total := len(strings.Join(want, "")); n != int64(total) {
+		t.Errorf("n = %d, want %d", n, total)
+	}
+}
+
+func TestWriteTo_ContextEndsDuringImage(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	data := BannerData{
+		Widgets: bnTestColumnWidgets(),
+		Images: []ImageSection{{ID: "slow", Render: func(context.Context) (string, error) {
+			<-release
+			return "late", nil
+		}}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var b strings.Builder
+	if _, err := WriteTo(ctx, &b, data, Compact, config.BannerConfig{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WriteTo() error = %v, want the deadline", err)
+	}
+	if out := b.String(); !strings.HasSuffix(out, bnImagePlaceholder) || strings.Contains(out, "late") {
+		t.Errorf("output ends %q, want the placeholder", out[len(out)-40:])
+	}
+}
+
+func TestWriteCached_SkipsCacheOnPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	fail := true
+	data := BannerData{
+		Widgets: bnTestColumnWidgets(),
+		Images: []ImageSection{{ID: "waifu", Render: func(context.Context) (string, error) {
+			if fail {
+				return "", errors.New("no image")
+			}
+			return "<image>", nil
+		}}},
+	}
+
+	var b strings.Builder
+	if _, err := WriteCached(context.Background(), &b, dir, data, Standard, CacheOptions{}); !errors.Is(err, ErrImagePlaceholder) {
+		t.Fatalf("WriteCached() error = %v, want a placeholder", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "banner-*.cache")); len(files) != 0 {
+		t.Errorf("cache files = %v, want none for a banner with a placeholder", files)
+	}
+
+	fail = false
+	b.Reset()
+	if _, err := WriteCached(context.Background(), &b, dir, data, Standard, CacheOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "<image>") {
+		t.Errorf("output does not end with the image")
+	}
+	fail = true
+	if got, err := RenderCachedWithOptions(dir, data, Standard, CacheOptions{}); err != nil || got != b.String() {
+		t.Errorf("RenderCachedWithOptions() = %v, want the cached banner with its image", err)
+	}
+}
+
+// --- Rendered banner cache tests ---
+
+func TestLoadCached_HitUntilDataChanges(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "claude.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+	opts := CacheOptions{Protocol: "kitty", DataStamp: DataStamp(dir), TTL: time.Minute}
+
+	if _, ok := LoadCached(dir, Standard, opts); ok {
+		t.Fatal("LoadCached hit on an empty cache")
+	}
+	rendered, err := RenderCachedWithOptions(dir, data, Standard, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The next shell finds it before building any widget data.
+	got, ok := LoadCached(dir, Standard, opts)
+	if !ok || got != rendered {
+		t.Fatalf("LoadCached = %v, want the rendered banner", ok)
+	}
+
+	// New collector data changes the stamp and misses.
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "claude.json"), future, future)
+	opts.DataStamp = DataStamp(dir)
+	if _, ok := LoadCached(dir, Standard, opts); ok {
+		t.Error("LoadCached should miss after collector data changes")
+	}
+}
+
+func TestLoadCached_KeyedOnProtocolAndTerminal(t *testing.T) {
+	dir := t.TempDir()
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+	opts := CacheOptions{Protocol: "kitty", Terminal: "136:100", DataStamp: DataStamp(dir)}
+	if _, err := RenderCachedWithOptions(dir, data, Standard, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	other := opts
+	other.Protocol = "halfblocks"
+	if _, ok := LoadCached(dir, Standard, other); ok {
+		t.Error("a different protocol should not share the cached banner")
+	}
+	other = opts
+	other.Terminal = "136:200"
+	if _, ok := LoadCached(dir, Standard, other); ok {
+		t.Error("a fresh terminal should not reuse Kitty placements")
+	}
+	other = opts
+	other.Theme = "solarized-light"
+	if _, ok := LoadCached(dir, Standard, other); ok {
+		t.Error("a different theme should not share the cached banner")
+	}
+	if _, ok := LoadCached(dir, Wide, opts); ok {
+		t.Error("a different size should not share the cached banner")
+	}
+
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	defer render.SetCurrent(orig)
+	if _, ok := LoadCached(dir, Standard, opts); ok {
+		t.Error("plain output should not share the cached banner")
+	}
+}
+
+func TestLoadCached_TTLAndBypass(t *testing.T) {
+	dir := t.TempDir()
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+	opts := CacheOptions{DataStamp: DataStamp(dir), TTL: time.Hour}
+	if _, err := RenderCachedWithOptions(dir, data, Standard, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Age the entry past a short TTL but within the long one.
+	files, _ := filepath.Glob(filepath.Join(dir, "banner-*.cache"))
+	past := time.Now().Add(-10 * time.Minute)
+	for _, f := range files {
+		os.Chtimes(f, past, past)
+	}
+	if _, ok := LoadCached(dir, Standard, opts); !ok {
+		t.Error("entry within the TTL should hit")
+	}
+	opts.TTL = 5 * time.Minute
+	if _, ok := LoadCached(dir, Standard, opts); ok {
+		t.Error("entry older than the TTL should miss")
+	}
+
+	opts.TTL = time.Hour
+	opts.Bypass = true
+	if _, ok := LoadCached(dir, Standard, opts); ok {
+		t.Error("Bypass should never hit")
+	}
+	before, _ := filepath.Glob(filepath.Join(dir, "banner-*.cache"))
+	if _, err := RenderCachedWithOptions(dir, BannerData{}, Compact, opts); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := filepath.Glob(filepath.Join(dir, "banner-*.cache"))
+	if len(after) != len(before) {
+		t.Error("Bypass should not write a cache file")
+	}
+}
+
+func TestCacheKey_ZeroOptionsUnchanged(t *testing.T) {
+	data := BannerData{Widgets: bnTestColumnWidgets()}
+	if bnOptionsCacheKey(data, Standard, CacheOptions{}) != bnCacheKey(data, Standard) {
+		t.Error("zero options should keep the content-based key")
+	}
+}
+
+// --- SysInfo tests ---
+
+func bnFakeSysInfo(ctx context.Context) (SysInfo, error) {
+	return SysInfo{
+		Hostname:  "honey",
+		OS:        "ubuntu 24.04",
+		Kernel:    "6.8.0",
+		Arch:      "x86_64",
+		Uptime:    76*time.Hour + 30*time.Minute,
+		Load:      [3]float64{0.5, 0.25, 1},
+		MemUsed:   4 << 30,
+		MemTotal:  16 << 30,
+		DiskUsed:  100 << 30,
+		DiskTotal: 400 << 30,
+		IPs:       []string{"10.0.0.5", "100.64.0.1"},
+	}, nil
+}
+
+func bnStubFastfetch(t *testing.T, out string, err error) *int {
+	t.Helper()
+	calls := 0
+	orig := bnRunFastfetch
+	bnRunFastfetch = func(context.Context) (string, error) {
+		calls++
+		return out, err
+	}
+	t.Cleanup(func() { bnRunFastfetch = orig })
+	return &calls
+}
+
+func TestSysInfoWidget_Native(t *testing.T) {
+	calls := bnStubFastfetch(t, "fastfetch output", nil)
+	w := SysInfoWidget(context.Background(), SysInfoNative, 80, bnFakeSysInfo)
+
+	want := strings.Join([]string{
+		"host    honey",
+		"os      ubuntu 24.04 6.8.0 (x86_64)",
+		"uptime  3d 4h",
+		"load    0.50 0.25 1.00",
+		"memory  4.0/16.0 GiB (25%)",
+		"disk    100.0/400.0 GiB (25%)",
+		"ip      10.0.0.5, 100.64.0.1",
+	}, "\n")
+	if w.ID != "sysinfo" {
+		t.Errorf("ID = %q, want sysinfo", w.ID)
+	}
+	if w.Content != want {
+		t.Errorf("content:\n%s\nwant:\n%s", w.Content, want)
+	}
+	if *calls != 0 {
+		t.Errorf("native mode ran fastfetch %d times", *calls)
+	}
+}
+
+func TestSysInfoWidget_AutoNeverRunsFastfetch(t *testing.T) {
+	calls := bnStubFastfetch(t, "fastfetch output", nil)
+	w := SysInfoWidget(context.Background(), SysInfoAuto, 80, bnFakeSysInfo)
+	if *calls != 0 {
+		t.Errorf("auto mode ran fastfetch %d times", *calls)
+	}
+	if !strings.HasPrefix(w.Content, "host    honey") {
+		t.Errorf("auto mode content = %q, want native info", w.Content)
+	}
+}
+
+func TestSysInfoWidget_Truncates(t *testing.T) {
+	bnStubFastfetch(t, "", nil)
+	w := SysInfoWidget(context.Background(), SysInfoNative, 12, bnFakeSysInfo)
+	for _, line := range strings.Split(w.Content, "\n") {
+		if components.VisibleLen(line) > 12 {
+			t.Errorf("line %q wider than 12", line)
+		}
+	}
+}
+
+func TestSysInfoWidget_Fastfetch(t *testing.T) {
+	calls := bnStubFastfetch(t, "OS: Arch\nHost: honey\n", nil)
+	w := SysInfoWidget(context.Background(), SysInfoFastfetch, 80, bnFakeSysInfo)
+	if *calls != 1 {
+		t.Errorf("fastfetch calls = %d, want 1", *calls)
+	}
+	if w.Content != "OS: Arch\nHost: honey" {
+		t.Errorf("content = %q, want fastfetch output", w.Content)
+	}
+}
+
+func TestSysInfoWidget_FastfetchFallsBack(t *testing.T) {
+	bnStubFastfetch(t, "", errors.New("not found"))
+	w := SysInfoWidget(context.Background(), SysInfoFastfetch, 80, bnFakeSysInfo)
+	if !strings.HasPrefix(w.Content, "host    honey") {
+		t.Errorf("content = %q, want native fallback", w.Content)
+	}
+}
+
+func TestSysInfoWidget_NativeError(t *testing.T) {
+	bnStubFastfetch(t, "", nil)
+	failing := func(context.Context) (SysInfo, error) { return SysInfo{}, errors.New("boom") }
+	w := SysInfoWidget(context.Background(), SysInfoNative, 80, failing)
+	if w.Content != "system info unavailable" {
+		t.Errorf("content = %q", w.Content)
+	}
+}
+
+func TestSysInfoWidget_InFastfetchColumn(t *testing.T) {
+	if got := bnWidgetColumn(WidgetData{ID: "sysinfo"}); got != bnColumnFastfetch {
+		t.Errorf("sysinfo column = %q, want %q", got, bnColumnFastfetch)
+	}
+}
+
+// --- WeatherSuffix tests ---
+
+func TestWeatherSuffix(t *testing.T) {
+	dir := t.TempDir()
+	if got := WeatherSuffix(dir); got != "" {
+		t.Errorf("WeatherSuffix(empty) = %q, want empty", got)
+	}
+
+	path := filepath.Join(dir, "weather.json")
+	if err := os.WriteFile(path, []byte(`{"temperature":71.4,"unit":"°F","glyph":"⛅"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := WeatherSuffix(dir); got != "⛅ 71°F" {
+		t.Errorf("WeatherSuffix = %q, want %q", got, "⛅ 71°F")
+	}
+
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if got := WeatherSuffix(dir); got != "" {
+		t.Errorf("WeatherSuffix(stale) = %q, want empty", got)
+	}
+}
+
+// --- OllamaLine tests ---
+
+func TestOllamaLine(t *testing.T) {
+	dir := t.TempDir()
+	if got := OllamaLine(dir); got != "" {
+		t.Errorf("OllamaLine(empty) = %q, want empty", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ollama.json"), []byte(`{"available":true,"models":[`+
+		`{"name":"llama3.1:8b","size_bytes":5153960755,"vram_bytes":5153960755}],"vram_bytes":5153960755,"requests":128}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := OllamaLine(dir), "Ollama llama3.1:8b · 4.8GB VRAM · 128 req"; got != want {
+		t.Errorf("OllamaLine = %q, want %q", got, want)
+	}
+}
+
+// --- ChecksLine tests ---
+
+func TestChecksLine(t *testing.T) {
+	dir := t.TempDir()
+	if got := ChecksLine(dir); got != "" {
+		t.Errorf("ChecksLine(empty) = %q, want empty", got)
+	}
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "checks.json"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"checks":[{"name":"nas","state":"up"},{"name":"router","state":"pending"}]}`)
+	if got, want := ChecksLine(dir), "Checks ⚠ 1/2"; got != want {
+		t.Errorf("ChecksLine(ungrouped) = %q, want %q", got, want)
+	}
+
+	write(`{"checks":[` +
+		`{"name":"api","state":"up","group":"website"},` +
+		`{"name":"cdn","state":"up","group":"website"},` +
+		`{"name":"db","state":"up","group":"website","critical":true},` +
+		`{"name":"primary","state":"down","group":"storage","critical":true},` +
+		`{"name":"replica","state":"up","group":"storage"},` +
+		`{"name":"router","state":"up"}]}`)
+	if got, want := ChecksLine(dir), "Checks storage ✗ 1/2 · website ✓ 3/3 · other ✓ 1/1"; got != want {
+		t.Errorf("ChecksLine(grouped) = %q, want %q", got, want)
+	}
+}
+
+// --- GPULines tests ---
+
+func TestGPULines(t *testing.T) {
+	dir := t.TempDir()
+	if got := GPULines(dir); got != nil {
+		t.Errorf("GPULines(empty) = %q, want nil", got)
+	}
+
+	path := filepath.Join(dir, "gpu.json")
+	if err := os.WriteFile(path, []byte(`{"available":false,"error":"no supported GPU found","gpus":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := GPULines(dir); got != nil {
+		t.Errorf("GPULines(not available) = %q, want nil", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"available":true,"gpus":[`+
+		`{"index":0,"name":"RTX 3090","utilization_percent":67,"memory_used_bytes":8803844096,"memory_total_bytes":25769803776,"temperature_c":71},`+
+		`{"index":1,"name":"Apple M2 Pro","utilization_percent":12,"memory_used_bytes":1395802112}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"GPU 67% 8.2/24GB 71°C", "GPU 12% 1.3GB"}
+	if got := GPULines(dir); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("GPULines = %q, want %q", got, want)
+	}
+}
+
+// --- AgeSuffix tests ---
+
+func TestAgeSuffix(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	s := cache.Staleness{StaleAfter: time.Hour, ExpireAfter: 24 * time.Hour}
+	if _, show := AgeSuffix(dir, "weather", s, now); show {
+		t.Error("AgeSuffix(missing) should hide the section")
+	}
+
+	path := filepath.Join(dir, "weather.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		age    time.Duration
+		suffix string
+		show   bool
+	}{
+		{10 * time.Minute, "", true},
+		{3*time.Hour + time.Minute, " (3h old)", true},
+		{25 * time.Hour, "", false},
+	} {
+		at := now.Add(-tc.age)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+		suffix, show := AgeSuffix(dir, "weather", s, now)
+		if suffix != tc.suffix || show != tc.show {
+			t.Errorf("AgeSuffix(%v old) = %q, %v; want %q, %v", tc.age, suffix, show, tc.suffix, tc.show)
+		}
+	}
+
+	// Data the daemon is re-collecting after a suspend is not marked old.
+	at := now.Add(-3 * time.Hour)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.WriteBackfill(dir, &cache.Backfill{At: now, Reason: cache.BackfillResume, Pending: []string{"weather"}}); err != nil {
+		t.Fatal(err)
+	}
+	if suffix, show := AgeSuffix(dir, "weather", s, now); suffix != "" || !show {
+		t.Errorf("AgeSuffix(backfilling) = %q, %v; want no suffix", suffix, show)
+	}
+}
+
+// --- ClaudeForecastLine tests ---
+
+func TestClaudeForecastLine(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	if got := ClaudeForecastLine(dir, now); got != "" {
+		t.Errorf("ClaudeForecastLine(empty) = %q, want empty", got)
+	}
+
+	write := func(report string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "claude.json"), []byte(report), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"timestamp":"2026-02-09T11:55:00Z","accounts":[
+		{"name":"personal","forecast":{"limit":100,"tokens_per_hour":9000}},
+		{"name":"work","forecast":{"limit":100,"tokens_per_hour":41200,"limit_at":"2026-02-09T13:05:00Z"}}]}`)
+	if got, want := ClaudeForecastLine(dir, now), "Claude work: limit in ~1h05m (41.2K tok/h)"; got != want {
+		t.Errorf("ClaudeForecastLine = %q, want %q", got, want)
+	}
+	if got := ClaudeForecastLine(dir, now.Add(2*time.Hour)); got != "" {
+		t.Errorf("ClaudeForecastLine(stale) = %q, want empty", got)
+	}
+
+	write(`{"timestamp":"2026-02-09T11:55:00Z","accounts":[
+		{"name":"work","forecast":{"limit":100,"limit_at":"2026-02-09T11:55:00Z"}}]}`)
+	if got, want := ClaudeForecastLine(dir, now), "Claude work: window limit reached"; got != want {
+		t.Errorf("ClaudeForecastLine = %q, want %q", got, want)
+	}
+}
+
+func TestClaudeCostLine(t *testing.T) {
+	dir := t.TempDir()
+	if got := ClaudeCostLine(dir); got != "" {
+		t.Errorf("ClaudeCostLine(empty) = %q, want empty", got)
+	}
+
+	write := func(report string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "claude.json"), []byte(report), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"accounts":[{"name":"api","current_month":{"cost_usd":12.5}}]}`)
+	if got, want := ClaudeCostLine(dir), "Claude $12.50"; got != want {
+		t.Errorf("ClaudeCostLine = %q, want %q", got, want)
+	}
+
+	write(`{"accounts":[
+		{"name":"api","current_month":{"cost_usd":12.5}},
+		{"name":"idle"},
+		{"name":"personal","sessions_cost_usd":1.5,"unpriced_models":["claude-next"]}]}`)
+	if got, want := ClaudeCostLine(dir), "Claude $14.00: api $12.50, personal $1.50* (*claude-next at default rate)"; got != want {
+		t.Errorf("ClaudeCostLine = %q, want %q", got, want)
+	}
+}
+
+// --- Generate tests ---
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(key, data string, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, key+".json")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("claude", `{"accounts":[{"name":"api","current_month":{"cost_usd":12.5}}]}`, time.Minute)
+	write("billing", `{"providers":[{"name":"civo","month_to_date":24.6,"breakdown":[{"type":"instance","count":3,"cost":21}]}]}`, 3*time.Hour)
+	write("ollama", `{"available":false,"error":"ollama not running"}`, 0)
+	write("checks", `{"checks":[{"name":"api","state":"down","group":"website"}]}`, 0)
+	write("events", `[{"time":"`+now.Add(-5*time.Minute).Format(time.RFC3339)+`","source":"checks","text":"checks api up→down"}]`, 0)
+
+	cfg := config.DefaultConfig()
+	cfg.General.CacheDir = dir
+	cfg.General.StateDir = dir
+	cfg.Collectors.Billing.Enabled = true
+	cfg.Collectors.Ollama.Enabled = true
+	cfg.Collectors.Checks.Enabled = true
+	cfg.Banner.BillingBreakdown = true
+	cfg.Banner.ShowLastEvent = true
+	cfg.Banner.Fastfetch.Mode = SysInfoNative
+	sysInfo := func(context.Context) (SysInfo, error) { return SysInfo{Hostname: "testhost"}, nil }
+
+	data := Generate(context.Background(), cfg, Preset{Name: "compact", Width: 90, Height: 20}, GenerateOptions{
+		Header:    "prompt-pulse vtest",
+		Staleness: cache.Staleness{StaleAfter: time.Hour},
+		TimedOut:  []string{"uptimekuma"},
+		SysInfo:   sysInfo,
+		Now:       now,
+	})
+	if len(data.Widgets) != 2 {
+		t.Fatalf("Generate() = %d widgets, want status and system", len(data.Widgets))
+	}
+	status := data.Widgets[0].Content
+	for _, want := range []string{"prompt-pulse vtest", "Claude $12.50", "Ollama inactive", "Checks website ✗ 0/1", "civo $24.60: instance $21.00 (3h old)", "Δ checks api up→down (5m ago)", "⏱ stale: uptimekuma"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status = %q, want it to contain %q", status, want)
+		}
+	}
+	if !strings.Contains(data.Widgets[1].Content, "testhost") {
+		t.Errorf("system = %q, want the stub host", data.Widgets[1].Content)
+	}
+}
+
+func TestLastEventLine(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	if got := LastEventLine(dir, now); got != "" {
+		t.Errorf("LastEventLine(empty) = %q, want empty", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "events.json"), []byte(`[
+		{"time":"2026-02-09T10:00:00Z","source":"billing","text":"billing.digitalocean +$3.20"},
+		{"time":"2026-02-09T11:48:00Z","source":"k8s","text":"k8s prod pods running 41→39"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := LastEventLine(dir, now), "Δ k8s prod pods running 41→39 (12m ago)"; got != want {
+		t.Errorf("LastEventLine = %q, want %q", got, want)
+	}
+}
+
+// --- BillingBreakdownLines tests ---
+
+func TestBillingBreakdownLines(t *testing.T) {
+	dir := t.TempDir()
+	if got := BillingBreakdownLines(dir); got != nil {
+		t.Errorf("BillingBreakdownLines(empty) = %q, want nil", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "billing.json"), []byte(`{"providers":[
+		{"name":"civo","month_to_date":24.6,"breakdown":[{"type":"instance","count":3,"cost":21},{"type":"volume","count":2,"cost":1.6}]},
+		{"name":"digitalocean","month_to_date":45}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"civo $24.60: instance $21.00, volume $1.60"}
+	if got := BillingBreakdownLines(dir); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("BillingBreakdownLines = %q, want %q", got, want)
+	}
+
+	// A billing period other than the calendar month is labeled.
+	if err := os.WriteFile(filepath.Join(dir, "billing.json"), []byte(`{"providers":[
+		{"name":"civo","month_to_date":24.6,"period_start":"2026-02-12T00:00:00Z","period_end":"2026-03-12T00:00:00Z",
+		 "breakdown":[{"type":"instance","count":3,"cost":24.6}]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"civo $24.60 (Feb 12 – Mar 11): instance $24.60"}
+	if got := BillingBreakdownLines(dir); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("BillingBreakdownLines = %q, want %q", got, want)
+	}
+}
+
+func TestBillingAnomalyLines(t *testing.T) {
+	dir := t.TempDir()
+	if got := BillingAnomalyLines(dir); got != nil {
+		t.Errorf("BillingAnomalyLines(empty) = %q, want nil", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "billing.json"), []byte(`{"currency":"USD","providers":[
+		{"name":"digitalocean","connected":true,"month_to_date":45,"anomaly":{"spend":6.2,"baseline":2,"factor":3.1}},
+		{"name":"vultr","connected":true,"month_to_date":4,"anomaly":{"spend":4,"baseline":0}},
+		{"name":"civo","connected":true,"month_to_date":24.6}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"⚠ digitalocean spend 3.1× usual", "⚠ vultr spend $4.00 today, usually $0.00"}
+	if got := BillingAnomalyLines(dir); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("BillingAnomalyLines = %q, want %q", got, want)
+	}
+}
+
+func TestShellActivityLine(t *testing.T) {
+	dir := t.TempDir()
+	if got := ShellActivityLine(dir); got != "" {
+		t.Errorf("ShellActivityLine(empty) = %q, want empty", got)
+	}
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "shell.json"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"sessions":[{"shell":"zsh","pid":42,"active":true}],"commands":0}`)
+	if got := ShellActivityLine(dir); got != "" {
+		t.Errorf("ShellActivityLine(no commands) = %q, want empty", got)
+	}
+
+	write(`{"sessions":[{"shell":"zsh","pid":42,"active":true,"commands":50,"failures":3}],"commands":50,"failures":3,
+		"longest":{"name":"make","exit":0,"duration":252000000000}}`)
+	if got, want := ShellActivityLine(dir), "Shell 50 commands today · 6% failed · longest make 4m12s"; got != want {
+		t.Errorf("ShellActivityLine = %q, want %q", got, want)
+	}
+}
+
+// --- Stacked layout tests ---
+
+var bnUpdateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// bnTestNarrowWidgets is the banner main builds: status, system info, and a
+// waifu image, with a status line wider than any narrow terminal.
+func bnTestNarrowWidgets() []WidgetData {
+	return []WidgetData{
+		{ID: "waifu", Title: "Waifu", Content: strings.Repeat("▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀\n", 11) + "▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀", MinW: 20, MinH: 14},
+		{ID: "status", Title: "System Status", MinW: 30, MinH: 3,
+			Content: "prompt-pulse v2.0.5 (abc1234)  ☀ 18°C Ithaca\nClaude work: limit in ~1h05m (41.2K tok/h)\ncivo $24.60: instance $21.00, volume $1.60, load_balancer $3.00"},
+		{ID: "sysinfo", Title: "System", Content: "host    yoga\nos      nixos 25.05\nuptime  3d 4h\nmemory  12.1/32.0 GiB (38%)"},
+	}
+}
+
+func TestSelectPresetWithConfig(t *testing.T) {
+	tests := []struct {
+		width    int
+		cfg      config.BannerConfig
+		wantName string
+		wantW    int
+	}{
+		{60, config.BannerConfig{}, "stacked", 60},
+		{69, config.BannerConfig{}, "stacked", 69},
+		{70, config.BannerConfig{}, "compact", 70},
+		{90, config.BannerConfig{}, "compact", 80},
+		{90, config.BannerConfig{StackBelowWidth: 100}, "stacked", 90},
+		{130, config.BannerConfig{StackBelowWidth: 100}, "standard", 120},
+	}
+	for _, tt := range tests {
+		p := SelectPresetWithConfig(tt.width, 40, tt.cfg)
+		if p.Name != tt.wantName || p.Width != tt.wantW {
+			t.Errorf("SelectPresetWithConfig(%d, below %d) = %s %d wide, want %s %d wide",
+				tt.width, tt.cfg.StackBelowWidth, p.Name, p.Width, tt.wantName, tt.wantW)
+		}
+	}
+}
+
+// TestRenderWithConfig_NarrowGolden renders the same banner at 60, 70, and
+// 90 columns: stacked below the 70-column breakpoint, a single column at
+// and above it. Run with -update to rewrite testdata/narrow-*.golden.
+func TestRenderWithConfig_NarrowGolden(t *testing.T) {
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	t.Cleanup(func() { render.SetCurrent(orig) })
+
+	data := BannerData{Widgets: bnTestNarrowWidgets()}
+	for _, width := range []int{60, 70, 90} {
+		t.Run(strconv.Itoa(width), func(t *testing.T) {
+			preset := SelectPresetWithConfig(width, 24, config.BannerConfig{})
+			got := RenderWithConfig(data, preset, config.BannerConfig{}) + "\n"
+
+			for i, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+				if w := components.VisibleLen(line); w > width {
+					t.Errorf("line %d is %d wide, over the %d-column terminal: %q", i, w, width, line)
+				}
+			}
+
+			path := filepath.Join("testdata", fmt.Sprintf("narrow-%d.golden", width))
+			if *bnUpdateGolden {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("%d-column banner differs from %s:\n%s", width, path, got)
+			}
+		})
+	}
+}
+
+func TestRenderWithConfig_StackOrder(t *testing.T) {
+	data := BannerData{Widgets: bnTestNarrowWidgets()}
+	preset := SelectPresetWithConfig(60, 40, config.BannerConfig{})
+
+	placements, used := bnArrangeStacked(data.Widgets, preset.Width, preset.Height, []string{"sysinfo", "Waifu", "status", "waifu"})
+	var order []string
+	for _, p := range placements {
+		order = append(order, p.Widget.ID)
+		if p.X != 0 || p.W != 60 {
+			t.Errorf("%s placed at x=%d w=%d, want full width", p.Widget.ID, p.X, p.W)
+		}
+	}
+	if got := strings.Join(order, ","); got != "waifu,status" {
+		t.Errorf("stacked order = %s, want waifu,status (unknown and repeated names skipped)", got)
+	}
+	if placements[0].H != bnStackedWaifuMaxHeight || used != bnStackedWaifuMaxHeight+5 {
+		t.Errorf("waifu height %d, rows used %d; want a %d-row thumbnail and 5-row status", placements[0].H, used, bnStackedWaifuMaxHeight)
+	}
+
+	// The banner is only as tall as the stacked sections.
+	out := RenderWithConfig(data, preset, config.BannerConfig{StackOrder: []string{"status"}})
+	if n := strings.Count(out, "\n") + 1; n != 5 {
+		t.Errorf("status-only stacked banner is %d lines, want 5", n)
+	}
+}
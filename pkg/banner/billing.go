@@ -0,0 +1,75 @@
+package banner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+)
+
+// BillingBreakdownLines returns one line per provider that itemizes its
+// charges, from the cached billing collector data in cacheDir, listing its
+// spend in the billing period by resource type, most expensive first. A
+// period that is not a calendar month is labeled with its days. It returns
+// nil when the data is missing or unreadable, or no provider itemizes.
+// Amounts are in the currency the provider bills in.
+// Example: "civo $24.60: instance $21.00, volume $1.60"
+// Example: "civo $24.60 (Feb 12 – Mar 11): instance $21.00, volume $1.60"
+func BillingBreakdownLines(cacheDir string) []string {
+	r := bnReadBilling(cacheDir)
+	if r == nil {
+		return nil
+	}
+	var lines []string
+	for _, p := range r.Providers {
+		if len(p.Breakdown) == 0 {
+			continue
+		}
+		currency := p.BillingCurrency()
+		parts := make([]string, len(p.Breakdown))
+		for i, it := range p.Breakdown {
+			parts[i] = it.Type + " " + billing.FormatAmount(it.Cost, currency)
+		}
+		spend := p.FormatNative()
+		if label := p.PeriodLabel(); label != "" {
+			spend += " (" + label + ")"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s", p.Name, spend, strings.Join(parts, ", ")))
+	}
+	return lines
+}
+
+// BillingAnomalyLines returns one line per provider whose spend today is
+// unusual for it, from the cached billing collector data in cacheDir, or
+// nil when there is none or the data is missing or unreadable.
+// Example: "⚠ digitalocean spend 3.1× usual"
+func BillingAnomalyLines(cacheDir string) []string {
+	r := bnReadBilling(cacheDir)
+	if r == nil {
+		return nil
+	}
+	var lines []string
+	for _, p := range r.Providers {
+		if p.Anomaly != nil && p.Connected {
+			lines = append(lines, "⚠ "+p.Anomaly.Summary(p.Name, r.DisplayCurrency()))
+		}
+	}
+	return lines
+}
+
+// bnReadBilling returns the cached billing report in cacheDir, or nil when
+// it is missing or unreadable.
+func bnReadBilling(cacheDir string) *billing.BillingReport {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "billing.json"))
+	if err != nil {
+		return nil
+	}
+	var r billing.BillingReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil
+	}
+	return &r
+}
@@ -1,50 +1,186 @@
 package banner
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // bnCacheTTL is the maximum age of a cached banner file before it is
 // considered stale and re-rendered.
 const bnCacheTTL = 30 * time.Second
 
+// CacheOptions tunes RenderCachedWithOptions and LoadCached.
+type CacheOptions struct {
+	// Layout is the column layout (see RenderWithConfig).
+	Layout config.BannerConfig
+
+	// Protocol is the graphics protocol the banner's images were rendered
+	// for. Banners for different protocols never share an entry, so a
+	// cached string always carries escape sequences the terminal accepts.
+	Protocol string
+
+	// Terminal identifies the terminal instance (see image.TTYIdentity).
+	// Set it when images use Kitty ID reuse: their placement-only output
+	// is only valid in the terminal that received the original transmit.
+	Terminal string
+
+	// Theme is the active theme name. Widgets color their content from
+	// it, so a terminal with a different resolved theme gets its own
+	// entry.
+	Theme string
+
+	// DataStamp summarizes collector data freshness (see DataStamp). When
+	// set it replaces widget content in the cache key, so LoadCached can
+	// answer before any widget data is built.
+	DataStamp string
+
+	// TTL is the maximum age of a cache entry. Zero uses 30 seconds.
+	TTL time.Duration
+
+	// Bypass renders fresh without reading or writing the cache.
+	Bypass bool
+}
+
 // RenderCached renders the banner, using a disk cache to avoid redundant
 // work. If a cached file exists for the given data+preset combination and
 // is younger than 30 seconds, its contents are returned directly (the
 // fast <1ms path). Otherwise the banner is rendered fresh, written to the
 // cache atomically (temp file + rename), and the result is returned.
 func RenderCached(cacheDir string, data BannerData, preset Preset) (string, error) {
-	key := bnCacheKey(data, preset)
-	path := filepath.Join(cacheDir, "banner-"+key+".cache")
-
-	// Check for a fresh cache hit.
-	if info, err := os.Stat(path); err == nil {
-		age := time.Since(info.ModTime())
-		if age < bnCacheTTL {
-			content, err := os.ReadFile(path)
-			if err == nil {
-				return string(content), nil
-			}
-			// Fall through on read error.
+	return RenderCachedWithOptions(cacheDir, data, preset, CacheOptions{})
+}
+
+// RenderCachedWithOptions is RenderCached with a column layout, cache key
+// inputs, and TTL taken from opts. It is WriteCached collected into a
+// string.
+func RenderCachedWithOptions(cacheDir string, data BannerData, preset Preset, opts CacheOptions) (string, error) {
+	var b strings.Builder
+	if _, err := WriteCached(context.Background(), &b, cacheDir, data, preset, opts); err != nil && !errors.Is(err, ErrImagePlaceholder) {
+		return b.String(), err
+	}
+	return b.String(), nil
+}
+
+// WriteCached writes the banner to w like WriteTo, using the disk cache as
+// RenderCachedWithOptions does: a fresh cached banner is written whole,
+// and otherwise the banner is streamed as it renders and then cached. A
+// banner with an image replaced by its placeholder is not cached, so the
+// next call tries the image again. Errors are as for WriteTo.
+func WriteCached(ctx context.Context, w io.Writer, cacheDir string, data BannerData, preset Preset, opts CacheOptions) (int64, error) {
+	if opts.Bypass {
+		return WriteTo(ctx, w, data, preset, opts.Layout)
+	}
+
+	path := bnCachePath(cacheDir, bnOptionsCacheKey(data, preset, opts))
+	if content, ok := bnReadFresh(path, opts.TTL); ok {
+		n, err := io.WriteString(w, content)
+		return int64(n), err
+	}
+
+	var rendered strings.Builder
+	n, err := WriteTo(ctx, io.MultiWriter(w, &rendered), data, preset, opts.Layout)
+	if err == nil {
+		// A cache write failure is non-fatal; the banner was written.
+		_ = bnAtomicWriteCache(cacheDir, path, rendered.String())
+	}
+	return n, err
+}
+
+// LoadCached returns a banner previously stored by RenderCachedWithOptions
+// for the same preset and options, without needing widget data. It only
+// answers when opts.DataStamp is set, since otherwise the key depends on
+// widget content.
+func LoadCached(cacheDir string, preset Preset, opts CacheOptions) (string, bool) {
+	if opts.Bypass || opts.DataStamp == "" {
+		return "", false
+	}
+	return bnReadFresh(bnCachePath(cacheDir, bnOptionsCacheKey(BannerData{}, preset, opts)), opts.TTL)
+}
+
+// DataStamp hashes the names and modification times of the collector
+// cache files (*.json) in cacheDir. It changes whenever a collector writes
+// new data, and is cheap enough to compute on every shell start.
+func DataStamp(cacheDir string) string {
+	h := sha256.New()
+	entries, _ := os.ReadDir(cacheDir) // sorted by name
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
 		}
+		fmt.Fprintf(h, "%s:%d\x00", e.Name(), info.ModTime().UnixNano())
+	}
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:12])
+}
+
+// bnCachePath returns the cache file for key.
+func bnCachePath(cacheDir, key string) string {
+	return filepath.Join(cacheDir, "banner-"+key+".cache")
+}
+
+// bnReadFresh returns the contents of path if it is younger than ttl
+// (bnCacheTTL when zero).
+func bnReadFresh(path string, ttl time.Duration) (string, bool) {
+	if ttl <= 0 {
+		ttl = bnCacheTTL
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) >= ttl {
+		return "", false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
 	}
+	return string(content), true
+}
 
-	// Render fresh.
-	result := Render(data, preset)
+// bnOptionsCacheKey extends bnCacheKey with the options that change the
+// output, and with render.Current when it is not render.Full. With zero
+// options and the full profile it equals bnCacheKey. With a DataStamp, widget
+// content is left out of the key in favor of the stamp.
+func bnOptionsCacheKey(data BannerData, preset Preset, opts CacheOptions) string {
+	if len(opts.Layout.Columns) == 0 && len(opts.Layout.StackOrder) == 0 && opts.Protocol == "" && opts.Terminal == "" && opts.Theme == "" && opts.DataStamp == "" && render.Current == render.Full {
+		return bnCacheKey(data, preset)
+	}
 
-	// Write to cache atomically.
-	if err := bnAtomicWriteCache(cacheDir, path, result); err != nil {
-		// Cache write failure is non-fatal; return the rendered result.
-		return result, nil
+	base := bnCacheKey(data, preset)
+	if opts.DataStamp != "" {
+		base = bnCacheKey(BannerData{}, preset)
 	}
 
-	return result, nil
+	h := sha256.New()
+	h.Write([]byte(base))
+	fmt.Fprintf(h, "\x00%s\x00%s\x00%s\x00%s", opts.Protocol, opts.Terminal, opts.Theme, opts.DataStamp)
+	if render.Current != render.Full {
+		fmt.Fprintf(h, "\x00%s", render.Current)
+	}
+	if len(opts.Layout.Columns) > 0 {
+		fmt.Fprintf(h, "\x00%d:%d", opts.Layout.StandardMinWidth, opts.Layout.WideMinWidth)
+		for _, c := range opts.Layout.Columns {
+			fmt.Fprintf(h, "\x00%s:%d", c.Name, c.Width)
+		}
+	}
+	if preset.Name == Stacked.Name {
+		fmt.Fprintf(h, "\x00%s", strings.Join(opts.Layout.StackOrder, ","))
+	}
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:12])
 }
 
 // bnCacheKey produces a deterministic cache key by hashing all widget data
@@ -0,0 +1,36 @@
+package banner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+)
+
+// ChecksLine returns a status line rolling up the cached checks collector
+// data in cacheDir by group, with the state and member counts of each, or
+// of all checks when none names a group. It returns "" when the data is
+// missing, unreadable, or has no checks.
+// Example: "Checks website ✓ 3/3 · storage ✗ 1/2 · other ⚠ 4/5"
+func ChecksLine(cacheDir string) string {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "checks.json"))
+	if err != nil {
+		return ""
+	}
+	var s checks.Status
+	if err := json.Unmarshal(data, &s); err != nil || len(s.Checks) == 0 {
+		return ""
+	}
+	groups := s.Groups()
+	if !s.Grouped() {
+		groups[0].Name = "Checks"
+		return groups[0].Label()
+	}
+	labels := make([]string, len(groups))
+	for i, g := range groups {
+		labels[i] = g.Label()
+	}
+	return "Checks " + strings.Join(labels, " · ")
+}
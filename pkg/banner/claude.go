@@ -0,0 +1,96 @@
+package banner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+)
+
+// ClaudeForecastLine returns a status line naming the Claude account
+// forecast to reach its usage window limit soonest, from the cached claude
+// collector data in cacheDir. It returns "" when no account is projected to
+// reach its limit this window or the data is older than
+// claude.ForecastLookback.
+// Example: "Claude work: limit in ~23m (41.2K tok/h)"
+func ClaudeForecastLine(cacheDir string, now time.Time) string {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "claude.json"))
+	if err != nil {
+		return ""
+	}
+	var r claude.UsageReport
+	if err := json.Unmarshal(data, &r); err != nil || now.Sub(r.Timestamp) > claude.ForecastLookback {
+		return ""
+	}
+	name, left, ok := r.SoonestLimit(now)
+	if !ok {
+		return ""
+	}
+	var rate float64
+	for _, a := range r.Accounts {
+		if a.Name == name {
+			rate = a.Forecast.TokensPerHour
+		}
+	}
+	if left == 0 {
+		return fmt.Sprintf("Claude %s: window limit reached", name)
+	}
+	return fmt.Sprintf("Claude %s: limit in ~%s (%.1fK tok/h)", name, bnFormatMinutes(left), rate/1000)
+}
+
+// ClaudeCostLine returns a status line with the estimated Claude cost from
+// the cached claude collector data in cacheDir, split by account when
+// there are several. Accounts without API usage are costed from their
+// sessions. Costs that include models priced at the default rate are
+// marked with "*" and the models named. It returns "" when there is no
+// cost to show.
+// Example: "Claude $14.00: api $12.50, personal $1.50* (*claude-next at default rate)"
+func ClaudeCostLine(cacheDir string) string {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "claude.json"))
+	if err != nil {
+		return ""
+	}
+	var r claude.UsageReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return ""
+	}
+	_, total, unpriced := r.Spend()
+	if total <= 0 {
+		return ""
+	}
+
+	mark := func(models []string) string {
+		if len(models) > 0 {
+			return "*"
+		}
+		return ""
+	}
+	line := fmt.Sprintf("Claude $%.2f%s", total, mark(unpriced))
+	if len(r.Accounts) > 1 {
+		var parts []string
+		for i := range r.Accounts {
+			a := &r.Accounts[i]
+			if _, cost := a.Spend(); cost > 0 {
+				parts = append(parts, fmt.Sprintf("%s $%.2f%s", a.Name, cost, mark(a.UnpricedModels)))
+			}
+		}
+		line = fmt.Sprintf("Claude $%.2f: %s", total, strings.Join(parts, ", "))
+	}
+	if len(unpriced) > 0 {
+		line += fmt.Sprintf(" (*%s at default rate)", strings.Join(unpriced, ", "))
+	}
+	return line
+}
+
+// bnFormatMinutes formats d as "23m" or "1h05m".
+func bnFormatMinutes(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,194 @@
+package banner
+
+import (
+	"context"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// Banner column names accepted in config.BannerColumnConfig.
+const (
+	bnColumnWaifu     = "waifu"
+	bnColumnFastfetch = "fastfetch"
+	bnColumnStatus    = "status"
+)
+
+// Default collapse breakpoints, used when BannerConfig leaves them unset.
+const (
+	bnDefaultTwoColumnWidth   = 120
+	bnDefaultThreeColumnWidth = 160
+)
+
+// bnColumn is a resolved banner column.
+type bnColumn struct {
+	Name string
+	X    int
+	W    int
+}
+
+// RenderWithConfig renders like Render, but lays out columns from
+// cfg.Columns when set: columns appear in the configured order, omitted
+// columns take no space, and narrow terminals collapse to fewer columns at
+// cfg.StandardMinWidth and cfg.WideMinWidth. Widgets belonging to a
+// collapsed column move into the last visible column. With no columns
+// configured the preset layout is used. The Stacked preset stacks the
+// sections in cfg.StackOrder instead. It is WriteTo collected into a
+// string.
+func RenderWithConfig(data BannerData, preset Preset, cfg config.BannerConfig) string {
+	var b strings.Builder
+	WriteTo(context.Background(), &b, data, preset, cfg)
+	return b.String()
+}
+
+// bnRenderText lays out and composes the widgets of data as
+// RenderWithConfig describes, without the images.
+func bnRenderText(data BannerData, preset Preset, cfg config.BannerConfig) string {
+	if preset.Name == Stacked.Name {
+		return bnRenderStacked(data, preset, cfg.StackOrder)
+	}
+	if len(cfg.Columns) == 0 {
+		placements := bnArrangeWidgets(data.Widgets, preset.Width, preset.Height)
+		return bnCompose(placements, preset.Width, preset.Height)
+	}
+	placements := bnArrangeColumns(data.Widgets, preset.Width, preset.Height, cfg)
+	return bnCompose(placements, preset.Width, preset.Height)
+}
+
+// bnResolveColumns returns the columns visible at width with their
+// offsets and widths. Unknown and duplicate column names are skipped.
+func bnResolveColumns(cfg config.BannerConfig, width int) (visible []bnColumn, collapsed []string) {
+	var cols []config.BannerColumnConfig
+	seen := make(map[string]bool)
+	for _, c := range cfg.Columns {
+		name := strings.ToLower(c.Name)
+		switch name {
+		case bnColumnWaifu, bnColumnFastfetch, bnColumnStatus:
+		default:
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		c.Name = name
+		cols = append(cols, c)
+	}
+	if len(cols) == 0 || width <= 0 {
+		return nil, nil
+	}
+
+	twoCol := cfg.StandardMinWidth
+	if twoCol <= 0 {
+		twoCol = bnDefaultTwoColumnWidth
+	}
+	threeCol := cfg.WideMinWidth
+	if threeCol <= 0 {
+		threeCol = bnDefaultThreeColumnWidth
+	}
+	limit := len(cols)
+	switch {
+	case width < twoCol:
+		limit = 1
+	case width < threeCol:
+		limit = min(limit, 2)
+	}
+	for _, c := range cols[limit:] {
+		collapsed = append(collapsed, c.Name)
+	}
+	cols = cols[:limit]
+
+	// Auto columns split what the explicit ones leave. If the explicit
+	// widths leave nothing, every column is weighted equally instead.
+	explicit, autos := 0, 0
+	for _, c := range cols {
+		if c.Width > 0 {
+			explicit += c.Width
+		} else {
+			autos++
+		}
+	}
+	autoPct := 0
+	if autos > 0 {
+		autoPct = (100 - explicit) / autos
+		if autoPct <= 0 {
+			autoPct = 100 / len(cols)
+		}
+	}
+	weights := make([]int, len(cols))
+	total := 0
+	for i, c := range cols {
+		weights[i] = c.Width
+		if c.Width <= 0 {
+			weights[i] = autoPct
+		}
+		total += weights[i]
+	}
+
+	x := 0
+	for i, c := range cols {
+		w := width * weights[i] / total
+		if i == len(cols)-1 {
+			w = width - x // last column takes the rounding remainder
+		}
+		visible = append(visible, bnColumn{Name: c.Name, X: x, W: w})
+		x += w
+	}
+	return visible, collapsed
+}
+
+// bnWidgetColumn returns the column a widget belongs to.
+func bnWidgetColumn(w WidgetData) string {
+	switch {
+	case bnIsWaifuWidget(w):
+		return bnColumnWaifu
+	case strings.HasPrefix(w.ID, bnColumnFastfetch), w.ID == "sysinfo":
+		return bnColumnFastfetch
+	default:
+		return bnColumnStatus
+	}
+}
+
+// bnArrangeColumns places widgets into the configured columns, stacking
+// them vertically within each column in input order.
+func bnArrangeColumns(widgets []WidgetData, width, height int, cfg config.BannerConfig) []bnPlacement {
+	if len(widgets) == 0 || width <= 0 || height <= 0 {
+		return nil
+	}
+
+	cols, collapsed := bnResolveColumns(cfg, width)
+	if len(cols) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(cols)+len(collapsed))
+	for i, c := range cols {
+		index[c.Name] = i
+	}
+	for _, name := range collapsed {
+		index[name] = len(cols) - 1
+	}
+
+	colY := make([]int, len(cols))
+	var placements []bnPlacement
+	for _, w := range widgets {
+		col, ok := index[bnWidgetColumn(w)]
+		if !ok {
+			continue // column omitted from the layout
+		}
+		c := cols[col]
+		wh := bnWidgetHeight(w, c.W, height-colY[col])
+		if wh <= 0 {
+			continue
+		}
+		placements = append(placements, bnPlacement{
+			Widget: w,
+			X:      c.X,
+			Y:      colY[col],
+			W:      c.W,
+			H:      wh,
+		})
+		colY[col] += wh
+	}
+	return placements
+}
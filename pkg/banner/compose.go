@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // bnCompose places rendered widget boxes onto a 2D character grid and returns
@@ -14,7 +15,8 @@ import (
 // The returned string has exactly `height` lines, each with exactly `width`
 // visible characters. ANSI escape sequences are handled correctly: visible
 // length is measured with components.VisibleLen and lines are truncated with
-// components.Truncate.
+// components.Truncate. The result is finally fitted to render.Current, so
+// plain profiles get ASCII with no escape sequences.
 func bnCompose(placements []bnPlacement, width, height int) string {
 	if width <= 0 || height <= 0 {
 		return ""
@@ -37,7 +39,7 @@ func bnCompose(placements []bnPlacement, width, height int) string {
 	for i, row := range grid {
 		lines[i] = string(row)
 	}
-	return strings.Join(lines, "\n")
+	return render.Current.Apply(strings.Join(lines, "\n"))
 }
 
 // bnStampOnGrid writes the rendered box content onto the grid at position
@@ -0,0 +1,22 @@
+package banner
+
+import (
+	"path/filepath"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+)
+
+// LastEventLine returns a status line with the most recent change in the
+// daemon's event log in stateDir and how long ago it was logged. It
+// returns "" when nothing was logged or the log is unreadable.
+// Example: "Δ k8s prod pods running 41→39 (12m ago)"
+func LastEventLine(stateDir string, now time.Time) string {
+	events, err := changelog.Read(filepath.Join(stateDir, changelog.FileName))
+	if err != nil || len(events) == 0 {
+		return ""
+	}
+	last := events[len(events)-1]
+	return "Δ " + last.Text + " (" + cache.FormatAge(max(now.Sub(last.Time), 0)) + " ago)"
+}
@@ -0,0 +1,129 @@
+package banner
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// GenerateOptions holds what Generate needs beyond the configuration.
+type GenerateOptions struct {
+	// Header is the first line of the status section, e.g. the version.
+	Header string
+
+	// Staleness decides how old cached data is marked or hidden.
+	Staleness cache.Staleness
+
+	// TimedOut names the collectors whose last daemon run timed out, from
+	// the daemon's health file.
+	TimedOut []string
+
+	// SysInfo gathers native system info; nil uses NativeSysInfo.
+	SysInfo SysInfoFunc
+
+	// Now is the time cached data ages are measured against. Zero means
+	// time.Now.
+	Now time.Time
+}
+
+// Generate builds the banner's sections for preset from the collector data
+// cached in cfg.General.CacheDir: a status section with the lines of each
+// enabled collector, optionally the last change in the event log in
+// cfg.General.StateDir, and the
+// system info section. Data older than opts.Staleness allows is marked
+// with its age or left out.
+func Generate(ctx context.Context, cfg *config.Config, preset Preset, opts GenerateOptions) BannerData {
+	dir := cfg.General.CacheDir
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	age := func(key string) (string, bool) {
+		return AgeSuffix(dir, key, opts.Staleness, now)
+	}
+
+	status := opts.Header
+	if cfg.Collectors.Weather.Enabled {
+		suffix, ok := age("weather")
+		if w := WeatherSuffix(dir); ok && w != "" {
+			status += "  " + w + suffix
+		}
+	}
+	if cfg.Collectors.Claude.Enabled {
+		suffix, ok := age("claude")
+		if line := ClaudeCostLine(dir); ok && line != "" {
+			status += "\n" + line + suffix
+		}
+		if line := ClaudeForecastLine(dir, now); ok && line != "" {
+			status += "\n" + line + suffix
+		}
+	}
+	if cfg.Collectors.Ollama.Enabled {
+		suffix, ok := age("ollama")
+		if line := OllamaLine(dir); ok && line != "" {
+			status += "\n" + line + suffix
+		}
+	}
+	if cfg.Collectors.Checks.Enabled {
+		suffix, ok := age("checks")
+		if line := ChecksLine(dir); ok && line != "" {
+			status += "\n" + line + suffix
+		}
+	}
+	if cfg.Collectors.Billing.Enabled {
+		if suffix, ok := age("billing"); ok {
+			if cfg.Banner.BillingBreakdown {
+				for _, line := range BillingBreakdownLines(dir) {
+					status += "\n" + line + suffix
+				}
+			}
+			for _, line := range BillingAnomalyLines(dir) {
+				status += "\n" + line + suffix
+			}
+		}
+	}
+	if cfg.Banner.ShowShellActivity && cfg.Collectors.Shell.Enabled {
+		suffix, ok := age("shell")
+		if line := ShellActivityLine(dir); ok && line != "" {
+			status += "\n" + line + suffix
+		}
+	}
+	if cfg.Banner.ShowLastEvent {
+		if line := LastEventLine(cfg.General.StateDir, now); line != "" {
+			status += "\n" + line
+		}
+	}
+	if len(opts.TimedOut) > 0 {
+		status += "\n⏱ stale: " + strings.Join(opts.TimedOut, ", ")
+	}
+
+	sysWidth := preset.Width / 3
+	if preset.Name == Stacked.Name {
+		sysWidth = preset.Width - 2 // full width inside the border
+	}
+	sys := SysInfoWidget(ctx, cfg.Banner.Fastfetch.Mode, sysWidth, opts.SysInfo)
+	if cfg.Collectors.GPU.Enabled {
+		if suffix, ok := age("gpu"); ok {
+			for _, line := range GPULines(dir) {
+				sys.Content += "\n" + components.Truncate(line+suffix, sysWidth)
+			}
+		}
+	}
+
+	return BannerData{
+		Widgets: []WidgetData{
+			{
+				ID:      "status",
+				Title:   "System Status",
+				Content: status,
+				MinW:    30,
+				MinH:    3,
+			},
+			sys,
+		},
+	}
+}
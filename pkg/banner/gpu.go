@@ -0,0 +1,29 @@
+package banner
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/gpu"
+)
+
+// GPULines returns one compact "GPU 67% 8.2/24GB 71°C" line per GPU from
+// the cached gpu collector data in cacheDir, for appending to the system
+// info column. It returns nil when the data is missing or unreadable, or
+// the machine has no supported GPU.
+func GPULines(cacheDir string) []string {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "gpu.json"))
+	if err != nil {
+		return nil
+	}
+	var s gpu.Status
+	if err := json.Unmarshal(data, &s); err != nil || !s.Available {
+		return nil
+	}
+	lines := make([]string, 0, len(s.GPUs))
+	for _, d := range s.GPUs {
+		lines = append(lines, d.Summary())
+	}
+	return lines
+}
@@ -2,6 +2,7 @@ package banner
 
 import (
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // bnPlacement describes where a widget is placed on the character grid.
@@ -220,5 +221,5 @@ func bnPickColumn(colY []int, layout bnColumnLayout, colWidths []int) int {
 func bnRenderWidgetBox(w WidgetData, boxW, boxH int) string {
 	style := components.DefaultBoxStyle()
 	style.Title = w.Title
-	return components.RenderBox(w.Content, boxW, boxH, style)
+	return components.RenderBox(render.Current.Text(w.Content), boxW, boxH, style)
 }
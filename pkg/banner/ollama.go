@@ -0,0 +1,26 @@
+package banner
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/ollama"
+)
+
+// OllamaLine returns a status line with the models loaded by the local
+// Ollama server from the cached ollama collector data in cacheDir, or
+// "Ollama inactive" when the server is not running. It returns "" when
+// the data is missing or unreadable.
+// Example: "Ollama llama3.1:8b · 4.8GB VRAM · 128 req"
+func OllamaLine(cacheDir string) string {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "ollama.json"))
+	if err != nil {
+		return ""
+	}
+	var s ollama.Status
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ""
+	}
+	return s.Summary()
+}
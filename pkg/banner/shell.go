@@ -0,0 +1,28 @@
+package banner
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+)
+
+// ShellActivityLine returns a status line with today's commands across
+// the shells reporting to the cached shell collector data in cacheDir. It
+// returns "" when the data is missing, unreadable, or no command ran.
+// Example: "Shell 142 commands today · 6% failed · longest make 4m12s"
+func ShellActivityLine(cacheDir string) string {
+	data, err := cache.ReadFile(filepath.Join(cacheDir, "shell.json"))
+	if err != nil {
+		return ""
+	}
+	var a shellactivity.Activity
+	if err := json.Unmarshal(data, &a); err != nil {
+		return ""
+	}
+	if s := a.Summary(); s != "" {
+		return "Shell " + s
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package banner
+
+import (
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// Stacked is the layout for terminals too narrow for columns: sections are
+// stacked top to bottom, each the full terminal width, and the banner is
+// only as tall as its sections. SelectPresetWithConfig returns it sized to
+// the terminal.
+var Stacked = Preset{"stacked", 0, 0}
+
+// Stacking defaults, used when BannerConfig leaves them unset.
+const (
+	bnDefaultStackBelowWidth = 70
+
+	// bnStackedWaifuMaxHeight caps the waifu section when stack_order
+	// includes it, so it stays a thumbnail above or below the data.
+	bnStackedWaifuMaxHeight = 8
+)
+
+// bnDefaultStackOrder is the stacking order when BannerConfig leaves it
+// unset: status first, then system info, and no waifu.
+var bnDefaultStackOrder = []string{bnColumnStatus, bnColumnFastfetch}
+
+// SelectPresetWithConfig chooses the layout for a terminal of the given
+// size. Below cfg.StackBelowWidth it returns Stacked at the terminal's
+// size; otherwise it returns SelectPreset's choice, narrowed to the
+// terminal so that no line is wider than it.
+func SelectPresetWithConfig(termWidth, termHeight int, cfg config.BannerConfig) Preset {
+	below := cfg.StackBelowWidth
+	if below <= 0 {
+		below = bnDefaultStackBelowWidth
+	}
+	if termWidth > 0 && termWidth < below {
+		return Preset{Name: Stacked.Name, Width: termWidth, Height: termHeight}
+	}
+	p := SelectPreset(termWidth, termHeight)
+	if termWidth > 0 && p.Width > termWidth {
+		p.Width = termWidth
+	}
+	return p
+}
+
+// bnRenderStacked renders data in the Stacked layout with the sections in
+// order, trimming the banner to the rows the sections use.
+func bnRenderStacked(data BannerData, preset Preset, order []string) string {
+	placements, used := bnArrangeStacked(data.Widgets, preset.Width, preset.Height, order)
+	return bnCompose(placements, preset.Width, used)
+}
+
+// bnStackOrder returns the known section names of order, lower-cased and
+// without duplicates, or bnDefaultStackOrder when order names none.
+func bnStackOrder(order []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, name := range order {
+		name = strings.ToLower(name)
+		switch name {
+		case bnColumnWaifu, bnColumnFastfetch, bnColumnStatus:
+		default:
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	if len(out) == 0 {
+		return bnDefaultStackOrder
+	}
+	return out
+}
+
+// bnArrangeStacked places widgets full width, one below the other, section
+// by section in order and in input order within a section. Widgets of
+// sections not in order are left out. Each widget is as tall as its
+// content, and placement stops at height. It returns the placements and
+// the number of rows they use.
+func bnArrangeStacked(widgets []WidgetData, width, height int, order []string) ([]bnPlacement, int) {
+	if len(widgets) == 0 || width <= 0 || height <= 0 {
+		return nil, 0
+	}
+
+	var placements []bnPlacement
+	y := 0
+	for _, section := range bnStackOrder(order) {
+		for _, w := range widgets {
+			if bnWidgetColumn(w) != section {
+				continue
+			}
+			h := bnStackedHeight(w, height-y)
+			if h <= 0 {
+				return placements, y
+			}
+			placements = append(placements, bnPlacement{Widget: w, X: 0, Y: y, W: width, H: h})
+			y += h
+		}
+	}
+	return placements, y
+}
+
+// bnStackedHeight is the height of w in the Stacked layout: its content
+// plus the border, at least MinH, with the waifu capped to a thumbnail,
+// and at most availHeight.
+func bnStackedHeight(w WidgetData, availHeight int) int {
+	h := strings.Count(w.Content, "\n") + 3 // content lines + top and bottom border
+	if w.MinH > h {
+		h = w.MinH
+	}
+	if bnIsWaifuWidget(w) && h > bnStackedWaifuMaxHeight {
+		h = bnStackedWaifuMaxHeight
+	}
+	return min(h, availHeight)
+}
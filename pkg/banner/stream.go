@@ -0,0 +1,96 @@
+package banner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// ErrImagePlaceholder marks an image section that WriteTo replaced with
+// its placeholder. The rest of the banner was written normally.
+var ErrImagePlaceholder = errors.New("image replaced by placeholder")
+
+// ImageSection is graphics output drawn once the banner's text is written,
+// such as an image placed with cursor movement and protocol escape
+// sequences.
+type ImageSection struct {
+	// ID names the section in errors.
+	ID string
+
+	// Render returns the section's escape sequences. WriteTo starts it
+	// before writing the text, so it renders while the text is shown, and
+	// writes nothing of it unless it succeeds.
+	Render func(ctx context.Context) (string, error)
+
+	// Placeholder is written when Render fails or ctx ends first. Empty
+	// writes bnImagePlaceholder.
+	Placeholder string
+}
+
+// bnImagePlaceholder stands in for an image section that could not be
+// rendered and has no placeholder of its own.
+const bnImagePlaceholder = "\n[image unavailable]"
+
+// bnImageResult is the outcome of one ImageSection.Render.
+type bnImageResult struct {
+	out string
+	err error
+}
+
+// WriteTo renders data for preset with the column layout in cfg, like
+// RenderWithConfig, and writes it to w as each section is ready: the text
+// of every widget first, then each of data.Images in order as soon as it
+// and those before it have rendered. Image sections render concurrently.
+// One that fails, or is still rendering when ctx ends, is replaced by its
+// placeholder, so the output never holds a partial escape sequence.
+//
+// It returns the number of bytes written. An error from w ends the write
+// and is returned; otherwise the error wraps ErrImagePlaceholder for each
+// image that was replaced, or is nil.
+func WriteTo(ctx context.Context, w io.Writer, data BannerData, preset Preset, cfg config.BannerConfig) (int64, error) {
+	results := make([]chan bnImageResult, len(data.Images))
+	for i, img := range data.Images {
+		results[i] = make(chan bnImageResult, 1)
+		if img.Render == nil {
+			results[i] <- bnImageResult{err: errors.New("no renderer")}
+			continue
+		}
+		go func() {
+			out, err := img.Render(ctx)
+			results[i] <- bnImageResult{out, err}
+		}()
+	}
+
+	n, err := io.WriteString(w, bnRenderText(data, preset, cfg))
+	total := int64(n)
+	if err != nil {
+		return total, err
+	}
+
+	var failed []error
+	for i, img := range data.Images {
+		var r bnImageResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			r.err = ctx.Err()
+		}
+		out := r.out
+		if r.err != nil {
+			failed = append(failed, fmt.Errorf("%w: %s: %w", ErrImagePlaceholder, img.ID, r.err))
+			out = img.Placeholder
+			if out == "" {
+				out = bnImagePlaceholder
+			}
+		}
+		n, err := io.WriteString(w, out)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, errors.Join(failed...)
+}
@@ -0,0 +1,212 @@
+package banner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// System info column modes for config.FastfetchConfig.Mode.
+const (
+	SysInfoNative    = "native"
+	SysInfoFastfetch = "fastfetch"
+	SysInfoAuto      = "auto"
+)
+
+// bnMaxIPs caps how many local addresses the sysinfo column lists.
+const bnMaxIPs = 3
+
+// SysInfo is the data shown in the native system info column.
+type SysInfo struct {
+	Hostname  string
+	OS        string // e.g. "ubuntu 24.04"
+	Kernel    string
+	Arch      string
+	Uptime    time.Duration
+	Load      [3]float64
+	MemUsed   uint64
+	MemTotal  uint64
+	DiskUsed  uint64 // root filesystem
+	DiskTotal uint64
+	IPs       []string
+}
+
+// SysInfoFunc gathers SysInfo. NativeSysInfo is the real implementation;
+// tests pass a fake for deterministic output.
+type SysInfoFunc func(ctx context.Context) (SysInfo, error)
+
+// bnRunFastfetch runs fastfetch and returns its plain-text output. It is a
+// variable so tests can stub it.
+var bnRunFastfetch = func(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "fastfetch", "--pipe", "--logo", "none").Output()
+	if err != nil {
+		return "", fmt.Errorf("fastfetch: %w", err)
+	}
+	return string(out), nil
+}
+
+// NativeSysInfo gathers system info from /proc and syscalls via gopsutil,
+// without starting any process. Fields that cannot be read are left zero.
+func NativeSysInfo(ctx context.Context) (SysInfo, error) {
+	var info SysInfo
+
+	if h, err := host.InfoWithContext(ctx); err == nil {
+		info.Hostname = h.Hostname
+		info.OS = strings.TrimSpace(h.Platform + " " + h.PlatformVersion)
+		if info.OS == "" {
+			info.OS = h.OS
+		}
+		info.Kernel = h.KernelVersion
+		info.Arch = h.KernelArch
+		info.Uptime = time.Duration(h.Uptime) * time.Second
+	}
+	if avg, err := load.AvgWithContext(ctx); err == nil {
+		info.Load = [3]float64{avg.Load1, avg.Load5, avg.Load15}
+	}
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		info.MemUsed, info.MemTotal = vm.Used, vm.Total
+	}
+	if du, err := disk.UsageWithContext(ctx, "/"); err == nil {
+		info.DiskUsed, info.DiskTotal = du.Used, du.Total
+	}
+	info.IPs = bnLocalIPs()
+
+	return info, nil
+}
+
+// bnLocalIPs returns up to bnMaxIPs addresses of interfaces that are up
+// and not loopback, IPv4 first.
+func bnLocalIPs() []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var v4, v6 []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok || ipnet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			if ip4 := ipnet.IP.To4(); ip4 != nil {
+				v4 = append(v4, ip4.String())
+			} else {
+				v6 = append(v6, ipnet.IP.String())
+			}
+		}
+	}
+	ips := append(v4, v6...)
+	if len(ips) > bnMaxIPs {
+		ips = ips[:bnMaxIPs]
+	}
+	return ips
+}
+
+// SysInfoWidget builds the system info column widget. Mode "fastfetch"
+// shows fastfetch output, falling back to native info if fastfetch is
+// missing or fails; "native" and "auto" use native. Lines are truncated
+// to width, the inner width of the column.
+func SysInfoWidget(ctx context.Context, mode string, width int, native SysInfoFunc) WidgetData {
+	w := WidgetData{ID: "sysinfo", Title: "System"}
+
+	if mode == SysInfoFastfetch {
+		if out, err := bnRunFastfetch(ctx); err == nil && strings.TrimSpace(out) != "" {
+			w.Content = bnFitLines(strings.Split(strings.TrimRight(out, "\n"), "\n"), width)
+			return w
+		}
+	}
+
+	if native == nil {
+		native = NativeSysInfo
+	}
+	info, err := native(ctx)
+	if err != nil {
+		w.Content = components.Truncate("system info unavailable", width)
+		return w
+	}
+	w.Content = bnFitLines(bnSysInfoLines(info), width)
+	return w
+}
+
+// bnSysInfoLines formats info as "label value" lines, skipping fields
+// that were not gathered.
+func bnSysInfoLines(info SysInfo) []string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%-7s %s", label, value))
+		}
+	}
+
+	add("host", info.Hostname)
+	osLine := info.OS
+	if info.Kernel != "" {
+		osLine = strings.TrimSpace(osLine + " " + info.Kernel)
+	}
+	if info.Arch != "" && osLine != "" {
+		osLine += " (" + info.Arch + ")"
+	}
+	add("os", osLine)
+	if info.Uptime > 0 {
+		add("uptime", bnFormatUptime(info.Uptime))
+	}
+	if info.Load != [3]float64{} {
+		add("load", fmt.Sprintf("%.2f %.2f %.2f", info.Load[0], info.Load[1], info.Load[2]))
+	}
+	if info.MemTotal > 0 {
+		add("memory", bnUsage(info.MemUsed, info.MemTotal))
+	}
+	if info.DiskTotal > 0 {
+		add("disk", bnUsage(info.DiskUsed, info.DiskTotal))
+	}
+	add("ip", strings.Join(info.IPs, ", "))
+	return lines
+}
+
+// bnUsage formats used/total in GiB with a percentage.
+func bnUsage(used, total uint64) string {
+	const gib = 1 << 30
+	return fmt.Sprintf("%.1f/%.1f GiB (%d%%)",
+		float64(used)/gib, float64(total)/gib, used*100/total)
+}
+
+// bnFormatUptime formats a duration as "3d 4h", "4h 12m", or "12m".
+func bnFormatUptime(d time.Duration) string {
+	mins := int(d.Minutes())
+	days, hours, m := mins/(24*60), mins%(24*60)/60, mins%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, m)
+	default:
+		return fmt.Sprintf("%dm", m)
+	}
+}
+
+// bnFitLines truncates each line to width and joins them.
+func bnFitLines(lines []string, width int) string {
+	if width > 0 {
+		for i, l := range lines {
+			lines[i] = components.Truncate(l, width)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
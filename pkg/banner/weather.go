@@ -0,0 +1,32 @@
+package banner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/weather"
+)
+
+// WeatherSuffix returns a short "glyph temperature" string from the cached
+// weather collector data in cacheDir, for appending to the status column.
+// It returns "" when the data is missing, unreadable, or older than
+// weather.MaxAge, so a failed fetch shows nothing.
+func WeatherSuffix(cacheDir string) string {
+	path := filepath.Join(cacheDir, "weather.json")
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > weather.MaxAge {
+		return ""
+	}
+	data, err := cache.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var s weather.Status
+	if err := json.Unmarshal(data, &s); err != nil || s.Unit == "" {
+		return ""
+	}
+	return s.Glyph + " " + s.TemperatureText()
+}
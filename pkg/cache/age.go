@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Freshness classifies cached data by its age; see Staleness.
+type Freshness int
+
+const (
+	// Fresh data is shown as is.
+	Fresh Freshness = iota
+
+	// Stale data is shown with a marker of its age.
+	Stale
+
+	// Expired data is too old to show at all.
+	Expired
+)
+
+// Staleness decides how cached data is shown as it ages: as is until
+// StaleAfter, marked as old until ExpireAfter, and not at all after that.
+// A zero bound is disabled.
+type Staleness struct {
+	StaleAfter  time.Duration
+	ExpireAfter time.Duration
+}
+
+// Classify returns the freshness of data of the given age.
+func (s Staleness) Classify(age time.Duration) Freshness {
+	switch {
+	case s.ExpireAfter > 0 && age > s.ExpireAfter:
+		return Expired
+	case s.StaleAfter > 0 && age > s.StaleAfter:
+		return Stale
+	default:
+		return Fresh
+	}
+}
+
+// FileAge returns how long before now the cache file at path was last
+// written, and false if it does not exist.
+func FileAge(path string, now time.Time) (time.Duration, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	return max(now.Sub(info.ModTime()), 0), true
+}
+
+// FormatAge renders an age in its largest whole unit for display, such as
+// "40s", "12m", "3h", or "2d".
+func FormatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age/time.Second))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// BackfillFileName is the marker the daemon keeps in its data directory
+// while it re-collects after a gap in its schedule, such as a suspend.
+const BackfillFileName = "backfill.json"
+
+// BackfillGrace bounds how long after a backfill starts the entries it is
+// re-collecting are excused from stale markers. A collector that still has
+// not delivered by then, say because the network is down, is marked again.
+const BackfillGrace = 2 * time.Minute
+
+// Backfill reasons.
+const (
+	BackfillResume = "resume" // systemd-logind reported a resume
+	BackfillClock  = "clock"  // a scheduled check ran far too late
+)
+
+// Backfill describes a re-collection after the daemon's schedule stalled.
+type Backfill struct {
+	// At is when the gap was detected and the backfill started.
+	At time.Time `json:"at"`
+
+	// Gap is how long the schedule stalled.
+	Gap time.Duration `json:"gap"`
+
+	// Reason is how the gap was noticed: BackfillResume or BackfillClock.
+	Reason string `json:"reason"`
+
+	// Pending lists the cache keys whose fresh data has not landed yet.
+	Pending []string `json:"pending,omitempty"`
+}
+
+// Excuses reports whether the entry for key is being re-collected by b as
+// of now, so its age reflects the gap rather than a failing collector.
+func (b *Backfill) Excuses(key string, now time.Time) bool {
+	return b != nil && now.Sub(b.At) < BackfillGrace && slices.Contains(b.Pending, key)
+}
+
+// ReadBackfill returns the backfill in progress in dir, or nil when there
+// is none.
+func ReadBackfill(dir string) *Backfill {
+	data, err := os.ReadFile(filepath.Join(dir, BackfillFileName))
+	if err != nil {
+		return nil
+	}
+	var b Backfill
+	if json.Unmarshal(data, &b) != nil {
+		return nil
+	}
+	return &b
+}
+
+// WriteBackfill records b as the backfill in progress in dir, or removes
+// the marker once nothing is pending.
+func WriteBackfill(dir string, b *Backfill) error {
+	path := filepath.Join(dir, BackfillFileName)
+	if len(b.Pending) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// EntryFreshness classifies the cached data for key in dir under s,
+// returning its age and false when there is none. Stale data that a
+// backfill is about to replace (see Backfill.Excuses) counts as fresh, so
+// a terminal opened right after a resume does not show every entry as old
+// for the few seconds until the new data lands.
+func EntryFreshness(dir, key string, s Staleness, now time.Time) (time.Duration, Freshness, bool) {
+	age, ok := FileAge(filepath.Join(dir, key+".json"), now)
+	if !ok {
+		return 0, Fresh, false
+	}
+	f := s.Classify(age)
+	if f == Stale && ReadBackfill(dir).Excuses(key, now) {
+		f = Fresh
+	}
+	return age, f, true
+}
@@ -2,12 +2,14 @@ package cache
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -466,6 +468,106 @@ func TestGetTypedPutTypedWithStruct(t *testing.T) {
 	}
 }
 
+func TestGetTypedAtReturnsWriteTime(t *testing.T) {
+	s := newTestStore(t)
+
+	before := time.Now()
+	if err := PutTyped(s, "billing", 42); err != nil {
+		t.Fatalf("PutTyped: %v", err)
+	}
+	v, at, ok := GetTypedAt[int](s, "billing")
+	if !ok || v != 42 {
+		t.Fatalf("GetTypedAt = %v, %v, want 42", v, ok)
+	}
+	if at.Before(before) || at.After(time.Now()) {
+		t.Errorf("written at %v, want between %v and now", at, before)
+	}
+
+	if _, at, ok := GetTypedAt[int](s, "missing"); ok || !at.IsZero() {
+		t.Errorf("missing key = %v, %v, want zero time and false", at, ok)
+	}
+}
+
+func TestStalenessAndFormatAge(t *testing.T) {
+	s := Staleness{StaleAfter: 30 * time.Minute, ExpireAfter: 24 * time.Hour}
+	tests := []struct {
+		age  time.Duration
+		want Freshness
+		text string
+	}{
+		{40 * time.Second, Fresh, "40s"},
+		{31 * time.Minute, Stale, "31m"},
+		{3*time.Hour + 59*time.Minute, Stale, "3h"},
+		{25 * time.Hour, Expired, "25h"},
+		{72 * time.Hour, Expired, "3d"},
+	}
+	for _, tt := range tests {
+		if got := s.Classify(tt.age); got != tt.want {
+			t.Errorf("Classify(%s) = %d, want %d", tt.age, got, tt.want)
+		}
+		if got := FormatAge(tt.age); got != tt.text {
+			t.Errorf("FormatAge(%s) = %q, want %q", tt.age, got, tt.text)
+		}
+	}
+	if got := (Staleness{}).Classify(1000 * time.Hour); got != Fresh {
+		t.Errorf("zero Staleness classified ancient data as %d, want Fresh", got)
+	}
+}
+
+func TestBackfillMarker(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	if ReadBackfill(dir) != nil {
+		t.Fatal("ReadBackfill without a marker should return nil")
+	}
+	b := &Backfill{At: now, Gap: 8 * time.Hour, Reason: BackfillResume, Pending: []string{"billing", "weather"}}
+	if err := WriteBackfill(dir, b); err != nil {
+		t.Fatalf("WriteBackfill: %v", err)
+	}
+	got := ReadBackfill(dir)
+	if got == nil || got.Gap != b.Gap || got.Reason != BackfillResume || len(got.Pending) != 2 {
+		t.Fatalf("ReadBackfill = %+v, want %+v", got, b)
+	}
+	if !got.Excuses("billing", now.Add(time.Minute)) {
+		t.Error("a pending entry should be excused during the grace period")
+	}
+	if got.Excuses("claude", now) || got.Excuses("billing", now.Add(BackfillGrace)) {
+		t.Error("only pending entries should be excused, and only during the grace period")
+	}
+
+	// Stale data a backfill is replacing is not marked; expired data is
+	// still hidden.
+	s := Staleness{StaleAfter: time.Hour, ExpireAfter: 24 * time.Hour}
+	for key, age := range map[string]time.Duration{"billing": 8 * time.Hour, "weather": 30 * time.Hour, "docker": 8 * time.Hour} {
+		path := filepath.Join(dir, key+".json")
+		if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, want := range map[string]Freshness{"billing": Fresh, "weather": Expired, "docker": Stale} {
+		if _, f, ok := EntryFreshness(dir, key, s, now); !ok || f != want {
+			t.Errorf("EntryFreshness(%s) = %d, %v; want %d", key, f, ok, want)
+		}
+	}
+	if _, _, ok := EntryFreshness(dir, "missing", s, now); ok {
+		t.Error("EntryFreshness of a missing entry should report false")
+	}
+
+	b.Pending = nil
+	if err := WriteBackfill(dir, b); err != nil {
+		t.Fatalf("WriteBackfill: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, BackfillFileName)); !os.IsNotExist(err) {
+		t.Error("the marker should be removed once nothing is pending")
+	}
+	if err := WriteBackfill(dir, b); err != nil {
+		t.Errorf("removing a missing marker: %v", err)
+	}
+}
+
 func TestGetTypedInvalidJSONReturnsFalse(t *testing.T) {
 	s := newTestStore(t)
 
@@ -521,6 +623,453 @@ func TestPutTypedWithTTL(t *testing.T) {
 	}
 }
 
+// --- Schema versions and migration ---
+
+// claudeV2 is the current shape of the test claude payload; v1 stored a
+// single flat total instead of per-account usage.
+type claudeV2 struct {
+	Accounts []struct {
+		Name    string  `json:"name"`
+		CostUSD float64 `json:"cost_usd"`
+	} `json:"accounts"`
+}
+
+// claudeV1ToV2 wraps the v1 flat total in a single "default" account.
+func claudeV1ToV2(data []byte) ([]byte, error) {
+	var v1 struct {
+		TotalCost float64 `json:"total_cost"`
+	}
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"accounts": []map[string]interface{}{{"name": "default", "cost_usd": v1.TotalCost}},
+	})
+}
+
+func TestLookupTypedMigratesV1ClaudePayload(t *testing.T) {
+	dir := t.TempDir()
+	old := newTestStore(t, func(c *StoreConfig) { c.Dir = dir; c.Schemas = NewSchemas() })
+	if err := old.PutWithTTL("claude", []byte(`{"total_cost":12.5}`), time.Hour); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	before, _ := old.readMeta(hashKey("claude"))
+	old.Close()
+
+	schemas := NewSchemas()
+	schemas.Register("claude", 2, claudeV1ToV2)
+	s := newTestStore(t, func(c *StoreConfig) { c.Dir = dir; c.Schemas = schemas })
+
+	got, state := LookupTyped[claudeV2](s, "claude")
+	if state != ReadMigrated {
+		t.Fatalf("state = %v, want migrated", state)
+	}
+	if len(got.Accounts) != 1 || got.Accounts[0].Name != "default" || got.Accounts[0].CostUSD != 12.5 {
+		t.Errorf("migrated value = %+v", got)
+	}
+
+	// The entry was rewritten in the new format, keeping its lifetime.
+	after, err := s.readMeta(hashKey("claude"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Version != 2 || after.Created != before.Created || after.TTLNS != before.TTLNS {
+		t.Errorf("meta after migration = %+v, want version 2 with created/ttl of %+v", after, before)
+	}
+	if raw, _ := s.Get("claude"); !strings.Contains(string(raw), `"accounts"`) {
+		t.Errorf("stored data = %s, want the v2 shape", raw)
+	}
+	if _, state := LookupTyped[claudeV2](s, "claude"); state != ReadOK {
+		t.Errorf("second lookup state = %v, want ok", state)
+	}
+	if got, ok := GetTyped[claudeV2](s, "claude"); !ok || len(got.Accounts) != 1 {
+		t.Errorf("GetTyped = %+v, %v", got, ok)
+	}
+}
+
+func TestLookupTypedMigrationChainAndNamespaces(t *testing.T) {
+	schemas := NewSchemas()
+	schemas.Register("claude", 2, claudeV1ToV2)
+	schemas.Register("claude", 3, func(data []byte) ([]byte, error) {
+		return []byte(strings.ReplaceAll(string(data), `"default"`, `"personal"`)), nil
+	})
+	if v := schemas.Version("claude:work"); v != 3 {
+		t.Errorf("Version(claude:work) = %d, want 3", v)
+	}
+	if v := schemas.Version("billing"); v != 1 {
+		t.Errorf("Version(billing) = %d, want 1", v)
+	}
+
+	s := newTestStore(t, func(c *StoreConfig) { c.Schemas = schemas })
+	writeLegacyEntry(t, s, "claude:work", `{"total_cost":3}`)
+
+	got, state := LookupTyped[claudeV2](s, "claude:work")
+	if state != ReadMigrated || len(got.Accounts) != 1 || got.Accounts[0].Name != "personal" {
+		t.Errorf("LookupTyped = %+v, %v, want migrated through v3", got, state)
+	}
+}
+
+func TestLookupTypedStaleAndUnreadable(t *testing.T) {
+	schemas := NewSchemas()
+	// Version 3 is registered without a step from 1 to 2.
+	schemas.Register("gap", 3, func(data []byte) ([]byte, error) { return data, nil })
+	schemas.Register("broken", 2, func([]byte) ([]byte, error) { return nil, os.ErrInvalid })
+	s := newTestStore(t, func(c *StoreConfig) { c.Schemas = schemas })
+
+	type payload struct {
+		Value int `json:"value"`
+	}
+	tests := []struct {
+		key, data string
+		version   int
+		want      ReadState
+	}{
+		{"missing", "", 0, ReadMissing},
+		{"plain", `{"value":1}`, 1, ReadOK},
+		{"gap", `{"value":1}`, 1, ReadStale},
+		{"broken", `{"value":1}`, 1, ReadStale},
+		{"broken:newer", `{"value":1}`, 5, ReadStale},
+		{"plain:garbage", `not json`, 1, ReadUnreadable},
+		{"broken:garbage", `[1,2]`, 1, ReadUnreadable},
+	}
+	for _, tt := range tests {
+		if tt.data != "" {
+			writeLegacyEntry(t, s, tt.key, tt.data)
+			setEntryVersion(t, s, tt.key, tt.version)
+		}
+		got, state := LookupTyped[payload](s, tt.key)
+		if state != tt.want {
+			t.Errorf("%s: state = %v, want %v", tt.key, state, tt.want)
+		}
+		if state.Usable() != (got.Value == 1) {
+			t.Errorf("%s: value = %+v with state %v", tt.key, got, state)
+		}
+		if _, ok := GetTyped[payload](s, tt.key); ok != state.Usable() {
+			t.Errorf("%s: GetTyped ok = %v, want %v", tt.key, ok, state.Usable())
+		}
+	}
+}
+
+// writeLegacyEntry stores data under key with a meta file written before
+// schema versions existed.
+func writeLegacyEntry(t *testing.T, s *Store, key, data string) {
+	t.Helper()
+	if err := s.Put(key, []byte(data)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	setEntryVersion(t, s, key, 0)
+}
+
+// setEntryVersion rewrites the schema version in key's meta file.
+func setEntryVersion(t *testing.T, s *Store, key string, version int) {
+	t.Helper()
+	h := hashKey(key)
+	meta, err := s.readMeta(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Version = version
+	raw, _ := json.Marshal(meta)
+	if err := os.WriteFile(s.metaPath(h), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// --- Quarantine and Compaction ---
+
+// quarantinedStems lists the entries in s's quarantine directory.
+func quarantinedStems(t *testing.T, s *Store) []string {
+	t.Helper()
+	files, err := os.ReadDir(filepath.Join(s.cfg.Dir, QuarantineDir))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var stems []string
+	for _, f := range files {
+		if stem, ok := strings.CutSuffix(f.Name(), ".meta"); ok {
+			stems = append(stems, stem)
+		}
+	}
+	return stems
+}
+
+func TestLookupTypedQuarantinesTruncatedEntry(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Put("claude", []byte(`{"accounts":[{"name":"work","used":12}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a power loss mid-write.
+	if err := os.WriteFile(s.dataPath(hashKey("claude")), []byte(`{"accounts":[{"na`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, state := LookupTyped[claudeV2](s, "claude"); state != ReadUnreadable {
+		t.Fatalf("state = %v, want unreadable", state)
+	}
+	if _, state := LookupTyped[claudeV2](s, "claude"); state != ReadMissing {
+		t.Errorf("second lookup state = %v, want missing", state)
+	}
+	if got := len(quarantinedStems(t, s)); got != 1 {
+		t.Errorf("quarantined %d entries, want 1", got)
+	}
+	if st := s.Stats(); st.Entries != 0 || st.Quarantined != 1 {
+		t.Errorf("Stats = %d entries, %d quarantined; want 0, 1", st.Entries, st.Quarantined)
+	}
+}
+
+func TestCorruptMetaIsQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, func(c *StoreConfig) { c.Dir = dir })
+	for _, k := range []string{"a", "b"} {
+		if err := s.PutString(k, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(s.metaPath(hashKey("a")), []byte(`{"key":"a","crea`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A live store quarantines the entry on read.
+	if _, ok := s.Get("a"); ok {
+		t.Fatal("Get of corrupt entry succeeded")
+	}
+	if s.Has("a") {
+		t.Error("corrupt entry still indexed after Get")
+	}
+
+	// A store opened over the directory quarantines it during the scan.
+	if err := s.PutString("c", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.metaPath(hashKey("c")), []byte(`not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s2 := newTestStore(t, func(c *StoreConfig) { c.Dir = dir })
+	if got, ok := s2.GetString("b"); !ok || got != "b" {
+		t.Errorf("GetString(b) = %q, %v", got, ok)
+	}
+	if st := s2.Stats(); st.Entries != 1 || st.Quarantined != 2 {
+		t.Errorf("Stats = %d entries, %d quarantined; want 1, 2", st.Entries, st.Quarantined)
+	}
+}
+
+func TestQuarantineKeepsNewest(t *testing.T) {
+	s := newTestStore(t, func(c *StoreConfig) { c.QuarantineKeep = 2 })
+	for i := 0; i < 4; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if err := s.PutString(key, key); err != nil {
+			t.Fatal(err)
+		}
+		s.Quarantine(key)
+	}
+
+	stems := quarantinedStems(t, s)
+	if len(stems) != 2 {
+		t.Fatalf("quarantine holds %d entries, want 2", len(stems))
+	}
+	for i, key := range []string{"k2", "k3"} {
+		if !strings.HasSuffix(stems[i], hashKey(key)) {
+			t.Errorf("quarantine[%d] = %s, want the entry for %s", i, stems[i], key)
+		}
+	}
+	if got := s.Stats().Quarantined; got != 2 {
+		t.Errorf("Stats().Quarantined = %d, want 2", got)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, func(c *StoreConfig) { c.Dir = dir })
+	if err := s.PutWithTTL("old", []byte("x"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutString("fresh", "y"); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"q1", "q2"} {
+		if err := s.PutString(k, k); err != nil {
+			t.Fatal(err)
+		}
+		s.Quarantine(k)
+	}
+
+	stale := filepath.Join(dir, ".tmp-stale")
+	recent := filepath.Join(dir, ".tmp-recent")
+	for _, p := range []string{stale, recent} {
+		if err := os.WriteFile(p, []byte("partial"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-2 * tempFileMaxAge)
+	if err := os.Chtimes(stale, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	s.cfg.QuarantineKeep = 1
+	time.Sleep(5 * time.Millisecond)
+	res, err := s.Compact()
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	want := CompactResult{Expired: 1, Quarantined: 1, TempFiles: 1}
+	if res != want {
+		t.Errorf("Compact = %+v, want %+v", res, want)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent temp file removed: %v", err)
+	}
+	if st := s.Stats(); st.Entries != 1 || st.Quarantined != 1 {
+		t.Errorf("Stats = %d entries, %d quarantined; want 1, 1", st.Entries, st.Quarantined)
+	}
+}
+
+// --- Encryption ---
+
+// spendEntry stands in for a sensitive collector payload.
+type spendEntry struct {
+	Account string  `json:"account"`
+	Spend   float64 `json:"spend"`
+}
+
+func testEncryption(t *testing.T, secret string, namespaces ...string) *Encryption {
+	t.Helper()
+	e, err := NewEncryption([]byte(secret), namespaces)
+	if err != nil {
+		t.Fatalf("NewEncryption: %v", err)
+	}
+	return e
+}
+
+func TestEncryptedTypedRoundTrip(t *testing.T) {
+	enc := testEncryption(t, "correct horse battery staple", "claude")
+	s := newTestStore(t, func(c *StoreConfig) { c.Encryption = enc })
+
+	want := spendEntry{Account: "work", Spend: 12.5}
+	if err := PutTyped(s, "claude:work", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutTyped(s, "weather", map[string]int{"temp": 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := s.Get("claude:work")
+	if !IsEncrypted(raw) || strings.Contains(string(raw), "work") {
+		t.Errorf("claude entry stored in plaintext: %q", raw)
+	}
+	if raw, _ := s.Get("weather"); IsEncrypted(raw) {
+		t.Error("weather entry encrypted, want plaintext")
+	}
+
+	got, state, err := ReadTyped[spendEntry](s, "claude:work")
+	if err != nil || state != ReadOK {
+		t.Fatalf("ReadTyped = %v, %v", state, err)
+	}
+	if got != want {
+		t.Errorf("ReadTyped = %+v, want %+v", got, want)
+	}
+
+	// Each write uses a fresh nonce.
+	if err := PutTyped(s, "claude:work", want); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := s.Get("claude:work"); string(again) == string(raw) {
+		t.Error("identical ciphertext for two writes")
+	}
+}
+
+func TestEncryptedLegacyPlaintextEntry(t *testing.T) {
+	s := newTestStore(t, func(c *StoreConfig) {
+		c.Encryption = testEncryption(t, "correct horse battery staple", "claude")
+	})
+	if err := s.Put("claude", []byte(`{"account":"home","spend":3}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok := GetTyped[spendEntry](s, "claude")
+	if !ok || v.Account != "home" {
+		t.Fatalf("GetTyped of plaintext entry = %+v, %v", v, ok)
+	}
+	if err := PutTyped(s, "claude", v); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := s.Get("claude"); !IsEncrypted(raw) {
+		t.Error("entry not encrypted after rewrite")
+	}
+}
+
+func TestEncryptedWrongOrMissingKey(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, func(c *StoreConfig) {
+		c.Dir = dir
+		c.Encryption = testEncryption(t, "correct horse battery staple", "billing")
+	})
+	if err := PutTyped(s, "billing", map[string]float64{"civo": 12.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := newTestStore(t, func(c *StoreConfig) {
+		c.Dir = dir
+		c.Encryption = testEncryption(t, "a different secret entirely", "billing")
+	})
+	_, state, err := ReadTyped[map[string]float64](other, "billing")
+	if state != ReadUnreadable || !errors.Is(err, ErrWrongKey) {
+		t.Errorf("wrong key: state %v, err %v; want unreadable, ErrWrongKey", state, err)
+	}
+	if !other.Has("billing") {
+		t.Error("entry with the wrong key was removed")
+	}
+
+	SetDefaultEncryption(nil)
+	none := newTestStore(t, func(c *StoreConfig) { c.Dir = dir })
+	if _, _, err := ReadTyped[map[string]float64](none, "billing"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("no key: err %v, want ErrNoKey", err)
+	}
+}
+
+func TestLoadSecretFromFile(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "key")
+	if err := os.WriteFile(good, []byte("  0123456789abcdef0123\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := LoadSecret(good)
+	if err != nil || string(secret) != "0123456789abcdef0123" {
+		t.Errorf("LoadSecret = %q, %v", secret, err)
+	}
+
+	short := filepath.Join(dir, "short")
+	if err := os.WriteFile(short, []byte("tiny\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSecret(short); err == nil {
+		t.Error("LoadSecret accepted a 4-byte key")
+	}
+}
+
+func TestReadFileDecryptsWithDefaultEncryption(t *testing.T) {
+	enc := testEncryption(t, "correct horse battery staple", "claude")
+	SetDefaultEncryption(enc)
+	t.Cleanup(func() { SetDefaultEncryption(nil) })
+
+	sealed, err := enc.Seal("claude", []byte(`{"ok":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "claude.json")
+	if err := os.WriteFile(path, sealed, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFile(path)
+	if err != nil || string(got) != `{"ok":true}` {
+		t.Errorf("ReadFile = %q, %v", got, err)
+	}
+
+	SetDefaultEncryption(testEncryption(t, "a different secret entirely"))
+	if _, err := ReadFile(path); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("ReadFile with wrong key: err %v, want ErrWrongKey", err)
+	}
+}
+
 // --- Concurrent Access ---
 
 func TestConcurrentAccess(t *testing.T) {
@@ -888,3 +1437,227 @@ func TestMetaFileIntegrity(t *testing.T) {
 		t.Error("meta created should not be zero")
 	}
 }
+
+// --- Cross-process lease / single-flight ---
+
+// withLeaseProcs replaces the process hooks for the duration of a test.
+func withLeaseProcs(t *testing.T, pid func() int, alive func(int) bool) {
+	t.Helper()
+	origPID, origAlive := leasePID, leaseAlive
+	leasePID, leaseAlive = pid, alive
+	t.Cleanup(func() { leasePID, leaseAlive = origPID, origAlive })
+}
+
+func TestAcquireLeaseExclusive(t *testing.T) {
+	dir := t.TempDir()
+
+	l1, ok, err := AcquireLease(dir, "claude", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("first acquire: ok=%v err=%v", ok, err)
+	}
+
+	_, ok, err = AcquireLease(dir, "claude", time.Second)
+	if err != nil {
+		t.Fatalf("second acquire: %v", err)
+	}
+	if ok {
+		t.Fatal("second acquire should fail while lease is held")
+	}
+
+	if err := l1.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	l3, ok, err := AcquireLease(dir, "claude", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("acquire after release: ok=%v err=%v", ok, err)
+	}
+	_ = l3.Release()
+}
+
+func TestAcquireLeaseStealsExpired(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, ok, _ := AcquireLease(dir, "k", time.Millisecond); !ok {
+		t.Fatal("expected first acquire to succeed")
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	l, ok, err := AcquireLease(dir, "k", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("expected expired lease to be stolen: ok=%v err=%v", ok, err)
+	}
+	_ = l.Release()
+}
+
+func TestAcquireLeaseStealsDeadPID(t *testing.T) {
+	dir := t.TempDir()
+	const deadPID = 999999
+
+	rec, _ := json.Marshal(leaseRecord{PID: deadPID, Deadline: time.Now().Add(time.Hour).UnixNano()})
+	if err := os.WriteFile(leasePath(dir, "k"), rec, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withLeaseProcs(t, func() int { return 1234 }, func(pid int) bool { return pid != deadPID })
+
+	l, ok, err := AcquireLease(dir, "k", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("expected lease from dead PID to be stolen: ok=%v err=%v", ok, err)
+	}
+	held, err := readLease(leasePath(dir, "k"))
+	if err != nil {
+		t.Fatalf("readLease: %v", err)
+	}
+	if held.PID != 1234 {
+		t.Errorf("lease PID = %d, want 1234", held.PID)
+	}
+	_ = l.Release()
+}
+
+func TestAcquireLeaseRespectsLiveHolder(t *testing.T) {
+	dir := t.TempDir()
+
+	rec, _ := json.Marshal(leaseRecord{PID: 42, Deadline: time.Now().Add(time.Hour).UnixNano()})
+	if err := os.WriteFile(leasePath(dir, "k"), rec, 0644); err != nil {
+		t.Fatal(err)
+	}
+	withLeaseProcs(t, func() int { return 7 }, func(int) bool { return true })
+
+	if _, ok, _ := AcquireLease(dir, "k", time.Second); ok {
+		t.Fatal("lease held by a live PID must not be stolen")
+	}
+}
+
+func TestReleaseDoesNotRemoveStolenLease(t *testing.T) {
+	dir := t.TempDir()
+
+	l1, _, _ := AcquireLease(dir, "k", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	l2, ok, _ := AcquireLease(dir, "k", time.Second)
+	if !ok {
+		t.Fatal("expected steal")
+	}
+
+	// The original holder finishing late must not drop the new lease.
+	_ = l1.Release()
+	if _, err := os.Stat(leasePath(dir, "k")); err != nil {
+		t.Fatalf("stolen lease removed by stale holder: %v", err)
+	}
+	_ = l2.Release()
+}
+
+func TestCoalesceFreshSkipsRefresh(t *testing.T) {
+	dir := t.TempDir()
+	called := false
+	res, err := Coalesce(dir, "k", LeaseOptions{}, func() bool { return true }, func() error {
+		called = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != CoalesceFresh || called {
+		t.Errorf("res=%v called=%v, want fresh and no refresh", res, called)
+	}
+}
+
+func TestCoalesceStampedeSingleRefresh(t *testing.T) {
+	dir := t.TempDir()
+	const workers = 48
+	const wait = 5 * time.Millisecond
+
+	var (
+		refreshed atomic.Bool
+		refreshes atomic.Int32
+		start     = make(chan struct{})
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		maxFollow time.Duration
+		results   = make(map[CoalesceResult]int)
+	)
+
+	fresh := func() bool { return refreshed.Load() }
+	refresh := func() error {
+		refreshes.Add(1)
+		time.Sleep(30 * time.Millisecond) // slower than every follower's budget
+		refreshed.Store(true)
+		return nil
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			t0 := time.Now()
+			res, err := Coalesce(dir, "starship:claude", LeaseOptions{Wait: wait}, fresh, refresh)
+			elapsed := time.Since(t0)
+			if err != nil {
+				t.Errorf("Coalesce: %v", err)
+			}
+			mu.Lock()
+			results[res]++
+			if res != CoalesceRefreshed && elapsed > maxFollow {
+				maxFollow = elapsed
+			}
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if n := refreshes.Load(); n != 1 {
+		t.Fatalf("refresh ran %d times, want exactly 1 (results %v)", n, results)
+	}
+	if results[CoalesceRefreshed] != 1 {
+		t.Errorf("refreshed results = %d, want 1", results[CoalesceRefreshed])
+	}
+	// Followers must give up close to their wait budget, well before the
+	// 30ms refresh completes. Allow generous scheduling slack.
+	if maxFollow > wait+20*time.Millisecond {
+		t.Errorf("follower waited %v, budget %v", maxFollow, wait)
+	}
+	if _, err := os.Stat(leasePath(dir, "starship:claude")); !os.IsNotExist(err) {
+		t.Errorf("lease file should be released, stat err = %v", err)
+	}
+}
+
+func TestCoalesceFollowerSeesFreshResult(t *testing.T) {
+	dir := t.TempDir()
+
+	var refreshed atomic.Bool
+	lease, ok, _ := AcquireLease(dir, "k", time.Second)
+	if !ok {
+		t.Fatal("setup acquire failed")
+	}
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		refreshed.Store(true)
+		_ = lease.Release()
+	}()
+
+	res, err := Coalesce(dir, "k", LeaseOptions{Wait: 200 * time.Millisecond},
+		refreshed.Load, func() error { t.Error("follower must not refresh"); return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != CoalesceWaited {
+		t.Errorf("res = %v, want waited", res)
+	}
+}
+
+func TestCoalesceZeroWaitServesStale(t *testing.T) {
+	dir := t.TempDir()
+	lease, _, _ := AcquireLease(dir, "k", time.Second)
+	defer lease.Release()
+
+	t0 := time.Now()
+	res, _ := Coalesce(dir, "k", LeaseOptions{}, func() bool { return false }, func() error { return nil })
+	if res != CoalesceStale {
+		t.Errorf("res = %v, want stale", res)
+	}
+	if time.Since(t0) > 10*time.Millisecond {
+		t.Errorf("zero-wait coalesce took %v", time.Since(t0))
+	}
+}
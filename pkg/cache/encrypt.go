@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Encrypted entries are stored as encMagic, the 4-byte ID of the key that
+// sealed them, a random AES-GCM nonce, and the ciphertext. JSON never
+// starts with encMagic, so unencrypted entries are told apart by prefix.
+const (
+	encMagic   = "PPENC1"
+	encIDLen   = 4
+	encHKDFTag = "prompt-pulse cache v1"
+)
+
+// minSecretLen is the shortest secret accepted from a key file.
+const minSecretLen = 16
+
+// keyringService and keyringAccount name the cache secret in the user's
+// keyring.
+const (
+	keyringService = "prompt-pulse"
+	keyringAccount = "cache-key"
+)
+
+var (
+	// ErrWrongKey is returned when an entry was encrypted with a key other
+	// than the configured one.
+	ErrWrongKey = errors.New("cache: entry was encrypted with a different key")
+
+	// ErrNoKey is returned when an entry is encrypted but no key is
+	// configured.
+	ErrNoKey = errors.New("cache: entry is encrypted but no cache key is configured")
+)
+
+// Encryption encrypts the entries of selected cache keys at rest with
+// AES-256-GCM. Keys are matched by namespace, the part before the first
+// ":", so encrypting "claude" covers "claude:work". A nil *Encryption
+// encrypts nothing and cannot decrypt. Encryption is safe for concurrent
+// use.
+type Encryption struct {
+	namespaces map[string]bool
+	aead       cipher.AEAD
+	id         []byte
+}
+
+// NewEncryption returns an Encryption for namespaces with a key derived
+// from secret. Entries of other namespaces are left in plaintext, but
+// encrypted entries of any namespace are decrypted.
+func NewEncryption(secret []byte, namespaces []string) (*Encryption, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("cache: empty encryption secret")
+	}
+	key, err := hkdf.Key(sha256.New, secret, nil, encHKDFTag, 32)
+	if err != nil {
+		return nil, fmt.Errorf("cache: derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	sum := sha256.Sum256(key)
+
+	ns := make(map[string]bool, len(namespaces))
+	for _, n := range namespaces {
+		ns[namespaceOf(n)] = true
+	}
+	return &Encryption{namespaces: ns, aead: aead, id: sum[:encIDLen]}, nil
+}
+
+// Encrypts reports whether entries of key are stored encrypted.
+func (e *Encryption) Encrypts(key string) bool {
+	return e != nil && e.namespaces[namespaceOf(key)]
+}
+
+// Seal encrypts data if key's entries are stored encrypted and returns it
+// unchanged otherwise. Each call uses a fresh random nonce.
+func (e *Encryption) Seal(key string, data []byte) ([]byte, error) {
+	if !e.Encrypts(key) {
+		return data, nil
+	}
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("cache: nonce: %w", err)
+	}
+	out := make([]byte, 0, len(encMagic)+encIDLen+len(nonce)+len(data)+e.aead.Overhead())
+	out = append(out, encMagic...)
+	out = append(out, e.id...)
+	out = append(out, nonce...)
+	return e.aead.Seal(out, nonce, data, nil), nil
+}
+
+// Open decrypts data sealed by Seal. Data that is not encrypted, such as
+// entries written before encryption was enabled, is returned unchanged.
+// It returns ErrNoKey when e is nil and ErrWrongKey when data was sealed
+// with another key.
+func (e *Encryption) Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if e == nil {
+		return nil, ErrNoKey
+	}
+	rest := data[len(encMagic):]
+	if len(rest) < encIDLen+e.aead.NonceSize() {
+		return nil, errors.New("cache: encrypted entry is truncated")
+	}
+	if !bytes.Equal(rest[:encIDLen], e.id) {
+		return nil, fmt.Errorf("%w (entry key %x, configured key %x)", ErrWrongKey, rest[:encIDLen], e.id)
+	}
+	rest = rest[encIDLen:]
+	nonce, sealed := rest[:e.aead.NonceSize()], rest[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.New("cache: encrypted entry is corrupted")
+	}
+	return plain, nil
+}
+
+// IsEncrypted reports whether data is an encrypted cache entry.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encMagic))
+}
+
+// defaultEncryption is the Encryption used by stores without one
+// configured and by ReadFile.
+var defaultEncryption atomic.Pointer[Encryption]
+
+// DefaultEncryption returns the process-wide Encryption, or nil if none is
+// configured.
+func DefaultEncryption() *Encryption {
+	return defaultEncryption.Load()
+}
+
+// SetDefaultEncryption sets the process-wide Encryption. Nil disables
+// encryption.
+func SetDefaultEncryption(e *Encryption) {
+	defaultEncryption.Store(e)
+}
+
+// ConfigureEncryption sets the process-wide Encryption from the cache
+// settings: namespaces to encrypt and a key file. Without a key file the
+// secret is kept in the user's keyring and created on first use. With no
+// namespaces and no key file, encryption is disabled and the keyring is
+// not touched.
+func ConfigureEncryption(namespaces []string, keyFile string) error {
+	if len(namespaces) == 0 && keyFile == "" {
+		SetDefaultEncryption(nil)
+		return nil
+	}
+	secret, err := LoadSecret(keyFile)
+	if err != nil {
+		return err
+	}
+	e, err := NewEncryption(secret, namespaces)
+	if err != nil {
+		return err
+	}
+	SetDefaultEncryption(e)
+	return nil
+}
+
+// LoadSecret returns the encryption secret from keyFile, or from the
+// user's keyring when keyFile is empty. Surrounding whitespace in the file
+// is ignored, so secrets written by sops-nix or echo work unchanged.
+func LoadSecret(keyFile string) ([]byte, error) {
+	if keyFile == "" {
+		return keyringSecret()
+	}
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cache: key file: %w", err)
+	}
+	secret := bytes.TrimSpace(raw)
+	if len(secret) < minSecretLen {
+		return nil, fmt.Errorf("cache: key file %s: key must be at least %d bytes", keyFile, minSecretLen)
+	}
+	return secret, nil
+}
+
+// ReadFile reads the file at path and decrypts it with the process-wide
+// Encryption if it is encrypted.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := DefaultEncryption().Open(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// keyringSecret returns the cache secret from the macOS Keychain or the
+// Secret Service (secret-tool), generating and storing a random one if
+// none exists. Replacing a secret only costs re-collecting the encrypted
+// entries, since those sealed with the old one read as ErrWrongKey.
+func keyringSecret() ([]byte, error) {
+	var lookup *exec.Cmd
+	var store func(secret string) *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		lookup = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+		store = func(secret string) *exec.Cmd {
+			return exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", keyringAccount, "-w", secret)
+		}
+	default:
+		lookup = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+		store = func(secret string) *exec.Cmd {
+			cmd := exec.Command("secret-tool", "store", "--label=prompt-pulse cache key", "service", keyringService, "account", keyringAccount)
+			cmd.Stdin = strings.NewReader(secret)
+			return cmd
+		}
+	}
+
+	if out, err := lookup.Output(); err == nil {
+		if secret := bytes.TrimSpace(out); len(secret) > 0 {
+			return secret, nil
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("cache: generate key: %w", err)
+	}
+	secret := hex.EncodeToString(buf)
+	if out, err := store(secret).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cache: no key_file set and the keyring is unavailable: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return []byte(secret), nil
+}
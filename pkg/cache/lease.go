@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Default lease timings. They are sized for the prompt hot path: a refresh
+// holder gets a couple of seconds before its lease can be stolen, and
+// followers never wait more than a few milliseconds for the fresh result.
+const (
+	DefaultLeaseTTL  = 2 * time.Second
+	DefaultLeaseWait = 5 * time.Millisecond
+	DefaultLeasePoll = time.Millisecond
+)
+
+// LeaseOptions controls cross-process single-flight coordination.
+type LeaseOptions struct {
+	// TTL is how long an acquired lease stays valid. A lease whose deadline
+	// has passed may be stolen by another process. Default: DefaultLeaseTTL.
+	TTL time.Duration
+
+	// Wait is the maximum time a follower polls for fresh data before
+	// giving up and serving stale data. Zero means do not wait at all.
+	Wait time.Duration
+
+	// Poll is the interval between freshness checks while waiting.
+	// Default: DefaultLeasePoll.
+	Poll time.Duration
+}
+
+// CoalesceResult describes how a Coalesce call was resolved.
+type CoalesceResult int
+
+const (
+	// CoalesceFresh means the data was already fresh; nothing was done.
+	CoalesceFresh CoalesceResult = iota
+
+	// CoalesceRefreshed means this caller held the lease and ran refresh.
+	CoalesceRefreshed
+
+	// CoalesceWaited means another process refreshed the data while this
+	// caller was waiting.
+	CoalesceWaited
+
+	// CoalesceStale means the wait budget elapsed (or was zero) before fresh
+	// data appeared. The caller should serve whatever stale data it has.
+	CoalesceStale
+)
+
+// String returns a human-readable name for the result.
+func (r CoalesceResult) String() string {
+	switch r {
+	case CoalesceFresh:
+		return "fresh"
+	case CoalesceRefreshed:
+		return "refreshed"
+	case CoalesceWaited:
+		return "waited"
+	case CoalesceStale:
+		return "stale"
+	default:
+		return "unknown"
+	}
+}
+
+// leaseRecord is the JSON content of a lease file.
+type leaseRecord struct {
+	PID      int   `json:"pid"`
+	Deadline int64 `json:"deadline"` // UnixNano
+}
+
+// Lease is a short-lived, cross-process lock on a single cache key. Leases
+// are plain files created with O_EXCL so they work on any filesystem,
+// including network mounts where flock is unreliable.
+type Lease struct {
+	path   string
+	record leaseRecord
+}
+
+// leasePID and leaseAlive are indirections over the process table so tests
+// can simulate many processes from goroutines in a single test binary.
+var (
+	leasePID   = os.Getpid
+	leaseAlive = processAlive
+)
+
+// AcquireLease attempts to take the lease for key in dir. It returns the
+// lease and true when this caller now holds it, or nil and false when a live
+// holder already owns it. Leases held by dead PIDs or past their deadline are
+// stolen. AcquireLease never blocks.
+func AcquireLease(dir, key string, ttl time.Duration) (*Lease, bool, error) {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, false, fmt.Errorf("cache: create lease directory %s: %w", dir, err)
+	}
+
+	path := leasePath(dir, key)
+
+	// Two attempts: the second one follows a successful steal.
+	for attempt := 0; attempt < 2; attempt++ {
+		l, err := createLease(path, ttl)
+		if err == nil {
+			return l, true, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, false, fmt.Errorf("cache: create lease for %q: %w", key, err)
+		}
+
+		held, err := readLease(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Released between our create and read; try again.
+				continue
+			}
+			// An unreadable lease is either mid-write or garbage. Treat a
+			// garbage file as stale once it is older than ttl.
+			info, statErr := os.Stat(path)
+			if statErr != nil || time.Since(info.ModTime()) < ttl {
+				return nil, false, nil
+			}
+			held = leaseRecord{}
+		}
+
+		if !leaseStale(held) {
+			return nil, false, nil
+		}
+		if !stealLease(path, held) {
+			return nil, false, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// Release removes the lease file if it is still owned by this lease. A lease
+// that has already been stolen is left alone.
+func (l *Lease) Release() error {
+	if l == nil {
+		return nil
+	}
+	held, err := readLease(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("cache: read lease %s: %w", l.path, err)
+	}
+	if held != l.record {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cache: release lease %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// Deadline returns the time after which the lease may be stolen.
+func (l *Lease) Deadline() time.Time {
+	return time.Unix(0, l.record.Deadline)
+}
+
+// Coalesce performs cross-process single-flight for a stale cache entry.
+// fresh reports whether the entry is currently fresh. If it is not, the
+// first caller to take the lease runs refresh; everyone else polls fresh for
+// up to opts.Wait and then gives up. Coalesce never waits longer than
+// opts.Wait unless this caller is the refresher, in which case it returns as
+// soon as refresh does.
+func Coalesce(dir, key string, opts LeaseOptions, fresh func() bool, refresh func() error) (CoalesceResult, error) {
+	if opts.Poll <= 0 {
+		opts.Poll = DefaultLeasePoll
+	}
+
+	if fresh() {
+		return CoalesceFresh, nil
+	}
+
+	lease, ok, err := AcquireLease(dir, key, opts.TTL)
+	if err != nil {
+		return CoalesceStale, err
+	}
+	if ok {
+		defer lease.Release()
+		// Another process may have finished a refresh between our freshness
+		// check and the lease acquisition.
+		if fresh() {
+			return CoalesceWaited, nil
+		}
+		if err := refresh(); err != nil {
+			return CoalesceStale, err
+		}
+		return CoalesceRefreshed, nil
+	}
+
+	deadline := time.Now().Add(opts.Wait)
+	for time.Now().Before(deadline) {
+		sleep := opts.Poll
+		if remaining := time.Until(deadline); remaining < sleep {
+			sleep = remaining
+		}
+		time.Sleep(sleep)
+		if fresh() {
+			return CoalesceWaited, nil
+		}
+	}
+	return CoalesceStale, nil
+}
+
+// --- lease helpers ---
+
+// leasePath returns the lease file path for key. The key is hashed so any
+// string is safe to use.
+func leasePath(dir, key string) string {
+	return filepath.Join(dir, hashKey(key)+".lease")
+}
+
+// createLease exclusively creates the lease file and writes its record.
+func createLease(path string, ttl time.Duration) (*Lease, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := leaseRecord{
+		PID:      leasePID(),
+		Deadline: time.Now().Add(ttl).UnixNano(),
+	}
+	data, _ := json.Marshal(rec)
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return &Lease{path: path, record: rec}, nil
+}
+
+// readLease parses the record stored in a lease file.
+func readLease(path string) (leaseRecord, error) {
+	var rec leaseRecord
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+// leaseStale reports whether a lease may be stolen: its deadline has passed
+// or its holder is no longer running.
+func leaseStale(rec leaseRecord) bool {
+	if time.Now().UnixNano() > rec.Deadline {
+		return true
+	}
+	return !leaseAlive(rec.PID)
+}
+
+// stealLease removes a stale lease without racing other stealers. The lease
+// is renamed to a unique tombstone first so only one process wins; if the
+// file we moved turns out to be a fresh lease taken by someone else in the
+// meantime, it is linked back into place.
+func stealLease(path string, stale leaseRecord) bool {
+	tomb := path + ".stale-" + strconv.Itoa(leasePID()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := os.Rename(path, tomb); err != nil {
+		return false
+	}
+	defer os.Remove(tomb)
+
+	moved, err := readLease(tomb)
+	if err == nil && moved != stale {
+		// We grabbed a live lease. Put it back unless a new one exists.
+		_ = os.Link(tomb, path)
+		return false
+	}
+	return true
+}
+
+// processAlive checks whether pid refers to a running process by sending
+// signal 0.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Migration upgrades a cached JSON payload to the next schema version of
+// its namespace.
+type Migration func(data []byte) ([]byte, error)
+
+// errNoMigration is returned when an entry's schema version has no
+// registered path to the current one.
+var errNoMigration = errors.New("no migration registered")
+
+// Schemas records the current schema version of each cache namespace and
+// the migrations that upgrade older entries to it. A key's namespace is
+// the part before its first ":", or the whole key, so "claude" and
+// "claude:work" share a schema. Namespaces without registered migrations
+// are at version 1. Schemas is safe for concurrent use.
+type Schemas struct {
+	mu         sync.RWMutex
+	versions   map[string]int
+	migrations map[string]map[int]Migration // namespace -> target version
+}
+
+// NewSchemas returns an empty schema registry.
+func NewSchemas() *Schemas {
+	return &Schemas{
+		versions:   make(map[string]int),
+		migrations: make(map[string]map[int]Migration),
+	}
+}
+
+// DefaultSchemas is the registry used by stores without one configured.
+// Collectors register their payload migrations here with
+// RegisterMigration.
+var DefaultSchemas = NewSchemas()
+
+// RegisterMigration registers up in DefaultSchemas; see Schemas.Register.
+func RegisterMigration(namespace string, version int, up Migration) {
+	DefaultSchemas.Register(namespace, version, up)
+}
+
+// Register declares that namespace has schema version, reached from
+// version-1 by up. The namespace's current version is the highest one
+// registered. It panics if version is below 2, since version 1 is the
+// unversioned baseline.
+func (s *Schemas) Register(namespace string, version int, up Migration) {
+	if version < 2 {
+		panic(fmt.Sprintf("cache: migration for %q must target version 2 or later, got %d", namespace, version))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.migrations[namespace] == nil {
+		s.migrations[namespace] = make(map[int]Migration)
+	}
+	s.migrations[namespace][version] = up
+	if version > s.versions[namespace] {
+		s.versions[namespace] = version
+	}
+}
+
+// Version returns the current schema version of the namespace of key.
+func (s *Schemas) Version(key string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if v, ok := s.versions[namespaceOf(key)]; ok {
+		return v
+	}
+	return 1
+}
+
+// migrate upgrades data for key from schema version from to the current
+// version. It returns the upgraded data, or the input and errNoMigration
+// if a step is missing.
+func (s *Schemas) migrate(key string, from int, data []byte) ([]byte, error) {
+	ns := namespaceOf(key)
+	to := s.Version(key)
+	for v := from + 1; v <= to; v++ {
+		s.mu.RLock()
+		up, ok := s.migrations[ns][v]
+		s.mu.RUnlock()
+		if !ok {
+			return data, fmt.Errorf("cache: %q version %d to %d: %w", key, v-1, v, errNoMigration)
+		}
+		next, err := up(data)
+		if err != nil {
+			return data, fmt.Errorf("cache: migrate %q to version %d: %w", key, v, err)
+		}
+		data = next
+	}
+	return data, nil
+}
+
+// namespaceOf returns the schema namespace of key.
+func namespaceOf(key string) string {
+	ns, _, _ := strings.Cut(key, ":")
+	return ns
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -26,8 +27,29 @@ type StoreConfig struct {
 	// CleanupInterval is how often the background goroutine sweeps for
 	// expired entries. Default: 5 minutes.
 	CleanupInterval time.Duration
+
+	// Schemas holds the schema versions and migrations for typed entries.
+	// Default: DefaultSchemas.
+	Schemas *Schemas
+
+	// QuarantineKeep is how many corrupted entries are kept in the
+	// quarantine subdirectory for debugging. Default: 10.
+	QuarantineKeep int
+
+	// Encryption selects the keys whose typed entries are encrypted at
+	// rest; Get and Put store bytes as given. Default: the process-wide
+	// DefaultEncryption at the time of each call.
+	Encryption *Encryption
 }
 
+// QuarantineDir is the subdirectory of the cache directory that corrupted
+// entries are moved into.
+const QuarantineDir = "quarantine"
+
+// tempFileMaxAge is how old a leftover temporary file must be before
+// Compact removes it; younger ones may belong to a write in progress.
+const tempFileMaxAge = time.Hour
+
 // CacheStats holds runtime statistics for a cache Store.
 type CacheStats struct {
 	Hits      int64
@@ -35,6 +57,23 @@ type CacheStats struct {
 	Evictions int64
 	Size      int64
 	Entries   int
+
+	// Quarantined is the number of corrupted entries held in the
+	// quarantine subdirectory.
+	Quarantined int
+}
+
+// CompactResult reports what Compact removed.
+type CompactResult struct {
+	// Expired is the number of expired entries removed.
+	Expired int
+
+	// Quarantined is the number of quarantined entries removed beyond
+	// StoreConfig.QuarantineKeep.
+	Quarantined int
+
+	// TempFiles is the number of abandoned temporary files removed.
+	TempFiles int
 }
 
 // entryMeta is the JSON structure persisted alongside each cache entry.
@@ -43,6 +82,18 @@ type entryMeta struct {
 	Created int64  `json:"created"` // UnixNano
 	TTLNS   int64  `json:"ttl_ns"`  // 0 = no TTL
 	Size    int64  `json:"size"`    // data file size in bytes
+
+	// Version is the schema version of the data's namespace when it was
+	// written. Entries from before versioning omit it and are version 1.
+	Version int `json:"version,omitempty"`
+}
+
+// version returns the entry's schema version.
+func (m entryMeta) version() int {
+	if m.Version < 1 {
+		return 1
+	}
+	return m.Version
 }
 
 // lruEntry is the value stored in each list.Element.
@@ -66,6 +117,8 @@ type Store struct {
 	misses   int64
 	evictions int64
 
+	quarantined int // entries in the quarantine subdirectory
+
 	done      chan struct{} // signals cleanup goroutine to stop
 	closeOnce sync.Once
 	wg        sync.WaitGroup
@@ -87,6 +140,12 @@ func NewStore(cfg StoreConfig) (*Store, error) {
 	if cfg.CleanupInterval <= 0 {
 		cfg.CleanupInterval = 5 * time.Minute
 	}
+	if cfg.Schemas == nil {
+		cfg.Schemas = DefaultSchemas
+	}
+	if cfg.QuarantineKeep <= 0 {
+		cfg.QuarantineKeep = 10
+	}
 
 	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
 		return nil, fmt.Errorf("cache: create directory %s: %w", cfg.Dir, err)
@@ -112,6 +171,12 @@ func NewStore(cfg StoreConfig) (*Store, error) {
 // Get retrieves the raw bytes for key. Returns (nil, false) if the key is
 // missing or expired. On a hit, the entry is promoted to the front of the LRU.
 func (s *Store) Get(key string) ([]byte, bool) {
+	data, _, ok := s.getEntry(key)
+	return data, ok
+}
+
+// getEntry is Get, also returning the entry's metadata.
+func (s *Store) getEntry(key string) ([]byte, entryMeta, bool) {
 	h := hashKey(key)
 
 	s.mu.Lock()
@@ -120,31 +185,37 @@ func (s *Store) Get(key string) ([]byte, bool) {
 	elem, ok := s.items[h]
 	if !ok {
 		s.misses++
-		return nil, false
+		return nil, entryMeta{}, false
 	}
 
 	// Check TTL
 	meta, err := s.readMeta(h)
 	if err != nil {
+		if os.IsNotExist(err) {
+			s.removeLocked(h, elem)
+		} else {
+			s.quarantineLocked(h, elem)
+		}
 		s.misses++
-		return nil, false
+		return nil, entryMeta{}, false
 	}
 	if s.isExpired(meta) {
 		s.removeLocked(h, elem)
 		s.misses++
-		return nil, false
+		return nil, entryMeta{}, false
 	}
 
 	data, err := os.ReadFile(s.dataPath(h))
 	if err != nil {
+		s.removeLocked(h, elem)
 		s.misses++
-		return nil, false
+		return nil, entryMeta{}, false
 	}
 
 	// Promote in LRU
 	s.lru.MoveToFront(elem)
 	s.hits++
-	return data, true
+	return data, meta, true
 }
 
 // GetString is a convenience method that returns the cached value as a string.
@@ -163,16 +234,22 @@ func (s *Store) Put(key string, value []byte) error {
 
 // PutWithTTL stores value under key with a custom TTL. A TTL of 0 means the
 // entry never expires by time (only by LRU eviction or explicit deletion).
+// The entry is stamped with the current schema version of its namespace.
 func (s *Store) PutWithTTL(key string, value []byte, ttl time.Duration) error {
-	h := hashKey(key)
-	size := int64(len(value))
-
-	meta := entryMeta{
-		Key:     key,
+	return s.putEntry(key, value, entryMeta{
 		Created: time.Now().UnixNano(),
 		TTLNS:   int64(ttl),
-		Size:    size,
-	}
+	})
+}
+
+// putEntry stores value under key with meta's creation time and TTL,
+// stamping it with the current schema version.
+func (s *Store) putEntry(key string, value []byte, meta entryMeta) error {
+	h := hashKey(key)
+	size := int64(len(value))
+	meta.Key = key
+	meta.Size = size
+	meta.Version = s.cfg.Schemas.Version(key)
 
 	metaBytes, err := json.Marshal(meta)
 	if err != nil {
@@ -332,14 +409,68 @@ func (s *Store) Stats() CacheStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return CacheStats{
-		Hits:      s.hits,
-		Misses:    s.misses,
-		Evictions: s.evictions,
-		Size:      s.curSize,
-		Entries:   s.lru.Len(),
+		Hits:        s.hits,
+		Misses:      s.misses,
+		Evictions:   s.evictions,
+		Size:        s.curSize,
+		Entries:     s.lru.Len(),
+		Quarantined: s.quarantined,
+	}
+}
+
+// encryption returns the Encryption for typed entries.
+func (s *Store) encryption() *Encryption {
+	if s.cfg.Encryption != nil {
+		return s.cfg.Encryption
+	}
+	return DefaultEncryption()
+}
+
+// Quarantine moves the entry for key into the quarantine subdirectory, so
+// later reads miss instead of failing on it again. Callers use it when an
+// entry's data turns out to be corrupted, such as JSON truncated by a power
+// loss. It is a no-op if the key is not cached.
+func (s *Store) Quarantine(key string) {
+	h := hashKey(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[h]; ok {
+		s.quarantineLocked(h, elem)
 	}
 }
 
+// Compact removes expired entries, quarantined entries beyond
+// StoreConfig.QuarantineKeep, and temporary files abandoned by interrupted
+// writes.
+func (s *Store) Compact() (CompactResult, error) {
+	res := CompactResult{Expired: s.sweepExpired()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res.Quarantined = s.pruneQuarantineLocked()
+
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return res, fmt.Errorf("cache: compact read dir: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < tempFileMaxAge {
+			continue
+		}
+		if os.Remove(filepath.Join(s.cfg.Dir, e.Name())) == nil {
+			res.TempFiles++
+		}
+	}
+	return res, nil
+}
+
 // Close stops the background cleanup goroutine and waits for it to finish.
 // It is safe to call Close multiple times.
 func (s *Store) Close() error {
@@ -395,6 +526,74 @@ func (s *Store) removeLocked(hash string, elem *list.Element) {
 	_ = os.Remove(s.metaPath(hash))
 }
 
+// quarantineLocked moves an entry's files into the quarantine
+// subdirectory, named by the time they were quarantined, and removes it
+// from the LRU. Only the newest StoreConfig.QuarantineKeep entries are
+// kept. Caller must hold s.mu write lock.
+func (s *Store) quarantineLocked(hash string, elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	s.curSize -= entry.size
+	s.lru.Remove(elem)
+	delete(s.items, hash)
+	s.quarantineFiles(hash)
+	s.pruneQuarantineLocked()
+}
+
+// quarantineFiles moves the data and meta files of hash into the
+// quarantine subdirectory, deleting them if they cannot be moved.
+func (s *Store) quarantineFiles(hash string) {
+	dir := filepath.Join(s.cfg.Dir, QuarantineDir)
+	stem := fmt.Sprintf("%020d-%s", time.Now().UnixNano(), hash)
+	moved := false
+	if err := os.MkdirAll(dir, 0755); err == nil {
+		for _, ext := range []string{".cache", ".meta"} {
+			if os.Rename(filepath.Join(s.cfg.Dir, hash+ext), filepath.Join(dir, stem+ext)) == nil {
+				moved = true
+			}
+		}
+	}
+	_ = os.Remove(s.dataPath(hash))
+	_ = os.Remove(s.metaPath(hash))
+	if moved {
+		s.quarantined++
+	}
+}
+
+// pruneQuarantineLocked deletes the oldest quarantined entries beyond
+// StoreConfig.QuarantineKeep, recounts the rest, and returns how many it
+// deleted. Caller must hold s.mu write lock.
+func (s *Store) pruneQuarantineLocked() int {
+	dir := filepath.Join(s.cfg.Dir, QuarantineDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		s.quarantined = 0
+		return 0
+	}
+
+	// Entries are named "<quarantine time>-<hash>.<ext>", so sorting the
+	// stems orders them oldest first.
+	var stems []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		stem := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		if !seen[stem] {
+			seen[stem] = true
+			stems = append(stems, stem)
+		}
+	}
+	sort.Strings(stems)
+
+	removed := 0
+	for len(stems)-removed > s.cfg.QuarantineKeep {
+		for _, ext := range []string{".cache", ".meta"} {
+			_ = os.Remove(filepath.Join(dir, stems[removed]+ext))
+		}
+		removed++
+	}
+	s.quarantined = len(stems) - removed
+	return removed
+}
+
 // evictLocked removes entries until curSize is within maxBytes.
 // Expired entries are evicted first, then LRU from the back.
 // Caller must hold s.mu write lock.
@@ -466,9 +665,8 @@ func (s *Store) scanDir() error {
 
 		meta, err := s.readMeta(hash)
 		if err != nil {
-			// Corrupted meta file, remove both files
-			_ = os.Remove(s.metaPath(hash))
-			_ = os.Remove(s.dataPath(hash))
+			// Corrupted meta file, e.g. truncated by a power loss.
+			s.quarantineFiles(hash)
 			continue
 		}
 
@@ -489,6 +687,7 @@ func (s *Store) scanDir() error {
 		s.curSize += meta.Size
 	}
 
+	s.pruneQuarantineLocked()
 	return nil
 }
 
@@ -508,8 +707,8 @@ func (s *Store) cleanupLoop() {
 	}
 }
 
-// sweepExpired removes all expired entries.
-func (s *Store) sweepExpired() {
+// sweepExpired removes all expired entries and returns how many it removed.
+func (s *Store) sweepExpired() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -532,6 +731,7 @@ func (s *Store) sweepExpired() {
 		s.removeLocked(r.hash, r.elem)
 		s.evictions++
 	}
+	return len(toRemove)
 }
 
 // atomicWrite writes data to path via a temporary file and rename.
@@ -6,37 +6,146 @@ import (
 	"time"
 )
 
-// GetTyped deserializes a cached JSON value into the given type T.
-// Returns the zero value of T and false if the key is missing, expired,
-// or the stored data is not valid JSON for type T.
+// ReadState describes what LookupTyped found for a key.
+type ReadState int
+
+const (
+	// ReadMissing means the key is absent or expired.
+	ReadMissing ReadState = iota
+
+	// ReadOK means the entry is at the current schema version.
+	ReadOK
+
+	// ReadMigrated means the entry was written under an older schema
+	// version and upgraded by the registered migrations. The upgraded
+	// value has been written back.
+	ReadMigrated
+
+	// ReadStale means the entry is from another schema version that could
+	// not be migrated, but still decodes. It is usable, though fields
+	// added since may be empty, until the next write replaces it.
+	ReadStale
+
+	// ReadUnreadable means the entry exists but cannot be decrypted or
+	// does not decode as the requested type, even after migration. Entries that are not valid
+	// JSON at all are quarantined, so the next lookup is ReadMissing.
+	ReadUnreadable
+)
+
+// String returns the state's name.
+func (r ReadState) String() string {
+	switch r {
+	case ReadMissing:
+		return "missing"
+	case ReadOK:
+		return "ok"
+	case ReadMigrated:
+		return "migrated"
+	case ReadStale:
+		return "stale"
+	case ReadUnreadable:
+		return "unreadable"
+	default:
+		return fmt.Sprintf("ReadState(%d)", int(r))
+	}
+}
+
+// Usable reports whether LookupTyped returned a value.
+func (r ReadState) Usable() bool {
+	return r == ReadOK || r == ReadMigrated || r == ReadStale
+}
+
+// GetTyped deserializes a cached JSON value into the given type T,
+// migrating entries written under an older schema version and decrypting
+// encrypted ones. Returns the zero value of T and false if the key is
+// missing, expired, or the stored data cannot be decoded as T; see
+// LookupTyped for the distinction and ReadTyped for the reason.
 func GetTyped[T any](s *Store, key string) (T, bool) {
-	data, ok := s.Get(key)
+	v, state := LookupTyped[T](s, key)
+	return v, state.Usable()
+}
+
+// GetTypedAt is GetTyped that also returns when the entry was written, so
+// callers can show how old the value is. Migrating an entry keeps its
+// original time.
+func GetTypedAt[T any](s *Store, key string) (T, time.Time, bool) {
+	v, state, created, _ := readTyped[T](s, key)
+	return v, created, state.Usable()
+}
+
+// LookupTyped deserializes a cached JSON value into the given type T and
+// reports how it was read. Entries from an older schema version of the
+// key's namespace are upgraded through the store's registered migrations
+// and rewritten in the current version, keeping their creation time and
+// TTL.
+func LookupTyped[T any](s *Store, key string) (T, ReadState) {
+	v, state, _ := ReadTyped[T](s, key)
+	return v, state
+}
+
+// ReadTyped is LookupTyped that also returns why an entry is
+// ReadUnreadable, such as ErrWrongKey for an entry encrypted with another
+// key.
+func ReadTyped[T any](s *Store, key string) (T, ReadState, error) {
+	v, state, _, err := readTyped[T](s, key)
+	return v, state, err
+}
+
+// readTyped is ReadTyped that also returns the entry's creation time.
+func readTyped[T any](s *Store, key string) (T, ReadState, time.Time, error) {
+	var zero T
+	stored, meta, ok := s.getEntry(key)
 	if !ok {
-		var zero T
-		return zero, false
+		return zero, ReadMissing, time.Time{}, nil
+	}
+	created := time.Unix(0, meta.Created)
+	data, err := s.encryption().Open(stored)
+	if err != nil {
+		return zero, ReadUnreadable, created, fmt.Errorf("cache: read %q: %w", key, err)
+	}
+
+	state := ReadOK
+	if meta.version() != s.cfg.Schemas.Version(key) {
+		state = ReadStale
+		if meta.version() < s.cfg.Schemas.Version(key) {
+			if migrated, err := s.cfg.Schemas.migrate(key, meta.version(), data); err == nil {
+				data, state = migrated, ReadMigrated
+			}
+		}
 	}
+
 	var v T
 	if err := json.Unmarshal(data, &v); err != nil {
-		var zero T
-		return zero, false
+		if !json.Valid(data) {
+			s.Quarantine(key)
+		}
+		return zero, ReadUnreadable, created, fmt.Errorf("cache: decode %q: %w", key, err)
+	}
+	if state == ReadMigrated {
+		// Best effort: a failed rewrite only means migrating again.
+		if sealed, err := s.encryption().Seal(key, data); err == nil {
+			_ = s.putEntry(key, sealed, meta)
+		}
 	}
-	return v, true
+	return v, state, created, nil
 }
 
-// PutTyped serializes value as JSON and stores it with the default TTL.
+// PutTyped serializes value as JSON and stores it with the default TTL,
+// encrypted if the store encrypts key.
 func PutTyped[T any](s *Store, key string, value T) error {
-	data, err := json.Marshal(value)
-	if err != nil {
-		return fmt.Errorf("cache: marshal typed value for %q: %w", key, err)
-	}
-	return s.Put(key, data)
+	return PutTypedWithTTL(s, key, value, s.cfg.DefaultTTL)
 }
 
-// PutTypedWithTTL serializes value as JSON and stores it with a custom TTL.
+// PutTypedWithTTL serializes value as JSON and stores it with a custom TTL,
+// encrypted if the store encrypts key.
 func PutTypedWithTTL[T any](s *Store, key string, value T, ttl time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("cache: marshal typed value for %q: %w", key, err)
 	}
+	data, err = s.encryption().Seal(key, data)
+	if err != nil {
+		return err
+	}
 	return s.PutWithTTL(key, data, ttl)
 }
@@ -0,0 +1,335 @@
+// Package changelog records what changed in collector data between polls:
+// a cloud provider's spend going up, a cluster losing running pods, a
+// check going down. The daemon diffs each collector's new data against
+// what it last reported and appends an event for every change larger
+// than its noise threshold to a bounded log file, which the TUI's Events
+// pane and the banner read.
+package changelog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+)
+
+// FileName is the event log in the daemon's data directory. It is sealed
+// like collector data when "events" is listed in cache.encrypt.
+const FileName = "events.json"
+
+// Default settings.
+const (
+	DefaultMaxEntries      = 200
+	DefaultBillingMinDelta = 0.50
+	DefaultClaudeMinDelta  = 1.00
+	DefaultPodJitter       = 1
+)
+
+// Event is one change in a collector's data.
+type Event struct {
+	Time time.Time `json:"time"`
+
+	// Source is the collector whose data changed.
+	Source string `json:"source"`
+
+	// Text describes the change, e.g. "k8s prod pods running 41→39".
+	Text string `json:"text"`
+}
+
+// Thresholds keep small changes out of the log.
+type Thresholds struct {
+	// BillingMinDelta is the smallest change in a provider's
+	// month-to-date spend that is logged, in the report currency.
+	BillingMinDelta float64
+
+	// ClaudeMinDelta is the smallest change in an account's monthly cost
+	// that is logged, in USD.
+	ClaudeMinDelta float64
+
+	// PodJitter is how far a cluster's running pod count may move
+	// without being logged.
+	PodJitter int
+}
+
+// DefaultThresholds returns the thresholds used when none are configured.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		BillingMinDelta: DefaultBillingMinDelta,
+		ClaudeMinDelta:  DefaultClaudeMinDelta,
+		PodJitter:       DefaultPodJitter,
+	}
+}
+
+// Differ turns successive collector data into change events. It is safe
+// for concurrent use.
+type Differ struct {
+	// now is the clock, replaceable in tests.
+	now func() time.Time
+
+	mu         sync.Mutex
+	thresholds Thresholds
+	// reported holds, per source, the value of each fact as last
+	// reported. A fact's value only moves when a change is logged, so
+	// small changes add up until they cross the threshold.
+	reported map[string]map[string]clFact
+}
+
+// New returns a Differ applying th.
+func New(th Thresholds) *Differ {
+	return &Differ{
+		now:        time.Now,
+		thresholds: th,
+		reported:   make(map[string]map[string]clFact),
+	}
+}
+
+// SetThresholds replaces the thresholds, as on a configuration reload.
+// What was last reported is kept.
+func (d *Differ) SetThresholds(th Thresholds) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.thresholds = th
+}
+
+// Observe compares the named collector's latest data with what was last
+// reported and returns an event for each change past its threshold. The
+// first data of a source, and facts not seen before, are the baseline and
+// yield no events. Data of a type without a differ yields none.
+func (d *Differ) Observe(source string, data interface{}) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	facts, ok := clFacts(data, d.thresholds)
+	if !ok {
+		return nil
+	}
+	prev, seen := d.reported[source]
+	next := make(map[string]clFact, len(facts))
+	now := d.now()
+	var events []Event
+	for _, f := range facts {
+		old, had := prev[f.key]
+		if !seen || !had {
+			next[f.key] = f
+			continue
+		}
+		text, changed := f.diff(old)
+		if !changed {
+			next[f.key] = old
+			continue
+		}
+		next[f.key] = f
+		events = append(events, Event{Time: now, Source: source, Text: text})
+	}
+	d.reported[source] = next
+	return events
+}
+
+// clFact is one value a differ watches: a number, such as a provider's
+// spend, or a state, such as a check being up.
+type clFact struct {
+	key   string
+	value float64
+	state string
+
+	// min is the smallest change of value that is reported.
+	min float64
+
+	// format describes a change of value from old to new; states are
+	// described as "key old→new".
+	format func(old, new float64) string
+}
+
+// diff describes the change from old to f, and reports false if there is
+// none worth logging.
+func (f clFact) diff(old clFact) (string, bool) {
+	if f.format == nil {
+		if f.state == old.state {
+			return "", false
+		}
+		return fmt.Sprintf("%s %s→%s", f.key, old.state, f.state), true
+	}
+	delta := f.value - old.value
+	// Compare in hundredths so 0.1+0.2 against a 0.30 threshold counts.
+	if delta == 0 || math.Round(math.Abs(delta)*100) < math.Round(f.min*100) {
+		return "", false
+	}
+	return f.format(old.value, f.value), true
+}
+
+// clCount returns a format describing the count label changing, e.g.
+// "k8s prod pods running 41→39".
+func clCount(label string) func(float64, float64) string {
+	return func(old, new float64) string {
+		return fmt.Sprintf("%s %.0f→%.0f", label, old, new)
+	}
+}
+
+// clMoney returns a format describing the amount label, in currency,
+// changing by its signed delta, e.g. "billing.digitalocean +$3.20".
+func clMoney(label, currency string) func(float64, float64) string {
+	return func(old, new float64) string {
+		sign := "+"
+		if new < old {
+			sign = "-"
+		}
+		return label + " " + sign + billing.FormatAmount(math.Abs(new-old), currency)
+	}
+}
+
+// clFacts returns the facts a differ watches in data, and false for data
+// of a type without one.
+func clFacts(data interface{}, th Thresholds) ([]clFact, bool) {
+	var facts []clFact
+	state := func(key, s string) {
+		facts = append(facts, clFact{key: key, state: s})
+	}
+	switch v := data.(type) {
+	case *billing.BillingReport:
+		if v == nil {
+			return nil, false
+		}
+		currency := v.DisplayCurrency()
+		for _, p := range v.Providers {
+			key := "billing." + p.Name
+			if !p.Connected {
+				state(key, "disconnected")
+				continue
+			}
+			state(key, "connected")
+			if p.Currency != "" && p.Currency != currency {
+				continue // not converted; its amount is not comparable
+			}
+			facts = append(facts, clFact{key: key + " spend", value: p.MonthToDate, min: th.BillingMinDelta, format: clMoney(key, currency)})
+		}
+
+	case *claude.UsageReport:
+		if v == nil {
+			return nil, false
+		}
+		for _, a := range v.Accounts {
+			key := "claude." + a.Name
+			if !a.Connected {
+				continue
+			}
+			facts = append(facts, clFact{key: key + " cost", value: a.CurrentMonth.CostUSD, min: th.ClaudeMinDelta, format: clMoney(key, "USD")})
+		}
+
+	case *k8s.ClusterStatus:
+		if v == nil {
+			return nil, false
+		}
+		for _, c := range v.Clusters {
+			key := "k8s " + c.Context
+			if !c.Connected {
+				state(key, "disconnected")
+				continue
+			}
+			state(key, "connected")
+			ready := 0
+			for _, n := range c.Nodes {
+				if n.Ready {
+					ready++
+				}
+			}
+			facts = append(facts,
+				clFact{key: key + " pods running", value: float64(c.RunningPods), min: float64(th.PodJitter + 1), format: clCount(key + " pods running")},
+				clFact{key: key + " nodes ready", value: float64(ready), min: 1, format: clCount(key + " nodes ready")},
+				clFact{key: key + " health", state: string(c.Health)},
+			)
+		}
+
+	case *checks.Status:
+		if v == nil {
+			return nil, false
+		}
+		for _, c := range v.Checks {
+			state("checks "+c.Name, c.State)
+		}
+
+	case *uptimekuma.Status:
+		if v == nil {
+			return nil, false
+		}
+		for _, m := range v.Monitors {
+			state("uptimekuma "+m.Name, m.State)
+		}
+
+	case *tailscale.Status:
+		if v == nil {
+			return nil, false
+		}
+		facts = append(facts, clFact{key: "tailscale peers online", value: float64(v.OnlinePeers), min: 1, format: clCount("tailscale peers online")})
+		state("tailscale", v.BackendState)
+
+	default:
+		return nil, false
+	}
+	return facts, true
+}
+
+// Read returns the events logged at path, oldest first, or none if
+// nothing was logged.
+func Read(path string) ([]Event, error) {
+	b, err := cache.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	if err := json.Unmarshal(b, &events); err != nil {
+		return nil, fmt.Errorf("changelog: parse %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// Append adds events to the log at path, keeping the newest max entries,
+// and rewrites it atomically. A log that cannot be parsed is started
+// afresh. Callers serialize appends to the same path.
+func Append(path string, events []Event, max int) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if max <= 0 {
+		max = DefaultMaxEntries
+	}
+	logged, _ := Read(path)
+	logged = append(logged, events...)
+	if len(logged) > max {
+		logged = logged[len(logged)-max:]
+	}
+
+	b, err := json.Marshal(logged)
+	if err != nil {
+		return fmt.Errorf("changelog: marshal: %w", err)
+	}
+	if b, err = cache.DefaultEncryption().Seal("events", b); err != nil {
+		return fmt.Errorf("changelog: encrypt: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("changelog: write: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("changelog: rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+)
+
+// texts returns the text of each event.
+func texts(events []Event) string {
+	out := make([]string, len(events))
+	for i, ev := range events {
+		out[i] = ev.Text
+	}
+	return strings.Join(out, "|")
+}
+
+func TestObserveBilling(t *testing.T) {
+	d := New(DefaultThresholds())
+	report := func(do, civo float64, civoUp bool) *billing.BillingReport {
+		return &billing.BillingReport{Providers: []billing.ProviderBilling{
+			{Name: "digitalocean", Connected: true, MonthToDate: do},
+			{Name: "civo", Connected: civoUp, MonthToDate: civo},
+		}}
+	}
+
+	steps := []struct {
+		name string
+		data *billing.BillingReport
+		want string
+	}{
+		{"baseline", report(40, 20, true), ""},
+		{"under the threshold", report(40.30, 20, true), ""},
+		{"small changes add up", report(40.60, 20, true), "billing.digitalocean +$0.60"},
+		{"decrease", report(37.40, 20, true), "billing.digitalocean -$3.20"},
+		{"disconnect", report(37.40, 0, false), "billing.civo connected→disconnected"},
+		{"reconnect is a new baseline for spend", report(37.40, 25, true), "billing.civo disconnected→connected"},
+		{"unchanged", report(37.40, 25, true), ""},
+	}
+	for _, s := range steps {
+		if got := texts(d.Observe("billing", s.data)); got != s.want {
+			t.Errorf("%s: events = %q, want %q", s.name, got, s.want)
+		}
+	}
+}
+
+func TestObserveK8sJitter(t *testing.T) {
+	d := New(Thresholds{PodJitter: 1})
+	cluster := func(running int, ready []bool, health k8s.HealthLevel) *k8s.ClusterStatus {
+		var nodes []k8s.NodeInfo
+		for _, r := range ready {
+			nodes = append(nodes, k8s.NodeInfo{Ready: r})
+		}
+		return &k8s.ClusterStatus{Clusters: []k8s.ClusterInfo{{Context: "prod", Connected: true, RunningPods: running, Nodes: nodes, Health: health}}}
+	}
+
+	d.Observe("k8s", cluster(41, []bool{true, true}, k8s.HealthOK))
+	if got := texts(d.Observe("k8s", cluster(40, []bool{true, true}, k8s.HealthOK))); got != "" {
+		t.Errorf("jitter of 1: events = %q, want none", got)
+	}
+	if got := texts(d.Observe("k8s", cluster(42, []bool{true, true}, k8s.HealthOK))); got != "" {
+		t.Errorf("back within jitter of the reported 41: events = %q, want none", got)
+	}
+	want := "k8s prod pods running 41→39|k8s prod nodes ready 2→1|k8s prod health healthy→warning"
+	if got := texts(d.Observe("k8s", cluster(39, []bool{true, false}, k8s.HealthWarning))); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestObserveOtherSources(t *testing.T) {
+	d := New(DefaultThresholds())
+	now := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	usage := func(cost float64) *claude.UsageReport {
+		return &claude.UsageReport{Accounts: []claude.AccountUsage{{Name: "work", Connected: true, CurrentMonth: claude.MonthUsage{CostUSD: cost}}}}
+	}
+	d.Observe("claude", usage(10))
+	if got := texts(d.Observe("claude", usage(10.80))); got != "" {
+		t.Errorf("claude under $1: events = %q, want none", got)
+	}
+	if got := texts(d.Observe("claude", usage(11.25))); got != "claude.work +$1.25" {
+		t.Errorf("claude events = %q, want +$1.25", got)
+	}
+
+	status := func(state string) *checks.Status {
+		return &checks.Status{Checks: []checks.Result{{Name: "api", State: state}}}
+	}
+	d.Observe("checks", status(checks.StateUp))
+	events := d.Observe("checks", status(checks.StateDown))
+	if len(events) != 1 || events[0].Text != "checks api up→down" || events[0].Source != "checks" || !events[0].Time.Equal(now) {
+		t.Errorf("checks events = %+v, want api going down at %v", events, now)
+	}
+
+	d.Observe("tailscale", &tailscale.Status{OnlinePeers: 5, BackendState: "Running"})
+	if got := texts(d.Observe("tailscale", &tailscale.Status{OnlinePeers: 4, BackendState: "Running"})); got != "tailscale peers online 5→4" {
+		t.Errorf("tailscale events = %q", got)
+	}
+
+	if events := d.Observe("weather", struct{}{}); events != nil {
+		t.Errorf("data without a differ: events = %+v, want none", events)
+	}
+}
+
+func TestSetThresholds(t *testing.T) {
+	d := New(DefaultThresholds())
+	report := func(spend float64) *billing.BillingReport {
+		return &billing.BillingReport{Providers: []billing.ProviderBilling{{Name: "civo", Connected: true, MonthToDate: spend}}}
+	}
+	d.Observe("billing", report(10))
+	d.SetThresholds(Thresholds{BillingMinDelta: 5})
+	if got := texts(d.Observe("billing", report(13))); got != "" {
+		t.Errorf("events = %q, want none under the new $5 threshold", got)
+	}
+	if got := texts(d.Observe("billing", report(15))); got != "billing.civo +$5.00" {
+		t.Errorf("events = %q, want +$5.00 from the kept baseline", got)
+	}
+}
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", FileName)
+	if events, err := Read(path); err != nil || events != nil {
+		t.Fatalf("Read(missing) = %v, %v; want nothing", events, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		ev := Event{Time: time.Unix(int64(i), 0), Source: "k8s", Text: string(rune('a' + i))}
+		if err := Append(path, []Event{ev}, 3); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	events, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := texts(events); got != "b|c|d" {
+		t.Errorf("log = %q, want the newest 3, oldest first", got)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Read(corrupt) should fail")
+	}
+	if err := Append(path, []Event{{Text: "e"}}, 3); err != nil {
+		t.Fatalf("Append(corrupt) error: %v", err)
+	}
+	if events, _ := Read(path); texts(events) != "e" {
+		t.Errorf("log = %q, want a fresh log", texts(events))
+	}
+}
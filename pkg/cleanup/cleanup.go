@@ -1,7 +1,8 @@
 // Package cleanup provides dead code analysis and cleanup validation for the
 // v1-to-v2 migration. It scans the codebase, identifies v1 code that has been
 // replaced by v2 packages, and generates a cleanup manifest describing what
-// can safely be removed. This package analyzes only — it never deletes files.
+// can safely be removed. This package never deletes files; the only one it
+// writes is ApplyImportRewrites, which updates v1 import paths in place.
 package cleanup
 
 import (
@@ -35,6 +36,11 @@ type Modification struct {
 	Description string
 	OldImport   string
 	NewImport   string
+
+	// Status is set by ApplyImportRewrites: ModPlanned, ModApplied, or
+	// ModSkipped, with the reason for skipping in Error.
+	Status string
+	Error  string
 }
 
 // ManifestSummary aggregates high-level statistics about the cleanup.
@@ -153,10 +159,27 @@ func (m *CleanupManifest) RenderMarkdown() string {
 
 	if len(m.Modifications) > 0 {
 		b.WriteString("## Modifications\n\n")
-		b.WriteString("| File | Old Import | New Import |\n")
-		b.WriteString("|------|-----------|------------|\n")
+		withStatus := false
 		for _, mod := range m.Modifications {
-			b.WriteString(fmt.Sprintf("| `%s` | `%s` | `%s` |\n", mod.Path, mod.OldImport, mod.NewImport))
+			withStatus = withStatus || mod.Status != ""
+		}
+		if withStatus {
+			b.WriteString("| File | Old Import | New Import | Status |\n")
+			b.WriteString("|------|-----------|------------|--------|\n")
+		} else {
+			b.WriteString("| File | Old Import | New Import |\n")
+			b.WriteString("|------|-----------|------------|\n")
+		}
+		for _, mod := range m.Modifications {
+			b.WriteString(fmt.Sprintf("| `%s` | `%s` | `%s` |", mod.Path, mod.OldImport, mod.NewImport))
+			if withStatus {
+				status := mod.Status
+				if mod.Error != "" {
+					status += ": " + mod.Error
+				}
+				b.WriteString(" " + status + " |")
+			}
+			b.WriteString("\n")
 		}
 	}
 
@@ -843,6 +843,103 @@ func main() { fmt.Println("hi") }
 	}
 }
 
+func TestApplyImportRewrites(t *testing.T) {
+	root := t.TempDir()
+	const base = "gitlab.com/tinyland/lab/prompt-pulse"
+	files := map[string]string{
+		"cmd/ok.go": `package cmd
+
+import (
+	"fmt"
+
+	// Theme colors.
+	clr "` + base + `/display/color"
+	"` + base + `/collectors/claude"
+)
+
+func run() { fmt.Println(clr.X, claude.Y) }
+`,
+		"cmd/dup.go": `package cmd
+
+import (
+	"` + base + `/display/banner"
+	"` + base + `/display/layout"
+)
+`,
+		"cmd/broken.go": `package cmd
+
+import "` + base + `/waifu"
+
+func {
+`,
+		"cmd/clean.go": "package cmd\n\nimport \"fmt\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	plan, err := ApplyImportRewrites(root, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if read("cmd/ok.go") != files["cmd/ok.go"] {
+		t.Error("dry run modified a file")
+	}
+	if plan.Summary.TotalModifications != 5 {
+		t.Errorf("planned %d modifications, want 5", plan.Summary.TotalModifications)
+	}
+	md := plan.RenderMarkdown()
+	if !strings.Contains(md, "| Status |") || !strings.Contains(md, "`"+base+"/pkg/theme` | planned |") {
+		t.Errorf("markdown should show the planned rewrites:\n%s", md)
+	}
+
+	m, err := ApplyImportRewrites(root, false)
+	if err != nil {
+		t.Fatalf("ApplyImportRewrites: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, mod := range m.Modifications {
+		statuses[filepath.Base(mod.Path)+" "+mod.OldImport] = mod.Status
+	}
+	if s := statuses["ok.go "+base+"/collectors/claude"]; s != ModApplied {
+		t.Errorf("ok.go status = %q, want applied", s)
+	}
+	if s := statuses["dup.go "+base+"/display/banner"]; s != ModSkipped {
+		t.Errorf("dup.go status = %q, want skipped", s)
+	}
+	if s := statuses["broken.go "+base+"/waifu"]; s != ModSkipped {
+		t.Errorf("broken.go status = %q, want skipped", s)
+	}
+
+	ok := read("cmd/ok.go")
+	for _, want := range []string{`clr "` + base + `/pkg/theme"`, `"` + base + `/pkg/collectors/claude"`, "// Theme colors."} {
+		if !strings.Contains(ok, want) {
+			t.Errorf("rewritten ok.go missing %q:\n%s", want, ok)
+		}
+	}
+	for _, name := range []string{"cmd/dup.go", "cmd/broken.go", "cmd/clean.go"} {
+		if read(name) != files[name] {
+			t.Errorf("%s should be left untouched", name)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "cmd")); len(entries) != 4 {
+		t.Errorf("cmd has %d entries, want no leftover temp files", len(entries))
+	}
+}
+
 // --- Metrics tests ---
 
 func TestComputeMetrics(t *testing.T) {
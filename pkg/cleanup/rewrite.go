@@ -0,0 +1,210 @@
+package cleanup
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Modification statuses set by ApplyImportRewrites.
+const (
+	ModPlanned = "planned" // dry run: would be rewritten
+	ModApplied = "applied" // rewritten on disk
+	ModSkipped = "skipped" // left untouched; see Modification.Error
+)
+
+// ApplyImportRewrites rewrites the v1 import paths of every Go file under
+// root to their v2 replacements from clV1ImportMap. Import aliases and
+// comments are kept, and the file is re-printed in gofmt style. Each
+// rewritten import becomes a Modification in the returned manifest, with
+// Status set to ModApplied, or ModPlanned when dryRun is set and nothing
+// is written.
+//
+// A file that does not parse, or would not parse or would import a
+// package twice after the rewrite, is left untouched and its
+// modifications are reported as ModSkipped with the reason in Error.
+// Files are replaced atomically.
+func ApplyImportRewrites(root string, dryRun bool) (*CleanupManifest, error) {
+	v1Map := clV1ImportMap()
+	manifest := &CleanupManifest{}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if info.IsDir() {
+			base := filepath.Base(path)
+			if base == "vendor" || base == ".git" || base == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		mods, err := clRewriteFile(path, info.Mode().Perm(), v1Map, dryRun)
+		if err != nil {
+			return err
+		}
+		manifest.Modifications = append(manifest.Modifications, mods...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Summary = clComputeSummary(manifest)
+	return manifest, nil
+}
+
+// clRewriteFile rewrites the v1 imports of one file and returns a
+// Modification per rewritten import. Only I/O errors are returned; files
+// that cannot be rewritten safely are reported as skipped.
+func clRewriteFile(path string, perm os.FileMode, v1Map map[string]string, dryRun bool) ([]Modification, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(src, []byte(`"gitlab.com/`)) {
+		return nil, nil // cheap filter: no module import at all
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		// Report any v1 imports the line scanner finds, untouched.
+		refs, _ := clScanFileImports(path, v1Map)
+		var mods []Modification
+		for _, ref := range refs {
+			mod := clModification(path, ref.ImportPath, ref.V2Replacement)
+			mod.Status, mod.Error = ModSkipped, "does not parse: "+err.Error()
+			mods = append(mods, mod)
+		}
+		return mods, nil
+	}
+
+	var mods []Modification
+	for _, imp := range f.Imports {
+		old, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		repl := clRewriteImportPath(old, v1Map)
+		if repl == "" {
+			continue
+		}
+		imp.Path.Value = strconv.Quote(repl)
+		mods = append(mods, clModification(path, old, repl))
+	}
+	if len(mods) == 0 {
+		return nil, nil
+	}
+
+	skip := func(reason string) []Modification {
+		for i := range mods {
+			mods[i].Status, mods[i].Error = ModSkipped, reason
+		}
+		return mods
+	}
+
+	if dup := clDuplicateImport(f); dup != "" {
+		return skip(fmt.Sprintf("would import %s twice", dup)), nil
+	}
+	var out bytes.Buffer
+	if err := format.Node(&out, fset, f); err != nil {
+		return skip("cannot print rewritten file: " + err.Error()), nil
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), path, out.Bytes(), parser.ParseComments); err != nil {
+		return skip("rewritten file does not parse: " + err.Error()), nil
+	}
+
+	status := ModPlanned
+	if !dryRun {
+		if err := clWriteFileAtomic(path, out.Bytes(), perm); err != nil {
+			return nil, err
+		}
+		status = ModApplied
+	}
+	for i := range mods {
+		mods[i].Status = status
+	}
+	return mods, nil
+}
+
+// clRewriteImportPath returns the v2 path for a v1 import path, using the
+// longest matching v1 prefix, or "" if it is not a v1 import.
+func clRewriteImportPath(importPath string, v1Map map[string]string) string {
+	best := ""
+	for v1Prefix := range v1Map {
+		if (importPath == v1Prefix || strings.HasPrefix(importPath, v1Prefix+"/")) && len(v1Prefix) > len(best) {
+			best = v1Prefix
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return v1Map[best] + strings.TrimPrefix(importPath, best)
+}
+
+// clModification describes one import rewrite.
+func clModification(path, oldImport, newImport string) Modification {
+	return Modification{
+		Path:        path,
+		Description: "Update import from v1 to v2 package",
+		OldImport:   oldImport,
+		NewImport:   newImport,
+	}
+}
+
+// clDuplicateImport returns an import path that f imports more than once
+// under the same name, which two v1 packages mapping to one v2 package
+// would cause.
+func clDuplicateImport(f *ast.File) string {
+	seen := map[string]bool{}
+	for _, imp := range f.Imports {
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name == "_" {
+			continue
+		}
+		key := name + " " + imp.Path.Value
+		if seen[key] {
+			return imp.Path.Value
+		}
+		seen[key] = true
+	}
+	return ""
+}
+
+// clWriteFileAtomic replaces path with data via a temporary file in the
+// same directory, so readers never see a partial file.
+func clWriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cleanup-*.go.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
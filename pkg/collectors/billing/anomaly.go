@@ -0,0 +1,173 @@
+package billing
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultAnomalyFactor is how many times its usual daily spend a provider
+// must spend in a day to be flagged when no factor is configured.
+const DefaultAnomalyFactor = 2.0
+
+// AnomalyBaselineDays is how many days before today the usual daily spend
+// is the median of.
+const AnomalyBaselineDays = 14
+
+// anomalyMinDays is the fewest days of spend a baseline is taken from.
+const anomalyMinDays = 3
+
+// AnomalyRule sets when a provider's spend today is unusual: above its
+// baseline times Factor, or above it by more than MinDelta. A zero field
+// turns its test off, and a zero rule detects nothing.
+type AnomalyRule struct {
+	Factor   float64
+	MinDelta float64
+}
+
+// enabled reports whether r can flag anything.
+func (r AnomalyRule) enabled() bool {
+	return r.Factor > 0 || r.MinDelta > 0
+}
+
+// SpendAnomaly flags a provider spending well above its usual daily
+// amount today. Amounts are in the report currency.
+type SpendAnomaly struct {
+	// Spend is the provider's spend so far today.
+	Spend float64 `json:"spend"`
+
+	// Baseline is its median daily spend over the AnomalyBaselineDays
+	// before today.
+	Baseline float64 `json:"baseline"`
+
+	// Factor is Spend over Baseline, or 0 when Baseline is 0.
+	Factor float64 `json:"factor,omitempty"`
+}
+
+// Summary describes the anomaly for the provider name.
+// Example: "digitalocean spend 3.1× usual"
+// Example: "vultr spend $4.00 today, usually $0.00"
+func (a SpendAnomaly) Summary(name, currency string) string {
+	if a.Factor > 0 {
+		return fmt.Sprintf("%s spend %.1f× usual", name, a.Factor)
+	}
+	return fmt.Sprintf("%s spend %s today, usually %s", name, FormatAmount(a.Spend, currency), FormatAmount(a.Baseline, currency))
+}
+
+// DaySpend is a provider's spend on one calendar day.
+type DaySpend struct {
+	Day   time.Time
+	Spend float64
+}
+
+// DailySpend turns the month-to-date totals in snaps into each provider's
+// spend per calendar day in loc, oldest first, from the change in its
+// last total of each day. A total lower than the day before is a new
+// billing period, counted as spent from zero. The change across days with
+// no snapshots, such as while the daemon was off, is spread evenly over
+// them. A provider's first day has no change to measure and is left out.
+func DailySpend(snaps []Snapshot, loc *time.Location) map[string][]DaySpend {
+	sorted := append([]Snapshot(nil), snaps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	type dayEnd struct {
+		day   time.Time
+		total float64
+	}
+	ends := make(map[string][]dayEnd)
+	for _, s := range sorted {
+		day := anomalyDay(s.Timestamp, loc)
+		for name, total := range s.Providers {
+			e := ends[name]
+			if n := len(e); n > 0 && e[n-1].day.Equal(day) {
+				e[n-1].total = total
+				continue
+			}
+			ends[name] = append(e, dayEnd{day: day, total: total})
+		}
+	}
+
+	out := make(map[string][]DaySpend, len(ends))
+	for name, e := range ends {
+		var days []DaySpend
+		for i := 1; i < len(e); i++ {
+			delta := e[i].total - e[i-1].total
+			if delta < 0 {
+				delta = e[i].total
+			}
+			gap := anomalyDaysBetween(e[i-1].day, e[i].day)
+			for d := 1; d <= gap; d++ {
+				days = append(days, DaySpend{Day: e[i-1].day.AddDate(0, 0, d), Spend: delta / float64(gap)})
+			}
+		}
+		if len(days) > 0 {
+			out[name] = days
+		}
+	}
+	return out
+}
+
+// DetectAnomalies returns the providers whose spend on now's day, in
+// now's location, breaks rule against the median of their daily spend
+// over the AnomalyBaselineDays before it, keyed by provider name. A
+// provider with fewer than three days of spend in that window is not
+// judged. Today is usually partial, so its spend only grows toward the
+// threshold.
+func DetectAnomalies(snaps []Snapshot, now time.Time, rule AnomalyRule) map[string]SpendAnomaly {
+	if !rule.enabled() {
+		return nil
+	}
+	today := anomalyDay(now, now.Location())
+	from := today.AddDate(0, 0, -AnomalyBaselineDays)
+
+	out := make(map[string]SpendAnomaly)
+	for name, days := range DailySpend(snaps, now.Location()) {
+		last := days[len(days)-1]
+		if !last.Day.Equal(today) {
+			continue
+		}
+		var window []float64
+		for _, d := range days[:len(days)-1] {
+			if !d.Day.Before(from) {
+				window = append(window, d.Spend)
+			}
+		}
+		if len(window) < anomalyMinDays {
+			continue
+		}
+		baseline := anomalyMedian(window)
+		byFactor := rule.Factor > 0 && baseline > 0 && last.Spend > baseline*rule.Factor
+		byDelta := rule.MinDelta > 0 && last.Spend-baseline > rule.MinDelta
+		if !byFactor && !byDelta {
+			continue
+		}
+		a := SpendAnomaly{Spend: last.Spend, Baseline: baseline}
+		if baseline > 0 {
+			a.Factor = last.Spend / baseline
+		}
+		out[name] = a
+	}
+	return out
+}
+
+// anomalyDay returns midnight of t's calendar day in loc.
+func anomalyDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// anomalyDaysBetween counts the calendar days from a to b, both midnights,
+// rounding so a daylight saving change does not lose one.
+func anomalyDaysBetween(a, b time.Time) int {
+	return int((b.Sub(a) + 12*time.Hour) / (24 * time.Hour))
+}
+
+// anomalyMedian returns the median of v, which it sorts.
+func anomalyMedian(v []float64) float64 {
+	sort.Float64s(v)
+	n := len(v)
+	if n%2 == 1 {
+		return v[n/2]
+	}
+	return (v[n/2-1] + v[n/2]) / 2
+}
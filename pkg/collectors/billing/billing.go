@@ -3,6 +3,9 @@ package billing
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,47 +26,158 @@ type Config struct {
 	// DigitalOcean holds API credentials for DigitalOcean. Nil disables DO.
 	DigitalOcean *DOConfig
 
+	// Hetzner holds API credentials for Hetzner Cloud. Nil disables Hetzner.
+	Hetzner *HetznerConfig
+
+	// Vultr holds API credentials for Vultr. Nil disables Vultr.
+	Vultr *VultrConfig
+
+	// Currency is the ISO 4217 code the report totals spend in, and
+	// budgets are given in. Empty uses DefaultCurrency.
+	Currency string
+
+	// Rates converts the spend of providers billing in another currency.
+	// Nil converts none, and their spend is reported in subtotals.
+	Rates RateSource
+
 	// BudgetUSD is the monthly budget for percentage calculation. Zero means
 	// no budget is set, and BudgetPercent will be 0 in the report.
 	BudgetUSD float64
+
+	// Budgets maps a provider name to its monthly budget in the report
+	// currency. Providers without an entry (or with a zero budget) are left
+	// unannotated.
+	Budgets map[string]float64
+
+	// WarnPercent and CriticalPercent are the percent-of-budget thresholds
+	// for the warn and critical budget statuses. Defaults: 80 and 100.
+	WarnPercent     float64
+	CriticalPercent float64
+
+	// Periods maps a provider name to its billing period. Providers
+	// without an entry bill by the calendar month in UTC.
+	Periods map[string]Period
+
+	// HistoryDir, if set, is the directory where a snapshot of each
+	// collection is appended to the billing history file.
+	HistoryDir string
+
+	// HistoryRetention is how long history snapshots are kept. Zero uses
+	// DefaultHistoryRetention.
+	HistoryRetention time.Duration
+
+	// Anomaly flags providers whose daily spend, measured from the
+	// history, is unusual. The zero value flags none.
+	Anomaly AnomalyRule
 }
 
-// CivoConfig holds authentication details for the Civo API.
+// CivoConfig holds authentication details for the Civo API. BaseURL,
+// here and in the other provider configs, overrides the provider's API
+// endpoint, e.g. to reach it through a proxy or a test server.
 type CivoConfig struct {
-	APIKey string
-	Region string
+	APIKey  string
+	Region  string
+	BaseURL string
 }
 
 // DOConfig holds authentication details for the DigitalOcean API.
 type DOConfig struct {
 	APIToken string
+	BaseURL  string
+}
+
+// HetznerConfig holds authentication details for the Hetzner Cloud API.
+type HetznerConfig struct {
+	APIToken string
+	BaseURL  string
+}
+
+// VultrConfig holds authentication details for the Vultr API.
+type VultrConfig struct {
+	APIKey  string
+	BaseURL string
 }
 
-// BillingReport is the top-level data returned by Collect.
+// BillingReport is the top-level data returned by Collect. Its totals and
+// budgets are in Currency, despite the USD in their names; data cached
+// before reports named a currency is in DefaultCurrency.
 type BillingReport struct {
 	Providers       []ProviderBilling `json:"providers"`
+	Currency        string            `json:"currency,omitempty"`
 	TotalMonthlyUSD float64           `json:"total_monthly_usd"`
 	BudgetUSD       float64           `json:"budget_usd"`
 	BudgetPercent   float64           `json:"budget_percent"`
 	Timestamp       time.Time         `json:"timestamp"`
+
+	// Subtotals is set instead of the total when some provider's spend
+	// could not be converted to Currency: the connected providers'
+	// month-to-date spend summed per currency. ConversionError says why.
+	Subtotals       map[string]float64 `json:"subtotals,omitempty"`
+	ConversionError string             `json:"conversion_error,omitempty"`
 }
 
-// ProviderBilling contains billing data for a single cloud provider.
+// ProviderBilling contains billing data for a single cloud provider. The
+// budget fields are only set when a budget is configured for the provider.
+//
+// MonthToDate is the spend in the current billing period, from
+// PeriodStart up to PeriodEnd: the calendar month unless another period is
+// configured. It is in Currency, the report currency unless the provider's
+// spend could not be converted. A converted provider keeps its spend in
+// the currency it bills in as NativeMonthToDate and NativeCurrency;
+// Balance, Resources, and Breakdown always stay in that currency.
 type ProviderBilling struct {
-	Name        string         `json:"name"`
-	Connected   bool           `json:"connected"`
-	Error       string         `json:"error,omitempty"`
-	MonthToDate float64        `json:"month_to_date"`
-	Balance     float64        `json:"balance"`
-	Resources   []ResourceCost `json:"resources"`
+	Name              string         `json:"name"`
+	Connected         bool           `json:"connected"`
+	Error             string         `json:"error,omitempty"`
+	Currency          string         `json:"currency,omitempty"`
+	MonthToDate       float64        `json:"month_to_date"`
+	NativeCurrency    string         `json:"native_currency,omitempty"`
+	NativeMonthToDate float64        `json:"native_month_to_date,omitempty"`
+	Balance           float64        `json:"balance"`
+	Resources         []ResourceCost `json:"resources"`
+	Breakdown         []CostItem     `json:"breakdown,omitempty"`
+	BudgetUSD         float64        `json:"budget_usd,omitempty"`
+	BudgetPercent     float64        `json:"budget_percent,omitempty"`
+	BudgetStatus      string         `json:"budget_status,omitempty"`
+	PeriodStart       time.Time      `json:"period_start"`
+	PeriodEnd         time.Time      `json:"period_end"`
+
+	// Anomaly is set when the provider's spend today is unusual for it
+	// (see DetectAnomalies).
+	Anomaly *SpendAnomaly `json:"anomaly,omitempty"`
+}
+
+// Budget status levels reported in ProviderBilling.BudgetStatus.
+const (
+	BudgetOK       = "ok"
+	BudgetWarn     = "warn"
+	BudgetCritical = "critical"
+)
+
+// Default budget thresholds, in percent of the provider budget.
+const (
+	DefaultWarnPercent     = 80.0
+	DefaultCriticalPercent = 100.0
+)
+
+// CostItem is one line of a provider's month-to-date spend broken down by
+// resource type, e.g. all volume charges together. Only providers that
+// itemize their charges (Civo) report a breakdown.
+type CostItem struct {
+	Type  string  `json:"type"`
+	Count int     `json:"count"`
+	Cost  float64 `json:"cost"`
 }
 
-// ResourceCost represents the cost of a single cloud resource.
+// ResourceCost represents the cost of a single cloud resource. Plan and
+// Accrued are only set by providers that expose a per-resource breakdown.
 type ResourceCost struct {
 	Name        string  `json:"name"`
 	Type        string  `json:"type"`
+	Plan        string  `json:"plan,omitempty"`
 	MonthlyCost float64 `json:"monthly_cost"`
 	HourlyCost  float64 `json:"hourly_cost"`
+	Accrued     float64 `json:"accrued,omitempty"`
 }
 
 // Collector gathers billing data from configured cloud providers.
@@ -71,8 +185,24 @@ type Collector struct {
 	cfg      Config
 	interval time.Duration
 
-	civoClient CivoClient
-	doClient   DOClient
+	civoClient    CivoClient
+	doClient      DOClient
+	hetznerClient HetznerClient
+	vultrClient   VultrClient
+
+	// nowFunc allows tests to inject a deterministic clock.
+	nowFunc func() time.Time
+
+	// logf receives budget threshold warnings. Tests replace it to capture
+	// output.
+	logf func(format string, args ...interface{})
+
+	// alerted records the highest budget status already logged for each
+	// provider, keyed by provider name, along with its billing period.
+	alerted map[string]budgetAlert
+
+	// history is nil unless Config.HistoryDir is set.
+	history *History
 
 	mu      sync.Mutex
 	healthy bool
@@ -90,14 +220,25 @@ func New(cfg Config) *Collector {
 	c := &Collector{
 		cfg:      cfg,
 		interval: interval,
+		nowFunc:  time.Now,
+		logf:     log.Printf,
 		healthy:  true,
 	}
 
 	if cfg.Civo != nil {
-		c.civoClient = newCivoHTTPClient(cfg.Civo.APIKey, cfg.Civo.Region)
+		c.civoClient = newCivoHTTPClient(cfg.Civo.APIKey, cfg.Civo.Region, cfg.Civo.BaseURL)
 	}
 	if cfg.DigitalOcean != nil {
-		c.doClient = newDOHTTPClient(cfg.DigitalOcean.APIToken)
+		c.doClient = newDOHTTPClient(cfg.DigitalOcean.APIToken, cfg.DigitalOcean.BaseURL)
+	}
+	if cfg.Hetzner != nil {
+		c.hetznerClient = newHetznerHTTPClient(cfg.Hetzner.APIToken, cfg.Hetzner.BaseURL)
+	}
+	if cfg.Vultr != nil {
+		c.vultrClient = newVultrHTTPClient(cfg.Vultr.APIKey, cfg.Vultr.BaseURL)
+	}
+	if cfg.HistoryDir != "" {
+		c.history = NewHistory(cfg.HistoryDir, cfg.HistoryRetention)
 	}
 
 	return c
@@ -114,6 +255,8 @@ func newWithClients(cfg Config, civo CivoClient, do DOClient) *Collector {
 		interval:   interval,
 		civoClient: civo,
 		doClient:   do,
+		nowFunc:    time.Now,
+		logf:       log.Printf,
 		healthy:    true,
 	}
 }
@@ -152,58 +295,55 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("billing collect: %w", err)
 	}
 
-	type providerResult struct {
-		billing ProviderBilling
-	}
-
-	var wg sync.WaitGroup
-	var civoResult, doResult *providerResult
-
-	// Query Civo concurrently if configured.
+	// Each configured provider is queried concurrently. Results are stored
+	// by index so the report order is stable regardless of completion order.
+	var providers []func(context.Context) ProviderBilling
 	if c.civoClient != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			pb := c.collectCivo(ctx)
-			civoResult = &providerResult{billing: pb}
-		}()
+		providers = append(providers, c.collectCivo)
 	}
-
-	// Query DigitalOcean concurrently if configured.
 	if c.doClient != nil {
+		providers = append(providers, c.collectDO)
+	}
+	if c.hetznerClient != nil {
+		providers = append(providers, c.collectHetzner)
+	}
+	if c.vultrClient != nil {
+		providers = append(providers, c.collectVultr)
+	}
+
+	results := make([]ProviderBilling, len(providers))
+	var wg sync.WaitGroup
+	for i, collect := range providers {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			pb := c.collectDO(ctx)
-			doResult = &providerResult{billing: pb}
+			results[i] = collect(ctx)
 		}()
 	}
-
 	wg.Wait()
 
+	display := c.cfg.Currency
+	if display == "" {
+		display = DefaultCurrency
+	}
 	report := &BillingReport{
+		Currency:  display,
 		BudgetUSD: c.cfg.BudgetUSD,
 		Timestamp: time.Now(),
 	}
+	if err := c.convertCurrency(ctx, display, results); err != nil {
+		report.ConversionError = err.Error()
+	}
 
-	configuredCount := 0
+	configuredCount := len(results)
 	failedCount := 0
+	subtotals := make(map[string]float64)
 
-	if civoResult != nil {
-		configuredCount++
-		report.Providers = append(report.Providers, civoResult.billing)
-		if civoResult.billing.Connected {
-			report.TotalMonthlyUSD += civoResult.billing.MonthToDate
-		} else {
-			failedCount++
-		}
-	}
-
-	if doResult != nil {
-		configuredCount++
-		report.Providers = append(report.Providers, doResult.billing)
-		if doResult.billing.Connected {
-			report.TotalMonthlyUSD += doResult.billing.MonthToDate
+	for _, pb := range results {
+		c.applyBudget(&pb, display)
+		report.Providers = append(report.Providers, pb)
+		if pb.Connected {
+			subtotals[pb.Currency] += pb.MonthToDate
 		} else {
 			failedCount++
 		}
@@ -214,8 +354,16 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 		report.Providers = []ProviderBilling{}
 	}
 
+	// Spend left in another currency is reported per currency rather than
+	// summed into a misleading total.
+	if _, other := subtotals[display]; len(subtotals) > 1 || (len(subtotals) == 1 && !other) {
+		report.Subtotals = subtotals
+	} else {
+		report.TotalMonthlyUSD = subtotals[display]
+	}
+
 	// Calculate budget percentage.
-	if c.cfg.BudgetUSD > 0 {
+	if c.cfg.BudgetUSD > 0 && report.Subtotals == nil {
 		report.BudgetPercent = (report.TotalMonthlyUSD / c.cfg.BudgetUSD) * 100
 	}
 
@@ -226,28 +374,64 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 		c.setHealthy(true)
 	}
 
+	// Record history only when at least one provider reported and the
+	// spend could be totalled, so outages do not show up as a drop to zero
+	// in the trend.
+	if c.history != nil && failedCount < configuredCount && report.Subtotals == nil {
+		if err := c.history.Append(SnapshotFromReport(report)); err != nil {
+			c.logf("billing: %v", err)
+		}
+		c.flagAnomalies(report)
+	}
+
 	return report, nil
 }
 
+// flagAnomalies marks the connected providers in report whose spend today
+// is unusual against the history.
+func (c *Collector) flagAnomalies(report *BillingReport) {
+	if !c.cfg.Anomaly.enabled() {
+		return
+	}
+	snaps, err := c.history.Load()
+	if err != nil {
+		c.logf("billing: %v", err)
+		return
+	}
+	anomalies := DetectAnomalies(snaps, c.nowFunc(), c.cfg.Anomaly)
+	for i := range report.Providers {
+		p := &report.Providers[i]
+		if a, ok := anomalies[p.Name]; ok && p.Connected {
+			p.Anomaly = &a
+		}
+	}
+}
+
 // collectCivo queries the Civo API and returns a ProviderBilling result.
 func (c *Collector) collectCivo(ctx context.Context) ProviderBilling {
 	pb := ProviderBilling{
 		Name:      "civo",
+		Currency:  "USD",
 		Resources: []ResourceCost{},
 	}
+	now := c.nowFunc()
+	pb.PeriodStart, pb.PeriodEnd = c.periodFor(pb.Name).Bounds(now)
 
-	// Fetch charges for month-to-date spend.
-	charges, err := c.civoClient.GetCharges(ctx)
+	// Fetch charges for the billing period's spend so far.
+	charges, err := c.civoClient.GetCharges(ctx, pb.PeriodStart, now)
 	if err != nil {
 		pb.Error = err.Error()
 		return pb
 	}
 
+	var items []CivoCharge
 	if charges != nil {
-		for _, charge := range charges.Items {
+		items = charges.Items
+		for _, charge := range items {
 			pb.MonthToDate += charge.TotalCost
 		}
 	}
+	pb.Breakdown = civoBreakdown(items)
 
 	// Fetch Kubernetes clusters.
 	k8s, err := c.civoClient.GetKubernetes(ctx)
@@ -257,11 +441,13 @@ func (c *Collector) collectCivo(ctx context.Context) ProviderBilling {
 	}
 
 	if k8s != nil {
+		accrued := civoClusterAccrued(items, k8s.Items)
 		for _, cluster := range k8s.Items {
 			pb.Resources = append(pb.Resources, ResourceCost{
 				Name:        cluster.Name,
 				Type:        "kubernetes",
 				MonthlyCost: cluster.MonthlyCost,
+				Accrued:     accrued[cluster.ID],
 			})
 		}
 	}
@@ -287,12 +473,85 @@ func (c *Collector) collectCivo(ctx context.Context) ProviderBilling {
 	return pb
 }
 
+// civoChargeType maps a Civo charge code such as "instance-g3.small" or
+// "volume" to the resource type it is broken down under.
+func civoChargeType(code string) string {
+	code = strings.ToLower(code)
+	switch {
+	case strings.HasPrefix(code, "k8s"), strings.HasPrefix(code, "k3s"), strings.HasPrefix(code, "kubernetes"):
+		return "kubernetes"
+	case strings.HasPrefix(code, "instance"):
+		return "instance"
+	case strings.HasPrefix(code, "volume"):
+		return "volume"
+	case strings.HasPrefix(code, "loadbalancer"), strings.HasPrefix(code, "load_balancer"):
+		return "load_balancer"
+	case strings.HasPrefix(code, "objectstore"), strings.HasPrefix(code, "object_store"):
+		return "object_store"
+	case strings.HasPrefix(code, "ip"), strings.HasPrefix(code, "network"), strings.HasPrefix(code, "bandwidth"):
+		return "network"
+	}
+	return "other"
+}
+
+// civoBreakdown totals charges by resource type, most expensive first.
+func civoBreakdown(charges []CivoCharge) []CostItem {
+	index := make(map[string]int)
+	var out []CostItem
+	for _, ch := range charges {
+		t := civoChargeType(ch.Code)
+		i, ok := index[t]
+		if !ok {
+			i = len(out)
+			index[t] = i
+			out = append(out, CostItem{Type: t})
+		}
+		out[i].Count++
+		out[i].Cost += ch.TotalCost
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Cost > out[j].Cost })
+	return out
+}
+
+// civoClusterAccrued attributes charges to the Kubernetes clusters they
+// belong to and returns each cluster's month-to-date cost by cluster ID.
+// Civo labels the charges for a cluster's nodes, volumes, and load
+// balancers with resource names that embed the cluster name (e.g.
+// "k3s-prod-a1b2-node-pool-c3d4"), so a charge belongs to the cluster
+// whose name or ID its label contains; the longest match wins when one
+// cluster's name is a prefix of another's.
+func civoClusterAccrued(charges []CivoCharge, clusters []CivoK8sCluster) map[string]float64 {
+	accrued := make(map[string]float64)
+	for _, ch := range charges {
+		label := strings.ToLower(ch.Label)
+		best, bestLen := "", 0
+		for _, cl := range clusters {
+			for _, key := range []string{cl.Name, cl.ID} {
+				if key != "" && len(key) > bestLen && strings.Contains(label, strings.ToLower(key)) {
+					best, bestLen = cl.ID, len(key)
+				}
+			}
+		}
+		if bestLen > 0 {
+			accrued[best] += ch.TotalCost
+		}
+	}
+	return accrued
+}
+
 // collectDO queries the DigitalOcean API and returns a ProviderBilling result.
+// DigitalOcean only reports calendar month-to-date spend, so for another
+// billing period spend is estimated from each droplet's hourly price and
+// how long it has existed in the period, capped at its monthly price.
 func (c *Collector) collectDO(ctx context.Context) ProviderBilling {
 	pb := ProviderBilling{
 		Name:      "digitalocean",
+		Currency:  "USD",
 		Resources: []ResourceCost{},
 	}
+	period := c.periodFor(pb.Name)
+	now := c.nowFunc()
+	pb.PeriodStart, pb.PeriodEnd = period.Bounds(now)
 
 	// Fetch account balance (month-to-date and credits).
 	balance, err := c.doClient.GetBalance(ctx)
@@ -307,7 +566,9 @@ func (c *Collector) collectDO(ctx context.Context) ProviderBilling {
 			pb.Error = fmt.Sprintf("parsing month-to-date balance: %v", err)
 			return pb
 		}
-		pb.MonthToDate = mtd
+		if period.Calendar() {
+			pb.MonthToDate = mtd
+		}
 
 		acctBal, err := balance.ParseAccountBalance()
 		if err != nil {
@@ -345,11 +606,95 @@ func (c *Collector) collectDO(ctx context.Context) ProviderBilling {
 
 	if droplets != nil {
 		for _, d := range droplets.Droplets {
-			pb.Resources = append(pb.Resources, ResourceCost{
+			rc := ResourceCost{
 				Name:        d.Name,
 				Type:        "droplet",
 				MonthlyCost: d.Size.PriceMonthly,
 				HourlyCost:  d.Size.PriceHourly,
+			}
+			if !period.Calendar() {
+				rc.Accrued = accruedSince(rc.HourlyCost, rc.MonthlyCost, d.Created, pb.PeriodStart, now)
+				pb.MonthToDate += rc.Accrued
+			}
+			pb.Resources = append(pb.Resources, rc)
+		}
+	}
+
+	pb.Connected = true
+	return pb
+}
+
+// collectHetzner queries the Hetzner Cloud API and returns a ProviderBilling
+// result. Hetzner has no month-to-date billing endpoint, so spend is
+// estimated from each resource's hourly price and how long it has existed
+// this billing period, capped at the monthly price as Hetzner does when
+// invoicing.
+// Prices are in the account currency, EUR unless the pricing endpoint
+// names another.
+func (c *Collector) collectHetzner(ctx context.Context) ProviderBilling {
+	pb := ProviderBilling{
+		Name:      "hetzner",
+		Currency:  "EUR",
+		Resources: []ResourceCost{},
+	}
+
+	now := c.nowFunc()
+	pb.PeriodStart, pb.PeriodEnd = c.periodFor(pb.Name).Bounds(now)
+
+	servers, err := c.hetznerClient.GetServers(ctx)
+	if err != nil {
+		pb.Error = err.Error()
+		return pb
+	}
+
+	for _, srv := range servers {
+		price, ok := srv.ServerType.priceFor(srv.Datacenter.Location.Name)
+		if !ok {
+			continue
+		}
+		hourly, _ := parseHetznerAmount(price.PriceHourly.Gross)
+		monthly, _ := parseHetznerAmount(price.PriceMonthly.Gross)
+
+		accrued := accruedSince(hourly, monthly, srv.Created, pb.PeriodStart, now)
+		pb.MonthToDate += accrued
+		pb.Resources = append(pb.Resources, ResourceCost{
+			Name:        srv.Name,
+			Type:        "server",
+			Plan:        srv.ServerType.Name,
+			MonthlyCost: monthly,
+			HourlyCost:  hourly,
+			Accrued:     accrued,
+		})
+	}
+
+	volumes, err := c.hetznerClient.GetVolumes(ctx)
+	if err != nil {
+		pb.Error = err.Error()
+		return pb
+	}
+
+	if len(volumes) > 0 {
+		pricing, err := c.hetznerClient.GetPricing(ctx)
+		if err != nil {
+			pb.Error = err.Error()
+			return pb
+		}
+		if pricing.Currency != "" {
+			pb.Currency = pricing.Currency
+		}
+		perGB, _ := parseHetznerAmount(pricing.Volume.PricePerGBMonth.Gross)
+
+		for _, vol := range volumes {
+			monthly := perGB * float64(vol.Size)
+			hourly := monthly / hetznerHoursPerMonth
+			accrued := accruedSince(hourly, monthly, vol.Created, pb.PeriodStart, now)
+			pb.MonthToDate += accrued
+			pb.Resources = append(pb.Resources, ResourceCost{
+				Name:        vol.Name,
+				Type:        "volume",
+				MonthlyCost: monthly,
+				HourlyCost:  hourly,
+				Accrued:     accrued,
 			})
 		}
 	}
@@ -357,3 +702,191 @@ func (c *Collector) collectDO(ctx context.Context) ProviderBilling {
 	pb.Connected = true
 	return pb
 }
+
+// hetznerHoursPerMonth is the hour count Hetzner uses to derive hourly
+// prices from monthly ones.
+const hetznerHoursPerMonth = 730
+
+// accruedSince returns the charge accrued by a resource between the later
+// of created and periodStart, and now. The result never exceeds monthly.
+func accruedSince(hourly, monthly float64, created, periodStart, now time.Time) float64 {
+	from := periodStart
+	if created.After(from) {
+		from = created
+	}
+	if !now.After(from) {
+		return 0
+	}
+	accrued := hourly * now.Sub(from).Hours()
+	if monthly > 0 && accrued > monthly {
+		accrued = monthly
+	}
+	return accrued
+}
+
+// collectVultr queries the Vultr API and returns a ProviderBilling result.
+// Month-to-date spend is the account's pending charges. Each instance is
+// listed with its plan and the charges it has accrued this billing period,
+// estimated from the plan's monthly price the same way Vultr bills: hourly,
+// capped at vultrHoursPerMonth hours. Pending charges cover the calendar
+// month, so for another period spend is the instances' accrued charges.
+func (c *Collector) collectVultr(ctx context.Context) ProviderBilling {
+	pb := ProviderBilling{
+		Name:      "vultr",
+		Currency:  "USD",
+		Resources: []ResourceCost{},
+	}
+	period := c.periodFor(pb.Name)
+	now := c.nowFunc()
+	pb.PeriodStart, pb.PeriodEnd = period.Bounds(now)
+
+	account, err := c.vultrClient.GetAccount(ctx)
+	if err != nil {
+		pb.Error = err.Error()
+		return pb
+	}
+	if period.Calendar() {
+		pb.MonthToDate = account.PendingCharges
+	}
+	pb.Balance = account.Balance
+
+	instances, err := c.vultrClient.GetInstances(ctx)
+	if err != nil {
+		pb.Error = err.Error()
+		return pb
+	}
+
+	if len(instances) > 0 {
+		plans, err := c.vultrClient.GetPlans(ctx)
+		if err != nil {
+			pb.Error = err.Error()
+			return pb
+		}
+		monthlyByPlan := make(map[string]float64, len(plans))
+		for _, p := range plans {
+			monthlyByPlan[p.ID] = p.MonthlyCost
+		}
+
+		for _, inst := range instances {
+			monthly := monthlyByPlan[inst.Plan]
+			hourly := monthly / vultrHoursPerMonth
+			name := inst.Label
+			if name == "" {
+				name = inst.ID
+			}
+			accrued := accruedSince(hourly, monthly, inst.DateCreated, pb.PeriodStart, now)
+			if !period.Calendar() {
+				pb.MonthToDate += accrued
+			}
+			pb.Resources = append(pb.Resources, ResourceCost{
+				Name:        name,
+				Type:        "instance",
+				Plan:        inst.Plan,
+				MonthlyCost: monthly,
+				HourlyCost:  hourly,
+				Accrued:     accrued,
+			})
+		}
+	}
+
+	pb.Connected = true
+	return pb
+}
+
+// vultrHoursPerMonth is the number of hours after which Vultr stops billing
+// an instance for the rest of the month.
+const vultrHoursPerMonth = 672
+
+// budgetAlert is the last budget status logged for a provider.
+type budgetAlert struct {
+	period string // the period's start, "2006-01-02"
+	status string
+}
+
+// applyBudget annotates pb with its configured budget, prorated to its
+// billing period, percent used, and status level, and logs a warning the
+// first time the provider reaches each threshold within a billing period. Providers without a budget, providers
+// that failed to report, and providers whose spend is not in the display
+// currency the budget is in are left untouched.
+func (c *Collector) applyBudget(pb *ProviderBilling, display string) {
+	budget := c.cfg.Budgets[pb.Name] * c.periodFor(pb.Name).BudgetFactor()
+	if budget <= 0 || !pb.Connected || pb.Currency != display {
+		return
+	}
+
+	pb.BudgetUSD = budget
+	pb.BudgetPercent = pb.MonthToDate / budget * 100
+	pb.BudgetStatus = budgetStatus(pb.BudgetPercent, c.cfg.WarnPercent, c.cfg.CriticalPercent)
+
+	if pb.BudgetStatus == BudgetOK {
+		return
+	}
+
+	period := pb.PeriodStart.Format(time.DateOnly)
+
+	c.mu.Lock()
+	if c.alerted == nil {
+		c.alerted = make(map[string]budgetAlert)
+	}
+	prev := c.alerted[pb.Name]
+	escalated := prev.period != period || budgetRank(pb.BudgetStatus) > budgetRank(prev.status)
+	if escalated {
+		c.alerted[pb.Name] = budgetAlert{period: period, status: pb.BudgetStatus}
+	}
+	c.mu.Unlock()
+
+	if escalated {
+		c.logf("billing: %s spend %s is %.0f%% of %s budget (%s)",
+			pb.Name, FormatAmount(pb.MonthToDate, display), pb.BudgetPercent, FormatAmount(budget, display), pb.BudgetStatus)
+	}
+}
+
+// budgetStatus maps a percent-of-budget figure onto a status level. Zero
+// thresholds fall back to DefaultWarnPercent and DefaultCriticalPercent.
+func budgetStatus(percent, warn, critical float64) string {
+	if warn <= 0 {
+		warn = DefaultWarnPercent
+	}
+	if critical <= 0 {
+		critical = DefaultCriticalPercent
+	}
+	switch {
+	case percent >= critical:
+		return BudgetCritical
+	case percent >= warn:
+		return BudgetWarn
+	default:
+		return BudgetOK
+	}
+}
+
+// budgetRank orders budget statuses from least to most severe.
+func budgetRank(status string) int {
+	switch status {
+	case BudgetWarn:
+		return 1
+	case BudgetCritical:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// WorstBudgetStatus returns the most severe per-provider budget status in
+// the report, or "" when no provider has a budget configured.
+func (r *BillingReport) WorstBudgetStatus() string {
+	worst := ""
+	for _, p := range r.Providers {
+		switch p.BudgetStatus {
+		case BudgetCritical:
+			return BudgetCritical
+		case BudgetWarn:
+			worst = BudgetWarn
+		case BudgetOK:
+			if worst == "" {
+				worst = BudgetOK
+			}
+		}
+	}
+	return worst
+}
@@ -3,9 +3,18 @@ package billing
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
 )
 
 // ---------------------------------------------------------------------------
@@ -22,7 +31,7 @@ type mockCivoClient struct {
 	instancesErr error
 }
 
-func (m *mockCivoClient) GetCharges(ctx context.Context) (*CivoChargesResponse, error) {
+func (m *mockCivoClient) GetCharges(ctx context.Context, from, to time.Time) (*CivoChargesResponse, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -136,6 +145,119 @@ func buildDOMock() *mockDOClient {
 	}
 }
 
+type mockHetznerClient struct {
+	servers []HetznerServer
+	volumes []HetznerVolume
+	pricing *HetznerPricing
+
+	serversErr error
+	volumesErr error
+	pricingErr error
+}
+
+func (m *mockHetznerClient) GetServers(ctx context.Context) ([]HetznerServer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.servers, m.serversErr
+}
+
+func (m *mockHetznerClient) GetVolumes(ctx context.Context) ([]HetznerVolume, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.volumes, m.volumesErr
+}
+
+func (m *mockHetznerClient) GetPricing(ctx context.Context) (*HetznerPricing, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.pricing, m.pricingErr
+}
+
+// buildHetznerMock returns one CX22 server in fsn1 created before the month
+// started and one 10 GB volume created on the 11th.
+func buildHetznerMock() *mockHetznerClient {
+	srv := HetznerServer{
+		ID:      1,
+		Name:    "hz-web-01",
+		Created: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		ServerType: HetznerServerType{
+			Name: "cx22",
+			Prices: []HetznerLocationPrice{
+				{
+					Location:     "nbg1",
+					PriceHourly:  HetznerAmount{Gross: "0.0100"},
+					PriceMonthly: HetznerAmount{Gross: "6.0000"},
+				},
+				{
+					Location:     "fsn1",
+					PriceHourly:  HetznerAmount{Gross: "0.0070"},
+					PriceMonthly: HetznerAmount{Gross: "4.5100"},
+				},
+			},
+		},
+	}
+	srv.Datacenter.Location.Name = "fsn1"
+
+	pricing := &HetznerPricing{Currency: "EUR"}
+	pricing.Volume.PricePerGBMonth = HetznerAmount{Gross: "0.0584"}
+
+	return &mockHetznerClient{
+		servers: []HetznerServer{srv},
+		volumes: []HetznerVolume{
+			{ID: 7, Name: "hz-data", Size: 10, Created: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		},
+		pricing: pricing,
+	}
+}
+
+type mockVultrClient struct {
+	account   *VultrAccount
+	instances []VultrInstance
+	plans     []VultrPlan
+
+	accountErr   error
+	instancesErr error
+	plansErr     error
+}
+
+func (m *mockVultrClient) GetAccount(ctx context.Context) (*VultrAccount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.account, m.accountErr
+}
+
+func (m *mockVultrClient) GetInstances(ctx context.Context) ([]VultrInstance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.instances, m.instancesErr
+}
+
+func (m *mockVultrClient) GetPlans(ctx context.Context) ([]VultrPlan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.plans, m.plansErr
+}
+
+func buildVultrMock() *mockVultrClient {
+	return &mockVultrClient{
+		account: &VultrAccount{Balance: -50.00, PendingCharges: 12.34},
+		instances: []VultrInstance{
+			{ID: "a1", Label: "edge-1", Plan: "vc2-1c-1gb", DateCreated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: "b2", Label: "", Plan: "vc2-2c-4gb", DateCreated: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		},
+		plans: []VultrPlan{
+			{ID: "vc2-1c-1gb", MonthlyCost: 6.72},
+			{ID: "vc2-2c-4gb", MonthlyCost: 20.16},
+		},
+	}
+}
+
 func floatEqual(a, b float64) bool {
 	return math.Abs(a-b) < 0.001
 }
@@ -328,6 +450,55 @@ func TestCollect_BothProviders(t *testing.T) {
 	}
 }
 
+func TestCollect_CivoBreakdown(t *testing.T) {
+	civo := buildCivoMock()
+	civo.charges = &CivoChargesResponse{Items: []CivoCharge{
+		{Code: "instance-g4s.kube.medium", Label: "k3s-prod-a1b2-node-pool-c3d4", TotalCost: 8.00},
+		{Code: "instance-g4s.kube.medium", Label: "k3s-prod-a1b2-node-pool-e5f6", TotalCost: 8.00},
+		{Code: "volume", Label: "pvc-k3s-prod-data", SizeGB: 20, TotalCost: 1.20},
+		{Code: "loadbalancer", Label: "k3s-prod-ingress", TotalCost: 3.00},
+		{Code: "volume", Label: "k3s-prod-2-scratch", TotalCost: 0.40},
+		{Code: "instance-g3.small", Label: "web-01", TotalCost: 5.00},
+		{Code: "objectstore", Label: "backups", TotalCost: 0.50},
+	}}
+	civo.k8s = &CivoK8sResponse{Items: []CivoK8sCluster{
+		{ID: "c-1", Name: "k3s-prod", MonthlyCost: 36.00},
+		{ID: "c-2", Name: "k3s-prod-2", MonthlyCost: 12.00},
+	}}
+	c := newWithClients(Config{Civo: &CivoConfig{APIKey: "k"}}, civo, nil)
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	prov := result.(*BillingReport).Providers[0]
+
+	want := []CostItem{
+		{Type: "instance", Count: 3, Cost: 21.00},
+		{Type: "load_balancer", Count: 1, Cost: 3.00},
+		{Type: "volume", Count: 2, Cost: 1.60},
+		{Type: "object_store", Count: 1, Cost: 0.50},
+	}
+	if len(prov.Breakdown) != len(want) {
+		t.Fatalf("Breakdown = %+v, want %+v", prov.Breakdown, want)
+	}
+	for i, w := range want {
+		got := prov.Breakdown[i]
+		if got.Type != w.Type || got.Count != w.Count || !floatEqual(got.Cost, w.Cost) {
+			t.Errorf("Breakdown[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+
+	// k3s-prod-2's scratch volume goes to it, not to k3s-prod, whose
+	// name it also contains.
+	accrued := map[string]float64{"k3s-prod": 20.20, "k3s-prod-2": 0.40}
+	for _, r := range prov.Resources {
+		if want, ok := accrued[r.Name]; ok && !floatEqual(r.Accrued, want) {
+			t.Errorf("%s Accrued = %.2f, want %.2f", r.Name, r.Accrued, want)
+		}
+	}
+}
+
 func TestCollect_CivoError_DOStillWorks(t *testing.T) {
 	civo := &mockCivoClient{
 		chargesErr: errors.New("civo API unavailable"),
@@ -839,6 +1010,880 @@ type collectorIface interface {
 
 var _ collectorIface = (*Collector)(nil)
 
+func TestCollect_HetznerOnly(t *testing.T) {
+	c := newWithClients(Config{Currency: "EUR", Hetzner: &HetznerConfig{APIToken: "token"}}, nil, nil)
+	c.hetznerClient = buildHetznerMock()
+	// 10 days into March: 240h for the server, 0h for the volume.
+	c.nowFunc = func() time.Time { return time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC) }
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	if len(report.Providers) != 1 {
+		t.Fatalf("len(Providers) = %d, want 1", len(report.Providers))
+	}
+	prov := report.Providers[0]
+
+	if prov.Name != "hetzner" {
+		t.Errorf("Name = %q, want %q", prov.Name, "hetzner")
+	}
+	if !prov.Connected {
+		t.Fatalf("Connected = false, Error = %q", prov.Error)
+	}
+	if want := 0.0070 * 240; !floatEqual(prov.MonthToDate, want) {
+		t.Errorf("MonthToDate = %f, want %f", prov.MonthToDate, want)
+	}
+	if len(prov.Resources) != 2 {
+		t.Fatalf("len(Resources) = %d, want 2", len(prov.Resources))
+	}
+	if r := prov.Resources[0]; r.Type != "server" || !floatEqual(r.MonthlyCost, 4.51) {
+		t.Errorf("server resource = %+v, want fsn1 price 4.51", r)
+	}
+	if r := prov.Resources[1]; r.Type != "volume" || !floatEqual(r.MonthlyCost, 0.584) {
+		t.Errorf("volume resource = %+v, want 0.584/month", r)
+	}
+	if !floatEqual(report.TotalMonthlyUSD, prov.MonthToDate) {
+		t.Errorf("TotalMonthlyUSD = %f, want %f", report.TotalMonthlyUSD, prov.MonthToDate)
+	}
+}
+
+func TestCollect_HetznerError_ExcludedFromTotal(t *testing.T) {
+	c := newWithClients(Config{
+		Civo:    &CivoConfig{APIKey: "key"},
+		Hetzner: &HetznerConfig{APIToken: "bad"},
+	}, buildCivoMock(), nil)
+	c.hetznerClient = &mockHetznerClient{
+		serversErr: errors.New("hetzner API rejected token (check HCLOUD_TOKEN): 401"),
+	}
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	if len(report.Providers) != 2 {
+		t.Fatalf("len(Providers) = %d, want 2", len(report.Providers))
+	}
+	hz := report.Providers[1]
+	if hz.Name != "hetzner" || hz.Connected {
+		t.Errorf("hetzner provider = %+v, want disconnected", hz)
+	}
+	if hz.Error == "" {
+		t.Error("hetzner.Error is empty, want token error")
+	}
+	if !floatEqual(report.TotalMonthlyUSD, report.Providers[0].MonthToDate) {
+		t.Errorf("TotalMonthlyUSD = %f, want civo only (%f)", report.TotalMonthlyUSD, report.Providers[0].MonthToDate)
+	}
+	if !c.Healthy() {
+		t.Error("Healthy() = false, want true while one provider still succeeds")
+	}
+}
+
+func TestCollect_VultrOnly(t *testing.T) {
+	c := newWithClients(Config{Vultr: &VultrConfig{APIKey: "key"}}, nil, nil)
+	c.vultrClient = buildVultrMock()
+	c.nowFunc = func() time.Time { return time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC) }
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	if len(report.Providers) != 1 {
+		t.Fatalf("len(Providers) = %d, want 1", len(report.Providers))
+	}
+	prov := report.Providers[0]
+
+	if prov.Name != "vultr" || !prov.Connected {
+		t.Fatalf("provider = %+v, want connected vultr", prov)
+	}
+	if !floatEqual(prov.MonthToDate, 12.34) {
+		t.Errorf("MonthToDate = %f, want 12.34", prov.MonthToDate)
+	}
+	if !floatEqual(prov.Balance, -50.00) {
+		t.Errorf("Balance = %f, want -50.00", prov.Balance)
+	}
+	if len(prov.Resources) != 2 {
+		t.Fatalf("len(Resources) = %d, want 2", len(prov.Resources))
+	}
+
+	// 240h at 6.72/672 per hour.
+	edge := prov.Resources[0]
+	if edge.Name != "edge-1" || edge.Plan != "vc2-1c-1gb" || !floatEqual(edge.Accrued, 2.40) {
+		t.Errorf("edge-1 = %+v, want plan vc2-1c-1gb accrued 2.40", edge)
+	}
+	// Unlabelled instances fall back to their ID; 24h at 20.16/672 per hour.
+	unlabelled := prov.Resources[1]
+	if unlabelled.Name != "b2" || !floatEqual(unlabelled.Accrued, 0.72) {
+		t.Errorf("b2 = %+v, want accrued 0.72", unlabelled)
+	}
+}
+
+func TestCollect_VultrError_ExcludedFromTotal(t *testing.T) {
+	c := newWithClients(Config{
+		DigitalOcean: &DOConfig{APIToken: "token"},
+		Vultr:        &VultrConfig{APIKey: "bad"},
+	}, nil, buildDOMock())
+	c.vultrClient = &mockVultrClient{
+		accountErr: errors.New("vultr API rejected key (check VULTR_API_KEY): 401"),
+	}
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	if len(report.Providers) != 2 {
+		t.Fatalf("len(Providers) = %d, want 2", len(report.Providers))
+	}
+	vultr := report.Providers[1]
+	if vultr.Name != "vultr" || vultr.Connected || vultr.Error == "" {
+		t.Errorf("vultr provider = %+v, want disconnected with error", vultr)
+	}
+	if !floatEqual(report.TotalMonthlyUSD, 45.67) {
+		t.Errorf("TotalMonthlyUSD = %f, want DO only (45.67)", report.TotalMonthlyUSD)
+	}
+}
+
+func TestVultrHTTPClient_CursorPagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{"instances":[{"id":"a","label":"one"}],"meta":{"links":{"next":"c2"}}}`)
+		default:
+			fmt.Fprint(w, `{"instances":[{"id":"b","label":"two"}],"meta":{"links":{"next":""}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := newVultrHTTPClient("key", srv.URL)
+
+	instances, err := c.GetInstances(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstances() error: %v", err)
+	}
+	if len(instances) != 2 || instances[1].Label != "two" {
+		t.Errorf("instances = %+v, want both pages", instances)
+	}
+}
+
+func TestCollect_ProviderBudgets(t *testing.T) {
+	c := newWithClients(Config{
+		Civo:         &CivoConfig{APIKey: "key"},
+		DigitalOcean: &DOConfig{APIToken: "token"},
+		Budgets:      map[string]float64{"digitalocean": 50},
+	}, buildCivoMock(), buildDOMock())
+	var logs []string
+	c.logf = func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+
+	civo, do := report.Providers[0], report.Providers[1]
+	if civo.BudgetUSD != 0 || civo.BudgetStatus != "" {
+		t.Errorf("civo budget fields = %v/%q, want unset", civo.BudgetUSD, civo.BudgetStatus)
+	}
+	// 45.67 / 50 = 91.34% with default thresholds -> warn.
+	if do.BudgetUSD != 50 || !floatEqual(do.BudgetPercent, 91.34) {
+		t.Errorf("do budget = %v (%.2f%%), want 50 (91.34%%)", do.BudgetUSD, do.BudgetPercent)
+	}
+	if do.BudgetStatus != BudgetWarn {
+		t.Errorf("do.BudgetStatus = %q, want %q", do.BudgetStatus, BudgetWarn)
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "digitalocean") {
+		t.Fatalf("logs = %q, want one digitalocean warning", logs)
+	}
+
+	// A second collection at the same level does not log again.
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("logs = %q, want no repeat warning in the same period", logs)
+	}
+
+	// Escalating to critical logs once more.
+	c.cfg.Budgets["digitalocean"] = 40
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if len(logs) != 2 || !strings.Contains(logs[1], BudgetCritical) {
+		t.Errorf("logs = %q, want critical warning", logs)
+	}
+
+	// A new billing period resets the alert state.
+	c.nowFunc = func() time.Time { return time.Now().AddDate(0, 1, 0) }
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Errorf("logs = %q, want warning repeated in new period", logs)
+	}
+}
+
+func TestBudgetStatus(t *testing.T) {
+	tests := []struct {
+		percent, warn, critical float64
+		want                    string
+	}{
+		{50, 0, 0, BudgetOK},
+		{80, 0, 0, BudgetWarn},
+		{100, 0, 0, BudgetCritical},
+		{70, 60, 90, BudgetWarn},
+		{95, 60, 90, BudgetCritical},
+	}
+	for _, tt := range tests {
+		if got := budgetStatus(tt.percent, tt.warn, tt.critical); got != tt.want {
+			t.Errorf("budgetStatus(%v, %v, %v) = %q, want %q", tt.percent, tt.warn, tt.critical, got, tt.want)
+		}
+	}
+}
+
+func TestHistory_AppendLoad(t *testing.T) {
+	h := NewHistory(t.TempDir(), 0)
+	now := time.Now()
+
+	for i, total := range []float64{10, 12.5, 15} {
+		s := Snapshot{
+			Timestamp: now.Add(time.Duration(i-3) * time.Hour),
+			TotalUSD:  total,
+			Providers: map[string]float64{"civo": total},
+		}
+		if err := h.Append(s); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	snaps, err := h.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(snaps) != 3 {
+		t.Fatalf("len(snaps) = %d, want 3", len(snaps))
+	}
+	if snaps[2].TotalUSD != 15 || snaps[2].Providers["civo"] != 15 {
+		t.Errorf("last snapshot = %+v, want total 15", snaps[2])
+	}
+}
+
+func TestHistory_LoadMissingFile(t *testing.T) {
+	snaps, err := NewHistory(t.TempDir(), 0).Load()
+	if err != nil || len(snaps) != 0 {
+		t.Errorf("Load() = %v, %v; want empty, nil", snaps, err)
+	}
+}
+
+func TestHistory_Retention(t *testing.T) {
+	dir := t.TempDir()
+	h := NewHistory(dir, 24*time.Hour)
+	now := time.Now()
+
+	if err := h.Append(Snapshot{Timestamp: now.Add(-48 * time.Hour), TotalUSD: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Append(Snapshot{Timestamp: now, TotalUSD: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(h.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Errorf("history has %d lines after prune, want 1:\n%s", n, data)
+	}
+}
+
+func TestHistory_ConcurrentAppend(t *testing.T) {
+	dir := t.TempDir()
+	const writers, each = 8, 25
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each writer uses its own History, as separate processes would.
+			h := NewHistory(dir, 0)
+			for i := 0; i < each; i++ {
+				if err := h.Append(Snapshot{Timestamp: time.Now(), TotalUSD: float64(i)}); err != nil {
+					t.Errorf("Append() error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	snaps, err := NewHistory(dir, 0).Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(snaps) != writers*each {
+		t.Errorf("len(snaps) = %d, want %d", len(snaps), writers*each)
+	}
+}
+
+func TestVelocity(t *testing.T) {
+	now := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	snaps := []Snapshot{
+		{Timestamp: now.AddDate(0, 0, -10), TotalUSD: 5},  // outside 7d window
+		{Timestamp: now.AddDate(0, 0, -4), TotalUSD: 40},  // window start
+		{Timestamp: now.AddDate(0, 0, -2), TotalUSD: 46},  //
+		{Timestamp: now, TotalUSD: 52},                    // latest
+		{Timestamp: now.AddDate(0, 0, 1), TotalUSD: 1000}, // future, ignored
+	}
+
+	perDay, projected, ok := Velocity(snaps, now)
+	if !ok {
+		t.Fatal("Velocity() ok = false, want true")
+	}
+	if !floatEqual(perDay, 3) {
+		t.Errorf("perDay = %f, want 3", perDay)
+	}
+	// 12 days left in March at $3/day.
+	if !floatEqual(projected, 52+36) {
+		t.Errorf("projected = %f, want 88", projected)
+	}
+
+	if _, _, ok := Velocity(snaps[:1], now); ok {
+		t.Error("Velocity() with one snapshot in window should not be ok")
+	}
+}
+
+func TestCollect_AppendsHistory(t *testing.T) {
+	dir := t.TempDir()
+	c := newWithClients(Config{Civo: &CivoConfig{APIKey: "key"}}, buildCivoMock(), nil)
+	c.history = NewHistory(dir, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Collect(context.Background()); err != nil {
+			t.Fatalf("Collect() error: %v", err)
+		}
+	}
+
+	snaps, err := c.history.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("len(snaps) = %d, want 2", len(snaps))
+	}
+	if _, ok := snaps[0].Providers["civo"]; !ok {
+		t.Errorf("snapshot providers = %v, want civo", snaps[0].Providers)
+	}
+
+	// A collection where every provider fails is not recorded.
+	c.civoClient = &mockCivoClient{chargesErr: errors.New("down")}
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := c.history.Load(); len(snaps) != 2 {
+		t.Errorf("len(snaps) = %d after failed collection, want 2", len(snaps))
+	}
+}
+
+func TestDailySpend(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, time.UTC) }
+	snaps := []Snapshot{
+		{Timestamp: day(28, 23), Providers: map[string]float64{"do": 50}},
+		{Timestamp: day(29, 9), Providers: map[string]float64{"do": 51}},
+		{Timestamp: day(29, 22), Providers: map[string]float64{"do": 52}},
+		// Daemon off for two days, and the month rolls over.
+		{Timestamp: time.Date(2024, 4, 1, 6, 0, 0, 0, time.UTC), Providers: map[string]float64{"do": 3}},
+		{Timestamp: time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC), Providers: map[string]float64{"do": 6}},
+		{Timestamp: time.Date(2024, 4, 2, 20, 0, 0, 0, time.UTC), Providers: map[string]float64{"do": 8}},
+	}
+
+	got := DailySpend(snaps, time.UTC)["do"]
+	want := []DaySpend{
+		{Day: time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC), Spend: 2},
+		{Day: time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC), Spend: 2},
+		{Day: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), Spend: 2},
+		{Day: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Spend: 2},
+		{Day: time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC), Spend: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DailySpend = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Day.Equal(want[i].Day) || !floatEqual(got[i].Spend, want[i].Spend) {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	now := time.Date(2024, 3, 20, 15, 0, 0, 0, time.UTC)
+	// history returns daily snapshots for the 14 days before now, each
+	// adding perDay, then today's total after spending today.
+	history := func(perDay, today float64) []Snapshot {
+		var snaps []Snapshot
+		total := 10.0
+		for d := 14; d >= 1; d-- {
+			snaps = append(snaps, Snapshot{Timestamp: now.AddDate(0, 0, -d), Providers: map[string]float64{"do": total, "civo": total}})
+			total += perDay
+		}
+		return append(snaps, Snapshot{Timestamp: now, Providers: map[string]float64{"do": total - perDay + today, "civo": total}})
+	}
+
+	got := DetectAnomalies(history(2, 6.2), now, AnomalyRule{Factor: DefaultAnomalyFactor})
+	a, ok := got["do"]
+	if !ok || len(got) != 1 {
+		t.Fatalf("DetectAnomalies = %+v, want do only", got)
+	}
+	if !floatEqual(a.Spend, 6.2) || !floatEqual(a.Baseline, 2) || !floatEqual(a.Factor, 3.1) {
+		t.Errorf("anomaly = %+v, want 6.20 against 2.00, 3.1x", a)
+	}
+	if s := a.Summary("do", "USD"); s != "do spend 3.1× usual" {
+		t.Errorf("Summary = %q", s)
+	}
+
+	if got := DetectAnomalies(history(2, 3.9), now, AnomalyRule{Factor: DefaultAnomalyFactor}); len(got) != 0 {
+		t.Errorf("spend under 2x = %+v, want none", got)
+	}
+	if got := DetectAnomalies(history(2, 3.9), now, AnomalyRule{MinDelta: 1.5}); len(got) != 1 {
+		t.Errorf("spend 1.90 over baseline with min delta 1.50 = %+v, want do", got)
+	}
+	if got := DetectAnomalies(history(0, 5), now, AnomalyRule{Factor: 2}); len(got) != 0 {
+		t.Errorf("factor against a zero baseline = %+v, want none", got)
+	}
+	if got := DetectAnomalies(history(2, 6.2), now, AnomalyRule{}); got != nil {
+		t.Errorf("zero rule = %+v, want nil", got)
+	}
+
+	// Too few days for a baseline.
+	snaps := history(2, 6.2)
+	if got := DetectAnomalies(snaps[len(snaps)-3:], now, AnomalyRule{Factor: 2}); len(got) != 0 {
+		t.Errorf("two days of history = %+v, want none", got)
+	}
+	// Nothing recorded today yet.
+	if got := DetectAnomalies(snaps[:len(snaps)-1], now, AnomalyRule{Factor: 2}); len(got) != 0 {
+		t.Errorf("no snapshot today = %+v, want none", got)
+	}
+}
+
+func TestCollect_FlagsAnomaly(t *testing.T) {
+	dir := t.TempDir()
+	c := newWithClients(Config{Civo: &CivoConfig{APIKey: "key"}, Anomaly: AnomalyRule{Factor: 2}}, buildCivoMock(), nil)
+	c.history = NewHistory(dir, 0)
+
+	// Civo reports 35.50 month to date: a usual 1.00 a day, then 10.00 today.
+	today := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 12, 0, 0, 0, time.Local)
+	for d := 5; d >= 1; d-- {
+		s := Snapshot{Timestamp: today.AddDate(0, 0, -d), Providers: map[string]float64{"civo": 26.5 - float64(d)}}
+		if err := c.history.Append(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := data.(*BillingReport).Providers[0]
+	if p.Anomaly == nil || !floatEqual(p.Anomaly.Factor, 10) {
+		t.Errorf("civo anomaly = %+v, want 10x usual", p.Anomaly)
+	}
+}
+
+func TestAccruedSince(t *testing.T) {
+	monthStart := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		created time.Time
+		now     time.Time
+		want    float64
+	}{
+		{"created last month", monthStart.AddDate(0, -1, 0), monthStart.Add(10 * time.Hour), 1.0},
+		{"created mid month", monthStart.Add(5 * time.Hour), monthStart.Add(10 * time.Hour), 0.5},
+		{"created in future", monthStart.Add(20 * time.Hour), monthStart.Add(10 * time.Hour), 0},
+		{"capped at monthly", monthStart, monthStart.Add(1000 * time.Hour), 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := accruedSince(0.1, 50, tt.created, monthStart, tt.now)
+			if !floatEqual(got, tt.want) {
+				t.Errorf("accruedSince() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHetznerHTTPClient_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer bad-token" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := newHetznerHTTPClient("bad-token", srv.URL)
+
+	_, err := c.GetServers(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "HCLOUD_TOKEN") {
+		t.Errorf("GetServers() error = %v, want token hint", err)
+	}
+}
+
+func TestHetznerHTTPClient_Pagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"servers":[{"id":1,"name":"a"}],"meta":{"pagination":{"next_page":2}}}`)
+		default:
+			fmt.Fprint(w, `{"servers":[{"id":2,"name":"b"}],"meta":{"pagination":{"next_page":null}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := newHetznerHTTPClient("token", srv.URL)
+
+	servers, err := c.GetServers(context.Background())
+	if err != nil {
+		t.Fatalf("GetServers() error: %v", err)
+	}
+	if len(servers) != 2 || servers[1].Name != "b" {
+		t.Errorf("servers = %+v, want both pages", servers)
+	}
+}
+
 // Ensure mock clients satisfy their interfaces.
 var _ CivoClient = (*mockCivoClient)(nil)
 var _ DOClient = (*mockDOClient)(nil)
+var _ HetznerClient = (*mockHetznerClient)(nil)
+var _ VultrClient = (*mockVultrClient)(nil)
+
+func TestCollect_ConvertsCurrency(t *testing.T) {
+	c := newWithClients(Config{
+		Civo:    &CivoConfig{APIKey: "key"},
+		Hetzner: &HetznerConfig{APIToken: "token"},
+		Rates:   StaticRates("USD", map[string]float64{"EUR": 1.10}),
+	}, buildCivoMock(), nil)
+	c.hetznerClient = buildHetznerMock()
+	c.nowFunc = func() time.Time { return time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC) }
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	if report.ConversionError != "" || report.Subtotals != nil {
+		t.Fatalf("ConversionError = %q, Subtotals = %v; want a converted total", report.ConversionError, report.Subtotals)
+	}
+
+	var hetzner ProviderBilling
+	for _, p := range report.Providers {
+		if p.Name == "hetzner" {
+			hetzner = p
+		}
+	}
+	native := 0.0070 * 240
+	if hetzner.Currency != "USD" || hetzner.NativeCurrency != "EUR" || !floatEqual(hetzner.NativeMonthToDate, native) {
+		t.Errorf("hetzner = %s %f (native %s %f), want USD converted from EUR %f",
+			hetzner.Currency, hetzner.MonthToDate, hetzner.NativeCurrency, hetzner.NativeMonthToDate, native)
+	}
+	if !floatEqual(hetzner.MonthToDate, native*1.10) {
+		t.Errorf("hetzner MonthToDate = %f, want %f", hetzner.MonthToDate, native*1.10)
+	}
+	if want := 35.50 + native*1.10; !floatEqual(report.TotalMonthlyUSD, want) {
+		t.Errorf("TotalMonthlyUSD = %f, want %f", report.TotalMonthlyUSD, want)
+	}
+	if got, want := hetzner.FormatNative(), "€1.68 (≈$1.85)"; got != want {
+		t.Errorf("FormatNative() = %q, want %q", got, want)
+	}
+}
+
+// TestCollect_ConversionFailure checks spend that cannot be converted is
+// reported per currency, without a total, budget percentage, or history.
+func TestCollect_ConversionFailure(t *testing.T) {
+	c := newWithClients(Config{
+		Civo:      &CivoConfig{APIKey: "key"},
+		Hetzner:   &HetznerConfig{APIToken: "token"},
+		BudgetUSD: 100,
+	}, buildCivoMock(), nil)
+	c.hetznerClient = buildHetznerMock()
+	c.nowFunc = func() time.Time { return time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC) }
+	c.history = NewHistory(t.TempDir(), 0)
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	if !strings.Contains(report.ConversionError, "hetzner") {
+		t.Errorf("ConversionError = %q, want it to name hetzner", report.ConversionError)
+	}
+	if report.TotalMonthlyUSD != 0 || report.BudgetPercent != 0 {
+		t.Errorf("TotalMonthlyUSD = %f, BudgetPercent = %f; want neither", report.TotalMonthlyUSD, report.BudgetPercent)
+	}
+	if !floatEqual(report.Subtotals["USD"], 35.50) || !floatEqual(report.Subtotals["EUR"], 1.68) {
+		t.Errorf("Subtotals = %v, want USD 35.50 and EUR 1.68", report.Subtotals)
+	}
+	if got, want := report.FormatTotal(), "$35.50 + €1.68"; got != want {
+		t.Errorf("FormatTotal() = %q, want %q", got, want)
+	}
+	if snaps, _ := c.history.Load(); len(snaps) != 0 {
+		t.Errorf("len(snaps) = %d, want no history without a total", len(snaps))
+	}
+}
+
+func TestExchangeRates_Convert(t *testing.T) {
+	rates := ExchangeRates{"EUR": 1, "USD": 1.08, "GBP": 0.85}
+	if got, err := rates.Convert(10, "EUR", "USD"); err != nil || !floatEqual(got, 10.80) {
+		t.Errorf("Convert(10 EUR, USD) = %f, %v; want 10.80", got, err)
+	}
+	if got, err := rates.Convert(10.80, "USD", "GBP"); err != nil || !floatEqual(got, 8.50) {
+		t.Errorf("Convert(10.80 USD, GBP) = %f, %v; want 8.50", got, err)
+	}
+	if _, err := rates.Convert(10, "CHF", "USD"); err == nil {
+		t.Error("Convert() from a currency without a rate succeeded")
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{24.6, "USD", "$24.60"},
+		{12, "EUR", "€12.00"},
+		{9.5, "CHF", "CHF 9.50"},
+		{1, "", "$1.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("FormatAmount(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+const testECBFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-03-13">
+			<Cube currency="USD" rate="1.0800"/>
+			<Cube currency="GBP" rate="0.8500"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBSource(t *testing.T) {
+	defer httpx.Configure(httpx.Configure(httpx.Settings{MaxRetries: 1, RetryBaseDelay: time.Millisecond}))
+
+	var hits int
+	var fail bool
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits++
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, testECBFeed)
+	}))
+	defer srv.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), ECBRatesFileName)
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	src := NewECBSource(srv.URL, cacheFile, time.Hour)
+	src.nowFunc = func() time.Time { return now }
+
+	rates, err := src.Rates(context.Background())
+	if err != nil {
+		t.Fatalf("Rates() error: %v", err)
+	}
+	if rates["EUR"] != 1 || rates["USD"] != 1.08 || rates["GBP"] != 0.85 {
+		t.Errorf("rates = %v, want EUR 1, USD 1.08, GBP 0.85", rates)
+	}
+
+	// Fresh rates come from memory, or from the cache file in a new source.
+	if _, err := src.Rates(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	again := NewECBSource(srv.URL, cacheFile, time.Hour)
+	again.nowFunc = func() time.Time { return now.Add(30 * time.Minute) }
+	if rates, err := again.Rates(context.Background()); err != nil || rates["USD"] != 1.08 {
+		t.Errorf("Rates() from the cache file = %v, %v", rates, err)
+	}
+	if hits != 1 {
+		t.Errorf("feed fetched %d times, want 1 while the rates are fresh", hits)
+	}
+
+	// Stale rates are fetched again, retried once, and kept when fetching
+	// fails.
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	now = now.Add(2 * time.Hour)
+	if rates, err := src.Rates(context.Background()); err != nil || rates["USD"] != 1.08 {
+		t.Errorf("Rates() with the feed down = %v, %v; want the stale rates", rates, err)
+	}
+	if hits != 3 {
+		t.Errorf("feed fetched %d times, want 3 once the rates are stale", hits)
+	}
+
+	empty := NewECBSource(srv.URL, "", time.Hour)
+	if _, err := empty.Rates(context.Background()); err == nil {
+		t.Error("Rates() with the feed down and nothing cached succeeded")
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	day := func(y int, m time.Month, d int, loc *time.Location) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	tests := []struct {
+		name       string
+		period     Period
+		now        time.Time
+		start, end time.Time
+	}{
+		{"calendar", Period{}, time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC), day(2026, 3, 1, time.UTC), day(2026, 4, 1, time.UTC)},
+		{"anchor before", Period{AnchorDay: 12}, day(2026, 3, 5, time.UTC), day(2026, 2, 12, time.UTC), day(2026, 3, 12, time.UTC)},
+		{"anchor on", Period{AnchorDay: 12}, day(2026, 3, 12, time.UTC), day(2026, 3, 12, time.UTC), day(2026, 4, 12, time.UTC)},
+		{"anchor year end", Period{AnchorDay: 20}, day(2026, 1, 2, time.UTC), day(2025, 12, 20, time.UTC), day(2026, 1, 20, time.UTC)},
+		{"anchor 31 in Feb", Period{AnchorDay: 31}, day(2026, 2, 20, time.UTC), day(2026, 1, 31, time.UTC), day(2026, 2, 28, time.UTC)},
+		{"anchor 31 from Feb", Period{AnchorDay: 31}, day(2026, 3, 30, time.UTC), day(2026, 2, 28, time.UTC), day(2026, 3, 31, time.UTC)},
+		{"anchor 30 leap year", Period{AnchorDay: 30}, day(2024, 2, 29, time.UTC), day(2024, 2, 29, time.UTC), day(2024, 3, 30, time.UTC)},
+		{"anchor 29 in Apr", Period{AnchorDay: 29}, day(2026, 4, 29, time.UTC), day(2026, 4, 29, time.UTC), day(2026, 5, 29, time.UTC)},
+		{"cycle", Period{CycleDays: 14, CycleStart: day(2026, 1, 5, time.UTC)}, day(2026, 3, 1, time.UTC), day(2026, 2, 16, time.UTC), day(2026, 3, 2, time.UTC)},
+		{"cycle before start", Period{CycleDays: 14, CycleStart: day(2026, 1, 5, time.UTC)}, day(2026, 1, 1, time.UTC), day(2025, 12, 22, time.UTC), day(2026, 1, 5, time.UTC)},
+		// 21:00 on Feb 28 in Los Angeles is already March in UTC.
+		{"zone", Period{Location: la}, time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC), day(2026, 2, 1, la), day(2026, 3, 1, la)},
+		// Across the spring DST change a cycle still starts at midnight.
+		{"cycle across DST", Period{CycleDays: 7, CycleStart: day(2026, 3, 2, time.UTC), Location: la}, time.Date(2026, 3, 16, 7, 30, 0, 0, time.UTC), day(2026, 3, 16, la), day(2026, 3, 23, la)},
+	}
+	for _, tt := range tests {
+		start, end := tt.period.Bounds(tt.now)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: Bounds(%s) = %s – %s, want %s – %s", tt.name, tt.now, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestPeriodLabel(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		start, end time.Time
+		want       string
+	}{
+		{day(2, 12), day(3, 12), "Feb 12 – Mar 11"},
+		{day(3, 1), day(4, 1), ""},
+		{day(3, 1), day(3, 15), "Mar 1 – Mar 14"},
+		{time.Time{}, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		if got := (ProviderBilling{PeriodStart: tt.start, PeriodEnd: tt.end}).PeriodLabel(); got != tt.want {
+			t.Errorf("PeriodLabel(%s – %s) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestCollect_BillingPeriods(t *testing.T) {
+	now := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	c := newWithClients(Config{
+		Currency:     "USD",
+		DigitalOcean: &DOConfig{APIToken: "token"},
+		Vultr:        &VultrConfig{APIKey: "key"},
+		Hetzner:      &HetznerConfig{APIToken: "token"},
+		Budgets:      map[string]float64{"digitalocean": 10, "vultr": 10},
+		Periods: map[string]Period{
+			"digitalocean": {CycleDays: 14, CycleStart: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)},
+			"vultr":        {AnchorDay: 5},
+			"hetzner":      {AnchorDay: 5},
+		},
+	}, nil, buildDOMock())
+	c.vultrClient = buildVultrMock()
+	c.hetznerClient = buildHetznerMock()
+	c.nowFunc = func() time.Time { return now }
+	var logs []string
+	c.logf = func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*BillingReport)
+	do, hz, vultr := report.Providers[0], report.Providers[1], report.Providers[2]
+
+	// DigitalOcean: 12 days (288h) of both droplets, not the calendar
+	// month-to-date balance, against a budget prorated to 14 days.
+	if want := (0.00893 + 0.03571) * 288; !floatEqual(do.MonthToDate, want) {
+		t.Errorf("do.MonthToDate = %f, want %f", do.MonthToDate, want)
+	}
+	if want := 10 * 14 / (365.25 / 12); !floatEqual(do.BudgetUSD, want) {
+		t.Errorf("do.BudgetUSD = %f, want %f prorated", do.BudgetUSD, want)
+	}
+	if do.BudgetStatus != BudgetCritical {
+		t.Errorf("do.BudgetStatus = %q, want %q", do.BudgetStatus, BudgetCritical)
+	}
+	if got := do.PeriodLabel(); got != "Feb 28 – Mar 12" {
+		t.Errorf("do.PeriodLabel() = %q", got)
+	}
+
+	// Hetzner: the server accrues from the 5th, 144h.
+	if want := 0.0070 * 144; !floatEqual(hz.MonthToDate, want) {
+		t.Errorf("hetzner.MonthToDate = %f, want %f", hz.MonthToDate, want)
+	}
+	if !hz.PeriodStart.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) || !hz.PeriodEnd.Equal(time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("hetzner period = %s – %s", hz.PeriodStart, hz.PeriodEnd)
+	}
+
+	// Vultr: instances' accrued charges since the 5th replace the calendar
+	// month's pending charges: 144h at 0.01/h and 24h at 0.03/h.
+	if want := 1.44 + 0.72; !floatEqual(vultr.MonthToDate, want) {
+		t.Errorf("vultr.MonthToDate = %f, want %f", vultr.MonthToDate, want)
+	}
+	if vultr.BudgetUSD != 10 || vultr.BudgetStatus != BudgetOK {
+		t.Errorf("vultr budget = %v %q, want the monthly budget unprorated", vultr.BudgetUSD, vultr.BudgetStatus)
+	}
+
+	// Alerts reset when the next cycle starts, not the next calendar month.
+	delete(c.cfg.Budgets, "vultr")
+	now = time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	now = time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("logs = %q, want one warning per cycle", logs)
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	r := &BillingReport{
+		Currency:  "EUR",
+		BudgetUSD: 200,
+		Subtotals: map[string]float64{"EUR": 12, "USD": 30},
+		Providers: []ProviderBilling{{BudgetStatus: BudgetOK}, {BudgetStatus: BudgetWarn}, {}},
+	}
+	s := NewSummary(r)
+	if !s.Current() || s.BudgetStatus != BudgetWarn || s.BudgetUSD != 200 {
+		t.Errorf("NewSummary() = %+v", s)
+	}
+	if got, want := s.FormatTotal(), r.FormatTotal(); got != want {
+		t.Errorf("FormatTotal() = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 // Package billing provides a collector that aggregates cloud billing data from
-// Civo and DigitalOcean APIs. Each provider is queried independently; failures
-// in one provider do not prevent collection from the other.
+// Civo, DigitalOcean, Hetzner Cloud, and Vultr APIs. Each provider is queried
+// independently; failures in one provider do not prevent collection from the
+// others.
 package billing
 
 import (
@@ -9,8 +10,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
 )
 
 // ---------------------------------------------------------------------------
@@ -19,7 +24,7 @@ import (
 
 // CivoClient abstracts the Civo API for testability.
 type CivoClient interface {
-	GetCharges(ctx context.Context) (*CivoChargesResponse, error)
+	GetCharges(ctx context.Context, from, to time.Time) (*CivoChargesResponse, error)
 	GetKubernetes(ctx context.Context) (*CivoK8sResponse, error)
 	GetInstances(ctx context.Context) (*CivoInstancesResponse, error)
 }
@@ -66,6 +71,15 @@ type CivoInstance struct {
 	MonthlyCost float64 `json:"monthly_cost"`
 }
 
+// billingBaseURL returns override, without a trailing slash, or def when
+// override is empty.
+func billingBaseURL(override, def string) string {
+	if override == "" {
+		return def
+	}
+	return strings.TrimRight(override, "/")
+}
+
 // civoHTTPClient implements CivoClient using net/http.
 type civoHTTPClient struct {
 	baseURL string
@@ -73,14 +87,11 @@ type civoHTTPClient struct {
 	client  *http.Client
 }
 
-func newCivoHTTPClient(apiKey, region string) *civoHTTPClient {
-	baseURL := "https://api.civo.com/v2"
+func newCivoHTTPClient(apiKey, region, baseURL string) *civoHTTPClient {
 	return &civoHTTPClient{
-		baseURL: baseURL,
+		baseURL: billingBaseURL(baseURL, "https://api.civo.com/v2"),
 		apiKey:  apiKey,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  httpx.New(httpx.Config{Name: "billing"}),
 	}
 }
 
@@ -111,9 +122,12 @@ func (c *civoHTTPClient) doRequest(ctx context.Context, path string, out interfa
 	return nil
 }
 
-func (c *civoHTTPClient) GetCharges(ctx context.Context) (*CivoChargesResponse, error) {
+// GetCharges fetches the charges between from and to. Civo limits the
+// range to 31 days.
+func (c *civoHTTPClient) GetCharges(ctx context.Context, from, to time.Time) (*CivoChargesResponse, error) {
+	q := url.Values{"from": {from.UTC().Format(time.RFC3339)}, "to": {to.UTC().Format(time.RFC3339)}}
 	var resp CivoChargesResponse
-	if err := c.doRequest(ctx, "/charges", &resp); err != nil {
+	if err := c.doRequest(ctx, "/charges?"+q.Encode(), &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -208,9 +222,10 @@ type DODropletsResponse struct {
 
 // DODroplet is a single droplet from the DigitalOcean API.
 type DODroplet struct {
-	ID   int       `json:"id"`
-	Name string    `json:"name"`
-	Size DOSize    `json:"size"`
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	Size    DOSize    `json:"size"`
+	Created time.Time `json:"created_at"`
 }
 
 // DOSize contains the pricing information for a droplet or node pool.
@@ -227,13 +242,11 @@ type doHTTPClient struct {
 	client   *http.Client
 }
 
-func newDOHTTPClient(apiToken string) *doHTTPClient {
+func newDOHTTPClient(apiToken, baseURL string) *doHTTPClient {
 	return &doHTTPClient{
-		baseURL:  "https://api.digitalocean.com/v2",
+		baseURL:  billingBaseURL(baseURL, "https://api.digitalocean.com/v2"),
 		apiToken: apiToken,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:   httpx.New(httpx.Config{Name: "billing"}),
 	}
 }
 
@@ -287,3 +300,332 @@ func (c *doHTTPClient) GetDroplets(ctx context.Context) (*DODropletsResponse, er
 	}
 	return &resp, nil
 }
+
+// ---------------------------------------------------------------------------
+// Hetzner Cloud API types and client
+// ---------------------------------------------------------------------------
+
+// HetznerClient abstracts the Hetzner Cloud API for testability.
+type HetznerClient interface {
+	GetServers(ctx context.Context) ([]HetznerServer, error)
+	GetVolumes(ctx context.Context) ([]HetznerVolume, error)
+	GetPricing(ctx context.Context) (*HetznerPricing, error)
+}
+
+// HetznerAmount is a price as returned by the Hetzner API. Amounts are
+// decimal strings in the account currency (EUR).
+type HetznerAmount struct {
+	Net   string `json:"net"`
+	Gross string `json:"gross"`
+}
+
+// HetznerLocationPrice is the price of a server type in one location.
+type HetznerLocationPrice struct {
+	Location     string        `json:"location"`
+	PriceHourly  HetznerAmount `json:"price_hourly"`
+	PriceMonthly HetznerAmount `json:"price_monthly"`
+}
+
+// HetznerServerType describes a server plan and its per-location prices.
+type HetznerServerType struct {
+	Name   string                 `json:"name"`
+	Prices []HetznerLocationPrice `json:"prices"`
+}
+
+// priceFor returns the price entry for location. If no entry matches, the
+// first price is used so a server in an unlisted location is still counted.
+func (t HetznerServerType) priceFor(location string) (HetznerLocationPrice, bool) {
+	for _, p := range t.Prices {
+		if p.Location == location {
+			return p, true
+		}
+	}
+	if len(t.Prices) > 0 {
+		return t.Prices[0], true
+	}
+	return HetznerLocationPrice{}, false
+}
+
+// HetznerServer is a single server from GET /v1/servers.
+type HetznerServer struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Status     string            `json:"status"`
+	Created    time.Time         `json:"created"`
+	ServerType HetznerServerType `json:"server_type"`
+	Datacenter struct {
+		Location struct {
+			Name string `json:"name"`
+		} `json:"location"`
+	} `json:"datacenter"`
+}
+
+// HetznerVolume is a single block storage volume from GET /v1/volumes.
+type HetznerVolume struct {
+	ID      int64     `json:"id"`
+	Name    string    `json:"name"`
+	Size    int       `json:"size"` // GB
+	Created time.Time `json:"created"`
+}
+
+// HetznerPricing is the subset of GET /v1/pricing used for cost estimates.
+type HetznerPricing struct {
+	Currency string `json:"currency"`
+	Volume   struct {
+		PricePerGBMonth HetznerAmount `json:"price_per_gb_month"`
+	} `json:"volume"`
+}
+
+// hetznerMeta carries pagination info common to Hetzner list responses.
+type hetznerMeta struct {
+	Pagination struct {
+		NextPage *int `json:"next_page"`
+	} `json:"pagination"`
+}
+
+// parseHetznerAmount parses a Hetzner decimal price string.
+func parseHetznerAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// hetznerMaxPages bounds pagination so a misbehaving API cannot loop forever.
+const hetznerMaxPages = 20
+
+// hetznerHTTPClient implements HetznerClient using net/http.
+type hetznerHTTPClient struct {
+	baseURL  string
+	apiToken string
+	client   *http.Client
+}
+
+func newHetznerHTTPClient(apiToken, baseURL string) *hetznerHTTPClient {
+	return &hetznerHTTPClient{
+		baseURL:  billingBaseURL(baseURL, "https://api.hetzner.cloud/v1"),
+		apiToken: apiToken,
+		client:   httpx.New(httpx.Config{Name: "billing"}),
+	}
+}
+
+func (c *hetznerHTTPClient) doRequest(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("hetzner API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("hetzner API rejected token (check HCLOUD_TOKEN): %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("hetzner API %s returned %d: %s", path, resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (c *hetznerHTTPClient) GetServers(ctx context.Context) ([]HetznerServer, error) {
+	var all []HetznerServer
+	page := 1
+	for i := 0; i < hetznerMaxPages; i++ {
+		var resp struct {
+			Servers []HetznerServer `json:"servers"`
+			Meta    hetznerMeta     `json:"meta"`
+		}
+		q := url.Values{"page": {strconv.Itoa(page)}, "per_page": {"50"}}
+		if err := c.doRequest(ctx, "/servers", q, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Servers...)
+		if resp.Meta.Pagination.NextPage == nil {
+			break
+		}
+		page = *resp.Meta.Pagination.NextPage
+	}
+	return all, nil
+}
+
+func (c *hetznerHTTPClient) GetVolumes(ctx context.Context) ([]HetznerVolume, error) {
+	var all []HetznerVolume
+	page := 1
+	for i := 0; i < hetznerMaxPages; i++ {
+		var resp struct {
+			Volumes []HetznerVolume `json:"volumes"`
+			Meta    hetznerMeta     `json:"meta"`
+		}
+		q := url.Values{"page": {strconv.Itoa(page)}, "per_page": {"50"}}
+		if err := c.doRequest(ctx, "/volumes", q, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Volumes...)
+		if resp.Meta.Pagination.NextPage == nil {
+			break
+		}
+		page = *resp.Meta.Pagination.NextPage
+	}
+	return all, nil
+}
+
+func (c *hetznerHTTPClient) GetPricing(ctx context.Context) (*HetznerPricing, error) {
+	var resp struct {
+		Pricing HetznerPricing `json:"pricing"`
+	}
+	if err := c.doRequest(ctx, "/pricing", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Pricing, nil
+}
+
+// ---------------------------------------------------------------------------
+// Vultr API types and client
+// ---------------------------------------------------------------------------
+
+// VultrClient abstracts the Vultr API for testability.
+type VultrClient interface {
+	GetAccount(ctx context.Context) (*VultrAccount, error)
+	GetInstances(ctx context.Context) ([]VultrInstance, error)
+	GetPlans(ctx context.Context) ([]VultrPlan, error)
+}
+
+// VultrAccount is the subset of GET /v2/account used for billing. Vultr
+// reports a negative balance when the account is in credit.
+type VultrAccount struct {
+	Balance        float64 `json:"balance"`
+	PendingCharges float64 `json:"pending_charges"`
+}
+
+// VultrInstance is a single instance from GET /v2/instances.
+type VultrInstance struct {
+	ID          string    `json:"id"`
+	Label       string    `json:"label"`
+	Plan        string    `json:"plan"`
+	Region      string    `json:"region"`
+	Status      string    `json:"status"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// VultrPlan is a single plan from GET /v2/plans.
+type VultrPlan struct {
+	ID          string  `json:"id"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+// vultrMeta carries cursor pagination info common to Vultr list responses.
+type vultrMeta struct {
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+// vultrMaxPages bounds pagination so a misbehaving API cannot loop forever.
+const vultrMaxPages = 20
+
+// vultrHTTPClient implements VultrClient using net/http.
+type vultrHTTPClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newVultrHTTPClient(apiKey, baseURL string) *vultrHTTPClient {
+	return &vultrHTTPClient{
+		baseURL: billingBaseURL(baseURL, "https://api.vultr.com/v2"),
+		apiKey:  apiKey,
+		client:  httpx.New(httpx.Config{Name: "billing"}),
+	}
+}
+
+func (c *vultrHTTPClient) doRequest(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vultr API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("vultr API rejected key (check VULTR_API_KEY): %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("vultr API %s returned %d: %s", path, resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (c *vultrHTTPClient) GetAccount(ctx context.Context) (*VultrAccount, error) {
+	var resp struct {
+		Account VultrAccount `json:"account"`
+	}
+	if err := c.doRequest(ctx, "/account", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Account, nil
+}
+
+func (c *vultrHTTPClient) GetInstances(ctx context.Context) ([]VultrInstance, error) {
+	var all []VultrInstance
+	cursor := ""
+	for i := 0; i < vultrMaxPages; i++ {
+		var resp struct {
+			Instances []VultrInstance `json:"instances"`
+			Meta      vultrMeta       `json:"meta"`
+		}
+		q := url.Values{"per_page": {"100"}}
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		if err := c.doRequest(ctx, "/instances", q, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Instances...)
+		if resp.Meta.Links.Next == "" {
+			break
+		}
+		cursor = resp.Meta.Links.Next
+	}
+	return all, nil
+}
+
+func (c *vultrHTTPClient) GetPlans(ctx context.Context) ([]VultrPlan, error) {
+	var resp struct {
+		Plans []VultrPlan `json:"plans"`
+	}
+	if err := c.doRequest(ctx, "/plans", url.Values{"per_page": {"500"}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plans, nil
+}
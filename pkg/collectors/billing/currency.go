@@ -0,0 +1,354 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// DefaultCurrency is the currency reports total spend in unless another
+// is configured, and the currency of cached data written before reports
+// named one.
+const DefaultCurrency = "USD"
+
+// ECBRatesURL is the European Central Bank's daily euro reference rates.
+const ECBRatesURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBRatesFileName is the name of the file ECB rates are cached in.
+const ECBRatesFileName = "billing-rates.json"
+
+// DefaultRatesTTL is how long fetched exchange rates are reused when no
+// TTL is configured. The ECB publishes once per working day.
+const DefaultRatesTTL = 24 * time.Hour
+
+// ExchangeRates maps each currency code to how many units of it one unit
+// of a common base currency buys. The base itself has rate 1.
+type ExchangeRates map[string]float64
+
+// Convert converts amount from one currency to another.
+func (r ExchangeRates) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, ok := r[from]
+	if !ok || fromRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := r[to]
+	if !ok || toRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", to)
+	}
+	return amount / fromRate * toRate, nil
+}
+
+// RateSource supplies the exchange rates billing reports convert with.
+type RateSource interface {
+	Rates(ctx context.Context) (ExchangeRates, error)
+}
+
+// staticRates is a RateSource of fixed rates.
+type staticRates ExchangeRates
+
+// StaticRates returns a RateSource of fixed rates, given as the value of
+// one unit of each currency in the display currency, e.g. {"EUR": 1.08}
+// with display currency USD.
+func StaticRates(display string, values map[string]float64) RateSource {
+	r := staticRates{display: 1}
+	for code, v := range values {
+		if v > 0 && code != display {
+			r[code] = 1 / v
+		}
+	}
+	return r
+}
+
+// Rates returns the fixed rates.
+func (r staticRates) Rates(context.Context) (ExchangeRates, error) {
+	return ExchangeRates(r), nil
+}
+
+// ECBSource is a RateSource of the ECB's daily euro reference rates. The
+// rates are cached in memory and, given a cache file, on disk, and are
+// fetched again once older than the TTL. If fetching fails, older rates
+// are used rather than none.
+type ECBSource struct {
+	url       string
+	cacheFile string
+	ttl       time.Duration
+	client    *http.Client
+
+	// nowFunc allows tests to inject a deterministic clock.
+	nowFunc func() time.Time
+
+	mu    sync.Mutex
+	cache ecbCache
+}
+
+// ecbCache is the on-disk form of the cached ECB rates.
+type ecbCache struct {
+	FetchedAt time.Time     `json:"fetched_at"`
+	Date      string        `json:"date"`
+	Rates     ExchangeRates `json:"rates"`
+}
+
+// NewECBSource returns an ECBSource fetching from url, ECBRatesURL when
+// empty, and caching the rates in cacheFile for ttl, DefaultRatesTTL when
+// zero. An empty cacheFile keeps them in memory only.
+func NewECBSource(url, cacheFile string, ttl time.Duration) *ECBSource {
+	if url == "" {
+		url = ECBRatesURL
+	}
+	if ttl <= 0 {
+		ttl = DefaultRatesTTL
+	}
+	return &ECBSource{
+		url:       url,
+		cacheFile: cacheFile,
+		ttl:       ttl,
+		client:    httpx.New(httpx.Config{Name: "billing"}),
+		nowFunc:   time.Now,
+	}
+}
+
+// Rates returns the cached rates while they are fresh, and otherwise
+// fetches the current ones.
+func (s *ECBSource) Rates(ctx context.Context) (ExchangeRates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cache.Rates == nil && s.cacheFile != "" {
+		if data, err := os.ReadFile(s.cacheFile); err == nil {
+			_ = json.Unmarshal(data, &s.cache)
+		}
+	}
+	now := s.nowFunc()
+	if s.cache.Rates != nil && now.Sub(s.cache.FetchedAt) < s.ttl {
+		return s.cache.Rates, nil
+	}
+
+	date, rates, err := s.fetch(ctx)
+	if err != nil {
+		if s.cache.Rates != nil {
+			log.Printf("billing: using exchange rates of %s: %v", s.cache.Date, err)
+			return s.cache.Rates, nil
+		}
+		return nil, err
+	}
+	s.cache = ecbCache{FetchedAt: now, Date: date, Rates: rates}
+	if s.cacheFile != "" {
+		if err := writeRatesCache(s.cacheFile, s.cache); err != nil {
+			log.Printf("billing: %v", err)
+		}
+	}
+	return rates, nil
+}
+
+// ecbEnvelope is the part of the ECB daily feed holding the rates.
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// fetch downloads the feed and returns its date and rates, with EUR as
+// the base.
+func (s *ECBSource) fetch(ctx context.Context) (string, ExchangeRates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching ECB rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", nil, fmt.Errorf("ECB rates returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var env ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return "", nil, fmt.Errorf("decoding ECB rates: %w", err)
+	}
+	day := env.Cube.Cube
+	if len(day.Rates) == 0 {
+		return "", nil, fmt.Errorf("ECB rates: feed has no rates")
+	}
+	rates := ExchangeRates{"EUR": 1}
+	for _, r := range day.Rates {
+		if r.Currency != "" && r.Rate > 0 {
+			rates[r.Currency] = r.Rate
+		}
+	}
+	return day.Time, rates, nil
+}
+
+// writeRatesCache replaces path with c through a temporary file.
+func writeRatesCache(path string, c ecbCache) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("exchange rates cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rates-*.tmp")
+	if err != nil {
+		return fmt.Errorf("exchange rates cache: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("exchange rates cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("exchange rates cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// currencySymbols are the symbols written in place of a currency code.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// CurrencySymbol returns the prefix amounts in currency are written with:
+// its symbol, e.g. "$", or else its code and a space, e.g. "CHF ". An
+// empty currency is DefaultCurrency.
+func CurrencySymbol(currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if sym, ok := currencySymbols[currency]; ok {
+		return sym
+	}
+	return currency + " "
+}
+
+// FormatAmount formats amount in currency, e.g. "$24.60", "€12.00", or
+// "CHF 9.50". An empty currency is DefaultCurrency.
+func FormatAmount(amount float64, currency string) string {
+	return fmt.Sprintf("%s%.2f", CurrencySymbol(currency), amount)
+}
+
+// FormatTotal returns the report's month-to-date total, e.g. "$36.20", or,
+// when some spend could not be converted to the report currency, its
+// per-currency subtotals, e.g. "$24.60 + €12.00".
+func (r *BillingReport) FormatTotal() string {
+	if len(r.Subtotals) == 0 {
+		return FormatAmount(r.TotalMonthlyUSD, r.Currency)
+	}
+	codes := make([]string, 0, len(r.Subtotals))
+	for code := range r.Subtotals {
+		codes = append(codes, code)
+	}
+	display := r.DisplayCurrency()
+	sort.Slice(codes, func(i, j int) bool {
+		if (codes[i] == display) != (codes[j] == display) {
+			return codes[i] == display
+		}
+		return codes[i] < codes[j]
+	})
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = FormatAmount(r.Subtotals[code], code)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// DisplayCurrency returns the currency the report totals spend in.
+func (r *BillingReport) DisplayCurrency() string {
+	if r.Currency == "" {
+		return DefaultCurrency
+	}
+	return r.Currency
+}
+
+// FormatNative returns the provider's month-to-date spend in the currency
+// it bills in, e.g. "€12.00", followed by its value in the report currency
+// when that differs, e.g. "€12.00 (≈$13.08)".
+func (p ProviderBilling) FormatNative() string {
+	if p.NativeCurrency == "" {
+		return FormatAmount(p.MonthToDate, p.Currency)
+	}
+	return fmt.Sprintf("%s (≈%s)", FormatAmount(p.NativeMonthToDate, p.NativeCurrency), FormatAmount(p.MonthToDate, p.Currency))
+}
+
+// BillingCurrency returns the currency the provider bills in, which its
+// Balance, Resources, and Breakdown are in.
+func (p ProviderBilling) BillingCurrency() string {
+	switch {
+	case p.NativeCurrency != "":
+		return p.NativeCurrency
+	case p.Currency != "":
+		return p.Currency
+	}
+	return DefaultCurrency
+}
+
+// convertCurrency converts the provider results to the display currency
+// in place, fetching exchange rates only when some provider has spent
+// something in another currency. A provider that cannot be converted
+// keeps its native currency, and the error is returned.
+func (c *Collector) convertCurrency(ctx context.Context, display string, results []ProviderBilling) error {
+	var rates ExchangeRates
+	var ratesErr error
+	fetched := false
+	var firstErr error
+	for i := range results {
+		pb := &results[i]
+		native := pb.Currency
+		if native == "" {
+			native = DefaultCurrency
+		}
+		pb.Currency = native
+		if native == display || !pb.Connected {
+			continue
+		}
+		if pb.MonthToDate == 0 {
+			pb.NativeCurrency, pb.Currency = native, display
+			continue
+		}
+		if !fetched {
+			fetched = true
+			if c.cfg.Rates == nil {
+				ratesErr = fmt.Errorf("no exchange rate source configured")
+			} else {
+				rates, ratesErr = c.cfg.Rates.Rates(ctx)
+			}
+		}
+		err := ratesErr
+		var converted float64
+		if err == nil {
+			converted, err = rates.Convert(pb.MonthToDate, native, display)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("converting %s spend from %s to %s: %w", pb.Name, native, display, err)
+			}
+			continue
+		}
+		pb.NativeCurrency, pb.NativeMonthToDate = native, pb.MonthToDate
+		pb.Currency, pb.MonthToDate = display, converted
+	}
+	return firstErr
+}
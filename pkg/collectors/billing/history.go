@@ -0,0 +1,247 @@
+package billing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// HistoryFileName is the name of the billing history file inside the state
+// directory.
+const HistoryFileName = "billing-history.jsonl"
+
+// DefaultHistoryRetention is how long snapshots are kept when no retention
+// is configured.
+const DefaultHistoryRetention = 90 * 24 * time.Hour
+
+// velocityWindow is the look-back window used by Velocity.
+const velocityWindow = 7 * 24 * time.Hour
+
+// Snapshot is one timestamped line of billing history: the connected
+// providers' month-to-date totals at the time of a collection.
+type Snapshot struct {
+	Timestamp time.Time          `json:"ts"`
+	TotalUSD  float64            `json:"total_usd"`
+	Providers map[string]float64 `json:"providers"`
+}
+
+// History is an append-only JSON lines file of billing snapshots. All
+// access goes through an flock on a sibling lock file, so the daemon and
+// any number of single-shot runs can append to the same file safely.
+type History struct {
+	path      string
+	retention time.Duration
+}
+
+// NewHistory returns a History stored in dir. A zero retention uses
+// DefaultHistoryRetention.
+func NewHistory(dir string, retention time.Duration) *History {
+	if retention <= 0 {
+		retention = DefaultHistoryRetention
+	}
+	return &History{
+		path:      filepath.Join(dir, HistoryFileName),
+		retention: retention,
+	}
+}
+
+// Path returns the history file path.
+func (h *History) Path() string {
+	return h.path
+}
+
+// SnapshotFromReport builds a Snapshot from a report. Providers that failed
+// to report are omitted so a transient error does not look like a drop in
+// spend.
+func SnapshotFromReport(r *BillingReport) Snapshot {
+	s := Snapshot{
+		Timestamp: r.Timestamp,
+		TotalUSD:  r.TotalMonthlyUSD,
+		Providers: make(map[string]float64, len(r.Providers)),
+	}
+	for _, p := range r.Providers {
+		if p.Connected {
+			s.Providers[p.Name] = p.MonthToDate
+		}
+	}
+	return s
+}
+
+// Append adds a snapshot to the history. Snapshots older than the
+// retention period are pruned whenever the oldest line has expired.
+func (h *History) Append(s Snapshot) error {
+	line, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("billing history: encode snapshot: %w", err)
+	}
+	line = append(line, '\n')
+
+	unlock, err := h.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("billing history: open %s: %w", h.path, err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("billing history: append: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("billing history: close: %w", err)
+	}
+
+	return h.pruneLocked(s.Timestamp)
+}
+
+// Load returns all snapshots within the retention period, oldest first.
+// A missing file yields no snapshots and no error; malformed lines are
+// skipped.
+func (h *History) Load() ([]Snapshot, error) {
+	unlock, err := h.lock(syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	snaps, err := h.readLocked()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-h.retention)
+	kept := snaps[:0]
+	for _, s := range snaps {
+		if !s.Timestamp.Before(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	return kept, nil
+}
+
+// Velocity estimates the month-end total from the spend rate over the last
+// seven days of snapshots taken in now's month. It returns false when there
+// are fewer than two usable snapshots or they span no time.
+func Velocity(snaps []Snapshot, now time.Time) (perDay, projected float64, ok bool) {
+	year, month, _ := now.Date()
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	from := now.Add(-velocityWindow)
+	if from.Before(monthStart) {
+		from = monthStart
+	}
+
+	var first, last *Snapshot
+	for i := range snaps {
+		s := &snaps[i]
+		if s.Timestamp.Before(from) || s.Timestamp.After(now) {
+			continue
+		}
+		if first == nil || s.Timestamp.Before(first.Timestamp) {
+			first = s
+		}
+		if last == nil || s.Timestamp.After(last.Timestamp) {
+			last = s
+		}
+	}
+	if first == nil || last == nil {
+		return 0, 0, false
+	}
+	span := last.Timestamp.Sub(first.Timestamp)
+	if span <= 0 {
+		return 0, 0, false
+	}
+
+	perHour := (last.TotalUSD - first.TotalUSD) / span.Hours()
+	if perHour < 0 {
+		perHour = 0
+	}
+	projected = last.TotalUSD + perHour*monthEnd.Sub(last.Timestamp).Hours()
+	return perHour * 24, projected, true
+}
+
+// --- history helpers ---
+
+// lock takes an flock of the given kind on the history lock file and
+// returns a function that releases it.
+func (h *History) lock(how int) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return nil, fmt.Errorf("billing history: create directory: %w", err)
+	}
+	f, err := os.OpenFile(h.path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("billing history: open lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("billing history: lock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// readLocked parses the history file. The caller must hold the lock.
+func (h *History) readLocked() ([]Snapshot, error) {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("billing history: read %s: %w", h.path, err)
+	}
+
+	var snaps []Snapshot
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var s Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+	return snaps, nil
+}
+
+// pruneLocked rewrites the history without expired snapshots. It is a no-op
+// unless the oldest snapshot has expired, so the common append path does
+// not rewrite the file. The caller must hold the exclusive lock.
+func (h *History) pruneLocked(now time.Time) error {
+	snaps, err := h.readLocked()
+	if err != nil || len(snaps) == 0 {
+		return err
+	}
+	cutoff := now.Add(-h.retention)
+	if !snaps[0].Timestamp.Before(cutoff) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, s := range snaps {
+		if s.Timestamp.Before(cutoff) {
+			continue
+		}
+		line, _ := json.Marshal(s)
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("billing history: write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("billing history: replace %s: %w", h.path, err)
+	}
+	return nil
+}
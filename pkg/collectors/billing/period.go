@@ -0,0 +1,105 @@
+package billing
+
+import "time"
+
+// Period is a provider's billing cycle. The zero Period is the calendar
+// month in UTC, which Civo, DigitalOcean, Hetzner, and Vultr all bill by.
+type Period struct {
+	// AnchorDay is the day of the month each cycle starts on. In months
+	// shorter than AnchorDay the cycle starts on their last day, so an
+	// anchor of 31 starts cycles on Jan 31, Feb 28, Mar 31, and so on.
+	// 0 and 1 are the calendar month.
+	AnchorDay int
+
+	// CycleDays, when positive, is a fixed cycle length in days counted
+	// from CycleStart, instead of a monthly AnchorDay.
+	CycleDays int
+
+	// CycleStart is a day on which a cycle began. Only its date is used.
+	CycleStart time.Time
+
+	// Location is the time zone whose midnight cycles start at. Nil is
+	// UTC.
+	Location *time.Location
+}
+
+// avgMonthDays is the length of the average calendar month.
+const avgMonthDays = 365.25 / 12
+
+// Calendar reports whether p is the calendar month.
+func (p Period) Calendar() bool {
+	return p.CycleDays <= 0 && p.AnchorDay <= 1
+}
+
+// Bounds returns the start of the cycle containing now and the start of
+// the next, both at midnight in p's time zone.
+func (p Period) Bounds(now time.Time) (start, end time.Time) {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+
+	if p.CycleDays > 0 {
+		y, m, d := p.CycleStart.Date()
+		origin := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		// Estimate the cycle by elapsed time, then step by calendar days,
+		// which differ from 24 hours across DST changes.
+		k := int(now.Sub(origin).Hours()/24) / p.CycleDays
+		if now.Before(origin) {
+			k--
+		}
+		start = origin.AddDate(0, 0, k*p.CycleDays)
+		for start.After(now) {
+			start = start.AddDate(0, 0, -p.CycleDays)
+		}
+		for !now.Before(start.AddDate(0, 0, p.CycleDays)) {
+			start = start.AddDate(0, 0, p.CycleDays)
+		}
+		return start, start.AddDate(0, 0, p.CycleDays)
+	}
+
+	anchor := max(p.AnchorDay, 1)
+	start = anchorDate(now.Year(), now.Month(), anchor, loc)
+	if now.Before(start) {
+		start = anchorDate(now.Year(), now.Month()-1, anchor, loc)
+	}
+	return start, anchorDate(start.Year(), start.Month()+1, anchor, loc)
+}
+
+// anchorDate returns midnight on day of the month, or on its last day when
+// the month is shorter. Months outside 1-12 roll over into other years.
+func anchorDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	last := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(day, last), 0, 0, 0, 0, loc)
+}
+
+// BudgetFactor returns the share of a monthly budget one cycle gets: 1 for
+// monthly cycles, and CycleDays over the average month's length for fixed
+// ones, e.g. 0.46 for 14 days.
+func (p Period) BudgetFactor() float64 {
+	if p.CycleDays > 0 {
+		return float64(p.CycleDays) / avgMonthDays
+	}
+	return 1
+}
+
+// periodFor returns the billing period configured for the named provider.
+func (c *Collector) periodFor(name string) Period {
+	return c.cfg.Periods[name]
+}
+
+// PeriodLabel returns the billing period as its first and last day, e.g.
+// "Feb 12 – Mar 11", or "" when it is a calendar month or unknown, as in
+// data cached before periods were reported.
+func (p ProviderBilling) PeriodLabel() string {
+	if p.PeriodStart.IsZero() || !p.PeriodEnd.After(p.PeriodStart) {
+		return ""
+	}
+	if p.PeriodStart.Day() == 1 && p.PeriodEnd.Equal(p.PeriodStart.AddDate(0, 1, 0)) {
+		return ""
+	}
+	last := p.PeriodEnd.AddDate(0, 0, -1)
+	return p.PeriodStart.Format("Jan 2") + " – " + last.Format("Jan 2")
+}
@@ -0,0 +1,52 @@
+package billing
+
+import "time"
+
+// SummaryVersion is the version of the Summary schema. Readers ignore a
+// summary of another version and fall back to the full report.
+const SummaryVersion = 1
+
+// Summary is the compact form of a BillingReport the prompt renders from.
+// The daemon writes it beside the full report so the prompt can skip
+// decoding every provider's resources and breakdown.
+type Summary struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+
+	Currency        string             `json:"currency,omitempty"`
+	TotalMonthlyUSD float64            `json:"total_monthly_usd"`
+	Subtotals       map[string]float64 `json:"subtotals,omitempty"`
+	BudgetUSD       float64            `json:"budget_usd"`
+
+	// BudgetStatus is the report's WorstBudgetStatus.
+	BudgetStatus string `json:"budget_status,omitempty"`
+}
+
+// NewSummary returns the summary of r.
+func NewSummary(r *BillingReport) Summary {
+	return Summary{
+		Version:         SummaryVersion,
+		Timestamp:       r.Timestamp,
+		Currency:        r.Currency,
+		TotalMonthlyUSD: r.TotalMonthlyUSD,
+		Subtotals:       r.Subtotals,
+		BudgetUSD:       r.BudgetUSD,
+		BudgetStatus:    r.WorstBudgetStatus(),
+	}
+}
+
+// Summarize returns the summary of r, for the daemon to write beside it.
+func (r *BillingReport) Summarize() any {
+	return NewSummary(r)
+}
+
+// Current reports whether s has the schema version this build writes.
+func (s *Summary) Current() bool {
+	return s.Version == SummaryVersion
+}
+
+// FormatTotal is BillingReport.FormatTotal for the summarized report.
+func (s *Summary) FormatTotal() string {
+	r := BillingReport{Currency: s.Currency, TotalMonthlyUSD: s.TotalMonthlyUSD, Subtotals: s.Subtotals}
+	return r.FormatTotal()
+}
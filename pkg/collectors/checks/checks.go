@@ -0,0 +1,305 @@
+// Package checks provides a collector that runs simple HTTP, TCP, and ping
+// checks defined in the configuration, for hosts and services that have no
+// dedicated collector. Each check records its latency and up/down state;
+// a check is only reported down after several consecutive failures so a
+// single dropped packet does not flap the prompt.
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval         = 60 * time.Second
+	DefaultTimeout          = 5 * time.Second
+	DefaultWorkers          = 4
+	DefaultFailureThreshold = 2
+)
+
+// Check types.
+const (
+	TypeHTTP = "http"
+	TypeTCP  = "tcp"
+	TypePing = "ping"
+)
+
+// Check states.
+const (
+	StateUp      = "up"
+	StateDown    = "down"
+	StatePending = "pending" // failing, but not yet FailureThreshold times
+)
+
+// Check defines one check.
+type Check struct {
+	// Name labels the check in every display. Names are unique.
+	Name string
+
+	// Type is TypeHTTP, TypeTCP, or TypePing.
+	Type string
+
+	// Target is a URL for HTTP, "host:port" for TCP, and a host for ping.
+	Target string
+
+	// ExpectStatus is the HTTP status code required for success. Zero
+	// accepts any 2xx or 3xx status.
+	ExpectStatus int
+
+	// ExpectBody, when set, must appear in the HTTP response body.
+	ExpectBody string
+
+	// Timeout bounds one attempt. Zero uses DefaultTimeout.
+	Timeout time.Duration
+
+	// Interval is how often the check runs. Zero runs it on every
+	// collection.
+	Interval time.Duration
+
+	// Group names the service the check belongs to, e.g. "website" for
+	// its db, api, and cdn checks. Empty puts it in GroupOther.
+	Group string
+
+	// Critical marks the check as one its group cannot work without: the
+	// group is down when it is.
+	Critical bool
+}
+
+// Config holds the configuration for the checks collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// Workers bounds how many checks run at once. Zero uses
+	// DefaultWorkers.
+	Workers int
+
+	// FailureThreshold is how many consecutive failures mark a check down.
+	// Zero uses DefaultFailureThreshold.
+	FailureThreshold int
+
+	// Checks are the checks to run.
+	Checks []Check
+}
+
+// Result is the latest state of one check.
+type Result struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	State     string    `json:"state"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"consecutive_failures,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Group     string    `json:"group,omitempty"`
+	Critical  bool      `json:"critical,omitempty"`
+}
+
+// Status is the data returned by a single Collect call.
+type Status struct {
+	Checks    []Result  `json:"checks"`
+	Up        int       `json:"up"`
+	Down      int       `json:"down"`
+	Total     int       `json:"total"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Collector runs the configured checks.
+type Collector struct {
+	checks    []Check
+	interval  time.Duration
+	workers   int
+	threshold int
+
+	// ping runs one ping attempt; replaced in tests.
+	ping func(ctx context.Context, host string) error
+
+	mu      sync.Mutex
+	results map[string]Result
+	healthy bool
+}
+
+// New creates a new checks collector. Zero settings in cfg use the
+// package defaults.
+func New(cfg Config) *Collector {
+	c := &Collector{
+		checks:    cfg.Checks,
+		interval:  cfg.Interval,
+		workers:   cfg.Workers,
+		threshold: cfg.FailureThreshold,
+		ping:      systemPing,
+		results:   make(map[string]Result, len(cfg.Checks)),
+		healthy:   true, // healthy until first failure
+	}
+	if c.interval <= 0 {
+		c.interval = DefaultInterval
+	}
+	if c.workers <= 0 {
+		c.workers = DefaultWorkers
+	}
+	if c.threshold <= 0 {
+		c.threshold = DefaultFailureThreshold
+	}
+	return c
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "checks"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection could run its checks. Checks
+// that fail do not make the collector unhealthy.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// Collect runs every check that is due, at most Workers at a time, and
+// returns the state of all checks. Checks whose Interval has not elapsed
+// keep their previous result.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	now := time.Now()
+	due := make(chan Check)
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers && i < len(c.checks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chk := range due {
+				a := c.run(ctx, chk)
+				// An attempt cut short by shutdown says nothing about
+				// the target.
+				if ctx.Err() == nil {
+					c.record(chk, a)
+				}
+			}
+		}()
+	}
+	for _, chk := range c.checks {
+		if c.isDue(chk, now) {
+			due <- chk
+		}
+	}
+	close(due)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		c.mu.Lock()
+		c.healthy = false
+		c.mu.Unlock()
+		return nil, fmt.Errorf("checks: %w", err)
+	}
+	return c.status(), nil
+}
+
+// attempt is the outcome of running a check once.
+type attempt struct {
+	latency time.Duration
+	err     error
+	at      time.Time
+}
+
+// run performs one attempt of chk within its timeout.
+func (c *Collector) run(ctx context.Context, chk Check) attempt {
+	timeout := chk.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch chk.Type {
+	case TypeHTTP:
+		err = checkHTTP(ctx, chk)
+	case TypeTCP:
+		err = checkTCP(ctx, chk.Target)
+	case TypePing:
+		err = c.ping(ctx, chk.Target)
+	default:
+		err = fmt.Errorf("unknown check type %q", chk.Type)
+	}
+	return attempt{latency: time.Since(start), err: err, at: start}
+}
+
+// isDue reports whether chk should run at now.
+func (c *Collector) isDue(chk Check, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev, ok := c.results[chk.Name]
+	return !ok || chk.Interval <= 0 || now.Sub(prev.CheckedAt) >= chk.Interval
+}
+
+// record folds an attempt into the check's result. A failure only turns a
+// check down once it has failed threshold times in a row; until then it
+// keeps its previous state, or is pending if it has never succeeded.
+func (c *Collector) record(chk Check, a attempt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, seen := c.results[chk.Name]
+	r := Result{
+		Name:      chk.Name,
+		Type:      chk.Type,
+		Target:    chk.Target,
+		LatencyMs: float64(a.latency.Microseconds()) / 1000,
+		CheckedAt: a.at,
+		Group:     chk.Group,
+		Critical:  chk.Critical,
+	}
+	if a.err == nil {
+		r.State = StateUp
+	} else {
+		r.Error = a.err.Error()
+		r.Failures = prev.Failures + 1
+		switch {
+		case r.Failures >= c.threshold:
+			r.State = StateDown
+		case seen && prev.State != StatePending:
+			r.State = prev.State
+		default:
+			r.State = StatePending
+		}
+	}
+	c.results[chk.Name] = r
+	c.healthy = true
+}
+
+// status snapshots the results in name order.
+func (c *Collector) status() *Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &Status{Checks: make([]Result, 0, len(c.results)), Timestamp: time.Now()}
+	for _, chk := range c.checks {
+		r, ok := c.results[chk.Name]
+		if !ok {
+			continue
+		}
+		s.Checks = append(s.Checks, r)
+		switch r.State {
+		case StateUp:
+			s.Up++
+		case StateDown:
+			s.Down++
+		}
+	}
+	s.Total = len(s.Checks)
+	sort.Slice(s.Checks, func(i, j int) bool {
+		return s.Checks[i].Name < s.Checks[j].Name
+	})
+	return s
+}
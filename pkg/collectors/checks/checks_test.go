@@ -0,0 +1,335 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collect runs one collection and returns the status.
+func collect(t *testing.T, c *Collector) *Status {
+	t.Helper()
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	return data.(*Status)
+}
+
+// result returns the named check from s.
+func result(t *testing.T, s *Status, name string) Result {
+	t.Helper()
+	for _, r := range s.Checks {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no result for %q in %+v", name, s.Checks)
+	return Result{}
+}
+
+// closedAddr returns a local address nothing listens on.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestHTTPCheckStatusAndBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			fmt.Fprint(w, `{"status":"ok"}`)
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{FailureThreshold: 1, Checks: []Check{
+		{Name: "health", Type: TypeHTTP, Target: srv.URL + "/health", ExpectBody: `"ok"`},
+		{Name: "body", Type: TypeHTTP, Target: srv.URL + "/health", ExpectBody: "degraded"},
+		{Name: "missing", Type: TypeHTTP, Target: srv.URL + "/nope"},
+		{Name: "teapot", Type: TypeHTTP, Target: srv.URL + "/teapot", ExpectStatus: http.StatusTeapot},
+	}})
+	s := collect(t, c)
+
+	if r := result(t, s, "health"); r.State != StateUp || r.Error != "" || r.LatencyMs <= 0 {
+		t.Errorf("health = %+v, want up with latency", r)
+	}
+	if r := result(t, s, "body"); r.State != StateDown || !strings.Contains(r.Error, `does not contain "degraded"`) {
+		t.Errorf("body = %+v, want down on missing substring", r)
+	}
+	if r := result(t, s, "missing"); r.State != StateDown || r.Error != "HTTP 404" {
+		t.Errorf("missing = %+v, want down with HTTP 404", r)
+	}
+	if r := result(t, s, "teapot"); r.State != StateUp {
+		t.Errorf("teapot = %+v, want up on the expected status", r)
+	}
+	if s.Up != 2 || s.Down != 2 || s.Total != 4 {
+		t.Errorf("Up/Down/Total = %d/%d/%d, want 2/2/4", s.Up, s.Down, s.Total)
+	}
+	if s.Checks[0].Name != "body" {
+		t.Errorf("checks not sorted by name: first is %q", s.Checks[0].Name)
+	}
+}
+
+func TestTCPCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	c := New(Config{FailureThreshold: 1, Checks: []Check{
+		{Name: "open", Type: TypeTCP, Target: ln.Addr().String()},
+		{Name: "closed", Type: TypeTCP, Target: closedAddr(t)},
+	}})
+	s := collect(t, c)
+	if r := result(t, s, "open"); r.State != StateUp {
+		t.Errorf("open = %+v, want up", r)
+	}
+	if r := result(t, s, "closed"); r.State != StateDown || !strings.Contains(r.Error, "refused") {
+		t.Errorf("closed = %+v, want down with connection refused", r)
+	}
+}
+
+func TestFailureThresholdSuppressesFlaps(t *testing.T) {
+	var failing atomic.Bool
+	c := New(Config{FailureThreshold: 3, Checks: []Check{{Name: "gw", Type: TypePing, Target: "10.0.0.1"}}})
+	c.ping = func(ctx context.Context, host string) error {
+		if failing.Load() {
+			return errors.New("ping: 100% packet loss")
+		}
+		return nil
+	}
+
+	failing.Store(true)
+	if r := result(t, collect(t, c), "gw"); r.State != StatePending || r.Failures != 1 {
+		t.Errorf("first failure = %+v, want pending", r)
+	}
+
+	failing.Store(false)
+	if r := result(t, collect(t, c), "gw"); r.State != StateUp || r.Failures != 0 {
+		t.Errorf("after success = %+v, want up with failures reset", r)
+	}
+
+	failing.Store(true)
+	for i := 1; i <= 2; i++ {
+		if r := result(t, collect(t, c), "gw"); r.State != StateUp || r.Failures != i {
+			t.Errorf("failure %d = %+v, want still up", i, r)
+		}
+	}
+	r := result(t, collect(t, c), "gw")
+	if r.State != StateDown || r.Failures != 3 || r.Error != "ping: 100% packet loss" {
+		t.Errorf("third failure = %+v, want down with the reason", r)
+	}
+}
+
+func TestWorkersBoundConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer srv.Close()
+
+	var list []Check
+	for i := 0; i < 6; i++ {
+		list = append(list, Check{Name: fmt.Sprintf("c%d", i), Type: TypeHTTP, Target: srv.URL})
+	}
+	c := New(Config{Workers: 2, Checks: list})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		collect(t, c)
+	}()
+	for i := 0; i < 6; i++ {
+		// Let the pool fill before releasing each request.
+		want := int32(min(2, 6-i))
+		deadline := time.Now().Add(5 * time.Second)
+		for running.Load() < want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		release <- struct{}{}
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrent checks = %d, want 2", p)
+	}
+}
+
+func TestCheckIntervalSkipsRecentChecks(t *testing.T) {
+	var calls atomic.Int32
+	c := New(Config{Checks: []Check{
+		{Name: "slow", Type: TypePing, Target: "a", Interval: time.Hour},
+		{Name: "fast", Type: TypePing, Target: "b"},
+	}})
+	c.ping = func(ctx context.Context, host string) error {
+		calls.Add(1)
+		return nil
+	}
+
+	collect(t, c)
+	s := collect(t, c)
+	if n := calls.Load(); n != 3 {
+		t.Errorf("ping calls = %d, want 3 (slow once, fast twice)", n)
+	}
+	if s.Total != 2 {
+		t.Errorf("Total = %d, want 2: skipped checks keep their result", s.Total)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	c := New(Config{FailureThreshold: 1, Checks: []Check{
+		{Name: "hang", Type: TypePing, Target: "x", Timeout: 20 * time.Millisecond},
+	}})
+	c.ping = func(ctx context.Context, host string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	r := result(t, collect(t, c), "hang")
+	if r.State != StateDown || !strings.Contains(r.Error, "deadline exceeded") {
+		t.Errorf("hang = %+v, want down on timeout", r)
+	}
+}
+
+func TestCollectCancelledDoesNotCountFailures(t *testing.T) {
+	c := New(Config{FailureThreshold: 1, Checks: []Check{{Name: "gw", Type: TypePing, Target: "x"}}})
+	c.ping = func(ctx context.Context, host string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Collect(ctx); err == nil {
+		t.Fatal("Collect() with a cancelled context succeeded")
+	}
+	if _, ok := c.results["gw"]; ok {
+		t.Error("cancelled attempt was recorded as a failure")
+	}
+}
+
+func TestCollectRecordsGroup(t *testing.T) {
+	c := New(Config{FailureThreshold: 1, Checks: []Check{
+		{Name: "db", Type: TypePing, Target: "x", Group: "website", Critical: true},
+		{Name: "nas", Type: TypePing, Target: "x"},
+	}})
+	c.ping = func(ctx context.Context, host string) error { return nil }
+	s := collect(t, c)
+	if r := result(t, s, "db"); r.Group != "website" || !r.Critical {
+		t.Errorf("db = %+v, want group website, critical", r)
+	}
+	if r := result(t, s, "nas"); r.Group != "" {
+		t.Errorf("nas group = %q, want none", r.Group)
+	}
+}
+
+func TestGroupsRollUp(t *testing.T) {
+	s := &Status{Checks: []Result{
+		{Name: "api", Group: "website", State: StateUp},
+		{Name: "cdn", Group: "website", State: StateDown},
+		{Name: "db", Group: "website", State: StateUp, Critical: true},
+		{Name: "primary", Group: "storage", State: StateDown, Critical: true},
+		{Name: "replica", Group: "storage", State: StateUp},
+		{Name: "router", State: StateUp},
+		{Name: "nas", State: StateUp},
+		{Name: "mqtt", Group: "home", State: StateDown},
+		{Name: "hass", Group: "home", State: StateDown},
+		{Name: "vpn", Group: "edge", State: StatePending},
+		{Name: "dns", Group: "edge", State: StateUp},
+		{Name: "grafana", Group: "metrics", State: StateUp},
+	}}
+	if !s.Grouped() {
+		t.Error("Grouped() = false, want true")
+	}
+
+	var got []string
+	for _, g := range s.Groups() {
+		got = append(got, fmt.Sprintf("%s %s %d/%d", g.Name, g.State, g.Up, g.Total))
+	}
+	want := []string{
+		"edge warn 1/2", // pending member
+		"home down 0/2", // every member down
+		"metrics ok 1/1",
+		"storage down 1/2", // critical member down
+		"website warn 2/3", // non-critical member down
+		"other ok 2/2",     // ungrouped, last
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Groups() = %q, want %q", got, want)
+	}
+	if o := s.Overall(); o != GroupDown {
+		t.Errorf("Overall() = %q, want down", o)
+	}
+
+	members := s.Groups()[4].Members
+	if members[0].Name != "api" || members[2].Name != "db" {
+		t.Errorf("website members = %+v, want name order", members)
+	}
+}
+
+func TestGroupsUngrouped(t *testing.T) {
+	s := &Status{Checks: []Result{{Name: "a", State: StateUp}, {Name: "b", State: StatePending}}}
+	if s.Grouped() {
+		t.Error("Grouped() = true for checks without groups")
+	}
+	groups := s.Groups()
+	if len(groups) != 1 || groups[0].Name != GroupOther || groups[0].State != GroupWarn {
+		t.Errorf("Groups() = %+v, want one warn %q group", groups, GroupOther)
+	}
+	if o := (&Status{}).Overall(); o != GroupOK {
+		t.Errorf("Overall() with no checks = %q, want ok", o)
+	}
+}
+
+func TestGroupLabel(t *testing.T) {
+	tests := []struct {
+		g    Group
+		want string
+	}{
+		{Group{Name: "website", State: GroupOK, Up: 3, Total: 3}, "website ✓ 3/3"},
+		{Group{Name: "edge", State: GroupWarn, Up: 1, Total: 2}, "edge ⚠ 1/2"},
+		{Group{Name: "storage", State: GroupDown, Up: 1, Total: 2}, "storage ✗ 1/2"},
+	}
+	for _, tt := range tests {
+		if got := tt.g.Label(); got != tt.want {
+			t.Errorf("Label() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package checks
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GroupOther is the group of checks that name none.
+const GroupOther = "other"
+
+// Group states, rolled up from the states of their members.
+const (
+	GroupOK   = "ok"
+	GroupWarn = "warn" // a member is down or pending
+	GroupDown = "down" // a critical member, or every member, is down
+)
+
+// Group is the rolled-up state of the checks of one service.
+type Group struct {
+	Name    string
+	State   string
+	Up      int
+	Total   int
+	Members []Result // in name order
+}
+
+// Label renders the group with a state glyph and its member counts.
+// Example: "website ✓ 3/3"
+func (g Group) Label() string {
+	glyph := "✓"
+	switch g.State {
+	case GroupWarn:
+		glyph = "⚠"
+	case GroupDown:
+		glyph = "✗"
+	}
+	return fmt.Sprintf("%s %s %d/%d", g.Name, glyph, g.Up, g.Total)
+}
+
+// Grouped reports whether any check names a group, so displays can keep
+// the flat per-check view for configs that define none.
+func (s *Status) Grouped() bool {
+	for _, r := range s.Checks {
+		if r.Group != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups rolls the checks up into their groups, in name order with
+// GroupOther last. A group is down when any critical member is down or
+// every member is, and warn when any other member is down or pending.
+func (s *Status) Groups() []Group {
+	index := make(map[string]int)
+	var groups []Group
+	for _, r := range s.Checks {
+		name := r.Group
+		if name == "" {
+			name = GroupOther
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, Group{Name: name})
+		}
+		groups[i].Members = append(groups[i].Members, r)
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		sort.Slice(g.Members, func(a, b int) bool { return g.Members[a].Name < g.Members[b].Name })
+		g.Total = len(g.Members)
+		down, criticalDown := 0, false
+		for _, r := range g.Members {
+			switch r.State {
+			case StateUp:
+				g.Up++
+			case StateDown:
+				down++
+				criticalDown = criticalDown || r.Critical
+			}
+		}
+		switch {
+		case criticalDown || down == g.Total:
+			g.State = GroupDown
+		case g.Up < g.Total:
+			g.State = GroupWarn
+		default:
+			g.State = GroupOK
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Name == GroupOther) != (groups[j].Name == GroupOther) {
+			return groups[j].Name == GroupOther
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// Overall returns the worst state of the groups, or GroupOK when there
+// are no checks.
+func (s *Status) Overall() string {
+	state := GroupOK
+	for _, g := range s.Groups() {
+		switch g.State {
+		case GroupDown:
+			return GroupDown
+		case GroupWarn:
+			state = GroupWarn
+		}
+	}
+	return state
+}
@@ -0,0 +1,81 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// maxBody caps how much of an HTTP response is searched for ExpectBody.
+const maxBody = 1 << 20
+
+// httpClient is shared by HTTP checks. Each attempt is bounded by its
+// context and never retried, since the failure threshold already absorbs
+// a transient failure. Redirects are followed so ExpectStatus sees the
+// final response.
+var httpClient = httpx.New(httpx.Config{Name: "checks", NoRetry: true})
+
+// checkHTTP requests chk.Target and verifies the status and body.
+func checkHTTP(ctx context.Context, chk Check) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chk.Target, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("User-Agent", "prompt-pulse-check")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if chk.ExpectStatus != 0 {
+		if resp.StatusCode != chk.ExpectStatus {
+			return fmt.Errorf("HTTP %d, want %d", resp.StatusCode, chk.ExpectStatus)
+		}
+	} else if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if chk.ExpectBody == "" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if !bytes.Contains(body, []byte(chk.ExpectBody)) {
+		return fmt.Errorf("body does not contain %q", chk.ExpectBody)
+	}
+	return nil
+}
+
+// checkTCP opens and closes a connection to addr.
+func checkTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// systemPing sends one echo request with the system ping binary, which
+// holds the privileges raw ICMP sockets need. The context bounds the wait.
+func systemPing(ctx context.Context, host string) error {
+	out, err := exec.CommandContext(ctx, "ping", "-c", "1", host).CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("ping: %w", ctx.Err())
+	}
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		return fmt.Errorf("ping: %s", lines[len(lines)-1])
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package claude
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -19,11 +20,24 @@ type Config struct {
 
 	// Accounts is the list of Anthropic accounts to monitor.
 	Accounts []AccountConfig
+
+	// HistoryDir, if set, is the directory where each collection's window
+	// usage is appended to the history file that forecasts are based on.
+	HistoryDir string
+
+	// Pricing replaces or adds entries of the built-in price table, keyed
+	// by model name or name prefix.
+	Pricing map[string]ModelPricing
+
+	// DefaultPricing, if set, replaces the rate for models that match no
+	// price table entry.
+	DefaultPricing *ModelPricing
 }
 
 // AccountConfig identifies a single Anthropic account.
 type AccountConfig struct {
-	// Name is a human-readable label (e.g., "personal", "work").
+	// Name is a human-readable label (e.g., "personal", "work"). It is
+	// carried through to AccountUsage.Name for display.
 	Name string
 
 	// AdminAPIKey is the Anthropic Admin API key for this account.
@@ -31,6 +45,24 @@ type AccountConfig struct {
 
 	// OrganizationID is the Anthropic organization identifier.
 	OrganizationID string
+
+	// Credentials is the path of a Claude Code credentials file (e.g.
+	// ~/.claude-work/.credentials.json) identifying a subscription account.
+	// Accounts with Credentials but no AdminAPIKey skip the Admin API and
+	// report plan utilization and session usage instead. Expired access
+	// tokens are refreshed and written back to the file.
+	Credentials string
+
+	// SessionsDir is a Claude Code projects directory (usually
+	// ~/.claude/projects) whose session transcripts are attributed to this
+	// account. When empty it defaults to the projects directory next to
+	// Credentials; with neither, session collection is disabled.
+	SessionsDir string
+
+	// WindowLimit is the account's token limit per usage window. When set
+	// along with SessionsDir and Config.HistoryDir, each report forecasts
+	// when the limit will be reached.
+	WindowLimit int64
 }
 
 // UsageReport is the top-level data returned by a single Collect call.
@@ -50,6 +82,33 @@ type AccountUsage struct {
 	PreviousMonth  MonthUsage       `json:"previous_month"`
 	Models         []ModelUsage     `json:"models"`
 	Workspaces     []WorkspaceUsage `json:"workspaces"`
+
+	// Sessions are the account's recent Claude Code sessions, most recent
+	// first; Window is the usage window in progress, if any. Both come
+	// from SessionsDir.
+	Sessions []SessionUsage `json:"sessions,omitempty"`
+	Window   *WindowUsage   `json:"window,omitempty"`
+
+	// Forecast projects when Window reaches the account's WindowLimit.
+	Forecast *Forecast `json:"forecast,omitempty"`
+
+	// Subscription and CredentialsExpiresAt come from the account's
+	// Credentials file, if any, and Plan from the OAuth usage endpoint.
+	Subscription         string     `json:"subscription,omitempty"`
+	CredentialsExpiresAt time.Time  `json:"credentials_expires_at,omitempty"`
+	Plan                 *PlanUsage `json:"plan,omitempty"`
+
+	// NeedsLogin is set when the Credentials refresh token was rejected,
+	// so the account stays disconnected until the user runs claude login.
+	NeedsLogin bool `json:"needs_login,omitempty"`
+
+	// SessionsCostUSD is the estimated cost of Sessions.
+	SessionsCostUSD float64 `json:"sessions_cost_usd,omitempty"`
+
+	// UnpricedModels lists, sorted, the models in this account's usage or
+	// sessions that matched no price table entry and were priced at the
+	// default rate.
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
 }
 
 // MonthUsage aggregates token counts and cost for a calendar month.
@@ -61,12 +120,15 @@ type MonthUsage struct {
 	CostUSD             float64 `json:"cost_usd"`
 }
 
-// ModelUsage breaks down usage by model within a single month.
+// ModelUsage breaks down usage by model within a single month. Unpriced
+// is set when the model matched no price table entry, so CostUSD is at the
+// default rate.
 type ModelUsage struct {
 	Model        string  `json:"model"`
 	InputTokens  int64   `json:"input_tokens"`
 	OutputTokens int64   `json:"output_tokens"`
 	CostUSD      float64 `json:"cost_usd"`
+	Unpriced     bool    `json:"unpriced,omitempty"`
 }
 
 // WorkspaceUsage breaks down usage by workspace. Currently populated as a
@@ -83,12 +145,17 @@ type WorkspaceUsage struct {
 // Collector gathers Anthropic API usage and cost data.
 type Collector struct {
 	client   APIClient
+	oauth    OAuthClient
 	accounts []AccountConfig
 	interval time.Duration
+	prices   PriceTable
 
 	// nowFunc allows tests to inject a deterministic clock.
 	nowFunc func() time.Time
 
+	// history is nil unless Config.HistoryDir is set.
+	history *History
+
 	mu      sync.Mutex
 	healthy bool
 }
@@ -103,13 +170,19 @@ func New(cfg Config, client APIClient) *Collector {
 	if client == nil {
 		client = NewHTTPClient("")
 	}
-	return &Collector{
+	c := &Collector{
 		client:   client,
+		oauth:    newOAuthHTTPClient(),
 		accounts: cfg.Accounts,
 		interval: interval,
+		prices:   DefaultPriceTable(cfg.Pricing, cfg.DefaultPricing),
 		nowFunc:  time.Now,
 		healthy:  true,
 	}
+	if cfg.HistoryDir != "" {
+		c.history = NewHistory(cfg.HistoryDir)
+	}
+	return c
 }
 
 // Name returns the collector identifier.
@@ -164,6 +237,15 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 		}
 
 		au := c.collectAccount(ctx, acct, curStart, curEnd, prevStart, prevEnd)
+		if dir := acct.sessionsDir(); dir != "" {
+			au.Sessions, au.Window = scanSessions(dir, now, c.prices)
+			for _, s := range au.Sessions {
+				au.SessionsCostUSD += s.CostUSD
+				if s.UnpricedModel != "" {
+					au.UnpricedModels = addUnpriced(au.UnpricedModels, s.UnpricedModel)
+				}
+			}
+		}
 		report.Accounts = append(report.Accounts, au)
 		if au.Connected {
 			anyConnected = true
@@ -171,10 +253,100 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 		report.TotalCostUSD += au.CurrentMonth.CostUSD
 	}
 
+	if c.history != nil {
+		c.forecast(report, now)
+	}
+
 	c.setHealthy(anyConnected || len(c.accounts) == 0)
 	return report, nil
 }
 
+// Spend returns the account's tokens (input plus output) and estimated
+// cost: this month's API usage, or, for accounts without any such as
+// subscriptions, the total of its recent sessions.
+func (a *AccountUsage) Spend() (tokens int64, costUSD float64) {
+	if m := a.CurrentMonth; m.CostUSD > 0 || m.InputTokens+m.OutputTokens > 0 {
+		return m.InputTokens + m.OutputTokens, m.CostUSD
+	}
+	for _, s := range a.Sessions {
+		tokens += s.InputTokens + s.OutputTokens
+	}
+	return tokens, a.SessionsCostUSD
+}
+
+// Spend returns the sum of every account's Spend, and the sorted models
+// any of them priced at the default rate.
+func (r *UsageReport) Spend() (tokens int64, costUSD float64, unpriced []string) {
+	for i := range r.Accounts {
+		t, c := r.Accounts[i].Spend()
+		tokens += t
+		costUSD += c
+		for _, m := range r.Accounts[i].UnpricedModels {
+			unpriced = addUnpriced(unpriced, m)
+		}
+	}
+	return tokens, costUSD, unpriced
+}
+
+// TopModel returns the model with the highest cost this month across all
+// accounts, or "" when no model has any.
+func (r *UsageReport) TopModel() string {
+	top := ""
+	var topCost float64
+	for _, a := range r.Accounts {
+		for _, m := range a.Models {
+			if m.CostUSD > topCost {
+				top, topCost = m.Model, m.CostUSD
+			}
+		}
+	}
+	return top
+}
+
+// WindowPercent returns the highest usage window percentage across
+// accounts: the plan's five-hour utilization, or window tokens against the
+// configured window limit. It reports false when no account has either.
+func (r *UsageReport) WindowPercent() (float64, bool) {
+	var highest float64
+	found := false
+	for _, a := range r.Accounts {
+		var pct float64
+		switch {
+		case a.Plan != nil && a.Plan.FiveHour != nil:
+			pct = a.Plan.FiveHour.Utilization
+		case a.Window != nil && a.Forecast != nil && a.Forecast.Limit > 0:
+			pct = float64(a.Window.Tokens) / float64(a.Forecast.Limit) * 100
+		default:
+			continue
+		}
+		if !found || pct > highest {
+			highest, found = pct, true
+		}
+	}
+	return highest, found
+}
+
+// forecast records the report's window usage in the history and attaches a
+// Forecast to each account with a window limit. History errors only cost
+// the forecast, so they are not reported.
+func (c *Collector) forecast(report *UsageReport, now time.Time) {
+	snap := SnapshotFromReport(report)
+	if len(snap.Accounts) == 0 {
+		return
+	}
+	if err := c.history.Append(snap); err != nil {
+		return
+	}
+	snaps, err := c.history.Load()
+	if err != nil {
+		return
+	}
+	for i, acct := range c.accounts {
+		au := &report.Accounts[i]
+		au.Forecast = ForecastWindow(snaps, au.Name, au.Window, acct.WindowLimit, now)
+	}
+}
+
 // collectAccount fetches usage for a single account, returning an
 // AccountUsage. Errors are captured in the struct rather than propagated.
 func (c *Collector) collectAccount(
@@ -187,6 +359,18 @@ func (c *Collector) collectAccount(
 		OrganizationID: acct.OrganizationID,
 	}
 
+	if acct.Credentials != "" {
+		if err := c.collectCredentials(ctx, acct, &au); err != nil {
+			au.Error = err.Error()
+			au.NeedsLogin = errors.Is(err, ErrRefreshRejected)
+			return au
+		}
+		if acct.AdminAPIKey == "" {
+			au.Connected = true
+			return au
+		}
+	}
+
 	// Fetch current month usage.
 	curResp, err := c.client.GetUsage(ctx, acct.OrganizationID, acct.AdminAPIKey, curStart, curEnd)
 	if err != nil {
@@ -195,20 +379,57 @@ func (c *Collector) collectAccount(
 	}
 
 	au.Connected = true
-	au.CurrentMonth = aggregateMonth(curResp)
-	au.Models = aggregateModels(curResp)
+	au.CurrentMonth = c.aggregateMonth(curResp)
+	au.Models = c.aggregateModels(curResp)
+	for _, m := range au.Models {
+		if m.Unpriced {
+			au.UnpricedModels = addUnpriced(au.UnpricedModels, m.Model)
+		}
+	}
 
 	// Fetch previous month usage (best-effort).
 	prevResp, err := c.client.GetUsage(ctx, acct.OrganizationID, acct.AdminAPIKey, prevStart, prevEnd)
 	if err == nil {
-		au.PreviousMonth = aggregateMonth(prevResp)
+		au.PreviousMonth = c.aggregateMonth(prevResp)
 	}
 
 	return au
 }
 
+// collectCredentials reads the account's Claude Code credentials into au,
+// refreshing an expired access token first. Accounts without an admin key
+// also fetch plan utilization, refreshing and retrying once if the access
+// token is rejected.
+func (c *Collector) collectCredentials(ctx context.Context, acct AccountConfig, au *AccountUsage) error {
+	now := c.nowFunc()
+	creds, err := ReadCredentials(acct.Credentials)
+	if err == nil && creds.expired(now) {
+		creds, err = RefreshCredentials(ctx, c.oauth, acct.Credentials, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	if acct.AdminAPIKey == "" {
+		au.Plan, err = c.oauth.Usage(ctx, creds.accessToken)
+		if errors.Is(err, errUnauthorized) {
+			if creds, err = RefreshCredentials(ctx, c.oauth, acct.Credentials, now); err != nil {
+				return err
+			}
+			au.Plan, err = c.oauth.Usage(ctx, creds.accessToken)
+		}
+		if err != nil {
+			return fmt.Errorf("plan usage: %w", err)
+		}
+	}
+
+	au.Subscription = creds.Subscription
+	au.CredentialsExpiresAt = creds.ExpiresAt
+	return nil
+}
+
 // aggregateMonth sums all entries in an API response into a single MonthUsage.
-func aggregateMonth(resp *APIUsageResponse) MonthUsage {
+func (c *Collector) aggregateMonth(resp *APIUsageResponse) MonthUsage {
 	if resp == nil {
 		return MonthUsage{}
 	}
@@ -218,28 +439,30 @@ func aggregateMonth(resp *APIUsageResponse) MonthUsage {
 		mu.OutputTokens += entry.OutputTokens
 		mu.CacheCreationTokens += entry.CacheCreationTokens
 		mu.CacheReadTokens += entry.CacheReadTokens
-		mu.CostUSD += CalculateCost(
+		cost, _ := c.prices.Cost(
 			entry.Model,
 			entry.InputTokens,
 			entry.OutputTokens,
 			entry.CacheCreationTokens,
 			entry.CacheReadTokens,
 		)
+		mu.CostUSD += cost
 	}
 	return mu
 }
 
 // aggregateModels builds per-model usage summaries from the API response.
-func aggregateModels(resp *APIUsageResponse) []ModelUsage {
+func (c *Collector) aggregateModels(resp *APIUsageResponse) []ModelUsage {
 	if resp == nil {
 		return nil
 	}
 
 	// Aggregate by model name.
 	type modelAcc struct {
-		input  int64
-		output int64
-		cost   float64
+		input    int64
+		output   int64
+		cost     float64
+		unpriced bool
 	}
 	byModel := make(map[string]*modelAcc)
 	order := make([]string, 0)
@@ -253,13 +476,15 @@ func aggregateModels(resp *APIUsageResponse) []ModelUsage {
 		}
 		acc.input += entry.InputTokens
 		acc.output += entry.OutputTokens
-		acc.cost += CalculateCost(
+		cost, known := c.prices.Cost(
 			entry.Model,
 			entry.InputTokens,
 			entry.OutputTokens,
 			entry.CacheCreationTokens,
 			entry.CacheReadTokens,
 		)
+		acc.cost += cost
+		acc.unpriced = !known
 	}
 
 	models := make([]ModelUsage, 0, len(byModel))
@@ -270,6 +495,7 @@ func aggregateModels(resp *APIUsageResponse) []ModelUsage {
 			InputTokens:  acc.input,
 			OutputTokens: acc.output,
 			CostUSD:      acc.cost,
+			Unpriced:     acc.unpriced,
 		})
 	}
 	return models
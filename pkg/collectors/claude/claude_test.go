@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -456,6 +457,60 @@ func TestLookupPricing_Fallback(t *testing.T) {
 	}
 }
 
+func TestPriceTable_Overrides(t *testing.T) {
+	fallback := ModelPricing{InputPer1M: 10, OutputPer1M: 50}
+	table := DefaultPriceTable(map[string]ModelPricing{
+		"claude-opus-4-6": {InputPer1M: 5, OutputPer1M: 25},
+		"claude-next":     {InputPer1M: 1, OutputPer1M: 2},
+	}, &fallback)
+
+	tests := []struct {
+		model     string
+		wantCost  float64 // for 1M input and 1M output tokens
+		wantKnown bool
+	}{
+		{"claude-opus-4-6", 5 + 25, true},
+		{"claude-next-1-20270101", 1 + 2, true},
+		{"claude-sonnet-4-5-20250929", 3 + 15, true}, // built-in entry kept
+		{"mystery-model", 10 + 50, false},
+	}
+	for _, tt := range tests {
+		cost, known := table.Cost(tt.model, 1_000_000, 1_000_000, 0, 0)
+		if math.Abs(cost-tt.wantCost) > 0.001 || known != tt.wantKnown {
+			t.Errorf("Cost(%s) = %.2f, %v; want %.2f, %v", tt.model, cost, known, tt.wantCost, tt.wantKnown)
+		}
+	}
+
+	if p := LookupPricing("claude-opus-4-6"); p.InputPer1M != 15.0 {
+		t.Errorf("overrides leaked into the built-in table: InputPer1M = %f", p.InputPer1M)
+	}
+}
+
+func TestUsageReport_Spend(t *testing.T) {
+	report := &UsageReport{Accounts: []AccountUsage{
+		{
+			Name:            "api",
+			CurrentMonth:    MonthUsage{InputTokens: 1000, OutputTokens: 500, CostUSD: 12.5},
+			SessionsCostUSD: 99, // ignored: the month's API usage wins
+			UnpricedModels:  []string{"claude-next"},
+		},
+		{
+			Name:            "subscription",
+			Sessions:        []SessionUsage{{InputTokens: 200, OutputTokens: 100}, {InputTokens: 50}},
+			SessionsCostUSD: 1.5,
+			UnpricedModels:  []string{"claude-alpha", "claude-next"},
+		},
+	}}
+
+	tokens, cost, unpriced := report.Spend()
+	if tokens != 1850 || math.Abs(cost-14) > 0.001 {
+		t.Errorf("Spend() = %d tokens, $%.2f; want 1850, $14.00", tokens, cost)
+	}
+	if got := strings.Join(unpriced, ","); got != "claude-alpha,claude-next" {
+		t.Errorf("unpriced = %q", got)
+	}
+}
+
 func TestDateRange_CurrentMonth(t *testing.T) {
 	now := time.Date(2026, 2, 9, 15, 30, 0, 0, time.UTC)
 	start, end := currentMonthRange(now)
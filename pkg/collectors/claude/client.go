@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
 )
 
 const (
@@ -59,10 +61,8 @@ func NewHTTPClient(baseURL string) *HTTPClient {
 		baseURL = defaultBaseURL
 	}
 	return &HTTPClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: httpTimeout,
-		},
+		baseURL:    baseURL,
+		httpClient: httpx.New(httpx.Config{Name: "claude", Timeout: httpTimeout}),
 	}
 }
 
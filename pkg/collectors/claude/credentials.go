@@ -0,0 +1,172 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// refreshSkew refreshes access tokens this long before they expire, so a
+// token does not lapse between the check and the request.
+const refreshSkew = time.Minute
+
+// Credentials is what the collector reads from a Claude Code credentials
+// file (~/.claude/.credentials.json, or .credentials.json under another
+// CLAUDE_CONFIG_DIR). The OAuth tokens are unexported so they never reach
+// a report or the cache.
+type Credentials struct {
+	// ExpiresAt is when the access token expires. The collector and
+	// Claude Code both refresh it when they find it expired.
+	ExpiresAt time.Time
+
+	// Subscription is the plan, e.g. "pro" or "max".
+	Subscription string
+
+	accessToken  string
+	refreshToken string
+}
+
+// expired reports whether the access token is expired, or about to be, at
+// now. Tokens without a recorded expiry are assumed valid.
+func (c *Credentials) expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt.Add(-refreshSkew))
+}
+
+// credentialsFile is the on-disk layout of .credentials.json.
+type credentialsFile struct {
+	ClaudeAiOauth *struct {
+		AccessToken      string `json:"accessToken"`
+		RefreshToken     string `json:"refreshToken"`
+		ExpiresAt        int64  `json:"expiresAt"` // Unix milliseconds
+		SubscriptionType string `json:"subscriptionType"`
+	} `json:"claudeAiOauth"`
+}
+
+// ReadCredentials parses the Claude Code credentials file at path. A
+// leading "~/" is expanded.
+func ReadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("claude credentials: %w", err)
+	}
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("claude credentials: parse %s: %w", path, err)
+	}
+	if f.ClaudeAiOauth == nil || f.ClaudeAiOauth.AccessToken == "" {
+		return nil, errors.New("claude credentials: " + path + ": no OAuth login")
+	}
+	c := &Credentials{
+		Subscription: f.ClaudeAiOauth.SubscriptionType,
+		accessToken:  f.ClaudeAiOauth.AccessToken,
+		refreshToken: f.ClaudeAiOauth.RefreshToken,
+	}
+	if ms := f.ClaudeAiOauth.ExpiresAt; ms > 0 {
+		c.ExpiresAt = time.UnixMilli(ms)
+	}
+	return c, nil
+}
+
+// sessionsDir returns the directory of the account's session transcripts:
+// SessionsDir if set, otherwise the projects directory next to its
+// Credentials file, where Claude Code keeps them.
+func (a AccountConfig) sessionsDir() string {
+	if a.SessionsDir != "" || a.Credentials == "" {
+		return a.SessionsDir
+	}
+	return filepath.Join(filepath.Dir(expandHome(a.Credentials)), "projects")
+}
+
+// RefreshCredentials exchanges the refresh token in the credentials file at
+// path for new tokens through client and writes them back. It returns
+// ErrRefreshRejected when the refresh token is missing or refused.
+func RefreshCredentials(ctx context.Context, client OAuthClient, path string, now time.Time) (*Credentials, error) {
+	creds, err := ReadCredentials(path)
+	if err != nil {
+		return nil, err
+	}
+	if creds.refreshToken == "" {
+		return nil, ErrRefreshRejected
+	}
+	ts, err := client.Refresh(ctx, creds.refreshToken)
+	if err != nil {
+		if errors.Is(err, ErrRefreshRejected) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("claude credentials: refresh: %w", err)
+	}
+
+	creds.accessToken = ts.AccessToken
+	if ts.RefreshToken != "" {
+		creds.refreshToken = ts.RefreshToken
+	}
+	creds.ExpiresAt = time.Time{}
+	if ts.ExpiresIn > 0 {
+		creds.ExpiresAt = now.Add(time.Duration(ts.ExpiresIn) * time.Second).Truncate(time.Millisecond)
+	}
+	if err := writeCredentials(path, creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// writeCredentials stores creds' tokens in the credentials file at path,
+// keeping every other field Claude Code wrote. The file is replaced
+// atomically and keeps its permissions.
+func writeCredentials(path string, creds *Credentials) error {
+	path = expandHome(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("claude credentials: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("claude credentials: %w", err)
+	}
+
+	var file map[string]json.RawMessage
+	var oauth map[string]interface{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("claude credentials: parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(file["claudeAiOauth"], &oauth); err != nil || oauth == nil {
+		return fmt.Errorf("claude credentials: parse %s: no OAuth login", path)
+	}
+	oauth["accessToken"] = creds.accessToken
+	oauth["refreshToken"] = creds.refreshToken
+	if !creds.ExpiresAt.IsZero() {
+		oauth["expiresAt"] = creds.ExpiresAt.UnixMilli()
+	}
+	if file["claudeAiOauth"], err = json.Marshal(oauth); err != nil {
+		return fmt.Errorf("claude credentials: encode: %w", err)
+	}
+	out, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("claude credentials: encode: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*.tmp")
+	if err != nil {
+		return fmt.Errorf("claude credentials: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("claude credentials: %w", err)
+	}
+	if _, err := tmp.Write(out); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("claude credentials: write: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("claude credentials: write: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("claude credentials: replace %s: %w", path, err)
+	}
+	return nil
+}
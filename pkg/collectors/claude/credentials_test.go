@@ -0,0 +1,115 @@
+package claude
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCredentialsFile writes a Claude Code credentials file into a new
+// config directory under root and returns its path.
+func writeCredentialsFile(t *testing.T, root, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(root, dir, ".credentials.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCredentials(t *testing.T) {
+	root := t.TempDir()
+	path := writeCredentialsFile(t, root, ".claude-work",
+		`{"claudeAiOauth":{"accessToken":"sk-ant-oat01-x","refreshToken":"r","expiresAt":1770658200000,"subscriptionType":"max"}}`)
+
+	c, err := ReadCredentials(path)
+	if err != nil {
+		t.Fatalf("ReadCredentials() error: %v", err)
+	}
+	if c.Subscription != "max" || !c.ExpiresAt.Equal(time.UnixMilli(1770658200000)) {
+		t.Errorf("credentials = %+v", c)
+	}
+}
+
+func TestReadCredentials_Errors(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name, body, want string
+	}{
+		{"malformed", `{`, "parse"},
+		{"no oauth", `{"other":{}}`, "no OAuth login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCredentialsFile(t, root, tt.name, tt.body)
+			if _, err := ReadCredentials(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := ReadCredentials(filepath.Join(root, "missing.json")); err == nil {
+		t.Error("missing file should be an error")
+	}
+}
+
+func TestAccountConfig_SessionsDir(t *testing.T) {
+	tests := []struct {
+		acct AccountConfig
+		want string
+	}{
+		{AccountConfig{}, ""},
+		{AccountConfig{SessionsDir: "/s"}, "/s"},
+		{AccountConfig{Credentials: "/home/u/.claude-work/.credentials.json"}, "/home/u/.claude-work/projects"},
+		{AccountConfig{Credentials: "/c/.credentials.json", SessionsDir: "/s"}, "/s"},
+	}
+	for _, tt := range tests {
+		if got := tt.acct.sessionsDir(); got != tt.want {
+			t.Errorf("%+v.sessionsDir() = %q, want %q", tt.acct, got, tt.want)
+		}
+	}
+}
+
+func TestCollect_CredentialAccounts(t *testing.T) {
+	root := t.TempDir()
+	now := fixedNow()
+	work := writeCredentialsFile(t, root, ".claude-work",
+		`{"claudeAiOauth":{"accessToken":"a","expiresAt":1770658200000,"subscriptionType":"max"}}`)
+	personal := writeCredentialsFile(t, root, ".claude", `{"claudeAiOauth":{"accessToken":"b","subscriptionType":"pro"}}`)
+	writeSession(t, filepath.Join(root, ".claude-work", "projects"), "p", "sess",
+		[]sessionMsg{{"m1", "claude-opus-4-6", now.Add(-time.Minute), 10, 20}})
+
+	client := newMockAPIClient()
+	c := New(Config{Accounts: []AccountConfig{
+		{Name: "work", Credentials: work},
+		{Name: "personal", Credentials: personal},
+		{Name: "broken", Credentials: filepath.Join(root, "nope", ".credentials.json")},
+	}}, client)
+	c.oauth = newMockOAuthClient("")
+	c.nowFunc = fixedNow
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if len(client.calls) != 0 {
+		t.Errorf("Admin API calls = %d, want none for credential-only accounts", len(client.calls))
+	}
+
+	report := result.(*UsageReport)
+	w, p, b := report.Accounts[0], report.Accounts[1], report.Accounts[2]
+	if w.Name != "work" || !w.Connected || w.Subscription != "max" || len(w.Sessions) != 1 || w.Window == nil {
+		t.Errorf("work = %+v, want connected max plan with its sessions", w)
+	}
+	if p.Name != "personal" || !p.Connected || p.Subscription != "pro" || len(p.Sessions) != 0 {
+		t.Errorf("personal = %+v, want connected pro plan without sessions", p)
+	}
+	if b.Name != "broken" || b.Connected || b.Error == "" {
+		t.Errorf("broken = %+v, want disconnected with an error", b)
+	}
+}
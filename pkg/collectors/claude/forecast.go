@@ -0,0 +1,109 @@
+package claude
+
+import (
+	"sort"
+	"time"
+)
+
+// Forecast tuning.
+const (
+	// ForecastLookback is how far back snapshots are used to measure the
+	// token consumption rate.
+	ForecastLookback = time.Hour
+
+	// forecastMaxGap is the longest interval between consecutive snapshots
+	// that still counts toward the rate. Longer intervals mean the daemon
+	// was not running, and are left out rather than averaged in as idle
+	// time.
+	forecastMaxGap = 15 * time.Minute
+
+	// forecastMinSpan is the least observed time needed for a rate.
+	forecastMinSpan = 5 * time.Minute
+)
+
+// Forecast projects when an account reaches its usage window token limit at
+// the recent consumption rate.
+type Forecast struct {
+	Limit         int64   `json:"limit"`
+	TokensPerHour float64 `json:"tokens_per_hour"`
+
+	// LimitAt is when the limit is projected to be reached. It is zero
+	// when the window resets first or nothing is being consumed.
+	LimitAt time.Time `json:"limit_at,omitempty"`
+}
+
+// Remaining returns the time from now until the projected limit, or false
+// if the limit is not projected to be reached this window. A limit already
+// reached yields zero.
+func (f *Forecast) Remaining(now time.Time) (time.Duration, bool) {
+	if f == nil || f.LimitAt.IsZero() {
+		return 0, false
+	}
+	d := f.LimitAt.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// ForecastWindow projects when account reaches limit tokens in window,
+// using the rate observed over the ForecastLookback before now in snaps.
+// Intervals spanning a gap in the history, or a window reset, are skipped.
+// It returns nil when there is no window, no limit, or too little history
+// to measure a rate.
+func ForecastWindow(snaps []Snapshot, account string, window *WindowUsage, limit int64, now time.Time) *Forecast {
+	if window == nil || limit <= 0 {
+		return nil
+	}
+	f := &Forecast{Limit: limit}
+	if window.Tokens >= limit {
+		f.LimitAt = now
+		return f
+	}
+
+	var points []Snapshot
+	from := now.Add(-ForecastLookback)
+	for _, s := range snaps {
+		if _, ok := s.Accounts[account]; ok && !s.Timestamp.Before(from) && !s.Timestamp.After(now) {
+			points = append(points, s)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+
+	var tokens int64
+	var span time.Duration
+	for i := 1; i < len(points); i++ {
+		gap := points[i].Timestamp.Sub(points[i-1].Timestamp)
+		delta := points[i].Accounts[account] - points[i-1].Accounts[account]
+		if gap <= 0 || gap > forecastMaxGap || delta < 0 {
+			continue
+		}
+		tokens += delta
+		span += gap
+	}
+	if span < forecastMinSpan {
+		return nil
+	}
+
+	f.TokensPerHour = float64(tokens) / span.Hours()
+	if f.TokensPerHour <= 0 {
+		return f
+	}
+	left := time.Duration(float64(limit-window.Tokens) / f.TokensPerHour * float64(time.Hour))
+	if at := now.Add(left); at.Before(window.Reset) {
+		f.LimitAt = at
+	}
+	return f
+}
+
+// SoonestLimit returns the account projected to reach its window limit
+// first and the time remaining from now, or false if no account is.
+func (r *UsageReport) SoonestLimit(now time.Time) (account string, remaining time.Duration, ok bool) {
+	for _, a := range r.Accounts {
+		d, hit := a.Forecast.Remaining(now)
+		if hit && (!ok || d < remaining) {
+			account, remaining, ok = a.Name, d, true
+		}
+	}
+	return account, remaining, ok
+}
@@ -0,0 +1,200 @@
+package claude
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// forecastSnaps returns snapshots of account's window tokens, one per
+// entry, taken at now minus the given offsets.
+func forecastSnaps(now time.Time, account string, points map[time.Duration]int64) []Snapshot {
+	var snaps []Snapshot
+	for ago, tokens := range points {
+		snaps = append(snaps, Snapshot{
+			Timestamp: now.Add(-ago),
+			Accounts:  map[string]int64{account: tokens},
+		})
+	}
+	return snaps
+}
+
+func TestForecastWindow(t *testing.T) {
+	now := fixedNow()
+	window := &WindowUsage{Start: now.Add(-2 * time.Hour), Reset: now.Add(3 * time.Hour), Tokens: 60_000}
+
+	tests := []struct {
+		name     string
+		points   map[time.Duration]int64
+		wantRate float64
+		wantLeft time.Duration // -1 for no projected limit
+		wantNil  bool
+	}{
+		{
+			name:     "steady rate",
+			points:   map[time.Duration]int64{30 * time.Minute: 40_000, 20 * time.Minute: 46_000, 10 * time.Minute: 53_000, 0: 60_000},
+			wantRate: 40_000,
+			wantLeft: 60 * time.Minute, // 40K left at 40K/h
+		},
+		{
+			name: "gap while the daemon was off is skipped",
+			points: map[time.Duration]int64{
+				55 * time.Minute: 0, 50 * time.Minute: 10_000,
+				10 * time.Minute: 50_000, 0: 60_000,
+			},
+			wantRate: 80_000, // 20K over 15m; the 40m gap is ignored
+			wantLeft: 30 * time.Minute,
+		},
+		{
+			name:     "window reset is skipped",
+			points:   map[time.Duration]int64{20 * time.Minute: 900_000, 10 * time.Minute: 50_000, 0: 60_000},
+			wantRate: 60_000,
+			wantLeft: 40 * time.Minute,
+		},
+		{
+			name:     "window resets first",
+			points:   map[time.Duration]int64{10 * time.Minute: 59_000, 0: 60_000},
+			wantRate: 6_000,
+			wantLeft: -1,
+		},
+		{
+			name:    "too little history",
+			points:  map[time.Duration]int64{2 * time.Minute: 59_000, 0: 60_000},
+			wantNil: true,
+		},
+		{
+			name:    "outside the lookback",
+			points:  map[time.Duration]int64{3 * time.Hour: 0, 2 * time.Hour: 50_000},
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := ForecastWindow(forecastSnaps(now, "work", tt.points), "work", window, 100_000, now)
+			if tt.wantNil {
+				if f != nil {
+					t.Fatalf("ForecastWindow() = %+v, want nil", f)
+				}
+				return
+			}
+			if f == nil {
+				t.Fatal("ForecastWindow() = nil")
+			}
+			if f.TokensPerHour != tt.wantRate {
+				t.Errorf("TokensPerHour = %v, want %v", f.TokensPerHour, tt.wantRate)
+			}
+			left, ok := f.Remaining(now)
+			if tt.wantLeft < 0 {
+				if ok {
+					t.Errorf("Remaining() = %v, want no limit before reset", left)
+				}
+				return
+			}
+			if !ok || left.Round(time.Second) != tt.wantLeft {
+				t.Errorf("Remaining() = %v, %v, want %v", left, ok, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestForecastWindow_LimitReached(t *testing.T) {
+	now := fixedNow()
+	window := &WindowUsage{Reset: now.Add(time.Hour), Tokens: 120_000}
+	f := ForecastWindow(nil, "work", window, 100_000, now)
+	if left, ok := f.Remaining(now); !ok || left != 0 {
+		t.Errorf("Remaining() = %v, %v, want 0 at the limit", left, ok)
+	}
+	if ForecastWindow(nil, "work", window, 0, now) != nil {
+		t.Error("no limit should give no forecast")
+	}
+	if ForecastWindow(nil, "work", nil, 100_000, now) != nil {
+		t.Error("no window should give no forecast")
+	}
+}
+
+func TestSoonestLimit_MultiAccount(t *testing.T) {
+	now := fixedNow()
+	r := &UsageReport{Accounts: []AccountUsage{
+		{Name: "personal"},
+		{Name: "work", Forecast: &Forecast{LimitAt: now.Add(40 * time.Minute)}},
+		{Name: "side", Forecast: &Forecast{LimitAt: now.Add(15 * time.Minute)}},
+		{Name: "idle", Forecast: &Forecast{}},
+	}}
+	name, left, ok := r.SoonestLimit(now)
+	if !ok || name != "side" || left != 15*time.Minute {
+		t.Errorf("SoonestLimit() = %q, %v, %v, want side in 15m", name, left, ok)
+	}
+
+	r.Accounts = r.Accounts[:1]
+	if _, _, ok := r.SoonestLimit(now); ok {
+		t.Error("SoonestLimit() should report nothing without forecasts")
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	now := fixedNow()
+	r := &UsageReport{Timestamp: now, Accounts: []AccountUsage{
+		{
+			Name:         "work",
+			CurrentMonth: MonthUsage{InputTokens: 100, OutputTokens: 50, CostUSD: 3},
+			Models:       []ModelUsage{{Model: "claude-haiku-4-5", CostUSD: 1}, {Model: "claude-opus-4-1", CostUSD: 2}},
+			Window:       &WindowUsage{Tokens: 600},
+			Forecast:     &Forecast{Limit: 1000, LimitAt: now.Add(20 * time.Minute)},
+		},
+		{Name: "personal", SessionsCostUSD: 1, UnpricedModels: []string{"claude-next"}},
+	}}
+	s := NewSummary(r)
+	if !s.Current() || s.Tokens != 150 || s.CostUSD != 4 || s.TopModel != "claude-opus-4-1" || s.Accounts != 2 {
+		t.Errorf("NewSummary() = %+v", s)
+	}
+	if len(s.UnpricedModels) != 1 || !s.HasWindow || s.WindowPercent != 60 {
+		t.Errorf("NewSummary() unpriced %v, window %g %v", s.UnpricedModels, s.WindowPercent, s.HasWindow)
+	}
+	if name, left, ok := s.SoonestLimit(now); !ok || name != "work" || left != 20*time.Minute {
+		t.Errorf("SoonestLimit() = %q, %v, %v, want work in 20m", name, left, ok)
+	}
+	if _, left, ok := s.SoonestLimit(now.Add(time.Hour)); !ok || left != 0 {
+		t.Errorf("SoonestLimit() past the limit = %v, %v, want 0, true", left, ok)
+	}
+}
+
+func TestCollect_RecordsHistoryAndForecasts(t *testing.T) {
+	sessions, cache := t.TempDir(), t.TempDir()
+	now := fixedNow()
+	writeSession(t, sessions, "p", "sess", []sessionMsg{{"m1", "claude-sonnet-4-5", now.Add(-time.Minute), 1_000, 2_000}})
+
+	h := NewHistory(cache)
+	for _, s := range forecastSnaps(now, "personal", map[time.Duration]int64{20 * time.Minute: 0, 10 * time.Minute: 1_500}) {
+		if err := h.Append(s); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	c := New(Config{HistoryDir: cache, Accounts: []AccountConfig{
+		{Name: "personal", OrganizationID: "org", SessionsDir: sessions, WindowLimit: 10_000},
+		{Name: "work", OrganizationID: "org-work"},
+	}}, newMockAPIClient())
+	c.nowFunc = fixedNow
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*UsageReport)
+	f := report.Accounts[0].Forecast
+	if f == nil || f.TokensPerHour != 9_000 {
+		t.Fatalf("personal forecast = %+v, want 9K tokens/hour", f)
+	}
+	if left, ok := f.Remaining(now); !ok || left.Round(time.Second) != 46*time.Minute+40*time.Second {
+		t.Errorf("Remaining() = %v, %v, want 46m40s", left, ok)
+	}
+	if report.Accounts[1].Forecast != nil {
+		t.Error("work has no window and should have no forecast")
+	}
+
+	snaps, err := h.Load()
+	if err != nil || len(snaps) != 3 || snaps[2].Accounts["personal"] != 3_000 {
+		t.Errorf("history = %+v, %v, want the new snapshot appended", snaps, err)
+	}
+}
@@ -0,0 +1,179 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// HistoryFileName is the name of the Claude usage history file inside the
+// state directory.
+const HistoryFileName = "claude-history.jsonl"
+
+// historyRetention is how long snapshots are kept. Forecasts only look back
+// ForecastLookback, so a day is ample.
+const historyRetention = 24 * time.Hour
+
+// Snapshot is one timestamped line of Claude usage history: the tokens each
+// account had used in its current usage window at the time of a
+// collection. Accounts without an open window are omitted.
+type Snapshot struct {
+	Timestamp time.Time        `json:"ts"`
+	Accounts  map[string]int64 `json:"accounts"`
+}
+
+// History is an append-only JSON lines file of usage snapshots. All access
+// goes through an flock on a sibling lock file, so the daemon and any
+// number of single-shot runs can append to the same file safely.
+type History struct {
+	path string
+}
+
+// NewHistory returns a History stored in dir.
+func NewHistory(dir string) *History {
+	return &History{path: filepath.Join(dir, HistoryFileName)}
+}
+
+// Path returns the history file path.
+func (h *History) Path() string {
+	return h.path
+}
+
+// SnapshotFromReport builds a Snapshot from the window usage of each
+// account in r.
+func SnapshotFromReport(r *UsageReport) Snapshot {
+	s := Snapshot{
+		Timestamp: r.Timestamp,
+		Accounts:  make(map[string]int64, len(r.Accounts)),
+	}
+	for _, a := range r.Accounts {
+		if a.Window != nil {
+			s.Accounts[a.Name] = a.Window.Tokens
+		}
+	}
+	return s
+}
+
+// Append adds a snapshot to the history. Snapshots older than the
+// retention period are pruned whenever the oldest line has expired.
+func (h *History) Append(s Snapshot) error {
+	line, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("claude history: encode snapshot: %w", err)
+	}
+	line = append(line, '\n')
+
+	unlock, err := h.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("claude history: open %s: %w", h.path, err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("claude history: append: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("claude history: close: %w", err)
+	}
+
+	return h.pruneLocked(s.Timestamp)
+}
+
+// Load returns all snapshots, oldest first. A missing file yields no
+// snapshots and no error; malformed lines are skipped.
+func (h *History) Load() ([]Snapshot, error) {
+	unlock, err := h.lock(syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return h.readLocked()
+}
+
+// --- history helpers ---
+
+// lock takes an flock of the given kind on the history lock file and
+// returns a function that releases it.
+func (h *History) lock(how int) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return nil, fmt.Errorf("claude history: create directory: %w", err)
+	}
+	f, err := os.OpenFile(h.path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("claude history: open lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("claude history: lock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// readLocked parses the history file. The caller must hold the lock.
+func (h *History) readLocked() ([]Snapshot, error) {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claude history: read %s: %w", h.path, err)
+	}
+
+	var snaps []Snapshot
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var s Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+	return snaps, nil
+}
+
+// pruneLocked rewrites the history without expired snapshots. It is a no-op
+// unless the oldest snapshot has expired, so the common append path does
+// not rewrite the file. The caller must hold the exclusive lock.
+func (h *History) pruneLocked(now time.Time) error {
+	snaps, err := h.readLocked()
+	if err != nil || len(snaps) == 0 {
+		return err
+	}
+	cutoff := now.Add(-historyRetention)
+	if !snaps[0].Timestamp.Before(cutoff) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, s := range snaps {
+		if s.Timestamp.Before(cutoff) {
+			continue
+		}
+		line, _ := json.Marshal(s)
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("claude history: write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("claude history: replace %s: %w", h.path, err)
+	}
+	return nil
+}
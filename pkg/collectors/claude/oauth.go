@@ -0,0 +1,146 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// OAuth endpoints used by Claude Code subscription logins. Variables so
+// tests can point them at a local server.
+var (
+	oauthTokenURL = "https://console.anthropic.com/v1/oauth/token"
+	oauthUsageURL = defaultBaseURL + "/api/oauth/usage"
+)
+
+const (
+	// oauthClientID is Claude Code's public OAuth client ID, which issued
+	// the tokens in .credentials.json.
+	oauthClientID = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+
+	// oauthBeta is the beta header the OAuth usage endpoint requires.
+	oauthBeta = "oauth-2025-04-20"
+)
+
+// ErrRefreshRejected is returned when the OAuth server refuses a refresh
+// token. Only a new login can fix it.
+var ErrRefreshRejected = errors.New("refresh token rejected — run claude login")
+
+// errUnauthorized is returned by OAuthClient.Usage when the access token is
+// not accepted.
+var errUnauthorized = errors.New("access token rejected")
+
+// OAuthClient abstracts the Claude OAuth endpoints for testability.
+type OAuthClient interface {
+	// Usage returns the subscription's plan limits utilization. It returns
+	// errUnauthorized when the access token is rejected.
+	Usage(ctx context.Context, accessToken string) (*PlanUsage, error)
+
+	// Refresh exchanges a refresh token for new tokens. It returns
+	// ErrRefreshRejected when the refresh token is no longer valid.
+	Refresh(ctx context.Context, refreshToken string) (*TokenSet, error)
+}
+
+// TokenSet is the result of a token refresh.
+type TokenSet struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds
+}
+
+// PlanUsage is a subscription's utilization of its plan limits.
+type PlanUsage struct {
+	FiveHour *PlanWindow `json:"five_hour,omitempty"`
+	SevenDay *PlanWindow `json:"seven_day,omitempty"`
+}
+
+// PlanWindow is the utilization of one plan limit.
+type PlanWindow struct {
+	Utilization float64   `json:"utilization"` // percent, 0-100
+	ResetsAt    time.Time `json:"resets_at"`
+}
+
+// oauthHTTPClient implements OAuthClient over HTTP.
+type oauthHTTPClient struct {
+	httpClient *http.Client
+}
+
+// newOAuthHTTPClient creates an oauthHTTPClient with the default timeout.
+func newOAuthHTTPClient() *oauthHTTPClient {
+	return &oauthHTTPClient{httpClient: httpx.New(httpx.Config{Name: "claude", Timeout: httpTimeout})}
+}
+
+// Usage calls the OAuth usage endpoint.
+func (c *oauthHTTPClient) Usage(ctx context.Context, accessToken string) (*PlanUsage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, oauthUsageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("anthropic-beta", oauthBeta)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("usage API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result PlanUsage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &result, nil
+}
+
+// Refresh calls the OAuth token endpoint with a refresh_token grant.
+func (c *oauthHTTPClient) Refresh(ctx context.Context, refreshToken string) (*TokenSet, error) {
+	body, _ := json.Marshal(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+		"client_id":     oauthClientID,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, oauthTokenURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// invalid_grant comes back as 400; some deployments use 401.
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrRefreshRejected
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("token refresh returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var ts TokenSet
+	if err := json.NewDecoder(resp.Body).Decode(&ts); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if ts.AccessToken == "" {
+		return nil, errors.New("token refresh returned no access token")
+	}
+	return &ts, nil
+}
@@ -0,0 +1,223 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockOAuthClient is a test double for OAuthClient.
+type mockOAuthClient struct {
+	// valid, if set, is the only access token Usage accepts.
+	valid string
+	// refreshed is returned by Refresh; refreshErr overrides it.
+	refreshed  *TokenSet
+	refreshErr error
+	// calls records "usage:<token>" and "refresh:<token>" in order.
+	calls []string
+}
+
+func newMockOAuthClient(valid string) *mockOAuthClient {
+	return &mockOAuthClient{valid: valid}
+}
+
+func (m *mockOAuthClient) Usage(ctx context.Context, accessToken string) (*PlanUsage, error) {
+	m.calls = append(m.calls, "usage:"+accessToken)
+	if m.valid != "" && accessToken != m.valid {
+		return nil, errUnauthorized
+	}
+	return &PlanUsage{FiveHour: &PlanWindow{Utilization: 42}}, nil
+}
+
+func (m *mockOAuthClient) Refresh(ctx context.Context, refreshToken string) (*TokenSet, error) {
+	m.calls = append(m.calls, "refresh:"+refreshToken)
+	if m.refreshErr != nil {
+		return nil, m.refreshErr
+	}
+	return m.refreshed, nil
+}
+
+func TestRefreshCredentials_WritesBack(t *testing.T) {
+	path := writeCredentialsFile(t, t.TempDir(), ".claude",
+		`{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1","expiresAt":1,"subscriptionType":"max","scopes":["user:inference"]},"mcpOAuth":{"x":1}}`)
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	client := newMockOAuthClient("")
+	client.refreshed = &TokenSet{AccessToken: "new", RefreshToken: "r2", ExpiresIn: 3600}
+
+	creds, err := RefreshCredentials(context.Background(), client, path, fixedNow())
+	if err != nil {
+		t.Fatalf("RefreshCredentials() error: %v", err)
+	}
+	if creds.accessToken != "new" || !creds.ExpiresAt.Equal(fixedNow().Add(time.Hour)) {
+		t.Errorf("credentials = %+v", creds)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want 0640 kept", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	var file struct {
+		ClaudeAiOauth map[string]interface{} `json:"claudeAiOauth"`
+		McpOAuth      map[string]interface{} `json:"mcpOAuth"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("rewritten file: %v", err)
+	}
+	o := file.ClaudeAiOauth
+	if o["accessToken"] != "new" || o["refreshToken"] != "r2" || o["subscriptionType"] != "max" || o["scopes"] == nil {
+		t.Errorf("claudeAiOauth = %v, want new tokens and other fields kept", o)
+	}
+	if file.McpOAuth["x"] != float64(1) {
+		t.Errorf("mcpOAuth = %v, want kept", file.McpOAuth)
+	}
+	if got, _ := ReadCredentials(path); got == nil || !got.ExpiresAt.Equal(creds.ExpiresAt) {
+		t.Errorf("reread credentials = %+v, want expiry %v", got, creds.ExpiresAt)
+	}
+}
+
+func TestRefreshCredentials_Rejected(t *testing.T) {
+	root := t.TempDir()
+	client := newMockOAuthClient("")
+	client.refreshErr = ErrRefreshRejected
+
+	path := writeCredentialsFile(t, root, "a", `{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1"}}`)
+	if _, err := RefreshCredentials(context.Background(), client, path, fixedNow()); !errors.Is(err, ErrRefreshRejected) {
+		t.Errorf("err = %v, want ErrRefreshRejected", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"old"`) {
+		t.Error("a rejected refresh should leave the file alone")
+	}
+
+	path = writeCredentialsFile(t, root, "b", `{"claudeAiOauth":{"accessToken":"old"}}`)
+	if _, err := RefreshCredentials(context.Background(), client, path, fixedNow()); !errors.Is(err, ErrRefreshRejected) {
+		t.Errorf("no refresh token: err = %v, want ErrRefreshRejected", err)
+	}
+}
+
+func TestCollect_OAuthRefresh(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		refreshErr error
+		wantCalls  []string
+		wantLogin  bool
+		wantAccess string
+	}{
+		{
+			name:       "valid token",
+			body:       `{"claudeAiOauth":{"accessToken":"new","refreshToken":"r1"}}`,
+			wantCalls:  []string{"usage:new"},
+			wantAccess: "new",
+		},
+		{
+			name:       "unauthorized is refreshed and retried once",
+			body:       `{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1"}}`,
+			wantCalls:  []string{"usage:old", "refresh:r1", "usage:new"},
+			wantAccess: "new",
+		},
+		{
+			name:       "expired token is refreshed first",
+			body:       `{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1","expiresAt":1770600000000}}`,
+			wantCalls:  []string{"refresh:r1", "usage:new"},
+			wantAccess: "new",
+		},
+		{
+			name:       "rejected refresh needs login",
+			body:       `{"claudeAiOauth":{"accessToken":"old","refreshToken":"r1"}}`,
+			refreshErr: ErrRefreshRejected,
+			wantCalls:  []string{"usage:old", "refresh:r1"},
+			wantLogin:  true,
+			wantAccess: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCredentialsFile(t, t.TempDir(), ".claude", tt.body)
+			oauth := newMockOAuthClient("new")
+			oauth.refreshed = &TokenSet{AccessToken: "new", ExpiresIn: 3600}
+			oauth.refreshErr = tt.refreshErr
+
+			c := New(Config{Accounts: []AccountConfig{{Name: "personal", Credentials: path}}}, newMockAPIClient())
+			c.oauth = oauth
+			c.nowFunc = fixedNow
+
+			result, err := c.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error: %v", err)
+			}
+			a := result.(*UsageReport).Accounts[0]
+			if strings.Join(oauth.calls, " ") != strings.Join(tt.wantCalls, " ") {
+				t.Errorf("calls = %v, want %v", oauth.calls, tt.wantCalls)
+			}
+			if tt.wantLogin {
+				if a.Connected || !a.NeedsLogin || a.Error != ErrRefreshRejected.Error() {
+					t.Errorf("account = %+v, want disconnected needing login", a)
+				}
+			} else if !a.Connected || a.NeedsLogin || a.Plan == nil || a.Plan.FiveHour.Utilization != 42 {
+				t.Errorf("account = %+v, want connected with plan usage", a)
+			}
+			if creds, _ := ReadCredentials(path); creds == nil || creds.accessToken != tt.wantAccess {
+				t.Errorf("stored credentials = %+v, want access token %q", creds, tt.wantAccess)
+			}
+		})
+	}
+}
+
+func TestOAuthHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/usage":
+			if r.Header.Get("Authorization") != "Bearer good" || r.Header.Get("anthropic-beta") != oauthBeta {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"five_hour":{"utilization":12.5,"resets_at":"2026-02-09T18:00:00Z"},"seven_day":null}`))
+		case "/token":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["grant_type"] != "refresh_token" || body["refresh_token"] != "good" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"a2","refresh_token":"r2","expires_in":28800}`))
+		}
+	}))
+	defer srv.Close()
+
+	oldToken, oldUsage := oauthTokenURL, oauthUsageURL
+	oauthTokenURL, oauthUsageURL = srv.URL+"/token", srv.URL+"/usage"
+	defer func() { oauthTokenURL, oauthUsageURL = oldToken, oldUsage }()
+
+	c := newOAuthHTTPClient()
+	ctx := context.Background()
+
+	usage, err := c.Usage(ctx, "good")
+	if err != nil || usage.FiveHour == nil || usage.FiveHour.Utilization != 12.5 || usage.SevenDay != nil {
+		t.Errorf("Usage() = %+v, %v", usage, err)
+	}
+	if _, err := c.Usage(ctx, "bad"); !errors.Is(err, errUnauthorized) {
+		t.Errorf("Usage(bad) err = %v, want errUnauthorized", err)
+	}
+
+	ts, err := c.Refresh(ctx, "good")
+	if err != nil || ts.AccessToken != "a2" || ts.RefreshToken != "r2" || ts.ExpiresIn != 28800 {
+		t.Errorf("Refresh() = %+v, %v", ts, err)
+	}
+	if _, err := c.Refresh(ctx, "bad"); !errors.Is(err, ErrRefreshRejected) {
+		t.Errorf("Refresh(bad) err = %v, want ErrRefreshRejected", err)
+	}
+}
@@ -3,7 +3,10 @@
 // pricing tables.
 package claude
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 // ModelPricing holds the per-million-token costs for a given model.
 type ModelPricing struct {
@@ -80,12 +83,40 @@ var fallbackPricing = ModelPricing{
 	CacheReadPer1M:     0.30,
 }
 
-// LookupPricing returns the pricing for a model name. It first tries an
-// exact match, then the longest prefix match, and finally returns the
-// fallback pricing.
-func LookupPricing(model string) ModelPricing {
-	if p, ok := pricing[model]; ok {
-		return p
+// PriceTable prices token usage by model. Models are matched like the
+// built-in table: exact name first, then the longest prefix. Models that
+// match nothing are priced at Default and reported as unpriced, so their
+// cost is an estimate rather than silently zero.
+type PriceTable struct {
+	// Models maps model names or name prefixes to their pricing.
+	Models map[string]ModelPricing
+
+	// Default prices models that match no entry in Models.
+	Default ModelPricing
+}
+
+// DefaultPriceTable returns the built-in pricing with overrides applied
+// over it. Overrides replace or add models by name or prefix; a non-nil
+// fallback replaces the default rate for unknown models.
+func DefaultPriceTable(overrides map[string]ModelPricing, fallback *ModelPricing) PriceTable {
+	t := PriceTable{Models: make(map[string]ModelPricing, len(pricing)+len(overrides)), Default: fallbackPricing}
+	for model, p := range pricing {
+		t.Models[model] = p
+	}
+	for model, p := range overrides {
+		t.Models[model] = p
+	}
+	if fallback != nil {
+		t.Default = *fallback
+	}
+	return t
+}
+
+// Lookup returns the pricing for model and whether it matched an entry
+// rather than falling back to Default.
+func (t PriceTable) Lookup(model string) (ModelPricing, bool) {
+	if p, ok := t.Models[model]; ok {
+		return p, true
 	}
 
 	// Longest-prefix match: e.g. "claude-sonnet-4-5-20250929" matches
@@ -93,7 +124,7 @@ func LookupPricing(model string) ModelPricing {
 	bestLen := 0
 	var bestPricing ModelPricing
 	found := false
-	for prefix, p := range pricing {
+	for prefix, p := range t.Models {
 		if strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
 			bestLen = len(prefix)
 			bestPricing = p
@@ -101,20 +132,48 @@ func LookupPricing(model string) ModelPricing {
 		}
 	}
 	if found {
-		return bestPricing
+		return bestPricing, true
 	}
 
-	return fallbackPricing
+	return t.Default, false
 }
 
-// CalculateCost computes the dollar cost for a given model's token usage.
-func CalculateCost(model string, inputTokens, outputTokens, cacheCreation, cacheRead int64) float64 {
-	p := LookupPricing(model)
+// Cost computes the dollar cost of a model's token usage and whether the
+// model was priced from its own entry rather than Default.
+func (t PriceTable) Cost(model string, inputTokens, outputTokens, cacheCreation, cacheRead int64) (float64, bool) {
+	p, known := t.Lookup(model)
 
 	cost := float64(inputTokens) / 1_000_000.0 * p.InputPer1M
 	cost += float64(outputTokens) / 1_000_000.0 * p.OutputPer1M
 	cost += float64(cacheCreation) / 1_000_000.0 * p.CacheCreationPer1M
 	cost += float64(cacheRead) / 1_000_000.0 * p.CacheReadPer1M
 
+	return cost, known
+}
+
+// builtinPrices is the price table without overrides.
+var builtinPrices = DefaultPriceTable(nil, nil)
+
+// LookupPricing returns the built-in pricing for a model name. It first
+// tries an exact match, then the longest prefix match, and finally returns
+// the fallback pricing.
+func LookupPricing(model string) ModelPricing {
+	p, _ := builtinPrices.Lookup(model)
+	return p
+}
+
+// CalculateCost computes the dollar cost for a given model's token usage
+// at built-in prices.
+func CalculateCost(model string, inputTokens, outputTokens, cacheCreation, cacheRead int64) float64 {
+	cost, _ := builtinPrices.Cost(model, inputTokens, outputTokens, cacheCreation, cacheRead)
 	return cost
 }
+
+// addUnpriced adds model to the sorted set of unpriced models.
+func addUnpriced(models []string, model string) []string {
+	i := sort.SearchStrings(models, model)
+	if i < len(models) && models[i] == model {
+		return models
+	}
+	return append(models[:i], append([]string{model}, models[i:]...)...)
+}
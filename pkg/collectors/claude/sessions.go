@@ -0,0 +1,229 @@
+package claude
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session scanning limits.
+const (
+	// WindowDuration is the length of a Claude usage window. A window
+	// opens at the hour of the first message sent after the previous one
+	// closed.
+	WindowDuration = 5 * time.Hour
+
+	// SessionLookback bounds which session files are read: only those
+	// modified this recently.
+	SessionLookback = 24 * time.Hour
+
+	// maxSessions caps how many sessions are reported per account, most
+	// recent first.
+	maxSessions = 50
+)
+
+// SessionUsage summarizes one Claude Code session from its transcript.
+type SessionUsage struct {
+	ID                  string    `json:"id"`
+	Project             string    `json:"project,omitempty"`
+	Model               string    `json:"model"`
+	InputTokens         int64     `json:"input_tokens"`
+	OutputTokens        int64     `json:"output_tokens"`
+	CacheCreationTokens int64     `json:"cache_creation_tokens"`
+	CacheReadTokens     int64     `json:"cache_read_tokens"`
+	CostUSD             float64   `json:"cost_usd"`
+	LastActivity        time.Time `json:"last_activity"`
+
+	// UnpricedModel names a model the session used that matched no price
+	// table entry, so CostUSD is partly at the default rate.
+	UnpricedModel string `json:"unpriced_model,omitempty"`
+
+	// WindowTokens are the input and output tokens this session used in
+	// the current usage window.
+	WindowTokens int64 `json:"window_tokens"`
+}
+
+// WindowUsage is the usage window in progress.
+type WindowUsage struct {
+	Start   time.Time `json:"start"`
+	Reset   time.Time `json:"reset"`
+	Tokens  int64     `json:"tokens"` // input + output across all sessions
+	CostUSD float64   `json:"cost_usd"`
+}
+
+// sessionLine is the subset of a Claude Code transcript line we read.
+// Only assistant messages carry usage.
+type sessionLine struct {
+	Type      string    `json:"type"`
+	SessionID string    `json:"sessionId"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// sessionEvent is one billed assistant message.
+type sessionEvent struct {
+	session *SessionUsage
+	at      time.Time
+	tokens  int64
+	cost    float64
+}
+
+// scanSessions reads the Claude Code transcripts under dir
+// (<dir>/<project>/<session>.jsonl) modified within SessionLookback of now,
+// pricing them from prices. It returns per-session totals, most recent first, and the current usage
+// window, or nil when no window is open at now. Unreadable files and
+// malformed lines are skipped.
+func scanSessions(dir string, now time.Time, prices PriceTable) ([]SessionUsage, *WindowUsage) {
+	files, _ := filepath.Glob(filepath.Join(expandHome(dir), "*", "*.jsonl"))
+
+	var sessions []*SessionUsage
+	var events []sessionEvent
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil || now.Sub(info.ModTime()) > SessionLookback {
+			continue
+		}
+		s, evs := scanSessionFile(path, prices)
+		if s == nil {
+			continue
+		}
+		sessions = append(sessions, s)
+		events = append(events, evs...)
+	}
+
+	window := currentWindow(events, now)
+	if window != nil {
+		for _, ev := range events {
+			if !ev.at.Before(window.Start) && ev.at.Before(window.Reset) {
+				ev.session.WindowTokens += ev.tokens
+			}
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivity.After(sessions[j].LastActivity)
+	})
+	if len(sessions) > maxSessions {
+		sessions = sessions[:maxSessions]
+	}
+	out := make([]SessionUsage, len(sessions))
+	for i, s := range sessions {
+		out[i] = *s
+	}
+	return out, window
+}
+
+// scanSessionFile totals one transcript. Streaming can log the same
+// message more than once, so messages are counted once by ID.
+func scanSessionFile(path string, prices PriceTable) (*SessionUsage, []sessionEvent) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil
+	}
+	defer f.Close()
+
+	s := &SessionUsage{
+		ID:      strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+		Project: filepath.Base(filepath.Dir(path)),
+	}
+	var events []sessionEvent
+	seen := make(map[string]bool)
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if !strings.Contains(string(line), `"usage"`) {
+			continue
+		}
+		var l sessionLine
+		if err := json.Unmarshal(line, &l); err != nil || l.Type != "assistant" {
+			continue
+		}
+		if id := l.Message.ID; id != "" {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+
+		u := l.Message.Usage
+		cost, known := prices.Cost(l.Message.Model, u.InputTokens, u.OutputTokens,
+			u.CacheCreationInputTokens, u.CacheReadInputTokens)
+		s.InputTokens += u.InputTokens
+		s.OutputTokens += u.OutputTokens
+		s.CacheCreationTokens += u.CacheCreationInputTokens
+		s.CacheReadTokens += u.CacheReadInputTokens
+		s.CostUSD += cost
+		if l.SessionID != "" {
+			s.ID = l.SessionID
+		}
+		if !known && cost > 0 {
+			s.UnpricedModel = l.Message.Model
+		}
+		if !l.Timestamp.Before(s.LastActivity) {
+			s.LastActivity = l.Timestamp
+			if l.Message.Model != "" {
+				s.Model = l.Message.Model
+			}
+		}
+		events = append(events, sessionEvent{
+			session: s,
+			at:      l.Timestamp,
+			tokens:  u.InputTokens + u.OutputTokens,
+			cost:    cost,
+		})
+	}
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return s, events
+}
+
+// currentWindow replays events in time order to find the window open at
+// now. Each window starts at the hour of the first message after the
+// previous window closed.
+func currentWindow(events []sessionEvent, now time.Time) *WindowUsage {
+	sort.Slice(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	var w *WindowUsage
+	for _, ev := range events {
+		if ev.at.After(now) {
+			break
+		}
+		if w == nil || !ev.at.Before(w.Reset) {
+			start := ev.at.Truncate(time.Hour)
+			w = &WindowUsage{Start: start, Reset: start.Add(WindowDuration)}
+		}
+		w.Tokens += ev.tokens
+		w.CostUSD += ev.cost
+	}
+	if w == nil || !now.Before(w.Reset) {
+		return nil
+	}
+	return w
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
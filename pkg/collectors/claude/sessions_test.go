@@ -0,0 +1,178 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSession writes a Claude Code transcript with one assistant message
+// per entry in msgs.
+func writeSession(t *testing.T, dir, project, id string, msgs []sessionMsg) {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString(`{"type":"user","sessionId":"` + id + `","message":{"role":"user","content":"hi"}}` + "\n")
+	for _, m := range msgs {
+		fmt.Fprintf(&b, `{"type":"assistant","sessionId":%q,"timestamp":%q,"message":{"id":%q,"model":%q,"usage":{"input_tokens":%d,"output_tokens":%d,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}`+"\n",
+			id, m.at.Format(time.RFC3339), m.id, m.model, m.in, m.out)
+	}
+	b.WriteString("not json\n")
+	path := filepath.Join(dir, project, id+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type sessionMsg struct {
+	id      string
+	model   string
+	at      time.Time
+	in, out int64
+}
+
+func TestScanSessions(t *testing.T) {
+	dir := t.TempDir()
+	now := fixedNow() // 15:30
+	model := "claude-sonnet-4-5-20250929"
+
+	// Window opens at 13:00 (first message 13:10). The 08:00 message
+	// belongs to an earlier, closed window.
+	writeSession(t, dir, "-home-me-app", "sess-a", []sessionMsg{
+		{"m1", model, now.Add(-7*time.Hour - 30*time.Minute), 1000, 100},
+		{"m2", model, now.Add(-2*time.Hour - 20*time.Minute), 2000, 200},
+		{"m2", model, now.Add(-2*time.Hour - 20*time.Minute), 2000, 200}, // duplicate
+	})
+	writeSession(t, dir, "-home-me-lib", "sess-b", []sessionMsg{
+		{"m3", "claude-opus-4-6", now.Add(-10 * time.Minute), 5000, 500},
+	})
+
+	sessions, window := scanSessions(dir, now, builtinPrices)
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions))
+	}
+	if sessions[0].ID != "sess-b" || sessions[0].Project != "-home-me-lib" {
+		t.Errorf("sessions[0] = %+v, want most recent sess-b first", sessions[0])
+	}
+	a := sessions[1]
+	if a.InputTokens != 3000 || a.OutputTokens != 300 {
+		t.Errorf("sess-a tokens = %d/%d, want 3000/300 with duplicate dropped", a.InputTokens, a.OutputTokens)
+	}
+	if a.WindowTokens != 2200 {
+		t.Errorf("sess-a window tokens = %d, want 2200", a.WindowTokens)
+	}
+	if a.CostUSD <= 0 {
+		t.Error("sess-a cost should be estimated")
+	}
+
+	if window == nil {
+		t.Fatal("expected an open window")
+	}
+	wantStart := time.Date(2026, 2, 9, 13, 0, 0, 0, time.UTC)
+	if !window.Start.Equal(wantStart) || !window.Reset.Equal(wantStart.Add(WindowDuration)) {
+		t.Errorf("window = %v..%v, want 13:00..18:00", window.Start, window.Reset)
+	}
+	if window.Tokens != 2200+5500 {
+		t.Errorf("window tokens = %d, want 7700", window.Tokens)
+	}
+}
+
+func TestScanSessions_NoOpenWindow(t *testing.T) {
+	dir := t.TempDir()
+	now := fixedNow()
+	writeSession(t, dir, "p", "old", []sessionMsg{
+		{"m1", "claude-sonnet-4-5", now.Add(-6 * time.Hour), 10, 10},
+	})
+
+	sessions, window := scanSessions(dir, now, builtinPrices)
+	if len(sessions) != 1 || sessions[0].WindowTokens != 0 {
+		t.Errorf("sessions = %+v, want one session with no window tokens", sessions)
+	}
+	if window != nil {
+		t.Errorf("window = %+v, want nil after reset", window)
+	}
+}
+
+func TestScanSessions_SkipsStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeSession(t, dir, "p", "stale", []sessionMsg{{"m1", "claude-sonnet-4-5", now, 10, 10}})
+	old := now.Add(-SessionLookback - time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "p", "stale.jsonl"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := scanSessions(dir, now, builtinPrices); len(sessions) != 0 {
+		t.Errorf("sessions = %d, want stale file skipped", len(sessions))
+	}
+}
+
+func TestCollect_FlagsUnpricedSessions(t *testing.T) {
+	dir := t.TempDir()
+	now := fixedNow()
+	writeSession(t, dir, "p", "known", []sessionMsg{{"m1", "claude-sonnet-4-5", now.Add(-time.Hour), 1_000_000, 0}})
+	writeSession(t, dir, "p", "mixed", []sessionMsg{
+		{"m2", "claude-mystery-9", now.Add(-30 * time.Minute), 1_000_000, 0},
+		{"m3", "claude-sonnet-4-5", now.Add(-time.Minute), 1_000_000, 0},
+		{"m4", "<synthetic>", now.Add(-time.Minute), 0, 0},
+	})
+
+	c := New(Config{
+		Accounts:       []AccountConfig{{Name: "personal", SessionsDir: dir}},
+		DefaultPricing: &ModelPricing{InputPer1M: 20},
+	}, newMockAPIClient())
+	c.nowFunc = fixedNow
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	acct := result.(*UsageReport).Accounts[0]
+	if got := strings.Join(acct.UnpricedModels, ","); got != "claude-mystery-9" {
+		t.Errorf("UnpricedModels = %q, want claude-mystery-9 (and not the zero-token <synthetic>)", got)
+	}
+	for _, s := range acct.Sessions {
+		switch s.ID {
+		case "known":
+			if s.UnpricedModel != "" || s.CostUSD != 3 {
+				t.Errorf("known session = %+v, want $3.00 and priced", s)
+			}
+		case "mixed":
+			if s.UnpricedModel != "claude-mystery-9" || s.CostUSD != 23 || s.Model != "<synthetic>" {
+				t.Errorf("mixed session = %+v, want $23.00 flagged with the mystery model", s)
+			}
+		}
+	}
+	if acct.SessionsCostUSD != 26 {
+		t.Errorf("SessionsCostUSD = %.2f, want 26.00", acct.SessionsCostUSD)
+	}
+}
+
+func TestCollect_AttachesSessions(t *testing.T) {
+	dir := t.TempDir()
+	now := fixedNow()
+	writeSession(t, dir, "p", "sess", []sessionMsg{{"m1", "claude-sonnet-4-5", now.Add(-time.Minute), 10, 20}})
+
+	c := New(Config{Accounts: []AccountConfig{
+		{Name: "personal", OrganizationID: "org", SessionsDir: dir},
+		{Name: "work", OrganizationID: "org-work"},
+	}}, newMockAPIClient())
+	c.nowFunc = fixedNow
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	report := result.(*UsageReport)
+	if got := report.Accounts[0]; len(got.Sessions) != 1 || got.Window == nil {
+		t.Errorf("personal = %+v, want one session and an open window", got)
+	}
+	if got := report.Accounts[1]; got.Sessions != nil || got.Window != nil {
+		t.Errorf("work = %+v, want no sessions without sessions_dir", got)
+	}
+}
@@ -0,0 +1,77 @@
+package claude
+
+import "time"
+
+// SummaryVersion is the version of the Summary schema. Readers ignore a
+// summary of another version and fall back to the full report.
+const SummaryVersion = 1
+
+// Summary is the compact form of a UsageReport the prompt renders from.
+// The daemon writes it beside the full report so the prompt can skip
+// decoding every account's models, sessions, and plan windows.
+type Summary struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Tokens, CostUSD, and UnpricedModels are the report's Spend.
+	Tokens         int64    `json:"tokens"`
+	CostUSD        float64  `json:"cost_usd"`
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
+
+	TopModel string `json:"top_model,omitempty"`
+
+	// WindowPercent is the report's WindowPercent, set when HasWindow.
+	WindowPercent float64 `json:"window_percent,omitempty"`
+	HasWindow     bool    `json:"has_window,omitempty"`
+
+	// Accounts is the number of accounts, and Limits those projected to
+	// reach their window limit.
+	Accounts int            `json:"accounts"`
+	Limits   []AccountLimit `json:"limits,omitempty"`
+}
+
+// AccountLimit is when an account is projected to reach its window limit.
+type AccountLimit struct {
+	Name    string    `json:"name"`
+	LimitAt time.Time `json:"limit_at"`
+}
+
+// NewSummary returns the summary of r.
+func NewSummary(r *UsageReport) Summary {
+	s := Summary{
+		Version:   SummaryVersion,
+		Timestamp: r.Timestamp,
+		TopModel:  r.TopModel(),
+		Accounts:  len(r.Accounts),
+	}
+	s.Tokens, s.CostUSD, s.UnpricedModels = r.Spend()
+	s.WindowPercent, s.HasWindow = r.WindowPercent()
+	for _, a := range r.Accounts {
+		if a.Forecast != nil && !a.Forecast.LimitAt.IsZero() {
+			s.Limits = append(s.Limits, AccountLimit{Name: a.Name, LimitAt: a.Forecast.LimitAt})
+		}
+	}
+	return s
+}
+
+// Summarize returns the summary of r, for the daemon to write beside it.
+func (r *UsageReport) Summarize() any {
+	return NewSummary(r)
+}
+
+// Current reports whether s has the schema version this build writes.
+func (s *Summary) Current() bool {
+	return s.Version == SummaryVersion
+}
+
+// SoonestLimit is UsageReport.SoonestLimit for the summarized report.
+func (s *Summary) SoonestLimit(now time.Time) (account string, remaining time.Duration, ok bool) {
+	for _, l := range s.Limits {
+		f := Forecast{LimitAt: l.LimitAt}
+		d, _ := f.Remaining(now)
+		if !ok || d < remaining {
+			account, remaining, ok = l.Name, d, true
+		}
+	}
+	return account, remaining, ok
+}
@@ -29,6 +29,14 @@ type Collector interface {
 	Healthy() bool
 }
 
+// Summarizer is implemented by collector results with a compact summary.
+// The daemon writes the summary beside the full result so the prompt can
+// render without decoding all of it.
+type Summarizer interface {
+	// Summarize returns the summary, which must marshal to JSON.
+	Summarize() any
+}
+
 // CollectorStatus tracks the runtime state of a single collector. The runner
 // updates this after every collection cycle.
 type CollectorStatus struct {
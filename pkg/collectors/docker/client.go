@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// httpClient implements ContainerClient against the Docker Engine API
+// using net/http, dialing the unix socket directly when the host is a
+// unix:// address.
+type httpClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// newHTTPClient builds a client for a DOCKER_HOST-style address. Unknown
+// schemes produce a client whose requests fail with a descriptive error.
+func newHTTPClient(host string) *httpClient {
+	transport := &http.Transport{}
+	baseURL := ""
+
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		path := strings.TrimPrefix(host, "unix://")
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		baseURL = "http://docker"
+	case strings.HasPrefix(host, "tcp://"):
+		baseURL = "http://" + strings.TrimPrefix(host, "tcp://")
+	case strings.HasPrefix(host, "http://"), strings.HasPrefix(host, "https://"):
+		baseURL = host
+	}
+
+	return &httpClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: httpx.New(httpx.Config{
+			Name:      "docker",
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		}),
+	}
+}
+
+// apiContainer is the subset of GET /containers/json we decode.
+type apiContainer struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+// ListContainers fetches all containers, including stopped ones.
+func (c *httpClient) ListContainers(ctx context.Context) ([]Container, error) {
+	if c.baseURL == "" {
+		return nil, fmt.Errorf("unsupported docker host (want unix:// or tcp://)")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/containers/json?all=1", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("docker /containers/json returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var raw []apiContainer
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decoding containers: %w", err)
+	}
+
+	containers := make([]Container, 0, len(raw))
+	for _, r := range raw {
+		name := r.ID
+		if len(name) > 12 {
+			name = name[:12]
+		}
+		if len(r.Names) > 0 {
+			name = strings.TrimPrefix(r.Names[0], "/")
+		}
+		containers = append(containers, Container{
+			ID:     r.ID,
+			Name:   name,
+			Image:  r.Image,
+			State:  r.State,
+			Status: r.Status,
+			Health: healthFromStatus(r.Status),
+		})
+	}
+	return containers, nil
+}
@@ -0,0 +1,204 @@
+// Package docker provides a collector that reads container status from the
+// local Docker daemon over its Engine API. It reports running, exited, and
+// unhealthy container counts along with the names of containers whose
+// healthcheck is failing.
+package docker
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultHost     = "unix:///var/run/docker.sock"
+)
+
+// Container states as reported by the Docker Engine API.
+const (
+	StateRunning = "running"
+	StateExited  = "exited"
+)
+
+// Container health values derived from the container status string.
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	HealthStarting  = "starting"
+)
+
+// ContainerClient abstracts the Docker Engine API for testability.
+type ContainerClient interface {
+	ListContainers(ctx context.Context) ([]Container, error)
+}
+
+// Config holds the configuration for the Docker collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// Host is the daemon address ("unix:///path" or "tcp://host:port").
+	// Empty uses DOCKER_HOST, falling back to DefaultHost.
+	Host string
+}
+
+// Container is the status of a single container.
+type Container struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Health string `json:"health,omitempty"`
+}
+
+// Status is the data returned by a single Collect call. When the daemon is
+// not reachable Available is false and Error explains why; the counts are
+// then zero.
+type Status struct {
+	Available      bool        `json:"available"`
+	Error          string      `json:"error,omitempty"`
+	Containers     []Container `json:"containers"`
+	Running        int         `json:"running"`
+	Exited         int         `json:"exited"`
+	Unhealthy      int         `json:"unhealthy"`
+	Total          int         `json:"total"`
+	UnhealthyNames []string    `json:"unhealthy_names,omitempty"`
+	Timestamp      time.Time   `json:"timestamp"`
+}
+
+// Collector gathers container status from the Docker daemon.
+type Collector struct {
+	client   ContainerClient
+	interval time.Duration
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+// New creates a new Docker collector. If cfg.Interval is zero,
+// DefaultInterval is used.
+func New(cfg Config) *Collector {
+	host := cfg.Host
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = DefaultHost
+	}
+	return newWithClient(cfg, newHTTPClient(host))
+}
+
+// newWithClient creates a collector with an injected client for testing.
+func newWithClient(cfg Config, client ContainerClient) *Collector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Collector{
+		client:   client,
+		interval: interval,
+		healthy:  true, // healthy until first failure
+	}
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "docker"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// setHealthy updates the internal healthy flag under the mutex.
+func (c *Collector) setHealthy(v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthy = v
+}
+
+// Collect lists containers and returns a Status snapshot. Like the k8s
+// collector it never returns a Go error: a missing or refusing daemon
+// socket yields Available=false (Docker simply is not running here, so the
+// collector stays healthy), while any other failure also marks the
+// collector unhealthy.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	status := &Status{
+		Containers: []Container{},
+		Timestamp:  time.Now(),
+	}
+
+	containers, err := c.client.ListContainers(ctx)
+	if err != nil {
+		if notAvailable(err) {
+			status.Error = "docker not available: " + err.Error()
+			c.setHealthy(true)
+		} else {
+			status.Error = err.Error()
+			c.setHealthy(false)
+		}
+		return status, nil
+	}
+
+	status.Available = true
+	for _, ct := range containers {
+		switch ct.State {
+		case StateRunning:
+			status.Running++
+		case StateExited:
+			status.Exited++
+		}
+		if ct.Health == HealthUnhealthy {
+			status.Unhealthy++
+			status.UnhealthyNames = append(status.UnhealthyNames, ct.Name)
+		}
+		status.Containers = append(status.Containers, ct)
+	}
+	status.Total = len(status.Containers)
+
+	sort.Slice(status.Containers, func(i, j int) bool {
+		return status.Containers[i].Name < status.Containers[j].Name
+	})
+	sort.Strings(status.UnhealthyNames)
+
+	c.setHealthy(true)
+	return status, nil
+}
+
+// notAvailable reports whether err means there is no daemon to talk to.
+func notAvailable(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// healthFromStatus extracts the healthcheck result from a container status
+// string such as "Up 2 hours (unhealthy)".
+func healthFromStatus(status string) string {
+	switch {
+	case strings.HasSuffix(status, "(unhealthy)"):
+		return HealthUnhealthy
+	case strings.HasSuffix(status, "(healthy)"):
+		return HealthHealthy
+	case strings.HasSuffix(status, "(health: starting)"):
+		return HealthStarting
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,189 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mockClient is a test double for ContainerClient.
+type mockClient struct {
+	containers []Container
+	err        error
+}
+
+func (m *mockClient) ListContainers(ctx context.Context) ([]Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.containers, m.err
+}
+
+// testContainersJSON is a trimmed GET /containers/json?all=1 response.
+const testContainersJSON = `[
+  {"Id":"a1b2c3d4e5f60718","Names":["/web"],"Image":"nginx:1.27","State":"running","Status":"Up 2 hours (healthy)"},
+  {"Id":"b1b2c3d4e5f60718","Names":["/db"],"Image":"postgres:16","State":"running","Status":"Up 3 days (unhealthy)"},
+  {"Id":"c1b2c3d4e5f60718","Names":["/migrate"],"Image":"app:latest","State":"exited","Status":"Exited (0) 3 days ago"},
+  {"Id":"d1b2c3d4e5f60718","Names":[],"Image":"busybox","State":"running","Status":"Up 5 seconds"}
+]`
+
+// serveUnix starts an HTTP server on a unix socket and returns the
+// DOCKER_HOST-style address. The directory is kept short because unix
+// socket paths are limited to ~104 bytes on macOS.
+func serveUnix(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "pp-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	sock := filepath.Join(dir, "d.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return "unix://" + sock
+}
+
+func TestHTTPClient_ListContainers(t *testing.T) {
+	host := serveUnix(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" || r.URL.Query().Get("all") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, testContainersJSON)
+	}))
+
+	containers, err := newHTTPClient(host).ListContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListContainers() error: %v", err)
+	}
+	if len(containers) != 4 {
+		t.Fatalf("len(containers) = %d, want 4", len(containers))
+	}
+	if containers[1].Name != "db" || containers[1].Health != HealthUnhealthy {
+		t.Errorf("containers[1] = %+v, want unhealthy db", containers[1])
+	}
+	if containers[3].Name != "d1b2c3d4e5f6" {
+		t.Errorf("unnamed container Name = %q, want short ID", containers[3].Name)
+	}
+}
+
+func TestCollect_Counts(t *testing.T) {
+	c := newWithClient(Config{}, &mockClient{containers: []Container{
+		{Name: "web", State: StateRunning, Health: HealthHealthy},
+		{Name: "worker", State: StateRunning, Health: HealthUnhealthy},
+		{Name: "db", State: StateRunning, Health: HealthUnhealthy},
+		{Name: "migrate", State: StateExited},
+		{Name: "new", State: "created"},
+	}})
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	st := result.(*Status)
+
+	if !st.Available {
+		t.Error("Available = false, want true")
+	}
+	if st.Running != 3 || st.Exited != 1 || st.Unhealthy != 2 || st.Total != 5 {
+		t.Errorf("Running/Exited/Unhealthy/Total = %d/%d/%d/%d, want 3/1/2/5",
+			st.Running, st.Exited, st.Unhealthy, st.Total)
+	}
+	if fmt.Sprint(st.UnhealthyNames) != "[db worker]" {
+		t.Errorf("UnhealthyNames = %v, want [db worker]", st.UnhealthyNames)
+	}
+	if st.Containers[0].Name != "db" {
+		t.Errorf("Containers[0] = %q, want sorted by name", st.Containers[0].Name)
+	}
+	if !c.Healthy() {
+		t.Error("Healthy() = false after success")
+	}
+}
+
+func TestCollect_MissingSocketNotAvailable(t *testing.T) {
+	c := New(Config{Host: "unix:///nonexistent/pp-docker.sock"})
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error = %v, want nil for a missing socket", err)
+	}
+	st := result.(*Status)
+	if st.Available {
+		t.Error("Available = true, want false")
+	}
+	if st.Error == "" {
+		t.Error("Error is empty, want a not-available reason")
+	}
+	if !c.Healthy() {
+		t.Error("Healthy() = false for a missing socket, want true")
+	}
+}
+
+func TestCollect_APIErrorMarksUnhealthy(t *testing.T) {
+	c := newWithClient(Config{}, &mockClient{err: errors.New("docker /containers/json returned 500")})
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if st := result.(*Status); st.Available || st.Error == "" {
+		t.Errorf("status = %+v, want unavailable with error", st)
+	}
+	if c.Healthy() {
+		t.Error("Healthy() = true after API error, want false")
+	}
+}
+
+func TestNew_HonorsDockerHost(t *testing.T) {
+	host := serveUnix(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testContainersJSON)
+	}))
+	t.Setenv("DOCKER_HOST", host)
+
+	result, _ := New(Config{}).Collect(context.Background())
+	if st := result.(*Status); !st.Available || st.Total != 4 {
+		t.Errorf("status = %+v, want 4 containers via DOCKER_HOST", st)
+	}
+}
+
+func TestHealthFromStatus(t *testing.T) {
+	tests := map[string]string{
+		"Up 2 hours (healthy)":            HealthHealthy,
+		"Up 2 hours (unhealthy)":          HealthUnhealthy,
+		"Up 3 seconds (health: starting)": HealthStarting,
+		"Up 2 hours":                      "",
+		"Exited (1) 5 minutes ago":        "",
+	}
+	for in, want := range tests {
+		if got := healthFromStatus(in); got != want {
+			t.Errorf("healthFromStatus(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestInterval(t *testing.T) {
+	if got := newWithClient(Config{}, nil).Interval(); got != DefaultInterval {
+		t.Errorf("default Interval() = %v, want %v", got, DefaultInterval)
+	}
+	if got := newWithClient(Config{Interval: time.Minute}, nil).Interval(); got != time.Minute {
+		t.Errorf("Interval() = %v, want 1m", got)
+	}
+	if got := newWithClient(Config{}, nil).Name(); got != "docker" {
+		t.Errorf("Name() = %q, want %q", got, "docker")
+	}
+}
+
+// Ensure the mock satisfies the interface.
+var _ ContainerClient = (*mockClient)(nil)
@@ -0,0 +1,254 @@
+// Package gpu provides a collector for GPU utilization, memory,
+// temperature, and power. NVIDIA GPUs are read with nvidia-smi on Linux,
+// and the Apple Silicon GPU with ioreg on macOS, which needs no root. On a
+// machine without a supported GPU the collector reports it as not
+// available rather than failing.
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval  = 15 * time.Second
+	DefaultNvidiaSMI = "nvidia-smi"
+)
+
+// GPU vendors reported in Device.Vendor.
+const (
+	VendorNVIDIA = "nvidia"
+	VendorApple  = "apple"
+)
+
+// Runner runs a command and returns its standard output. Tests inject one
+// returning canned output.
+type Runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// Config holds the configuration for the GPU collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// NvidiaSMI is the nvidia-smi binary. Empty uses DefaultNvidiaSMI
+	// from PATH.
+	NvidiaSMI string
+}
+
+// Device is the state of one GPU. Metrics a GPU does not report are nil
+// or zero: Apple Silicon shares system memory, so MemoryTotalBytes is
+// zero there, and reports neither temperature nor power.
+type Device struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name"`
+	Vendor string `json:"vendor"`
+
+	// UtilizationPercent is how busy the GPU is, 0-100.
+	UtilizationPercent float64 `json:"utilization_percent"`
+
+	MemoryUsedBytes  uint64 `json:"memory_used_bytes"`
+	MemoryTotalBytes uint64 `json:"memory_total_bytes,omitempty"`
+
+	TemperatureC *float64 `json:"temperature_c,omitempty"`
+	PowerWatts   *float64 `json:"power_watts,omitempty"`
+}
+
+// MemoryPercent returns memory used as a percentage of the total, and
+// false when the total is unknown.
+func (d Device) MemoryPercent() (float64, bool) {
+	if d.MemoryTotalBytes == 0 {
+		return 0, false
+	}
+	return float64(d.MemoryUsedBytes) / float64(d.MemoryTotalBytes) * 100, true
+}
+
+// Summary returns a compact one-line reading such as
+// "GPU 67% 8.2/24GB 71°C", leaving out what the GPU does not report.
+func (d Device) Summary() string {
+	parts := []string{fmt.Sprintf("GPU %.0f%%", d.UtilizationPercent)}
+	if mem := d.MemoryText(); mem != "" {
+		parts = append(parts, mem)
+	}
+	if d.TemperatureC != nil {
+		parts = append(parts, fmt.Sprintf("%.0f°C", *d.TemperatureC))
+	}
+	return strings.Join(parts, " ")
+}
+
+// MemoryText returns memory use as "8.2/24GB", or "1.3GB" when the total
+// is unknown, or "" when the GPU reports no memory figures.
+func (d Device) MemoryText() string {
+	switch {
+	case d.MemoryTotalBytes > 0:
+		return gbText(d.MemoryUsedBytes) + "/" + gbText(d.MemoryTotalBytes) + "GB"
+	case d.MemoryUsedBytes > 0:
+		return gbText(d.MemoryUsedBytes) + "GB"
+	}
+	return ""
+}
+
+// gbText formats bytes in GiB with one decimal, dropping a trailing ".0".
+func gbText(b uint64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(float64(b)/(1<<30), 'f', 1, 64), ".0")
+}
+
+// Status is the data returned by a single Collect call. When there is no
+// supported GPU Available is false and Error says why.
+type Status struct {
+	Available bool      `json:"available"`
+	Error     string    `json:"error,omitempty"`
+	GPUs      []Device  `json:"gpus"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Collector gathers GPU metrics from the platform's command-line tools.
+type Collector struct {
+	interval  time.Duration
+	nvidiaSMI string
+	goos      string
+	run       Runner
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+// New creates a new GPU collector for the running platform.
+func New(cfg Config) *Collector {
+	return newWithRunner(cfg, runtime.GOOS, execRunner)
+}
+
+// newWithRunner creates a collector for goos with an injected command
+// runner for testing.
+func newWithRunner(cfg Config, goos string, run Runner) *Collector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	smi := cfg.NvidiaSMI
+	if smi == "" {
+		smi = DefaultNvidiaSMI
+	}
+	return &Collector{
+		interval:  interval,
+		nvidiaSMI: smi,
+		goos:      goos,
+		run:       run,
+		healthy:   true, // healthy until first failure
+	}
+}
+
+// execRunner runs the command with os/exec.
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String() + string(out)); msg != "" {
+			return out, fmt.Errorf("%w: %s", err, msg)
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "gpu"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// setHealthy updates the internal healthy flag under the mutex.
+func (c *Collector) setHealthy(v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthy = v
+}
+
+// Collect reads every GPU and returns a Status snapshot. Like the docker
+// collector it never returns a Go error: no GPU, or no tool to read it,
+// yields Available=false with the collector still healthy, while a tool
+// that fails or prints something unparsable also marks it unhealthy.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	status := &Status{
+		GPUs:      []Device{},
+		Timestamp: time.Now(),
+	}
+
+	devices, err := c.devices(ctx)
+	switch {
+	case errors.Is(err, errNoGPU):
+		status.Error = err.Error()
+		c.setHealthy(true)
+	case err != nil:
+		status.Error = err.Error()
+		c.setHealthy(false)
+	case len(devices) == 0:
+		status.Error = errNoGPU.Error()
+		c.setHealthy(true)
+	default:
+		status.Available = true
+		status.GPUs = devices
+		c.setHealthy(true)
+	}
+	return status, nil
+}
+
+// errNoGPU means the machine has no GPU the collector can read.
+var errNoGPU = errors.New("no supported GPU found")
+
+// devices reads the GPUs with the tool for the platform.
+func (c *Collector) devices(ctx context.Context) ([]Device, error) {
+	switch c.goos {
+	case "linux":
+		out, err := c.run(ctx, c.nvidiaSMI, nvidiaSMIArgs...)
+		if err != nil {
+			if nvidiaNotAvailable(err, out) {
+				return nil, fmt.Errorf("%w: %v", errNoGPU, err)
+			}
+			return nil, fmt.Errorf("nvidia-smi: %w", err)
+		}
+		return parseNvidiaSMI(out)
+	case "darwin":
+		out, err := c.run(ctx, "ioreg", "-r", "-d", "1", "-w", "0", "-c", "IOAccelerator")
+		if err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return nil, fmt.Errorf("%w: %v", errNoGPU, err)
+			}
+			return nil, fmt.Errorf("ioreg: %w", err)
+		}
+		return parseIOReg(out), nil
+	}
+	return nil, fmt.Errorf("%w: GPU metrics are not supported on %s", errNoGPU, c.goos)
+}
+
+// nvidiaNotAvailable reports whether an nvidia-smi failure means there is
+// no usable NVIDIA GPU: the tool is missing, there is no device, or no
+// driver is loaded.
+func nvidiaNotAvailable(err error, out []byte) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return true
+	}
+	msg := err.Error() + " " + string(out)
+	return strings.Contains(msg, "No devices were found") ||
+		strings.Contains(msg, "couldn't communicate with the NVIDIA driver")
+}
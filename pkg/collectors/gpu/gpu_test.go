@@ -0,0 +1,154 @@
+package gpu
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testNvidiaSMI is nvidiaSMIArgs output for two GPUs, the second without
+// power readings.
+const testNvidiaSMI = `0, NVIDIA GeForce RTX 3090, 67, 8396, 24576, 71, 215.32
+1, NVIDIA T400, 0, 1, 2048, 34, [N/A]
+`
+
+// testIOReg is trimmed ioreg output for an Apple M2 Pro.
+const testIOReg = `+-o AGXAcceleratorG14X  <class AGXAcceleratorG14X, id 0x1000004d1, registered, matched, active, busy 0 (0 ms), retain 72>
+    {
+      "IOClass" = "AGXAcceleratorG14X"
+      "model" = "Apple M2 Pro"
+      "gpu-core-count" = 19
+      "PerformanceStatistics" = {"In use system memory"=1395802112,"Device Utilization %"=12,"Renderer Utilization %"=11,"Alloc system memory"=3925245952}
+    }
+`
+
+// fakeRunner returns canned output for one command.
+func fakeRunner(t *testing.T, wantName string, out string, err error) Runner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if name != wantName {
+			t.Errorf("ran %q, want %q", name, wantName)
+		}
+		return []byte(out), err
+	}
+}
+
+func TestParseNvidiaSMI(t *testing.T) {
+	devices, err := parseNvidiaSMI([]byte(testNvidiaSMI))
+	if err != nil {
+		t.Fatalf("parseNvidiaSMI() error: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("got %d devices, want 2", len(devices))
+	}
+	d := devices[0]
+	if d.Name != "NVIDIA GeForce RTX 3090" || d.Vendor != VendorNVIDIA || d.UtilizationPercent != 67 {
+		t.Errorf("device 0 = %+v", d)
+	}
+	if d.MemoryUsedBytes != 8396*mib || d.MemoryTotalBytes != 24576*mib {
+		t.Errorf("memory = %d/%d", d.MemoryUsedBytes, d.MemoryTotalBytes)
+	}
+	if d.TemperatureC == nil || *d.TemperatureC != 71 || d.PowerWatts == nil || *d.PowerWatts != 215.32 {
+		t.Errorf("temperature/power = %v/%v", d.TemperatureC, d.PowerWatts)
+	}
+	if devices[1].Index != 1 || devices[1].PowerWatts != nil {
+		t.Errorf("device 1 = %+v, want index 1 and no power reading", devices[1])
+	}
+
+	if _, err := parseNvidiaSMI([]byte("0, GPU, 12\n")); err == nil {
+		t.Error("short line should fail to parse")
+	}
+}
+
+func TestParseIOReg(t *testing.T) {
+	devices := parseIOReg([]byte(testIOReg))
+	if len(devices) != 1 {
+		t.Fatalf("got %d devices, want 1", len(devices))
+	}
+	d := devices[0]
+	if d.Name != "Apple M2 Pro" || d.Vendor != VendorApple || d.UtilizationPercent != 12 || d.MemoryUsedBytes != 1395802112 {
+		t.Errorf("device = %+v", d)
+	}
+	if _, ok := d.MemoryPercent(); ok || d.TemperatureC != nil {
+		t.Errorf("unified memory should have no total and no temperature: %+v", d)
+	}
+
+	if got := parseIOReg([]byte("+-o IntelAccelerator  <class IntelAccelerator>\n    {\n      \"IOClass\" = \"IntelAccelerator\"\n    }\n")); len(got) != 0 {
+		t.Errorf("entry without utilization = %+v, want skipped", got)
+	}
+}
+
+func TestDeviceSummary(t *testing.T) {
+	devices, _ := parseNvidiaSMI([]byte(testNvidiaSMI))
+	if got, want := devices[0].Summary(), "GPU 67% 8.2/24GB 71°C"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if got, want := parseIOReg([]byte(testIOReg))[0].Summary(), "GPU 12% 1.3GB"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name        string
+		goos        string
+		cmd         string
+		out         string
+		err         error
+		available   bool
+		healthy     bool
+		errContains string
+	}{
+		{"nvidia", "linux", "nvidia-smi", testNvidiaSMI, nil, true, true, ""},
+		{"apple", "darwin", "ioreg", testIOReg, nil, true, true, ""},
+		{"no nvidia-smi", "linux", "nvidia-smi", "", exec.ErrNotFound, false, true, "no supported GPU"},
+		{"no devices", "linux", "nvidia-smi", "No devices were found\n", errors.New("exit status 6"), false, true, "no supported GPU"},
+		{"no driver", "linux", "nvidia-smi", "", errors.New("exit status 9: NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver."), false, true, "no supported GPU"},
+		{"smi fails", "linux", "nvidia-smi", "", errors.New("exit status 15: GPU is lost"), false, false, "GPU is lost"},
+		{"garbage", "linux", "nvidia-smi", "0, x\n", nil, false, false, "parsing nvidia-smi"},
+		{"intel mac", "darwin", "ioreg", "", nil, false, true, "no supported GPU"},
+		{"unsupported", "windows", "", "", nil, false, true, "not supported on windows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newWithRunner(Config{}, tt.goos, fakeRunner(t, tt.cmd, tt.out, tt.err))
+			data, err := c.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error: %v", err)
+			}
+			st := data.(*Status)
+			if st.Available != tt.available || c.Healthy() != tt.healthy {
+				t.Errorf("available=%v healthy=%v, want %v/%v (error %q)", st.Available, c.Healthy(), tt.available, tt.healthy, st.Error)
+			}
+			if !strings.Contains(st.Error, tt.errContains) {
+				t.Errorf("Error = %q, want it to contain %q", st.Error, tt.errContains)
+			}
+			if st.GPUs == nil || st.Timestamp.IsZero() {
+				t.Errorf("status = %+v, want non-nil GPUs and a timestamp", st)
+			}
+		})
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	c := New(Config{})
+	if c.Name() != "gpu" || c.Interval() != DefaultInterval || c.nvidiaSMI != DefaultNvidiaSMI || !c.Healthy() {
+		t.Errorf("New(Config{}) = %+v", c)
+	}
+	c = New(Config{Interval: time.Minute, NvidiaSMI: "/run/current-system/sw/bin/nvidia-smi"})
+	if c.Interval() != time.Minute || c.nvidiaSMI != "/run/current-system/sw/bin/nvidia-smi" {
+		t.Errorf("New() ignored config: %+v", c)
+	}
+}
+
+func TestExecRunner_IncludesOutputInError(t *testing.T) {
+	_, err := execRunner(context.Background(), "sh", "-c", "echo No devices were found; exit 6")
+	if err == nil || !nvidiaNotAvailable(err, nil) {
+		t.Errorf("execRunner error = %v, want one nvidiaNotAvailable recognizes", err)
+	}
+	if _, err := execRunner(context.Background(), "pp-no-such-command"); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("missing command error = %v, want exec.ErrNotFound", err)
+	}
+}
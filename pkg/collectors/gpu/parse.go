@@ -0,0 +1,109 @@
+package gpu
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nvidiaSMIArgs queries one CSV line per GPU, in the column order
+// parseNvidiaSMI expects. Memory is in MiB and power in watts.
+var nvidiaSMIArgs = []string{
+	"--query-gpu=index,name,utilization.gpu,memory.used,memory.total,temperature.gpu,power.draw",
+	"--format=csv,noheader,nounits",
+}
+
+// mib is the unit nvidia-smi reports memory in.
+const mib = 1 << 20
+
+// parseNvidiaSMI parses nvidiaSMIArgs output. Values nvidia-smi cannot
+// read, such as "[N/A]" or "[Not Supported]", are left unset.
+func parseNvidiaSMI(out []byte) ([]Device, error) {
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing nvidia-smi output: %w", err)
+	}
+
+	devices := make([]Device, 0, len(records))
+	for _, rec := range records {
+		if len(rec) != 7 {
+			return nil, fmt.Errorf("parsing nvidia-smi output: %d fields in %q, want 7", len(rec), strings.Join(rec, ", "))
+		}
+		d := Device{Name: strings.TrimSpace(rec[1]), Vendor: VendorNVIDIA}
+		if v, ok := parseValue(rec[0]); ok {
+			d.Index = int(v)
+		}
+		if v, ok := parseValue(rec[2]); ok {
+			d.UtilizationPercent = v
+		}
+		if v, ok := parseValue(rec[3]); ok {
+			d.MemoryUsedBytes = uint64(v * mib)
+		}
+		if v, ok := parseValue(rec[4]); ok {
+			d.MemoryTotalBytes = uint64(v * mib)
+		}
+		if v, ok := parseValue(rec[5]); ok {
+			d.TemperatureC = &v
+		}
+		if v, ok := parseValue(rec[6]); ok {
+			d.PowerWatts = &v
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// parseValue parses one nvidia-smi number, reporting false for the
+// bracketed placeholders it prints for unavailable values.
+func parseValue(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil
+}
+
+// ioreg patterns for the properties of an IOAccelerator entry.
+var (
+	ioregEntry       = regexp.MustCompile(`(?m)^\+-o `)
+	ioregModel       = regexp.MustCompile(`"model" = "([^"]*)"`)
+	ioregUtilization = regexp.MustCompile(`"Device Utilization %"=(\d+)`)
+	ioregMemoryInUse = regexp.MustCompile(`"In use system memory"=(\d+)`)
+)
+
+// parseIOReg parses "ioreg -r -d 1 -w 0 -c IOAccelerator" output, one
+// "+-o" entry per GPU. Entries without a utilization figure, such as
+// Intel GPUs in older Macs, are skipped.
+func parseIOReg(out []byte) []Device {
+	var devices []Device
+	text := string(out)
+	starts := ioregEntry.FindAllStringIndex(text, -1)
+	for i, s := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		entry := text[s[0]:end]
+
+		m := ioregUtilization.FindStringSubmatch(entry)
+		if m == nil {
+			continue
+		}
+		util, _ := strconv.ParseFloat(m[1], 64)
+		d := Device{
+			Index:              len(devices),
+			Name:               "Apple GPU",
+			Vendor:             VendorApple,
+			UtilizationPercent: util,
+		}
+		if m := ioregModel.FindStringSubmatch(entry); m != nil {
+			d.Name = m[1]
+		}
+		if m := ioregMemoryInUse.FindStringSubmatch(entry); m != nil {
+			d.MemoryUsedBytes, _ = strconv.ParseUint(m[1], 10, 64)
+		}
+		devices = append(devices, d)
+	}
+	return devices
+}
@@ -0,0 +1,178 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HealthLevel is the overall health of a cluster as computed by
+// EvaluateHealth.
+type HealthLevel string
+
+// Cluster health levels, ordered from best to worst.
+const (
+	HealthOK       HealthLevel = "healthy"
+	HealthWarning  HealthLevel = "warning"
+	HealthCritical HealthLevel = "critical"
+)
+
+// severity returns a rank for comparing levels; higher is worse.
+func (l HealthLevel) severity() int {
+	switch l {
+	case HealthWarning:
+		return 1
+	case HealthCritical:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Worse reports whether l is more severe than other.
+func (l HealthLevel) Worse(other HealthLevel) bool {
+	return l.severity() > other.severity()
+}
+
+// controlPlaneRoles are the node roles treated as control plane.
+var controlPlaneRoles = map[string]bool{
+	"control-plane": true,
+	"master":        true,
+}
+
+// EvaluateHealth computes a cluster's health level and the reasons behind
+// it. A cluster is critical when it is unreachable, a control-plane node is
+// not Ready, or more than half of its non-completed pods are not running.
+// It is a warning when any node is NotReady, cordoned, or reporting a
+// pressure condition, when any deployment has fewer ready replicas than
+// desired, or when a namespace requests more than utilizationWarn percent
+// of its CPU or memory quota (or of the cluster's allocatable). Zero
+// utilizationWarn skips the namespace check. Reasons are listed worst
+// first.
+func EvaluateHealth(c ClusterInfo, utilizationWarn float64) (HealthLevel, []string) {
+	if !c.Connected {
+		reason := "cluster unreachable"
+		if c.Error != "" {
+			reason += ": " + c.Error
+		}
+		return HealthCritical, []string{reason}
+	}
+
+	var critical, warning []string
+
+	for _, n := range c.Nodes {
+		if !n.Ready {
+			if isControlPlane(n) {
+				critical = append(critical, fmt.Sprintf("control-plane node %s NotReady", n.Name))
+			} else {
+				warning = append(warning, fmt.Sprintf("node %s NotReady", n.Name))
+			}
+		}
+		if n.Unschedulable {
+			warning = append(warning, fmt.Sprintf("node %s cordoned", n.Name))
+		}
+		if len(n.Conditions) > 0 {
+			warning = append(warning, fmt.Sprintf("node %s %s", n.Name, strings.Join(n.Conditions, ", ")))
+		}
+	}
+
+	// Completed pods are excluded so finished jobs do not count as down.
+	succeeded := 0
+	for _, ns := range c.Namespaces {
+		succeeded += ns.PodCounts.Succeeded
+	}
+	if active := c.TotalPods - succeeded; active > 0 {
+		if notRunning := active - c.RunningPods; notRunning*2 > active {
+			critical = append(critical, fmt.Sprintf("%d/%d pods not running", notRunning, active))
+		}
+	}
+
+	for _, ns := range c.Namespaces {
+		for _, d := range ns.Deployments {
+			if d.ReadyReplicas < d.Replicas {
+				warning = append(warning, fmt.Sprintf("deployment %s/%s %d/%d ready",
+					ns.Name, d.Name, d.ReadyReplicas, d.Replicas))
+			}
+		}
+	}
+
+	if utilizationWarn > 0 {
+		for _, ns := range c.Namespaces {
+			for _, r := range []struct {
+				name  string
+				usage ResourceUsage
+			}{{"CPU", ns.CPU}, {"memory", ns.Memory}} {
+				if r.usage.Limit > 0 && r.usage.Percent > utilizationWarn {
+					warning = append(warning, fmt.Sprintf("namespace %s %s requests %.0f%% of %s",
+						ns.Name, r.name, r.usage.Percent, r.usage.limitName()))
+				}
+			}
+		}
+	}
+
+	reasons := append(critical, warning...)
+	switch {
+	case len(critical) > 0:
+		return HealthCritical, reasons
+	case len(warning) > 0:
+		return HealthWarning, reasons
+	default:
+		return HealthOK, nil
+	}
+}
+
+// limitName describes where the Limit of u comes from.
+func (u ResourceUsage) limitName() string {
+	if u.Quota {
+		return "quota"
+	}
+	return "allocatable"
+}
+
+// isControlPlane reports whether a node carries a control-plane role.
+func isControlPlane(n NodeInfo) bool {
+	for _, r := range n.Roles {
+		if controlPlaneRoles[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthSummary returns a one-line description such as
+// "2 clusters, 1 warning" or "1 cluster, healthy".
+func (s *ClusterStatus) HealthSummary() string {
+	if s == nil || len(s.Clusters) == 0 {
+		return "no clusters"
+	}
+
+	noun := "clusters"
+	if len(s.Clusters) == 1 {
+		noun = "cluster"
+	}
+
+	var warnings, criticals int
+	for _, c := range s.Clusters {
+		switch c.Health {
+		case HealthWarning:
+			warnings++
+		case HealthCritical:
+			criticals++
+		}
+	}
+
+	parts := []string{fmt.Sprintf("%d %s", len(s.Clusters), noun)}
+	if criticals > 0 {
+		parts = append(parts, fmt.Sprintf("%d critical", criticals))
+	}
+	if warnings > 0 {
+		w := "warning"
+		if warnings > 1 {
+			w = "warnings"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", warnings, w))
+	}
+	if criticals == 0 && warnings == 0 {
+		parts = append(parts, string(HealthOK))
+	}
+	return strings.Join(parts, ", ")
+}
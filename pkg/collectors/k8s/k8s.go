@@ -6,6 +6,8 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +24,14 @@ const (
 	defaultInterval = 15 * time.Second
 )
 
+// AllContexts, as the only entry of Config.Contexts, selects every context
+// in the kubeconfig.
+const AllContexts = "*"
+
+// DefaultUtilizationWarn is the namespace utilization percentage the
+// configuration defaults Config.UtilizationWarn to.
+const DefaultUtilizationWarn = 90
+
 // ---------- Configuration ----------
 
 // Config holds the configuration for the Kubernetes collector.
@@ -34,19 +44,38 @@ type Config struct {
 	Kubeconfig string
 
 	// Contexts lists specific kubeconfig contexts to monitor. If empty,
-	// only the current context is used.
+	// only the current context is used. AllContexts ("*") enumerates every
+	// context in the kubeconfig on each collection.
 	Contexts []string
 
+	// ExcludeContexts removes discovered contexts whose names match any of
+	// these path.Match patterns (e.g. "kind-*"). It only applies with
+	// AllContexts.
+	ExcludeContexts []string
+
 	// Namespaces restricts collection to specific namespaces. If empty,
 	// all namespaces are queried.
 	Namespaces []string
+
+	// Watch keeps nodes, pods, and deployments in memory from API watches
+	// so Collect snapshots them instead of relisting every interval. The
+	// list path is used for any context whose watch setup fails.
+	Watch bool
+
+	// UtilizationWarn is the percentage of its quota, or of the cluster's
+	// allocatable resources when it has none, above which a namespace's
+	// CPU or memory requests make the cluster a warning. Zero disables the
+	// warning.
+	UtilizationWarn float64
 }
 
 // ---------- Result types ----------
 
-// ClusterStatus is the top-level data returned by Collect.
+// ClusterStatus is the top-level data returned by Collect. Health is the
+// worst level across all clusters.
 type ClusterStatus struct {
 	Clusters  []ClusterInfo `json:"clusters"`
+	Health    HealthLevel   `json:"health"`
 	Timestamp time.Time     `json:"timestamp"`
 }
 
@@ -61,21 +90,26 @@ type ClusterInfo struct {
 	RunningPods int             `json:"running_pods"`
 	PendingPods int             `json:"pending_pods"`
 	FailedPods  int             `json:"failed_pods"`
+
+	// Health and HealthReasons are filled in by EvaluateHealth.
+	Health        HealthLevel `json:"health"`
+	HealthReasons []string    `json:"health_reasons,omitempty"`
 }
 
 // NodeInfo holds status and resource information for a single node.
 type NodeInfo struct {
-	Name        string   `json:"name"`
-	Ready       bool     `json:"ready"`
-	Roles       []string `json:"roles,omitempty"`
-	CPUCapacity string   `json:"cpu_capacity"`
-	CPURequests string   `json:"cpu_requests"`
-	CPULimits   string   `json:"cpu_limits"`
-	MemCapacity string   `json:"mem_capacity"`
-	MemRequests string   `json:"mem_requests"`
-	MemLimits   string   `json:"mem_limits"`
-	PodCount    int      `json:"pod_count"`
-	Conditions  []string `json:"conditions,omitempty"`
+	Name          string   `json:"name"`
+	Ready         bool     `json:"ready"`
+	Unschedulable bool     `json:"unschedulable,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	CPUCapacity   string   `json:"cpu_capacity"`
+	CPURequests   string   `json:"cpu_requests"`
+	CPULimits     string   `json:"cpu_limits"`
+	MemCapacity   string   `json:"mem_capacity"`
+	MemRequests   string   `json:"mem_requests"`
+	MemLimits     string   `json:"mem_limits"`
+	PodCount      int      `json:"pod_count"`
+	Conditions    []string `json:"conditions,omitempty"`
 }
 
 // NamespaceInfo holds pod and deployment information for a single namespace.
@@ -83,6 +117,26 @@ type NamespaceInfo struct {
 	Name        string           `json:"name"`
 	PodCounts   PodCounts        `json:"pod_counts"`
 	Deployments []DeploymentInfo `json:"deployments,omitempty"`
+
+	// CPU (in millicores) and Memory (in bytes) are the namespace's
+	// resource requests against its quota or the cluster's allocatable.
+	CPU    ResourceUsage `json:"cpu"`
+	Memory ResourceUsage `json:"memory"`
+}
+
+// ResourceUsage is the sum of a namespace's pod requests for one resource
+// against the most it may request: the tightest ResourceQuota in the
+// namespace, or the allocatable total of the cluster's Ready nodes when no
+// quota limits the resource.
+type ResourceUsage struct {
+	Requested int64 `json:"requested"`
+	Limit     int64 `json:"limit"`
+
+	// Percent is Requested as a percentage of Limit, zero without a limit.
+	Percent float64 `json:"percent"`
+
+	// Quota reports whether Limit comes from a ResourceQuota.
+	Quota bool `json:"quota,omitempty"`
 }
 
 // PodCounts tracks pod phase counts within a namespace.
@@ -113,6 +167,7 @@ type K8sClient interface {
 	ListPods(ctx context.Context, namespace string) ([]corev1.Pod, error)
 	ListDeployments(ctx context.Context, namespace string) ([]appsv1.Deployment, error)
 	ListNamespaces(ctx context.Context) ([]corev1.Namespace, error)
+	ListResourceQuotas(ctx context.Context, namespace string) ([]corev1.ResourceQuota, error)
 }
 
 // realClient wraps a kubernetes.Clientset to implement K8sClient.
@@ -152,6 +207,14 @@ func (r *realClient) ListNamespaces(ctx context.Context) ([]corev1.Namespace, er
 	return list.Items, nil
 }
 
+func (r *realClient) ListResourceQuotas(ctx context.Context, namespace string) ([]corev1.ResourceQuota, error) {
+	list, err := r.cs.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 // ---------- clientFactory ----------
 
 // clientFactory creates K8sClient instances for a given kubeconfig context.
@@ -179,15 +242,73 @@ func defaultClientFactory(kubeconfig, ctxName string) (K8sClient, error) {
 	return &realClient{cs: cs}, nil
 }
 
+// contextLister returns the context names defined in a kubeconfig. Like
+// clientFactory it is a function type so tests can inject a fake.
+type contextLister func(kubeconfig string) ([]string, error)
+
+// defaultContextLister reads context names from the kubeconfig selected by
+// the default loading rules, sorted by name.
+func defaultContextLister(kubeconfig string) ([]string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	names := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CurrentContext returns the current context of the kubeconfig selected by
+// the default loading rules, or of kubeconfig when set, as kubectl would
+// use it. It reads only the kubeconfig files and never contacts a cluster.
+func CurrentContext(kubeconfig string) (string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	raw, err := rules.Load()
+	if err != nil {
+		return "", fmt.Errorf("load kubeconfig: %w", err)
+	}
+	return raw.CurrentContext, nil
+}
+
+// KubeconfigFiles returns the files CurrentContext reads for kubeconfig:
+// kubeconfig itself when set, otherwise the KUBECONFIG list or
+// ~/.kube/config.
+func KubeconfigFiles(kubeconfig string) []string {
+	if kubeconfig != "" {
+		return []string{kubeconfig}
+	}
+	return clientcmd.NewDefaultClientConfigLoadingRules().GetLoadingPrecedence()
+}
+
 // ---------- Collector ----------
 
 // Collector implements the pkg/collectors.Collector interface for Kubernetes.
 type Collector struct {
 	cfg     Config
 	factory clientFactory
+	lister  contextLister
 
 	mu      sync.RWMutex
 	healthy bool
+
+	// Watch mode state, see watch.go.
+	watchMu         sync.Mutex
+	watchers        map[string]*clusterWatcher
+	watchRetryAt    map[string]time.Time
+	watchCtx        context.Context
+	watchCancel     context.CancelFunc
+	watchBackoffMin time.Duration
+	watchBackoffMax time.Duration
 }
 
 // New creates a Collector with the given configuration.
@@ -196,9 +317,12 @@ func New(cfg Config) *Collector {
 		cfg.Interval = defaultInterval
 	}
 	return &Collector{
-		cfg:     cfg,
-		factory: defaultClientFactory,
-		healthy: true,
+		cfg:             cfg,
+		factory:         defaultClientFactory,
+		lister:          defaultContextLister,
+		healthy:         true,
+		watchBackoffMin: defaultWatchBackoffMin,
+		watchBackoffMax: defaultWatchBackoffMax,
 	}
 }
 
@@ -229,24 +353,45 @@ func (c *Collector) setHealthy(h bool) {
 }
 
 // Collect gathers Kubernetes cluster status from all configured contexts.
-// On success, Healthy() returns true. On total failure, Healthy() returns false
+// In watch mode each context is snapshotted from its watcher when one is
+// running. On success, Healthy() returns true. On total failure, Healthy() returns false
 // but a partial ClusterStatus with error details is still returned (not a Go error).
 func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
-	contexts := c.cfg.Contexts
-	if len(contexts) == 0 {
-		// Use the current/default context (empty string means default).
-		contexts = []string{""}
-	}
-
 	status := &ClusterStatus{
-		Clusters:  make([]ClusterInfo, 0, len(contexts)),
 		Timestamp: time.Now(),
 	}
 
+	contexts, err := c.resolveContexts()
+	if err != nil {
+		// Discovery failed: report it as a single disconnected entry.
+		info := ClusterInfo{Context: AllContexts, Error: err.Error()}
+		info.Health, info.HealthReasons = EvaluateHealth(info, c.cfg.UtilizationWarn)
+		status.Clusters = []ClusterInfo{info}
+		status.Health = info.Health
+		c.setHealthy(false)
+		return status, nil
+	}
+	status.Clusters = make([]ClusterInfo, 0, len(contexts))
+	if c.cfg.Watch {
+		c.pruneWatchers(contexts)
+	}
+
 	anyConnected := false
+	status.Health = HealthOK
 
 	for _, ctxName := range contexts {
-		info := c.collectContext(ctx, ctxName)
+		var info ClusterInfo
+		if w := c.watchFor(ctx, ctxName); w != nil {
+			// Quotas change rarely and are not watched; they are listed
+			// on each collection.
+			info = w.snapshot(c.collectQuotas(ctx, w.client, c.cfg.Namespaces))
+		} else {
+			info = c.collectContext(ctx, ctxName)
+		}
+		info.Health, info.HealthReasons = EvaluateHealth(info, c.cfg.UtilizationWarn)
+		if info.Health.Worse(status.Health) {
+			status.Health = info.Health
+		}
 		status.Clusters = append(status.Clusters, info)
 		if info.Connected {
 			anyConnected = true
@@ -258,6 +403,43 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 	return status, nil
 }
 
+// resolveContexts returns the contexts to collect. With AllContexts the
+// kubeconfig is re-read each time so added and removed clusters are picked
+// up without a restart.
+func (c *Collector) resolveContexts() ([]string, error) {
+	switch {
+	case len(c.cfg.Contexts) == 0:
+		// Use the current/default context (empty string means default).
+		return []string{""}, nil
+	case len(c.cfg.Contexts) == 1 && c.cfg.Contexts[0] == AllContexts:
+	default:
+		return c.cfg.Contexts, nil
+	}
+
+	discovered, err := c.lister(c.cfg.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("discover contexts: %w", err)
+	}
+	contexts := make([]string, 0, len(discovered))
+	for _, name := range discovered {
+		if !excludedContext(name, c.cfg.ExcludeContexts) {
+			contexts = append(contexts, name)
+		}
+	}
+	return contexts, nil
+}
+
+// excludedContext reports whether name matches any exclude pattern.
+// Malformed patterns never match.
+func excludedContext(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // collectContext gathers data for a single kubeconfig context.
 func (c *Collector) collectContext(ctx context.Context, ctxName string) ClusterInfo {
 	info := ClusterInfo{
@@ -293,6 +475,19 @@ func (c *Collector) collectContext(ctx context.Context, ctxName string) ClusterI
 	// Fetch all deployments across target namespaces.
 	deploysByNs := c.collectDeployments(ctx, client, namespacesToQuery)
 
+	// Fetch resource quotas across target namespaces.
+	quotasByNs := c.collectQuotas(ctx, client, namespacesToQuery)
+
+	assembleClusterInfo(&info, nodes, namespacesToQuery, allPods, podsByNs, deploysByNs, quotasByNs)
+	return info
+}
+
+// assembleClusterInfo fills in the node, namespace, and pod count fields of
+// info from raw API objects. Both the list and watch paths build their
+// ClusterInfo through it.
+func assembleClusterInfo(info *ClusterInfo, nodes []corev1.Node, namespaces []string,
+	allPods []corev1.Pod, podsByNs map[string][]corev1.Pod, deploysByNs map[string][]appsv1.Deployment,
+	quotasByNs map[string][]corev1.ResourceQuota) {
 	// Build node info (with pod counts per node).
 	podCountsByNode := countPodsByNode(allPods)
 	for i := range nodes {
@@ -301,11 +496,15 @@ func (c *Collector) collectContext(ctx context.Context, ctxName string) ClusterI
 	}
 
 	// Build namespace info.
-	for _, ns := range namespacesToQuery {
+	allocCPU, allocMem := allocatable(nodes)
+	for _, ns := range namespaces {
 		nsInfo := NamespaceInfo{
 			Name:      ns,
 			PodCounts: countPodPhases(podsByNs[ns]),
 		}
+		cpuReq, memReq := sumPodRequests(podsByNs[ns])
+		nsInfo.CPU = resourceUsage(cpuReq, allocCPU, quotasByNs[ns], corev1.ResourceRequestsCPU, corev1.ResourceCPU)
+		nsInfo.Memory = resourceUsage(memReq, allocMem, quotasByNs[ns], corev1.ResourceRequestsMemory, corev1.ResourceMemory)
 		if deps, ok := deploysByNs[ns]; ok {
 			for i := range deps {
 				nsInfo.Deployments = append(nsInfo.Deployments, buildDeploymentInfo(&deps[i]))
@@ -316,8 +515,6 @@ func (c *Collector) collectContext(ctx context.Context, ctxName string) ClusterI
 
 	// Aggregate pod counts.
 	info.TotalPods, info.RunningPods, info.PendingPods, info.FailedPods = aggregatePodCounts(allPods)
-
-	return info
 }
 
 // resolveNamespaces returns the list of namespaces to query.
@@ -392,15 +589,43 @@ func (c *Collector) collectDeployments(ctx context.Context, client K8sClient, na
 	return byNs
 }
 
+// collectQuotas fetches resource quotas from all target namespaces. Errors
+// are not fatal: without quotas, namespaces are measured against the
+// cluster's allocatable resources.
+func (c *Collector) collectQuotas(ctx context.Context, client K8sClient, namespaces []string) map[string][]corev1.ResourceQuota {
+	byNs := make(map[string][]corev1.ResourceQuota, len(namespaces))
+
+	if len(c.cfg.Namespaces) > 0 {
+		for _, ns := range namespaces {
+			quotas, err := client.ListResourceQuotas(ctx, ns)
+			if err != nil {
+				continue
+			}
+			byNs[ns] = quotas
+		}
+	} else {
+		quotas, err := client.ListResourceQuotas(ctx, "")
+		if err != nil {
+			return byNs
+		}
+		for i := range quotas {
+			ns := quotas[i].Namespace
+			byNs[ns] = append(byNs[ns], quotas[i])
+		}
+	}
+	return byNs
+}
+
 // ---------- Node helpers ----------
 
 // buildNodeInfo constructs a NodeInfo from a corev1.Node and pod data.
 func buildNodeInfo(node *corev1.Node, podCountsByNode map[string]int, allPods []corev1.Pod) NodeInfo {
 	ni := NodeInfo{
-		Name:     node.Name,
-		Ready:    isNodeReady(node),
-		Roles:    extractRoles(node),
-		PodCount: podCountsByNode[node.Name],
+		Name:          node.Name,
+		Ready:         isNodeReady(node),
+		Unschedulable: node.Spec.Unschedulable,
+		Roles:         extractRoles(node),
+		PodCount:      podCountsByNode[node.Name],
 	}
 
 	// Resource capacity.
@@ -509,8 +734,74 @@ func extractNonReadyConditions(node *corev1.Node) []string {
 	return conds
 }
 
+// allocatable returns the CPU (in millicores) and memory (in bytes) the
+// Ready nodes can give to pods.
+func allocatable(nodes []corev1.Node) (cpu, mem int64) {
+	for i := range nodes {
+		if !isNodeReady(&nodes[i]) {
+			continue
+		}
+		if v, ok := nodes[i].Status.Allocatable[corev1.ResourceCPU]; ok {
+			cpu += v.MilliValue()
+		}
+		if v, ok := nodes[i].Status.Allocatable[corev1.ResourceMemory]; ok {
+			mem += v.Value()
+		}
+	}
+	return cpu, mem
+}
+
+// ---------- Quota helpers ----------
+
+// resourceUsage measures requested against the tightest hard limit the
+// quotas set under either name, in the units of requested, falling back to
+// the cluster's allocatable.
+func resourceUsage(requested, allocatable int64, quotas []corev1.ResourceQuota, names ...corev1.ResourceName) ResourceUsage {
+	u := ResourceUsage{Requested: requested, Limit: allocatable}
+	for i := range quotas {
+		for _, name := range names {
+			v, ok := quotas[i].Spec.Hard[name]
+			if !ok {
+				continue
+			}
+			limit := v.Value()
+			if name == corev1.ResourceCPU || name == corev1.ResourceRequestsCPU {
+				limit = v.MilliValue()
+			}
+			if !u.Quota || limit < u.Limit {
+				u.Limit, u.Quota = limit, true
+			}
+		}
+	}
+	if u.Limit > 0 {
+		u.Percent = float64(u.Requested) / float64(u.Limit) * 100
+	}
+	return u
+}
+
 // ---------- Pod helpers ----------
 
+// sumPodRequests returns the CPU (in millicores) and memory (in bytes)
+// requested by the containers of pods that have not terminated, which are
+// the ones a quota counts.
+func sumPodRequests(pods []corev1.Pod) (cpu, mem int64) {
+	for i := range pods {
+		if phase := pods[i].Status.Phase; phase == corev1.PodSucceeded || phase == corev1.PodFailed {
+			continue
+		}
+		for j := range pods[i].Spec.Containers {
+			req := pods[i].Spec.Containers[j].Resources.Requests
+			if v, ok := req[corev1.ResourceCPU]; ok {
+				cpu += v.MilliValue()
+			}
+			if v, ok := req[corev1.ResourceMemory]; ok {
+				mem += v.Value()
+			}
+		}
+	}
+	return cpu, mem
+}
+
 // countPodsByNode maps node name to the number of pods scheduled on it.
 func countPodsByNode(pods []corev1.Pod) map[string]int {
 	counts := make(map[string]int)
@@ -3,6 +3,10 @@ package k8s
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,11 +14,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 // ---------- Mock K8sClient ----------
 
-// mockClient implements K8sClient with configurable return values.
+// mockClient implements K8sClient and WatchClient with configurable return
+// values. Every Watch* call returns a new FakeWatcher recorded under its
+// stream name ("nodes", "pods", "pods/default", ...).
 type mockClient struct {
 	nodes       []corev1.Node
 	nodesErr    error
@@ -24,13 +31,25 @@ type mockClient struct {
 	depsErr     error
 	namespaces  []corev1.Namespace
 	nsErr       error
+	quotas      map[string][]corev1.ResourceQuota
+	quotasErr   error
+	watchErr    error
+
+	mu           sync.Mutex
+	listPodCalls int
+	watches      map[string][]*watch.FakeWatcher
 }
 
 func (m *mockClient) ListNodes(_ context.Context) ([]corev1.Node, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.nodes, m.nodesErr
 }
 
 func (m *mockClient) ListPods(_ context.Context, namespace string) ([]corev1.Pod, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listPodCalls++
 	if m.podsErr != nil {
 		return nil, m.podsErr
 	}
@@ -41,6 +60,8 @@ func (m *mockClient) ListPods(_ context.Context, namespace string) ([]corev1.Pod
 }
 
 func (m *mockClient) ListDeployments(_ context.Context, namespace string) ([]appsv1.Deployment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.depsErr != nil {
 		return nil, m.depsErr
 	}
@@ -51,9 +72,64 @@ func (m *mockClient) ListDeployments(_ context.Context, namespace string) ([]app
 }
 
 func (m *mockClient) ListNamespaces(_ context.Context) ([]corev1.Namespace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.namespaces, m.nsErr
 }
 
+func (m *mockClient) ListResourceQuotas(_ context.Context, namespace string) ([]corev1.ResourceQuota, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.quotasErr != nil {
+		return nil, m.quotasErr
+	}
+	return m.quotas[namespace], nil
+}
+
+func (m *mockClient) WatchNodes(_ context.Context) (watch.Interface, error) {
+	return m.newWatch(watchStream{kind: kindNodes})
+}
+
+func (m *mockClient) WatchPods(_ context.Context, namespace string) (watch.Interface, error) {
+	return m.newWatch(watchStream{kind: kindPods, namespace: namespace})
+}
+
+func (m *mockClient) WatchDeployments(_ context.Context, namespace string) (watch.Interface, error) {
+	return m.newWatch(watchStream{kind: kindDeployments, namespace: namespace})
+}
+
+func (m *mockClient) newWatch(s watchStream) (watch.Interface, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.watchErr != nil {
+		return nil, m.watchErr
+	}
+	if m.watches == nil {
+		m.watches = make(map[string][]*watch.FakeWatcher)
+	}
+	fw := watch.NewFakeWithChanSize(16, false)
+	m.watches[s.String()] = append(m.watches[s.String()], fw)
+	return fw, nil
+}
+
+// latestWatch returns the most recent watcher for a stream and how many
+// have been opened.
+func (m *mockClient) latestWatch(stream string) (*watch.FakeWatcher, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws := m.watches[stream]
+	if len(ws) == 0 {
+		return nil, 0
+	}
+	return ws[len(ws)-1], len(ws)
+}
+
+func (m *mockClient) podListCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listPodCalls
+}
+
 // ---------- Helper builders ----------
 
 func makeNode(name string, ready bool, labels map[string]string, cpuCap, memCap string, extraConditions ...corev1.NodeCondition) corev1.Node {
@@ -83,6 +159,7 @@ func makeNode(name string, ready bool, labels map[string]string, cpuCap, memCap
 	if memCap != "" {
 		n.Status.Capacity[corev1.ResourceMemory] = resource.MustParse(memCap)
 	}
+	n.Status.Allocatable = n.Status.Capacity.DeepCopy()
 	return n
 }
 
@@ -144,6 +221,19 @@ func makeNamespace(name string) corev1.Namespace {
 	}
 }
 
+// makeQuota builds a ResourceQuota with the given hard limits, as
+// name/quantity pairs.
+func makeQuota(name, namespace string, hard ...string) corev1.ResourceQuota {
+	q := corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{}},
+	}
+	for i := 0; i+1 < len(hard); i += 2 {
+		q.Spec.Hard[corev1.ResourceName(hard[i])] = resource.MustParse(hard[i+1])
+	}
+	return q
+}
+
 func int32Ptr(v int32) *int32 { return &v }
 
 // mockFactory returns a clientFactory that ignores kubeconfig/context and
@@ -1071,3 +1161,632 @@ func TestCollect_DeploymentNilReplicas(t *testing.T) {
 		t.Errorf("ReadyReplicas = %d, want 1", d.ReadyReplicas)
 	}
 }
+
+// ---------- Health evaluation ----------
+
+func TestCollect_HealthWarningReasons(t *testing.T) {
+	cordoned := makeNode("node-2", true, nil, "4", "8Gi",
+		corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue})
+	cordoned.Spec.Unschedulable = true
+
+	mock := &mockClient{
+		nodes: []corev1.Node{
+			makeNode("node-1", true, map[string]string{"node-role.kubernetes.io/control-plane": ""}, "4", "8Gi"),
+			cordoned,
+		},
+		pods: map[string][]corev1.Pod{"": {
+			makePod("web-1", "default", "node-1", corev1.PodRunning, "", ""),
+			makePod("web-2", "default", "node-2", corev1.PodPending, "", ""),
+			makePod("job-1", "default", "node-2", corev1.PodSucceeded, "", ""),
+			makePod("job-2", "default", "node-2", corev1.PodSucceeded, "", ""),
+		}},
+		namespaces: []corev1.Namespace{makeNamespace("default")},
+		deployments: map[string][]appsv1.Deployment{"": {
+			makeDeployment("web", "default", 2, 1, 2, 1),
+		}},
+	}
+
+	c := newWithFactory(Config{}, mockFactory(mock))
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	status := result.(*ClusterStatus)
+	cl := status.Clusters[0]
+
+	if !cl.Nodes[1].Unschedulable {
+		t.Error("node-2 should be Unschedulable")
+	}
+	// 1 of 2 non-completed pods is running: exactly half is not critical.
+	if cl.Health != HealthWarning || status.Health != HealthWarning {
+		t.Fatalf("Health = %q (status %q), want warning; reasons %v", cl.Health, status.Health, cl.HealthReasons)
+	}
+	want := []string{
+		"node node-2 cordoned",
+		"node node-2 DiskPressure",
+		"deployment default/web 1/2 ready",
+	}
+	if len(cl.HealthReasons) != len(want) {
+		t.Fatalf("HealthReasons = %v, want %v", cl.HealthReasons, want)
+	}
+	for i := range want {
+		if cl.HealthReasons[i] != want[i] {
+			t.Errorf("HealthReasons[%d] = %q, want %q", i, cl.HealthReasons[i], want[i])
+		}
+	}
+}
+
+func TestCollect_NamespaceUtilization(t *testing.T) {
+	mock := &mockClient{
+		nodes: []corev1.Node{
+			makeNode("node-1", true, nil, "4", "8Gi"),
+			makeNode("node-2", false, nil, "4", "8Gi"),
+		},
+		pods: map[string][]corev1.Pod{"": {
+			makePod("api-1", "team-a", "node-1", corev1.PodRunning, "500m", "1Gi"),
+			makePod("api-2", "team-a", "", corev1.PodPending, "450m", "512Mi"),
+			makePod("job-1", "team-a", "node-1", corev1.PodSucceeded, "1", "1Gi"),
+			makePod("web-1", "default", "node-1", corev1.PodRunning, "1", "2Gi"),
+		}},
+		namespaces: []corev1.Namespace{makeNamespace("default"), makeNamespace("team-a")},
+		quotas: map[string][]corev1.ResourceQuota{"": {
+			makeQuota("compute", "team-a", "requests.cpu", "1", "requests.memory", "2Gi"),
+			makeQuota("legacy", "team-a", "cpu", "2"),
+		}},
+	}
+
+	c := newWithFactory(Config{UtilizationWarn: 90}, mockFactory(mock))
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	cl := result.(*ClusterStatus).Clusters[0]
+
+	const gib = 1 << 30
+	want := map[string][2]ResourceUsage{
+		// The tightest quota applies; the completed job is not counted.
+		"team-a": {
+			{Requested: 950, Limit: 1000, Percent: 95, Quota: true},
+			{Requested: gib + gib/2, Limit: 2 * gib, Percent: 75, Quota: true},
+		},
+		// Without a quota, only the Ready node's allocatable counts.
+		"default": {
+			{Requested: 1000, Limit: 4000, Percent: 25},
+			{Requested: 2 * gib, Limit: 8 * gib, Percent: 25},
+		},
+	}
+	for _, ns := range cl.Namespaces {
+		if w := want[ns.Name]; ns.CPU != w[0] || ns.Memory != w[1] {
+			t.Errorf("%s usage = %+v / %+v, want %+v / %+v", ns.Name, ns.CPU, ns.Memory, w[0], w[1])
+		}
+	}
+
+	// node-2 NotReady comes first; the namespace over 90% follows.
+	if cl.Health != HealthWarning || len(cl.HealthReasons) != 2 ||
+		cl.HealthReasons[1] != "namespace team-a CPU requests 95% of quota" {
+		t.Errorf("health = %q %v, want a warning for team-a CPU", cl.Health, cl.HealthReasons)
+	}
+
+	// Quotas that cannot be listed leave every namespace on allocatable.
+	mock.quotasErr = errors.New("forbidden")
+	result, _ = c.Collect(context.Background())
+	for _, ns := range result.(*ClusterStatus).Clusters[0].Namespaces {
+		if ns.CPU.Quota || ns.CPU.Limit != 4000 {
+			t.Errorf("%s CPU = %+v without quotas, want the 4000m allocatable", ns.Name, ns.CPU)
+		}
+	}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        ClusterInfo
+		want        HealthLevel
+		firstReason string
+	}{
+		{
+			name: "healthy",
+			info: ClusterInfo{
+				Connected: true, TotalPods: 3, RunningPods: 3,
+				Nodes: []NodeInfo{{Name: "n1", Ready: true}},
+			},
+			want: HealthOK,
+		},
+		{
+			name: "worker NotReady",
+			info: ClusterInfo{
+				Connected: true,
+				Nodes:     []NodeInfo{{Name: "n1", Ready: true}, {Name: "n2"}},
+			},
+			want:        HealthWarning,
+			firstReason: "node n2 NotReady",
+		},
+		{
+			name: "control plane down",
+			info: ClusterInfo{
+				Connected: true,
+				Nodes:     []NodeInfo{{Name: "cp", Roles: []string{"master"}}, {Name: "n2"}},
+			},
+			want:        HealthCritical,
+			firstReason: "control-plane node cp NotReady",
+		},
+		{
+			name: "most pods not running",
+			info: ClusterInfo{
+				Connected: true, TotalPods: 5, RunningPods: 2, PendingPods: 3,
+				Nodes: []NodeInfo{{Name: "n1", Ready: true, Conditions: []string{"MemoryPressure"}}},
+			},
+			want:        HealthCritical,
+			firstReason: "3/5 pods not running",
+		},
+		{
+			name: "namespace over its memory quota",
+			info: ClusterInfo{
+				Connected: true,
+				Namespaces: []NamespaceInfo{
+					{Name: "ci", Memory: ResourceUsage{Requested: 95, Limit: 100, Percent: 95, Quota: true}},
+					{Name: "web", CPU: ResourceUsage{Requested: 80, Limit: 100, Percent: 80}},
+				},
+			},
+			want:        HealthWarning,
+			firstReason: "namespace ci memory requests 95% of quota",
+		},
+		{
+			name:        "disconnected",
+			info:        ClusterInfo{Error: "timeout"},
+			want:        HealthCritical,
+			firstReason: "cluster unreachable: timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reasons := EvaluateHealth(tt.info, DefaultUtilizationWarn)
+			if got != tt.want {
+				t.Errorf("level = %q, want %q (reasons %v)", got, tt.want, reasons)
+			}
+			if tt.firstReason == "" {
+				if len(reasons) != 0 {
+					t.Errorf("reasons = %v, want none", reasons)
+				}
+				return
+			}
+			if len(reasons) == 0 || reasons[0] != tt.firstReason {
+				t.Errorf("reasons = %v, want first %q", reasons, tt.firstReason)
+			}
+		})
+	}
+}
+
+func TestHealthSummary(t *testing.T) {
+	tests := []struct {
+		levels []HealthLevel
+		want   string
+	}{
+		{nil, "no clusters"},
+		{[]HealthLevel{HealthOK}, "1 cluster, healthy"},
+		{[]HealthLevel{HealthOK, HealthWarning}, "2 clusters, 1 warning"},
+		{[]HealthLevel{HealthCritical, HealthWarning, HealthWarning}, "3 clusters, 1 critical, 2 warnings"},
+	}
+	for _, tt := range tests {
+		s := &ClusterStatus{}
+		for _, l := range tt.levels {
+			s.Clusters = append(s.Clusters, ClusterInfo{Health: l})
+		}
+		if got := s.HealthSummary(); got != tt.want {
+			t.Errorf("HealthSummary(%v) = %q, want %q", tt.levels, got, tt.want)
+		}
+	}
+}
+
+// ---------- Watch mode ----------
+
+// watchCollector returns a watch-mode collector over mock with millisecond
+// backoff, stopped when the test ends.
+func watchCollector(t *testing.T, cfg Config, mock K8sClient) *Collector {
+	t.Helper()
+	cfg.Watch = true
+	c := newWithFactory(cfg, mockFactory(mock))
+	c.watchBackoffMin = time.Millisecond
+	c.watchBackoffMax = 5 * time.Millisecond
+	t.Cleanup(c.Stop)
+	return c
+}
+
+// collectUntil collects until cond holds for the first cluster or the
+// deadline passes.
+func collectUntil(t *testing.T, c *Collector, desc string, cond func(ClusterInfo) bool) ClusterInfo {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		result, err := c.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("Collect() error = %v", err)
+		}
+		info := result.(*ClusterStatus).Clusters[0]
+		if cond(info) {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s; last cluster %+v", desc, info)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitForWatch waits until n watchers have been opened for stream.
+func waitForWatch(t *testing.T, mock *mockClient, stream string, n int) *watch.FakeWatcher {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if fw, got := mock.latestWatch(stream); got >= n {
+			return fw
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for watch %d on %s", n, stream)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCollect_Watch_IncrementalUpdates(t *testing.T) {
+	web1 := makePod("web-1", "default", "node-1", corev1.PodRunning, "", "")
+	mock := &mockClient{
+		nodes:      []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:       map[string][]corev1.Pod{"": {web1}},
+		namespaces: []corev1.Namespace{makeNamespace("default")},
+		deployments: map[string][]appsv1.Deployment{"": {
+			makeDeployment("web", "default", 2, 1, 2, 1),
+		}},
+		quotas: map[string][]corev1.ResourceQuota{"": {makeQuota("compute", "default", "requests.cpu", "2")}},
+	}
+	c := watchCollector(t, Config{}, mock)
+
+	info := collectUntil(t, c, "initial state", func(ci ClusterInfo) bool { return ci.TotalPods == 1 })
+	if !info.Connected || len(info.Nodes) != 1 || info.Nodes[0].PodCount != 1 {
+		t.Fatalf("initial snapshot = %+v, want one connected node with one pod", info)
+	}
+	if cpu := info.Namespaces[0].CPU; !cpu.Quota || cpu.Limit != 2000 {
+		t.Errorf("namespace CPU = %+v, want the 2-core quota listed alongside the watch", cpu)
+	}
+
+	pods := waitForWatch(t, mock, "pods", 1)
+	web2 := makePod("web-2", "default", "node-1", corev1.PodPending, "", "")
+	pods.Add(&web2)
+	info = collectUntil(t, c, "added pending pod", func(ci ClusterInfo) bool { return ci.PendingPods == 1 })
+	if info.TotalPods != 2 {
+		t.Errorf("TotalPods = %d, want 2", info.TotalPods)
+	}
+
+	web2.Status.Phase = corev1.PodRunning
+	pods.Modify(&web2)
+	pods.Delete(&web1)
+	info = collectUntil(t, c, "modify and delete", func(ci ClusterInfo) bool {
+		return ci.TotalPods == 1 && ci.RunningPods == 1
+	})
+	if info.Namespaces[0].PodCounts.Running != 1 {
+		t.Errorf("namespace running = %d, want 1", info.Namespaces[0].PodCounts.Running)
+	}
+
+	deps := waitForWatch(t, mock, "deployments", 1)
+	ready := makeDeployment("web", "default", 2, 2, 2, 2)
+	deps.Modify(&ready)
+	collectUntil(t, c, "deployment ready", func(ci ClusterInfo) bool {
+		return ci.Namespaces[0].Deployments[0].ReadyReplicas == 2
+	})
+
+	// A new namespace appears from watch events alone.
+	job := makePod("job-1", "batch", "node-1", corev1.PodSucceeded, "", "")
+	pods.Add(&job)
+	info = collectUntil(t, c, "new namespace", func(ci ClusterInfo) bool { return len(ci.Namespaces) == 2 })
+	if info.Namespaces[0].Name != "batch" {
+		t.Errorf("Namespaces[0] = %q, want batch (sorted)", info.Namespaces[0].Name)
+	}
+
+	if got := mock.podListCalls(); got != 1 {
+		t.Errorf("ListPods called %d times, want 1 (no relist per Collect)", got)
+	}
+}
+
+func TestCollect_Watch_ReconnectsAfterDrop(t *testing.T) {
+	mock := &mockClient{
+		nodes:      []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:       map[string][]corev1.Pod{"": {makePod("web-1", "default", "node-1", corev1.PodRunning, "", "")}},
+		namespaces: []corev1.Namespace{makeNamespace("default")},
+	}
+	c := watchCollector(t, Config{}, mock)
+	collectUntil(t, c, "initial state", func(ci ClusterInfo) bool { return ci.TotalPods == 1 })
+
+	// Dropping the watch triggers a relist and a new watch.
+	first := waitForWatch(t, mock, "pods", 1)
+	first.Stop()
+	second := waitForWatch(t, mock, "pods", 2)
+	if got := mock.podListCalls(); got != 2 {
+		t.Errorf("ListPods called %d times after reconnect, want 2", got)
+	}
+
+	extra := makePod("web-2", "default", "node-1", corev1.PodRunning, "", "")
+	second.Add(&extra)
+	collectUntil(t, c, "event on the new watch", func(ci ClusterInfo) bool { return ci.RunningPods == 2 })
+}
+
+func TestCollect_Watch_ErrorEventReconnects(t *testing.T) {
+	mock := &mockClient{
+		nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:  map[string][]corev1.Pod{"": {}},
+	}
+	c := watchCollector(t, Config{}, mock)
+	collectUntil(t, c, "initial state", func(ci ClusterInfo) bool { return ci.Connected })
+
+	waitForWatch(t, mock, "nodes", 1).Error(&metav1.Status{Message: "too old resource version"})
+	waitForWatch(t, mock, "nodes", 2)
+}
+
+func TestCollect_Watch_NodeRelistFailureDisconnects(t *testing.T) {
+	mock := &mockClient{
+		nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:  map[string][]corev1.Pod{"": {}},
+	}
+	c := watchCollector(t, Config{}, mock)
+	collectUntil(t, c, "initial state", func(ci ClusterInfo) bool { return ci.Connected })
+
+	mock.mu.Lock()
+	mock.nodesErr = errors.New("connection refused")
+	mock.mu.Unlock()
+	waitForWatch(t, mock, "nodes", 1).Stop()
+
+	info := collectUntil(t, c, "disconnect", func(ci ClusterInfo) bool { return !ci.Connected })
+	if !strings.Contains(info.Error, "watch nodes: connection refused") {
+		t.Errorf("Error = %q, want the node watch error", info.Error)
+	}
+
+	// Recovery clears the error once the backoff loop relists successfully.
+	mock.mu.Lock()
+	mock.nodesErr = nil
+	mock.mu.Unlock()
+	collectUntil(t, c, "reconnect", func(ci ClusterInfo) bool { return ci.Connected && ci.Error == "" })
+}
+
+func TestCollect_Watch_NamespaceFiltering(t *testing.T) {
+	mock := &mockClient{
+		nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods: map[string][]corev1.Pod{
+			"app": {makePod("a", "app", "node-1", corev1.PodRunning, "", "")},
+		},
+	}
+	c := watchCollector(t, Config{Namespaces: []string{"app"}}, mock)
+	info := collectUntil(t, c, "initial state", func(ci ClusterInfo) bool { return ci.TotalPods == 1 })
+	if len(info.Namespaces) != 1 || info.Namespaces[0].Name != "app" {
+		t.Errorf("Namespaces = %+v, want only app", info.Namespaces)
+	}
+	if _, n := mock.latestWatch("pods/app"); n != 1 {
+		t.Errorf("pods/app watches = %d, want 1", n)
+	}
+	if _, n := mock.latestWatch("pods"); n != 0 {
+		t.Errorf("all-namespace pod watches = %d, want 0 when filtered", n)
+	}
+}
+
+func TestCollect_Watch_FallsBackToList(t *testing.T) {
+	newMock := func() *mockClient {
+		return &mockClient{
+			nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+			pods:  map[string][]corev1.Pod{"": {makePod("a", "default", "node-1", corev1.PodRunning, "", "")}},
+		}
+	}
+
+	tests := map[string]func(*mockClient) K8sClient{
+		"watch error": func(m *mockClient) K8sClient {
+			m.watchErr = errors.New("watch forbidden")
+			return m
+		},
+		"client without watch": func(m *mockClient) K8sClient {
+			return struct{ K8sClient }{m}
+		},
+	}
+
+	for name, setup := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock := newMock()
+			c := watchCollector(t, Config{}, setup(mock))
+
+			for i := 0; i < 2; i++ {
+				result, err := c.Collect(context.Background())
+				if err != nil {
+					t.Fatalf("Collect() error = %v", err)
+				}
+				if info := result.(*ClusterStatus).Clusters[0]; !info.Connected || info.TotalPods != 1 {
+					t.Fatalf("Collect %d = %+v, want list-path data", i, info)
+				}
+			}
+			if len(c.watchers) != 0 {
+				t.Errorf("watchers = %d, want none after setup failure", len(c.watchers))
+			}
+			if c.watchRetryAt[""].IsZero() {
+				t.Error("watch retry time should be set after setup failure")
+			}
+		})
+	}
+}
+
+func TestStop_EndsWatches(t *testing.T) {
+	mock := &mockClient{
+		nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:  map[string][]corev1.Pod{"": {}},
+	}
+	c := watchCollector(t, Config{}, mock)
+	collectUntil(t, c, "initial state", func(ci ClusterInfo) bool { return ci.Connected })
+
+	c.Stop()
+	if fw, _ := mock.latestWatch("nodes"); !fw.IsStopped() {
+		t.Error("node watch should be stopped after Stop")
+	}
+
+	// Collect after Stop starts fresh watches.
+	collectUntil(t, c, "restart", func(ci ClusterInfo) bool { return ci.Connected })
+	if _, n := mock.latestWatch("nodes"); n != 2 {
+		t.Errorf("node watches = %d after restart, want 2", n)
+	}
+}
+
+// ---------- Context discovery ----------
+
+// staticLister returns a contextLister that reports the given names.
+func staticLister(names ...string) contextLister {
+	return func(string) ([]string, error) { return names, nil }
+}
+
+func TestCollect_AllContexts(t *testing.T) {
+	good := &mockClient{
+		nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:  map[string][]corev1.Pod{"": {}},
+	}
+	c := newWithFactory(Config{
+		Contexts:        []string{AllContexts},
+		ExcludeContexts: []string{"kind-*"},
+	}, contextFactory(map[string]K8sClient{"prod": good, "staging": good}))
+	c.lister = staticLister("kind-dev", "offline", "prod", "staging")
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	status := result.(*ClusterStatus)
+
+	var got []string
+	for _, cl := range status.Clusters {
+		got = append(got, cl.Context)
+	}
+	if strings.Join(got, ",") != "offline,prod,staging" {
+		t.Fatalf("contexts = %v, want offline,prod,staging (kind-dev excluded)", got)
+	}
+	if status.Clusters[0].Connected || status.Clusters[0].Error == "" {
+		t.Errorf("offline = %+v, want disconnected with error", status.Clusters[0])
+	}
+	if !status.Clusters[1].Connected || !status.Clusters[2].Connected {
+		t.Error("prod and staging should be connected")
+	}
+	if !c.Healthy() {
+		t.Error("collector should be healthy while at least one context connects")
+	}
+
+	// Contexts are re-discovered on every collection.
+	c.lister = staticLister("prod")
+	result, _ = c.Collect(context.Background())
+	if n := len(result.(*ClusterStatus).Clusters); n != 1 {
+		t.Errorf("clusters after kubeconfig change = %d, want 1", n)
+	}
+}
+
+func TestCollect_AllContexts_NoneConnect(t *testing.T) {
+	c := newWithFactory(Config{Contexts: []string{AllContexts}}, errorFactory(errors.New("unreachable")))
+	c.lister = staticLister("a", "b")
+
+	result, _ := c.Collect(context.Background())
+	if n := len(result.(*ClusterStatus).Clusters); n != 2 {
+		t.Fatalf("clusters = %d, want 2", n)
+	}
+	if c.Healthy() {
+		t.Error("collector should be unhealthy when no discovered context connects")
+	}
+}
+
+func TestCollect_AllContexts_DiscoveryError(t *testing.T) {
+	c := newWithFactory(Config{Contexts: []string{AllContexts}}, mockFactory(&mockClient{}))
+	c.lister = func(string) ([]string, error) { return nil, errors.New("no kubeconfig") }
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	status := result.(*ClusterStatus)
+	if len(status.Clusters) != 1 || status.Clusters[0].Connected ||
+		!strings.Contains(status.Clusters[0].Error, "discover contexts: no kubeconfig") {
+		t.Errorf("clusters = %+v, want one discovery error entry", status.Clusters)
+	}
+	if c.Healthy() {
+		t.Error("collector should be unhealthy when discovery fails")
+	}
+}
+
+func TestDefaultContextLister(t *testing.T) {
+	path := t.TempDir() + "/config"
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: c1
+  cluster: {server: "https://127.0.0.1:6443"}
+users:
+- name: u1
+  user: {token: t}
+contexts:
+- name: staging
+  context: {cluster: c1, user: u1}
+- name: prod
+  context: {cluster: c1, user: u1}
+current-context: prod
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := defaultContextLister(path)
+	if err != nil {
+		t.Fatalf("defaultContextLister() error = %v", err)
+	}
+	if strings.Join(names, ",") != "prod,staging" {
+		t.Errorf("names = %v, want [prod staging]", names)
+	}
+}
+
+func TestCollect_Watch_PrunesRemovedContexts(t *testing.T) {
+	mock := &mockClient{
+		nodes: []corev1.Node{makeNode("node-1", true, nil, "4", "8Gi")},
+		pods:  map[string][]corev1.Pod{"": {}},
+	}
+	c := watchCollector(t, Config{Contexts: []string{AllContexts}}, mock)
+	c.lister = staticLister("a", "b")
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(c.watchers) != 2 {
+		t.Fatalf("watchers = %d, want 2", len(c.watchers))
+	}
+
+	c.lister = staticLister("a")
+	c.Collect(context.Background())
+	if _, ok := c.watchers["b"]; ok || len(c.watchers) != 1 {
+		t.Errorf("watchers = %v, want only a after b was removed", c.watchers)
+	}
+}
+
+func TestCurrentContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: prod
+contexts:
+- name: prod
+  context: {cluster: prod, user: admin}
+- name: staging
+  context: {cluster: staging, user: admin}
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := CurrentContext(path)
+	if err != nil {
+		t.Fatalf("CurrentContext() error: %v", err)
+	}
+	if got != "prod" {
+		t.Errorf("CurrentContext() = %q, want prod", got)
+	}
+	if files := KubeconfigFiles(path); len(files) != 1 || files[0] != path {
+		t.Errorf("KubeconfigFiles() = %v, want [%s]", files, path)
+	}
+
+	if _, err := CurrentContext(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("CurrentContext() with a missing kubeconfig succeeded")
+	}
+}
@@ -0,0 +1,499 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Watch mode timing. A dropped watch is re-established after
+// defaultWatchBackoffMin, doubling up to defaultWatchBackoffMax while the
+// relist or watch keeps failing. When watch setup fails outright the
+// context uses the list path until watchRetryInterval has passed.
+const (
+	defaultWatchBackoffMin = time.Second
+	defaultWatchBackoffMax = time.Minute
+	watchRetryInterval     = 5 * time.Minute
+)
+
+// WatchClient is implemented by K8sClients that can stream changes. In
+// watch mode the collector type-asserts its client to WatchClient and falls
+// back to listing when the assertion or the watch setup fails.
+type WatchClient interface {
+	WatchNodes(ctx context.Context) (watch.Interface, error)
+	WatchPods(ctx context.Context, namespace string) (watch.Interface, error)
+	WatchDeployments(ctx context.Context, namespace string) (watch.Interface, error)
+}
+
+func (r *realClient) WatchNodes(ctx context.Context) (watch.Interface, error) {
+	return r.cs.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{})
+}
+
+func (r *realClient) WatchPods(ctx context.Context, namespace string) (watch.Interface, error) {
+	return r.cs.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{})
+}
+
+func (r *realClient) WatchDeployments(ctx context.Context, namespace string) (watch.Interface, error) {
+	return r.cs.AppsV1().Deployments(namespace).Watch(ctx, metav1.ListOptions{})
+}
+
+// Resource kinds streamed by a clusterWatcher.
+const (
+	kindNodes       = "nodes"
+	kindPods        = "pods"
+	kindDeployments = "deployments"
+)
+
+// watchStream identifies one list+watch loop: a resource kind and, for pods
+// and deployments, the namespace ("" for all namespaces).
+type watchStream struct {
+	kind      string
+	namespace string
+}
+
+func (s watchStream) String() string {
+	if s.namespace == "" {
+		return s.kind
+	}
+	return s.kind + "/" + s.namespace
+}
+
+// ---------- Collector integration ----------
+
+// watchFor returns the running watcher for a context, starting one if
+// needed. It returns nil when the context should use the list path: the
+// client cannot watch, setup failed recently, or the collector is stopping.
+func (c *Collector) watchFor(ctx context.Context, ctxName string) *clusterWatcher {
+	if !c.cfg.Watch {
+		return nil
+	}
+
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	if w, ok := c.watchers[ctxName]; ok {
+		return w
+	}
+	if time.Now().Before(c.watchRetryAt[ctxName]) {
+		return nil
+	}
+
+	if c.watchers == nil {
+		c.watchers = make(map[string]*clusterWatcher)
+		c.watchRetryAt = make(map[string]time.Time)
+		c.watchCtx, c.watchCancel = context.WithCancel(context.Background())
+	}
+
+	w, err := c.startWatcher(ctx, ctxName)
+	if err != nil {
+		c.watchRetryAt[ctxName] = time.Now().Add(watchRetryInterval)
+		return nil
+	}
+	c.watchers[ctxName] = w
+	return w
+}
+
+// startWatcher lists and opens watches for every stream of a context. ctx
+// bounds the initial lists; the watches live until Stop.
+func (c *Collector) startWatcher(ctx context.Context, ctxName string) (*clusterWatcher, error) {
+	client, err := c.factory(c.cfg.Kubeconfig, ctxName)
+	if err != nil {
+		return nil, err
+	}
+	wc, ok := client.(WatchClient)
+	if !ok {
+		return nil, fmt.Errorf("client for context %q does not support watch", ctxName)
+	}
+
+	w := &clusterWatcher{
+		ctxName:     ctxName,
+		client:      client,
+		wc:          wc,
+		filtered:    len(c.cfg.Namespaces) > 0,
+		backoffMin:  c.watchBackoffMin,
+		backoffMax:  c.watchBackoffMax,
+		nodes:       make(map[string]corev1.Node),
+		pods:        make(map[string]corev1.Pod),
+		deployments: make(map[string]appsv1.Deployment),
+		streamErrs:  make(map[watchStream]string),
+	}
+	// A namespace list failure is non-fatal, as on the list path: namespaces
+	// are also discovered from the pods and deployments seen.
+	w.namespaces, _ = c.resolveNamespaces(ctx, client)
+
+	runCtx, cancel := context.WithCancel(c.watchCtx)
+	if err := w.start(ctx, runCtx); err != nil {
+		cancel()
+		return nil, err
+	}
+	w.cancel = cancel
+	return w, nil
+}
+
+// pruneWatchers stops the watchers of contexts that are no longer
+// collected, such as clusters removed from the kubeconfig under
+// AllContexts.
+func (c *Collector) pruneWatchers(contexts []string) {
+	keep := make(map[string]bool, len(contexts))
+	for _, name := range contexts {
+		keep[name] = true
+	}
+
+	c.watchMu.Lock()
+	var stale []*clusterWatcher
+	for name, w := range c.watchers {
+		if !keep[name] {
+			stale = append(stale, w)
+			delete(c.watchers, name)
+		}
+	}
+	c.watchMu.Unlock()
+
+	for _, w := range stale {
+		w.cancel()
+		w.wg.Wait()
+	}
+}
+
+// Stop ends the watches started in watch mode and waits for their
+// goroutines to exit. A later Collect starts fresh watches.
+func (c *Collector) Stop() {
+	c.watchMu.Lock()
+	cancel, watchers := c.watchCancel, c.watchers
+	c.watchCtx, c.watchCancel, c.watchers, c.watchRetryAt = nil, nil, nil, nil
+	c.watchMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	for _, w := range watchers {
+		w.wg.Wait()
+	}
+}
+
+// ---------- clusterWatcher ----------
+
+// clusterWatcher keeps one context's nodes, pods, and deployments in memory,
+// applying watch events as they arrive, so Collect can snapshot the cluster
+// without listing it.
+type clusterWatcher struct {
+	ctxName    string
+	client     K8sClient
+	wc         WatchClient
+	filtered   bool // namespaces come from Config.Namespaces
+	backoffMin time.Duration
+	backoffMax time.Duration
+
+	mu          sync.RWMutex
+	namespaces  []string
+	nodes       map[string]corev1.Node
+	pods        map[string]corev1.Pod        // keyed by namespace/name
+	deployments map[string]appsv1.Deployment // keyed by namespace/name
+	streamErrs  map[watchStream]string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// streams returns the list+watch loops this watcher runs. Filtered
+// collectors watch each configured namespace; otherwise pods and
+// deployments are watched across all namespaces.
+func (w *clusterWatcher) streams() []watchStream {
+	streams := []watchStream{{kind: kindNodes}}
+	if !w.filtered {
+		return append(streams, watchStream{kind: kindPods}, watchStream{kind: kindDeployments})
+	}
+	for _, ns := range w.namespaces {
+		streams = append(streams, watchStream{kind: kindPods, namespace: ns})
+	}
+	for _, ns := range w.namespaces {
+		streams = append(streams, watchStream{kind: kindDeployments, namespace: ns})
+	}
+	return streams
+}
+
+// start performs the initial list and opens a watch for every stream. If
+// any of them fails, the watches already opened are stopped and the error
+// is returned so the caller can fall back to listing.
+func (w *clusterWatcher) start(setupCtx, runCtx context.Context) error {
+	streams := w.streams()
+	watches := make([]watch.Interface, 0, len(streams))
+	stopAll := func() {
+		for _, wi := range watches {
+			wi.Stop()
+		}
+	}
+
+	for _, s := range streams {
+		if err := w.list(setupCtx, s); err != nil {
+			stopAll()
+			return fmt.Errorf("list %s: %w", s, err)
+		}
+		wi, err := w.open(runCtx, s)
+		if err != nil {
+			stopAll()
+			return fmt.Errorf("watch %s: %w", s, err)
+		}
+		watches = append(watches, wi)
+	}
+
+	for i, s := range streams {
+		w.wg.Add(1)
+		go w.run(runCtx, s, watches[i])
+	}
+	return nil
+}
+
+// run applies events from wi until the watch drops, then relists and
+// re-watches with exponential backoff. It returns when ctx is cancelled.
+func (w *clusterWatcher) run(ctx context.Context, s watchStream, wi watch.Interface) {
+	defer w.wg.Done()
+
+	for {
+		w.consume(ctx, wi)
+		wi.Stop()
+
+		backoff := w.backoffMin
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			err := w.list(ctx, s)
+			if err == nil {
+				wi, err = w.open(ctx, s)
+			}
+			w.setStreamErr(s, err)
+			if err == nil {
+				break
+			}
+			if backoff *= 2; backoff > w.backoffMax {
+				backoff = w.backoffMax
+			}
+		}
+	}
+}
+
+// consume applies events until the result channel closes, an error event
+// arrives, or ctx is cancelled.
+func (w *clusterWatcher) consume(ctx context.Context, wi watch.Interface) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-wi.ResultChan():
+			if !ok || ev.Type == watch.Error {
+				return
+			}
+			w.apply(ev)
+		}
+	}
+}
+
+// open starts the watch for a stream.
+func (w *clusterWatcher) open(ctx context.Context, s watchStream) (watch.Interface, error) {
+	switch s.kind {
+	case kindNodes:
+		return w.wc.WatchNodes(ctx)
+	case kindPods:
+		return w.wc.WatchPods(ctx, s.namespace)
+	default:
+		return w.wc.WatchDeployments(ctx, s.namespace)
+	}
+}
+
+// list fetches a stream's objects and replaces what the watcher holds for
+// that stream.
+func (w *clusterWatcher) list(ctx context.Context, s watchStream) error {
+	switch s.kind {
+	case kindNodes:
+		nodes, err := w.client.ListNodes(ctx)
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		w.nodes = make(map[string]corev1.Node, len(nodes))
+		for _, n := range nodes {
+			w.nodes[n.Name] = n
+		}
+		w.mu.Unlock()
+
+	case kindPods:
+		pods, err := w.client.ListPods(ctx, s.namespace)
+		if err != nil {
+			return err
+		}
+		if s.namespace != "" {
+			for i := range pods {
+				pods[i].Namespace = s.namespace
+			}
+		}
+		w.mu.Lock()
+		replaceNamespace(w.pods, s.namespace, pods, func(p corev1.Pod) metav1.ObjectMeta { return p.ObjectMeta })
+		w.mu.Unlock()
+
+	default:
+		deps, err := w.client.ListDeployments(ctx, s.namespace)
+		if err != nil {
+			return err
+		}
+		if s.namespace != "" {
+			for i := range deps {
+				deps[i].Namespace = s.namespace
+			}
+		}
+		w.mu.Lock()
+		replaceNamespace(w.deployments, s.namespace, deps, func(d appsv1.Deployment) metav1.ObjectMeta { return d.ObjectMeta })
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// apply updates the in-memory state for one watch event. Bookmarks and
+// unknown object types are ignored.
+func (w *clusterWatcher) apply(ev watch.Event) {
+	deleted := ev.Type == watch.Deleted
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch obj := ev.Object.(type) {
+	case *corev1.Node:
+		if deleted {
+			delete(w.nodes, obj.Name)
+		} else {
+			w.nodes[obj.Name] = *obj
+		}
+	case *corev1.Pod:
+		key := objectKey(obj.Namespace, obj.Name)
+		if deleted {
+			delete(w.pods, key)
+		} else {
+			w.pods[key] = *obj
+		}
+	case *appsv1.Deployment:
+		key := objectKey(obj.Namespace, obj.Name)
+		if deleted {
+			delete(w.deployments, key)
+		} else {
+			w.deployments[key] = *obj
+		}
+	}
+}
+
+// setStreamErr records or clears a stream's reconnect error.
+func (w *clusterWatcher) setStreamErr(s watchStream, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.streamErrs[s] = fmt.Sprintf("watch %s: %v", s, err)
+	} else {
+		delete(w.streamErrs, s)
+	}
+}
+
+// snapshot builds a ClusterInfo from the watcher's current state. While the
+// node stream cannot reconnect the cluster is reported as disconnected,
+// matching a ListNodes failure on the list path. Quotas are not watched and
+// are passed in by the caller.
+func (w *clusterWatcher) snapshot(quotasByNs map[string][]corev1.ResourceQuota) ClusterInfo {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	info := ClusterInfo{Context: w.ctxName}
+	if msg, ok := w.streamErrs[watchStream{kind: kindNodes}]; ok {
+		info.Error = msg
+		return info
+	}
+	info.Connected = true
+
+	errs := make([]string, 0, len(w.streamErrs))
+	for _, msg := range w.streamErrs {
+		errs = append(errs, msg)
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		info.Error = errs[0]
+	}
+
+	nodes := make([]corev1.Node, 0, len(w.nodes))
+	for _, n := range w.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	allPods := make([]corev1.Pod, 0, len(w.pods))
+	podsByNs := make(map[string][]corev1.Pod)
+	for _, p := range w.pods {
+		allPods = append(allPods, p)
+		podsByNs[p.Namespace] = append(podsByNs[p.Namespace], p)
+	}
+
+	deploysByNs := make(map[string][]appsv1.Deployment)
+	for _, d := range w.deployments {
+		deploysByNs[d.Namespace] = append(deploysByNs[d.Namespace], d)
+	}
+	for _, deps := range deploysByNs {
+		sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	}
+
+	namespaces := w.namespaces
+	if !w.filtered {
+		namespaces = mergeNamespaces(w.namespaces, podsByNs, deploysByNs)
+	}
+
+	assembleClusterInfo(&info, nodes, namespaces, allPods, podsByNs, deploysByNs, quotasByNs)
+	return info
+}
+
+// ---------- Helpers ----------
+
+// objectKey returns the map key for a namespaced object.
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// replaceNamespace swaps the objects held for a namespace ("" for all
+// namespaces) with a fresh list.
+func replaceNamespace[T any](m map[string]T, namespace string, items []T, meta func(T) metav1.ObjectMeta) {
+	for key, v := range m {
+		if namespace == "" || meta(v).Namespace == namespace {
+			delete(m, key)
+		}
+	}
+	for _, v := range items {
+		om := meta(v)
+		m[objectKey(om.Namespace, om.Name)] = v
+	}
+}
+
+// mergeNamespaces returns the sorted union of the listed namespaces and
+// those seen on watched pods and deployments, so namespaces created after
+// the watch started still appear.
+func mergeNamespaces(listed []string, podsByNs map[string][]corev1.Pod, deploysByNs map[string][]appsv1.Deployment) []string {
+	seen := make(map[string]bool, len(listed))
+	for _, ns := range listed {
+		seen[ns] = true
+	}
+	for ns := range podsByNs {
+		seen[ns] = true
+	}
+	for ns := range deploysByNs {
+		seen[ns] = true
+	}
+	out := make([]string, 0, len(seen))
+	for ns := range seen {
+		out = append(out, ns)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,178 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// httpClient implements Client against the Ollama HTTP API.
+type httpClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// newHTTPClient builds a client for the server at baseURL. Requests are
+// not retried: a local server either answers or is not running, and
+// retrying would only delay reporting it inactive.
+func newHTTPClient(baseURL string) *httpClient {
+	return &httpClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: httpx.New(httpx.Config{
+			Name:    "ollama",
+			Timeout: 5 * time.Second,
+			NoRetry: true,
+		}),
+	}
+}
+
+// get issues a GET for path and returns the response when its status is
+// 200 OK. Callers close the body.
+func (c *httpClient) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, &statusError{path: path, code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+// statusError is a response other than 200 OK.
+type statusError struct {
+	path string
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("ollama %s returned %d: %s", e.path, e.code, e.body)
+}
+
+// apiProcess is the subset of a GET /api/ps model we decode.
+type apiProcess struct {
+	Name      string    `json:"name"`
+	Size      uint64    `json:"size"`
+	SizeVRAM  uint64    `json:"size_vram"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Details   struct {
+		Family            string `json:"family"`
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+}
+
+// Running fetches the models loaded into memory.
+func (c *httpClient) Running(ctx context.Context) ([]Model, error) {
+	resp, err := c.get(ctx, "/api/ps")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var raw struct {
+		Models []apiProcess `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decoding /api/ps: %w", err)
+	}
+	models := make([]Model, 0, len(raw.Models))
+	for _, p := range raw.Models {
+		models = append(models, Model{
+			Name:          p.Name,
+			Family:        p.Details.Family,
+			ParameterSize: p.Details.ParameterSize,
+			Quantization:  p.Details.QuantizationLevel,
+			SizeBytes:     p.Size,
+			VRAMBytes:     p.SizeVRAM,
+			ExpiresAt:     p.ExpiresAt,
+		})
+	}
+	return models, nil
+}
+
+// Version fetches the server version.
+func (c *httpClient) Version(ctx context.Context) (string, error) {
+	resp, err := c.get(ctx, "/api/version")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return "", fmt.Errorf("decoding /api/version: %w", err)
+	}
+	return v.Version, nil
+}
+
+// Requests reads the request count from the Prometheus metrics at
+// /metrics, which stock Ollama does not serve but some builds and proxies
+// in front of it do. A missing endpoint reports false.
+func (c *httpClient) Requests(ctx context.Context) (int64, bool, error) {
+	resp, err := c.get(ctx, "/metrics")
+	if err != nil {
+		if se, ok := err.(*statusError); ok && se.code == http.StatusNotFound {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	n, ok := parseRequests(io.LimitReader(resp.Body, 1<<20))
+	return n, ok, nil
+}
+
+// parseRequests sums the samples of the Prometheus counters in r whose
+// names end in "_requests_total", and reports false when there are none.
+func parseRequests(r io.Reader) (int64, bool) {
+	var total float64
+	found := false
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, _, _ := strings.Cut(fields[0], "{")
+		if !strings.HasSuffix(name, "_requests_total") {
+			continue
+		}
+		// Labels may hold spaces; the value follows the closing brace.
+		value := fields[1]
+		if i := strings.LastIndex(line, "}"); i >= 0 {
+			rest := strings.Fields(line[i+1:])
+			if len(rest) == 0 {
+				continue
+			}
+			value = rest[0]
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		total += v
+		found = true
+	}
+	return int64(total), found
+}
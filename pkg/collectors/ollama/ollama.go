@@ -0,0 +1,253 @@
+// Package ollama provides a collector for a local Ollama server: the
+// models it has loaded, the VRAM and RAM each occupies, and, where the
+// server exposes Prometheus metrics, how many requests it has served. A
+// server that is not running is reported as inactive rather than as a
+// failure, since Ollama is often only started on demand.
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultURL      = "http://localhost:11434"
+)
+
+// Client abstracts the Ollama HTTP API for testability.
+type Client interface {
+	// Running lists the models loaded into memory (GET /api/ps).
+	Running(ctx context.Context) ([]Model, error)
+
+	// Version returns the server version (GET /api/version).
+	Version(ctx context.Context) (string, error)
+
+	// Requests returns the requests served since the server started, and
+	// false when it exposes no request metrics.
+	Requests(ctx context.Context) (int64, bool, error)
+}
+
+// Config holds the configuration for the Ollama collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// URL is the server's base URL. Empty uses OLLAMA_HOST, falling back
+	// to DefaultURL.
+	URL string
+}
+
+// Model is one model loaded into memory.
+type Model struct {
+	Name          string `json:"name"`
+	Family        string `json:"family,omitempty"`
+	ParameterSize string `json:"parameter_size,omitempty"`
+	Quantization  string `json:"quantization,omitempty"`
+
+	// SizeBytes is the memory the model occupies, VRAMBytes of it on the
+	// GPU and the rest in system RAM.
+	SizeBytes uint64 `json:"size_bytes"`
+	VRAMBytes uint64 `json:"vram_bytes"`
+
+	// ExpiresAt is when the server unloads the model if it stays idle.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RAMBytes returns the memory the model occupies outside the GPU.
+func (m Model) RAMBytes() uint64 {
+	if m.SizeBytes > m.VRAMBytes {
+		return m.SizeBytes - m.VRAMBytes
+	}
+	return 0
+}
+
+// Status is the data returned by a single Collect call. When the server is
+// not running Available is false and Error says why; the rest is then
+// empty.
+type Status struct {
+	Available bool    `json:"available"`
+	Error     string  `json:"error,omitempty"`
+	Version   string  `json:"version,omitempty"`
+	Models    []Model `json:"models"`
+
+	// VRAMBytes and RAMBytes total the loaded models' memory.
+	VRAMBytes uint64 `json:"vram_bytes"`
+	RAMBytes  uint64 `json:"ram_bytes"`
+
+	// Requests is the number of requests served since the server started,
+	// when it exposes request metrics.
+	Requests *int64 `json:"requests,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Summary returns a compact one-line reading such as
+// "Ollama llama3.1:8b, qwen2.5:7b · 9.4GB VRAM 1.2GB RAM · 128 req",
+// "Ollama idle" with no model loaded, or "Ollama inactive" when the
+// server is not running.
+func (s *Status) Summary() string {
+	if !s.Available {
+		return "Ollama inactive"
+	}
+	parts := []string{"Ollama idle"}
+	if len(s.Models) > 0 {
+		names := make([]string, len(s.Models))
+		for i, m := range s.Models {
+			names[i] = m.Name
+		}
+		parts = []string{"Ollama " + strings.Join(names, ", ")}
+		mem := gbText(s.VRAMBytes) + "GB VRAM"
+		if s.RAMBytes > 0 {
+			mem += " " + gbText(s.RAMBytes) + "GB RAM"
+		}
+		parts = append(parts, mem)
+	}
+	if s.Requests != nil {
+		parts = append(parts, fmt.Sprintf("%d req", *s.Requests))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// gbText formats bytes in GiB with one decimal, dropping a trailing ".0".
+func gbText(b uint64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(float64(b)/(1<<30), 'f', 1, 64), ".0")
+}
+
+// Collector gathers model and request data from an Ollama server.
+type Collector struct {
+	client   Client
+	interval time.Duration
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+// New creates a new Ollama collector. If cfg.Interval is zero,
+// DefaultInterval is used.
+func New(cfg Config) *Collector {
+	url := cfg.URL
+	if url == "" {
+		url = hostURL(os.Getenv("OLLAMA_HOST"))
+	}
+	if url == "" {
+		url = DefaultURL
+	}
+	return newWithClient(cfg, newHTTPClient(url))
+}
+
+// hostURL turns an OLLAMA_HOST value, which may leave out the scheme or
+// the port ("0.0.0.0", "127.0.0.1:11500"), into a base URL. A wildcard
+// listen address is reached on localhost.
+func hostURL(host string) string {
+	if host == "" {
+		return ""
+	}
+	scheme := "http"
+	if s, rest, ok := strings.Cut(host, "://"); ok {
+		scheme, host = s, rest
+	}
+	host = strings.TrimRight(host, "/")
+	h, port, err := net.SplitHostPort(host)
+	if err != nil {
+		h, port = host, "11434"
+	}
+	if h == "" || h == "0.0.0.0" || h == "::" {
+		h = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(h, port)
+}
+
+// newWithClient creates a collector with an injected client for testing.
+func newWithClient(cfg Config, client Client) *Collector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Collector{
+		client:   client,
+		interval: interval,
+		healthy:  true, // healthy until first failure
+	}
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "ollama"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// setHealthy updates the internal healthy flag under the mutex.
+func (c *Collector) setHealthy(v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthy = v
+}
+
+// Collect lists the loaded models and returns a Status snapshot. Like the
+// docker collector it never returns a Go error: a server that refuses the
+// connection yields Available=false with the collector still healthy,
+// while any other failure also marks it unhealthy. The version and
+// request count are best effort.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	status := &Status{
+		Models:    []Model{},
+		Timestamp: time.Now(),
+	}
+
+	models, err := c.client.Running(ctx)
+	if err != nil {
+		if notRunning(err) {
+			status.Error = "ollama not running: " + err.Error()
+			c.setHealthy(true)
+		} else {
+			status.Error = err.Error()
+			c.setHealthy(false)
+		}
+		return status, nil
+	}
+
+	status.Available = true
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	for _, m := range models {
+		status.VRAMBytes += m.VRAMBytes
+		status.RAMBytes += m.RAMBytes()
+	}
+	status.Models = append(status.Models, models...)
+
+	if v, err := c.client.Version(ctx); err == nil {
+		status.Version = v
+	}
+	if n, ok, err := c.client.Requests(ctx); err == nil && ok {
+		status.Requests = &n
+	}
+
+	c.setHealthy(true)
+	return status, nil
+}
+
+// notRunning reports whether err means no server is listening.
+func notRunning(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
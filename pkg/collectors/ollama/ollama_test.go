@@ -0,0 +1,176 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockClient is a test double for Client.
+type mockClient struct {
+	models   []Model
+	err      error
+	requests int64
+	metrics  bool
+}
+
+func (m *mockClient) Running(ctx context.Context) ([]Model, error) {
+	return m.models, m.err
+}
+
+func (m *mockClient) Version(ctx context.Context) (string, error) {
+	return "0.6.2", nil
+}
+
+func (m *mockClient) Requests(ctx context.Context) (int64, bool, error) {
+	return m.requests, m.metrics, nil
+}
+
+// testPsJSON is a trimmed GET /api/ps response: one model fully on the
+// GPU and one split between VRAM and RAM.
+const testPsJSON = `{"models":[
+  {"name":"qwen2.5:7b","model":"qwen2.5:7b","size":6000000000,"size_vram":4000000000,"expires_at":"2026-10-16T14:38:31Z",
+   "details":{"family":"qwen2","parameter_size":"7.6B","quantization_level":"Q4_K_M"}},
+  {"name":"llama3.1:8b","model":"llama3.1:8b","size":5137025024,"size_vram":5137025024,"expires_at":"2026-10-16T14:40:00Z",
+   "details":{"family":"llama","parameter_size":"8.0B","quantization_level":"Q4_0"}}
+]}`
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/ps":
+			fmt.Fprint(w, testPsJSON)
+		case "/api/version":
+			fmt.Fprint(w, `{"version":"0.6.2"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := newHTTPClient(srv.URL + "/")
+	ctx := context.Background()
+
+	models, err := c.Running(ctx)
+	if err != nil {
+		t.Fatalf("Running() error: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("len(models) = %d, want 2", len(models))
+	}
+	m := models[0]
+	if m.Name != "qwen2.5:7b" || m.ParameterSize != "7.6B" || m.Quantization != "Q4_K_M" || m.RAMBytes() != 2000000000 {
+		t.Errorf("models[0] = %+v, RAM %d", m, m.RAMBytes())
+	}
+	if v, err := c.Version(ctx); err != nil || v != "0.6.2" {
+		t.Errorf("Version() = %q, %v", v, err)
+	}
+	if _, ok, err := c.Requests(ctx); ok || err != nil {
+		t.Errorf("Requests() without /metrics = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestParseRequests(t *testing.T) {
+	metrics := `# HELP ollama_requests_total Requests served.
+# TYPE ollama_requests_total counter
+ollama_requests_total{endpoint="/api/chat",model="llama3.1 8b"} 120
+ollama_requests_total{endpoint="/api/generate"} 8
+ollama_request_duration_seconds_sum 42.5
+process_open_fds 12
+`
+	if n, ok := parseRequests(strings.NewReader(metrics)); !ok || n != 128 {
+		t.Errorf("parseRequests() = %d, %v; want 128, true", n, ok)
+	}
+	if _, ok := parseRequests(strings.NewReader("process_open_fds 12\n")); ok {
+		t.Error("parseRequests() without request counters reported true")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	client := &mockClient{
+		models: []Model{
+			{Name: "qwen2.5:7b", SizeBytes: 6 << 30, VRAMBytes: 4 << 30},
+			{Name: "llama3.1:8b", SizeBytes: 5 << 30, VRAMBytes: 5 << 30},
+		},
+		requests: 128,
+		metrics:  true,
+	}
+	c := newWithClient(Config{}, client)
+	if c.Interval() != DefaultInterval {
+		t.Errorf("Interval() = %v, want %v", c.Interval(), DefaultInterval)
+	}
+
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	s := data.(*Status)
+	if !s.Available || s.Version != "0.6.2" || s.VRAMBytes != 9<<30 || s.RAMBytes != 2<<30 {
+		t.Errorf("Status = %+v", s)
+	}
+	if s.Models[0].Name != "llama3.1:8b" {
+		t.Errorf("models not sorted by name: %+v", s.Models)
+	}
+	want := "Ollama llama3.1:8b, qwen2.5:7b · 9GB VRAM 2GB RAM · 128 req"
+	if got := s.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	client.models, client.metrics = nil, false
+	data, _ = c.Collect(context.Background())
+	if got := data.(*Status).Summary(); got != "Ollama idle" {
+		t.Errorf("Summary() with no model loaded = %q, want Ollama idle", got)
+	}
+}
+
+func TestCollect_NotRunning(t *testing.T) {
+	// A port nothing listens on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c := newWithClient(Config{}, newHTTPClient("http://"+addr))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	data, err := c.Collect(ctx)
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	s := data.(*Status)
+	if s.Available || !strings.HasPrefix(s.Error, "ollama not running") || !c.Healthy() {
+		t.Errorf("Status = %+v, healthy %v; want inactive and healthy", s, c.Healthy())
+	}
+	if got := s.Summary(); got != "Ollama inactive" {
+		t.Errorf("Summary() = %q, want Ollama inactive", got)
+	}
+
+	// Other failures mark the collector unhealthy.
+	c = newWithClient(Config{}, &mockClient{err: errors.New("ollama /api/ps returned 500: boom")})
+	data, _ = c.Collect(context.Background())
+	if s := data.(*Status); s.Available || c.Healthy() {
+		t.Errorf("Status = %+v, healthy %v; want unavailable and unhealthy", s, c.Healthy())
+	}
+}
+
+func TestHostURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                     "",
+		"0.0.0.0":              "http://localhost:11434",
+		"127.0.0.1:11500":      "http://127.0.0.1:11500",
+		"https://llm.lan:443/": "https://llm.lan:443",
+		"http://[::]:11434":    "http://localhost:11434",
+		"ollama.internal":      "http://ollama.internal:11434",
+	} {
+		if got := hostURL(in); got != want {
+			t.Errorf("hostURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
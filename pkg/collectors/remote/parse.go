@@ -0,0 +1,134 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseMetrics reads the output of metricsCommand. Uptime, load, and
+// memory are required; the CPU count and disks are reported when present.
+func parseMetrics(out []byte) (*Metrics, error) {
+	sections := make(map[string][]string)
+	var section string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "@") {
+			section = line[1:]
+			continue
+		}
+		if section != "" && strings.TrimSpace(line) != "" {
+			sections[section] = append(sections[section], line)
+		}
+	}
+
+	m := &Metrics{}
+	up := fieldsOf(sections["uptime"])
+	if len(up) < 1 {
+		return nil, fmt.Errorf("no uptime in output (is the host running Linux?)")
+	}
+	var err error
+	if m.UptimeSeconds, err = strconv.ParseFloat(up[0], 64); err != nil {
+		return nil, fmt.Errorf("parsing uptime: %w", err)
+	}
+
+	load := fieldsOf(sections["loadavg"])
+	if len(load) < 3 {
+		return nil, fmt.Errorf("no load average in output")
+	}
+	for i, dst := range []*float64{&m.Load1, &m.Load5, &m.Load15} {
+		if *dst, err = strconv.ParseFloat(load[i], 64); err != nil {
+			return nil, fmt.Errorf("parsing load average: %w", err)
+		}
+	}
+
+	if n := fieldsOf(sections["nproc"]); len(n) > 0 {
+		m.CPUs, _ = strconv.Atoi(n[0])
+	}
+
+	if err := parseMeminfo(m, sections["meminfo"]); err != nil {
+		return nil, err
+	}
+	m.Disks = parseDF(sections["df"])
+	return m, nil
+}
+
+// fieldsOf returns the fields of the first line of lines.
+func fieldsOf(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	return strings.Fields(lines[0])
+}
+
+// parseMeminfo sets m's memory figures from /proc/meminfo lines. Kernels
+// older than 3.14 lack MemAvailable; it is estimated from the free and
+// cache figures instead.
+func parseMeminfo(m *Metrics, lines []string) error {
+	kb := make(map[string]uint64, len(lines))
+	for _, line := range lines {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		f := strings.Fields(rest)
+		if len(f) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseUint(f[0], 10, 64); err == nil {
+			kb[key] = v
+		}
+	}
+	total, ok := kb["MemTotal"]
+	if !ok || total == 0 {
+		return fmt.Errorf("no MemTotal in /proc/meminfo output")
+	}
+	avail, ok := kb["MemAvailable"]
+	if !ok {
+		avail = kb["MemFree"] + kb["Buffers"] + kb["Cached"]
+	}
+	if avail > total {
+		avail = total
+	}
+	m.MemTotalBytes = total * 1024
+	m.MemAvailableBytes = avail * 1024
+	m.MemUsedPercent = float64(total-avail) / float64(total) * 100
+	return nil
+}
+
+// parseDF returns the disks in POSIX "df -P -k" output. Only filesystems
+// backed by a device are kept, which leaves out tmpfs, overlays, and the
+// like; loop devices such as snaps are left out too, and a device mounted
+// more than once is reported at its first mountpoint.
+func parseDF(lines []string) []Disk {
+	var disks []Disk
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		f := strings.Fields(line)
+		if len(f) < 6 || !strings.HasPrefix(f[0], "/") || strings.HasPrefix(f[0], "/dev/loop") || seen[f[0]] {
+			continue
+		}
+		used, err1 := strconv.ParseUint(f[2], 10, 64)
+		avail, err2 := strconv.ParseUint(f[3], 10, 64)
+		total, err3 := strconv.ParseUint(f[1], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil || total == 0 {
+			continue
+		}
+		seen[f[0]] = true
+		d := Disk{
+			Mountpoint: strings.Join(f[5:], " "),
+			TotalBytes: total * 1024,
+			UsedBytes:  used * 1024,
+		}
+		// Like df, count the space reserved for root as neither used nor
+		// available.
+		if used+avail > 0 {
+			d.UsedPercent = float64(used) / float64(used+avail) * 100
+		}
+		disks = append(disks, d)
+	}
+	return disks
+}
@@ -0,0 +1,321 @@
+// Package remote provides a collector that gathers basic metrics from
+// hosts that do not run prompt-pulse themselves, such as small VPSes. It
+// connects to each host over SSH, runs one batched shell command reading
+// uptime, load, memory, and disk usage, and reports whether the host is up.
+// Connections are kept open and reused across collections. A host is only
+// reported down after several consecutive failures, and a slow or
+// unreachable host never delays the others.
+package remote
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval         = 60 * time.Second
+	DefaultTimeout          = 10 * time.Second
+	DefaultFailureThreshold = 3
+	DefaultPort             = "22"
+)
+
+// Host key checking modes, named after OpenSSH's StrictHostKeyChecking.
+const (
+	// HostKeyStrict accepts only host keys already in the known_hosts
+	// file.
+	HostKeyStrict = "yes"
+
+	// HostKeyAcceptNew adds the key of a host missing from the
+	// known_hosts file to it, but still rejects a key that differs from
+	// the one recorded.
+	HostKeyAcceptNew = "accept-new"
+)
+
+// Host states.
+const (
+	StateUp      = "up"
+	StateDown    = "down"
+	StatePending = "pending" // failing, but not yet FailureThreshold times
+)
+
+// Host defines one remote host.
+type Host struct {
+	// Name labels the host in every display. Names are unique. Empty uses
+	// Address.
+	Name string
+
+	// Address is "host" or "host:port". The port defaults to 22.
+	Address string
+
+	// User is the login user. Empty uses the local user name.
+	User string
+
+	// KeyFile is an unencrypted private key to authenticate with. When
+	// empty, the keys of the SSH agent at SSH_AUTH_SOCK are used.
+	KeyFile string
+}
+
+// Config holds the configuration for the remote collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// Timeout bounds connecting to and querying one host. Zero uses
+	// DefaultTimeout.
+	Timeout time.Duration
+
+	// FailureThreshold is how many consecutive failures mark a host down.
+	// Zero uses DefaultFailureThreshold.
+	FailureThreshold int
+
+	// KnownHosts is the known_hosts file host keys are verified against.
+	// Empty uses ~/.ssh/known_hosts.
+	KnownHosts string
+
+	// HostKeyChecking is HostKeyStrict or HostKeyAcceptNew. Empty uses
+	// HostKeyStrict.
+	HostKeyChecking string
+
+	// Hosts are the hosts to query.
+	Hosts []Host
+}
+
+// Metrics are the figures read from a host.
+type Metrics struct {
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Load1         float64 `json:"load1"`
+	Load5         float64 `json:"load5"`
+	Load15        float64 `json:"load15"`
+	CPUs          int     `json:"cpus,omitempty"`
+
+	MemTotalBytes     uint64  `json:"mem_total_bytes"`
+	MemAvailableBytes uint64  `json:"mem_available_bytes"`
+	MemUsedPercent    float64 `json:"mem_used_percent"`
+
+	Disks []Disk `json:"disks,omitempty"`
+}
+
+// Disk is the usage of one mounted filesystem.
+type Disk struct {
+	Mountpoint  string  `json:"mountpoint"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// Result is the latest state of one host. Metrics are those of the last
+// successful query, kept while the host is failing.
+type Result struct {
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	State     string    `json:"state"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"consecutive_failures,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Metrics   *Metrics  `json:"metrics,omitempty"`
+}
+
+// Status is the data returned by a single Collect call.
+type Status struct {
+	Hosts     []Result  `json:"hosts"`
+	Up        int       `json:"up"`
+	Down      int       `json:"down"`
+	Total     int       `json:"total"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Collector queries the configured hosts.
+type Collector struct {
+	hosts     []Host
+	interval  time.Duration
+	timeout   time.Duration
+	threshold int
+	verifier  *hostKeyVerifier
+
+	// query runs the metrics command on one host; replaced in tests.
+	query func(ctx context.Context, h Host) ([]byte, error)
+
+	mu      sync.Mutex
+	results map[string]Result
+	healthy bool
+
+	// connMu guards conns, the open connection to each host by name.
+	connMu sync.Mutex
+	conns  map[string]*ssh.Client
+}
+
+// New creates a new remote collector. Zero settings in cfg use the
+// package defaults.
+func New(cfg Config) *Collector {
+	c := &Collector{
+		hosts:     make([]Host, len(cfg.Hosts)),
+		interval:  cfg.Interval,
+		timeout:   cfg.Timeout,
+		threshold: cfg.FailureThreshold,
+		verifier:  newHostKeyVerifier(cfg.KnownHosts, cfg.HostKeyChecking),
+		results:   make(map[string]Result, len(cfg.Hosts)),
+		conns:     make(map[string]*ssh.Client),
+		healthy:   true, // healthy until first failure
+	}
+	for i, h := range cfg.Hosts {
+		if h.Name == "" {
+			h.Name = h.Address
+		}
+		c.hosts[i] = h
+	}
+	c.query = c.runCommand
+	if c.interval <= 0 {
+		c.interval = DefaultInterval
+	}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	if c.threshold <= 0 {
+		c.threshold = DefaultFailureThreshold
+	}
+	return c
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "remote"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection could query its hosts.
+// Hosts that are down do not make the collector unhealthy.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// Collect queries every host at once, each within the timeout, and
+// returns the state of all hosts.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	var wg sync.WaitGroup
+	for _, h := range c.hosts {
+		wg.Add(1)
+		go func(h Host) {
+			defer wg.Done()
+			a := c.run(ctx, h)
+			// An attempt cut short by shutdown says nothing about the
+			// host.
+			if ctx.Err() == nil {
+				c.record(h, a)
+			}
+		}(h)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		c.mu.Lock()
+		c.healthy = false
+		c.mu.Unlock()
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	return c.status(), nil
+}
+
+// Stop closes the open connections.
+func (c *Collector) Stop() {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	for name, client := range c.conns {
+		client.Close()
+		delete(c.conns, name)
+	}
+}
+
+// attempt is the outcome of querying a host once.
+type attempt struct {
+	metrics *Metrics
+	latency time.Duration
+	err     error
+	at      time.Time
+}
+
+// run queries h once within the timeout and parses its output.
+func (c *Collector) run(ctx context.Context, h Host) attempt {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	out, err := c.query(ctx, h)
+	a := attempt{latency: time.Since(start), err: err, at: start}
+	if err == nil {
+		a.metrics, a.err = parseMetrics(out)
+	}
+	return a
+}
+
+// record folds an attempt into the host's result. A failure only turns a
+// host down once it has failed threshold times in a row; until then it
+// keeps its previous state, or is pending if it has never succeeded.
+func (c *Collector) record(h Host, a attempt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, seen := c.results[h.Name]
+	r := Result{
+		Name:      h.Name,
+		Address:   h.Address,
+		LatencyMs: float64(a.latency.Microseconds()) / 1000,
+		CheckedAt: a.at,
+		Metrics:   a.metrics,
+	}
+	if a.err == nil {
+		r.State = StateUp
+	} else {
+		r.Error = a.err.Error()
+		r.Failures = prev.Failures + 1
+		r.Metrics = prev.Metrics
+		switch {
+		case r.Failures >= c.threshold:
+			r.State = StateDown
+		case seen && prev.State != StatePending:
+			r.State = prev.State
+		default:
+			r.State = StatePending
+		}
+	}
+	c.results[h.Name] = r
+	c.healthy = true
+}
+
+// status snapshots the results in name order.
+func (c *Collector) status() *Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &Status{Hosts: make([]Result, 0, len(c.results)), Timestamp: time.Now()}
+	for _, h := range c.hosts {
+		r, ok := c.results[h.Name]
+		if !ok {
+			continue
+		}
+		s.Hosts = append(s.Hosts, r)
+		switch r.State {
+		case StateUp:
+			s.Up++
+		case StateDown:
+			s.Down++
+		}
+	}
+	s.Total = len(s.Hosts)
+	sort.Slice(s.Hosts, func(i, j int) bool {
+		return s.Hosts[i].Name < s.Hosts[j].Name
+	})
+	return s
+}
@@ -0,0 +1,298 @@
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const sampleOutput = `@uptime
+350735.47 234388.90
+@loadavg
+0.52 0.58 0.59 1/389 12345
+@nproc
+2
+@meminfo
+MemTotal:        4028752 kB
+MemFree:          311108 kB
+MemAvailable:    3021564 kB
+Buffers:          124260 kB
+Cached:          2448836 kB
+@df
+Filesystem     1024-blocks     Used Available Capacity Mounted on
+udev               1994164        0   1994164       0% /dev
+tmpfs               402876     1108    401768       1% /run
+/dev/vda1         81120644 40560322  36382706      53% /
+/dev/loop0           65536    65536         0     100% /snap/core/1
+/dev/vdb1        103081248 10308124  87513876      11% /srv/data files
+/dev/vda1         81120644 40560322  36382706      53% /var/lib/docker
+`
+
+func TestParseMetrics(t *testing.T) {
+	m, err := parseMetrics([]byte(sampleOutput))
+	if err != nil {
+		t.Fatalf("parseMetrics() error: %v", err)
+	}
+	if m.UptimeSeconds != 350735.47 || m.Load1 != 0.52 || m.Load5 != 0.58 || m.Load15 != 0.59 || m.CPUs != 2 {
+		t.Errorf("uptime/load = %+v", m)
+	}
+	if m.MemTotalBytes != 4028752*1024 || m.MemAvailableBytes != 3021564*1024 {
+		t.Errorf("memory = %d/%d", m.MemAvailableBytes, m.MemTotalBytes)
+	}
+	if m.MemUsedPercent < 24.9 || m.MemUsedPercent > 25.1 {
+		t.Errorf("MemUsedPercent = %.2f, want about 25", m.MemUsedPercent)
+	}
+	if len(m.Disks) != 2 || m.Disks[0].Mountpoint != "/" || m.Disks[1].Mountpoint != "/srv/data files" {
+		t.Fatalf("disks = %+v, want / and /srv/data files", m.Disks)
+	}
+	if d := m.Disks[0]; d.TotalBytes != 81120644*1024 || d.UsedPercent < 52.7 || d.UsedPercent > 52.8 {
+		t.Errorf("root disk = %+v", d)
+	}
+}
+
+func TestParseMetrics_OldKernelAndErrors(t *testing.T) {
+	old := strings.Replace(sampleOutput, "MemAvailable:    3021564 kB\n", "", 1)
+	m, err := parseMetrics([]byte(old))
+	if err != nil {
+		t.Fatalf("parseMetrics() error: %v", err)
+	}
+	if want := uint64(311108+124260+2448836) * 1024; m.MemAvailableBytes != want {
+		t.Errorf("MemAvailableBytes = %d, want free+buffers+cached %d", m.MemAvailableBytes, want)
+	}
+
+	for name, out := range map[string]string{
+		"empty":   "",
+		"no proc": "@uptime\n@loadavg\n@nproc\n4\n@meminfo\n@df\n",
+		"no mem":  "@uptime\n10.0 5.0\n@loadavg\n0.1 0.2 0.3 1/2 3\n@meminfo\n",
+	} {
+		if _, err := parseMetrics([]byte(out)); err == nil {
+			t.Errorf("%s: parseMetrics() succeeded", name)
+		}
+	}
+}
+
+// TestCollect_FailureThreshold checks a host goes pending, then down only
+// after FailureThreshold failures, keeping its last metrics, and that a
+// hanging host does not hold up the others.
+func TestCollect_FailureThreshold(t *testing.T) {
+	c := New(Config{
+		Timeout:          50 * time.Millisecond,
+		FailureThreshold: 2,
+		Hosts:            []Host{{Name: "vps", Address: "vps.example"}, {Address: "slow.example"}},
+	})
+	failing := false
+	c.query = func(ctx context.Context, h Host) ([]byte, error) {
+		if h.Name == "slow.example" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		if failing {
+			return nil, errors.New("connection refused")
+		}
+		return []byte(sampleOutput), nil
+	}
+
+	states := func() map[string]Result {
+		t.Helper()
+		start := time.Now()
+		data, err := c.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("Collect() error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Collect() took %v, want about the timeout", elapsed)
+		}
+		out := make(map[string]Result)
+		for _, r := range data.(*Status).Hosts {
+			out[r.Name] = r
+		}
+		return out
+	}
+
+	got := states()
+	if got["vps"].State != StateUp || got["vps"].Metrics == nil || got["slow.example"].State != StatePending {
+		t.Fatalf("first collection = %+v", got)
+	}
+	failing = true
+	if got = states(); got["vps"].State != StateUp || got["vps"].Failures != 1 || got["slow.example"].State != StateDown {
+		t.Errorf("second collection = %+v, want vps still up and slow down", got)
+	}
+	got = states()
+	if r := got["vps"]; r.State != StateDown || r.Error != "connection refused" || r.Metrics == nil {
+		t.Errorf("vps = %+v, want down with its last metrics", r)
+	}
+	if !c.Healthy() {
+		t.Error("collector unhealthy with hosts down")
+	}
+}
+
+// testServer is an SSH server answering every command with sampleOutput.
+type testServer struct {
+	addr    string
+	hostKey ssh.Signer
+	dials   atomic.Int32
+	ln      net.Listener
+}
+
+func newTestServer(t *testing.T, clientKey ssh.PublicKey) *testServer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{addr: ln.Addr().String(), hostKey: signer, ln: ln}
+	t.Cleanup(func() { ln.Close() })
+
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, errors.New("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(signer)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, cfg)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	s.dials.Add(1)
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				ch.Write([]byte(sampleOutput))
+				ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+				ch.Close()
+			}
+		}()
+	}
+}
+
+// writeClientKey writes a new private key for the client and returns its
+// path and public key.
+func writeClientKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, sshPub
+}
+
+func collectOnce(t *testing.T, c *Collector) Result {
+	t.Helper()
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	return data.(*Status).Hosts[0]
+}
+
+func TestSSH_KnownHostsAndReuse(t *testing.T) {
+	keyFile, clientKey := writeClientKey(t)
+	srv := newTestServer(t, clientKey)
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	host := Host{Name: "vps", Address: srv.addr, User: "monitor", KeyFile: keyFile}
+
+	// Strict checking refuses a host missing from known_hosts.
+	strict := New(Config{KnownHosts: knownHostsFile, FailureThreshold: 1, Hosts: []Host{host}})
+	defer strict.Stop()
+	if r := collectOnce(t, strict); r.State != StateDown || r.Error == "" {
+		t.Errorf("unknown host with strict checking = %+v, want down", r)
+	}
+
+	// accept-new records the key, after which strict checking accepts it
+	// and the connection is reused.
+	acceptNew := New(Config{KnownHosts: knownHostsFile, HostKeyChecking: HostKeyAcceptNew, Hosts: []Host{host}})
+	if r := collectOnce(t, acceptNew); r.State != StateUp {
+		t.Fatalf("accept-new = %+v, want up", r)
+	}
+	acceptNew.Stop()
+	data, err := os.ReadFile(knownHostsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := knownhosts.Line([]string{knownhosts.Normalize(srv.addr)}, srv.hostKey.PublicKey()); strings.TrimSpace(string(data)) != want {
+		t.Errorf("known_hosts = %q, want %q", data, want)
+	}
+
+	srv.dials.Store(0)
+	for i := 0; i < 3; i++ {
+		r := collectOnce(t, strict)
+		if r.State != StateUp || r.Metrics == nil || r.Metrics.CPUs != 2 {
+			t.Fatalf("collection %d = %+v, want up with metrics", i, r)
+		}
+	}
+	if n := srv.dials.Load(); n != 1 {
+		t.Errorf("connected %d times over 3 collections, want 1", n)
+	}
+
+	// A changed host key is rejected even when accepting new keys.
+	other := newTestServer(t, clientKey)
+	line := knownhosts.Line([]string{knownhosts.Normalize(other.addr)}, srv.hostKey.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	host.Address = other.addr
+	changed := New(Config{KnownHosts: knownHostsFile, HostKeyChecking: HostKeyAcceptNew, FailureThreshold: 1, Hosts: []Host{host}})
+	defer changed.Stop()
+	if r := collectOnce(t, changed); r.State != StateDown || !strings.Contains(r.Error, "does not match") {
+		t.Errorf("changed host key = %+v, want down with a mismatch error", r)
+	}
+}
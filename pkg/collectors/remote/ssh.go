@@ -0,0 +1,313 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// metricsCommand prints each metrics source under an "@name" marker line,
+// so one round trip gathers everything parseMetrics reads. It always exits
+// zero: df fails when any mount cannot be read, but still reports the
+// rest.
+const metricsCommand = "echo @uptime; cat /proc/uptime; " +
+	"echo @loadavg; cat /proc/loadavg; " +
+	"echo @nproc; nproc 2>/dev/null; " +
+	"echo @meminfo; cat /proc/meminfo; " +
+	"echo @df; LC_ALL=C df -P -k 2>/dev/null; exit 0"
+
+// runCommand runs metricsCommand on h, reusing the connection from the
+// previous collection when there is one. A reused connection that fails
+// may simply have been dropped by the server since, so the command is
+// retried once on a new one.
+func (c *Collector) runCommand(ctx context.Context, h Host) ([]byte, error) {
+	client, reused, err := c.client(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runSession(ctx, client)
+	if err != nil && reused && ctx.Err() == nil {
+		c.drop(h.Name, client)
+		if client, _, err = c.client(ctx, h); err != nil {
+			return nil, err
+		}
+		out, err = runSession(ctx, client)
+	}
+	if err != nil {
+		c.drop(h.Name, client)
+		return nil, err
+	}
+	return out, nil
+}
+
+// client returns the open connection to h, dialing one if there is none,
+// and whether it was reused.
+func (c *Collector) client(ctx context.Context, h Host) (*ssh.Client, bool, error) {
+	c.connMu.Lock()
+	client, ok := c.conns[h.Name]
+	c.connMu.Unlock()
+	if ok {
+		return client, true, nil
+	}
+
+	client, err := c.dial(ctx, h)
+	if err != nil {
+		return nil, false, err
+	}
+	c.connMu.Lock()
+	c.conns[h.Name] = client
+	c.connMu.Unlock()
+	return client, false, nil
+}
+
+// drop closes client and forgets it as the connection to the host name.
+func (c *Collector) drop(name string, client *ssh.Client) {
+	client.Close()
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conns[name] == client {
+		delete(c.conns, name)
+	}
+}
+
+// runSession runs metricsCommand in a new session on client and returns
+// its output. When ctx ends first, the session is abandoned; the caller
+// closes the connection to end it.
+func runSession(ctx context.Context, client *ssh.Client) ([]byte, error) {
+	sess, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("opening session: %w", err)
+	}
+	defer sess.Close()
+
+	type result struct {
+		out []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := sess.Output(metricsCommand)
+		done <- result{out, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("running metrics command: %w", r.err)
+		}
+		return r.out, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("running metrics command: %w", ctx.Err())
+	}
+}
+
+// dial connects and authenticates to h, verifying its host key. The
+// handshake is bounded by ctx's deadline.
+func (c *Collector) dial(ctx context.Context, h Host) (*ssh.Client, error) {
+	addr := hostAddress(h.Address)
+	name := h.User
+	if name == "" {
+		name = localUser()
+	}
+	auth, closeAuth, err := authMethods(h.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAuth()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	hostKey, algorithms, err := c.verifier.callback(addr, conn.RemoteAddr())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sc, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:              name,
+		Auth:              auth,
+		HostKeyCallback:   hostKey,
+		HostKeyAlgorithms: algorithms,
+		Timeout:           c.timeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return ssh.NewClient(sc, chans, reqs), nil
+}
+
+// hostAddress returns address with DefaultPort added when it has no port.
+func hostAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, DefaultPort)
+}
+
+// localUser returns the name of the user running prompt-pulse.
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// authMethods returns the SSH authentication for keyFile, or the SSH
+// agent's keys when keyFile is empty, and a function releasing the agent
+// connection once the handshake is done.
+func authMethods(keyFile string) ([]ssh.AuthMethod, func(), error) {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, nil, fmt.Errorf("key %s is encrypted; add it to ssh-agent and leave key_file unset", keyFile)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing key %s: %w", keyFile, err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, func() {}, nil
+	}
+
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, nil, fmt.Errorf("no key_file set and SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to ssh-agent: %w", err)
+	}
+	signers := agent.NewClient(conn).Signers
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(signers)}, func() { conn.Close() }, nil
+}
+
+// hostKeyVerifier checks host keys against a known_hosts file, adding
+// keys of new hosts to it when accepting new keys.
+type hostKeyVerifier struct {
+	path      string
+	acceptNew bool
+
+	// mu serializes reading and appending to the file.
+	mu sync.Mutex
+}
+
+// newHostKeyVerifier returns a verifier using the known_hosts file at
+// path, ~/.ssh/known_hosts when empty, in the HostKeyChecking mode.
+func newHostKeyVerifier(path, mode string) *hostKeyVerifier {
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".ssh", "known_hosts")
+		}
+	}
+	return &hostKeyVerifier{path: path, acceptNew: mode == HostKeyAcceptNew}
+}
+
+// callback reads the known_hosts file and returns the host key callback
+// for a connection to addr, and the host key algorithms to ask the server
+// for: those of the keys recorded for addr, so it presents one that can be
+// verified rather than its preferred one. With no keys recorded, the
+// algorithms are nil and the server chooses.
+func (v *hostKeyVerifier) callback(addr string, remote net.Addr) (ssh.HostKeyCallback, []string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	check, err := knownhosts.New(v.path)
+	switch {
+	case err == nil:
+	case v.acceptNew && errors.Is(err, fs.ErrNotExist):
+		check = func(string, net.Addr, ssh.PublicKey) error { return &knownhosts.KeyError{} }
+	default:
+		return nil, nil, fmt.Errorf("known_hosts: %w", err)
+	}
+
+	var algorithms []string
+	var keyErr *knownhosts.KeyError
+	if errors.As(check(addr, remote, probeKey{}), &keyErr) {
+		for _, k := range keyErr.Want {
+			algorithms = appendAlgorithms(algorithms, k.Key.Type())
+		}
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		switch {
+		case !errors.As(err, &keyErr):
+			return err
+		case len(keyErr.Want) > 0:
+			return fmt.Errorf("host key for %s does not match the one in %s", hostname, v.path)
+		case !v.acceptNew:
+			return fmt.Errorf("host key for %s is not in %s", hostname, v.path)
+		}
+		return v.add(hostname, key)
+	}, algorithms, nil
+}
+
+// add records key as the host key of hostname.
+func (v *hostKeyVerifier) add(hostname string, key ssh.PublicKey) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("known_hosts: %w", err)
+	}
+	f, err := os.OpenFile(v.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("known_hosts: %w", err)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return fmt.Errorf("known_hosts: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("known_hosts: %w", err)
+	}
+	log.Printf("remote: added %s host key for %s to %s", key.Type(), hostname, v.path)
+	return nil
+}
+
+// appendAlgorithms appends the host key algorithms that verify a key of
+// keyType to algorithms, once each.
+func appendAlgorithms(algorithms []string, keyType string) []string {
+	add := []string{keyType}
+	if keyType == ssh.KeyAlgoRSA {
+		add = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	for _, a := range add {
+		if !slices.Contains(algorithms, a) {
+			algorithms = append(algorithms, a)
+		}
+	}
+	return algorithms
+}
+
+// probeKey is a public key matching no known host, used to look up the
+// keys recorded for a host.
+type probeKey struct{}
+
+func (probeKey) Type() string                        { return "probe" }
+func (probeKey) Marshal() []byte                     { return []byte("probe") }
+func (probeKey) Verify([]byte, *ssh.Signature) error { return errors.New("probe key") }
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"sync"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/logging"
 )
 
 const (
@@ -186,7 +189,10 @@ func (r *Runner) collectAndSend(ctx context.Context, c Collector) {
 	})
 
 	if err != nil {
-		log.Printf("collectors: %s error: %v", name, err)
+		slog.Warn("collector run failed",
+			logging.KeyCollector, name,
+			logging.KeyDuration, latency.Milliseconds(),
+			logging.KeyError, err.Error())
 	}
 
 	update := Update{
@@ -0,0 +1,389 @@
+// Package shellactivity provides a collector that summarizes the commands
+// run in interactive shells today. The shell integration (see pkg/shell)
+// appends a line per command to a file of its own in Dir; the collector
+// reads them all and reports, per shell and overall, how many commands
+// ran, how many failed, and the longest.
+//
+// A shell's file is named "<shell>-<pid>.<n>", where n is 0 or 1. The
+// hook switches to the other file every RotateLines commands and
+// truncates it as it does, so a long-lived shell keeps at most twice
+// that many. Each line is
+//
+//	<unix seconds> <exit status> <duration ms>[ <command name>]
+//
+// written with a single append. Lines that do not parse, such as one cut
+// short by a crash, are skipped.
+package shellactivity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultRetention = 48 * time.Hour
+)
+
+// DirName is the directory in the daemon's state directory the shell
+// hooks write to.
+const DirName = "shell-activity"
+
+// RotateLines is how many commands a shell writes to one of its two files
+// before switching to the other.
+const RotateLines = 500
+
+// Config holds the configuration for the shell activity collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// Dir is the directory the shell hooks write to.
+	Dir string
+
+	// Retention is how long the files of a shell that has exited are kept
+	// after its last command. Zero uses DefaultRetention.
+	Retention time.Duration
+}
+
+// Command is one command a shell ran.
+type Command struct {
+	// Name is the command's first word, or empty when the shell does not
+	// report it (bash).
+	Name     string        `json:"name,omitempty"`
+	Exit     int           `json:"exit"`
+	Duration time.Duration `json:"duration"`
+	Time     time.Time     `json:"time"`
+}
+
+// Session is one shell's activity today.
+type Session struct {
+	Shell string `json:"shell"`
+	PID   int    `json:"pid"`
+
+	// Active reports whether the shell is still running.
+	Active bool `json:"active"`
+
+	Commands int      `json:"commands"`
+	Failures int      `json:"failures"`
+	Longest  *Command `json:"longest,omitempty"`
+
+	// Last is when the shell's last command today finished.
+	Last time.Time `json:"last"`
+}
+
+// Activity is the data returned by a single Collect call. Sessions lists
+// the running shells and those that ran a command today, most recently
+// used first; the totals cover them all.
+type Activity struct {
+	Sessions  []Session `json:"sessions"`
+	Commands  int       `json:"commands"`
+	Failures  int       `json:"failures"`
+	Longest   *Command  `json:"longest,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FailureRate returns the fraction of today's commands that exited
+// non-zero, or 0 when none ran.
+func (a *Activity) FailureRate() float64 {
+	if a.Commands == 0 {
+		return 0
+	}
+	return float64(a.Failures) / float64(a.Commands)
+}
+
+// Summary describes today's activity in one line, or "" when no command
+// ran.
+// Example: "142 commands today · 6% failed · longest make 4m12s"
+func (a *Activity) Summary() string {
+	if a.Commands == 0 {
+		return ""
+	}
+	noun := "commands"
+	if a.Commands == 1 {
+		noun = "command"
+	}
+	s := fmt.Sprintf("%d %s today · %.0f%% failed", a.Commands, noun, a.FailureRate()*100)
+	if a.Longest != nil {
+		s += " · longest " + a.Longest.Label()
+	}
+	return s
+}
+
+// Label returns the command's name, when known, and duration.
+// Example: "make 4m12s"
+func (c *Command) Label() string {
+	if c.Name == "" {
+		return FormatDuration(c.Duration)
+	}
+	return c.Name + " " + FormatDuration(c.Duration)
+}
+
+// FormatDuration renders d compactly: milliseconds below a second,
+// seconds below a minute, then minutes and seconds, or hours and minutes.
+// Example: "850ms", "12s", "4m12s", "1h03m"
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// Collector summarizes the shell activity files in a directory.
+type Collector struct {
+	dir       string
+	interval  time.Duration
+	retention time.Duration
+
+	// now and alive are replaced in tests.
+	now   func() time.Time
+	alive func(pid int) bool
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+// New creates a new shell activity collector. Zero fields of cfg take
+// their defaults.
+func New(cfg Config) *Collector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	retention := cfg.Retention
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Collector{
+		dir:       cfg.Dir,
+		interval:  interval,
+		retention: retention,
+		now:       time.Now,
+		alive:     saPIDAlive,
+		healthy:   true, // healthy until first failure
+	}
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "shell"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// setHealthy updates the internal healthy flag under the mutex.
+func (c *Collector) setHealthy(v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthy = v
+}
+
+// saFile is one activity file in the directory.
+type saFile struct {
+	path    string
+	modTime time.Time
+}
+
+// Collect reads every shell's activity files and returns today's
+// Activity. The files of shells that have exited and not written for the
+// retention period are removed. A missing directory means no shell has
+// the hook yet and yields an empty Activity.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	now := c.now()
+	act := &Activity{Sessions: []Session{}, Timestamp: now}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			c.setHealthy(true)
+			return act, nil
+		}
+		c.setHealthy(false)
+		return nil, fmt.Errorf("shell activity: %w", err)
+	}
+
+	files := make(map[string][]saFile)
+	for _, e := range entries {
+		key, ok := saSessionKey(e.Name())
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files[key] = append(files[key], saFile{path: filepath.Join(c.dir, e.Name()), modTime: info.ModTime()})
+	}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	for key, fs := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		shell, pid := saParseKey(key)
+		s := Session{Shell: shell, PID: pid, Active: c.alive(pid)}
+		var last time.Time
+		for _, f := range fs {
+			if f.modTime.After(last) {
+				last = f.modTime
+			}
+		}
+		if !s.Active && now.Sub(last) > c.retention {
+			for _, f := range fs {
+				os.Remove(f.path)
+			}
+			continue
+		}
+		for _, f := range fs {
+			saReadFile(f.path, today, &s)
+		}
+		if s.Active || s.Commands > 0 {
+			act.Sessions = append(act.Sessions, s)
+		}
+	}
+
+	for _, s := range act.Sessions {
+		act.Commands += s.Commands
+		act.Failures += s.Failures
+		if s.Longest != nil && (act.Longest == nil || s.Longest.Duration > act.Longest.Duration) {
+			act.Longest = s.Longest
+		}
+	}
+	sort.Slice(act.Sessions, func(i, j int) bool {
+		a, b := act.Sessions[i], act.Sessions[j]
+		if !a.Last.Equal(b.Last) {
+			return a.Last.After(b.Last)
+		}
+		return a.PID < b.PID
+	})
+
+	c.setHealthy(true)
+	return act, nil
+}
+
+// saReadFile adds the commands in the activity file at path that finished
+// at or after since to s. An unreadable file adds nothing.
+func saReadFile(path string, since time.Time, s *Session) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		cmd, ok := ParseLine(sc.Text())
+		if !ok || cmd.Time.Before(since) {
+			continue
+		}
+		s.Commands++
+		if cmd.Exit != 0 {
+			s.Failures++
+		}
+		if s.Longest == nil || cmd.Duration > s.Longest.Duration {
+			c := cmd
+			s.Longest = &c
+		}
+		if cmd.Time.After(s.Last) {
+			s.Last = cmd.Time
+		}
+	}
+}
+
+// ParseLine parses one line of an activity file, reporting false when it
+// is malformed.
+func ParseLine(line string) (Command, bool) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 4)
+	if len(fields) < 3 {
+		return Command{}, false
+	}
+	ts, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || ts <= 0 {
+		return Command{}, false
+	}
+	exit, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Command{}, false
+	}
+	ms, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || ms < 0 {
+		return Command{}, false
+	}
+	cmd := Command{
+		Exit:     exit,
+		Duration: time.Duration(ms) * time.Millisecond,
+		Time:     time.Unix(ts, 0),
+	}
+	if len(fields) == 4 {
+		cmd.Name = strings.TrimSpace(fields[3])
+	}
+	return cmd, true
+}
+
+// saSessionKey returns the "<shell>-<pid>" part of an activity file name,
+// reporting false for names that are not activity files.
+func saSessionKey(name string) (string, bool) {
+	key, n, ok := strings.Cut(name, ".")
+	if !ok || (n != "0" && n != "1") {
+		return "", false
+	}
+	shell, pid := saParseKey(key)
+	if shell == "" || pid <= 0 {
+		return "", false
+	}
+	return key, true
+}
+
+// saParseKey splits a "<shell>-<pid>" key, returning a zero pid when it
+// has none.
+func saParseKey(key string) (shell string, pid int) {
+	i := strings.LastIndexByte(key, '-')
+	if i <= 0 {
+		return "", 0
+	}
+	pid, err := strconv.Atoi(key[i+1:])
+	if err != nil {
+		return "", 0
+	}
+	return key[:i], pid
+}
+
+// saPIDAlive reports whether pid refers to a running process, using
+// signal 0. A process owned by another user is alive even though it
+// cannot be signalled.
+func saPIDAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,194 @@
+package shellactivity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saTestNow is the fixed collection time of the tests.
+var saTestNow = time.Date(2026, 3, 14, 15, 0, 0, 0, time.Local)
+
+// saWrite writes lines to the activity file name in dir.
+func saWrite(t *testing.T, dir, name string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// saLine formats an activity line for a command that finished ago before
+// saTestNow.
+func saLine(ago time.Duration, exit int, dur time.Duration, name string) string {
+	s := fmt.Sprintf("%d %d %d", saTestNow.Add(-ago).Unix(), exit, dur.Milliseconds())
+	if name != "" {
+		s += " " + name
+	}
+	return s
+}
+
+// saNewTest returns a collector over dir at saTestNow that treats the
+// PIDs in alive as running.
+func saNewTest(dir string, alive ...int) *Collector {
+	c := New(Config{Dir: dir})
+	c.now = func() time.Time { return saTestNow }
+	c.alive = func(pid int) bool {
+		for _, p := range alive {
+			if p == pid {
+				return true
+			}
+		}
+		return false
+	}
+	return c
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Command
+		ok   bool
+	}{
+		{"1700000000 0 1500", Command{Duration: 1500 * time.Millisecond, Time: time.Unix(1700000000, 0)}, true},
+		{"1700000000 2 30 make", Command{Name: "make", Exit: 2, Duration: 30 * time.Millisecond, Time: time.Unix(1700000000, 0)}, true},
+		{"1700000000 130 5 git push", Command{Name: "git push", Exit: 130, Duration: 5 * time.Millisecond, Time: time.Unix(1700000000, 0)}, true},
+		{"1700000000 0", Command{}, false},
+		{"17000000", Command{}, false},
+		{"x 0 10", Command{}, false},
+		{"1700000000 0 -5", Command{}, false},
+		{"", Command{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLine(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseLine(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCollect_MissingDir(t *testing.T) {
+	c := saNewTest(filepath.Join(t.TempDir(), "none"))
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	act := data.(*Activity)
+	if act.Commands != 0 || len(act.Sessions) != 0 {
+		t.Errorf("activity = %+v, want empty", act)
+	}
+	if !c.Healthy() {
+		t.Error("collector should be healthy without a directory")
+	}
+}
+
+func TestCollect_Stats(t *testing.T) {
+	dir := t.TempDir()
+	yesterday := saTestNow.Sub(time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local))
+	saWrite(t, dir, "zsh-100.0",
+		saLine(yesterday, 0, time.Hour, "sleep"),
+		saLine(3*time.Hour, 0, 2*time.Second, "ls"),
+		saLine(2*time.Hour, 2, 4*time.Minute+12*time.Second, "make"),
+	)
+	saWrite(t, dir, "zsh-100.1",
+		saLine(time.Hour, 1, time.Second, "false"),
+		"1773500000 0", // torn line
+	)
+	saWrite(t, dir, "bash-200.0",
+		saLine(10*time.Minute, 0, 30*time.Second, ""),
+	)
+	saWrite(t, dir, "fish-300.0") // running, nothing today
+	saWrite(t, dir, "notes.txt", "1 2 3")
+
+	data, err := saNewTest(dir, 100, 200, 300).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	act := data.(*Activity)
+	if act.Commands != 4 || act.Failures != 2 {
+		t.Errorf("Commands, Failures = %d, %d; want 4, 2", act.Commands, act.Failures)
+	}
+	if act.FailureRate() != 0.5 {
+		t.Errorf("FailureRate() = %v, want 0.5", act.FailureRate())
+	}
+	if act.Longest == nil || act.Longest.Name != "make" || act.Longest.Exit != 2 {
+		t.Errorf("Longest = %+v, want make", act.Longest)
+	}
+	if want := "4 commands today · 50% failed · longest make 4m12s"; act.Summary() != want {
+		t.Errorf("Summary() = %q, want %q", act.Summary(), want)
+	}
+
+	if len(act.Sessions) != 3 {
+		t.Fatalf("len(Sessions) = %d, want 3: %+v", len(act.Sessions), act.Sessions)
+	}
+	want := []struct {
+		shell    string
+		pid      int
+		commands int
+	}{{"bash", 200, 1}, {"zsh", 100, 3}, {"fish", 300, 0}}
+	for i, w := range want {
+		s := act.Sessions[i]
+		if s.Shell != w.shell || s.PID != w.pid || s.Commands != w.commands || !s.Active {
+			t.Errorf("Sessions[%d] = %+v, want active %s-%d with %d commands", i, s, w.shell, w.pid, w.commands)
+		}
+	}
+	if got := act.Sessions[0].Longest.Label(); got != "30s" {
+		t.Errorf("bash Longest.Label() = %q, want 30s", got)
+	}
+}
+
+func TestCollect_PrunesExitedShells(t *testing.T) {
+	dir := t.TempDir()
+	old := saWrite(t, dir, "bash-400.0", saLine(72*time.Hour, 0, time.Second, ""))
+	stale := saTestNow.Add(-72 * time.Hour)
+	if err := os.Chtimes(old, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	recent := saWrite(t, dir, "bash-500.0", saLine(time.Hour, 0, time.Second, ""))
+	if err := os.Chtimes(recent, saTestNow.Add(-time.Hour), saTestNow.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	running := saWrite(t, dir, "zsh-600.0", saLine(72*time.Hour, 0, time.Second, "ls"))
+	if err := os.Chtimes(running, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := saNewTest(dir, 600).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("file of a shell that exited 72h ago should be removed")
+	}
+	for _, path := range []string{recent, running} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(path), err)
+		}
+	}
+	act := data.(*Activity)
+	if len(act.Sessions) != 2 || act.Commands != 1 {
+		t.Errorf("activity = %+v, want the exited shell from today and the running one", act)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{850 * time.Millisecond, "850ms"},
+		{12 * time.Second, "12s"},
+		{4*time.Minute + 12*time.Second, "4m12s"},
+		{time.Hour + 3*time.Minute, "1h03m"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// reallocatedSectorsID is the ATA SMART attribute counting reallocated
+// sectors.
+const reallocatedSectorsID = 5
+
+// Disk is the SMART health of one drive. Passed and ReallocatedSectors are
+// nil when smartctl could not read them, e.g. without root, and Error then
+// says why.
+type Disk struct {
+	Device             string `json:"device"`
+	Model              string `json:"model,omitempty"`
+	Passed             *bool  `json:"passed,omitempty"`
+	ReallocatedSectors *int64 `json:"reallocated_sectors,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// Level returns StatusCritical for a drive failing its overall health
+// assessment, StatusWarn for one with reallocated sectors, and StatusOK
+// otherwise, including when its health could not be read.
+func (d Disk) Level() string {
+	switch {
+	case d.Passed != nil && !*d.Passed:
+		return StatusCritical
+	case d.ReallocatedSectors != nil && *d.ReallocatedSectors > 0:
+		return StatusWarn
+	}
+	return StatusOK
+}
+
+// execRunner runs the command with os/exec. smartctl reports drive state
+// in its exit status, so output is returned alongside any error.
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// smartScan is the subset of "smartctl --scan -j" output we decode.
+type smartScan struct {
+	Devices []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"devices"`
+}
+
+// smartReport is the subset of "smartctl -H -A -i -j" output we decode.
+type smartReport struct {
+	Smartctl struct {
+		Messages []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	ModelName   string `json:"model_name"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+}
+
+// readSMART reads the health of every drive smartctl finds. It returns
+// nothing when smartctl is not installed, and one Disk per drive
+// otherwise, each carrying its own error if it could not be read.
+func (c *Collector) readSMART(ctx context.Context) []Disk {
+	out, err := c.sys.run(ctx, c.cfg.Smartctl, "--scan", "-j")
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil
+	}
+	var scan smartScan
+	if jerr := json.Unmarshal(out, &scan); jerr != nil {
+		if err == nil {
+			err = jerr
+		}
+		return []Disk{{Device: "smartctl", Error: fmt.Sprintf("scanning drives: %v", err)}}
+	}
+
+	disks := make([]Disk, 0, len(scan.Devices))
+	for _, dev := range scan.Devices {
+		args := []string{"-H", "-A", "-i", "-j", dev.Name}
+		if dev.Type != "" {
+			args = append(args, "-d", dev.Type)
+		}
+		out, err := c.sys.run(ctx, c.cfg.Smartctl, args...)
+		disks = append(disks, parseSmartReport(dev.Name, out, err))
+	}
+	return disks
+}
+
+// parseSmartReport builds the Disk for device from smartctl's JSON output.
+// A non-zero exit is expected for failing drives, so err only matters when
+// the output holds no health assessment.
+func parseSmartReport(device string, out []byte, err error) Disk {
+	d := Disk{Device: device}
+	var r smartReport
+	if jerr := json.Unmarshal(out, &r); jerr != nil {
+		if err == nil {
+			err = jerr
+		}
+		d.Error = err.Error()
+		return d
+	}
+
+	d.Model = r.ModelName
+	if r.SmartStatus != nil {
+		passed := r.SmartStatus.Passed
+		d.Passed = &passed
+	}
+	for _, a := range r.ATASmartAttributes.Table {
+		if a.ID == reallocatedSectorsID {
+			n := a.Raw.Value
+			d.ReallocatedSectors = &n
+		}
+	}
+
+	if d.Passed == nil {
+		var msgs []string
+		for _, m := range r.Smartctl.Messages {
+			if m.Severity == "error" {
+				msgs = append(msgs, m.String)
+			}
+		}
+		switch {
+		case len(msgs) > 0:
+			d.Error = strings.Join(msgs, "; ")
+		case err != nil:
+			d.Error = err.Error()
+		default:
+			d.Error = "no SMART health reported"
+		}
+	}
+	return d
+}
@@ -0,0 +1,412 @@
+// Package storage provides a collector for per-mountpoint disk usage and,
+// where smartctl is available, SMART health. Each mountpoint is stat'ed
+// with its own short timeout, so a hung network mount is reported as
+// "timeout" instead of stalling the whole collection pass.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval        = 60 * time.Second
+	DefaultMountTimeout    = 2 * time.Second
+	DefaultWarnPercent     = 85.0
+	DefaultCriticalPercent = 95.0
+	DefaultSmartctl        = "smartctl"
+)
+
+// DefaultIgnoreFSTypes are the pseudo and virtual filesystems skipped when
+// Config.IgnoreFSTypes is empty.
+var DefaultIgnoreFSTypes = []string{
+	"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs",
+	"debugfs", "devfs", "devpts", "devtmpfs", "efivarfs", "fusectl",
+	"hugetlbfs", "map", "mqueue", "nsfs", "nullfs", "overlay", "proc",
+	"pstore", "ramfs", "rpc_pipefs", "securityfs", "squashfs", "sysfs",
+	"tmpfs", "tracefs",
+}
+
+// Mount and overall status levels. Levels order ok < warn < critical; a
+// mount that timed out or failed to stat counts as warn.
+const (
+	StatusOK       = "ok"
+	StatusWarn     = "warn"
+	StatusCritical = "critical"
+	StatusTimeout  = "timeout"
+	StatusError    = "error"
+)
+
+// Config holds the configuration for the storage collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// IgnoreFSTypes lists filesystem types to skip. Empty uses
+	// DefaultIgnoreFSTypes.
+	IgnoreFSTypes []string
+
+	// IgnoreMounts lists mountpoints to skip, as glob patterns such as
+	// "/snap/*". A pattern matching a directory also skips every mount
+	// beneath it.
+	IgnoreMounts []string
+
+	// MountTimeout bounds the stat of one mountpoint. Zero uses
+	// DefaultMountTimeout.
+	MountTimeout time.Duration
+
+	// WarnPercent and CriticalPercent are the used-space percentages at
+	// which a mount becomes warn and critical. Zero uses the defaults.
+	WarnPercent     float64
+	CriticalPercent float64
+
+	// SMART reads drive health with smartctl when it is installed.
+	SMART bool
+
+	// Smartctl is the smartctl binary. Empty uses DefaultSmartctl from
+	// PATH.
+	Smartctl string
+}
+
+// Mount is the usage of one mounted filesystem. The byte counts are zero
+// when Status is StatusTimeout or StatusError.
+type Mount struct {
+	Mountpoint  string  `json:"mountpoint"`
+	Device      string  `json:"device"`
+	FSType      string  `json:"fstype"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+	Status      string  `json:"status"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Status is the data returned by a single Collect call. Level is the worst
+// of the mount and disk levels.
+type Status struct {
+	Level     string    `json:"level"`
+	Error     string    `json:"error,omitempty"`
+	Mounts    []Mount   `json:"mounts"`
+	Disks     []Disk    `json:"disks,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Partition is a mounted filesystem as listed by the platform.
+type Partition struct {
+	Device     string
+	Mountpoint string
+	FSType     string
+}
+
+// Usage is the space on one filesystem.
+type Usage struct {
+	Total uint64
+	Used  uint64
+}
+
+// Runner runs a command and returns its standard output. Tests inject one
+// returning canned output.
+type Runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// system reads mounts and their usage and runs smartctl. Tests inject a
+// fake.
+type system struct {
+	partitions func(ctx context.Context) ([]Partition, error)
+	usage      func(path string) (Usage, error)
+	run        Runner
+}
+
+// Collector gathers storage usage and health.
+type Collector struct {
+	cfg Config
+	sys system
+
+	mu      sync.Mutex
+	healthy bool
+
+	// stuck holds the mountpoints whose stat from an earlier pass has not
+	// returned yet. They are reported as timed out without starting
+	// another stat that would hang too.
+	stuck map[string]bool
+}
+
+// New creates a new storage collector reading the local mounts.
+func New(cfg Config) *Collector {
+	return newWithSystem(cfg, system{
+		partitions: listPartitions,
+		usage:      diskUsage,
+		run:        execRunner,
+	})
+}
+
+// newWithSystem creates a collector with injected platform access for
+// testing.
+func newWithSystem(cfg Config, sys system) *Collector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if len(cfg.IgnoreFSTypes) == 0 {
+		cfg.IgnoreFSTypes = DefaultIgnoreFSTypes
+	}
+	if cfg.MountTimeout <= 0 {
+		cfg.MountTimeout = DefaultMountTimeout
+	}
+	if cfg.WarnPercent <= 0 {
+		cfg.WarnPercent = DefaultWarnPercent
+	}
+	if cfg.CriticalPercent <= 0 {
+		cfg.CriticalPercent = DefaultCriticalPercent
+	}
+	if cfg.Smartctl == "" {
+		cfg.Smartctl = DefaultSmartctl
+	}
+	return &Collector{
+		cfg:     cfg,
+		sys:     sys,
+		healthy: true, // healthy until first failure
+		stuck:   make(map[string]bool),
+	}
+}
+
+// listPartitions lists every mounted filesystem, including network and
+// pseudo filesystems, which Collect filters itself.
+func listPartitions(ctx context.Context) ([]Partition, error) {
+	parts, err := disk.PartitionsWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Partition, 0, len(parts))
+	for _, p := range parts {
+		out = append(out, Partition{Device: p.Device, Mountpoint: p.Mountpoint, FSType: p.Fstype})
+	}
+	return out, nil
+}
+
+// diskUsage stats path. It can block for as long as the filesystem does.
+func diskUsage(path string) (Usage, error) {
+	u, err := disk.Usage(path)
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Total: u.Total, Used: u.Used}, nil
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "storage"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.cfg.Interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// setHealthy updates the internal healthy flag under the mutex.
+func (c *Collector) setHealthy(v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthy = v
+}
+
+// Collect stats every mount that is not ignored and, when enabled, reads
+// SMART health, returning a Status snapshot. It never returns a Go error:
+// a failure to list mounts is reported in Status.Error and marks the
+// collector unhealthy, while single mounts that time out or fail are
+// reported on their own.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	status := &Status{
+		Level:     StatusOK,
+		Mounts:    []Mount{},
+		Timestamp: time.Now(),
+	}
+
+	parts, err := c.sys.partitions(ctx)
+	if err != nil {
+		status.Error = fmt.Sprintf("listing mounts: %v", err)
+		c.setHealthy(false)
+		return status, nil
+	}
+
+	status.Mounts = c.statMounts(c.filter(parts))
+	for _, m := range status.Mounts {
+		status.Level = Worst(status.Level, m.Status)
+	}
+
+	if c.cfg.SMART {
+		status.Disks = c.readSMART(ctx)
+		for _, d := range status.Disks {
+			status.Level = Worst(status.Level, d.Level())
+		}
+	}
+
+	c.setHealthy(true)
+	return status, nil
+}
+
+// filter drops ignored filesystem types and mountpoints, and all but the
+// first mount of a device mounted more than once, such as a bind mount.
+func (c *Collector) filter(parts []Partition) []Partition {
+	ignoreType := make(map[string]bool, len(c.cfg.IgnoreFSTypes))
+	for _, t := range c.cfg.IgnoreFSTypes {
+		ignoreType[t] = true
+	}
+	seen := make(map[string]bool, len(parts))
+	var out []Partition
+	for _, p := range parts {
+		if ignoreType[p.FSType] || c.ignoredMount(p.Mountpoint) {
+			continue
+		}
+		key := p.Device + "\x00" + p.FSType
+		if p.Device != "" && p.Device != "none" && seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, p)
+	}
+	return out
+}
+
+// ignoredMount reports whether mountpoint, or a directory above it,
+// matches one of the ignore patterns.
+func (c *Collector) ignoredMount(mountpoint string) bool {
+	for _, pat := range c.cfg.IgnoreMounts {
+		for p := mountpoint; ; p = filepath.Dir(p) {
+			if ok, _ := filepath.Match(pat, p); ok {
+				return true
+			}
+			if p == filepath.Dir(p) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// usageResult is the outcome of one mount's stat.
+type usageResult struct {
+	usage Usage
+	err   error
+}
+
+// statMounts stats parts concurrently, each bounded by the mount timeout,
+// and returns them sorted by mountpoint. A stat that has not returned by
+// its deadline is left running; its mount is reported as timed out, now
+// and on later passes until the stat returns.
+func (c *Collector) statMounts(parts []Partition) []Mount {
+	mounts := make([]Mount, len(parts))
+	var wg sync.WaitGroup
+	for i, p := range parts {
+		mounts[i] = Mount{Mountpoint: p.Mountpoint, Device: p.Device, FSType: p.FSType}
+		if c.isStuck(p.Mountpoint) {
+			mounts[i].Status = StatusTimeout
+			mounts[i].Error = "an earlier stat has not returned"
+			continue
+		}
+		wg.Add(1)
+		go func(m *Mount) {
+			defer wg.Done()
+			c.statMount(m)
+		}(&mounts[i])
+	}
+	wg.Wait()
+
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].Mountpoint < mounts[j].Mountpoint
+	})
+	return mounts
+}
+
+// statMount fills m with its usage and level, or marks it timed out.
+func (c *Collector) statMount(m *Mount) {
+	done := make(chan usageResult, 1)
+	c.setStuck(m.Mountpoint, true)
+	go func() {
+		u, err := c.sys.usage(m.Mountpoint)
+		c.setStuck(m.Mountpoint, false)
+		done <- usageResult{u, err}
+	}()
+
+	timer := time.NewTimer(c.cfg.MountTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			m.Status = StatusError
+			m.Error = r.err.Error()
+			return
+		}
+		m.TotalBytes, m.UsedBytes = r.usage.Total, r.usage.Used
+		if r.usage.Total > 0 {
+			m.UsedPercent = float64(r.usage.Used) / float64(r.usage.Total) * 100
+		}
+		m.Status = c.level(m.UsedPercent)
+	case <-timer.C:
+		m.Status = StatusTimeout
+		m.Error = fmt.Sprintf("no response within %s", c.cfg.MountTimeout)
+	}
+}
+
+// isStuck reports whether a stat of mountpoint is still outstanding.
+func (c *Collector) isStuck(mountpoint string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stuck[mountpoint]
+}
+
+// setStuck records whether a stat of mountpoint is outstanding.
+func (c *Collector) setStuck(mountpoint string, v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v {
+		c.stuck[mountpoint] = true
+	} else {
+		delete(c.stuck, mountpoint)
+	}
+}
+
+// level classifies a used-space percentage against the thresholds.
+func (c *Collector) level(pct float64) string {
+	switch {
+	case pct >= c.cfg.CriticalPercent:
+		return StatusCritical
+	case pct >= c.cfg.WarnPercent:
+		return StatusWarn
+	}
+	return StatusOK
+}
+
+// severity orders statuses for Worst.
+var severity = map[string]int{
+	StatusOK:       0,
+	StatusTimeout:  1,
+	StatusError:    1,
+	StatusWarn:     1,
+	StatusCritical: 2,
+}
+
+// Worst returns the more severe of two statuses as a level: StatusOK,
+// StatusWarn, or StatusCritical. Timeouts and errors count as warn.
+func Worst(a, b string) string {
+	s := max(severity[a], severity[b])
+	switch s {
+	case 2:
+		return StatusCritical
+	case 1:
+		return StatusWarn
+	}
+	return StatusOK
+}
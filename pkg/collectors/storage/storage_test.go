@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+const gib = 1 << 30
+
+// testPartitions mixes real, pseudo, bind-mounted, and network mounts.
+var testPartitions = []Partition{
+	{Device: "/dev/nvme0n1p2", Mountpoint: "/", FSType: "ext4"},
+	{Device: "proc", Mountpoint: "/proc", FSType: "proc"},
+	{Device: "tmpfs", Mountpoint: "/run", FSType: "tmpfs"},
+	{Device: "/dev/nvme0n1p1", Mountpoint: "/boot", FSType: "vfat"},
+	{Device: "/dev/nvme0n1p2", Mountpoint: "/var/lib/docker", FSType: "ext4"},
+	{Device: "/dev/loop3", Mountpoint: "/snap/core/1", FSType: "ext4"},
+	{Device: "nas:/export", Mountpoint: "/mnt/nas", FSType: "nfs4"},
+}
+
+// testSystem lists testPartitions with fixed usage for / and /boot. The
+// stat of /mnt/nas hangs until release is closed.
+func testSystem(t *testing.T, release chan struct{}, run Runner) system {
+	usage := map[string]Usage{
+		"/":     {Total: 100 * gib, Used: 90 * gib},
+		"/boot": {Total: 1 * gib, Used: gib / 4},
+	}
+	return system{
+		partitions: func(context.Context) ([]Partition, error) { return testPartitions, nil },
+		usage: func(path string) (Usage, error) {
+			if path == "/mnt/nas" {
+				<-release
+				return Usage{}, nil
+			}
+			u, ok := usage[path]
+			if !ok {
+				t.Errorf("stat of ignored mount %s", path)
+			}
+			return u, nil
+		},
+		run: run,
+	}
+}
+
+func TestCollect_UsageAndTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newWithSystem(Config{IgnoreMounts: []string{"/snap/*"}, MountTimeout: 20 * time.Millisecond}, testSystem(t, release, nil))
+
+	start := time.Now()
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Collect took %s with one hung mount", elapsed)
+	}
+	st := data.(*Status)
+
+	var got []string
+	for _, m := range st.Mounts {
+		got = append(got, m.Mountpoint+"="+m.Status)
+	}
+	want := "/=warn /boot=ok /mnt/nas=timeout"
+	if strings.Join(got, " ") != want {
+		t.Errorf("mounts = %s, want %s", strings.Join(got, " "), want)
+	}
+	if st.Mounts[0].UsedPercent != 90 || st.Mounts[0].TotalBytes != 100*gib {
+		t.Errorf("root = %+v", st.Mounts[0])
+	}
+	if st.Level != StatusWarn || !c.Healthy() {
+		t.Errorf("level = %s healthy = %v, want warn and healthy", st.Level, c.Healthy())
+	}
+
+	// The hung stat is still outstanding, so the next pass reports the
+	// mount without starting another.
+	data, _ = c.Collect(context.Background())
+	if m := data.(*Status).Mounts[2]; m.Status != StatusTimeout || !strings.Contains(m.Error, "earlier stat") {
+		t.Errorf("second pass /mnt/nas = %+v", m)
+	}
+}
+
+func TestCollect_Thresholds(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	c := newWithSystem(Config{WarnPercent: 20, CriticalPercent: 90}, testSystem(t, release, nil))
+	c.cfg.IgnoreMounts = []string{"/snap/*"}
+	data, _ := c.Collect(context.Background())
+	st := data.(*Status)
+	if st.Level != StatusCritical {
+		t.Errorf("level = %s, want critical", st.Level)
+	}
+	if st.Mounts[1].Status != StatusWarn {
+		t.Errorf("/boot at 25%% = %s, want warn", st.Mounts[1].Status)
+	}
+}
+
+func TestCollect_ListFails(t *testing.T) {
+	c := newWithSystem(Config{}, system{
+		partitions: func(context.Context) ([]Partition, error) { return nil, errors.New("no /proc") },
+	})
+	data, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if st := data.(*Status); !strings.Contains(st.Error, "no /proc") || c.Healthy() {
+		t.Errorf("status = %+v healthy = %v, want error and unhealthy", st, c.Healthy())
+	}
+}
+
+const testScan = `{"devices":[{"name":"/dev/sda","type":"sat"},{"name":"/dev/nvme0","type":"nvme"},{"name":"/dev/sdb","type":"sat"}]}`
+
+const testSdaReport = `{"model_name":"WDC WD40EFRX","smart_status":{"passed":true},
+"ata_smart_attributes":{"table":[{"id":1,"raw":{"value":0}},{"id":5,"raw":{"value":8}}]}}`
+
+const testNvmeReport = `{"model_name":"Samsung SSD 980","smart_status":{"passed":false}}`
+
+const testSdbReport = `{"smartctl":{"messages":[{"string":"Smartctl open device: /dev/sdb failed: Permission denied","severity":"error"}]}}`
+
+func TestCollect_SMART(t *testing.T) {
+	run := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if name != "/usr/sbin/smartctl" {
+			t.Errorf("ran %q", name)
+		}
+		if args[0] == "--scan" {
+			return []byte(testScan), nil
+		}
+		switch args[4] {
+		case "/dev/sda":
+			return []byte(testSdaReport), errors.New("exit status 64")
+		case "/dev/nvme0":
+			return []byte(testNvmeReport), errors.New("exit status 8")
+		case "/dev/sdb":
+			return []byte(testSdbReport), errors.New("exit status 2")
+		}
+		t.Errorf("unexpected smartctl %v", args)
+		return nil, nil
+	}
+	release := make(chan struct{})
+	close(release)
+	c := newWithSystem(Config{SMART: true, Smartctl: "/usr/sbin/smartctl", IgnoreMounts: []string{"/snap/*"}}, testSystem(t, release, run))
+	data, _ := c.Collect(context.Background())
+	st := data.(*Status)
+
+	if len(st.Disks) != 3 {
+		t.Fatalf("got %d disks, want 3: %+v", len(st.Disks), st.Disks)
+	}
+	sda, nvme, sdb := st.Disks[0], st.Disks[1], st.Disks[2]
+	if sda.Model != "WDC WD40EFRX" || sda.ReallocatedSectors == nil || *sda.ReallocatedSectors != 8 || sda.Level() != StatusWarn {
+		t.Errorf("sda = %+v", sda)
+	}
+	if nvme.Passed == nil || *nvme.Passed || nvme.Level() != StatusCritical {
+		t.Errorf("nvme = %+v", nvme)
+	}
+	if !strings.Contains(sdb.Error, "Permission denied") || sdb.Level() != StatusOK {
+		t.Errorf("sdb = %+v, want a permission error that does not raise the level", sdb)
+	}
+	if st.Level != StatusCritical {
+		t.Errorf("level = %s, want critical from the failing drive", st.Level)
+	}
+}
+
+func TestCollect_NoSmartctl(t *testing.T) {
+	run := func(context.Context, string, ...string) ([]byte, error) { return nil, exec.ErrNotFound }
+	release := make(chan struct{})
+	close(release)
+	c := newWithSystem(Config{SMART: true, IgnoreMounts: []string{"/snap/*"}}, testSystem(t, release, run))
+	data, _ := c.Collect(context.Background())
+	if st := data.(*Status); st.Disks != nil {
+		t.Errorf("disks = %+v, want none without smartctl", st.Disks)
+	}
+}
+
+func TestWorst(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{StatusOK, StatusOK, StatusOK},
+		{StatusOK, StatusTimeout, StatusWarn},
+		{StatusError, StatusOK, StatusWarn},
+		{StatusWarn, StatusCritical, StatusCritical},
+	}
+	for _, tt := range tests {
+		if got := Worst(tt.a, tt.b); got != tt.want {
+			t.Errorf("Worst(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	c := New(Config{})
+	if c.Name() != "storage" || c.Interval() != DefaultInterval || c.cfg.MountTimeout != DefaultMountTimeout ||
+		c.cfg.WarnPercent != DefaultWarnPercent || c.cfg.CriticalPercent != DefaultCriticalPercent ||
+		len(c.cfg.IgnoreFSTypes) != len(DefaultIgnoreFSTypes) {
+		t.Errorf("New(Config{}) = %+v", c.cfg)
+	}
+}
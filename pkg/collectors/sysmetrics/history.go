@@ -0,0 +1,95 @@
+package sysmetrics
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/data"
+)
+
+// HistoryFileName is the history file in the daemon's state directory.
+const HistoryFileName = "sysmetrics-history.json"
+
+// Default history settings: a day of samples, at most one every 15s.
+const (
+	DefaultHistoryRetention = 24 * time.Hour
+	DefaultHistoryInterval  = 15 * time.Second
+)
+
+// History series, in the order History records them.
+const (
+	HistoryCPU    = "cpu"    // total CPU usage, percent
+	HistoryLoad   = "load1"  // 1-minute load average
+	HistoryMemory = "memory" // memory used, percent
+	HistoryNetRx  = "net_rx" // received, bytes per second
+	HistoryNetTx  = "net_tx" // sent, bytes per second
+)
+
+// historySeries lists the series in recording order, sorted as a ring read
+// back from disk orders them.
+var historySeries = []string{HistoryCPU, HistoryLoad, HistoryMemory, HistoryNetRx, HistoryNetTx}
+
+// History keeps recent samples of the metrics worth graphing in a
+// fixed-size ring, so a day of samples costs the same memory and disk
+// whatever the collector interval. It is safe for concurrent use.
+type History struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	ring *data.Ring
+}
+
+// NewHistory returns an empty history keeping retention worth of samples
+// taken at most every interval. Zero values use the defaults.
+func NewHistory(retention, interval time.Duration) *History {
+	if retention <= 0 {
+		retention = DefaultHistoryRetention
+	}
+	if interval <= 0 {
+		interval = DefaultHistoryInterval
+	}
+	return &History{
+		interval: interval,
+		ring:     data.NewRing(int(retention/interval), historySeries...),
+	}
+}
+
+// LoadHistory is NewHistory continuing from the history saved at path. A
+// file that is missing, unreadable, or of another format is ignored; one
+// written with another retention keeps the newest samples that fit.
+func LoadHistory(path string, retention, interval time.Duration) *History {
+	h := NewHistory(retention, interval)
+	saved, err := data.ReadRing(path)
+	if err != nil || !slices.Equal(saved.Names(), historySeries) {
+		return h
+	}
+	if saved.Cap() != h.ring.Cap() {
+		saved = saved.Resized(h.ring.Cap())
+	}
+	h.ring = saved
+	return h
+}
+
+// Record adds m to the history unless the previous sample is less than
+// the history interval older.
+func (h *History) Record(m Metrics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if last := h.ring.Last(); !last.IsZero() && m.Timestamp.Sub(last) < h.interval {
+		return
+	}
+	h.ring.Add(m.Timestamp, m.CPU.Total, m.Load.Load1, m.Memory.UsedPercent, m.Network.RxRate, m.Network.TxRate)
+}
+
+// Ring returns the samples recorded so far.
+func (h *History) Ring() *data.Ring {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ring
+}
+
+// Save writes the history to path atomically.
+func (h *History) Save(path string) error {
+	return h.Ring().WriteFile(path)
+}
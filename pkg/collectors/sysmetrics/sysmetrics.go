@@ -1,6 +1,7 @@
 // Package sysmetrics provides a cross-platform system metrics collector for
-// prompt-pulse v2. It uses gopsutil to gather CPU, memory, disk, load, and
-// uptime data on both Darwin and Linux without /proc dependencies.
+// prompt-pulse v2. It uses gopsutil to gather CPU, memory, disk, load,
+// network, and uptime data on both Darwin and Linux without /proc
+// dependencies.
 package sysmetrics
 
 import (
@@ -15,6 +16,7 @@ import (
 	"github.com/shirou/gopsutil/v4/host"
 	"github.com/shirou/gopsutil/v4/load"
 	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
 )
 
 // Config controls the SysMetrics collector behaviour.
@@ -80,14 +82,27 @@ type LoadMetrics struct {
 	Load15 float64 `json:"load15"`
 }
 
+// NetworkMetrics holds traffic summed over all interfaces but loopback.
+type NetworkMetrics struct {
+	// RxBytes and TxBytes are the bytes received and sent since boot.
+	RxBytes uint64 `json:"rx_bytes"`
+	TxBytes uint64 `json:"tx_bytes"`
+
+	// RxRate and TxRate are the throughput since the previous
+	// collection, in bytes per second; zero on the first.
+	RxRate float64 `json:"rx_rate"`
+	TxRate float64 `json:"tx_rate"`
+}
+
 // Metrics is the aggregate snapshot returned by Collect.
 type Metrics struct {
-	CPU       CPUMetrics    `json:"cpu"`
-	Memory    MemoryMetrics `json:"memory"`
-	Disks     []DiskMetrics `json:"disks"`
-	Load      LoadMetrics   `json:"load"`
-	Uptime    time.Duration `json:"uptime"`
-	Timestamp time.Time     `json:"timestamp"`
+	CPU       CPUMetrics     `json:"cpu"`
+	Memory    MemoryMetrics  `json:"memory"`
+	Disks     []DiskMetrics  `json:"disks"`
+	Load      LoadMetrics    `json:"load"`
+	Network   NetworkMetrics `json:"network"`
+	Uptime    time.Duration  `json:"uptime"`
+	Timestamp time.Time      `json:"timestamp"`
 }
 
 // --- Collector implementation ---
@@ -98,6 +113,11 @@ type Collector struct {
 	cfg     Config
 	mu      sync.Mutex
 	healthy bool
+
+	// lastNet and lastNetAt are the network counters of the previous
+	// collection, from which throughput is computed.
+	lastNet   NetworkMetrics
+	lastNetAt time.Time
 }
 
 // New creates a Collector with the given configuration. Zero-value fields
@@ -175,13 +195,18 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 		errs = append(errs, fmt.Sprintf("load: %v", err))
 	}
 
+	// --- Network ---
+	if err := c.collectNetwork(ctx, &m); err != nil {
+		errs = append(errs, fmt.Sprintf("network: %v", err))
+	}
+
 	// --- Uptime ---
 	if err := c.collectUptime(ctx, &m); err != nil {
 		errs = append(errs, fmt.Sprintf("uptime: %v", err))
 	}
 
 	// If everything failed, report unhealthy and return an aggregated error.
-	if len(errs) == 5 {
+	if len(errs) == 6 {
 		c.setHealthy(false)
 		return nil, fmt.Errorf("sysmetrics: all sub-collectors failed: %s", strings.Join(errs, "; "))
 	}
@@ -303,6 +328,43 @@ func (c *Collector) collectLoad(ctx context.Context, m *Metrics) error {
 	return nil
 }
 
+func (c *Collector) collectNetwork(ctx context.Context, m *Metrics) error {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, nic := range counters {
+		if nic.Name == "lo" || nic.Name == "lo0" {
+			continue
+		}
+		m.Network.RxBytes += nic.BytesRecv
+		m.Network.TxBytes += nic.BytesSent
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m.Network.RxRate, m.Network.TxRate = networkRates(c.lastNet, m.Network, m.Timestamp.Sub(c.lastNetAt))
+	c.lastNet, c.lastNetAt = m.Network, m.Timestamp
+	return nil
+}
+
+// networkRates returns the receive and send throughput between two
+// counter readings elapsed apart. Counters that went backwards, as after
+// an interface was removed, give zero rather than a huge rate.
+func networkRates(prev, cur NetworkMetrics, elapsed time.Duration) (rx, tx float64) {
+	if elapsed <= 0 || prev.RxBytes == 0 && prev.TxBytes == 0 {
+		return 0, 0
+	}
+	secs := elapsed.Seconds()
+	if cur.RxBytes >= prev.RxBytes {
+		rx = float64(cur.RxBytes-prev.RxBytes) / secs
+	}
+	if cur.TxBytes >= prev.TxBytes {
+		tx = float64(cur.TxBytes-prev.TxBytes) / secs
+	}
+	return rx, tx
+}
+
 func (c *Collector) collectUptime(ctx context.Context, m *Metrics) error {
 	secs, err := host.UptimeWithContext(ctx)
 	if err != nil {
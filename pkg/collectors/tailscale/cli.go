@@ -0,0 +1,70 @@
+package tailscale
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"tailscale.com/ipn/ipnstate"
+)
+
+// ErrCLINotFound is returned by the CLI client when the tailscale binary is
+// not installed or not on PATH.
+var ErrCLINotFound = errors.New("tailscale CLI not found (is tailscale installed and on PATH?)")
+
+// NewCLIClient creates a StatusClient that runs `tailscale status --json`.
+// This works where the LocalAPI socket is not readable by the current user,
+// at the cost of a process spawn per collection. If path is empty,
+// "tailscale" is looked up on PATH.
+func NewCLIClient(path string) StatusClient {
+	if path == "" {
+		path = "tailscale"
+	}
+	return &cliClient{path: path}
+}
+
+// cliClient implements StatusClient by shelling out to the tailscale CLI.
+type cliClient struct {
+	path string
+}
+
+func (c *cliClient) Status(ctx context.Context) (*ipnstate.Status, error) {
+	bin, err := exec.LookPath(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCLINotFound, c.path)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "status", "--json")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if cliDaemonDown(msg) {
+			return nil, fmt.Errorf("%w: %s", ErrDaemonNotRunning, msg)
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("tailscale status --json: %s", msg)
+	}
+
+	var st ipnstate.Status
+	if err := json.Unmarshal(out, &st); err != nil {
+		return nil, fmt.Errorf("decoding tailscale status: %w", err)
+	}
+	return &st, nil
+}
+
+// cliDaemonDown reports whether CLI stderr says tailscaled is unreachable.
+func cliDaemonDown(stderr string) bool {
+	return strings.Contains(stderr, "failed to connect to local tailscaled") ||
+		strings.Contains(stderr, "doesn't appear to be running")
+}
@@ -1,13 +1,16 @@
 // Package tailscale provides a collector that gathers Tailscale network status
-// from the local tailscaled daemon via the LocalAPI unix socket. It maps the
-// ipnstate.Status response into a simplified Status struct for dashboard
-// rendering.
+// from the local tailscaled daemon, either via the LocalAPI unix socket or by
+// running `tailscale status --json`. It maps the ipnstate.Status response
+// into a simplified Status struct for dashboard rendering.
 package tailscale
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"sync"
+	"syscall"
 	"time"
 
 	"tailscale.com/ipn/ipnstate"
@@ -17,8 +20,16 @@ import (
 // Default configuration values.
 const (
 	DefaultInterval = 10 * time.Second
+
+	// DefaultKeyExpiryWarning is how far ahead of this node's key expiry
+	// the collector starts flagging it.
+	DefaultKeyExpiryWarning = 7 * 24 * time.Hour
 )
 
+// ErrDaemonNotRunning is returned by Collect when tailscaled cannot be
+// reached, so callers can tell a stopped daemon apart from other failures.
+var ErrDaemonNotRunning = errors.New("tailscaled is not running")
+
 // StatusClient abstracts the local Tailscale daemon API for testability.
 // The real implementation is tailscale.com/client/local.Client, whose
 // Status method satisfies this interface.
@@ -34,6 +45,10 @@ type Config struct {
 	// SocketPath is an optional custom tailscaled socket path.
 	// When empty, the platform default is used.
 	SocketPath string
+
+	// KeyExpiryWarning is how long before this node's key expires that
+	// Status.KeyExpiringSoon is set. Zero uses DefaultKeyExpiryWarning.
+	KeyExpiryWarning time.Duration
 }
 
 // PeerInfo contains summarised information about a single Tailscale peer.
@@ -51,6 +66,7 @@ type PeerInfo struct {
 	RxBytes        int64         `json:"rx_bytes"`
 	TxBytes        int64         `json:"tx_bytes"`
 	Latency        time.Duration `json:"latency"`
+	KeyExpiry      *time.Time    `json:"key_expiry,omitempty"`
 }
 
 // Status is the data returned by a single Collect call.
@@ -59,16 +75,23 @@ type Status struct {
 	Peers          []PeerInfo `json:"peers"`
 	MagicDNSSuffix string     `json:"magic_dns_suffix"`
 	TailnetName    string     `json:"tailnet_name"`
+	BackendState   string     `json:"backend_state"`
 	OnlinePeers    int        `json:"online_peers"`
 	TotalPeers     int        `json:"total_peers"`
 	ExitNode       *PeerInfo  `json:"exit_node,omitempty"`
-	Timestamp      time.Time  `json:"timestamp"`
+	// KeyExpiringSoon is set when this node's key expires (or has expired)
+	// within the configured warning window.
+	KeyExpiringSoon bool      `json:"key_expiring_soon"`
+	Timestamp       time.Time `json:"timestamp"`
 }
 
 // Collector gathers Tailscale network status from the local daemon.
 type Collector struct {
-	client   StatusClient
-	interval time.Duration
+	client           StatusClient
+	interval         time.Duration
+	keyExpiryWarning time.Duration
+	// nowFunc allows tests to override time.Now for deterministic output.
+	nowFunc func() time.Time
 
 	mu      sync.Mutex
 	healthy bool
@@ -76,17 +99,23 @@ type Collector struct {
 
 // New creates a new Tailscale collector. If cfg.Interval is zero,
 // DefaultInterval is used. The caller must provide a StatusClient; in
-// production this is a *local.Client configured with the optional
-// SocketPath.
+// production this is NewLocalClient with the optional SocketPath, or
+// NewCLIClient when the LocalAPI socket is not accessible.
 func New(cfg Config, client StatusClient) *Collector {
 	interval := cfg.Interval
 	if interval <= 0 {
 		interval = DefaultInterval
 	}
+	warning := cfg.KeyExpiryWarning
+	if warning <= 0 {
+		warning = DefaultKeyExpiryWarning
+	}
 	return &Collector{
-		client:   client,
-		interval: interval,
-		healthy:  true, // healthy until first failure
+		client:           client,
+		interval:         interval,
+		keyExpiryWarning: warning,
+		nowFunc:          time.Now,
+		healthy:          true, // healthy until first failure
 	}
 }
 
@@ -115,10 +144,15 @@ func (c *Collector) setHealthy(v bool) {
 }
 
 // Collect calls the local Tailscale daemon and returns a Status snapshot.
+// An unreachable daemon marks the collector unhealthy and returns an error
+// wrapping ErrDaemonNotRunning.
 func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 	st, err := c.client.Status(ctx)
 	if err != nil {
 		c.setHealthy(false)
+		if !errors.Is(err, ErrDaemonNotRunning) && daemonUnreachable(err) {
+			err = fmt.Errorf("%w: %w", ErrDaemonNotRunning, err)
+		}
 		return nil, fmt.Errorf("tailscale status: %w", err)
 	}
 
@@ -134,7 +168,7 @@ func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
 
 // mapStatus converts the ipnstate.Status into our simplified Status struct.
 func (c *Collector) mapStatus(st *ipnstate.Status) *Status {
-	now := time.Now()
+	now := c.nowFunc()
 
 	selfInfo := c.mapSelfPeer(st)
 
@@ -170,17 +204,26 @@ func (c *Collector) mapStatus(st *ipnstate.Status) *Status {
 	}
 
 	return &Status{
-		Self:           selfInfo,
-		Peers:          peers,
-		MagicDNSSuffix: magicDNS,
-		TailnetName:    tailnetName,
-		OnlinePeers:    onlineCount,
-		TotalPeers:     len(peers),
-		ExitNode:       exitNode,
-		Timestamp:      now,
+		Self:            selfInfo,
+		Peers:           peers,
+		MagicDNSSuffix:  magicDNS,
+		TailnetName:     tailnetName,
+		BackendState:    st.BackendState,
+		OnlinePeers:     onlineCount,
+		TotalPeers:      len(peers),
+		ExitNode:        exitNode,
+		KeyExpiringSoon: selfInfo.KeyExpiry != nil && selfInfo.KeyExpiry.Sub(now) < c.keyExpiryWarning,
+		Timestamp:       now,
 	}
 }
 
+// daemonUnreachable reports whether err indicates the tailscaled socket is
+// missing or refusing connections.
+func daemonUnreachable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, fs.ErrNotExist)
+}
+
 // mapSelfPeer extracts PeerInfo from the Self field of ipnstate.Status.
 func (c *Collector) mapSelfPeer(st *ipnstate.Status) PeerInfo {
 	if st.Self == nil {
@@ -204,6 +247,12 @@ func (c *Collector) mapPeerStatus(ps *ipnstate.PeerStatus) PeerInfo {
 		TxBytes:        ps.TxBytes,
 	}
 
+	// Nodes with key expiry disabled report no expiry.
+	if ps.KeyExpiry != nil && !ps.KeyExpiry.IsZero() {
+		t := *ps.KeyExpiry
+		pi.KeyExpiry = &t
+	}
+
 	// Convert TailscaleIPs from netip.Addr to string.
 	if len(ps.TailscaleIPs) > 0 {
 		pi.TailscaleIPs = make([]string, len(ps.TailscaleIPs))
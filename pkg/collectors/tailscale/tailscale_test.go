@@ -3,7 +3,11 @@ package tailscale
 import (
 	"context"
 	"errors"
+	"net"
 	"net/netip"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
@@ -630,6 +634,126 @@ func TestCollect_PeerStableID(t *testing.T) {
 	}
 }
 
+func TestCollect_KeyExpiry(t *testing.T) {
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		expiry *time.Time
+		want   bool
+	}{
+		{"no expiry", nil, false},
+		{"far off", ptrTime(now.Add(30 * 24 * time.Hour)), false},
+		{"within window", ptrTime(now.Add(3 * 24 * time.Hour)), true},
+		{"already expired", ptrTime(now.Add(-time.Hour)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := buildTestStatus()
+			st.Self.KeyExpiry = tt.expiry
+			c := New(Config{}, &mockClient{status: st})
+			c.nowFunc = func() time.Time { return now }
+
+			result, err := c.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error: %v", err)
+			}
+			status := result.(*Status)
+			if status.KeyExpiringSoon != tt.want {
+				t.Errorf("KeyExpiringSoon = %v, want %v", status.KeyExpiringSoon, tt.want)
+			}
+			if (status.Self.KeyExpiry != nil) != (tt.expiry != nil) {
+				t.Errorf("Self.KeyExpiry = %v, want %v", status.Self.KeyExpiry, tt.expiry)
+			}
+		})
+	}
+}
+
+func TestCollect_KeyExpiryWarningCustom(t *testing.T) {
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	st := buildTestStatus()
+	st.Self.KeyExpiry = ptrTime(now.Add(3 * 24 * time.Hour))
+	c := New(Config{KeyExpiryWarning: 24 * time.Hour}, &mockClient{status: st})
+	c.nowFunc = func() time.Time { return now }
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if result.(*Status).KeyExpiringSoon {
+		t.Error("KeyExpiringSoon = true with a 1d window and 3d left, want false")
+	}
+}
+
+func TestCollect_DaemonNotRunning(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	c := New(Config{}, &mockClient{err: dialErr})
+
+	_, err := c.Collect(context.Background())
+	if !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("Collect() error = %v, want ErrDaemonNotRunning", err)
+	}
+	if c.Healthy() {
+		t.Error("Healthy() = true with tailscaled down, want false")
+	}
+}
+
+// writeFakeCLI writes a shell script standing in for the tailscale binary.
+func writeFakeCLI(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tailscale")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCLIClient_Status(t *testing.T) {
+	path := writeFakeCLI(t, `[ "$1 $2" = "status --json" ] || exit 2
+cat <<'JSON'
+{"BackendState":"Running","Self":{"HostName":"xoxd-bates","Online":true,"KeyExpiry":"2026-03-01T00:00:00Z"},"MagicDNSSuffix":"tinyland.ts.net"}
+JSON
+`)
+	st, err := NewCLIClient(path).Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if st.BackendState != "Running" || st.Self == nil || st.Self.HostName != "xoxd-bates" {
+		t.Fatalf("Status() = %+v, want running xoxd-bates", st)
+	}
+	if st.Self.KeyExpiry == nil || !st.Self.KeyExpiry.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Self.KeyExpiry = %v, want 2026-03-01", st.Self.KeyExpiry)
+	}
+}
+
+func TestCLIClient_DaemonNotRunning(t *testing.T) {
+	path := writeFakeCLI(t, `echo "failed to connect to local tailscaled; it doesn't appear to be running" >&2
+exit 1
+`)
+	c := New(Config{}, NewCLIClient(path))
+
+	_, err := c.Collect(context.Background())
+	if !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("Collect() error = %v, want ErrDaemonNotRunning", err)
+	}
+	if c.Healthy() {
+		t.Error("Healthy() = true with tailscaled down, want false")
+	}
+}
+
+func TestCLIClient_NotInstalled(t *testing.T) {
+	c := New(Config{}, NewCLIClient(filepath.Join(t.TempDir(), "missing")))
+
+	_, err := c.Collect(context.Background())
+	if !errors.Is(err, ErrCLINotFound) {
+		t.Errorf("Collect() error = %v, want ErrCLINotFound", err)
+	}
+	if c.Healthy() {
+		t.Error("Healthy() = true without the CLI, want false")
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
 // Compile-time check that Collector satisfies the interface contract.
 // We define a local interface identical to pkg/collectors.Collector to avoid
 // importing that package (which is being built concurrently by another agent).
@@ -0,0 +1,174 @@
+package uptimekuma
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// Sample is a single sample from the Prometheus text exposition format.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// httpClient implements MetricsClient using net/http.
+type httpClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newHTTPClient(baseURL, apiKey string) *httpClient {
+	return &httpClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  httpx.New(httpx.Config{Name: "uptimekuma", Timeout: 15 * time.Second}),
+	}
+}
+
+// Metrics fetches and parses GET /metrics. Uptime Kuma authenticates API
+// keys as the basic-auth password with an empty username.
+func (c *httpClient) Metrics(ctx context.Context) ([]Sample, error) {
+	if c.baseURL == "" {
+		return nil, fmt.Errorf("no Uptime Kuma URL configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/metrics", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.SetBasicAuth("", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("uptime kuma unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("uptime kuma rejected API key: %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("uptime kuma /metrics returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	return parseMetrics(resp.Body)
+}
+
+// parseMetrics parses the monitor_* samples from a Prometheus text
+// exposition. Comment lines, other metric families, and malformed lines are
+// skipped.
+func parseMetrics(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || !strings.HasPrefix(line, "monitor_") {
+			continue
+		}
+		if s, ok := parseSample(line); ok {
+			samples = append(samples, s)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading metrics: %w", err)
+	}
+	return samples, nil
+}
+
+// parseSample parses one exposition line: name{labels} value [timestamp].
+func parseSample(line string) (Sample, bool) {
+	s := Sample{Labels: map[string]string{}}
+
+	i := strings.IndexAny(line, "{ ")
+	if i < 0 {
+		return s, false
+	}
+	s.Name = line[:i]
+	rest := line[i:]
+
+	if strings.HasPrefix(rest, "{") {
+		end, ok := parseLabels(rest[1:], s.Labels)
+		if !ok {
+			return s, false
+		}
+		rest = rest[1+end:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return s, false
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, false
+	}
+	s.Value = v
+	return s, true
+}
+
+// parseLabels parses `k="v",k2="v2"}` into labels and returns the index just
+// past the closing brace.
+func parseLabels(in string, labels map[string]string) (int, bool) {
+	i := 0
+	for i < len(in) {
+		if in[i] == '}' {
+			return i + 1, true
+		}
+		if in[i] == ',' || in[i] == ' ' {
+			i++
+			continue
+		}
+
+		eq := strings.IndexByte(in[i:], '=')
+		if eq < 0 {
+			return 0, false
+		}
+		key := strings.TrimSpace(in[i : i+eq])
+		i += eq + 1
+		if i >= len(in) || in[i] != '"' {
+			return 0, false
+		}
+		i++
+
+		var val strings.Builder
+		for {
+			if i >= len(in) {
+				return 0, false
+			}
+			ch := in[i]
+			if ch == '"' {
+				i++
+				break
+			}
+			if ch == '\\' && i+1 < len(in) {
+				i++
+				switch in[i] {
+				case 'n':
+					val.WriteByte('\n')
+				default:
+					val.WriteByte(in[i])
+				}
+				i++
+				continue
+			}
+			val.WriteByte(ch)
+			i++
+		}
+		labels[key] = val.String()
+	}
+	return 0, false
+}
@@ -0,0 +1,282 @@
+// Package uptimekuma provides a collector that reads monitor status from an
+// Uptime Kuma instance. Uptime Kuma exposes monitor state through its
+// Prometheus /metrics endpoint, authenticated with an API key; the collector
+// parses that endpoint and maps each monitor into a simplified Status struct
+// for dashboard rendering.
+package uptimekuma
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval = 60 * time.Second
+)
+
+// Monitor states as reported by Uptime Kuma.
+const (
+	StateDown        = "down"
+	StateUp          = "up"
+	StatePending     = "pending"
+	StateMaintenance = "maintenance"
+)
+
+// MetricsClient abstracts the Uptime Kuma metrics endpoint for testability.
+type MetricsClient interface {
+	Metrics(ctx context.Context) ([]Sample, error)
+}
+
+// Config holds the configuration for the Uptime Kuma collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval.
+	Interval time.Duration
+
+	// URL is the base URL of the Uptime Kuma instance,
+	// e.g. "https://status.example.com".
+	URL string
+
+	// APIKey is an Uptime Kuma API key (Settings > API Keys).
+	APIKey string
+
+	// Tags restricts collection to monitors carrying at least one of the
+	// given tags. Each entry is either "name" (any value) or "name:value".
+	// Empty means all monitors.
+	Tags []string
+}
+
+// Monitor is the status of a single Uptime Kuma monitor.
+type Monitor struct {
+	Name           string            `json:"name"`
+	Type           string            `json:"type"`
+	URL            string            `json:"url,omitempty"`
+	Hostname       string            `json:"hostname,omitempty"`
+	State          string            `json:"state"`
+	ResponseTimeMs float64           `json:"response_time_ms"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+// Status is the data returned by a single Collect call.
+type Status struct {
+	Monitors  []Monitor `json:"monitors"`
+	Up        int       `json:"up"`
+	Down      int       `json:"down"`
+	Total     int       `json:"total"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Collector gathers monitor status from an Uptime Kuma instance.
+type Collector struct {
+	client   MetricsClient
+	interval time.Duration
+	tags     []tagFilter
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+// New creates a new Uptime Kuma collector that talks to cfg.URL. If
+// cfg.Interval is zero, DefaultInterval is used.
+func New(cfg Config) *Collector {
+	return newWithClient(cfg, newHTTPClient(cfg.URL, cfg.APIKey))
+}
+
+// newWithClient creates a collector with an injected client for testing.
+func newWithClient(cfg Config, client MetricsClient) *Collector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Collector{
+		client:   client,
+		interval: interval,
+		tags:     parseTagFilters(cfg.Tags),
+		healthy:  true, // healthy until first failure
+	}
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "uptimekuma"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// setHealthy updates the internal healthy flag under the mutex.
+func (c *Collector) setHealthy(v bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthy = v
+}
+
+// Collect fetches the metrics endpoint and returns a Status snapshot. The
+// collector is marked unhealthy when the endpoint cannot be read.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	samples, err := c.client.Metrics(ctx)
+	if err != nil {
+		c.setHealthy(false)
+		return nil, fmt.Errorf("uptimekuma metrics: %w", err)
+	}
+
+	status := c.mapStatus(samples)
+	c.setHealthy(true)
+	return status, nil
+}
+
+// mapStatus groups the monitor_status and monitor_response_time samples by
+// monitor, applies the tag filter, and tallies the result.
+func (c *Collector) mapStatus(samples []Sample) *Status {
+	byName := make(map[string]*Monitor)
+	monitor := func(s Sample) *Monitor {
+		name := s.Labels["monitor_name"]
+		m, ok := byName[name]
+		if !ok {
+			m = &Monitor{
+				Name:     name,
+				Type:     s.Labels["monitor_type"],
+				URL:      labelValue(s.Labels, "monitor_url"),
+				Hostname: labelValue(s.Labels, "monitor_hostname"),
+				Tags:     monitorTags(s.Labels),
+			}
+			byName[name] = m
+		}
+		return m
+	}
+
+	for _, s := range samples {
+		if s.Labels["monitor_name"] == "" {
+			continue
+		}
+		switch s.Name {
+		case "monitor_status":
+			monitor(s).State = stateName(s.Value)
+		case "monitor_response_time":
+			monitor(s).ResponseTimeMs = s.Value
+		}
+	}
+
+	status := &Status{
+		Monitors:  []Monitor{},
+		Timestamp: time.Now(),
+	}
+	for _, m := range byName {
+		// Response-time-only series without a status are not monitors.
+		if m.State == "" || !c.matchTags(m.Tags) {
+			continue
+		}
+		status.Monitors = append(status.Monitors, *m)
+		switch m.State {
+		case StateUp:
+			status.Up++
+		case StateDown:
+			status.Down++
+		}
+	}
+	status.Total = len(status.Monitors)
+
+	sort.Slice(status.Monitors, func(i, j int) bool {
+		return status.Monitors[i].Name < status.Monitors[j].Name
+	})
+	return status
+}
+
+// labelValue returns a label, mapping the literal "null" Uptime Kuma emits
+// for unset fields to "".
+func labelValue(labels map[string]string, name string) string {
+	if v := labels[name]; v != "null" {
+		return v
+	}
+	return ""
+}
+
+// stateName converts the numeric monitor_status value to a state name.
+func stateName(v float64) string {
+	switch v {
+	case 0:
+		return StateDown
+	case 1:
+		return StateUp
+	case 2:
+		return StatePending
+	case 3:
+		return StateMaintenance
+	default:
+		return StatePending
+	}
+}
+
+// standardLabels are the monitor_* labels Uptime Kuma always emits. Any
+// other label on a monitor series is one of the monitor's tags.
+var standardLabels = map[string]bool{
+	"monitor_id":       true,
+	"monitor_name":     true,
+	"monitor_type":     true,
+	"monitor_url":      true,
+	"monitor_hostname": true,
+	"monitor_port":     true,
+}
+
+// monitorTags extracts the non-standard labels of a series as tags.
+func monitorTags(labels map[string]string) map[string]string {
+	var tags map[string]string
+	for k, v := range labels {
+		if standardLabels[k] {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[k] = v
+	}
+	return tags
+}
+
+// tagFilter is a parsed Config.Tags entry.
+type tagFilter struct {
+	name  string
+	value string // empty matches any value
+}
+
+// parseTagFilters parses "name" and "name:value" entries.
+func parseTagFilters(tags []string) []tagFilter {
+	var filters []tagFilter
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(t, ":")
+		filters = append(filters, tagFilter{name: name, value: value})
+	}
+	return filters
+}
+
+// matchTags reports whether a monitor with the given tags passes the
+// configured filter. With no filter every monitor matches.
+func (c *Collector) matchTags(tags map[string]string) bool {
+	if len(c.tags) == 0 {
+		return true
+	}
+	for _, f := range c.tags {
+		v, ok := tags[f.name]
+		if ok && (f.value == "" || f.value == v) {
+			return true
+		}
+	}
+	return false
+}
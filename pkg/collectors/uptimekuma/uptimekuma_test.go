@@ -0,0 +1,189 @@
+package uptimekuma
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockClient is a test double for MetricsClient.
+type mockClient struct {
+	samples []Sample
+	err     error
+}
+
+func (m *mockClient) Metrics(ctx context.Context) ([]Sample, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.samples, m.err
+}
+
+// testMetrics is a trimmed /metrics response with three monitors: two HTTP
+// monitors tagged env=prod and env=dev, and one untagged DNS monitor.
+const testMetrics = `# HELP monitor_status Monitor Status (1 = UP, 0= DOWN, 2= PENDING, 3= MAINTENANCE)
+# TYPE monitor_status gauge
+monitor_status{monitor_name="api",monitor_type="http",monitor_url="https://api.example.com",monitor_hostname="null",monitor_port="null",env="prod"} 1
+monitor_status{monitor_name="staging",monitor_type="http",monitor_url="https://staging.example.com",monitor_hostname="null",monitor_port="null",env="dev"} 0
+monitor_status{monitor_name="dns \"primary\"",monitor_type="dns",monitor_url="",monitor_hostname="ns1.example.com",monitor_port="53"} 3
+# HELP monitor_response_time Monitor Response Time (ms)
+# TYPE monitor_response_time gauge
+monitor_response_time{monitor_name="api",monitor_type="http",monitor_url="https://api.example.com",monitor_hostname="null",monitor_port="null",env="prod"} 142
+monitor_response_time{monitor_name="orphan",monitor_type="http"} 12
+process_cpu_user_seconds_total 1.5
+`
+
+func parseTestMetrics(t *testing.T) []Sample {
+	t.Helper()
+	samples, err := parseMetrics(strings.NewReader(testMetrics))
+	if err != nil {
+		t.Fatalf("parseMetrics() error: %v", err)
+	}
+	return samples
+}
+
+func TestParseMetrics(t *testing.T) {
+	samples := parseTestMetrics(t)
+	if len(samples) != 5 {
+		t.Fatalf("len(samples) = %d, want 5", len(samples))
+	}
+	if samples[2].Labels["monitor_name"] != `dns "primary"` {
+		t.Errorf("escaped label = %q, want %q", samples[2].Labels["monitor_name"], `dns "primary"`)
+	}
+	if samples[3].Name != "monitor_response_time" || samples[3].Value != 142 {
+		t.Errorf("samples[3] = %+v, want response time 142", samples[3])
+	}
+}
+
+func TestParseSample_Malformed(t *testing.T) {
+	for _, line := range []string{
+		"monitor_status",
+		`monitor_status{monitor_name="x" 1`,
+		`monitor_status{monitor_name=x} 1`,
+		`monitor_status{monitor_name="x"} notanumber`,
+	} {
+		if _, ok := parseSample(line); ok {
+			t.Errorf("parseSample(%q) ok = true, want false", line)
+		}
+	}
+}
+
+func TestCollect_MapsMonitors(t *testing.T) {
+	c := newWithClient(Config{}, &mockClient{samples: parseTestMetrics(t)})
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	st := result.(*Status)
+
+	if st.Total != 3 || st.Up != 1 || st.Down != 1 {
+		t.Errorf("Total/Up/Down = %d/%d/%d, want 3/1/1", st.Total, st.Up, st.Down)
+	}
+	// Sorted by name.
+	api := st.Monitors[0]
+	if api.Name != "api" || api.State != StateUp || api.ResponseTimeMs != 142 {
+		t.Errorf("api = %+v, want up with 142ms", api)
+	}
+	if api.Tags["env"] != "prod" || len(api.Tags) != 1 {
+		t.Errorf("api.Tags = %v, want env=prod only", api.Tags)
+	}
+	if st.Monitors[1].State != StateMaintenance {
+		t.Errorf("dns state = %q, want %q", st.Monitors[1].State, StateMaintenance)
+	}
+	if !c.Healthy() {
+		t.Error("Healthy() = false after success")
+	}
+}
+
+func TestCollect_TagFilter(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{nil, []string{"api", `dns "primary"`, "staging"}},
+		{[]string{"env"}, []string{"api", "staging"}},
+		{[]string{"env:prod"}, []string{"api"}},
+		{[]string{"env:qa", "env:dev"}, []string{"staging"}},
+		{[]string{"team"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.tags, ","), func(t *testing.T) {
+			c := newWithClient(Config{Tags: tt.tags}, &mockClient{samples: parseTestMetrics(t)})
+			result, err := c.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error: %v", err)
+			}
+			st := result.(*Status)
+			var got []string
+			for _, m := range st.Monitors {
+				got = append(got, m.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("monitors = %v, want %v", got, tt.want)
+			}
+			if st.Total != len(tt.want) {
+				t.Errorf("Total = %d, want %d", st.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestCollect_UnreachableMarksUnhealthy(t *testing.T) {
+	c := newWithClient(Config{}, &mockClient{err: errors.New("connection refused")})
+
+	if _, err := c.Collect(context.Background()); err == nil {
+		t.Fatal("Collect() error = nil, want error")
+	}
+	if c.Healthy() {
+		t.Error("Healthy() = true after failure, want false")
+	}
+}
+
+func TestInterval(t *testing.T) {
+	if got := newWithClient(Config{}, nil).Interval(); got != DefaultInterval {
+		t.Errorf("default Interval() = %v, want %v", got, DefaultInterval)
+	}
+	if got := newWithClient(Config{Interval: 5 * time.Minute}, nil).Interval(); got != 5*time.Minute {
+		t.Errorf("Interval() = %v, want 5m", got)
+	}
+	if got := newWithClient(Config{}, nil).Name(); got != "uptimekuma" {
+		t.Errorf("Name() = %q, want %q", got, "uptimekuma")
+	}
+}
+
+func TestHTTPClient_Metrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, pass, ok := r.BasicAuth(); !ok || pass != "kuma-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, testMetrics)
+	}))
+	defer srv.Close()
+
+	samples, err := newHTTPClient(srv.URL+"/", "kuma-key").Metrics(context.Background())
+	if err != nil {
+		t.Fatalf("Metrics() error: %v", err)
+	}
+	if len(samples) != 5 {
+		t.Errorf("len(samples) = %d, want 5", len(samples))
+	}
+
+	_, err = newHTTPClient(srv.URL, "wrong").Metrics(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "rejected API key") {
+		t.Errorf("Metrics() with bad key error = %v, want rejected API key", err)
+	}
+}
+
+// Ensure the mock satisfies the interface.
+var _ MetricsClient = (*mockClient)(nil)
@@ -0,0 +1,114 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// Open-Meteo endpoints. Variables so tests can point them at a local server.
+var (
+	forecastURL  = "https://api.open-meteo.com/v1/forecast"
+	geocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+)
+
+// httpClient implements Client using net/http.
+type httpClient struct {
+	client *http.Client
+}
+
+func newHTTPClient() *httpClient {
+	return &httpClient{
+		client: httpx.New(httpx.Config{Name: "weather", Timeout: 10 * time.Second}),
+	}
+}
+
+// forecastResponse is the subset of the forecast response we read.
+type forecastResponse struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+		WeatherCode int     `json:"weather_code"`
+		IsDay       int     `json:"is_day"`
+	} `json:"current"`
+	CurrentUnits struct {
+		Temperature string `json:"temperature_2m"`
+	} `json:"current_units"`
+}
+
+// geocodingResponse is the subset of the geocoding response we read.
+type geocodingResponse struct {
+	Results []struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"results"`
+}
+
+// Current fetches GET /v1/forecast with the current temperature, weather
+// code, and day/night flag.
+func (c *httpClient) Current(ctx context.Context, lat, lon float64, units string) (*Current, error) {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	q.Set("current", "temperature_2m,weather_code,is_day")
+	q.Set("temperature_unit", units)
+
+	var resp forecastResponse
+	if err := c.getJSON(ctx, forecastURL+"?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	return &Current{
+		Temperature: resp.Current.Temperature,
+		Unit:        resp.CurrentUnits.Temperature,
+		Code:        resp.Current.WeatherCode,
+		IsDay:       resp.Current.IsDay == 1,
+	}, nil
+}
+
+// Geocode fetches GET /v1/search and returns the best match.
+func (c *httpClient) Geocode(ctx context.Context, name string) (*Place, error) {
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("count", "1")
+
+	var resp geocodingResponse
+	if err := c.getJSON(ctx, geocodingURL+"?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("no match for %q", name)
+	}
+	r := resp.Results[0]
+	return &Place{Name: r.Name, Latitude: r.Latitude, Longitude: r.Longitude}, nil
+}
+
+// getJSON performs a GET and decodes a JSON response into v.
+func (c *httpClient) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("open-meteo unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("open-meteo returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
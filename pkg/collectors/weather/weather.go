@@ -0,0 +1,263 @@
+// Package weather provides an optional collector that reads current
+// conditions from Open-Meteo, which needs no API key. The location is either
+// a configured latitude/longitude or a place name resolved once through the
+// Open-Meteo geocoding API. Results are cached for a long TTL and the
+// collector never queries the API more often than MinInterval, however often
+// it is asked to collect.
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Default configuration values.
+const (
+	DefaultInterval = 30 * time.Minute
+
+	// MinInterval is the floor on how often the API is queried, applied
+	// regardless of the configured or global poll interval.
+	MinInterval = 10 * time.Minute
+
+	// MaxAge is how long a cached Status stays worth displaying. Older
+	// results are hidden rather than shown as current.
+	MaxAge = 90 * time.Minute
+)
+
+// Temperature units accepted in Config.Units.
+const (
+	UnitsCelsius    = "celsius"
+	UnitsFahrenheit = "fahrenheit"
+)
+
+// errNoLocation is returned when neither coordinates nor a place name are
+// configured.
+var errNoLocation = errors.New("no weather location configured")
+
+// Client abstracts the Open-Meteo endpoints for testability.
+type Client interface {
+	// Current returns the current conditions at lat/lon.
+	Current(ctx context.Context, lat, lon float64, units string) (*Current, error)
+
+	// Geocode resolves a place name to coordinates.
+	Geocode(ctx context.Context, name string) (*Place, error)
+}
+
+// Current is the subset of the Open-Meteo "current" block the collector
+// uses.
+type Current struct {
+	Temperature float64
+	Unit        string // e.g. "°C"
+	Code        int    // WMO weather interpretation code
+	IsDay       bool
+}
+
+// Place is a geocoding result.
+type Place struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Config holds the configuration for the weather collector.
+type Config struct {
+	// Interval is how often collection runs. Zero uses DefaultInterval;
+	// values below MinInterval are raised to it.
+	Interval time.Duration
+
+	// Latitude and Longitude locate the forecast. When both are zero,
+	// Location is geocoded instead.
+	Latitude  float64
+	Longitude float64
+
+	// Location is a place name such as "Berlin", used when no coordinates
+	// are set.
+	Location string
+
+	// Units is UnitsCelsius (default) or UnitsFahrenheit.
+	Units string
+}
+
+// Status is the data returned by a single Collect call.
+type Status struct {
+	Location    string    `json:"location,omitempty"`
+	Temperature float64   `json:"temperature"`
+	Unit        string    `json:"unit"`
+	Code        int       `json:"code"`
+	Condition   string    `json:"condition"`
+	Glyph       string    `json:"glyph"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// TemperatureText formats the temperature rounded to a whole degree with
+// its unit, e.g. "13°C".
+func (s *Status) TemperatureText() string {
+	return fmt.Sprintf("%.0f%s", s.Temperature, s.Unit)
+}
+
+// Collector gathers current weather conditions.
+type Collector struct {
+	client   Client
+	interval time.Duration
+	lat, lon float64
+	location string
+	units    string
+	now      func() time.Time
+
+	mu          sync.Mutex
+	healthy     bool
+	place       *Place    // resolved location
+	last        *Status   // last successful result
+	lastAttempt time.Time // last API query, successful or not
+}
+
+// New creates a weather collector that queries Open-Meteo.
+func New(cfg Config) *Collector {
+	return newWithClient(cfg, newHTTPClient())
+}
+
+// newWithClient creates a collector with an injected client for testing.
+func newWithClient(cfg Config, client Client) *Collector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if interval < MinInterval {
+		interval = MinInterval
+	}
+	units := cfg.Units
+	if units != UnitsFahrenheit {
+		units = UnitsCelsius
+	}
+	return &Collector{
+		client:   client,
+		interval: interval,
+		lat:      cfg.Latitude,
+		lon:      cfg.Longitude,
+		location: cfg.Location,
+		units:    units,
+		now:      time.Now,
+		healthy:  true, // healthy until first failure
+	}
+}
+
+// Name returns the collector identifier.
+func (c *Collector) Name() string {
+	return "weather"
+}
+
+// Interval returns how often this collector should run.
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Healthy returns whether the last collection succeeded.
+func (c *Collector) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy
+}
+
+// Collect returns the current conditions. Within MinInterval of the last
+// query it returns the previous result without touching the network, or an
+// error if that query failed; callers are expected to render nothing in
+// that case.
+func (c *Collector) Collect(ctx context.Context) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !c.lastAttempt.IsZero() && now.Sub(c.lastAttempt) < MinInterval {
+		if c.last != nil {
+			return c.last, nil
+		}
+		return nil, fmt.Errorf("weather: rate limited until %s", c.lastAttempt.Add(MinInterval).Format(time.Kitchen))
+	}
+	c.lastAttempt = now
+
+	status, err := c.fetch(ctx, now)
+	if err != nil {
+		c.healthy = false
+		return nil, fmt.Errorf("weather: %w", err)
+	}
+	c.healthy = true
+	c.last = status
+	return status, nil
+}
+
+// fetch resolves the location if needed and queries current conditions.
+// Called with c.mu held.
+func (c *Collector) fetch(ctx context.Context, now time.Time) (*Status, error) {
+	place, err := c.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := c.client.Current(ctx, place.Latitude, place.Longitude, c.units)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, glyph := Describe(cur.Code, cur.IsDay)
+	return &Status{
+		Location:    place.Name,
+		Temperature: cur.Temperature,
+		Unit:        cur.Unit,
+		Code:        cur.Code,
+		Condition:   condition,
+		Glyph:       glyph,
+		Timestamp:   now,
+	}, nil
+}
+
+// resolve returns the configured coordinates, geocoding Location on first
+// use. Called with c.mu held.
+func (c *Collector) resolve(ctx context.Context) (*Place, error) {
+	if c.place != nil {
+		return c.place, nil
+	}
+	if c.lat != 0 || c.lon != 0 {
+		c.place = &Place{Latitude: c.lat, Longitude: c.lon}
+		return c.place, nil
+	}
+	if c.location == "" {
+		return nil, errNoLocation
+	}
+	p, err := c.client.Geocode(ctx, c.location)
+	if err != nil {
+		return nil, fmt.Errorf("geocode %q: %w", c.location, err)
+	}
+	c.place = p
+	return p, nil
+}
+
+// Describe maps a WMO weather interpretation code to a short condition and
+// a glyph. Clear skies use a moon glyph at night.
+func Describe(code int, isDay bool) (condition, glyph string) {
+	switch {
+	case code == 0:
+		if !isDay {
+			return "clear", "🌙"
+		}
+		return "clear", "☀️"
+	case code == 1 || code == 2:
+		return "partly cloudy", "⛅"
+	case code == 3:
+		return "overcast", "☁️"
+	case code == 45 || code == 48:
+		return "fog", "🌫️"
+	case code >= 51 && code <= 57:
+		return "drizzle", "🌦️"
+	case (code >= 61 && code <= 67) || (code >= 80 && code <= 82):
+		return "rain", "🌧️"
+	case (code >= 71 && code <= 77) || code == 85 || code == 86:
+		return "snow", "🌨️"
+	case code >= 95 && code <= 99:
+		return "thunderstorm", "⛈️"
+	default:
+		return "unknown", "🌡️"
+	}
+}
@@ -0,0 +1,203 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mockClient is a test double for Client.
+type mockClient struct {
+	current  *Current
+	place    *Place
+	err      error
+	calls    int
+	geocodes int
+}
+
+func (m *mockClient) Current(ctx context.Context, lat, lon float64, units string) (*Current, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.current, nil
+}
+
+func (m *mockClient) Geocode(ctx context.Context, name string) (*Place, error) {
+	m.geocodes++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.place, nil
+}
+
+// fakeClock is an adjustable time source.
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func newTestCollector(cfg Config, client Client) (*Collector, *fakeClock) {
+	c := newWithClient(cfg, client)
+	clock := &fakeClock{t: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	c.now = clock.now
+	return c, clock
+}
+
+func TestCollect_Current(t *testing.T) {
+	m := &mockClient{current: &Current{Temperature: 12.5, Unit: "°C", Code: 61, IsDay: true}}
+	c, _ := newTestCollector(Config{Latitude: 52.52, Longitude: 13.41}, m)
+
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	s := result.(*Status)
+	if s.Temperature != 12.5 || s.Unit != "°C" || s.Condition != "rain" || s.Glyph != "🌧️" {
+		t.Errorf("status = %+v", s)
+	}
+	if m.geocodes != 0 {
+		t.Errorf("geocoded %d times with explicit coordinates", m.geocodes)
+	}
+}
+
+func TestCollect_GeocodesOnce(t *testing.T) {
+	m := &mockClient{
+		current: &Current{Temperature: 3, Unit: "°C", Code: 0},
+		place:   &Place{Name: "Berlin", Latitude: 52.52, Longitude: 13.41},
+	}
+	c, clock := newTestCollector(Config{Location: "Berlin"}, m)
+
+	for i := 0; i < 2; i++ {
+		result, err := c.Collect(context.Background())
+		if err != nil {
+			t.Fatalf("Collect() error: %v", err)
+		}
+		if s := result.(*Status); s.Location != "Berlin" || s.Glyph != "🌙" {
+			t.Errorf("status = %+v, want Berlin at night", s)
+		}
+		clock.t = clock.t.Add(MinInterval)
+	}
+	if m.geocodes != 1 {
+		t.Errorf("geocodes = %d, want 1", m.geocodes)
+	}
+}
+
+func TestCollect_NoLocation(t *testing.T) {
+	c, _ := newTestCollector(Config{}, &mockClient{})
+	if _, err := c.Collect(context.Background()); !errors.Is(err, errNoLocation) {
+		t.Errorf("err = %v, want errNoLocation", err)
+	}
+}
+
+func TestCollect_RateLimited(t *testing.T) {
+	m := &mockClient{current: &Current{Temperature: 20, Unit: "°C", Code: 3}}
+	c, clock := newTestCollector(Config{Latitude: 1, Longitude: 1}, m)
+
+	first, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	clock.t = clock.t.Add(MinInterval - time.Second)
+	second, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if m.calls != 1 {
+		t.Errorf("API calls = %d, want 1 within MinInterval", m.calls)
+	}
+	if first != second {
+		t.Error("throttled Collect should return the previous result")
+	}
+
+	clock.t = clock.t.Add(time.Second)
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if m.calls != 2 {
+		t.Errorf("API calls = %d, want 2 after MinInterval", m.calls)
+	}
+}
+
+func TestCollect_FailureThrottledAndUnhealthy(t *testing.T) {
+	m := &mockClient{err: errors.New("connection refused")}
+	c, _ := newTestCollector(Config{Latitude: 1, Longitude: 1}, m)
+
+	if _, err := c.Collect(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if c.Healthy() {
+		t.Error("collector should be unhealthy after a failure")
+	}
+	if _, err := c.Collect(context.Background()); err == nil {
+		t.Fatal("expected rate-limit error")
+	}
+	if m.calls != 1 {
+		t.Errorf("API calls = %d, want failures throttled too", m.calls)
+	}
+}
+
+func TestInterval_Floor(t *testing.T) {
+	tests := []struct {
+		in, want time.Duration
+	}{
+		{0, DefaultInterval},
+		{time.Minute, MinInterval},
+		{time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		c := newWithClient(Config{Interval: tt.in}, &mockClient{})
+		if got := c.Interval(); got != tt.want {
+			t.Errorf("Interval(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		code      int
+		condition string
+	}{
+		{0, "clear"}, {2, "partly cloudy"}, {3, "overcast"}, {45, "fog"},
+		{53, "drizzle"}, {63, "rain"}, {81, "rain"}, {73, "snow"},
+		{95, "thunderstorm"}, {42, "unknown"},
+	}
+	for _, tt := range tests {
+		if got, _ := Describe(tt.code, true); got != tt.condition {
+			t.Errorf("Describe(%d) = %q, want %q", tt.code, got, tt.condition)
+		}
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forecast":
+			if r.URL.Query().Get("temperature_unit") != "fahrenheit" {
+				t.Errorf("temperature_unit = %q", r.URL.Query().Get("temperature_unit"))
+			}
+			w.Write([]byte(`{"current":{"temperature_2m":71.2,"weather_code":2,"is_day":1},"current_units":{"temperature_2m":"°F"}}`))
+		case "/search":
+			w.Write([]byte(`{"results":[{"name":"Ithaca","latitude":42.44,"longitude":-76.5}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	origForecast, origGeocoding := forecastURL, geocodingURL
+	forecastURL, geocodingURL = srv.URL+"/forecast", srv.URL+"/search"
+	defer func() { forecastURL, geocodingURL = origForecast, origGeocoding }()
+
+	c := New(Config{Location: "Ithaca", Units: UnitsFahrenheit})
+	result, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	s := result.(*Status)
+	if s.Location != "Ithaca" || s.Temperature != 71.2 || s.Unit != "°F" || s.Condition != "partly cloudy" {
+		t.Errorf("status = %+v", s)
+	}
+}
@@ -2,6 +2,8 @@ package components
 
 import (
 	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // BorderStyle selects which set of box-drawing characters to use.
@@ -36,6 +38,15 @@ type borderChars struct {
 	RightTee    string
 }
 
+// asciiBorder replaces every border style when render.Current disallows
+// Unicode.
+var asciiBorder = borderChars{
+	TopLeft: "+", TopRight: "+",
+	BottomLeft: "+", BottomRight: "+",
+	Horizontal: "-", Vertical: "|",
+	LeftTee: "+", RightTee: "+",
+}
+
 // borderSets maps each BorderStyle to its character set.
 var borderSets = map[BorderStyle]borderChars{
 	BorderSingle: {
@@ -75,6 +86,7 @@ type BoxStyle struct {
 	Border     BorderStyle
 	Title      string
 	TitleAlign Align
+	Footer     string // right-aligned in the bottom border
 	Padding    Padding
 	FG         string // foreground ANSI color code (raw escape or hex like "#ff5500")
 	BG         string // background ANSI color code (raw escape or hex like "#001122")
@@ -108,6 +120,9 @@ func RenderBox(content string, width, height int, style BoxStyle) string {
 	}
 
 	chars := borderSets[style.Border]
+	if !render.Current.Unicode {
+		chars = asciiBorder
+	}
 
 	// Color prefix/suffix for border characters.
 	colorPre, colorSuf := styleColors(style)
@@ -206,9 +221,13 @@ func RenderBox(content string, width, height int, style BoxStyle) string {
 	buf.WriteString(colorPre)
 	buf.WriteString(chars.BottomLeft)
 	buf.WriteString(colorSuf)
-	buf.WriteString(colorPre)
-	buf.WriteString(strings.Repeat(chars.Horizontal, topFill))
-	buf.WriteString(colorSuf)
+	if style.Footer != "" && topFill > 0 {
+		buf.WriteString(renderTitleBar(style.Footer, AlignRight, topFill, chars.Horizontal, colorPre, colorSuf))
+	} else {
+		buf.WriteString(colorPre)
+		buf.WriteString(strings.Repeat(chars.Horizontal, topFill))
+		buf.WriteString(colorSuf)
+	}
 	buf.WriteString(colorPre)
 	buf.WriteString(chars.BottomRight)
 	buf.WriteString(colorSuf)
@@ -274,14 +293,16 @@ func renderNoBorder(content string, width, height int, style BoxStyle) string {
 }
 
 // fitLine truncates or right-pads a single content line to exactly
-// targetWidth visible characters.
+// targetWidth terminal cells. A wide character that would straddle the
+// edge is dropped and replaced by padding.
 func fitLine(line string, targetWidth int) string {
 	if targetWidth <= 0 {
 		return ""
 	}
 	vis := VisibleLen(line)
 	if vis > targetWidth {
-		return Truncate(line, targetWidth)
+		line = Truncate(line, targetWidth)
+		vis = VisibleLen(line)
 	}
 	if vis < targetWidth {
 		return PadRight(line, targetWidth)
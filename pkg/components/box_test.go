@@ -3,6 +3,8 @@ package components
 import (
 	"strings"
 	"testing"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // ---------------------------------------------------------------------------
@@ -296,6 +298,21 @@ func TestWrapZeroWidth(t *testing.T) {
 // Border style character tests
 // ---------------------------------------------------------------------------
 
+func TestRenderBoxPlainProfile(t *testing.T) {
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	defer render.SetCurrent(orig)
+
+	style := DefaultBoxStyle()
+	style.Title = "CPU"
+	style.FG = "#ff0000"
+	got := RenderBox(Bold("42%"), 12, 3, style)
+	want := "+- CPU ----+\n|42%       |\n+----------+"
+	if got != want {
+		t.Errorf("plain box =\n%s\nwant\n%s", got, want)
+	}
+}
+
 func TestBorderStyleSingleChars(t *testing.T) {
 	chars := borderSets[BorderSingle]
 	if chars.TopLeft != "\u250c" {
@@ -562,6 +579,26 @@ func TestRenderBoxTitleTruncation(t *testing.T) {
 	}
 }
 
+func TestRenderBoxFooter(t *testing.T) {
+	style := BoxStyle{
+		Border: BorderSingle,
+		Title:  "Pods",
+		Footer: "/web 3/40",
+	}
+	box := RenderBox("", 20, 3, style)
+	lines := strings.Split(box, "\n")
+	bottom := lines[len(lines)-1]
+	if !strings.HasSuffix(bottom, " /web 3/40 \u2500\u2518") {
+		t.Errorf("footer not right-aligned in bottom border: %q", bottom)
+	}
+	if vis := VisibleLen(bottom); vis != 20 {
+		t.Errorf("bottom border width = %d, want 20", vis)
+	}
+	if strings.Contains(lines[0], "web") {
+		t.Errorf("footer drawn in top border: %q", lines[0])
+	}
+}
+
 // ---------------------------------------------------------------------------
 // BorderNone tests
 // ---------------------------------------------------------------------------
@@ -698,3 +735,19 @@ func TestFitLineZeroWidth(t *testing.T) {
 		t.Errorf("fitLine(hello, 0) = %q, want empty", r)
 	}
 }
+
+func TestRenderBoxWideContentKeepsBorderAligned(t *testing.T) {
+	style := BoxStyle{Border: BorderRounded, Title: "ステータス"}
+	content := "ascii line\n日本語のテキストです\nemoji 🚀🚀🚀🚀🚀\nmixed a日b本c語"
+	for _, width := range []int{6, 9, 10, 13, 20} {
+		lines := strings.Split(strings.TrimSuffix(RenderBox(content, width, 6, style), "\n"), "\n")
+		if len(lines) != 6 {
+			t.Fatalf("width %d: got %d lines, want 6", width, len(lines))
+		}
+		for i, l := range lines {
+			if w := VisibleLen(l); w != width {
+				t.Errorf("width %d: line %d is %d cells wide: %q", width, i, w, l)
+			}
+		}
+	}
+}
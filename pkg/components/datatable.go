@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/charmbracelet/x/ansi"
 )
 
 // ---------------------------------------------------------------------------
@@ -69,11 +71,18 @@ func SizingFill() ColumnSizing {
 // ---------------------------------------------------------------------------
 
 // Column defines a single column in a DataTable.
+//
+// Priority marks a column as optional: when the table is too narrow to give
+// every column its minimum width, optional columns are hidden entirely,
+// lowest Priority first (ties from the right). Columns with Priority 0 are
+// never hidden; if they alone do not fit, the table scrolls horizontally.
+// A column's minimum width is MinWidth, or its width for Fixed sizing, or 1.
 type Column struct {
 	Title    string
 	Sizing   ColumnSizing
 	Align    ColumnAlign
 	MinWidth int
+	Priority int
 }
 
 // Row represents a single data row in a DataTable.
@@ -111,6 +120,10 @@ type DataTableConfig struct {
 	Selectable    bool
 	BorderChar    string
 	HeaderSepChar string
+
+	// Scrollbar adds a one-cell gutter on the right with a scrollbar
+	// whenever the rows overflow the table's height.
+	Scrollbar bool
 }
 
 // ---------------------------------------------------------------------------
@@ -130,10 +143,23 @@ type DataTable struct {
 	borderChar   string
 	headerSep    string
 	scrollOffset int
+	colOffset    int // first column shown when scrolled horizontally
 	selectedIdx  int // index into filteredRows
 	frozen       bool
 	filterFn     func(Row) bool
 	filteredRows []Row // cached filtered view
+	scrollbar    bool
+	hit          dtHit // where the last Render drew rows and the scrollbar
+}
+
+// dtHit records the geometry of the last Render for hit-testing mouse
+// events against what is on screen.
+type dtHit struct {
+	firstY int // line of the first row drawn
+	first  int // index into filteredRows of the first row drawn
+	count  int // rows drawn
+
+	barX, barY, barH int // scrollbar column, first line, and height; barH 0 = none
 }
 
 // NewDataTable creates a new DataTable from cfg. ShowHeader and ShowBorder
@@ -159,6 +185,7 @@ func NewDataTable(cfg DataTableConfig) *DataTable {
 		borderChar:  border,
 		headerSep:   sep,
 		selectedIdx: -1,
+		scrollbar:   cfg.Scrollbar,
 	}
 	dt.filteredRows = dt.applyFilter(dt.rows)
 	return dt
@@ -236,6 +263,28 @@ func (dt *DataTable) ScrollDown(n int) {
 	}
 }
 
+// ScrollLeft pans the view n columns left when the table is too wide for
+// its width.
+func (dt *DataTable) ScrollLeft(n int) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.colOffset -= n
+	if dt.colOffset < 0 {
+		dt.colOffset = 0
+	}
+}
+
+// ScrollRight pans the view n columns right when the table is too wide for
+// its width. The offset is clamped during render.
+func (dt *DataTable) ScrollRight(n int) {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.colOffset += n
+	if dt.colOffset > len(dt.columns) {
+		dt.colOffset = len(dt.columns)
+	}
+}
+
 // ScrollToTop scrolls to the first row.
 func (dt *DataTable) ScrollToTop() {
 	dt.mu.Lock()
@@ -327,25 +376,109 @@ func (dt *DataTable) IsFrozen() bool {
 
 // Render draws the table into a string of the given dimensions. Each line is
 // exactly width visible characters (padded with spaces). The output has
-// exactly height lines separated by newlines.
+// exactly height lines separated by newlines. When the visible columns do
+// not fit, the table scrolls horizontally and the header shows ‹ and › in
+// one-cell margins where more columns lie to either side. With Scrollbar
+// set and more rows than fit, the rightmost cell of each line is the
+// scrollbar gutter.
 func (dt *DataTable) Render(width, height int) string {
 	dt.mu.Lock()
 	defer dt.mu.Unlock()
 
+	dt.hit = dtHit{}
 	if width <= 0 || height <= 0 {
 		return ""
 	}
+	if dt.scrollbar && width > 1 && len(dt.filteredRows) > height-dt.headerLines() {
+		return dt.addScrollbar(dt.render(width-1, height), width-1, height)
+	}
+	return dt.render(width, height)
+}
 
-	resetSeq := "\x1b[0m"
+// RowAt returns the index, among the rows passing the filter, of the row
+// the last Render drew on line y, or -1 if that line shows no row.
+func (dt *DataTable) RowAt(y int) int {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	if y < dt.hit.firstY || y >= dt.hit.firstY+dt.hit.count {
+		return -1
+	}
+	return dt.hit.first + y - dt.hit.firstY
+}
 
-	// Resolve column widths.
-	colWidths := dt.resolveWidths(width)
+// OnScrollbar reports whether cell (x, y) of the last Render is on the
+// scrollbar.
+func (dt *DataTable) OnScrollbar(x, y int) bool {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	h := dt.hit
+	return h.barH > 0 && x == h.barX && y >= h.barY && y < h.barY+h.barH
+}
+
+// ScrollbarRow returns the row a press or drag at line y of the last
+// Render's scrollbar points to: the first row at the top of the track and
+// the last at the bottom. y is clamped to the track, so a drag keeps
+// working past its ends. It returns -1 when no scrollbar was drawn.
+func (dt *DataTable) ScrollbarRow(y int) int {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	h := dt.hit
+	if h.barH == 0 {
+		return -1
+	}
+	p := min(max(y-h.barY, 0), h.barH-1)
+	if h.barH == 1 {
+		return 0
+	}
+	return p * (len(dt.filteredRows) - 1) / (h.barH - 1)
+}
 
-	// Determine how many header lines we need.
-	headerLines := 0
+// headerLines returns the number of lines the header takes.
+func (dt *DataTable) headerLines() int {
 	if dt.showHeader {
-		headerLines = 2 // header row + separator
+		return 2 // header row + separator
 	}
+	return 0
+}
+
+// addScrollbar appends the scrollbar gutter to the lines of a table
+// rendered width cells wide: blank beside the header, then a track the
+// height of the data area with a thumb sized and placed by the rows in
+// view.
+func (dt *DataTable) addScrollbar(body string, width, height int) string {
+	lines := strings.Split(body, "\n")
+	top := dt.headerLines()
+	track := height - top
+	rows := len(dt.filteredRows)
+	thumb := max(1, track*dt.hit.count/rows)
+	pos := 0
+	if span := rows - dt.hit.count; span > 0 {
+		pos = (track - thumb) * dt.hit.first / span
+	}
+	for i := range lines {
+		switch {
+		case i == 0 && top > 0:
+			lines[i] += " "
+		case i < top:
+			lines[i] += dt.headerSep
+		case i-top >= pos && i-top < pos+thumb:
+			lines[i] += "█"
+		default:
+			lines[i] += "│"
+		}
+	}
+	dt.hit.barX, dt.hit.barY, dt.hit.barH = width, top, track
+	return strings.Join(lines, "\n")
+}
+
+// render draws the table as Render does, without the scrollbar.
+func (dt *DataTable) render(width, height int) string {
+	resetSeq := "\x1b[0m"
+
+	// Choose the visible columns and their widths.
+	lay := dt.layout(width)
+
+	headerLines := dt.headerLines()
 
 	dataHeight := height - headerLines
 	if dataHeight < 0 {
@@ -358,8 +491,8 @@ func (dt *DataTable) Render(width, height int) string {
 	if len(rows) == 0 && dataHeight > 0 {
 		var lines []string
 		if dt.showHeader {
-			lines = append(lines, dt.renderHeader(colWidths, width))
-			lines = append(lines, dt.renderSeparator(colWidths, width))
+			lines = append(lines, dt.renderHeader(lay, width))
+			lines = append(lines, dt.renderSeparator(lay, width))
 		}
 		noData := "(no data)"
 		if dtVisibleLen(noData) > width {
@@ -442,8 +575,8 @@ func (dt *DataTable) Render(width, height int) string {
 
 		// Header.
 		if dt.showHeader {
-			lines = append(lines, dt.renderHeader(colWidths, width))
-			lines = append(lines, dt.renderSeparator(colWidths, width))
+			lines = append(lines, dt.renderHeader(lay, width))
+			lines = append(lines, dt.renderSeparator(lay, width))
 		}
 
 		// Top scroll indicator.
@@ -460,8 +593,11 @@ func (dt *DataTable) Render(width, height int) string {
 		if end > len(rows) {
 			end = len(rows)
 		}
+		dt.hit.firstY = len(lines)
+		dt.hit.first = dt.scrollOffset
+		dt.hit.count = end - dt.scrollOffset
 		for i := dt.scrollOffset; i < end; i++ {
-			line := dt.renderRow(rows[i], i, colWidths, width)
+			line := dt.renderRow(rows[i], i, lay, width)
 			lines = append(lines, line+resetSeq)
 		}
 
@@ -488,9 +624,9 @@ func (dt *DataTable) Render(width, height int) string {
 	// dataHeight == 0: header only.
 	var lines []string
 	if dt.showHeader && height >= 1 {
-		lines = append(lines, dt.renderHeader(colWidths, width))
+		lines = append(lines, dt.renderHeader(lay, width))
 		if height >= 2 {
-			lines = append(lines, dt.renderSeparator(colWidths, width))
+			lines = append(lines, dt.renderSeparator(lay, width))
 		}
 	}
 	for len(lines) < height {
@@ -503,7 +639,44 @@ func (dt *DataTable) Render(width, height int) string {
 // Internal rendering helpers
 // ---------------------------------------------------------------------------
 
-func (dt *DataTable) renderHeader(colWidths []int, totalWidth int) string {
+// dtLayout is the columns a render shows, with their widths. When the
+// table scrolls horizontally, a one-cell margin on each side holds the ‹
+// and › indicators and the columns get the width between them.
+type dtLayout struct {
+	cols        []int // indices into dt.columns, in display order
+	widths      []int // width of each shown column
+	scrolled    bool  // margins are reserved
+	left, right bool  // more columns lie to that side
+}
+
+// inner returns the width available to columns in a line of totalWidth.
+func (l dtLayout) inner(totalWidth int) int {
+	if l.scrolled {
+		return totalWidth - 2
+	}
+	return totalWidth
+}
+
+// wrap places a line of columns between the margins, if any.
+func (l dtLayout) wrap(line, left, right string) string {
+	if !l.scrolled {
+		return line
+	}
+	return left + line + right
+}
+
+func (dt *DataTable) renderHeader(lay dtLayout, totalWidth int) string {
+	left, right := " ", " "
+	if lay.left {
+		left = "‹"
+	}
+	if lay.right {
+		right = "›"
+	}
+	return lay.wrap(dt.renderHeaderColumns(lay, lay.inner(totalWidth)), left, right)
+}
+
+func (dt *DataTable) renderHeaderColumns(lay dtLayout, totalWidth int) string {
 	var sb strings.Builder
 	fgSeq := dtColor(dt.headerStyle.FgColor)
 	bgSeq := dtBgColor(dt.headerStyle.BgColor)
@@ -516,11 +689,9 @@ func (dt *DataTable) renderHeader(colWidths []int, totalWidth int) string {
 	prefix := bgSeq + fgSeq + boldSeq
 
 	usedWidth := 0
-	for i, col := range dt.columns {
-		if i >= len(colWidths) {
-			break
-		}
-		w := colWidths[i]
+	for i, c := range lay.cols {
+		col := dt.columns[c]
+		w := lay.widths[i]
 		if w <= 0 {
 			continue
 		}
@@ -546,10 +717,14 @@ func (dt *DataTable) renderHeader(colWidths []int, totalWidth int) string {
 	return dtTrimTrailingVisibleSpaces(result, totalWidth)
 }
 
-func (dt *DataTable) renderSeparator(colWidths []int, totalWidth int) string {
+func (dt *DataTable) renderSeparator(lay dtLayout, totalWidth int) string {
+	return lay.wrap(dt.renderSeparatorColumns(lay, lay.inner(totalWidth)), dt.headerSep, dt.headerSep)
+}
+
+func (dt *DataTable) renderSeparatorColumns(lay dtLayout, totalWidth int) string {
 	var sb strings.Builder
 	usedWidth := 0
-	for i, w := range colWidths {
+	for i, w := range lay.widths {
 		if w <= 0 {
 			continue
 		}
@@ -571,10 +746,7 @@ func (dt *DataTable) renderSeparator(colWidths []int, totalWidth int) string {
 	return line
 }
 
-func (dt *DataTable) renderRow(row Row, rowIndex int, colWidths []int, totalWidth int) string {
-	var sb strings.Builder
-	resetSeq := "\x1b[0m"
-
+func (dt *DataTable) renderRow(row Row, rowIndex int, lay dtLayout, totalWidth int) string {
 	// Determine background.
 	bgSeq := ""
 	if dt.selectable && dt.selectedIdx >= 0 && rowIndex == dt.selectedIdx {
@@ -585,12 +757,18 @@ func (dt *DataTable) renderRow(row Row, rowIndex int, colWidths []int, totalWidt
 		bgSeq = dtBgColor(dt.rowStyle.OddBgColor)
 	}
 
+	line := dt.renderRowColumns(row, bgSeq, lay, lay.inner(totalWidth))
+	return lay.wrap(line, bgSeq+" ", bgSeq+" \x1b[0m")
+}
+
+func (dt *DataTable) renderRowColumns(row Row, bgSeq string, lay dtLayout, totalWidth int) string {
+	var sb strings.Builder
+	resetSeq := "\x1b[0m"
+
 	usedWidth := 0
-	for i, col := range dt.columns {
-		if i >= len(colWidths) {
-			break
-		}
-		w := colWidths[i]
+	for i, c := range lay.cols {
+		col := dt.columns[c]
+		w := lay.widths[i]
 		if w <= 0 {
 			continue
 		}
@@ -600,8 +778,8 @@ func (dt *DataTable) renderRow(row Row, rowIndex int, colWidths []int, totalWidt
 			usedWidth++
 		}
 		cell := ""
-		if i < len(row.Cells) {
-			cell = row.Cells[i]
+		if c < len(row.Cells) {
+			cell = row.Cells[c]
 		}
 		cell = dtTruncateVisible(cell, w)
 		cell = dtPadVisible(cell, w, col.Align)
@@ -624,11 +802,108 @@ func (dt *DataTable) renderRow(row Row, rowIndex int, colWidths []int, totalWidt
 // Column width resolution (3-pass algorithm)
 // ---------------------------------------------------------------------------
 
+// layout chooses the columns shown at totalWidth. Optional columns are
+// hidden until the rest fit at their minimum widths; if the required
+// columns still do not fit, the view scrolls horizontally from colOffset.
+func (dt *DataTable) layout(totalWidth int) dtLayout {
+	shown := make([]int, len(dt.columns))
+	for i := range shown {
+		shown[i] = i
+	}
+
+	for !dt.fits(shown, totalWidth) {
+		drop := -1
+		for k, c := range shown {
+			p := dt.columns[c].Priority
+			if p > 0 && (drop < 0 || p <= dt.columns[shown[drop]].Priority) {
+				drop = k
+			}
+		}
+		if drop < 0 {
+			break
+		}
+		shown = append(shown[:drop], shown[drop+1:]...)
+	}
+
+	if dt.fits(shown, totalWidth) {
+		dt.colOffset = 0
+		return dtLayout{cols: shown, widths: dt.resolveWidthsFor(shown, totalWidth)}
+	}
+
+	// Scroll: reserve the indicator margins and show the run of columns
+	// starting at colOffset that fits, clamping the offset so the last
+	// column is reachable but no further.
+	inner := totalWidth - 2
+	if inner < 1 {
+		// No room for the margins: squeeze what is shown.
+		return dtLayout{cols: shown, widths: dt.resolveWidthsFor(shown, totalWidth)}
+	}
+	maxOffset := len(shown) - 1
+	for maxOffset > 0 && dt.fits(shown[maxOffset-1:], inner) {
+		maxOffset--
+	}
+	if dt.colOffset > maxOffset {
+		dt.colOffset = maxOffset
+	}
+	end := dt.colOffset + 1
+	for end < len(shown) && dt.fits(shown[dt.colOffset:end+1], inner) {
+		end++
+	}
+	cols := shown[dt.colOffset:end]
+	return dtLayout{
+		cols:     cols,
+		widths:   dt.resolveWidthsFor(cols, inner),
+		scrolled: true,
+		left:     dt.colOffset > 0,
+		right:    end < len(shown),
+	}
+}
+
+// fits reports whether the columns cols can all have their minimum widths
+// in totalWidth.
+func (dt *DataTable) fits(cols []int, totalWidth int) bool {
+	need := 0
+	if dt.showBorder && totalWidth >= 20 && len(cols) > 0 {
+		need = len(cols) - 1
+	}
+	for _, c := range cols {
+		need += dt.columns[c].minWidth()
+	}
+	return need <= totalWidth
+}
+
+// minWidth returns the narrowest width the column is shown at.
+func (c Column) minWidth() int {
+	switch {
+	case c.MinWidth > 0:
+		return c.MinWidth
+	case c.Sizing.Kind == sizingFixed && c.Sizing.Value > 0:
+		return c.Sizing.Value
+	default:
+		return 1
+	}
+}
+
+// resolveWidths returns the width of every column at totalWidth.
 func (dt *DataTable) resolveWidths(totalWidth int) []int {
-	n := len(dt.columns)
+	cols := make([]int, len(dt.columns))
+	for i := range cols {
+		cols[i] = i
+	}
+	return dt.resolveWidthsFor(cols, totalWidth)
+}
+
+// resolveWidthsFor returns the widths of the columns at indices cols when
+// only they are shown in totalWidth.
+func (dt *DataTable) resolveWidthsFor(cols []int, totalWidth int) []int {
+	n := len(cols)
 	if n == 0 {
 		return nil
 	}
+	columns := make([]Column, n)
+	for i, c := range cols {
+		columns[i] = dt.columns[c]
+	}
 
 	widths := make([]int, n)
 
@@ -644,7 +919,7 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 
 	// Pass 1: Fixed columns.
 	remaining := available
-	for i, col := range dt.columns {
+	for i, col := range columns {
 		if col.Sizing.Kind == sizingFixed {
 			w := col.Sizing.Value
 			if w > remaining {
@@ -656,7 +931,7 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 	}
 
 	// Pass 2: Percentage columns.
-	for i, col := range dt.columns {
+	for i, col := range columns {
 		if col.Sizing.Kind == sizingPercent {
 			w := (available * col.Sizing.Value) / 100
 			if w > remaining {
@@ -669,7 +944,7 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 
 	// Pass 3: Fill columns share remaining space equally.
 	fillCount := 0
-	for _, col := range dt.columns {
+	for _, col := range columns {
 		if col.Sizing.Kind == sizingFill {
 			fillCount++
 		}
@@ -678,7 +953,7 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 		each := remaining / fillCount
 		extra := remaining % fillCount
 		filled := 0
-		for i, col := range dt.columns {
+		for i, col := range columns {
 			if col.Sizing.Kind == sizingFill {
 				w := each
 				if filled < extra {
@@ -691,7 +966,7 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 	}
 
 	// Pass 4: Enforce MinWidth constraints.
-	for i, col := range dt.columns {
+	for i, col := range columns {
 		if col.MinWidth > 0 && widths[i] < col.MinWidth {
 			deficit := col.MinWidth - widths[i]
 			widths[i] = col.MinWidth
@@ -700,8 +975,8 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 				if j == i {
 					continue
 				}
-				if dt.columns[j].Sizing.Kind == sizingFill {
-					canSteal := widths[j] - dt.columns[j].MinWidth
+				if columns[j].Sizing.Kind == sizingFill {
+					canSteal := widths[j] - columns[j].MinWidth
 					if canSteal <= 0 {
 						continue
 					}
@@ -724,10 +999,10 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 	if totalUsed > available {
 		excess := totalUsed - available
 		for i := n - 1; i >= 0 && excess > 0; i-- {
-			if dt.columns[i].Sizing.Kind == sizingFill {
+			if columns[i].Sizing.Kind == sizingFill {
 				canCut := widths[i]
-				if dt.columns[i].MinWidth > 0 {
-					canCut = widths[i] - dt.columns[i].MinWidth
+				if columns[i].MinWidth > 0 {
+					canCut = widths[i] - columns[i].MinWidth
 				}
 				if canCut <= 0 {
 					continue
@@ -740,6 +1015,16 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 				excess -= cut
 			}
 		}
+		// Pass 6: Minimum widths alone overflow; cut from the right so
+		// lines never exceed the requested width.
+		for i := n - 1; i >= 0 && excess > 0; i-- {
+			cut := excess
+			if cut > widths[i] {
+				cut = widths[i]
+			}
+			widths[i] -= cut
+			excess -= cut
+		}
 	}
 
 	return widths
@@ -749,6 +1034,24 @@ func (dt *DataTable) resolveWidths(totalWidth int) []int {
 // Filter helper
 // ---------------------------------------------------------------------------
 
+// QueryFilter returns a filter for SetFilter that passes the rows with a
+// cell containing query, ignoring case and ANSI styling, or nil, passing
+// every row, for an empty query.
+func QueryFilter(query string) func(Row) bool {
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+	return func(r Row) bool {
+		for _, cell := range r.Cells {
+			if strings.Contains(strings.ToLower(ansi.Strip(cell)), query) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func (dt *DataTable) applyFilter(rows []Row) []Row {
 	if dt.filterFn == nil {
 		// Return a copy to avoid aliasing.
@@ -769,72 +1072,32 @@ func (dt *DataTable) applyFilter(rows []Row) []Row {
 }
 
 // ---------------------------------------------------------------------------
-// Private ANSI / string helpers (self-contained, no sibling imports)
+// Private ANSI / string helpers
 // ---------------------------------------------------------------------------
 
-// dtVisibleLen returns the number of visible characters in s, skipping ANSI
-// escape sequences. Each rune counts as 1 (no wide-char handling in the
-// self-contained version).
+// dtVisibleLen returns the width of s in terminal cells, skipping ANSI
+// escape sequences. Wide characters (CJK, emoji) count as 2.
 func dtVisibleLen(s string) int {
-	n := 0
-	inEsc := false
-	for _, r := range s {
-		if inEsc {
-			if r == 'm' {
-				inEsc = false
-			}
-			continue
-		}
-		if r == '\x1b' {
-			inEsc = true
-			continue
-		}
-		n++
-	}
-	return n
+	return ansi.StringWidth(s)
 }
 
 // dtTruncateVisible truncates s so its visible width is at most max. If
 // truncation occurs, "…" is appended (consuming 1 visible char). ANSI
-// sequences before the cut point are preserved.
+// sequences before the cut point are preserved. A wide character that
+// would straddle the cut is dropped and replaced by a space, so a truncated
+// result is always exactly max cells wide.
 func dtTruncateVisible(s string, max int) string {
 	if max <= 0 {
 		return ""
 	}
-	vis := dtVisibleLen(s)
-	if vis <= max {
+	if dtVisibleLen(s) <= max {
 		return s
 	}
-	// We need to keep (max-1) visible chars and append "…".
-	cutAt := max - 1
-	if cutAt < 0 {
-		cutAt = 0
+	t := ansi.Truncate(s, max, "…")
+	if pad := max - dtVisibleLen(t); pad > 0 {
+		t += strings.Repeat(" ", pad)
 	}
-
-	var sb strings.Builder
-	count := 0
-	inEsc := false
-	for _, r := range s {
-		if inEsc {
-			sb.WriteRune(r)
-			if r == 'm' {
-				inEsc = false
-			}
-			continue
-		}
-		if r == '\x1b' {
-			inEsc = true
-			sb.WriteRune(r)
-			continue
-		}
-		if count >= cutAt {
-			break
-		}
-		sb.WriteRune(r)
-		count++
-	}
-	sb.WriteString("…")
-	return sb.String()
+	return t
 }
 
 // dtPadVisible pads s with spaces to the given width according to align.
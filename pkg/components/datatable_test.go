@@ -448,6 +448,34 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestQueryFilter(t *testing.T) {
+	if QueryFilter("") != nil {
+		t.Error("QueryFilter(\"\") should be nil")
+	}
+
+	dt := NewDataTable(defaultCfg())
+	rows := append(sampleRows(), Row{ID: "4", Cells: []string{"\x1b[31mDana\x1b[0m", "41", "Lond\x1b[1mon"}})
+	dt.SetRows(rows)
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{"lond", []string{"2", "4"}}, // styling inside a cell is ignored
+		{"DANA", []string{"4"}},
+		{"3", []string{"1", "3"}},
+		{"[31m", nil}, // escape codes are not text
+	} {
+		dt.SetFilter(QueryFilter(tt.query))
+		var got []string
+		for _, r := range dt.filteredRows {
+			got = append(got, r.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("QueryFilter(%q) passed rows %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
 func TestFreezeUnfreeze(t *testing.T) {
 	cfg := defaultCfg()
 	dt := NewDataTable(cfg)
@@ -865,6 +893,9 @@ func TestDtTruncateVisible(t *testing.T) {
 		{"hello", 1, 1},        // "…"
 		{"hello", 0, 0},        // empty
 		{"\x1b[1mhello\x1b[0m", 3, 3}, // with ANSI
+		{"日本語", 4, 4},                 // "日…" plus a space for the dropped half
+		{"日本語", 6, 6},                 // fits exactly
+		{"a🎉b", 2, 2},                 // emoji straddles the cut
 	}
 	for _, tt := range tests {
 		got := dtTruncateVisible(tt.input, tt.max)
@@ -1067,3 +1098,197 @@ func TestLargeDatasetScroll(t *testing.T) {
 		t.Error("should show bottom indicator when scrolled to middle")
 	}
 }
+
+// assertBox checks that out has exactly height lines, each exactly width
+// cells wide.
+func assertBox(t *testing.T, out string, width, height int) {
+	t.Helper()
+	ls := lines(out)
+	if len(ls) != height {
+		t.Fatalf("got %d lines, want %d", len(ls), height)
+	}
+	for i, l := range ls {
+		if w := dtVisibleLen(l); w != width {
+			t.Errorf("line %d is %d cells wide, want %d: %q", i, w, width, stripANSI(l))
+		}
+	}
+}
+
+func priorityCfg() DataTableConfig {
+	return DataTableConfig{
+		Columns: []Column{
+			{Title: "Pod", Sizing: SizingFill(), MinWidth: 10},
+			{Title: "Status", Sizing: SizingFixed(8)},
+			{Title: "Restarts", Sizing: SizingFixed(8), Priority: 1},
+			{Title: "Node", Sizing: SizingFixed(12), Priority: 2},
+			{Title: "Age", Sizing: SizingFixed(5), Priority: 1},
+		},
+		ShowHeader: true,
+		ShowBorder: true,
+	}
+}
+
+func TestPriorityHidesLowestFirst(t *testing.T) {
+	dt := NewDataTable(priorityCfg())
+	dt.SetRows([]Row{{Cells: []string{"api-7d9f", "Running", "3", "worker-1", "2d"}}})
+
+	tests := []struct {
+		width int
+		want  []string
+		gone  []string
+	}{
+		// All five: 10+8+8+12+5 + 4 separators = 47.
+		{47, []string{"Pod", "Status", "Restarts", "Node", "Age"}, nil},
+		// Priority 1 ties go from the right: Age first, then Restarts.
+		{46, []string{"Pod", "Status", "Restarts", "Node"}, []string{"Age"}},
+		{40, []string{"Pod", "Status", "Node"}, []string{"Restarts", "Age"}},
+		{25, []string{"Pod", "Status"}, []string{"Restarts", "Node", "Age"}},
+	}
+	for _, tt := range tests {
+		out := dt.Render(tt.width, 4)
+		assertBox(t, out, tt.width, 4)
+		header := stripANSI(lines(out)[0])
+		for _, title := range tt.want {
+			if !strings.Contains(header, title) {
+				t.Errorf("width %d: header %q missing %s", tt.width, header, title)
+			}
+		}
+		for _, title := range tt.gone {
+			if strings.Contains(header, title) {
+				t.Errorf("width %d: header %q shows hidden column %s", tt.width, header, title)
+			}
+		}
+		if strings.ContainsAny(header, "‹›") {
+			t.Errorf("width %d: header %q shows scroll indicators", tt.width, header)
+		}
+	}
+}
+
+func TestHorizontalScroll(t *testing.T) {
+	cfg := DataTableConfig{
+		Columns: []Column{
+			{Title: "Alpha", Sizing: SizingFixed(10)},
+			{Title: "Bravo", Sizing: SizingFixed(10)},
+			{Title: "Charlie", Sizing: SizingFixed(10)},
+			{Title: "Delta", Sizing: SizingFixed(10)},
+		},
+		ShowHeader: true,
+		ShowBorder: true,
+	}
+	dt := NewDataTable(cfg)
+	dt.SetRows([]Row{{Cells: []string{"a1", "b1", "c1", "d1"}}})
+
+	// 24 wide leaves 22 between the margins: two columns and a separator.
+	header := func() string {
+		out := dt.Render(24, 4)
+		assertBox(t, out, 24, 4)
+		return stripANSI(lines(out)[0])
+	}
+	if h := header(); !strings.HasPrefix(h, " Alpha") || !strings.HasSuffix(h, "›") || !strings.Contains(h, "Bravo") {
+		t.Errorf("initial header = %q", h)
+	}
+
+	dt.ScrollRight(1)
+	if h := header(); !strings.HasPrefix(h, "‹Bravo") || !strings.HasSuffix(h, "›") || strings.Contains(h, "Alpha") {
+		t.Errorf("header after ScrollRight(1) = %q", h)
+	}
+
+	// Scrolling past the end stops with the last column in view.
+	dt.ScrollRight(10)
+	if h := header(); !strings.HasPrefix(h, "‹Charlie") || !strings.Contains(h, "Delta") || strings.HasSuffix(h, "›") {
+		t.Errorf("header after ScrollRight(10) = %q", h)
+	}
+	if !containsVisible(dt.Render(24, 4), "d1") {
+		t.Error("last column's cell not shown after scrolling right")
+	}
+
+	dt.ScrollLeft(10)
+	if h := header(); !strings.HasPrefix(h, " Alpha") {
+		t.Errorf("header after ScrollLeft(10) = %q", h)
+	}
+
+	// Wide enough for everything: no margins, and the offset resets.
+	dt.ScrollRight(2)
+	if h := stripANSI(lines(dt.Render(43, 4))[0]); strings.ContainsAny(h, "‹›") || !strings.HasPrefix(h, "Alpha") {
+		t.Errorf("header at full width = %q", h)
+	}
+}
+
+func TestRenderNeverExceedsWidthWithWideCells(t *testing.T) {
+	cfg := priorityCfg()
+	cfg.Selectable = true
+	dt := NewDataTable(cfg)
+	dt.SetRows([]Row{
+		{Cells: []string{"日本語のポッド名前", "実行中", "🔁🔁🔁", "ノード一二三四五", "二日"}},
+		{Cells: []string{"emoji-🚀🚀🚀🚀🚀🚀", "OK", "0", "n", "1d"}},
+		{Cells: []string{"\x1b[31m赤い文字列です\x1b[0m", "Failed", "12", "worker", "3h"}},
+	})
+	dt.SelectNext()
+	for _, width := range []int{1, 2, 3, 7, 11, 19, 20, 21, 33, 47, 80} {
+		for _, height := range []int{1, 2, 3, 5, 8} {
+			assertBox(t, dt.Render(width, height), width, height)
+		}
+	}
+}
+
+func TestScrollbarGutter(t *testing.T) {
+	cfg := defaultCfg()
+	cfg.Scrollbar = true
+	dt := NewDataTable(cfg)
+	dt.SetRows(sampleRows())
+
+	// Everything fits: no gutter.
+	out := dt.Render(30, 6)
+	assertBox(t, out, 30, 6)
+	if containsVisible(out, "█") || dt.OnScrollbar(29, 2) || dt.ScrollbarRow(2) != -1 {
+		t.Errorf("scrollbar drawn for a table that fits:\n%s", stripANSI(out))
+	}
+
+	rows := make([]Row, 20)
+	for i := range rows {
+		rows[i] = Row{Cells: []string{fmt.Sprintf("Row%d", i), "0", "X"}}
+	}
+	dt.SetRows(rows)
+	out = dt.Render(30, 12) // header(2) + a 10-line track
+	assertBox(t, out, 30, 12)
+	ls := lines(stripANSI(out))
+	if !strings.HasSuffix(ls[2], "█") || !strings.HasSuffix(ls[11], "│") {
+		t.Errorf("thumb should start at the top of the track:\n%s", strings.Join(ls, "\n"))
+	}
+
+	dt.ScrollToBottom()
+	ls = lines(stripANSI(dt.Render(30, 12)))
+	if !strings.HasSuffix(ls[11], "█") || !strings.HasSuffix(ls[2], "│") {
+		t.Errorf("thumb should end at the bottom of the track:\n%s", strings.Join(ls, "\n"))
+	}
+
+	if !dt.OnScrollbar(29, 2) || dt.OnScrollbar(28, 2) || dt.OnScrollbar(29, 1) {
+		t.Error("OnScrollbar should hit only the gutter beside the rows")
+	}
+	for y, want := range map[int]int{2: 0, 11: 19, 0: 0, 40: 19} {
+		if got := dt.ScrollbarRow(y); got != want {
+			t.Errorf("ScrollbarRow(%d) = %d, want %d", y, got, want)
+		}
+	}
+}
+
+func TestRowAt(t *testing.T) {
+	dt := NewDataTable(defaultCfg())
+	rows := make([]Row, 20)
+	for i := range rows {
+		rows[i] = Row{Cells: []string{fmt.Sprintf("Row%d", i), "0", "X"}}
+	}
+	dt.SetRows(rows)
+	dt.ScrollDown(5)
+
+	// Header, separator, "▲ 5 more", then rows 5-7, then "▼ 12 more".
+	out := lines(stripANSI(dt.Render(40, 7)))
+	for y, want := range map[int]int{0: -1, 1: -1, 2: -1, 3: 5, 5: 7, 6: -1} {
+		if got := dt.RowAt(y); got != want {
+			t.Errorf("RowAt(%d) = %d, want %d", y, got, want)
+		}
+		if want >= 0 && !strings.HasPrefix(out[y], fmt.Sprintf("Row%d", want)) {
+			t.Errorf("line %d = %q, want Row%d", y, out[y], want)
+		}
+	}
+}
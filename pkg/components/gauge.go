@@ -5,6 +5,8 @@ import (
 	"math"
 	"strconv"
 	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // Block characters for sub-cell precision (8 levels per cell).
@@ -116,7 +118,7 @@ func (g *Gauge) Render(value, maxValue float64, width int) string {
 	if g.style.Label != "" {
 		labelW := g.style.LabelWidth
 		if labelW <= 0 {
-			labelW = len(g.style.Label) + 1
+			labelW = VisibleLen(g.style.Label) + 1
 		}
 		padded := gaugePadRight(g.style.Label, labelW)
 		b.WriteString(padded)
@@ -147,8 +149,8 @@ func (g *Gauge) RenderMulti(gauges []GaugeData, width int) string {
 	// Find the maximum label width for alignment.
 	maxLabelLen := 0
 	for _, gd := range gauges {
-		if len(gd.Label) > maxLabelLen {
-			maxLabelLen = len(gd.Label)
+		if w := VisibleLen(gd.Label); w > maxLabelLen {
+			maxLabelLen = w
 		}
 	}
 
@@ -165,7 +167,14 @@ func (g *Gauge) RenderMulti(gauges []GaugeData, width int) string {
 }
 
 // gaugeRenderBar builds the ANSI-colored bar string with sub-cell precision.
+// When render.Current disallows Unicode it draws "#" for filled cells and
+// "-" for empty ones instead.
 func gaugeRenderBar(ratio float64, width int, fillColor, emptyColor string) string {
+	if !render.Current.Unicode {
+		filled := int(math.Round(ratio * float64(width)))
+		return strings.Repeat("#", filled) + strings.Repeat("-", width-filled)
+	}
+
 	// Total sub-cell units available.
 	totalUnits := width * 8
 	filledUnits := int(math.Round(ratio * float64(totalUnits)))
@@ -258,12 +267,10 @@ func gaugeParseHexColor(hex string) (r, g, b uint8, ok bool) {
 	return uint8(rv), uint8(gv), uint8(bv), true
 }
 
-// gaugePadRight pads s to the given width with spaces on the right.
+// gaugePadRight pads s to the given width in terminal cells with spaces on
+// the right.
 func gaugePadRight(s string, width int) string {
-	if len(s) >= width {
-		return s
-	}
-	return s + strings.Repeat(" ", width-len(s))
+	return PadRight(s, width)
 }
 
 // gaugeStripANSI removes ANSI escape sequences for visible-width calculations.
@@ -286,8 +293,8 @@ func gaugeStripANSI(s string) string {
 	return b.String()
 }
 
-// gaugeVisibleWidth returns the visible width of a string, ignoring ANSI escapes.
+// gaugeVisibleWidth returns the visible width of a string in terminal
+// cells, ignoring ANSI escapes.
 func gaugeVisibleWidth(s string) int {
-	stripped := gaugeStripANSI(s)
-	return len([]rune(stripped))
+	return VisibleLen(s)
 }
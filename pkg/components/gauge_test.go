@@ -3,6 +3,8 @@ package components
 import (
 	"strings"
 	"testing"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // gaugeTestStrip removes ANSI escapes for asserting visible content.
@@ -65,6 +67,18 @@ func TestGaugeFiftyPercent(t *testing.T) {
 	}
 }
 
+func TestGaugePlainProfile(t *testing.T) {
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	defer render.SetCurrent(orig)
+
+	g := NewGauge(DefaultGaugeStyle())
+	g.style.ShowPercent = true
+	if got, want := g.Render(50, 100, 10), "#####----- 50%"; !strings.HasPrefix(got, want) {
+		t.Errorf("plain gauge = %q, want prefix %q", got, want)
+	}
+}
+
 func TestGaugeSubCellPrecision(t *testing.T) {
 	// 12.5% of width=8 = 1 cell exactly (8 sub-units), but
 	// 12.5% of width=10 = 10 sub-units = 1 full + 1/4 block.
@@ -364,3 +378,23 @@ func TestGaugeParseHexColor(t *testing.T) {
 		}
 	}
 }
+
+func TestGaugeRenderMultiAlignsWideLabels(t *testing.T) {
+	g := NewGauge(DefaultGaugeStyle())
+	g.style.ShowPercent = false
+	gauges := []GaugeData{
+		{Label: "CPU", Value: 50, MaxValue: 100},
+		{Label: "メモリ", Value: 50, MaxValue: 100},
+		{Label: "🔋", Value: 50, MaxValue: 100},
+	}
+	lines := strings.Split(g.RenderMulti(gauges, 10), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	// "メモリ" is 6 cells wide, so every label area is 7 cells.
+	for i, line := range lines {
+		if w := VisibleLen(line); w != 17 {
+			t.Errorf("line %d is %d cells wide, want 17: %q", i, w, gaugeTestStrip(line))
+		}
+	}
+}
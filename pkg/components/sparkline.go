@@ -5,6 +5,8 @@ import (
 	"math"
 	"strconv"
 	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // Sparkline block characters: 8 vertical levels per cell.
@@ -19,6 +21,9 @@ var sparkBlocks = [8]rune{
 	'\u2588', // 8/8 █
 }
 
+// sparkASCII replaces sparkBlocks when render.Current disallows Unicode.
+var sparkASCII = [8]rune{'_', '.', '-', ':', '=', '+', '*', '#'}
+
 // SparklineStyle configures the appearance of a sparkline.
 type SparklineStyle struct {
 	Width      int      // number of cells to display
@@ -165,6 +170,10 @@ func sparkAutoRange(data []float64) (minY, maxY float64) {
 func sparkMapToBlocks(data []float64, minY, maxY float64) string {
 	var b strings.Builder
 	rangeY := maxY - minY
+	blocks := sparkBlocks
+	if !render.Current.Unicode {
+		blocks = sparkASCII
+	}
 
 	for _, v := range data {
 		var idx int
@@ -185,7 +194,7 @@ func sparkMapToBlocks(data []float64, minY, maxY float64) string {
 				idx = 7
 			}
 		}
-		b.WriteRune(sparkBlocks[idx])
+		b.WriteRune(blocks[idx])
 	}
 
 	return b.String()
@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // Color produces an ANSI true-color (24-bit) foreground escape sequence from
 // a hex color string like "#ff5500" or "ff5500". Returns an empty string if
-// the input is empty or malformed.
+// the input is empty or malformed, or when render.Current disallows color.
 func Color(hex string) string {
 	r, g, b, ok := parseHex(hex)
-	if !ok {
+	if !ok || !render.Current.Color {
 		return ""
 	}
 	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b)
@@ -21,29 +23,43 @@ func Color(hex string) string {
 // from a hex color string like "#ff5500" or "ff5500".
 func BgColor(hex string) string {
 	r, g, b, ok := parseHex(hex)
-	if !ok {
+	if !ok || !render.Current.Color {
 		return ""
 	}
 	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, g, b)
 }
 
-// Bold wraps s in ANSI bold escape sequences.
+// Bold wraps s in ANSI bold escape sequences. Like the other attribute
+// helpers it returns s unchanged when render.Current disallows color.
 func Bold(s string) string {
+	if !render.Current.Color {
+		return s
+	}
 	return "\x1b[1m" + s + "\x1b[22m"
 }
 
 // Dim wraps s in ANSI dim/faint escape sequences.
 func Dim(s string) string {
+	if !render.Current.Color {
+		return s
+	}
 	return "\x1b[2m" + s + "\x1b[22m"
 }
 
 // Italic wraps s in ANSI italic escape sequences.
 func Italic(s string) string {
+	if !render.Current.Color {
+		return s
+	}
 	return "\x1b[3m" + s + "\x1b[23m"
 }
 
-// Reset returns the ANSI reset sequence that clears all styling.
+// Reset returns the ANSI reset sequence that clears all styling, or ""
+// when render.Current disallows color.
 func Reset() string {
+	if !render.Current.Color {
+		return ""
+	}
 	return "\x1b[0m"
 }
 
@@ -544,12 +544,13 @@ func tooSmallMsg(width int) string {
 	return msg
 }
 
-// padLeft right-aligns s within a field of the given width using spaces.
+// padLeft right-aligns s within a field of the given width in terminal
+// cells, truncating it if it is wider.
 func padLeft(s string, width int) string {
-	if len(s) >= width {
-		return s[:width]
+	if VisibleLen(s) > width {
+		return PadRight(Truncate(s, width), width)
 	}
-	return strings.Repeat(" ", width-len(s)) + s
+	return PadLeft(s, width)
 }
 
 // trimRight removes trailing whitespace from a string.
@@ -755,6 +755,9 @@ func TestPadLeft(t *testing.T) {
 		{"1.5K", 6, "  1.5K"},
 		{"toolong", 4, "tool"},
 		{"abc", 3, "abc"},
+		{"µs", 4, "  µs"},
+		{"日本", 5, " 日本"},
+		{"日本", 3, "日 "},
 	}
 	for _, tt := range tests {
 		got := padLeft(tt.s, tt.width)
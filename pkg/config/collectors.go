@@ -0,0 +1,144 @@
+package config
+
+import "sort"
+
+// Reasons a collector is enabled or disabled, as reported by
+// CollectorReason.
+const (
+	// ReasonExplicit means the file sets the collector's enabled flag.
+	ReasonExplicit = "explicit config"
+
+	// ReasonCredentials and ReasonNoCredentials mean the file leaves the
+	// flag unset and the collector, which needs credentials to report
+	// anything, was enabled or disabled by whether they were found.
+	ReasonCredentials   = "credentials found"
+	ReasonNoCredentials = "missing credentials"
+
+	// ReasonProfile means the flag is unset and the host profile lists,
+	// or leaves out, the collector among its features.
+	ReasonProfile = "host profile"
+
+	// ReasonDefault means the flag is unset and keeps its default.
+	ReasonDefault = "default"
+)
+
+// CollectorReason reports why the collector configured under
+// [collectors.<key>] is enabled or disabled: one of the Reason constants,
+// with ReasonNoCredentials appended when the file enables a collector
+// that has none. A Config not read by a Load function reports
+// ReasonDefault.
+func (c *Config) CollectorReason(key string) string {
+	if r, ok := c.collectorReasons[key]; ok {
+		return r
+	}
+	return ReasonDefault
+}
+
+// resolveCollectors settles whether each collector is enabled and records
+// why. A flag set in the file is kept. With a host profile, profile holds
+// the collectors it enables and every other flag left unset follows it.
+// Otherwise a collector that needs credentials and leaves its flag unset
+// is enabled when they are found; the rest keep their defaults. defined
+// reports whether a key is set in the file.
+func resolveCollectors(cfg *Config, defined func(key ...string) bool, profile map[string]bool) {
+	c := &cfg.Collectors
+	flags := collectorFlags(c)
+	credentials := map[string]bool{
+		"claude":     claudeHasCredentials(c.Claude),
+		"billing":    billingHasCredentials(c.Billing),
+		"uptimekuma": c.UptimeKuma.URL != "",
+	}
+
+	cfg.collectorReasons = make(map[string]string, len(flags))
+	for key, enabled := range flags {
+		found, needed := credentials[key]
+		switch {
+		case defined("collectors", key, "enabled"):
+			reason := ReasonExplicit
+			if *enabled && needed && !found {
+				reason += ", " + ReasonNoCredentials
+			}
+			cfg.collectorReasons[key] = reason
+		case profile != nil:
+			*enabled = profile[key]
+			reason := ReasonProfile
+			if *enabled && needed && !found {
+				reason += ", " + ReasonNoCredentials
+			}
+			cfg.collectorReasons[key] = reason
+		case needed:
+			*enabled = found
+			cfg.collectorReasons[key] = ReasonNoCredentials
+			if found {
+				cfg.collectorReasons[key] = ReasonCredentials
+			}
+		default:
+			cfg.collectorReasons[key] = ReasonDefault
+		}
+	}
+}
+
+// collectorFlags returns the enabled flag of each collector, keyed by its
+// [collectors] table.
+func collectorFlags(c *CollectorsConfig) map[string]*bool {
+	return map[string]*bool{
+		"sysmetrics": &c.SysMetrics.Enabled,
+		"gpu":        &c.GPU.Enabled,
+		"storage":    &c.Storage.Enabled,
+		"tailscale":  &c.Tailscale.Enabled,
+		"kubernetes": &c.Kubernetes.Enabled,
+		"claude":     &c.Claude.Enabled,
+		"ollama":     &c.Ollama.Enabled,
+		"billing":    &c.Billing.Enabled,
+		"uptimekuma": &c.UptimeKuma.Enabled,
+		"docker":     &c.Docker.Enabled,
+		"weather":    &c.Weather.Enabled,
+		"checks":     &c.Checks.Enabled,
+		"remote":     &c.Remote.Enabled,
+		"shell":      &c.Shell.Enabled,
+	}
+}
+
+// EnabledCollectors returns the [collectors] tables of the enabled
+// collectors, sorted.
+func (c *Config) EnabledCollectors() []string {
+	var keys []string
+	for key, enabled := range collectorFlags(&c.Collectors) {
+		if *enabled {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// claudeHasCredentials reports whether any Claude account has an admin
+// key, of its own or the shared one, a credentials file, or a sessions
+// directory.
+func claudeHasCredentials(c ClaudeCollectorConfig) bool {
+	for _, a := range c.Accounts {
+		if a.AdminKey != "" || c.AdminKey != "" || a.Credentials != "" || a.SessionsDir != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// billingHasCredentials reports whether any enabled billing provider has
+// an API key.
+func billingHasCredentials(b BillingCollectorConfig) bool {
+	for _, p := range []struct {
+		enabled bool
+		key     string
+	}{
+		{b.Civo.Enabled, b.Civo.APIKey},
+		{b.DigitalOcean.Enabled, b.DigitalOcean.APIKey},
+		{b.Hetzner.Enabled, b.Hetzner.APIKey},
+		{b.Vultr.Enabled, b.Vultr.APIKey},
+	} {
+		if p.enabled && p.key != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -5,23 +5,190 @@ type Config struct {
 	// General settings
 	General GeneralConfig `toml:"general"`
 
+	// Daemon log output
+	Log LogConfig `toml:"log"`
+
 	// Dashboard layout
 	Layout LayoutConfig `toml:"layout"`
 
 	// Data collectors
 	Collectors CollectorsConfig `toml:"collectors"`
 
+	// HTTP client shared by collectors
+	HTTP HTTPConfig `toml:"http"`
+
+	// Cache encryption
+	Cache CacheConfig `toml:"cache"`
+
 	// Image/waifu settings
 	Image ImageConfig `toml:"image"`
 
 	// Theme
 	Theme ThemeConfig `toml:"theme"`
 
+	// Output compatibility
+	Display DisplayConfig `toml:"display"`
+
 	// Shell integration
 	Shell ShellConfig `toml:"shell"`
 
+	// Starship module
+	Starship StarshipConfig `toml:"starship"`
+
 	// Banner mode settings
 	Banner BannerConfig `toml:"banner"`
+
+	// Fullscreen TUI settings
+	TUI TUIConfig `toml:"tui"`
+
+	// Daemon notifications
+	Notifications NotificationsConfig `toml:"notifications"`
+
+	// Static HTML status page
+	StatusPage StatusPageConfig `toml:"status_page"`
+
+	// Change log of collector data
+	Events EventsConfig `toml:"events"`
+
+	// Sanity checks on collector results
+	Validation ValidationConfig `toml:"validation"`
+
+	// collectorReasons records why each collector is enabled, keyed by
+	// its [collectors] table; see CollectorReason.
+	collectorReasons map[string]string
+
+	// profile is the host profile the configuration was resolved with;
+	// see Profile.
+	profile *ProfileInfo
+}
+
+// TUIConfig holds fullscreen TUI settings.
+type TUIConfig struct {
+	// Mouse enables mouse input: clicking panes and table rows, the
+	// scroll wheel, and dragging table scrollbars. Turn it off for
+	// terminals that mishandle mouse reporting.
+	Mouse bool `toml:"mouse"`
+
+	// Keys maps each action (see TUIKeyActions) to the keys that trigger
+	// it. Actions not listed keep their default bindings.
+	Keys map[string][]string `toml:"keys"`
+}
+
+// StatusPageConfig controls the static HTML status page written by
+// "prompt-pulse -render-html" and, optionally, by the daemon.
+type StatusPageConfig struct {
+	// Path is the file the page is written to. -render-html writes to
+	// stdout when it is empty and -output is not given.
+	Path string `toml:"path"`
+
+	// Template is an html/template file used in place of the built-in
+	// page. It is executed with statuspage.Page.
+	Template string `toml:"template"`
+
+	// Title heads the page.
+	Title string `toml:"title"`
+
+	// Interval is how often the daemon rewrites the page at Path. Zero
+	// leaves it to -render-html.
+	Interval Duration `toml:"interval"`
+}
+
+// EventsConfig controls the log of changes the daemon finds between
+// successive collector results, shown in the TUI's Events pane.
+type EventsConfig struct {
+	// Enabled turns on diffing and the event log.
+	Enabled bool `toml:"enabled"`
+
+	// MaxEntries bounds the log; the oldest events are dropped first.
+	MaxEntries int `toml:"max_entries"`
+
+	// BillingMinDelta is the smallest change in a provider's
+	// month-to-date spend that is logged, in the report currency.
+	BillingMinDelta float64 `toml:"billing_min_delta"`
+
+	// ClaudeMinDelta is the smallest change in a Claude account's monthly
+	// cost that is logged, in USD.
+	ClaudeMinDelta float64 `toml:"claude_min_delta"`
+
+	// PodJitter is how far a cluster's running pod count may move without
+	// being logged.
+	PodJitter int `toml:"pod_jitter"`
+}
+
+// ValidationConfig controls the sanity checks the daemon runs on each
+// collector result before caching it. A rejected result leaves the cached
+// data in place, marks the collector degraded, and is written to
+// rejected.json in the state directory.
+type ValidationConfig struct {
+	// Enabled turns the checks on.
+	Enabled bool `toml:"enabled"`
+
+	// BillingMaxFactor is how many times larger or smaller than the last
+	// accepted value a provider's month-to-date spend may be within one
+	// billing period.
+	BillingMaxFactor float64 `toml:"billing_max_factor"`
+
+	// ClaudeWindow is how long after the last accepted report a Claude
+	// account's monthly token counts must not go down.
+	ClaudeWindow Duration `toml:"claude_window"`
+
+	// K8sAllowZeroNodes accepts a reachable cluster reporting no nodes
+	// after it had some.
+	K8sAllowZeroNodes bool `toml:"k8s_allow_zero_nodes"`
+}
+
+// NotificationsConfig holds the daemon's notification rules and the sinks
+// they deliver to. Notifications are off unless at least one rule is set.
+type NotificationsConfig struct {
+	// Sinks are the delivery targets, one [[notifications.sink]] table
+	// each.
+	Sinks []NotificationSinkConfig `toml:"sink"`
+
+	// Rules are the events to notify about, one [[notifications.rule]]
+	// table each.
+	Rules []NotificationRuleConfig `toml:"rule"`
+}
+
+// NotificationSinkConfig defines one notification sink.
+type NotificationSinkConfig struct {
+	// Name labels the sink for rules. Names must be unique.
+	Name string `toml:"name"`
+
+	// Type is "desktop" (notify-send or osascript), "webhook" (a JSON
+	// POST to URL), or "command" (Command run with the event JSON on
+	// stdin).
+	Type string `toml:"type"`
+
+	// URL is the webhook endpoint.
+	URL string `toml:"url"`
+
+	// Command is the shell command for the command sink.
+	Command string `toml:"command"`
+
+	// Timeout bounds one delivery. Zero uses 10 seconds.
+	Timeout Duration `toml:"timeout"`
+}
+
+// NotificationRuleConfig defines one notification rule.
+type NotificationRuleConfig struct {
+	// Name labels the rule in notifications. Names must be unique.
+	Name string `toml:"name"`
+
+	// Event is "billing_budget", "billing_anomaly", "claude_window",
+	// "check_down", or "k8s_critical".
+	Event string `toml:"event"`
+
+	// Threshold is the percentage that triggers billing_budget (default
+	// 100, of the budget) and claude_window (default 80, of the usage
+	// window). Other events ignore it.
+	Threshold float64 `toml:"threshold"`
+
+	// Cooldown is the minimum time between two notifications of the rule
+	// for the same subject, even if it recovers and triggers again.
+	Cooldown Duration `toml:"cooldown"`
+
+	// Sinks names the sinks to deliver to. Empty uses every sink.
+	Sinks []string `toml:"sinks"`
 }
 
 // GeneralConfig holds daemon-level general settings.
@@ -35,8 +202,76 @@ type GeneralConfig struct {
 	// LogLevel for daemon logging.
 	LogLevel string `toml:"log_level"`
 
-	// CacheDir overrides the default cache directory.
+	// CacheDir overrides the default cache directory, which holds
+	// collector data and other files that can be rebuilt.
 	CacheDir string `toml:"cache_dir"`
+
+	// StateDir overrides the default state directory, which holds what
+	// the daemon cannot rebuild or must find at a fixed place: the
+	// instance lock, control socket, event log, and metric and spend
+	// history.
+	StateDir string `toml:"state_dir"`
+
+	// HostProfile names the deploy host profile whose features are the
+	// default set of enabled collectors and display options. Empty uses
+	// the profile matching the hostname, if any; "none" uses none.
+	HostProfile string `toml:"host_profile"`
+
+	// TUIRefreshInterval is how often the TUI reloads cached collector data.
+	TUIRefreshInterval Duration `toml:"tui_refresh_interval"`
+
+	// CollectTimeout bounds a single collector run in the daemon. A
+	// collector still running at the deadline is abandoned and reported
+	// as timed out.
+	CollectTimeout Duration `toml:"collect_timeout"`
+
+	// ShutdownGrace is how long a stopping daemon waits for collector runs
+	// in flight to finish and write their data before cancelling them.
+	// Zero cancels them at once.
+	ShutdownGrace Duration `toml:"shutdown_grace"`
+
+	// StaleAfterPolls marks cached data as old in the banner, prompt, and
+	// TUI once it is this many daemon poll intervals old. Zero disables
+	// the marker.
+	StaleAfterPolls float64 `toml:"stale_after_polls"`
+
+	// ExpireAfter is the age past which cached data is not shown at all,
+	// rather than showing ancient values. Zero shows data of any age.
+	ExpireAfter Duration `toml:"expire_after"`
+}
+
+// CacheConfig holds at-rest encryption settings for cached collector data.
+type CacheConfig struct {
+	// Encrypt lists the cache keys whose entries are encrypted with
+	// AES-GCM, e.g. ["claude", "billing"].
+	Encrypt []string `toml:"encrypt"`
+
+	// KeyFile is a file holding the encryption secret, such as a sops-nix
+	// secret. Empty keeps a generated secret in the user's keyring (macOS
+	// Keychain or the Secret Service).
+	KeyFile string `toml:"key_file"`
+}
+
+// HTTPConfig tunes the HTTP client collectors share: how failed requests
+// are retried and how fast each API host may be called.
+type HTTPConfig struct {
+	// MaxRetries is how many times a request failing with a network
+	// error, 429, or 5xx status is retried. Only idempotent requests are
+	// retried. Zero disables retrying.
+	MaxRetries int `toml:"max_retries"`
+
+	// RetryBaseDelay is the delay before the first retry, doubled for
+	// each one after it and jittered.
+	RetryBaseDelay Duration `toml:"retry_base_delay"`
+
+	// MaxRetryDelay caps the delay between attempts. A Retry-After asking
+	// for longer fails the request instead of waiting.
+	MaxRetryDelay Duration `toml:"max_retry_delay"`
+
+	// RateLimits maps an API host name to the requests per second allowed
+	// to it, e.g. {"api.github.com" = 1}. Hosts not listed are not
+	// limited.
+	RateLimits map[string]float64 `toml:"rate_limits"`
 }
 
 // LayoutConfig defines the dashboard layout via presets or custom rows.
@@ -60,7 +295,8 @@ type RowConfig struct {
 
 // ChildConfig defines a widget or sub-container in a layout row.
 type ChildConfig struct {
-	// Type is the widget type: "waifu", "claude", "billing", "tailscale", "k8s", "sysmetrics"
+	// Type is the widget type: "waifu", "claude", "billing", "tailscale",
+	// "uptimekuma", "docker", "k8s", "sysmetrics"
 	Type string `toml:"type"`
 
 	// Ratio is the proportional width weight for this child (default: 1).
@@ -73,22 +309,92 @@ type ChildConfig struct {
 // CollectorsConfig holds settings for all data collectors.
 type CollectorsConfig struct {
 	SysMetrics SysMetricsCollectorConfig `toml:"sysmetrics"`
+	GPU        GPUCollectorConfig        `toml:"gpu"`
+	Storage    StorageCollectorConfig    `toml:"storage"`
 	Tailscale  TailscaleCollectorConfig  `toml:"tailscale"`
 	Kubernetes K8sCollectorConfig        `toml:"kubernetes"`
 	Claude     ClaudeCollectorConfig     `toml:"claude"`
+	Ollama     OllamaCollectorConfig     `toml:"ollama"`
 	Billing    BillingCollectorConfig    `toml:"billing"`
+	UptimeKuma UptimeKumaCollectorConfig `toml:"uptimekuma"`
+	Docker     DockerCollectorConfig     `toml:"docker"`
+	Weather    WeatherCollectorConfig    `toml:"weather"`
+	Checks     ChecksCollectorConfig     `toml:"checks"`
+	Remote     RemoteCollectorConfig     `toml:"remote"`
+	Shell      ShellCollectorConfig      `toml:"shell"`
 }
 
 // SysMetricsCollectorConfig controls system metrics collection.
 type SysMetricsCollectorConfig struct {
 	Enabled  bool     `toml:"enabled"`
 	Interval Duration `toml:"interval"`
+
+	// HistoryRetention is how far back the daemon keeps CPU, memory,
+	// load, and network samples for the TUI's graphs; zero keeps none.
+	// HistoryInterval is the least time between samples, which bounds
+	// the history's size when the collector runs often.
+	HistoryRetention Duration `toml:"history_retention"`
+	HistoryInterval  Duration `toml:"history_interval"`
+}
+
+// GPUCollectorConfig controls GPU metrics collection.
+type GPUCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// NvidiaSMI is the nvidia-smi binary used on Linux. When empty,
+	// nvidia-smi is looked up in PATH.
+	NvidiaSMI string `toml:"nvidia_smi"`
+}
+
+// StorageCollectorConfig controls disk usage and SMART health collection.
+type StorageCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// IgnoreFSTypes lists filesystem types to skip. Empty skips the
+	// built-in list of pseudo filesystems (proc, sysfs, tmpfs, overlay,
+	// squashfs, and the like).
+	IgnoreFSTypes []string `toml:"ignore_fstypes"`
+
+	// IgnoreMounts lists mountpoint glob patterns to skip, such as
+	// "/snap/*". A pattern matching a directory also skips the mounts
+	// beneath it.
+	IgnoreMounts []string `toml:"ignore_mounts"`
+
+	// MountTimeout bounds the stat of one mountpoint, so a hung network
+	// mount is reported as "timeout" instead of stalling collection.
+	MountTimeout Duration `toml:"mount_timeout"`
+
+	// WarnPercent and CriticalPercent are the used-space percentages at
+	// which a mount becomes "warn" and "critical".
+	WarnPercent     float64 `toml:"warn_percent"`
+	CriticalPercent float64 `toml:"critical_percent"`
+
+	// SMART reads drive health with smartctl when it is installed. It
+	// usually needs root to open the drives.
+	SMART bool `toml:"smart"`
+
+	// Smartctl is the smartctl binary. When empty, smartctl is looked up
+	// in PATH.
+	Smartctl string `toml:"smartctl"`
 }
 
 // TailscaleCollectorConfig controls Tailscale status collection.
 type TailscaleCollectorConfig struct {
 	Enabled  bool     `toml:"enabled"`
 	Interval Duration `toml:"interval"`
+
+	// SocketPath overrides the tailscaled LocalAPI socket location.
+	SocketPath string `toml:"socket_path"`
+
+	// CLIPath, when set, collects via `tailscale status --json` using this
+	// binary instead of the LocalAPI socket.
+	CLIPath string `toml:"cli_path"`
+
+	// KeyExpiryWarning is how far ahead of this node's key expiry it is
+	// flagged as expiring soon.
+	KeyExpiryWarning Duration `toml:"key_expiry_warning"`
 }
 
 // K8sCollectorConfig controls Kubernetes status collection.
@@ -97,6 +403,23 @@ type K8sCollectorConfig struct {
 	Interval   Duration `toml:"interval"`
 	Contexts   []string `toml:"contexts"`
 	Namespaces []string `toml:"namespaces"`
+
+	// Kubeconfig is the kubeconfig file to read. Empty uses KUBECONFIG,
+	// then ~/.kube/config.
+	Kubeconfig string `toml:"kubeconfig"`
+
+	// ExcludeContexts filters contexts discovered with contexts = ["*"].
+	// Entries are glob patterns such as "kind-*".
+	ExcludeContexts []string `toml:"exclude_contexts"`
+
+	// Watch keeps cluster state current from API watches instead of
+	// relisting every interval.
+	Watch bool `toml:"watch"`
+
+	// UtilizationWarn is the percentage of its CPU or memory quota (or of
+	// the cluster's allocatable, without one) a namespace may request
+	// before the cluster is reported as a warning. Zero disables it.
+	UtilizationWarn float64 `toml:"utilization_warn"`
 }
 
 // ClaudeCollectorConfig controls Claude usage collection.
@@ -111,6 +434,33 @@ type ClaudeCollectorConfig struct {
 
 	// Accounts holds per-account configurations.
 	Accounts []ClaudeAccountConfig `toml:"account"`
+
+	// ForecastWarning is how close a projected window limit must be before
+	// the prompt shows a warning. Zero disables the warning.
+	ForecastWarning Duration `toml:"forecast_warning"`
+
+	// Pricing overrides or extends the built-in per-model prices, keyed by
+	// model name or name prefix (e.g. "claude-opus-4-6").
+	Pricing map[string]ClaudePricingConfig `toml:"pricing"`
+
+	// DefaultPricing prices models matching no entry; such costs are
+	// flagged as estimates. Zero keeps the built-in Sonnet-tier rate.
+	DefaultPricing ClaudePricingConfig `toml:"default_pricing"`
+}
+
+// ClaudePricingConfig is a model's price in USD per million tokens. When
+// both cache rates are zero they are derived from Input at Anthropic's
+// usual ratios: 1.25x for cache writes and 0.1x for cache reads.
+type ClaudePricingConfig struct {
+	Input      float64 `toml:"input"`
+	Output     float64 `toml:"output"`
+	CacheWrite float64 `toml:"cache_write"`
+	CacheRead  float64 `toml:"cache_read"`
+}
+
+// IsZero reports whether p sets no price at all.
+func (p ClaudePricingConfig) IsZero() bool {
+	return p == ClaudePricingConfig{}
 }
 
 // ClaudeAccountConfig represents a single Claude account entry.
@@ -121,6 +471,187 @@ type ClaudeAccountConfig struct {
 	// AdminKey is the per-account admin key.
 	// Prefer setting via environment variable instead of config file.
 	AdminKey string `toml:"admin_key"`
+
+	// Credentials is the path of this account's Claude Code credentials
+	// file (e.g. "~/.claude-work/.credentials.json"). Its sessions default
+	// to the projects directory beside it.
+	Credentials string `toml:"credentials"`
+
+	// SessionsDir is a Claude Code projects directory (e.g.
+	// "~/.claude/projects") whose sessions are shown for this account.
+	// Empty uses the projects directory beside Credentials, if set.
+	SessionsDir string `toml:"sessions_dir"`
+
+	// WindowLimit is the account's token limit per 5-hour usage window,
+	// used to forecast when it will be reached. Zero disables the forecast.
+	WindowLimit int64 `toml:"window_limit"`
+}
+
+// UptimeKumaCollectorConfig controls Uptime Kuma monitor collection.
+type UptimeKumaCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// URL is the base URL of the Uptime Kuma instance.
+	URL string `toml:"url"`
+
+	// APIKey for the Uptime Kuma metrics endpoint.
+	// Prefer setting via UPTIME_KUMA_API_KEY or UPTIME_KUMA_API_KEY_FILE
+	// environment variable.
+	APIKey string `toml:"api_key"`
+
+	// Tags limits collection to monitors with one of these tags, given as
+	// "name" or "name:value". Empty collects every monitor.
+	Tags []string `toml:"tags"`
+}
+
+// OllamaCollectorConfig controls collection from a local Ollama server.
+type OllamaCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// URL is the Ollama server's base URL. When empty, OLLAMA_HOST is
+	// used, then http://localhost:11434.
+	URL string `toml:"url"`
+}
+
+// DockerCollectorConfig controls Docker container status collection.
+type DockerCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// Host is the Docker daemon address ("unix:///path" or "tcp://host:port").
+	// When empty, DOCKER_HOST is used, then the default unix socket.
+	Host string `toml:"host"`
+}
+
+// ShellCollectorConfig controls the shell activity collector, which
+// summarizes the commands run in shells with the integration loaded.
+// Enabling it also makes "prompt-pulse shell" install the hooks that
+// report each command.
+type ShellCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// Retention is how long the activity files of a shell that has exited
+	// are kept after its last command.
+	Retention Duration `toml:"retention"`
+}
+
+// ChecksCollectorConfig controls the HTTP, TCP, and ping checks defined
+// in the config.
+type ChecksCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// Workers bounds how many checks run at once.
+	Workers int `toml:"workers"`
+
+	// FailureThreshold is how many consecutive failures mark a check down,
+	// so a single dropped packet does not flap the prompt.
+	FailureThreshold int `toml:"failure_threshold"`
+
+	// Checks are the checks to run, one [[collectors.checks.check]] table
+	// each.
+	Checks []CheckConfig `toml:"check"`
+}
+
+// CheckConfig defines one check.
+type CheckConfig struct {
+	// Name labels the check. Names must be unique.
+	Name string `toml:"name"`
+
+	// Type is "http", "tcp", or "ping".
+	Type string `toml:"type"`
+
+	// Target is a URL for http, "host:port" for tcp, and a host for ping.
+	Target string `toml:"target"`
+
+	// ExpectStatus is the HTTP status required for success. Zero accepts
+	// any status below 400.
+	ExpectStatus int `toml:"expect_status"`
+
+	// ExpectBody, when set, must appear in the HTTP response body.
+	ExpectBody string `toml:"expect_body"`
+
+	// Timeout bounds one attempt. Zero uses 5s.
+	Timeout Duration `toml:"timeout"`
+
+	// Interval is how often this check runs, when it should run less often
+	// than the collector. Zero runs it on every collection.
+	Interval Duration `toml:"interval"`
+
+	// Group names the service the check belongs to. Displays roll checks
+	// up by group; ungrouped checks form the group "other".
+	Group string `toml:"group"`
+
+	// Critical marks the group as down whenever this check is down.
+	// Other members only put it in a warning state.
+	Critical bool `toml:"critical"`
+}
+
+// RemoteCollectorConfig controls the uptime, load, memory, and disk
+// metrics gathered over SSH from hosts that do not run prompt-pulse.
+type RemoteCollectorConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Interval Duration `toml:"interval"`
+
+	// Timeout bounds connecting to and querying one host.
+	Timeout Duration `toml:"timeout"`
+
+	// FailureThreshold is how many consecutive failures mark a host down.
+	FailureThreshold int `toml:"failure_threshold"`
+
+	// KnownHosts is the known_hosts file host keys are verified against.
+	// When empty, ~/.ssh/known_hosts is used.
+	KnownHosts string `toml:"known_hosts"`
+
+	// StrictHostKeyChecking is "yes" to accept only hosts already in
+	// KnownHosts, or "accept-new" to add the key of a new host to it. A
+	// key differing from the one recorded is always rejected.
+	StrictHostKeyChecking string `toml:"strict_host_key_checking"`
+
+	// Hosts are the hosts to query, one [[collectors.remote.host]] table
+	// each.
+	Hosts []RemoteHostConfig `toml:"host"`
+}
+
+// RemoteHostConfig defines one host queried over SSH.
+type RemoteHostConfig struct {
+	// Name labels the host. Names must be unique; when empty, Address is
+	// used.
+	Name string `toml:"name"`
+
+	// Address is "host" or "host:port"; the port defaults to 22.
+	Address string `toml:"address"`
+
+	// User is the login user. When empty, the local user name is used.
+	User string `toml:"user"`
+
+	// KeyFile is an unencrypted private key to log in with. When empty,
+	// the keys of the SSH agent at SSH_AUTH_SOCK are used.
+	KeyFile string `toml:"key_file"`
+}
+
+// WeatherCollectorConfig controls Open-Meteo weather collection.
+type WeatherCollectorConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Interval is how often conditions are fetched. Values below 10m are
+	// raised to 10m to stay polite to the free API.
+	Interval Duration `toml:"interval"`
+
+	// Latitude and Longitude locate the forecast. When both are zero,
+	// Location is geocoded instead.
+	Latitude  float64 `toml:"latitude"`
+	Longitude float64 `toml:"longitude"`
+
+	// Location is a place name (e.g. "Berlin") resolved via Open-Meteo
+	// geocoding when no coordinates are set.
+	Location string `toml:"location"`
+
+	// Units is "celsius" or "fahrenheit".
+	Units string `toml:"units"`
 }
 
 // BillingCollectorConfig controls billing data collection.
@@ -129,6 +660,59 @@ type BillingCollectorConfig struct {
 	Interval     Duration `toml:"interval"`
 	Civo         CivoConfig `toml:"civo"`
 	DigitalOcean DOConfig   `toml:"digitalocean"`
+	Hetzner      HetznerConfig `toml:"hetzner"`
+	Vultr        VultrConfig   `toml:"vultr"`
+
+	// Currency sets the currency spend is totalled in and how amounts in
+	// other currencies are converted to it.
+	Currency CurrencyConfig `toml:"currency"`
+
+	// Budgets maps a provider name (civo, digitalocean, hetzner, vultr) to
+	// its monthly budget in the display currency. Providers without a
+	// budget are not checked against any threshold.
+	Budgets map[string]float64 `toml:"budgets"`
+
+	// WarnPercent and CriticalPercent are the percent-of-budget thresholds
+	// at which a provider's budget status becomes "warn" and "critical".
+	WarnPercent     float64 `toml:"warn_percent"`
+	CriticalPercent float64 `toml:"critical_percent"`
+
+	// HistoryRetentionDays is how many days of spend snapshots are kept in
+	// the billing history file under the cache directory.
+	HistoryRetentionDays int `toml:"history_retention_days"`
+
+	// AnomalyFactor flags a provider whose spend today is more than this
+	// many times its median daily spend over the previous two weeks, as
+	// measured from the history. Zero turns the test off.
+	AnomalyFactor float64 `toml:"anomaly_factor"`
+
+	// AnomalyMinDelta flags a provider whose spend today is more than this
+	// amount above its median daily spend, in the display currency. Zero
+	// turns the test off.
+	AnomalyMinDelta float64 `toml:"anomaly_min_delta"`
+}
+
+// CurrencyConfig holds billing currency conversion settings. Civo,
+// DigitalOcean, and Vultr bill in USD, Hetzner in EUR.
+type CurrencyConfig struct {
+	// Display is the ISO 4217 code totals and budgets are in.
+	Display string `toml:"display"`
+
+	// Source is where exchange rates come from: "ecb" for the European
+	// Central Bank's daily reference rates, or "static" for Rates alone.
+	Source string `toml:"source"`
+
+	// Rates maps a currency code to the value of one unit of it in the
+	// display currency, e.g. EUR = 1.08 with display USD. With the ecb
+	// source they are unused.
+	Rates map[string]float64 `toml:"rates"`
+
+	// RatesTTL is how long fetched ECB rates are reused before fetching
+	// them again.
+	RatesTTL Duration `toml:"rates_ttl"`
+
+	// ECBURL overrides the ECB feed address, e.g. for a test server.
+	ECBURL string `toml:"ecb_url"`
 }
 
 // CivoConfig holds Civo cloud billing settings.
@@ -136,8 +720,15 @@ type CivoConfig struct {
 	Enabled bool `toml:"enabled"`
 
 	// APIKey for Civo API access.
-	// Prefer setting via CIVO_TOKEN environment variable.
+	// Prefer setting via CIVO_TOKEN or CIVO_TOKEN_FILE environment variable.
 	APIKey string `toml:"api_key"`
+
+	// BaseURL overrides the API endpoint, e.g. for a proxy or a test
+	// server. Empty uses the provider's public API.
+	BaseURL string `toml:"base_url"`
+
+	// Period is the billing cycle, when it is not the calendar month.
+	Period BillingPeriodConfig `toml:"period"`
 }
 
 // DOConfig holds DigitalOcean billing settings.
@@ -145,8 +736,69 @@ type DOConfig struct {
 	Enabled bool `toml:"enabled"`
 
 	// APIKey for DigitalOcean API access.
-	// Prefer setting via DIGITALOCEAN_TOKEN environment variable.
+	// Prefer setting via DIGITALOCEAN_TOKEN or DIGITALOCEAN_TOKEN_FILE
+	// environment variable.
+	APIKey string `toml:"api_key"`
+
+	// BaseURL overrides the API endpoint, as for Civo.
+	BaseURL string `toml:"base_url"`
+
+	// Period is the billing cycle, as for Civo.
+	Period BillingPeriodConfig `toml:"period"`
+}
+
+// HetznerConfig holds Hetzner Cloud billing settings.
+type HetznerConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// APIKey for Hetzner Cloud API access.
+	// Prefer setting via HCLOUD_TOKEN or HCLOUD_TOKEN_FILE environment variable.
+	APIKey string `toml:"api_key"`
+
+	// BaseURL overrides the API endpoint, as for Civo.
+	BaseURL string `toml:"base_url"`
+
+	// Period is the billing cycle, as for Civo.
+	Period BillingPeriodConfig `toml:"period"`
+}
+
+// VultrConfig holds Vultr billing settings.
+type VultrConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// APIKey for Vultr API access.
+	// Prefer setting via VULTR_API_KEY or VULTR_API_KEY_FILE environment
+	// variable.
 	APIKey string `toml:"api_key"`
+
+	// BaseURL overrides the API endpoint, as for Civo.
+	BaseURL string `toml:"base_url"`
+
+	// Period is the billing cycle, as for Civo.
+	Period BillingPeriodConfig `toml:"period"`
+}
+
+// BillingPeriodConfig sets a provider's billing cycle when it does not
+// follow the calendar month. Spend, budgets, and budget alerts then cover
+// the current cycle, and budgets are prorated to its length.
+type BillingPeriodConfig struct {
+	// AnchorDay is the day of the month each cycle starts on, 1-31. In
+	// months shorter than AnchorDay the cycle starts on their last day. 0
+	// or 1 is the calendar month.
+	AnchorDay int `toml:"anchor_day"`
+
+	// CycleDays is a fixed cycle length in days, 1-31, counted from Start,
+	// instead of a monthly AnchorDay.
+	CycleDays int `toml:"cycle_days"`
+
+	// Start is the date one cycle began, "2006-01-02". Required with
+	// CycleDays.
+	Start string `toml:"start"`
+
+	// Timezone is the IANA time zone whose midnight cycles start at, e.g.
+	// "America/Los_Angeles". Empty uses the provider's, UTC for all of
+	// them.
+	Timezone string `toml:"timezone"`
 }
 
 // ImageConfig holds image and waifu display settings.
@@ -157,21 +809,121 @@ type ImageConfig struct {
 	// MaxCacheSizeMB is the maximum disk cache size for images in MB.
 	MaxCacheSizeMB int `toml:"max_cache_size_mb"`
 
+	// DiskCacheDir enables a rendered-image cache on disk shared across
+	// processes, capped at MaxCacheSizeMB. Empty keeps renders in memory.
+	DiskCacheDir string `toml:"disk_cache_dir"`
+
 	// MaxSessions is the maximum number of per-session cached images.
 	MaxSessions int `toml:"max_sessions"`
 
+	// MaxAnimationFrames caps the frames decoded from an animated GIF.
+	// Set to 1 to always show a still frame.
+	MaxAnimationFrames int `toml:"max_animation_frames"`
+
+	// SixelColors is the palette size for Sixel output (2-256).
+	SixelColors int `toml:"sixel_colors"`
+
+	// SixelDither selects Sixel dithering: "none", "floyd", or "ordered".
+	SixelDither string `toml:"sixel_dither"`
+
+	// KittyRetransmit always resends Kitty images instead of placing an
+	// image the terminal already holds from an earlier prompt.
+	KittyRetransmit bool `toml:"kitty_retransmit"`
+
+	// BlockMode selects the character-cell fallback: "halfblocks" (two
+	// pixels per cell) or "quadrants" (four, for sharper hard edges).
+	BlockMode string `toml:"block_mode"`
+
+	// Background is the terminal background color as "#rrggbb", used to
+	// blend translucent pixels in the character-cell fallback. Empty uses
+	// the color the terminal reports.
+	Background string `toml:"background"`
+
 	// WaifuEnabled toggles waifu image display.
 	WaifuEnabled bool `toml:"waifu_enabled"`
 
-	// WaifuCategory for API fetching.
+	// WaifuCategory for API fetching. Local images are picked from the
+	// subdirectory of the same name when it exists.
 	WaifuCategory string `toml:"waifu_category"`
+
+	// WaifuWeights biases local image selection by subdirectory, e.g.
+	// {favorites = 3}. Images elsewhere have weight 1; 0 excludes a
+	// subdirectory.
+	WaifuWeights map[string]float64 `toml:"waifu_weights"`
+
+	// WaifuURL is a remote image provider, with "{category}" replaced by
+	// WaifuCategory (e.g. "https://api.waifu.pics/sfw/{category}"). Empty
+	// uses local images only.
+	WaifuURL string `toml:"waifu_url"`
+
+	// WaifuMaxCacheMB caps the disk space of downloaded images. The least
+	// recently used are removed beyond it.
+	WaifuMaxCacheMB int `toml:"waifu_max_cache_mb"`
+
+	// WaifuCrop crops images to the shape of their cell area before
+	// scaling: "none", "center", or "entropy" (keep the most detailed
+	// region).
+	WaifuCrop string `toml:"waifu_crop"`
+
+	// WaifuPad fills the cell area around an image of another shape:
+	// "none", "theme" (the theme background), "edge" (the image's most
+	// common border color), or a "#rrggbb" color.
+	WaifuPad string `toml:"waifu_pad"`
+
+	// WaifuCornerRadius rounds image corners by this many pixels on
+	// protocols that draw transparency (Kitty and iTerm2). Zero keeps
+	// them square.
+	WaifuCornerRadius int `toml:"waifu_corner_radius"`
 }
 
 // ThemeConfig selects the visual theme.
 type ThemeConfig struct {
-	// Name of the built-in theme.
-	// Options: "default", "gruvbox", "nord", "catppuccin", "dracula", "tokyo-night"
+	// Name of the built-in theme, or "auto" to pick Light or Dark from the
+	// terminal background.
+	// Options: "default", "gruvbox", "nord", "catppuccin", "dracula",
+	// "tokyo-night", "solarized-light", "auto"
 	Name string `toml:"name"`
+
+	// Light and Dark are the themes "auto" picks for light and dark
+	// terminal backgrounds.
+	Light string `toml:"light"`
+	Dark  string `toml:"dark"`
+
+	// Terminals overrides Name per terminal emulator, keyed by
+	// TERM_PROGRAM. Values are theme names, "light" or "dark" for the
+	// variants above, or "auto".
+	Terminals map[string]string `toml:"terminals"`
+}
+
+// LogConfig holds daemon log output settings. The level is
+// general.log_level.
+type LogConfig struct {
+	// Format is "text" or "json".
+	Format string `toml:"format"`
+
+	// File is where the daemon writes its log, rotating it in-process.
+	// Empty logs to stderr.
+	File string `toml:"file"`
+
+	// MaxSizeMB rotates File once it would grow past this many megabytes.
+	// Zero means no size limit.
+	MaxSizeMB int `toml:"max_size_mb"`
+
+	// MaxAge rotates File once the daemon has written to it this long.
+	// Zero means no age limit.
+	MaxAge Duration `toml:"max_age"`
+
+	// MaxFiles is how many rotated files are kept alongside File.
+	MaxFiles int `toml:"max_files"`
+}
+
+// DisplayConfig holds output compatibility settings shared by the banner,
+// TUI, and starship module.
+type DisplayConfig struct {
+	// ASCII forces plain output: no color, ASCII in place of box-drawing,
+	// block, and glyph characters, and no images. It is also used when
+	// banner output is not a terminal or TERM is "dumb".
+	ASCII bool `toml:"ascii"`
 }
 
 // ShellConfig holds shell integration settings.
@@ -189,6 +941,78 @@ type ShellConfig struct {
 	InstantBanner bool `toml:"instant_banner"`
 }
 
+// StarshipConfig holds Starship custom module settings.
+type StarshipConfig struct {
+	// Summary configures the combined "-starship summary" segment.
+	Summary StarshipSummaryConfig `toml:"summary"`
+
+	// Thresholds set the usage percentages at which segments turn to the
+	// theme's warning and error colors.
+	Thresholds StarshipThresholdsConfig `toml:"thresholds"`
+
+	// Wrap marks color codes as zero-width for "bash" or "zsh" when the
+	// output is placed in PS1 or PROMPT directly. Leave it empty under
+	// Starship, which does this itself.
+	Wrap string `toml:"wrap"`
+
+	// ClaudeDisplay chooses what the claude segment shows: "cost" (the
+	// default), "tokens", or "both".
+	ClaudeDisplay string `toml:"claude_display"`
+
+	// Budget bounds how long "-starship" takes. Segments whose cached
+	// data is not read in time are left out, and overruns are counted in
+	// the cache directory for -diagnose. Zero waits for every segment.
+	Budget Duration `toml:"budget"`
+
+	// MaxWidth bounds the visible width of each "-starship" module's
+	// output, keyed by module name, e.g. claude or infra. Unlisted modules
+	// get 60 cells; summary uses Summary.MaxWidth instead.
+	MaxWidth map[string]int `toml:"max_width"`
+}
+
+// StarshipThresholdsConfig holds per-segment color thresholds.
+type StarshipThresholdsConfig struct {
+	// Claude applies to the fullest usage window.
+	Claude ThresholdConfig `toml:"claude"`
+
+	// Billing applies to monthly spend as a percentage of the budget.
+	Billing ThresholdConfig `toml:"billing"`
+
+	// System applies to the higher of CPU and RAM usage.
+	System ThresholdConfig `toml:"system"`
+}
+
+// ThresholdConfig holds the percentages at which a segment turns to its
+// warning and critical colors.
+type ThresholdConfig struct {
+	Warn     float64 `toml:"warn"`
+	Critical float64 `toml:"critical"`
+}
+
+// StarshipSummaryConfig configures "-starship summary", which composes
+// several segments into one prompt slot.
+type StarshipSummaryConfig struct {
+	// Segments lists the segments to include, in order. See
+	// StarshipSummarySegments for the valid names.
+	Segments []string `toml:"segments"`
+
+	// Separator is placed between segments. Empty uses a dim "│".
+	Separator string `toml:"separator"`
+
+	// MaxWidth is the total visible width budget. Over it, each segment is
+	// truncated independently to its share.
+	MaxWidth int `toml:"max_width"`
+}
+
+// StarshipSummarySegments lists the segment names accepted in
+// starship.summary.segments. "infra" combines tailscale, uptimekuma,
+// checks, remote, docker, and storage.
+var StarshipSummarySegments = []string{
+	"claude", "billing", "infra", "tailscale", "uptimekuma", "checks",
+	"remote", "docker", "storage", "k8s", "kubernetes", "system", "sys",
+	"weather",
+}
+
 // BannerConfig holds terminal width threshold overrides for banner modes.
 type BannerConfig struct {
 	// CompactMaxWidth is the max terminal width for compact mode.
@@ -202,4 +1026,52 @@ type BannerConfig struct {
 
 	// UltraWideMinWidth is the min terminal width for ultra-wide mode.
 	UltraWideMinWidth int `toml:"ultrawide_min_width"`
+
+	// Columns orders the banner columns left to right. Columns left out
+	// are not rendered. If empty, the preset's built-in layout is used.
+	// Below StandardMinWidth only the first column is shown, and below
+	// WideMinWidth only the first two.
+	Columns []BannerColumnConfig `toml:"column"`
+
+	// Fastfetch selects how the system info column is produced.
+	Fastfetch FastfetchConfig `toml:"fastfetch"`
+
+	// StackBelowWidth is the terminal width below which the banner stacks
+	// its sections vertically, each the full width, instead of placing
+	// them side by side.
+	StackBelowWidth int `toml:"stack_below_width"`
+
+	// StackOrder lists the sections of the stacked banner top to bottom:
+	// "status", "fastfetch", and "waifu" (shown as a small thumbnail).
+	// Sections left out are not rendered.
+	StackOrder []string `toml:"stack_order"`
+
+	// BillingBreakdown adds each itemizing provider's month-to-date spend
+	// by resource type to the status column.
+	BillingBreakdown bool `toml:"billing_breakdown"`
+
+	// ShowLastEvent adds the most recent change from the event log to the
+	// status column.
+	ShowLastEvent bool `toml:"show_last_event"`
+
+	// ShowShellActivity adds today's shell activity (see
+	// collectors.shell) to the status column.
+	ShowShellActivity bool `toml:"show_shell_activity"`
+}
+
+// FastfetchConfig controls the banner's system info column.
+type FastfetchConfig struct {
+	// Mode is "native" (gathered in-process), "fastfetch" (run the
+	// fastfetch binary, falling back to native), or "auto" (native).
+	Mode string `toml:"mode"`
+}
+
+// BannerColumnConfig defines a single banner column.
+type BannerColumnConfig struct {
+	// Name is the column: "waifu", "fastfetch", or "status".
+	Name string `toml:"name"`
+
+	// Width is the column width as a percentage of the terminal width.
+	// 0 means auto: auto columns share whatever the others leave.
+	Width int `toml:"width"`
 }
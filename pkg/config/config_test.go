@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +16,15 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.General.DaemonPollInterval.Duration != 15*time.Minute {
 		t.Errorf("DaemonPollInterval = %v, want 15m", cfg.General.DaemonPollInterval)
 	}
+	if cfg.General.CollectTimeout.Duration != 30*time.Second {
+		t.Errorf("CollectTimeout = %v, want 30s", cfg.General.CollectTimeout)
+	}
+	if cfg.General.ShutdownGrace.Duration != 10*time.Second {
+		t.Errorf("ShutdownGrace = %v, want 10s", cfg.General.ShutdownGrace)
+	}
+	if cfg.General.StaleAfterPolls != 2 || cfg.General.ExpireAfter.Duration != 24*time.Hour {
+		t.Errorf("StaleAfterPolls/ExpireAfter = %g/%v, want 2/24h", cfg.General.StaleAfterPolls, cfg.General.ExpireAfter)
+	}
 	if cfg.General.DataRetention.Duration != 10*time.Minute {
 		t.Errorf("DataRetention = %v, want 10m", cfg.General.DataRetention)
 	}
@@ -22,6 +34,30 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.General.CacheDir == "" {
 		t.Error("CacheDir should not be empty")
 	}
+	if cfg.General.StateDir == "" || cfg.General.StateDir == cfg.General.CacheDir {
+		t.Errorf("StateDir = %q, want a directory of its own", cfg.General.StateDir)
+	}
+	if cfg.General.TUIRefreshInterval.Duration != 5*time.Second {
+		t.Errorf("TUIRefreshInterval = %v, want 5s", cfg.General.TUIRefreshInterval)
+	}
+	if len(cfg.TUI.Keys) != len(TUIKeyActions) {
+		t.Errorf("TUI.Keys has %d actions, want %d", len(cfg.TUI.Keys), len(TUIKeyActions))
+	}
+	if !cfg.TUI.Mouse {
+		t.Error("TUI.Mouse should default to true")
+	}
+	if want := (StatusPageConfig{Title: "prompt-pulse status"}); cfg.StatusPage != want {
+		t.Errorf("StatusPage = %+v, want %+v", cfg.StatusPage, want)
+	}
+	if want := (EventsConfig{Enabled: true, MaxEntries: 200, BillingMinDelta: 0.50, ClaudeMinDelta: 1.00, PodJitter: 1}); cfg.Events != want {
+		t.Errorf("Events = %+v, want %+v", cfg.Events, want)
+	}
+	if want := (ValidationConfig{Enabled: true, BillingMaxFactor: 10, ClaudeWindow: Duration{time.Hour}}); cfg.Validation != want {
+		t.Errorf("Validation = %+v, want %+v", cfg.Validation, want)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("default config fails validation: %v", err)
+	}
 
 	// Layout defaults
 	if cfg.Layout.Preset != "dashboard" {
@@ -47,12 +83,73 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Collectors.Kubernetes.Interval.Duration <= 0 {
 		t.Error("Kubernetes.Interval should be > 0 even when disabled")
 	}
+	if cfg.Collectors.Kubernetes.UtilizationWarn != 90 {
+		t.Errorf("Kubernetes.UtilizationWarn = %g, want 90", cfg.Collectors.Kubernetes.UtilizationWarn)
+	}
 	if !cfg.Collectors.Claude.Enabled {
 		t.Error("Claude should be enabled by default")
 	}
+	if cfg.Collectors.Claude.ForecastWarning.Duration != 30*time.Minute {
+		t.Errorf("Claude.ForecastWarning = %v, want 30m", cfg.Collectors.Claude.ForecastWarning)
+	}
+	if cfg.Starship.ClaudeDisplay != "cost" {
+		t.Errorf("Starship.ClaudeDisplay = %q, want cost", cfg.Starship.ClaudeDisplay)
+	}
+	if cfg.Starship.Budget.Duration != 50*time.Millisecond {
+		t.Errorf("Starship.Budget = %s, want 50ms", cfg.Starship.Budget)
+	}
+	if len(cfg.Collectors.Claude.Pricing) != 0 || !cfg.Collectors.Claude.DefaultPricing.IsZero() {
+		t.Errorf("Claude pricing = %v, %+v; want no overrides", cfg.Collectors.Claude.Pricing, cfg.Collectors.Claude.DefaultPricing)
+	}
 	if cfg.Collectors.Billing.Enabled {
 		t.Error("Billing should be disabled by default")
 	}
+	if cfg.Collectors.UptimeKuma.Enabled {
+		t.Error("UptimeKuma should be disabled by default")
+	}
+	if cfg.Collectors.Docker.Enabled {
+		t.Error("Docker should be disabled by default")
+	}
+	if cfg.Collectors.Shell.Enabled || cfg.Collectors.Shell.Retention.Duration != 48*time.Hour {
+		t.Errorf("Shell = %+v, want disabled with 48h retention", cfg.Collectors.Shell)
+	}
+	if o := cfg.Collectors.Ollama; o.Enabled || o.Interval.Duration != 30*time.Second || o.URL != "" {
+		t.Errorf("Ollama = %+v, want disabled every 30s with no URL", o)
+	}
+	if s := cfg.Collectors.SysMetrics; s.HistoryRetention.Duration != 24*time.Hour || s.HistoryInterval.Duration != 15*time.Second {
+		t.Errorf("SysMetrics = %+v, want 24h of history every 15s", s)
+	}
+	if cfg.Collectors.GPU.Enabled || cfg.Collectors.GPU.Interval.Duration != 15*time.Second {
+		t.Errorf("GPU = %+v, want disabled with a 15s interval", cfg.Collectors.GPU)
+	}
+	if s := cfg.Collectors.Storage; s.Enabled || s.MountTimeout.Duration != 2*time.Second || s.WarnPercent != 85 || s.CriticalPercent != 95 {
+		t.Errorf("Storage = %+v, want disabled with a 2s mount timeout and 85/95%% thresholds", s)
+	}
+	if cfg.Collectors.Weather.Enabled {
+		t.Error("Weather should be disabled by default")
+	}
+	if cfg.Collectors.Weather.Interval.Duration != 30*time.Minute || cfg.Collectors.Weather.Units != "celsius" {
+		t.Errorf("Weather = %+v, want 30m interval in celsius", cfg.Collectors.Weather)
+	}
+	if r := cfg.Collectors.Remote; r.Enabled || r.Timeout.Duration != 10*time.Second || r.FailureThreshold != 3 || r.StrictHostKeyChecking != "yes" {
+		t.Errorf("Remote = %+v, want disabled with a 10s timeout, threshold 3, and strict host key checking", r)
+	}
+	if h := cfg.HTTP; h.MaxRetries != 3 || h.RetryBaseDelay.Duration != 500*time.Millisecond || h.MaxRetryDelay.Duration != 30*time.Second || len(h.RateLimits) != 0 {
+		t.Errorf("HTTP = %+v, want 3 retries from 500ms up to 30s and no rate limits", h)
+	}
+	if cfg.Collectors.Billing.HistoryRetentionDays != 90 {
+		t.Errorf("Billing.HistoryRetentionDays = %d, want 90", cfg.Collectors.Billing.HistoryRetentionDays)
+	}
+	if cfg.Collectors.Billing.AnomalyFactor != 2 {
+		t.Errorf("Billing.AnomalyFactor = %v, want 2", cfg.Collectors.Billing.AnomalyFactor)
+	}
+	if cfg.Collectors.Billing.WarnPercent != 80 || cfg.Collectors.Billing.CriticalPercent != 100 {
+		t.Errorf("Billing thresholds = %v/%v, want 80/100",
+			cfg.Collectors.Billing.WarnPercent, cfg.Collectors.Billing.CriticalPercent)
+	}
+	if cc := cfg.Collectors.Billing.Currency; cc.Display != "USD" || cc.Source != "ecb" || cc.RatesTTL.Duration != 24*time.Hour {
+		t.Errorf("Billing.Currency = %+v, want USD from ECB rates cached 24h", cc)
+	}
 
 	// Image defaults
 	if cfg.Image.Protocol != "auto" {
@@ -67,14 +164,49 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Image.MaxSessions != 10 {
 		t.Errorf("MaxSessions = %d, want 10", cfg.Image.MaxSessions)
 	}
+	if cfg.Image.MaxAnimationFrames != 64 {
+		t.Errorf("MaxAnimationFrames = %d, want 64", cfg.Image.MaxAnimationFrames)
+	}
+	if cfg.Image.SixelColors != 256 || cfg.Image.SixelDither != "floyd" {
+		t.Errorf("Sixel = %d/%q, want 256/floyd", cfg.Image.SixelColors, cfg.Image.SixelDither)
+	}
+	if cfg.Image.DiskCacheDir != "" {
+		t.Errorf("DiskCacheDir = %q, want empty", cfg.Image.DiskCacheDir)
+	}
+	if cfg.Image.KittyRetransmit {
+		t.Error("KittyRetransmit should default to false")
+	}
 	if cfg.Image.WaifuCategory != "waifu" {
 		t.Errorf("WaifuCategory = %q, want %q", cfg.Image.WaifuCategory, "waifu")
 	}
+	if cfg.Image.WaifuCrop != "none" || cfg.Image.WaifuPad != "none" || cfg.Image.WaifuCornerRadius != 0 {
+		t.Errorf("WaifuCrop = %q, WaifuPad = %q, WaifuCornerRadius = %d, want no preprocessing",
+			cfg.Image.WaifuCrop, cfg.Image.WaifuPad, cfg.Image.WaifuCornerRadius)
+	}
 
 	// Theme defaults
 	if cfg.Theme.Name != "default" {
 		t.Errorf("Theme.Name = %q, want %q", cfg.Theme.Name, "default")
 	}
+	if cfg.Theme.Light != "solarized-light" || cfg.Theme.Dark != "default" {
+		t.Errorf("Theme.Light/Dark = %q/%q, want solarized-light/default", cfg.Theme.Light, cfg.Theme.Dark)
+	}
+	if cfg.Display.ASCII {
+		t.Error("Display.ASCII = true, want false")
+	}
+
+	// Log defaults
+	if cfg.Log.Format != "text" || cfg.Log.File != "" {
+		t.Errorf("Log.Format/File = %q/%q, want text to stderr", cfg.Log.Format, cfg.Log.File)
+	}
+	if cfg.Log.MaxSizeMB != 10 || cfg.Log.MaxAge.Duration != 7*24*time.Hour || cfg.Log.MaxFiles != 5 {
+		t.Errorf("Log rotation = %+v, want 10MB, 7 days, 5 files", cfg.Log)
+	}
+
+	// Notifications are off by default
+	if len(cfg.Notifications.Rules) != 0 || len(cfg.Notifications.Sinks) != 0 {
+		t.Errorf("Notifications = %+v, want no rules or sinks", cfg.Notifications)
+	}
 
 	// Shell defaults
 	if cfg.Shell.TUIKeybinding != `\C-p` {
@@ -103,6 +235,27 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Banner.UltraWideMinWidth != 200 {
 		t.Errorf("UltraWideMinWidth = %d, want 200", cfg.Banner.UltraWideMinWidth)
 	}
+	if cfg.Banner.Fastfetch.Mode != "auto" {
+		t.Errorf("Fastfetch.Mode = %q, want auto", cfg.Banner.Fastfetch.Mode)
+	}
+	if len(cfg.Banner.Columns) != 0 {
+		t.Errorf("Banner.Columns = %+v, want none (preset layout)", cfg.Banner.Columns)
+	}
+	if cfg.Banner.BillingBreakdown {
+		t.Error("Banner.BillingBreakdown should be false by default")
+	}
+	if cfg.Banner.ShowLastEvent {
+		t.Error("Banner.ShowLastEvent should be false by default")
+	}
+	if cfg.Banner.ShowShellActivity {
+		t.Error("Banner.ShowShellActivity should be false by default")
+	}
+	if cfg.Banner.StackBelowWidth != 70 {
+		t.Errorf("StackBelowWidth = %d, want 70", cfg.Banner.StackBelowWidth)
+	}
+	if got := strings.Join(cfg.Banner.StackOrder, ","); got != "status,fastfetch" {
+		t.Errorf("StackOrder = %q, want status,fastfetch", got)
+	}
 }
 
 func TestLoadFromReader_Minimal(t *testing.T) {
@@ -145,6 +298,12 @@ daemon_poll_interval = "10m"
 data_retention = "30m"
 log_level = "debug"
 cache_dir = "/tmp/ppulse-cache"
+state_dir = "/tmp/ppulse-state"
+host_profile = "none"
+
+[log]
+format = "json"
+max_files = 2
 
 [layout]
 preset = "ops"
@@ -167,6 +326,11 @@ namespaces = ["default", "monitoring"]
 enabled = true
 interval = "10m"
 
+[collectors.claude.pricing."claude-next"]
+input = 2.0
+output = 10.0
+cache_read = 0.2
+
 [[collectors.claude.account]]
 name = "personal"
 
@@ -182,6 +346,7 @@ enabled = true
 
 [collectors.billing.digitalocean]
 enabled = true
+base_url = "http://127.0.0.1:8080/v2/"
 
 [image]
 protocol = "kitty"
@@ -192,6 +357,13 @@ waifu_category = "neko"
 
 [theme]
 name = "catppuccin"
+dark = "tokyo-night"
+
+[theme.terminals]
+Apple_Terminal = "light"
+
+[display]
+ascii = true
 
 [shell]
 tui_keybinding = "\\C-p"
@@ -204,6 +376,9 @@ compact_max_width = 90
 standard_min_width = 130
 wide_min_width = 170
 ultrawide_min_width = 220
+billing_breakdown = true
+stack_below_width = 60
+stack_order = ["status", "waifu"]
 `
 	cfg, err := LoadFromReader(strings.NewReader(input))
 	if err != nil {
@@ -223,6 +398,12 @@ ultrawide_min_width = 220
 	if cfg.General.CacheDir != "/tmp/ppulse-cache" {
 		t.Errorf("CacheDir = %q, want %q", cfg.General.CacheDir, "/tmp/ppulse-cache")
 	}
+	if cfg.General.StateDir != "/tmp/ppulse-state" {
+		t.Errorf("StateDir = %q, want %q", cfg.General.StateDir, "/tmp/ppulse-state")
+	}
+	if cfg.General.HostProfile != NoHostProfile {
+		t.Errorf("HostProfile = %q, want %q", cfg.General.HostProfile, NoHostProfile)
+	}
 
 	// Layout
 	if cfg.Layout.Preset != "ops" {
@@ -267,6 +448,9 @@ ultrawide_min_width = 220
 	if cfg.Collectors.Claude.Accounts[1].Name != "work" {
 		t.Errorf("Claude.Accounts[1].Name = %q, want %q", cfg.Collectors.Claude.Accounts[1].Name, "work")
 	}
+	if got := cfg.Collectors.Claude.Pricing["claude-next"]; got != (ClaudePricingConfig{Input: 2, Output: 10, CacheRead: 0.2}) {
+		t.Errorf("Claude.Pricing[claude-next] = %+v", got)
+	}
 
 	// Billing
 	if !cfg.Collectors.Billing.Enabled {
@@ -278,6 +462,9 @@ ultrawide_min_width = 220
 	if !cfg.Collectors.Billing.DigitalOcean.Enabled {
 		t.Error("DigitalOcean billing should be enabled per config")
 	}
+	if got := cfg.Collectors.Billing.DigitalOcean.BaseURL; got != "http://127.0.0.1:8080/v2/" {
+		t.Errorf("Billing.DigitalOcean.BaseURL = %q", got)
+	}
 
 	// Image
 	if cfg.Image.Protocol != "kitty" {
@@ -297,6 +484,18 @@ ultrawide_min_width = 220
 	if cfg.Theme.Name != "catppuccin" {
 		t.Errorf("Theme.Name = %q, want %q", cfg.Theme.Name, "catppuccin")
 	}
+	if cfg.Theme.Dark != "tokyo-night" {
+		t.Errorf("Theme.Dark = %q, want %q", cfg.Theme.Dark, "tokyo-night")
+	}
+	if got := cfg.Theme.Terminals["Apple_Terminal"]; got != "light" {
+		t.Errorf("Theme.Terminals[Apple_Terminal] = %q, want %q", got, "light")
+	}
+	if !cfg.Display.ASCII {
+		t.Error("Display.ASCII = false, want true")
+	}
+	if cfg.Log.Format != "json" || cfg.Log.MaxFiles != 2 || cfg.Log.MaxSizeMB != 10 {
+		t.Errorf("Log = %+v, want json, 2 files, default size", cfg.Log)
+	}
 
 	// Shell
 	if cfg.Shell.ShowBannerOnStartup {
@@ -322,6 +521,15 @@ ultrawide_min_width = 220
 	if cfg.Banner.UltraWideMinWidth != 220 {
 		t.Errorf("UltraWideMinWidth = %d, want 220", cfg.Banner.UltraWideMinWidth)
 	}
+	if !cfg.Banner.BillingBreakdown {
+		t.Error("Banner.BillingBreakdown should be true per config")
+	}
+	if cfg.Banner.StackBelowWidth != 60 {
+		t.Errorf("StackBelowWidth = %d, want 60", cfg.Banner.StackBelowWidth)
+	}
+	if got := strings.Join(cfg.Banner.StackOrder, ","); got != "status,waifu" {
+		t.Errorf("StackOrder = %q, want status,waifu", got)
+	}
 }
 
 func TestDuration_Parse(t *testing.T) {
@@ -434,6 +642,27 @@ func TestEnvOverrides(t *testing.T) {
 			check:  func(c *Config) bool { return c.Collectors.Billing.DigitalOcean.APIKey == "do-test-token" },
 			errMsg: "Billing.DigitalOcean.APIKey not set from DIGITALOCEAN_TOKEN",
 		},
+		{
+			name:   "HCLOUD_TOKEN",
+			envKey: "HCLOUD_TOKEN",
+			envVal: "hcloud-test-token",
+			check:  func(c *Config) bool { return c.Collectors.Billing.Hetzner.APIKey == "hcloud-test-token" },
+			errMsg: "Billing.Hetzner.APIKey not set from HCLOUD_TOKEN",
+		},
+		{
+			name:   "VULTR_API_KEY",
+			envKey: "VULTR_API_KEY",
+			envVal: "vultr-test-key",
+			check:  func(c *Config) bool { return c.Collectors.Billing.Vultr.APIKey == "vultr-test-key" },
+			errMsg: "Billing.Vultr.APIKey not set from VULTR_API_KEY",
+		},
+		{
+			name:   "UPTIME_KUMA_API_KEY",
+			envKey: "UPTIME_KUMA_API_KEY",
+			envVal: "kuma-test-key",
+			check:  func(c *Config) bool { return c.Collectors.UptimeKuma.APIKey == "kuma-test-key" },
+			errMsg: "UptimeKuma.APIKey not set from UPTIME_KUMA_API_KEY",
+		},
 		{
 			name:   "PPULSE_PROTOCOL",
 			envKey: "PPULSE_PROTOCOL",
@@ -441,6 +670,13 @@ func TestEnvOverrides(t *testing.T) {
 			check:  func(c *Config) bool { return c.Image.Protocol == "sixel" },
 			errMsg: "Image.Protocol not set from PPULSE_PROTOCOL",
 		},
+		{
+			name:   "PPULSE_KITTY_RETRANSMIT",
+			envKey: "PPULSE_KITTY_RETRANSMIT",
+			envVal: "1",
+			check:  func(c *Config) bool { return c.Image.KittyRetransmit },
+			errMsg: "Image.KittyRetransmit not set from PPULSE_KITTY_RETRANSMIT",
+		},
 		{
 			name:   "PPULSE_THEME",
 			envKey: "PPULSE_THEME",
@@ -455,6 +691,27 @@ func TestEnvOverrides(t *testing.T) {
 			check:  func(c *Config) bool { return c.Layout.Preset == "minimal" },
 			errMsg: "Layout.Preset not set from PPULSE_LAYOUT",
 		},
+		{
+			name:   "PPULSE_CACHE_DIR",
+			envKey: "PPULSE_CACHE_DIR",
+			envVal: "/srv/ppulse/cache",
+			check:  func(c *Config) bool { return c.General.CacheDir == "/srv/ppulse/cache" },
+			errMsg: "General.CacheDir not set from PPULSE_CACHE_DIR",
+		},
+		{
+			name:   "PPULSE_STATE_DIR",
+			envKey: "PPULSE_STATE_DIR",
+			envVal: "/srv/ppulse/state",
+			check:  func(c *Config) bool { return c.General.StateDir == "/srv/ppulse/state" },
+			errMsg: "General.StateDir not set from PPULSE_STATE_DIR",
+		},
+		{
+			name:   "PPULSE_HOST_PROFILE",
+			envKey: "PPULSE_HOST_PROFILE",
+			envVal: NoHostProfile,
+			check:  func(c *Config) bool { return c.General.HostProfile == NoHostProfile },
+			errMsg: "General.HostProfile not set from PPULSE_HOST_PROFILE",
+		},
 	}
 
 	for _, tt := range tests {
@@ -471,6 +728,30 @@ func TestEnvOverrides(t *testing.T) {
 	}
 }
 
+func TestEnvOverrideFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hcloud-token")
+	if err := os.WriteFile(path, []byte("hcloud-from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HCLOUD_TOKEN", "")
+	t.Setenv("HCLOUD_TOKEN_FILE", path)
+
+	cfg, err := LoadFromReader(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error: %v", err)
+	}
+	if got := cfg.Collectors.Billing.Hetzner.APIKey; got != "hcloud-from-file" {
+		t.Errorf("Hetzner.APIKey = %q, want %q", got, "hcloud-from-file")
+	}
+
+	// The plain variable takes precedence over the file.
+	t.Setenv("HCLOUD_TOKEN", "hcloud-direct")
+	cfg, _ = LoadFromReader(strings.NewReader(""))
+	if got := cfg.Collectors.Billing.Hetzner.APIKey; got != "hcloud-direct" {
+		t.Errorf("Hetzner.APIKey = %q, want %q", got, "hcloud-direct")
+	}
+}
+
 func TestLayoutPreset_Dashboard(t *testing.T) {
 	layout := LayoutPreset("dashboard")
 	if layout.Preset != "dashboard" {
@@ -665,6 +946,60 @@ func TestLoadFromFile_NonExistent(t *testing.T) {
 	}
 }
 
+func TestFindPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	if got := FindPath(); got != "" {
+		t.Errorf("FindPath() = %q, want empty without a config file", got)
+	}
+
+	fallback := filepath.Join(home, ".config", "prompt-pulse", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(fallback), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fallback, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindPath(); got != fallback {
+		t.Errorf("FindPath() = %q, want %q", got, fallback)
+	}
+
+	explicit := filepath.Join(home, "elsewhere.toml")
+	t.Setenv("PPULSE_CONFIG", explicit)
+	if got := FindPath(); got != "" {
+		t.Errorf("FindPath() = %q, want empty while $PPULSE_CONFIG does not exist", got)
+	}
+	if err := os.WriteFile(explicit, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindPath(); got != explicit {
+		t.Errorf("FindPath() = %q, want %q", got, explicit)
+	}
+}
+
+func TestDefaultDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, want := DefaultCacheDir(), filepath.Join(home, ".cache", "prompt-pulse"); got != want {
+		t.Errorf("DefaultCacheDir() = %q, want %q", got, want)
+	}
+	if got, want := DefaultStateDir(), filepath.Join(home, ".local", "state", "prompt-pulse"); got != want {
+		t.Errorf("DefaultStateDir() = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	if got, want := DefaultCacheDir(), filepath.Join("/xdg/cache", "prompt-pulse"); got != want {
+		t.Errorf("DefaultCacheDir() = %q, want %q", got, want)
+	}
+	if got, want := DefaultStateDir(), filepath.Join("/xdg/state", "prompt-pulse"); got != want {
+		t.Errorf("DefaultStateDir() = %q, want %q", got, want)
+	}
+}
+
 func TestLoadFromFile_Testdata(t *testing.T) {
 	cfg, err := LoadFromFile("testdata/full.toml")
 	if err != nil {
@@ -673,12 +1008,242 @@ func TestLoadFromFile_Testdata(t *testing.T) {
 	if cfg.General.LogLevel != "debug" {
 		t.Errorf("LogLevel = %q, want %q", cfg.General.LogLevel, "debug")
 	}
+	if cfg.General.TUIRefreshInterval.Duration != 2*time.Second {
+		t.Errorf("TUIRefreshInterval = %v, want 2s", cfg.General.TUIRefreshInterval)
+	}
+	if cfg.General.StateDir != "/tmp/ppulse-state" {
+		t.Errorf("StateDir = %q, want %q", cfg.General.StateDir, "/tmp/ppulse-state")
+	}
+	if cfg.General.HostProfile != NoHostProfile {
+		t.Errorf("HostProfile = %q, want %q", cfg.General.HostProfile, NoHostProfile)
+	}
+	if want := (LogConfig{Format: "json", File: "/var/log/prompt-pulse/daemon.log", MaxSizeMB: 25, MaxAge: Duration{72 * time.Hour}, MaxFiles: 3}); cfg.Log != want {
+		t.Errorf("Log = %+v, want %+v", cfg.Log, want)
+	}
+	if want := (StatusPageConfig{Path: "/var/www/status/index.html", Title: "homelab status", Interval: Duration{5 * time.Minute}}); cfg.StatusPage != want {
+		t.Errorf("StatusPage = %+v, want %+v", cfg.StatusPage, want)
+	}
+	if want := (EventsConfig{Enabled: true, MaxEntries: 500, BillingMinDelta: 1.0, ClaudeMinDelta: 2.5, PodJitter: 2}); cfg.Events != want {
+		t.Errorf("Events = %+v, want %+v", cfg.Events, want)
+	}
+	if want := (ValidationConfig{Enabled: true, BillingMaxFactor: 4, ClaudeWindow: Duration{30 * time.Minute}, K8sAllowZeroNodes: true}); cfg.Validation != want {
+		t.Errorf("Validation = %+v, want %+v", cfg.Validation, want)
+	}
+	if accts := cfg.Collectors.Claude.Accounts; len(accts) != 2 || accts[0].SessionsDir != "~/.claude/projects" || accts[1].SessionsDir != "" {
+		t.Errorf("Claude.Accounts = %+v, want sessions_dir on personal only", accts)
+	}
+	if accts := cfg.Collectors.Claude.Accounts; len(accts) != 2 || accts[1].Credentials != "~/.claude-work/.credentials.json" {
+		t.Errorf("Claude.Accounts = %+v, want credentials on work", accts)
+	}
+	if accts := cfg.Collectors.Claude.Accounts; len(accts) != 2 || accts[0].WindowLimit != 4_000_000 || accts[1].WindowLimit != 0 {
+		t.Errorf("Claude.Accounts = %+v, want window_limit on personal only", accts)
+	}
+	if cfg.Collectors.Claude.ForecastWarning.Duration != 45*time.Minute {
+		t.Errorf("Claude.ForecastWarning = %v, want 45m", cfg.Collectors.Claude.ForecastWarning)
+	}
+	if got := cfg.Collectors.Claude.Pricing["claude-opus-4-6"]; got.Input != 5 || got.Output != 25 {
+		t.Errorf("Claude.Pricing[claude-opus-4-6] = %+v, want 5/25", got)
+	}
+	if got := cfg.Collectors.Claude.DefaultPricing; got.Input != 15 || got.Output != 75 {
+		t.Errorf("Claude.DefaultPricing = %+v, want 15/75", got)
+	}
+	if cfg.TUI.Mouse {
+		t.Error("TUI.Mouse = true, want false")
+	}
+	if got := cfg.TUI.Keys[KeyNextTab]; len(got) != 2 || got[1] != "l" {
+		t.Errorf("TUI.Keys.next_tab = %v, want [tab l]", got)
+	}
+	if got := cfg.TUI.Keys[KeyHelp]; len(got) != 1 || got[0] != "?" {
+		t.Errorf("TUI.Keys.help = %v, want default [?]", got)
+	}
 	if cfg.Theme.Name != "catppuccin" {
 		t.Errorf("Theme.Name = %q, want %q", cfg.Theme.Name, "catppuccin")
 	}
+	if got := cfg.Theme.Terminals["Apple_Terminal"]; cfg.Theme.Dark != "tokyo-night" || got != "light" {
+		t.Errorf("Theme.Dark = %q, Terminals[Apple_Terminal] = %q; want tokyo-night, light", cfg.Theme.Dark, got)
+	}
+	if n := cfg.Notifications; len(n.Sinks) != 2 || len(n.Rules) != 2 {
+		t.Fatalf("Notifications = %d sinks, %d rules; want 2, 2", len(n.Sinks), len(n.Rules))
+	}
+	if s := cfg.Notifications.Sinks[1]; s.Type != "webhook" || s.Timeout.Duration != 5*time.Second {
+		t.Errorf("Notifications.Sinks[1] = %+v, want a webhook with a 5s timeout", s)
+	}
+	if r := cfg.Notifications.Rules[0]; r.Threshold != 90 || r.Cooldown.Duration != 30*time.Minute || len(r.Sinks) != 1 {
+		t.Errorf("Notifications.Rules[0] = %+v, want threshold 90, cooldown 30m, one sink", r)
+	}
 	if cfg.Image.Protocol != "kitty" {
 		t.Errorf("Image.Protocol = %q, want %q", cfg.Image.Protocol, "kitty")
 	}
+	b := cfg.Collectors.Billing
+	if b.Budgets["digitalocean"] != 50 || b.Budgets["hetzner"] != 25 {
+		t.Errorf("Billing.Budgets = %v, want digitalocean=50 hetzner=25", b.Budgets)
+	}
+	if _, ok := b.Budgets["civo"]; ok {
+		t.Error("Billing.Budgets should not contain civo")
+	}
+	if b.WarnPercent != 75 || b.CriticalPercent != 95 {
+		t.Errorf("thresholds = %v/%v, want 75/95", b.WarnPercent, b.CriticalPercent)
+	}
+	if b.AnomalyFactor != 3 || b.AnomalyMinDelta != 5 {
+		t.Errorf("anomaly = %vx/+%v, want 3x/+5", b.AnomalyFactor, b.AnomalyMinDelta)
+	}
+	if cc := b.Currency; cc.Display != "EUR" || cc.Source != "static" || cc.Rates["USD"] != 0.92 || cc.RatesTTL.Duration != 12*time.Hour {
+		t.Errorf("Billing.Currency = %+v, want EUR from static rates with USD = 0.92", cc)
+	}
+	k := cfg.Collectors.UptimeKuma
+	if !k.Enabled || k.URL != "https://status.example.com" || len(k.Tags) != 2 {
+		t.Errorf("UptimeKuma = %+v, want enabled with URL and 2 tags", k)
+	}
+	ts := cfg.Collectors.Tailscale
+	if ts.CLIPath != "/usr/local/bin/tailscale" || ts.KeyExpiryWarning.Duration != 72*time.Hour {
+		t.Errorf("Tailscale = %+v, want cli_path and 72h key expiry warning", ts)
+	}
+	if sm := cfg.Collectors.SysMetrics; sm.HistoryRetention.Duration != 12*time.Hour || sm.HistoryInterval.Duration != 30*time.Second {
+		t.Errorf("SysMetrics = %+v, want 12h of history every 30s", sm)
+	}
+	if p := cfg.Collectors.Billing.Vultr.Period; p != (BillingPeriodConfig{AnchorDay: 12, Timezone: "America/New_York"}) {
+		t.Errorf("Billing.Vultr.Period = %+v, want anchored on the 12th in New York", p)
+	}
+	g := cfg.Collectors.GPU
+	if !g.Enabled || g.Interval.Duration != 10*time.Second || g.NvidiaSMI != "/run/current-system/sw/bin/nvidia-smi" {
+		t.Errorf("GPU = %+v, want enabled every 10s with nvidia_smi", g)
+	}
+	st := cfg.Collectors.Storage
+	if !st.Enabled || len(st.IgnoreMounts) != 1 || st.MountTimeout.Duration != 500*time.Millisecond ||
+		st.WarnPercent != 80 || st.CriticalPercent != 90 || !st.SMART {
+		t.Errorf("Storage = %+v, want enabled with one ignored mount, 500ms timeout, 80/90%%, and SMART", st)
+	}
+	o := cfg.Collectors.Ollama
+	if !o.Enabled || o.Interval.Duration != 15*time.Second || o.URL != "http://gpu-box.lan:11434" {
+		t.Errorf("Ollama = %+v, want enabled every 15s with a URL", o)
+	}
+	d := cfg.Collectors.Docker
+	if !d.Enabled || d.Host != "unix:///run/user/1000/docker.sock" || d.Interval.Duration != 20*time.Second {
+		t.Errorf("Docker = %+v, want enabled with rootless socket and 20s interval", d)
+	}
+	sh := cfg.Collectors.Shell
+	if !sh.Enabled || sh.Interval.Duration != time.Minute || sh.Retention.Duration != 72*time.Hour {
+		t.Errorf("Shell = %+v, want enabled every 1m with 72h retention", sh)
+	}
+	w := cfg.Collectors.Weather
+	if !w.Enabled || w.Location != "Ithaca" || w.Units != "fahrenheit" || w.Interval.Duration != 45*time.Minute {
+		t.Errorf("Weather = %+v, want enabled for Ithaca in fahrenheit every 45m", w)
+	}
+	if cfg.General.CollectTimeout.Duration != 45*time.Second {
+		t.Errorf("CollectTimeout = %v, want 45s", cfg.General.CollectTimeout)
+	}
+	if cfg.General.ShutdownGrace.Duration != 5*time.Second {
+		t.Errorf("ShutdownGrace = %v, want 5s", cfg.General.ShutdownGrace)
+	}
+	if cfg.General.StaleAfterPolls != 3 || cfg.General.ExpireAfter.Duration != 12*time.Hour {
+		t.Errorf("StaleAfterPolls/ExpireAfter = %g/%v, want 3/12h", cfg.General.StaleAfterPolls, cfg.General.ExpireAfter)
+	}
+	cc := cfg.Collectors.Checks
+	if !cc.Enabled || cc.FailureThreshold != 3 || cc.Workers != 4 || len(cc.Checks) != 3 {
+		t.Errorf("Checks = %+v, want enabled with threshold 3, default workers, and 3 checks", cc)
+	} else if g := cc.Checks[1]; g.Type != "http" || g.ExpectStatus != 200 || g.ExpectBody != "ok" || g.Timeout.Duration != 3*time.Second {
+		t.Errorf("Checks[1] = %+v, want http check expecting 200 and \"ok\" with 3s timeout", g)
+	} else if g.Group != "monitoring" || !g.Critical || cc.Checks[2].Group != "monitoring" || cc.Checks[2].Critical || cc.Checks[0].Group != "" {
+		t.Errorf("Checks = %+v, want grafana and nas-ssh in group monitoring, grafana critical", cc.Checks)
+	}
+	rc := cfg.Collectors.Remote
+	if !rc.Enabled || rc.Timeout.Duration != 5*time.Second || rc.StrictHostKeyChecking != "accept-new" || rc.KnownHosts != "/etc/prompt-pulse/known_hosts" || len(rc.Hosts) != 2 {
+		t.Errorf("Remote = %+v, want enabled with a 5s timeout, accept-new, its own known_hosts, and 2 hosts", rc)
+	} else if h := rc.Hosts[0]; h.Name != "vps" || h.User != "monitor" || h.KeyFile != "/etc/prompt-pulse/id_ed25519" {
+		t.Errorf("Remote.Hosts[0] = %+v, want vps as monitor with a key file", h)
+	}
+	if h := cfg.HTTP; h.MaxRetries != 5 || h.RetryBaseDelay.Duration != time.Second || h.MaxRetryDelay.Duration != time.Minute ||
+		h.RateLimits["api.github.com"] != 1 || h.RateLimits["api.anthropic.com"] != 2.5 {
+		t.Errorf("HTTP = %+v, want 5 retries from 1s up to 1m and two rate limits", h)
+	}
+	if !cfg.Collectors.Kubernetes.Watch {
+		t.Error("Kubernetes.Watch should be true per config")
+	}
+	if cfg.Collectors.Kubernetes.UtilizationWarn != 80 {
+		t.Errorf("Kubernetes.UtilizationWarn = %g, want 80", cfg.Collectors.Kubernetes.UtilizationWarn)
+	}
+	if cfg.Image.MaxAnimationFrames != 32 {
+		t.Errorf("MaxAnimationFrames = %d, want 32", cfg.Image.MaxAnimationFrames)
+	}
+	if cfg.Image.SixelColors != 128 || cfg.Image.SixelDither != "ordered" {
+		t.Errorf("Sixel = %d/%q, want 128/ordered", cfg.Image.SixelColors, cfg.Image.SixelDither)
+	}
+	if cfg.Image.DiskCacheDir != "/tmp/pp-renders" {
+		t.Errorf("DiskCacheDir = %q, want /tmp/pp-renders", cfg.Image.DiskCacheDir)
+	}
+	if !cfg.Image.KittyRetransmit {
+		t.Error("KittyRetransmit should be true from testdata")
+	}
+	if ex := cfg.Collectors.Kubernetes.ExcludeContexts; len(ex) != 1 || ex[0] != "kind-*" {
+		t.Errorf("Kubernetes.ExcludeContexts = %v, want [kind-*]", ex)
+	}
+	if cfg.Collectors.Kubernetes.Kubeconfig != "/etc/prompt-pulse/kubeconfig" {
+		t.Errorf("Kubernetes.Kubeconfig = %q", cfg.Collectors.Kubernetes.Kubeconfig)
+	}
+	if got := cfg.Cache.Encrypt; len(got) != 2 || got[0] != "claude" || got[1] != "billing" {
+		t.Errorf("Cache.Encrypt = %v, want [claude billing]", got)
+	}
+	if cfg.Cache.KeyFile != "/run/secrets/prompt-pulse-cache-key" {
+		t.Errorf("Cache.KeyFile = %q", cfg.Cache.KeyFile)
+	}
+	sum := cfg.Starship.Summary
+	if len(sum.Segments) != 2 || sum.Segments[0] != "infra" || sum.Separator != " · " || sum.MaxWidth != 48 {
+		t.Errorf("Starship.Summary = %+v", sum)
+	}
+	if cfg.Image.WaifuURL != "https://api.waifu.pics/sfw/{category}" || cfg.Image.WaifuMaxCacheMB != 25 {
+		t.Errorf("Image.WaifuURL = %q, WaifuMaxCacheMB = %d", cfg.Image.WaifuURL, cfg.Image.WaifuMaxCacheMB)
+	}
+	if w := cfg.Image.WaifuWeights; len(w) != 2 || w["favorites"] != 3 || w["sfw/seasonal"] != 0.5 {
+		t.Errorf("Image.WaifuWeights = %v", w)
+	}
+	if cfg.Image.BlockMode != "quadrants" || cfg.Image.Background != "#fdf6e3" {
+		t.Errorf("Image.BlockMode = %q, Background = %q", cfg.Image.BlockMode, cfg.Image.Background)
+	}
+	if cfg.Image.WaifuCrop != "entropy" || cfg.Image.WaifuPad != "#1e1e2e" || cfg.Image.WaifuCornerRadius != 12 {
+		t.Errorf("Image.WaifuCrop = %q, WaifuPad = %q, WaifuCornerRadius = %d",
+			cfg.Image.WaifuCrop, cfg.Image.WaifuPad, cfg.Image.WaifuCornerRadius)
+	}
+	th := cfg.Starship.Thresholds
+	if th.Claude != (ThresholdConfig{Warn: 60, Critical: 90}) || th.Billing != (ThresholdConfig{Warn: 75, Critical: 100}) {
+		t.Errorf("Starship.Thresholds = %+v", th)
+	}
+	if th.System != (ThresholdConfig{Warn: 50, Critical: 80}) {
+		t.Errorf("Starship.Thresholds.System = %+v, want defaults", th.System)
+	}
+	if cfg.Starship.Wrap != "zsh" {
+		t.Errorf("Starship.Wrap = %q, want zsh", cfg.Starship.Wrap)
+	}
+	if cfg.Starship.ClaudeDisplay != "both" {
+		t.Errorf("Starship.ClaudeDisplay = %q, want both", cfg.Starship.ClaudeDisplay)
+	}
+	if cfg.Starship.Budget.Duration != 80*time.Millisecond {
+		t.Errorf("Starship.Budget = %s, want 80ms", cfg.Starship.Budget)
+	}
+	if w := cfg.Starship.MaxWidth; len(w) != 2 || w["claude"] != 30 || w["infra"] != 40 {
+		t.Errorf("Starship.MaxWidth = %v, want claude 30, infra 40", w)
+	}
+	want := []BannerColumnConfig{{Name: "status", Width: 30}, {Name: "waifu"}}
+	if got := cfg.Banner.Columns; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Banner.Columns = %+v, want %+v", got, want)
+	}
+	if cfg.Banner.Fastfetch.Mode != "fastfetch" {
+		t.Errorf("Fastfetch.Mode = %q, want fastfetch", cfg.Banner.Fastfetch.Mode)
+	}
+	if !cfg.Banner.BillingBreakdown {
+		t.Error("Banner.BillingBreakdown = false, want true")
+	}
+	if !cfg.Banner.ShowLastEvent {
+		t.Error("Banner.ShowLastEvent = false, want true")
+	}
+	if !cfg.Banner.ShowShellActivity {
+		t.Error("Banner.ShowShellActivity = false, want true")
+	}
+	if cfg.Banner.StackBelowWidth != 64 {
+		t.Errorf("StackBelowWidth = %d, want 64", cfg.Banner.StackBelowWidth)
+	}
+	if got := strings.Join(cfg.Banner.StackOrder, ","); got != "fastfetch,status" {
+		t.Errorf("StackOrder = %q, want fastfetch,status", got)
+	}
 }
 
 func TestLoadFromFile_TestdataMinimal(t *testing.T) {
@@ -708,3 +1273,764 @@ func assertChild(t *testing.T, c ChildConfig, wantType string, wantRatio int) {
 		t.Errorf("child %q ratio = %d, want %d", c.Type, c.Ratio, wantRatio)
 	}
 }
+
+func TestLoadFromReader_ClaudeAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"labeled", "[[collectors.claude.account]]\nname = \"personal\"\n[[collectors.claude.account]]\nname = \"work\"\ncredentials = \"~/.claude-work/.credentials.json\"\n", ""},
+		{"missing name", "[[collectors.claude.account]]\ncredentials = \"~/.claude/.credentials.json\"\n", "collectors.claude.account[0]: name is required"},
+		{"duplicate name", "[[collectors.claude.account]]\nname = \"work\"\n[[collectors.claude.account]]\nname = \"work\"\n", `collectors.claude.account[1]: duplicate name "work"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_ClaudePricing(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"override", "[collectors.claude.pricing.\"claude-opus-4-6\"]\ninput = 5.0\noutput = 25.0\n", ""},
+		{"default", "[collectors.claude.default_pricing]\ninput = 15.0\noutput = 75.0\n", ""},
+		{"negative", "[collectors.claude.pricing.\"claude-opus-4-6\"]\ninput = -1.0\n", `collectors.claude.pricing."claude-opus-4-6": prices must not be negative`},
+		{"negative default", "[collectors.claude.default_pricing]\ncache_read = -0.1\n", "collectors.claude.default_pricing: prices must not be negative"},
+		{"empty model", "[collectors.claude.pricing.\"\"]\ninput = 1.0\n", "collectors.claude.pricing: model name is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_StarshipSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"ordered", "[starship.summary]\nsegments = [\"infra\", \"claude\"]\n", ""},
+		{"unknown segment", "[starship.summary]\nsegments = [\"claude\", \"spotify\"]\n", `starship.summary.segments[1]: unknown segment "spotify"`},
+		{"duplicate segment", "[starship.summary]\nsegments = [\"claude\", \"claude\"]\n", `starship.summary.segments[1]: duplicate segment "claude"`},
+		{"negative width", "[starship.summary]\nmax_width = -1\n", "starship.summary.max_width: must not be negative"},
+		{"thresholds", "[starship.thresholds.system]\nwarn = 70\ncritical = 95\n", ""},
+		{"warn above critical", "[starship.thresholds.claude]\nwarn = 90\ncritical = 60\n", "starship.thresholds.claude: warn (90) is above critical (60)"},
+		{"negative threshold", "[starship.thresholds.billing]\nwarn = -5\n", "starship.thresholds.billing: thresholds must not be negative"},
+		{"wrap", "[starship]\nwrap = \"bash\"\n", ""},
+		{"unknown wrap", "[starship]\nwrap = \"fish\"\n", `starship.wrap: unsupported shell "fish"`},
+		{"claude display", "[starship]\nclaude_display = \"tokens\"\n", ""},
+		{"unknown claude display", "[starship]\nclaude_display = \"percent\"\n", `starship.claude_display: unknown mode "percent"`},
+		{"module widths", "[starship.max_width]\nclaude = 30\nall = 80\n", ""},
+		{"unknown module width", "[starship.max_width]\nspotify = 30\n", `starship.max_width: unknown module "spotify"`},
+		{"summary module width", "[starship.max_width]\nsummary = 30\n", "starship.max_width.summary: set starship.summary.max_width instead"},
+		{"negative module width", "[starship.max_width]\ninfra = -1\n", "starship.max_width.infra: must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_Storage(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"thresholds", "[collectors.storage]\nwarn_percent = 70\ncritical_percent = 80\n", ""},
+		{"warn above critical", "[collectors.storage]\nwarn_percent = 99\n", "collectors.storage: warn_percent (99) is above critical_percent (95)"},
+		{"bad pattern", "[collectors.storage]\nignore_mounts = [\"/snap/[\"]\n", `collectors.storage.ignore_mounts[0]: invalid pattern "/snap/["`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_SysMetricsHistory(t *testing.T) {
+	cfg, err := LoadFromReader(strings.NewReader("[collectors.sysmetrics]\nhistory_retention = \"0s\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Collectors.SysMetrics.HistoryRetention.Duration != 0 {
+		t.Errorf("HistoryRetention = %v, want 0 to keep no history", cfg.Collectors.SysMetrics.HistoryRetention)
+	}
+	_, err = LoadFromReader(strings.NewReader("[collectors.sysmetrics]\nhistory_interval = \"-1s\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "negative duration") {
+		t.Errorf("err = %v, want the negative interval rejected", err)
+	}
+}
+
+func TestLoadFromReader_Currency(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"static rates", "[collectors.billing.currency]\ndisplay = \"GBP\"\nsource = \"static\"\n[collectors.billing.currency.rates]\nEUR = 0.86\n", ""},
+		{"lower-case display", "[collectors.billing.currency]\ndisplay = \"usd\"\n", `collectors.billing.currency.display: must be a three-letter ISO 4217 code such as "USD", got "usd"`},
+		{"bad source", "[collectors.billing.currency]\nsource = \"imf\"\n", `collectors.billing.currency.source: must be "ecb" or "static", got "imf"`},
+		{"bad rate code", "[collectors.billing.currency.rates]\neuro = 1.08\n", `collectors.billing.currency.rates: "euro" is not a three-letter ISO 4217 code`},
+		{"zero rate", "[collectors.billing.currency.rates]\nEUR = 0.0\n", "collectors.billing.currency.rates.EUR: must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_BillingPeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"anchor", "[collectors.billing.civo.period]\nanchor_day = 31\ntimezone = \"UTC\"\n", ""},
+		{"cycle", "[collectors.billing.hetzner.period]\ncycle_days = 14\nstart = \"2026-01-05\"\n", ""},
+		{"anchor too late", "[collectors.billing.civo.period]\nanchor_day = 32\n", "collectors.billing.civo.period.anchor_day: must be 1-31, got 32"},
+		{"cycle too long", "[collectors.billing.vultr.period]\ncycle_days = 45\nstart = \"2026-01-05\"\n", "collectors.billing.vultr.period.cycle_days: must be 1-31, got 45"},
+		{"both", "[collectors.billing.digitalocean.period]\nanchor_day = 12\ncycle_days = 14\nstart = \"2026-01-05\"\n", "collectors.billing.digitalocean.period: set anchor_day or cycle_days, not both"},
+		{"cycle without start", "[collectors.billing.civo.period]\ncycle_days = 14\n", "collectors.billing.civo.period.start: required with cycle_days"},
+		{"bad start", "[collectors.billing.civo.period]\ncycle_days = 14\nstart = \"Jan 5\"\n", `collectors.billing.civo.period.start: must be a date such as "2026-01-12", got "Jan 5"`},
+		{"bad timezone", "[collectors.billing.civo.period]\ntimezone = \"Mars/Olympus\"\n", "collectors.billing.civo.period.timezone:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_BillingAnomaly(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"factor", "[collectors.billing]\nanomaly_factor = 1.5\nanomaly_min_delta = 20.0\n", ""},
+		{"disabled", "[collectors.billing]\nanomaly_factor = 0.0\n", ""},
+		{"factor too small", "[collectors.billing]\nanomaly_factor = 0.5\n", "collectors.billing.anomaly_factor: must be above 1, or 0 to disable, got 0.5"},
+		{"negative delta", "[collectors.billing]\nanomaly_min_delta = -1.0\n", "collectors.billing.anomaly_min_delta: must not be negative, got -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_Log(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"json to file", "[log]\nformat = \"json\"\nfile = \"/tmp/pp.log\"\nmax_size_mb = 0\n", ""},
+		{"bad format", "[log]\nformat = \"logfmt\"\n", `log.format: unsupported format "logfmt"`},
+		{"negative size", "[log]\nmax_size_mb = -1\n", "log: max_size_mb, max_age, and max_files must not be negative"},
+		{"bad level", "[general]\nlog_level = \"verbose\"\n", `general.log_level: unsupported level "verbose"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_BannerStack(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"waifu thumbnail", "[banner]\nstack_below_width = 80\nstack_order = [\"waifu\", \"status\"]\n", ""},
+		{"negative width", "[banner]\nstack_below_width = -1\n", "banner.stack_below_width: must not be negative"},
+		{"unknown section", "[banner]\nstack_order = [\"status\", \"clock\"]\n", `banner.stack_order[1]: unknown section "clock"`},
+		{"duplicate section", "[banner]\nstack_order = [\"status\", \"status\"]\n", `banner.stack_order[1]: "status" listed twice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_NegativeWaifuWeight(t *testing.T) {
+	_, err := LoadFromReader(strings.NewReader("[image.waifu_weights]\nfavorites = -1.0\n"))
+	if err == nil || !strings.Contains(err.Error(), "image.waifu_weights.favorites: weight must not be negative") {
+		t.Errorf("err = %v, want negative weight error", err)
+	}
+}
+
+func TestLoadFromReader_ImageBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"quadrants", "[image]\nblock_mode = \"quadrants\"\nbackground = \"#FFFFFF\"\n", ""},
+		{"unknown mode", "[image]\nblock_mode = \"braille\"\n", `image.block_mode: must be "halfblocks" or "quadrants"`},
+		{"short color", "[image]\nbackground = \"#fff\"\n", "image.background: must be a color"},
+		{"named color", "[image]\nbackground = \"white\"\n", "image.background: must be a color"},
+		{"waifu preprocessing", "[image]\nwaifu_crop = \"center\"\nwaifu_pad = \"theme\"\nwaifu_corner_radius = 8\n", ""},
+		{"unknown crop", "[image]\nwaifu_crop = \"smart\"\n", `image.waifu_crop: must be "none", "center", or "entropy"`},
+		{"unknown pad", "[image]\nwaifu_pad = \"blur\"\n", "image.waifu_pad: must be"},
+		{"negative radius", "[image]\nwaifu_corner_radius = -1\n", "image.waifu_corner_radius: must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_Checks(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"valid", "[[collectors.checks.check]]\nname = \"web\"\ntype = \"http\"\ntarget = \"https://example.com\"\n[[collectors.checks.check]]\nname = \"db\"\ntype = \"tcp\"\ntarget = \"[::1]:5432\"\n", ""},
+		{"missing name", "[[collectors.checks.check]]\ntype = \"ping\"\ntarget = \"a\"\n", "collectors.checks.check[0]: name is required"},
+		{"duplicate name", "[[collectors.checks.check]]\nname = \"a\"\ntype = \"ping\"\ntarget = \"a\"\n[[collectors.checks.check]]\nname = \"a\"\ntype = \"ping\"\ntarget = \"b\"\n", `collectors.checks.check[1]: duplicate name "a"`},
+		{"unknown type", "[[collectors.checks.check]]\nname = \"a\"\ntype = \"dns\"\ntarget = \"a\"\n", `type must be "http", "tcp", or "ping"`},
+		{"tcp without port", "[[collectors.checks.check]]\nname = \"a\"\ntype = \"tcp\"\ntarget = \"nas.lan\"\n", "target must be host:port"},
+		{"http without scheme", "[[collectors.checks.check]]\nname = \"a\"\ntype = \"http\"\ntarget = \"example.com/health\"\n", "target must be an http(s) URL"},
+		{"expect on ping", "[[collectors.checks.check]]\nname = \"a\"\ntype = \"ping\"\ntarget = \"a\"\nexpect_status = 200\n", "apply only to http checks"},
+		{"negative workers", "[collectors.checks]\nworkers = -1\n", "collectors.checks.workers: must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_Remote(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"valid", "[[collectors.remote.host]]\naddress = \"vps.example.com\"\n[[collectors.remote.host]]\nname = \"backup\"\naddress = \"[2001:db8::1]:2222\"\n", ""},
+		{"missing address", "[[collectors.remote.host]]\nname = \"vps\"\n", "collectors.remote.host[0]: address is required"},
+		{"duplicate name", "[[collectors.remote.host]]\naddress = \"vps\"\n[[collectors.remote.host]]\nname = \"vps\"\naddress = \"vps:2222\"\n", `collectors.remote.host[1]: duplicate name "vps"`},
+		{"unknown checking", "[collectors.remote]\nstrict_host_key_checking = \"no\"\n", `must be "yes" or "accept-new"`},
+		{"negative threshold", "[collectors.remote]\nfailure_threshold = -1\n", "collectors.remote.failure_threshold: must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_HTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"valid", "[http]\nmax_retries = 0\n[http.rate_limits]\n\"api.github.com\" = 0.5\n", ""},
+		{"negative retries", "[http]\nmax_retries = -1\n", "http.max_retries: must not be negative"},
+		{"negative delay", "[http]\nretry_base_delay = \"-1s\"\n", "negative duration"},
+		{"negative rate", "[http.rate_limits]\n\"api.github.com\" = -2\n", "http.rate_limits.api.github.com: must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_K8sUtilizationWarn(t *testing.T) {
+	cfg, err := LoadFromReader(strings.NewReader("[collectors.kubernetes]\nutilization_warn = 0\n"))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error: %v", err)
+	}
+	if cfg.Collectors.Kubernetes.UtilizationWarn != 0 {
+		t.Errorf("UtilizationWarn = %g, want 0 to disable the warning", cfg.Collectors.Kubernetes.UtilizationWarn)
+	}
+	_, err = LoadFromReader(strings.NewReader("[collectors.kubernetes]\nutilization_warn = -5\n"))
+	if err == nil || !strings.Contains(err.Error(), "collectors.kubernetes.utilization_warn: must not be negative") {
+		t.Errorf("err = %v, want a negative utilization_warn rejected", err)
+	}
+}
+
+func TestLoadFromReader_Notifications(t *testing.T) {
+	const desktop = "[[notifications.sink]]\nname = \"desk\"\ntype = \"desktop\"\n"
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"valid", desktop + "[[notifications.sink]]\nname = \"hook\"\ntype = \"webhook\"\nurl = \"https://example.com/hook\"\n[[notifications.rule]]\nname = \"budget\"\nevent = \"billing_budget\"\nthreshold = 90\ncooldown = \"1h\"\nsinks = [\"hook\"]\n", ""},
+		{"sinks without rules", desktop, ""},
+		{"missing sink name", "[[notifications.sink]]\ntype = \"desktop\"\n", "notifications.sink[0]: name is required"},
+		{"duplicate sink", desktop + desktop, `notifications.sink[1]: duplicate name "desk"`},
+		{"unknown sink type", "[[notifications.sink]]\nname = \"a\"\ntype = \"email\"\n", `type must be "desktop", "webhook", or "command"`},
+		{"webhook without url", "[[notifications.sink]]\nname = \"a\"\ntype = \"webhook\"\n", "url must be an http(s) URL"},
+		{"command without command", "[[notifications.sink]]\nname = \"a\"\ntype = \"command\"\n", "command is required"},
+		{"unknown event", desktop + "[[notifications.rule]]\nname = \"a\"\nevent = \"disk_full\"\n", `event must be "billing_budget"`},
+		{"unknown rule sink", desktop + "[[notifications.rule]]\nname = \"a\"\nevent = \"check_down\"\nsinks = [\"pager\"]\n", `unknown sink "pager"`},
+		{"rule without sinks", "[[notifications.rule]]\nname = \"a\"\nevent = \"check_down\"\n", "no notifications.sink is configured"},
+		{"negative threshold", desktop + "[[notifications.rule]]\nname = \"a\"\nevent = \"claude_window\"\nthreshold = -5\n", "threshold must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_TUIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"override", "[tui.keys]\nquit = [\"q\", \"ctrl+c\"]\nrefresh = [\"f5\", \"alt+r\"]\n", ""},
+		{"conflict with default", "[tui.keys]\nsearch = [\"q\"]\n", `key "q" is bound to both quit and search`},
+		{"conflict within override", "[tui.keys]\nhelp = [\"x\"]\nrefresh = [\"x\"]\n", `key "x" is bound to both help and refresh`},
+		{"unknown action", "[tui.keys]\njump = [\"g\"]\n", `unknown action "jump"`},
+		{"empty binding", "[tui.keys]\nquit = []\n", "tui.keys.quit: no keys bound"},
+		{"invalid key", "[tui.keys]\nquit = [\"ctrl+shift+q\"]\n", `tui.keys.quit: invalid key "ctrl+shift+q"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.toml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_Events(t *testing.T) {
+	cfg, err := LoadFromReader(strings.NewReader("[events]\nenabled = false\npod_jitter = 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Events.Enabled || cfg.Events.PodJitter != 0 || cfg.Events.MaxEntries != 200 {
+		t.Errorf("Events = %+v, want disabled with no pod jitter and the default max_entries", cfg.Events)
+	}
+	for _, toml := range []string{"max_entries = -1", "billing_min_delta = -0.5", "claude_min_delta = -1", "pod_jitter = -1"} {
+		_, err := LoadFromReader(strings.NewReader("[events]\n" + toml + "\n"))
+		if err == nil || !strings.Contains(err.Error(), "events:") {
+			t.Errorf("%s: err = %v, want an events error", toml, err)
+		}
+	}
+}
+
+func TestLoadFromReader_HostProfile(t *testing.T) {
+	SetProfileResolver(func(name, hostname string) (HostProfile, bool) {
+		if name == "honey" || hostname == "honey" {
+			return HostProfile{Name: "honey", Features: []string{"tailscale", "k8s", "claude", "sysmetrics", "gpu", "ghostty"}}, true
+		}
+		return HostProfile{}, false
+	})
+	t.Cleanup(func() { SetProfileResolver(nil) })
+
+	cfg, err := LoadFromReader(strings.NewReader("[general]\nhost_profile = \"honey\"\n[collectors.claude]\nenabled = false\n[collectors.weather]\nenabled = true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"gpu", "kubernetes", "sysmetrics", "tailscale", "weather"}
+	if got := cfg.EnabledCollectors(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("EnabledCollectors() = %v, want %v", got, want)
+	}
+	if r := cfg.CollectorReason("kubernetes"); r != ReasonProfile {
+		t.Errorf("kubernetes reason = %q, want %q", r, ReasonProfile)
+	}
+	if r := cfg.CollectorReason("ollama"); r != ReasonProfile {
+		t.Errorf("ollama reason = %q, want %q for a collector the profile leaves out", r, ReasonProfile)
+	}
+	if r := cfg.CollectorReason("claude"); r != ReasonExplicit {
+		t.Errorf("claude reason = %q, want %q", r, ReasonExplicit)
+	}
+	if cfg.Image.WaifuEnabled {
+		t.Error("WaifuEnabled = true, want the profile's default of off")
+	}
+	p := cfg.Profile()
+	if p == nil || p.Name != "honey" || p.Source != ProfileFromConfig {
+		t.Fatalf("Profile() = %+v, want honey from the config", p)
+	}
+	if strings.Join(p.Applied, ",") != "gpu,k8s,sysmetrics,tailscale" || strings.Join(p.Overridden, ",") != "claude" || strings.Join(p.Unknown, ",") != "ghostty" {
+		t.Errorf("Profile() = %+v", p)
+	}
+
+	if _, err := LoadFromReader(strings.NewReader("[general]\nhost_profile = \"nope\"\n")); err == nil || !strings.Contains(err.Error(), "general.host_profile") {
+		t.Errorf("unknown profile: err = %v", err)
+	}
+	cfg, err = LoadFromReader(strings.NewReader("[general]\nhost_profile = \"none\"\n"))
+	if err != nil || cfg.Profile() != nil || cfg.CollectorReason("sysmetrics") != ReasonDefault {
+		t.Errorf("host_profile = none: profile %+v, %v", cfg.Profile(), err)
+	}
+}
+
+func TestResolveProfile_Hostname(t *testing.T) {
+	SetProfileResolver(func(name, hostname string) (HostProfile, bool) {
+		return HostProfile{Name: hostname}, name == "" && hostname == "honey"
+	})
+	t.Cleanup(func() { SetProfileResolver(nil) })
+
+	cfg := DefaultConfig()
+	p, source, err := resolveProfile(cfg, func() (string, error) { return "honey", nil })
+	if err != nil || p == nil || p.Name != "honey" || source != ProfileFromHostname {
+		t.Errorf("resolveProfile() = %+v, %q, %v; want honey from the hostname", p, source, err)
+	}
+	if p, _, err := resolveProfile(cfg, func() (string, error) { return "other", nil }); err != nil || p != nil {
+		t.Errorf("unmatched hostname: %+v, %v", p, err)
+	}
+}
+
+func TestLoadFromReader_Validation(t *testing.T) {
+	cfg, err := LoadFromReader(strings.NewReader("[validation]\nenabled = false\nbilling_max_factor = 0.0\n"))
+	if err != nil {
+		t.Fatalf("disabled validation should not need a factor: %v", err)
+	}
+	if cfg.Validation.Enabled || cfg.Validation.ClaudeWindow.Duration != time.Hour {
+		t.Errorf("Validation = %+v, want disabled with the default claude_window", cfg.Validation)
+	}
+	for toml, want := range map[string]string{
+		"billing_max_factor = 1.0": "validation.billing_max_factor",
+		`claude_window = "-1m"`:    "validation.claude_window",
+	} {
+		_, err := LoadFromReader(strings.NewReader("[validation]\n" + toml + "\n"))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want containing %q", toml, err, want)
+		}
+	}
+}
+
+func TestLoadFromReader_StatusPage(t *testing.T) {
+	if _, err := LoadFromReader(strings.NewReader("[status_page]\npath = \"/tmp/status.html\"\ninterval = \"1m\"\n")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err := LoadFromReader(strings.NewReader("[status_page]\ninterval = \"1m\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "status_page.interval") {
+		t.Errorf("err = %v, want status_page.interval to need a path", err)
+	}
+}
+
+func TestLoadFromReader_CollectorResolution(t *testing.T) {
+	for _, env := range []string{"ANTHROPIC_ADMIN_KEY", "CIVO_TOKEN", "CIVO_TOKEN_FILE", "UPTIME_KUMA_API_KEY", "UPTIME_KUMA_API_KEY_FILE"} {
+		t.Setenv(env, "")
+	}
+	tests := []struct {
+		name       string
+		toml       string
+		key        string
+		wantOn     bool
+		wantReason string
+	}{
+		{"default on", "", "sysmetrics", true, ReasonDefault},
+		{"default off", "", "docker", false, ReasonDefault},
+		{"explicit off", "[collectors.sysmetrics]\nenabled = false\n", "sysmetrics", false, ReasonExplicit},
+		{"claude without accounts", "", "claude", false, ReasonNoCredentials},
+		{"claude with account", "[[collectors.claude.account]]\nname = \"work\"\nsessions_dir = \"/tmp/projects\"\n", "claude", true, ReasonCredentials},
+		{"claude explicit without accounts", "[collectors.claude]\nenabled = true\n", "claude", true, ReasonExplicit + ", " + ReasonNoCredentials},
+		{"billing key but provider off", "[collectors.billing.civo]\napi_key = \"k\"\n", "billing", false, ReasonNoCredentials},
+		{"billing provider with key", "[collectors.billing.civo]\nenabled = true\napi_key = \"k\"\n", "billing", true, ReasonCredentials},
+		{"billing explicit off", "[collectors.billing]\nenabled = false\n[collectors.billing.civo]\nenabled = true\napi_key = \"k\"\n", "billing", false, ReasonExplicit},
+		{"uptimekuma url", "[collectors.uptimekuma]\nurl = \"http://kuma:3001\"\n", "uptimekuma", true, ReasonCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadFromReader(strings.NewReader(tt.toml))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			on := map[string]bool{
+				"sysmetrics": cfg.Collectors.SysMetrics.Enabled,
+				"docker":     cfg.Collectors.Docker.Enabled,
+				"claude":     cfg.Collectors.Claude.Enabled,
+				"billing":    cfg.Collectors.Billing.Enabled,
+				"uptimekuma": cfg.Collectors.UptimeKuma.Enabled,
+			}[tt.key]
+			if on != tt.wantOn {
+				t.Errorf("%s enabled = %v, want %v", tt.key, on, tt.wantOn)
+			}
+			if got := cfg.CollectorReason(tt.key); got != tt.wantReason {
+				t.Errorf("CollectorReason(%q) = %q, want %q", tt.key, got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_CollectorCredentialsFromEnv(t *testing.T) {
+	t.Setenv("CIVO_TOKEN", "from-env")
+	cfg, err := LoadFromReader(strings.NewReader("[collectors.billing.civo]\nenabled = true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Collectors.Billing.Enabled || cfg.CollectorReason("billing") != ReasonCredentials {
+		t.Errorf("billing = %v (%s), want enabled by the CIVO_TOKEN credentials", cfg.Collectors.Billing.Enabled, cfg.CollectorReason("billing"))
+	}
+}
+
+func TestExpandString(t *testing.T) {
+	env := map[string]string{"HOST": "tinyland", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		in, want, wantErr string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "/srv/${HOST}/cache", want: "/srv/tinyland/cache"},
+		{in: "${HOST}${HOST}", want: "tinylandtinyland"},
+		{in: "${MISSING:-/tmp}", want: "/tmp"},
+		{in: "${EMPTY:-fallback}", want: "fallback"},
+		{in: "${EMPTY}", want: ""},
+		{in: "${MISSING:-}", want: ""},
+		{in: "cost $$5 ${HOST}", want: "cost $5 tinyland"},
+		{in: "$$${HOST}", want: "$tinyland"},
+		{in: "$HOST and $", want: "$HOST and $"},
+		{in: "${MISSING}", wantErr: "${MISSING} is not set"},
+		{in: "${HOST", wantErr: "unterminated"},
+		{in: "${1X}", wantErr: "invalid variable reference"},
+	}
+	for _, tt := range tests {
+		got, err := expandString(tt.in, lookup)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expandString(%q) err = %v, want containing %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("expandString(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadFromReader_EnvExpansion(t *testing.T) {
+	t.Setenv("PP_TEST_HOST", "tinyland")
+	t.Setenv("PP_TEST_CIVO", "civo-secret")
+	t.Setenv("CIVO_TOKEN", "")
+	t.Setenv("CIVO_TOKEN_FILE", "")
+
+	input := `
+[general]
+cache_dir = "/var/cache/${PP_TEST_HOST}"
+
+[collectors.kubernetes]
+kubeconfig = "${PP_TEST_KUBECONFIG:-/etc/kube/config}"
+contexts = ["${PP_TEST_HOST}-prod", "literal-$$"]
+
+[collectors.billing.civo]
+api_key = "${PP_TEST_CIVO}"
+
+[[collectors.claude.account]]
+name = "work"
+credentials = "/home/${PP_TEST_HOST}/.claude/.credentials.json"
+
+[tui.keys]
+quit = ["${PP_TEST_QUIT:-q}"]
+`
+	cfg, err := LoadFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error: %v", err)
+	}
+	if cfg.General.CacheDir != "/var/cache/tinyland" {
+		t.Errorf("CacheDir = %q", cfg.General.CacheDir)
+	}
+	k := cfg.Collectors.Kubernetes
+	if k.Kubeconfig != "/etc/kube/config" {
+		t.Errorf("Kubeconfig = %q, want the default", k.Kubeconfig)
+	}
+	if len(k.Contexts) != 2 || k.Contexts[0] != "tinyland-prod" || k.Contexts[1] != "literal-$" {
+		t.Errorf("Contexts = %q", k.Contexts)
+	}
+	if cfg.Collectors.Billing.Civo.APIKey != "civo-secret" {
+		t.Errorf("Civo.APIKey = %q", cfg.Collectors.Billing.Civo.APIKey)
+	}
+	if got := cfg.Collectors.Claude.Accounts[0].Credentials; got != "/home/tinyland/.claude/.credentials.json" {
+		t.Errorf("Accounts[0].Credentials = %q", got)
+	}
+	if got := cfg.TUI.Keys["quit"]; len(got) != 1 || got[0] != "q" {
+		t.Errorf("TUI.Keys[quit] = %q", got)
+	}
+}
+
+func TestLoadFromReader_EnvExpansionErrorNamesKey(t *testing.T) {
+	tests := []struct {
+		input, wantKey string
+	}{
+		{"[collectors.billing.hetzner]\napi_key = \"${PP_TEST_UNSET}\"", "collectors.billing.hetzner.api_key"},
+		{"[collectors.kubernetes]\nnamespaces = [\"default\", \"${PP_TEST_UNSET}\"]", "collectors.kubernetes.namespaces[1]"},
+		{"[[collectors.claude.account]]\nname = \"${PP_TEST_UNSET}\"", "collectors.claude.account[0].name"},
+	}
+	for _, tt := range tests {
+		_, err := LoadFromReader(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.wantKey+": ${PP_TEST_UNSET} is not set") {
+			t.Errorf("err = %v, want naming %s", err, tt.wantKey)
+		}
+	}
+}
+
+func TestConfigSecrets(t *testing.T) {
+	cfg, err := LoadFromReader(strings.NewReader(`
+[cache]
+key_file = "/run/secrets/cache.key"
+
+[collectors.billing.civo]
+api_key = "civo-secret-123"
+
+[[collectors.claude.account]]
+name = "work"
+admin_key = "sk-ant-admin-456"
+`))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error: %v", err)
+	}
+	got := cfg.Secrets()
+	slices.Sort(got)
+	if want := []string{"civo-secret-123", "sk-ant-admin-456"}; !slices.Equal(got, want) {
+		t.Errorf("Secrets() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// TUI key actions. Each action is bound to one or more key names as
+// bubbletea reports them, e.g. "q", "ctrl+c", "shift+tab".
+const (
+	KeyQuit    = "quit"
+	KeyHelp    = "help"
+	KeySearch  = "search"
+	KeyRefresh = "refresh"
+	KeyNextTab = "next_tab"
+	KeyPrevTab = "prev_tab"
+	KeyExpand  = "expand"
+	KeyBack    = "back"
+)
+
+// TUIKeyActions lists every rebindable action in help-overlay order.
+var TUIKeyActions = []string{
+	KeyNextTab, KeyPrevTab, KeyExpand, KeyBack,
+	KeyRefresh, KeySearch, KeyHelp, KeyQuit,
+}
+
+// DefaultTUIKeys returns the default binding for every action.
+func DefaultTUIKeys() map[string][]string {
+	return map[string][]string{
+		KeyQuit:    {"q"},
+		KeyHelp:    {"?"},
+		KeySearch:  {"/"},
+		KeyRefresh: {"r"},
+		KeyNextTab: {"tab", "l", "j"},
+		KeyPrevTab: {"shift+tab", "h", "k"},
+		KeyExpand:  {"enter"},
+		KeyBack:    {"esc"},
+	}
+}
+
+// keyNames are the multi-character key names bubbletea reports.
+var keyNames = map[string]bool{
+	"tab": true, "shift+tab": true, "enter": true, "esc": true,
+	"backspace": true, "delete": true, "insert": true, "home": true,
+	"end": true, "pgup": true, "pgdown": true, "up": true, "down": true,
+	"left": true, "right": true, "f1": true, "f2": true, "f3": true,
+	"f4": true, "f5": true, "f6": true, "f7": true, "f8": true, "f9": true,
+	"f10": true, "f11": true, "f12": true,
+}
+
+// validKeyName reports whether name is a key bubbletea can report: a single
+// character, a named key, "ctrl+" plus a letter, or "alt+" plus any of
+// those.
+func validKeyName(name string) bool {
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok {
+		return validKeyName(rest)
+	}
+	if rest, ok := strings.CutPrefix(name, "ctrl+"); ok {
+		return len(rest) == 1 && rest[0] >= 'a' && rest[0] <= 'z'
+	}
+	return utf8.RuneCountInString(name) == 1 || keyNames[name]
+}
+
+// validateTUIKeys checks that every action is known and bound to at least
+// one valid key, and that no key is bound to two actions.
+func validateTUIKeys(keys map[string][]string) error {
+	known := make(map[string]bool, len(TUIKeyActions))
+	for _, a := range TUIKeyActions {
+		known[a] = true
+	}
+
+	// Sorted for a deterministic error when several problems exist.
+	actions := make([]string, 0, len(keys))
+	for a := range keys {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+
+	owner := make(map[string]string)
+	for _, action := range actions {
+		if !known[action] {
+			return fmt.Errorf("tui.keys: unknown action %q (valid: %s)", action, strings.Join(TUIKeyActions, ", "))
+		}
+		if len(keys[action]) == 0 {
+			return fmt.Errorf("tui.keys.%s: no keys bound", action)
+		}
+		for _, k := range keys[action] {
+			if !validKeyName(k) {
+				return fmt.Errorf("tui.keys.%s: invalid key %q", action, k)
+			}
+			if prev, ok := owner[k]; ok && prev != action {
+				return fmt.Errorf("tui.keys: key %q is bound to both %s and %s", k, prev, action)
+			}
+			owner[k] = action
+		}
+	}
+	return nil
+}
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -14,23 +20,41 @@ import (
 //  1. $XDG_CONFIG_HOME/prompt-pulse/config.toml
 //  2. ~/.config/prompt-pulse/config.toml
 //
-// If no file exists, returns DefaultConfig().
+// $PPULSE_CONFIG, when set, replaces both.
+//
+// If no file exists, returns DefaultConfig() with the environment
+// overrides applied.
 func Load() (*Config, error) {
-	paths := configSearchPaths()
-	for _, p := range paths {
+	if p := FindPath(); p != "" {
+		return LoadFromFile(p)
+	}
+	return LoadFromReader(strings.NewReader(""))
+}
+
+// FindPath returns the config file Load reads: the first of the search
+// paths that exists, or "" if none does.
+func FindPath() string {
+	for _, p := range configSearchPaths() {
 		if _, err := os.Stat(p); err == nil {
-			return LoadFromFile(p)
+			return p
 		}
 	}
-	return DefaultConfig(), nil
+	return ""
 }
 
-// LoadFromFile reads configuration from a specific file path.
+// DefaultPath returns the path Load reads first, and the one new config
+// files are written to: $XDG_CONFIG_HOME/prompt-pulse/config.toml.
+func DefaultPath() string {
+	return configSearchPaths()[0]
+}
+
+// LoadFromFile reads configuration from a specific file path. A missing
+// file reads as an empty one.
 func LoadFromFile(path string) (*Config, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return DefaultConfig(), nil
+			return LoadFromReader(strings.NewReader(""))
 		}
 		return nil, err
 	}
@@ -38,62 +62,715 @@ func LoadFromFile(path string) (*Config, error) {
 	return LoadFromReader(f)
 }
 
-// LoadFromReader reads configuration from an io.Reader.
+// LoadFromReader reads configuration from an io.Reader. The host profile,
+// if any, sets the collectors and display options the file leaves unset
+// (see SetProfileResolver). Collectors whose enabled flag is unset are
+// resolved as described at resolveCollectors.
 func LoadFromReader(r io.Reader) (*Config, error) {
 	cfg := DefaultConfig()
-	if _, err := toml.NewDecoder(r).Decode(cfg); err != nil {
+	md, err := toml.NewDecoder(r).Decode(cfg)
+	if err != nil {
 		return nil, err
 	}
+	if err := expandEnv(cfg, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
 	applyEnvOverrides(cfg)
+	profile, source, err := resolveProfile(cfg, os.Hostname)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	applyProfileOptions(cfg, profile, source, md.IsDefined)
+	resolveCollectors(cfg, md.IsDefined, profileCollectors(profile))
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// Validate reports settings that cannot be used, such as conflicting TUI
+// key bindings or unlabeled Claude accounts.
+func (c *Config) Validate() error {
+	if err := validateClaudeAccounts(c.Collectors.Claude.Accounts); err != nil {
+		return err
+	}
+	if err := validateClaudePricing(c.Collectors.Claude); err != nil {
+		return err
+	}
+	if err := validateStarshipSummary(c.Starship.Summary); err != nil {
+		return err
+	}
+	if err := validateStarshipThresholds(c.Starship); err != nil {
+		return err
+	}
+	if err := validateImageBlocks(c.Image); err != nil {
+		return err
+	}
+	if err := validateChecks(c.Collectors.Checks); err != nil {
+		return err
+	}
+	if err := validateRemote(c.Collectors.Remote); err != nil {
+		return err
+	}
+	if err := validateStorage(c.Collectors.Storage); err != nil {
+		return err
+	}
+	if err := validateCurrency(c.Collectors.Billing.Currency); err != nil {
+		return err
+	}
+	if err := validateBillingPeriods(c.Collectors.Billing); err != nil {
+		return err
+	}
+	if f := c.Collectors.Billing.AnomalyFactor; f < 0 || (f > 0 && f <= 1) {
+		return fmt.Errorf("collectors.billing.anomaly_factor: must be above 1, or 0 to disable, got %g", f)
+	}
+	if c.Collectors.Billing.AnomalyMinDelta < 0 {
+		return fmt.Errorf("collectors.billing.anomaly_min_delta: must not be negative, got %g", c.Collectors.Billing.AnomalyMinDelta)
+	}
+	if err := validateLog(c.General.LogLevel, c.Log); err != nil {
+		return err
+	}
+	if err := validateHTTP(c.HTTP); err != nil {
+		return err
+	}
+	if err := validateBannerStack(c.Banner); err != nil {
+		return err
+	}
+	if c.General.CollectTimeout.Duration < 0 {
+		return fmt.Errorf("general.collect_timeout: must not be negative, got %s", c.General.CollectTimeout.Duration)
+	}
+	if c.General.ShutdownGrace.Duration < 0 {
+		return fmt.Errorf("general.shutdown_grace: must not be negative, got %s", c.General.ShutdownGrace.Duration)
+	}
+	if c.Collectors.Kubernetes.UtilizationWarn < 0 {
+		return fmt.Errorf("collectors.kubernetes.utilization_warn: must not be negative, got %g", c.Collectors.Kubernetes.UtilizationWarn)
+	}
+	if c.General.StaleAfterPolls < 0 {
+		return fmt.Errorf("general.stale_after_polls: must not be negative, got %g", c.General.StaleAfterPolls)
+	}
+	if c.General.ExpireAfter.Duration < 0 {
+		return fmt.Errorf("general.expire_after: must not be negative, got %s", c.General.ExpireAfter.Duration)
+	}
+	if c.Image.WaifuMaxCacheMB < 0 {
+		return fmt.Errorf("image.waifu_max_cache_mb: must not be negative, got %d", c.Image.WaifuMaxCacheMB)
+	}
+	for dir, w := range c.Image.WaifuWeights {
+		if w < 0 {
+			return fmt.Errorf("image.waifu_weights.%s: weight must not be negative, got %g", dir, w)
+		}
+	}
+	if err := validateNotifications(c.Notifications); err != nil {
+		return err
+	}
+	if c.StatusPage.Interval.Duration > 0 && c.StatusPage.Path == "" {
+		return fmt.Errorf("status_page.interval: set status_page.path for the daemon to write to")
+	}
+	if e := c.Events; e.MaxEntries < 0 || e.BillingMinDelta < 0 || e.ClaudeMinDelta < 0 || e.PodJitter < 0 {
+		return fmt.Errorf("events: max_entries, billing_min_delta, claude_min_delta, and pod_jitter must not be negative")
+	}
+	if v := c.Validation; v.Enabled && v.BillingMaxFactor <= 1 {
+		return fmt.Errorf("validation.billing_max_factor: must be greater than 1, got %g", v.BillingMaxFactor)
+	}
+	if c.Validation.ClaudeWindow.Duration < 0 {
+		return fmt.Errorf("validation.claude_window: must not be negative")
+	}
+	for i, key := range c.Cache.Encrypt {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("cache.encrypt[%d]: key is empty", i)
+		}
+	}
+	return validateTUIKeys(c.TUI.Keys)
+}
+
+// validateLog checks the log level and log output settings.
+func validateLog(level string, l LogConfig) error {
+	switch level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("general.log_level: unsupported level %q (valid: debug, info, warn, error)", level)
+	}
+	switch l.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("log.format: unsupported format %q (valid: text, json)", l.Format)
+	}
+	if l.MaxSizeMB < 0 || l.MaxFiles < 0 || l.MaxAge.Duration < 0 {
+		return fmt.Errorf("log: max_size_mb, max_age, and max_files must not be negative")
+	}
+	return nil
+}
+
+// validateBannerStack checks the narrow-terminal stacking settings.
+func validateBannerStack(b BannerConfig) error {
+	if b.StackBelowWidth < 0 {
+		return fmt.Errorf("banner.stack_below_width: must not be negative, got %d", b.StackBelowWidth)
+	}
+	seen := make(map[string]bool)
+	for i, name := range b.StackOrder {
+		switch name {
+		case "status", "fastfetch", "waifu":
+		default:
+			return fmt.Errorf("banner.stack_order[%d]: unknown section %q (valid: status, fastfetch, waifu)", i, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("banner.stack_order[%d]: %q listed twice", i, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// validateStorage checks the storage thresholds and mount patterns.
+func validateStorage(s StorageCollectorConfig) error {
+	if s.WarnPercent < 0 || s.CriticalPercent < 0 {
+		return fmt.Errorf("collectors.storage: thresholds must not be negative")
+	}
+	if s.WarnPercent > s.CriticalPercent {
+		return fmt.Errorf("collectors.storage: warn_percent (%g) is above critical_percent (%g)", s.WarnPercent, s.CriticalPercent)
+	}
+	for i, pat := range s.IgnoreMounts {
+		if _, err := filepath.Match(pat, ""); err != nil {
+			return fmt.Errorf("collectors.storage.ignore_mounts[%d]: invalid pattern %q", i, pat)
+		}
+	}
+	return nil
+}
+
+// validateCurrency checks the billing display currency, rate source, and
+// static rates.
+func validateCurrency(cc CurrencyConfig) error {
+	if !isCurrencyCode(cc.Display) {
+		return fmt.Errorf("collectors.billing.currency.display: must be a three-letter ISO 4217 code such as \"USD\", got %q", cc.Display)
+	}
+	switch cc.Source {
+	case "ecb", "static":
+	default:
+		return fmt.Errorf("collectors.billing.currency.source: must be \"ecb\" or \"static\", got %q", cc.Source)
+	}
+	for code, rate := range cc.Rates {
+		if !isCurrencyCode(code) {
+			return fmt.Errorf("collectors.billing.currency.rates: %q is not a three-letter ISO 4217 code", code)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("collectors.billing.currency.rates.%s: must be positive, got %g", code, rate)
+		}
+	}
+	if cc.RatesTTL.Duration < 0 {
+		return fmt.Errorf("collectors.billing.currency.rates_ttl: must not be negative, got %s", cc.RatesTTL.Duration)
+	}
+	return nil
+}
+
+// validateBillingPeriods checks each provider's billing period: an anchor
+// day or a cycle length with its start date, but not both, and a known
+// time zone.
+func validateBillingPeriods(b BillingCollectorConfig) error {
+	for _, p := range []struct {
+		name   string
+		period BillingPeriodConfig
+	}{
+		{"civo", b.Civo.Period},
+		{"digitalocean", b.DigitalOcean.Period},
+		{"hetzner", b.Hetzner.Period},
+		{"vultr", b.Vultr.Period},
+	} {
+		field := "collectors.billing." + p.name + ".period"
+		bp := p.period
+		if bp.AnchorDay < 0 || bp.AnchorDay > 31 {
+			return fmt.Errorf("%s.anchor_day: must be 1-31, got %d", field, bp.AnchorDay)
+		}
+		if bp.CycleDays < 0 || bp.CycleDays > 31 {
+			return fmt.Errorf("%s.cycle_days: must be 1-31, got %d", field, bp.CycleDays)
+		}
+		if bp.CycleDays > 0 && bp.AnchorDay > 1 {
+			return fmt.Errorf("%s: set anchor_day or cycle_days, not both", field)
+		}
+		if bp.CycleDays > 0 && bp.Start == "" {
+			return fmt.Errorf("%s.start: required with cycle_days, e.g. \"2026-01-12\"", field)
+		}
+		if bp.Start != "" {
+			if _, err := time.Parse(time.DateOnly, bp.Start); err != nil {
+				return fmt.Errorf("%s.start: must be a date such as \"2026-01-12\", got %q", field, bp.Start)
+			}
+		}
+		if bp.Timezone != "" {
+			if _, err := time.LoadLocation(bp.Timezone); err != nil {
+				return fmt.Errorf("%s.timezone: %v", field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether s looks like an ISO 4217 code: three
+// upper-case letters.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateImageBlocks checks the character-cell fallback and waifu
+// preprocessing settings.
+func validateImageBlocks(img ImageConfig) error {
+	switch img.BlockMode {
+	case "", "halfblocks", "quadrants":
+	default:
+		return fmt.Errorf("image.block_mode: must be \"halfblocks\" or \"quadrants\", got %q", img.BlockMode)
+	}
+	if img.Background != "" && !isHexColor(img.Background) {
+		return fmt.Errorf("image.background: must be a color like \"#1e1e2e\", got %q", img.Background)
+	}
+	switch img.WaifuCrop {
+	case "", "none", "center", "entropy":
+	default:
+		return fmt.Errorf("image.waifu_crop: must be \"none\", \"center\", or \"entropy\", got %q", img.WaifuCrop)
+	}
+	switch {
+	case img.WaifuPad == "", img.WaifuPad == "none", img.WaifuPad == "theme", img.WaifuPad == "edge", isHexColor(img.WaifuPad):
+	default:
+		return fmt.Errorf("image.waifu_pad: must be \"none\", \"theme\", \"edge\", or a color like \"#1e1e2e\", got %q", img.WaifuPad)
+	}
+	if img.WaifuCornerRadius < 0 {
+		return fmt.Errorf("image.waifu_corner_radius: must not be negative, got %d", img.WaifuCornerRadius)
+	}
+	return nil
+}
+
+// isHexColor reports whether s is a "#rrggbb" color.
+func isHexColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateClaudeAccounts checks that every Claude account has a unique
+// name, since the name is the only label shown for its usage.
+func validateClaudeAccounts(accounts []ClaudeAccountConfig) error {
+	seen := make(map[string]bool, len(accounts))
+	for i, a := range accounts {
+		if a.Name == "" {
+			return fmt.Errorf("collectors.claude.account[%d]: name is required", i)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("collectors.claude.account[%d]: duplicate name %q", i, a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return nil
+}
+
+// validateClaudePricing checks that no model price is negative and that
+// every override names a model.
+func validateClaudePricing(c ClaudeCollectorConfig) error {
+	check := func(field string, p ClaudePricingConfig) error {
+		for _, v := range []float64{p.Input, p.Output, p.CacheWrite, p.CacheRead} {
+			if v < 0 {
+				return fmt.Errorf("%s: prices must not be negative", field)
+			}
+		}
+		return nil
+	}
+	models := make([]string, 0, len(c.Pricing))
+	for model := range c.Pricing {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		if strings.TrimSpace(model) == "" {
+			return fmt.Errorf("collectors.claude.pricing: model name is required")
+		}
+		if err := check(fmt.Sprintf("collectors.claude.pricing.%q", model), c.Pricing[model]); err != nil {
+			return err
+		}
+	}
+	return check("collectors.claude.default_pricing", c.DefaultPricing)
+}
+
+// validateNotifications checks that sinks and rules have unique names and
+// known types, that each sink has what its type needs, and that rules
+// only name configured sinks.
+func validateNotifications(nc NotificationsConfig) error {
+	sinks := make(map[string]bool, len(nc.Sinks))
+	for i, s := range nc.Sinks {
+		field := fmt.Sprintf("notifications.sink[%d]", i)
+		if s.Name == "" {
+			return fmt.Errorf("%s: name is required", field)
+		}
+		if sinks[s.Name] {
+			return fmt.Errorf("%s: duplicate name %q", field, s.Name)
+		}
+		sinks[s.Name] = true
+		switch s.Type {
+		case "desktop":
+		case "webhook":
+			u, err := url.Parse(s.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("%s: url must be an http(s) URL, got %q", field, s.URL)
+			}
+		case "command":
+			if strings.TrimSpace(s.Command) == "" {
+				return fmt.Errorf("%s: command is required", field)
+			}
+		default:
+			return fmt.Errorf("%s: type must be \"desktop\", \"webhook\", or \"command\", got %q", field, s.Type)
+		}
+		if s.Timeout.Duration < 0 {
+			return fmt.Errorf("%s: timeout must not be negative", field)
+		}
+	}
+
+	rules := make(map[string]bool, len(nc.Rules))
+	for i, r := range nc.Rules {
+		field := fmt.Sprintf("notifications.rule[%d]", i)
+		if r.Name == "" {
+			return fmt.Errorf("%s: name is required", field)
+		}
+		if rules[r.Name] {
+			return fmt.Errorf("%s: duplicate name %q", field, r.Name)
+		}
+		rules[r.Name] = true
+		switch r.Event {
+		case "billing_budget", "billing_anomaly", "claude_window", "check_down", "k8s_critical":
+		default:
+			return fmt.Errorf("%s: event must be \"billing_budget\", \"billing_anomaly\", \"claude_window\", \"check_down\", or \"k8s_critical\", got %q", field, r.Event)
+		}
+		if r.Threshold < 0 {
+			return fmt.Errorf("%s: threshold must not be negative, got %g", field, r.Threshold)
+		}
+		if r.Cooldown.Duration < 0 {
+			return fmt.Errorf("%s: cooldown must not be negative", field)
+		}
+		for _, name := range r.Sinks {
+			if !sinks[name] {
+				return fmt.Errorf("%s: unknown sink %q", field, name)
+			}
+		}
+		if len(nc.Sinks) == 0 {
+			return fmt.Errorf("%s: no notifications.sink is configured", field)
+		}
+	}
+	return nil
+}
+
+// validateChecks checks that every configured check has a unique name, a
+// known type, and a target of the right form for that type.
+func validateChecks(cc ChecksCollectorConfig) error {
+	if cc.Workers < 0 {
+		return fmt.Errorf("collectors.checks.workers: must not be negative, got %d", cc.Workers)
+	}
+	if cc.FailureThreshold < 0 {
+		return fmt.Errorf("collectors.checks.failure_threshold: must not be negative, got %d", cc.FailureThreshold)
+	}
+	seen := make(map[string]bool, len(cc.Checks))
+	for i, chk := range cc.Checks {
+		field := fmt.Sprintf("collectors.checks.check[%d]", i)
+		if chk.Name == "" {
+			return fmt.Errorf("%s: name is required", field)
+		}
+		if seen[chk.Name] {
+			return fmt.Errorf("%s: duplicate name %q", field, chk.Name)
+		}
+		seen[chk.Name] = true
+		if chk.Target == "" {
+			return fmt.Errorf("%s: target is required", field)
+		}
+		switch chk.Type {
+		case "http":
+			u, err := url.Parse(chk.Target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("%s: target must be an http(s) URL, got %q", field, chk.Target)
+			}
+		case "tcp":
+			if _, port, err := net.SplitHostPort(chk.Target); err != nil || port == "" {
+				return fmt.Errorf("%s: target must be host:port, got %q", field, chk.Target)
+			}
+		case "ping":
+		default:
+			return fmt.Errorf("%s: type must be \"http\", \"tcp\", or \"ping\", got %q", field, chk.Type)
+		}
+		if chk.Type != "http" && (chk.ExpectStatus != 0 || chk.ExpectBody != "") {
+			return fmt.Errorf("%s: expect_status and expect_body apply only to http checks", field)
+		}
+		if chk.Timeout.Duration < 0 || chk.Interval.Duration < 0 {
+			return fmt.Errorf("%s: timeout and interval must not be negative", field)
+		}
+	}
+	return nil
+}
+
+// validateRemote checks the host key checking mode and that every remote
+// host has an address and a unique name.
+func validateRemote(rc RemoteCollectorConfig) error {
+	if rc.FailureThreshold < 0 {
+		return fmt.Errorf("collectors.remote.failure_threshold: must not be negative, got %d", rc.FailureThreshold)
+	}
+	if rc.Timeout.Duration < 0 {
+		return fmt.Errorf("collectors.remote.timeout: must not be negative, got %s", rc.Timeout)
+	}
+	switch rc.StrictHostKeyChecking {
+	case "yes", "accept-new":
+	default:
+		return fmt.Errorf("collectors.remote.strict_host_key_checking: must be \"yes\" or \"accept-new\", got %q", rc.StrictHostKeyChecking)
+	}
+	seen := make(map[string]bool, len(rc.Hosts))
+	for i, h := range rc.Hosts {
+		field := fmt.Sprintf("collectors.remote.host[%d]", i)
+		if h.Address == "" {
+			return fmt.Errorf("%s: address is required", field)
+		}
+		name := h.Name
+		if name == "" {
+			name = h.Address
+		}
+		if seen[name] {
+			return fmt.Errorf("%s: duplicate name %q", field, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// validateHTTP checks the retry settings and rate limits are not
+// negative.
+func validateHTTP(h HTTPConfig) error {
+	if h.MaxRetries < 0 {
+		return fmt.Errorf("http.max_retries: must not be negative, got %d", h.MaxRetries)
+	}
+	if h.RetryBaseDelay.Duration < 0 {
+		return fmt.Errorf("http.retry_base_delay: must not be negative, got %s", h.RetryBaseDelay)
+	}
+	if h.MaxRetryDelay.Duration < 0 {
+		return fmt.Errorf("http.max_retry_delay: must not be negative, got %s", h.MaxRetryDelay)
+	}
+	for host, rps := range h.RateLimits {
+		if host == "" {
+			return fmt.Errorf("http.rate_limits: host name is empty")
+		}
+		if rps < 0 {
+			return fmt.Errorf("http.rate_limits.%s: must not be negative, got %g", host, rps)
+		}
+	}
+	return nil
+}
+
+// validateStarshipSummary checks that every summary segment is known and
+// listed once.
+func validateStarshipSummary(s StarshipSummaryConfig) error {
+	known := make(map[string]bool, len(StarshipSummarySegments))
+	for _, name := range StarshipSummarySegments {
+		known[name] = true
+	}
+	seen := make(map[string]bool, len(s.Segments))
+	for i, name := range s.Segments {
+		if !known[name] {
+			return fmt.Errorf("starship.summary.segments[%d]: unknown segment %q (valid: %s)", i, name, strings.Join(StarshipSummarySegments, ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("starship.summary.segments[%d]: duplicate segment %q", i, name)
+		}
+		seen[name] = true
+	}
+	if s.MaxWidth < 0 {
+		return fmt.Errorf("starship.summary.max_width: must not be negative, got %d", s.MaxWidth)
+	}
+	return nil
+}
+
+// validateStarshipThresholds checks that each segment turns to its warning
+// color no later than its critical color, that wrap names a supported
+// shell, that claude_display is a known mode, and that max_width names
+// known modules.
+func validateStarshipThresholds(s StarshipConfig) error {
+	for _, t := range []struct {
+		name string
+		cfg  ThresholdConfig
+	}{
+		{"claude", s.Thresholds.Claude},
+		{"billing", s.Thresholds.Billing},
+		{"system", s.Thresholds.System},
+	} {
+		if t.cfg.Warn < 0 || t.cfg.Critical < 0 {
+			return fmt.Errorf("starship.thresholds.%s: thresholds must not be negative", t.name)
+		}
+		if t.cfg.Warn > t.cfg.Critical {
+			return fmt.Errorf("starship.thresholds.%s: warn (%g) is above critical (%g)", t.name, t.cfg.Warn, t.cfg.Critical)
+		}
+	}
+	switch s.Wrap {
+	case "", "bash", "zsh":
+	default:
+		return fmt.Errorf("starship.wrap: unsupported shell %q (valid: bash, zsh)", s.Wrap)
+	}
+	switch s.ClaudeDisplay {
+	case "", "cost", "tokens", "both":
+	default:
+		return fmt.Errorf("starship.claude_display: unknown mode %q (valid: cost, tokens, both)", s.ClaudeDisplay)
+	}
+	modules := append(StarshipSummarySegments[:len(StarshipSummarySegments):len(StarshipSummarySegments)], "all")
+	known := make(map[string]bool, len(modules))
+	for _, name := range modules {
+		known[name] = true
+	}
+	mods := make([]string, 0, len(s.MaxWidth))
+	for mod := range s.MaxWidth {
+		mods = append(mods, mod)
+	}
+	sort.Strings(mods)
+	for _, mod := range mods {
+		switch {
+		case mod == "summary":
+			return fmt.Errorf("starship.max_width.summary: set starship.summary.max_width instead")
+		case !known[mod]:
+			return fmt.Errorf("starship.max_width: unknown module %q (valid: %s)", mod, strings.Join(modules, ", "))
+		case s.MaxWidth[mod] < 0:
+			return fmt.Errorf("starship.max_width.%s: must not be negative, got %d", mod, s.MaxWidth[mod])
+		}
+	}
+	return nil
+}
+
 // DefaultConfig returns the default configuration with sensible defaults.
 func DefaultConfig() *Config {
-	home, _ := os.UserHomeDir()
-	cacheDir := filepath.Join(xdgCacheHome(home), "prompt-pulse")
-
 	return &Config{
 		General: GeneralConfig{
 			DaemonPollInterval: Duration{15 * time.Minute},
+			CollectTimeout:     Duration{30 * time.Second},
+			ShutdownGrace:      Duration{10 * time.Second},
+			StaleAfterPolls:    2,
+			ExpireAfter:        Duration{24 * time.Hour},
 			DataRetention:      Duration{10 * time.Minute},
 			LogLevel:           "info",
-			CacheDir:           cacheDir,
+			CacheDir:           DefaultCacheDir(),
+			StateDir:           DefaultStateDir(),
+			TUIRefreshInterval: Duration{5 * time.Second},
+		},
+		Log: LogConfig{
+			Format:    "text",
+			MaxSizeMB: 10,
+			MaxAge:    Duration{7 * 24 * time.Hour},
+			MaxFiles:  5,
 		},
 		Layout: LayoutConfig{
 			Preset: "dashboard",
 		},
 		Collectors: CollectorsConfig{
 			SysMetrics: SysMetricsCollectorConfig{
-				Enabled:  true,
-				Interval: Duration{1 * time.Second},
+				Enabled:          true,
+				Interval:         Duration{1 * time.Second},
+				HistoryRetention: Duration{24 * time.Hour},
+				HistoryInterval:  Duration{15 * time.Second},
+			},
+			GPU: GPUCollectorConfig{
+				Enabled:  false,
+				Interval: Duration{15 * time.Second},
+			},
+			Storage: StorageCollectorConfig{
+				Enabled:         false,
+				Interval:        Duration{60 * time.Second},
+				MountTimeout:    Duration{2 * time.Second},
+				WarnPercent:     85,
+				CriticalPercent: 95,
 			},
 			Tailscale: TailscaleCollectorConfig{
-				Enabled:  true,
-				Interval: Duration{30 * time.Second},
+				Enabled:          true,
+				Interval:         Duration{30 * time.Second},
+				KeyExpiryWarning: Duration{7 * 24 * time.Hour},
 			},
 			Kubernetes: K8sCollectorConfig{
-				Enabled:  false,
-				Interval: Duration{60 * time.Second},
+				Enabled:         false,
+				Interval:        Duration{60 * time.Second},
+				UtilizationWarn: 90,
 			},
 			Claude: ClaudeCollectorConfig{
-				Enabled:  true,
-				Interval: Duration{5 * time.Minute},
+				Enabled:         true,
+				Interval:        Duration{5 * time.Minute},
+				ForecastWarning: Duration{30 * time.Minute},
+			},
+			Ollama: OllamaCollectorConfig{
+				Enabled:  false,
+				Interval: Duration{30 * time.Second},
 			},
 			Billing: BillingCollectorConfig{
+				Enabled:              false,
+				Interval:             Duration{15 * time.Minute},
+				WarnPercent:          80,
+				CriticalPercent:      100,
+				HistoryRetentionDays: 90,
+				AnomalyFactor:        2,
+				Currency: CurrencyConfig{
+					Display:  "USD",
+					Source:   "ecb",
+					RatesTTL: Duration{24 * time.Hour},
+				},
+			},
+			UptimeKuma: UptimeKumaCollectorConfig{
+				Enabled:  false,
+				Interval: Duration{60 * time.Second},
+			},
+			Docker: DockerCollectorConfig{
 				Enabled:  false,
-				Interval: Duration{15 * time.Minute},
+				Interval: Duration{30 * time.Second},
+			},
+			Weather: WeatherCollectorConfig{
+				Enabled:  false,
+				Interval: Duration{30 * time.Minute},
+				Units:    "celsius",
+			},
+			Checks: ChecksCollectorConfig{
+				Enabled:          false,
+				Interval:         Duration{60 * time.Second},
+				Workers:          4,
+				FailureThreshold: 2,
+			},
+			Remote: RemoteCollectorConfig{
+				Enabled:               false,
+				Interval:              Duration{60 * time.Second},
+				Timeout:               Duration{10 * time.Second},
+				FailureThreshold:      3,
+				StrictHostKeyChecking: "yes",
 			},
+			Shell: ShellCollectorConfig{
+				Enabled:   false,
+				Interval:  Duration{30 * time.Second},
+				Retention: Duration{48 * time.Hour},
+			},
+		},
+		HTTP: HTTPConfig{
+			MaxRetries:     3,
+			RetryBaseDelay: Duration{500 * time.Millisecond},
+			MaxRetryDelay:  Duration{30 * time.Second},
 		},
 		Image: ImageConfig{
-			Protocol:       "auto",
-			MaxCacheSizeMB: 50,
-			MaxSessions:    10,
-			WaifuEnabled:   true,
-			WaifuCategory:  "waifu",
+			Protocol:           "auto",
+			MaxCacheSizeMB:     50,
+			MaxSessions:        10,
+			MaxAnimationFrames: 64,
+			SixelColors:        256,
+			SixelDither:        "floyd",
+			BlockMode:          "halfblocks",
+			WaifuEnabled:       true,
+			WaifuCategory:      "waifu",
+			WaifuMaxCacheMB:    50,
+			WaifuCrop:          "none",
+			WaifuPad:           "none",
 		},
 		Theme: ThemeConfig{
-			Name: "default",
+			Name:  "default",
+			Light: "solarized-light",
+			Dark:  "default",
 		},
 		Shell: ShellConfig{
 			TUIKeybinding:       `\C-p`,
@@ -101,39 +778,146 @@ func DefaultConfig() *Config {
 			BannerTimeout:       Duration{2 * time.Second},
 			InstantBanner:       true,
 		},
+		Starship: StarshipConfig{
+			Summary: StarshipSummaryConfig{
+				Segments: []string{"claude", "billing", "infra"},
+				MaxWidth: 60,
+			},
+			Thresholds: StarshipThresholdsConfig{
+				Claude:  ThresholdConfig{Warn: 50, Critical: 80},
+				Billing: ThresholdConfig{Warn: 50, Critical: 80},
+				System:  ThresholdConfig{Warn: 50, Critical: 80},
+			},
+			ClaudeDisplay: "cost",
+			Budget:        Duration{50 * time.Millisecond},
+		},
 		Banner: BannerConfig{
 			CompactMaxWidth:   80,
 			StandardMinWidth:  120,
 			WideMinWidth:      160,
 			UltraWideMinWidth: 200,
+			Fastfetch:         FastfetchConfig{Mode: "auto"},
+			StackBelowWidth:   70,
+			StackOrder:        []string{"status", "fastfetch"},
+		},
+		TUI: TUIConfig{
+			Mouse: true,
+			Keys:  DefaultTUIKeys(),
+		},
+		StatusPage: StatusPageConfig{
+			Title: "prompt-pulse status",
+		},
+		Events: EventsConfig{
+			Enabled:         true,
+			MaxEntries:      200,
+			BillingMinDelta: 0.50,
+			ClaudeMinDelta:  1.00,
+			PodJitter:       1,
+		},
+		Validation: ValidationConfig{
+			Enabled:          true,
+			BillingMaxFactor: 10,
+			ClaudeWindow:     Duration{time.Hour},
 		},
 	}
 }
 
+// EnvOverride is an environment variable that, when set, replaces a
+// setting from the config file.
+type EnvOverride struct {
+	// Name is the variable, e.g. "CIVO_TOKEN".
+	Name string
+
+	// Key is the dotted path of the setting it replaces, e.g.
+	// "collectors.billing.civo.api_key".
+	Key string
+
+	// File reports whether Name+"_FILE" may instead name a file holding
+	// the value, for secrets.
+	File bool
+
+	set func(cfg *Config, v string)
+}
+
+// envOverrides are the environment variables applyEnvOverrides reads, in
+// the order they are applied.
+var envOverrides = []EnvOverride{
+	{Name: "ANTHROPIC_ADMIN_KEY", Key: "collectors.claude.admin_key",
+		set: func(cfg *Config, v string) { cfg.Collectors.Claude.AdminKey = v }},
+	{Name: "CIVO_TOKEN", Key: "collectors.billing.civo.api_key", File: true,
+		set: func(cfg *Config, v string) { cfg.Collectors.Billing.Civo.APIKey = v }},
+	{Name: "DIGITALOCEAN_TOKEN", Key: "collectors.billing.digitalocean.api_key", File: true,
+		set: func(cfg *Config, v string) { cfg.Collectors.Billing.DigitalOcean.APIKey = v }},
+	{Name: "HCLOUD_TOKEN", Key: "collectors.billing.hetzner.api_key", File: true,
+		set: func(cfg *Config, v string) { cfg.Collectors.Billing.Hetzner.APIKey = v }},
+	{Name: "VULTR_API_KEY", Key: "collectors.billing.vultr.api_key", File: true,
+		set: func(cfg *Config, v string) { cfg.Collectors.Billing.Vultr.APIKey = v }},
+	{Name: "UPTIME_KUMA_API_KEY", Key: "collectors.uptimekuma.api_key", File: true,
+		set: func(cfg *Config, v string) { cfg.Collectors.UptimeKuma.APIKey = v }},
+	{Name: "PPULSE_CACHE_DIR", Key: "general.cache_dir",
+		set: func(cfg *Config, v string) { cfg.General.CacheDir = v }},
+	{Name: "PPULSE_STATE_DIR", Key: "general.state_dir",
+		set: func(cfg *Config, v string) { cfg.General.StateDir = v }},
+	{Name: "PPULSE_HOST_PROFILE", Key: "general.host_profile",
+		set: func(cfg *Config, v string) { cfg.General.HostProfile = v }},
+	{Name: "PPULSE_PROTOCOL", Key: "image.protocol",
+		set: func(cfg *Config, v string) { cfg.Image.Protocol = v }},
+	{Name: "PPULSE_KITTY_RETRANSMIT", Key: "image.kitty_retransmit",
+		set: func(cfg *Config, v string) {
+			if b, err := strconv.ParseBool(v); err == nil {
+				cfg.Image.KittyRetransmit = b
+			}
+		}},
+	{Name: "PPULSE_THEME", Key: "theme.name",
+		set: func(cfg *Config, v string) { cfg.Theme.Name = v }},
+	{Name: "PPULSE_LAYOUT", Key: "layout.preset",
+		set: func(cfg *Config, v string) { cfg.Layout.Preset = v }},
+}
+
+// EnvOverrides returns the environment variables that replace settings
+// from the config file.
+func EnvOverrides() []EnvOverride {
+	return append([]EnvOverride(nil), envOverrides...)
+}
+
 // applyEnvOverrides checks environment variables and overrides config values.
 func applyEnvOverrides(cfg *Config) {
-	if v := os.Getenv("ANTHROPIC_ADMIN_KEY"); v != "" {
-		cfg.Collectors.Claude.AdminKey = v
-	}
-	if v := os.Getenv("CIVO_TOKEN"); v != "" {
-		cfg.Collectors.Billing.Civo.APIKey = v
-	}
-	if v := os.Getenv("DIGITALOCEAN_TOKEN"); v != "" {
-		cfg.Collectors.Billing.DigitalOcean.APIKey = v
+	for _, o := range envOverrides {
+		v := os.Getenv(o.Name)
+		if o.File {
+			v = envOrFile(o.Name)
+		}
+		if v != "" {
+			o.set(cfg, v)
+		}
 	}
-	if v := os.Getenv("PPULSE_PROTOCOL"); v != "" {
-		cfg.Image.Protocol = v
+}
+
+// envOrFile returns the value of the environment variable name. If it is
+// unset, the file named by name+"_FILE" is read instead, which supports
+// secret managers such as sops-nix that expose credentials as files.
+// Surrounding whitespace is trimmed; an unreadable file yields "".
+func envOrFile(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
 	}
-	if v := os.Getenv("PPULSE_THEME"); v != "" {
-		cfg.Theme.Name = v
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return ""
 	}
-	if v := os.Getenv("PPULSE_LAYOUT"); v != "" {
-		cfg.Layout.Preset = v
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
 	}
+	return strings.TrimSpace(string(data))
 }
 
-// configSearchPaths returns the ordered list of config file paths to try.
+// configSearchPaths returns the ordered list of config file paths to try:
+// only $PPULSE_CONFIG when it is set.
 func configSearchPaths() []string {
+	if p := os.Getenv("PPULSE_CONFIG"); p != "" {
+		return []string{p}
+	}
 	home, _ := os.UserHomeDir()
 	var paths []string
 
@@ -148,19 +932,3 @@ func configSearchPaths() []string {
 
 	return paths
 }
-
-// xdgConfigHome returns XDG_CONFIG_HOME or ~/.config as fallback.
-func xdgConfigHome(home string) string {
-	if v := os.Getenv("XDG_CONFIG_HOME"); v != "" {
-		return v
-	}
-	return filepath.Join(home, ".config")
-}
-
-// xdgCacheHome returns XDG_CACHE_HOME or ~/.cache as fallback.
-func xdgCacheHome(home string) string {
-	if v := os.Getenv("XDG_CACHE_HOME"); v != "" {
-		return v
-	}
-	return filepath.Join(home, ".cache")
-}
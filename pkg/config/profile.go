@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// NoHostProfile as general.host_profile turns host profiles off.
+const NoHostProfile = "none"
+
+// Where the host profile of a configuration came from, as reported in
+// ProfileInfo.Source.
+const (
+	ProfileFromConfig   = "general.host_profile"
+	ProfileFromHostname = "hostname"
+)
+
+// HostProfile is what the runtime takes from a deploy host profile: its
+// features, which are the default set of enabled collectors and display
+// options. The config file overrides them one entry at a time.
+type HostProfile struct {
+	Name     string
+	Features []string
+}
+
+// ProfileResolver returns the host profile called name, or when name is
+// empty the one for hostname, and whether there is one.
+type ProfileResolver func(name, hostname string) (HostProfile, bool)
+
+var (
+	profileMu       sync.RWMutex
+	profileResolver ProfileResolver
+)
+
+// SetProfileResolver sets how the Load functions find the host profile.
+// The profiles live in the deploy package, which imports this one, so
+// main installs deploy.ResolveProfile before loading the config. Without
+// a resolver no profile applies.
+func SetProfileResolver(r ProfileResolver) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	profileResolver = r
+}
+
+// featureCollectors maps each feature naming a collector to its
+// [collectors] table. Deploy profiles say k8s for kubernetes.
+var featureCollectors = map[string]string{
+	"sysmetrics": "sysmetrics",
+	"gpu":        "gpu",
+	"storage":    "storage",
+	"tailscale":  "tailscale",
+	"k8s":        "kubernetes",
+	"kubernetes": "kubernetes",
+	"claude":     "claude",
+	"ollama":     "ollama",
+	"billing":    "billing",
+	"uptimekuma": "uptimekuma",
+	"docker":     "docker",
+	"weather":    "weather",
+	"checks":     "checks",
+	"remote":     "remote",
+	"shell":      "shell",
+}
+
+// FeatureCollector returns the [collectors] table a profile feature
+// enables, or false when the feature is not a collector.
+func FeatureCollector(feature string) (string, bool) {
+	key, ok := featureCollectors[feature]
+	return key, ok
+}
+
+// featureOptions are the features that turn on a display option: the
+// option's key in the file, for telling whether the file sets it, and a
+// setter.
+var featureOptions = map[string]struct {
+	key []string
+	set func(cfg *Config, on bool)
+}{
+	"waifu": {[]string{"image", "waifu_enabled"}, func(cfg *Config, on bool) { cfg.Image.WaifuEnabled = on }},
+}
+
+// ProfileInfo describes the host profile a configuration was resolved
+// with, for "prompt-pulse -list-collectors".
+type ProfileInfo struct {
+	Name string `json:"name"`
+
+	// Source is ProfileFromConfig or ProfileFromHostname.
+	Source string `json:"source"`
+
+	// Applied lists the profile's features that set a collector or
+	// display option. Collectors the profile leaves out are disabled.
+	Applied []string `json:"applied,omitempty"`
+
+	// Overridden lists the profile's features whose setting the config
+	// file makes itself.
+	Overridden []string `json:"overridden,omitempty"`
+
+	// Unknown lists features that are neither a collector nor a display
+	// option, such as a terminal the host is expected to run.
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// Profile returns the host profile the configuration was resolved with,
+// or nil when none applied.
+func (c *Config) Profile() *ProfileInfo {
+	return c.profile
+}
+
+// resolveProfile finds the host profile for cfg: the one named by
+// general.host_profile, or the one matching the hostname when that is
+// unset. It is an error to name a profile that does not exist.
+func resolveProfile(cfg *Config, hostname func() (string, error)) (*HostProfile, string, error) {
+	name := cfg.General.HostProfile
+	if name == NoHostProfile {
+		return nil, "", nil
+	}
+	profileMu.RLock()
+	resolve := profileResolver
+	profileMu.RUnlock()
+
+	if name != "" {
+		if resolve != nil {
+			if p, ok := resolve(name, ""); ok {
+				return &p, ProfileFromConfig, nil
+			}
+		}
+		return nil, "", fmt.Errorf("general.host_profile: unknown profile %q", name)
+	}
+	if resolve == nil {
+		return nil, "", nil
+	}
+	host, err := hostname()
+	if err != nil || host == "" {
+		return nil, "", nil
+	}
+	if p, ok := resolve("", host); ok {
+		return &p, ProfileFromHostname, nil
+	}
+	return nil, "", nil
+}
+
+// applyProfileOptions sets the display options of profile's features that
+// the file leaves unset, and records the profile in cfg. Collectors are
+// settled by resolveCollectors.
+func applyProfileOptions(cfg *Config, profile *HostProfile, source string, defined func(key ...string) bool) {
+	if profile == nil {
+		return
+	}
+	info := &ProfileInfo{Name: profile.Name, Source: source}
+	has := make(map[string]bool, len(profile.Features))
+	for _, f := range profile.Features {
+		has[f] = true
+		key, isCollector := featureCollectors[f]
+		opt, isOption := featureOptions[f]
+		switch {
+		case isCollector && defined("collectors", key, "enabled"),
+			isOption && defined(opt.key...):
+			info.Overridden = append(info.Overridden, f)
+		case isCollector || isOption:
+			info.Applied = append(info.Applied, f)
+		default:
+			info.Unknown = append(info.Unknown, f)
+		}
+	}
+	for f, opt := range featureOptions {
+		if !defined(opt.key...) {
+			opt.set(cfg, has[f])
+		}
+	}
+	sort.Strings(info.Applied)
+	sort.Strings(info.Overridden)
+	sort.Strings(info.Unknown)
+	cfg.profile = info
+}
+
+// profileCollectors returns the [collectors] tables profile enables, or
+// nil when there is no profile.
+func profileCollectors(profile *HostProfile) map[string]bool {
+	if profile == nil {
+		return nil
+	}
+	on := make(map[string]bool)
+	for _, f := range profile.Features {
+		if key, ok := featureCollectors[f]; ok {
+			on[key] = true
+		}
+	}
+	return on
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/logging"
+)
+
+// Secrets returns the values of every set string setting of c whose key
+// names a secret by logging.IsSensitiveKey, such as
+// collectors.billing.civo.api_key, including those in lists and maps. They
+// are registered with the log redactor at startup.
+func (c *Config) Secrets() []string {
+	var out []string
+	secretValues(reflect.ValueOf(c).Elem(), "", &out)
+	return out
+}
+
+// secretValues appends to out the set strings in v, whose last config key
+// segment is name, that are secrets.
+func secretValues(v reflect.Value, name string, out *[]string) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() != "" && logging.IsSensitiveKey(name) {
+			*out = append(*out, v.String())
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+			if field == "" || field == "-" {
+				continue
+			}
+			secretValues(v.Field(i), field, out)
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			secretValues(v.Index(i), name, out)
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			secretValues(iter.Value(), name, out)
+		}
+	}
+}
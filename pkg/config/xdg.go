@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DefaultCacheDir returns the default general.cache_dir:
+// $XDG_CACHE_HOME/prompt-pulse, or ~/.cache/prompt-pulse.
+func DefaultCacheDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(xdgCacheHome(home), "prompt-pulse")
+}
+
+// DefaultStateDir returns the default general.state_dir:
+// $XDG_STATE_HOME/prompt-pulse, or ~/.local/state/prompt-pulse.
+func DefaultStateDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(xdgStateHome(home), "prompt-pulse")
+}
+
+// xdgConfigHome returns XDG_CONFIG_HOME or ~/.config as fallback.
+func xdgConfigHome(home string) string {
+	if v := os.Getenv("XDG_CONFIG_HOME"); v != "" {
+		return v
+	}
+	return filepath.Join(home, ".config")
+}
+
+// xdgCacheHome returns XDG_CACHE_HOME or ~/.cache as fallback.
+func xdgCacheHome(home string) string {
+	if v := os.Getenv("XDG_CACHE_HOME"); v != "" {
+		return v
+	}
+	return filepath.Join(home, ".cache")
+}
+
+// xdgStateHome returns XDG_STATE_HOME or ~/.local/state as fallback.
+func xdgStateHome(home string) string {
+	if v := os.Getenv("XDG_STATE_HOME"); v != "" {
+		return v
+	}
+	return filepath.Join(home, ".local", "state")
+}
@@ -0,0 +1,144 @@
+package daemon
+
+import (
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+)
+
+// backfillCheckInterval is how often the daemon checks its own schedule
+// for a gap.
+const backfillCheckInterval = 10 * time.Second
+
+// backfillGapFactor is how many check intervals apart two checks must be
+// for the daemon to take it as a gap, such as a suspend, rather than a
+// check delayed by load.
+const backfillGapFactor = 6
+
+// backfillMinSpacing is the least time between two backfills, so a resume
+// noticed both by systemd-logind and by the clock check is collected once.
+const backfillMinSpacing = time.Minute
+
+// gapDetector notices when the daemon's schedule checks run much later
+// than due, as they do after the system sleeps.
+type gapDetector struct {
+	last time.Time
+}
+
+// observe records a check at now. It returns the time since the previous
+// check and whether that is a gap: more than backfillGapFactor check
+// intervals. The wall clock is compared as well as the monotonic one,
+// since on Linux the monotonic clock stops while the system is suspended.
+func (g *gapDetector) observe(now time.Time) (time.Duration, bool) {
+	last := g.last
+	g.last = now
+	if last.IsZero() {
+		return 0, false
+	}
+	elapsed := max(now.Sub(last), now.Round(0).Sub(last.Round(0)))
+	return elapsed, elapsed > backfillGapFactor*backfillCheckInterval
+}
+
+// now returns the daemon's current time; tests replace d.clock.
+func (d *Daemon) now() time.Time {
+	if d.clock != nil {
+		return d.clock()
+	}
+	return time.Now()
+}
+
+// checkGap backfills when the schedule stalled since the previous check.
+// It is called from Start's main loop every backfillCheckInterval.
+func (d *Daemon) checkGap() {
+	if gap, ok := d.gaps.observe(d.now()); ok {
+		d.backfill(cache.BackfillClock, gap)
+	}
+}
+
+// backfill makes every scheduled collector run at once after the schedule
+// stalled for gap, and records their cache keys as pending in the backfill
+// marker (see cache.Backfill) until their fresh data lands. It reports
+// false, doing nothing, within backfillMinSpacing of the previous backfill.
+func (d *Daemon) backfill(reason string, gap time.Duration) bool {
+	now := d.now()
+	d.mu.Lock()
+	names := make([]string, 0, len(d.jobs))
+	jobs := make([]*collectorJob, 0, len(d.jobs))
+	for name, j := range d.jobs {
+		names = append(names, name)
+		jobs = append(jobs, j)
+	}
+	d.mu.Unlock()
+	sort.Strings(names)
+
+	d.backfillMu.Lock()
+	if prev := d.lastBackfill; prev != nil && now.Sub(prev.At) < backfillMinSpacing {
+		d.backfillMu.Unlock()
+		return false
+	}
+	b := &cache.Backfill{At: now, Gap: gap, Reason: reason, Pending: names}
+	d.lastBackfill = b
+	if err := cache.WriteBackfill(d.cfg.DataDir, b); err != nil {
+		log.Printf("daemon: write backfill marker: %v", err)
+	}
+	d.backfillMu.Unlock()
+
+	log.Printf("daemon: schedule stalled for %s (%s), collecting %s now", gap.Round(time.Second), reason, strings.Join(names, ", "))
+	for _, j := range jobs {
+		j.collectNow()
+	}
+	return true
+}
+
+// backfillLanded records that fresh data for the collector name was
+// cached, taking it off the pending list of the backfill in progress.
+func (d *Daemon) backfillLanded(name string) {
+	d.backfillMu.Lock()
+	defer d.backfillMu.Unlock()
+	b := d.lastBackfill
+	if b == nil {
+		return
+	}
+	i := slices.Index(b.Pending, name)
+	if i < 0 {
+		return
+	}
+	// Copy, since status hands out the previous value.
+	next := *b
+	next.Pending = slices.Delete(slices.Clone(b.Pending), i, i+1)
+	d.lastBackfill = &next
+	if err := cache.WriteBackfill(d.cfg.DataDir, &next); err != nil {
+		log.Printf("daemon: write backfill marker: %v", err)
+	}
+}
+
+// backfillStatus returns the most recent backfill, or nil when there has
+// been none.
+func (d *Daemon) backfillStatus() *cache.Backfill {
+	d.backfillMu.Lock()
+	defer d.backfillMu.Unlock()
+	return d.lastBackfill
+}
+
+// parsePrepareForSleep parses a line of "gdbus monitor" output for
+// systemd-logind's PrepareForSleep signal, which carries true as the
+// system goes to sleep and false once it resumes. It reports false for
+// any other line.
+// Example: "/org/freedesktop/login1: org.freedesktop.login1.Manager.PrepareForSleep (false,)"
+func parsePrepareForSleep(line string) (sleeping, ok bool) {
+	_, args, found := strings.Cut(line, "org.freedesktop.login1.Manager.PrepareForSleep (")
+	switch {
+	case !found:
+		return false, false
+	case strings.HasPrefix(args, "true"):
+		return true, true
+	case strings.HasPrefix(args, "false"):
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"time"
+)
+
+// watchResume listens on the system bus, through gdbus, for
+// systemd-logind's PrepareForSleep signal and sends how long the system
+// slept each time it resumes, until ctx is cancelled. It returns nil when
+// gdbus is not installed; without a system bus or logind nothing is ever
+// sent. Either way the clock check still notices resumes (see checkGap).
+func watchResume(ctx context.Context) <-chan time.Duration {
+	path, err := exec.LookPath("gdbus")
+	if err != nil {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, path, "monitor", "--system",
+		"--dest", "org.freedesktop.login1", "--object-path", "/org/freedesktop/login1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		return nil
+	}
+
+	resumed := make(chan time.Duration, 1)
+	go func() {
+		defer cmd.Wait()
+		var asleep time.Time
+		sc := bufio.NewScanner(out)
+		for sc.Scan() {
+			sleeping, ok := parsePrepareForSleep(sc.Text())
+			switch {
+			case !ok:
+			case sleeping:
+				asleep = time.Now()
+			default:
+				// The monotonic clock stopped while asleep.
+				var slept time.Duration
+				if !asleep.IsZero() {
+					slept = time.Now().Round(0).Sub(asleep.Round(0))
+				}
+				select {
+				case resumed <- slept:
+				default:
+				}
+			}
+		}
+	}()
+	return resumed
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"context"
+	"time"
+)
+
+// watchResume is a stub outside Linux, where there is no systemd-logind;
+// the clock check notices resumes (see checkGap).
+func watchResume(context.Context) <-chan time.Duration {
+	return nil
+}
@@ -0,0 +1,361 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/gpu"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/ollama"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/remote"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/storage"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/weather"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// collectorSpec describes a collector the configuration can enable.
+type collectorSpec struct {
+	name string
+
+	// key is the collector's table under [collectors].
+	key string
+
+	enabled bool
+
+	// settings fingerprints the configuration the collector is built from.
+	// A reload rebuilds the collector only when it changes.
+	settings string
+
+	build func() collectors.Collector
+}
+
+// collectorSpecs returns the collectors cfg enables. Collectors that keep
+// history (claude, billing) write it to historyDir, where the shell hooks
+// also write the shell collector's activity files, and caches they can
+// rebuild, such as exchange rates, to dataDir.
+func collectorSpecs(cfg *config.Config, dataDir, historyDir string) []collectorSpec {
+	var specs []collectorSpec
+	for _, s := range allCollectorSpecs(cfg, dataDir, historyDir) {
+		if s.enabled {
+			specs = append(specs, s)
+		}
+	}
+	return specs
+}
+
+// allCollectorSpecs returns every known collector as cfg configures it,
+// enabled or not.
+func allCollectorSpecs(cfg *config.Config, dataDir, historyDir string) []collectorSpec {
+	c := cfg.Collectors
+	var specs []collectorSpec
+	add := func(name, key string, enabled bool, settings interface{}, build func() collectors.Collector) {
+		specs = append(specs, collectorSpec{name: name, key: key, enabled: enabled, settings: fingerprint(settings), build: build})
+	}
+
+	add("sysmetrics", "sysmetrics", c.SysMetrics.Enabled, c.SysMetrics, func() collectors.Collector {
+		mc := sysmetrics.DefaultConfig()
+		if c.SysMetrics.Interval.Duration > 0 {
+			mc.FastInterval = c.SysMetrics.Interval.Duration
+		}
+		return sysmetrics.New(mc)
+	})
+
+	add("gpu", "gpu", c.GPU.Enabled, c.GPU, func() collectors.Collector {
+		return gpu.New(gpu.Config{
+			Interval:  c.GPU.Interval.Duration,
+			NvidiaSMI: c.GPU.NvidiaSMI,
+		})
+	})
+
+	add("storage", "storage", c.Storage.Enabled, c.Storage, func() collectors.Collector {
+		return storage.New(storage.Config{
+			Interval:        c.Storage.Interval.Duration,
+			IgnoreFSTypes:   c.Storage.IgnoreFSTypes,
+			IgnoreMounts:    c.Storage.IgnoreMounts,
+			MountTimeout:    c.Storage.MountTimeout.Duration,
+			WarnPercent:     c.Storage.WarnPercent,
+			CriticalPercent: c.Storage.CriticalPercent,
+			SMART:           c.Storage.SMART,
+			Smartctl:        c.Storage.Smartctl,
+		})
+	})
+
+	add("tailscale", "tailscale", c.Tailscale.Enabled, c.Tailscale, func() collectors.Collector {
+		client := tailscale.NewLocalClient(c.Tailscale.SocketPath)
+		if c.Tailscale.CLIPath != "" {
+			client = tailscale.NewCLIClient(c.Tailscale.CLIPath)
+		}
+		return tailscale.New(tailscale.Config{
+			Interval:         c.Tailscale.Interval.Duration,
+			SocketPath:       c.Tailscale.SocketPath,
+			KeyExpiryWarning: c.Tailscale.KeyExpiryWarning.Duration,
+		}, client)
+	})
+
+	add("k8s", "kubernetes", c.Kubernetes.Enabled, c.Kubernetes, func() collectors.Collector {
+		return k8s.New(k8s.Config{
+			Interval:        c.Kubernetes.Interval.Duration,
+			Kubeconfig:      c.Kubernetes.Kubeconfig,
+			Contexts:        c.Kubernetes.Contexts,
+			ExcludeContexts: c.Kubernetes.ExcludeContexts,
+			Namespaces:      c.Kubernetes.Namespaces,
+			Watch:           c.Kubernetes.Watch,
+			UtilizationWarn: c.Kubernetes.UtilizationWarn,
+		})
+	})
+
+	add("claude", "claude", c.Claude.Enabled, []interface{}{c.Claude, historyDir}, func() collectors.Collector {
+		accounts := make([]claude.AccountConfig, 0, len(c.Claude.Accounts))
+		for _, a := range c.Claude.Accounts {
+			key := a.AdminKey
+			if key == "" && a.Credentials == "" {
+				key = c.Claude.AdminKey
+			}
+			accounts = append(accounts, claude.AccountConfig{
+				Name:        a.Name,
+				AdminAPIKey: key,
+				Credentials: a.Credentials,
+				SessionsDir: a.SessionsDir,
+				WindowLimit: a.WindowLimit,
+			})
+		}
+		cc := claude.Config{
+			Interval:   c.Claude.Interval.Duration,
+			Accounts:   accounts,
+			HistoryDir: historyDir,
+		}
+		if len(c.Claude.Pricing) > 0 {
+			cc.Pricing = make(map[string]claude.ModelPricing, len(c.Claude.Pricing))
+			for model, p := range c.Claude.Pricing {
+				cc.Pricing[model] = claudePricing(p)
+			}
+		}
+		if !c.Claude.DefaultPricing.IsZero() {
+			p := claudePricing(c.Claude.DefaultPricing)
+			cc.DefaultPricing = &p
+		}
+		return claude.New(cc, nil)
+	})
+
+	add("ollama", "ollama", c.Ollama.Enabled, c.Ollama, func() collectors.Collector {
+		return ollama.New(ollama.Config{
+			Interval: c.Ollama.Interval.Duration,
+			URL:      c.Ollama.URL,
+		})
+	})
+
+	add("billing", "billing", c.Billing.Enabled, []interface{}{c.Billing, dataDir, historyDir}, func() collectors.Collector {
+		b := c.Billing
+		bc := billing.Config{
+			Interval:         b.Interval.Duration,
+			Currency:         b.Currency.Display,
+			Rates:            billingRates(b.Currency, dataDir),
+			Budgets:          b.Budgets,
+			WarnPercent:      b.WarnPercent,
+			CriticalPercent:  b.CriticalPercent,
+			HistoryDir:       historyDir,
+			HistoryRetention: time.Duration(b.HistoryRetentionDays) * 24 * time.Hour,
+			Anomaly:          billing.AnomalyRule{Factor: b.AnomalyFactor, MinDelta: b.AnomalyMinDelta},
+			Periods: map[string]billing.Period{
+				"civo":         billingPeriod(b.Civo.Period),
+				"digitalocean": billingPeriod(b.DigitalOcean.Period),
+				"hetzner":      billingPeriod(b.Hetzner.Period),
+				"vultr":        billingPeriod(b.Vultr.Period),
+			},
+		}
+		if b.Civo.Enabled {
+			bc.Civo = &billing.CivoConfig{APIKey: b.Civo.APIKey, BaseURL: b.Civo.BaseURL}
+		}
+		if b.DigitalOcean.Enabled {
+			bc.DigitalOcean = &billing.DOConfig{APIToken: b.DigitalOcean.APIKey, BaseURL: b.DigitalOcean.BaseURL}
+		}
+		if b.Hetzner.Enabled {
+			bc.Hetzner = &billing.HetznerConfig{APIToken: b.Hetzner.APIKey, BaseURL: b.Hetzner.BaseURL}
+		}
+		if b.Vultr.Enabled {
+			bc.Vultr = &billing.VultrConfig{APIKey: b.Vultr.APIKey, BaseURL: b.Vultr.BaseURL}
+		}
+		return billing.New(bc)
+	})
+
+	add("uptimekuma", "uptimekuma", c.UptimeKuma.Enabled, c.UptimeKuma, func() collectors.Collector {
+		return uptimekuma.New(uptimekuma.Config{
+			Interval: c.UptimeKuma.Interval.Duration,
+			URL:      c.UptimeKuma.URL,
+			APIKey:   c.UptimeKuma.APIKey,
+			Tags:     c.UptimeKuma.Tags,
+		})
+	})
+
+	add("docker", "docker", c.Docker.Enabled, c.Docker, func() collectors.Collector {
+		return docker.New(docker.Config{
+			Interval: c.Docker.Interval.Duration,
+			Host:     c.Docker.Host,
+		})
+	})
+
+	add("weather", "weather", c.Weather.Enabled, c.Weather, func() collectors.Collector {
+		return weather.New(weather.Config{
+			Interval:  c.Weather.Interval.Duration,
+			Latitude:  c.Weather.Latitude,
+			Longitude: c.Weather.Longitude,
+			Location:  c.Weather.Location,
+			Units:     c.Weather.Units,
+		})
+	})
+
+	add("checks", "checks", c.Checks.Enabled, c.Checks, func() collectors.Collector {
+		list := make([]checks.Check, len(c.Checks.Checks))
+		for i, chk := range c.Checks.Checks {
+			list[i] = checks.Check{
+				Name:         chk.Name,
+				Type:         chk.Type,
+				Target:       chk.Target,
+				ExpectStatus: chk.ExpectStatus,
+				ExpectBody:   chk.ExpectBody,
+				Timeout:      chk.Timeout.Duration,
+				Interval:     chk.Interval.Duration,
+				Group:        chk.Group,
+				Critical:     chk.Critical,
+			}
+		}
+		return checks.New(checks.Config{
+			Interval:         c.Checks.Interval.Duration,
+			Workers:          c.Checks.Workers,
+			FailureThreshold: c.Checks.FailureThreshold,
+			Checks:           list,
+		})
+	})
+
+	add("remote", "remote", c.Remote.Enabled, c.Remote, func() collectors.Collector {
+		hosts := make([]remote.Host, len(c.Remote.Hosts))
+		for i, h := range c.Remote.Hosts {
+			hosts[i] = remote.Host{
+				Name:    h.Name,
+				Address: h.Address,
+				User:    h.User,
+				KeyFile: h.KeyFile,
+			}
+		}
+		return remote.New(remote.Config{
+			Interval:         c.Remote.Interval.Duration,
+			Timeout:          c.Remote.Timeout.Duration,
+			FailureThreshold: c.Remote.FailureThreshold,
+			KnownHosts:       c.Remote.KnownHosts,
+			HostKeyChecking:  c.Remote.StrictHostKeyChecking,
+			Hosts:            hosts,
+		})
+	})
+
+	add("shell", "shell", c.Shell.Enabled, []interface{}{c.Shell, historyDir}, func() collectors.Collector {
+		return shellactivity.New(shellactivity.Config{
+			Interval:  c.Shell.Interval.Duration,
+			Dir:       filepath.Join(historyDir, shellactivity.DirName),
+			Retention: c.Shell.Retention.Duration,
+		})
+	})
+
+	return specs
+}
+
+// CollectorInfo describes one known collector as a configuration sets it
+// up, for "prompt-pulse -list-collectors".
+type CollectorInfo struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// Reason says why the collector is enabled or disabled; see
+	// config.CollectorReason.
+	Reason string `json:"reason"`
+
+	// Profile names the host profile when the reason is
+	// config.ReasonProfile.
+	Profile string `json:"profile,omitempty"`
+
+	// Interval is how often the collector runs: its configured interval,
+	// or its own default when none is set.
+	Interval config.Duration `json:"interval"`
+}
+
+// ListCollectors returns every known collector with whether cfg enables
+// it, why, and its effective interval, in the order the daemon runs them.
+// Collectors are built to read their interval but not started.
+func ListCollectors(cfg *config.Config) []CollectorInfo {
+	specs := allCollectorSpecs(cfg, "", "")
+	infos := make([]CollectorInfo, len(specs))
+	for i, s := range specs {
+		infos[i] = CollectorInfo{
+			Name:     s.name,
+			Enabled:  s.enabled,
+			Reason:   cfg.CollectorReason(s.key),
+			Interval: config.Duration{Duration: s.build().Interval()},
+		}
+		if p := cfg.Profile(); p != nil && strings.HasPrefix(infos[i].Reason, config.ReasonProfile) {
+			infos[i].Profile = p.Name
+		}
+	}
+	return infos
+}
+
+// claudePricing converts a configured price, deriving unset cache rates
+// from the input rate.
+func claudePricing(p config.ClaudePricingConfig) claude.ModelPricing {
+	mp := claude.ModelPricing{
+		InputPer1M:         p.Input,
+		OutputPer1M:        p.Output,
+		CacheCreationPer1M: p.CacheWrite,
+		CacheReadPer1M:     p.CacheRead,
+	}
+	if p.CacheWrite == 0 && p.CacheRead == 0 {
+		mp.CacheCreationPer1M = p.Input * 1.25
+		mp.CacheReadPer1M = p.Input * 0.1
+	}
+	return mp
+}
+
+// billingRates returns the exchange rate source cc configures. ECB rates
+// are cached in dir.
+func billingRates(cc config.CurrencyConfig, dir string) billing.RateSource {
+	if cc.Source == "static" {
+		return billing.StaticRates(cc.Display, cc.Rates)
+	}
+	cacheFile := ""
+	if dir != "" {
+		cacheFile = filepath.Join(dir, billing.ECBRatesFileName)
+	}
+	return billing.NewECBSource(cc.ECBURL, cacheFile, cc.RatesTTL.Duration)
+}
+
+// billingPeriod returns the billing period bp configures. Config
+// validation has already checked its date and time zone.
+func billingPeriod(bp config.BillingPeriodConfig) billing.Period {
+	p := billing.Period{AnchorDay: bp.AnchorDay, CycleDays: bp.CycleDays}
+	if bp.Start != "" {
+		p.CycleStart, _ = time.Parse(time.DateOnly, bp.Start)
+	}
+	if bp.Timezone != "" {
+		p.Location, _ = time.LoadLocation(bp.Timezone)
+	}
+	return p
+}
+
+// fingerprint returns a comparable form of a collector's settings.
+func fingerprint(settings interface{}) string {
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Sprintf("%#v", settings)
+	}
+	return string(b)
+}
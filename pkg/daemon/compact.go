@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"log"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+)
+
+// compactInterval is how often the daemon compacts the cache store.
+const compactInterval = 24 * time.Hour
+
+// compactCache compacts the cache store in DataDir, removing expired
+// entries, old quarantined entries, and abandoned temporary files, and logs
+// what it removed and what is left.
+func (d *Daemon) compactCache() (cache.CompactResult, error) {
+	store, err := cache.NewStore(cache.StoreConfig{Dir: d.cfg.DataDir})
+	if err != nil {
+		log.Printf("daemon: cache compaction: %v", err)
+		return cache.CompactResult{}, err
+	}
+	defer store.Close()
+
+	res, err := store.Compact()
+	if err != nil {
+		log.Printf("daemon: cache compaction: %v", err)
+		return res, err
+	}
+	st := store.Stats()
+	log.Printf("daemon: cache compacted: removed %d expired, %d quarantined, %d temp files; %d entries (%d bytes), %d quarantined",
+		res.Expired, res.Quarantined, res.TempFiles, st.Entries, st.Size, st.Quarantined)
+	return res, nil
+}
@@ -0,0 +1,416 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/logging"
+)
+
+// Control commands accepted as JSON on the daemon socket.
+const (
+	ControlStatus   = "status"
+	ControlCollect  = "collect"
+	ControlReload   = "reload"
+	ControlShutdown = "shutdown"
+	ControlAccept   = "accept"
+)
+
+// ControlSocketName is the file name of the control socket inside the
+// state directory.
+const ControlSocketName = "prompt-pulse.sock"
+
+// collectTimeout bounds a single collector run unless general.collect_timeout
+// sets another bound.
+const collectTimeout = 30 * time.Second
+
+// errCollectTimeout marks a collector run cut off by the collect timeout.
+var errCollectTimeout = errors.New("timed out")
+
+// ControlRequest is one line of JSON sent to the daemon socket.
+type ControlRequest struct {
+	// Command is one of ControlStatus, ControlCollect, ControlReload,
+	// ControlShutdown, or ControlAccept.
+	Command string `json:"command"`
+
+	// Collector limits ControlCollect to one collector. Empty collects
+	// every registered collector. ControlAccept requires it.
+	Collector string `json:"collector,omitempty"`
+}
+
+// ControlResponse is the daemon's one-line JSON reply to a ControlRequest.
+type ControlResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Status is set for ControlStatus.
+	Status *HealthStatus `json:"status,omitempty"`
+
+	// Collected names the collectors that ran successfully for
+	// ControlCollect.
+	Collected []string `json:"collected,omitempty"`
+
+	// TimedOut names the collectors ControlCollect cut off at the collect
+	// timeout; their cached data is stale.
+	TimedOut []string `json:"timed_out,omitempty"`
+
+	// Changes lists what a successful ControlReload applied.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// ControlHandler processes JSON control requests. An IPCHandler that also
+// implements ControlHandler receives lines starting with "{" here instead
+// of through HandleCommand.
+type ControlHandler interface {
+	HandleControl(req ControlRequest) ControlResponse
+}
+
+// Control sends req to the daemon as a line of JSON and returns its reply.
+func (c *IPCClient) Control(req ControlRequest) (*ControlResponse, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, fmt.Errorf("connect to daemon: %w", err)
+	}
+	defer conn.Close()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	fmt.Fprintf(conn, "%s\n", data)
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		return nil, fmt.Errorf("empty response from daemon")
+	}
+
+	var resp ControlResponse
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Ping asks the daemon at socketPath for its status, giving up after
+// timeout. It fails unless the daemon answers with a status naming its
+// PID, so a socket left behind by a crashed daemon, or one that accepts
+// connections but never replies, is an error.
+func Ping(socketPath string, timeout time.Duration) (*HealthStatus, error) {
+	resp, err := NewIPCClient(socketPath).WithTimeout(timeout).Control(ControlRequest{Command: ControlStatus})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("daemon refused status: %s", resp.Error)
+	}
+	if resp.Status == nil || resp.Status.PID <= 0 {
+		return nil, fmt.Errorf("daemon status reply has no PID")
+	}
+	return resp.Status, nil
+}
+
+// HandleControl implements ControlHandler.
+func (d *Daemon) HandleControl(req ControlRequest) ControlResponse {
+	switch req.Command {
+	case ControlStatus:
+		return ControlResponse{OK: true, Status: d.status()}
+
+	case ControlCollect:
+		res, err := d.Collect(context.Background(), req.Collector)
+		resp := ControlResponse{OK: err == nil, Collected: res.Collected, TimedOut: res.TimedOut}
+		if err != nil {
+			resp.Error = err.Error()
+		} else if len(res.Collected) == 0 {
+			resp.Message = "no collectors registered"
+		}
+		return resp
+
+	case ControlReload:
+		if err := d.Reload(); err != nil {
+			if errors.Is(err, errNoConfig) {
+				return ControlResponse{Error: err.Error()}
+			}
+			return ControlResponse{Error: "reload rejected, running config kept: " + err.Error()}
+		}
+		resp := ControlResponse{OK: true, Message: "config reloaded"}
+		if rs := d.status().LastReload; rs != nil {
+			resp.Changes = rs.Changes
+		}
+		return resp
+
+	case ControlShutdown:
+		d.requestShutdown()
+		return ControlResponse{OK: true, Message: "shutting down"}
+
+	case ControlAccept:
+		if err := d.acceptNext(req.Collector); err != nil {
+			return ControlResponse{Error: err.Error()}
+		}
+		return ControlResponse{OK: true, Message: "next " + req.Collector + " result will be accepted unchecked"}
+
+	default:
+		return ControlResponse{Error: fmt.Sprintf("unknown command: %q", req.Command)}
+	}
+}
+
+// CollectResult is the outcome of a Collect pass.
+type CollectResult struct {
+	// Collected names the collectors that succeeded.
+	Collected []string
+
+	// TimedOut names the collectors cut off at the collect timeout.
+	TimedOut []string
+}
+
+// Collect runs the named registered collector, or all of them when name is
+// empty, writing each result to <DataDir>/<name>.json and recording its
+// health. Collectors run now even if their schedule is backing off. They
+// run concurrently, and each result is written as soon as its collector
+// finishes, so a slow collector only delays its own data. It returns the
+// collectors that succeeded or timed out and an error naming those that
+// failed.
+func (d *Daemon) Collect(ctx context.Context, name string) (CollectResult, error) {
+	d.mu.Lock()
+	reg := d.registry
+	d.mu.Unlock()
+
+	var names []string
+	switch {
+	case name != "":
+		if reg == nil {
+			return CollectResult{}, fmt.Errorf("collector %q not registered", name)
+		}
+		if _, ok := reg.Get(name); !ok {
+			return CollectResult{}, fmt.Errorf("collector %q not registered", name)
+		}
+		names = []string{name}
+	case reg != nil:
+		names = reg.List()
+	}
+
+	errs := make([]error, len(names))
+	ran := make([]bool, len(names))
+	var wg sync.WaitGroup
+	for i, n := range names {
+		c, ok := reg.Get(n)
+		if !ok {
+			continue
+		}
+		ran[i] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.collectOne(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	var res CollectResult
+	var failed []string
+	for i, n := range names {
+		switch {
+		case !ran[i]:
+		case errs[i] == nil:
+			res.Collected = append(res.Collected, n)
+		default:
+			if errors.Is(errs[i], errCollectTimeout) {
+				res.TimedOut = append(res.TimedOut, n)
+			}
+			failed = append(failed, n+": "+errs[i].Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return res, errors.New(strings.Join(failed, "; "))
+	}
+	return res, nil
+}
+
+// collectOne runs c once, bounded by the collect timeout, checks its
+// result, writes it to <DataDir>/<name>.json, logs what changed in it to
+// the event log, adds sysmetrics data to the history, and records its
+// health. A collector that overruns the timeout is abandoned and recorded
+// as timed out; a result that fails validation is not written and the
+// collector is recorded as rejected. The run is logged with the
+// collector, its cache key, and how long it took: at debug when it
+// succeeds and as a warning when it fails.
+func (d *Daemon) collectOne(ctx context.Context, c collectors.Collector) error {
+	if !d.beginWork() {
+		return errShuttingDown
+	}
+	defer d.inflight.Done()
+
+	name := c.Name()
+	timeout := d.collectTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	data, err := runCollect(ctx, c)
+	timedOut := err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if timedOut {
+		err = fmt.Errorf("%w after %s", errCollectTimeout, timeout)
+	}
+	rejected := false
+	if err == nil {
+		err = d.validateResult(name, data)
+		rejected = err != nil
+	}
+	if err == nil {
+		err = WriteCollectorData(d.cfg.DataDir, name, data)
+	}
+	attrs := []any{
+		logging.KeyCollector, name,
+		logging.KeyCacheKey, name,
+		logging.KeyDuration, time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		slog.Warn("collector run failed", append(attrs, logging.KeyError, err.Error(), "timed_out", timedOut, "rejected", rejected)...)
+		d.RecordCollectorError(name, d.errorCount(name)+1, err)
+		if timedOut || rejected {
+			d.mu.Lock()
+			d.collectors[name].TimedOut = timedOut
+			d.collectors[name].Rejected = rejected
+			d.mu.Unlock()
+		}
+		return err
+	}
+	slog.Debug("collector run", attrs...)
+	d.UpdateCollector(name, true, d.errorCount(name))
+	d.backfillLanded(name)
+	d.recordChanges(name, data)
+	d.recordHistory(name, data)
+	d.notify(name, data)
+	return nil
+}
+
+// collectTimeout returns the bound on one collector run: the configured
+// general.collect_timeout, or collectTimeout.
+func (d *Daemon) collectTimeout() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.appCfg != nil && d.appCfg.General.CollectTimeout.Duration > 0 {
+		return d.appCfg.General.CollectTimeout.Duration
+	}
+	return collectTimeout
+}
+
+// runCollect calls c.Collect in its own goroutine, so a collector that
+// ignores ctx cannot hold up the caller past its deadline; such a call is
+// left to finish on its own and its result discarded. A panic in Collect
+// is logged with its stack and returned as an error instead of taking
+// down the daemon.
+func runCollect(ctx context.Context, c collectors.Collector) (interface{}, error) {
+	type result struct {
+		data interface{}
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("collector panicked", logging.KeyCollector, c.Name(), "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+				done <- result{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		data, err := c.Collect(ctx)
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// errorCount returns the recorded error count for the named collector.
+func (d *Daemon) errorCount(name string) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ch, ok := d.collectors[name]; ok {
+		return ch.ErrorCount
+	}
+	return 0
+}
+
+// WriteCollectorData writes the named collector's result as JSON to
+// <dir>/<name>.json, atomically, where the prompt, banner, and TUI cache
+// readers expect it. Results of collectors listed in cache.encrypt are
+// encrypted; the readers decrypt them with cache.ReadFile.
+//
+// A result implementing collectors.Summarizer also gets its summary
+// written to <dir>/<name>.summary.json. The summary is written after the
+// result and renamed into place first, so a summary is never older than
+// the result it summarizes; readers ignore one that is.
+func WriteCollectorData(dir, name string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal result: %w", err)
+	}
+	if b, err = cache.DefaultEncryption().Seal(name, b); err != nil {
+		return fmt.Errorf("encrypt result: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("write result: %w", err)
+	}
+
+	summaryPath := filepath.Join(dir, name+".summary.json")
+	s, ok := data.(collectors.Summarizer)
+	if !ok {
+		os.Remove(summaryPath)
+	} else if err := writeSummary(summaryPath, name, s); err != nil {
+		// The prompt falls back to the full result.
+		os.Remove(summaryPath)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename result: %w", err)
+	}
+	return nil
+}
+
+// writeSummary writes s's summary to path atomically, encrypted like the
+// named collector's result.
+func writeSummary(path, name string, s collectors.Summarizer) error {
+	b, err := json.Marshal(s.Summarize())
+	if err != nil {
+		return err
+	}
+	if b, err = cache.DefaultEncryption().Seal(name, b); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
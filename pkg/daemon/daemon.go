@@ -1,5 +1,5 @@
 // Package daemon implements the background data collection daemon for
-// prompt-pulse. It manages PID file locking, health reporting, IPC via Unix
+// prompt-pulse. It manages single-instance locking, health reporting, IPC via Unix
 // sockets, and a pre-rendered banner cache inspired by powerlevel10k's instant
 // prompt technique.
 package daemon
@@ -8,18 +8,36 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/notify"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/validate"
 )
 
 // Config holds all configuration for the daemon process.
 type Config struct {
-	// PIDFile is the path to the PID file used for singleton enforcement.
+	// PIDFile is the path to a file holding the daemon's PID, for tools
+	// that expect one. LockFile, not this file, keeps a second daemon from
+	// starting.
 	// Default: $XDG_RUNTIME_DIR/prompt-pulse.pid or /tmp/prompt-pulse-{uid}.pid
 	PIDFile string
 
+	// LockFile is the path to the instance lock; see AcquireLock.
+	// Default: LockFileName in StateDir.
+	LockFile string
+
 	// HealthFile is the path to the health status JSON file.
 	// Default: alongside PID file.
 	HealthFile string
@@ -31,9 +49,18 @@ type Config struct {
 	// DataDir is the directory for persistent data storage.
 	DataDir string
 
+	// StateDir is the directory for what cannot be rebuilt from a
+	// collection: the event log and the sysmetrics, Claude, and billing
+	// history. Default: DataDir.
+	StateDir string
+
 	// BannerCacheFile is the path to the pre-rendered banner cache.
 	// Default: alongside PID file with -banner.json suffix.
 	BannerCacheFile string
+
+	// Version is the running build's version, reported in status replies
+	// so deploy checks can tell which build answers.
+	Version string
 }
 
 // DefaultConfig returns a Config with platform-appropriate default paths.
@@ -45,6 +72,7 @@ func DefaultConfig() Config {
 		HealthFile:      filepath.Join(base, "prompt-pulse-health.json"),
 		SocketPath:      filepath.Join(base, "prompt-pulse.sock"),
 		DataDir:         filepath.Join(base, "data"),
+		LockFile:        filepath.Join(base, "data", LockFileName),
 		BannerCacheFile: filepath.Join(base, "prompt-pulse-banner.json"),
 	}
 }
@@ -60,10 +88,18 @@ func defaultBasePath() string {
 // HealthStatus represents the current state of the daemon and its collectors.
 type HealthStatus struct {
 	PID        int                        `json:"pid"`
+	Version    string                     `json:"version,omitempty"`
 	Uptime     time.Duration              `json:"uptime_ns"`
 	StartedAt  time.Time                  `json:"started_at"`
 	Collectors map[string]CollectorHealth `json:"collectors"`
 	LastUpdate time.Time                  `json:"last_update"`
+
+	// LastReload is the outcome of the most recent config reload, if any.
+	LastReload *ReloadStatus `json:"last_reload,omitempty"`
+
+	// LastBackfill is the most recent re-collection after a gap in the
+	// schedule, such as a suspend, if any.
+	LastBackfill *cache.Backfill `json:"last_backfill,omitempty"`
 }
 
 // CollectorHealth tracks the health of a single collector within the daemon.
@@ -72,6 +108,62 @@ type CollectorHealth struct {
 	Healthy    bool      `json:"healthy"`
 	LastRun    time.Time `json:"last_run"`
 	ErrorCount int64     `json:"error_count"`
+	LastError  string    `json:"last_error,omitempty"`
+
+	// NextRun is when the daemon next runs the collector on its schedule.
+	// ConsecutiveFailures counts the failed runs since the last success;
+	// while it is nonzero the collector runs less often.
+	NextRun             time.Time `json:"next_run,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures,omitempty"`
+
+	// TimedOut is set when the last run was cut off by the collect
+	// timeout, so the collector's cached data is stale.
+	TimedOut bool `json:"timed_out,omitempty"`
+
+	// Rejected is set when the last result failed validation and was not
+	// cached, so the collector's cached data is the last accepted.
+	Rejected bool `json:"rejected,omitempty"`
+
+	// HTTP counts the collector's HTTP requests, when it makes any.
+	HTTP *httpx.Stats `json:"http,omitempty"`
+}
+
+// LastSuccess returns when a collector last ran successfully, or the zero
+// time when none has.
+func (h *HealthStatus) LastSuccess() time.Time {
+	var last time.Time
+	for _, ch := range h.Collectors {
+		if ch.Healthy && ch.LastRun.After(last) {
+			last = ch.LastRun
+		}
+	}
+	return last
+}
+
+// TimedOut returns the collectors whose last run timed out, sorted by
+// name.
+func (h *HealthStatus) TimedOut() []string {
+	var names []string
+	for name, ch := range h.Collectors {
+		if ch.TimedOut {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rejected returns the collectors whose last result failed validation,
+// sorted by name.
+func (h *HealthStatus) Rejected() []string {
+	var names []string
+	for name, ch := range h.Collectors {
+		if ch.Rejected {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Daemon is the main background process that orchestrates data collection,
@@ -82,10 +174,73 @@ type Daemon struct {
 	running   bool
 	ipc       *IPCServer
 	banner    *BannerCache
+	lock      *InstanceLock
 
 	// collectors tracks health state for registered collectors.
 	collectors map[string]*CollectorHealth
 
+	// registry holds the collectors run by the collect control command.
+	registry *collectors.Registry
+
+	// appCfg is the running configuration, loaded from configPath; see
+	// SetConfig and Reload. configStamp identifies the file version loaded.
+	appCfg      *config.Config
+	configPath  string
+	configStamp string
+	lastReload  *ReloadStatus
+
+	// jobs are the collectors scheduled from appCfg, started with runCtx.
+	jobs   map[string]*collectorJob
+	runCtx context.Context
+
+	// statusPageAt is when writeStatusPage last wrote the status page.
+	statusPageAt time.Time
+
+	// notifier delivers notifications.rule events; nil when no rule is
+	// configured. See applyNotifications.
+	notifier *notify.Notifier
+
+	// differ finds the changes in collector data logged to the event
+	// log, keeping at most eventsMax entries; nil when events are
+	// disabled. eventsMu serializes writes to the log. See applyEvents.
+	differ    *changelog.Differ
+	eventsMax int
+	eventsMu  sync.Mutex
+
+	// validator checks collector results before they are cached; nil when
+	// validation is disabled. rejectMu serializes writes to the rejected
+	// log. See applyValidation.
+	validator *validate.Validator
+	rejectMu  sync.Mutex
+
+	// sysHistory keeps recent sysmetrics samples for the TUI's graphs,
+	// saved every sysHistorySaveInterval; nil when no history is kept.
+	// See applySysHistory.
+	sysHistory          *sysmetrics.History
+	sysHistoryRetention time.Duration
+	sysHistoryInterval  time.Duration
+
+	// clock replaces time.Now in tests; see now. gaps notices stalls in
+	// the schedule, after which lastBackfill records the re-collection
+	// started, guarded by backfillMu. See backfill.go.
+	clock        func() time.Time
+	gaps         gapDetector
+	lastBackfill *cache.Backfill
+	backfillMu   sync.Mutex
+
+	// shutdown is closed to end the main loop; see requestShutdown.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	// inflight counts the collector runs and notification deliveries a
+	// stopping daemon waits for. Once stopping is set, draining is closed
+	// and no new work starts; force cuts the wait short. See drain.
+	inflight  sync.WaitGroup
+	stopping  bool
+	draining  chan struct{}
+	force     chan struct{}
+	forceOnce sync.Once
+
 	mu sync.Mutex
 }
 
@@ -107,16 +262,40 @@ func New(cfg Config) (*Daemon, error) {
 	if cfg.BannerCacheFile == "" {
 		return nil, fmt.Errorf("daemon: BannerCacheFile must not be empty")
 	}
+	if cfg.StateDir == "" {
+		cfg.StateDir = cfg.DataDir
+	}
+	if cfg.LockFile == "" {
+		cfg.LockFile = filepath.Join(cfg.StateDir, LockFileName)
+	}
 
 	return &Daemon{
 		cfg:        cfg,
 		collectors: make(map[string]*CollectorHealth),
 		banner:     NewBannerCache(cfg.BannerCacheFile),
+		shutdown:   make(chan struct{}),
+		draining:   make(chan struct{}),
+		force:      make(chan struct{}),
 	}, nil
 }
 
-// Start acquires the PID lock, starts the IPC server, and enters the main
-// collection loop. It blocks until the context is cancelled or an error occurs.
+// SetRegistry sets the collectors run by the collect control command and
+// listed by status.
+func (d *Daemon) SetRegistry(reg *collectors.Registry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.registry = reg
+}
+
+// requestShutdown makes Start stop the daemon and return. It is safe to
+// call more than once.
+func (d *Daemon) requestShutdown() {
+	d.shutdownOnce.Do(func() { close(d.shutdown) })
+}
+
+// Start acquires the instance lock, writes the PID file, starts the IPC server, and enters the main
+// collection loop. It blocks until the context is cancelled, or a shutdown is requested, and the
+// daemon has shut down as shutdownGracefully describes.
 func (d *Daemon) Start(ctx context.Context) error {
 	// Ensure directories exist.
 	for _, dir := range []string{
@@ -124,20 +303,29 @@ func (d *Daemon) Start(ctx context.Context) error {
 		filepath.Dir(d.cfg.HealthFile),
 		filepath.Dir(d.cfg.SocketPath),
 		d.cfg.DataDir,
+		d.cfg.StateDir,
 	} {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return fmt.Errorf("daemon: create directory %s: %w", dir, err)
 		}
 	}
 
-	// Acquire PID lock.
+	lock, err := AcquireLock(d.cfg.LockFile)
+	if err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	// With the lock held, a PID file left in place is from a daemon that
+	// died without removing it.
+	ReleasePID(d.cfg.PIDFile)
 	if err := AcquirePID(d.cfg.PIDFile); err != nil {
+		lock.Release()
 		return fmt.Errorf("daemon: acquire PID: %w", err)
 	}
 
 	d.mu.Lock()
 	d.startedAt = time.Now()
 	d.running = true
+	d.lock = lock
 	d.mu.Unlock()
 
 	// Start IPC server.
@@ -146,6 +334,8 @@ func (d *Daemon) Start(ctx context.Context) error {
 		ReleasePID(d.cfg.PIDFile)
 		d.mu.Lock()
 		d.running = false
+		d.lock.Release()
+		d.lock = nil
 		d.mu.Unlock()
 		return fmt.Errorf("daemon: start IPC: %w", err)
 	}
@@ -156,22 +346,94 @@ func (d *Daemon) Start(ctx context.Context) error {
 		_ = err
 	}
 
-	// Main loop: write health periodically until context is cancelled.
+	// Compact the cache store before collectors write to it, then daily.
+	_, _ = d.compactCache()
+	compactTicker := time.NewTicker(compactInterval)
+	defer compactTicker.Stop()
+
+	// Collector runs outlive ctx by the shutdown grace period; see
+	// shutdownGracefully.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	// A marker left by a daemon that stopped mid-backfill is void, and the
+	// gap check counts from here.
+	_ = cache.WriteBackfill(d.cfg.DataDir, &cache.Backfill{})
+	d.checkGap()
+	d.startJobs(runCtx)
+
+	// SIGHUP, or an edit to the config file, reloads the configuration.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	configTicker := time.NewTicker(configPollInterval)
+	defer configTicker.Stop()
+	historyTicker := time.NewTicker(sysHistorySaveInterval)
+	defer historyTicker.Stop()
+
+	// After a suspend, or any other stall, collect everything at once
+	// rather than waiting out each collector's interval.
+	gapTicker := time.NewTicker(backfillCheckInterval)
+	defer gapTicker.Stop()
+	resumed := watchResume(runCtx)
+
+	// Main loop: write health, and the status page when one is
+	// configured, periodically until context is cancelled.
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return d.Stop()
-		case <-ticker.C:
+			return d.shutdownGracefully(cancel)
+		case <-d.shutdown:
+			return d.shutdownGracefully(cancel)
+		case <-hup:
+			_ = d.Reload()
+			_ = d.WriteHealth()
+		case <-configTicker.C:
+			if d.configChanged() {
+				_ = d.Reload()
+				_ = d.WriteHealth()
+			}
+		case <-compactTicker.C:
+			_, _ = d.compactCache()
+		case <-historyTicker.C:
+			d.saveSysHistory()
+		case <-gapTicker.C:
+			d.checkGap()
+		case slept := <-resumed:
+			d.backfill(cache.BackfillResume, slept)
+		case now := <-ticker.C:
 			_ = d.WriteHealth()
+			d.writeStatusPage(now)
 		}
 	}
 }
 
+// shutdownGracefully stops the daemon once Start's main loop ends: no new
+// collector runs start, those in flight get the shutdown grace period to
+// finish and write their data, and then cancel stops the rest before the
+// sysmetrics history is saved, the daemon stops as Stop describes, and it
+// drops its notification sinks. It returns ErrForcedShutdown when
+// ForceShutdown cut the wait short.
+func (d *Daemon) shutdownGracefully(cancel context.CancelFunc) error {
+	graceful := d.drain()
+	cancel()
+	d.saveSysHistory()
+
+	d.mu.Lock()
+	d.notifier = nil
+	d.mu.Unlock()
+
+	err := d.Stop()
+	if !graceful {
+		return ErrForcedShutdown
+	}
+	return err
+}
+
 // Stop performs a graceful shutdown: stops the IPC server, removes the PID
-// file, and cleans up the socket.
+// file, cleans up the socket, and releases the instance lock.
 func (d *Daemon) Stop() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -187,22 +449,21 @@ func (d *Daemon) Stop() error {
 		d.ipc.Stop()
 	}
 
-	// Remove PID file.
-	if err := ReleasePID(d.cfg.PIDFile); err != nil {
+	// Remove PID file, then let another daemon start.
+	err := ReleasePID(d.cfg.PIDFile)
+	d.lock.Release()
+	d.lock = nil
+	if err != nil {
 		return fmt.Errorf("daemon: release PID: %w", err)
 	}
 
 	return nil
 }
 
-// IsRunning checks whether a daemon instance is alive by reading the PID file
-// and probing the process.
+// IsRunning reports whether a daemon instance holds the instance lock.
 func (d *Daemon) IsRunning() bool {
-	pid, err := ReadPID(d.cfg.PIDFile)
-	if err != nil {
-		return false
-	}
-	return IsProcessAlive(pid)
+	_, ok := LockHolder(d.cfg.LockFile)
+	return ok
 }
 
 // Health reads the current health status from the health file.
@@ -212,23 +473,57 @@ func (d *Daemon) Health() (*HealthStatus, error) {
 
 // WriteHealth writes the current daemon health to the health file.
 func (d *Daemon) WriteHealth() error {
+	return WriteHealthFile(d.cfg.HealthFile, d.status())
+}
+
+// status builds the current health from memory. Registered collectors that
+// have not run yet are listed as healthy with a zero LastRun. Scheduled
+// collectors include their next run.
+func (d *Daemon) status() *HealthStatus {
 	d.mu.Lock()
 	collectors := make(map[string]CollectorHealth, len(d.collectors))
 	for k, v := range d.collectors {
 		collectors[k] = *v
 	}
+	if d.registry != nil {
+		for _, name := range d.registry.List() {
+			if _, ok := collectors[name]; !ok {
+				collectors[name] = CollectorHealth{Name: name, Healthy: true}
+			}
+		}
+	}
+	for name, j := range d.jobs {
+		ch, ok := collectors[name]
+		if !ok {
+			ch = CollectorHealth{Name: name, Healthy: true}
+		}
+		ch.NextRun, ch.ConsecutiveFailures = j.schedule()
+		collectors[name] = ch
+	}
+	for name, ch := range collectors {
+		if s, ok := httpx.StatsFor(name); ok {
+			ch.HTTP = &s
+			collectors[name] = ch
+		}
+	}
 	startedAt := d.startedAt
+	var lastReload *ReloadStatus
+	if d.lastReload != nil {
+		rs := *d.lastReload
+		lastReload = &rs
+	}
 	d.mu.Unlock()
 
-	status := &HealthStatus{
-		PID:        os.Getpid(),
-		Uptime:     time.Since(startedAt),
-		StartedAt:  startedAt,
-		Collectors: collectors,
-		LastUpdate: time.Now(),
+	return &HealthStatus{
+		PID:          os.Getpid(),
+		Version:      d.cfg.Version,
+		Uptime:       time.Since(startedAt),
+		StartedAt:    startedAt,
+		Collectors:   collectors,
+		LastUpdate:   time.Now(),
+		LastReload:   lastReload,
+		LastBackfill: d.backfillStatus(),
 	}
-
-	return WriteHealthFile(d.cfg.HealthFile, status)
 }
 
 // Running returns whether the daemon is currently in its main loop.
@@ -251,6 +546,24 @@ func (d *Daemon) UpdateCollector(name string, healthy bool, errCount int64) {
 	}
 }
 
+// RecordCollectorError marks a named collector unhealthy and keeps the
+// error message so health output can say why (e.g. tailscaled not running).
+func (d *Daemon) RecordCollectorError(name string, errCount int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch := &CollectorHealth{
+		Name:       name,
+		Healthy:    false,
+		LastRun:    time.Now(),
+		ErrorCount: errCount,
+	}
+	if err != nil {
+		ch.LastError = err.Error()
+	}
+	d.collectors[name] = ch
+}
+
 // HandleCommand implements the IPCHandler interface, dispatching IPC commands.
 func (d *Daemon) HandleCommand(cmd string, args map[string]string) (string, error) {
 	switch cmd {
@@ -258,21 +571,7 @@ func (d *Daemon) HandleCommand(cmd string, args map[string]string) (string, erro
 		status, err := d.Health()
 		if err != nil {
 			// If health file does not exist yet, build from memory.
-			d.mu.Lock()
-			collectors := make(map[string]CollectorHealth, len(d.collectors))
-			for k, v := range d.collectors {
-				collectors[k] = *v
-			}
-			startedAt := d.startedAt
-			d.mu.Unlock()
-
-			status = &HealthStatus{
-				PID:        os.Getpid(),
-				Uptime:     time.Since(startedAt),
-				StartedAt:  startedAt,
-				Collectors: collectors,
-				LastUpdate: time.Now(),
-			}
+			status = d.status()
 		}
 		return healthStatusToJSON(status)
 
@@ -291,11 +590,8 @@ func (d *Daemon) HandleCommand(cmd string, args map[string]string) (string, erro
 		return `{"status":"ok","message":"refresh triggered"}`, nil
 
 	case "QUIT":
-		go func() {
-			// Allow the response to be sent before stopping.
-			time.Sleep(100 * time.Millisecond)
-			d.Stop()
-		}()
+		// Start stops the daemon once the response has been sent.
+		d.requestShutdown()
 		return `{"status":"ok","message":"shutting down"}`, nil
 
 	default:
@@ -1,13 +1,29 @@
 package daemon
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/validate"
 )
 
 // shortSockDir creates a short temporary directory suitable for Unix socket
@@ -166,6 +182,85 @@ func TestIsProcessAlive_NegativePID(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Instance lock tests
+// ---------------------------------------------------------------------------
+
+func TestAcquireLock_RecordsHolder(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "cache", LockFileName)
+
+	lock, err := AcquireLock(lockPath)
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+	defer lock.Release()
+
+	info, ok := LockHolder(lockPath)
+	if !ok {
+		t.Fatal("LockHolder() = false while the lock is held")
+	}
+	if info.PID != os.Getpid() || time.Since(info.StartedAt) > time.Minute {
+		t.Errorf("LockHolder() = %+v, want this process, started now", info)
+	}
+}
+
+func TestAcquireLock_RefusesSecondDaemon(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), LockFileName)
+
+	lock, err := AcquireLock(lockPath)
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+
+	_, err = AcquireLock(lockPath)
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second AcquireLock() error = %v, want ErrAlreadyRunning", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("PID %d", os.Getpid())) {
+		t.Errorf("error %q does not name the running daemon", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if _, ok := LockHolder(lockPath); ok {
+		t.Error("LockHolder() = true after Release()")
+	}
+	again, err := AcquireLock(lockPath)
+	if err != nil {
+		t.Fatalf("AcquireLock() after Release() error: %v", err)
+	}
+	again.Release()
+}
+
+func TestAcquireLock_TakesOverFromDeadDaemon(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), LockFileName)
+
+	// A daemon that was killed leaves its record behind, but not the lock.
+	stale := `{"pid":4999999,"started_at":"2026-01-01T00:00:00Z"}`
+	if err := os.WriteFile(lockPath, []byte(stale), 0o644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if _, ok := LockHolder(lockPath); ok {
+		t.Error("LockHolder() = true for a dead daemon's record")
+	}
+
+	lock, err := AcquireLock(lockPath)
+	if err != nil {
+		t.Fatalf("AcquireLock() over a dead daemon error: %v", err)
+	}
+	defer lock.Release()
+	if info, _ := LockHolder(lockPath); info.PID != os.Getpid() {
+		t.Errorf("LockHolder().PID = %d, want %d (dead PID should be replaced)", info.PID, os.Getpid())
+	}
+}
+
+func TestLockHolder_NoFile(t *testing.T) {
+	if _, ok := LockHolder(filepath.Join(t.TempDir(), LockFileName)); ok {
+		t.Error("LockHolder() = true with no lock file")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Health file tests
 // ---------------------------------------------------------------------------
@@ -787,6 +882,31 @@ func TestNew_EmptyDataDir(t *testing.T) {
 	}
 }
 
+func TestNew_StateDirDefaults(t *testing.T) {
+	cfg := Config{
+		PIDFile:         "/tmp/test.pid",
+		HealthFile:      "/tmp/health.json",
+		SocketPath:      "/tmp/test.sock",
+		DataDir:         "/tmp/data",
+		BannerCacheFile: "/tmp/banner.json",
+	}
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if d.cfg.StateDir != cfg.DataDir || d.cfg.LockFile != filepath.Join(cfg.DataDir, LockFileName) {
+		t.Errorf("StateDir, LockFile = %q, %q; want both in DataDir", d.cfg.StateDir, d.cfg.LockFile)
+	}
+
+	cfg.StateDir = "/tmp/state"
+	if d, err = New(cfg); err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if d.cfg.LockFile != filepath.Join(cfg.StateDir, LockFileName) {
+		t.Errorf("LockFile = %q, want it in StateDir", d.cfg.LockFile)
+	}
+}
+
 func TestNew_EmptyBannerCacheFile(t *testing.T) {
 	cfg := Config{
 		PIDFile:         "/tmp/test.pid",
@@ -822,7 +942,7 @@ func TestDaemon_IsRunning_NoPIDFile(t *testing.T) {
 	}
 }
 
-func TestDaemon_IsRunning_WithPIDFile(t *testing.T) {
+func TestDaemon_IsRunning_WithLock(t *testing.T) {
 	dir := t.TempDir()
 	pidPath := filepath.Join(dir, "test.pid")
 	cfg := Config{
@@ -838,14 +958,49 @@ func TestDaemon_IsRunning_WithPIDFile(t *testing.T) {
 		t.Fatalf("New() error: %v", err)
 	}
 
-	// Write current PID.
+	// A PID file alone, e.g. left by a crashed daemon, is not enough.
 	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
 		t.Fatalf("WriteFile() error: %v", err)
 	}
-	defer os.Remove(pidPath)
+	if d.IsRunning() {
+		t.Error("IsRunning() = true, want false (PID file but no lock)")
+	}
+
+	lock, err := AcquireLock(filepath.Join(cfg.DataDir, LockFileName))
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+	defer lock.Release()
 
 	if !d.IsRunning() {
-		t.Error("IsRunning() = false, want true (current process PID in file)")
+		t.Error("IsRunning() = false, want true (lock held)")
+	}
+}
+
+func TestDaemon_Start_RefusesWhileLocked(t *testing.T) {
+	dir := shortSockDir(t)
+	cfg := Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, "test.sock"),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	}
+	lock, err := AcquireLock(filepath.Join(cfg.DataDir, LockFileName))
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+	defer lock.Release()
+
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := d.Start(context.Background()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("Start() error = %v, want ErrAlreadyRunning", err)
+	}
+	if _, err := os.Stat(cfg.SocketPath); !os.IsNotExist(err) {
+		t.Errorf("refused daemon created its socket; stat err = %v", err)
 	}
 }
 
@@ -911,6 +1066,42 @@ func TestDaemon_UpdateCollector(t *testing.T) {
 	}
 }
 
+func TestDaemon_RecordCollectorError(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, "test.sock"),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	}
+
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	d.RecordCollectorError("tailscale", 3, fmt.Errorf("tailscale status: tailscaled is not running"))
+
+	d.mu.Lock()
+	ts := d.collectors["tailscale"]
+	d.mu.Unlock()
+	if ts == nil || ts.Healthy || ts.ErrorCount != 3 {
+		t.Fatalf("tailscale health = %+v, want unhealthy with 3 errors", ts)
+	}
+	if ts.LastError != "tailscale status: tailscaled is not running" {
+		t.Errorf("LastError = %q, want the collector error", ts.LastError)
+	}
+
+	// A later successful run clears the error.
+	d.UpdateCollector("tailscale", true, 3)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.collectors["tailscale"].LastError != "" {
+		t.Errorf("LastError = %q after success, want empty", d.collectors["tailscale"].LastError)
+	}
+}
+
 func TestDaemon_HandleCommand_Health(t *testing.T) {
 	dir := t.TempDir()
 	cfg := Config{
@@ -1075,6 +1266,1019 @@ func TestIPC_WithDaemonHandler(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Control socket
+// ---------------------------------------------------------------------------
+
+// controlTestDaemon returns a daemon whose files live in a short temporary
+// directory, with its IPC server started on the control socket.
+func controlTestDaemon(t *testing.T) (*Daemon, *IPCClient) {
+	t.Helper()
+	dir := shortSockDir(t)
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	d.startedAt = time.Now()
+
+	srv := NewIPCServer(d.cfg.SocketPath, d)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(srv.Stop)
+	return d, NewIPCClient(d.cfg.SocketPath)
+}
+
+func TestControl_Status(t *testing.T) {
+	d, client := controlTestDaemon(t)
+	reg := collectors.NewRegistry()
+	reg.Register(collectors.NewMockCollector("claude", time.Minute))
+	d.SetRegistry(reg)
+	d.UpdateCollector("billing", true, 0)
+
+	resp, err := client.Control(ControlRequest{Command: ControlStatus})
+	if err != nil {
+		t.Fatalf("Control(status) error: %v", err)
+	}
+	if !resp.OK || resp.Status == nil || resp.Status.PID != os.Getpid() {
+		t.Fatalf("status response = %+v", resp)
+	}
+	if ch := resp.Status.Collectors["claude"]; !ch.Healthy || !ch.LastRun.IsZero() {
+		t.Errorf("claude = %+v, want listed as never run", ch)
+	}
+	if ch := resp.Status.Collectors["billing"]; ch.LastRun.IsZero() {
+		t.Errorf("billing = %+v, want its last run", ch)
+	}
+}
+
+func TestPing(t *testing.T) {
+	d, _ := controlTestDaemon(t)
+	d.cfg.Version = "1.2.3"
+	d.UpdateCollector("billing", true, 0)
+	d.RecordCollectorError("claude", 1, errors.New("boom"))
+
+	st, err := Ping(d.cfg.SocketPath, time.Second)
+	if err != nil {
+		t.Fatalf("Ping() error: %v", err)
+	}
+	if st.Version != "1.2.3" || st.PID != os.Getpid() {
+		t.Errorf("Ping() = %+v, want version 1.2.3 and this PID", st)
+	}
+	if want := st.Collectors["billing"].LastRun; !st.LastSuccess().Equal(want) {
+		t.Errorf("LastSuccess() = %s, want billing's last run %s", st.LastSuccess(), want)
+	}
+
+	// A socket file a crashed daemon left behind, and a listener that
+	// never replies, both fail within the timeout.
+	stale := filepath.Join(shortSockDir(t), "stale.sock")
+	os.WriteFile(stale, nil, 0o600)
+	if _, err := Ping(stale, time.Second); err == nil {
+		t.Error("Ping(stale socket) succeeded")
+	}
+	silent := filepath.Join(shortSockDir(t), "silent.sock")
+	ln, err := net.Listen("unix", silent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	start := time.Now()
+	if _, err := Ping(silent, 50*time.Millisecond); err == nil {
+		t.Error("Ping(silent socket) succeeded")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("Ping(silent socket) took %s, want the 50ms timeout", took)
+	}
+}
+
+func TestControl_Collect(t *testing.T) {
+	d, client := controlTestDaemon(t)
+	reg := collectors.NewRegistry()
+	reg.Register(collectors.NewMockCollector("claude", time.Minute, collectors.WithData(map[string]int{"tokens": 42})))
+	reg.Register(collectors.NewMockCollector("billing", time.Minute, collectors.WithError(errors.New("no API key"))))
+	d.SetRegistry(reg)
+
+	resp, err := client.Control(ControlRequest{Command: ControlCollect, Collector: "claude"})
+	if err != nil || !resp.OK || strings.Join(resp.Collected, ",") != "claude" {
+		t.Fatalf("collect claude = %+v, %v", resp, err)
+	}
+	data, err := os.ReadFile(filepath.Join(d.cfg.DataDir, "claude.json"))
+	if err != nil || string(data) != `{"tokens":42}` {
+		t.Errorf("claude.json = %q, %v", data, err)
+	}
+
+	resp, err = client.Control(ControlRequest{Command: ControlCollect})
+	if err != nil {
+		t.Fatalf("collect all error: %v", err)
+	}
+	if resp.OK || !strings.Contains(resp.Error, "billing: no API key") || strings.Join(resp.Collected, ",") != "claude" {
+		t.Errorf("collect all = %+v, want claude collected and billing failed", resp)
+	}
+	if ch := d.status().Collectors["billing"]; ch.Healthy || ch.ErrorCount != 1 || ch.LastError != "no API key" {
+		t.Errorf("billing health = %+v", ch)
+	}
+
+	resp, _ = client.Control(ControlRequest{Command: ControlCollect, Collector: "nope"})
+	if resp.OK || !strings.Contains(resp.Error, "not registered") {
+		t.Errorf("collect nope = %+v, want not registered", resp)
+	}
+}
+
+func TestCollect_ParallelWithTimeoutAndPanic(t *testing.T) {
+	d, _ := controlTestDaemon(t)
+	cfg := config.DefaultConfig()
+	cfg.General.CollectTimeout = config.Duration{Duration: 100 * time.Millisecond}
+	d.appCfg = cfg
+
+	release := make(chan struct{})
+	defer close(release)
+	reg := collectors.NewRegistry()
+	// hung ignores its context, as a stuck API call without a deadline
+	// would.
+	reg.Register(collectors.NewMockCollector("hung", time.Minute, collectors.WithCollectFunc(func(ctx context.Context) (interface{}, error) {
+		<-release
+		return 1, nil
+	})))
+	reg.Register(collectors.NewMockCollector("boom", time.Minute, collectors.WithCollectFunc(func(ctx context.Context) (interface{}, error) {
+		panic("nil map")
+	})))
+	reg.Register(collectors.NewMockCollector("fast", time.Minute, collectors.WithData(7)))
+	d.SetRegistry(reg)
+
+	start := time.Now()
+	res, err := d.Collect(context.Background(), "")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Collect took %s, want it bounded by the 100ms timeout", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "boom: panic: nil map") || !strings.Contains(err.Error(), "hung: timed out after 100ms") {
+		t.Errorf("err = %v, want the panic and the timeout", err)
+	}
+	if strings.Join(res.Collected, ",") != "fast" || strings.Join(res.TimedOut, ",") != "hung" {
+		t.Errorf("result = %+v, want fast collected and hung timed out", res)
+	}
+	if b, _ := os.ReadFile(filepath.Join(d.cfg.DataDir, "fast.json")); string(b) != "7" {
+		t.Errorf("fast.json = %q, want the fast result written", b)
+	}
+
+	st := d.status()
+	if ch := st.Collectors["boom"]; ch.Healthy || ch.TimedOut {
+		t.Errorf("boom = %+v, want unhealthy but not timed out", ch)
+	}
+	if got := st.TimedOut(); strings.Join(got, ",") != "hung" {
+		t.Errorf("TimedOut() = %v, want [hung]", got)
+	}
+
+	// A later successful run clears the stale marker.
+	reg.Unregister("hung")
+	reg.Register(collectors.NewMockCollector("hung", time.Minute, collectors.WithData(1)))
+	if _, err := d.Collect(context.Background(), "hung"); err != nil {
+		t.Fatalf("Collect(hung) error: %v", err)
+	}
+	if got := d.status().TimedOut(); len(got) != 0 {
+		t.Errorf("TimedOut() = %v after a successful run, want none", got)
+	}
+}
+
+// reloadTestConfig is a config with only the collectors in extra enabled.
+const reloadTestConfig = `
+[collectors.sysmetrics]
+enabled = false
+[collectors.tailscale]
+enabled = false
+[collectors.claude]
+enabled = false
+`
+
+// writeReloadConfig writes reloadTestConfig followed by extra to path.
+func writeReloadConfig(t *testing.T, path, extra string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(reloadTestConfig+extra), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestControl_Reload(t *testing.T) {
+	d, client := controlTestDaemon(t)
+
+	if resp, _ := client.Control(ControlRequest{Command: ControlReload}); resp.OK || !strings.Contains(resp.Error, "not supported") {
+		t.Errorf("reload without a config = %+v, want not supported", resp)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeReloadConfig(t, path, "")
+	cfg, err := config.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetConfig(cfg, path)
+
+	writeReloadConfig(t, path, "[collectors.docker]\nenabled = true\nhost = \"unix:///nonexistent/docker.sock\"\n")
+	resp, err := client.Control(ControlRequest{Command: ControlReload})
+	if err != nil || !resp.OK || strings.Join(resp.Changes, ",") != "docker: added" {
+		t.Fatalf("reload = %+v, %v, want docker added", resp, err)
+	}
+	st, _ := client.Control(ControlRequest{Command: ControlStatus})
+	if _, ok := st.Status.Collectors["docker"]; !ok || st.Status.LastReload == nil || !st.Status.LastReload.OK {
+		t.Errorf("status = %+v, want docker listed and a successful reload", st.Status)
+	}
+}
+
+func TestReload_AppliesChanges(t *testing.T) {
+	d, _ := controlTestDaemon(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeReloadConfig(t, path, "")
+	cfg, err := config.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetConfig(cfg, path)
+	if d.configChanged() {
+		t.Error("config should be unchanged right after SetConfig")
+	}
+
+	steps := []struct {
+		name    string
+		extra   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "collector enabled",
+			extra: "[collectors.docker]\nenabled = true\n",
+			want:  []string{"docker: added"},
+		},
+		{
+			name:  "interval changed",
+			extra: "[collectors.docker]\nenabled = true\ninterval = \"1m\"\n",
+			want:  []string{"docker: interval 30s -> 1m0s"},
+		},
+		{
+			name:    "invalid config is rejected",
+			extra:   "[collectors.docker]\nenabled = true\n[[collectors.claude.account]]\nname = \"a\"\n[[collectors.claude.account]]\nname = \"a\"\n",
+			wantErr: true,
+		},
+		{
+			name:  "collector disabled and display settings swapped",
+			extra: "[theme]\nname = \"nord\"\n",
+			want:  []string{"docker: removed", "theme: settings updated"},
+		},
+	}
+	for i, step := range steps {
+		writeReloadConfig(t, path, step.extra)
+		// Make the rewrite visible to the mtime check even on coarse clocks.
+		later := time.Now().Add(time.Duration(i+1) * time.Second)
+		os.Chtimes(path, later, later)
+		if !d.configChanged() {
+			t.Errorf("%s: config change not detected", step.name)
+		}
+
+		err := d.Reload()
+		rs := d.status().LastReload
+		if step.wantErr {
+			if err == nil || rs.OK || rs.Error == "" {
+				t.Errorf("%s: Reload() = %v, last reload %+v, want rejected", step.name, err, rs)
+			}
+			if c, ok := d.registry.Get("docker"); !ok || c.Interval() != time.Minute {
+				t.Errorf("%s: running collectors should be kept", step.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: Reload() error: %v", step.name, err)
+		}
+		if strings.Join(rs.Changes, ",") != strings.Join(step.want, ",") {
+			t.Errorf("%s: changes = %q, want %q", step.name, rs.Changes, step.want)
+		}
+		if d.configChanged() {
+			t.Errorf("%s: config should be unchanged after Reload", step.name)
+		}
+	}
+	if got := d.registry.List(); len(got) != 0 {
+		t.Errorf("registry = %v, want empty", got)
+	}
+}
+
+func TestDaemon_RunsAndReschedulesCollectors(t *testing.T) {
+	dir := shortSockDir(t)
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	mock := func(settings string, interval time.Duration, data int) collectorSpec {
+		return collectorSpec{name: "mock", settings: settings, build: func() collectors.Collector {
+			return collectors.NewMockCollector("mock", interval, collectors.WithData(data))
+		}}
+	}
+	dataFile := filepath.Join(d.cfg.DataDir, "mock.json")
+	waitFor := func(want string) {
+		t.Helper()
+		for i := 0; i < 200; i++ {
+			if b, _ := os.ReadFile(dataFile); string(b) == want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		b, _ := os.ReadFile(dataFile)
+		t.Fatalf("mock.json = %q, want %q", b, want)
+	}
+
+	d.applySpecs([]collectorSpec{mock("a", time.Hour, 1)})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Collected immediately on start.
+	waitFor("1")
+
+	// A rebuilt collector takes over at its new interval.
+	if changes := d.applySpecs([]collectorSpec{mock("b", 5*time.Millisecond, 2)}); len(changes) != 1 {
+		t.Errorf("changes = %q", changes)
+	}
+	waitFor("2")
+
+	if changes := d.applySpecs(nil); strings.Join(changes, ",") != "mock: removed" {
+		t.Errorf("changes = %q, want mock removed", changes)
+	}
+	if got := d.registry.List(); len(got) != 0 {
+		t.Errorf("registry = %v, want the removed collector gone", got)
+	}
+	if _, err := os.Stat(dataFile); err != nil {
+		t.Errorf("cached data should be kept after removal: %v", err)
+	}
+}
+
+func TestGapDetector(t *testing.T) {
+	var g gapDetector
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if _, ok := g.observe(start); ok {
+		t.Error("the first check should not be a gap")
+	}
+	if gap, ok := g.observe(start.Add(backfillCheckInterval + time.Second)); ok {
+		t.Errorf("a check %s late should not be a gap", gap)
+	}
+	if gap, ok := g.observe(start.Add(2 * time.Hour)); !ok || gap < time.Hour {
+		t.Errorf("observe after 2h = %s, %v; want a gap", gap, ok)
+	}
+	if _, ok := g.observe(start.Add(2*time.Hour + backfillCheckInterval)); ok {
+		t.Error("the check after a gap should count from the gap")
+	}
+}
+
+func TestParsePrepareForSleep(t *testing.T) {
+	tests := []struct {
+		line         string
+		sleeping, ok bool
+	}{
+		{"/org/freedesktop/login1: org.freedesktop.login1.Manager.PrepareForSleep (true,)", true, true},
+		{"/org/freedesktop/login1: org.freedesktop.login1.Manager.PrepareForSleep (false,)", false, true},
+		{"/org/freedesktop/login1: org.freedesktop.login1.Manager.SessionNew ('3', objectpath '/org/freedesktop/login1/session/_33')", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		if sleeping, ok := parsePrepareForSleep(tt.line); sleeping != tt.sleeping || ok != tt.ok {
+			t.Errorf("parsePrepareForSleep(%q) = %v, %v; want %v, %v", tt.line, sleeping, ok, tt.sleeping, tt.ok)
+		}
+	}
+}
+
+func TestDaemon_BackfillsAfterGap(t *testing.T) {
+	dir := shortSockDir(t)
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	var clock atomic.Int64
+	clock.Store(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC).UnixNano())
+	d.clock = func() time.Time { return time.Unix(0, clock.Load()) }
+	advance := func(by time.Duration) { clock.Add(int64(by)) }
+
+	mock := collectors.NewMockCollector("mock", time.Hour, collectors.WithData(1))
+	d.applySpecs([]collectorSpec{{name: "mock", settings: "a", build: func() collectors.Collector { return mock }}})
+	waitCalls := func(want int64) {
+		t.Helper()
+		for i := 0; i < 200 && mock.CallCount() < want; i++ {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := mock.CallCount(); got != want {
+			t.Fatalf("CallCount = %d, want %d", got, want)
+		}
+	}
+
+	// A marker left by an earlier daemon is cleared on start.
+	if err := os.MkdirAll(d.cfg.DataDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.WriteBackfill(d.cfg.DataDir, &cache.Backfill{Pending: []string{"mock"}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	waitCalls(1)
+
+	// Checks on schedule collect nothing.
+	advance(backfillCheckInterval)
+	d.checkGap()
+	if d.status().LastBackfill != nil {
+		t.Fatal("a check on schedule should not backfill")
+	}
+
+	// Two hours pass between checks, as in a suspend: the hourly
+	// collector runs at once instead of when its interval is up.
+	advance(2 * time.Hour)
+	d.checkGap()
+	waitCalls(2)
+	var b *cache.Backfill
+	for i := 0; i < 200; i++ {
+		if b = d.status().LastBackfill; b != nil && len(b.Pending) == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if b == nil || b.Reason != cache.BackfillClock || b.Gap != 2*time.Hour || len(b.Pending) != 0 {
+		t.Fatalf("LastBackfill = %+v, want a finished clock backfill after 2h", b)
+	}
+	if _, err := os.Stat(filepath.Join(d.cfg.DataDir, cache.BackfillFileName)); !os.IsNotExist(err) {
+		t.Errorf("the backfill marker should be removed once the data lands: %v", err)
+	}
+
+	// A resume reported right after is the same gap.
+	if d.backfill(cache.BackfillResume, 2*time.Hour) {
+		t.Error("a second backfill within a minute should be skipped")
+	}
+	advance(backfillMinSpacing)
+	if !d.backfill(cache.BackfillResume, time.Hour) {
+		t.Error("a backfill a minute later should run")
+	}
+	waitCalls(3)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{time.Minute, 0, time.Minute},
+		{time.Minute, 1, 2 * time.Minute},
+		{time.Minute, 3, 8 * time.Minute},
+		{time.Minute, 10, maxBackoff},
+		{time.Minute, 100, maxBackoff},
+		{2 * time.Hour, 3, 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.interval, tt.failures); got != tt.want {
+			t.Errorf("backoff(%s, %d) = %s, want %s", tt.interval, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestJitterStaysWithinBounds(t *testing.T) {
+	d := 10 * time.Second
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 200; i++ {
+		got := jitter(d)
+		if got < 9*time.Second || got > 11*time.Second {
+			t.Fatalf("jitter(%s) = %s, want within 10%%", d, got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jitter never varied the delay")
+	}
+}
+
+func TestDaemon_FailingCollectorBacksOff(t *testing.T) {
+	dir := shortSockDir(t)
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	mock := collectors.NewMockCollector("flaky", 20*time.Millisecond, collectors.WithError(errors.New("provider down")))
+	d.applySpecs([]collectorSpec{{name: "flaky", settings: "a", build: func() collectors.Collector { return mock }}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Runs at about 0, 40, 120, and 280ms while failing; a fixed interval
+	// would have run it about 20 times.
+	time.Sleep(400 * time.Millisecond)
+	if n := mock.CallCount(); n < 2 || n > 6 {
+		t.Errorf("failing collector ran %d times in 400ms, want it backed off", n)
+	}
+	ch := d.status().Collectors["flaky"]
+	if ch.ConsecutiveFailures < 2 || ch.NextRun.Before(time.Now()) {
+		t.Errorf("health = %+v, want consecutive failures and a future next run", ch)
+	}
+
+	// A success resets the schedule to the plain interval.
+	mock.SetError(nil)
+	mock.SetData(1)
+	for i := 0; i < 200; i++ {
+		if ch = d.status().Collectors["flaky"]; ch.ConsecutiveFailures == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ch.ConsecutiveFailures != 0 || !ch.Healthy {
+		t.Errorf("health after recovery = %+v, want failures reset", ch)
+	}
+	if until := time.Until(ch.NextRun); until > 30*time.Millisecond {
+		t.Errorf("next run in %s after recovery, want about the 20ms interval", until)
+	}
+}
+
+func TestDaemon_UnhealthyCollectorBacksOff(t *testing.T) {
+	j := &collectorJob{}
+	c := collectors.NewMockCollector("k8s", time.Minute, collectors.WithHealthy(false))
+	d := &Daemon{cfg: Config{DataDir: t.TempDir(), HealthFile: filepath.Join(t.TempDir(), "h.json")}, collectors: make(map[string]*CollectorHealth)}
+	if delay := d.runScheduled(context.Background(), j, c); delay < 108*time.Second {
+		t.Errorf("delay = %s, want the interval doubled for an unhealthy collector", delay)
+	}
+	if _, failures := j.schedule(); failures != 1 {
+		t.Errorf("failures = %d, want 1", failures)
+	}
+}
+
+func TestDaemon_LogsCollectorRuns(t *testing.T) {
+	var buf bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	d := &Daemon{cfg: Config{DataDir: t.TempDir()}, collectors: make(map[string]*CollectorHealth)}
+	d.collectOne(context.Background(), collectors.NewMockCollector("sysmetrics", time.Second))
+	d.collectOne(context.Background(), collectors.NewMockCollector("billing", time.Minute, collectors.WithError(errors.New("401 Unauthorized"))))
+
+	type line struct {
+		Level     string `json:"level"`
+		Collector string `json:"collector"`
+		CacheKey  string `json:"cache_key"`
+		Duration  *int64 `json:"duration_ms"`
+		Error     string `json:"error"`
+	}
+	var got []line
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var l line
+		if err := dec.Decode(&l); err != nil {
+			t.Fatalf("decode log line: %v", err)
+		}
+		got = append(got, l)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d log lines, want 2: %+v", len(got), got)
+	}
+	if ok := got[0]; ok.Level != "DEBUG" || ok.Collector != "sysmetrics" || ok.CacheKey != "sysmetrics" || ok.Duration == nil || ok.Error != "" {
+		t.Errorf("success line = %+v", ok)
+	}
+	if bad := got[1]; bad.Level != "WARN" || bad.Collector != "billing" || bad.Duration == nil || bad.Error != "401 Unauthorized" {
+		t.Errorf("failure line = %+v", bad)
+	}
+}
+
+func TestDaemon_NotifiesOnTransition(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events")
+	d := &Daemon{cfg: Config{DataDir: t.TempDir()}, collectors: make(map[string]*CollectorHealth)}
+	if err := d.applyNotifications(config.NotificationsConfig{}); err != nil || d.notifier != nil {
+		t.Fatalf("no rules: notifier = %v, err = %v; want disabled", d.notifier, err)
+	}
+	err := d.applyNotifications(config.NotificationsConfig{
+		Sinks: []config.NotificationSinkConfig{{Name: "log", Type: "command", Command: "echo \"$PROMPT_PULSE_SUBJECT\" >> " + out}},
+		Rules: []config.NotificationRuleConfig{{Name: "down", Event: "check_down"}},
+	})
+	if err != nil {
+		t.Fatalf("applyNotifications() error: %v", err)
+	}
+
+	down := &checks.Status{Checks: []checks.Result{{Name: "router", State: checks.StateDown}}}
+	c := collectors.NewMockCollector("checks", time.Minute, collectors.WithData(down))
+	for i := 0; i < 3; i++ {
+		if err := d.collectOne(context.Background(), c); err != nil {
+			t.Fatalf("collectOne() error: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(out)
+		if string(data) == "router\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sink output = %q, want one event for router", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemon_LogsChanges(t *testing.T) {
+	dir := t.TempDir()
+	d := &Daemon{cfg: Config{DataDir: t.TempDir(), StateDir: dir}, collectors: make(map[string]*CollectorHealth)}
+	d.applyEvents(config.EventsConfig{Enabled: true, MaxEntries: 10})
+
+	run := func(state string) {
+		t.Helper()
+		st := &checks.Status{Checks: []checks.Result{{Name: "router", State: state}}}
+		if err := d.collectOne(context.Background(), collectors.NewMockCollector("checks", time.Minute, collectors.WithData(st))); err != nil {
+			t.Fatalf("collectOne() error: %v", err)
+		}
+	}
+	run(checks.StateUp)
+	run(checks.StateUp)
+	run(checks.StateDown)
+
+	events, err := changelog.Read(filepath.Join(dir, changelog.FileName))
+	if err != nil || len(events) != 1 || events[0].Source != "checks" || events[0].Text != "checks router up→down" {
+		t.Fatalf("events = %+v, %v; want router going down", events, err)
+	}
+
+	d.applyEvents(config.EventsConfig{})
+	run(checks.StateUp)
+	if events, _ := changelog.Read(filepath.Join(dir, changelog.FileName)); len(events) != 1 {
+		t.Errorf("disabled: %d events, want no more logged", len(events))
+	}
+}
+
+func TestDaemon_RejectsImplausibleResult(t *testing.T) {
+	d, client := controlTestDaemon(t)
+	d.cfg.StateDir = t.TempDir()
+	d.applyValidation(config.DefaultConfig().Validation)
+
+	mock := collectors.NewMockCollector("k8s", time.Minute)
+	reg := collectors.NewRegistry()
+	reg.Register(mock)
+	d.SetRegistry(reg)
+	cached := filepath.Join(d.cfg.DataDir, "k8s.json")
+	nodes := func(n int) *k8s.ClusterStatus {
+		c := k8s.ClusterInfo{Context: "prod", Connected: true}
+		for i := 0; i < n; i++ {
+			c.Nodes = append(c.Nodes, k8s.NodeInfo{Name: fmt.Sprintf("n%d", i), Ready: true})
+		}
+		return &k8s.ClusterStatus{Clusters: []k8s.ClusterInfo{c}}
+	}
+
+	mock.SetData(nodes(3))
+	if err := d.collectOne(context.Background(), mock); err != nil {
+		t.Fatalf("collectOne() error: %v", err)
+	}
+	good, _ := os.ReadFile(cached)
+
+	mock.SetData(nodes(0))
+	err := d.collectOne(context.Background(), mock)
+	var rej *validate.Rejection
+	if !errors.As(err, &rej) || rej.Source != "k8s" {
+		t.Fatalf("collectOne(no nodes) = %v, want a rejection", err)
+	}
+	if data, _ := os.ReadFile(cached); !bytes.Equal(data, good) {
+		t.Errorf("k8s.json = %s, want the last accepted result kept", data)
+	}
+	if ch := d.status().Collectors["k8s"]; ch.Healthy || !ch.Rejected {
+		t.Errorf("k8s health = %+v, want unhealthy and rejected", ch)
+	}
+	entries, err := validate.Read(filepath.Join(d.cfg.StateDir, validate.RejectedFileName))
+	if err != nil || len(entries) != 1 || !strings.Contains(entries[0].Reason, "prod") || !strings.Contains(string(entries[0].Payload), `"prod"`) {
+		t.Fatalf("rejected log = %+v, %v; want the no-node payload", entries, err)
+	}
+
+	if resp, _ := client.Control(ControlRequest{Command: ControlAccept}); resp.OK {
+		t.Errorf("accept without a collector = %+v, want an error", resp)
+	}
+	if resp, _ := client.Control(ControlRequest{Command: ControlAccept, Collector: "k8s"}); !resp.OK {
+		t.Fatalf("accept k8s = %+v", resp)
+	}
+	if err := d.collectOne(context.Background(), mock); err != nil {
+		t.Fatalf("collectOne(accepted) error: %v", err)
+	}
+	if ch := d.status().Collectors["k8s"]; !ch.Healthy || ch.Rejected {
+		t.Errorf("k8s health = %+v, want healthy after accept", ch)
+	}
+
+	d.applyValidation(config.ValidationConfig{})
+	if resp, _ := client.Control(ControlRequest{Command: ControlAccept, Collector: "k8s"}); resp.OK || !strings.Contains(resp.Error, "disabled") {
+		t.Errorf("accept with validation disabled = %+v, want an error", resp)
+	}
+}
+
+func TestDaemon_RecordsSysmetricsHistory(t *testing.T) {
+	dir := t.TempDir()
+	d := &Daemon{cfg: Config{DataDir: t.TempDir(), StateDir: dir}, collectors: make(map[string]*CollectorHealth)}
+	sc := config.SysMetricsCollectorConfig{Enabled: true, HistoryRetention: config.Duration{Duration: time.Hour}, HistoryInterval: config.Duration{Duration: time.Minute}}
+	d.applySysHistory(sc)
+
+	t0 := time.Now().Add(-10 * time.Minute)
+	for i, cpu := range []float64{10, 20, 30} {
+		m := sysmetrics.Metrics{Timestamp: t0.Add(time.Duration(i) * time.Minute), CPU: sysmetrics.CPUMetrics{Total: cpu}}
+		if err := d.collectOne(context.Background(), collectors.NewMockCollector("sysmetrics", time.Second, collectors.WithData(m))); err != nil {
+			t.Fatalf("collectOne() error: %v", err)
+		}
+	}
+	d.saveSysHistory()
+
+	path := filepath.Join(dir, sysmetrics.HistoryFileName)
+	h := sysmetrics.LoadHistory(path, time.Hour, time.Minute)
+	if snap, _ := h.Ring().Since(sysmetrics.HistoryCPU, time.Time{}); fmt.Sprint(snap.Values) != "[10 20 30]" {
+		t.Fatalf("saved cpu history = %v, want [10 20 30]", snap.Values)
+	}
+
+	// A shorter retention keeps the newest samples from the saved file.
+	sc.HistoryRetention.Duration = 2 * time.Minute
+	d.applySysHistory(sc)
+	if r := d.sysHistory.Ring(); r.Cap() != 2 || r.Len() != 2 {
+		t.Errorf("history after shrinking = %d of %d samples, want 2 of 2", r.Len(), r.Cap())
+	}
+
+	sc.HistoryRetention.Duration = 0
+	d.applySysHistory(sc)
+	if d.sysHistory != nil {
+		t.Error("zero retention should stop recording")
+	}
+}
+
+func TestControl_UnknownAndMalformed(t *testing.T) {
+	_, client := controlTestDaemon(t)
+	if resp, _ := client.Control(ControlRequest{Command: "explode"}); resp.OK || !strings.Contains(resp.Error, "unknown command") {
+		t.Errorf("unknown command = %+v", resp)
+	}
+
+	resp, err := client.SendCommand(`{"command":`)
+	if err != nil || !strings.Contains(resp, "invalid request") {
+		t.Errorf("malformed request = %q, %v", resp, err)
+	}
+}
+
+func TestControl_ShutdownStopsDaemon(t *testing.T) {
+	dir := shortSockDir(t)
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- d.Start(context.Background()) }()
+
+	client := NewIPCClient(d.cfg.SocketPath)
+	var resp *ControlResponse
+	for i := 0; i < 100; i++ {
+		if resp, err = client.Control(ControlRequest{Command: ControlShutdown}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || !resp.OK {
+		t.Fatalf("shutdown = %+v, %v", resp, err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon did not stop after shutdown")
+	}
+	if _, err := os.Stat(d.cfg.SocketPath); !os.IsNotExist(err) {
+		t.Error("socket should be removed on shutdown")
+	}
+	if _, err := os.Stat(d.cfg.PIDFile); !os.IsNotExist(err) {
+		t.Error("PID file should be removed on shutdown")
+	}
+}
+
+// startSlowDaemon starts a daemon running one collector, slow, whose runs
+// call collect, with the given shutdown grace period. It returns once the
+// first run has begun, with the context that stops the daemon and a
+// channel receiving Start's result.
+func startSlowDaemon(t *testing.T, grace time.Duration, collect func(ctx context.Context) (interface{}, error)) (*Daemon, context.CancelFunc, <-chan error) {
+	t.Helper()
+	dir := shortSockDir(t)
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         filepath.Join(dir, "data"),
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.General.ShutdownGrace = config.Duration{Duration: grace}
+	d.appCfg = cfg
+
+	started := make(chan struct{}, 1)
+	mock := collectors.NewMockCollector("slow", 5*time.Millisecond, collectors.WithCollectFunc(func(ctx context.Context) (interface{}, error) {
+		started <- struct{}{}
+		return collect(ctx)
+	}))
+	d.applySpecs([]collectorSpec{{name: "slow", settings: "a", build: func() collectors.Collector { return mock }}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	stopped := make(chan struct{})
+	go func() {
+		done <- d.Start(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		d.ForceShutdown()
+		<-stopped
+	})
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("collector did not run")
+	}
+	return d, cancel, done
+}
+
+func TestDaemon_ShutdownDrainsCollectorInFlight(t *testing.T) {
+	var runs atomic.Int32
+	d, cancel, done := startSlowDaemon(t, 5*time.Second, func(ctx context.Context) (interface{}, error) {
+		runs.Add(1)
+		select {
+		case <-time.After(200 * time.Millisecond):
+			return 42, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() = %v, want a clean shutdown", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("daemon did not stop after the collector finished")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Start() returned after %s, before the collector in flight finished", elapsed)
+	}
+	if b, _ := os.ReadFile(filepath.Join(d.cfg.DataDir, "slow.json")); string(b) != "42" {
+		t.Errorf("slow.json = %q, want the drained run's data written", b)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("collector ran %d times, want no run started after the shutdown began", n)
+	}
+	if _, err := os.Stat(d.cfg.PIDFile); !os.IsNotExist(err) {
+		t.Error("PID file should be removed on shutdown")
+	}
+	if _, err := os.Stat(d.cfg.SocketPath); !os.IsNotExist(err) {
+		t.Error("socket should be removed on shutdown")
+	}
+}
+
+func TestDaemon_ShutdownCancelsAfterGrace(t *testing.T) {
+	cancelled := make(chan struct{})
+	d, cancel, done := startSlowDaemon(t, 50*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() = %v, want a clean shutdown after the grace period", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("daemon did not stop after the grace period")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("collector in flight was not cancelled after the grace period")
+	}
+	if _, err := os.Stat(filepath.Join(d.cfg.DataDir, "slow.json")); !os.IsNotExist(err) {
+		t.Errorf("cancelled run wrote data: %v", err)
+	}
+}
+
+func TestDaemon_ForceShutdown(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	// The collector ignores its context, so only forcing ends the wait.
+	d, cancel, done := startSlowDaemon(t, time.Minute, func(ctx context.Context) (interface{}, error) {
+		<-release
+		return 1, nil
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("Start() = %v while a collector was in flight, want it to wait", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	d.ForceShutdown()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrForcedShutdown) {
+			t.Errorf("Start() = %v, want ErrForcedShutdown", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon did not stop when forced")
+	}
+	if _, err := os.Stat(d.cfg.PIDFile); !os.IsNotExist(err) {
+		t.Error("PID file should be removed on a forced shutdown")
+	}
+}
+
+func TestIPCServer_ReplacesStaleSocket(t *testing.T) {
+	sockPath := filepath.Join(shortSockDir(t), "test.sock")
+
+	// A listener closed without unlinking leaves the file behind, as a
+	// crashed daemon would.
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	if _, err := os.Stat(sockPath); err != nil {
+		t.Fatalf("stale socket not left behind: %v", err)
+	}
+
+	srv := NewIPCServer(sockPath, &testHandler{})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() over stale socket: %v", err)
+	}
+	defer srv.Stop()
+
+	if resp, err := NewIPCClient(sockPath).SendCommand("PING"); err != nil || !strings.Contains(resp, "pong") {
+		t.Errorf("PING = %q, %v", resp, err)
+	}
+}
+
+func TestIPCServer_RefusesLiveSocket(t *testing.T) {
+	sockPath := filepath.Join(shortSockDir(t), "test.sock")
+	first := NewIPCServer(sockPath, &testHandler{})
+	if err := first.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer first.Stop()
+
+	if err := NewIPCServer(sockPath, &testHandler{}).Start(); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("second Start() = %v, want socket in use", err)
+	}
+	if _, err := NewIPCClient(sockPath).SendCommand("PING"); err != nil {
+		t.Errorf("first server should still answer: %v", err)
+	}
+
+	notSock := filepath.Join(filepath.Dir(sockPath), "file")
+	os.WriteFile(notSock, nil, 0o600)
+	if err := NewIPCServer(notSock, &testHandler{}).Start(); err == nil {
+		t.Error("Start() over a regular file should fail")
+	}
+}
+
 func TestComputeHash(t *testing.T) {
 	h1 := computeHash("hello")
 	h2 := computeHash("hello")
@@ -1090,3 +2294,167 @@ func TestComputeHash(t *testing.T) {
 		t.Errorf("SHA-256 hex hash should be 64 chars, got %d", len(h1))
 	}
 }
+
+func TestCompactCache(t *testing.T) {
+	dir := t.TempDir()
+	d, err := New(Config{
+		PIDFile:         filepath.Join(dir, "test.pid"),
+		HealthFile:      filepath.Join(dir, "health.json"),
+		SocketPath:      filepath.Join(dir, ControlSocketName),
+		DataDir:         dir,
+		BannerCacheFile: filepath.Join(dir, "banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	store, err := cache.NewStore(cache.StoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutWithTTL("expired", []byte("x"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutString("kept", "y"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	collectorFile := filepath.Join(dir, "docker.json")
+	if err := WriteCollectorData(dir, "docker", map[string]int{"containers": 3}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := d.compactCache(); err != nil {
+		t.Fatalf("compactCache() error: %v", err)
+	}
+	if _, err := os.Stat(collectorFile); err != nil {
+		t.Errorf("collector data removed: %v", err)
+	}
+
+	store, err = cache.NewStore(cache.StoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if got := store.Stats().Entries; got != 1 {
+		t.Errorf("entries after compaction = %d, want 1", got)
+	}
+}
+
+func TestWriteCollectorData_Encrypted(t *testing.T) {
+	enc, err := cache.NewEncryption([]byte("correct horse battery staple"), []string{"billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.SetDefaultEncryption(enc)
+	t.Cleanup(func() { cache.SetDefaultEncryption(nil) })
+
+	dir := t.TempDir()
+	for _, name := range []string{"billing", "docker"} {
+		if err := WriteCollectorData(dir, name, map[string]string{"account": "acme"}); err != nil {
+			t.Fatalf("WriteCollectorData(%s) error: %v", name, err)
+		}
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "billing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cache.IsEncrypted(raw) || strings.Contains(string(raw), "acme") {
+		t.Errorf("billing.json stored in plaintext: %q", raw)
+	}
+	if data, err := cache.ReadFile(filepath.Join(dir, "billing.json")); err != nil || string(data) != `{"account":"acme"}` {
+		t.Errorf("ReadFile(billing.json) = %q, %v", data, err)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dir, "docker.json")); cache.IsEncrypted(raw) {
+		t.Error("docker.json encrypted, want plaintext")
+	}
+}
+
+// summarized is a collector result with a summary.
+type summarized struct{ Total int }
+
+func (s summarized) Summarize() any { return map[string]int{"v": 1, "total": s.Total} }
+
+func TestWriteCollectorData_Summary(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteCollectorData(dir, "billing", summarized{Total: 7}); err != nil {
+		t.Fatalf("WriteCollectorData() error: %v", err)
+	}
+	summaryPath := filepath.Join(dir, "billing.summary.json")
+	data, err := os.ReadFile(summaryPath)
+	if err != nil || string(data) != `{"total":7,"v":1}` {
+		t.Errorf("billing.summary.json = %q, %v", data, err)
+	}
+	main, err := os.Stat(filepath.Join(dir, "billing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(summaryPath); err != nil || info.ModTime().Before(main.ModTime()) {
+		t.Errorf("summary older than the result it summarizes")
+	}
+
+	// A result without a summary removes the old one, which no longer
+	// matches it.
+	if err := WriteCollectorData(dir, "billing", map[string]int{"total": 8}); err != nil {
+		t.Fatalf("WriteCollectorData() error: %v", err)
+	}
+	if _, err := os.Stat(summaryPath); !os.IsNotExist(err) {
+		t.Errorf("stale summary kept: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(matches) > 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
+
+func TestListCollectors(t *testing.T) {
+	t.Setenv("UPTIME_KUMA_API_KEY", "")
+	t.Setenv("UPTIME_KUMA_API_KEY_FILE", "")
+	cfg, err := config.LoadFromReader(strings.NewReader("[collectors.kubernetes]\nenabled = true\ninterval = \"0s\"\n[collectors.uptimekuma]\nurl = \"http://kuma:3001\"\ninterval = \"2m\"\n"))
+	if err != nil {
+		t.Fatalf("LoadFromReader() error: %v", err)
+	}
+	infos := make(map[string]CollectorInfo)
+	for _, c := range ListCollectors(cfg) {
+		infos[c.Name] = c
+	}
+	if len(infos) != 14 {
+		t.Errorf("listed %d collectors, want all 14", len(infos))
+	}
+	want := map[string]CollectorInfo{
+		"k8s":        {Name: "k8s", Enabled: true, Reason: config.ReasonExplicit, Interval: config.Duration{Duration: 15 * time.Second}},
+		"uptimekuma": {Name: "uptimekuma", Enabled: true, Reason: config.ReasonCredentials, Interval: config.Duration{Duration: 2 * time.Minute}},
+		"docker":     {Name: "docker", Enabled: false, Reason: config.ReasonDefault, Interval: config.Duration{Duration: 30 * time.Second}},
+	}
+	for name, w := range want {
+		if got := infos[name]; got != w {
+			t.Errorf("%s = %+v, want %+v", name, got, w)
+		}
+	}
+}
+
+func TestDaemon_WriteStatusPage(t *testing.T) {
+	d, _ := controlTestDaemon(t)
+	cfg := config.DefaultConfig()
+	cfg.General.CacheDir = d.cfg.DataDir
+	cfg.StatusPage.Path = filepath.Join(t.TempDir(), "status.html")
+	cfg.StatusPage.Interval = config.Duration{Duration: 5 * time.Minute}
+	d.appCfg = cfg
+
+	now := time.Now()
+	d.writeStatusPage(now)
+	if data, err := os.ReadFile(cfg.StatusPage.Path); err != nil || !strings.Contains(string(data), "<h1>prompt-pulse status</h1>") {
+		t.Fatalf("status page = %q, %v; want it written", data, err)
+	}
+
+	os.Remove(cfg.StatusPage.Path)
+	d.writeStatusPage(now.Add(time.Minute))
+	if _, err := os.Stat(cfg.StatusPage.Path); !os.IsNotExist(err) {
+		t.Errorf("page rewritten before the interval; stat err = %v", err)
+	}
+	d.writeStatusPage(now.Add(5 * time.Minute))
+	if _, err := os.Stat(cfg.StatusPage.Path); err != nil {
+		t.Errorf("page not rewritten after the interval: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"log"
+	"path/filepath"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// applyEvents makes the daemon's change log match ec. Disabling it drops
+// what was last reported, so enabling it again starts from a new
+// baseline.
+func (d *Daemon) applyEvents(ec config.EventsConfig) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !ec.Enabled {
+		d.differ = nil
+		return
+	}
+	th := changelog.Thresholds{
+		BillingMinDelta: ec.BillingMinDelta,
+		ClaudeMinDelta:  ec.ClaudeMinDelta,
+		PodJitter:       ec.PodJitter,
+	}
+	if d.differ == nil {
+		d.differ = changelog.New(th)
+	} else {
+		d.differ.SetThresholds(th)
+	}
+	d.eventsMax = ec.MaxEntries
+}
+
+// recordChanges diffs a collector's new data against what the change log
+// last reported and appends the changes to the event log in StateDir.
+// Failures to write the log are logged.
+func (d *Daemon) recordChanges(name string, data interface{}) {
+	d.mu.Lock()
+	differ, max := d.differ, d.eventsMax
+	d.mu.Unlock()
+	if differ == nil {
+		return
+	}
+	events := differ.Observe(name, data)
+	if len(events) == 0 {
+		return
+	}
+	d.eventsMu.Lock()
+	defer d.eventsMu.Unlock()
+	if err := changelog.Append(filepath.Join(d.cfg.StateDir, changelog.FileName), events, max); err != nil {
+		log.Printf("daemon: %v", err)
+	}
+}
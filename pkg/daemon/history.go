@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// sysHistorySaveInterval is how often the sysmetrics history is saved to
+// the data directory, which bounds what a crash loses and how far the
+// TUI's graphs lag.
+const sysHistorySaveInterval = time.Minute
+
+// applySysHistory makes the daemon's sysmetrics history match sc. The
+// first call, and one that changes the retention or interval, continues
+// from the saved history; a zero retention stops recording.
+func (d *Daemon) applySysHistory(sc config.SysMetricsCollectorConfig) {
+	retention, interval := sc.HistoryRetention.Duration, sc.HistoryInterval.Duration
+	if !sc.Enabled {
+		retention = 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if retention <= 0 {
+		d.sysHistory = nil
+		return
+	}
+	if d.sysHistory != nil && d.sysHistoryRetention == retention && d.sysHistoryInterval == interval {
+		return
+	}
+	path := filepath.Join(d.cfg.StateDir, sysmetrics.HistoryFileName)
+	if d.sysHistory != nil {
+		if err := d.sysHistory.Save(path); err != nil {
+			log.Printf("daemon: save sysmetrics history: %v", err)
+		}
+	}
+	d.sysHistory = sysmetrics.LoadHistory(path, retention, interval)
+	d.sysHistoryRetention, d.sysHistoryInterval = retention, interval
+}
+
+// recordHistory adds a sysmetrics collection to the history.
+func (d *Daemon) recordHistory(name string, data interface{}) {
+	m, ok := data.(sysmetrics.Metrics)
+	if !ok || name != "sysmetrics" {
+		return
+	}
+	d.mu.Lock()
+	h := d.sysHistory
+	d.mu.Unlock()
+	if h != nil {
+		h.Record(m)
+	}
+}
+
+// saveSysHistory writes the sysmetrics history to the state directory, if
+// one is kept. Failures are logged; the next save tries again.
+func (d *Daemon) saveSysHistory() {
+	d.mu.Lock()
+	h := d.sysHistory
+	d.mu.Unlock()
+	if h == nil {
+		return
+	}
+	if err := h.Save(filepath.Join(d.cfg.StateDir, sysmetrics.HistoryFileName)); err != nil {
+		log.Printf("daemon: save sysmetrics history: %v", err)
+	}
+}
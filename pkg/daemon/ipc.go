@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 // IPCHandler processes incoming IPC commands. Implementations dispatch
@@ -24,6 +25,8 @@ type IPCHandler interface {
 //   - Client sends a single line: COMMAND [arg1] [arg2] ...
 //   - Server responds with a JSON line followed by a newline.
 //   - Supported commands: HEALTH, BANNER {width} {height} {protocol}, REFRESH, QUIT
+//   - A line starting with "{" is a JSON ControlRequest, answered with a
+//     JSON ControlResponse, when the handler implements ControlHandler.
 type IPCServer struct {
 	socketPath string
 	handler    IPCHandler
@@ -43,11 +46,12 @@ func NewIPCServer(socketPath string, handler IPCHandler) *IPCServer {
 }
 
 // Start begins listening for connections on the Unix socket. The socket file
-// is created with mode 0600 for security. Any existing socket file at the
-// path is removed first.
+// is created with mode 0600 for security. A socket file left behind by a
+// crashed daemon is replaced; one that still accepts connections is not.
 func (s *IPCServer) Start() error {
-	// Remove stale socket file.
-	os.Remove(s.socketPath)
+	if err := removeStaleSocket(s.socketPath); err != nil {
+		return err
+	}
 
 	ln, err := net.Listen("unix", s.socketPath)
 	if err != nil {
@@ -127,6 +131,17 @@ func (s *IPCServer) handleConn(conn net.Conn) {
 		return
 	}
 
+	if ch, ok := s.handler.(ControlHandler); ok && strings.HasPrefix(line, "{") {
+		var req ControlRequest
+		resp := ControlResponse{Error: "invalid request"}
+		if err := json.Unmarshal([]byte(line), &req); err == nil {
+			resp = ch.HandleControl(req)
+		}
+		data, _ := json.Marshal(resp)
+		fmt.Fprintf(conn, "%s\n", data)
+		return
+	}
+
 	cmd, args := parseIPCCommand(line)
 
 	response, err := s.handler.HandleCommand(cmd, args)
@@ -149,6 +164,30 @@ func (s *IPCServer) handleConn(conn net.Conn) {
 	fmt.Fprintf(conn, "%s\n", response)
 }
 
+// removeStaleSocket removes the socket file at path unless a server is
+// still listening on it. A missing path is not an error, and a path that is
+// not a socket is left alone.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat socket: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, 100*time.Millisecond); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use by a running daemon", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove stale socket: %w", err)
+	}
+	return nil
+}
+
 // parseIPCCommand parses a line-based IPC command into the command name
 // and a map of positional arguments.
 //
@@ -186,6 +225,7 @@ func parseIPCCommand(line string) (string, map[string]string) {
 // IPCClient connects to a running daemon via Unix socket to send commands.
 type IPCClient struct {
 	socketPath string
+	timeout    time.Duration
 }
 
 // NewIPCClient creates a client that will connect to the daemon at socketPath.
@@ -193,11 +233,31 @@ func NewIPCClient(socketPath string) *IPCClient {
 	return &IPCClient{socketPath: socketPath}
 }
 
+// WithTimeout bounds each request, from connecting to reading the reply,
+// to d. Zero, the default, waits as long as the daemon takes.
+func (c *IPCClient) WithTimeout(d time.Duration) *IPCClient {
+	c.timeout = d
+	return c
+}
+
+// dial connects to the daemon, setting the deadline of c's timeout.
+func (c *IPCClient) dial() (net.Conn, error) {
+	if c.timeout <= 0 {
+		return net.Dial("unix", c.socketPath)
+	}
+	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	return conn, nil
+}
+
 // SendCommand sends a text command to the daemon and returns the response.
 // Each call opens a new connection, sends the command, reads the response,
 // and closes the connection.
 func (c *IPCClient) SendCommand(cmd string) (string, error) {
-	conn, err := net.Dial("unix", c.socketPath)
+	conn, err := c.dial()
 	if err != nil {
 		return "", fmt.Errorf("connect to daemon: %w", err)
 	}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the file name of the instance lock inside the state
+// directory.
+const LockFileName = "prompt-pulse.lock"
+
+// ErrAlreadyRunning is returned by AcquireLock when another live daemon
+// holds the instance lock.
+var ErrAlreadyRunning = errors.New("another daemon is already running")
+
+// lockAttempts and lockRetryDelay bound how long AcquireLock waits for a
+// lock that is only briefly held, e.g. by LockHolder probing it.
+const (
+	lockAttempts   = 3
+	lockRetryDelay = 50 * time.Millisecond
+)
+
+// LockInfo is what the daemon holding the instance lock records in it.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// InstanceLock is a held instance lock. One daemon at a time can hold the
+// lock for a cache directory, so two daemons never race on its files or
+// poll the same APIs twice.
+type InstanceLock struct {
+	f *os.File
+}
+
+// AcquireLock takes the instance lock at path and records the current
+// process in it. The lock is an flock(2) on the file, which the kernel
+// releases when its holder exits, however it exits: a daemon that crashed
+// or was killed never leaves a held lock behind, and the next daemon takes
+// the lock over and overwrites the dead PID. When a live daemon holds the
+// lock the error wraps ErrAlreadyRunning and names that daemon.
+func AcquireLock(path string) (*InstanceLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if !errors.Is(err, syscall.EWOULDBLOCK) || attempt == lockAttempts {
+			break
+		}
+		time.Sleep(lockRetryDelay)
+	}
+	if err != nil {
+		f.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if info, ok := LockHolder(path); ok && info.PID != 0 {
+			return nil, fmt.Errorf("%w (PID %d, started %s)", ErrAlreadyRunning, info.PID, info.StartedAt.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("%w (lock %s)", ErrAlreadyRunning, path)
+	}
+
+	data, _ := json.Marshal(LockInfo{PID: os.Getpid(), StartedAt: time.Now()})
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write lock file: %w", err)
+	}
+	if _, err := f.WriteAt(append(data, '\n'), 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write lock file: %w", err)
+	}
+	return &InstanceLock{f: f}, nil
+}
+
+// Release gives up the lock. The file stays in place: removing it would
+// let a daemon that opened it just before lock the removed file while
+// another locks a new one.
+func (l *InstanceLock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	l.f.Truncate(0)
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// LockHolder reports the daemon holding the instance lock at path. ok is
+// false when no live daemon holds it, including when the file still
+// records a daemon that has since died. This, not the presence of the
+// socket or PID file, is what says whether the daemon is running.
+func LockHolder(path string) (info LockInfo, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return LockInfo{}, false
+	}
+	defer f.Close()
+
+	// A shared lock can be taken only when no daemon holds the exclusive
+	// one. Closing the file drops it again.
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB)
+	if err == nil || !errors.Is(err, syscall.EWOULDBLOCK) {
+		return LockInfo{}, false
+	}
+	// The holder may be between truncating and writing the file, in which
+	// case info is left zero.
+	_ = json.NewDecoder(f).Decode(&info)
+	return info, true
+}
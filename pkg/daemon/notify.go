@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"context"
+	"log"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/notify"
+)
+
+// applyNotifications makes the daemon's notification rules and sinks match
+// nc. With no rules, notifications are disabled. A notifier that already
+// exists is updated in place, so subjects that are still triggered do not
+// notify again after a reload.
+func (d *Daemon) applyNotifications(nc config.NotificationsConfig) error {
+	if len(nc.Rules) == 0 {
+		d.mu.Lock()
+		d.notifier = nil
+		d.mu.Unlock()
+		return nil
+	}
+	sinks, err := notify.SinksFromConfig(nc)
+	if err != nil {
+		d.mu.Lock()
+		d.notifier = nil
+		d.mu.Unlock()
+		return err
+	}
+	rules := notify.RulesFromConfig(nc)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.notifier == nil {
+		d.notifier = notify.New(rules, sinks)
+	} else {
+		d.notifier.SetRules(rules, sinks)
+	}
+	return nil
+}
+
+// notify checks a collector's new data against the notification rules and
+// delivers any events in the background, so a slow sink never delays
+// collection. A shutting-down daemon waits for deliveries like collector
+// runs, and starts none once its grace period begins. Delivery failures
+// are logged.
+func (d *Daemon) notify(name string, data interface{}) {
+	d.mu.Lock()
+	n := d.notifier
+	d.mu.Unlock()
+	if n == nil {
+		return
+	}
+	events := n.Observe(name, data)
+	if len(events) == 0 || !d.beginWork() {
+		return
+	}
+	go func() {
+		defer d.inflight.Done()
+		if err := n.Deliver(context.Background(), events); err != nil {
+			log.Printf("daemon: %v", err)
+		}
+	}()
+}
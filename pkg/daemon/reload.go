@@ -0,0 +1,363 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/httpx"
+)
+
+// configPollInterval is how often the daemon checks its config file for
+// changes.
+const configPollInterval = 2 * time.Second
+
+// errNoConfig is returned by Reload when the daemon was not given a
+// configuration with SetConfig.
+var errNoConfig = errors.New("reload not supported: daemon has no configuration")
+
+// ReloadStatus is the outcome of the most recent configuration reload.
+type ReloadStatus struct {
+	At    time.Time `json:"at"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+
+	// Changes describes what the reload applied, e.g. "docker: added" or
+	// "claude: interval 5m0s -> 1m0s".
+	Changes []string `json:"changes,omitempty"`
+}
+
+// collectorJob runs one collector on its interval until stopped.
+type collectorJob struct {
+	spec collectorSpec
+	c    collectors.Collector
+
+	// next holds a rebuilt collector for the job to switch to once its
+	// current collection, if any, finishes. wake signals that it is set.
+	mu   sync.Mutex
+	next collectors.Collector
+	wake chan struct{}
+
+	// kick asks the job to collect at once; see collectNow.
+	kick chan struct{}
+
+	// nextRun and failures describe the job's schedule; see reschedule.
+	nextRun  time.Time
+	failures int
+
+	stop chan struct{}
+}
+
+// SetConfig sets the configuration the daemon collects with and the file
+// Reload re-reads. An empty path reloads from the default search paths
+// and disables watching for changes. Called before Start, it only records
+// the collectors to run; afterwards it applies them like a reload.
+func (d *Daemon) SetConfig(cfg *config.Config, path string) {
+	d.mu.Lock()
+	d.appCfg = cfg
+	d.configPath = path
+	d.configStamp = statStamp(path)
+	d.mu.Unlock()
+	configureHTTP(cfg.HTTP)
+	d.applySpecs(collectorSpecs(cfg, d.cfg.DataDir, d.cfg.StateDir))
+	if err := d.applyNotifications(cfg.Notifications); err != nil {
+		log.Printf("daemon: notifications disabled: %v", err)
+	}
+	d.applyEvents(cfg.Events)
+	d.applyValidation(cfg.Validation)
+	d.applySysHistory(cfg.Collectors.SysMetrics)
+}
+
+// Reload re-reads the configuration and applies it: collectors are added,
+// removed, or rebuilt with their new settings while cached data and
+// unchanged collectors are kept. A configuration that fails to load or
+// validate is rejected and the running one stays active. The outcome is
+// reported by status as LastReload.
+func (d *Daemon) Reload() error {
+	d.mu.Lock()
+	old, path := d.appCfg, d.configPath
+	d.configStamp = statStamp(path)
+	d.mu.Unlock()
+	if old == nil {
+		return errNoConfig
+	}
+
+	var cfg *config.Config
+	var err error
+	if path != "" {
+		cfg, err = config.LoadFromFile(path)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		log.Printf("daemon: config reload rejected, keeping the running config: %v", err)
+		d.setLastReload(&ReloadStatus{At: time.Now(), Error: err.Error()})
+		return err
+	}
+
+	configureHTTP(cfg.HTTP)
+	changes := d.applySpecs(collectorSpecs(cfg, d.cfg.DataDir, d.cfg.StateDir))
+	changes = append(changes, configChanges(old, cfg)...)
+	if err := d.applyNotifications(cfg.Notifications); err != nil {
+		changes = append(changes, "notifications: disabled: "+err.Error())
+	}
+	d.applyEvents(cfg.Events)
+	d.applyValidation(cfg.Validation)
+	d.applySysHistory(cfg.Collectors.SysMetrics)
+	d.mu.Lock()
+	d.appCfg = cfg
+	d.mu.Unlock()
+
+	for _, c := range changes {
+		log.Printf("daemon: config reload: %s", c)
+	}
+	d.setLastReload(&ReloadStatus{At: time.Now(), OK: true, Changes: changes})
+	return nil
+}
+
+// setLastReload records the outcome of a reload.
+func (d *Daemon) setLastReload(rs *ReloadStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastReload = rs
+}
+
+// configChanged reports whether the config file was modified since it was
+// last loaded.
+func (d *Daemon) configChanged() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.appCfg == nil || d.configPath == "" {
+		return false
+	}
+	return statStamp(d.configPath) != d.configStamp
+}
+
+// statStamp identifies a version of the file at path by its modification
+// time and size. It is empty when path is empty or missing.
+func statStamp(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// applySpecs makes the scheduled collectors match specs and returns a
+// description of each change. Removed collectors stop after any collection
+// in flight; changed ones switch to their rebuilt collector the same way.
+func (d *Daemon) applySpecs(specs []collectorSpec) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.registry == nil {
+		d.registry = collectors.NewRegistry()
+	}
+	if d.jobs == nil {
+		d.jobs = make(map[string]*collectorJob)
+	}
+
+	want := make(map[string]bool, len(specs))
+	var changes []string
+	for _, spec := range specs {
+		want[spec.name] = true
+		j, ok := d.jobs[spec.name]
+		switch {
+		case !ok:
+			j = &collectorJob{
+				spec: spec,
+				c:    spec.build(),
+				wake: make(chan struct{}, 1),
+				kick: make(chan struct{}, 1),
+				stop: make(chan struct{}),
+			}
+			d.jobs[spec.name] = j
+			d.registry.Unregister(spec.name)
+			_ = d.registry.Register(j.c)
+			if d.runCtx != nil {
+				go d.runJob(d.runCtx, j)
+			}
+			changes = append(changes, spec.name+": added")
+
+		case j.spec.settings != spec.settings:
+			c := spec.build()
+			change := spec.name + ": settings changed"
+			if prev := j.current().Interval(); prev != c.Interval() {
+				change = fmt.Sprintf("%s: interval %s -> %s", spec.name, prev, c.Interval())
+			}
+			j.spec = spec
+			j.replace(c)
+			d.registry.Unregister(spec.name)
+			_ = d.registry.Register(c)
+			changes = append(changes, change)
+		}
+	}
+
+	for name, j := range d.jobs {
+		if want[name] {
+			continue
+		}
+		close(j.stop)
+		delete(d.jobs, name)
+		delete(d.collectors, name)
+		d.registry.Unregister(name)
+		changes = append(changes, name+": removed")
+	}
+	return changes
+}
+
+// startJobs starts every scheduled collector. It is called once by Start.
+func (d *Daemon) startJobs(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.runCtx = ctx
+	for _, j := range d.jobs {
+		go d.runJob(ctx, j)
+	}
+}
+
+// runJob collects with j's collector immediately and then on its
+// interval, backing off while it fails, until j is stopped, the daemon
+// starts draining, or ctx is cancelled. A rebuilt collector starts with a
+// fresh schedule, and collectNow runs the collector ahead of it.
+func (d *Daemon) runJob(ctx context.Context, j *collectorJob) {
+	c := j.current()
+	timer := time.NewTimer(d.runScheduled(ctx, j, c))
+	defer timer.Stop()
+	defer func() { stopCollector(c) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.stop:
+			return
+		case <-d.draining:
+			return
+		case <-j.wake:
+			old := c
+			c = j.current()
+			stopCollector(old)
+			timer.Reset(j.reschedule(c, false))
+		case <-j.kick:
+			timer.Reset(d.runScheduled(ctx, j, c))
+		case <-timer.C:
+			timer.Reset(d.runScheduled(ctx, j, c))
+		}
+	}
+}
+
+// runScheduled runs c once for j and returns the delay until its next
+// run. A run counts as failed when Collect errors or the collector reports
+// itself unhealthy afterwards.
+func (d *Daemon) runScheduled(ctx context.Context, j *collectorJob, c collectors.Collector) time.Duration {
+	err := d.collectOne(ctx, c)
+	delay := j.reschedule(c, err != nil || !c.Healthy())
+	_ = d.WriteHealth()
+	return delay
+}
+
+// current returns the collector the job should run, adopting one set by
+// replace.
+func (j *collectorJob) current() collectors.Collector {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.next != nil {
+		j.c, j.next = j.next, nil
+	}
+	return j.c
+}
+
+// collectNow makes the job run its collector at once rather than at its
+// next scheduled time.
+func (j *collectorJob) collectNow() {
+	select {
+	case j.kick <- struct{}{}:
+	default:
+	}
+}
+
+// replace hands the job a rebuilt collector to switch to.
+func (j *collectorJob) replace(c collectors.Collector) {
+	j.mu.Lock()
+	j.next = c
+	j.mu.Unlock()
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// jobInterval returns c's interval, guarding against a zero ticker.
+func jobInterval(c collectors.Collector) time.Duration {
+	if iv := c.Interval(); iv > 0 {
+		return iv
+	}
+	return time.Second
+}
+
+// stopCollector releases background resources held by collectors that
+// have them, such as Kubernetes watches.
+func stopCollector(c collectors.Collector) {
+	if s, ok := c.(interface{ Stop() }); ok {
+		s.Stop()
+	}
+}
+
+// configureHTTP applies the [http] settings to the client collectors
+// share.
+func configureHTTP(h config.HTTPConfig) {
+	httpx.Configure(httpx.Settings{
+		MaxRetries:     h.MaxRetries,
+		RetryBaseDelay: h.RetryBaseDelay.Duration,
+		MaxRetryDelay:  h.MaxRetryDelay.Duration,
+		RateLimits:     h.RateLimits,
+	})
+}
+
+// configChanges describes changes to settings outside the collectors.
+func configChanges(old, cfg *config.Config) []string {
+	var changes []string
+	if old.General.CacheDir != cfg.General.CacheDir {
+		changes = append(changes, "general.cache_dir: takes effect after a restart")
+	}
+	if old.General.StateDir != cfg.General.StateDir {
+		changes = append(changes, "general.state_dir: takes effect after a restart")
+	}
+	if !reflect.DeepEqual(old.Cache, cfg.Cache) {
+		changes = append(changes, "cache: takes effect after a restart")
+	}
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"layout", old.Layout, cfg.Layout},
+		{"image", old.Image, cfg.Image},
+		{"theme", old.Theme, cfg.Theme},
+		{"shell", old.Shell, cfg.Shell},
+		{"starship", old.Starship, cfg.Starship},
+		{"banner", old.Banner, cfg.Banner},
+		{"tui", old.TUI, cfg.TUI},
+		{"http", old.HTTP, cfg.HTTP},
+		{"notifications", old.Notifications, cfg.Notifications},
+		{"status_page", old.StatusPage, cfg.StatusPage},
+		{"events", old.Events, cfg.Events},
+		{"validation", old.Validation, cfg.Validation},
+	}
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.old, s.new) {
+			changes = append(changes, s.name+": settings updated")
+		}
+	}
+	return changes
+}
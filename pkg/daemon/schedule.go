@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+)
+
+// maxBackoff caps how far a failing collector's runs are spread out. A
+// collector whose own interval is longer is never run more often than that.
+const maxBackoff = 30 * time.Minute
+
+// jitterFraction is the largest random share of a delay added to or taken
+// from it, so collectors started together drift apart instead of firing
+// in lockstep.
+const jitterFraction = 0.1
+
+// reschedule records the outcome of a run of c and returns how long j
+// waits before the next one: c's interval after a success, doubled for
+// each consecutive failure up to maxBackoff, with jitter either way.
+func (j *collectorJob) reschedule(c collectors.Collector, failed bool) time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if failed {
+		j.failures++
+	} else {
+		j.failures = 0
+	}
+	delay := jitter(backoff(jobInterval(c), j.failures))
+	j.nextRun = time.Now().Add(delay)
+	return delay
+}
+
+// schedule returns when j next runs and how many times in a row it has
+// failed.
+func (j *collectorJob) schedule() (time.Time, int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.nextRun, j.failures
+}
+
+// backoff returns the delay before the next run of a collector with the
+// given interval after failures consecutive failures.
+func backoff(interval time.Duration, failures int) time.Duration {
+	limit := max(interval, maxBackoff)
+	delay := interval
+	for i := 0; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// jitter moves d by a random amount of up to jitterFraction of it.
+func jitter(d time.Duration) time.Duration {
+	spread := int64(float64(d) * jitterFraction)
+	if spread <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int64N(2*spread+1)-spread)
+}
@@ -0,0 +1,82 @@
+package daemon
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// shutdownGrace is how long a stopping daemon waits for work in flight
+// unless general.shutdown_grace sets another bound.
+const shutdownGrace = 10 * time.Second
+
+// ErrForcedShutdown is returned by Start when ForceShutdown cut its
+// graceful shutdown short.
+var ErrForcedShutdown = errors.New("daemon: shutdown forced")
+
+// errShuttingDown is returned for a collector run asked for after the
+// daemon began shutting down.
+var errShuttingDown = errors.New("daemon is shutting down")
+
+// ForceShutdown makes a graceful shutdown in progress, or the next one,
+// stop waiting for work in flight. Start then returns ErrForcedShutdown.
+// It is safe to call more than once.
+func (d *Daemon) ForceShutdown() {
+	d.forceOnce.Do(func() { close(d.force) })
+}
+
+// beginWork registers a collector run or notification delivery for drain
+// to wait for. It returns false, registering nothing, once the daemon is
+// shutting down; the caller must call d.inflight.Done after a true result.
+func (d *Daemon) beginWork() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopping {
+		return false
+	}
+	d.inflight.Add(1)
+	return true
+}
+
+// drain stops new collector runs from starting and waits up to the
+// shutdown grace period for those in flight, and the notifications they
+// raised, to finish. It reports false when ForceShutdown ended the wait.
+func (d *Daemon) drain() bool {
+	d.mu.Lock()
+	if !d.stopping {
+		d.stopping = true
+		close(d.draining)
+	}
+	d.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(idle)
+	}()
+
+	grace := d.shutdownGrace()
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-d.force:
+		slog.Warn("daemon shutdown forced, cancelling work in flight")
+		return false
+	case <-timer.C:
+		slog.Warn("daemon shutdown grace period over, cancelling work in flight", "grace", grace.String())
+		return true
+	}
+}
+
+// shutdownGrace returns how long drain waits: the configured
+// general.shutdown_grace, or shutdownGrace without a configuration.
+func (d *Daemon) shutdownGrace() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.appCfg != nil {
+		return d.appCfg.General.ShutdownGrace.Duration
+	}
+	return shutdownGrace
+}
@@ -0,0 +1,31 @@
+package daemon
+
+import (
+	"log"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/statuspage"
+)
+
+// writeStatusPage rewrites the status page at status_page.path when
+// status_page.interval has passed since it was last written. It runs on
+// the health ticker, so intervals shorter than that are rounded up to it.
+// Failures are logged and retried at the next interval.
+func (d *Daemon) writeStatusPage(now time.Time) {
+	d.mu.Lock()
+	cfg := d.appCfg
+	due := cfg != nil && cfg.StatusPage.Interval.Duration > 0 &&
+		now.Sub(d.statusPageAt) >= cfg.StatusPage.Interval.Duration
+	if due {
+		d.statusPageAt = now
+	}
+	d.mu.Unlock()
+	if !due {
+		return
+	}
+
+	sp := cfg.StatusPage
+	if err := statuspage.WriteFile(sp.Path, statuspage.Build(cfg, now), sp.Template); err != nil {
+		log.Printf("daemon: status page: %v", err)
+	}
+}
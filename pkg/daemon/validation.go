@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/validate"
+)
+
+// applyValidation makes the daemon's result checks match vc. Disabling
+// them drops the accepted results, so enabling them again starts from
+// whatever each collector returns next.
+func (d *Daemon) applyValidation(vc config.ValidationConfig) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !vc.Enabled {
+		d.validator = nil
+		return
+	}
+	tol := validate.Tolerances{
+		BillingMaxFactor: vc.BillingMaxFactor,
+		ClaudeWindow:     vc.ClaudeWindow.Duration,
+		AllowZeroNodes:   vc.K8sAllowZeroNodes,
+	}
+	if d.validator == nil {
+		d.validator = validate.New(tol)
+	} else {
+		d.validator.SetTolerances(tol)
+	}
+}
+
+// validateResult checks a collector's new data before it is cached. A
+// rejected result is logged with its payload to the rejected log in
+// StateDir and returned as a *validate.Rejection.
+func (d *Daemon) validateResult(name string, data interface{}) error {
+	d.mu.Lock()
+	v := d.validator
+	d.mu.Unlock()
+	if v == nil {
+		return nil
+	}
+	err := v.Check(name, data)
+	var rej *validate.Rejection
+	if !errors.As(err, &rej) {
+		return err
+	}
+	d.rejectMu.Lock()
+	defer d.rejectMu.Unlock()
+	if lerr := validate.LogRejected(filepath.Join(d.cfg.StateDir, validate.RejectedFileName), rej, data, time.Now(), validate.DefaultMaxRejected); lerr != nil {
+		log.Printf("daemon: %v", lerr)
+	}
+	return err
+}
+
+// acceptNext makes the named collector's next result pass its checks.
+func (d *Daemon) acceptNext(name string) error {
+	d.mu.Lock()
+	v, reg := d.validator, d.registry
+	d.mu.Unlock()
+	if name == "" {
+		return errors.New("accept needs a collector")
+	}
+	if v == nil {
+		return errors.New("validation is disabled")
+	}
+	if reg == nil {
+		return fmt.Errorf("collector %q not registered", name)
+	}
+	if _, ok := reg.Get(name); !ok {
+		return fmt.Errorf("collector %q not registered", name)
+	}
+	v.AcceptNext(name)
+	return nil
+}
@@ -1,8 +1,10 @@
 package data
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"os"
 	"sort"
 	"sync"
 	"testing"
@@ -854,3 +856,122 @@ func TestGetRangeOnFrozenSeries(t *testing.T) {
 // Verify we have at least 25 tests by counting test functions in this file.
 // Current count: 35 Test* functions.
 var _ = math.Abs // keep math import used
+
+// ---------- ring ----------
+
+func TestRingAddWrapsAndSince(t *testing.T) {
+	r := NewRing(4, "a", "b")
+	if r.Len() != 0 || !r.Last().IsZero() {
+		t.Fatalf("empty ring: Len() = %d, Last() = %v", r.Len(), r.Last())
+	}
+	t0 := baseTime()
+	for i := 0; i < 6; i++ {
+		r.Add(t0.Add(time.Duration(i)*time.Second), float64(i), float64(10*i))
+	}
+	if r.Len() != 4 || r.Cap() != 4 {
+		t.Fatalf("Len() = %d, Cap() = %d, want 4, 4", r.Len(), r.Cap())
+	}
+	if !r.Last().Equal(t0.Add(5 * time.Second)) {
+		t.Errorf("Last() = %v, want the sixth sample", r.Last())
+	}
+
+	snap, ok := r.Since("b", time.Time{})
+	if !ok || fmt.Sprint(snap.Values) != "[20 30 40 50]" {
+		t.Errorf("Since(b) = %v, want the newest four oldest first", snap.Values)
+	}
+	snap, _ = r.Since("a", t0.Add(4*time.Second))
+	if fmt.Sprint(snap.Values) != "[4 5]" || !snap.Times[0].Equal(t0.Add(4*time.Second)) {
+		t.Errorf("Since(a, 4s) = %v at %v, want [4 5]", snap.Values, snap.Times)
+	}
+	if _, ok := r.Since("c", time.Time{}); ok {
+		t.Error("Since of an unknown series reported ok")
+	}
+
+	// Missing and non-finite values are recorded as zero.
+	r.Add(t0.Add(6*time.Second), math.NaN())
+	snap, _ = r.Since("a", t0.Add(6*time.Second))
+	snapB, _ := r.Since("b", t0.Add(6*time.Second))
+	if snap.Last() != 0 || snapB.Last() != 0 {
+		t.Errorf("NaN and missing values = %v, %v, want 0, 0", snap.Last(), snapB.Last())
+	}
+}
+
+func TestRingResized(t *testing.T) {
+	r := NewRing(5, "a")
+	for i := 0; i < 5; i++ {
+		r.Add(baseTime().Add(time.Duration(i)*time.Second), float64(i))
+	}
+	small := r.Resized(3)
+	if snap, _ := small.Since("a", time.Time{}); small.Cap() != 3 || fmt.Sprint(snap.Values) != "[2 3 4]" {
+		t.Errorf("Resized(3) = %v, want the newest three", snap.Values)
+	}
+	big := r.Resized(10)
+	if snap, _ := big.Since("a", time.Time{}); big.Cap() != 10 || fmt.Sprint(snap.Values) != "[0 1 2 3 4]" {
+		t.Errorf("Resized(10) = %v, want all five", snap.Values)
+	}
+	big.Add(baseTime().Add(5*time.Second), 5)
+	if big.Len() != 6 {
+		t.Errorf("Len() after Add = %d, want 6", big.Len())
+	}
+}
+
+func TestRingFileRoundTrip(t *testing.T) {
+	path := t.TempDir() + "/ring.json"
+	r := NewRing(3, "cpu", "mem")
+	for i := 0; i < 4; i++ {
+		r.Add(baseTime().Add(time.Duration(i)*time.Minute), float64(i), 0.5)
+	}
+	if err := r.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := ReadRing(path)
+	if err != nil {
+		t.Fatalf("ReadRing: %v", err)
+	}
+	if got.Cap() != 3 || got.Len() != 3 || fmt.Sprint(got.Names()) != "[cpu mem]" {
+		t.Errorf("read ring: Cap %d, Len %d, Names %v, want 3, 3, [cpu mem]", got.Cap(), got.Len(), got.Names())
+	}
+	snap, _ := got.Since("cpu", time.Time{})
+	if fmt.Sprint(snap.Values) != "[1 2 3]" || !snap.Times[2].Equal(baseTime().Add(3*time.Minute)) {
+		t.Errorf("cpu = %v at %v, want [1 2 3] ending at 3m", snap.Values, snap.Times)
+	}
+	got.Add(baseTime().Add(4*time.Minute), 4, 0.5)
+	if snap, _ := got.Since("cpu", time.Time{}); fmt.Sprint(snap.Values) != "[2 3 4]" {
+		t.Errorf("cpu after Add = %v, want the read ring to keep wrapping", snap.Values)
+	}
+
+	// Another format version is refused, not misread.
+	if err := os.WriteFile(path, []byte(`{"version":99,"times":[1],"values":{"cpu":[1]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRing(path); !errors.Is(err, ErrRingFormat) {
+		t.Errorf("ReadRing of version 99 = %v, want ErrRingFormat", err)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	values := []float64{1, 9, 2, 2, 3, 3, 4, 8}
+	if got := Downsample(values, 4, AggregateMax); fmt.Sprint(got) != "[9 2 3 8]" {
+		t.Errorf("max = %v, want [9 2 3 8]", got)
+	}
+	if got := Downsample(values, 4, AggregateAvg); fmt.Sprint(got) != "[5 2 3 6]" {
+		t.Errorf("avg = %v, want [5 2 3 6]", got)
+	}
+	if got := Downsample(values, 3, AggregateMax); fmt.Sprint(got) != "[9 3 8]" {
+		t.Errorf("uneven max = %v, want [9 3 8]", got)
+	}
+	if got := Downsample(values, 20, AggregateAvg); fmt.Sprint(got) != fmt.Sprint(values) {
+		t.Errorf("values that fit = %v, want them unchanged", got)
+	}
+	if Downsample(values, 0, AggregateAvg) != nil || Downsample(nil, 4, AggregateAvg) != nil {
+		t.Error("no buckets or no values should give nil")
+	}
+
+	// A day of 15s samples draws into 80 cells.
+	day := make([]float64, 5760)
+	day[1234] = 100
+	got := Downsample(day, 80, AggregateMax)
+	if len(got) != 80 || got[1234*80/5760] != 100 {
+		t.Errorf("day downsample: len %d, spike at %v, want 80 with the spike kept", len(got), got[1234*80/5760])
+	}
+}
@@ -0,0 +1,42 @@
+package data
+
+// Aggregation selects how Downsample combines the values in a bucket.
+type Aggregation int
+
+const (
+	AggregateAvg Aggregation = iota // arithmetic mean
+	AggregateMax                    // largest value, so short spikes survive
+)
+
+// Downsample reduces values to at most buckets values, each combining a
+// run of consecutive inputs by agg, so a long series can be drawn in a few
+// cells in one pass. Values that already fit are returned as a copy.
+func Downsample(values []float64, buckets int, agg Aggregation) []float64 {
+	if buckets <= 0 || len(values) == 0 {
+		return nil
+	}
+	if len(values) <= buckets {
+		return copyValues(values)
+	}
+	out := make([]float64, buckets)
+	for b := range out {
+		lo := b * len(values) / buckets
+		hi := (b + 1) * len(values) / buckets
+		bucket := values[lo:hi]
+		switch agg {
+		case AggregateMax:
+			m := bucket[0]
+			for _, v := range bucket[1:] {
+				m = max(m, v)
+			}
+			out[b] = m
+		default:
+			sum := 0.0
+			for _, v := range bucket {
+				sum += v
+			}
+			out[b] = sum / float64(len(bucket))
+		}
+	}
+	return out
+}
@@ -0,0 +1,244 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RingFormatVersion is the version of the file written by Ring.WriteFile.
+// Files of another version are refused by ReadRing rather than misread.
+const RingFormatVersion = 1
+
+// ErrRingFormat is returned by ReadRing for a file of another format
+// version.
+var ErrRingFormat = errors.New("data: ring file has an unsupported format version")
+
+// Ring is a fixed-capacity buffer of samples of several series taken at the
+// same times. Like Store it keeps a Structure-of-Arrays layout: one time
+// slice and one value slice per series, written in place so a full ring
+// allocates nothing per sample. It is safe for concurrent use.
+type Ring struct {
+	mu     sync.RWMutex
+	names  []string
+	times  []time.Time
+	values [][]float64 // values[series][slot]
+
+	// next is the slot the next sample is written to, and n the number of
+	// samples held.
+	next, n int
+}
+
+// NewRing returns an empty ring holding up to capacity samples of the
+// named series. A capacity below one is raised to one.
+func NewRing(capacity int, names ...string) *Ring {
+	capacity = max(capacity, 1)
+	r := &Ring{
+		names:  slices.Clone(names),
+		times:  make([]time.Time, capacity),
+		values: make([][]float64, len(names)),
+	}
+	for i := range r.values {
+		r.values[i] = make([]float64, capacity)
+	}
+	return r
+}
+
+// Names returns the series names, in the order Add takes their values.
+func (r *Ring) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.names)
+}
+
+// Cap returns how many samples the ring holds when full.
+func (r *Ring) Cap() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.times)
+}
+
+// Len returns how many samples the ring holds.
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.n
+}
+
+// Add records a sample taken at t, overwriting the oldest when the ring is
+// full. values are given in Names order; missing values are recorded as
+// zero, and NaN and infinities as zero so the ring can be written out.
+func (r *Ring) Add(t time.Time, values ...float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.times[r.next] = t
+	for i, col := range r.values {
+		v := 0.0
+		if i < len(values) && !math.IsNaN(values[i]) && !math.IsInf(values[i], 0) {
+			v = values[i]
+		}
+		col[r.next] = v
+	}
+	r.next = (r.next + 1) % len(r.times)
+	r.n = min(r.n+1, len(r.times))
+}
+
+// Last returns the time of the newest sample, or the zero time when the
+// ring is empty.
+func (r *Ring) Last() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.n == 0 {
+		return time.Time{}
+	}
+	return r.times[(r.next-1+len(r.times))%len(r.times)]
+}
+
+// Since returns a snapshot of the named series from the samples taken at
+// or after since, oldest first, and false for a series the ring does not
+// hold.
+func (r *Ring) Since(name string, since time.Time) (*SeriesSnapshot, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	col := slices.Index(r.names, name)
+	if col < 0 {
+		return nil, false
+	}
+	snap := &SeriesSnapshot{Name: name}
+	for i := 0; i < r.n; i++ {
+		slot := r.slot(i)
+		if r.times[slot].Before(since) {
+			continue
+		}
+		snap.Times = append(snap.Times, r.times[slot])
+		snap.Values = append(snap.Values, r.values[col][slot])
+	}
+	return snap, true
+}
+
+// Resized returns a copy of the ring with the given capacity, keeping the
+// newest samples that fit.
+func (r *Ring) Resized(capacity int) *Ring {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := NewRing(capacity, r.names...)
+	row := make([]float64, len(r.names))
+	for i := max(0, r.n-out.Cap()); i < r.n; i++ {
+		slot := r.slot(i)
+		for c, col := range r.values {
+			row[c] = col[slot]
+		}
+		out.Add(r.times[slot], row...)
+	}
+	return out
+}
+
+// slot returns the slot of the i-th oldest sample. Callers hold r.mu.
+func (r *Ring) slot(i int) int {
+	return (r.next - r.n + i + len(r.times)) % len(r.times)
+}
+
+// ringFile is the on-disk form of a Ring: its samples oldest first, with
+// times in Unix seconds.
+type ringFile struct {
+	Version  int                  `json:"version"`
+	Capacity int                  `json:"capacity"`
+	Times    []int64              `json:"times"`
+	Values   map[string][]float64 `json:"values"`
+}
+
+// MarshalJSON encodes the ring in the versioned file format.
+func (r *Ring) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f := ringFile{
+		Version:  RingFormatVersion,
+		Capacity: len(r.times),
+		Times:    make([]int64, r.n),
+		Values:   make(map[string][]float64, len(r.names)),
+	}
+	for c, name := range r.names {
+		col := make([]float64, r.n)
+		for i := range col {
+			col[i] = r.values[c][r.slot(i)]
+		}
+		f.Values[name] = col
+	}
+	for i := range f.Times {
+		f.Times[i] = r.times[r.slot(i)].Unix()
+	}
+	return json.Marshal(f)
+}
+
+// UnmarshalJSON decodes a ring written by MarshalJSON, returning
+// ErrRingFormat for another format version. Series are ordered by name.
+func (r *Ring) UnmarshalJSON(b []byte) error {
+	var f ringFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	if f.Version != RingFormatVersion {
+		return fmt.Errorf("%w: %d", ErrRingFormat, f.Version)
+	}
+	names := make([]string, 0, len(f.Values))
+	for name, col := range f.Values {
+		if len(col) != len(f.Times) {
+			return fmt.Errorf("data: ring series %q has %d values for %d times", name, len(col), len(f.Times))
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fresh := NewRing(max(f.Capacity, len(f.Times)), names...)
+	row := make([]float64, len(names))
+	for i, ts := range f.Times {
+		for c, name := range names {
+			row[c] = f.Values[name][i]
+		}
+		fresh.Add(time.Unix(ts, 0), row...)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names, r.times, r.values, r.next, r.n = fresh.names, fresh.times, fresh.values, fresh.next, fresh.n
+	return nil
+}
+
+// WriteFile writes the ring to path atomically.
+func (r *Ring) WriteFile(path string) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("data: marshal ring: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("data: write ring: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("data: rename ring: %w", err)
+	}
+	return nil
+}
+
+// ReadRing reads a ring written by WriteFile. A file of another format
+// version yields an error wrapping ErrRingFormat.
+func ReadRing(path string) (*Ring, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := new(Ring)
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("data: read ring %s: %w", path, err)
+	}
+	return r, nil
+}
@@ -4,6 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/migrate"
 )
 
 // Check represents a single deployment verification check.
@@ -69,34 +76,163 @@ func dpCheckConfig(profile *HostProfile) Check {
 	}
 }
 
-// dpCheckDaemon returns a check that verifies the daemon socket or PID
-// file exists, indicating the daemon is running or was recently active.
-func dpCheckDaemon(profile *HostProfile) Check {
+// dpCheckProfileDrift returns a check that loads the host's config and
+// compares the collectors it enables, and whether it shows the waifu,
+// with profile.ExpectedCollectors. A config that overrides the profile
+// fails it, as a warning.
+func dpCheckProfileDrift(profile *HostProfile) Check {
 	return Check{
-		Name:     "daemon",
+		Name:     "profile-drift",
 		Required: false,
 		Run: func() (bool, string) {
-			sock := profile.SocketPath
-			if sock == "" {
-				sock = dpDefaultSocketPath()
+			path := profile.ConfigPath
+			if path == "" {
+				path = dpDefaultConfigPath()
 			}
-			if _, err := os.Stat(sock); err == nil {
-				return true, fmt.Sprintf("daemon socket ok: %s", sock)
+			cfg, err := config.LoadFromFile(path)
+			if err != nil {
+				return false, fmt.Sprintf("config does not load: %v", err)
 			}
+			return dpProfileDrift(profile, cfg)
+		},
+	}
+}
+
+// dpProfileDrift compares the collectors cfg enables, and its waifu
+// setting, with profile.ExpectedCollectors.
+func dpProfileDrift(profile *HostProfile, cfg *config.Config) (bool, string) {
+	want := map[string]bool{}
+	waifu := false
+	for _, name := range profile.ExpectedCollectors {
+		if key, ok := config.FeatureCollector(name); ok {
+			want[key] = true
+		} else if name == "waifu" {
+			waifu = true
+		}
+	}
+
+	var extra, missing []string
+	enabled := map[string]bool{}
+	for _, key := range cfg.EnabledCollectors() {
+		enabled[key] = true
+		if !want[key] {
+			extra = append(extra, key)
+		}
+	}
+	for key := range want {
+		if !enabled[key] {
+			missing = append(missing, key)
+		}
+	}
+	switch {
+	case waifu && !cfg.Image.WaifuEnabled:
+		missing = append(missing, "waifu")
+	case !waifu && cfg.Image.WaifuEnabled:
+		extra = append(extra, "waifu")
+	}
+	sort.Strings(extra)
+	sort.Strings(missing)
+
+	var drift []string
+	if len(missing) > 0 {
+		drift = append(drift, "expected but disabled: "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		drift = append(drift, "enabled but not expected: "+strings.Join(extra, ", "))
+	}
+	if len(drift) > 0 {
+		return false, fmt.Sprintf("config drifts from profile %s: %s", profile.Name, strings.Join(drift, "; "))
+	}
+	return true, fmt.Sprintf("config matches profile %s", profile.Name)
+}
 
-			pid := profile.PIDFile
-			if pid == "" {
-				pid = dpDefaultPIDPath()
+// dpCheckDaemon returns a check that verifies a daemon holds the instance
+// lock and answers on its control socket, and that it runs
+// profile.ExpectedVersion when that is set. The message names the
+// version that answered.
+func dpCheckDaemon(profile *HostProfile) Check {
+	return Check{
+		Name:     "daemon",
+		Required: false,
+		Run: func() (bool, string) {
+			probe := dpProbeDaemon(
+				dpSocketPath(profile.SocketPath, profile.StateDir),
+				dpLockPath(profile.LockFile, profile.StateDir),
+				time.Now(),
+			)
+			if probe.status != "healthy" {
+				return false, probe.message
 			}
-			if _, err := os.Stat(pid); err == nil {
-				return true, fmt.Sprintf("daemon PID file ok: %s", pid)
+			if want := profile.ExpectedVersion; want != "" && probe.version != want {
+				return false, fmt.Sprintf("%s; want version %s", probe.message, want)
 			}
-
-			return false, "daemon not detected (no socket or PID file)"
+			return true, probe.message
 		},
 	}
 }
 
+// dpPingTimeout bounds how long daemon checks wait for the control socket
+// to answer.
+const dpPingTimeout = 2 * time.Second
+
+// dpDaemonProbe is what dpProbeDaemon learned about the daemon.
+type dpDaemonProbe struct {
+	status  string // "healthy", "degraded", or "unhealthy"
+	message string
+	version string
+	uptime  time.Duration
+}
+
+// dpProbeDaemon checks that a daemon holds the instance lock and answers a
+// status request on sock within dpPingTimeout. A socket or PID file can
+// outlive a daemon that crashed, and a hung daemon keeps its lock, so only
+// the answer shows it is alive. The message names the daemon's version and
+// when it last collected successfully.
+func dpProbeDaemon(sock, lock string, now time.Time) dpDaemonProbe {
+	info, ok := daemon.LockHolder(lock)
+	if !ok {
+		return dpDaemonProbe{
+			status:  "unhealthy",
+			message: fmt.Sprintf("daemon not running (lock not held: %s)", lock),
+		}
+	}
+	if _, err := os.Stat(sock); err != nil {
+		return dpDaemonProbe{
+			status:  "degraded",
+			message: fmt.Sprintf("daemon running (PID %d) but socket not found: %s", info.PID, sock),
+		}
+	}
+	st, err := daemon.Ping(sock, dpPingTimeout)
+	if err != nil {
+		return dpDaemonProbe{
+			status:  "unhealthy",
+			message: fmt.Sprintf("unhealthy (stale socket): daemon (PID %d) did not answer at %s: %v", info.PID, sock, err),
+		}
+	}
+	if st.PID != info.PID {
+		return dpDaemonProbe{
+			status:  "degraded",
+			message: fmt.Sprintf("socket %s answered by PID %d, but PID %d holds the lock", sock, st.PID, info.PID),
+			version: st.Version,
+		}
+	}
+
+	version := st.Version
+	if version == "" {
+		version = "unknown"
+	}
+	collected := "no collection yet"
+	if last := st.LastSuccess(); !last.IsZero() {
+		collected = fmt.Sprintf("last collection %s ago", now.Sub(last).Round(time.Second))
+	}
+	return dpDaemonProbe{
+		status:  "healthy",
+		message: fmt.Sprintf("daemon running: PID %d, version %s, %s", st.PID, version, collected),
+		version: st.Version,
+		uptime:  st.Uptime,
+	}
+}
+
 // dpCheckCache returns a check that verifies the cache directory exists
 // with the expected subdirectories.
 func dpCheckCache(profile *HostProfile) Check {
@@ -116,7 +252,7 @@ func dpCheckCache(profile *HostProfile) Check {
 				return false, fmt.Sprintf("cache path is not a directory: %s", dir)
 			}
 
-			subdirs := []string{"waifu", "banner", "sessions"}
+			subdirs := []string{"waifu", "banner"}
 			var missing []string
 			for _, sub := range subdirs {
 				p := filepath.Join(dir, sub)
@@ -132,6 +268,43 @@ func dpCheckCache(profile *HostProfile) Check {
 	}
 }
 
+// dpCheckState returns a check that verifies the state directory exists
+// and that no daemon state is left in the cache directory, where earlier
+// versions kept it.
+func dpCheckState(profile *HostProfile) Check {
+	return Check{
+		Name:     "state",
+		Required: true,
+		Run: func() (bool, string) {
+			return dpStateStatus(profile.CacheDir, profile.StateDir)
+		},
+	}
+}
+
+// dpStateStatus reports whether the state directory is in place and the
+// cache directory migrated out of, and why not.
+func dpStateStatus(cacheDir, stateDir string) (bool, string) {
+	if cacheDir == "" {
+		cacheDir = dpDefaultCacheDir()
+	}
+	if stateDir == "" {
+		stateDir = dpDefaultStateDir()
+	}
+	info, err := os.Stat(stateDir)
+	if err != nil {
+		return false, fmt.Sprintf("state dir not found: %s", stateDir)
+	}
+	if !info.IsDir() {
+		return false, fmt.Sprintf("state path is not a directory: %s", stateDir)
+	}
+	if filepath.Clean(cacheDir) != filepath.Clean(stateDir) {
+		if left := migrate.LegacyStateFiles(cacheDir); len(left) > 0 {
+			return false, fmt.Sprintf("state not migrated: %v still in %s", left, cacheDir)
+		}
+	}
+	return true, fmt.Sprintf("state ok: %s", stateDir)
+}
+
 // dpCheckShell returns a check that verifies shell integration for the
 // given shell name. It checks that a shell-integration marker file exists.
 func dpCheckShell(profile *HostProfile, shell string) Check {
@@ -153,7 +326,7 @@ func dpCheckShell(profile *HostProfile, shell string) Check {
 }
 
 // dpCheckCollector returns a check that verifies a collector's data
-// file exists in the cache directory.
+// file, {name}.json, exists in the cache directory.
 func dpCheckCollector(profile *HostProfile, name string) Check {
 	return Check{
 		Name:     fmt.Sprintf("collector-%s", name),
@@ -163,7 +336,7 @@ func dpCheckCollector(profile *HostProfile, name string) Check {
 			if dir == "" {
 				dir = dpDefaultCacheDir()
 			}
-			dataFile := filepath.Join(dir, "collectors", name+".json")
+			dataFile := filepath.Join(dir, name+".json")
 			if _, err := os.Stat(dataFile); err != nil {
 				return false, fmt.Sprintf("collector data missing: %s", dataFile)
 			}
@@ -229,8 +402,8 @@ func dpCheckImage() Check {
 	}
 }
 
-// dpCheckPermissions returns a check that verifies the config and cache
-// directories have appropriate permissions (owner read/write, not world-writable).
+// dpCheckPermissions returns a check that verifies the config, cache, and
+// state directories have appropriate permissions (owner read/write, not world-writable).
 func dpCheckPermissions(profile *HostProfile) Check {
 	return Check{
 		Name:     "permissions",
@@ -250,6 +423,12 @@ func dpCheckPermissions(profile *HostProfile) Check {
 			}
 			dirs["cache"] = cacheDir
 
+			stateDir := profile.StateDir
+			if stateDir == "" {
+				stateDir = dpDefaultStateDir()
+			}
+			dirs["state"] = stateDir
+
 			for label, dir := range dirs {
 				info, err := os.Stat(dir)
 				if err != nil {
@@ -272,32 +451,44 @@ func dpDefaultBinaryPath() string {
 	return filepath.Join(home, ".local", "bin", "prompt-pulse")
 }
 
-// dpDefaultConfigPath returns the conventional config file location.
+// dpDefaultConfigPath returns the config file location prompt-pulse reads
+// first, as config.DefaultPath does.
 func dpDefaultConfigPath() string {
-	dir, err := os.UserConfigDir()
-	if err != nil {
-		home, _ := os.UserHomeDir()
-		dir = filepath.Join(home, ".config")
-	}
-	return filepath.Join(dir, "prompt-pulse", "config.toml")
+	return config.DefaultPath()
 }
 
-// dpDefaultCacheDir returns the conventional cache directory.
+// dpDefaultCacheDir returns the default cache directory,
+// $XDG_CACHE_HOME/prompt-pulse, the same on every OS.
 func dpDefaultCacheDir() string {
-	dir, err := os.UserCacheDir()
-	if err != nil {
-		home, _ := os.UserHomeDir()
-		dir = filepath.Join(home, ".cache")
-	}
-	return filepath.Join(dir, "prompt-pulse")
+	return config.DefaultCacheDir()
+}
+
+// dpDefaultStateDir returns the default state directory,
+// $XDG_STATE_HOME/prompt-pulse.
+func dpDefaultStateDir() string {
+	return config.DefaultStateDir()
 }
 
-// dpDefaultSocketPath returns the conventional daemon socket location.
-func dpDefaultSocketPath() string {
-	return filepath.Join(os.TempDir(), "prompt-pulse.sock")
+// dpSocketPath returns the daemon control socket location: socketPath when
+// set, otherwise the socket in stateDir or the default state directory.
+func dpSocketPath(socketPath, stateDir string) string {
+	if socketPath != "" {
+		return socketPath
+	}
+	if stateDir == "" {
+		stateDir = dpDefaultStateDir()
+	}
+	return filepath.Join(stateDir, daemon.ControlSocketName)
 }
 
-// dpDefaultPIDPath returns the conventional PID file location.
-func dpDefaultPIDPath() string {
-	return filepath.Join(os.TempDir(), "prompt-pulse.pid")
+// dpLockPath returns the daemon instance lock location: lockFile when set,
+// otherwise the lock in stateDir or the default state directory.
+func dpLockPath(lockFile, stateDir string) string {
+	if lockFile != "" {
+		return lockFile
+	}
+	if stateDir == "" {
+		stateDir = dpDefaultStateDir()
+	}
+	return filepath.Join(stateDir, daemon.LockFileName)
 }
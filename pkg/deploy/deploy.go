@@ -2,7 +2,10 @@ package deploy
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
 )
 
 // HostProfile describes a target host for deployment verification.
@@ -31,14 +34,25 @@ type HostProfile struct {
 	// ConfigPath overrides the default config file location for testing.
 	ConfigPath string
 
-	// CacheDir overrides the default cache directory for testing.
+	// CacheDir overrides the default cache directory,
+	// $XDG_CACHE_HOME/prompt-pulse, for testing.
 	CacheDir string
 
-	// SocketPath overrides the default daemon socket location for testing.
+	// StateDir overrides the default state directory,
+	// $XDG_STATE_HOME/prompt-pulse, for testing.
+	StateDir string
+
+	// SocketPath overrides the default daemon socket location, which is in
+	// StateDir, for testing.
 	SocketPath string
 
-	// PIDFile overrides the default PID file location for testing.
-	PIDFile string
+	// LockFile overrides the default daemon instance lock location, which
+	// is in StateDir, for testing.
+	LockFile string
+
+	// ExpectedVersion, when set, is the version the daemon must report,
+	// so verification after a rollout fails while the old build runs.
+	ExpectedVersion string
 }
 
 // VerifyResult holds the outcome of verifying a host profile.
@@ -116,6 +130,28 @@ func PettingZooMini() *HostProfile {
 	}
 }
 
+// Profiles returns the known host profiles.
+func Profiles() []*HostProfile {
+	return []*HostProfile{XoxdBates(), Honey(), PettingZooMini()}
+}
+
+// ResolveProfile returns the features of the host profile called name,
+// or when name is empty of the one whose Name is hostname, ignoring case
+// and any domain (e.g. "honey.local"). It is the config.ProfileResolver
+// prompt-pulse installs, so each host's profile sets its default
+// collectors.
+func ResolveProfile(name, hostname string) (config.HostProfile, bool) {
+	if name == "" {
+		name, _, _ = strings.Cut(hostname, ".")
+	}
+	for _, p := range Profiles() {
+		if strings.EqualFold(p.Name, name) {
+			return config.HostProfile{Name: p.Name, Features: p.Features}, true
+		}
+	}
+	return config.HostProfile{}, false
+}
+
 // Verify runs all applicable checks for the given host profile and returns
 // the aggregated result. It does NOT perform deployment; it only validates
 // that an existing deployment is correct.
@@ -124,7 +160,11 @@ func Verify(profile *HostProfile) (*VerifyResult, error) {
 		return nil, fmt.Errorf("deploy: nil host profile")
 	}
 
-	checks := dpBuildChecks(profile)
+	return dpRunChecks(profile.Name, dpBuildChecks(profile)), nil
+}
+
+// dpRunChecks runs checks for host and aggregates their results.
+func dpRunChecks(host string, checks []Check) *VerifyResult {
 	results := make([]CheckResult, 0, len(checks))
 	allPassed := true
 
@@ -147,11 +187,11 @@ func Verify(profile *HostProfile) (*VerifyResult, error) {
 	}
 
 	return &VerifyResult{
-		Host:      profile.Name,
+		Host:      host,
 		Passed:    allPassed,
 		Checks:    results,
 		Timestamp: time.Now(),
-	}, nil
+	}
 }
 
 // dpBuildChecks assembles the list of checks for a profile.
@@ -159,8 +199,10 @@ func dpBuildChecks(profile *HostProfile) []Check {
 	checks := []Check{
 		dpCheckBinary(profile),
 		dpCheckConfig(profile),
+		dpCheckProfileDrift(profile),
 		dpCheckDaemon(profile),
 		dpCheckCache(profile),
+		dpCheckState(profile),
 		dpCheckTheme(profile),
 		dpCheckTerminal(),
 		dpCheckImage(),
@@ -1,18 +1,67 @@
 package deploy
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
 )
 
 // ---------- helpers ----------
 
+// holdDaemonLock takes the daemon instance lock in stateDir, as a running
+// daemon would, until the test ends.
+func holdDaemonLock(t *testing.T, stateDir string) {
+	t.Helper()
+	lock, err := daemon.AcquireLock(filepath.Join(stateDir, daemon.LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lock.Release() })
+}
+
+// testDaemon answers control requests on a socket like a daemon of
+// version that last collected at lastRun.
+type testDaemon struct {
+	version string
+	lastRun time.Time
+}
+
+func (d *testDaemon) HandleCommand(cmd string, args map[string]string) (string, error) {
+	return "{}", nil
+}
+
+func (d *testDaemon) HandleControl(req daemon.ControlRequest) daemon.ControlResponse {
+	return daemon.ControlResponse{OK: true, Status: &daemon.HealthStatus{
+		PID:     os.Getpid(),
+		Version: d.version,
+		Uptime:  time.Hour,
+		Collectors: map[string]daemon.CollectorHealth{
+			"sysmetrics": {Name: "sysmetrics", Healthy: true, LastRun: d.lastRun},
+		},
+	}}
+}
+
+// serveDaemon starts a testDaemon of version on sockPath until the test
+// ends.
+func serveDaemon(t *testing.T, sockPath, version string) {
+	t.Helper()
+	srv := daemon.NewIPCServer(sockPath, &testDaemon{version: version, lastRun: time.Now().Add(-time.Minute)})
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Stop)
+}
+
 // testProfile returns a HostProfile pointing at the given temp directory
-// with a fake binary, config, cache, and socket laid out.
+// with a fake binary, config, cache and state directories, and socket laid
+// out, and the daemon lock held.
 func testProfile(t *testing.T, dir string) *HostProfile {
 	t.Helper()
 
@@ -20,17 +69,17 @@ func testProfile(t *testing.T, dir string) *HostProfile {
 	confDir := filepath.Join(dir, "config")
 	confPath := filepath.Join(confDir, "config.toml")
 	cacheDir := filepath.Join(dir, "cache")
+	stateDir := filepath.Join(dir, "state")
 	sockPath := filepath.Join(dir, "prompt-pulse.sock")
 
 	for _, d := range []string{
 		filepath.Join(dir, "bin"),
 		confDir,
 		cacheDir,
+		stateDir,
 		filepath.Join(cacheDir, "waifu"),
 		filepath.Join(cacheDir, "banner"),
-		filepath.Join(cacheDir, "sessions"),
 		filepath.Join(cacheDir, "shells"),
-		filepath.Join(cacheDir, "collectors"),
 	} {
 		if err := os.MkdirAll(d, 0o755); err != nil {
 			t.Fatal(err)
@@ -45,10 +94,8 @@ func testProfile(t *testing.T, dir string) *HostProfile {
 	if err := os.WriteFile(confPath, []byte("[general]\n"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	// Socket stub.
-	if err := os.WriteFile(sockPath, nil, 0o600); err != nil {
-		t.Fatal(err)
-	}
+	serveDaemon(t, sockPath, "1.2.3")
+	holdDaemonLock(t, stateDir)
 	// Theme file.
 	if err := os.WriteFile(filepath.Join(cacheDir, "theme.json"), []byte("{}"), 0o644); err != nil {
 		t.Fatal(err)
@@ -64,6 +111,7 @@ func testProfile(t *testing.T, dir string) *HostProfile {
 		BinaryPath:         binPath,
 		ConfigPath:         confPath,
 		CacheDir:           cacheDir,
+		StateDir:           stateDir,
 		SocketPath:         sockPath,
 	}
 }
@@ -200,6 +248,47 @@ func TestCheckConfig_Missing(t *testing.T) {
 	}
 }
 
+func TestResolveProfile(t *testing.T) {
+	for _, tt := range []struct{ name, hostname, want string }{
+		{"", "honey", "honey"},
+		{"", "Xoxd-Bates.local", "xoxd-bates"},
+		{"petting-zoo-mini", "honey", "petting-zoo-mini"},
+		{"", "unknown-host", ""},
+		{"nope", "honey", ""},
+	} {
+		p, ok := ResolveProfile(tt.name, tt.hostname)
+		if p.Name != tt.want || ok != (tt.want != "") {
+			t.Errorf("ResolveProfile(%q, %q) = %q, %v; want %q", tt.name, tt.hostname, p.Name, ok, tt.want)
+		}
+	}
+	if p, _ := ResolveProfile("honey", ""); strings.Join(p.Features, ",") != strings.Join(Honey().Features, ",") {
+		t.Errorf("honey features = %v", p.Features)
+	}
+}
+
+func TestCheckProfileDrift(t *testing.T) {
+	config.SetProfileResolver(ResolveProfile)
+	t.Cleanup(func() { config.SetProfileResolver(nil) })
+
+	dir := t.TempDir()
+	p := Honey()
+	p.ConfigPath = filepath.Join(dir, "config.toml")
+	os.WriteFile(p.ConfigPath, []byte("[general]\nhost_profile = \"honey\"\n"), 0o644)
+	if passed, msg := dpCheckProfileDrift(p).Run(); !passed {
+		t.Errorf("config following the profile: %s", msg)
+	}
+
+	os.WriteFile(p.ConfigPath, []byte("[general]\nhost_profile = \"honey\"\n[collectors.gpu]\nenabled = false\n[collectors.weather]\nenabled = true\n"), 0o644)
+	c := dpCheckProfileDrift(p)
+	passed, msg := c.Run()
+	if passed || c.Required {
+		t.Errorf("drift check passed = %v, required = %v; want a failing warning", passed, c.Required)
+	}
+	if !strings.Contains(msg, "expected but disabled: gpu") || !strings.Contains(msg, "enabled but not expected: weather") {
+		t.Errorf("message = %q, want gpu missing and weather extra", msg)
+	}
+}
+
 func TestCheckCache_Complete(t *testing.T) {
 	dir := t.TempDir()
 	p := testProfile(t, dir)
@@ -215,7 +304,7 @@ func TestCheckCache_MissingSubdir(t *testing.T) {
 	dir := t.TempDir()
 	cacheDir := filepath.Join(dir, "cache")
 	os.MkdirAll(cacheDir, 0o755)
-	// Only create waifu, skip banner and sessions.
+	// Only create waifu, skip banner.
 	os.MkdirAll(filepath.Join(cacheDir, "waifu"), 0o755)
 
 	p := &HostProfile{CacheDir: cacheDir}
@@ -229,6 +318,44 @@ func TestCheckCache_MissingSubdir(t *testing.T) {
 	}
 }
 
+func TestCheckState(t *testing.T) {
+	dir := t.TempDir()
+	p := testProfile(t, dir)
+	if passed, msg := dpCheckState(p).Run(); !passed {
+		t.Errorf("state check failed: %s", msg)
+	}
+
+	// An event log left in the cache directory was never migrated.
+	os.WriteFile(filepath.Join(p.CacheDir, "events.json"), []byte(`[]`), 0o644)
+	if passed, msg := dpCheckState(p).Run(); passed || !strings.Contains(msg, "events.json") {
+		t.Errorf("state check = %v, %q; want it failed naming the event log", passed, msg)
+	}
+
+	p.StateDir = filepath.Join(dir, "missing")
+	if passed, msg := dpCheckState(p).Run(); passed || !strings.Contains(msg, "state dir not found") {
+		t.Errorf("state check = %v, %q; want it failed without a state dir", passed, msg)
+	}
+}
+
+func TestDefaultDirsFollowXDG(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	t.Setenv("PPULSE_CONFIG", "")
+	if got := dpDefaultCacheDir(); got != "/xdg/cache/prompt-pulse" {
+		t.Errorf("cache dir = %q", got)
+	}
+	if got := dpLockPath("", ""); got != "/xdg/state/prompt-pulse/"+daemon.LockFileName {
+		t.Errorf("lock = %q, want it in the state dir", got)
+	}
+	if got := dpSocketPath("", ""); got != "/xdg/state/prompt-pulse/"+daemon.ControlSocketName {
+		t.Errorf("socket = %q, want it in the state dir", got)
+	}
+	if got := dpDefaultConfigPath(); got != "/xdg/config/prompt-pulse/config.toml" {
+		t.Errorf("config = %q", got)
+	}
+}
+
 func TestCheckShell_Exists(t *testing.T) {
 	dir := t.TempDir()
 	p := testProfile(t, dir)
@@ -278,7 +405,7 @@ func TestCheckPermissions_WorldWritable(t *testing.T) {
 	}
 }
 
-func TestCheckDaemon_SocketExists(t *testing.T) {
+func TestCheckDaemon_LockHeld(t *testing.T) {
 	dir := t.TempDir()
 	p := testProfile(t, dir)
 
@@ -287,17 +414,64 @@ func TestCheckDaemon_SocketExists(t *testing.T) {
 	if !passed {
 		t.Errorf("daemon check failed: %s", msg)
 	}
+	if !strings.Contains(msg, "version 1.2.3") || !strings.Contains(msg, "last collection 1m") {
+		t.Errorf("daemon check message = %q, want the version and last collection", msg)
+	}
 }
 
-func TestCheckDaemon_NeitherExists(t *testing.T) {
+func TestCheckDaemon_ExpectedVersion(t *testing.T) {
+	dir := t.TempDir()
+	p := testProfile(t, dir)
+
+	p.ExpectedVersion = "1.2.3"
+	if passed, msg := dpCheckDaemon(p).Run(); !passed {
+		t.Errorf("daemon check failed for the expected version: %s", msg)
+	}
+	p.ExpectedVersion = "1.3.0"
+	if passed, msg := dpCheckDaemon(p).Run(); passed || !strings.Contains(msg, "want version 1.3.0") {
+		t.Errorf("daemon check = %v, %q; want it failed for the old version", passed, msg)
+	}
+}
+
+func TestCheckDaemon_SocketNotAnswering(t *testing.T) {
+	dir := t.TempDir()
+	p := testProfile(t, dir)
+
+	// A crashed daemon's socket file stays behind while something else,
+	// here the test, holds the lock.
+	p.SocketPath = filepath.Join(dir, "stale.sock")
+	os.WriteFile(p.SocketPath, nil, 0o600)
+
+	passed, msg := dpCheckDaemon(p).Run()
+	if passed || !strings.Contains(msg, "unhealthy (stale socket)") {
+		t.Errorf("daemon check = %v, %q; want it failed with a stale socket", passed, msg)
+	}
+}
+
+func TestCheckDaemon_NotRunning(t *testing.T) {
 	p := &HostProfile{
 		SocketPath: "/nonexistent/socket",
-		PIDFile:    "/nonexistent/pid",
+		LockFile:   "/nonexistent/lock",
 	}
 	c := dpCheckDaemon(p)
 	passed, _ := c.Run()
 	if passed {
-		t.Error("daemon check should fail when no socket or pid file")
+		t.Error("daemon check should fail when no lock file")
+	}
+}
+
+func TestCheckDaemon_StaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	p := testProfile(t, dir)
+
+	// A crashed daemon's socket and lock record stay behind, but its lock
+	// does not.
+	p.LockFile = filepath.Join(dir, "stale.lock")
+	os.WriteFile(p.LockFile, []byte(`{"pid":4999999}`), 0o644)
+
+	c := dpCheckDaemon(p)
+	if passed, msg := c.Run(); passed {
+		t.Errorf("daemon check passed with only a stale socket: %s", msg)
 	}
 }
 
@@ -308,8 +482,7 @@ func TestVerify_PassesWithFullProfile(t *testing.T) {
 	p := testProfile(t, dir)
 
 	// Create collector data.
-	colDir := filepath.Join(p.CacheDir, "collectors")
-	os.WriteFile(filepath.Join(colDir, "sysmetrics.json"), []byte(`{}`), 0o644)
+	os.WriteFile(filepath.Join(p.CacheDir, "sysmetrics.json"), []byte(`{}`), 0o644)
 
 	// Create shell integration.
 	shellDir := filepath.Join(p.CacheDir, "shells")
@@ -450,6 +623,130 @@ func TestRolloutPlan_AddHostSorts(t *testing.T) {
 	}
 }
 
+// ---------- Rollout execution tests ----------
+
+// passingVerify reports every host healthy except those named in failing.
+func passingVerify(failing ...string) func(*HostProfile) (*VerifyResult, error) {
+	return func(p *HostProfile) (*VerifyResult, error) {
+		for _, name := range failing {
+			if p.Name == name {
+				return &VerifyResult{Host: p.Name, Checks: []CheckResult{{Name: "daemon", Message: "not running"}}}, nil
+			}
+		}
+		return &VerifyResult{Host: p.Name, Passed: true, Checks: []CheckResult{{Name: "daemon", Passed: true, Message: "running"}}}, nil
+	}
+}
+
+func TestExecute_SerialStopsAndRollsBack(t *testing.T) {
+	var deployed []string
+	var rolledBack []string
+	res, err := Execute(context.Background(), DefaultRolloutPlan(), ExecuteOptions{
+		Deploy: func(ctx context.Context, p *HostProfile) error {
+			deployed = append(deployed, p.Name)
+			return nil
+		},
+		Verify:          passingVerify("petting-zoo-mini"),
+		Rollback:        &RollbackConfig{BackupDir: "/backup", PreviousVersion: "v1.9.0"},
+		ExecuteRollback: true,
+		RunRollback: func(ctx context.Context, p *HostProfile, script string) error {
+			if !strings.Contains(script, "v1.9.0") {
+				t.Errorf("rollback script for %s does not restore v1.9.0", p.Name)
+			}
+			rolledBack = append(rolledBack, p.Name)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(deployed, ",") != "xoxd-bates,petting-zoo-mini" {
+		t.Errorf("deployed = %v, want to stop after petting-zoo-mini", deployed)
+	}
+	if strings.Join(rolledBack, ",") != "petting-zoo-mini" {
+		t.Errorf("rolled back = %v, want only the failed host", rolledBack)
+	}
+	if res.Passed || res.FailedHost != "petting-zoo-mini" {
+		t.Errorf("Passed = %v, FailedHost = %q", res.Passed, res.FailedHost)
+	}
+	var statuses []string
+	for _, o := range res.Hosts {
+		statuses = append(statuses, o.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "succeeded,failed,skipped" {
+		t.Errorf("statuses = %s", got)
+	}
+	failed := res.Hosts[1]
+	if failed.Stage != "verify" || !strings.Contains(failed.Error, "daemon") || !failed.RolledBack {
+		t.Errorf("failed host outcome = %+v", failed)
+	}
+
+	text := res.Report().RenderText()
+	for _, want := range []string{"Host: petting-zoo-mini [FAIL]", "[+] rollback: rolled back", "not deployed: rollout stopped at petting-zoo-mini"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestExecute_ParallelRunsCommandOnEveryHost(t *testing.T) {
+	dir := t.TempDir()
+	plan := NewRolloutPlan("parallel")
+	for i, name := range []string{"a", "b"} {
+		p := NewHostProfile(name, "linux", "x86_64")
+		p.CacheDir = dir // no version file
+		plan.AddHost(p, i)
+	}
+
+	res, err := Execute(context.Background(), plan, ExecuteOptions{
+		Command: `test "$PROMPT_PULSE_HOST" != b && touch "` + dir + `/$PROMPT_PULSE_HOST"`,
+		Verify:  passingVerify(),
+		// No previous version to find: the failure is recorded, not fatal.
+		Rollback: &RollbackConfig{BackupDir: "/backup"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); err != nil {
+		t.Error("deploy command did not run for a")
+	}
+	if res.Hosts[0].Status != HostSucceeded || res.Hosts[1].Status != HostFailed || res.Hosts[1].Stage != "deploy" {
+		t.Errorf("outcomes = %+v", res.Hosts)
+	}
+	if res.Hosts[1].RollbackError == "" || res.Hosts[1].RolledBack {
+		t.Errorf("rollback without a version should fail: %+v", res.Hosts[1])
+	}
+}
+
+func TestExecute_DryRunPrintsPlan(t *testing.T) {
+	var out strings.Builder
+	res, err := Execute(context.Background(), DefaultRolloutPlan(), ExecuteOptions{
+		Command: "exit 1",
+		DryRun:  true,
+		Out:     &out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Passed || !res.DryRun || res.Hosts[2].Status != HostPlanned {
+		t.Errorf("dry run result = %+v", res)
+	}
+	for _, want := range []string{"1. xoxd-bates", "3. honey", "deploy: exit 1", "Stops at the first host"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("plan missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestExecute_InvalidPlan(t *testing.T) {
+	if _, err := Execute(context.Background(), NewRolloutPlan("yolo"), ExecuteOptions{Command: "true"}); err == nil {
+		t.Error("invalid plan should be rejected")
+	}
+	if _, err := Execute(context.Background(), DefaultRolloutPlan(), ExecuteOptions{}); err == nil {
+		t.Error("plan without a deploy command should be rejected")
+	}
+}
+
 // ---------- Report tests ----------
 
 func TestReport_TextFormat(t *testing.T) {
@@ -696,19 +993,23 @@ func TestGenerateRollbackScript_NilConfig(t *testing.T) {
 func TestHealthCheck_AllHealthy(t *testing.T) {
 	dir := t.TempDir()
 	cacheDir := filepath.Join(dir, "cache")
-	os.MkdirAll(filepath.Join(cacheDir, "collectors"), 0o755)
+	stateDir := filepath.Join(dir, "state")
+	os.MkdirAll(cacheDir, 0o755)
+	os.MkdirAll(stateDir, 0o755)
 
 	sockPath := filepath.Join(dir, "sock")
-	os.WriteFile(sockPath, nil, 0o600)
+	serveDaemon(t, sockPath, "1.2.3")
 
 	// Fresh collector data.
 	now := time.Now()
 	colData := `{"updated_at":"` + now.Format(time.RFC3339) + `"}`
-	os.WriteFile(filepath.Join(cacheDir, "collectors", "sysmetrics.json"), []byte(colData), 0o644)
+	os.WriteFile(filepath.Join(cacheDir, "sysmetrics.json"), []byte(colData), 0o644)
+	holdDaemonLock(t, stateDir)
 
 	cfg := &HealthConfig{
 		SocketPath:    sockPath,
 		CacheDir:      cacheDir,
+		StateDir:      stateDir,
 		Collectors:    []string{"sysmetrics"},
 		StaleDuration: time.Hour,
 		Now:           func() time.Time { return now },
@@ -736,7 +1037,58 @@ func TestHealthCheck_DaemonUnhealthy(t *testing.T) {
 		t.Fatal(err)
 	}
 	if status.Healthy {
-		t.Error("should be unhealthy when daemon socket missing")
+		t.Error("should be unhealthy when daemon not running")
+	}
+}
+
+func TestHealthCheck_DaemonSocketMissing(t *testing.T) {
+	stateDir := t.TempDir()
+	holdDaemonLock(t, stateDir)
+	cfg := &HealthConfig{
+		SocketPath: "/nonexistent/sock",
+		StateDir:   stateDir,
+	}
+	if got, _ := dpCheckDaemonHealth(cfg); got.Status != "degraded" || !strings.Contains(got.Message, "PID") {
+		t.Errorf("daemon = %+v, want degraded naming the running daemon", got)
+	}
+}
+
+func TestHealthCheck_DaemonAnswers(t *testing.T) {
+	stateDir := t.TempDir()
+	holdDaemonLock(t, stateDir)
+	serveDaemon(t, filepath.Join(stateDir, daemon.ControlSocketName), "1.2.3")
+
+	got, uptime := dpCheckDaemonHealth(&HealthConfig{StateDir: stateDir})
+	if got.Status != "healthy" || got.Version != "1.2.3" || uptime != time.Hour {
+		t.Errorf("daemon = %+v, uptime %s; want healthy version 1.2.3 up 1h", got, uptime)
+	}
+}
+
+func TestHealthCheck_DaemonStaleSocket(t *testing.T) {
+	stateDir := t.TempDir()
+	holdDaemonLock(t, stateDir)
+	sockPath := filepath.Join(stateDir, daemon.ControlSocketName)
+	os.WriteFile(sockPath, nil, 0o600)
+
+	got, _ := dpCheckDaemonHealth(&HealthConfig{StateDir: stateDir})
+	if got.Status != "unhealthy" || !strings.Contains(got.Message, "stale socket") {
+		t.Errorf("daemon = %+v, want unhealthy with a stale socket", got)
+	}
+}
+
+func TestHealthCheck_StateNotMigrated(t *testing.T) {
+	cacheDir, stateDir := t.TempDir(), t.TempDir()
+	cfg := &HealthConfig{CacheDir: cacheDir, StateDir: stateDir}
+	if got := dpCheckStateHealth(cfg); got.Status != "healthy" {
+		t.Errorf("state = %+v, want healthy", got)
+	}
+	os.WriteFile(filepath.Join(cacheDir, "sysmetrics-history.json"), []byte(`{}`), 0o644)
+	if got := dpCheckStateHealth(cfg); got.Status != "degraded" || !strings.Contains(got.Message, "not migrated") {
+		t.Errorf("state = %+v, want degraded until migrated", got)
+	}
+	cfg.StateDir = filepath.Join(stateDir, "missing")
+	if got := dpCheckStateHealth(cfg); got.Status != "unhealthy" {
+		t.Errorf("state = %+v, want unhealthy without a state dir", got)
 	}
 }
 
@@ -781,7 +1133,7 @@ func TestHealthCheck_CacheStale(t *testing.T) {
 func TestHealthCheck_CollectorOutdated(t *testing.T) {
 	dir := t.TempDir()
 	cacheDir := filepath.Join(dir, "cache")
-	os.MkdirAll(filepath.Join(cacheDir, "collectors"), 0o755)
+	os.MkdirAll(cacheDir, 0o755)
 
 	sockPath := filepath.Join(dir, "sock")
 	os.WriteFile(sockPath, nil, 0o600)
@@ -790,7 +1142,7 @@ func TestHealthCheck_CollectorOutdated(t *testing.T) {
 	now := time.Now()
 	staleTime := now.Add(-48 * time.Hour)
 	colData := `{"updated_at":"` + staleTime.Format(time.RFC3339) + `"}`
-	os.WriteFile(filepath.Join(cacheDir, "collectors", "gpu.json"), []byte(colData), 0o644)
+	os.WriteFile(filepath.Join(cacheDir, "gpu.json"), []byte(colData), 0o644)
 
 	cfg := &HealthConfig{
 		SocketPath:    sockPath,
@@ -931,8 +1283,7 @@ func TestEndToEnd_VerifyAndReport(t *testing.T) {
 	p := testProfile(t, dir)
 
 	// Set up complete environment.
-	colDir := filepath.Join(p.CacheDir, "collectors")
-	os.WriteFile(filepath.Join(colDir, "sysmetrics.json"), []byte(`{}`), 0o644)
+	os.WriteFile(filepath.Join(p.CacheDir, "sysmetrics.json"), []byte(`{}`), 0o644)
 	shellDir := filepath.Join(p.CacheDir, "shells")
 	os.WriteFile(filepath.Join(shellDir, "bash.sh"), []byte("# ok"), 0o644)
 
@@ -0,0 +1,374 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Host outcome statuses in a RolloutResult.
+const (
+	HostPlanned   = "planned"   // dry run: would be deployed
+	HostSucceeded = "succeeded" // deployed and verified
+	HostFailed    = "failed"    // deploy or verification failed
+	HostSkipped   = "skipped"   // not reached: an earlier host failed
+)
+
+// ExecuteOptions controls how Execute deploys each host.
+type ExecuteOptions struct {
+	// Command is the shell command that deploys one host, run with
+	// "sh -c". The host name is in $PROMPT_PULSE_HOST.
+	Command string
+
+	// Deploy, when set, deploys one host instead of Command.
+	Deploy func(ctx context.Context, profile *HostProfile) error
+
+	// Verify checks a host after it is deployed. Nil runs the host's
+	// PostChecks.
+	Verify func(profile *HostProfile) (*VerifyResult, error)
+
+	// Rollback is the template for the rollback script generated for a
+	// failed host; Host is filled in per host, and an empty
+	// PreviousVersion is read from the host's cache directory. Nil
+	// generates no rollback script.
+	Rollback *RollbackConfig
+
+	// ExecuteRollback runs the generated rollback script for a failed
+	// host instead of only recording it.
+	ExecuteRollback bool
+
+	// RunRollback runs a rollback script. Nil runs it with bash.
+	RunRollback func(ctx context.Context, profile *HostProfile, script string) error
+
+	// DryRun prints the plan to Out without deploying anything. The
+	// plan's own DryRun field has the same effect.
+	DryRun bool
+
+	// Out receives the dry-run plan. Nil uses os.Stdout.
+	Out io.Writer
+}
+
+// RolloutResult summarizes an executed (or dry-run) rollout.
+type RolloutResult struct {
+	// Strategy is the plan's strategy.
+	Strategy string
+
+	// DryRun is true when nothing was deployed.
+	DryRun bool
+
+	// Passed is true when every host deployed and verified.
+	Passed bool
+
+	// FailedHost names the first host that failed, if any.
+	FailedHost string
+
+	// Hosts lists the outcome for each host in plan order.
+	Hosts []HostOutcome
+
+	// Duration is the wall-clock time of the whole rollout.
+	Duration time.Duration
+}
+
+// HostOutcome records what happened to one host during a rollout.
+type HostOutcome struct {
+	// Host is the hostname.
+	Host string
+
+	// Status is HostPlanned, HostSucceeded, HostFailed, or HostSkipped.
+	Status string
+
+	// Stage is "deploy" or "verify" for a failed host.
+	Stage string
+
+	// Error describes the failure.
+	Error string
+
+	// Verify is the verification result, when verification ran.
+	Verify *VerifyResult
+
+	// DeployDuration and VerifyDuration time each stage.
+	DeployDuration time.Duration
+	VerifyDuration time.Duration
+
+	// RollbackScript is the script generated for a failed host.
+	RollbackScript string
+
+	// RolledBack is true when the rollback script ran successfully.
+	RolledBack bool
+
+	// RollbackError explains why no script was generated, or why it
+	// failed to run.
+	RollbackError string
+}
+
+// Execute runs plan: each host is deployed, then verified. With the serial
+// strategy hosts go one at a time in order and the rollout stops at the
+// first failure; with the parallel strategy all hosts deploy at once. Each
+// failed host gets a rollback script, which is run when
+// opts.ExecuteRollback is set. An invalid plan is an error; host failures
+// are reported in the result.
+func Execute(ctx context.Context, plan *RolloutPlan, opts ExecuteOptions) (*RolloutResult, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("deploy: nil rollout plan")
+	}
+	if problems := plan.Validate(); len(problems) > 0 {
+		return nil, fmt.Errorf("deploy: invalid rollout plan: %s", strings.Join(problems, "; "))
+	}
+	if opts.Command == "" && opts.Deploy == nil && !opts.DryRun && !plan.DryRun {
+		return nil, fmt.Errorf("deploy: no deploy command or callback")
+	}
+
+	start := time.Now()
+	result := &RolloutResult{
+		Strategy: plan.Strategy,
+		DryRun:   opts.DryRun || plan.DryRun,
+		Hosts:    make([]HostOutcome, len(plan.Hosts)),
+	}
+	for i, h := range plan.Hosts {
+		result.Hosts[i] = HostOutcome{Host: h.Profile.Name, Status: HostSkipped}
+	}
+
+	if result.DryRun {
+		out := opts.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		dpWritePlan(out, plan, opts)
+		for i := range result.Hosts {
+			result.Hosts[i].Status = HostPlanned
+		}
+		result.Passed = true
+		return result, nil
+	}
+
+	if plan.Strategy == "parallel" {
+		var wg sync.WaitGroup
+		for i, h := range plan.Hosts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result.Hosts[i] = dpRolloutHost(ctx, h, opts)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i, h := range plan.Hosts {
+			result.Hosts[i] = dpRolloutHost(ctx, h, opts)
+			if result.Hosts[i].Status == HostFailed {
+				break
+			}
+		}
+	}
+
+	result.Passed = true
+	for _, o := range result.Hosts {
+		if o.Status == HostSucceeded {
+			continue
+		}
+		result.Passed = false
+		if o.Status == HostFailed && result.FailedHost == "" {
+			result.FailedHost = o.Host
+		}
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// dpRolloutHost deploys and verifies one host, rolling it back on failure.
+func dpRolloutHost(ctx context.Context, h HostRollout, opts ExecuteOptions) HostOutcome {
+	o := HostOutcome{Host: h.Profile.Name}
+
+	start := time.Now()
+	err := dpDeployHost(ctx, h.Profile, opts)
+	o.DeployDuration = time.Since(start)
+	if err != nil {
+		o.Stage = "deploy"
+		o.Error = err.Error()
+	} else {
+		start = time.Now()
+		vr, err := dpVerifyHost(h, opts)
+		o.VerifyDuration = time.Since(start)
+		o.Verify = vr
+		switch {
+		case err != nil:
+			o.Stage, o.Error = "verify", err.Error()
+		case !vr.Passed:
+			o.Stage, o.Error = "verify", "failed checks: "+strings.Join(dpFailedChecks(vr), ", ")
+		}
+	}
+
+	if o.Error == "" {
+		o.Status = HostSucceeded
+		return o
+	}
+	o.Status = HostFailed
+	dpRollbackHost(ctx, h.Profile, opts, &o)
+	return o
+}
+
+// dpDeployHost runs the deploy callback or command for one host.
+func dpDeployHost(ctx context.Context, profile *HostProfile, opts ExecuteOptions) error {
+	if opts.Deploy != nil {
+		return opts.Deploy(ctx, profile)
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", opts.Command)
+	cmd.Env = append(os.Environ(), "PROMPT_PULSE_HOST="+profile.Name)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("deploy: %w: %s", err, msg)
+		}
+		return fmt.Errorf("deploy: %w", err)
+	}
+	return nil
+}
+
+// dpVerifyHost verifies one host with the Verify option or its PostChecks.
+func dpVerifyHost(h HostRollout, opts ExecuteOptions) (*VerifyResult, error) {
+	if opts.Verify != nil {
+		vr, err := opts.Verify(h.Profile)
+		if err == nil && vr == nil {
+			err = fmt.Errorf("deploy: verify returned no result")
+		}
+		return vr, err
+	}
+	return dpRunChecks(h.Profile.Name, h.PostChecks), nil
+}
+
+// dpFailedChecks names the checks that failed in vr.
+func dpFailedChecks(vr *VerifyResult) []string {
+	var names []string
+	for _, c := range vr.Checks {
+		if !c.Passed {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// dpRollbackHost generates the rollback script for a failed host and runs
+// it when requested, recording the outcome in o.
+func dpRollbackHost(ctx context.Context, profile *HostProfile, opts ExecuteOptions, o *HostOutcome) {
+	if opts.Rollback == nil {
+		return
+	}
+	cfg := *opts.Rollback
+	cfg.Host = profile.Name
+	if cfg.PreviousVersion == "" {
+		cacheDir := profile.CacheDir
+		if cacheDir == "" {
+			cacheDir = dpDefaultCacheDir()
+		}
+		if v, err := dpDetectPreviousVersion(cacheDir); err == nil {
+			cfg.PreviousVersion = v
+		}
+	}
+	script, err := dpGenerateRollbackScript(&cfg)
+	if err != nil {
+		o.RollbackError = err.Error()
+		return
+	}
+	o.RollbackScript = script
+	if !opts.ExecuteRollback {
+		return
+	}
+
+	run := opts.RunRollback
+	if run == nil {
+		run = dpRunRollbackScript
+	}
+	if err := run(ctx, profile, script); err != nil {
+		o.RollbackError = err.Error()
+		return
+	}
+	o.RolledBack = true
+}
+
+// dpRunRollbackScript runs script locally with bash.
+func dpRunRollbackScript(ctx context.Context, profile *HostProfile, script string) error {
+	cmd := exec.CommandContext(ctx, "bash", "-s")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("deploy: rollback %s: %w: %s", profile.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// dpWritePlan prints what Execute would do for plan.
+func dpWritePlan(w io.Writer, plan *RolloutPlan, opts ExecuteOptions) {
+	fmt.Fprintf(w, "Rollout plan (%s, dry run)\n", plan.Strategy)
+	for i, h := range plan.Hosts {
+		fmt.Fprintf(w, "  %d. %s (%s/%s)\n", i+1, h.Profile.Name, h.Profile.OS, h.Profile.Arch)
+		switch {
+		case opts.Deploy != nil:
+			fmt.Fprintf(w, "     deploy: callback\n")
+		case opts.Command != "":
+			fmt.Fprintf(w, "     deploy: %s\n", opts.Command)
+		}
+		if opts.Verify != nil {
+			fmt.Fprintf(w, "     verify: callback\n")
+		} else {
+			fmt.Fprintf(w, "     verify: %d checks\n", len(h.PostChecks))
+		}
+	}
+	if plan.Strategy == "serial" {
+		fmt.Fprintln(w, "Stops at the first host that fails verification.")
+	}
+	if opts.Rollback != nil {
+		action := "generated"
+		if opts.ExecuteRollback {
+			action = "generated and run"
+		}
+		fmt.Fprintf(w, "A rollback script is %s for each failed host.\n", action)
+	}
+}
+
+// Report converts the rollout into a DeployReport for the text, Markdown,
+// and JSON renderers. Each host gets a "deploy" check ahead of its
+// verification checks, and a "rollback" check when it was rolled back.
+func (r *RolloutResult) Report() *DeployReport {
+	results := make([]VerifyResult, 0, len(r.Hosts))
+	for _, o := range r.Hosts {
+		vr := VerifyResult{Host: o.Host, Passed: o.Status == HostSucceeded || o.Status == HostPlanned}
+		if o.Verify != nil {
+			vr.Timestamp = o.Verify.Timestamp
+		}
+
+		deploy := CheckResult{Name: "deploy", Passed: true, Message: "deployed", Duration: o.DeployDuration}
+		switch {
+		case o.Status == HostPlanned:
+			deploy.Message = "planned (dry run)"
+		case o.Status == HostSkipped:
+			deploy.Passed = false
+			deploy.Message = "not deployed: rollout stopped at " + r.FailedHost
+		case o.Stage == "deploy":
+			deploy.Passed = false
+			deploy.Message = o.Error
+		}
+		vr.Checks = append(vr.Checks, deploy)
+		if o.Verify != nil {
+			vr.Checks = append(vr.Checks, o.Verify.Checks...)
+		} else if o.Stage == "verify" {
+			vr.Checks = append(vr.Checks, CheckResult{Name: "verify", Message: o.Error, Duration: o.VerifyDuration})
+		}
+
+		if o.RollbackScript != "" || o.RollbackError != "" {
+			rb := CheckResult{Name: "rollback", Passed: o.RollbackError == ""}
+			switch {
+			case o.RollbackError != "":
+				rb.Message = o.RollbackError
+			case o.RolledBack:
+				rb.Message = "rolled back"
+			default:
+				rb.Message = "rollback script generated"
+			}
+			vr.Checks = append(vr.Checks, rb)
+		}
+		results = append(results, vr)
+	}
+	return NewReport(results...)
+}
@@ -33,16 +33,26 @@ type ComponentHealth struct {
 
 	// LastCheck records when this component was last checked.
 	LastCheck time.Time
+
+	// Version is the version the daemon reported, for the daemon
+	// component.
+	Version string
 }
 
 // HealthConfig holds paths and thresholds for health checks.
 type HealthConfig struct {
-	// SocketPath is the daemon socket location.
+	// SocketPath is the daemon socket location. Default: in StateDir.
 	SocketPath string
 
+	// LockFile is the daemon instance lock location. Default: in StateDir.
+	LockFile string
+
 	// CacheDir is the cache directory.
 	CacheDir string
 
+	// StateDir is the state directory.
+	StateDir string
+
 	// Collectors lists collector names to check.
 	Collectors []string
 
@@ -70,9 +80,11 @@ func dpCheckHealth(cfg *HealthConfig) (*HealthStatus, error) {
 		return nil, fmt.Errorf("deploy: nil health config")
 	}
 
+	daemonHealth, uptime := dpCheckDaemonHealth(cfg)
 	components := []ComponentHealth{
-		dpCheckDaemonHealth(cfg),
+		daemonHealth,
 		dpCheckCacheHealth(cfg),
+		dpCheckStateHealth(cfg),
 	}
 
 	for _, col := range cfg.Collectors {
@@ -92,31 +104,26 @@ func dpCheckHealth(cfg *HealthConfig) (*HealthStatus, error) {
 	return &HealthStatus{
 		Healthy:    healthy,
 		Components: components,
+		Uptime:     uptime,
 	}, nil
 }
 
-// dpCheckDaemonHealth checks whether the daemon socket exists.
-func dpCheckDaemonHealth(cfg *HealthConfig) ComponentHealth {
-	sock := cfg.SocketPath
-	if sock == "" {
-		sock = dpDefaultSocketPath()
-	}
-
+// dpCheckDaemonHealth probes the daemon with dpProbeDaemon and returns
+// its component health and uptime.
+func dpCheckDaemonHealth(cfg *HealthConfig) (ComponentHealth, time.Duration) {
 	now := cfg.now()
-	if _, err := os.Stat(sock); err != nil {
-		return ComponentHealth{
-			Name:      "daemon",
-			Status:    "unhealthy",
-			Message:   fmt.Sprintf("socket not found: %s", sock),
-			LastCheck: now,
-		}
-	}
+	probe := dpProbeDaemon(
+		dpSocketPath(cfg.SocketPath, cfg.StateDir),
+		dpLockPath(cfg.LockFile, cfg.StateDir),
+		now,
+	)
 	return ComponentHealth{
 		Name:      "daemon",
-		Status:    "healthy",
-		Message:   "daemon socket present",
+		Status:    probe.status,
+		Message:   probe.message,
 		LastCheck: now,
-	}
+		Version:   probe.version,
+	}, probe.uptime
 }
 
 // dpCheckCacheHealth verifies the cache directory is readable and not stale.
@@ -160,6 +167,38 @@ func dpCheckCacheHealth(cfg *HealthConfig) ComponentHealth {
 	}
 }
 
+// dpCheckStateHealth verifies the state directory exists, and reports the
+// deployment degraded while daemon state is still in the cache directory.
+func dpCheckStateHealth(cfg *HealthConfig) ComponentHealth {
+	now := cfg.now()
+	dir := cfg.StateDir
+	if dir == "" {
+		dir = dpDefaultStateDir()
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return ComponentHealth{
+			Name:      "state",
+			Status:    "unhealthy",
+			Message:   fmt.Sprintf("state dir not found: %s", dir),
+			LastCheck: now,
+		}
+	}
+	if ok, msg := dpStateStatus(cfg.CacheDir, dir); !ok {
+		return ComponentHealth{
+			Name:      "state",
+			Status:    "degraded",
+			Message:   msg,
+			LastCheck: now,
+		}
+	}
+	return ComponentHealth{
+		Name:      "state",
+		Status:    "healthy",
+		Message:   "state ok",
+		LastCheck: now,
+	}
+}
+
 // collectorMeta is the expected JSON structure of a collector data file.
 type collectorMeta struct {
 	UpdatedAt string `json:"updated_at"`
@@ -174,7 +213,7 @@ func dpCheckCollectorHealth(cfg *HealthConfig, name string) ComponentHealth {
 	}
 
 	now := cfg.now()
-	dataFile := filepath.Join(dir, "collectors", name+".json")
+	dataFile := filepath.Join(dir, name+".json")
 
 	data, err := os.ReadFile(dataFile)
 	if err != nil {
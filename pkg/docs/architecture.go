@@ -156,9 +156,9 @@ func dcAllPackages() []PackageInfo {
 		{
 			Name:          "theme",
 			Path:          "pkg/theme",
-			Description:   "Named color themes with 6 built-in palettes: default, gruvbox, nord, catppuccin, dracula, tokyo-night.",
+			Description:   "Named color themes with 7 built-in palettes: default, gruvbox, nord, catppuccin, dracula, tokyo-night, solarized-light. Selection picks a light or dark variant from the terminal background, with per-terminal overrides.",
 			Dependencies:  nil,
-			ExportedTypes: []string{"Theme", "Palette", "Colors"},
+			ExportedTypes: []string{"Theme", "Palette", "Colors", "Selection"},
 		},
 
 		// Data layer
@@ -186,7 +186,7 @@ func dcAllPackages() []PackageInfo {
 		{
 			Name:          "collectors/billing",
 			Path:          "pkg/collectors/billing",
-			Description:   "Cloud billing collector: Civo and DigitalOcean spend tracking.",
+			Description:   "Cloud billing collector: Civo, DigitalOcean, Hetzner, and Vultr spend tracking.",
 			Dependencies:  []string{"data"},
 			ExportedTypes: []string{"Collector", "BillingSummary", "ProviderCost"},
 		},
@@ -347,9 +347,9 @@ func dcAllPackages() []PackageInfo {
 		{
 			Name:          "migrate",
 			Path:          "pkg/migrate",
-			Description:   "v1-to-v2 config migration: parse flat format, transform to nested TOML, backup originals.",
+			Description:   "v1-to-v2 config migration: parse flat format, transform to nested TOML, backup originals. Moves daemon state from the cache to the state directory.",
 			Dependencies:  nil,
-			ExportedTypes: []string{"Migrator", "V1Config", "MigrationResult"},
+			ExportedTypes: []string{"Migrator", "V1Config", "MigrationResult", "LayoutResult"},
 		},
 		{
 			Name:          "docs",
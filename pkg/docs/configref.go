@@ -49,16 +49,41 @@ func dcGenerateConfigRef() *ConfigRef {
 	return &ConfigRef{
 		Sections: []ConfigSection{
 			dcGeneralSection(),
+			dcLogSection(),
+			dcCacheSection(),
 			dcLayoutSection(),
 			dcCollectorsSysMetricsSection(),
+			dcCollectorsGPUSection(),
+			dcCollectorsStorageSection(),
 			dcCollectorsTailscaleSection(),
 			dcCollectorsK8sSection(),
 			dcCollectorsClaudeSection(),
+			dcCollectorsOllamaSection(),
 			dcCollectorsBillingSection(),
+			dcCollectorsUptimeKumaSection(),
+			dcCollectorsDockerSection(),
+			dcCollectorsWeatherSection(),
+			dcCollectorsChecksSection(),
+			dcCollectorsRemoteSection(),
+			dcCollectorsShellSection(),
+			dcHTTPSection(),
 			dcImageSection(),
 			dcThemeSection(),
+			dcDisplaySection(),
 			dcShellSection(),
+			dcStarshipSection(),
+			dcStarshipSummarySection(),
+			dcStarshipThresholdSection("claude", "the fullest Claude usage window (five-hour utilization, or window tokens against `window_limit`)"),
+			dcStarshipThresholdSection("billing", "monthly cloud spend as a percentage of the budget, or of $100 without one"),
+			dcStarshipThresholdSection("system", "the higher of CPU and RAM usage"),
 			dcBannerSection(),
+			dcBannerFastfetchSection(),
+			dcTUISection(),
+			dcTUIKeysSection(),
+			dcNotificationsSection(),
+			dcStatusPageSection(),
+			dcEventsSection(),
+			dcValidationSection(),
 		},
 	}
 }
@@ -69,7 +94,9 @@ func dcRenderConfigMarkdown(ref *ConfigRef) string {
 
 	b.WriteString("# Configuration Reference\n\n")
 	b.WriteString("prompt-pulse v2 uses TOML configuration.\n\n")
-	b.WriteString("Config file location: `$XDG_CONFIG_HOME/prompt-pulse/config.toml`\n\n")
+	b.WriteString("Config file location: `$XDG_CONFIG_HOME/prompt-pulse/config.toml`, or `$PPULSE_CONFIG` when set\n\n")
+	b.WriteString("String values may reference environment variables as `${VAR}` or `${VAR:-default}`; ")
+	b.WriteString("write `$$` for a literal `$`. A variable that is unset and has no default is a config error.\n\n")
 
 	for _, s := range ref.Sections {
 		b.WriteString(fmt.Sprintf("## `[%s]`\n\n", s.Name))
@@ -116,16 +143,30 @@ func dcGeneralSection() ConfigSection {
 				Name:        "log_level",
 				Type:        "string",
 				Default:     "info",
-				Description: "Logging verbosity: debug, info, warn, error",
+				Description: "Daemon logging verbosity: debug, info, warn, error. Successful collector runs are logged at debug",
 				Example:     `log_level = "info"`,
 			},
 			{
 				Name:        "cache_dir",
 				Type:        "string",
 				Default:     "$XDG_CACHE_HOME/prompt-pulse",
-				Description: "Override the default cache directory path",
+				Description: "Directory for collector data and other files that can be rebuilt (env: PPULSE_CACHE_DIR)",
 				Example:     `cache_dir = "/tmp/ppulse-cache"`,
 			},
+			{
+				Name:        "state_dir",
+				Type:        "string",
+				Default:     "$XDG_STATE_HOME/prompt-pulse",
+				Description: "Directory for the daemon's instance lock, control socket, event log, and metric and spend history (env: PPULSE_STATE_DIR). Files left in cache_dir by earlier versions are moved here when the daemon starts",
+				Example:     `state_dir = "/tmp/ppulse-state"`,
+			},
+			{
+				Name:        "host_profile",
+				Type:        "string",
+				Default:     `""`,
+				Description: "Deploy host profile whose features (tailscale, k8s, claude, billing, sysmetrics, gpu, waifu, ...) are the default set of enabled collectors and image.waifu_enabled; collectors it leaves out are disabled. Any of them set in this file wins. Empty uses the profile named like the hostname, if any; \"none\" uses none (env: PPULSE_HOST_PROFILE). prompt-pulse -list-collectors shows the profile applied",
+				Example:     `host_profile = "honey"`,
+			},
 			{
 				Name:        "daemon_poll_interval",
 				Type:        "duration",
@@ -133,6 +174,34 @@ func dcGeneralSection() ConfigSection {
 				Description: "Base polling interval for the background daemon",
 				Example:     `daemon_poll_interval = "15m"`,
 			},
+			{
+				Name:        "collect_timeout",
+				Type:        "duration",
+				Default:     "30s",
+				Description: "Longest a single collector may run in the daemon; one still running is abandoned, marked timed out, and its cached data shown as stale",
+				Example:     `collect_timeout = "30s"`,
+			},
+			{
+				Name:        "shutdown_grace",
+				Type:        "duration",
+				Default:     "10s",
+				Description: "How long a stopping daemon lets collector runs in flight finish and write their data before cancelling them (0 cancels at once)",
+				Example:     `shutdown_grace = "10s"`,
+			},
+			{
+				Name:        "stale_after_polls",
+				Type:        "float",
+				Default:     "2",
+				Description: "Mark cached data as old once it is this many daemon_poll_intervals old: \"(3h old)\" in the banner, ⟳ in the prompt, and its age in the TUI status bar (0 disables)",
+				Example:     `stale_after_polls = 2`,
+			},
+			{
+				Name:        "expire_after",
+				Type:        "duration",
+				Default:     "24h",
+				Description: "Show no data rather than cached data older than this (0 shows data of any age)",
+				Example:     `expire_after = "24h"`,
+			},
 			{
 				Name:        "data_retention",
 				Type:        "duration",
@@ -140,6 +209,117 @@ func dcGeneralSection() ConfigSection {
 				Description: "How long time-series data is retained in memory",
 				Example:     `data_retention = "10m"`,
 			},
+			{
+				Name:        "tui_refresh_interval",
+				Type:        "duration",
+				Default:     "5s",
+				Description: "How often the TUI reloads cached collector data (r refreshes immediately)",
+				Example:     `tui_refresh_interval = "5s"`,
+			},
+		},
+	}
+}
+
+func dcLogSection() ConfigSection {
+	return ConfigSection{
+		Name:        "log",
+		Description: "Daemon log output. Lines about collector runs carry `collector`, `cache_key`, and `duration_ms` fields, and `error` when the run failed. The log file is rotated by the daemon itself, so no logrotate is needed: the current file is renamed to file.1, older copies shift up, and a new file is started without losing lines written meanwhile. API keys and tokens from the config and environment, and values of fields or URL query parameters named like `token`, `secret`, `password`, or `api_key`, are masked as `[REDACTED]`.",
+		Fields: []ConfigField{
+			{
+				Name:        "format",
+				Type:        "string",
+				Default:     "text",
+				Description: "Log line format: text (key=value) or json (one object per line, for jq). The `-log-format` flag overrides it",
+				Example:     `format = "json"`,
+			},
+			{
+				Name:        "file",
+				Type:        "string",
+				Default:     `""`,
+				Description: "File the daemon writes its log to. Empty logs to stderr, where a service manager may capture it",
+				Example:     `file = "${HOME}/.cache/prompt-pulse/daemon.log"`,
+			},
+			{
+				Name:        "max_size_mb",
+				Type:        "int",
+				Default:     "10",
+				Description: "Rotate the log file before it grows past this many megabytes (0 disables)",
+				Example:     `max_size_mb = 10`,
+			},
+			{
+				Name:        "max_age",
+				Type:        "duration",
+				Default:     "168h",
+				Description: "Rotate the log file once the daemon has been writing to it this long (0 disables)",
+				Example:     `max_age = "168h"`,
+			},
+			{
+				Name:        "max_files",
+				Type:        "int",
+				Default:     "5",
+				Description: "Rotated log files to keep, as file.1 (newest) through file.N",
+				Example:     `max_files = 5`,
+			},
+		},
+	}
+}
+
+func dcCacheSection() ConfigSection {
+	return ConfigSection{
+		Name:        "cache",
+		Description: "At-rest encryption of cached collector data. Listed entries are encrypted with AES-GCM; entries written before encryption was enabled still read and are encrypted when next written. An entry encrypted with a different key is reported as an error, not shown.",
+		Fields: []ConfigField{
+			{
+				Name:        "encrypt",
+				Type:        "[]string",
+				Default:     "[]",
+				Description: "Cache keys to encrypt, such as claude and billing",
+				Example:     `encrypt = ["claude", "billing"]`,
+			},
+			{
+				Name:        "key_file",
+				Type:        "string",
+				Default:     "",
+				Description: "File holding the encryption secret (at least 16 bytes; surrounding whitespace ignored), e.g. a sops-nix secret. Empty keeps a generated secret in the keyring (macOS Keychain or secret-tool)",
+				Example:     `key_file = "/run/secrets/prompt-pulse-cache-key"`,
+			},
+		},
+	}
+}
+
+func dcHTTPSection() ConfigSection {
+	return ConfigSection{
+		Name:        "http",
+		Description: "The HTTP client collectors share. Idempotent requests failing with a network error, 429, or 5xx status are retried after an exponential backoff with jitter, or after the server's Retry-After. Each collector's request count, retries, and last status appear in `prompt-pulse -diagnose`.",
+		Fields: []ConfigField{
+			{
+				Name:        "max_retries",
+				Type:        "int",
+				Default:     "3",
+				Description: "Retries of a failed request; 0 disables retrying",
+				Example:     "max_retries = 5",
+			},
+			{
+				Name:        "retry_base_delay",
+				Type:        "duration",
+				Default:     "500ms",
+				Description: "Delay before the first retry, doubled for each one after it",
+				Example:     `retry_base_delay = "1s"`,
+			},
+			{
+				Name:        "max_retry_delay",
+				Type:        "duration",
+				Default:     "30s",
+				Description: "Longest delay between attempts. A Retry-After asking for longer fails the request instead",
+				Example:     `max_retry_delay = "1m"`,
+			},
+			{
+				Name:        "rate_limits",
+				Type:        "table",
+				Default:     "{}",
+				Description: "Requests per second allowed to each API host. Hosts not listed are not limited",
+				Example:     "[http.rate_limits]\n\"api.github.com\" = 1\n\"api.anthropic.com\" = 2",
+			},
 		},
 	}
 }
@@ -156,6 +336,13 @@ func dcLayoutSection() ConfigSection {
 				Description: "Built-in layout preset: dashboard, minimal, ops, billing",
 				Example:     `preset = "dashboard"`,
 			},
+			{
+				Name:        "row",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Custom layout rows, replacing the preset: ratio (relative height, default 1) and child tables, each with type (waifu, claude, billing, tailscale, uptimekuma, docker, k8s, sysmetrics), ratio (relative width), and nested child tables for sub-rows",
+				Example:     "[[layout.row]]\nratio = 2\n\n[[layout.row.child]]\ntype = \"claude\"",
+			},
 		},
 	}
 }
@@ -163,7 +350,7 @@ func dcLayoutSection() ConfigSection {
 func dcCollectorsSysMetricsSection() ConfigSection {
 	return ConfigSection{
 		Name:        "collectors.sysmetrics",
-		Description: "System metrics collection: CPU, memory, disk, GPU, and network.",
+		Description: "System metrics collection: CPU, memory, disk, and network.",
 		Fields: []ConfigField{
 			{
 				Name:        "enabled",
@@ -179,6 +366,118 @@ func dcCollectorsSysMetricsSection() ConfigSection {
 				Description: "Collection interval for system metrics",
 				Example:     `interval = "1s"`,
 			},
+			{
+				Name:        "history_retention",
+				Type:        "duration",
+				Default:     "24h",
+				Description: "How far back the daemon keeps CPU, memory, load, and network samples for the TUI's last-hour graphs, saved in the data directory; 0 keeps none",
+				Example:     `history_retention = "24h"`,
+			},
+			{
+				Name:        "history_interval",
+				Type:        "duration",
+				Default:     "15s",
+				Description: "Least time between history samples; collections in between are not recorded",
+				Example:     `history_interval = "15s"`,
+			},
+		},
+	}
+}
+
+func dcCollectorsGPUSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.gpu",
+		Description: "GPU utilization, memory, temperature, and power: NVIDIA via nvidia-smi on Linux, Apple Silicon via ioreg on macOS. Machines without a supported GPU report it as not available.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable GPU metrics collection",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "15s",
+				Description: "Collection interval for GPU metrics",
+				Example:     `interval = "15s"`,
+			},
+			{
+				Name:        "nvidia_smi",
+				Type:        "string",
+				Description: "nvidia-smi binary on Linux; empty looks it up in PATH",
+				Example:     `nvidia_smi = "/run/current-system/sw/bin/nvidia-smi"`,
+			},
+		},
+	}
+}
+
+func dcCollectorsStorageSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.storage",
+		Description: "Disk usage per mountpoint, marked warn or critical by threshold, and optional SMART health via smartctl. Mounts over a threshold turn the starship infra segment yellow or red.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable storage collection",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "60s",
+				Description: "Collection interval for storage usage",
+				Example:     `interval = "60s"`,
+			},
+			{
+				Name:        "ignore_fstypes",
+				Type:        "[]string",
+				Description: "Filesystem types to skip; empty skips pseudo filesystems such as proc, sysfs, tmpfs, overlay, and squashfs",
+				Example:     `ignore_fstypes = ["tmpfs", "overlay", "squashfs"]`,
+			},
+			{
+				Name:        "ignore_mounts",
+				Type:        "[]string",
+				Description: "Mountpoint glob patterns to skip; a pattern matching a directory also skips the mounts beneath it",
+				Example:     `ignore_mounts = ["/snap/*", "/var/lib/docker"]`,
+			},
+			{
+				Name:        "mount_timeout",
+				Type:        "duration",
+				Default:     "2s",
+				Description: "How long to wait for one mountpoint; slower mounts, such as a hung NFS share, are reported as \"timeout\"",
+				Example:     `mount_timeout = "2s"`,
+			},
+			{
+				Name:        "warn_percent",
+				Type:        "float",
+				Default:     "85",
+				Description: "Used-space percentage at which a mount becomes \"warn\"",
+				Example:     `warn_percent = 85`,
+			},
+			{
+				Name:        "critical_percent",
+				Type:        "float",
+				Default:     "95",
+				Description: "Used-space percentage at which a mount becomes \"critical\"",
+				Example:     `critical_percent = 95`,
+			},
+			{
+				Name:        "smart",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Read overall SMART health and reallocated sectors with smartctl, when installed; opening drives usually needs root",
+				Example:     `smart = true`,
+			},
+			{
+				Name:        "smartctl",
+				Type:        "string",
+				Description: "smartctl binary; empty looks it up in PATH",
+				Example:     `smartctl = "/usr/sbin/smartctl"`,
+			},
 		},
 	}
 }
@@ -186,7 +485,7 @@ func dcCollectorsSysMetricsSection() ConfigSection {
 func dcCollectorsTailscaleSection() ConfigSection {
 	return ConfigSection{
 		Name:        "collectors.tailscale",
-		Description: "Tailscale mesh network status: peer list, exit nodes, connection health.",
+		Description: "Tailscale mesh network status: peer list, exit nodes, key expiry, connection health.",
 		Fields: []ConfigField{
 			{
 				Name:        "enabled",
@@ -202,6 +501,25 @@ func dcCollectorsTailscaleSection() ConfigSection {
 				Description: "Collection interval for Tailscale status",
 				Example:     `interval = "30s"`,
 			},
+			{
+				Name:        "socket_path",
+				Type:        "string",
+				Description: "tailscaled LocalAPI socket path (empty uses the platform default)",
+				Example:     `socket_path = "/var/run/tailscale/tailscaled.sock"`,
+			},
+			{
+				Name:        "cli_path",
+				Type:        "string",
+				Description: "Collect via `tailscale status --json` with this binary instead of the LocalAPI socket",
+				Example:     `cli_path = "tailscale"`,
+			},
+			{
+				Name:        "key_expiry_warning",
+				Type:        "duration",
+				Default:     "168h",
+				Description: "Flag this node's key as expiring soon within this window",
+				Example:     `key_expiry_warning = "72h"`,
+			},
 		},
 	}
 }
@@ -229,9 +547,23 @@ func dcCollectorsK8sSection() ConfigSection {
 				Name:        "contexts",
 				Type:        "[]string",
 				Default:     "[]",
-				Description: "Kubernetes contexts to monitor (empty = current context)",
+				Description: "Kubernetes contexts to monitor (empty = current context, [\"*\"] = every context in the kubeconfig)",
 				Example:     `contexts = ["prod", "staging"]`,
 			},
+			{
+				Name:        "kubeconfig",
+				Type:        "string",
+				Default:     "",
+				Description: "Kubeconfig file (empty = KUBECONFIG, then ~/.kube/config)",
+				Example:     `kubeconfig = "${HOME}/.kube/civo.yaml"`,
+			},
+			{
+				Name:        "exclude_contexts",
+				Type:        "[]string",
+				Default:     "[]",
+				Description: "Glob patterns of contexts to skip when contexts = [\"*\"]",
+				Example:     `exclude_contexts = ["kind-*", "minikube"]`,
+			},
 			{
 				Name:        "namespaces",
 				Type:        "[]string",
@@ -239,6 +571,20 @@ func dcCollectorsK8sSection() ConfigSection {
 				Description: "Namespaces to monitor (empty = all namespaces)",
 				Example:     `namespaces = ["default", "kube-system"]`,
 			},
+			{
+				Name:        "watch",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Maintain state from API watches instead of relisting each interval (falls back to listing if watches fail)",
+				Example:     `watch = true`,
+			},
+			{
+				Name:        "utilization_warn",
+				Type:        "float",
+				Default:     "90",
+				Description: "Warn when a namespace requests more than this percentage of its CPU or memory quota, or of the cluster's allocatable without one (0 = off)",
+				Example:     `utilization_warn = 80`,
+			},
 		},
 	}
 }
@@ -251,8 +597,8 @@ func dcCollectorsClaudeSection() ConfigSection {
 			{
 				Name:        "enabled",
 				Type:        "bool",
-				Default:     "true",
-				Description: "Enable Claude usage collection",
+				Default:     "when an account is configured",
+				Description: "Enable Claude usage collection. Unset, it is on when an account has an admin key, credentials, or sessions_dir",
 				Example:     `enabled = true`,
 			},
 			{
@@ -269,6 +615,34 @@ func dcCollectorsClaudeSection() ConfigSection {
 				Description: "Anthropic Admin API key (prefer ANTHROPIC_ADMIN_KEY env var)",
 				Example:     `# admin_key = "sk-ant-admin-..."  # prefer env var`,
 			},
+			{
+				Name:        "forecast_warning",
+				Type:        "duration",
+				Default:     "30m",
+				Description: "Warn in the prompt when an account is projected to hit its window_limit within this long (0 disables)",
+				Example:     `forecast_warning = "30m"`,
+			},
+			{
+				Name:        "pricing",
+				Type:        "map[string]table",
+				Default:     "{}",
+				Description: "Per-model price overrides in USD per million tokens, keyed by model name or prefix: input, output, cache_write, and cache_read (cache rates default to 1.25x and 0.1x input when both are unset). Entries extend the built-in table",
+				Example:     "[collectors.claude.pricing.\"claude-opus-4-6\"]\ninput = 15.0\noutput = 75.0",
+			},
+			{
+				Name:        "default_pricing",
+				Type:        "table",
+				Default:     "Sonnet-tier rates",
+				Description: "Price for models matching no pricing entry, with the same fields as pricing; costs at this rate are flagged as estimates",
+				Example:     "[collectors.claude.default_pricing]\ninput = 3.0\noutput = 15.0",
+			},
+			{
+				Name:        "account",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Per-account settings: name (required, unique; the label shown everywhere), admin_key, credentials (a Claude Code .credentials.json; expired tokens are refreshed and written back), sessions_dir (Claude Code transcripts for the TUI session drill-down; defaults to the projects directory beside credentials), and window_limit (tokens per 5-hour window, enables the limit forecast)",
+				Example:     "[[collectors.claude.account]]\nname = \"work\"\ncredentials = \"~/.claude-work/.credentials.json\"\nwindow_limit = 4000000",
+			},
 		},
 	}
 }
@@ -276,13 +650,13 @@ func dcCollectorsClaudeSection() ConfigSection {
 func dcCollectorsBillingSection() ConfigSection {
 	return ConfigSection{
 		Name:        "collectors.billing",
-		Description: "Cloud billing data: Civo and DigitalOcean spend tracking and budget alerts.",
+		Description: "Cloud billing data: Civo, DigitalOcean, Hetzner, and Vultr spend tracking and budget alerts.",
 		Fields: []ConfigField{
 			{
 				Name:        "enabled",
 				Type:        "bool",
-				Default:     "false",
-				Description: "Enable billing data collection",
+				Default:     "when a provider has a key",
+				Description: "Enable billing data collection. Unset, it is on when an enabled provider has an API key",
 				Example:     `enabled = false`,
 			},
 			{
@@ -292,71 +666,650 @@ func dcCollectorsBillingSection() ConfigSection {
 				Description: "Collection interval for billing data",
 				Example:     `interval = "15m"`,
 			},
+			{
+				Name:        "warn_percent",
+				Type:        "float",
+				Default:     "80",
+				Description: "Percent of a provider budget at which its status becomes warn",
+				Example:     `warn_percent = 80`,
+			},
+			{
+				Name:        "critical_percent",
+				Type:        "float",
+				Default:     "100",
+				Description: "Percent of a provider budget at which its status becomes critical",
+				Example:     `critical_percent = 100`,
+			},
+			{
+				Name:        "history_retention_days",
+				Type:        "int",
+				Default:     "90",
+				Description: "Days of spend snapshots kept in the billing history file",
+				Example:     `history_retention_days = 90`,
+			},
+			{
+				Name:        "anomaly_factor",
+				Type:        "float",
+				Default:     "2",
+				Description: "Flag a provider whose spend today is more than this many times its median daily spend over the previous 14 days, measured from the billing history as daily changes so missed days and period resets are handled; shown as a warning in the TUI and banner and as billing_anomaly notifications. 0 disables",
+				Example:     `anomaly_factor = 2.5`,
+			},
+			{
+				Name:        "anomaly_min_delta",
+				Type:        "float",
+				Default:     "0",
+				Description: "Also flag a provider whose spend today is more than this amount above its median daily spend, in the display currency. 0 disables",
+				Example:     `anomaly_min_delta = 10.0`,
+			},
+			{
+				Name:        "budgets",
+				Type:        "table",
+				Default:     "{}",
+				Description: "Monthly budget in the display currency per provider; unlisted providers have no budget",
+				Example:     "[collectors.billing.budgets]\ndigitalocean = 50.0",
+			},
+			{
+				Name:        "currency",
+				Type:        "table",
+				Default:     `display = "USD", source = "ecb"`,
+				Description: "Currency spend is totalled in: display (ISO 4217 code budgets are also in), source of exchange rates for providers billing in another currency (ecb for the European Central Bank's daily rates, cached for rates_ttl, default 24h; static for rates alone), and rates (value of one unit of a currency in the display currency). Spend that cannot be converted is shown as per-currency subtotals instead of a total",
+				Example:     "[collectors.billing.currency]\ndisplay = \"USD\"\nsource = \"static\"\n\n[collectors.billing.currency.rates]\nEUR = 1.08",
+			},
+			{
+				Name:        "civo",
+				Type:        "table",
+				Default:     "disabled",
+				Description: "Civo account: enabled, api_key (prefer CIVO_TOKEN or CIVO_TOKEN_FILE), base_url (API endpoint override, for a proxy or a test server), and period, the billing cycle when it is not the calendar month: anchor_day (1-31, the day each cycle starts; the last day in shorter months) or cycle_days (1-31) counted from start (a date), and timezone (default UTC) whose midnight cycles start at. Spend, budgets, and budget alerts then cover the current cycle, budgets prorated to cycle_days; the banner and TUI label the cycle, e.g. Feb 12 – Mar 11",
+				Example:     "[collectors.billing.civo]\nenabled = true\n\n[collectors.billing.civo.period]\nanchor_day = 12\ntimezone = \"America/Los_Angeles\"",
+			},
+			{
+				Name:        "digitalocean",
+				Type:        "table",
+				Default:     "disabled",
+				Description: "DigitalOcean account: enabled, api_key (prefer DIGITALOCEAN_TOKEN or DIGITALOCEAN_TOKEN_FILE), base_url, and period, as for civo. DigitalOcean reports only calendar month spend, so a cycle's spend is estimated from droplet prices",
+				Example:     "[collectors.billing.digitalocean]\nenabled = true",
+			},
+			{
+				Name:        "hetzner",
+				Type:        "table",
+				Default:     "disabled",
+				Description: "Hetzner Cloud account: enabled, api_key (prefer HCLOUD_TOKEN or HCLOUD_TOKEN_FILE), base_url, and period, as for civo",
+				Example:     "[collectors.billing.hetzner]\nenabled = true",
+			},
+			{
+				Name:        "vultr",
+				Type:        "table",
+				Default:     "disabled",
+				Description: "Vultr account: enabled, api_key (prefer VULTR_API_KEY or VULTR_API_KEY_FILE), base_url, and period, as for civo. Vultr reports only calendar month pending charges, so a cycle's spend is estimated from instance plans",
+				Example:     "[collectors.billing.vultr]\nenabled = true",
+			},
 		},
 	}
 }
 
-func dcImageSection() ConfigSection {
+func dcCollectorsUptimeKumaSection() ConfigSection {
 	return ConfigSection{
-		Name:        "image",
-		Description: "Image rendering and waifu display settings.",
+		Name:        "collectors.uptimekuma",
+		Description: "Uptime Kuma monitor status, shown alongside the built-in infra checks.",
 		Fields: []ConfigField{
 			{
-				Name:        "protocol",
-				Type:        "string",
-				Default:     "auto",
-				Description: "Image protocol: auto, kitty, iterm2, sixel, halfblocks, none",
-				Example:     `protocol = "auto"`,
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "when url is set",
+				Description: "Enable Uptime Kuma monitor collection. Unset, it is on when url is set",
+				Example:     `enabled = false`,
 			},
 			{
-				Name:        "max_cache_size_mb",
-				Type:        "int",
-				Default:     "50",
-				Description: "Maximum disk cache size for images in MB",
-				Example:     `max_cache_size_mb = 50`,
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "60s",
+				Description: "Collection interval for Uptime Kuma monitors",
+				Example:     `interval = "60s"`,
 			},
 			{
-				Name:        "max_sessions",
-				Type:        "int",
-				Default:     "10",
-				Description: "Maximum number of per-session cached images",
-				Example:     `max_sessions = 10`,
+				Name:        "url",
+				Type:        "string",
+				Description: "Base URL of the Uptime Kuma instance",
+				Example:     `url = "https://status.example.com"`,
 			},
 			{
-				Name:        "waifu_enabled",
-				Type:        "bool",
-				Default:     "true",
-				Description: "Toggle waifu image display in banner and TUI",
-				Example:     `waifu_enabled = true`,
+				Name:        "api_key",
+				Type:        "string",
+				Description: "Uptime Kuma API key (prefer UPTIME_KUMA_API_KEY env var)",
+				Example:     `# api_key = "uk1_..."  # prefer env var`,
 			},
 			{
-				Name:        "waifu_category",
-				Type:        "string",
-				Default:     "waifu",
-				Description: "Waifu API category for image fetching",
-				Example:     `waifu_category = "waifu"`,
+				Name:        "tags",
+				Type:        "[]string",
+				Default:     "[]",
+				Description: "Only collect monitors with one of these tags (\"name\" or \"name:value\")",
+				Example:     `tags = ["prompt-pulse"]`,
 			},
 		},
 	}
 }
 
-func dcThemeSection() ConfigSection {
+func dcCollectorsOllamaSection() ConfigSection {
 	return ConfigSection{
-		Name:        "theme",
-		Description: "Visual theme selection. Six built-in themes are available.",
+		Name:        "collectors.ollama",
+		Description: "Local Ollama server: loaded models with their VRAM and RAM use, and requests served where the server exposes Prometheus metrics. A server that is not running is shown as inactive.",
 		Fields: []ConfigField{
 			{
-				Name:        "name",
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable Ollama collection",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "30s",
+				Description: "Collection interval for Ollama status",
+				Example:     `interval = "30s"`,
+			},
+			{
+				Name:        "url",
 				Type:        "string",
-				Default:     "default",
-				Description: "Theme name: default, gruvbox, nord, catppuccin, dracula, tokyo-night",
-				Example:     `name = "catppuccin"`,
+				Description: "Ollama server URL; empty uses OLLAMA_HOST, then http://localhost:11434",
+				Example:     `url = "http://gpu-box.lan:11434"`,
 			},
 		},
 	}
 }
 
-func dcShellSection() ConfigSection {
+func dcCollectorsDockerSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.docker",
+		Description: "Docker container status: running, exited, and unhealthy counts from the local daemon.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable Docker container collection",
+				Example:     `enabled = false`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "30s",
+				Description: "Collection interval for Docker container status",
+				Example:     `interval = "30s"`,
+			},
+			{
+				Name:        "host",
+				Type:        "string",
+				Description: "Docker daemon address; empty uses DOCKER_HOST, then unix:///var/run/docker.sock",
+				Example:     `host = "unix:///run/user/1000/docker.sock"`,
+			},
+		},
+	}
+}
+
+func dcCollectorsShellSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.shell",
+		Description: "Shell activity: commands run today, failure rate, and the longest command, per shell and overall. When enabled, `prompt-pulse shell` also installs hooks that append each command's exit status and duration to a file per shell in the state directory (Bash 5+, Zsh, Fish).",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable shell activity collection and the shell hooks that feed it",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "30s",
+				Description: "Collection interval for shell activity",
+				Example:     `interval = "30s"`,
+			},
+			{
+				Name:        "retention",
+				Type:        "duration",
+				Default:     "48h",
+				Description: "How long the activity files of a shell that has exited are kept after its last command",
+				Example:     `retention = "48h"`,
+			},
+		},
+	}
+}
+
+func dcCollectorsWeatherSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.weather",
+		Description: "Current temperature and conditions from Open-Meteo (no API key). Failures show nothing.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable weather collection",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "30m",
+				Description: "Collection interval; values below 10m are raised to 10m",
+				Example:     `interval = "1h"`,
+			},
+			{
+				Name:        "latitude",
+				Type:        "float",
+				Default:     "0",
+				Description: "Forecast latitude; with longitude, takes precedence over location",
+				Example:     `latitude = 52.52`,
+			},
+			{
+				Name:        "longitude",
+				Type:        "float",
+				Default:     "0",
+				Description: "Forecast longitude",
+				Example:     `longitude = 13.41`,
+			},
+			{
+				Name:        "location",
+				Type:        "string",
+				Description: "Place name geocoded via Open-Meteo when no coordinates are set",
+				Example:     `location = "Berlin"`,
+			},
+			{
+				Name:        "units",
+				Type:        "string",
+				Default:     "celsius",
+				Description: "Temperature units: celsius or fahrenheit",
+				Example:     `units = "fahrenheit"`,
+			},
+		},
+	}
+}
+
+func dcCollectorsChecksSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.checks",
+		Description: "HTTP, TCP, and ping checks defined in the config, for hosts and services without a dedicated collector. Shown with the infra checks.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable the configured checks",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "60s",
+				Description: "Collection interval for the checks",
+				Example:     `interval = "60s"`,
+			},
+			{
+				Name:        "workers",
+				Type:        "int",
+				Default:     "4",
+				Description: "Maximum number of checks run at once",
+				Example:     `workers = 4`,
+			},
+			{
+				Name:        "failure_threshold",
+				Type:        "int",
+				Default:     "2",
+				Description: "Consecutive failures before a check is shown down; earlier failures keep its previous state",
+				Example:     `failure_threshold = 3`,
+			},
+			{
+				Name:        "check",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Checks to run: name (required, unique), type (http, tcp, or ping), target (URL, host:port, or host), expect_status and expect_body (http only), timeout (default 5s), interval (to run a check less often than the collector), group (the service it belongs to; ungrouped checks form \"other\"), and critical (the group is down whenever this check is; other members only warn)",
+				Example:     "[[collectors.checks.check]]\nname = \"api\"\ntype = \"http\"\ntarget = \"https://example.com/health\"\ngroup = \"website\"\ncritical = true",
+			},
+		},
+	}
+}
+
+func dcCollectorsRemoteSection() ConfigSection {
+	return ConfigSection{
+		Name:        "collectors.remote",
+		Description: "Uptime, load, memory, and disk usage of hosts that do not run prompt-pulse, read over SSH with one batched command per host. Connections are reused across collections and host keys are verified against a known_hosts file. Shown with the infra checks.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Enable the remote host collector",
+				Example:     `enabled = true`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "60s",
+				Description: "Collection interval for the remote hosts",
+				Example:     `interval = "2m"`,
+			},
+			{
+				Name:        "timeout",
+				Type:        "duration",
+				Default:     "10s",
+				Description: "Time allowed to connect to and query one host; hosts are queried at once, so a slow one does not delay the others",
+				Example:     `timeout = "5s"`,
+			},
+			{
+				Name:        "failure_threshold",
+				Type:        "int",
+				Default:     "3",
+				Description: "Consecutive failures before a host is shown down; earlier failures keep its previous state",
+				Example:     `failure_threshold = 3`,
+			},
+			{
+				Name:        "known_hosts",
+				Type:        "string",
+				Default:     "~/.ssh/known_hosts",
+				Description: "known_hosts file host keys are verified against",
+				Example:     `known_hosts = "${HOME}/.config/prompt-pulse/known_hosts"`,
+			},
+			{
+				Name:        "strict_host_key_checking",
+				Type:        "string",
+				Default:     "yes",
+				Description: "yes to accept only hosts already in known_hosts, or accept-new to record the key of a new host on first connection. A key that differs from the recorded one is always rejected",
+				Example:     `strict_host_key_checking = "accept-new"`,
+			},
+			{
+				Name:        "host",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Hosts to query: address (required; host or host:port), name (unique; default the address), user (default the local user), and key_file (an unencrypted private key; when unset, the keys of the agent at SSH_AUTH_SOCK are used)",
+				Example:     "[[collectors.remote.host]]\nname = \"vps\"\naddress = \"vps.example.com\"\nuser = \"monitor\"\nkey_file = \"${HOME}/.ssh/id_ed25519\"",
+			},
+		},
+	}
+}
+
+func dcImageSection() ConfigSection {
+	return ConfigSection{
+		Name:        "image",
+		Description: "Image rendering and waifu display settings.",
+		Fields: []ConfigField{
+			{
+				Name:        "protocol",
+				Type:        "string",
+				Default:     "auto",
+				Description: "Image protocol: auto, kitty, iterm2, sixel, halfblocks, none",
+				Example:     `protocol = "auto"`,
+			},
+			{
+				Name:        "max_cache_size_mb",
+				Type:        "int",
+				Default:     "50",
+				Description: "Maximum disk cache size for images in MB",
+				Example:     `max_cache_size_mb = 50`,
+			},
+			{
+				Name:        "disk_cache_dir",
+				Type:        "string",
+				Default:     "",
+				Description: "Directory for rendered images shared by the daemon and banner runs (empty = memory only)",
+				Example:     `disk_cache_dir = "/home/user/.cache/prompt-pulse/renders"`,
+			},
+			{
+				Name:        "max_sessions",
+				Type:        "int",
+				Default:     "10",
+				Description: "Maximum number of per-session cached images",
+				Example:     `max_sessions = 10`,
+			},
+			{
+				Name:        "max_animation_frames",
+				Type:        "int",
+				Default:     "64",
+				Description: "Maximum frames played from an animated GIF on Kitty (1 = still image)",
+				Example:     `max_animation_frames = 64`,
+			},
+			{
+				Name:        "sixel_colors",
+				Type:        "int",
+				Default:     "256",
+				Description: "Palette size for Sixel output (2-256)",
+				Example:     `sixel_colors = 256`,
+			},
+			{
+				Name:        "sixel_dither",
+				Type:        "string",
+				Default:     "floyd",
+				Description: "Sixel dithering: none, floyd (Floyd-Steinberg), ordered (Bayer)",
+				Example:     `sixel_dither = "ordered"`,
+			},
+			{
+				Name:        "kitty_retransmit",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Always resend Kitty images instead of reusing ones the terminal holds (env: PPULSE_KITTY_RETRANSMIT)",
+				Example:     `kitty_retransmit = true`,
+			},
+			{
+				Name:        "block_mode",
+				Type:        "string",
+				Default:     "halfblocks",
+				Description: "Character-cell fallback: halfblocks (2 pixels per cell) or quadrants (4, sharper on hard edges)",
+				Example:     `block_mode = "quadrants"`,
+			},
+			{
+				Name:        "background",
+				Type:        "string",
+				Default:     `""`,
+				Description: "Terminal background color for blending translucent pixels in the cell fallback (empty = ask the terminal)",
+				Example:     `background = "#fdf6e3"`,
+			},
+			{
+				Name:        "waifu_enabled",
+				Type:        "bool",
+				Default:     "true",
+				Description: "Toggle waifu image display in banner and TUI",
+				Example:     `waifu_enabled = true`,
+			},
+			{
+				Name:        "waifu_category",
+				Type:        "string",
+				Default:     "waifu",
+				Description: "Waifu API category for image fetching. Local images are picked from the subdirectory of the same name when it exists",
+				Example:     `waifu_category = "waifu"`,
+			},
+			{
+				Name:        "waifu_weights",
+				Type:        "table",
+				Default:     "{}",
+				Description: "Selection weight per image subdirectory (slash-separated, relative to the image directory). Images elsewhere have weight 1; 0 excludes a subdirectory",
+				Example:     "[image.waifu_weights]\nfavorites = 3.0\nseasonal = 1.0",
+			},
+			{
+				Name:        "waifu_url",
+				Type:        "string",
+				Default:     `""`,
+				Description: "Remote image provider; {category} is replaced with waifu_category. Each new session downloads one image, falling back to local images when offline. Empty uses local images only",
+				Example:     `waifu_url = "https://api.waifu.pics/sfw/{category}"`,
+			},
+			{
+				Name:        "waifu_max_cache_mb",
+				Type:        "int",
+				Default:     "50",
+				Description: "Disk space for downloaded images; the least recently used are removed beyond it",
+				Example:     `waifu_max_cache_mb = 50`,
+			},
+			{
+				Name:        "waifu_crop",
+				Type:        "string",
+				Default:     "none",
+				Description: "Crop images to the shape of their cell area: none, center, or entropy (keep the most detailed region)",
+				Example:     `waifu_crop = "entropy"`,
+			},
+			{
+				Name:        "waifu_pad",
+				Type:        "string",
+				Default:     "none",
+				Description: "Fill the cell area around an image of another shape: none, theme (theme background), edge (the image's border color), or a #rrggbb color",
+				Example:     `waifu_pad = "edge"`,
+			},
+			{
+				Name:        "waifu_corner_radius",
+				Type:        "int",
+				Default:     "0",
+				Description: "Round image corners by this many pixels on Kitty and iTerm2 (0 = square)",
+				Example:     `waifu_corner_radius = 12`,
+			},
+		},
+	}
+}
+
+func dcThemeSection() ConfigSection {
+	return ConfigSection{
+		Name:        "theme",
+		Description: "Visual theme selection. Seven built-in themes are available, and \"auto\" picks a light or dark one from the terminal background (OSC 11 query, falling back to COLORFGBG), cached per TTY with the terminal capabilities.",
+		Fields: []ConfigField{
+			{
+				Name:        "name",
+				Type:        "string",
+				Default:     "default",
+				Description: "Theme name: default, gruvbox, nord, catppuccin, dracula, tokyo-night, solarized-light, or auto",
+				Example:     `name = "auto"`,
+			},
+			{
+				Name:        "light",
+				Type:        "string",
+				Default:     "solarized-light",
+				Description: "Theme used by auto on a light terminal background",
+				Example:     `light = "solarized-light"`,
+			},
+			{
+				Name:        "dark",
+				Type:        "string",
+				Default:     "default",
+				Description: "Theme used by auto on a dark or unknown terminal background",
+				Example:     `dark = "tokyo-night"`,
+			},
+			{
+				Name:        "terminals",
+				Type:        "table",
+				Default:     "{}",
+				Description: "Per-terminal overrides keyed by TERM_PROGRAM. Values are theme names, \"light\" or \"dark\" for the variants above, or \"auto\"",
+				Example:     "[theme.terminals]\nApple_Terminal = \"light\"",
+			},
+		},
+	}
+}
+
+func dcDisplaySection() ConfigSection {
+	return ConfigSection{
+		Name:        "display",
+		Description: "Output compatibility for dumb terminals, logs, and pipes. Plain output has no ANSI escapes, draws boxes and bars with + - | #, spells out glyphs such as ✓ and ⚠ as [OK] and [WARN], and renders no images. It is used when ascii is set, when banner output is not a terminal, or when TERM is dumb. The NO_COLOR environment variable only turns color off.",
+		Fields: []ConfigField{
+			{
+				Name:        "ascii",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Always produce plain ASCII output without color or images",
+				Example:     `ascii = true`,
+			},
+		},
+	}
+}
+
+func dcStarshipSection() ConfigSection {
+	return ConfigSection{
+		Name:        "starship",
+		Description: "Starship segment output. Segments are colored with the theme's success, warning, and error colors.",
+		Fields: []ConfigField{
+			{
+				Name:        "wrap",
+				Type:        "string",
+				Default:     `""`,
+				Description: "Mark color codes as zero-width for bash or zsh when `-starship` output is placed in PS1 or PROMPT directly. Leave empty under Starship, which does this itself",
+				Example:     `wrap = "zsh"`,
+			},
+			{
+				Name:        "claude_display",
+				Type:        "string",
+				Default:     `"cost"`,
+				Description: "What the claude segment shows: cost (estimated USD), tokens (input plus output), or both. Costs that include models priced at the default rate are marked with *",
+				Example:     `claude_display = "both"`,
+			},
+			{
+				Name:        "budget",
+				Type:        "duration",
+				Default:     "50ms",
+				Description: "Time `-starship` may take before Starship drops the module. Segments whose cached data is not read in time are left out (a placeholder is shown if none is ready) and the overrun is counted in the cache directory, shown by `-diagnose`. 0 waits for every segment",
+				Example:     `budget = "100ms"`,
+			},
+			{
+				Name:        "max_width",
+				Type:        "table",
+				Default:     "{}",
+				Description: "Visible width in cells per `-starship` module, such as claude, infra, or all; unlisted modules get 60. Over it, details such as the top model or exit node are dropped first, then the last segment that fits is cut with …. The summary module uses starship.summary.max_width",
+				Example:     "[starship.max_width]\nclaude = 30\ninfra = 40",
+			},
+		},
+	}
+}
+
+// dcStarshipThresholdSection documents the color thresholds of one
+// Starship segment, applied to what.
+func dcStarshipThresholdSection(segment, what string) ConfigSection {
+	return ConfigSection{
+		Name:        "starship.thresholds." + segment,
+		Description: "Color thresholds for the " + segment + " segment, applied to " + what + ".",
+		Fields: []ConfigField{
+			{
+				Name:        "warn",
+				Type:        "float",
+				Default:     "50",
+				Description: "Percentage at which the segment turns to the theme's warning color",
+				Example:     `warn = 60`,
+			},
+			{
+				Name:        "critical",
+				Type:        "float",
+				Default:     "80",
+				Description: "Percentage at which the segment turns to the theme's error color; must not be below warn",
+				Example:     `critical = 90`,
+			},
+		},
+	}
+}
+
+func dcStarshipSummarySection() ConfigSection {
+	return ConfigSection{
+		Name:        "starship.summary",
+		Description: "The combined `-starship summary` segment, which fits several segments into one Starship prompt slot. Segments without data are left out along with their separator.",
+		Fields: []ConfigField{
+			{
+				Name:        "segments",
+				Type:        "[]string",
+				Default:     `["claude", "billing", "infra"]`,
+				Description: "Segments to include, in order: claude, billing, infra (tailscale, uptimekuma, checks, remote, docker, and storage), tailscale, uptimekuma, checks, remote, docker, storage, k8s, system, weather",
+				Example:     `segments = ["claude", "infra"]`,
+			},
+			{
+				Name:        "separator",
+				Type:        "string",
+				Default:     `" │ "`,
+				Description: "Text placed between segments",
+				Example:     `separator = " · "`,
+			},
+			{
+				Name:        "max_width",
+				Type:        "int",
+				Default:     "60",
+				Description: "Total visible width. Over it, each segment is truncated with … to its share, and segments shorter than their share give the rest to the others",
+				Example:     `max_width = 60`,
+			},
+		},
+	}
+}
+
+func dcShellSection() ConfigSection {
 	return ConfigSection{
 		Name:        "shell",
 		Description: "Shell integration settings for hook behavior and keybindings.",
@@ -426,6 +1379,286 @@ func dcBannerSection() ConfigSection {
 				Description: "Minimum terminal width for ultra-wide banner mode",
 				Example:     `ultrawide_min_width = 200`,
 			},
+			{
+				Name:        "column",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Banner columns in display order, each with name (waifu, fastfetch, status) and width (percent, 0 = auto); omitted columns are not rendered",
+				Example:     "[[banner.column]]\nname = \"status\"\nwidth = 30",
+			},
+			{
+				Name:        "stack_below_width",
+				Type:        "int",
+				Default:     "70",
+				Description: "Terminal width below which the banner stacks its sections vertically at full width instead of side by side",
+				Example:     `stack_below_width = 70`,
+			},
+			{
+				Name:        "stack_order",
+				Type:        "[]string",
+				Default:     `["status", "fastfetch"]`,
+				Description: "Sections of the stacked banner, top to bottom: status, fastfetch, waifu (a small thumbnail); omitted sections are not rendered",
+				Example:     `stack_order = ["status", "fastfetch", "waifu"]`,
+			},
+			{
+				Name:        "billing_breakdown",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Add a line per billing provider that itemizes its charges (Civo) to the status column, with month-to-date spend by resource type",
+				Example:     `billing_breakdown = true`,
+			},
+			{
+				Name:        "show_last_event",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Add the most recent change from the event log (see [events]) to the status column, with its age",
+				Example:     `show_last_event = true`,
+			},
+			{
+				Name:        "show_shell_activity",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Add today's shell activity (see [collectors.shell]) to the status column: commands run, failure rate, and the longest command",
+				Example:     `show_shell_activity = true`,
+			},
+		},
+	}
+}
+
+func dcBannerFastfetchSection() ConfigSection {
+	return ConfigSection{
+		Name:        "banner.fastfetch",
+		Description: "Source of the banner's system info column.",
+		Fields: []ConfigField{
+			{
+				Name:        "mode",
+				Type:        "string",
+				Default:     "auto",
+				Description: "native (in-process, no fastfetch needed), fastfetch (run fastfetch, native on failure), or auto (native)",
+				Example:     `mode = "native"`,
+			},
+		},
+	}
+}
+
+func dcTUISection() ConfigSection {
+	return ConfigSection{
+		Name:        "tui",
+		Description: "Fullscreen TUI settings.",
+		Fields: []ConfigField{
+			{
+				Name:        "mouse",
+				Type:        "bool",
+				Default:     "true",
+				Description: "Mouse input: click a pane to focus it and a table row to select it, scroll the focused table with the wheel, and drag table scrollbars. Turn off for terminals that misbehave with mouse reporting",
+				Example:     "mouse = false",
+			},
+		},
+	}
+}
+
+func dcTUIKeysSection() ConfigSection {
+	return ConfigSection{
+		Name:        "tui.keys",
+		Description: "TUI key bindings. Each action takes a list of keys; unlisted actions keep their defaults. A key bound to two actions is a config error.",
+		Fields: []ConfigField{
+			{
+				Name:        "next_tab",
+				Type:        "[]string",
+				Default:     `["tab", "l", "j"]`,
+				Description: "Focus the next widget",
+				Example:     `next_tab = ["tab", "l"]`,
+			},
+			{
+				Name:        "prev_tab",
+				Type:        "[]string",
+				Default:     `["shift+tab", "h", "k"]`,
+				Description: "Focus the previous widget",
+				Example:     `prev_tab = ["shift+tab", "h"]`,
+			},
+			{
+				Name:        "expand",
+				Type:        "[]string",
+				Default:     `["enter"]`,
+				Description: "Expand or collapse the focused widget",
+				Example:     `expand = ["enter"]`,
+			},
+			{
+				Name:        "back",
+				Type:        "[]string",
+				Default:     `["esc"]`,
+				Description: "Close the help overlay or collapse the expanded widget",
+				Example:     `back = ["esc"]`,
+			},
+			{
+				Name:        "refresh",
+				Type:        "[]string",
+				Default:     `["r"]`,
+				Description: "Reload data now",
+				Example:     `refresh = ["r", "f5"]`,
+			},
+			{
+				Name:        "search",
+				Type:        "[]string",
+				Default:     `["/"]`,
+				Description: "Search the focused pane's table rows, or the widgets when it shows no table",
+				Example:     `search = ["/"]`,
+			},
+			{
+				Name:        "help",
+				Type:        "[]string",
+				Default:     `["?"]`,
+				Description: "Toggle the help overlay",
+				Example:     `help = ["?"]`,
+			},
+			{
+				Name:        "quit",
+				Type:        "[]string",
+				Default:     `["q"]`,
+				Description: "Quit (ctrl+c always quits)",
+				Example:     `quit = ["q", "ctrl+c"]`,
+			},
+		},
+	}
+}
+
+func dcNotificationsSection() ConfigSection {
+	return ConfigSection{
+		Name:        "notifications",
+		Description: "Daemon notifications when collected data crosses a threshold. A rule fires once when a subject (provider, account, check, or cluster) enters the triggered state, not on every poll. Notifications are off without rules; prompt-pulse -test-notification sends a test event through every sink.",
+		Fields: []ConfigField{
+			{
+				Name:        "sink",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Delivery targets: name (required, unique), type (desktop via notify-send or osascript, webhook for a JSON POST to url, or command run with sh -c and the event JSON on stdin), and timeout (default 10s)",
+				Example:     "[[notifications.sink]]\nname = \"desktop\"\ntype = \"desktop\"",
+			},
+			{
+				Name:        "rule",
+				Type:        "[]table",
+				Default:     "[]",
+				Description: "Events to notify about: name (required, unique), event (billing_budget, billing_anomaly for a provider's unusual daily spend, claude_window, check_down, or k8s_critical), threshold (percent; default 100 of the budget, 80 of the Claude window), cooldown (minimum time between notifications for the same subject), and sinks (default all)",
+				Example:     "[[notifications.rule]]\nname = \"budget\"\nevent = \"billing_budget\"\nthreshold = 90\ncooldown = \"6h\"\nsinks = [\"desktop\"]",
+			},
+		},
+	}
+}
+
+func dcStatusPageSection() ConfigSection {
+	return ConfigSection{
+		Name:        "status_page",
+		Description: "Static HTML status page of the cached infra checks, Kubernetes clusters, and billing totals, with inline CSS and no scripts. prompt-pulse -render-html writes it once; the daemon rewrites it every interval when one is set. Sections of disabled collectors, or without cached data, are left out.",
+		Fields: []ConfigField{
+			{
+				Name:        "path",
+				Type:        "string",
+				Default:     `""`,
+				Description: "File the page is written to, replaced atomically. -render-html writes to stdout when it is empty and -output is not given",
+				Example:     `path = "/var/www/status/index.html"`,
+			},
+			{
+				Name:        "template",
+				Type:        "string",
+				Default:     `""`,
+				Description: "html/template file used in place of the built-in page, executed with the title, generation time, and the Infra, K8s, and Billing sections (nil when absent)",
+				Example:     `template = "${HOME}/.config/prompt-pulse/status.html.tmpl"`,
+			},
+			{
+				Name:        "title",
+				Type:        "string",
+				Default:     `"prompt-pulse status"`,
+				Description: "Page heading and title",
+				Example:     `title = "homelab status"`,
+			},
+			{
+				Name:        "interval",
+				Type:        "duration",
+				Default:     "0s",
+				Description: "How often the daemon rewrites the page at path, at most every 30s. 0s leaves it to -render-html",
+				Example:     `interval = "5m"`,
+			},
+		},
+	}
+}
+
+func dcValidationSection() ConfigSection {
+	return ConfigSection{
+		Name:        "validation",
+		Description: "Sanity checks on collector results before they are cached. A result that fails one, such as a billing total of $0 decoded from an error page, is not written: the cached data stays, the collector is marked degraded, and the payload is kept in rejected.json in the state directory. The first result after the daemon starts is checked on its own. `prompt-pulse -ctl accept <collector>` accepts the next result unchecked, for legitimate large changes such as deleting a cluster.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "true",
+				Description: "Check collector results before caching them",
+				Example:     `enabled = false`,
+			},
+			{
+				Name:        "billing_max_factor",
+				Type:        "float",
+				Default:     "10",
+				Description: "How many times larger or smaller than the last accepted value a provider's month-to-date spend may be within one billing period. Spend under 1 in the report currency is not compared",
+				Example:     `billing_max_factor = 4.0`,
+			},
+			{
+				Name:        "claude_window",
+				Type:        "duration",
+				Default:     "1h",
+				Description: "How long after the last accepted report a Claude account's monthly token count must not go down (0 = not checked)",
+				Example:     `claude_window = "30m"`,
+			},
+			{
+				Name:        "k8s_allow_zero_nodes",
+				Type:        "bool",
+				Default:     "false",
+				Description: "Accept a reachable cluster reporting no nodes after it had some",
+				Example:     `k8s_allow_zero_nodes = true`,
+			},
+		},
+	}
+}
+
+func dcEventsSection() ConfigSection {
+	return ConfigSection{
+		Name:        "events",
+		Description: "Change log of collector data. After each run the daemon compares the result with what it last reported and appends a line per change, such as \"billing.digitalocean +$3.20\" or \"k8s prod pods running 41→39\", to events.json in the state directory, shown by the TUI's Events pane. Billing, Claude, Kubernetes, checks, Uptime Kuma, and Tailscale data are diffed; the first result after the daemon starts is the baseline. The log is encrypted when \"events\" is listed in cache.encrypt.",
+		Fields: []ConfigField{
+			{
+				Name:        "enabled",
+				Type:        "bool",
+				Default:     "true",
+				Description: "Diff collector results and keep the event log",
+				Example:     `enabled = false`,
+			},
+			{
+				Name:        "max_entries",
+				Type:        "int",
+				Default:     "200",
+				Description: "Events kept in the log; the oldest are dropped first",
+				Example:     `max_entries = 500`,
+			},
+			{
+				Name:        "billing_min_delta",
+				Type:        "float",
+				Default:     "0.5",
+				Description: "Smallest change in a provider's month-to-date spend that is logged, in the report currency. Smaller changes add up until they reach it",
+				Example:     `billing_min_delta = 1.0`,
+			},
+			{
+				Name:        "claude_min_delta",
+				Type:        "float",
+				Default:     "1.0",
+				Description: "Smallest change in a Claude account's monthly cost that is logged, in USD",
+				Example:     `claude_min_delta = 2.5`,
+			},
+			{
+				Name:        "pod_jitter",
+				Type:        "int",
+				Default:     "1",
+				Description: "How far a cluster's running pod count may move from the last logged count without being logged",
+				Example:     `pod_jitter = 2`,
+			},
 		},
 	}
 }
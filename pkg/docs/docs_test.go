@@ -1,11 +1,17 @@
 package docs
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/BurntSushi/toml"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
 )
 
 // ---------------------------------------------------------------------------
@@ -298,16 +304,41 @@ func TestConfigRefAllSections(t *testing.T) {
 
 	expected := []string{
 		"general",
+		"log",
+		"cache",
 		"layout",
 		"collectors.sysmetrics",
+		"collectors.gpu",
+		"collectors.storage",
 		"collectors.tailscale",
 		"collectors.kubernetes",
 		"collectors.claude",
+		"collectors.ollama",
 		"collectors.billing",
+		"collectors.uptimekuma",
+		"collectors.docker",
+		"collectors.weather",
+		"collectors.checks",
+		"collectors.remote",
+		"collectors.shell",
+		"http",
 		"image",
 		"theme",
+		"display",
 		"shell",
+		"starship",
+		"starship.summary",
+		"starship.thresholds.claude",
+		"starship.thresholds.billing",
+		"starship.thresholds.system",
 		"banner",
+		"banner.fastfetch",
+		"tui",
+		"tui.keys",
+		"notifications",
+		"status_page",
+		"events",
+		"validation",
 	}
 
 	if len(ref.Sections) != len(expected) {
@@ -846,3 +877,108 @@ func TestGenerateRoffFormat(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Config scaffold tests
+// ---------------------------------------------------------------------------
+
+// TestConfigScaffoldLoadsAsDefaults checks the scaffold loads to the same
+// configuration as an empty file.
+func TestConfigScaffoldLoadsAsDefaults(t *testing.T) {
+	scaffold := ConfigScaffold()
+	got, err := config.LoadFromReader(strings.NewReader(scaffold))
+	if err != nil {
+		t.Fatalf("LoadFromReader(scaffold) error: %v\n%s", err, scaffold)
+	}
+	want, err := config.LoadFromReader(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(c *config.Config) string {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if g, w := encode(got), encode(want); g != w {
+		t.Errorf("scaffold loads as\n%s\nwant the defaults\n%s", g, w)
+	}
+}
+
+// TestConfigScaffoldDocumentsEveryKey checks every config key is in the
+// configuration reference, as a field of its own or within a table
+// field, so the scaffold has a comment for it.
+func TestConfigScaffoldDocumentsEveryKey(t *testing.T) {
+	sections := make(map[string]ConfigSection)
+	for _, s := range dcGenerateConfigRef().Sections {
+		sections[s.Name] = s
+	}
+	documented := func(path []string) bool {
+		for i := len(path) - 1; i > 0; i-- {
+			if dcFindField(sections[strings.Join(path[:i], ".")], path[i]).Name != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	seen := make(map[reflect.Type]bool)
+	var walk func(path []string, v reflect.Value)
+	walk = func(path []string, v reflect.Value) {
+		keys, tables := dcFields(v)
+		for _, f := range keys {
+			if key := append(path[:len(path):len(path)], f.key); !documented(key) {
+				t.Errorf("%s is not in the configuration reference", strings.Join(key, "."))
+			}
+		}
+		for _, f := range tables {
+			sub := append(path[:len(path):len(path)], f.key)
+			switch f.v.Kind() {
+			case reflect.Struct:
+				walk(sub, f.v)
+			case reflect.Slice:
+				if elem := f.v.Type().Elem(); !seen[elem] {
+					seen[elem] = true
+					walk(sub, reflect.New(elem).Elem())
+				}
+			case reflect.Map:
+				if _, ok := sections[strings.Join(sub, ".")]; !ok && !documented(sub) {
+					t.Errorf("%s is not in the configuration reference", strings.Join(sub, "."))
+				}
+			}
+		}
+	}
+	walk(nil, reflect.ValueOf(*config.DefaultConfig()))
+}
+
+func TestConfigScaffoldComments(t *testing.T) {
+	scaffold := ConfigScaffold()
+	for _, want := range []string{
+		"\n[general]\n",
+		"\ndaemon_poll_interval = \"15m\"\n",
+		// Collector flags stay unset so credentials decide.
+		"\n# enabled = false\n",
+		// Optional tables are commented out, with example values.
+		"\n# [collectors.billing.civo]\n# enabled = true\n",
+		"\n# [[notifications.sink]]\n# name = \"desktop\"\n",
+		"# Overridden by CIVO_TOKEN, or a file named by CIVO_TOKEN_FILE.\n# api_key = \"\"\n",
+	} {
+		if !strings.Contains(scaffold, want) {
+			t.Errorf("scaffold lacks %q", want)
+		}
+	}
+	// Every environment override names a key the scaffold writes.
+	for _, o := range config.EnvOverrides() {
+		if !strings.Contains(scaffold, o.Name) {
+			t.Errorf("scaffold does not mention %s, overriding %s", o.Name, o.Key)
+		}
+	}
+	for i, line := range strings.Split(scaffold, "\n") {
+		if len(line) > dcScaffoldWidth && strings.HasPrefix(line, "# ") && strings.Contains(line, " ") {
+			if !strings.Contains(strings.TrimPrefix(line, "# "), " = ") {
+				t.Errorf("line %d is %d columns, over %d: %s", i+1, len(line), dcScaffoldWidth, line)
+			}
+		}
+	}
+}
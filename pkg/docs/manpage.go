@@ -188,13 +188,57 @@ Show current configuration.
 Migrate v1 configuration to v2 format.
 .TP
 .B \-\-theme <name>
-Override the color theme (default, gruvbox, nord, catppuccin, dracula, tokyo-night).
+Override the color theme (default, gruvbox, nord, catppuccin, dracula,
+tokyo-night, solarized-light). "auto" picks theme.light or theme.dark from
+the terminal background. The flag ignores theme.terminals.
 .TP
 .B \-\-protocol <name>
 Override image rendering protocol (auto, kitty, iterm2, sixel, halfblocks, none).
 .TP
 .B \-\-layout <preset>
-Override layout preset (dashboard, minimal, ops, billing).`,
+Override layout preset (dashboard, minimal, ops, billing).
+.TP
+.B \-\-list-collectors
+List every collector with whether it is enabled, why (explicit config,
+host profile, credentials found, missing credentials, or default), and the
+interval it runs at. The host profile matched (general.host_profile) is listed
+first with the features taken from it and those the config overrides. With
+\-\-format json, print a JSON array instead.
+.TP
+.B \-\-init-config
+Write a commented config file listing every setting with its default to
+\-\-config, or $XDG_CONFIG_HOME/prompt-pulse/config.toml. Unset and optional
+settings are commented out with an example value. An existing file is left
+alone unless \-\-force is given.
+.TP
+.B \-\-test-notification
+Send a test event through every configured notification sink and report
+which succeeded.
+.TP
+.B \-\-export <format>
+Dump everything in the cache as json (nested by collector) or csv (one file per
+collector), without collecting or touching the network. Collectors without
+usable data are listed with a null value and the reason.
+.TP
+.B \-\-output <path>
+Write \-\-export json to this file instead of stdout. For \-\-export csv,
+the directory to write into, or a path ending in .zip for a zip archive.
+For \-\-render-html, the page file, in place of status_page.path.
+.TP
+.B \-\-render-html
+Render the cached infra checks, Kubernetes clusters, and billing totals as a
+self-contained static HTML page, written to status_page.path or stdout.
+Sections of disabled collectors or without cached data are left out.
+.TP
+.B \-\-install-service
+Install the daemon as a systemd user unit (Linux) or launchd agent (macOS) and
+start it. With \-\-print-only, print the unit or plist instead.
+.TP
+.B \-\-uninstall-service
+Stop the daemon service and remove its unit or plist.
+.TP
+.B \-\-service-status
+Report whether the daemon service is installed, loaded, and running.`,
 		Examples: `.nf
 # Show banner
 prompt-pulse banner
@@ -227,11 +271,91 @@ func dcManDaemon() *ManPage {
 		Description: `The prompt-pulse daemon runs in the background, collecting data from configured
 sources at regular intervals. It communicates with clients via a Unix domain socket.
 
+Each collector runs on its own interval, shifted by a little random jitter so
+collectors do not all fire at once. A collector that fails, or reports itself
+unhealthy, is retried at a doubling interval of up to 30 minutes until it
+succeeds again. prompt-pulse -ctl status shows when each collector runs next;
+prompt-pulse -ctl collect runs collectors immediately regardless.
+
+Collectors run concurrently, each bounded by general.collect_timeout. One still
+running at the deadline is abandoned and marked timed out, and the banner lists
+it as stale; a collector that panics is marked unhealthy without stopping the
+daemon.
+
+Each result is checked against the last one accepted before it is cached
+([validation]): a billing total that is negative or jumps more than
+validation.billing_max_factor times, Claude token counts that go down within
+the month, or a reachable cluster that suddenly has no nodes. A rejected result
+is not written, the collector is marked rejected with its cached data kept, and
+the payload is kept in rejected.json in the state directory. prompt-pulse -ctl
+accept <collector> accepts the next result unchecked.
+
+On SIGTERM or SIGINT, or prompt-pulse -ctl shutdown, the daemon starts no new
+collector runs and gives those in flight general.shutdown_grace to finish and
+write their data before cancelling them, then closes the control socket and
+exits 0. A second signal during the grace period exits at once with status 1.
+
 The daemon caches collected data so that banner and TUI modes can display
-information instantly without waiting for API calls.`,
+information instantly without waiting for API calls. Data older than
+general.stale_after_polls poll intervals is marked: a "(3h old)" suffix in the
+banner, a ⟳ after the starship segment, and a note in the TUI status bar. Data
+older than general.expire_after is not shown at all.
+
+Notification rules ([[notifications.rule]]) watch the collected data for a
+billing budget crossed, a provider's unusual daily spend, a Claude usage window
+above a threshold, a check going down, or a Kubernetes cluster turning critical,
+and deliver to desktop, webhook, or command sinks ([[notifications.sink]]). A rule fires once when its subject
+enters that state, not on every poll, and not again within its cooldown.
+Without rules, notifications are off. prompt-pulse -test-notification sends a
+test event through every configured sink.
+
+A provider's spend today is unusual when it is more than
+collectors.billing.anomaly_factor times (default 2) the median of its daily
+spend over the previous 14 days, or more than collectors.billing.anomaly_min_delta
+above it. Daily spend is the change in the month-to-date total recorded in the
+billing history, so days the daemon was off and the reset at the start of a
+billing period are accounted for. Such providers get a ⚠ in the TUI, a line in
+the banner, and billing_anomaly notification events.
+
+With collectors.shell enabled, prompt-pulse -shell also installs hooks that
+append each command's exit status and duration (and, in Zsh and Fish, its first
+word) to a file per shell in shell-activity in the state directory. The hooks
+use shell builtins and a single appended line per command, and switch between
+two files of 500 lines each so a long-lived shell's file stays bounded. The
+shell collector reports commands run today, the failure rate, and the longest
+command per shell and overall, in the TUI's Shell Activity pane and, with
+banner.show_shell_activity, in the banner. Files of shells that have exited are
+removed after collectors.shell.retention.
+
+The daemon re-reads its configuration on SIGHUP, when the config file changes,
+or on prompt-pulse -ctl reload. Collectors are added, removed, or rebuilt with
+their new settings without restarting; an invalid configuration is rejected and
+the running one kept. prompt-pulse -ctl status shows the outcome of the last
+reload.
+
+The daemon logs to stderr, or to log.file, which it rotates itself once the file
+reaches log.max_size_mb or log.max_age, keeping log.max_files old copies. Lines
+are text or, with log.format = "json" or -log-format json, one JSON object each.
+Collector runs are logged with collector, cache_key, and duration_ms fields:
+failures as warnings, successes at debug (general.log_level, or -verbose).
+Secrets are masked as [REDACTED] in every line and in the output of -diagnose
+and -billing-check: API keys and tokens from the config and environment, values
+of attributes and URL query parameters named like token, secret, password, or
+api_key, and bearer credentials.
+
+prompt-pulse -install-service runs the daemon at login: it writes a systemd user
+unit to ~/.config/systemd/user/prompt-pulse.service on Linux, or a launchd agent
+to ~/Library/LaunchAgents/com.tinyland.prompt-pulse.plist on macOS, and starts
+it. -uninstall-service stops and removes it, and -service-status reports whether
+it is loaded and running. Add -print-only to print the unit instead, for
+installing it by other means such as Nix.`,
 		Options: `.TP
 .B start
-Start the daemon in the background. Creates a PID file and Unix socket.
+Start the daemon in the background. Takes the instance lock
+(prompt-pulse.lock in the state directory), then creates a PID file and Unix
+socket. A second daemon for the same state directory refuses to start and names
+the PID holding the lock. The lock is released by the kernel however the daemon
+exits, so a crashed daemon never blocks the next one.
 .TP
 .B stop
 Stop the running daemon gracefully.
@@ -254,8 +378,14 @@ prompt-pulse daemon start
 # Check status
 prompt-pulse daemon status
 
+# Apply config changes
+kill -HUP "$(cat "$XDG_RUNTIME_DIR/prompt-pulse.pid")"
+
 # Run in foreground for debugging
 prompt-pulse daemon start --foreground
+
+# Run at login under systemd or launchd
+prompt-pulse -install-service
 .fi`,
 		SeeAlso: `.BR prompt-pulse (1),
 .BR prompt-pulse.toml (5)`,
@@ -271,6 +401,8 @@ func dcManBanner() *ManPage {
 		Description: `The banner mode displays a compact inline summary of system status in the terminal.
 It adapts to terminal width with four layout modes: compact (<80 cols),
 standard (120+ cols), wide (160+ cols), and ultra-wide (200+ cols).
+Below banner.stack_below_width (70 cols by default) the sections are stacked
+vertically at full width instead, in the order of banner.stack_order.
 
 The banner reads data from the daemon's cache for instant display.
 If the daemon is not running, it will attempt a quick data fetch with a timeout.`,
@@ -285,7 +417,18 @@ Override terminal width detection.
 Use pre-rendered cached banner (default: true).
 .TP
 .B \-\-timeout <duration>
-Maximum time to wait for daemon data.`,
+Maximum time to wait for daemon data.
+.TP
+.B \-\-no-banner-cache
+Render fresh instead of reusing the last rendered banner. The cache is
+keyed on layout size, graphics protocol, and collector data, and entries
+expire after daemon_poll_interval.
+.TP
+.B \-\-redetect-terminal
+Query the terminal for its graphics protocol, cell size, and background
+color again. The
+result is otherwise cached per terminal for six hours, or until the
+terminal is resized.`,
 		Examples: `.nf
 # Show banner
 prompt-pulse banner
@@ -349,10 +492,18 @@ func dcManConfig() *ManPage {
 		Synopsis:  "$XDG_CONFIG_HOME/prompt-pulse/config.toml",
 		Description: `prompt-pulse uses a TOML configuration file with nested tables for each subsystem.
 The file is searched for in $XDG_CONFIG_HOME/prompt-pulse/config.toml, falling back
-to ~/.config/prompt-pulse/config.toml.
+to ~/.config/prompt-pulse/config.toml. $PPULSE_CONFIG names another file instead.
+
+Collector data, which can be rebuilt, is kept in general.cache_dir
+($XDG_CACHE_HOME/prompt-pulse). The instance lock, control socket, event log, and
+metric and spend history are kept in general.state_dir ($XDG_STATE_HOME/prompt-pulse).
+The first daemon start after an upgrade moves those files out of the cache directory,
+where earlier versions kept them, and records that it did so in .layout-v2 in the
+state directory.
 
 If no configuration file is found, built-in defaults are used. Environment variables
-can override specific settings (see ENVIRONMENT section below).
+can override specific settings (see ENVIRONMENT section below). prompt-pulse --init-config
+writes a commented starting file holding every setting.
 
 The configuration is organized into these top-level tables: general, layout,
 collectors, image, theme, shell, and banner.`,
@@ -368,6 +519,21 @@ Overrides collectors.billing.civo.api_key.
 .B DIGITALOCEAN_TOKEN
 Overrides collectors.billing.digitalocean.api_key.
 .TP
+.B HCLOUD_TOKEN
+Overrides collectors.billing.hetzner.api_key.
+.TP
+.B VULTR_API_KEY
+Overrides collectors.billing.vultr.api_key.
+.TP
+.B UPTIME_KUMA_API_KEY
+Overrides collectors.uptimekuma.api_key.
+.TP
+.B DOCKER_HOST
+Docker daemon address used when collectors.docker.host is empty.
+.TP
+.B OLLAMA_HOST
+Ollama server address used when collectors.ollama.url is empty.
+.TP
 .B PPULSE_PROTOCOL
 Overrides image.protocol.
 .TP
@@ -375,7 +541,31 @@ Overrides image.protocol.
 Overrides theme.name.
 .TP
 .B PPULSE_LAYOUT
-Overrides layout.preset.`,
+Overrides layout.preset.
+.TP
+.B PPULSE_CACHE_DIR
+Overrides general.cache_dir.
+.TP
+.B PPULSE_STATE_DIR
+Overrides general.state_dir.
+.TP
+.B PPULSE_HOST_PROFILE
+Overrides general.host_profile.
+.TP
+.B PPULSE_CONFIG
+Path of the configuration file, replacing the search above.
+.PP
+Each billing token and UPTIME_KUMA_API_KEY may instead be read from a file named by the same
+variable with a _FILE suffix (e.g. HCLOUD_TOKEN_FILE).
+.PP
+Any string value may reference environment variables as ${VAR} or ${VAR:-default}, expanded
+when the file is loaded; $$ is a literal $. A variable that is unset and has no default is an
+error naming the key.
+.PP
+Cached data for the keys listed in cache.encrypt (e.g. ["claude", "billing"]) is encrypted at
+rest with AES-GCM. The secret is read from cache.key_file, or kept in the keyring (macOS
+Keychain or secret-tool) when unset. Entries encrypted with a different key are reported as
+errors; the daemon refuses to start if the key cannot be loaded.`,
 		Examples: `.nf
 [general]
 log_level = "info"
@@ -0,0 +1,381 @@
+package docs
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// dcScaffoldWidth is the column comments in a scaffolded config wrap at.
+const dcScaffoldWidth = 78
+
+// ConfigScaffold returns a config.toml holding every table and key of
+// config.Config, generated from the struct definitions and filled in with
+// DefaultConfig's values. Each key is commented with its description from
+// the configuration reference and the environment variables that
+// override it. Keys whose default is unset, zero, or empty, and the
+// collectors' enabled flags, are commented out, so loading the scaffold
+// gives the same configuration as loading an empty file.
+func ConfigScaffold() string {
+	s := &dcScaffolder{
+		sections: make(map[string]ConfigSection),
+		env:      make(map[string][]config.EnvOverride),
+	}
+	for _, sec := range dcGenerateConfigRef().Sections {
+		s.sections[sec.Name] = sec
+	}
+	for _, o := range config.EnvOverrides() {
+		s.env[o.Key] = append(s.env[o.Key], o)
+	}
+	s.home, _ = os.UserHomeDir()
+
+	s.comment("prompt-pulse configuration, written by prompt-pulse -init-config.")
+	s.b.WriteString("#\n")
+	s.comment("Settings are filled in with their defaults. Commented-out settings are unset, shown with an example value; uncomment one to set it. Durations are strings such as \"30s\", \"15m\", or \"1h30m\". String values may reference environment variables as ${VAR} or ${VAR:-default}; write $$ for a literal $.")
+	s.table(nil, reflect.ValueOf(*config.DefaultConfig()), "", "", false)
+	return s.b.String()
+}
+
+// dcScaffolder accumulates a scaffolded config.
+type dcScaffolder struct {
+	b        strings.Builder
+	sections map[string]ConfigSection
+	env      map[string][]config.EnvOverride
+	home     string
+
+	// inTable tracks the nested array-of-table element types being
+	// written, so a self-referencing one is written once.
+	inTable []reflect.Type
+}
+
+// dcTextMarshaler is the type of values TOML writes as a string.
+var dcTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// dcIsTable reports whether values of t are written as a TOML table or
+// array of tables rather than a key's value.
+func dcIsTable(t reflect.Type) bool {
+	if t.Implements(dcTextMarshaler) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Struct && !t.Elem().Implements(dcTextMarshaler)
+	}
+	return false
+}
+
+// dcField is one keyed field of a config struct.
+type dcField struct {
+	key string
+	v   reflect.Value
+}
+
+// dcFields returns the TOML-keyed fields of struct v, keys first and
+// tables after, as TOML requires.
+func dcFields(v reflect.Value) (keys, tables []dcField) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if !f.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		if dcIsTable(f.Type) {
+			tables = append(tables, dcField{key, v.Field(i)})
+		} else {
+			keys = append(keys, dcField{key, v.Field(i)})
+		}
+	}
+	return keys, tables
+}
+
+// table writes struct v as the table at path, under desc when it has no
+// section of its own. Keys without a default are shown with their value
+// in example, if any. commented writes all of it commented out. A table
+// holding only tables gets no header of its own.
+func (s *dcScaffolder) table(path []string, v reflect.Value, desc, example string, commented bool) {
+	name := strings.Join(path, ".")
+	keys, tables := dcFields(v)
+	sec, documented := s.sections[name]
+	if documented {
+		desc = sec.Description
+	}
+	examples := dcExampleValues(example, "["+name+"]")
+
+	if len(path) > 0 && (len(keys) > 0 || desc != "") {
+		s.b.WriteString("\n")
+		s.comment(dcSentence(desc))
+		if len(keys) > 0 {
+			s.line("["+name+"]", commented)
+		}
+	}
+	for _, f := range keys {
+		doc := dcFindField(sec, f.key)
+		text := dcSentence(doc.Description)
+		if f.v.Type() == reflect.TypeOf(config.Duration{}) {
+			text = strings.TrimSpace(text + " A duration.")
+		}
+		for _, o := range s.env[name+"."+f.key] {
+			if strings.Contains(text, o.Name) {
+				continue
+			}
+			text = strings.TrimSpace(text + " Overridden by " + o.Name)
+			if o.File {
+				text += ", or a file named by " + o.Name + "_FILE"
+			}
+			text += "."
+		}
+		s.comment(text)
+
+		line := s.keyValue(f.key, f.v.Interface())
+		// A collector's enabled flag stays unset: set, it would stop
+		// credentials from deciding whether the collector runs.
+		off := commented || f.v.IsZero() || (len(path) == 2 && path[0] == "collectors" && f.key == "enabled")
+		if f.v.IsZero() {
+			if ex, ok := dcExampleValues(doc.Example, "")[f.key]; ok {
+				line = ex
+			} else if ex, ok := examples[f.key]; ok {
+				line = ex
+			}
+		}
+		s.line(line, off)
+	}
+
+	for _, f := range tables {
+		sub := append(path[:len(path):len(path)], f.key)
+		doc := dcFindField(sec, f.key)
+		if doc.Example == "" {
+			doc.Example = example
+		}
+		switch f.v.Kind() {
+		case reflect.Struct:
+			s.table(sub, f.v, doc.Description, doc.Example, commented || f.v.IsZero())
+		case reflect.Map:
+			s.mapTable(sub, f.v, doc.Description, doc.Example, commented)
+		case reflect.Slice:
+			s.arrayTables(sub, f.v, doc.Description, doc.Example)
+		}
+	}
+}
+
+// mapTable writes map v as the table at path, under desc when it has no
+// section of its own, with one key per entry. An empty map is written
+// commented out with the entries of example.
+func (s *dcScaffolder) mapTable(path []string, v reflect.Value, desc, example string, commented bool) {
+	name := strings.Join(path, ".")
+	sec, documented := s.sections[name]
+	if documented {
+		desc = sec.Description
+	}
+	header := "[" + name + "]"
+
+	if v.Len() == 0 {
+		s.b.WriteString("\n")
+		s.comment(dcSentence(desc))
+		if entries := dcExampleValues(example, header); len(entries) > 0 {
+			s.line(header, true)
+			for _, k := range dcSortedKeys(sec, entries) {
+				s.line(entries[k], true)
+			}
+		} else if strings.HasPrefix(example, "["+name) {
+			for _, l := range strings.Split(example, "\n") {
+				if l != "" {
+					s.line(l, true)
+				}
+			}
+		} else {
+			s.line(header, true)
+		}
+		return
+	}
+
+	entries := make(map[string]reflect.Value, v.Len())
+	for _, k := range v.MapKeys() {
+		entries[k.String()] = v.MapIndex(k)
+	}
+	keys := dcSortedKeys(sec, entries)
+	if v.Type().Elem().Kind() == reflect.Struct {
+		for _, k := range keys {
+			s.table(append(path[:len(path):len(path)], k), entries[k], desc, "", commented)
+		}
+		return
+	}
+
+	s.b.WriteString("\n")
+	s.comment(dcSentence(desc))
+	s.line(header, commented)
+	for _, k := range keys {
+		s.comment(dcSentence(dcFindField(sec, k).Description))
+		s.line(s.keyValue(k, entries[k].Interface()), commented)
+	}
+}
+
+// dcSortedKeys returns the keys of m in the order sec documents them,
+// then the undocumented ones alphabetically.
+func dcSortedKeys[V any](sec ConfigSection, m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	rank := func(k string) int {
+		for i, f := range sec.Fields {
+			if f.Name == k {
+				return i
+			}
+		}
+		return len(sec.Fields)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ri, rj := rank(keys[i]), rank(keys[j]); ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// arrayTables writes slice v as the array of tables at path, under desc.
+// Without elements, one is written commented out, with its keys shown
+// with their value in example, if any.
+func (s *dcScaffolder) arrayTables(path []string, v reflect.Value, desc, example string) {
+	elem := v.Type().Elem()
+	for _, t := range s.inTable {
+		if t == elem {
+			return
+		}
+	}
+	s.inTable = append(s.inTable, elem)
+	defer func() { s.inTable = s.inTable[:len(s.inTable)-1] }()
+
+	name := strings.Join(path, ".")
+	examples := dcExampleValues(example, "[["+name+"]]")
+	elems := []reflect.Value{reflect.New(elem).Elem()}
+	if v.Len() > 0 {
+		elems = elems[:0]
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, v.Index(i))
+		}
+	}
+	for i, e := range elems {
+		commented := v.Len() == 0
+		s.b.WriteString("\n")
+		if i == 0 {
+			s.comment(dcSentence(desc))
+		}
+		s.line("[["+name+"]]", commented)
+		keys, tables := dcFields(e)
+		for _, f := range keys {
+			line := s.keyValue(f.key, f.v.Interface())
+			if ex, ok := examples[f.key]; ok && commented {
+				line = ex
+			}
+			s.line(line, commented || f.v.IsZero())
+		}
+		for _, f := range tables {
+			if f.v.Kind() == reflect.Slice {
+				s.arrayTables(append(path[:len(path):len(path)], f.key), f.v, "", example)
+			}
+		}
+	}
+}
+
+// keyValue formats one key and value as TOML, writing paths under the
+// home directory relative to ${HOME} so the file suits other machines,
+// and durations without zero units, e.g. "15m" rather than "15m0s".
+func (s *dcScaffolder) keyValue(key string, v any) string {
+	switch x := v.(type) {
+	case string:
+		if s.home != "" && strings.HasPrefix(x, s.home+string(os.PathSeparator)) {
+			v = "${HOME}" + strings.TrimPrefix(x, s.home)
+		}
+	case config.Duration:
+		d := x.String()
+		if strings.HasSuffix(d, "m0s") {
+			d = strings.TrimSuffix(d, "0s")
+		}
+		if strings.HasSuffix(d, "h0m") {
+			d = strings.TrimSuffix(d, "0m")
+		}
+		v = d
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{key: v}); err != nil {
+		return fmt.Sprintf("%s = %q", key, fmt.Sprint(v))
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// line writes one line of TOML, commented out when off.
+func (s *dcScaffolder) line(l string, off bool) {
+	if off {
+		s.b.WriteString("# ")
+	}
+	s.b.WriteString(l + "\n")
+}
+
+// comment writes text as "# " lines wrapped at dcScaffoldWidth, and
+// nothing for "".
+func (s *dcScaffolder) comment(text string) {
+	if text == "" {
+		return
+	}
+	line := "#"
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > dcScaffoldWidth && line != "#" {
+			s.b.WriteString(line + "\n")
+			line = "#"
+		}
+		line += " " + word
+	}
+	s.b.WriteString(line + "\n")
+}
+
+// dcFindField returns the field of sec named key, or the zero field.
+func dcFindField(sec ConfigSection, key string) ConfigField {
+	for _, f := range sec.Fields {
+		if f.Name == key {
+			return f
+		}
+	}
+	return ConfigField{}
+}
+
+// dcSentence returns text ending in a full stop, or "" for "".
+func dcSentence(text string) string {
+	if text == "" || strings.HasSuffix(text, ".") {
+		return text
+	}
+	return text + "."
+}
+
+// dcExampleValues returns the "key = value" lines of a TOML example by
+// key: those before any table header, or, given header, those of that
+// table.
+func dcExampleValues(example, header string) map[string]string {
+	out := make(map[string]string)
+	in := header == ""
+	for _, l := range strings.Split(example, "\n") {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "[") {
+			in = l == header
+			continue
+		}
+		if key, _, ok := strings.Cut(l, " = "); ok && in {
+			out[key] = l
+		}
+	}
+	return out
+}
@@ -100,6 +100,7 @@ func dcBashShell() ShellInfo {
 			"TUI toggle hotkey via `bind -x`",
 			"Tab completions via `complete -C`",
 			"Daemon auto-start on first prompt",
+			"Starship segment cached in PROMPT_PULSE_SEGMENT with `-prompt-segment`, re-rendered only when collector data changes",
 		},
 		Caveats: []string{
 			"PROMPT_COMMAND is appended, not replaced, to preserve existing hooks",
@@ -131,6 +132,7 @@ func dcZshShell() ShellInfo {
 			"TUI toggle hotkey via ZLE widget with /dev/tty",
 			"Tab completions via compdef",
 			"Daemon auto-start on first precmd",
+			"Starship segment cached in PROMPT_PULSE_SEGMENT with `-prompt-segment`, re-rendered only when collector data changes",
 		},
 		Caveats: []string{
 			"ZLE widget reads from /dev/tty to avoid interfering with line editing",
@@ -162,11 +164,13 @@ func dcFishShell() ShellInfo {
 			"TUI toggle hotkey via bind in normal, insert, and visual modes",
 			"Tab completions via complete command",
 			"Daemon auto-start on first prompt event",
+			"Starship segment cached in PROMPT_PULSE_SEGMENT with `-prompt-segment`, re-rendered only when collector data changes",
 		},
 		Caveats: []string{
 			"Fish uses `source` instead of `eval` for initialization",
 			"Keybindings must be registered for all three modes (normal, insert, visual)",
 			"Fish completions use a different syntax than Bash/Zsh",
+			"The `-prompt-segment` hook needs fish 3.5+ for the `path mtime` builtin",
 		},
 		Example: `# prompt-pulse shell integration for Fish
 # Add to ~/.config/fish/config.fish
@@ -196,6 +200,7 @@ func dcKshShell() ShellInfo {
 		},
 		Caveats: []string{
 			"Ksh93 or mksh required; pdksh is not supported",
+			"No prompt hook, so `-prompt-segment` is ignored",
 			"KEYBD trap is ksh93-specific and not available in all implementations",
 			"No native tab completion support; uses basic word expansion",
 		},
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// exRowKeys names, for collectors that report a list, the field whose
+// elements become the CSV rows. Other collectors export one row.
+var exRowKeys = map[string]string{
+	"claude":     "accounts",
+	"ollama":     "models",
+	"billing":    "providers",
+	"checks":     "checks",
+	"remote":     "hosts",
+	"k8s":        "clusters",
+	"tailscale":  "peers",
+	"uptimekuma": "monitors",
+	"docker":     "containers",
+	"gpu":        "gpus",
+	"storage":    "mounts",
+	"shell":      "sessions",
+}
+
+// exStatusColumns lead every CSV file, named apart from the data fields. A
+// collector without data has a single row filling only these.
+var exStatusColumns = []string{"cache_status", "cache_updated_at", "cache_reason"}
+
+// WriteCSVDir writes one <collector>.csv per collector into dir, creating
+// it if needed.
+func WriteCSVDir(dir string, d *Dump) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range Collectors {
+		data, err := CSV(d.Collectors[name], name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".csv"), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSVZip writes one <collector>.csv per collector into a zip archive
+// on w.
+func WriteCSVZip(w io.Writer, d *Dump) error {
+	zw := zip.NewWriter(w)
+	for _, name := range Collectors {
+		data, err := CSV(d.Collectors[name], name)
+		if err != nil {
+			return err
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name + ".csv", Method: zip.Deflate, Modified: d.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// CSV renders the entry of the named collector as CSV: the status columns,
+// then one column per field, with nested objects flattened to dotted names
+// and lists kept as JSON. Collectors that report a list have a row per
+// element. An entry without rows still has one, so its status is never
+// lost.
+func CSV(e Entry, name string) ([]byte, error) {
+	var updated string
+	if e.UpdatedAt != nil {
+		updated = e.UpdatedAt.Format(time.RFC3339)
+	}
+
+	var rows []map[string]string
+	if e.Data != nil {
+		var err error
+		if rows, err = exRows(e.Data, exRowKeys[name]); err != nil {
+			return nil, fmt.Errorf("export: %s: %w", name, err)
+		}
+	}
+
+	seen := map[string]bool{}
+	var fields []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(append(append([]string{}, exStatusColumns...), fields...))
+	if len(rows) == 0 {
+		_ = w.Write([]string{e.Status, updated, e.Reason})
+	}
+	for _, row := range rows {
+		record := []string{e.Status, updated, e.Reason}
+		for _, f := range fields {
+			record = append(record, row[f])
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// exRows flattens data into CSV rows: one per element of the list at
+// rowKey, or the whole document when rowKey is empty.
+func exRows(data []byte, rowKey string) ([]map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	items := []interface{}{doc}
+	if rowKey != "" {
+		obj, _ := doc.(map[string]interface{})
+		list, _ := obj[rowKey].([]interface{})
+		items = list
+	}
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		row := map[string]string{}
+		exFlatten(row, "", item)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// exFlatten stores v in row under prefix, descending into objects with
+// dotted names. Lists are stored as JSON and null as an empty cell.
+func exFlatten(row map[string]string, prefix string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			exFlatten(row, key, child)
+		}
+	case nil:
+		row[exKey(prefix)] = ""
+	case string:
+		row[exKey(prefix)] = v
+	case json.Number:
+		row[exKey(prefix)] = v.String()
+	case bool:
+		row[exKey(prefix)] = fmt.Sprint(v)
+	default:
+		b, _ := json.Marshal(v)
+		row[exKey(prefix)] = string(b)
+	}
+}
+
+// exKey names the column for a value: its dotted path, or "value" for a
+// document that is not an object.
+func exKey(prefix string) string {
+	if prefix == "" {
+		return "value"
+	}
+	return prefix
+}
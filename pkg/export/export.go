@@ -0,0 +1,140 @@
+// Package export dumps the daemon's cached collector data for ad-hoc
+// analysis, as one JSON document or as one CSV file per collector. It only
+// reads the cache directory: no collector runs and nothing touches the
+// network. Collectors without usable data are listed with a reason rather
+// than left out, so a consumer can tell "no data" from zero.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+)
+
+// Collectors lists the cached collectors an export covers, in output order.
+var Collectors = []string{
+	"claude",
+	"ollama",
+	"billing",
+	"checks",
+	"remote",
+	"k8s",
+	"sysmetrics",
+	"gpu",
+	"storage",
+	"tailscale",
+	"uptimekuma",
+	"docker",
+	"weather",
+	"shell",
+}
+
+// Entry statuses. Only StatusOK and StatusStale entries carry data.
+const (
+	StatusOK      = "ok"      // fresh data
+	StatusStale   = "stale"   // data older than the stale bound
+	StatusExpired = "expired" // data older than the expiry bound, omitted
+	StatusMissing = "missing" // no cache file
+	StatusError   = "error"   // unreadable or not valid JSON
+)
+
+// Dump is every collector's cached data at one point in time.
+type Dump struct {
+	// GeneratedAt is when the dump was taken.
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// CacheDir is the directory the data was read from.
+	CacheDir string `json:"cache_dir"`
+
+	// Collectors maps each collector name to its entry.
+	Collectors map[string]Entry `json:"collectors"`
+}
+
+// Entry is one collector's cached data and how it stands.
+type Entry struct {
+	// Status is one of the Status* values.
+	Status string `json:"status"`
+
+	// UpdatedAt is when the cache file was last written, or nil if there
+	// is none.
+	UpdatedAt *time.Time `json:"updated_at"`
+
+	// Age is the time since UpdatedAt, e.g. "12m".
+	Age string `json:"age,omitempty"`
+
+	// Reason explains why Data is null.
+	Reason string `json:"reason,omitempty"`
+
+	// Data is the collector's cached JSON, or null.
+	Data json.RawMessage `json:"data"`
+}
+
+// Load reads every collector's cache file from dir, classifying its age
+// under s as of now. Problems with one collector are recorded in its
+// entry and do not stop the others.
+func Load(dir string, s cache.Staleness, now time.Time) *Dump {
+	d := &Dump{
+		GeneratedAt: now,
+		CacheDir:    dir,
+		Collectors:  make(map[string]Entry, len(Collectors)),
+	}
+	for _, name := range Collectors {
+		d.Collectors[name] = exLoadEntry(filepath.Join(dir, name+".json"), s, now)
+	}
+	return d
+}
+
+// exLoadEntry reads one collector's cache file.
+func exLoadEntry(path string, s cache.Staleness, now time.Time) Entry {
+	age, ok := cache.FileAge(path, now)
+	if !ok {
+		return Entry{Status: StatusMissing, Reason: "no cached data; is the daemon running with this collector enabled?"}
+	}
+	updated := now.Add(-age).UTC()
+	e := Entry{UpdatedAt: &updated, Age: cache.FormatAge(age)}
+
+	switch s.Classify(age) {
+	case cache.Expired:
+		e.Status = StatusExpired
+		e.Reason = fmt.Sprintf("cached data is %s old, past expire_after (%s)", e.Age, s.ExpireAfter)
+		return e
+	case cache.Stale:
+		e.Status = StatusStale
+	default:
+		e.Status = StatusOK
+	}
+
+	data, err := cache.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Entry{Status: StatusMissing, Reason: "no cached data"}
+		}
+		e.Status, e.Reason = StatusError, err.Error()
+		return e
+	}
+	if !json.Valid(data) {
+		e.Status, e.Reason = StatusError, "cached data is not valid JSON"
+		return e
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		e.Status, e.Reason = StatusError, err.Error()
+		return e
+	}
+	e.Data = compact.Bytes()
+	return e
+}
+
+// WriteJSON writes d to w as indented JSON.
+func WriteJSON(w io.Writer, d *Dump) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+)
+
+// writeCache writes a collector cache file aged by age.
+func writeCache(t *testing.T, dir, name, data string, now time.Time, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(dir, name+".json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := now.Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDump(t *testing.T) *Dump {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now()
+	writeCache(t, dir, "checks", `{"checks":[{"name":"router","state":"down","latency_ms":0},{"name":"nas","state":"up","latency_ms":1.5}],"up":1}`, now, time.Minute)
+	writeCache(t, dir, "billing", `{"providers":[{"name":"civo","month_to_date":0,"resources":[{"name":"vm"}]}]}`, now, 2*time.Hour)
+	writeCache(t, dir, "sysmetrics", `{"cpu":{"total_percent":12.5},"disks":[]}`, now, 3*24*time.Hour)
+	writeCache(t, dir, "k8s", `{"clusters":`, now, time.Minute)
+	return Load(dir, cache.Staleness{StaleAfter: time.Hour, ExpireAfter: 24 * time.Hour}, now)
+}
+
+func TestLoad_Statuses(t *testing.T) {
+	d := testDump(t)
+
+	if len(d.Collectors) != len(Collectors) {
+		t.Errorf("got %d collectors, want every one of %d", len(d.Collectors), len(Collectors))
+	}
+	tests := []struct {
+		name     string
+		status   string
+		wantData bool
+	}{
+		{"checks", StatusOK, true},
+		{"billing", StatusStale, true},
+		{"sysmetrics", StatusExpired, false},
+		{"k8s", StatusError, false},
+		{"claude", StatusMissing, false},
+	}
+	for _, tt := range tests {
+		e := d.Collectors[tt.name]
+		if e.Status != tt.status || (e.Data != nil) != tt.wantData {
+			t.Errorf("%s = %s with data %s, want %s", tt.name, e.Status, e.Data, tt.status)
+		}
+		if !tt.wantData && e.Reason == "" {
+			t.Errorf("%s has no reason for its missing data", tt.name)
+		}
+		if (tt.status == StatusMissing) != (e.UpdatedAt == nil) {
+			t.Errorf("%s updated_at = %v", tt.name, e.UpdatedAt)
+		}
+	}
+}
+
+func TestWriteJSON_NullsMissingData(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testDump(t)); err != nil {
+		t.Fatalf("WriteJSON() error: %v", err)
+	}
+
+	var got struct {
+		Collectors map[string]map[string]interface{} `json:"collectors"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	claude, ok := got.Collectors["claude"]
+	if !ok {
+		t.Fatal("missing collector omitted from the output")
+	}
+	if data, ok := claude["data"]; !ok || data != nil || claude["updated_at"] != nil {
+		t.Errorf("claude = %v, want explicit null data and updated_at", claude)
+	}
+	checks := got.Collectors["checks"]["data"].(map[string]interface{})
+	if checks["up"] != 1.0 {
+		t.Errorf("checks data = %v, want the cached document", checks)
+	}
+}
+
+func readCSV(t *testing.T, data []byte) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v\n%s", err, data)
+	}
+	return records
+}
+
+func TestCSV(t *testing.T) {
+	d := testDump(t)
+
+	data, err := CSV(d.Collectors["checks"], "checks")
+	if err != nil {
+		t.Fatalf("CSV() error: %v", err)
+	}
+	records := readCSV(t, data)
+	want := []string{"cache_status", "cache_updated_at", "cache_reason", "latency_ms", "name", "state"}
+	if strings.Join(records[0], ",") != strings.Join(want, ",") {
+		t.Errorf("header = %q, want %q", records[0], want)
+	}
+	if len(records) != 3 || records[1][0] != StatusOK || records[1][3] != "0" || records[2][4] != "nas" {
+		t.Errorf("rows = %q, want one per check with zero kept", records[1:])
+	}
+
+	data, _ = CSV(d.Collectors["billing"], "billing")
+	records = readCSV(t, data)
+	if got := records[1][len(records[1])-1]; got != `[{"name":"vm"}]` {
+		t.Errorf("nested list cell = %q, want JSON", got)
+	}
+
+	data, _ = CSV(d.Collectors["claude"], "claude")
+	records = readCSV(t, data)
+	if len(records) != 2 || records[1][0] != StatusMissing || records[1][2] == "" {
+		t.Errorf("missing collector = %q, want a status row with a reason", records)
+	}
+
+	data, _ = CSV(Entry{Status: StatusOK, Data: json.RawMessage(`{"cpu":{"total_percent":3}}`)}, "sysmetrics")
+	if records = readCSV(t, data); records[0][3] != "cpu.total_percent" || records[1][3] != "3" {
+		t.Errorf("single-row collector = %q, want flattened dotted columns", records)
+	}
+}
+
+func TestWriteCSVDirAndZip(t *testing.T) {
+	d := testDump(t)
+
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := WriteCSVDir(dir, d); err != nil {
+		t.Fatalf("WriteCSVDir() error: %v", err)
+	}
+	for _, name := range Collectors {
+		if _, err := os.Stat(filepath.Join(dir, name+".csv")); err != nil {
+			t.Errorf("%s.csv: %v", name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSVZip(&buf, d); err != nil {
+		t.Fatalf("WriteCSVZip() error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	if len(zr.File) != len(Collectors) || zr.File[0].Name != Collectors[0]+".csv" {
+		t.Errorf("zip has %d files starting %s", len(zr.File), zr.File[0].Name)
+	}
+}
@@ -0,0 +1,288 @@
+// Package httpx provides the HTTP client shared by collectors. Requests go
+// through a transport that applies a per-host rate limit, bounds each
+// attempt with a timeout, retries idempotent requests that fail with a
+// network error, 429, or 5xx status after an exponential backoff with
+// jitter (or the server's Retry-After), and sends a common User-Agent. The
+// requests, retries, and last status of each collector are counted for
+// the daemon's health report.
+package httpx
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Default settings.
+const (
+	DefaultTimeout        = 30 * time.Second
+	DefaultMaxRetries     = 3
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultMaxRetryDelay  = 30 * time.Second
+)
+
+// UserAgent is sent with requests that do not set their own. main adds the
+// version at startup.
+var UserAgent = "prompt-pulse"
+
+// Settings are the process-wide retry and rate limit settings.
+type Settings struct {
+	// MaxRetries is how many times a failed request is retried. Zero
+	// disables retrying.
+	MaxRetries int
+
+	// RetryBaseDelay is the delay before the first retry, doubled for
+	// each one after it and jittered.
+	RetryBaseDelay time.Duration
+
+	// MaxRetryDelay caps the delay between attempts. A response whose
+	// Retry-After asks for longer is returned rather than waited for.
+	MaxRetryDelay time.Duration
+
+	// RateLimits maps a host name to the requests per second allowed to
+	// it. Hosts not listed are not limited.
+	RateLimits map[string]float64
+}
+
+// DefaultSettings returns the settings used until Configure is called.
+func DefaultSettings() Settings {
+	return Settings{
+		MaxRetries:     DefaultMaxRetries,
+		RetryBaseDelay: DefaultRetryBaseDelay,
+		MaxRetryDelay:  DefaultMaxRetryDelay,
+	}
+}
+
+// current holds the process-wide settings and the rate limiter of each
+// limited host, created on first use.
+var current = struct {
+	mu       sync.Mutex
+	settings Settings
+	limiters map[string]*rate.Limiter
+}{settings: DefaultSettings()}
+
+// Configure sets the process-wide settings and returns the previous ones.
+// Rate limits start afresh.
+func Configure(s Settings) Settings {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	prev := current.settings
+	current.settings = s
+	current.limiters = nil
+	return prev
+}
+
+// settingsFor returns the current settings and the rate limiter for host,
+// or nil if it is not limited.
+func settingsFor(host string) (Settings, *rate.Limiter) {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	s := current.settings
+	rps := s.RateLimits[host]
+	if rps <= 0 {
+		return s, nil
+	}
+	l, ok := current.limiters[host]
+	if !ok {
+		if current.limiters == nil {
+			current.limiters = make(map[string]*rate.Limiter)
+		}
+		l = rate.NewLimiter(rate.Limit(rps), max(1, int(rps)))
+		current.limiters[host] = l
+	}
+	return s, l
+}
+
+// Config configures one client.
+type Config struct {
+	// Name is the collector the client belongs to. Clients with the same
+	// name share their Stats.
+	Name string
+
+	// Timeout bounds each attempt, including reading the response body.
+	// Zero uses DefaultTimeout.
+	Timeout time.Duration
+
+	// NoRetry sends each request once, for callers whose failures are
+	// the point, such as health checks.
+	NoRetry bool
+
+	// Transport sends the requests. Nil uses http.DefaultTransport; tests
+	// inject their own.
+	Transport http.RoundTripper
+}
+
+// New returns an http.Client sending requests as cfg describes.
+func New(cfg Config) *http.Client {
+	t := &transport{
+		base:    cfg.Transport,
+		timeout: cfg.Timeout,
+		noRetry: cfg.NoRetry,
+		stats:   statsFor(cfg.Name),
+		sleep:   sleepContext,
+	}
+	if t.base == nil {
+		t.base = http.DefaultTransport
+	}
+	if t.timeout <= 0 {
+		t.timeout = DefaultTimeout
+	}
+	return &http.Client{Transport: t}
+}
+
+// transport is the http.RoundTripper of clients returned by New.
+type transport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	noRetry bool
+	stats   *counter
+
+	// sleep waits between attempts; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// RoundTrip sends req, retrying it while it fails transiently.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s, limiter := settingsFor(req.URL.Hostname())
+	retries := s.MaxRetries
+	if t.noRetry || !idempotent(req) {
+		retries = 0
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.send(req)
+		t.stats.record(resp, err, attempt > 0)
+		if attempt >= retries || !retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := backoff(s, attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp, time.Now()); ok {
+				if after > s.MaxRetryDelay {
+					return resp, nil
+				}
+				delay = after
+			}
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// send makes one attempt within the timeout, which stays in force until
+// the response body is closed.
+func (t *transport) send(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody ends an attempt's timeout when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// idempotent reports whether req may be sent again: its method is
+// idempotent and its body, if any, can be recreated.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return false
+}
+
+// retryable reports whether an attempt failed in a way worth retrying: a
+// network error, too many requests, or a server error other than 501.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+}
+
+// backoff returns the delay before retry attempt+1: the base delay doubled
+// per attempt, capped at the maximum, and jittered to between half and all
+// of that so clients that failed together do not retry together.
+func backoff(s Settings, attempt int) time.Duration {
+	d := s.RetryBaseDelay
+	for i := 0; i < attempt && d < s.MaxRetryDelay; i++ {
+		d *= 2
+	}
+	if s.MaxRetryDelay > 0 && d > s.MaxRetryDelay {
+		d = s.MaxRetryDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter returns the delay a response's Retry-After header asks for,
+// given in seconds or as an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx ends.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,246 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// fakeServer answers each attempt with the next of its statuses, then
+// 200, and records the requests it saw.
+type fakeServer struct {
+	mu       sync.Mutex
+	statuses []int
+	header   http.Header
+	requests []*http.Request
+	bodies   []string
+}
+
+func (s *fakeServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	if r.Body != nil {
+		b, _ := io.ReadAll(r.Body)
+		s.bodies = append(s.bodies, string(b))
+	}
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	h := s.header
+	if h == nil {
+		h = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: h, Body: io.NopCloser(strings.NewReader("ok")), Request: r}, nil
+}
+
+// newTestClient returns a client sending through rt that records its
+// sleeps instead of waiting, with fast retry settings for the test.
+func newTestClient(t *testing.T, name string, rt http.RoundTripper, noRetry bool) (*http.Client, *[]time.Duration) {
+	t.Helper()
+	prev := Configure(Settings{MaxRetries: 3, RetryBaseDelay: 100 * time.Millisecond, MaxRetryDelay: time.Second})
+	t.Cleanup(func() { Configure(prev) })
+	c := New(Config{Name: name, Transport: rt, NoRetry: noRetry})
+	var slept []time.Duration
+	c.Transport.(*transport).sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return c, &slept
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	srv := &fakeServer{statuses: []int{503, 502}}
+	c, slept := newTestClient(t, t.Name(), srv, false)
+
+	resp, err := c.Get("https://api.example.com/v1/things")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || len(srv.requests) != 3 {
+		t.Errorf("status %d after %d attempts, want 200 after 3", resp.StatusCode, len(srv.requests))
+	}
+	if ua := srv.requests[0].Header.Get("User-Agent"); ua != UserAgent {
+		t.Errorf("User-Agent = %q, want %q", ua, UserAgent)
+	}
+	if len(*slept) != 2 || (*slept)[0] < 50*time.Millisecond || (*slept)[0] > 100*time.Millisecond ||
+		(*slept)[1] < 100*time.Millisecond || (*slept)[1] > 200*time.Millisecond {
+		t.Errorf("backoff = %v, want about 100ms then 200ms, jittered down to half", *slept)
+	}
+
+	s, ok := StatsFor(t.Name())
+	if !ok || s.Requests != 3 || s.Retries != 2 || s.LastStatus != 200 || s.LastError != "" {
+		t.Errorf("stats = %+v, want 3 requests, 2 retries, last status 200", s)
+	}
+}
+
+func TestRetryLimitsAndMethods(t *testing.T) {
+	srv := &fakeServer{statuses: []int{500, 500, 500, 500, 500}}
+	c, _ := newTestClient(t, t.Name(), srv, false)
+	resp, err := c.Get("https://api.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 500 || len(srv.requests) != 4 {
+		t.Errorf("status %d after %d attempts, want the 500 after 1 try and 3 retries", resp.StatusCode, len(srv.requests))
+	}
+
+	for name, tc := range map[string]struct {
+		method  string
+		status  int
+		noRetry bool
+	}{
+		"POST is not idempotent": {http.MethodPost, 503, false},
+		"404 is not transient":   {http.MethodGet, 404, false},
+		"501 is not transient":   {http.MethodGet, 501, false},
+		"NoRetry":                {http.MethodGet, 503, true},
+	} {
+		srv := &fakeServer{statuses: []int{tc.status}}
+		c, _ := newTestClient(t, t.Name(), srv, tc.noRetry)
+		req, _ := http.NewRequest(tc.method, "https://api.example.com/", strings.NewReader("{}"))
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status || len(srv.requests) != 1 {
+			t.Errorf("%s: status %d after %d attempts, want %d after 1", name, resp.StatusCode, len(srv.requests), tc.status)
+		}
+	}
+
+	// A PUT body is sent again on retry.
+	srv = &fakeServer{statuses: []int{503}}
+	c, _ = newTestClient(t, t.Name(), srv, false)
+	req, _ := http.NewRequest(http.MethodPut, "https://api.example.com/", strings.NewReader("payload"))
+	if resp, err := c.Do(req); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+	if strings.Join(srv.bodies, ",") != "payload,payload" {
+		t.Errorf("bodies = %q, want the payload twice", srv.bodies)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv := &fakeServer{statuses: []int{429}, header: http.Header{"Retry-After": {"1"}}}
+	c, slept := newTestClient(t, t.Name(), srv, false)
+	resp, err := c.Get("https://api.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || len(*slept) != 1 || (*slept)[0] != time.Second {
+		t.Errorf("status %d after waiting %v, want 200 after the 1s Retry-After", resp.StatusCode, *slept)
+	}
+
+	// Longer than MaxRetryDelay, the 429 is returned rather than waited on.
+	srv = &fakeServer{statuses: []int{429}, header: http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}}
+	c, slept = newTestClient(t, t.Name(), srv, false)
+	resp, err = c.Get("https://api.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 429 || len(*slept) != 0 {
+		t.Errorf("status %d after waiting %v, want the 429 at once", resp.StatusCode, *slept)
+	}
+}
+
+func TestNetworkErrorsAndTimeout(t *testing.T) {
+	attempts := 0
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			// The first attempt hangs until its own timeout.
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return nil, errors.New("connection reset by peer")
+	})
+	prev := Configure(Settings{MaxRetries: 2, RetryBaseDelay: time.Millisecond, MaxRetryDelay: time.Millisecond})
+	defer Configure(prev)
+	c := New(Config{Name: t.Name(), Transport: rt, Timeout: 20 * time.Millisecond})
+
+	_, err := c.Get("https://api.example.com/")
+	if err == nil || !strings.Contains(err.Error(), "connection reset") || attempts != 3 {
+		t.Errorf("err = %v after %d attempts, want the last network error after 3", err, attempts)
+	}
+	if s, _ := StatsFor(t.Name()); s.LastStatus != 0 || !strings.Contains(s.LastError, "connection reset") {
+		t.Errorf("stats = %+v, want the network error recorded", s)
+	}
+
+	// Cancelling the request stops retrying.
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts = 0
+	c = New(Config{Name: t.Name(), Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		cancel()
+		return nil, errors.New("connection refused")
+	})})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/", nil)
+	if _, err := c.Do(req); err == nil || attempts != 1 {
+		t.Errorf("err = %v after %d attempts, want an error after 1", err, attempts)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	prev := Configure(Settings{RateLimits: map[string]float64{"slow.example.com": 20}})
+	defer Configure(prev)
+	c := New(Config{Name: t.Name(), Transport: &fakeServer{}})
+
+	get := func(url string) {
+		resp, err := c.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		get("https://fast.example.com/")
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("30 requests to an unlimited host took %v", elapsed)
+	}
+
+	// A burst of 20 goes at once; the 10 after it wait 50ms each.
+	start = time.Now()
+	for i := 0; i < 30; i++ {
+		get("https://slow.example.com/")
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("30 requests at 20/s took %v, want at least 400ms", elapsed)
+	}
+}
+
+func TestAllStats(t *testing.T) {
+	c, _ := newTestClient(t, "all-stats", &fakeServer{}, false)
+	resp, err := c.Get("https://api.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	New(Config{Name: "never-used"})
+
+	all := AllStats()
+	if s, ok := all["all-stats"]; !ok || s.Requests != 1 {
+		t.Errorf("AllStats()[all-stats] = %+v, want 1 request", s)
+	}
+	if _, ok := all["never-used"]; ok {
+		t.Error("AllStats() lists a client that sent nothing")
+	}
+}
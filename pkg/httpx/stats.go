@@ -0,0 +1,94 @@
+package httpx
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Stats counts the requests of the clients sharing a name.
+type Stats struct {
+	// Requests counts attempts sent, retries included.
+	Requests int64 `json:"requests"`
+	Retries  int64 `json:"retries"`
+
+	// LastStatus is the status code of the last attempt, or zero when it
+	// failed without a response; LastError says why.
+	LastStatus  int       `json:"last_status,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastRequest time.Time `json:"last_request"`
+}
+
+// counter accumulates the Stats of one name.
+type counter struct {
+	mu sync.Mutex
+	s  Stats
+}
+
+// counters holds the counter of each client name.
+var counters = struct {
+	mu     sync.Mutex
+	byName map[string]*counter
+}{byName: make(map[string]*counter)}
+
+// statsFor returns the counter of name, creating it on first use.
+func statsFor(name string) *counter {
+	counters.mu.Lock()
+	defer counters.mu.Unlock()
+	c, ok := counters.byName[name]
+	if !ok {
+		c = &counter{}
+		counters.byName[name] = c
+	}
+	return c
+}
+
+// record counts one attempt and its outcome.
+func (c *counter) record(resp *http.Response, err error, retry bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.s.Requests++
+	if retry {
+		c.s.Retries++
+	}
+	c.s.LastRequest = time.Now()
+	c.s.LastStatus, c.s.LastError = 0, ""
+	if err != nil {
+		c.s.LastError = err.Error()
+	} else {
+		c.s.LastStatus = resp.StatusCode
+	}
+}
+
+// StatsFor returns the Stats of the clients named name, and false if none
+// has sent a request.
+func StatsFor(name string) (Stats, bool) {
+	counters.mu.Lock()
+	c, ok := counters.byName[name]
+	counters.mu.Unlock()
+	if !ok {
+		return Stats{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.s, c.s.Requests > 0
+}
+
+// AllStats returns the Stats of every client name that has sent a
+// request.
+func AllStats() map[string]Stats {
+	counters.mu.Lock()
+	names := make([]string, 0, len(counters.byName))
+	for name := range counters.byName {
+		names = append(names, name)
+	}
+	counters.mu.Unlock()
+
+	out := make(map[string]Stats, len(names))
+	for _, name := range names {
+		if s, ok := StatsFor(name); ok {
+			out[name] = s
+		}
+	}
+	return out
+}
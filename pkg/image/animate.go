@@ -0,0 +1,243 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
+)
+
+// DefaultMaxAnimationFrames caps how many frames of an animated image are
+// decoded and transmitted when config.ImageConfig.MaxAnimationFrames is
+// unset. Frames past the cap are dropped, so long GIFs play a truncated
+// loop rather than filling the render cache.
+const DefaultMaxAnimationFrames = 64
+
+// imgAnimatedCacheProtocol is the CacheKey protocol for Kitty animation
+// sequences, keeping them apart from static renders of the same image.
+const imgAnimatedCacheProtocol = "kitty-animated"
+
+// imgMinFrameDelay is substituted for GIF delays of 0 or 10ms, matching
+// how browsers play such frames.
+const imgMinFrameDelay = 100 * time.Millisecond
+
+// Animation is a decoded multi-frame image. Each frame is fully composited
+// onto the logical screen, so any frame can be displayed on its own.
+type Animation struct {
+	Frames []image.Image
+	Delays []time.Duration
+
+	// LoopCount follows image/gif: 0 loops forever, -1 plays once, and
+	// n > 0 plays n+1 times.
+	LoopCount int
+}
+
+// DecodeAnimation decodes every frame of a GIF, applying each frame's
+// disposal method so the returned frames are complete images. At most
+// maxFrames frames are kept; maxFrames <= 0 uses DefaultMaxAnimationFrames.
+func DecodeAnimation(r io.Reader, maxFrames int) (*Animation, error) {
+	if maxFrames <= 0 {
+		maxFrames = DefaultMaxAnimationFrames
+	}
+
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode gif: %w", err)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("decode gif: no frames")
+	}
+
+	screen := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if screen.Empty() {
+		screen = g.Image[0].Bounds()
+	}
+	canvas := image.NewNRGBA(screen)
+
+	n := len(g.Image)
+	if n > maxFrames {
+		n = maxFrames
+	}
+	anim := &Animation{
+		Frames:    make([]image.Image, 0, n),
+		Delays:    make([]time.Duration, 0, n),
+		LoopCount: g.LoopCount,
+	}
+
+	for i := 0; i < n; i++ {
+		frame := g.Image[i]
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var previous *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			previous = imgCloneNRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		anim.Frames = append(anim.Frames, imgCloneNRGBA(canvas))
+
+		delay := imgMinFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		anim.Delays = append(anim.Delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return anim, nil
+}
+
+// RenderAnimated renders an animation at the given cell dimensions. On the
+// Kitty protocol every frame is transmitted with animation control codes
+// and playback starts immediately; the whole sequence is cached under a
+// hash of all frames. Other protocols, and single-frame animations, render
+// frame 0 through Render.
+func (r *Renderer) RenderAnimated(anim *Animation, width, height int) (string, error) {
+	if anim == nil || len(anim.Frames) == 0 {
+		return "", fmt.Errorf("animation has no frames")
+	}
+	if r.protocol != terminal.ProtocolKitty || len(anim.Frames) == 1 {
+		return r.Render(anim.Frames[0], width, height)
+	}
+
+	frames := anim.Frames
+	if limit := r.maxAnimationFrames(); len(frames) > limit {
+		frames = frames[:limit]
+	}
+
+	hash := r.prep.key(r.hashAnimation(frames, anim.Delays))
+	key := MakeCacheKey(r.cacheFormat(imgAnimatedCacheProtocol), width, height, hash)
+	if cached, ok := r.cache.Get(key); ok {
+		return cached, nil
+	}
+
+	cellW := r.caps.Size.CellW
+	cellH := r.caps.Size.CellH
+	pixels := make([][]byte, len(frames))
+	var frameW, frameH int
+	for i, f := range frames {
+		resized := ImageToNRGBA(ResizeToFit(r.preprocessFrame(f, width, height), width, height, cellW, cellH))
+		frameW, frameH = resized.Bounds().Dx(), resized.Bounds().Dy()
+		pixels[i] = imgNRGBAPixels(resized)
+	}
+
+	gaps := make([]int, len(frames))
+	for i := range gaps {
+		gaps[i] = int(imgMinFrameDelay / time.Millisecond)
+		if i < len(anim.Delays) && anim.Delays[i] > 0 {
+			gaps[i] = int(anim.Delays[i] / time.Millisecond)
+		}
+	}
+
+	id := binary.BigEndian.Uint32(hash[:4])
+	if id == 0 {
+		id = 1
+	}
+	rendered := imgWrapPassthrough(imgKittyAnimation(id, pixels, frameW, frameH, gaps,
+		imgKittyLoops(anim.LoopCount), height, width), r.passthrough)
+
+	r.cache.Put(key, rendered)
+	return rendered, nil
+}
+
+// maxAnimationFrames returns the configured frame cap.
+func (r *Renderer) maxAnimationFrames() int {
+	if r.cfg.MaxAnimationFrames > 0 {
+		return r.cfg.MaxAnimationFrames
+	}
+	return DefaultMaxAnimationFrames
+}
+
+// hashAnimation combines the hash of every frame and its delay.
+func (r *Renderer) hashAnimation(frames []image.Image, delays []time.Duration) [32]byte {
+	hasher := sha256.New()
+	var buf [8]byte
+	for i, f := range frames {
+		h := r.hashImage(f)
+		hasher.Write(h[:])
+		if i < len(delays) {
+			binary.LittleEndian.PutUint64(buf[:], uint64(delays[i]))
+			hasher.Write(buf[:])
+		}
+	}
+	var result [32]byte
+	copy(result[:], hasher.Sum(nil))
+	return result
+}
+
+// imgKittyAnimation builds the full Kitty sequence for an animation: the
+// root frame is transmitted with a=t, each further frame with a=f and its
+// gap, then the image is placed and started with a=a. All commands use q=2
+// so the terminal does not write responses into the shell's input.
+func imgKittyAnimation(id uint32, frames [][]byte, w, h int, gapsMS []int, loops, rows, cols int) string {
+	var b strings.Builder
+
+	for i, data := range frames {
+		payload, compressionFlag := imgKittyPayload(data, true)
+		action := "a=t"
+		if i > 0 {
+			action = fmt.Sprintf("a=f,z=%d", gapsMS[i])
+		}
+		b.WriteString(imgKittyChunked(
+			fmt.Sprintf("%s,i=%d,f=32,s=%d,v=%d,q=2%s", action, id, w, h, compressionFlag),
+			payload))
+	}
+
+	// The root frame's gap is set separately, then place and run.
+	fmt.Fprintf(&b, "%sa=a,i=%d,r=1,z=%d,q=2;%s", imgKittyESC, id, gapsMS[0], imgKittyST)
+	fmt.Fprintf(&b, "%sa=p,i=%d,c=%d,r=%d,q=2;%s", imgKittyESC, id, cols, rows, imgKittyST)
+	fmt.Fprintf(&b, "%sa=a,i=%d,s=3,v=%d,q=2;%s", imgKittyESC, id, loops, imgKittyST)
+
+	return b.String()
+}
+
+// imgKittyLoops converts a GIF loop count to Kitty's v= value, where 1
+// loops forever and n plays n-1 times.
+func imgKittyLoops(gifLoopCount int) int {
+	switch {
+	case gifLoopCount == 0:
+		return 1
+	case gifLoopCount < 0:
+		return 2
+	default:
+		return gifLoopCount + 2
+	}
+}
+
+// imgCloneNRGBA returns a copy of img with its own pixel buffer.
+func imgCloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	dst := image.NewNRGBA(img.Bounds())
+	copy(dst.Pix, img.Pix)
+	return dst
+}
+
+// imgNRGBAPixels returns img's pixels as tightly packed RGBA rows, copying
+// only when the stride includes padding or the image is a sub-image.
+func imgNRGBAPixels(img *image.NRGBA) []byte {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if img.Stride == w*4 && len(img.Pix) == w*h*4 {
+		return img.Pix
+	}
+	out := make([]byte, 0, w*h*4)
+	for y := 0; y < h; y++ {
+		start := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		out = append(out, img.Pix[start:start+w*4]...)
+	}
+	return out
+}
@@ -13,18 +13,30 @@ type renderJob struct {
 	img      image.Image
 	width    int
 	height   int
+	hash     [32]byte
+	priority bool
 	callback func(string, error)
 }
 
 // AsyncRenderer manages a bounded goroutine pool for non-blocking image
 // rendering. It is designed for TUI event loops where rendering must not
 // block the main thread.
+//
+// Queued jobs are coalesced by image hash: submitting a new job for an
+// image drops any older job for the same image that has not started yet,
+// so a burst of resize renders collapses to the latest size. Priority jobs
+// run before all normal jobs.
 type AsyncRenderer struct {
 	renderer *Renderer
-	jobs     chan renderJob
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	high   []*renderJob
+	normal []*renderJob
+	closed bool
+
 	wg       sync.WaitGroup
 	stopOnce sync.Once
-	stop     chan struct{}
 }
 
 // NewAsyncRenderer creates an async wrapper around a Renderer with a
@@ -42,9 +54,8 @@ func NewAsyncRendererWithWorkers(r *Renderer, workers int) *AsyncRenderer {
 
 	ar := &AsyncRenderer{
 		renderer: r,
-		jobs:     make(chan renderJob, workers*4),
-		stop:     make(chan struct{}),
 	}
+	ar.cond = sync.NewCond(&ar.mu)
 
 	for i := 0; i < workers; i++ {
 		ar.wg.Add(1)
@@ -60,8 +71,43 @@ func NewAsyncRendererWithWorkers(r *Renderer, workers int) *AsyncRenderer {
 // if the render has already started, it will complete but the callback will
 // still fire).
 //
-// This method never blocks the caller beyond channel send.
+// If a newer job for the same image is submitted before this one starts,
+// this job is dropped and its callback never fires.
+//
+// This method never blocks the caller beyond hashing the image.
 func (ar *AsyncRenderer) RenderAsync(img image.Image, width, height int, callback func(string, error)) func() {
+	return ar.submit(img, width, height, false, callback)
+}
+
+// RenderAsyncPriority is RenderAsync for a job that should jump ahead of
+// all queued normal jobs, such as the render for the final size after a
+// terminal resize. Priority jobs run in submission order among themselves.
+func (ar *AsyncRenderer) RenderAsyncPriority(img image.Image, width, height int, callback func(string, error)) func() {
+	return ar.submit(img, width, height, true, callback)
+}
+
+// RenderAsyncWithPlaceholder returns img's placeholder (see
+// Renderer.RenderPlaceholder) for the caller to show at once and submits
+// the real render as RenderAsync does. The result handed to callback
+// starts with ClearRegion over the placeholder's cells, so a smaller
+// render does not leave the placeholder's edges on screen.
+func (ar *AsyncRenderer) RenderAsyncWithPlaceholder(img image.Image, width, height int, callback func(string, error)) (string, func()) {
+	placeholder, cols, rows, err := ar.renderer.renderPlaceholder(img, width, height)
+	if err != nil {
+		placeholder = ""
+	}
+	clear := ClearRegion(cols, rows)
+	cancel := ar.submit(img, width, height, false, func(result string, err error) {
+		if err == nil {
+			result = clear + result
+		}
+		callback(result, err)
+	})
+	return placeholder, cancel
+}
+
+// submit wraps the callback for cancellation and enqueues the job.
+func (ar *AsyncRenderer) submit(img image.Image, width, height int, priority bool, callback func(string, error)) func() {
 	cancelled := make(chan struct{})
 
 	wrappedCallback := func(result string, err error) {
@@ -74,58 +120,126 @@ func (ar *AsyncRenderer) RenderAsync(img image.Image, width, height int, callbac
 		}
 	}
 
-	job := renderJob{
+	job := &renderJob{
 		img:      img,
 		width:    width,
 		height:   height,
+		priority: priority,
 		callback: wrappedCallback,
 	}
-
-	// Non-blocking send: if the job queue is full, run synchronously in a
-	// new goroutine to avoid blocking the TUI loop.
-	select {
-	case ar.jobs <- job:
-	default:
-		go func() {
-			result, err := ar.renderer.Render(img, width, height)
-			wrappedCallback(result, err)
-		}()
+	if img != nil {
+		job.hash = ar.renderer.hashImage(img)
 	}
 
+	ar.enqueue(job)
+
+	var once sync.Once
 	return func() {
-		close(cancelled)
+		once.Do(func() {
+			close(cancelled)
+			ar.remove(job)
+		})
 	}
 }
 
+// enqueue adds job to its queue, dropping queued jobs for the same image.
+// After Close, the job runs on its own goroutine instead.
+func (ar *AsyncRenderer) enqueue(job *renderJob) {
+	ar.mu.Lock()
+	if ar.closed {
+		ar.mu.Unlock()
+		go ar.run(job)
+		return
+	}
+
+	if job.img != nil {
+		coalesce := func(q []*renderJob) []*renderJob {
+			kept := q[:0]
+			for _, j := range q {
+				if j.img == nil || j.hash != job.hash {
+					kept = append(kept, j)
+				}
+			}
+			return kept
+		}
+		ar.high = coalesce(ar.high)
+		ar.normal = coalesce(ar.normal)
+	}
+
+	if job.priority {
+		ar.high = append(ar.high, job)
+	} else {
+		ar.normal = append(ar.normal, job)
+	}
+	ar.mu.Unlock()
+	ar.cond.Signal()
+}
+
+// remove drops job from the queue if it has not started.
+func (ar *AsyncRenderer) remove(job *renderJob) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	drop := func(q []*renderJob) []*renderJob {
+		for i, j := range q {
+			if j == job {
+				return append(q[:i], q[i+1:]...)
+			}
+		}
+		return q
+	}
+	ar.high = drop(ar.high)
+	ar.normal = drop(ar.normal)
+}
+
+// next blocks until a job is available, returning priority jobs first.
+// It returns nil once the pool is closed and the queues are drained.
+func (ar *AsyncRenderer) next() *renderJob {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	for len(ar.high) == 0 && len(ar.normal) == 0 && !ar.closed {
+		ar.cond.Wait()
+	}
+
+	var job *renderJob
+	switch {
+	case len(ar.high) > 0:
+		job, ar.high = ar.high[0], ar.high[1:]
+	case len(ar.normal) > 0:
+		job, ar.normal = ar.normal[0], ar.normal[1:]
+	}
+	return job
+}
+
+// run renders job and invokes its callback.
+func (ar *AsyncRenderer) run(job *renderJob) {
+	result, err := ar.renderer.Render(job.img, job.width, job.height)
+	job.callback(result, err)
+}
+
 // Close shuts down the worker pool. It signals all workers to stop and
-// waits for in-flight jobs to complete.
+// waits for in-flight and queued jobs to complete.
 func (ar *AsyncRenderer) Close() {
 	ar.stopOnce.Do(func() {
-		close(ar.stop)
-		close(ar.jobs)
+		ar.mu.Lock()
+		ar.closed = true
+		ar.mu.Unlock()
+		ar.cond.Broadcast()
 		ar.wg.Wait()
 	})
 }
 
-// worker processes jobs from the queue until the pool is closed.
+// worker processes jobs from the queue until the pool is closed and
+// drained.
 func (ar *AsyncRenderer) worker() {
 	defer ar.wg.Done()
 
 	for {
-		select {
-		case <-ar.stop:
-			// Drain remaining jobs before exiting.
-			for job := range ar.jobs {
-				result, err := ar.renderer.Render(job.img, job.width, job.height)
-				job.callback(result, err)
-			}
+		job := ar.next()
+		if job == nil {
 			return
-		case job, ok := <-ar.jobs:
-			if !ok {
-				return
-			}
-			result, err := ar.renderer.Render(job.img, job.width, job.height)
-			job.callback(result, err)
 		}
+		ar.run(job)
 	}
 }
@@ -0,0 +1,199 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
+)
+
+// imgQuadrantRunes maps a 2x2 coverage mask to its block character. Bit 0
+// is the top-left quadrant, bit 1 top-right, bit 2 bottom-left, and bit 3
+// bottom-right.
+var imgQuadrantRunes = [16]rune{
+	' ', '▘', '▝', '▀',
+	'▖', '▌', '▞', '▛',
+	'▗', '▚', '▐', '▜',
+	'▄', '▙', '▟', '█',
+}
+
+// cellBlocks reports whether images are drawn with block characters
+// rather than a graphics protocol.
+func (r *Renderer) cellBlocks() bool {
+	switch r.protocol {
+	case terminal.ProtocolKitty, terminal.ProtocolITerm2, terminal.ProtocolSixel:
+		return false
+	default:
+		return true
+	}
+}
+
+// blockCellSize returns the pixels drawn per cell by the block renderer:
+// 1x2 for half blocks and 2x2 for quadrants.
+func (r *Renderer) blockCellSize() (w, h int) {
+	if r.quadrants {
+		return 2, 2
+	}
+	return 1, 2
+}
+
+// renderFormat names the rendered output in cache keys. Block output also
+// depends on the block mode and background, so each combination is cached
+// separately.
+func (r *Renderer) renderFormat() string {
+	if !r.cellBlocks() {
+		return r.protocol.String()
+	}
+	format := "halfblocks"
+	if r.quadrants {
+		format = "quadrants"
+	}
+	if r.hasBackground {
+		bg := r.background
+		format += fmt.Sprintf("@%02x%02x%02x", bg.R, bg.G, bg.B)
+	}
+	return format
+}
+
+// blockColor returns the color the block renderers draw for p, and false
+// when p is left to the terminal background. With a known background,
+// translucent pixels are blended into it; without one, pixels under half
+// opacity are left undrawn rather than shown in their unblended color.
+func (r *Renderer) blockColor(p color.NRGBA) (color.NRGBA, bool) {
+	switch {
+	case p.A == 0:
+		return p, false
+	case p.A == 255:
+		return p, true
+	case !r.hasBackground:
+		return color.NRGBA{R: p.R, G: p.G, B: p.B, A: 255}, p.A >= 128
+	}
+	blend := func(c, bg uint8) uint8 {
+		return uint8((int(c)*int(p.A) + int(bg)*(255-int(p.A)) + 127) / 255)
+	}
+	bg := r.background
+	return color.NRGBA{R: blend(p.R, bg.R), G: blend(p.G, bg.G), B: blend(p.B, bg.B), A: 255}, true
+}
+
+// renderQuadrants renders using the 2x2 quadrant block characters with
+// 24-bit ANSI true color. Each cell covers four pixels, split into a
+// foreground and a background color; hard edges keep twice the horizontal
+// resolution of half blocks. Undrawn pixels (see blockColor) are left to
+// the terminal background, and the remaining pixels of such a cell share
+// the foreground color.
+func (r *Renderer) renderQuadrants(img image.Image) (string, error) {
+	bounds := img.Bounds()
+	srcW := bounds.Dx()
+	srcH := bounds.Dy()
+
+	if srcW <= 0 || srcH <= 0 {
+		return "", nil
+	}
+
+	nrgba := ImageToNRGBA(img)
+
+	var b strings.Builder
+	b.Grow((srcW / 2) * (srcH / 2) * 40)
+
+	for y := 0; y < srcH; y += 2 {
+		if y > 0 {
+			b.WriteString("\x1b[0m\n")
+		}
+		for x := 0; x < srcW; x += 2 {
+			var px [4]color.NRGBA
+			var drawn [4]bool
+			for i := range px {
+				qx, qy := x+i%2, y+i/2
+				if qx < srcW && qy < srcH {
+					px[i], drawn[i] = r.blockColor(nrgba.NRGBAAt(bounds.Min.X+qx, bounds.Min.Y+qy))
+				}
+			}
+
+			mask, fg, bg, hasBG := imgSplitQuadrants(px, drawn)
+			switch {
+			case mask == 0:
+				b.WriteString("\x1b[0m ")
+			case !hasBG:
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[49m%c", fg.R, fg.G, fg.B, imgQuadrantRunes[mask])
+			default:
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm%c",
+					fg.R, fg.G, fg.B, bg.R, bg.G, bg.B, imgQuadrantRunes[mask])
+			}
+		}
+	}
+
+	b.WriteString("\x1b[0m")
+	return b.String(), nil
+}
+
+// imgSplitQuadrants divides the pixels of a cell into a foreground and a
+// background group and returns the foreground mask and the mean color of
+// each group. When any pixel is undrawn, the drawn ones form the
+// foreground and hasBG is false. Otherwise the two most different pixels
+// seed the groups and every pixel joins the nearer one; a uniform cell is
+// a full foreground block.
+func imgSplitQuadrants(px [4]color.NRGBA, drawn [4]bool) (mask int, fg, bg color.NRGBA, hasBG bool) {
+	for i := range px {
+		if !drawn[i] {
+			for j := range px {
+				if drawn[j] {
+					mask |= 1 << j
+				}
+			}
+			return mask, imgMeanColor(px, mask), color.NRGBA{}, false
+		}
+	}
+
+	seedFG, seedBG, far := 0, 0, -1
+	for i := range px {
+		for j := i + 1; j < len(px); j++ {
+			if d := imgColorDist(px[i], px[j]); d > far {
+				seedFG, seedBG, far = i, j, d
+			}
+		}
+	}
+	if far == 0 {
+		return 15, px[0], color.NRGBA{}, false
+	}
+	for i := range px {
+		if imgColorDist(px[i], px[seedFG]) <= imgColorDist(px[i], px[seedBG]) {
+			mask |= 1 << i
+		}
+	}
+	return mask, imgMeanColor(px, mask), imgMeanColor(px, 15&^mask), true
+}
+
+// imgMeanColor averages the pixels whose bits are set in mask.
+func imgMeanColor(px [4]color.NRGBA, mask int) color.NRGBA {
+	var r, g, b, n int
+	for i, p := range px {
+		if mask&(1<<i) != 0 {
+			r, g, b, n = r+int(p.R), g+int(p.G), b+int(p.B), n+1
+		}
+	}
+	if n == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{R: uint8((r + n/2) / n), G: uint8((g + n/2) / n), B: uint8((b + n/2) / n), A: 255}
+}
+
+// imgColorDist is the squared RGB distance between a and b.
+func imgColorDist(a, b color.NRGBA) int {
+	dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+// imgParseHexColor parses a "#rrggbb" color.
+func imgParseHexColor(s string) (color.NRGBA, bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.NRGBA{}, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, true
+}
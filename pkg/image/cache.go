@@ -25,13 +25,20 @@ func (k CacheKey) String() string {
 	return fmt.Sprintf("%s:%dx%d:%x", k.Protocol, k.Width, k.Height, k.ImageHash[:8])
 }
 
-// CacheStats reports hit/miss counts for observability.
+// CacheStats reports hit/miss counts for observability. Hits is the sum of
+// MemHits and DiskHits; Misses counts lookups that missed every level.
+// Entries and SizeBytes describe the memory level only.
 type CacheStats struct {
-	Hits       uint64
-	Misses     uint64
-	Evictions  uint64
-	Entries    int
-	SizeBytes  int64
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	SizeBytes int64
+
+	MemHits       uint64
+	DiskHits      uint64
+	DiskEntries   int
+	DiskSizeBytes int64
 }
 
 // cacheEntry is stored in the LRU list.
@@ -42,7 +49,9 @@ type cacheEntry struct {
 }
 
 // Cache is a thread-safe LRU cache for rendered terminal image strings.
-// It uses container/list for O(1) eviction and promotion.
+// It uses container/list for O(1) eviction and promotion. A cache created
+// with NewCacheWithDisk also has a disk level shared across processes:
+// memory misses fall through to disk, and Puts write through to both.
 type Cache struct {
 	mu        sync.RWMutex
 	items     map[CacheKey]*list.Element
@@ -50,7 +59,10 @@ type Cache struct {
 	maxBytes  int64
 	usedBytes int64
 
+	disk *imgDiskCache // nil when memory-only
+
 	hits      atomic.Uint64
+	diskHits  atomic.Uint64
 	misses    atomic.Uint64
 	evictions atomic.Uint64
 }
@@ -68,14 +80,37 @@ func NewCache(maxMB int) *Cache {
 	}
 }
 
+// NewCacheWithDisk creates a cache with a memory level of maxMB and a disk
+// level under dir capped at diskMB (<= 0 uses 32 MB). An empty dir yields
+// a memory-only cache.
+func NewCacheWithDisk(maxMB int, dir string, diskMB int) *Cache {
+	c := NewCache(maxMB)
+	if dir == "" {
+		return c
+	}
+	if diskMB <= 0 {
+		diskMB = 32
+	}
+	c.disk = newImgDiskCache(dir, int64(diskMB)*1024*1024)
+	return c
+}
+
 // Get retrieves a cached rendered string. Returns the string and true on
-// hit, or empty string and false on miss.
+// hit, or empty string and false on miss. A disk hit is promoted into the
+// memory level.
 func (c *Cache) Get(key CacheKey) (string, bool) {
 	c.mu.RLock()
 	elem, ok := c.items[key]
 	c.mu.RUnlock()
 
 	if !ok {
+		if c.disk != nil {
+			if rendered, ok := c.disk.get(key); ok {
+				c.diskHits.Add(1)
+				c.putMem(key, rendered)
+				return rendered, true
+			}
+		}
 		c.misses.Add(1)
 		return "", false
 	}
@@ -90,8 +125,18 @@ func (c *Cache) Get(key CacheKey) (string, bool) {
 }
 
 // Put stores a rendered string in the cache. If the cache exceeds its
-// maximum size, the least recently used entries are evicted.
+// maximum size, the least recently used entries are evicted. With a disk
+// level the entry is also written to disk; write errors are ignored since
+// the memory level still holds it.
 func (c *Cache) Put(key CacheKey, rendered string) {
+	c.putMem(key, rendered)
+	if c.disk != nil {
+		c.disk.put(key, rendered)
+	}
+}
+
+// putMem stores a rendered string in the memory level only.
+func (c *Cache) putMem(key CacheKey, rendered string) {
 	entrySize := int64(len(rendered))
 
 	c.mu.Lock()
@@ -124,7 +169,8 @@ func (c *Cache) Put(key CacheKey, rendered string) {
 	c.usedBytes += entrySize
 }
 
-// Invalidate clears all cache entries.
+// Invalidate clears all memory entries. The disk level is shared with
+// other processes and is left to its own pruning.
 func (c *Cache) Invalidate() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -139,13 +185,19 @@ func (c *Cache) Stats() CacheStats {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return CacheStats{
-		Hits:      c.hits.Load(),
+	stats := CacheStats{
+		Hits:      c.hits.Load() + c.diskHits.Load(),
 		Misses:    c.misses.Load(),
 		Evictions: c.evictions.Load(),
 		Entries:   c.order.Len(),
 		SizeBytes: c.usedBytes,
+		MemHits:   c.hits.Load(),
+		DiskHits:  c.diskHits.Load(),
+	}
+	if c.disk != nil {
+		stats.DiskEntries, stats.DiskSizeBytes = c.disk.usage()
 	}
+	return stats
 }
 
 // evictLocked evicts entries from the back until under maxBytes.
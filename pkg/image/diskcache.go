@@ -0,0 +1,173 @@
+package image
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// imgDiskCacheExt is the extension of rendered entries on disk.
+const imgDiskCacheExt = ".render"
+
+// imgDiskCache is the optional second level of Cache: rendered strings
+// stored as files so separate processes (the daemon, earlier banner runs)
+// share renders. Writes are atomic, and the directory is pruned by mtime
+// once it exceeds maxBytes. Hits refresh an entry's mtime, so pruning is
+// least-recently-used across every process sharing the directory.
+type imgDiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu        sync.Mutex
+	usedBytes int64
+	entries   int
+}
+
+// newImgDiskCache opens a disk cache rooted at dir, scanning it for the
+// current usage.
+func newImgDiskCache(dir string, maxBytes int64) *imgDiskCache {
+	d := &imgDiskCache{dir: dir, maxBytes: maxBytes}
+	d.scan()
+	return d
+}
+
+// path returns the file for key: a subdirectory per hash prefix, and a
+// filename carrying the protocol, size, and full hash.
+func (d *imgDiskCache) path(key CacheKey) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%x", key.ImageHash[:1]),
+		fmt.Sprintf("%s_%dx%d_%x%s", key.Protocol, key.Width, key.Height, key.ImageHash, imgDiskCacheExt))
+}
+
+// get reads key from disk and refreshes its mtime.
+func (d *imgDiskCache) get(key CacheKey) (string, bool) {
+	p := d.path(key)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(p, now, now) // best effort; only affects pruning order
+	return string(data), true
+}
+
+// put writes key atomically (temp file + rename) so concurrent readers in
+// other processes never see a partial entry, then prunes if over budget.
+func (d *imgDiskCache) put(key CacheKey, rendered string) error {
+	p := d.path(key)
+	dir := filepath.Dir(p)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".render-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.WriteString(rendered); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	var replaced int64 = -1
+	if info, err := os.Stat(p); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmpName, p); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("rename temp to final: %w", err)
+	}
+
+	d.mu.Lock()
+	if replaced >= 0 {
+		d.usedBytes -= replaced
+	} else {
+		d.entries++
+	}
+	d.usedBytes += int64(len(rendered))
+	over := d.usedBytes > d.maxBytes
+	d.mu.Unlock()
+
+	if over {
+		d.prune()
+	}
+	return nil
+}
+
+// prune removes the least recently used entries until the directory is
+// under maxBytes. Other processes may prune concurrently, so removal
+// errors are ignored and usage is rescanned afterwards.
+func (d *imgDiskCache) prune() {
+	type fileEntry struct {
+		path  string
+		size  int64
+		mtime int64
+	}
+
+	var files []fileEntry
+	var total int64
+	filepath.Walk(d.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != imgDiskCacheExt {
+			return nil
+		}
+		files = append(files, fileEntry{path: path, size: info.Size(), mtime: info.ModTime().UnixNano()})
+		total += info.Size()
+		return nil
+	})
+
+	// Oldest first.
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].mtime < files[j].mtime
+	})
+
+	for _, f := range files {
+		if total <= d.maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			continue
+		}
+		total -= f.size
+		os.Remove(filepath.Dir(f.path)) // only succeeds if empty
+	}
+
+	d.scan()
+}
+
+// scan recomputes usage from the directory contents.
+func (d *imgDiskCache) scan() {
+	var size int64
+	var count int
+	filepath.Walk(d.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && filepath.Ext(path) == imgDiskCacheExt {
+			size += info.Size()
+			count++
+		}
+		return nil
+	})
+
+	d.mu.Lock()
+	d.usedBytes = size
+	d.entries = count
+	d.mu.Unlock()
+}
+
+// usage returns the entry count and total bytes on disk.
+func (d *imgDiskCache) usage() (int, int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.entries, d.usedBytes
+}
@@ -5,10 +5,16 @@ import (
 	"compress/zlib"
 	"container/list"
 	"encoding/base64"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	"image/gif"
+	"image/png"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -18,6 +24,8 @@ import (
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
+	xdraw "golang.org/x/image/draw"
 )
 
 // --- helpers ---------------------------------------------------------------
@@ -59,6 +67,7 @@ func makeCaps(proto terminal.GraphicsProtocol) terminal.Capabilities {
 			CellW:  8,
 			CellH:  16,
 		},
+		KittyPlaceholders: true,
 	}
 }
 
@@ -330,6 +339,123 @@ func TestCacheConcurrency(t *testing.T) {
 	}
 }
 
+// --- Disk cache tests ------------------------------------------------------
+
+func TestCacheDiskSharedAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+	key := MakeCacheKey("kitty", 20, 10, [32]byte{7, 7})
+
+	// One process renders and writes through.
+	daemon := NewCacheWithDisk(1, dir, 1)
+	daemon.Put(key, "rendered-by-daemon")
+
+	// A later banner run starts cold in memory but hits disk.
+	banner := NewCacheWithDisk(1, dir, 1)
+	got, ok := banner.Get(key)
+	if !ok || got != "rendered-by-daemon" {
+		t.Fatalf("Get = %q, %v; want disk hit", got, ok)
+	}
+	// The disk hit is promoted to memory.
+	banner.Get(key)
+	banner.Get(MakeCacheKey("kitty", 1, 1, [32]byte{9}))
+
+	stats := banner.Stats()
+	if stats.DiskHits != 1 || stats.MemHits != 1 || stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("stats = %+v, want 1 disk hit, 1 mem hit, 1 miss", stats)
+	}
+	if stats.DiskEntries != 1 || stats.DiskSizeBytes != int64(len("rendered-by-daemon")) {
+		t.Errorf("disk usage = %d entries / %d bytes", stats.DiskEntries, stats.DiskSizeBytes)
+	}
+}
+
+func TestCacheDiskAtomicWrites(t *testing.T) {
+	dir := t.TempDir()
+	key := MakeCacheKey("halfblocks", 5, 5, [32]byte{1})
+
+	// Concurrent writers of the same key never leave temp files or a
+	// torn entry behind.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			NewCacheWithDisk(1, dir, 1).Put(key, strings.Repeat(string(rune('a'+i)), 4096))
+		}(i)
+	}
+	wg.Wait()
+
+	got, ok := NewCacheWithDisk(1, dir, 1).Get(key)
+	if !ok || len(got) != 4096 || strings.Count(got, got[:1]) != 4096 {
+		t.Errorf("entry is torn or missing (ok=%v, len=%d)", ok, len(got))
+	}
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*", ".render-*.tmp"))
+	if len(tmps) != 0 {
+		t.Errorf("leftover temp files: %v", tmps)
+	}
+}
+
+func TestCacheDiskPrunesLRU(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCacheWithDisk(1, dir, 1)
+	big := strings.Repeat("x", 400*1024)
+
+	old := MakeCacheKey("kitty", 1, 1, [32]byte{1})
+	used := MakeCacheKey("kitty", 1, 1, [32]byte{2})
+	c.Put(old, big)
+	c.Put(used, big)
+
+	// Age both entries, then touch one through a cold cache.
+	past := time.Now().Add(-time.Hour)
+	for _, k := range []CacheKey{old, used} {
+		os.Chtimes(c.disk.path(k), past, past)
+	}
+	NewCacheWithDisk(1, dir, 1).Get(used)
+
+	// A third entry pushes the directory over 1 MB.
+	c.Put(MakeCacheKey("kitty", 1, 1, [32]byte{3}), big)
+
+	cold := NewCacheWithDisk(1, dir, 1)
+	if _, ok := cold.Get(old); ok {
+		t.Error("least recently used entry should be pruned")
+	}
+	if _, ok := cold.Get(used); !ok {
+		t.Error("recently read entry should survive pruning")
+	}
+	if s := cold.Stats(); s.DiskSizeBytes > 1024*1024 {
+		t.Errorf("disk size = %d, want <= 1 MB", s.DiskSizeBytes)
+	}
+}
+
+func TestNewCacheWithDiskEmptyDir(t *testing.T) {
+	c := NewCacheWithDisk(1, "", 1)
+	if c.disk != nil {
+		t.Error("empty dir should give a memory-only cache")
+	}
+}
+
+func TestRendererUsesDiskCache(t *testing.T) {
+	cfg := makeCfg()
+	cfg.DiskCacheDir = t.TempDir()
+	img := makeGradientImage(16, 16)
+
+	first, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 8, 4)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg)
+	second, err := r.Render(img, 8, 4)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if second != first {
+		t.Error("disk-cached render differs from the original")
+	}
+	if r.Cache().Stats().DiskHits != 1 {
+		t.Errorf("DiskHits = %d, want 1", r.Cache().Stats().DiskHits)
+	}
+}
+
 // --- Resize tests ----------------------------------------------------------
 
 func TestResizeToFitMaintainsAspectRatio(t *testing.T) {
@@ -506,6 +632,133 @@ func TestRenderHalfblocksTransparent(t *testing.T) {
 	}
 }
 
+func TestRenderHalfblocksBlendsIntoBackground(t *testing.T) {
+	// Half-transparent black.
+	img := makeImage(2, 2, color.NRGBA{R: 0, G: 0, B: 0, A: 128})
+
+	cfg := makeCfg()
+	cfg.Background = "#ffffff"
+	out, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 10, 10)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1b[38;2;127;127;127m") {
+		t.Errorf("pixel not blended into the white background: %q", out)
+	}
+
+	// Without a known background, a mostly transparent pixel is left to
+	// the terminal instead of being drawn black.
+	img = makeImage(2, 2, color.NRGBA{R: 0, G: 0, B: 0, A: 100})
+	out, err = NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg()).Render(img, 10, 10)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if strings.Contains(out, "38;2;") {
+		t.Errorf("translucent pixel drawn without a background: %q", out)
+	}
+}
+
+func TestRenderHalfblocksBackgroundFromCapabilities(t *testing.T) {
+	caps := makeCaps(terminal.ProtocolHalfblocks)
+	caps.Background = "#000000"
+	cfg := makeCfg()
+	r := NewRenderer(caps, cfg)
+	if !r.hasBackground || r.background != (color.NRGBA{A: 255}) {
+		t.Errorf("background = %v (known %v), want the detected black", r.background, r.hasBackground)
+	}
+
+	cfg.Background = "#fdf6e3"
+	if r := NewRenderer(caps, cfg); r.background != (color.NRGBA{R: 0xfd, G: 0xf6, B: 0xe3, A: 255}) {
+		t.Errorf("background = %v, want the configured override", r.background)
+	}
+}
+
+func TestRenderQuadrantsHardEdge(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	img.SetNRGBA(0, 0, red)
+	img.SetNRGBA(0, 1, red)
+	img.SetNRGBA(1, 0, blue)
+	img.SetNRGBA(1, 1, blue)
+
+	cfg := makeCfg()
+	cfg.BlockMode = "quadrants"
+	out, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 10, 10)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	want := "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m\u258c"
+	if !strings.Contains(out, want) {
+		t.Errorf("output = %q, want left half block red on blue", out)
+	}
+}
+
+func TestRenderQuadrantsTransparentQuadrants(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA(1, 1, color.NRGBA{G: 255, A: 255})
+
+	cfg := makeCfg()
+	cfg.BlockMode = "quadrants"
+	out, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 10, 10)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1b[38;2;0;255;0m\x1b[49m\u2597") {
+		t.Errorf("output = %q, want a green lower-right quadrant on the terminal background", out)
+	}
+}
+
+func TestRenderBlockModesCellSize(t *testing.T) {
+	img := makeGradientImage(200, 200)
+	for _, mode := range []string{"halfblocks", "quadrants"} {
+		cfg := makeCfg()
+		cfg.BlockMode = mode
+		out, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 12, 6)
+		if err != nil {
+			t.Fatalf("%s: render failed: %v", mode, err)
+		}
+		lines := strings.Split(out, "\n")
+		if len(lines) != 6 {
+			t.Errorf("%s: %d rows, want 6", mode, len(lines))
+		}
+		plain := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(lines[0], "")
+		if n := utf8.RuneCountInString(plain); n > 12 {
+			t.Errorf("%s: first row is %d cells, want at most 12", mode, n)
+		}
+	}
+}
+
+func TestRenderBlockModesCachedSeparately(t *testing.T) {
+	dir := t.TempDir()
+	img := makeGradientImage(8, 8)
+	render := func(mode, bg string) string {
+		cfg := makeCfg()
+		cfg.DiskCacheDir = dir
+		cfg.BlockMode = mode
+		cfg.Background = bg
+		out, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 10, 10)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		return out
+	}
+
+	half := render("halfblocks", "")
+	quad := render("quadrants", "")
+	if half == quad {
+		t.Error("quadrant render returned the cached halfblock output")
+	}
+	if got := render("halfblocks", ""); got != half {
+		t.Error("halfblock render changed between identical renderers")
+	}
+	r1 := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), config.ImageConfig{Background: "#ffffff"})
+	r2 := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), config.ImageConfig{Background: "#000000"})
+	if r1.renderFormat() == r2.renderFormat() {
+		t.Errorf("backgrounds share the cache format %q", r1.renderFormat())
+	}
+}
+
 func TestRenderHalfblocksOddHeight(t *testing.T) {
 	caps := makeCaps(terminal.ProtocolHalfblocks)
 	r := NewRenderer(caps, makeCfg())
@@ -748,6 +1001,108 @@ func TestAsyncRendererClose(t *testing.T) {
 	ar.Close()
 }
 
+// asyncBlockWorker occupies ar's only worker until the returned channel is
+// closed, so later submissions stay queued.
+func asyncBlockWorker(t *testing.T, ar *AsyncRenderer) chan struct{} {
+	t.Helper()
+	started := make(chan struct{})
+	blocker := make(chan struct{})
+	ar.RenderAsync(makeImage(2, 2, color.Black), 1, 1, func(string, error) {
+		close(started)
+		<-blocker
+	})
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocking job never started")
+	}
+	return blocker
+}
+
+func TestAsyncRenderPriorityJumpsQueue(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	ar := NewAsyncRendererWithWorkers(r, 1)
+	blocker := asyncBlockWorker(t, ar)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(string, error) {
+		return func(string, error) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+
+	// 20 stale renders of distinct images, then one priority render.
+	for i := 0; i < 20; i++ {
+		img := makeImage(4, 4, color.NRGBA{R: uint8(i), A: 255})
+		ar.RenderAsync(img, 10+i, 5+i, record("stale"))
+	}
+	ar.RenderAsyncPriority(makeGradientImage(8, 8), 40, 20, record("priority"))
+
+	close(blocker)
+	ar.Close()
+
+	if len(order) != 21 {
+		t.Fatalf("callbacks fired = %d, want 21", len(order))
+	}
+	if order[0] != "priority" {
+		t.Errorf("first callback = %q, want the priority job", order[0])
+	}
+}
+
+func TestAsyncRenderCoalescesSameImage(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	ar := NewAsyncRendererWithWorkers(r, 1)
+	blocker := asyncBlockWorker(t, ar)
+
+	img := makeGradientImage(16, 16)
+	other := makeImage(4, 4, color.White)
+
+	var mu sync.Mutex
+	var sizes []int
+	var otherCalled atomic.Bool
+	for w := 10; w < 30; w++ {
+		ar.RenderAsync(img, w, w/2, func(string, error) {
+			mu.Lock()
+			sizes = append(sizes, w)
+			mu.Unlock()
+		})
+	}
+	ar.RenderAsync(other, 10, 10, func(string, error) { otherCalled.Store(true) })
+
+	close(blocker)
+	ar.Close()
+
+	if len(sizes) != 1 || sizes[0] != 29 {
+		t.Errorf("rendered sizes = %v, want only the latest [29]", sizes)
+	}
+	if !otherCalled.Load() {
+		t.Error("a job for a different image should not be coalesced")
+	}
+}
+
+func TestAsyncRenderCancelRemovesQueuedJob(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	ar := NewAsyncRendererWithWorkers(r, 1)
+	blocker := asyncBlockWorker(t, ar)
+
+	var called atomic.Bool
+	cancel := ar.RenderAsyncPriority(makeImage(4, 4, color.White), 10, 10, func(string, error) {
+		called.Store(true)
+	})
+	cancel()
+	cancel() // idempotent
+
+	close(blocker)
+	ar.Close()
+
+	if called.Load() {
+		t.Error("cancelled queued job should not fire its callback")
+	}
+}
+
 // --- ImageToNRGBA tests ----------------------------------------------------
 
 func TestImageToNRGBA(t *testing.T) {
@@ -974,6 +1329,17 @@ func TestImgKittyDisplayIncludesIDAndDimensions(t *testing.T) {
 	}
 }
 
+func TestImgKittyPlace(t *testing.T) {
+	classic := imgKittyPlace(7, 3, 5, false)
+	if want := "\x1b_Ga=p,i=7,c=5,r=3,q=2;\x1b\\"; classic != want {
+		t.Errorf("classic placement = %q, want %q", classic, want)
+	}
+	virtual := imgKittyPlace(7, 3, 5, true)
+	if want := "\x1b_Ga=p,i=7,U=1,c=5,r=3,q=2;\x1b\\" + imgKittyUnicodePlaceholder(7, 3, 5); virtual != want {
+		t.Errorf("placeholder placement = %q, want %q", virtual, want)
+	}
+}
+
 // --- ZLIB compression tests ------------------------------------------------
 
 func TestImgZlibCompressRoundtrip(t *testing.T) {
@@ -1157,6 +1523,112 @@ func TestImgLanczosResizeZeroDimensions(t *testing.T) {
 	}
 }
 
+// --- Resample tests --------------------------------------------------------
+
+// makeDetailImage creates an image with hard edges and fine stripes, which
+// stress resampling more than gradients. When transparent is set, its
+// bottom right quarter is partly transparent.
+func makeDetailImage(w, h int, transparent bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8((x ^ y) * 3), A: 255}
+			if (x/16+y/16)%2 == 0 {
+				c.R, c.G = 255-c.R, 255-c.G
+			}
+			if transparent && x > w/2 && y > h/2 {
+				c.A = uint8(64 + (x+y)%192)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// imgReferenceResize is the resize and sharpen path imgResample replaced:
+// x/image/draw kernel scaling followed by unsharpen.
+func imgReferenceResize(src image.Image, width, height int, k imgKernel, sharpen float64) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	kernel := &xdraw.Kernel{Support: k.support, At: k.at}
+	kernel.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Over, nil)
+	return unsharpen(dst, sharpen, 1)
+}
+
+func TestImgResampleMatchesReference(t *testing.T) {
+	// Sharpened cases are opaque: unsharpen reads premultiplied colors
+	// back as straight ones, which imgResample does not reproduce.
+	tests := []struct {
+		name          string
+		src           *image.NRGBA
+		width, height int
+		k             imgKernel
+		sharpen       float64
+	}{
+		{"lanczos gradient", makeGradientImage(1200, 1200), 300, 300, imgLanczos3Kernel, 0},
+		{"lanczos detail", makeDetailImage(400, 300, false), 120, 90, imgLanczos3Kernel, 0},
+		{"lanczos upscale", makeDetailImage(40, 30, false), 100, 70, imgLanczos3Kernel, 0},
+		{"lanczos transparent", makeDetailImage(400, 300, true), 120, 90, imgLanczos3Kernel, 0},
+		{"catmullrom detail sharpened", makeDetailImage(400, 300, false), 160, 64, imgCatmullRomKernel, 0.3},
+		{"lanczos detail sharpened", makeDetailImage(640, 480, false), 80, 80, imgLanczos3Kernel, imgSharpenHalfblock},
+		{"subimage", makeDetailImage(300, 300, false).SubImage(image.Rect(50, 70, 250, 230)).(*image.NRGBA), 64, 48, imgCatmullRomKernel, 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := imgResample(tt.src, tt.width, tt.height, tt.k, tt.sharpen)
+			want := imgReferenceResize(tt.src, tt.width, tt.height, tt.k, tt.sharpen)
+			if got.Bounds() != want.Bounds() {
+				t.Fatalf("bounds = %v, want %v", got.Bounds(), want.Bounds())
+			}
+
+			// Rounding differs: x/image works in 16-bit integers and
+			// unsharpen truncates its blur to 8 bits, where imgResample
+			// keeps float32 throughout.
+			var maxDiff, total int
+			for i := range got.Pix {
+				d := int(got.Pix[i]) - int(want.Pix[i])
+				if d < 0 {
+					d = -d
+				}
+				maxDiff = max(maxDiff, d)
+				total += d
+			}
+			mean := float64(total) / float64(len(got.Pix))
+			if maxDiff > 3 || mean > 0.5 {
+				t.Errorf("differs from reference by up to %d, %.3f on average", maxDiff, mean)
+			}
+		})
+	}
+}
+
+func TestImgResampleSharpensEdges(t *testing.T) {
+	src := makeDetailImage(400, 300, false)
+	plain := imgResample(src, 100, 75, imgLanczos3Kernel, 0)
+	sharp := imgResample(src, 100, 75, imgLanczos3Kernel, imgSharpenDefault)
+
+	// Sharpening moves every edge pixel further from its neighbours, so it
+	// raises the total contrast between horizontal neighbours.
+	contrast := func(img *image.NRGBA) int {
+		var sum int
+		for y := 0; y < 75; y++ {
+			for x := 1; x < 100; x++ {
+				d := int(img.NRGBAAt(x, y).R) - int(img.NRGBAAt(x-1, y).R)
+				sum += max(d, -d)
+			}
+		}
+		return sum
+	}
+	if contrast(sharp) <= contrast(plain) {
+		t.Errorf("sharpened contrast %d, want more than unsharpened %d", contrast(sharp), contrast(plain))
+	}
+}
+
+func TestImgResampleEmpty(t *testing.T) {
+	got := imgResample(image.NewNRGBA(image.Rect(0, 0, 0, 0)), 10, 10, imgLanczos3Kernel, 0.3)
+	if got.Bounds().Dx() != 10 || got.Bounds().Dy() != 10 {
+		t.Errorf("bounds = %v, want 10x10", got.Bounds())
+	}
+}
+
 // --- Terminal pipeline tests -----------------------------------------------
 
 func TestImgTerminalPipelineCorrectPixelDimensions(t *testing.T) {
@@ -1318,6 +1790,65 @@ func TestImgRenderPlacementsSequentialProtocol(t *testing.T) {
 	}
 }
 
+func TestImgRenderPlacementsITerm2(t *testing.T) {
+	placements := []imgPlacement{
+		{Data: []byte{0xFF, 0x00, 0x00, 0xFF}, Width: 1, Height: 1, Rows: 2, Cols: 3},
+		{Data: []byte{0x00, 0xFF, 0x00, 0xFF}, Width: 1, Height: 1, Row: 5, Col: 10, Rows: 4, Cols: 6},
+	}
+
+	result := imgRenderPlacements(placements, terminal.ProtocolITerm2)
+
+	re := regexp.MustCompile(`\x1b7(\x1b\[(\d+);(\d+)H)?\x1b\]1337;File=inline=1;size=(\d+);width=(\d+);height=(\d+);preserveAspectRatio=0:([A-Za-z0-9+/=]+)\a\x1b8`)
+	matches := re.FindAllStringSubmatch(result, -1)
+	if len(matches) != 2 {
+		t.Fatalf("found %d iTerm2 placements, want 2:\n%q", len(matches), result)
+	}
+
+	// The first placement is at the origin and needs no cursor move.
+	if matches[0][1] != "" {
+		t.Errorf("placement 0 cursor = %q, want none", matches[0][1])
+	}
+	if matches[1][2] != "6" || matches[1][3] != "11" {
+		t.Errorf("placement 1 cursor = %q, want row 6 col 11", matches[1][1])
+	}
+
+	for i, m := range matches {
+		p := placements[i]
+		if m[5] != fmt.Sprint(p.Cols) || m[6] != fmt.Sprint(p.Rows) {
+			t.Errorf("placement %d size = %sx%s cells, want %dx%d", i, m[5], m[6], p.Cols, p.Rows)
+		}
+		raw, err := base64.StdEncoding.DecodeString(m[7])
+		if err != nil {
+			t.Fatalf("placement %d payload is not valid base64: %v", i, err)
+		}
+		if m[4] != fmt.Sprint(len(raw)) {
+			t.Errorf("placement %d size= %s, want %d", i, m[4], len(raw))
+		}
+		img, err := png.Decode(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("placement %d payload is not a PNG: %v", i, err)
+		}
+		r, g, _, _ := img.At(0, 0).RGBA()
+		if (r>>8 == 0xFF) != (i == 0) || (g>>8 == 0xFF) != (i == 1) {
+			t.Errorf("placement %d pixel = %v", i, img.At(0, 0))
+		}
+	}
+}
+
+func TestImgRenderPlacementsITerm2EncodedData(t *testing.T) {
+	// Data that is not raw RGBA of the given size passes through as-is.
+	data := []byte("GIF89a-not-really")
+	result := imgRenderPlacements([]imgPlacement{{Data: data, Rows: 1, Cols: 1}}, terminal.ProtocolITerm2)
+
+	want := base64.StdEncoding.EncodeToString(data)
+	if !strings.Contains(result, ":"+want+"\a") {
+		t.Errorf("payload should be the original bytes, got %q", result)
+	}
+	if !strings.HasPrefix(result, "\x1b7") || !strings.HasSuffix(result, "\x1b8") {
+		t.Errorf("placement should be wrapped in cursor save/restore: %q", result)
+	}
+}
+
 // --- Kitty transmit base64 validity ----------------------------------------
 
 func TestImgKittyTransmitBase64Valid(t *testing.T) {
@@ -1374,3 +1905,835 @@ func TestImgLanczos3AtKernelValues(t *testing.T) {
 		t.Errorf("Lanczos3 should be symmetric: f(1.5)=%f, f(-1.5)=%f", v1, v2)
 	}
 }
+
+// --- Animation tests -------------------------------------------------------
+
+// makeGIF encodes an animated GIF with one solid frame per color. Frames
+// after the first cover only the left half, so compositing is observable.
+func makeGIF(t *testing.T, colors []color.Color, delay int, disposal byte) []byte {
+	t.Helper()
+	pal := color.Palette{color.Transparent}
+	pal = append(pal, colors...)
+
+	g := &gif.GIF{Config: image.Config{Width: 8, Height: 8, ColorModel: pal}}
+	for i, c := range colors {
+		rect := image.Rect(0, 0, 8, 8)
+		if i > 0 {
+			rect = image.Rect(0, 0, 4, 8)
+		}
+		frame := image.NewPaletted(rect, pal)
+		draw.Draw(frame, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, delay)
+		g.Disposal = append(g.Disposal, disposal)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("EncodeAll: %v", err)
+	}
+	return buf.Bytes()
+}
+
+var (
+	gifRed   = color.RGBA{R: 255, A: 255}
+	gifGreen = color.RGBA{G: 255, A: 255}
+	gifBlue  = color.RGBA{B: 255, A: 255}
+)
+
+func TestDecodeAnimationCompositesFrames(t *testing.T) {
+	data := makeGIF(t, []color.Color{gifRed, gifGreen, gifBlue}, 5, gif.DisposalNone)
+
+	anim, err := DecodeAnimation(bytes.NewReader(data), 0)
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+	if len(anim.Frames) != 3 {
+		t.Fatalf("frames = %d, want 3", len(anim.Frames))
+	}
+	if anim.Delays[0] != 50*time.Millisecond {
+		t.Errorf("delay = %v, want 50ms", anim.Delays[0])
+	}
+
+	// Frame 2 draws blue over the left half; the right half keeps frame 0.
+	last := anim.Frames[2]
+	if r, g, b, _ := last.At(1, 1).RGBA(); r != 0 || g != 0 || b == 0 {
+		t.Errorf("left pixel = %v, want blue", last.At(1, 1))
+	}
+	if r, _, _, _ := last.At(6, 1).RGBA(); r == 0 {
+		t.Errorf("right pixel = %v, want red from the first frame", last.At(6, 1))
+	}
+}
+
+func TestDecodeAnimationDisposalBackground(t *testing.T) {
+	data := makeGIF(t, []color.Color{gifRed, gifGreen, gifBlue}, 5, gif.DisposalBackground)
+
+	anim, err := DecodeAnimation(bytes.NewReader(data), 0)
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+	// Frame 0 is cleared after display, so frame 1's right half is empty.
+	if _, _, _, a := anim.Frames[1].At(6, 1).RGBA(); a != 0 {
+		t.Errorf("right pixel alpha = %d, want transparent after background disposal", a)
+	}
+}
+
+func TestDecodeAnimationFrameCap(t *testing.T) {
+	data := makeGIF(t, []color.Color{gifRed, gifGreen, gifBlue, gifRed}, 0, gif.DisposalNone)
+
+	anim, err := DecodeAnimation(bytes.NewReader(data), 2)
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+	if len(anim.Frames) != 2 {
+		t.Errorf("frames = %d, want capped at 2", len(anim.Frames))
+	}
+	if anim.Delays[0] != imgMinFrameDelay {
+		t.Errorf("zero delay = %v, want %v", anim.Delays[0], imgMinFrameDelay)
+	}
+}
+
+func TestRenderAnimatedKitty(t *testing.T) {
+	data := makeGIF(t, []color.Color{gifRed, gifGreen, gifBlue}, 5, gif.DisposalNone)
+	anim, err := DecodeAnimation(bytes.NewReader(data), 0)
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+
+	r := NewRenderer(makeCaps(terminal.ProtocolKitty), makeCfg())
+	out, err := r.RenderAnimated(anim, 4, 2)
+	if err != nil {
+		t.Fatalf("RenderAnimated: %v", err)
+	}
+
+	if n := strings.Count(out, "a=t,"); n != 1 {
+		t.Errorf("root transmissions = %d, want 1", n)
+	}
+	if n := strings.Count(out, "a=f,z=50,"); n != 2 {
+		t.Errorf("frame transmissions = %d, want 2 with a 50ms gap", n)
+	}
+	for _, want := range []string{"a=a,i=", "r=1,z=50", "a=p,i=", "c=4,r=2", "s=3,v=1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+
+	// The full sequence is cached.
+	if _, err := r.RenderAnimated(anim, 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	if stats := r.Cache().Stats(); stats.Hits != 1 || stats.Entries != 1 {
+		t.Errorf("cache stats = %+v, want 1 hit and 1 entry", stats)
+	}
+}
+
+func TestRenderAnimatedFrameCapFromConfig(t *testing.T) {
+	data := makeGIF(t, []color.Color{gifRed, gifGreen, gifBlue}, 5, gif.DisposalNone)
+	anim, err := DecodeAnimation(bytes.NewReader(data), 0)
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+
+	cfg := makeCfg()
+	cfg.MaxAnimationFrames = 2
+	out, err := NewRenderer(makeCaps(terminal.ProtocolKitty), cfg).RenderAnimated(anim, 4, 2)
+	if err != nil {
+		t.Fatalf("RenderAnimated: %v", err)
+	}
+	if n := strings.Count(out, "a=f,"); n != 1 {
+		t.Errorf("frame transmissions = %d, want 1 with a 2-frame cap", n)
+	}
+}
+
+func TestRenderAnimatedFallsBackToFirstFrame(t *testing.T) {
+	data := makeGIF(t, []color.Color{gifRed, gifGreen}, 5, gif.DisposalNone)
+	anim, err := DecodeAnimation(bytes.NewReader(data), 0)
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	got, err := r.RenderAnimated(anim, 4, 2)
+	if err != nil {
+		t.Fatalf("RenderAnimated: %v", err)
+	}
+	want, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg()).Render(anim.Frames[0], 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Error("halfblocks should render the first frame")
+	}
+}
+
+func TestRenderFileAnimatedGIF(t *testing.T) {
+	path := t.TempDir() + "/anim.gif"
+	if err := os.WriteFile(path, makeGIF(t, []color.Color{gifRed, gifGreen}, 5, gif.DisposalNone), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := NewRenderer(makeCaps(terminal.ProtocolKitty), makeCfg()).RenderFile(path, 4, 2)
+	if err != nil {
+		t.Fatalf("RenderFile: %v", err)
+	}
+	if !strings.Contains(out, "a=f,") {
+		t.Error("GIF file should render as a Kitty animation")
+	}
+}
+
+func TestImgKittyLoops(t *testing.T) {
+	tests := map[int]int{0: 1, -1: 2, 3: 5}
+	for in, want := range tests {
+		if got := imgKittyLoops(in); got != want {
+			t.Errorf("imgKittyLoops(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+// --- Sixel tests -----------------------------------------------------------
+
+func TestImgSixelEncodeGolden(t *testing.T) {
+	img := makeImage(2, 6, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{B: 255, A: 255})
+	pal := imgMedianCutPalette(img, 4)
+
+	got := imgSixelEncode(imgDither(img, pal, SixelDitherNone), pal, 2, 6)
+	want := "\x1bP0;1;0q\"1;1;2;6#0;2;0;0;100#1;2;100;0;0#0?@$#1~}-\x1b\\"
+	if got != want {
+		t.Errorf("sixel =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestImgSixelDitherGolden(t *testing.T) {
+	img := makeGradientImage(8, 6)
+	pal := imgMedianCutPalette(img, 4)
+	const header = "\x1bP0;1;0q\"1;1;8;6#0;2;21;20;50#1;2;78;20;50#2;2;21;80;50#3;2;78;80;50"
+
+	tests := map[string]string{
+		SixelDitherNone:    header + "#0!4F!4?$#1!4?!4F$#2!4w!4?$#3!4?!4w-\x1b\\",
+		SixelDitherFloyd:   header + "#0!4F!4?$#1!4?FNFF$#2!4w!4?$#3!4?woww-\x1b\\",
+		SixelDitherOrdered: header + "#0FBDMT???$#1??A@ABFF$#2w{W_!4?$#3??_Og{ww-\x1b\\",
+	}
+	for mode, want := range tests {
+		got := imgSixelEncode(imgDither(img, pal, mode), pal, 8, 6)
+		if got != want {
+			t.Errorf("%s sixel =\n%q\nwant\n%q", mode, got, want)
+		}
+		// Dithering is deterministic.
+		if again := imgSixelEncode(imgDither(img, pal, mode), pal, 8, 6); again != got {
+			t.Errorf("%s output differs between runs", mode)
+		}
+	}
+}
+
+func TestImgMedianCutPaletteSize(t *testing.T) {
+	img := makeGradientImage(32, 32)
+
+	for _, n := range []int{2, 16, 256} {
+		pal := imgMedianCutPalette(img, n)
+		if len(pal) != n {
+			t.Errorf("palette size = %d, want %d", len(pal), n)
+		}
+	}
+
+	// Fewer distinct colors than requested yields an exact palette.
+	solid := makeImage(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	if pal := imgMedianCutPalette(solid, 256); len(pal) != 1 {
+		t.Errorf("solid palette size = %d, want 1", len(pal))
+	}
+}
+
+func TestImgSixelTransparentPixelsUnpainted(t *testing.T) {
+	img := makeImage(3, 6, color.NRGBA{})
+	img.SetNRGBA(1, 2, color.NRGBA{G: 255, A: 255})
+	pal := imgMedianCutPalette(img, 256)
+
+	indices := imgDither(img, pal, SixelDitherFloyd)
+	for i, idx := range indices {
+		if i != 2*3+1 && idx != -1 {
+			t.Errorf("pixel %d index = %d, want -1 for transparent", i, idx)
+		}
+	}
+}
+
+func TestRenderSixelUsesConfiguredPalette(t *testing.T) {
+	cfg := makeCfg()
+	cfg.SixelColors = 4
+	cfg.SixelDither = SixelDitherOrdered
+	r := NewRenderer(makeCaps(terminal.ProtocolSixel), cfg)
+
+	img := makeGradientImage(64, 64)
+	out, err := r.Render(img, 8, 4)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.HasPrefix(out, imgSixelDCS) || !strings.HasSuffix(out, imgSixelST) {
+		t.Errorf("output is not a sixel sequence: %q", out[:min(len(out), 40)])
+	}
+	if n := strings.Count(out, ";2;"); n != 4 {
+		t.Errorf("palette definitions = %d, want 4", n)
+	}
+
+	// A second size of the same image reuses the palette.
+	out2, err := r.Render(img, 4, 2)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if len(r.sixelPalettes.palettes) != 1 {
+		t.Errorf("cached palettes = %d, want 1", len(r.sixelPalettes.palettes))
+	}
+	palDefs := regexp.MustCompile(`#\d+;2;\d+;\d+;\d+`)
+	if a, b := palDefs.FindAllString(out, -1), palDefs.FindAllString(out2, -1); strings.Join(a, "") != strings.Join(b, "") {
+		t.Errorf("palette differs between sizes:\n%v\n%v", a, b)
+	}
+	if r.Cache().Stats().Entries != 2 {
+		t.Errorf("cache entries = %d, want 2 sizes", r.Cache().Stats().Entries)
+	}
+}
+
+// --- Kitty ID reuse tests --------------------------------------------------
+
+func TestKittyImageIDStable(t *testing.T) {
+	h := [32]byte{0, 0, 0, 0, 9}
+	if KittyImageID(h) != 1 {
+		t.Errorf("zero-prefixed hash ID = %d, want 1", KittyImageID(h))
+	}
+	h2 := [32]byte{0x12, 0x34, 0x56, 0x78}
+	if KittyImageID(h2) != 0x12345678 || KittyImageID(h2) != KittyImageID(h2) {
+		t.Errorf("ID = %#x, want 0x12345678", KittyImageID(h2))
+	}
+}
+
+func TestKittyStatePersists(t *testing.T) {
+	dir := t.TempDir()
+
+	s := imgOpenKittyState(dir, "136:4242")
+	if s.Has(7) {
+		t.Fatal("fresh state should not have ID 7")
+	}
+	if err := s.Mark(7); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+
+	// A later invocation on the same TTY sees the ID.
+	if !imgOpenKittyState(dir, "136:4242").Has(7) {
+		t.Error("reopened state should have ID 7")
+	}
+	// A different TTY, or the same pty in a new session, does not.
+	if imgOpenKittyState(dir, "136:5000").Has(7) {
+		t.Error("state should be invalidated for a different TTY")
+	}
+
+	if err := s.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if imgOpenKittyState(dir, "136:4242").Has(7) {
+		t.Error("Reset should forget transmitted IDs")
+	}
+}
+
+func TestKittyStateNoTTY(t *testing.T) {
+	dir := t.TempDir()
+	s := imgOpenKittyState(dir, "")
+	if err := s.Mark(3); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+	if s.Has(3) {
+		t.Error("state without a TTY should never report IDs")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("state without a TTY wrote %d files", len(entries))
+	}
+}
+
+func TestKittyStateBounded(t *testing.T) {
+	s := imgOpenKittyState(t.TempDir(), "tty")
+	for id := uint32(1); id <= imgKittyStateMaxIDs+5; id++ {
+		if err := s.Mark(id); err != nil {
+			t.Fatalf("Mark: %v", err)
+		}
+	}
+	if s.Has(1) {
+		t.Error("oldest ID should be forgotten")
+	}
+	if !s.Has(imgKittyStateMaxIDs + 5) {
+		t.Error("newest ID should be kept")
+	}
+}
+
+func TestRenderKittyReusesTransmittedImage(t *testing.T) {
+	dir := t.TempDir()
+	img := makeGradientImage(32, 32)
+
+	// First invocation transmits and places.
+	r1 := NewRenderer(makeCaps(terminal.ProtocolKitty), makeCfg())
+	r1.UseKittyState(imgOpenKittyState(dir, "tty-a"))
+	first, err := r1.Render(img, 10, 5)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(first, "a=t,") || !strings.Contains(first, "a=p,") {
+		t.Fatalf("first render should transmit and place: %q", first[:min(len(first), 80)])
+	}
+
+	// Second invocation, same terminal: placement only.
+	r2 := NewRenderer(makeCaps(terminal.ProtocolKitty), makeCfg())
+	r2.UseKittyState(imgOpenKittyState(dir, "tty-a"))
+	second, err := r2.Render(img, 10, 5)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	id := KittyImageID(imgKittySizedHash(r2.hashImage(img), 10, 5))
+	want := fmt.Sprintf("%sa=p,i=%d,c=10,r=5,q=2;%s", imgKittyESC, id, imgKittyST)
+	if second != want {
+		t.Errorf("second render = %q, want placement only %q", second, want)
+	}
+	if !strings.HasSuffix(first, want) {
+		t.Error("first render should end with the same placement")
+	}
+
+	// A new terminal gets a full transmit again.
+	r3 := NewRenderer(makeCaps(terminal.ProtocolKitty), makeCfg())
+	r3.UseKittyState(imgOpenKittyState(dir, "tty-b"))
+	if third, _ := r3.Render(img, 10, 5); !strings.Contains(third, "a=t,") {
+		t.Error("render on a new TTY should transmit")
+	}
+
+	// A different size is a different image ID.
+	if other, _ := r2.Render(img, 6, 3); !strings.Contains(other, "a=t,") {
+		t.Error("render at a new size should transmit")
+	}
+}
+
+func TestRenderKittyRetransmitOverride(t *testing.T) {
+	dir := t.TempDir()
+	img := makeGradientImage(16, 16)
+
+	cfg := makeCfg()
+	cfg.KittyRetransmit = true
+	r := NewRenderer(makeCaps(terminal.ProtocolKitty), cfg)
+	r.UseKittyState(imgOpenKittyState(dir, "tty"))
+
+	for i := 0; i < 2; i++ {
+		out, err := r.Render(img, 8, 4)
+		if err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+		if !strings.Contains(out, "a=t,") {
+			t.Errorf("render %d should transmit with KittyRetransmit", i)
+		}
+	}
+}
+
+// --- Preprocessing tests ---------------------------------------------------
+
+// makeNoiseImage creates an image of pseudo-random gray levels.
+func makeNoiseImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(1)
+	for i := 0; i < len(img.Pix); i += 4 {
+		seed = seed*1664525 + 1013904223
+		v := uint8(seed >> 24)
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = v, v, v, 255
+	}
+	return img
+}
+
+func TestPreprocessFillsCellBox(t *testing.T) {
+	sources := map[string]image.Image{
+		"sliver":    makeGradientImage(3, 900),
+		"panorama":  makeGradientImage(1200, 2),
+		"tall":      makeGradientImage(100, 1000),
+		"tiny wide": makeGradientImage(9, 1),
+	}
+	for _, proto := range []terminal.GraphicsProtocol{terminal.ProtocolKitty, terminal.ProtocolHalfblocks} {
+		for _, settings := range []struct{ crop, pad string }{
+			{CropCenter, PadNone},
+			{CropEntropy, PadNone},
+			{CropNone, PadEdge},
+			{CropNone, "#102030"},
+			{CropCenter, PadTheme},
+		} {
+			cfg := makeCfg()
+			cfg.WaifuCrop, cfg.WaifuPad = settings.crop, settings.pad
+			r := NewRenderer(makeCaps(proto), cfg)
+			cellW, cellH := 8, 16
+			if r.cellBlocks() {
+				cellW, cellH = r.blockCellSize()
+			}
+			for name, src := range sources {
+				for _, cells := range [][2]int{{20, 6}, {4, 30}, {1, 1}} {
+					out := r.preprocess(src, cells[0], cells[1])
+					want := image.Rect(0, 0, cells[0]*cellW, cells[1]*cellH)
+					if out.Bounds() != want {
+						t.Errorf("%v %+v %s in %dx%d cells: bounds %v, want %v",
+							proto, settings, name, cells[0], cells[1], out.Bounds(), want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPreprocessNoneLeavesImage(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolKitty), config.ImageConfig{WaifuCrop: CropNone, WaifuPad: PadNone})
+	img := makeGradientImage(20, 20)
+	if out := r.preprocess(img, 10, 5); out != image.Image(img) {
+		t.Error("preprocess() with no settings should return the image itself")
+	}
+	var h [32]byte
+	h[0] = 1
+	if r.prep.key(h) != h {
+		t.Error("inactive preprocessing should not change the cache key")
+	}
+}
+
+func TestImgEntropyCrop(t *testing.T) {
+	// A flat top half over a noisy bottom half: a square crop should land
+	// in the noise.
+	img := makeImage(40, 400, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	draw.Draw(img, image.Rect(0, 200, 40, 400), makeNoiseImage(40, 200), image.Point{}, draw.Src)
+
+	got := imgEntropyCrop(img, 10, 10)
+	if got.Dx() != 40 || got.Dy() != 40 || got.Min.Y < 200 {
+		t.Errorf("entropy crop = %v, want a 40x40 square in the noisy bottom half", got)
+	}
+	if center := imgCenterCrop(img.Bounds(), 10, 10); center != image.Rect(0, 180, 40, 220) {
+		t.Errorf("center crop = %v, want the middle 40x40", center)
+	}
+
+	// With no detail anywhere, the center is kept.
+	flat := makeImage(300, 30, color.White)
+	if got := imgEntropyCrop(flat, 1, 1); got != image.Rect(135, 0, 165, 30) {
+		t.Errorf("entropy crop of a flat image = %v, want the center", got)
+	}
+}
+
+func TestPreprocessPadColors(t *testing.T) {
+	// A blue portrait with a red border: edge padding uses the red.
+	img := makeImage(20, 60, color.NRGBA{R: 220, A: 255})
+	draw.Draw(img, image.Rect(2, 2, 18, 58), &image.Uniform{color.NRGBA{B: 255, A: 255}}, image.Point{}, draw.Src)
+
+	for pad, want := range map[string]color.NRGBA{
+		PadEdge:   {R: 220, A: 255},
+		"#102030": {R: 0x10, G: 0x20, B: 0x30, A: 255},
+	} {
+		cfg := makeCfg()
+		cfg.WaifuPad = pad
+		out := ImageToNRGBA(NewRenderer(makeCaps(terminal.ProtocolKitty), cfg).preprocess(img, 20, 5))
+		if got := out.NRGBAAt(0, 40); got != want {
+			t.Errorf("pad %q: left margin = %v, want %v", pad, got, want)
+		}
+		if got := out.NRGBAAt(80, 40); got.B < 200 {
+			t.Errorf("pad %q: center = %v, want the blue image", pad, got)
+		}
+	}
+
+	defer func(prev theme.Theme) { theme.Current = prev }(theme.Current)
+	theme.Current.Background = "#123456"
+	if got := imgPadColor(PadTheme, img); got != (color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 255}) {
+		t.Errorf("theme pad = %v, want the theme background", got)
+	}
+	if _, ok := imgEdgeColor(makeImage(4, 4, color.Transparent)); ok {
+		t.Error("imgEdgeColor() of a transparent image should find no color")
+	}
+}
+
+func TestPreprocessRoundedCorners(t *testing.T) {
+	cfg := makeCfg()
+	cfg.WaifuCrop = CropCenter
+	cfg.WaifuCornerRadius = 12
+	img := makeImage(100, 100, color.NRGBA{G: 255, A: 255})
+
+	out := ImageToNRGBA(NewRenderer(makeCaps(terminal.ProtocolKitty), cfg).preprocess(img, 10, 5))
+	b := out.Bounds()
+	for _, p := range []image.Point{{0, 0}, {b.Max.X - 1, 0}, {0, b.Max.Y - 1}, {b.Max.X - 1, b.Max.Y - 1}} {
+		if a := out.NRGBAAt(p.X, p.Y).A; a != 0 {
+			t.Errorf("corner %v alpha = %d, want 0", p, a)
+		}
+	}
+	if a := out.NRGBAAt(b.Dx()/2, 0).A; a != 255 {
+		t.Errorf("top edge alpha = %d, want 255", a)
+	}
+	if a := out.NRGBAAt(12, 12).A; a != 255 {
+		t.Errorf("inside the radius alpha = %d, want 255", a)
+	}
+	if a := img.NRGBAAt(0, 0).A; a != 255 {
+		t.Error("preprocess() modified the source image")
+	}
+
+	// Sixel draws no partial transparency, so its corners stay square.
+	out = ImageToNRGBA(NewRenderer(makeCaps(terminal.ProtocolSixel), cfg).preprocess(img, 10, 5))
+	if a := out.NRGBAAt(0, 0).A; a != 255 {
+		t.Errorf("sixel corner alpha = %d, want 255", a)
+	}
+}
+
+func TestPreprocessCacheKey(t *testing.T) {
+	img := makeGradientImage(30, 90)
+	dir := t.TempDir()
+	render := func(crop string) string {
+		cfg := makeCfg()
+		cfg.DiskCacheDir = dir
+		cfg.WaifuCrop = crop
+		out, err := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), cfg).Render(img, 10, 5)
+		if err != nil {
+			t.Fatalf("Render() error: %v", err)
+		}
+		return out
+	}
+	plain, cropped := render(CropNone), render(CropCenter)
+	if plain == cropped {
+		t.Error("a cropped render was served from the uncropped render's cache entry")
+	}
+	if again := render(CropCenter); again != cropped {
+		t.Error("the same settings should render the same output")
+	}
+}
+
+// --- Multiplexer passthrough tests ------------------------------------------
+
+func TestWrapPassthroughTmux(t *testing.T) {
+	transmit := imgKittyTransmit([]byte("rgba"), 7, false)
+	display := imgKittyDisplay(7, 2, 3, 0)
+	wrapped := imgWrapPassthrough(transmit+display, terminal.PassthroughTmux)
+
+	wantTransmit := "\x1bPtmux;\x1b\x1b_Ga=t,i=7,f=32,m=0;cmdiYQ==\x1b\x1b\\\x1b\\"
+	if !strings.HasPrefix(wrapped, wantTransmit) {
+		t.Errorf("wrapped transmit = %q, want prefix %q", wrapped[:min(len(wrapped), 80)], wantTransmit)
+	}
+	wantDisplay := "\x1bPtmux;\x1b\x1b_Ga=p,i=7,U=1,r=2,c=3,z=0;\x1b\x1b\\\x1b\\"
+	if !strings.Contains(wrapped, wantDisplay) {
+		t.Errorf("wrapped = %q, want the display command wrapped as %q", wrapped, wantDisplay)
+	}
+	// The placeholders are text for tmux to draw, not passed through.
+	if !strings.HasSuffix(wrapped, wantDisplay+imgKittyUnicodePlaceholder(7, 2, 3)) {
+		t.Error("placeholders should follow the wrapped display command unchanged")
+	}
+
+	// Each chunk of a chunked transmit is wrapped on its own.
+	chunked := imgKittyTransmit(make([]byte, imgKittyChunkSize), 9, false)
+	wrapped = imgWrapPassthrough(chunked, terminal.PassthroughTmux)
+	if n, want := strings.Count(wrapped, "\x1bPtmux;"), strings.Count(chunked, imgKittyESC); n != want || want < 2 {
+		t.Errorf("wrapped %d sequences, want %d", n, want)
+	}
+
+	if got := imgWrapPassthrough(transmit, terminal.PassthroughNone); got != transmit {
+		t.Error("output outside a multiplexer should not be wrapped")
+	}
+	if got := imgWrapPassthrough("\x1b[2A▀\x1b[0m", terminal.PassthroughTmux); got != "\x1b[2A▀\x1b[0m" {
+		t.Errorf("character cell output = %q, want it unwrapped", got)
+	}
+}
+
+func TestWrapPassthroughScreen(t *testing.T) {
+	seq := imgITerm2Inline(make([]byte, 1000), 2, 4)
+	wrapped := imgWrapPassthrough("\x1b7"+seq+"\x1b8", terminal.PassthroughScreen)
+
+	if !strings.HasPrefix(wrapped, "\x1b7\x1bP\x1b]1337;File=") || !strings.HasSuffix(wrapped, "\a\x1b\\\x1b8") {
+		t.Errorf("wrapped = %q..., want the OSC in DCS strings between the cursor saves", wrapped[:min(len(wrapped), 40)])
+	}
+	chunks := strings.Count(wrapped, "\x1bP")
+	if want := (len(seq) + imgScreenChunkSize - 1) / imgScreenChunkSize; chunks != want {
+		t.Errorf("split into %d DCS strings, want %d", chunks, want)
+	}
+	if got := strings.ReplaceAll(strings.ReplaceAll(wrapped, "\x1bP", ""), "\x1b\\", ""); got != "\x1b7"+seq+"\x1b8" {
+		t.Error("unwrapping the DCS strings should give back the sequence")
+	}
+}
+
+func TestRendererPassthrough(t *testing.T) {
+	// go-termimg wraps bare output itself when it sees TMUX.
+	t.Setenv("TMUX", "")
+	img := makeGradientImage(32, 32)
+	tmuxCaps := func(passthrough bool) terminal.Capabilities {
+		caps := makeCaps(terminal.ProtocolKitty)
+		caps.Tmux, caps.Mux, caps.Passthrough = true, true, passthrough
+		return caps
+	}
+
+	// With passthrough on, every graphics sequence is wrapped.
+	r := NewRenderer(tmuxCaps(true), makeCfg())
+	if r.Protocol() != terminal.ProtocolKitty {
+		t.Fatalf("protocol = %v, want kitty", r.Protocol())
+	}
+	out, err := r.Render(img, 10, 5)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.HasPrefix(out, "\x1bPtmux;\x1b\x1b_G") || strings.Contains(out, "\x1bPtmux;\x1b\x1bPtmux;") {
+		t.Errorf("render = %q..., want each sequence wrapped once", out[:min(len(out), 40)])
+	}
+	if strings.Count(out, "\x1bPtmux;") != strings.Count(out, "\x1b\x1b_G") {
+		t.Error("every APC should be inside a passthrough")
+	}
+
+	// Wrapped and bare output are cached under different keys.
+	bare := NewRenderer(makeCaps(terminal.ProtocolKitty), makeCfg())
+	hash := r.prep.key(r.hashImage(img))
+	wrappedKey := MakeCacheKey(r.cacheFormat(r.renderFormat()), 10, 5, hash)
+	bareKey := MakeCacheKey(bare.cacheFormat(bare.renderFormat()), 10, 5, hash)
+	if wrappedKey == bareKey {
+		t.Fatal("wrapped and bare output share a cache key")
+	}
+	if cached, ok := r.Cache().Get(wrappedKey); !ok || cached != out {
+		t.Error("wrapped output should be cached under the wrapped key")
+	}
+	bare.cache = r.cache
+	if got, _ := bare.Render(img, 10, 5); strings.Contains(got, "\x1bPtmux;") {
+		t.Error("a bare renderer sharing the cache was served wrapped output")
+	}
+
+	// The persistent Kitty path wraps the transmit and the placement,
+	// which uses placeholders.
+	r = NewRenderer(tmuxCaps(true), makeCfg())
+	r.UseKittyState(imgOpenKittyState(t.TempDir(), "tty-a"))
+	out, _ = r.Render(img, 10, 5)
+	id := KittyImageID(imgKittySizedHash(hash, 10, 5))
+	place := fmt.Sprintf("\x1bPtmux;\x1b\x1b_Ga=p,i=%d,U=1,c=10,r=5,q=2;\x1b\x1b\\\x1b\\", id) + imgKittyUnicodePlaceholder(id, 5, 10)
+	if !strings.HasPrefix(out, "\x1bPtmux;") || !strings.HasSuffix(out, place) {
+		t.Errorf("persistent render should wrap the transmit and end with %q", place)
+	}
+
+	// A terminal without placeholders cannot show Kitty images through
+	// tmux, so they fall back a level.
+	caps := tmuxCaps(true)
+	caps.KittyPlaceholders = false
+	if r := NewRenderer(caps, makeCfg()); r.Protocol() != terminal.ProtocolHalfblocks {
+		t.Errorf("protocol without placeholders = %v, want halfblocks", r.Protocol())
+	}
+	caps.Term = terminal.TermWezTerm
+	if r := NewRenderer(caps, makeCfg()); r.Protocol() != terminal.ProtocolITerm2 {
+		t.Errorf("protocol for WezTerm without placeholders = %v, want iterm2", r.Protocol())
+	}
+	caps.Tmux, caps.Mux = false, false
+	if r := NewRenderer(caps, makeCfg()); r.Protocol() != terminal.ProtocolKitty {
+		t.Errorf("protocol without placeholders outside tmux = %v, want kitty placed at the cursor", r.Protocol())
+	}
+
+	// With passthrough off, graphics fall back to halfblocks.
+	r = NewRenderer(tmuxCaps(false), makeCfg())
+	if r.Protocol() != terminal.ProtocolHalfblocks {
+		t.Errorf("protocol with passthrough off = %v, want halfblocks", r.Protocol())
+	}
+	cfg := makeCfg()
+	cfg.Protocol = "iterm2"
+	if r := NewRenderer(tmuxCaps(false), cfg); r.Protocol() != terminal.ProtocolHalfblocks {
+		t.Errorf("iterm2 override with passthrough off = %v, want halfblocks", r.Protocol())
+	}
+}
+
+// --- Placeholder tests -----------------------------------------------------
+
+func TestRenderPlaceholder_CoversRenderArea(t *testing.T) {
+	sgr := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	img := makeImage(100, 50, color.NRGBA{R: 200, G: 40, B: 10, A: 255})
+
+	for _, proto := range []terminal.GraphicsProtocol{terminal.ProtocolHalfblocks, terminal.ProtocolKitty} {
+		r := NewRenderer(makeCaps(proto), makeCfg())
+		out, err := r.RenderPlaceholder(img, 20, 10)
+		if err != nil {
+			t.Fatalf("%v: RenderPlaceholder: %v", proto, err)
+		}
+		if strings.Contains(out, "\x1b_G") {
+			t.Errorf("%v: placeholder should be half blocks, got graphics output", proto)
+		}
+		if !strings.Contains(out, "\x1b[38;2;200;40;10m") {
+			t.Errorf("%v: placeholder should be drawn in the image's color", proto)
+		}
+
+		// 100x50 scaled into 20x10 cells keeps its 2:1 aspect ratio.
+		wantCols, wantRows := 20, 5
+		if proto == terminal.ProtocolKitty {
+			// 8x16 cells: 160x160 pixels fit 100x50 unscaled.
+			wantCols, wantRows = 13, 4
+		}
+		lines := strings.Split(out, "\n")
+		if len(lines) != wantRows {
+			t.Errorf("%v: rows = %d, want %d", proto, len(lines), wantRows)
+		}
+		for i, line := range lines {
+			if n := utf8.RuneCountInString(sgr.ReplaceAllString(line, "")); n != wantCols {
+				t.Errorf("%v: line %d is %d cells wide, want %d", proto, i, n, wantCols)
+			}
+		}
+	}
+}
+
+func TestRenderPlaceholder_CachesThumbnail(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	img := makeGradientImage(64, 64)
+
+	a, _ := r.RenderPlaceholder(img, 10, 5)
+	b, _ := r.RenderPlaceholder(img, 10, 5)
+	if a != b {
+		t.Error("placeholders of the same image should match")
+	}
+	if _, err := r.RenderPlaceholder(img, 20, 10); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(r.thumbs.thumbs); n != 1 {
+		t.Errorf("cached thumbnails = %d, want 1 for one image at two sizes", n)
+	}
+
+	if _, err := r.RenderPlaceholder(nil, 10, 5); err == nil {
+		t.Error("a nil image should be an error")
+	}
+	if out, _ := r.RenderPlaceholder(img, 0, 5); out != "" {
+		t.Errorf("an empty area should render nothing, got %q", out)
+	}
+}
+
+func TestRenderPlaceholder_TransparentLeftUndrawn(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	out, err := r.RenderPlaceholder(makeImage(8, 8, color.NRGBA{}), 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "38;2;") || strings.Contains(out, "48;2;") {
+		t.Errorf("a transparent image should leave the background, got %q", out)
+	}
+}
+
+func TestClearRegion(t *testing.T) {
+	got := ClearRegion(3, 2)
+	want := "\x1b7\x1b[0m   \x1b[1B\x1b[3D   \x1b8"
+	if got != want {
+		t.Errorf("ClearRegion(3, 2) = %q, want %q", got, want)
+	}
+	if ClearRegion(0, 2) != "" {
+		t.Error("an empty region should need no clearing")
+	}
+}
+
+func TestAsyncRenderWithPlaceholder(t *testing.T) {
+	r := NewRenderer(makeCaps(terminal.ProtocolHalfblocks), makeCfg())
+	ar := NewAsyncRenderer(r)
+	defer ar.Close()
+
+	img := makeImage(40, 20, color.NRGBA{G: 255, A: 255})
+	done := make(chan string, 1)
+	placeholder, _ := ar.RenderAsyncWithPlaceholder(img, 10, 10, func(s string, err error) {
+		if err != nil {
+			t.Errorf("async render error: %v", err)
+		}
+		done <- s
+	})
+	if placeholder == "" {
+		t.Fatal("placeholder should be returned at once")
+	}
+
+	select {
+	case result := <-done:
+		// 40x20 in 10x10 half-block cells is 10x3 cells.
+		if clear := ClearRegion(10, 3); !strings.HasPrefix(result, clear) {
+			t.Errorf("result should start by clearing the placeholder's cells, got %q", result)
+		}
+		real, _ := r.Render(img, 10, 10)
+		if !strings.HasSuffix(result, real) {
+			t.Error("result should end with the real render")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("async render timed out")
+	}
+}
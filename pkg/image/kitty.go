@@ -76,19 +76,28 @@ func imgKittyTransmit(data []byte, id uint32, compressed bool) string {
 		return fmt.Sprintf("%sa=t,i=%d,f=32,m=0;%s", imgKittyESC, id, imgKittyST)
 	}
 
-	payload := data
-	compressionFlag := ""
-	if compressed {
-		var err error
-		payload, err = imgZlibCompress(data)
-		if err != nil {
-			// Fall back to uncompressed on error.
-			payload = data
-		} else {
-			compressionFlag = ",o=z"
-		}
+	payload, compressionFlag := imgKittyPayload(data, compressed)
+	return imgKittyChunked(fmt.Sprintf("a=t,i=%d,f=32%s", id, compressionFlag), payload)
+}
+
+// imgKittyPayload optionally ZLIB-compresses data, returning the bytes to
+// send and the matching ",o=z" flag (empty when uncompressed). Compression
+// errors fall back to the raw data.
+func imgKittyPayload(data []byte, compressed bool) ([]byte, string) {
+	if !compressed {
+		return data, ""
+	}
+	payload, err := imgZlibCompress(data)
+	if err != nil {
+		return data, ""
 	}
+	return payload, ",o=z"
+}
 
+// imgKittyChunked base64-encodes payload and splits it into APC sequences
+// of imgKittyChunkSize bytes. The first chunk carries header; continuation
+// chunks only specify m (more).
+func imgKittyChunked(header string, payload []byte) string {
 	encoded := base64.StdEncoding.EncodeToString(payload)
 
 	var b strings.Builder
@@ -108,8 +117,8 @@ func imgKittyTransmit(data []byte, id uint32, compressed bool) string {
 
 		if i == 0 {
 			// First chunk includes all header fields.
-			fmt.Fprintf(&b, "%sa=t,i=%d,f=32%s,m=%d;%s%s",
-				imgKittyESC, id, compressionFlag, more, chunk, imgKittyST)
+			fmt.Fprintf(&b, "%s%s,m=%d;%s%s",
+				imgKittyESC, header, more, chunk, imgKittyST)
 		} else {
 			// Continuation chunks only specify m (more).
 			fmt.Fprintf(&b, "%sm=%d;%s%s",
@@ -133,6 +142,18 @@ func imgKittyDisplay(id uint32, rows, cols int, zIndex int) string {
 	return header + placeholder
 }
 
+// imgKittyPlace builds a quiet (q=2) placement of a previously transmitted
+// image over rows x cols cells. With placeholders it is a virtual
+// placement followed by its Unicode placeholder grid; otherwise the image
+// is placed at the cursor.
+func imgKittyPlace(id uint32, rows, cols int, placeholders bool) string {
+	if !placeholders {
+		return fmt.Sprintf("%sa=p,i=%d,c=%d,r=%d,q=2;%s", imgKittyESC, id, cols, rows, imgKittyST)
+	}
+	return fmt.Sprintf("%sa=p,i=%d,U=1,c=%d,r=%d,q=2;%s", imgKittyESC, id, cols, rows, imgKittyST) +
+		imgKittyUnicodePlaceholder(id, rows, cols)
+}
+
 // imgZlibCompress compresses data using ZLIB (deflate with zlib header).
 func imgZlibCompress(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
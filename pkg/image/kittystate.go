@@ -0,0 +1,207 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
+)
+
+// imgKittyPersistentCacheProtocol is the CacheKey protocol for transmit
+// sequences produced by renderKittyPersistent.
+const imgKittyPersistentCacheProtocol = "kitty-persistent"
+
+// imgKittyStateMaxIDs bounds how many transmitted IDs a state file tracks.
+// The oldest IDs are forgotten first; forgetting one only costs a
+// retransmit.
+const imgKittyStateMaxIDs = 32
+
+// KittyState records which Kitty image IDs have been transmitted to the
+// current terminal, persisted across prompt invocations so a banner can
+// place an image the terminal already holds instead of sending it again.
+//
+// The state is tied to a TTY identity. Kitty keeps image data per terminal
+// instance, so a state file written under a different TTY (including the
+// same pty reopened by a new terminal) is discarded on load.
+type KittyState struct {
+	mu   sync.Mutex
+	path string
+	tty  string
+	ids  []uint32
+}
+
+// imgKittyStateFile is the on-disk form of KittyState.
+type imgKittyStateFile struct {
+	TTY string   `json:"tty"`
+	IDs []uint32 `json:"ids"`
+}
+
+// OpenKittyState loads the state for the current TTY from dir, typically
+// the waifu cache directory. Without a controlling TTY the returned state
+// never reports an ID as transmitted, so every render transmits.
+func OpenKittyState(dir string) *KittyState {
+	return imgOpenKittyState(dir, TTYIdentity())
+}
+
+// imgOpenKittyState loads state for an explicit TTY identity.
+func imgOpenKittyState(dir, tty string) *KittyState {
+	s := &KittyState{tty: tty}
+	if tty == "" {
+		return s
+	}
+
+	sum := sha256.Sum256([]byte(tty))
+	s.path = filepath.Join(dir, fmt.Sprintf("kitty-%x.json", sum[:4]))
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s
+	}
+	var f imgKittyStateFile
+	if err := json.Unmarshal(data, &f); err != nil || f.TTY != tty {
+		return s
+	}
+	s.ids = f.IDs
+	return s
+}
+
+// KittyImageID derives a stable, non-zero Kitty image ID from a content
+// hash, so the same image gets the same ID in every invocation.
+func KittyImageID(hash [32]byte) uint32 {
+	id := binary.BigEndian.Uint32(hash[:4])
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
+
+// Has reports whether id has been transmitted to this terminal.
+func (s *KittyState) Has(id uint32) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Mark records id as transmitted and saves the state file.
+func (s *KittyState) Mark(id uint32) error {
+	if s == nil || s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, v := range s.ids {
+		if v == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			break
+		}
+	}
+	s.ids = append(s.ids, id)
+	if len(s.ids) > imgKittyStateMaxIDs {
+		s.ids = s.ids[len(s.ids)-imgKittyStateMaxIDs:]
+	}
+	return s.save()
+}
+
+// Reset forgets every transmitted ID and removes the state file.
+func (s *KittyState) Reset() error {
+	if s == nil || s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = nil
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove kitty state: %w", err)
+	}
+	return nil
+}
+
+// save writes the state file atomically. Callers hold s.mu.
+func (s *KittyState) save() error {
+	data, err := json.Marshal(imgKittyStateFile{TTY: s.tty, IDs: s.ids})
+	if err != nil {
+		return fmt.Errorf("marshal kitty state: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create kitty state dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".kitty-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, s.path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("rename temp to final: %w", err)
+	}
+	return nil
+}
+
+// renderKittyPersistent renders img over Kitty using a stable image ID.
+// When r.kittyState records that the terminal already holds the ID, only
+// the placement is emitted; otherwise the image is transmitted, placed,
+// and the ID recorded. The transmit sequence is kept in the render cache.
+// Inside a multiplexer both are wrapped for passthrough, and the image is
+// placed with Unicode placeholders, which NewRenderer only keeps Kitty
+// for when the terminal supports them.
+func (r *Renderer) renderKittyPersistent(img image.Image, imgHash [32]byte, width, height int) string {
+	id := KittyImageID(imgKittySizedHash(imgHash, width, height))
+	place := imgWrapPassthrough(imgKittyPlace(id, height, width, r.passthrough != terminal.PassthroughNone), r.passthrough)
+
+	if !r.cfg.KittyRetransmit && r.kittyState.Has(id) {
+		return place
+	}
+
+	key := MakeCacheKey(r.cacheFormat(imgKittyPersistentCacheProtocol), width, height, imgHash)
+	transmit, ok := r.cache.Get(key)
+	if !ok {
+		resized := ImageToNRGBA(ResizeToFit(r.preprocess(img, width, height), width, height, r.caps.Size.CellW, r.caps.Size.CellH))
+		payload, compressionFlag := imgKittyPayload(imgNRGBAPixels(resized), true)
+		transmit = imgKittyChunked(
+			fmt.Sprintf("a=t,i=%d,f=32,s=%d,v=%d,q=2%s",
+				id, resized.Bounds().Dx(), resized.Bounds().Dy(), compressionFlag),
+			payload)
+		transmit = imgWrapPassthrough(transmit, r.passthrough)
+		r.cache.Put(key, transmit)
+	}
+
+	// A failed save only means the next prompt transmits again.
+	_ = r.kittyState.Mark(id)
+	return transmit + place
+}
+
+// imgKittySizedHash mixes the target cell size into an image hash, since
+// each size is transmitted as a separately resized image.
+func imgKittySizedHash(imgHash [32]byte, width, height int) [32]byte {
+	var buf [32 + 16]byte
+	copy(buf[:], imgHash[:])
+	binary.BigEndian.PutUint64(buf[32:], uint64(width))
+	binary.BigEndian.PutUint64(buf[40:], uint64(height))
+	return sha256.Sum256(buf[:])
+}
@@ -1,7 +1,11 @@
 package image
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"image"
+	"image/png"
 	"strings"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
@@ -16,6 +20,12 @@ type imgPlacement struct {
 	Rows   int    // Height in terminal cells
 	Cols   int    // Width in terminal cells
 	ZIndex int    // Stacking order (higher = on top)
+
+	// Pixel dimensions of Data. iTerm2 needs an encoded image file, so
+	// raw RGBA data matching Width*Height*4 is converted to PNG; any
+	// other Data is sent as-is on the assumption it is already encoded.
+	Width  int
+	Height int
 }
 
 // imgRenderPlacements renders multiple image placements into a single
@@ -28,7 +38,12 @@ type imgPlacement struct {
 //   - This allows the terminal to compose all images in a single
 //     frame update
 //
-// For other protocols (Halfblocks, Sixel, iTerm2):
+// For iTerm2:
+//   - Each image is sent as an OSC 1337 File= sequence sized in cells
+//   - The cursor is saved before and restored after each placement so
+//     placements do not shift one another
+//
+// For other protocols (Halfblocks, Sixel):
 //   - Images are rendered sequentially with cursor positioning
 //     between placements
 func imgRenderPlacements(placements []imgPlacement, protocol terminal.GraphicsProtocol) string {
@@ -39,6 +54,8 @@ func imgRenderPlacements(placements []imgPlacement, protocol terminal.GraphicsPr
 	switch protocol {
 	case terminal.ProtocolKitty:
 		return imgRenderPlacementsKitty(placements)
+	case terminal.ProtocolITerm2:
+		return imgRenderPlacementsITerm2(placements)
 	default:
 		return imgRenderPlacementsSequential(placements, protocol)
 	}
@@ -76,9 +93,55 @@ func imgRenderPlacementsKitty(placements []imgPlacement) string {
 	return b.String()
 }
 
+// imgRenderPlacementsITerm2 renders placements using the iTerm2 inline
+// image protocol. Each placement is wrapped in cursor save/restore and
+// positioned the same way as the Kitty path, with its width and height
+// given in cells so the terminal scales it to the placement.
+func imgRenderPlacementsITerm2(placements []imgPlacement) string {
+	var b strings.Builder
+	b.Grow(len(placements) * 1024)
+
+	for _, p := range placements {
+		b.WriteString("\x1b7")
+		// Move cursor to placement position (1-indexed for ANSI).
+		if p.Row > 0 || p.Col > 0 {
+			fmt.Fprintf(&b, "\x1b[%d;%dH", p.Row+1, p.Col+1)
+		}
+		b.WriteString(imgITerm2Inline(imgITerm2File(p), p.Rows, p.Cols))
+		b.WriteString("\x1b8")
+	}
+
+	return b.String()
+}
+
+// imgITerm2Inline builds an OSC 1337 File= sequence displaying data inline
+// at exactly cols x rows cells.
+func imgITerm2Inline(data []byte, rows, cols int) string {
+	return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=0:%s\a",
+		len(data), cols, rows, base64.StdEncoding.EncodeToString(data))
+}
+
+// imgITerm2File returns the placement's data as an image file, encoding
+// raw RGBA pixels to PNG when the placement's pixel dimensions match.
+func imgITerm2File(p imgPlacement) []byte {
+	if p.Width <= 0 || p.Height <= 0 || len(p.Data) != p.Width*p.Height*4 {
+		return p.Data
+	}
+	img := &image.NRGBA{
+		Pix:    p.Data,
+		Stride: p.Width * 4,
+		Rect:   image.Rect(0, 0, p.Width, p.Height),
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return p.Data
+	}
+	return buf.Bytes()
+}
+
 // imgRenderPlacementsSequential renders placements one at a time with
 // cursor positioning. Used for protocols that don't support batched
-// image composition (Halfblocks, Sixel, iTerm2).
+// image composition (Halfblocks, Sixel).
 func imgRenderPlacementsSequential(placements []imgPlacement, protocol terminal.GraphicsProtocol) string {
 	var b strings.Builder
 	b.Grow(len(placements) * 512)
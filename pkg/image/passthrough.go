@@ -0,0 +1,115 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
+)
+
+// imgScreenChunkSize is the most bytes sent in one screen passthrough DCS;
+// screen drops longer strings.
+const imgScreenChunkSize = 512
+
+// cacheFormat names rendered output in cache keys, marking output wrapped
+// for a multiplexer so it is never served to the bare terminal, or the
+// other way round.
+func (r *Renderer) cacheFormat(format string) string {
+	if r.passthrough == terminal.PassthroughNone {
+		return format
+	}
+	return format + "+" + r.passthrough.String()
+}
+
+// renderPassthrough encodes img for the Kitty or iTerm2 protocol inside a
+// multiplexer, where go-termimg's own tmux handling is bypassed so the
+// output is wrapped exactly once. Kitty images are shown with Unicode
+// placeholders, which the multiplexer moves and clips like text.
+func (r *Renderer) renderPassthrough(img image.Image, imgHash [32]byte, widthCells, heightCells int) (string, error) {
+	if r.protocol == terminal.ProtocolITerm2 {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return "", fmt.Errorf("encode png: %w", err)
+		}
+		return imgITerm2Inline(buf.Bytes(), heightCells, widthCells), nil
+	}
+	nrgba := ImageToNRGBA(img)
+	id := KittyImageID(imgKittySizedHash(imgHash, widthCells, heightCells))
+	payload, compressionFlag := imgKittyPayload(imgNRGBAPixels(nrgba), true)
+	transmit := imgKittyChunked(
+		fmt.Sprintf("a=t,i=%d,f=32,s=%d,v=%d,q=2%s",
+			id, nrgba.Bounds().Dx(), nrgba.Bounds().Dy(), compressionFlag),
+		payload)
+	return transmit + imgKittyDisplay(id, heightCells, widthCells, 0), nil
+}
+
+// imgWrapPassthrough wraps every APC, OSC, and DCS sequence in s for the
+// multiplexer: for tmux each becomes DCS "tmux;" with its ESC bytes
+// doubled, for screen a series of plain DCS strings of at most
+// imgScreenChunkSize bytes. Text between them, such as Kitty placeholders
+// and cursor movement, is left for the multiplexer to draw.
+func imgWrapPassthrough(s string, mode terminal.Passthrough) string {
+	if mode == terminal.PassthroughNone {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/8 + 64)
+	for {
+		start := imgNextStringSequence(s)
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:start])
+		n := imgStringSequenceLen(s[start:])
+		seq := s[start : start+n]
+		s = s[start+n:]
+
+		if mode == terminal.PassthroughTmux {
+			b.WriteString("\x1bPtmux;")
+			b.WriteString(strings.ReplaceAll(seq, "\x1b", "\x1b\x1b"))
+			b.WriteString("\x1b\\")
+			continue
+		}
+		for len(seq) > 0 {
+			chunk := seq[:min(len(seq), imgScreenChunkSize)]
+			seq = seq[len(chunk):]
+			b.WriteString("\x1bP")
+			b.WriteString(chunk)
+			b.WriteString("\x1b\\")
+		}
+	}
+}
+
+// imgNextStringSequence returns the index of the first APC, OSC, or DCS
+// introducer in s, or -1.
+func imgNextStringSequence(s string) int {
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] != '\x1b' {
+			continue
+		}
+		switch s[i+1] {
+		case '_', ']', 'P':
+			return i
+		}
+	}
+	return -1
+}
+
+// imgStringSequenceLen returns the length of the string sequence at the
+// start of s, through its ST, or BEL for OSC. An unterminated sequence
+// runs to the end of s.
+func imgStringSequenceLen(s string) int {
+	for i := 2; i < len(s); i++ {
+		switch {
+		case s[i] == '\a' && s[1] == ']':
+			return i + 1
+		case s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\':
+			return i + 2
+		}
+	}
+	return len(s)
+}
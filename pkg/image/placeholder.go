@@ -0,0 +1,272 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// imgThumbSize is the side of the thumbnail a placeholder is drawn from.
+const imgThumbSize = 8
+
+// imgThumbSamples is the side of the grid of source pixels sampled for a
+// thumbnail, so its cost does not grow with the image.
+const imgThumbSamples = 32
+
+// imgThumbCacheMax bounds the thumbnails kept by content hash.
+const imgThumbCacheMax = 64
+
+// imgThumb is an imgThumbSize square downscale of an image, row-major.
+type imgThumb [imgThumbSize * imgThumbSize]color.NRGBA
+
+// imgThumbs caches thumbnails by the hash of the sampled pixels. The
+// cache is cleared when it reaches imgThumbCacheMax.
+type imgThumbs struct {
+	mu     sync.Mutex
+	thumbs map[[32]byte]*imgThumb
+}
+
+// get returns the cached thumbnail for hash, building it with build on a
+// miss.
+func (t *imgThumbs) get(hash [32]byte, build func() *imgThumb) *imgThumb {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if th, ok := t.thumbs[hash]; ok {
+		return th
+	}
+	if t.thumbs == nil || len(t.thumbs) >= imgThumbCacheMax {
+		t.thumbs = make(map[[32]byte]*imgThumb)
+	}
+	th := build()
+	t.thumbs[hash] = th
+	return th
+}
+
+// RenderPlaceholder renders a blurred stand-in for img, for a TUI to show
+// at once while the real render runs on an AsyncRenderer. It is drawn in
+// half blocks whatever the protocol, scaled up from an 8x8 thumbnail, and
+// covers the cells the real render of img at width by height will, so
+// swapping one for the other does not move the layout. Every line is the
+// same width.
+//
+// The thumbnail is built from a fixed grid of samples and cached by their
+// hash, so the cost does not depend on the image's size.
+func (r *Renderer) RenderPlaceholder(img image.Image, width, height int) (string, error) {
+	out, _, _, err := r.renderPlaceholder(img, width, height)
+	return out, err
+}
+
+// renderPlaceholder is RenderPlaceholder, also returning the cells the
+// placeholder covers.
+func (r *Renderer) renderPlaceholder(img image.Image, width, height int) (out string, cols, rows int, err error) {
+	if img == nil {
+		return "", 0, 0, fmt.Errorf("image is nil")
+	}
+	bounds := img.Bounds()
+	if width <= 0 || height <= 0 || bounds.Empty() {
+		return "", 0, 0, nil
+	}
+
+	samples := imgSampleGrid(img)
+	hash := ImageHashFromBounds(bounds.Dx(), bounds.Dy(), samples)
+	thumb := r.thumbs.get(hash, func() *imgThumb { return imgBuildThumb(samples) })
+
+	cols, rows = r.placeholderCells(bounds.Dx(), bounds.Dy(), width, height)
+	return r.renderThumb(thumb, cols, rows), cols, rows, nil
+}
+
+// RenderFilePlaceholder decodes the image at path and renders its
+// placeholder (see RenderPlaceholder). A GIF shows its first frame.
+func (r *Renderer) RenderFilePlaceholder(path string, width, height int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("open image file: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image file: %w", err)
+	}
+	return r.RenderPlaceholder(img, width, height)
+}
+
+// ClearRegion returns the escape sequence that blanks width by height
+// cells from the cursor and puts the cursor back, so a render smaller
+// than the placeholder it replaces leaves none of it behind. Write it
+// just before the real render.
+func ClearRegion(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	blank := strings.Repeat(" ", width)
+	var b strings.Builder
+	b.WriteString("\x1b7\x1b[0m")
+	for y := 0; y < height; y++ {
+		if y > 0 {
+			fmt.Fprintf(&b, "\x1b[1B\x1b[%dD", width)
+		}
+		b.WriteString(blank)
+	}
+	b.WriteString("\x1b8")
+	return b.String()
+}
+
+// placeholderCells returns the cells the real render of a srcW by srcH
+// image fills in a width by height area: all of it when the image is
+// cropped or padded to the area, otherwise the scaled-down image with its
+// aspect ratio kept.
+func (r *Renderer) placeholderCells(srcW, srcH, width, height int) (cols, rows int) {
+	if r.prep.crop != "" || r.prep.pad != "" {
+		return width, height
+	}
+	cellW, cellH := r.caps.Size.CellW, r.caps.Size.CellH
+	if r.cellBlocks() {
+		cellW, cellH = r.blockCellSize()
+	}
+	if cellW <= 0 {
+		cellW = imgDefaultCellW
+	}
+	if cellH <= 0 {
+		cellH = imgDefaultCellH
+	}
+	scale := math.Min(1, math.Min(float64(width*cellW)/float64(srcW), float64(height*cellH)/float64(srcH)))
+	cols = int(math.Ceil(float64(srcW) * scale / float64(cellW)))
+	rows = int(math.Ceil(float64(srcH) * scale / float64(cellH)))
+	return min(max(cols, 1), width), min(max(rows, 1), height)
+}
+
+// renderThumb draws thumb in half blocks over cols by rows cells,
+// interpolating between its pixels. Pixels the block renderers would
+// leave undrawn show the terminal background.
+func (r *Renderer) renderThumb(thumb *imgThumb, cols, rows int) string {
+	var b strings.Builder
+	b.Grow(cols * rows * 40)
+	buf := make([]byte, 0, 48)
+	for y := 0; y < rows; y++ {
+		if y > 0 {
+			b.WriteString("\x1b[0m\n")
+		}
+		for x := 0; x < cols; x++ {
+			top, topDrawn := r.blockColor(imgThumbAt(thumb, x, 2*y, cols, 2*rows))
+			bot, botDrawn := r.blockColor(imgThumbAt(thumb, x, 2*y+1, cols, 2*rows))
+			buf = buf[:0]
+			switch {
+			case !topDrawn && !botDrawn:
+				buf = append(buf, "\x1b[0m "...)
+			case !topDrawn:
+				buf = imgAppendSGR(buf, 38, bot)
+				buf = append(buf, "\x1b[49m▄"...)
+			case !botDrawn:
+				buf = imgAppendSGR(buf, 38, top)
+				buf = append(buf, "\x1b[49m▀"...)
+			default:
+				buf = imgAppendSGR(buf, 38, top)
+				buf = imgAppendSGR(buf, 48, bot)
+				buf = append(buf, "▀"...)
+			}
+			b.Write(buf)
+		}
+	}
+	b.WriteString("\x1b[0m")
+	return b.String()
+}
+
+// imgAppendSGR appends the true-color SGR sequence selecting c, with sel
+// 38 for the foreground or 48 for the background.
+func imgAppendSGR(buf []byte, sel int, c color.NRGBA) []byte {
+	buf = append(buf, "\x1b["...)
+	buf = strconv.AppendInt(buf, int64(sel), 10)
+	buf = append(buf, ";2;"...)
+	buf = strconv.AppendInt(buf, int64(c.R), 10)
+	buf = append(buf, ';')
+	buf = strconv.AppendInt(buf, int64(c.G), 10)
+	buf = append(buf, ';')
+	buf = strconv.AppendInt(buf, int64(c.B), 10)
+	return append(buf, 'm')
+}
+
+// imgThumbAt bilinearly interpolates thumb at pixel (x, y) of a w by h
+// grid stretched over it.
+func imgThumbAt(thumb *imgThumb, x, y, w, h int) color.NRGBA {
+	fx := (float64(x)+0.5)*imgThumbSize/float64(w) - 0.5
+	fy := (float64(y)+0.5)*imgThumbSize/float64(h) - 0.5
+	fx = math.Max(0, math.Min(fx, imgThumbSize-1))
+	fy = math.Max(0, math.Min(fy, imgThumbSize-1))
+	x0, y0 := int(fx), int(fy)
+	x1, y1 := min(x0+1, imgThumbSize-1), min(y0+1, imgThumbSize-1)
+	tx, ty := fx-float64(x0), fy-float64(y0)
+
+	p00, p10 := thumb[y0*imgThumbSize+x0], thumb[y0*imgThumbSize+x1]
+	p01, p11 := thumb[y1*imgThumbSize+x0], thumb[y1*imgThumbSize+x1]
+	lerp := func(a, b, c, d uint8) uint8 {
+		top := float64(a) + (float64(b)-float64(a))*tx
+		bot := float64(c) + (float64(d)-float64(c))*tx
+		return uint8(math.Round(top + (bot-top)*ty))
+	}
+	return color.NRGBA{
+		R: lerp(p00.R, p10.R, p01.R, p11.R),
+		G: lerp(p00.G, p10.G, p01.G, p11.G),
+		B: lerp(p00.B, p10.B, p01.B, p11.B),
+		A: lerp(p00.A, p10.A, p01.A, p11.A),
+	}
+}
+
+// imgSampleGrid returns the premultiplied RGBA bytes of an
+// imgThumbSamples square grid of pixels spread evenly over img, row-major.
+func imgSampleGrid(img image.Image) []byte {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	out := make([]byte, 0, imgThumbSamples*imgThumbSamples*4)
+	for sy := 0; sy < imgThumbSamples; sy++ {
+		y := bounds.Min.Y + (2*sy+1)*h/(2*imgThumbSamples)
+		for sx := 0; sx < imgThumbSamples; sx++ {
+			x := bounds.Min.X + (2*sx+1)*w/(2*imgThumbSamples)
+			r, g, b, a := img.At(x, y).RGBA()
+			out = append(out, uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8))
+		}
+	}
+	return out
+}
+
+// imgBuildThumb averages the sample grid down to a thumbnail, weighting
+// by alpha so transparent samples do not darken their neighbors.
+func imgBuildThumb(samples []byte) *imgThumb {
+	const per = imgThumbSamples / imgThumbSize
+	var thumb imgThumb
+	for ty := 0; ty < imgThumbSize; ty++ {
+		for tx := 0; tx < imgThumbSize; tx++ {
+			var r, g, b, a int
+			for sy := ty * per; sy < (ty+1)*per; sy++ {
+				for sx := tx * per; sx < (tx+1)*per; sx++ {
+					i := (sy*imgThumbSamples + sx) * 4
+					r += int(samples[i])
+					g += int(samples[i+1])
+					b += int(samples[i+2])
+					a += int(samples[i+3])
+				}
+			}
+			thumb[ty*imgThumbSize+tx] = imgUnpremultiply(r, g, b, a, per*per)
+		}
+	}
+	return &thumb
+}
+
+// imgUnpremultiply turns sums of n premultiplied samples into their mean
+// straight-alpha color.
+func imgUnpremultiply(r, g, b, a, n int) color.NRGBA {
+	if a == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8(min(255, (r*255+a/2)/a)),
+		G: uint8(min(255, (g*255+a/2)/a)),
+		B: uint8(min(255, (b*255+a/2)/a)),
+		A: uint8((a + n/2) / n),
+	}
+}
@@ -0,0 +1,361 @@
+package image
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	xdraw "golang.org/x/image/draw"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
+)
+
+// Crop modes for config.ImageConfig.WaifuCrop.
+const (
+	CropNone    = "none"
+	CropCenter  = "center"
+	CropEntropy = "entropy"
+)
+
+// Pad modes for config.ImageConfig.WaifuPad. Any other value is a
+// "#rrggbb" color.
+const (
+	PadNone  = "none"
+	PadTheme = "theme"
+	PadEdge  = "edge"
+)
+
+// imgEntropyBins is the number of luminance buckets in the histograms
+// entropy cropping compares.
+const imgEntropyBins = 32
+
+// imgEntropySamples caps the pixels sampled per line along each axis when
+// measuring entropy, so large images cost no more than small ones.
+const imgEntropySamples = 64
+
+// imgPreprocess shapes an image to its cell area before it is scaled and
+// encoded: cropped to the area's aspect ratio, padded out to it, and its
+// corners rounded. The zero value leaves images alone.
+type imgPreprocess struct {
+	crop   string
+	pad    string
+	radius int
+}
+
+// newImgPreprocess returns the preprocessing cfg asks for.
+func newImgPreprocess(cfg config.ImageConfig) imgPreprocess {
+	p := imgPreprocess{crop: cfg.WaifuCrop, pad: cfg.WaifuPad, radius: cfg.WaifuCornerRadius}
+	if p.crop == CropNone {
+		p.crop = ""
+	}
+	if p.pad == PadNone {
+		p.pad = ""
+	}
+	return p
+}
+
+// active reports whether p changes images at all.
+func (p imgPreprocess) active() bool {
+	return p.crop != "" || p.pad != "" || p.radius > 0
+}
+
+// key mixes the preprocessing settings into an image hash so each
+// combination is cached, and placed over Kitty, separately. The hash is
+// unchanged when p is inactive.
+func (p imgPreprocess) key(imgHash [32]byte) [32]byte {
+	if !p.active() {
+		return imgHash
+	}
+	pad := p.pad
+	if pad == PadTheme {
+		// The theme can change between renders.
+		pad += theme.Current.Background
+	}
+	h := sha256.New()
+	h.Write(imgHash[:])
+	h.Write([]byte(p.crop + "|" + pad + "|" + strconv.Itoa(p.radius)))
+	var result [32]byte
+	copy(result[:], h.Sum(nil))
+	return result
+}
+
+// preprocess applies r's preprocessing to img for an area of width by
+// height cells, returning img itself when there is none. Cropped or padded
+// images come back exactly the pixel size of the area.
+func (r *Renderer) preprocess(img image.Image, width, height int) image.Image {
+	return r.preprocessWith(r.prep, img, width, height)
+}
+
+// preprocessFrame is preprocess for a frame of an animation. Entropy
+// cropping is replaced by a center crop so the frames stay aligned.
+func (r *Renderer) preprocessFrame(img image.Image, width, height int) image.Image {
+	p := r.prep
+	if p.crop == CropEntropy {
+		p.crop = CropCenter
+	}
+	return r.preprocessWith(p, img, width, height)
+}
+
+// preprocessWith is preprocess with the settings p.
+func (r *Renderer) preprocessWith(p imgPreprocess, img image.Image, width, height int) image.Image {
+	if !p.active() {
+		return img
+	}
+	cellW, cellH := r.caps.Size.CellW, r.caps.Size.CellH
+	if r.cellBlocks() {
+		cellW, cellH = r.blockCellSize()
+	}
+	if cellW <= 0 {
+		cellW = imgDefaultCellW
+	}
+	if cellH <= 0 {
+		cellH = imgDefaultCellH
+	}
+	boxW, boxH := max(width, 1)*cellW, max(height, 1)*cellH
+
+	src := ImageToNRGBA(img)
+	switch p.crop {
+	case CropCenter:
+		src = src.SubImage(imgCenterCrop(src.Bounds(), boxW, boxH)).(*image.NRGBA)
+	case CropEntropy:
+		src = src.SubImage(imgEntropyCrop(src, boxW, boxH)).(*image.NRGBA)
+	}
+
+	var out *image.NRGBA
+	switch {
+	case p.pad != "":
+		out = imgPadToBox(src, boxW, boxH, imgPadColor(p.pad, src))
+	case p.crop != "":
+		out = image.NewNRGBA(image.Rect(0, 0, boxW, boxH))
+		xdraw.CatmullRom.Scale(out, out.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+	default:
+		out = ImageToNRGBA(ResizeToFit(src, width, height, cellW, cellH))
+	}
+
+	if p.radius > 0 && r.blendsAlpha() {
+		if out == img {
+			out = imgCloneNRGBA(out)
+		}
+		imgRoundCorners(out, p.radius)
+	}
+	return out
+}
+
+// blendsAlpha reports whether the protocol draws partial transparency, so
+// rounded corners come out smooth rather than stepped.
+func (r *Renderer) blendsAlpha() bool {
+	return r.protocol == terminal.ProtocolKitty || r.protocol == terminal.ProtocolITerm2
+}
+
+// imgPadColor returns the color the pad mode fills the area around src
+// with. The theme background falls back to transparent, leaving the
+// terminal's own background, when it is not a "#rrggbb" color.
+func imgPadColor(pad string, src *image.NRGBA) color.NRGBA {
+	switch pad {
+	case PadTheme:
+		c, _ := imgParseHexColor(theme.Current.Background)
+		return c
+	case PadEdge:
+		if c, ok := imgEdgeColor(src); ok {
+			return c
+		}
+		c, _ := imgParseHexColor(theme.Current.Background)
+		return c
+	default:
+		c, _ := imgParseHexColor(pad)
+		return c
+	}
+}
+
+// imgCenterCrop returns the largest rectangle of the aspect ratio boxW:boxH
+// centered in b.
+func imgCenterCrop(b image.Rectangle, boxW, boxH int) image.Rectangle {
+	w, h := imgCropSize(b.Dx(), b.Dy(), boxW, boxH)
+	x := b.Min.X + (b.Dx()-w)/2
+	y := b.Min.Y + (b.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// imgCropSize returns the largest w by h within srcW by srcH with the
+// aspect ratio boxW:boxH.
+func imgCropSize(srcW, srcH, boxW, boxH int) (w, h int) {
+	if srcW*boxH > srcH*boxW {
+		// Wider than the box: keep the height.
+		w = max(int(math.Round(float64(srcH)*float64(boxW)/float64(boxH))), 1)
+		return min(w, srcW), srcH
+	}
+	h = max(int(math.Round(float64(srcW)*float64(boxH)/float64(boxW))), 1)
+	return srcW, min(h, srcH)
+}
+
+// imgEntropyCrop returns the rectangle of the aspect ratio boxW:boxH that
+// keeps the most detailed part of src, measured as the entropy of its
+// luminance histogram. The crop slides along the axis src is too long in;
+// ties keep the center.
+func imgEntropyCrop(src *image.NRGBA, boxW, boxH int) image.Rectangle {
+	b := src.Bounds()
+	w, h := imgCropSize(b.Dx(), b.Dy(), boxW, boxH)
+	horizontal := w < b.Dx()
+	length, window := b.Dy(), h
+	if horizontal {
+		length, window = b.Dx(), w
+	}
+	if window >= length {
+		return imgCenterCrop(b, boxW, boxH)
+	}
+
+	// One histogram per line across the slide axis, sampled.
+	other := b.Dx()
+	if horizontal {
+		other = b.Dy()
+	}
+	step := max(other/imgEntropySamples, 1)
+	lines := make([][imgEntropyBins]int, length)
+	for i := range lines {
+		for j := 0; j < other; j += step {
+			x, y := b.Min.X+j, b.Min.Y+i
+			if horizontal {
+				x, y = b.Min.X+i, b.Min.Y+j
+			}
+			c := src.NRGBAAt(x, y)
+			lum := (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+			lines[i][lum*imgEntropyBins/256]++
+		}
+	}
+
+	entropy := func(off int) float64 {
+		var hist [imgEntropyBins]int
+		total := 0
+		for i := off; i < off+window; i++ {
+			for k, n := range lines[i] {
+				hist[k] += n
+				total += n
+			}
+		}
+		e := 0.0
+		for _, n := range hist {
+			if n > 0 {
+				p := float64(n) / float64(total)
+				e -= p * math.Log2(p)
+			}
+		}
+		return e
+	}
+
+	best := (length - window) / 2
+	bestE := entropy(best)
+	stride := max((length-window)/imgEntropySamples, 1)
+	for off := 0; off <= length-window; off += stride {
+		if e := entropy(off); e > bestE+1e-9 {
+			best, bestE = off, e
+		}
+	}
+	if horizontal {
+		return image.Rect(b.Min.X+best, b.Min.Y, b.Min.X+best+w, b.Max.Y)
+	}
+	return image.Rect(b.Min.X, b.Min.Y+best, b.Max.X, b.Min.Y+best+h)
+}
+
+// imgPadToBox scales src to fit boxW by boxH and centers it on a canvas of
+// exactly that size filled with bg.
+func imgPadToBox(src *image.NRGBA, boxW, boxH int, bg color.NRGBA) *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, boxW, boxH))
+	draw.Draw(out, out.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	b := src.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return out
+	}
+	scale := math.Min(float64(boxW)/float64(b.Dx()), float64(boxH)/float64(b.Dy()))
+	w := min(max(int(math.Round(float64(b.Dx())*scale)), 1), boxW)
+	h := min(max(int(math.Round(float64(b.Dy())*scale)), 1), boxH)
+	x, y := (boxW-w)/2, (boxH-h)/2
+	xdraw.CatmullRom.Scale(out, image.Rect(x, y, x+w, y+h), src, b, xdraw.Over, nil)
+	return out
+}
+
+// imgEdgeColor returns the most common color along the border of img,
+// with each channel bucketed to 16 levels and the bucket averaged. Mostly
+// transparent pixels are ignored; false means there were no others.
+func imgEdgeColor(img *image.NRGBA) (color.NRGBA, bool) {
+	type sum struct{ n, r, g, b int }
+	buckets := make(map[int]*sum)
+	add := func(x, y int) {
+		c := img.NRGBAAt(x, y)
+		if c.A < 128 {
+			return
+		}
+		k := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+		s, ok := buckets[k]
+		if !ok {
+			s = &sum{}
+			buckets[k] = s
+		}
+		s.n++
+		s.r += int(c.R)
+		s.g += int(c.G)
+		s.b += int(c.B)
+	}
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		add(x, b.Min.Y)
+		if b.Dy() > 1 {
+			add(x, b.Max.Y-1)
+		}
+	}
+	for y := b.Min.Y + 1; y < b.Max.Y-1; y++ {
+		add(b.Min.X, y)
+		if b.Dx() > 1 {
+			add(b.Max.X-1, y)
+		}
+	}
+
+	var best *sum
+	bestKey := 0
+	for k, s := range buckets {
+		// Ties go to the lower key so the result is deterministic.
+		if best == nil || s.n > best.n || (s.n == best.n && k < bestKey) {
+			best, bestKey = s, k
+		}
+	}
+	if best == nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{R: uint8(best.r / best.n), G: uint8(best.g / best.n), B: uint8(best.b / best.n), A: 255}, true
+}
+
+// imgRoundCorners makes the corners of img transparent outside a quarter
+// circle of the given radius, antialiasing the edge.
+func imgRoundCorners(img *image.NRGBA, radius int) {
+	b := img.Bounds()
+	radius = min(radius, b.Dx()/2, b.Dy()/2)
+	if radius <= 0 {
+		return
+	}
+	rf := float64(radius)
+	for dy := 0; dy < radius; dy++ {
+		for dx := 0; dx < radius; dx++ {
+			// Distance from the pixel center to the corner circle's center.
+			d := math.Hypot(rf-float64(dx)-0.5, rf-float64(dy)-0.5)
+			cover := math.Max(0, math.Min(1, rf-d+0.5))
+			if cover >= 1 {
+				continue
+			}
+			for _, p := range [4]image.Point{
+				{b.Min.X + dx, b.Min.Y + dy},
+				{b.Max.X - 1 - dx, b.Min.Y + dy},
+				{b.Min.X + dx, b.Max.Y - 1 - dy},
+				{b.Max.X - 1 - dx, b.Max.Y - 1 - dy},
+			} {
+				c := img.NRGBAAt(p.X, p.Y)
+				c.A = uint8(float64(c.A) * cover)
+				img.SetNRGBA(p.X, p.Y, c)
+			}
+		}
+	}
+}
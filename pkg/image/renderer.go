@@ -1,6 +1,7 @@
 package image
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/blacktop/go-termimg"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/terminal"
 )
 
@@ -26,29 +28,83 @@ type Renderer struct {
 	caps     terminal.Capabilities
 	cache    *Cache
 	cfg      config.ImageConfig
+
+	// sixelPalettes holds quantized palettes by image hash (see sixel.go).
+	sixelPalettes imgSixelPalettes
+
+	// thumbs holds placeholder thumbnails by image hash (see
+	// placeholder.go).
+	thumbs imgThumbs
+
+	// kittyState, when set, lets Kitty renders skip retransmitting images
+	// the terminal already holds (see UseKittyState).
+	kittyState *KittyState
+
+	// quadrants selects 2x2 block characters for the character-cell
+	// fallback instead of half blocks (see blocks.go).
+	quadrants bool
+
+	// background is the terminal background that translucent pixels are
+	// blended into by the character-cell fallback, when known.
+	background    color.NRGBA
+	hasBackground bool
+
+	// prep crops, pads, and rounds images before they are scaled (see
+	// preprocess.go).
+	prep imgPreprocess
+
+	// passthrough is how graphics output is wrapped to get through a
+	// terminal multiplexer (see passthrough.go).
+	passthrough terminal.Passthrough
 }
 
 // NewRenderer creates a Renderer configured from terminal capabilities and
 // user configuration. Protocol selection follows a cascade:
 //
-//  1. If cfg.Protocol is set (and not "auto"), use that override.
-//  2. Otherwise, use caps.Protocol from terminal detection.
+//  1. If render.Current disallows images, rendering is disabled.
+//  2. If cfg.Protocol is set (and not "auto"), use that override.
+//  3. Otherwise, use caps.Protocol from terminal detection.
+//  4. Inside a multiplexer, Kitty needs Unicode placeholders, the only
+//     placement the multiplexer can move with its text; without them it
+//     falls back a level (see terminal.Capabilities.PlaceableProtocol).
+//  5. Inside a multiplexer that will not pass the protocol through to the
+//     outer terminal, such as tmux with allow-passthrough off, fall back
+//     to halfblocks.
 func NewRenderer(caps terminal.Capabilities, cfg config.ImageConfig) *Renderer {
 	proto := caps.Protocol
-	if cfg.Protocol != "" && cfg.Protocol != "auto" {
+	switch {
+	case !render.Current.Images:
+		proto = terminal.ProtocolNone
+	case cfg.Protocol != "" && cfg.Protocol != "auto":
 		proto = terminal.SelectProtocolWithOverride(caps.Term, cfg.Protocol)
 	}
+	proto = caps.PlaceableProtocol(proto)
+	passthrough, ok := caps.GraphicsPassthrough(proto)
+	if !ok {
+		proto = terminal.ProtocolHalfblocks
+	}
 
 	cacheMB := cfg.MaxCacheSizeMB
 	if cacheMB <= 0 {
 		cacheMB = 32
 	}
 
+	bg := cfg.Background
+	if bg == "" {
+		bg = caps.Background
+	}
+	background, hasBackground := imgParseHexColor(bg)
+
 	return &Renderer{
-		protocol: proto,
-		caps:     caps,
-		cache:    NewCache(cacheMB),
-		cfg:      cfg,
+		protocol:      proto,
+		caps:          caps,
+		cache:         NewCacheWithDisk(cacheMB, cfg.DiskCacheDir, cacheMB),
+		cfg:           cfg,
+		quadrants:     cfg.BlockMode == "quadrants",
+		background:    background,
+		hasBackground: hasBackground,
+		prep:          newImgPreprocess(cfg),
+		passthrough:   passthrough,
 	}
 }
 
@@ -62,8 +118,18 @@ func (r *Renderer) Cache() *Cache {
 	return r.cache
 }
 
+// UseKittyState enables Kitty image ID reuse across prompt invocations.
+// With a state set, Kitty renders use a stable ID derived from the image
+// and size, and only emit a placement when the state records that the
+// terminal already has that ID. cfg.KittyRetransmit forces a full
+// transmit every time. Passing nil disables reuse.
+func (r *Renderer) UseKittyState(s *KittyState) {
+	r.kittyState = s
+}
+
 // Render converts an image.Image to a terminal escape string at the given
-// cell dimensions. It checks the cache first, then resizes and renders.
+// cell dimensions. It checks the cache first, then preprocesses, resizes,
+// and renders.
 func (r *Renderer) Render(img image.Image, width, height int) (string, error) {
 	if img == nil {
 		return "", fmt.Errorf("image is nil")
@@ -72,25 +138,34 @@ func (r *Renderer) Render(img image.Image, width, height int) (string, error) {
 		return "", fmt.Errorf("image rendering is disabled (protocol=none)")
 	}
 
-	// Compute image hash for cache key.
-	imgHash := r.hashImage(img)
-	key := MakeCacheKey(r.protocol.String(), width, height, imgHash)
+	// Compute image hash for cache key, including how the image is
+	// preprocessed.
+	imgHash := r.prep.key(r.hashImage(img))
+	if r.protocol == terminal.ProtocolKitty && r.kittyState != nil {
+		return r.renderKittyPersistent(img, imgHash, width, height), nil
+	}
+	key := MakeCacheKey(r.cacheFormat(r.renderFormat()), width, height, imgHash)
 
 	// Check cache.
 	if cached, ok := r.cache.Get(key); ok {
 		return cached, nil
 	}
 
-	// Resize to fit target cell area.
+	// Resize to fit target cell area. The character-cell fallback draws
+	// a fixed number of pixels per cell rather than the font's.
 	cellW := r.caps.Size.CellW
 	cellH := r.caps.Size.CellH
-	resized := ResizeToFit(img, width, height, cellW, cellH)
+	if r.cellBlocks() {
+		cellW, cellH = r.blockCellSize()
+	}
+	resized := ResizeToFit(r.preprocess(img, width, height), width, height, cellW, cellH)
 
 	// Render via the appropriate protocol.
-	rendered, err := r.renderWithProtocol(resized, width, height)
+	rendered, err := r.renderWithProtocol(resized, imgHash, width, height)
 	if err != nil {
 		return "", fmt.Errorf("render failed: %w", err)
 	}
+	rendered = imgWrapPassthrough(rendered, r.passthrough)
 
 	// Store in cache.
 	r.cache.Put(key, rendered)
@@ -98,15 +173,24 @@ func (r *Renderer) Render(img image.Image, width, height int) (string, error) {
 	return rendered, nil
 }
 
-// RenderFile loads an image from a file path and renders it.
+// RenderFile loads an image from a file path and renders it. GIFs go
+// through RenderAnimated, so they play on Kitty and show their first frame
+// elsewhere.
 func (r *Renderer) RenderFile(path string, width, height int) (string, error) {
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("open image file: %w", err)
 	}
-	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && format == "gif" {
+		anim, err := DecodeAnimation(bytes.NewReader(data), r.maxAnimationFrames())
+		if err != nil {
+			return "", fmt.Errorf("decode image file: %w", err)
+		}
+		return r.RenderAnimated(anim, width, height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return "", fmt.Errorf("decode image file: %w", err)
 	}
@@ -114,24 +198,36 @@ func (r *Renderer) RenderFile(path string, width, height int) (string, error) {
 	return r.Render(img, width, height)
 }
 
-// renderWithProtocol dispatches to the correct rendering backend.
-func (r *Renderer) renderWithProtocol(img image.Image, widthCells, heightCells int) (string, error) {
+// renderWithProtocol dispatches to the correct rendering backend. imgHash
+// identifies the source image so Sixel can reuse its palette.
+func (r *Renderer) renderWithProtocol(img image.Image, imgHash [32]byte, widthCells, heightCells int) (string, error) {
 	switch r.protocol {
 	case terminal.ProtocolHalfblocks:
+		if r.quadrants {
+			return r.renderQuadrants(img)
+		}
 		return r.renderHalfblocks(img, widthCells, heightCells)
-	case terminal.ProtocolKitty:
+	case terminal.ProtocolKitty, terminal.ProtocolITerm2:
+		if r.passthrough != terminal.PassthroughNone {
+			return r.renderPassthrough(img, imgHash, widthCells, heightCells)
+		}
+		if r.protocol == terminal.ProtocolITerm2 {
+			return r.renderTermimg(img, termimg.ITerm2, widthCells, heightCells)
+		}
 		return r.renderTermimg(img, termimg.Kitty, widthCells, heightCells)
-	case terminal.ProtocolITerm2:
-		return r.renderTermimg(img, termimg.ITerm2, widthCells, heightCells)
 	case terminal.ProtocolSixel:
-		return r.renderTermimg(img, termimg.Sixel, widthCells, heightCells)
+		return r.renderSixel(img, imgHash)
 	default:
-		// Fall back to halfblocks for any unknown protocol.
+		// Fall back to the character-cell renderer for any unknown
+		// protocol.
+		if r.quadrants {
+			return r.renderQuadrants(img)
+		}
 		return r.renderHalfblocks(img, widthCells, heightCells)
 	}
 }
 
-// renderTermimg delegates to go-termimg for Kitty, iTerm2, and Sixel protocols.
+// renderTermimg delegates to go-termimg for the Kitty and iTerm2 protocols.
 func (r *Renderer) renderTermimg(img image.Image, proto termimg.Protocol, widthCells, heightCells int) (string, error) {
 	ti := termimg.New(img)
 	if ti == nil {
@@ -146,7 +242,8 @@ func (r *Renderer) renderTermimg(img image.Image, proto termimg.Protocol, widthC
 // renderHalfblocks renders using Unicode upper-half-block characters with
 // 24-bit ANSI true color. Each character cell encodes two vertical pixels:
 // the top pixel as the foreground color (via the upper half block U+2580)
-// and the bottom pixel as the background color.
+// and the bottom pixel as the background color. Undrawn pixels (see
+// blockColor) are left to the terminal background.
 //
 // This is a pure Go implementation that works on all terminals with true
 // color support. No external process calls.
@@ -177,33 +274,28 @@ func (r *Renderer) renderHalfblocks(img image.Image, widthCells, heightCells int
 
 		for x := 0; x < srcW; x++ {
 			// Top pixel (foreground via upper half block).
-			topR, topG, topB, topA := nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y).R,
-				nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y).G,
-				nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y).B,
-				nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y).A
+			top, topDrawn := r.blockColor(nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y))
 
 			// Bottom pixel (background). May not exist if height is odd.
-			var botR, botG, botB, botA uint8
+			var bot color.NRGBA
+			var botDrawn bool
 			if y+1 < srcH {
-				botR = nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y+1).R
-				botG = nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y+1).G
-				botB = nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y+1).B
-				botA = nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y+1).A
+				bot, botDrawn = r.blockColor(nrgba.NRGBAAt(bounds.Min.X+x, bounds.Min.Y+y+1))
 			}
 
-			// Handle transparency: treat transparent pixels as terminal default.
-			if topA == 0 && botA == 0 {
+			// Undrawn pixels show the terminal background.
+			if !topDrawn && !botDrawn {
 				b.WriteString("\x1b[0m ")
-			} else if topA == 0 {
+			} else if !topDrawn {
 				// Only bottom pixel visible: use lower half block with fg = bottom.
-				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[49m\u2584", botR, botG, botB)
-			} else if botA == 0 || y+1 >= srcH {
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[49m\u2584", bot.R, bot.G, bot.B)
+			} else if !botDrawn {
 				// Only top pixel visible: use upper half block with fg = top.
-				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[49m\u2580", topR, topG, topB)
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[49m\u2580", top.R, top.G, top.B)
 			} else {
 				// Both pixels visible: fg = top (upper half block), bg = bottom.
 				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm\u2580",
-					topR, topG, topB, botR, botG, botB)
+					top.R, top.G, top.B, bot.R, bot.G, bot.B)
 			}
 		}
 	}
@@ -0,0 +1,239 @@
+package image
+
+import (
+	"image"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// imgKernel is a resampling filter: its weight at distance t, in source
+// pixels at unit scale, and the distance beyond which the weight is zero.
+type imgKernel struct {
+	support float64
+	at      func(t float64) float64
+}
+
+var (
+	imgLanczos3Kernel   = imgKernel{support: 3, at: imgLanczos3At}
+	imgCatmullRomKernel = imgKernel{support: 2, at: imgCatmullRomAt}
+)
+
+// imgCatmullRomAt is the Catmull-Rom kernel function, as used by
+// x/image/draw.CatmullRom.
+func imgCatmullRomAt(t float64) float64 {
+	if t < 0 {
+		t = -t
+	}
+	if t < 1 {
+		return (1.5*t-2.5)*t*t + 1
+	}
+	if t < 2 {
+		return ((-0.5*t+2.5)*t-4)*t + 2
+	}
+	return 0
+}
+
+// imgWeights holds the kernel weights of one axis of a resize, computed
+// once per output column or row rather than once per pixel. Output i
+// reads source pixels start[i], start[i]+1, ... with the weights
+// w[off[i]:off[i+1]].
+type imgWeights struct {
+	start []int
+	off   []int
+	w     []float32
+}
+
+// imgNewWeights computes the normalized weights of k for resizing src
+// pixels to dst. Like x/image/draw, it widens the kernel by the scale
+// factor when shrinking so every source pixel contributes.
+func imgNewWeights(k imgKernel, dst, src int) imgWeights {
+	scale := float64(src) / float64(dst)
+	halfWidth, argScale := k.support, 1.0
+	if scale > 1 {
+		halfWidth *= scale
+		argScale = 1 / scale
+	}
+
+	ws := imgWeights{
+		start: make([]int, dst),
+		off:   make([]int, dst+1),
+	}
+	var row []float64
+	for i := 0; i < dst; i++ {
+		center := (float64(i)+0.5)*scale - 0.5
+		lo := max(int(math.Floor(center-halfWidth)), 0)
+		hi := min(int(math.Ceil(center+halfWidth)), src)
+		hi = max(hi, lo+1)
+
+		row = row[:0]
+		total := 0.0
+		for c := lo; c < hi; c++ {
+			var w float64
+			if t := math.Abs(center-float64(c)) * argScale; t < k.support {
+				w = k.at(t)
+			}
+			row = append(row, w)
+			total += w
+		}
+		if total == 0 {
+			total = 1
+		}
+		ws.start[i] = lo
+		for _, w := range row {
+			ws.w = append(ws.w, float32(w/total))
+		}
+		ws.off[i+1] = len(ws.w)
+	}
+	return ws
+}
+
+// imgResample resizes img to width x height with kernel k. When sharpen is
+// positive it applies the unsharp mask of unsharpen with that amount while
+// writing the output, so there is no separate sharpen pass over the
+// resized image.
+//
+// It works on the NRGBA pixel buffer directly: a horizontal pass into a
+// premultiplied float32 buffer, then a vertical one, each spreading rows
+// across GOMAXPROCS goroutines. The result matches x/image/draw's kernel
+// scaling followed by unsharpen to within rounding.
+func imgResample(img image.Image, width, height int, k imgKernel, sharpen float64) *image.NRGBA {
+	src := ImageToNRGBA(img)
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if sw <= 0 || sh <= 0 || width <= 0 || height <= 0 {
+		return dst
+	}
+
+	xw := imgNewWeights(k, width, sw)
+	yw := imgNewWeights(k, height, sh)
+
+	// Horizontal pass: every source row, premultiplied into a float32 row
+	// once, to width pixels.
+	tmp := make([]float32, sh*width*4)
+	imgParallel(sh, func(lo, hi int) {
+		in := make([]float32, sw*4)
+		for y := lo; y < hi; y++ {
+			off := src.PixOffset(b.Min.X, b.Min.Y+y)
+			imgPremultiplyRow(in, src.Pix[off:off+sw*4])
+			out := tmp[y*width*4 : (y+1)*width*4]
+			for x := 0; x < width; x++ {
+				ws := xw.w[xw.off[x]:xw.off[x+1]]
+				p := in[xw.start[x]*4 : (xw.start[x]+len(ws))*4]
+				var r, g, bl, a float32
+				for i, w := range ws {
+					q := p[i*4 : i*4+4 : i*4+4]
+					r += w * q[0]
+					g += w * q[1]
+					bl += w * q[2]
+					a += w * q[3]
+				}
+				o := out[x*4 : x*4+4 : x*4+4]
+				o[0], o[1], o[2], o[3] = r, g, bl, a
+			}
+		}
+	})
+
+	// Vertical pass: accumulate whole weighted rows of tmp, which keeps
+	// the inner loop on contiguous memory.
+	stride := width * 4
+	acc := make([]float32, height*stride)
+	imgParallel(height, func(lo, hi int) {
+		for y := lo; y < hi; y++ {
+			out := acc[y*stride : (y+1)*stride]
+			ws := yw.w[yw.off[y]:yw.off[y+1]]
+			for i, w := range ws {
+				in := tmp[(yw.start[y]+i)*stride : (yw.start[y]+i+1)*stride]
+				for j := range out {
+					out[j] += w * in[j]
+				}
+			}
+		}
+	})
+
+	amount := float32(sharpen)
+	if amount > 0 && (width < 3 || height < 3) {
+		amount = 0
+	}
+	imgParallel(height, func(lo, hi int) {
+		for y := lo; y < hi; y++ {
+			for x := 0; x < width; x++ {
+				i := y*stride + x*4
+				r, g, bl, a := acc[i], acc[i+1], acc[i+2], acc[i+3]
+				if amount > 0 {
+					br, bg, bb := imgBoxMean(acc, width, height, x, y)
+					r += amount * (r - br)
+					g += amount * (g - bg)
+					bl += amount * (bl - bb)
+				}
+				o := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4 : y*dst.Stride+x*4+4]
+				alpha := imgClampColor(float64(a + 0.5))
+				o[3] = alpha
+				if alpha == 0 {
+					o[0], o[1], o[2] = 0, 0, 0
+					continue
+				}
+				// Undo the premultiplication by the unrounded alpha.
+				un := 255 / min(max(a, 1), 255)
+				o[0] = imgClampColor(float64(r*un + 0.5))
+				o[1] = imgClampColor(float64(g*un + 0.5))
+				o[2] = imgClampColor(float64(bl*un + 0.5))
+			}
+		}
+	})
+	return dst
+}
+
+// imgPremultiplyRow converts a row of NRGBA pixels to float32 RGBA
+// premultiplied by alpha.
+func imgPremultiplyRow(dst []float32, pix []uint8) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		p := pix[i : i+4 : i+4]
+		d := dst[i : i+4 : i+4]
+		a := float32(p[3])
+		if p[3] == 0xff {
+			d[0], d[1], d[2], d[3] = float32(p[0]), float32(p[1]), float32(p[2]), a
+			continue
+		}
+		m := a * (1.0 / 255)
+		d[0], d[1], d[2], d[3] = float32(p[0])*m, float32(p[1])*m, float32(p[2])*m, a
+	}
+}
+
+// imgBoxMean returns the mean premultiplied color of the pixels of buf, a
+// width x height float32 RGBA buffer, within one pixel of (x, y), like
+// boxBlur with a radius of one.
+func imgBoxMean(buf []float32, width, height, x, y int) (r, g, b float32) {
+	var n float32
+	for sy := max(y-1, 0); sy <= min(y+1, height-1); sy++ {
+		for sx := max(x-1, 0); sx <= min(x+1, width-1); sx++ {
+			i := (sy*width + sx) * 4
+			r += buf[i]
+			g += buf[i+1]
+			b += buf[i+2]
+			n++
+		}
+	}
+	return r / n, g / n, b / n
+}
+
+// imgParallel calls fn for consecutive ranges covering [0, n), one per
+// worker, with as many workers as GOMAXPROCS allows, and waits for them.
+func imgParallel(n int, fn func(lo, hi int)) {
+	workers := min(runtime.GOMAXPROCS(0), n)
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, min(lo+chunk, n))
+	}
+	wg.Wait()
+}
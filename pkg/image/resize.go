@@ -5,13 +5,11 @@ import (
 	"image/color"
 	"image/draw"
 	"math"
-
-	xdraw "golang.org/x/image/draw"
 )
 
 // ResizeToFit scales an image to fit within the given cell dimensions while
-// maintaining aspect ratio. It uses Lanczos resampling for high quality
-// downscaling and applies a subtle unsharp mask after resize.
+// maintaining aspect ratio. It uses CatmullRom resampling for high quality
+// downscaling and applies a subtle unsharp mask in the same pass.
 //
 // Parameters:
 //   - img: source image
@@ -78,14 +76,10 @@ func ResizeToFit(img image.Image, maxWidthCells, maxHeightCells, cellW, cellH in
 		dstH = 1
 	}
 
-	// Resample with CatmullRom (Lanczos-like quality, good performance).
-	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
-	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Over, nil)
-
-	// Apply subtle unsharp mask to restore edge detail lost during downscale.
-	sharpened := unsharpen(dst, 0.3, 1)
-
-	return sharpened
+	// Resample with CatmullRom (Lanczos-like quality, good performance),
+	// with a subtle unsharp mask to restore edge detail lost during
+	// downscale.
+	return imgResample(img, dstW, dstH, imgCatmullRomKernel, 0.3)
 }
 
 // unsharpen applies a simple unsharp mask: result = original + amount*(original - blurred).
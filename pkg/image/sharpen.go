@@ -4,8 +4,6 @@ import (
 	"image"
 	"image/color"
 	"math"
-
-	xdraw "golang.org/x/image/draw"
 )
 
 // imgSharpenDefault is the unsharp mask amount for standard protocols.
@@ -54,18 +52,7 @@ func imgLanczosResize(img image.Image, width, height int) image.Image {
 		return img
 	}
 
-	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
-
-	// x/image does not export a named Lanczos3 kernel, but BiLinear and
-	// CatmullRom are the built-in options. We use a custom Lanczos3
-	// kernel via the Kernel type.
-	lanczos3 := &xdraw.Kernel{
-		Support: 3,
-		At:      imgLanczos3At,
-	}
-	lanczos3.Scale(dst, dst.Bounds(), img, bounds, xdraw.Over, nil)
-
-	return dst
+	return imgResample(img, width, height, imgLanczos3Kernel, 0)
 }
 
 // imgLanczos3At is the Lanczos3 kernel function. It evaluates the
@@ -87,7 +74,7 @@ func imgLanczos3At(t float64) float64 {
 // imgTerminalPipeline applies the full image processing pipeline for
 // terminal display:
 //  1. Resize to pixel-perfect dimensions (targetCols * cellW, targetRows * cellH)
-//  2. Sharpen to restore edge detail
+//  2. Sharpen to restore edge detail, in the same pass as the resize
 //  3. Return the processed image
 //
 // The sharpenAmount defaults:
@@ -115,13 +102,13 @@ func imgTerminalPipeline(img image.Image, targetCols, targetRows, cellW, cellH i
 	pixelW := targetCols * cellW
 	pixelH := targetRows * cellH
 
-	// Step 1: Lanczos3 resize.
-	resized := imgLanczosResize(img, pixelW, pixelH)
-
-	// Step 2: Sharpen for terminal display.
-	sharpened := imgSharpenFilter(resized, imgSharpenDefault)
+	// An image already at the target size is only sharpened.
+	if b := img.Bounds(); b.Dx() == pixelW && b.Dy() == pixelH {
+		return imgSharpenFilter(img, imgSharpenDefault)
+	}
 
-	return sharpened
+	// Lanczos3 resize, sharpened for terminal display.
+	return imgResample(img, pixelW, pixelH, imgLanczos3Kernel, imgSharpenDefault)
 }
 
 // imgClampColor clamps a float64 color value to [0, 255].
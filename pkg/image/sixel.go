@@ -0,0 +1,414 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sixel dithering modes accepted in config.ImageConfig.SixelDither.
+const (
+	SixelDitherNone    = "none"
+	SixelDitherFloyd   = "floyd"
+	SixelDitherOrdered = "ordered"
+)
+
+// DefaultSixelColors is the palette size used when
+// config.ImageConfig.SixelColors is unset. Sixel terminals commonly
+// support up to 256 color registers.
+const DefaultSixelColors = 256
+
+// Sixel control sequence boundaries.
+const (
+	imgSixelDCS = "\x1bP0;1;0q"
+	imgSixelST  = "\x1b\\"
+)
+
+// imgSixelPaletteCacheMax bounds the number of palettes kept per renderer.
+const imgSixelPaletteCacheMax = 64
+
+// imgSixelAlphaThreshold is the alpha below which a pixel is left
+// transparent rather than painted.
+const imgSixelAlphaThreshold = 128
+
+// imgBayer8 is the 8x8 Bayer threshold matrix used for ordered dithering.
+var imgBayer8 = [8][8]uint8{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// imgSixelPalettes caches quantized palettes by source image hash, so every
+// size (and every frame sharing a hash) of the same image is encoded
+// against the same colors and its cached renders stay consistent.
+type imgSixelPalettes struct {
+	mu       sync.Mutex
+	palettes map[[32]byte]color.Palette
+}
+
+// get returns the cached palette for hash, building it with build on a
+// miss. The cache is cleared when it reaches imgSixelPaletteCacheMax.
+func (p *imgSixelPalettes) get(hash [32]byte, build func() color.Palette) color.Palette {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pal, ok := p.palettes[hash]; ok {
+		return pal
+	}
+	if p.palettes == nil || len(p.palettes) >= imgSixelPaletteCacheMax {
+		p.palettes = make(map[[32]byte]color.Palette)
+	}
+	pal := build()
+	p.palettes[hash] = pal
+	return pal
+}
+
+// renderSixel quantizes img to the configured palette size, dithers it, and
+// encodes it as a Sixel sequence. The palette is built from the full image
+// once per image hash.
+func (r *Renderer) renderSixel(img image.Image, imgHash [32]byte) (string, error) {
+	nrgba := ImageToNRGBA(img)
+	if nrgba.Bounds().Empty() {
+		return "", nil
+	}
+
+	colors := r.cfg.SixelColors
+	if colors <= 0 || colors > DefaultSixelColors {
+		colors = DefaultSixelColors
+	}
+	palette := r.sixelPalettes.get(imgHash, func() color.Palette {
+		return imgMedianCutPalette(nrgba, colors)
+	})
+
+	indices := imgDither(nrgba, palette, r.cfg.SixelDither)
+	return imgSixelEncode(indices, palette, nrgba.Bounds().Dx(), nrgba.Bounds().Dy()), nil
+}
+
+// ---------- Quantization ----------
+
+// imgColorBox is a set of colors for median-cut quantization.
+type imgColorBox struct {
+	colors []color.NRGBA
+}
+
+// span returns the channel (0=R, 1=G, 2=B) with the widest range and that
+// range.
+func (b *imgColorBox) span() (channel int, width uint8) {
+	lo := [3]uint8{255, 255, 255}
+	var hi [3]uint8
+	for _, c := range b.colors {
+		for i, v := range [3]uint8{c.R, c.G, c.B} {
+			if v < lo[i] {
+				lo[i] = v
+			}
+			if v > hi[i] {
+				hi[i] = v
+			}
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if hi[i]-lo[i] > width {
+			channel, width = i, hi[i]-lo[i]
+		}
+	}
+	return channel, width
+}
+
+// average returns the mean color of the box.
+func (b *imgColorBox) average() color.NRGBA {
+	var r, g, bl int
+	for _, c := range b.colors {
+		r += int(c.R)
+		g += int(c.G)
+		bl += int(c.B)
+	}
+	n := len(b.colors)
+	return color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 255}
+}
+
+// imgMedianCutPalette builds a palette of at most n colors from the opaque
+// pixels of img. Images with n or fewer distinct colors get an exact
+// palette; otherwise boxes are split at the median of their widest channel
+// until there are n of them. The result is deterministic for a given image.
+func imgMedianCutPalette(img *image.NRGBA, n int) color.Palette {
+	b := img.Bounds()
+	seen := make(map[color.NRGBA]bool)
+	var colors []color.NRGBA
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A < imgSixelAlphaThreshold {
+				continue
+			}
+			c.A = 255
+			colors = append(colors, c)
+			seen[c] = true
+		}
+	}
+
+	if len(seen) <= n {
+		unique := make([]color.NRGBA, 0, len(seen))
+		for c := range seen {
+			unique = append(unique, c)
+		}
+		sort.Slice(unique, func(i, j int) bool { return imgPackRGB(unique[i]) < imgPackRGB(unique[j]) })
+		pal := make(color.Palette, len(unique))
+		for i, c := range unique {
+			pal[i] = c
+		}
+		return pal
+	}
+
+	boxes := []*imgColorBox{{colors: colors}}
+	for len(boxes) < n {
+		// Split the box with the widest channel range.
+		best, bestWidth, bestChannel := -1, uint8(0), 0
+		for i, box := range boxes {
+			if len(box.colors) < 2 {
+				continue
+			}
+			if ch, w := box.span(); w > bestWidth {
+				best, bestWidth, bestChannel = i, w, ch
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		sort.SliceStable(box.colors, func(i, j int) bool {
+			return imgChannel(box.colors[i], bestChannel) < imgChannel(box.colors[j], bestChannel)
+		})
+		mid := len(box.colors) / 2
+		boxes[best] = &imgColorBox{colors: box.colors[:mid]}
+		boxes = append(boxes, &imgColorBox{colors: box.colors[mid:]})
+	}
+
+	pal := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		pal[i] = box.average()
+	}
+	return pal
+}
+
+// imgChannel returns channel 0 (R), 1 (G), or 2 (B) of c.
+func imgChannel(c color.NRGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	default:
+		return c.B
+	}
+}
+
+// imgPackRGB packs a color into a sortable integer.
+func imgPackRGB(c color.NRGBA) uint32 {
+	return uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+}
+
+// ---------- Dithering ----------
+
+// imgDither maps every pixel of img to a palette index using the given
+// mode. Transparent pixels map to -1. Unknown modes behave like
+// SixelDitherNone.
+func imgDither(img *image.NRGBA, palette color.Palette, mode string) []int {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := make([]int, w*h)
+	nearest := imgNearestFunc(palette)
+
+	switch mode {
+	case SixelDitherFloyd:
+		// Error buffers for the current and next row, per channel.
+		cur := make([][3]float64, w+2)
+		next := make([][3]float64, w+2)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c := img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+				if c.A < imgSixelAlphaThreshold {
+					out[y*w+x] = -1
+					continue
+				}
+				want := [3]float64{
+					float64(c.R) + cur[x+1][0],
+					float64(c.G) + cur[x+1][1],
+					float64(c.B) + cur[x+1][2],
+				}
+				idx := nearest(imgClamp8(want[0]), imgClamp8(want[1]), imgClamp8(want[2]))
+				out[y*w+x] = idx
+
+				pr, pg, pb, _ := palette[idx].RGBA()
+				got := [3]float64{float64(pr >> 8), float64(pg >> 8), float64(pb >> 8)}
+				for ch := 0; ch < 3; ch++ {
+					e := want[ch] - got[ch]
+					cur[x+2][ch] += e * 7 / 16
+					next[x][ch] += e * 3 / 16
+					next[x+1][ch] += e * 5 / 16
+					next[x+2][ch] += e * 1 / 16
+				}
+			}
+			cur, next = next, cur
+			for i := range next {
+				next[i] = [3]float64{}
+			}
+		}
+
+	case SixelDitherOrdered:
+		spread := 255 / math.Cbrt(float64(len(palette)))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c := img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+				if c.A < imgSixelAlphaThreshold {
+					out[y*w+x] = -1
+					continue
+				}
+				offset := (float64(imgBayer8[y%8][x%8])/64 - 0.5) * spread
+				out[y*w+x] = nearest(
+					imgClamp8(float64(c.R)+offset),
+					imgClamp8(float64(c.G)+offset),
+					imgClamp8(float64(c.B)+offset))
+			}
+		}
+
+	default:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c := img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+				if c.A < imgSixelAlphaThreshold {
+					out[y*w+x] = -1
+					continue
+				}
+				out[y*w+x] = nearest(c.R, c.G, c.B)
+			}
+		}
+	}
+
+	return out
+}
+
+// imgNearestFunc returns a memoized nearest-color lookup for palette by
+// squared RGB distance. Ties go to the lower index.
+func imgNearestFunc(palette color.Palette) func(r, g, b uint8) int {
+	rgb := make([][3]int, len(palette))
+	for i, c := range palette {
+		r, g, b, _ := c.RGBA()
+		rgb[i] = [3]int{int(r >> 8), int(g >> 8), int(b >> 8)}
+	}
+	memo := make(map[uint32]int)
+
+	return func(r, g, b uint8) int {
+		key := uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+		if idx, ok := memo[key]; ok {
+			return idx
+		}
+		best, bestDist := 0, math.MaxInt
+		for i, p := range rgb {
+			dr, dg, db := int(r)-p[0], int(g)-p[1], int(b)-p[2]
+			if d := dr*dr + dg*dg + db*db; d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		memo[key] = best
+		return best
+	}
+}
+
+// imgClamp8 rounds v and clamps it to 0..255.
+func imgClamp8(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
+
+// ---------- Encoding ----------
+
+// imgSixelEncode writes palette-indexed pixels as a Sixel DCS sequence.
+// Pixels with index -1 are not painted (P2=1 keeps the background). Each
+// six-row band emits one run-length encoded pass per color used in it.
+func imgSixelEncode(indices []int, palette color.Palette, w, h int) string {
+	var b strings.Builder
+	b.Grow(w * h / 2)
+
+	b.WriteString(imgSixelDCS)
+	fmt.Fprintf(&b, "\"1;1;%d;%d", w, h)
+
+	for i, c := range palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i,
+			(r>>8)*100/255, (g>>8)*100/255, (bl>>8)*100/255)
+	}
+
+	row := make([]byte, w)
+	for band := 0; band < h; band += 6 {
+		// Colors used in this band, in palette order for stable output.
+		used := make(map[int]bool)
+		for y := band; y < band+6 && y < h; y++ {
+			for x := 0; x < w; x++ {
+				if idx := indices[y*w+x]; idx >= 0 {
+					used[idx] = true
+				}
+			}
+		}
+		order := make([]int, 0, len(used))
+		for idx := range used {
+			order = append(order, idx)
+		}
+		sort.Ints(order)
+
+		for n, idx := range order {
+			if n > 0 {
+				b.WriteByte('$')
+			}
+			for x := 0; x < w; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if indices[(band+dy)*w+x] == idx {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+			fmt.Fprintf(&b, "#%d", idx)
+			imgSixelRLE(&b, row)
+		}
+		b.WriteByte('-')
+	}
+
+	b.WriteString(imgSixelST)
+	return b.String()
+}
+
+// imgSixelRLE writes row with runs of four or more repeated characters
+// compressed as "!<count><char>".
+func imgSixelRLE(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if run := j - i; run > 3 {
+			fmt.Fprintf(b, "!%d%c", run, row[i])
+		} else {
+			for k := 0; k < run; k++ {
+				b.WriteByte(row[i])
+			}
+		}
+		i = j
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package image
+
+// TTYIdentity is a stub for non-Unix platforms; an empty identity
+// disables Kitty ID reuse.
+func TTYIdentity() string {
+	return ""
+}
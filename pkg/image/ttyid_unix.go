@@ -0,0 +1,35 @@
+//go:build unix
+
+package image
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// TTYIdentity identifies the terminal instance attached to this
+// process: the device number of the first standard stream that is a TTY
+// plus the session ID. A restarted terminal starts a new session even when
+// the kernel hands out the same pty device, so the identity changes.
+// Returns "" when no standard stream is a TTY.
+func TTYIdentity() string {
+	for fd := 0; fd <= 2; fd++ {
+		var st unix.Stat_t
+		if err := unix.Fstat(fd, &st); err != nil {
+			continue
+		}
+		if st.Mode&unix.S_IFMT != unix.S_IFCHR {
+			continue
+		}
+		if _, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err != nil {
+			continue
+		}
+		sid, err := unix.Getsid(0)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%d:%d", uint64(st.Rdev), sid)
+	}
+	return ""
+}
@@ -326,8 +326,15 @@ func TestMockClaudeData(t *testing.T) {
 	if _, ok := data["total_cost_usd"]; !ok {
 		t.Error("missing total_cost_usd key")
 	}
-	if _, ok := data["accounts"]; !ok {
-		t.Error("missing accounts key")
+	accounts, ok := data["accounts"].([]map[string]any)
+	if !ok || len(accounts) == 0 {
+		t.Fatal("missing accounts key")
+	}
+	if _, ok := accounts[0]["sessions"]; !ok {
+		t.Error("missing sessions key on first account")
+	}
+	if _, ok := accounts[0]["window"]; !ok {
+		t.Error("missing window key on first account")
 	}
 }
 
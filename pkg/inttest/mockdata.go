@@ -25,6 +25,14 @@ func itMockClaudeData() map[string]any {
 					{"model": "claude-opus-4-20250514", "cost_usd": 98.50, "input_tokens": 1500000, "output_tokens": 800000},
 					{"model": "claude-3-5-sonnet-20241022", "cost_usd": 43.80, "input_tokens": 3200000, "output_tokens": 1200000},
 				},
+				"sessions": []map[string]any{
+					{"id": "5f0c2a9e-7d41-4b8a-9c3e-1a2b3c4d5e6f", "project": "-home-jess-git-pp", "model": "claude-opus-4-20250514", "input_tokens": 42000, "output_tokens": 118000, "cost_usd": 9.48, "last_activity": "2026-02-09T11:52:00Z", "window_tokens": 96000},
+					{"id": "b7e19d03-2c58-4f6a-8e0b-9f8e7d6c5b4a", "project": "-home-jess-git-lab", "model": "claude-3-5-sonnet-20241022", "input_tokens": 15000, "output_tokens": 31000, "cost_usd": 0.51, "last_activity": "2026-02-09T10:05:00Z", "window_tokens": 46000},
+				},
+				"window": map[string]any{
+					"start": "2026-02-09T09:00:00Z", "reset": "2026-02-09T14:00:00Z",
+					"tokens": 142000, "cost_usd": 6.21,
+				},
 			},
 		},
 		"period_start": "2026-02-01T00:00:00Z",
@@ -0,0 +1,107 @@
+// Package logging sets up the process-wide slog logger: text or JSON
+// output at the configured level, written to stderr or to a log file that
+// is rotated in-process by size and age. The standard log package is
+// routed through the same handler, so every line shares one format, and
+// every line has its secrets masked by a Redactor.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formats accepted by NewHandler.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Attribute keys shared by log lines about collector runs, so one jq
+// filter such as select(.collector == "billing") finds them all.
+const (
+	KeyCollector = "collector"
+	KeyCacheKey  = "cache_key"
+	KeyDuration  = "duration_ms" // whole milliseconds
+	KeyError     = "error"
+)
+
+// Options configures Setup.
+type Options struct {
+	// Level is the minimum level logged: debug, info, warn, or error.
+	// Empty means info.
+	Level string
+
+	// Format is FormatText or FormatJSON. Empty means text.
+	Format string
+
+	// File is the log file path. Empty logs to stderr.
+	File string
+
+	// Rotation bounds File's size, age, and rotated copies.
+	Rotation Rotation
+
+	// Redactor masks secrets in every line. Nil masks only values of
+	// sensitive keys and what Redactor.Scrub recognizes unregistered.
+	Redactor *Redactor
+}
+
+// ParseLevel returns the slog level named by s.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (valid: debug, info, warn, error)", s)
+}
+
+// NewHandler returns a handler writing format output to w at level and
+// above.
+func NewHandler(w io.Writer, format string, level slog.Level) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (valid: text, json)", format)
+}
+
+// Setup makes the logger described by opts the slog default, which the
+// standard log package also writes through. It returns the log file to
+// close at exit, or nil when logging to stderr.
+func Setup(opts Options) (io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer = os.Stderr
+	var file *RotatingFile
+	if opts.File != "" {
+		if file, err = OpenFile(opts.File, opts.Rotation); err != nil {
+			return nil, err
+		}
+		w = file
+	}
+	h, err := NewHandler(w, opts.Format, level)
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+	slog.SetDefault(slog.New(NewRedactHandler(h, opts.Redactor)))
+	if file == nil {
+		return nil, nil
+	}
+	return file, nil
+}
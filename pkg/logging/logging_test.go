@@ -0,0 +1,270 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, FormatJSON, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("NewHandler() error: %v", err)
+	}
+	logger := slog.New(h)
+	logger.Debug("hidden")
+	logger.Warn("collector run failed", KeyCollector, "billing", KeyCacheKey, "billing", KeyDuration, int64(1520), KeyError, "401")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("output %q is not one JSON object: %v", buf.String(), err)
+	}
+	if line["collector"] != "billing" || line["duration_ms"] != 1520.0 || line["level"] != "WARN" {
+		t.Errorf("line = %v", line)
+	}
+
+	if _, err := NewHandler(&buf, "logfmt", slog.LevelInfo); err == nil {
+		t.Error("NewHandler(logfmt) succeeded")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"": slog.LevelInfo, "debug": slog.LevelDebug, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) succeeded")
+	}
+}
+
+func TestSetupRoutesStandardLog(t *testing.T) {
+	orig := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	path := filepath.Join(t.TempDir(), "logs", "daemon.log")
+	closer, err := Setup(Options{Format: FormatJSON, File: path})
+	if err != nil {
+		t.Fatalf("Setup() error: %v", err)
+	}
+	log.Printf("daemon: cache compacted")
+	closer.Close()
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"msg":"daemon: cache compacted"`) {
+		t.Errorf("log file = %q, want the log.Printf line as JSON", data)
+	}
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	f, err := OpenFile(path, Rotation{MaxSize: 20, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("OpenFile() error: %v", err)
+	}
+	defer f.Close()
+
+	// Each line is 10 bytes, so every file holds two.
+	for i := range 7 {
+		fmt.Fprintf(f, "line %04d\n", i)
+	}
+
+	want := map[string]string{
+		path:        "line 0006\n",
+		path + ".1": "line 0004\nline 0005\n",
+		path + ".2": "line 0002\nline 0003\n",
+	}
+	for p, w := range want {
+		if got, _ := os.ReadFile(p); string(got) != w {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), got, w)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("kept a third rotated file; stat err = %v", err)
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	f, err := OpenFile(path, Rotation{MaxAge: time.Hour, MaxFiles: 1})
+	if err != nil {
+		t.Fatalf("OpenFile() error: %v", err)
+	}
+	defer f.Close()
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	f.opened = now
+
+	f.Write([]byte("old\n"))
+	now = now.Add(59 * time.Minute)
+	f.Write([]byte("still old\n"))
+	now = now.Add(time.Minute)
+	f.Write([]byte("new\n"))
+
+	if got, _ := os.ReadFile(path + ".1"); string(got) != "old\nstill old\n" {
+		t.Errorf("rotated file = %q", got)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "new\n" {
+		t.Errorf("current file = %q", got)
+	}
+}
+
+// TestRotatingFileConcurrent writes from many goroutines through many
+// rotations and checks every line arrives whole, in exactly one file.
+func TestRotatingFileConcurrent(t *testing.T) {
+	const writers, lines = 8, 200
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	f, err := OpenFile(path, Rotation{MaxSize: 4096, MaxFiles: 1000})
+	if err != nil {
+		t.Fatalf("OpenFile() error: %v", err)
+	}
+	logger := slog.New(slog.NewJSONHandler(f, nil))
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range lines {
+				logger.Info("collector run", KeyCollector, fmt.Sprintf("c%d", w), "seq", i)
+			}
+		}()
+	}
+	wg.Wait()
+	f.Close()
+
+	files, _ := filepath.Glob(path + "*")
+	if len(files) < 10 {
+		t.Errorf("only %d files; expected many rotations", len(files))
+	}
+	seen := make(map[string]bool)
+	for _, p := range files {
+		file, _ := os.Open(p)
+		sc := bufio.NewScanner(file)
+		for sc.Scan() {
+			var line struct {
+				Collector string `json:"collector"`
+				Seq       int    `json:"seq"`
+			}
+			if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+				t.Fatalf("%s: torn line %q", filepath.Base(p), sc.Text())
+			}
+			key := fmt.Sprintf("%s/%d", line.Collector, line.Seq)
+			if seen[key] {
+				t.Errorf("line %s written twice", key)
+			}
+			seen[key] = true
+		}
+		file.Close()
+	}
+	if len(seen) != writers*lines {
+		t.Errorf("found %d lines, want %d", len(seen), writers*lines)
+	}
+}
+
+func TestIsSensitiveKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"api_key": true, "X-Api-Key": true, "apiKey": true, "admin_key": true, "key": true,
+		"HCLOUD_TOKEN": true, "refresh_token": true, "client_secret": true, "password": true, "Authorization": true,
+		"cache_key": false, "key_file": false, "VULTR_API_KEY_FILE": false, "keys": false,
+		"collector": false, "tokens_used": false, "monkey": false,
+	} {
+		if got := IsSensitiveKey(key); got != want {
+			t.Errorf("IsSensitiveKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+// TestRedactHandler feeds synthetic secrets through every part of a log
+// record and checks none reaches the output.
+func TestRedactHandler(t *testing.T) {
+	const (
+		configured = "civo-7f3a9b2c4d5e"
+		fromEnv    = "hcloud-0a1b2c3d4e5f"
+		inURL      = "wx-9z8y7x6w5v"
+		bearer     = "eyJhbGciOiJIUzI1NiJ9.payload"
+		byKey      = "short1"
+	)
+	r := NewRedactor(configured, "on") // "on" is too short to register
+	r.AddEnv([]string{"HCLOUD_TOKEN=" + fromEnv, "HOME=/home/jess"})
+
+	// The short "on" must survive as the state attribute's value.
+	for format, state := range map[string]string{FormatText: "state=on", FormatJSON: `"state":"on"`} {
+		var buf bytes.Buffer
+		h, err := NewHandler(&buf, format, slog.LevelDebug)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := slog.New(NewRedactHandler(h, r)).With("token", byKey)
+		logger.Info("using key "+configured, KeyCollector, "billing", KeyCacheKey, "billing", "state", "on")
+		logger.Debug("request", "url", "https://api.example.com/v1/data?units=metric&appid=1&api_key="+inURL)
+		logger.Warn("collector run failed", KeyError, fmt.Errorf("GET /v2/costs: 401 for token %s", fromEnv))
+		logger.WithGroup("http").Info("sent", slog.Group("headers", "Authorization", "Bearer "+bearer, "Accept", "application/json"))
+		logger.Info("probe", "detail", `{"password": "hunter2hunter2"}`)
+
+		out := buf.String()
+		for _, secret := range []string{configured, fromEnv, inURL, bearer, byKey, "hunter2hunter2"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s output contains secret %q:\n%s", format, secret, out)
+			}
+		}
+		for _, keep := range []string{"billing", "units=metric", "application/json", state} {
+			if !strings.Contains(out, keep) {
+				t.Errorf("%s output lost %q:\n%s", format, keep, out)
+			}
+		}
+		if !strings.Contains(out, Redacted) {
+			t.Errorf("%s output has no %s marker:\n%s", format, Redacted, out)
+		}
+	}
+}
+
+func TestSetupRedactsStandardLog(t *testing.T) {
+	orig := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	const secret = "do-tok-5566778899"
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	closer, err := Setup(Options{File: path, Redactor: NewRedactor(secret)})
+	if err != nil {
+		t.Fatalf("Setup() error: %v", err)
+	}
+	log.Printf("digitalocean: GET https://api.digitalocean.com/v2?token=%s failed", secret)
+	slog.Info("config", "api_key", "unregistered-value")
+	closer.Close()
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), secret) || strings.Contains(string(data), "unregistered-value") {
+		t.Errorf("log file holds a secret:\n%s", data)
+	}
+}
+
+func TestRedactorWriter(t *testing.T) {
+	const secret = "sk-ant-admin01-abcdef"
+	var buf bytes.Buffer
+	w := NewRedactor(secret).Writer(&buf)
+	n, err := fmt.Fprintf(w, "  work  admin key %s configured\n", secret)
+	if err != nil || n != len("  work  admin key "+secret+" configured\n") {
+		t.Errorf("Fprintf = %d, %v; want the unscrubbed length", n, err)
+	}
+	if want := "  work  admin key " + Redacted + " configured\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	// A nil Redactor still masks what it recognizes unregistered.
+	var nilR *Redactor
+	if got := nilR.Scrub("curl -H 'Authorization: Bearer abc.def' 'https://x/?key=AIzaSy123'"); strings.Contains(got, "abc.def") || strings.Contains(got, "AIzaSy123") {
+		t.Errorf("nil Scrub = %q", got)
+	}
+}
@@ -0,0 +1,240 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Redacted replaces every masked value.
+const Redacted = "[REDACTED]"
+
+// minSecretLen is the shortest value Redactor.Add registers. Shorter
+// values, such as "on" from a mistyped setting, would mask ordinary text.
+const minSecretLen = 8
+
+var (
+	// rdQueryParam matches one URL query parameter, e.g. "&api_key=abc".
+	rdQueryParam = regexp.MustCompile(`([?&;])([^=&\s?#;]+)=([^&\s#;"'<>]*)`)
+
+	// rdJSONField matches a JSON string field, e.g. `"token": "abc"`.
+	rdJSONField = regexp.MustCompile(`"([^"\\]+)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+
+	// rdBearer matches an HTTP bearer or basic credential.
+	rdBearer = regexp.MustCompile(`(?i)\b(bearer|basic)(\s+)[A-Za-z0-9._~+/=-]+`)
+)
+
+// rdSensitiveWords end a key naming a secret. "key" is only sensitive
+// after one of rdKeyQualifiers, so cache_key and key_file stay readable.
+var (
+	rdSensitiveWords = []string{"token", "secret", "password", "passwd", "apikey", "authorization", "credential"}
+	rdKeyQualifiers  = []string{"api", "admin", "access", "private", "secret", "auth", "signing"}
+)
+
+// IsSensitiveKey reports whether key, a log attribute, setting, query
+// parameter, or environment variable name, names a secret: its last word
+// is token, secret, password, or the like, or it is an API, admin, access,
+// or private key. Words are split at underscores, dashes, dots, and
+// lower-to-upper case changes, so "api_key", "X-Api-Key", "apiKey", and
+// "HCLOUD_TOKEN" all match.
+func IsSensitiveKey(key string) bool {
+	words := rdWords(key)
+	if len(words) == 0 {
+		return false
+	}
+	last := words[len(words)-1]
+	if slices.Contains(rdSensitiveWords, last) {
+		return true
+	}
+	if last != "key" {
+		return false
+	}
+	return len(words) == 1 || slices.Contains(rdKeyQualifiers, words[len(words)-2])
+}
+
+// rdWords splits key into lower-case words.
+func rdWords(key string) []string {
+	var words []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	prevLower := false
+	for _, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			prevLower = false
+			continue
+		case unicode.IsUpper(r) && prevLower:
+			flush()
+		}
+		prevLower = unicode.IsLower(r)
+		cur.WriteRune(unicode.ToLower(r))
+	}
+	flush()
+	return words
+}
+
+// Redactor masks secrets in text: values registered with it, values of
+// sensitive URL query parameters and JSON fields, and bearer credentials.
+// A nil Redactor masks only the latter. It is safe for concurrent use.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string // longest first, so no secret is half masked
+}
+
+// NewRedactor returns a Redactor masking secrets.
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+	return r
+}
+
+// Add registers secret values to mask. Values shorter than eight bytes
+// after trimming are ignored.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		s = strings.TrimSpace(s)
+		if len(s) < minSecretLen || slices.Contains(r.secrets, s) {
+			continue
+		}
+		r.secrets = append(r.secrets, s)
+	}
+	slices.SortFunc(r.secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// AddEnv registers the values of the variables in environ, as from
+// os.Environ, whose names are sensitive, such as CIVO_TOKEN.
+func (r *Redactor) AddEnv(environ []string) {
+	for _, kv := range environ {
+		if name, v, ok := strings.Cut(kv, "="); ok && IsSensitiveKey(name) {
+			r.Add(v)
+		}
+	}
+}
+
+// Scrub returns s with every secret it recognizes replaced by Redacted.
+func (r *Redactor) Scrub(s string) string {
+	if r != nil {
+		r.mu.RLock()
+		for _, secret := range r.secrets {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+		r.mu.RUnlock()
+	}
+	s = rdQueryParam.ReplaceAllStringFunc(s, func(m string) string {
+		sub := rdQueryParam.FindStringSubmatch(m)
+		name, err := url.QueryUnescape(sub[2])
+		if err != nil {
+			name = sub[2]
+		}
+		if sub[3] == "" || sub[3] == Redacted || !IsSensitiveKey(name) {
+			return m
+		}
+		return sub[1] + sub[2] + "=" + Redacted
+	})
+	s = rdJSONField.ReplaceAllStringFunc(s, func(m string) string {
+		sub := rdJSONField.FindStringSubmatch(m)
+		if sub[3] == "" || sub[3] == Redacted || !IsSensitiveKey(sub[1]) {
+			return m
+		}
+		return `"` + sub[1] + `"` + sub[2] + `"` + Redacted + `"`
+	})
+	return rdBearer.ReplaceAllString(s, "${1}${2}"+Redacted)
+}
+
+// attr returns a with its value masked when its key is sensitive and
+// scrubbed otherwise. Groups are masked attribute by attribute.
+func (r *Redactor) attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		group := v.Group()
+		out := make([]any, len(group))
+		for i, ga := range group {
+			out[i] = r.attr(ga)
+		}
+		return slog.Group(a.Key, out...)
+	}
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.Scrub(v.String()))
+	case slog.KindAny:
+		// Errors and other values are logged by their text, which may
+		// hold a request URL.
+		s := fmt.Sprint(v.Any())
+		if scrubbed := r.Scrub(s); scrubbed != s {
+			return slog.String(a.Key, scrubbed)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// Writer returns a writer scrubbing everything written to w. Each write is
+// scrubbed on its own, so callers write whole lines.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &rdWriter{w: w, r: r}
+}
+
+type rdWriter struct {
+	w io.Writer
+	r *Redactor
+}
+
+func (w *rdWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.Scrub(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewRedactHandler returns a handler that masks secrets with r in every
+// record's message and attributes before passing it to h.
+func NewRedactHandler(h slog.Handler, r *Redactor) slog.Handler {
+	return &redactHandler{h: h, r: r}
+}
+
+type redactHandler struct {
+	h slog.Handler
+	r *Redactor
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, rec slog.Record) error {
+	out := slog.NewRecord(rec.Time, rec.Level, h.r.Scrub(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.r.attr(a))
+		return true
+	})
+	return h.h.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		masked[i] = h.r.attr(a)
+	}
+	return &redactHandler{h: h.h.WithAttrs(masked), r: h.r}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{h: h.h.WithGroup(name), r: h.r}
+}
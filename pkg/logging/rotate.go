@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Rotation bounds a RotatingFile.
+type Rotation struct {
+	// MaxSize is the size in bytes past which the file is rotated. Zero
+	// means no limit.
+	MaxSize int64
+
+	// MaxAge is how long the file is written to before it is rotated,
+	// counted from when it was opened. Zero means no limit.
+	MaxAge time.Duration
+
+	// MaxFiles is how many rotated files are kept, as path.1 (newest)
+	// through path.MaxFiles. Zero keeps none.
+	MaxFiles int
+}
+
+// RotatingFile is a log file that rotates itself, so it works the same on
+// macOS, where there is no logrotate, as on Linux. Writes are serialized
+// with rotation: a write that would take the file past its limits first
+// renames it to path.1 and opens a fresh one, and no write can land
+// between the two. Each Write goes entirely to one file, so a log line is
+// never split across files.
+type RotatingFile struct {
+	path string
+	rot  Rotation
+	now  func() time.Time
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenFile opens the log file at path for appending, creating it and its
+// directory as needed.
+func OpenFile(path string, rot Rotation) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, rot: rot, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens r.path and makes it the current file.
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open log file: %w", err)
+	}
+	r.f, r.size, r.opened = f, info.Size(), r.now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would take it past
+// MaxSize or it has been open longer than MaxAge.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.due(len(p)) {
+		if err := r.rotate(); err != nil {
+			// Keep writing to the file we have rather than drop the line,
+			// and try again once it has grown by another MaxSize or aged
+			// another MaxAge.
+			fmt.Fprintf(os.Stderr, "logging: %v\n", err)
+			r.size, r.opened = 0, r.now()
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// due reports whether the file must be rotated before n more bytes are
+// written. An empty file is never rotated, so a line longer than MaxSize
+// is still written, alone.
+func (r *RotatingFile) due(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.rot.MaxSize > 0 && r.size+int64(n) > r.rot.MaxSize {
+		return true
+	}
+	return r.rot.MaxAge > 0 && r.now().Sub(r.opened) >= r.rot.MaxAge
+}
+
+// rotate shifts the rotated files up by one, dropping any past MaxFiles,
+// renames the current file to path.1, and opens a new current file. The
+// current file stays open across the renames, so if the new file cannot
+// be opened writes continue to the old one.
+func (r *RotatingFile) rotate() error {
+	for i := r.rot.MaxFiles; i >= 1; i-- {
+		src := r.rotated(i)
+		if i == r.rot.MaxFiles {
+			os.Remove(src)
+			continue
+		}
+		if err := os.Rename(src, r.rotated(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rotate log file: %w", err)
+		}
+	}
+	if r.rot.MaxFiles > 0 {
+		if err := os.Rename(r.path, r.rotated(1)); err != nil {
+			return fmt.Errorf("rotate log file: %w", err)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	old := r.f
+	if err := r.open(); err != nil {
+		return err
+	}
+	old.Close()
+	return nil
+}
+
+// rotated returns the path of the i'th rotated file.
+func (r *RotatingFile) rotated(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
+
+// Close closes the file. Later writes fail.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
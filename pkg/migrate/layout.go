@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
+)
+
+// LayoutMarkerFile is the file in the state directory recording that
+// MigrateLayout has run there.
+const LayoutMarkerFile = ".layout-v2"
+
+// StateFiles are the files earlier versions kept in the cache directory
+// that now live in the state directory: the event log and the sysmetrics,
+// billing, and Claude history.
+var StateFiles = []string{
+	changelog.FileName,
+	sysmetrics.HistoryFileName,
+	billing.HistoryFileName,
+	claude.HistoryFileName,
+}
+
+// ErrLegacyDaemonRunning is returned by MigrateLayout while a daemon
+// holds the instance lock in the cache directory, where earlier versions
+// took it. The files are moved once it has stopped.
+var ErrLegacyDaemonRunning = errors.New("a daemon from an earlier version is running")
+
+// LayoutResult holds the outcome of MigrateLayout.
+type LayoutResult struct {
+	// Moved lists the files moved to the state directory.
+	Moved []string
+
+	// Warnings lists files left in the cache directory, e.g. because the
+	// state directory already has a file of the same name.
+	Warnings []string
+}
+
+// mgLayoutMarker is the content of LayoutMarkerFile.
+type mgLayoutMarker struct {
+	MigratedAt time.Time `json:"migrated_at"`
+	From       string    `json:"from"`
+	Moved      []string  `json:"moved,omitempty"`
+}
+
+// LayoutMigrated reports whether MigrateLayout has run for stateDir.
+func LayoutMigrated(stateDir string) bool {
+	_, err := os.Stat(filepath.Join(stateDir, LayoutMarkerFile))
+	return err == nil
+}
+
+// LegacyStateFiles returns the StateFiles still in cacheDir.
+func LegacyStateFiles(cacheDir string) []string {
+	var out []string
+	for _, name := range StateFiles {
+		if _, err := os.Stat(filepath.Join(cacheDir, name)); err == nil {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// MigrateLayout moves the StateFiles from cacheDir to stateDir, once, and
+// records that it has in LayoutMarkerFile. Each file is renamed, or where
+// the directories are on different file systems copied, synced, and then
+// removed. A file already in stateDir is never overwritten; the one in
+// cacheDir is left with a warning. A stale instance lock and control
+// socket left in cacheDir are removed.
+//
+// It does nothing when the directories are the same or the marker exists,
+// and returns ErrLegacyDaemonRunning while an earlier daemon holds its
+// lock in cacheDir.
+func MigrateLayout(cacheDir, stateDir string) (*LayoutResult, error) {
+	result := &LayoutResult{}
+	if cacheDir == "" || stateDir == "" || filepath.Clean(cacheDir) == filepath.Clean(stateDir) {
+		return result, nil
+	}
+	if LayoutMigrated(stateDir) {
+		return result, nil
+	}
+	oldLock := filepath.Join(cacheDir, daemon.LockFileName)
+	if info, ok := daemon.LockHolder(oldLock); ok {
+		return nil, fmt.Errorf("%w (PID %d); stop it and start the daemon again", ErrLegacyDaemonRunning, info.PID)
+	}
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating state directory %s: %w", stateDir, err)
+	}
+
+	for _, name := range LegacyStateFiles(cacheDir) {
+		src, dst := filepath.Join(cacheDir, name), filepath.Join(stateDir, name)
+		if _, err := os.Lstat(dst); err == nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: already in %s, left in %s", name, stateDir, cacheDir))
+			continue
+		}
+		if err := mgMoveFile(src, dst); err != nil {
+			return result, fmt.Errorf("moving %s to %s: %w", name, stateDir, err)
+		}
+		result.Moved = append(result.Moved, name)
+	}
+
+	// Nothing holds them, so they are left over from a daemon that exited.
+	for _, name := range []string{daemon.LockFileName, daemon.ControlSocketName} {
+		if err := os.Remove(filepath.Join(cacheDir, name)); err != nil && !os.IsNotExist(err) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	marker, err := json.MarshalIndent(mgLayoutMarker{MigratedAt: time.Now().UTC(), From: cacheDir, Moved: result.Moved}, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, LayoutMarkerFile), append(marker, '\n'), 0o644); err != nil {
+		return result, fmt.Errorf("recording layout migration: %w", err)
+	}
+	return result, nil
+}
+
+// mgMoveFile renames src to dst, falling back to mgCopyFile when they are
+// on different file systems.
+func mgMoveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := mgCopyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// mgCopyFile copies src to dst through a temporary file in dst's
+// directory, syncing the file before renaming it into place and the
+// directory after, so a crash leaves either no dst or all of it.
+func mgCopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".migrate-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(dst))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -1,11 +1,14 @@
 package migrate
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
 )
 
 // ---------- helpers ----------
@@ -850,6 +853,118 @@ func TestWriteConfig_AtomicWrite(t *testing.T) {
 	}
 }
 
+// ---------- State Directory Layout ----------
+
+func TestMigrateLayout_MovesStateFiles(t *testing.T) {
+	cacheDir, stateDir := mgTempDir(t), filepath.Join(mgTempDir(t), "state")
+	mgWriteFile(t, cacheDir, "events.json", `[]`)
+	mgWriteFile(t, cacheDir, "billing-history.jsonl", "{}\n")
+	mgWriteFile(t, cacheDir, "claude.json", `{}`)
+	mgWriteFile(t, cacheDir, "prompt-pulse.lock", "")
+
+	result, err := MigrateLayout(cacheDir, stateDir)
+	if err != nil {
+		t.Fatalf("MigrateLayout() error: %v", err)
+	}
+	if got := strings.Join(result.Moved, ","); got != "events.json,billing-history.jsonl" {
+		t.Errorf("Moved = %v, want the event log and billing history", result.Moved)
+	}
+	for _, name := range result.Moved {
+		if _, err := os.Stat(filepath.Join(stateDir, name)); err != nil {
+			t.Errorf("%s not in the state directory: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(cacheDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still in the cache directory", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "claude.json")); err != nil {
+		t.Errorf("collector data should stay in the cache directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "prompt-pulse.lock")); !os.IsNotExist(err) {
+		t.Error("stale lock should be removed from the cache directory")
+	}
+	if !LayoutMigrated(stateDir) {
+		t.Error("LayoutMigrated() = false after migrating")
+	}
+
+	// It runs once: later files are left where they are.
+	mgWriteFile(t, cacheDir, "events.json", `[]`)
+	if result, err := MigrateLayout(cacheDir, stateDir); err != nil || len(result.Moved) != 0 {
+		t.Errorf("second MigrateLayout() = %+v, %v; want nothing moved", result, err)
+	}
+}
+
+func TestMigrateLayout_KeepsExisting(t *testing.T) {
+	cacheDir, stateDir := mgTempDir(t), mgTempDir(t)
+	mgWriteFile(t, cacheDir, "events.json", `["old"]`)
+	mgWriteFile(t, stateDir, "events.json", `["new"]`)
+
+	result, err := MigrateLayout(cacheDir, stateDir)
+	if err != nil {
+		t.Fatalf("MigrateLayout() error: %v", err)
+	}
+	if len(result.Moved) != 0 || len(result.Warnings) != 1 {
+		t.Errorf("result = %+v, want one warning and nothing moved", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(stateDir, "events.json")); string(data) != `["new"]` {
+		t.Errorf("state events.json = %s, want it kept", data)
+	}
+}
+
+func TestMigrateLayout_SameDirectory(t *testing.T) {
+	dir := mgTempDir(t)
+	mgWriteFile(t, dir, "events.json", `[]`)
+	if result, err := MigrateLayout(dir, dir+"/"); err != nil || len(result.Moved) != 0 {
+		t.Errorf("MigrateLayout(same) = %+v, %v; want nothing to do", result, err)
+	}
+	if LayoutMigrated(dir) {
+		t.Error("no marker should be written when the directories are the same")
+	}
+}
+
+func TestMigrateLayout_LegacyDaemonRunning(t *testing.T) {
+	cacheDir, stateDir := mgTempDir(t), mgTempDir(t)
+	mgWriteFile(t, cacheDir, "events.json", `[]`)
+	lock, err := daemon.AcquireLock(filepath.Join(cacheDir, daemon.LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	if _, err := MigrateLayout(cacheDir, stateDir); !errors.Is(err, ErrLegacyDaemonRunning) {
+		t.Fatalf("MigrateLayout() error = %v, want ErrLegacyDaemonRunning", err)
+	}
+	if got := LegacyStateFiles(cacheDir); len(got) != 1 {
+		t.Errorf("LegacyStateFiles() = %v, want the event log left in place", got)
+	}
+}
+
+func TestCopyFile_Syncs(t *testing.T) {
+	src := mgWriteFile(t, mgTempDir(t), "billing-history.jsonl", "{\"a\":1}\n")
+	if err := os.Chmod(src, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dstDir := mgTempDir(t)
+	dst := filepath.Join(dstDir, "billing-history.jsonl")
+	if err := mgCopyFile(src, dst); err != nil {
+		t.Fatalf("mgCopyFile() error: %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "{\"a\":1}\n" {
+		t.Errorf("copy = %q", data)
+	}
+	entries, _ := os.ReadDir(dstDir)
+	if len(entries) != 1 {
+		t.Errorf("destination has %d entries, want no temp file left", len(entries))
+	}
+}
+
 // ---------- helpers for assertions ----------
 
 func mgAssertChangeExists(t *testing.T, changes []ConfigChange, field, action string) {
@@ -0,0 +1,300 @@
+// Package mocks provides named scenarios of collector data for developing
+// the banner, starship module, and TUI against specific situations without
+// live services. Every scenario is deterministic: for a given time it
+// produces the same data, with timestamps relative to that time so the
+// cache readers see it as fresh. WriteToCache writes a scenario where the
+// daemon would, so the real cache-reading code paths render it.
+package mocks
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
+)
+
+// Scenario is a named, documented set of collector results.
+type Scenario struct {
+	// Name selects the scenario, e.g. with the -mock-scenario flag.
+	Name string
+
+	// Description says what the scenario shows.
+	Description string
+
+	build func(now time.Time) map[string]any
+}
+
+// Data returns the scenario's collector results keyed by cache key
+// ("claude", "billing", ...), as of now. Collectors absent from the map
+// have no data.
+func (s Scenario) Data(now time.Time) map[string]any {
+	if s.build == nil {
+		return map[string]any{}
+	}
+	return s.build(now)
+}
+
+// scenarios lists every scenario, in the order Scenarios returns them.
+var scenarios = []Scenario{
+	{
+		Name:        "healthy",
+		Description: "Everything normal: Claude at 42% of its 5-hour window, billing at $57.50 of a $100 budget, 4 of 5 Tailscale peers online, a 3-node cluster with all pods running, CPU 23% and RAM 48%.",
+		build:       healthy,
+	},
+	{
+		Name:        "billing-error",
+		Description: "Like healthy, but the DigitalOcean billing API rejects its token, so only Civo reports spend.",
+		build:       billingError,
+	},
+	{
+		Name:        "claude-near-limit",
+		Description: "Like healthy, but Claude is at 95% of its 5-hour window, which resets in 40 minutes.",
+		build:       claudeNearLimit,
+	},
+	{
+		Name:        "k8s-node-notready",
+		Description: "Like healthy, but cluster node worker-2 is NotReady and its 6 pods are pending.",
+		build:       k8sNodeNotReady,
+	},
+	{
+		Name:        "first-run",
+		Description: "No collector has run yet: nothing is cached.",
+		build:       func(time.Time) map[string]any { return map[string]any{} },
+	},
+}
+
+// Scenarios returns every scenario.
+func Scenarios() []Scenario {
+	return append([]Scenario(nil), scenarios...)
+}
+
+// Lookup returns the scenario called name.
+func Lookup(name string) (Scenario, bool) {
+	for _, s := range scenarios {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// Names returns the names of every scenario, in order.
+func Names() []string {
+	names := make([]string, len(scenarios))
+	for i, s := range scenarios {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// WriteToCache writes the scenario's data as of now into dir the way the
+// daemon writes collector results, so the banner, starship module, and
+// TUI read it as they would live data.
+func WriteToCache(dir string, s Scenario, now time.Time) error {
+	for key, data := range s.Data(now) {
+		if err := daemon.WriteCollectorData(dir, key, data); err != nil {
+			return fmt.Errorf("mock scenario %s: %s: %w", s.Name, key, err)
+		}
+	}
+	return nil
+}
+
+// healthy is the baseline the other scenarios change one thing in.
+func healthy(now time.Time) map[string]any {
+	return map[string]any{
+		"claude":     claudeReport(now, 42),
+		"billing":    billingReport(now, false),
+		"tailscale":  tailscaleStatus(now),
+		"k8s":        clusterStatus(now, false),
+		"sysmetrics": systemMetrics(now),
+	}
+}
+
+func billingError(now time.Time) map[string]any {
+	data := healthy(now)
+	data["billing"] = billingReport(now, true)
+	return data
+}
+
+func claudeNearLimit(now time.Time) map[string]any {
+	data := healthy(now)
+	data["claude"] = claudeReport(now, 95)
+	return data
+}
+
+func k8sNodeNotReady(now time.Time) map[string]any {
+	data := healthy(now)
+	data["k8s"] = clusterStatus(now, true)
+	return data
+}
+
+// claudeReport is one subscription account at windowPercent of its
+// 5-hour limit, with the window resetting 40 minutes after now.
+func claudeReport(now time.Time, windowPercent float64) claude.UsageReport {
+	return claude.UsageReport{
+		Accounts: []claude.AccountUsage{{
+			Name:         "personal",
+			Connected:    true,
+			Subscription: "max",
+			CurrentMonth: claude.MonthUsage{InputTokens: 4_700_000, OutputTokens: 2_000_000, CostUSD: 142.30},
+			Models: []claude.ModelUsage{
+				{Model: "claude-opus-4-20250514", InputTokens: 1_500_000, OutputTokens: 800_000, CostUSD: 98.50},
+				{Model: "claude-3-5-sonnet-20241022", InputTokens: 3_200_000, OutputTokens: 1_200_000, CostUSD: 43.80},
+			},
+			Plan: &claude.PlanUsage{
+				FiveHour: &claude.PlanWindow{Utilization: windowPercent, ResetsAt: now.Add(40 * time.Minute)},
+				SevenDay: &claude.PlanWindow{Utilization: 31, ResetsAt: now.Add(3 * 24 * time.Hour)},
+			},
+		}},
+		TotalCostUSD: 142.30,
+		Timestamp:    now,
+	}
+}
+
+// billingReport is Civo and DigitalOcean against a $100 budget. With
+// doFails DigitalOcean reports an authentication error instead of spend.
+func billingReport(now time.Time, doFails bool) billing.BillingReport {
+	civo := billing.ProviderBilling{
+		Name:        "civo",
+		Connected:   true,
+		MonthToDate: 12.50,
+		Resources: []billing.ResourceCost{
+			{Name: "k3s-cluster", Type: "kubernetes", MonthlyCost: 10.00, Accrued: 10.00},
+			{Name: "network", Type: "network", MonthlyCost: 2.50},
+		},
+		Breakdown: []billing.CostItem{
+			{Type: "instance", Count: 3, Cost: 10.00},
+			{Type: "network", Count: 1, Cost: 2.50},
+		},
+	}
+	do := billing.ProviderBilling{
+		Name:        "digitalocean",
+		Connected:   true,
+		MonthToDate: 45.00,
+		Resources: []billing.ResourceCost{
+			{Name: "doks-cluster", Type: "kubernetes", MonthlyCost: 36.00},
+			{Name: "load-balancer", Type: "load_balancer", MonthlyCost: 9.00},
+		},
+	}
+	if doFails {
+		do = billing.ProviderBilling{
+			Name:  "digitalocean",
+			Error: "GET /v2/customers/my/balance: 401 Unauthorized: Unable to authenticate you",
+		}
+	}
+	total := civo.MonthToDate + do.MonthToDate
+	return billing.BillingReport{
+		Providers:       []billing.ProviderBilling{civo, do},
+		TotalMonthlyUSD: total,
+		BudgetUSD:       100,
+		BudgetPercent:   total,
+		Timestamp:       now,
+	}
+}
+
+// tailscaleStatus is a tailnet with 4 of 5 peers online.
+func tailscaleStatus(now time.Time) tailscale.Status {
+	peer := func(host, os, ip string, online bool, seen time.Duration) tailscale.PeerInfo {
+		return tailscale.PeerInfo{
+			ID:           host,
+			Hostname:     host,
+			DNSName:      host + ".tail1234.ts.net.",
+			OS:           os,
+			TailscaleIPs: []string{ip},
+			Online:       online,
+			LastSeen:     now.Add(-seen),
+		}
+	}
+	peers := []tailscale.PeerInfo{
+		peer("honey", "linux", "100.64.0.2", true, 0),
+		peer("petting-zoo-mini", "macOS", "100.64.0.3", true, 0),
+		peer("yoga", "linux", "100.64.0.4", true, 0),
+		peer("nas", "linux", "100.64.0.5", true, 0),
+		peer("xoxd-bates", "macOS", "100.64.0.6", false, 26*time.Hour),
+	}
+	return tailscale.Status{
+		Self:           peer("workstation", "linux", "100.64.0.1", true, 0),
+		Peers:          peers,
+		MagicDNSSuffix: "tail1234.ts.net",
+		TailnetName:    "example.github",
+		BackendState:   "Running",
+		OnlinePeers:    4,
+		TotalPeers:     5,
+		Timestamp:      now,
+	}
+}
+
+// clusterStatus is a 3-node cluster with 24 pods, the default namespace
+// at three quarters of its quota. With notReady node
+// worker-2 is NotReady and the 6 pods scheduled on it are pending.
+func clusterStatus(now time.Time, notReady bool) k8s.ClusterStatus {
+	const gib = 1 << 30
+	node := func(name string, roles ...string) k8s.NodeInfo {
+		return k8s.NodeInfo{
+			Name: name, Ready: true, Roles: roles,
+			CPUCapacity: "4", CPURequests: "1500m", CPULimits: "3",
+			MemCapacity: "16Gi", MemRequests: "6Gi", MemLimits: "10Gi",
+			PodCount: 8,
+		}
+	}
+	c := k8s.ClusterInfo{
+		Context:   "homelab",
+		Connected: true,
+		Nodes: []k8s.NodeInfo{
+			node("cp-1", "control-plane"),
+			node("worker-1", "worker"),
+			node("worker-2", "worker"),
+		},
+		Namespaces: []k8s.NamespaceInfo{
+			{Name: "default", PodCounts: k8s.PodCounts{Total: 6, Running: 6},
+				Deployments: []k8s.DeploymentInfo{{Name: "web", Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}},
+				CPU:         k8s.ResourceUsage{Requested: 1500, Limit: 2000, Percent: 75, Quota: true},
+				Memory:      k8s.ResourceUsage{Requested: 3 * gib, Limit: 4 * gib, Percent: 75, Quota: true}},
+			{Name: "kube-system", PodCounts: k8s.PodCounts{Total: 18, Running: 18},
+				CPU:    k8s.ResourceUsage{Requested: 3000, Limit: 12000, Percent: 25},
+				Memory: k8s.ResourceUsage{Requested: 15 * gib, Limit: 48 * gib, Percent: 31.25}},
+		},
+		TotalPods:   24,
+		RunningPods: 24,
+	}
+	if notReady {
+		c.Nodes[2].Ready = false
+		c.Nodes[2].Conditions = []string{"Ready=False: KubeletNotReady"}
+		c.Namespaces[0].PodCounts = k8s.PodCounts{Total: 6, Running: 3, Pending: 3}
+		c.Namespaces[0].Deployments[0].ReadyReplicas = 1
+		c.Namespaces[0].Deployments[0].AvailableReplicas = 1
+		c.Namespaces[1].PodCounts = k8s.PodCounts{Total: 18, Running: 15, Pending: 3}
+		c.RunningPods, c.PendingPods = 18, 6
+	}
+	c.Health, c.HealthReasons = k8s.EvaluateHealth(c, k8s.DefaultUtilizationWarn)
+	return k8s.ClusterStatus{
+		Clusters:  []k8s.ClusterInfo{c},
+		Health:    c.Health,
+		Timestamp: now,
+	}
+}
+
+// systemMetrics is an 8-core machine at 23% CPU and 48% of 32 GiB RAM.
+func systemMetrics(now time.Time) sysmetrics.Metrics {
+	const gib = 1 << 30
+	return sysmetrics.Metrics{
+		CPU: sysmetrics.CPUMetrics{
+			Cores: []float64{31, 18, 27, 12, 40, 9, 22, 25},
+			Total: 23,
+			Count: 8,
+		},
+		Memory: sysmetrics.MemoryMetrics{
+			Total:       32 * gib,
+			Used:        32 * gib * 48 / 100,
+			Available:   32 * gib * 52 / 100,
+			UsedPercent: 48,
+		},
+		Uptime:    76*time.Hour + 30*time.Minute,
+		Timestamp: now,
+	}
+}
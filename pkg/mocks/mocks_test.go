@@ -0,0 +1,99 @@
+package mocks
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/starship"
+)
+
+var testNow = time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+func TestScenariosDeterministic(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, s := range Scenarios() {
+		if s.Name == "" || s.Description == "" || seen[s.Name] {
+			t.Errorf("scenario %q: needs a unique name and a description", s.Name)
+		}
+		seen[s.Name] = true
+
+		a, err := json.Marshal(s.Data(testNow))
+		if err != nil {
+			t.Fatalf("%s: %v", s.Name, err)
+		}
+		b, _ := json.Marshal(s.Data(testNow))
+		if string(a) != string(b) {
+			t.Errorf("%s: data differs between calls", s.Name)
+		}
+	}
+	if !reflect.DeepEqual(Names(), []string{"healthy", "billing-error", "claude-near-limit", "k8s-node-notready", "first-run"}) {
+		t.Errorf("Names() = %v", Names())
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("Lookup(nope) found a scenario")
+	}
+}
+
+// TestWriteToCache renders each scenario through the starship module's
+// cache readers, as a prompt would after the daemon wrote the data.
+func TestWriteToCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(t *testing.T, out starship.JSONOutput)
+	}{
+		{"healthy", func(t *testing.T, out starship.JSONOutput) {
+			if out.K8s == nil || out.K8s.Health != "healthy" || out.Billing == nil || out.Billing.TotalMonthlyUSD != 57.50 {
+				t.Errorf("k8s = %+v billing = %+v", out.K8s, out.Billing)
+			}
+		}},
+		{"billing-error", func(t *testing.T, out starship.JSONOutput) {
+			if out.Billing == nil || out.Billing.Providers[1].Status != "error" || out.Billing.Providers[1].Error == "" {
+				t.Errorf("billing = %+v, want digitalocean erroring", out.Billing)
+			}
+		}},
+		{"k8s-node-notready", func(t *testing.T, out starship.JSONOutput) {
+			if out.K8s == nil || out.K8s.Health != "warning" || out.K8s.Clusters[0].HealthReasons[0] != "node worker-2 NotReady" {
+				t.Errorf("k8s = %+v, want a warning for worker-2", out.K8s)
+			}
+		}},
+		{"first-run", func(t *testing.T, out starship.JSONOutput) {
+			if out != (starship.JSONOutput{}) {
+				t.Errorf("output = %+v, want nothing", out)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ok := Lookup(tt.name)
+			if !ok {
+				t.Fatalf("no scenario %s", tt.name)
+			}
+			dir := t.TempDir()
+			if err := WriteToCache(dir, s, time.Now()); err != nil {
+				t.Fatalf("WriteToCache() error: %v", err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != len(s.Data(testNow)) {
+				t.Errorf("wrote %d files, want one per collector", len(entries))
+			}
+			tt.check(t, starship.Collect(starship.Config{
+				ShowClaude: true, ShowBilling: true, ShowTailscale: true, ShowK8s: true, ShowSystem: true,
+				CacheDir: dir,
+			}))
+		})
+	}
+}
+
+func TestClaudeNearLimit(t *testing.T) {
+	s, _ := Lookup("claude-near-limit")
+	dir := t.TempDir()
+	if err := WriteToCache(dir, s, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	cfg := starship.Config{ShowClaude: true, CacheDir: dir, Palette: starship.Palette{Critical: "<crit>"}}
+	if got := starship.Render(cfg); len(got) < 6 || got[:6] != "<crit>" {
+		t.Errorf("Render() = %q, want the critical color at 95%% of the window", got)
+	}
+}
@@ -0,0 +1,416 @@
+// Package notify tells the user when collector data crosses a threshold: a
+// cloud billing budget or unusual daily spend, a Claude usage window, an infra check going down,
+// or a Kubernetes cluster turning critical. Rules fire when a subject (a
+// provider, account, check, or cluster) enters the triggered state, not
+// on every poll, and a per-rule cooldown keeps a flapping subject quiet.
+// Events are delivered to desktop, webhook, and command sinks.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// Rule events, as written in notifications.rule.event.
+const (
+	EventBillingBudget  = "billing_budget"
+	EventBillingAnomaly = "billing_anomaly"
+	EventClaudeWindow   = "claude_window"
+	EventCheckDown      = "check_down"
+	EventK8sCritical    = "k8s_critical"
+
+	// EventTest is the kind of the event sent by TestEvent.
+	EventTest = "test"
+)
+
+// Event levels.
+const (
+	LevelWarn     = "warn"
+	LevelCritical = "critical"
+)
+
+// Default thresholds, in percent, for rules that leave Threshold at zero.
+const (
+	DefaultBudgetThreshold = 100.0
+	DefaultWindowThreshold = 80.0
+)
+
+// ntSources maps each rule event to the collector whose data it watches.
+var ntSources = map[string]string{
+	EventBillingBudget:  "billing",
+	EventBillingAnomaly: "billing",
+	EventClaudeWindow:   "claude",
+	EventCheckDown:      "checks",
+	EventK8sCritical:    "k8s",
+}
+
+// ntLevels maps each rule event to the level of its events.
+var ntLevels = map[string]string{
+	EventBillingBudget:  LevelWarn,
+	EventBillingAnomaly: LevelWarn,
+	EventClaudeWindow:   LevelWarn,
+	EventCheckDown:      LevelCritical,
+	EventK8sCritical:    LevelCritical,
+}
+
+// Event is one notification, also the JSON payload of webhook and command
+// sinks.
+type Event struct {
+	// Rule names the rule that fired.
+	Rule string `json:"rule"`
+
+	// Kind is the rule event, e.g. "check_down".
+	Kind string `json:"event"`
+
+	// Level is LevelWarn or LevelCritical, by Kind.
+	Level string `json:"level,omitempty"`
+
+	// Subject is the provider, account, check, or cluster concerned.
+	Subject string `json:"subject"`
+
+	// Title and Message are the human-readable notification.
+	Title   string `json:"title"`
+	Message string `json:"message"`
+
+	// Value and Threshold are the percentages for threshold events.
+	Value     float64 `json:"value,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// Time is when the event fired.
+	Time time.Time `json:"time"`
+}
+
+// Rule maps a threshold event to the sinks it is delivered to.
+type Rule struct {
+	// Name labels the rule.
+	Name string
+
+	// Event is one of the Event* rule events.
+	Event string
+
+	// Threshold is the triggering percentage for EventBillingBudget and
+	// EventClaudeWindow. Zero uses the event's default.
+	Threshold float64
+
+	// Cooldown is the minimum time between two events of the rule for the
+	// same subject.
+	Cooldown time.Duration
+
+	// Sinks names the sinks to deliver to. Empty uses every sink.
+	Sinks []string
+}
+
+// threshold returns the rule's effective threshold.
+func (r Rule) threshold() float64 {
+	switch {
+	case r.Threshold > 0:
+		return r.Threshold
+	case r.Event == EventBillingBudget:
+		return DefaultBudgetThreshold
+	case r.Event == EventClaudeWindow:
+		return DefaultWindowThreshold
+	}
+	return 0
+}
+
+// Notifier evaluates rules against collector data and delivers the events
+// they fire. It is safe for concurrent use.
+type Notifier struct {
+	rules []Rule
+	sinks []Sink
+
+	// now is the clock, replaceable in tests.
+	now func() time.Time
+
+	mu sync.Mutex
+	// state tracks each rule and subject, keyed by rule name and subject.
+	state map[string]*ntState
+}
+
+// ntState is the last known state of one rule for one subject.
+type ntState struct {
+	active bool      // the subject is over the threshold
+	fired  time.Time // when the last event was sent
+}
+
+// New returns a Notifier for rules, delivering to sinks.
+func New(rules []Rule, sinks []Sink) *Notifier {
+	return &Notifier{
+		rules: rules,
+		sinks: sinks,
+		now:   time.Now,
+		state: make(map[string]*ntState),
+	}
+}
+
+// RulesFromConfig returns the rules configured in nc.
+func RulesFromConfig(nc config.NotificationsConfig) []Rule {
+	rules := make([]Rule, len(nc.Rules))
+	for i, r := range nc.Rules {
+		rules[i] = Rule{
+			Name:      r.Name,
+			Event:     r.Event,
+			Threshold: r.Threshold,
+			Cooldown:  r.Cooldown.Duration,
+			Sinks:     r.Sinks,
+		}
+	}
+	return rules
+}
+
+// SetRules replaces the rules and sinks, as on a configuration reload. The
+// state of rules that keep their name is kept, so a reload does not
+// repeat events for subjects that are still triggered.
+func (n *Notifier) SetRules(rules []Rule, sinks []Sink) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.rules, n.sinks = rules, sinks
+	keep := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		keep[r.Name] = true
+	}
+	for key := range n.state {
+		if !keep[key[:strings.IndexByte(key, 0)]] {
+			delete(n.state, key)
+		}
+	}
+}
+
+// Observe evaluates the rules that watch the named collector against its
+// latest data and returns the events to deliver: one for each subject
+// that became triggered since the last observation and is out of its
+// rule's cooldown. A subject that recovers can fire again later.
+func (n *Notifier) Observe(source string, data interface{}) []Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	var events []Event
+	for _, r := range n.rules {
+		if ntSources[r.Event] != source {
+			continue
+		}
+		for _, c := range ntEvaluate(r, data) {
+			key := r.Name + "\x00" + c.subject
+			st := n.state[key]
+			if st == nil {
+				st = &ntState{}
+				n.state[key] = st
+			}
+			wasActive := st.active
+			st.active = c.active
+			if !c.active || wasActive {
+				continue
+			}
+			if !st.fired.IsZero() && now.Sub(st.fired) < r.Cooldown {
+				continue
+			}
+			st.fired = now
+			events = append(events, Event{
+				Rule:      r.Name,
+				Kind:      r.Event,
+				Level:     ntLevels[r.Event],
+				Subject:   c.subject,
+				Title:     c.title,
+				Message:   c.message,
+				Value:     c.value,
+				Threshold: c.threshold,
+				Time:      now,
+			})
+		}
+	}
+	return events
+}
+
+// Deliver sends each event to the sinks of the rule that fired it. Every
+// sink is tried; the failures are returned together.
+func (n *Notifier) Deliver(ctx context.Context, events []Event) error {
+	n.mu.Lock()
+	rules := make(map[string]Rule, len(n.rules))
+	for _, r := range n.rules {
+		rules[r.Name] = r
+	}
+	sinks := n.sinks
+	n.mu.Unlock()
+
+	var errs []error
+	for _, ev := range events {
+		for _, s := range ntRuleSinks(rules[ev.Rule], sinks) {
+			if err := s.Send(ctx, ev); err != nil {
+				errs = append(errs, fmt.Errorf("notify: %s to %s: %w", ev.Rule, s.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ntRuleSinks returns the sinks r delivers to.
+func ntRuleSinks(r Rule, sinks []Sink) []Sink {
+	if len(r.Sinks) == 0 {
+		return sinks
+	}
+	var out []Sink
+	for _, s := range sinks {
+		for _, name := range r.Sinks {
+			if s.Name() == name {
+				out = append(out, s)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// TestEvent returns a dummy event for checking that sinks work.
+func TestEvent() Event {
+	return Event{
+		Rule:    "test",
+		Kind:    EventTest,
+		Subject: "prompt-pulse",
+		Title:   "prompt-pulse test notification",
+		Message: "Notifications are working.",
+		Time:    time.Now(),
+	}
+}
+
+// ntCondition is a rule's verdict for one subject.
+type ntCondition struct {
+	subject          string
+	active           bool
+	title, message   string
+	value, threshold float64
+}
+
+// ntEvaluate returns the condition of every subject in data for r. Data of
+// an unexpected type yields none.
+func ntEvaluate(r Rule, data interface{}) []ntCondition {
+	limit := r.threshold()
+	var out []ntCondition
+	switch r.Event {
+	case EventBillingBudget:
+		report, ok := data.(*billing.BillingReport)
+		if !ok || report == nil {
+			return nil
+		}
+		currency := report.DisplayCurrency()
+		for _, p := range report.Providers {
+			if p.BudgetUSD > 0 {
+				out = append(out, ntBudget(p.Name, currency, p.MonthToDate, p.BudgetUSD, p.BudgetPercent, limit))
+			}
+		}
+		if report.BudgetUSD > 0 {
+			out = append(out, ntBudget("total", currency, report.TotalMonthlyUSD, report.BudgetUSD, report.BudgetPercent, limit))
+		}
+
+	case EventBillingAnomaly:
+		report, ok := data.(*billing.BillingReport)
+		if !ok || report == nil {
+			return nil
+		}
+		currency := report.DisplayCurrency()
+		for _, p := range report.Providers {
+			if !p.Connected {
+				continue
+			}
+			c := ntCondition{subject: p.Name, active: p.Anomaly != nil}
+			if a := p.Anomaly; a != nil {
+				c.title = "Billing " + a.Summary(p.Name, currency)
+				c.message = fmt.Sprintf("%s has spent %s today against a usual %s a day.", p.Name, billing.FormatAmount(a.Spend, currency), billing.FormatAmount(a.Baseline, currency))
+			}
+			out = append(out, c)
+		}
+
+	case EventClaudeWindow:
+		report, ok := data.(*claude.UsageReport)
+		if !ok || report == nil {
+			return nil
+		}
+		for _, a := range report.Accounts {
+			pct, ok := ntWindowPercent(a)
+			if !ok {
+				continue
+			}
+			out = append(out, ntCondition{
+				subject:   a.Name,
+				active:    pct >= limit,
+				title:     fmt.Sprintf("Claude %s: usage window at %.0f%%", a.Name, pct),
+				message:   fmt.Sprintf("The %s usage window is %.0f%% used (threshold %.0f%%).", a.Name, pct, limit),
+				value:     pct,
+				threshold: limit,
+			})
+		}
+
+	case EventCheckDown:
+		status, ok := data.(*checks.Status)
+		if !ok || status == nil {
+			return nil
+		}
+		for _, c := range status.Checks {
+			msg := fmt.Sprintf("Check %s (%s %s) is down.", c.Name, c.Type, c.Target)
+			if c.Error != "" {
+				msg = fmt.Sprintf("Check %s (%s %s) is down: %s", c.Name, c.Type, c.Target, c.Error)
+			}
+			out = append(out, ntCondition{
+				subject: c.Name,
+				active:  c.State == checks.StateDown,
+				title:   "Check down: " + c.Name,
+				message: msg,
+			})
+		}
+
+	case EventK8sCritical:
+		status, ok := data.(*k8s.ClusterStatus)
+		if !ok || status == nil {
+			return nil
+		}
+		for _, c := range status.Clusters {
+			msg := fmt.Sprintf("Cluster %s is critical.", c.Context)
+			if len(c.HealthReasons) > 0 {
+				msg = fmt.Sprintf("Cluster %s is critical: %s", c.Context, strings.Join(c.HealthReasons, "; "))
+			}
+			out = append(out, ntCondition{
+				subject: c.Context,
+				active:  c.Health == k8s.HealthCritical,
+				title:   "Kubernetes critical: " + c.Context,
+				message: msg,
+			})
+		}
+	}
+	return out
+}
+
+// ntBudget is the billing_budget condition for one provider, or "total",
+// with amounts in currency.
+func ntBudget(name, currency string, spent, budget, pct, limit float64) ntCondition {
+	return ntCondition{
+		subject:   name,
+		active:    pct >= limit,
+		title:     fmt.Sprintf("Billing %s: %.0f%% of budget", name, pct),
+		message:   fmt.Sprintf("%s spend %s is %.0f%% of the %s budget (threshold %.0f%%).", name, billing.FormatAmount(spent, currency), pct, billing.FormatAmount(budget, currency), limit),
+		value:     pct,
+		threshold: limit,
+	}
+}
+
+// ntWindowPercent returns how full an account's usage window is: the
+// plan's five-hour utilization, or window tokens against the forecast
+// limit. It reports false when the account has neither.
+func ntWindowPercent(a claude.AccountUsage) (float64, bool) {
+	switch {
+	case a.Plan != nil && a.Plan.FiveHour != nil:
+		return a.Plan.FiveHour.Utilization, true
+	case a.Window != nil && a.Forecast != nil && a.Forecast.Limit > 0:
+		return float64(a.Window.Tokens) / float64(a.Forecast.Limit) * 100, true
+	}
+	return 0, false
+}
@@ -0,0 +1,262 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// recordSink records the events sent to it.
+type recordSink struct {
+	name string
+	err  error
+
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordSink) Name() string { return s.name }
+
+func (s *recordSink) Send(_ context.Context, ev Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, ev)
+	return s.err
+}
+
+func checkStatus(states ...string) *checks.Status {
+	st := &checks.Status{}
+	for i, s := range states {
+		st.Checks = append(st.Checks, checks.Result{Name: string(rune('a' + i)), Type: "ping", Target: "host", State: s})
+	}
+	return st
+}
+
+func TestObserve_FiresOnTransitionOnly(t *testing.T) {
+	n := New([]Rule{{Name: "down", Event: EventCheckDown}}, nil)
+
+	if ev := n.Observe("checks", checkStatus(checks.StateUp, checks.StateDown)); len(ev) != 1 || ev[0].Subject != "b" {
+		t.Fatalf("first observation = %+v, want one event for b", ev)
+	}
+	if ev := n.Observe("checks", checkStatus(checks.StateUp, checks.StateDown)); len(ev) != 0 {
+		t.Errorf("still down = %+v, want no repeat", ev)
+	}
+	if ev := n.Observe("checks", checkStatus(checks.StateUp, checks.StateUp)); len(ev) != 0 {
+		t.Errorf("recovery = %+v, want no event", ev)
+	}
+	if ev := n.Observe("checks", checkStatus(checks.StateUp, checks.StateDown)); len(ev) != 1 {
+		t.Errorf("down again = %+v, want one event", ev)
+	}
+	if ev := n.Observe("k8s", checkStatus(checks.StateDown)); len(ev) != 0 {
+		t.Errorf("other source = %+v, want no event", ev)
+	}
+}
+
+func TestObserve_Cooldown(t *testing.T) {
+	n := New([]Rule{{Name: "down", Event: EventCheckDown, Cooldown: time.Hour}}, nil)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+
+	flap := func() int {
+		n.Observe("checks", checkStatus(checks.StateUp))
+		return len(n.Observe("checks", checkStatus(checks.StateDown)))
+	}
+	if got := flap(); got != 1 {
+		t.Fatalf("first transition fired %d events, want 1", got)
+	}
+	now = now.Add(10 * time.Minute)
+	if got := flap(); got != 0 {
+		t.Errorf("transition within the cooldown fired %d events, want 0", got)
+	}
+	now = now.Add(time.Hour)
+	if got := flap(); got != 1 {
+		t.Errorf("transition after the cooldown fired %d events, want 1", got)
+	}
+}
+
+func TestObserve_Thresholds(t *testing.T) {
+	n := New([]Rule{
+		{Name: "budget", Event: EventBillingBudget},
+		{Name: "window", Event: EventClaudeWindow, Threshold: 90},
+		{Name: "cluster", Event: EventK8sCritical},
+	}, nil)
+
+	ev := n.Observe("billing", &billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{Name: "hetzner", MonthToDate: 55, BudgetUSD: 50, BudgetPercent: 110},
+			{Name: "civo", MonthToDate: 10, BudgetUSD: 50, BudgetPercent: 20},
+			{Name: "vultr", MonthToDate: 500}, // no budget
+		},
+	})
+	if len(ev) != 1 || ev[0].Subject != "hetzner" || ev[0].Threshold != DefaultBudgetThreshold || ev[0].Value != 110 {
+		t.Errorf("billing events = %+v, want hetzner over the default 100%%", ev)
+	}
+
+	ev = n.Observe("claude", &claude.UsageReport{Accounts: []claude.AccountUsage{
+		{Name: "work", Plan: &claude.PlanUsage{FiveHour: &claude.PlanWindow{Utilization: 95}}},
+		{Name: "home", Plan: &claude.PlanUsage{FiveHour: &claude.PlanWindow{Utilization: 85}}},
+		{Name: "api"},
+	}})
+	if len(ev) != 1 || ev[0].Subject != "work" || !strings.Contains(ev[0].Title, "95%") {
+		t.Errorf("claude events = %+v, want work over 90%%", ev)
+	}
+
+	ev = n.Observe("k8s", &k8s.ClusterStatus{Clusters: []k8s.ClusterInfo{
+		{Context: "prod", Health: k8s.HealthCritical, HealthReasons: []string{"2 nodes not ready"}},
+		{Context: "dev", Health: k8s.HealthOK},
+	}})
+	if len(ev) != 1 || ev[0].Subject != "prod" || !strings.Contains(ev[0].Message, "2 nodes not ready") {
+		t.Errorf("k8s events = %+v, want prod with its reason", ev)
+	}
+
+	if ev := n.Observe("billing", map[string]int{"unexpected": 1}); len(ev) != 0 {
+		t.Errorf("unexpected data = %+v, want no events", ev)
+	}
+}
+
+func TestObserve_BillingAnomaly(t *testing.T) {
+	n := New([]Rule{{Name: "spend", Event: EventBillingAnomaly}}, nil)
+
+	ev := n.Observe("billing", &billing.BillingReport{
+		Currency: "USD",
+		Providers: []billing.ProviderBilling{
+			{Name: "digitalocean", Connected: true, Anomaly: &billing.SpendAnomaly{Spend: 6.2, Baseline: 2, Factor: 3.1}},
+			{Name: "civo", Connected: true},
+			{Name: "vultr", Anomaly: &billing.SpendAnomaly{Spend: 9, Baseline: 1, Factor: 9}}, // disconnected
+		},
+	})
+	if len(ev) != 1 || ev[0].Subject != "digitalocean" {
+		t.Fatalf("events = %+v, want one for digitalocean", ev)
+	}
+	if e := ev[0]; e.Level != LevelWarn || e.Title != "Billing digitalocean spend 3.1× usual" || !strings.Contains(e.Message, "$6.20 today against a usual $2.00") {
+		t.Errorf("event = %+v, want a warning naming the spend and the baseline", e)
+	}
+}
+
+func TestSetRules_KeepsState(t *testing.T) {
+	n := New([]Rule{{Name: "down", Event: EventCheckDown}, {Name: "other", Event: EventCheckDown}}, nil)
+	n.Observe("checks", checkStatus(checks.StateDown))
+
+	n.SetRules([]Rule{{Name: "down", Event: EventCheckDown}, {Name: "new", Event: EventCheckDown}}, nil)
+	ev := n.Observe("checks", checkStatus(checks.StateDown))
+	if len(ev) != 1 || ev[0].Rule != "new" {
+		t.Errorf("after SetRules = %+v, want only the new rule to fire", ev)
+	}
+}
+
+func TestDeliver_RoutesToRuleSinks(t *testing.T) {
+	desk := &recordSink{name: "desk"}
+	hook := &recordSink{name: "hook", err: errors.New("503")}
+	n := New([]Rule{
+		{Name: "down", Event: EventCheckDown, Sinks: []string{"desk"}},
+		{Name: "all", Event: EventCheckDown},
+	}, []Sink{desk, hook})
+
+	err := n.Deliver(context.Background(), n.Observe("checks", checkStatus(checks.StateDown)))
+	if err == nil || !strings.Contains(err.Error(), "all to hook: 503") {
+		t.Errorf("Deliver error = %v, want the hook failure", err)
+	}
+	if len(desk.events) != 2 || len(hook.events) != 1 || hook.events[0].Rule != "all" {
+		t.Errorf("desk got %d, hook got %+v; want 2 and the all rule only", len(desk.events), hook.events)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got Event
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("payload is not JSON: %v", err)
+		}
+		if got.Subject == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	s := &WebhookSink{SinkName: "hook", URL: srv.URL}
+	if err := s.Send(context.Background(), TestEvent()); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if contentType != "application/json" || got.Kind != EventTest || got.Title == "" {
+		t.Errorf("received %q %+v, want the test event as JSON", contentType, got)
+	}
+
+	if err := s.Send(context.Background(), Event{Subject: "fail"}); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Send() to a failing hook = %v, want a 502 error", err)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "event")
+	s := &CommandSink{SinkName: "cmd", Command: `cat > "$OUT"; echo "$PROMPT_PULSE_EVENT $PROMPT_PULSE_SUBJECT" >> "$OUT"`}
+	t.Setenv("OUT", out)
+
+	ev := Event{Rule: "down", Kind: EventCheckDown, Subject: "router", Title: "Check down: router"}
+	if err := s.Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"subject":"router"`) || !strings.HasSuffix(string(data), "check_down router\n") {
+		t.Errorf("command saw %q, want the event JSON and environment", data)
+	}
+
+	fail := &CommandSink{SinkName: "cmd", Command: "echo nope >&2; exit 3"}
+	if err := fail.Send(context.Background(), ev); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("failing command = %v, want its stderr in the error", err)
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	ev := Event{Title: `Check "down"`, Message: "router is down"}
+
+	name, args, err := ntDesktopCommand("linux", ev)
+	if err != nil || name != "notify-send" || args[len(args)-2] != ev.Title || args[len(args)-1] != ev.Message {
+		t.Errorf("linux = %s %q, %v", name, args, err)
+	}
+	name, args, err = ntDesktopCommand("darwin", ev)
+	want := `display notification "router is down" with title "Check \"down\""`
+	if err != nil || name != "osascript" || args[1] != want {
+		t.Errorf("darwin = %s %q, %v; want script %q", name, args, err, want)
+	}
+	if _, _, err := ntDesktopCommand("windows", ev); err == nil {
+		t.Error("windows should be unsupported")
+	}
+}
+
+func TestSinksFromConfig(t *testing.T) {
+	sinks, err := SinksFromConfig(config.NotificationsConfig{Sinks: []config.NotificationSinkConfig{
+		{Name: "desk", Type: "desktop"},
+		{Name: "hook", Type: "webhook", URL: "https://example.com", Timeout: config.Duration{Duration: 3 * time.Second}},
+		{Name: "cmd", Type: "command", Command: "true"},
+	}})
+	if err != nil {
+		t.Fatalf("SinksFromConfig() error: %v", err)
+	}
+	if len(sinks) != 3 || sinks[1].(*WebhookSink).Timeout != 3*time.Second || sinks[2].(*CommandSink).Timeout != DefaultSinkTimeout {
+		t.Errorf("sinks = %+v", sinks)
+	}
+	if _, err := SinksFromConfig(config.NotificationsConfig{Sinks: []config.NotificationSinkConfig{{Name: "x", Type: "pager"}}}); err == nil {
+		t.Error("unknown sink type should fail")
+	}
+}
@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+// DefaultSinkTimeout bounds one delivery when a sink sets no timeout.
+const DefaultSinkTimeout = 10 * time.Second
+
+// Sink delivers events to one destination.
+type Sink interface {
+	// Name labels the sink for rules and error messages.
+	Name() string
+
+	// Send delivers ev.
+	Send(ctx context.Context, ev Event) error
+}
+
+// SinksFromConfig builds every sink configured in nc.
+func SinksFromConfig(nc config.NotificationsConfig) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(nc.Sinks))
+	for _, s := range nc.Sinks {
+		timeout := s.Timeout.Duration
+		if timeout <= 0 {
+			timeout = DefaultSinkTimeout
+		}
+		switch s.Type {
+		case "desktop":
+			sinks = append(sinks, &DesktopSink{SinkName: s.Name, Timeout: timeout})
+		case "webhook":
+			sinks = append(sinks, &WebhookSink{SinkName: s.Name, URL: s.URL, Timeout: timeout})
+		case "command":
+			sinks = append(sinks, &CommandSink{SinkName: s.Name, Command: s.Command, Timeout: timeout})
+		default:
+			return nil, fmt.Errorf("notify: sink %s: unknown type %q", s.Name, s.Type)
+		}
+	}
+	return sinks, nil
+}
+
+// DesktopSink shows events as desktop notifications, with notify-send on
+// Linux and the BSDs and osascript on macOS.
+type DesktopSink struct {
+	SinkName string
+	Timeout  time.Duration
+
+	// GOOS selects the notifier. Empty uses runtime.GOOS.
+	GOOS string
+}
+
+// Name returns the sink name.
+func (s *DesktopSink) Name() string { return s.SinkName }
+
+// Send shows ev as a desktop notification.
+func (s *DesktopSink) Send(ctx context.Context, ev Event) error {
+	goos := s.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	name, args, err := ntDesktopCommand(goos, ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := ntTimeout(ctx, s.Timeout)
+	defer cancel()
+	return ntRun(exec.CommandContext(ctx, name, args...), nil)
+}
+
+// ntDesktopCommand returns the command that shows ev on goos.
+func ntDesktopCommand(goos string, ev Event) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", ntAppleScriptString(ev.Message), ntAppleScriptString(ev.Title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=prompt-pulse", ev.Title, ev.Message}, nil
+	}
+	return "", nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+}
+
+// ntAppleScriptString quotes s as an AppleScript string literal.
+func ntAppleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// WebhookSink POSTs each event as JSON to URL.
+type WebhookSink struct {
+	SinkName string
+	URL      string
+	Timeout  time.Duration
+
+	// Client sends the request. Nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+// Name returns the sink name.
+func (s *WebhookSink) Name() string { return s.SinkName }
+
+// Send POSTs ev. Any status other than 2xx is an error.
+func (s *WebhookSink) Send(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := ntTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "prompt-pulse")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// CommandSink runs Command with "sh -c" for each event. The event JSON is
+// on stdin, and $PROMPT_PULSE_EVENT, $PROMPT_PULSE_RULE,
+// $PROMPT_PULSE_SUBJECT, $PROMPT_PULSE_TITLE, and $PROMPT_PULSE_MESSAGE
+// hold its fields.
+type CommandSink struct {
+	SinkName string
+	Command  string
+	Timeout  time.Duration
+}
+
+// Name returns the sink name.
+func (s *CommandSink) Name() string { return s.SinkName }
+
+// Send runs the command for ev. A non-zero exit is an error.
+func (s *CommandSink) Send(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := ntTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.Command)
+	cmd.Env = append(os.Environ(),
+		"PROMPT_PULSE_EVENT="+ev.Kind,
+		"PROMPT_PULSE_RULE="+ev.Rule,
+		"PROMPT_PULSE_SUBJECT="+ev.Subject,
+		"PROMPT_PULSE_TITLE="+ev.Title,
+		"PROMPT_PULSE_MESSAGE="+ev.Message,
+	)
+	return ntRun(cmd, body)
+}
+
+// ntTimeout bounds ctx by d, or DefaultSinkTimeout when d is zero.
+func ntTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		d = DefaultSinkTimeout
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// ntRun runs cmd with stdin, folding its output into the error on failure.
+func ntRun(cmd *exec.Cmd, stdin []byte) error {
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package perf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/perfval"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/starship"
+)
+
+// pfWriteClaudeCache writes a claude cache entry the size of a busy
+// multi-account setup to dir, with its summary when summary is set, and
+// returns a starship config rendering only the Claude segment from it.
+func pfWriteClaudeCache(tb testing.TB, dir string, summary bool) starship.Config {
+	tb.Helper()
+	now := time.Now()
+	report := &claude.UsageReport{Timestamp: now}
+	for a := 0; a < 3; a++ {
+		acct := claude.AccountUsage{
+			Name:         fmt.Sprintf("account-%d", a),
+			Connected:    true,
+			CurrentMonth: claude.MonthUsage{InputTokens: 4_000_000, OutputTokens: 900_000, CostUSD: 42.5},
+			Window:       &claude.WindowUsage{Tokens: 120_000},
+			Forecast:     &claude.Forecast{Limit: 500_000, LimitAt: now.Add(90 * time.Minute)},
+		}
+		for m := 0; m < 12; m++ {
+			acct.Models = append(acct.Models, claude.ModelUsage{
+				Model: fmt.Sprintf("claude-sonnet-4-%d", m), InputTokens: 300_000, OutputTokens: 70_000, CostUSD: float64(m),
+			})
+		}
+		for s := 0; s < 200; s++ {
+			acct.Sessions = append(acct.Sessions, claude.SessionUsage{
+				ID: fmt.Sprintf("session-%04d", s), Project: "/home/dev/src/prompt-pulse", Model: "claude-sonnet-4-5",
+				InputTokens: 12_000, OutputTokens: 3_000, CostUSD: 0.08, LastActivity: now.Add(-time.Duration(s) * time.Minute),
+			})
+		}
+		report.Accounts = append(report.Accounts, acct)
+	}
+
+	write := func(name string, v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	write("claude.json", report)
+	if summary {
+		write("claude.summary.json", claude.NewSummary(report))
+	}
+	return starship.Config{ShowClaude: true, CacheDir: dir, MaxWidth: 60}
+}
+
+// BenchmarkStarshipClaudeFull benchmarks the Claude prompt segment decoding
+// the full cache entry, as it does when the daemon wrote no summary.
+func BenchmarkStarshipClaudeFull(b *testing.B) {
+	cfg := pfWriteClaudeCache(b, b.TempDir(), false)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = starship.Render(cfg)
+	}
+}
+
+// BenchmarkStarshipClaudeSummary benchmarks the Claude prompt segment
+// reading the summary the daemon writes beside the cache entry.
+func BenchmarkStarshipClaudeSummary(b *testing.B) {
+	cfg := pfWriteClaudeCache(b, b.TempDir(), true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = starship.Render(cfg)
+	}
+}
+
+// TestStarshipClaudeSummaryCacheRead checks that the summary read path
+// allocates less than decoding the full entry and fits the perfval
+// cache_read target.
+func TestStarshipClaudeSummaryCacheRead(t *testing.T) {
+	full := pfWriteClaudeCache(t, t.TempDir(), false)
+	summary := pfWriteClaudeCache(t, t.TempDir(), true)
+	if a, b := starship.Render(full), starship.Render(summary); a != b {
+		t.Fatalf("summary renders %q, full entry %q", b, a)
+	}
+
+	fullAllocs := testing.AllocsPerRun(20, func() { starship.Render(full) })
+	summaryAllocs := testing.AllocsPerRun(20, func() { starship.Render(summary) })
+	if summaryAllocs*4 > fullAllocs {
+		t.Errorf("summary read allocs = %.0f, full read %.0f; want under a quarter", summaryAllocs, fullAllocs)
+	}
+
+	var target perfval.Target
+	for _, tgt := range perfval.DefaultTargets() {
+		if tgt.Name == "cache_read" {
+			target = tgt
+		}
+	}
+	res := perfval.ValidateTarget(target, func() error {
+		starship.Render(summary)
+		return nil
+	}, 50)
+	if !res.Passed {
+		t.Errorf("summary read p95 %v, budget %v", res.Actual, res.Budget)
+	}
+}
@@ -108,6 +108,19 @@ func BenchmarkImageSharpen(b *testing.B) {
 	}
 }
 
+// BenchmarkImageResize1200To300 benchmarks ResizeToFit shrinking a
+// 1200x1200 image to 300x300 pixels, a photo-sized source for a small pane,
+// where the resample and fused sharpen dominate.
+func BenchmarkImageResize1200To300(b *testing.B) {
+	src := pfMakeTestImage(1200, 1200)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ppimage.ResizeToFit(src, 30, 15, 10, 20)
+	}
+}
+
 // BenchmarkHalfblockRender benchmarks the halfblock protocol rendering path
 // for a 160x120 pixel image via the full Renderer.Render path.
 func BenchmarkHalfblockRender(b *testing.B) {
@@ -124,6 +137,20 @@ func BenchmarkHalfblockRender(b *testing.B) {
 	}
 }
 
+// BenchmarkImagePlaceholder benchmarks the placeholder a TUI shows while a
+// 1200x1200 image renders in the background, drawn into a 40x20 pane. It
+// must stay under 2ms to be worth showing first (see DefaultThresholds).
+func BenchmarkImagePlaceholder(b *testing.B) {
+	src := pfMakeTestImage(1200, 1200)
+	r := ppimage.NewRenderer(pfMakeTestCaps(terminal.ProtocolKitty), pfMakeTestImageCfg())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = r.RenderPlaceholder(src, 40, 20)
+	}
+}
+
 // BenchmarkKittyTransmit benchmarks Kitty-style ZLIB compression and base64
 // encoding on raw RGBA pixel data, exercised through the image cache
 // machinery.
@@ -16,6 +16,7 @@ func pfMakeShellOptions() shell.Options {
 		ShowBanner:        true,
 		DaemonAutoStart:   true,
 		EnableCompletions: true,
+		ActivityDir:       "/home/user/.local/state/prompt-pulse/shell-activity",
 	}
 }
 
@@ -448,6 +448,13 @@ func TestBenchmarkSelectPresetSmoke(t *testing.T) {
 	}
 }
 
+func TestBenchmarkImagePlaceholderSmoke(t *testing.T) {
+	result := testing.Benchmark(BenchmarkImagePlaceholder)
+	if result.N == 0 {
+		t.Error("BenchmarkImagePlaceholder did not run")
+	}
+}
+
 // --- pfMakeSolidImage test --------------------------------------------------
 
 func TestPfMakeSolidImage(t *testing.T) {
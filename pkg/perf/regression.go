@@ -39,7 +39,9 @@ type Violation struct {
 //   - layout_6widget < 5ms: constraint solver for typical dashboard
 //   - shell_generate < 1ms: string concatenation only
 //   - starship_render < 20ms: segment assembly with cache reads
+//   - cache_read < 1ms: prompt segment from a collector's summary sidecar
 //   - component_gauge < 100us: single gauge bar render
+//   - image_placeholder < 2ms: shown while the real render is in flight
 func DefaultThresholds() []Threshold {
 	return []Threshold{
 		{Name: "banner_cached", MaxNs: 1_000_000, MaxAlloc: 16384},
@@ -49,6 +51,7 @@ func DefaultThresholds() []Threshold {
 		{Name: "layout_cache_hit", MaxNs: 500_000, MaxAlloc: 8192},
 		{Name: "shell_generate", MaxNs: 1_000_000, MaxAlloc: 16384},
 		{Name: "starship_render", MaxNs: 20_000_000, MaxAlloc: 262144},
+		{Name: "cache_read", MaxNs: 1_000_000, MaxAlloc: 16384},
 		{Name: "component_gauge", MaxNs: 100_000, MaxAlloc: 8192},
 		{Name: "component_sparkline", MaxNs: 200_000, MaxAlloc: 16384},
 		{Name: "component_box", MaxNs: 500_000, MaxAlloc: 32768},
@@ -56,6 +59,7 @@ func DefaultThresholds() []Threshold {
 		{Name: "text_truncate", MaxNs: 50_000, MaxAlloc: 4096},
 		{Name: "visible_len", MaxNs: 50_000, MaxAlloc: 2048},
 		{Name: "image_resize", MaxNs: 500_000_000, MaxAlloc: 33_554_432},
+		{Name: "image_placeholder", MaxNs: 2_000_000, MaxAlloc: 262144},
 	}
 }
 
@@ -0,0 +1,246 @@
+package perfval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pvExecTimeout bounds a single run of a TargetExec command when
+// TargetExec.Timeout is zero.
+const pvExecTimeout = 10 * time.Second
+
+// TargetExec defines a performance budget measured by running a command,
+// so the measurement includes process startup (fork/exec, runtime init,
+// config loading) as a user's prompt sees it, rather than only the
+// in-process work a Target closure covers.
+type TargetExec struct {
+	// Target names the measurement and holds its p95 budget.
+	Target
+
+	// Path is the binary to run. A bare name is looked up in PATH.
+	Path string
+
+	// Args are passed to the binary, after "-config ConfigPath".
+	Args []string
+
+	// ConfigPath is the config file passed with -config. When empty, a
+	// config setting only general.cache_dir to CacheDir is generated.
+	ConfigPath string
+
+	// CacheDir is the collector cache the command reads. Populate it
+	// before validating to measure the warm-cache path.
+	CacheDir string
+
+	// Env holds extra "KEY=value" entries for the command's environment.
+	Env []string
+
+	// Timeout bounds a single run. Zero uses 10 seconds.
+	Timeout time.Duration
+}
+
+// DefaultExecTargets returns startup-latency budgets for the prompt-pulse
+// binary at binary, reading the warm collector cache in cacheDir. They
+// cover what a user waits for when a new prompt or shell appears.
+func DefaultExecTargets(binary, cacheDir string) []TargetExec {
+	return []TargetExec{
+		{
+			Target: Target{
+				Name:        "starship_exec",
+				MaxDuration: 30 * time.Millisecond,
+				Description: "prompt-pulse -starship claude with a warm cache, including process startup, must complete in under 30ms",
+			},
+			Path:     binary,
+			Args:     []string{"-starship", "claude"},
+			CacheDir: cacheDir,
+		},
+		{
+			Target: Target{
+				Name:        "banner_exec",
+				MaxDuration: 100 * time.Millisecond,
+				Description: "prompt-pulse -banner with a warm cache, including process startup, must complete in under 100ms",
+			},
+			Path:     binary,
+			Args:     []string{"-banner"},
+			CacheDir: cacheDir,
+		},
+	}
+}
+
+// ValidateExec runs target's command samples times after one discarded
+// warmup run, computes the p95 wall-clock latency including process
+// startup, and checks it against the budget. Each run gets an isolated
+// environment: PATH from the caller, plus a temporary HOME and XDG
+// directories so no user config, cache, or PPULSE_* override leaks in.
+//
+// A missing binary yields a skipped result with the reason recorded
+// rather than a failure. A run that exits with an error fails the target.
+func ValidateExec(target TargetExec, samples int) *ValidationResult {
+	result := &ValidationResult{
+		Target: target.Name,
+		Budget: target.MaxDuration,
+		Passed: true,
+		Margin: 1.0,
+	}
+	if samples <= 0 {
+		return result
+	}
+
+	path, err := pvResolveBinary(target.Path)
+	if err != nil {
+		result.Skipped = true
+		result.Reason = err.Error()
+		return result
+	}
+
+	home, err := os.MkdirTemp("", "perfval-home-*")
+	if err != nil {
+		result.Skipped = true
+		result.Reason = fmt.Sprintf("create isolated home: %v", err)
+		return result
+	}
+	defer os.RemoveAll(home)
+
+	args, err := pvExecArgs(target, home)
+	if err != nil {
+		result.Skipped = true
+		result.Reason = err.Error()
+		return result
+	}
+	env := pvExecEnv(home, target.Env)
+
+	timeout := target.Timeout
+	if timeout <= 0 {
+		timeout = pvExecTimeout
+	}
+
+	durations := make([]time.Duration, 0, samples)
+	for i := 0; i <= samples; i++ {
+		d, err := pvRunOnce(path, args, env, timeout)
+		if err != nil {
+			result.Passed = false
+			result.Margin = 0
+			result.Reason = fmt.Sprintf("run %d: %v", i+1, err)
+			result.Samples = len(durations)
+			return result
+		}
+		if i == 0 {
+			continue // warmup: page cache, dynamic loader
+		}
+		durations = append(durations, d)
+	}
+
+	p95 := pvP95(durations)
+	result.Actual = p95
+	result.Passed = p95 <= target.MaxDuration
+	result.Samples = samples
+	if target.MaxDuration > 0 {
+		result.Margin = float64(target.MaxDuration-p95) / float64(target.MaxDuration)
+	} else {
+		result.Margin = 0
+	}
+	return result
+}
+
+// ValidateAllExec runs ValidateExec for every target and collects the
+// results into a ValidationReport. Skipped targets do not fail the report.
+func ValidateAllExec(targets []TargetExec, samples int) *ValidationReport {
+	report := &ValidationReport{
+		Timestamp: time.Now(),
+		AllPassed: true,
+	}
+	for _, t := range targets {
+		result := ValidateExec(t, samples)
+		report.Results = append(report.Results, *result)
+		if !result.Passed {
+			report.AllPassed = false
+		}
+	}
+	return report
+}
+
+// pvResolveBinary returns the path of the binary to run, or an error
+// explaining why it cannot be run.
+func pvResolveBinary(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("no binary path configured")
+	}
+	if !strings.ContainsRune(path, filepath.Separator) {
+		p, err := exec.LookPath(path)
+		if err != nil {
+			return "", fmt.Errorf("binary %q not found in PATH", path)
+		}
+		return p, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("binary %s does not exist", path)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("binary %s is a directory", path)
+	}
+	return path, nil
+}
+
+// pvExecArgs returns the command arguments for target, writing a config
+// pointing at target.CacheDir into home when no ConfigPath is given.
+func pvExecArgs(target TargetExec, home string) ([]string, error) {
+	config := target.ConfigPath
+	if config == "" {
+		config = filepath.Join(home, "config.toml")
+		body := "[general]\ncache_dir = " + strconv.Quote(target.CacheDir) + "\n"
+		if target.CacheDir == "" {
+			body = ""
+		}
+		if err := os.WriteFile(config, []byte(body), 0o600); err != nil {
+			return nil, fmt.Errorf("write isolated config: %w", err)
+		}
+	}
+	return append([]string{"-config", config}, target.Args...), nil
+}
+
+// pvExecEnv builds the environment for a run: the caller's PATH, HOME and
+// the XDG directories under home, then extra.
+func pvExecEnv(home string, extra []string) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + home,
+		"XDG_CONFIG_HOME=" + filepath.Join(home, ".config"),
+		"XDG_CACHE_HOME=" + filepath.Join(home, ".cache"),
+		"XDG_DATA_HOME=" + filepath.Join(home, ".local", "share"),
+	}
+	return append(env, extra...)
+}
+
+// pvRunOnce runs the command once and returns its wall-clock duration from
+// start to exit. Output is discarded; stderr is kept for error messages.
+func pvRunOnce(path string, args, env []string, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = env
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("timed out after %v", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return 0, fmt.Errorf("%w: %s", err, msg)
+		}
+		return 0, err
+	}
+	return elapsed, nil
+}
@@ -3,6 +3,8 @@ package perfval
 import (
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -1065,3 +1067,109 @@ func TestReportExecutiveSummarySections(t *testing.T) {
 		t.Error("report with leak should contain LEAK DETECTED")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Exec target tests
+// ---------------------------------------------------------------------------
+
+// TestMain lets the test binary stand in for prompt-pulse in exec target
+// tests: with PERFVAL_HELPER set it checks its isolated environment and
+// exits instead of running the tests.
+func TestMain(m *testing.M) {
+	switch os.Getenv("PERFVAL_HELPER") {
+	case "":
+		os.Exit(m.Run())
+	case "fail":
+		fmt.Fprintln(os.Stderr, "cache unreadable")
+		os.Exit(2)
+	}
+	// Expect "-config <path>" and a generated config naming the cache dir.
+	if len(os.Args) < 3 || os.Args[1] != "-config" {
+		fmt.Fprintf(os.Stderr, "args = %q\n", os.Args[1:])
+		os.Exit(1)
+	}
+	body, err := os.ReadFile(os.Args[2])
+	if err != nil || !strings.Contains(string(body), os.Getenv("PERFVAL_WANT_CACHE")) {
+		fmt.Fprintf(os.Stderr, "config = %q, err = %v\n", body, err)
+		os.Exit(1)
+	}
+	if os.Getenv("PPULSE_THEME") != "" || !strings.Contains(os.Getenv("HOME"), "perfval-home-") {
+		fmt.Fprintln(os.Stderr, "environment not isolated")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestValidateExecMeasuresBinary(t *testing.T) {
+	t.Setenv("PPULSE_THEME", "leaked")
+	cacheDir := t.TempDir()
+	target := TargetExec{
+		Target:   Target{Name: "starship_exec", MaxDuration: time.Minute},
+		Path:     os.Args[0],
+		Args:     []string{"-starship", "claude"},
+		CacheDir: cacheDir,
+		Env:      []string{"PERFVAL_HELPER=ok", "PERFVAL_WANT_CACHE=" + cacheDir},
+	}
+
+	r := ValidateExec(target, 3)
+	if r.Skipped || !r.Passed || r.Reason != "" {
+		t.Fatalf("result = %+v, want a passing measurement", r)
+	}
+	if r.Samples != 3 || r.Actual <= 0 || r.Budget != time.Minute {
+		t.Errorf("result = %+v, want 3 samples with a positive p95", r)
+	}
+}
+
+func TestValidateExecRunFailure(t *testing.T) {
+	target := TargetExec{
+		Target: Target{Name: "broken", MaxDuration: time.Minute},
+		Path:   os.Args[0],
+		Env:    []string{"PERFVAL_HELPER=fail"},
+	}
+	r := ValidateExec(target, 2)
+	if r.Passed || r.Skipped {
+		t.Fatalf("result = %+v, want a failure", r)
+	}
+	if !strings.Contains(r.Reason, "run 1") || !strings.Contains(r.Reason, "cache unreadable") {
+		t.Errorf("Reason = %q, want the failing run and its stderr", r.Reason)
+	}
+}
+
+func TestValidateAllExecSkipsMissingBinary(t *testing.T) {
+	report := ValidateAllExec([]TargetExec{{
+		Target: Target{Name: "startup", MaxDuration: 20 * time.Millisecond},
+		Path:   filepath.Join(t.TempDir(), "prompt-pulse"),
+	}}, 5)
+	if !report.AllPassed || len(report.Results) != 1 {
+		t.Fatalf("report = %+v, want one skipped result that does not fail", report)
+	}
+	r := report.Results[0]
+	if !r.Skipped || !strings.Contains(r.Reason, "does not exist") {
+		t.Errorf("result = %+v, want skipped with the reason", r)
+	}
+
+	output, err := GenerateReport(&PerfReport{Targets: report, Platform: *pvDetectPlatform()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| startup | 20.0ms |", "SKIP", "Targets skipped: 1", "- startup: binary"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("report should contain %q", want)
+		}
+	}
+}
+
+func TestDefaultExecTargets(t *testing.T) {
+	targets := DefaultExecTargets("/usr/bin/prompt-pulse", "/tmp/cache")
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 exec targets, got %d", len(targets))
+	}
+	for _, tgt := range targets {
+		if tgt.Path != "/usr/bin/prompt-pulse" || tgt.CacheDir != "/tmp/cache" {
+			t.Errorf("%s: path/cache = %q/%q", tgt.Name, tgt.Path, tgt.CacheDir)
+		}
+		if tgt.MaxDuration <= 0 || tgt.Description == "" || len(tgt.Args) == 0 {
+			t.Errorf("%s: incomplete target %+v", tgt.Name, tgt)
+		}
+	}
+}
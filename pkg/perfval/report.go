@@ -85,6 +85,7 @@ func GenerateReport(report *PerfReport) (string, error) {
 		sb.WriteString("## Target Validation\n\n")
 		sb.WriteString(pvRenderTargetTable(report.Targets.Results))
 		sb.WriteString("\n")
+		pvWriteTargetNotes(&sb, report.Targets.Results)
 	}
 
 	// Soak Test Results
@@ -121,10 +122,13 @@ func GenerateReport(report *PerfReport) (string, error) {
 func pvWriteExecutiveSummary(sb *strings.Builder, report *PerfReport) {
 	passed := 0
 	failed := 0
+	skipped := 0
 
 	if report.Targets != nil {
 		for _, r := range report.Targets.Results {
-			if r.Passed {
+			if r.Skipped {
+				skipped++
+			} else if r.Passed {
 				passed++
 			} else {
 				failed++
@@ -138,6 +142,9 @@ func pvWriteExecutiveSummary(sb *strings.Builder, report *PerfReport) {
 		sb.WriteString(fmt.Sprintf("**Status: FAIL** - %d of %d targets exceeded budget.\n\n",
 			failed, passed+failed))
 	}
+	if skipped > 0 {
+		sb.WriteString(fmt.Sprintf("- Targets skipped: %d\n", skipped))
+	}
 
 	if report.Soak != nil {
 		if report.Soak.Stable {
@@ -178,7 +185,10 @@ func pvRenderTargetTable(results []ValidationResult) string {
 
 	for _, r := range results {
 		status := "PASS"
-		if !r.Passed {
+		switch {
+		case r.Skipped:
+			status = "SKIP"
+		case !r.Passed:
 			status = "FAIL"
 		}
 		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %+.1f%% | %s |\n",
@@ -193,9 +203,29 @@ func pvRenderTargetTable(results []ValidationResult) string {
 	return sb.String()
 }
 
-// pvTargetBudget looks up the budget for a validation result by matching
-// against the default targets. Returns 0 if not found.
+// pvWriteTargetNotes lists the reasons targets were skipped or failed to
+// run.
+func pvWriteTargetNotes(sb *strings.Builder, results []ValidationResult) {
+	wrote := false
+	for _, r := range results {
+		if r.Reason == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", r.Target, r.Reason))
+		wrote = true
+	}
+	if wrote {
+		sb.WriteString("\n")
+	}
+}
+
+// pvTargetBudget returns the budget recorded in a validation result, or
+// looks it up by matching against the default targets. Returns 0 if not
+// found.
 func pvTargetBudget(r ValidationResult) time.Duration {
+	if r.Budget > 0 {
+		return r.Budget
+	}
 	for _, t := range DefaultTargets() {
 		if t.Name == r.Target {
 			return t.MaxDuration
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"os"
 	"runtime"
 	"strings"
 	"testing"
@@ -527,3 +528,117 @@ func TestPlatformConstants(t *testing.T) {
 		t.Errorf("Linux = %q, want linux", Linux)
 	}
 }
+
+// --- Test 31: NewServiceFile picks the manager and path by platform ---
+
+func TestNewServiceFile(t *testing.T) {
+	cfg := ServiceConfig{BinaryPath: "/usr/bin/prompt-pulse", ConfigPath: "/home/u/.config/prompt-pulse/config.toml"}
+
+	f, err := NewServiceFile(Linux, "/home/u", cfg)
+	if err != nil || f.Manager != Systemd || f.Path != "/home/u/.config/systemd/user/prompt-pulse.service" {
+		t.Errorf("linux = %+v, %v", f, err)
+	}
+	f, err = NewServiceFile(Darwin, "/Users/u", cfg)
+	if err != nil || f.Manager != Launchd || f.Path != "/Users/u/Library/LaunchAgents/com.tinyland.prompt-pulse.plist" {
+		t.Errorf("darwin = %+v, %v", f, err)
+	}
+	if !strings.Contains(f.Content, "<string>/Users/u/Library/Logs/prompt-pulse.log</string>") {
+		t.Error("launchd plist should default its log path under ~/Library/Logs")
+	}
+	if _, err := NewServiceFile("windows", `C:\Users\u`, cfg); err == nil {
+		t.Error("windows should be unsupported")
+	}
+}
+
+// --- Test 32: SystemdUnit runs the daemon with the config, hardened ---
+
+func TestSystemdUnitExecStart(t *testing.T) {
+	unit := SystemdUnit(ServiceConfig{BinaryPath: "/opt/my tools/prompt-pulse", ConfigPath: "/etc/pp%1.toml"})
+	if !strings.Contains(unit, "ExecStart=\"/opt/my tools/prompt-pulse\" -daemon -config /etc/pp%%1.toml\n") {
+		t.Errorf("ExecStart not quoted and escaped:\n%s", unit)
+	}
+	for _, want := range []string{"Restart=on-failure", "NoNewPrivileges=yes", "WantedBy=default.target"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q", want)
+		}
+	}
+	if strings.Contains(unit, "StandardOutput") {
+		t.Error("unit without a log path should log to the journal")
+	}
+	if unit := SystemdUnit(ServiceConfig{BinaryPath: "/usr/bin/prompt-pulse"}); !strings.Contains(unit, "ExecStart=/usr/bin/prompt-pulse -daemon\n") {
+		t.Errorf("unit without a config path should not pass -config:\n%s", unit)
+	}
+}
+
+// --- Test 33: LaunchdPlist escapes its arguments ---
+
+func TestLaunchdPlistArguments(t *testing.T) {
+	plist := LaunchdPlist(ServiceConfig{BinaryPath: "/Applications/A&B/prompt-pulse", ConfigPath: "/Users/u/pp.toml"})
+	want := "\t\t<string>/Applications/A&amp;B/prompt-pulse</string>\n\t\t<string>-daemon</string>\n\t\t<string>-config</string>\n\t\t<string>/Users/u/pp.toml</string>\n"
+	if !strings.Contains(plist, want) {
+		t.Errorf("plist arguments not as expected:\n%s", plist)
+	}
+	if !strings.Contains(plist, "<key>SuccessfulExit</key>") {
+		t.Error("plist should restart the daemon only after abnormal exits")
+	}
+}
+
+// --- Test 34: Install, status, and uninstall drive the service manager ---
+
+func TestServiceLifecycle(t *testing.T) {
+	var calls []string
+	show := "LoadState=loaded\nActiveState=active\nSubState=running\nMainPID=4242\n"
+	orig := plRunCommand
+	plRunCommand = func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		if len(args) > 1 && args[1] == "show" {
+			return []byte(show), nil
+		}
+		return nil, nil
+	}
+	defer func() { plRunCommand = orig }()
+
+	f, _ := NewServiceFile(Linux, t.TempDir(), ServiceConfig{BinaryPath: "/usr/bin/prompt-pulse"})
+	if err := InstallService(f); err != nil {
+		t.Fatalf("InstallService() error: %v", err)
+	}
+	if data, err := os.ReadFile(f.Path); err != nil || string(data) != f.Content {
+		t.Fatalf("unit not written: %v", err)
+	}
+	want := "systemctl --user daemon-reload|systemctl --user enable prompt-pulse.service|systemctl --user restart prompt-pulse.service"
+	if got := strings.Join(calls, "|"); got != want {
+		t.Errorf("install ran %q, want %q", got, want)
+	}
+
+	st, err := QueryServiceStatus(f)
+	if err != nil || !st.Installed || !st.Loaded || !st.Running || st.PID != 4242 || st.Detail != "active (running)" {
+		t.Errorf("status = %+v, %v", st, err)
+	}
+
+	if err := UninstallService(f); err != nil {
+		t.Fatalf("UninstallService() error: %v", err)
+	}
+	if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+		t.Errorf("unit still present after uninstall: %v", err)
+	}
+	show = "LoadState=not-found\nActiveState=inactive\nSubState=dead\nMainPID=0\n"
+	if st, _ := QueryServiceStatus(f); st.Installed || st.Loaded || st.Running || st.Detail != "not-found" {
+		t.Errorf("status after uninstall = %+v", st)
+	}
+}
+
+// --- Test 35: launchctl list output is parsed ---
+
+func TestParseLaunchctlList(t *testing.T) {
+	var st ServiceStatus
+	plParseLaunchctlList("{\n\t\"LimitLoadToSessionType\" = \"Aqua\";\n\t\"Label\" = \"com.tinyland.prompt-pulse\";\n\t\"PID\" = 812;\n\t\"LastExitStatus\" = 0;\n};\n", &st)
+	if !st.Loaded || !st.Running || st.PID != 812 {
+		t.Errorf("running job = %+v", st)
+	}
+
+	st = ServiceStatus{}
+	plParseLaunchctlList("{\n\t\"Label\" = \"com.tinyland.prompt-pulse\";\n\t\"LastExitStatus\" = 256;\n};\n", &st)
+	if st.Running || st.Detail != "not running, last exit status 256" {
+		t.Errorf("exited job = %+v", st)
+	}
+}
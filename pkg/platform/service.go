@@ -0,0 +1,350 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Service managers, as reported in ServiceFile.Manager.
+const (
+	Systemd = "systemd"
+	Launchd = "launchd"
+)
+
+// Service names: the systemd user unit and the launchd job label.
+const (
+	SystemdUnitName = "prompt-pulse.service"
+	LaunchdLabel    = "com.tinyland.prompt-pulse"
+)
+
+// ServiceFile is the generated service definition for the daemon and where
+// it is installed.
+type ServiceFile struct {
+	// Manager is Systemd or Launchd.
+	Manager string
+
+	// Path is where the file is installed: a systemd user unit under
+	// ~/.config/systemd/user or a launchd plist under ~/Library/LaunchAgents.
+	Path string
+
+	// Content is the unit or plist.
+	Content string
+}
+
+// ServiceStatus is what the service manager reports about the daemon.
+type ServiceStatus struct {
+	// Installed is true when the service file exists.
+	Installed bool
+
+	// Loaded is true when the service manager knows the service.
+	Loaded bool
+
+	// Running is true when the daemon process is up.
+	Running bool
+
+	// PID is the daemon's process ID while it runs.
+	PID int
+
+	// Detail is the manager's own state, e.g. "active (running)" or
+	// "last exit status 1".
+	Detail string
+}
+
+// plRunCommand runs a service manager command and returns its output,
+// replaceable in tests.
+var plRunCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// NewServiceFile returns the service definition for p, for the user whose
+// home directory is home. ConfigPath may be empty to use the default
+// config search. Platforms other than Linux and macOS are unsupported.
+func NewServiceFile(p Platform, home string, cfg ServiceConfig) (*ServiceFile, error) {
+	switch p {
+	case Linux:
+		return &ServiceFile{
+			Manager: Systemd,
+			Path:    filepath.Join(home, ".config", "systemd", "user", SystemdUnitName),
+			Content: SystemdUnit(cfg),
+		}, nil
+	case Darwin:
+		if cfg.LogPath == "" {
+			cfg.LogPath = filepath.Join(home, "Library", "Logs", "prompt-pulse.log")
+		}
+		return &ServiceFile{
+			Manager: Launchd,
+			Path:    filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist"),
+			Content: LaunchdPlist(cfg),
+		}, nil
+	}
+	return nil, fmt.Errorf("service files are not supported on %s (only systemd on linux and launchd on darwin)", p)
+}
+
+// SystemdUnit renders a systemd user unit running the daemon. Output goes
+// to the journal unless cfg.LogPath is set. The hardening settings are
+// limited to those that work in a user manager and leave the daemon able
+// to write its cache and run notification commands.
+func SystemdUnit(cfg ServiceConfig) string {
+	var b strings.Builder
+	b.WriteString(`[Unit]
+Description=prompt-pulse status daemon
+Documentation=man:prompt-pulse(1)
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+`)
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(plQuoteAll(plDaemonArgs(cfg), plSystemdQuote), " "))
+	b.WriteString(`ExecReload=/bin/kill -HUP $MAINPID
+Restart=on-failure
+RestartSec=5
+`)
+	if cfg.LogPath != "" {
+		fmt.Fprintf(&b, "StandardOutput=append:%s\n", plSystemdEscape(cfg.LogPath))
+		fmt.Fprintf(&b, "StandardError=append:%s\n", plSystemdEscape(cfg.LogPath))
+	}
+	b.WriteString(`
+# Hardening
+NoNewPrivileges=yes
+LockPersonality=yes
+RestrictRealtime=yes
+RestrictSUIDSGID=yes
+MemoryDenyWriteExecute=yes
+SystemCallArchitectures=native
+UMask=0077
+
+[Install]
+WantedBy=default.target
+`)
+	return b.String()
+}
+
+// LaunchdPlist renders a launchd agent plist running the daemon at login
+// and restarting it if it exits abnormally.
+func LaunchdPlist(cfg ServiceConfig) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + LaunchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range plDaemonArgs(cfg) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", plXMLEscape(arg))
+	}
+	b.WriteString(`	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ProcessType</key>
+	<string>Background</string>
+`)
+	if cfg.LogPath != "" {
+		fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", plXMLEscape(cfg.LogPath))
+		fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", plXMLEscape(cfg.LogPath))
+	}
+	b.WriteString(`	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PATH</key>
+		<string>/usr/local/bin:/opt/homebrew/bin:/usr/bin:/bin:/usr/sbin:/sbin</string>
+	</dict>
+</dict>
+</plist>
+`)
+	return b.String()
+}
+
+// plDaemonArgs returns the command line that runs the daemon.
+func plDaemonArgs(cfg ServiceConfig) []string {
+	args := []string{cfg.BinaryPath, "-daemon"}
+	if cfg.ConfigPath != "" {
+		args = append(args, "-config", cfg.ConfigPath)
+	}
+	return args
+}
+
+// plQuoteAll applies quote to each of args.
+func plQuoteAll(args []string, quote func(string) string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = quote(a)
+	}
+	return out
+}
+
+// plSystemdQuote quotes an ExecStart argument when it holds whitespace or
+// quotes, after escaping the specifiers systemd would expand.
+func plSystemdQuote(s string) string {
+	s = plSystemdEscape(s)
+	if !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// plSystemdEscape escapes "%" specifiers and "$" variables in a unit value.
+func plSystemdEscape(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// plXMLEscape escapes s for XML character data.
+func plXMLEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// InstallService writes f and has its service manager load and start it,
+// replacing any earlier install.
+func InstallService(f *ServiceFile) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(f.Path), err)
+	}
+	if err := os.WriteFile(f.Path, []byte(f.Content), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", f.Path, err)
+	}
+
+	switch f.Manager {
+	case Systemd:
+		if err := plRun("systemctl", "--user", "daemon-reload"); err != nil {
+			return err
+		}
+		// restart, not start, so a reinstall picks up the new unit.
+		if err := plRun("systemctl", "--user", "enable", SystemdUnitName); err != nil {
+			return err
+		}
+		return plRun("systemctl", "--user", "restart", SystemdUnitName)
+	case Launchd:
+		_, _ = plRunCommand("launchctl", "unload", f.Path) // not loaded yet is fine
+		return plRun("launchctl", "load", "-w", f.Path)
+	}
+	return fmt.Errorf("unknown service manager %q", f.Manager)
+}
+
+// UninstallService stops the service, has its manager forget it, and
+// removes f. Stopping a service that is not running is not an error.
+func UninstallService(f *ServiceFile) error {
+	switch f.Manager {
+	case Systemd:
+		_, _ = plRunCommand("systemctl", "--user", "disable", "--now", SystemdUnitName)
+	case Launchd:
+		_, _ = plRunCommand("launchctl", "unload", "-w", f.Path)
+	default:
+		return fmt.Errorf("unknown service manager %q", f.Manager)
+	}
+	if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing %s: %w", f.Path, err)
+	}
+	if f.Manager == Systemd {
+		_, _ = plRunCommand("systemctl", "--user", "daemon-reload")
+	}
+	return nil
+}
+
+// QueryServiceStatus asks the service manager whether the daemon's service
+// is loaded and running.
+func QueryServiceStatus(f *ServiceFile) (*ServiceStatus, error) {
+	st := &ServiceStatus{}
+	if _, err := os.Stat(f.Path); err == nil {
+		st.Installed = true
+	}
+
+	switch f.Manager {
+	case Systemd:
+		out, err := plRunCommand("systemctl", "--user", "show", SystemdUnitName,
+			"--property=LoadState,ActiveState,SubState,MainPID")
+		if err != nil {
+			return nil, fmt.Errorf("systemctl: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		plParseSystemctlShow(string(out), st)
+	case Launchd:
+		// launchctl list fails for a job that is not loaded.
+		out, err := plRunCommand("launchctl", "list", LaunchdLabel)
+		if err != nil {
+			st.Detail = "not loaded"
+			return st, nil
+		}
+		plParseLaunchctlList(string(out), st)
+	default:
+		return nil, fmt.Errorf("unknown service manager %q", f.Manager)
+	}
+	return st, nil
+}
+
+// plParseSystemctlShow fills st from "systemctl show" key=value output.
+func plParseSystemctlShow(out string, st *ServiceStatus) {
+	props := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[k] = v
+		}
+	}
+	st.Loaded = props["LoadState"] == "loaded"
+	st.Running = props["ActiveState"] == "active"
+	if st.Running {
+		st.PID, _ = strconv.Atoi(props["MainPID"])
+	}
+	st.Detail = props["LoadState"]
+	if st.Loaded {
+		st.Detail = fmt.Sprintf("%s (%s)", props["ActiveState"], props["SubState"])
+	}
+}
+
+// plParseLaunchctlList fills st from "launchctl list <label>" output, a
+// dictionary with a "PID" entry while the job runs.
+func plParseLaunchctlList(out string, st *ServiceStatus) {
+	st.Loaded = true
+	exit := ""
+	for _, line := range strings.Split(out, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSuffix(strings.TrimSpace(v), ";")
+		switch strings.Trim(strings.TrimSpace(k), `"`) {
+		case "PID":
+			st.PID, _ = strconv.Atoi(v)
+		case "LastExitStatus":
+			exit = v
+		}
+	}
+	st.Running = st.PID > 0
+	switch {
+	case st.Running:
+		st.Detail = "running"
+	case exit != "":
+		st.Detail = "not running, last exit status " + exit
+	default:
+		st.Detail = "not running"
+	}
+}
+
+// plRun runs a service manager command, folding its output into the error
+// on failure.
+func plRun(name string, args ...string) error {
+	out, err := plRunCommand(name, args...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
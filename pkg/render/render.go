@@ -0,0 +1,205 @@
+// Package render holds the display profile every output path consults:
+// whether to emit ANSI color, whether to draw with Unicode box-drawing,
+// block, and glyph characters, and whether to render images. The banner,
+// TUI, starship module, and components all read Current rather than each
+// deciding for themselves, so one setting changes every display at once.
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Profile describes what the output may contain.
+type Profile struct {
+	// Color allows ANSI color and text attribute escape sequences.
+	Color bool
+
+	// Unicode allows box-drawing, block, and glyph characters. Without it
+	// output is plain ASCII.
+	Unicode bool
+
+	// Images allows terminal graphics protocols.
+	Images bool
+}
+
+var (
+	// Full is the default profile for an interactive terminal.
+	Full = Profile{Color: true, Unicode: true, Images: true}
+
+	// Plain is the accessibility profile for dumb terminals, logs, and
+	// pipes: ASCII only, with no escape sequences or images.
+	Plain = Profile{}
+)
+
+// Current is the profile in effect. It defaults to Full.
+var Current = Full
+
+// SetCurrent makes p the profile in effect.
+func SetCurrent(p Profile) {
+	Current = p
+}
+
+// Detect chooses the profile for the running process. Plain is used when
+// ascii is set (display.ascii in config), when stdout is not a terminal,
+// or when TERM is "dumb". NO_COLOR only turns color off, following
+// no-color.org: the terminal can still draw Unicode and images.
+func Detect(ascii, tty bool, getenv func(string) string) Profile {
+	if ascii || !tty || getenv("TERM") == "dumb" {
+		return Plain
+	}
+	p := Full
+	if getenv("NO_COLOR") != "" {
+		p.Color = false
+	}
+	return p
+}
+
+// String names the profile, e.g. for use in a cache key.
+func (p Profile) String() string {
+	var parts []string
+	if p.Color {
+		parts = append(parts, "color")
+	}
+	if p.Unicode {
+		parts = append(parts, "unicode")
+	}
+	if p.Images {
+		parts = append(parts, "images")
+	}
+	if len(parts) == 0 {
+		return "plain"
+	}
+	return strings.Join(parts, "+")
+}
+
+// textGlyphs replaces indicator glyphs with the text a reader of plain
+// output needs. Replacements change the width of s, so Text must run
+// before anything is measured or laid out.
+var textGlyphs = strings.NewReplacer(
+	"✓", "[OK]",
+	"✔", "[OK]",
+	"✗", "[FAIL]",
+	"✘", "[FAIL]",
+	"⚠️", "[WARN]",
+	"⚠", "[WARN]",
+	"⟳", "[STALE]",
+	"⏱", "!",
+	"▲", "^",
+	"▼", "v",
+	"→", "->",
+	"…", "...",
+	"°", "",
+)
+
+// Text returns s with indicator glyphs spelled out in ASCII when p does
+// not allow Unicode, and s unchanged otherwise.
+func (p Profile) Text(s string) string {
+	if p.Unicode || isASCII(s) {
+		return s
+	}
+	return textGlyphs.Replace(s)
+}
+
+// Apply makes already laid-out output conform to p: escape sequences are
+// removed without Color, and every non-ASCII character is replaced with
+// ASCII of the same width without Unicode, so columns stay aligned.
+func (p Profile) Apply(s string) string {
+	if !p.Color {
+		s = ansi.Strip(s)
+	}
+	if !p.Unicode {
+		s = ASCII(s)
+	}
+	return s
+}
+
+// foldRunes maps single characters to an ASCII character of the same
+// width. Box drawing and block elements are handled by range in foldRune.
+var foldRunes = map[rune]byte{
+	'●': '*', '◉': '*', '•': '*', '◆': '*', '★': '*',
+	'○': 'o', '◯': 'o', '◇': 'o', '☆': 'o',
+	'▲': '^', '△': '^', '↑': '^',
+	'▼': 'v', '▽': 'v', '↓': 'v',
+	'▶': '>', '►': '>', '›': '>', '→': '>',
+	'◀': '<', '◄': '<', '‹': '<', '←': '<',
+	'…': '.', '·': '.',
+	'—': '-', '–': '-',
+	'°': 'o',
+	'✓': '+', '✔': '+',
+	'✗': 'x', '✘': 'x',
+	'⚠': '!',
+}
+
+// ASCII replaces every non-ASCII grapheme in s with ASCII of the same
+// display width: box-drawing lines with "-" and "|", corners and tees with
+// "+", block elements with "#", and glyph indicators with the nearest
+// ASCII character. Anything else becomes "?", padded with spaces to its
+// width. Escape sequences are left in place.
+func ASCII(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for s != "" {
+		if s[0] < utf8.RuneSelf {
+			b.WriteByte(s[0])
+			s = s[1:]
+			continue
+		}
+		cluster, width := ansi.FirstGraphemeCluster(s, ansi.GraphemeWidth)
+		if cluster == "" {
+			// Invalid UTF-8: drop the byte.
+			s = s[1:]
+			continue
+		}
+		s = s[len(cluster):]
+		if width <= 0 {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(cluster)
+		c := foldRune(r)
+		b.WriteByte(c)
+		for i := 1; i < width; i++ {
+			if c == '-' || c == '#' {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return b.String()
+}
+
+// foldRune returns the ASCII stand-in for r.
+func foldRune(r rune) byte {
+	if c, ok := foldRunes[r]; ok {
+		return c
+	}
+	switch {
+	case r >= 0x2500 && r <= 0x257F: // box drawing
+		switch {
+		case strings.ContainsRune("─━═┄┅┈┉╌╍╴╶╸╺╼╾", r):
+			return '-'
+		case strings.ContainsRune("│┃║┆┇┊┋╎╏╵╷╹╻╽╿", r):
+			return '|'
+		}
+		return '+'
+	case r >= 0x2580 && r <= 0x259F: // block elements
+		return '#'
+	}
+	return '?'
+}
+
+// isASCII reports whether s has no bytes above 0x7f.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
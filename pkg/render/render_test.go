@@ -0,0 +1,87 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestDetect(t *testing.T) {
+	noColor := Full
+	noColor.Color = false
+	tests := []struct {
+		name  string
+		ascii bool
+		tty   bool
+		env   map[string]string
+		want  Profile
+	}{
+		{"terminal", false, true, map[string]string{"TERM": "xterm-256color"}, Full},
+		{"config", true, true, nil, Plain},
+		{"pipe", false, false, nil, Plain},
+		{"dumb terminal", false, true, map[string]string{"TERM": "dumb"}, Plain},
+		{"NO_COLOR", false, true, map[string]string{"NO_COLOR": "1"}, noColor},
+		{"empty NO_COLOR", false, true, map[string]string{"NO_COLOR": ""}, Full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(k string) string { return tt.env[k] }
+			if got := Detect(tt.ascii, tt.tty, getenv); got != tt.want {
+				t.Errorf("Detect() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	in := "✓ synced  ✗ failed  ⚠️ low  ▲ 3 more  71°C ⟳"
+	if got := Full.Text(in); got != in {
+		t.Errorf("Full.Text() = %q, want unchanged", got)
+	}
+	want := "[OK] synced  [FAIL] failed  [WARN] low  ^ 3 more  71C [STALE]"
+	if got := Plain.Text(in); got != want {
+		t.Errorf("Plain.Text() = %q, want %q", got, want)
+	}
+}
+
+func TestASCIIKeepsWidth(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"╭─ CPU ─╮", "+- CPU -+"},
+		{"│ ▁▃▅█ │", "| #### |"},
+		{"┣━━┫ ║x║", "+--+ |x|"},
+		{"● up ○ down", "* up o down"},
+		{"‹ col ›", "< col >"},
+		{"🐳 3", "?  3"},
+		{"☁️ ok", "?  ok"},
+		{"\x1b[31m█\x1b[0m", "\x1b[31m#\x1b[0m"},
+	}
+	for _, tt := range tests {
+		got := ASCII(tt.in)
+		if got != tt.want {
+			t.Errorf("ASCII(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if ansi.StringWidth(got) != ansi.StringWidth(tt.in) {
+			t.Errorf("ASCII(%q) width %d, want %d", tt.in, ansi.StringWidth(got), ansi.StringWidth(tt.in))
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	in := "\x1b[38;2;1;2;3m╭──╮\x1b[0m \x1b[1m● ok\x1b[22m"
+
+	if got := Full.Apply(in); got != in {
+		t.Errorf("Full.Apply() = %q, want unchanged", got)
+	}
+	if got, want := (Profile{Unicode: true}).Apply(in), "╭──╮ ● ok"; got != want {
+		t.Errorf("no-color Apply() = %q, want %q", got, want)
+	}
+
+	got := Plain.Apply(in)
+	if got != "+--+ * ok" {
+		t.Errorf("Plain.Apply() = %q", got)
+	}
+	if strings.ContainsRune(got, '\x1b') {
+		t.Errorf("Plain.Apply() kept an escape sequence: %q", got)
+	}
+}
@@ -0,0 +1,222 @@
+package reposync
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ApplyOptions tunes ApplySync.
+type ApplyOptions struct {
+	// DryRun computes the changes without touching the target.
+	DryRun bool
+}
+
+// SyncChanges summarizes what ApplySync changed (or, in a dry run, would
+// change) in the target directory.
+type SyncChanges struct {
+	// Added, Updated, and Deleted count files by change.
+	Added   int
+	Updated int
+	Deleted int
+
+	// Rewritten counts the added or updated files whose module path was
+	// rewritten.
+	Rewritten int
+
+	// Files lists every changed file in path order.
+	Files []SyncChange
+
+	// Hashes maps each synced target path to the SHA-256 hex digest of its
+	// content after the sync.
+	Hashes map[string]string
+
+	// DryRun is true when the target was not modified.
+	DryRun bool
+}
+
+// SyncChange records one changed file.
+type SyncChange struct {
+	// Path is relative to the target root.
+	Path string
+
+	// Change is "add", "update", or "delete".
+	Change string
+
+	// Rewritten is true when the module path was rewritten.
+	Rewritten bool
+}
+
+// Total returns the number of changed files.
+func (c *SyncChanges) Total() int {
+	return c.Added + c.Updated + c.Deleted
+}
+
+// ApplySync performs the sync described by config locally: it builds the
+// manifest for sourceDir, copies the included files into targetDir,
+// rewrites the module path in files marked "rewrite", and deletes target
+// files the manifest does not include. Paths matching config.ProtectedPaths
+// (and .git/ always) are never deleted. Files whose content already matches
+// are left alone, so a second run with no source changes reports none.
+func ApplySync(config *SyncConfig, sourceDir, targetDir string, opts ApplyOptions) (*SyncChanges, error) {
+	manifest, err := rsGenerateManifest(config, sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	oldModule, newModule := "", rsTargetModule(config.TargetRepo)
+	if data, err := os.ReadFile(filepath.Join(sourceDir, "go.mod")); err == nil {
+		oldModule, _ = rsDetectModule(string(data))
+	}
+
+	changes := &SyncChanges{Hashes: map[string]string{}, DryRun: opts.DryRun}
+	synced := map[string]bool{}
+	for _, f := range manifest.Files {
+		if f.Action == "exclude" {
+			continue
+		}
+		synced[f.TargetPath] = true
+		if err := rsApplyFile(sourceDir, targetDir, f, oldModule, newModule, opts, changes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rsDeleteUnsynced(config, targetDir, synced, opts, changes); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changes.Files, func(i, j int) bool {
+		return changes.Files[i].Path < changes.Files[j].Path
+	})
+	return changes, nil
+}
+
+// rsApplyFile writes one manifest file into targetDir when its content
+// differs, recording the change.
+func rsApplyFile(sourceDir, targetDir string, f SyncFile, oldModule, newModule string, opts ApplyOptions, changes *SyncChanges) error {
+	src := filepath.Join(sourceDir, filepath.FromSlash(f.SourcePath))
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", src, err)
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", src, err)
+	}
+
+	content := string(data)
+	if f.Action == "rewrite" && oldModule != "" {
+		var rewritten string
+		if f.SourcePath == "go.mod" {
+			rewritten, err = rsRewriteGoMod(content, oldModule, newModule)
+		} else {
+			rewritten, err = rsRewriteImports(content, oldModule, newModule)
+		}
+		if err != nil {
+			return fmt.Errorf("rewriting %s: %w", f.SourcePath, err)
+		}
+		content = rewritten
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+	changes.Hashes[f.TargetPath] = hash
+
+	dst := filepath.Join(targetDir, filepath.FromSlash(f.TargetPath))
+	change := "add"
+	if existing, err := os.ReadFile(dst); err == nil {
+		if fmt.Sprintf("%x", sha256.Sum256(existing)) == hash {
+			return nil
+		}
+		change = "update"
+	}
+
+	if !opts.DryRun {
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", f.TargetPath, err)
+		}
+		if err := os.WriteFile(dst, []byte(content), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", dst, err)
+		}
+	}
+
+	rewritten := content != string(data)
+	if change == "add" {
+		changes.Added++
+	} else {
+		changes.Updated++
+	}
+	if rewritten {
+		changes.Rewritten++
+	}
+	changes.Files = append(changes.Files, SyncChange{Path: f.TargetPath, Change: change, Rewritten: rewritten})
+	return nil
+}
+
+// rsDeleteUnsynced removes files in targetDir that are neither synced nor
+// protected, then any directories the removals left empty.
+func rsDeleteUnsynced(config *SyncConfig, targetDir string, synced map[string]bool, opts ApplyOptions, changes *SyncChanges) error {
+	protected := append([]string{".git/"}, config.ProtectedPaths...)
+	isProtected := func(rel string) bool {
+		for _, p := range protected {
+			if rsPathMatch(rel, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(targetDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == targetDir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(targetDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if isProtected(rel + "/") {
+				return fs.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		}
+		if synced[rel] || isProtected(rel) {
+			return nil
+		}
+
+		if !opts.DryRun {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("deleting %s: %w", path, err)
+			}
+		}
+		changes.Deleted++
+		changes.Files = append(changes.Files, SyncChange{Path: rel, Change: "delete"})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walking target: %w", err)
+	}
+
+	if !opts.DryRun {
+		// Deepest first, so emptied parents are removed too. Remove fails
+		// harmlessly on directories that still have files.
+		sort.Slice(dirs, func(i, j int) bool {
+			return strings.Count(dirs[i], string(filepath.Separator)) > strings.Count(dirs[j], string(filepath.Separator))
+		})
+		for _, dir := range dirs {
+			_ = os.Remove(dir)
+		}
+	}
+	return nil
+}
@@ -32,6 +32,11 @@ type SyncConfig struct {
 
 	// CITemplate is the path to the CI template that drives synchronization.
 	CITemplate string
+
+	// ProtectedPaths lists target paths that a local sync (ApplySync) never
+	// deletes, such as files that only exist in the target. .git/ is
+	// always protected.
+	ProtectedPaths []string
 }
 
 // SyncStatus captures the current state of synchronization between source
@@ -82,7 +87,8 @@ func DefaultConfig() *SyncConfig {
 			"scripts/",
 			"tests/",
 		},
-		CITemplate: "ci/templates/sync-external.yml",
+		CITemplate:     "ci/templates/sync-external.yml",
+		ProtectedPaths: []string{".git/"},
 	}
 }
 
@@ -804,6 +804,87 @@ func TestRenderManifestMarkdown_Nil(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Local sync tests
+// ---------------------------------------------------------------------------
+
+func TestApplySync(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, src, "go.mod", "module example.com/mono/pp\n\ngo 1.21\n")
+	writeFile(t, src, "main.go", "package main\n\nimport _ \"example.com/mono/pp/pkg/foo\"\n")
+	writeFile(t, src, "pkg/foo/foo.go", "package foo\n")
+	writeFile(t, src, "docs/guide.md", "# Guide\n")
+	writeFile(t, src, "internal/secret.go", "package internal\n")
+
+	writeFile(t, dst, ".git/HEAD", "ref: refs/heads/main\n")
+	writeFile(t, dst, "LICENSE", "MIT\n")
+	writeFile(t, dst, "docs/guide.md", "# Old guide\n")
+	writeFile(t, dst, "pkg/gone/gone.go", "package gone\n")
+
+	config := &SyncConfig{
+		TargetRepo:     "example.com/pp",
+		SyncPaths:      []string{"pkg/", "go.mod", "*.go", "docs/"},
+		ExcludePaths:   []string{"internal/"},
+		ProtectedPaths: []string{"LICENSE"},
+	}
+
+	dry, err := ApplySync(config, src, dst, ApplyOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "main.go")); err == nil {
+		t.Error("dry run wrote to the target")
+	}
+
+	got, err := ApplySync(config, src, dst, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("ApplySync: %v", err)
+	}
+	if got.Added != 3 || got.Updated != 1 || got.Deleted != 1 || got.Rewritten != 2 {
+		t.Errorf("added/updated/deleted/rewritten = %d/%d/%d/%d, want 3/1/1/2",
+			got.Added, got.Updated, got.Deleted, got.Rewritten)
+	}
+	if dry.Total() != got.Total() || dry.Rewritten != got.Rewritten {
+		t.Errorf("dry run reported %d changes, real run %d", dry.Total(), got.Total())
+	}
+	if len(got.Hashes) != 4 || got.Hashes["pkg/foo/foo.go"] == "" {
+		t.Errorf("hashes = %v, want one per synced file", got.Hashes)
+	}
+
+	mainGo, _ := os.ReadFile(filepath.Join(dst, "main.go"))
+	if !strings.Contains(string(mainGo), `"example.com/pp/pkg/foo"`) {
+		t.Errorf("main.go imports not rewritten:\n%s", mainGo)
+	}
+	goMod, _ := os.ReadFile(filepath.Join(dst, "go.mod"))
+	if !strings.HasPrefix(string(goMod), "module example.com/pp\n") {
+		t.Errorf("go.mod not rewritten:\n%s", goMod)
+	}
+	for _, kept := range []string{".git/HEAD", "LICENSE"} {
+		if _, err := os.Stat(filepath.Join(dst, kept)); err != nil {
+			t.Errorf("protected %s was deleted", kept)
+		}
+	}
+	for _, gone := range []string{"pkg/gone", "internal/secret.go"} {
+		if _, err := os.Stat(filepath.Join(dst, gone)); err == nil {
+			t.Errorf("%s should not be in the target", gone)
+		}
+	}
+
+	again, err := ApplySync(config, src, dst, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("second ApplySync: %v", err)
+	}
+	if again.Total() != 0 || again.Rewritten != 0 || len(again.Files) != 0 {
+		t.Errorf("second run changed %v, want no changes", again.Files)
+	}
+}
+
+func TestApplySync_NilConfig(t *testing.T) {
+	if _, err := ApplySync(nil, t.TempDir(), t.TempDir(), ApplyOptions{}); err == nil {
+		t.Error("expected error for nil config")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Path filtering tests
 // ---------------------------------------------------------------------------
@@ -1,6 +1,10 @@
 package shell
 
-import "fmt"
+import (
+	"fmt"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+)
 
 // shGenerateBash produces the Bash shell integration script.
 func shGenerateBash(opts Options) string {
@@ -9,6 +13,8 @@ func shGenerateBash(opts Options) string {
 
 `)
 	s += shBashBanner(opts)
+	s += shBashPromptSegment(opts)
+	s += shBashActivity(opts)
 	s += shBashKeybinding(opts)
 	s += shBashCompletions(opts)
 	s += shBashDaemonFunctions(opts)
@@ -38,6 +44,72 @@ fi
 `, bin)
 }
 
+// shBashPromptSegment generates the PROMPT_COMMAND hook that caches the
+// starship segment in PROMPT_PULSE_SEGMENT for Bash. Bash cannot read an
+// mtime without a process, so the cache files are compared against a
+// per-shell stamp file with the -nt test instead.
+func shBashPromptSegment(opts Options) string {
+	if opts.PromptSegment == "" || len(opts.PromptCacheFiles) == 0 {
+		return ""
+	}
+	bin := shQuote(opts.BinaryPath)
+	seg := shQuote(opts.PromptSegment)
+	return fmt.Sprintf(`# Cache the starship segment, re-rendering only when collector data changes
+__prompt_pulse_stamp="${XDG_RUNTIME_DIR:-${TMPDIR:-/tmp}}/prompt-pulse-segment.$$"
+__prompt_pulse_segment() {
+    local f changed=
+    [ -e "$__prompt_pulse_stamp" ] || changed=1
+    for f in %[3]s; do
+        [[ "$f" -nt "$__prompt_pulse_stamp" ]] && changed=1
+    done
+    [ -n "$changed" ] || return 0
+    : >"$__prompt_pulse_stamp"
+    PROMPT_PULSE_SEGMENT=$(%[1]s -starship %[2]s 2>/dev/null)
+    export PROMPT_PULSE_SEGMENT
+}
+if [[ "$PROMPT_COMMAND" != *"__prompt_pulse_segment"* ]]; then
+    PROMPT_COMMAND="__prompt_pulse_segment;${PROMPT_COMMAND:-}"
+fi
+
+`, bin, seg, shQuoteAll(opts.PromptCacheFiles))
+}
+
+// shBashActivity generates the hooks that append each command's exit
+// status and duration to the shell's activity file. PS0 stamps the start
+// time through an arithmetic side effect, as Bash has no preexec hook,
+// and the PROMPT_COMMAND hook, run first so $? is still the command's,
+// writes the line with builtins only. Bash does not say which command
+// ran, so the line has no name. EPOCHREALTIME needs Bash 5; older shells
+// skip the hooks.
+func shBashActivity(opts Options) string {
+	if opts.ActivityDir == "" {
+		return ""
+	}
+	return fmt.Sprintf(`# Report each command's exit status and duration to prompt-pulse
+if (( BASH_VERSINFO[0] >= 5 )); then
+    __prompt_pulse_activity_file=%[1]s/bash-$$
+    __prompt_pulse_activity_n=0
+    __prompt_pulse_activity() {
+        local st=$? now f
+        [[ -n "$__prompt_pulse_start" ]] || return $st
+        now=${EPOCHREALTIME//[!0-9]/}
+        f=$__prompt_pulse_activity_file.$(( __prompt_pulse_activity_n / %[2]d %% 2 ))
+        (( __prompt_pulse_activity_n++ %% %[2]d )) || : 2>/dev/null >"$f"
+        printf '%%s %%s %%s\n' "${now:0:-6}" "$st" "$(( (now - __prompt_pulse_start) / 1000 ))" 2>/dev/null >>"$f"
+        __prompt_pulse_start=
+        return $st
+    }
+    if [[ "$PS0" != *"__prompt_pulse_start"* ]]; then
+        PS0+='${PS0:0:$((__prompt_pulse_start=${EPOCHREALTIME//[!0-9]/}, 0))}'
+    fi
+    if [[ "$PROMPT_COMMAND" != *"__prompt_pulse_activity"* ]]; then
+        PROMPT_COMMAND="__prompt_pulse_activity;${PROMPT_COMMAND:-}"
+    fi
+fi
+
+`, shQuote(opts.ActivityDir), shellactivity.RotateLines)
+}
+
 // shBashKeybinding generates the keybinding block for Bash.
 func shBashKeybinding(opts Options) string {
 	bin := shQuote(opts.BinaryPath)
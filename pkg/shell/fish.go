@@ -1,6 +1,10 @@
 package shell
 
-import "fmt"
+import (
+	"fmt"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+)
 
 // shGenerateFish produces the Fish shell integration script.
 func shGenerateFish(opts Options) string {
@@ -9,6 +13,8 @@ func shGenerateFish(opts Options) string {
 
 `)
 	s += shFishBanner(opts)
+	s += shFishPromptSegment(opts)
+	s += shFishActivity(opts)
 	s += shFishKeybinding(opts)
 	s += shFishCompletions(opts)
 	s += shFishDaemonFunctions(opts)
@@ -35,6 +41,55 @@ end
 `, bin)
 }
 
+// shFishPromptSegment generates the fish_prompt handler that caches the
+// starship segment in PROMPT_PULSE_SEGMENT for Fish, reading cache mtimes
+// with the path builtin (fish 3.5+).
+func shFishPromptSegment(opts Options) string {
+	if opts.PromptSegment == "" || len(opts.PromptCacheFiles) == 0 {
+		return ""
+	}
+	bin := shFishQuote(opts.BinaryPath)
+	seg := shFishQuote(opts.PromptSegment)
+	return fmt.Sprintf(`# Cache the starship segment, re-rendering only when collector data changes
+function __prompt_pulse_segment --on-event fish_prompt
+    set -l key (path mtime -- %[3]s)
+    if set -q __prompt_pulse_key; and test "$key" = "$__prompt_pulse_key"
+        return
+    end
+    set -g __prompt_pulse_key "$key"
+    set -gx PROMPT_PULSE_SEGMENT (%[1]s -starship %[2]s 2>/dev/null | string collect)
+end
+
+`, bin, seg, shQuoteAll(opts.PromptCacheFiles))
+}
+
+// shFishActivity generates the fish_postexec handler that appends each
+// command's exit status, duration (CMD_DURATION), and first word to the
+// shell's activity file. Fish has no clock variable, so the timestamp
+// costs one date process.
+func shFishActivity(opts Options) string {
+	if opts.ActivityDir == "" {
+		return ""
+	}
+	return fmt.Sprintf(`# Report each command's exit status and duration to prompt-pulse
+set -g __prompt_pulse_activity_file %[1]s/fish-$fish_pid
+set -g __prompt_pulse_activity_n 0
+function __prompt_pulse_activity --on-event fish_postexec
+    set -l st $status
+    set -l ms $CMD_DURATION
+    set -l cmd (string trim -- "$argv[1]")
+    test -n "$cmd"; or return
+    set -l f $__prompt_pulse_activity_file.(math "floor($__prompt_pulse_activity_n / %[2]d) %% 2")
+    begin
+        test (math "$__prompt_pulse_activity_n %% %[2]d") -eq 0; and true >$f
+        printf '%%s %%s %%s %%s\n' (date +%%s) $st $ms (string split -f1 ' ' -- "$cmd") >>$f
+    end 2>/dev/null
+    set -g __prompt_pulse_activity_n (math $__prompt_pulse_activity_n + 1)
+end
+
+`, shQuote(opts.ActivityDir), shellactivity.RotateLines)
+}
+
 // shFishKeybinding generates the keybinding block for Fish, binding in all
 // three modes (default, insert, visual).
 func shFishKeybinding(opts Options) string {
@@ -7,12 +7,18 @@
 //   - A keybinding to launch the TUI dashboard (default: Ctrl+P)
 //   - Lazy completion loading
 //   - Daemon management functions (pp-start, pp-stop, pp-status)
+//   - Optionally, a prompt hook caching a starship segment (see PromptSegment)
+//   - Optionally, hooks reporting each command's exit status and duration
+//     (see ActivityDir)
 //
 // All private helpers are prefixed with "sh" to avoid naming conflicts with
 // other packages in the prompt-pulse module.
 package shell
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ShellType identifies a supported shell for integration script generation.
 type ShellType string
@@ -46,6 +52,26 @@ type Options struct {
 
 	// EnableCompletions installs tab completions for the prompt-pulse binary.
 	EnableCompletions bool
+
+	// PromptSegment, if set, is a -starship segment (e.g. "all") that a
+	// prompt hook keeps in the exported PROMPT_PULSE_SEGMENT variable, for
+	// starship's env_var module. Ksh93 has no prompt hook and ignores it.
+	PromptSegment string
+
+	// PromptCacheFiles are the cache files PromptSegment is rendered from
+	// (see starship.CacheFiles). The hook compares their mtimes with shell
+	// builtins and only runs prompt-pulse when one changed, so a prompt
+	// with a warm cache starts no process. The hook is omitted without
+	// them.
+	PromptCacheFiles []string
+
+	// ActivityDir, if set, is the directory a prompt hook appends each
+	// command's exit status and duration to, in a file per shell, for the
+	// shell activity collector (see pkg/collectors/shellactivity). The
+	// hook writes with shell builtins and starts no process, except for a
+	// date under Fish. Bash needs version 5; Ksh93 has no prompt hook and
+	// ignores it.
+	ActivityDir string
 }
 
 // shDefaultOptions returns Options with sensible defaults filled in for the
@@ -86,6 +112,15 @@ func Generate(shell ShellType, opts Options) string {
 	}
 }
 
+// shQuoteAll quotes each of ss with shQuote and joins them with spaces.
+func shQuoteAll(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = shQuote(s)
+	}
+	return strings.Join(quoted, " ")
+}
+
 // shQuote wraps a string in single quotes for POSIX shells, escaping any
 // embedded single quotes via the '\'' idiom.
 func shQuote(s string) string {
@@ -1,9 +1,16 @@
 package shell
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/perfval"
 )
 
 // --- Generate() tests for each shell type ---
@@ -402,6 +409,146 @@ func TestDaemonFunctions_AllShells(t *testing.T) {
 	}
 }
 
+// --- PromptSegment caches the starship segment ---
+
+func TestPromptSegment_AllHookShells(t *testing.T) {
+	opts := Options{PromptSegment: "claude", PromptCacheFiles: []string{"/c/claude.json"}}
+	for _, sh := range []ShellType{Bash, Zsh, Fish} {
+		out := Generate(sh, opts)
+		for _, want := range []string{"-starship 'claude'", "'/c/claude.json'", "PROMPT_PULSE_SEGMENT"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s output missing %q", sh, want)
+			}
+		}
+		if out := Generate(sh, Options{PromptSegment: "claude"}); strings.Contains(out, "PROMPT_PULSE_SEGMENT") {
+			t.Errorf("%s without cache files should not install the segment hook", sh)
+		}
+	}
+	if out := Generate(Ksh, opts); strings.Contains(out, "PROMPT_PULSE_SEGMENT") {
+		t.Error("Ksh has no prompt hook and should ignore PromptSegment")
+	}
+}
+
+// shRunBashSegmentHook runs the Bash segment hook script against a stub
+// prompt-pulse that logs each invocation, and returns the log.
+func shRunBashSegmentHook(t *testing.T, body string) []string {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "pp")
+	if err := os.WriteFile(stub, []byte("#!/bin/sh\necho \"$1\" >> \"$PP_LOG\"\necho seg\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cacheFile := filepath.Join(dir, "claude.json")
+	if err := os.WriteFile(cacheFile, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := Generate(Bash, Options{BinaryPath: stub, PromptSegment: "all", PromptCacheFiles: []string{cacheFile}}) + body
+	cmd := exec.Command(bash, "--norc", "--noprofile", "-c", script)
+	cmd.Env = append(os.Environ(), "XDG_RUNTIME_DIR="+dir, "PP_LOG="+filepath.Join(dir, "log"), "CACHE="+cacheFile)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	log, _ := os.ReadFile(filepath.Join(dir, "log"))
+	return strings.Fields(string(log))
+}
+
+func TestPromptSegment_BashRendersOnlyOnChange(t *testing.T) {
+	got := shRunBashSegmentHook(t, `
+__prompt_pulse_segment; __prompt_pulse_segment
+[ "$PROMPT_PULSE_SEGMENT" = seg ] || exit 1
+touch -t 200001010000 "$__prompt_pulse_stamp"
+__prompt_pulse_segment; __prompt_pulse_segment
+`)
+	if strings.Join(got, " ") != "-starship -starship" {
+		t.Errorf("invocations = %v, want one render at start and one after the cache changed", got)
+	}
+}
+
+// TestPromptSegment_BashWarmCacheBudget checks that the Bash hook with a
+// warm cache fits the perfval shell_source target.
+func TestPromptSegment_BashWarmCacheBudget(t *testing.T) {
+	var target perfval.Target
+	for _, tgt := range perfval.DefaultTargets() {
+		if tgt.Name == "shell_source" {
+			target = tgt
+		}
+	}
+	const prompts = 200
+	start := time.Now()
+	got := shRunBashSegmentHook(t, `__prompt_pulse_segment
+for i in $(seq `+fmt.Sprint(prompts)+`); do __prompt_pulse_segment; done
+`)
+	// Bash startup is included, so this overstates the per-prompt cost.
+	if per := time.Since(start) / prompts; per > target.MaxDuration {
+		t.Errorf("warm-cache prompt hook took %v per prompt, budget %v", per, target.MaxDuration)
+	}
+	if len(got) != 1 {
+		t.Errorf("invocations = %v, want a single render", got)
+	}
+}
+
+// --- ActivityDir reports each command to prompt-pulse ---
+
+func TestActivity_AllHookShells(t *testing.T) {
+	opts := Options{ActivityDir: "/s/shell-activity"}
+	for sh, want := range map[ShellType]string{
+		Bash: "'/s/shell-activity'/bash-$$",
+		Zsh:  "'/s/shell-activity'/zsh-$$",
+		Fish: "'/s/shell-activity'/fish-$fish_pid",
+	} {
+		if out := Generate(sh, opts); !strings.Contains(out, want) {
+			t.Errorf("%s output missing %q", sh, want)
+		}
+		if out := Generate(sh, Options{}); strings.Contains(out, "__prompt_pulse_activity") {
+			t.Errorf("%s without ActivityDir should not install the activity hook", sh)
+		}
+	}
+	if out := Generate(Ksh, opts); strings.Contains(out, "__prompt_pulse_activity") {
+		t.Error("Ksh has no prompt hook and should ignore ActivityDir")
+	}
+}
+
+func TestActivity_BashAppendsAndRotates(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	dir := t.TempDir()
+	script := Generate(Bash, Options{ActivityDir: dir}) + fmt.Sprintf(`
+[[ "$PROMPT_COMMAND" == __prompt_pulse_activity\;* ]] || exit 2
+__prompt_pulse_activity
+__prompt_pulse_start=${EPOCHREALTIME//[!0-9]/}; false; __prompt_pulse_activity
+__prompt_pulse_activity_n=%d
+__prompt_pulse_start=${EPOCHREALTIME//[!0-9]/}; true; __prompt_pulse_activity
+echo "$$"
+`, shellactivity.RotateLines)
+	out, err := exec.Command(bash, "--norc", "--noprofile", "-c", script).Output()
+	if err != nil {
+		t.Fatalf("bash: %v", err)
+	}
+	pid := strings.TrimSpace(string(out))
+
+	for n, wantExit := range []int{1, 0} {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("bash-%s.%d", pid, n)))
+		if err != nil {
+			t.Fatalf("activity file %d: %v", n, err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("activity file %d has %d lines, want 1: %q", n, len(lines), data)
+		}
+		cmd, ok := shellactivity.ParseLine(lines[0])
+		if !ok || cmd.Exit != wantExit || time.Since(cmd.Time) > time.Minute {
+			t.Errorf("activity file %d line %q parses to %+v, %v; want exit %d just now", n, lines[0], cmd, ok, wantExit)
+		}
+	}
+}
+
 // --- Custom keybinding overrides ---
 
 func TestCustomKeybinding_Bash(t *testing.T) {
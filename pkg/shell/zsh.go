@@ -1,6 +1,10 @@
 package shell
 
-import "fmt"
+import (
+	"fmt"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+)
 
 // shGenerateZsh produces the Zsh shell integration script.
 func shGenerateZsh(opts Options) string {
@@ -9,6 +13,8 @@ func shGenerateZsh(opts Options) string {
 
 `)
 	s += shZshBanner(opts)
+	s += shZshPromptSegment(opts)
+	s += shZshActivity(opts)
 	s += shZshKeybinding(opts)
 	s += shZshCompletions(opts)
 	s += shZshDaemonFunctions(opts)
@@ -37,6 +43,66 @@ add-zsh-hook precmd __prompt_pulse_precmd
 `, bin)
 }
 
+// shZshPromptSegment generates the precmd hook that caches the starship
+// segment in PROMPT_PULSE_SEGMENT for Zsh, reading cache mtimes with the
+// zstat builtin.
+func shZshPromptSegment(opts Options) string {
+	if opts.PromptSegment == "" || len(opts.PromptCacheFiles) == 0 {
+		return ""
+	}
+	bin := shQuote(opts.BinaryPath)
+	seg := shQuote(opts.PromptSegment)
+	return fmt.Sprintf(`# Cache the starship segment, re-rendering only when collector data changes
+autoload -Uz add-zsh-hook
+zmodload -F zsh/stat b:zstat
+__prompt_pulse_segment() {
+    local f m key=
+    for f in %[3]s; do
+        zstat -A m +mtime -- "$f" 2>/dev/null && key+="$m "
+    done
+    (( ${+__prompt_pulse_key} )) && [[ "$key" == "$__prompt_pulse_key" ]] && return 0
+    typeset -g __prompt_pulse_key=$key
+    export PROMPT_PULSE_SEGMENT="$(%[1]s -starship %[2]s 2>/dev/null)"
+}
+add-zsh-hook precmd __prompt_pulse_segment
+
+`, bin, seg, shQuoteAll(opts.PromptCacheFiles))
+}
+
+// shZshActivity generates the preexec and precmd hooks that append each
+// command's exit status, duration, and first word to the shell's activity
+// file, timed with zsh/datetime. The precmd hook goes first so $? is
+// still the command's.
+func shZshActivity(opts Options) string {
+	if opts.ActivityDir == "" {
+		return ""
+	}
+	return fmt.Sprintf(`# Report each command's exit status and duration to prompt-pulse
+autoload -Uz add-zsh-hook
+zmodload zsh/datetime
+typeset -g __prompt_pulse_activity_file=%[1]s/zsh-$$
+typeset -gi __prompt_pulse_activity_n=0
+__prompt_pulse_activity_preexec() {
+    typeset -g __prompt_pulse_start=$EPOCHREALTIME
+    typeset -g __prompt_pulse_cmd=${${(z)1}[1]}
+}
+__prompt_pulse_activity() {
+    local st=$? f
+    [[ -n "$__prompt_pulse_start" ]] || return $st
+    local -i ms=$(( (EPOCHREALTIME - __prompt_pulse_start) * 1000 ))
+    f=$__prompt_pulse_activity_file.$(( __prompt_pulse_activity_n / %[2]d %% 2 ))
+    (( __prompt_pulse_activity_n++ %% %[2]d )) || : 2>/dev/null >"$f"
+    printf '%%s %%s %%s %%s\n' $EPOCHSECONDS $st $ms "$__prompt_pulse_cmd" 2>/dev/null >>"$f"
+    __prompt_pulse_start=
+    return $st
+}
+add-zsh-hook preexec __prompt_pulse_activity_preexec
+add-zsh-hook -d precmd __prompt_pulse_activity
+precmd_functions=(__prompt_pulse_activity $precmd_functions)
+
+`, shQuote(opts.ActivityDir), shellactivity.RotateLines)
+}
+
 // shZshKeybinding generates the keybinding block for Zsh using a ZLE widget
 // with proper /dev/tty redirection.
 func shZshKeybinding(opts Options) string {
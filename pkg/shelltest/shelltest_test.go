@@ -15,6 +15,7 @@ func stFullOpts() shell.Options {
 		ShowBanner:        true,
 		DaemonAutoStart:   true,
 		EnableCompletions: true,
+		ActivityDir:       "/home/user/.local/state/prompt-pulse/shell-activity",
 	}
 }
 
@@ -0,0 +1,127 @@
+package starship
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
+)
+
+// ssPlaceholder is the whole line when no segment was ready within the
+// budget.
+const ssPlaceholder = "…"
+
+// OverrunFileName is the file in the cache directory where prompts that
+// ran out of their budget are counted; see Overruns.
+const OverrunFileName = "starship-overruns"
+
+// Overruns counts the prompts that ran out of their time budget, to
+// diagnose a slow cache after the fact.
+type Overruns struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+
+	// LastElapsedMS is how long the last overrunning prompt spent reading
+	// the cache, and LastLate the segments it left out.
+	LastElapsedMS int64    `json:"last_elapsed_ms"`
+	LastLate      []string `json:"last_late"`
+}
+
+// ReadOverruns returns the counts recorded in path, or the zero value if
+// nothing was recorded.
+func ReadOverruns(path string) Overruns {
+	var o Overruns
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &o)
+	}
+	return o
+}
+
+// ssRenderSegments renders the segment of each key and returns them in
+// order, nil for keys without data. With a cfg.Budget the segments render
+// concurrently and cache reads get four fifths of it, leaving the rest for
+// formatting: Starship drops a custom module that runs too long, so a
+// partial line beats a complete one that never shows. Segments not ready
+// by then are left out and returned as late; their reads carry on in the
+// background until the process exits.
+func ssRenderSegments(cfg Config, keys []string) (segs []*Segment, late []string) {
+	segs = make([]*Segment, len(keys))
+	if cfg.Budget <= 0 {
+		for i, key := range keys {
+			segs[i] = ssRenderSegment(cfg, key)
+		}
+		return segs, nil
+	}
+	start := time.Now()
+	timer := time.NewTimer(cfg.Budget * 4 / 5)
+	defer timer.Stop()
+
+	var mu sync.Mutex
+	rendered := make([]*Segment, len(keys))
+	ready := make([]bool, len(keys))
+	done := make(chan struct{}, len(keys))
+	for i, key := range keys {
+		go func() {
+			seg := ssRenderSegment(cfg, key)
+			mu.Lock()
+			rendered[i], ready[i] = seg, true
+			mu.Unlock()
+			done <- struct{}{}
+		}()
+	}
+
+wait:
+	for range keys {
+		select {
+		case <-done:
+		case <-timer.C:
+			break wait
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	copy(segs, rendered)
+	for i, ok := range ready {
+		if !ok {
+			late = append(late, keys[i])
+		}
+	}
+	if len(late) > 0 {
+		ssRecordOverrun(cfg.OverrunFile, late, time.Since(start))
+	}
+	return segs, late
+}
+
+// ssPlaceholderLine is the output when nothing was ready in time.
+func ssPlaceholderLine(cfg Config) string {
+	return ssWrapEscapes(render.Current.Apply(render.Current.Text(ssPlaceholder)), cfg.Wrap)
+}
+
+// ssRecordOverrun counts an overrun in path, if set. Concurrent prompts
+// may lose each other's counts; the file is a debugging aid, not a tally.
+func ssRecordOverrun(path string, late []string, elapsed time.Duration) {
+	if path == "" {
+		return
+	}
+	o := ReadOverruns(path)
+	o.Count++
+	o.Last = time.Now()
+	o.LastElapsedMS = elapsed.Milliseconds()
+	o.LastLate = late
+	data, err := json.Marshal(o)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	if cerr := tmp.Close(); werr != nil || cerr != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
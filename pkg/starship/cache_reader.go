@@ -4,20 +4,36 @@
 package starship
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
 )
 
 // ssMaxCacheAge is the maximum age of a cache file before it is considered
 // stale and ignored. Collectors are expected to refresh more frequently.
 const ssMaxCacheAge = 5 * time.Minute
 
+// Defaults for the coalesced refresh path. Both are deliberately tiny: a
+// prompt must never block noticeably on a refresh.
+const (
+	ssDefaultRefreshBudget = 20 * time.Millisecond
+	ssDefaultRefreshWait   = cache.DefaultLeaseWait
+)
+
 // ssReadCachedData reads a JSON cache file for the given collector key from
 // cacheDir. Returns nil if the file does not exist, cannot be parsed, or is
 // older than ssMaxCacheAge.
 func ssReadCachedData[T any](cacheDir, key string) (*T, error) {
+	return ssReadCachedDataMaxAge[T](cache.ReadFile, cacheDir, key, ssMaxCacheAge)
+}
+
+// ssReadCachedDataMaxAge is ssReadCachedData reading with readFile and an
+// explicit staleness bound. A maxAge of zero accepts data of any age.
+func ssReadCachedDataMaxAge[T any](readFile func(string) ([]byte, error), cacheDir, key string, maxAge time.Duration) (*T, error) {
 	path := filepath.Join(cacheDir, key+".json")
 
 	info, err := os.Stat(path)
@@ -29,11 +45,11 @@ func ssReadCachedData[T any](cacheDir, key string) (*T, error) {
 	}
 
 	// Reject stale data.
-	if time.Since(info.ModTime()) > ssMaxCacheAge {
+	if maxAge > 0 && time.Since(info.ModTime()) > maxAge {
 		return nil, nil
 	}
 
-	data, err := os.ReadFile(path)
+	data, err := readFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -45,3 +61,162 @@ func ssReadCachedData[T any](cacheDir, key string) (*T, error) {
 
 	return &v, nil
 }
+
+// ssReadSummaryMaxAge is ssReadCachedDataMaxAge for a key whose results
+// the daemon also writes a compact summary of, to <key>.summary.json. It
+// decodes the summary when it is current and no older than the full
+// entry, and otherwise decodes the full entry and summarizes it. The full
+// entry's age decides staleness either way.
+func ssReadSummaryMaxAge[S, T any](readFile func(string) ([]byte, error), cacheDir, key string, maxAge time.Duration, current func(*S) bool, summarize func(*T) S) (*S, error) {
+	info, err := os.Stat(filepath.Join(cacheDir, key+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if maxAge > 0 && time.Since(info.ModTime()) > maxAge {
+		return nil, nil
+	}
+
+	path := filepath.Join(cacheDir, key+".summary.json")
+	if sinfo, err := os.Stat(path); err == nil && !sinfo.ModTime().Before(info.ModTime()) {
+		if data, err := readFile(path); err == nil {
+			var s S
+			if json.Unmarshal(data, &s) == nil && current(&s) {
+				return &s, nil
+			}
+		}
+	}
+
+	full, err := ssReadCachedDataMaxAge[T](readFile, cacheDir, key, 0)
+	if full == nil || err != nil {
+		return nil, err
+	}
+	s := summarize(full)
+	return &s, nil
+}
+
+// ssCacheReader returns the function reading cfg's cache files.
+func ssCacheReader(cfg Config) func(string) ([]byte, error) {
+	if cfg.readFile != nil {
+		return cfg.readFile
+	}
+	return cache.ReadFile
+}
+
+// ssLoadCachedData reads cached data for a segment. When the entry is stale
+// or missing and cfg.Refresh is set, it joins a cross-process single-flight
+// so that only one of many simultaneously-starting prompts runs the refresh.
+// Prompts that lose the race wait at most cfg.RefreshWait for the fresh file
+// and otherwise fall back to the stale data rather than rendering nothing.
+func ssLoadCachedData[T any](cfg Config, key string) (*T, error) {
+	readFile := ssCacheReader(cfg)
+	return ssLoad(cfg, key, func(maxAge time.Duration) (*T, error) {
+		return ssReadCachedDataMaxAge[T](readFile, cfg.CacheDir, key, maxAge)
+	})
+}
+
+// ssLoadSummary is ssLoadCachedData reading key's summary, as
+// ssReadSummaryMaxAge does.
+func ssLoadSummary[S, T any](cfg Config, key string, current func(*S) bool, summarize func(*T) S) (*S, error) {
+	readFile := ssCacheReader(cfg)
+	return ssLoad(cfg, key, func(maxAge time.Duration) (*S, error) {
+		return ssReadSummaryMaxAge(readFile, cfg.CacheDir, key, maxAge, current, summarize)
+	})
+}
+
+// ssLoad implements ssLoadCachedData with read, which reads key's cached
+// data if it is no older than maxAge.
+func ssLoad[T any](cfg Config, key string, read func(maxAge time.Duration) (*T, error)) (*T, error) {
+	if cfg.Staleness != (cache.Staleness{}) {
+		// Old data is marked by ssRenderSegment rather than hidden.
+		return read(cfg.Staleness.ExpireAfter)
+	}
+	v, err := read(ssMaxCacheAge)
+	if v != nil || err != nil || cfg.Refresh == nil {
+		return v, err
+	}
+
+	budget := cfg.RefreshBudget
+	if budget <= 0 {
+		budget = ssDefaultRefreshBudget
+	}
+	wait := cfg.RefreshWait
+	if wait <= 0 {
+		wait = ssDefaultRefreshWait
+	}
+
+	fresh := func() bool { return ssIsFresh(cfg.CacheDir, key) }
+	refresh := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), budget)
+		defer cancel()
+		return cfg.Refresh(ctx, key)
+	}
+
+	opts := cache.LeaseOptions{Wait: wait}
+	res, _ := cache.Coalesce(cfg.CacheDir, "starship:"+key, opts, fresh, refresh)
+	if res != cache.CoalesceStale {
+		if v, err := read(ssMaxCacheAge); v != nil || err != nil {
+			return v, err
+		}
+	}
+
+	// Serve whatever we have, however old.
+	return read(0)
+}
+
+// ssIsFresh reports whether the cache file for key exists and is younger
+// than ssMaxCacheAge. It only stats the file.
+func ssIsFresh(cacheDir, key string) bool {
+	info, err := os.Stat(filepath.Join(cacheDir, key+".json"))
+	if err != nil {
+		return false
+	}
+	return time.Since(info.ModTime()) <= ssMaxCacheAge
+}
+
+// CacheMTime returns the newest modification time among CacheFiles(cfg),
+// or the zero time if none exist. It only stats the files, so callers can
+// poll it and re-render only when the result changes.
+func CacheMTime(cfg Config) time.Time {
+	var newest time.Time
+	for _, path := range CacheFiles(cfg) {
+		info, err := os.Stat(path)
+		if err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest
+}
+
+// CacheFiles returns the paths of the cache files read by the segments
+// enabled in cfg, whether or not they exist yet, and the kubeconfig files
+// when the k8s segment is enabled.
+func CacheFiles(cfg Config) []string {
+	var files []string
+	for _, s := range []struct {
+		on  bool
+		key string
+	}{
+		{cfg.ShowClaude, "claude"},
+		{cfg.ShowBilling, "billing"},
+		{cfg.ShowTailscale, "tailscale"},
+		{cfg.ShowUptimeKuma, "uptimekuma"},
+		{cfg.ShowChecks, "checks"},
+		{cfg.ShowRemote, "remote"},
+		{cfg.ShowDocker, "docker"},
+		{cfg.ShowStorage, "storage"},
+		{cfg.ShowK8s, "k8s"},
+		{cfg.ShowSystem, "sysmetrics"},
+		{cfg.ShowWeather, "weather"},
+	} {
+		if s.on {
+			files = append(files, filepath.Join(cfg.CacheDir, s.key+".json"))
+		}
+	}
+	if cfg.ShowK8s {
+		files = append(files, cfg.KubeconfigFiles...)
+	}
+	return files
+}
@@ -0,0 +1,128 @@
+package starship
+
+import (
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
+)
+
+// ssLevel is the status a segment is colored by.
+type ssLevel int
+
+const (
+	ssLevelOK ssLevel = iota
+	ssLevelWarn
+	ssLevelCritical
+)
+
+// Thresholds are the usage percentages at which a segment turns to its
+// warning and critical colors. The zero value uses DefaultThresholds.
+type Thresholds struct {
+	Warn     float64
+	Critical float64
+}
+
+// DefaultThresholds turn a segment yellow at 50% and red at 80%.
+var DefaultThresholds = Thresholds{Warn: 50, Critical: 80}
+
+// level returns the status for a usage percentage.
+func (t Thresholds) level(pct float64) ssLevel {
+	if t == (Thresholds{}) {
+		t = DefaultThresholds
+	}
+	switch {
+	case pct >= t.Critical:
+		return ssLevelCritical
+	case pct >= t.Warn:
+		return ssLevelWarn
+	default:
+		return ssLevelOK
+	}
+}
+
+// Palette holds the ANSI escape sequence that sets the foreground color for
+// each status. Empty fields use the basic ANSI green, yellow, and red.
+type Palette struct {
+	OK       string
+	Warn     string
+	Critical string
+}
+
+// ThemePalette returns the status colors of t as true-color sequences.
+func ThemePalette(t theme.Theme) Palette {
+	return Palette{
+		OK:       theme.Foreground(t.StatusOK),
+		Warn:     theme.Foreground(t.StatusWarn),
+		Critical: theme.Foreground(t.StatusError),
+	}
+}
+
+// ssColor returns the escape sequence for l in cfg's palette.
+func (cfg Config) ssColor(l ssLevel) string {
+	switch l {
+	case ssLevelCritical:
+		if cfg.Palette.Critical != "" {
+			return cfg.Palette.Critical
+		}
+		return ssColorRed
+	case ssLevelWarn:
+		if cfg.Palette.Warn != "" {
+			return cfg.Palette.Warn
+		}
+		return ssColorYellow
+	default:
+		if cfg.Palette.OK != "" {
+			return cfg.Palette.OK
+		}
+		return ssColorGreen
+	}
+}
+
+// Shells whose prompt escapes Config.Wrap can target.
+const (
+	WrapBash = "bash"
+	WrapZsh  = "zsh"
+)
+
+// ssWrapEscapes marks every ANSI escape sequence in s as zero-width for
+// shell so prompt width is computed correctly when s is placed in PS1
+// (bash) or PROMPT (zsh) directly. For zsh, literal "%" is escaped too.
+// Any other shell returns s unchanged; Starship wraps the sequences in
+// custom module output itself.
+func ssWrapEscapes(s, shell string) string {
+	var open, end string
+	switch shell {
+	case WrapBash:
+		open, end = "\x01", "\x02"
+	case WrapZsh:
+		open, end = "%{", "%}"
+		s = strings.ReplaceAll(s, "%", "%%")
+	default:
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for {
+		i := strings.Index(s, "\033[")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		j := i + 2
+		for j < len(s) && (s[j] < 0x40 || s[j] > 0x7E) {
+			j++
+		}
+		if j == len(s) {
+			// Unterminated sequence: leave it out rather than emit a
+			// prefix the terminal would swallow the prompt into.
+			b.WriteString(s[:i])
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		b.WriteString(open)
+		b.WriteString(s[i : j+1])
+		b.WriteString(end)
+		s = s[j+1:]
+	}
+}
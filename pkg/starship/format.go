@@ -1,8 +1,11 @@
 package starship
 
 import (
+	"slices"
 	"strings"
-	"unicode/utf8"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // ssAnsiReset is the ANSI escape sequence to reset all text attributes.
@@ -11,20 +14,24 @@ const ssAnsiReset = "\033[0m"
 // ssSeparator is the dim separator character placed between segments.
 const ssSeparator = "\033[2m│\033[0m"
 
+// ssEllipsis ends a segment truncated to fit the width budget.
+const ssEllipsis = "…"
+
 // ssColorize wraps text in the given ANSI color code and appends a reset
-// sequence. If color is empty, text is returned unmodified.
+// sequence. If color is empty or render.Current disallows color, text is
+// returned unmodified.
 func ssColorize(text, color string) string {
-	if color == "" {
+	if color == "" || !render.Current.Color {
 		return text
 	}
 	return color + text + ssAnsiReset
 }
 
-// ssVisibleWidth returns the number of visible (non-ANSI-escape) runes in s.
-// It strips CSI sequences (ESC [ ... final byte) before counting.
+// ssVisibleWidth returns the number of terminal cells s occupies, ignoring
+// ANSI escape sequences and counting wide characters such as emoji and CJK
+// as two.
 func ssVisibleWidth(s string) int {
-	stripped := ssStripAnsi(s)
-	return utf8.RuneCountInString(stripped)
+	return components.VisibleLen(s)
 }
 
 // ssStripAnsi removes ANSI CSI escape sequences (ESC [ ... final) from s.
@@ -67,62 +74,92 @@ func ssStripAnsi(s string) string {
 	return b.String()
 }
 
+// ssLineSeparator joins segments in Render, and ssLineSeparatorWidth is
+// its visible width.
+const (
+	ssLineSeparator      = " " + ssSeparator + " "
+	ssLineSeparatorWidth = 3
+)
+
 // ssFormatLine joins the given segments with a dim separator, applies ANSI
-// colors, and drops rightmost segments if the total visible width exceeds
-// maxWidth. Returns an empty string if segments is empty.
+// colors, and fits the line within maxWidth visible cells. Over it, segment
+// details are dropped first, lowest priority first and the rightmost first
+// among equals. Then segments are kept from the left while they fit, and
+// the first that does not is ellipsized into the rest of the line when
+// there is room for its icon and a character. Returns an empty string if
+// segments is empty.
 func ssFormatLine(segments []*Segment, maxWidth int) string {
 	if len(segments) == 0 {
 		return ""
 	}
 
 	if maxWidth <= 0 {
-		maxWidth = 60
+		maxWidth = ssDefaultMaxWidth
 	}
 
-	// Build each segment's rendered form and record its visible width.
-	type rendered struct {
-		text         string
-		visibleWidth int
+	// Drop details from copies, leaving the callers' segments as they are.
+	segs := make([]Segment, len(segments))
+	for i, seg := range segments {
+		segs[i] = *seg
+		segs[i].Details = slices.Clone(seg.Details)
 	}
-
-	parts := make([]rendered, 0, len(segments))
-	for _, seg := range segments {
-		full := seg.Icon + " " + seg.Text
-		colored := ssColorize(full, seg.Color)
-		parts = append(parts, rendered{
-			text:         colored,
-			visibleWidth: ssVisibleWidth(colored),
-		})
+	for ssLineWidth(segs) > maxWidth && ssDropDetail(segs) {
 	}
 
-	// Separator visible width is 1 character (the pipe).
-	const sepWidth = 1
-
-	// Greedily include segments left-to-right until maxWidth is exceeded.
-	var included []rendered
-	totalVisible := 0
-	for i, p := range parts {
-		needed := p.visibleWidth
+	var b strings.Builder
+	used := 0
+	for i := range segs {
+		seg := &segs[i]
+		text := ssColorize(seg.Icon+" "+seg.fullText(), seg.Color)
+		room := maxWidth - used
+		if i > 0 {
+			room -= ssLineSeparatorWidth
+		}
+		width := ssVisibleWidth(text)
+		fits := width <= room
+		if !fits {
+			if room < ssVisibleWidth(seg.Icon)+3 {
+				break
+			}
+			text = components.TruncateWithTail(text, room, ssEllipsis)
+			width = ssVisibleWidth(text)
+		}
 		if i > 0 {
-			needed += sepWidth + 2 // separator + surrounding spaces
+			b.WriteString(ssLineSeparator)
+			used += ssLineSeparatorWidth
 		}
-		if totalVisible+needed > maxWidth {
+		b.WriteString(text)
+		used += width
+		if !fits {
 			break
 		}
-		included = append(included, p)
-		totalVisible += needed
 	}
+	return b.String()
+}
 
-	if len(included) == 0 {
-		return ""
+// ssLineWidth returns the visible width of segs joined by ssFormatLine.
+func ssLineWidth(segs []Segment) int {
+	width := ssLineSeparatorWidth * (len(segs) - 1)
+	for i := range segs {
+		width += ssVisibleWidth(segs[i].Icon + " " + segs[i].fullText())
 	}
+	return width
+}
 
-	var b strings.Builder
-	for i, p := range included {
-		if i > 0 {
-			b.WriteString(" " + ssSeparator + " ")
+// ssDropDetail removes the lowest-priority detail from segs, the rightmost
+// among equals, and reports whether there was one.
+func ssDropDetail(segs []Segment) bool {
+	seg, at := -1, -1
+	for i := len(segs) - 1; i >= 0; i-- {
+		for j := len(segs[i].Details) - 1; j >= 0; j-- {
+			if seg < 0 || segs[i].Details[j].Priority < segs[seg].Details[at].Priority {
+				seg, at = i, j
+			}
 		}
-		b.WriteString(p.text)
 	}
-	return b.String()
+	if seg < 0 {
+		return false
+	}
+	segs[seg].Details = slices.Delete(segs[seg].Details, at, at+1)
+	return true
 }
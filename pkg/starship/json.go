@@ -0,0 +1,490 @@
+package starship
+
+import (
+	"encoding/json"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/remote"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/storage"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+)
+
+// JSONOutput is the structured counterpart to Render for consumers other than
+// Starship (tmux status scripts, editors, etc). The schema is stable: fields
+// may be added but existing fields are never renamed or removed. Sections for
+// disabled segments or missing cache data are omitted entirely, so an empty
+// cache serializes to {}.
+type JSONOutput struct {
+	Claude  *ClaudeJSON  `json:"claude,omitempty"`
+	Billing *BillingJSON `json:"billing,omitempty"`
+	Infra   *InfraJSON   `json:"infra,omitempty"`
+	K8s     *K8sJSON     `json:"k8s,omitempty"`
+	System  *SystemJSON  `json:"system,omitempty"`
+}
+
+// ClaudeJSON summarizes Claude usage across all accounts.
+type ClaudeJSON struct {
+	TotalCostUSD float64             `json:"total_cost_usd"`
+	TopModel     string              `json:"top_model,omitempty"`
+	ResetsAt     time.Time           `json:"resets_at"`
+	Accounts     []ClaudeAccountJSON `json:"accounts"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// UnpricedModels lists models priced at the default rate, whose
+	// costs are estimates.
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
+}
+
+// ClaudeAccountJSON holds token counts for a single Claude account.
+type ClaudeAccountJSON struct {
+	Name         string  `json:"name"`
+	Connected    bool    `json:"connected"`
+	Error        string  `json:"error,omitempty"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+
+	// SessionsCostUSD is the estimated cost of the account's recent
+	// Claude Code sessions.
+	SessionsCostUSD float64 `json:"sessions_cost_usd,omitempty"`
+}
+
+// BillingJSON holds cloud spend totals per provider. Amounts are in
+// Currency, despite the USD in their names. Subtotals replaces the total
+// when some spend could not be converted to Currency.
+type BillingJSON struct {
+	Currency        string                `json:"currency"`
+	TotalMonthlyUSD float64               `json:"total_monthly_usd"`
+	Subtotals       map[string]float64    `json:"subtotals,omitempty"`
+	BudgetUSD       float64               `json:"budget_usd,omitempty"`
+	BudgetPercent   float64               `json:"budget_percent,omitempty"`
+	Providers       []BillingProviderJSON `json:"providers"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+// BillingProviderJSON holds the month-to-date spend for one provider, in
+// Currency. A provider billing in another currency that was converted
+// also has its spend in NativeCurrency. The budget fields are present
+// only when the provider has a budget.
+type BillingProviderJSON struct {
+	Name              string  `json:"name"`
+	Status            string  `json:"status"` // "ok" or "error"
+	Error             string  `json:"error,omitempty"`
+	Currency          string  `json:"currency"`
+	MonthToDate       float64 `json:"month_to_date_usd"`
+	NativeCurrency    string  `json:"native_currency,omitempty"`
+	NativeMonthToDate float64 `json:"native_month_to_date,omitempty"`
+	BudgetUSD         float64 `json:"budget_usd,omitempty"`
+	BudgetPercent     float64 `json:"budget_percent,omitempty"`
+	BudgetStatus      string  `json:"budget_status,omitempty"` // "ok", "warn", or "critical"
+}
+
+// InfraJSON reports per-check infrastructure status.
+type InfraJSON struct {
+	Online    int              `json:"online"`
+	Total     int              `json:"total"`
+	Checks    []InfraCheckJSON `json:"checks"`
+	UpdatedAt time.Time        `json:"updated_at"`
+
+	// Groups rolls up the configured checks by the service they belong
+	// to, set only when a check names a group.
+	Groups []InfraGroupJSON `json:"groups,omitempty"`
+
+	// Tailscale node details, set only when Tailscale data is present.
+	ExitNode        string     `json:"exit_node,omitempty"`
+	KeyExpiry       *time.Time `json:"key_expiry,omitempty"`
+	KeyExpiringSoon bool       `json:"key_expiring_soon,omitempty"`
+}
+
+// InfraCheckJSON is the status of a single infrastructure check.
+type InfraCheckJSON struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // collector that produced the check
+	Status string `json:"status"` // "up" or "down"; Uptime Kuma adds "pending" and "maintenance", storage "warn" and "timeout"
+	Group  string `json:"group,omitempty"`
+}
+
+// InfraGroupJSON is the rolled-up status of the checks of one service.
+type InfraGroupJSON struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "ok", "warn", or "down"
+	Up     int    `json:"up"`
+	Total  int    `json:"total"`
+}
+
+// K8sJSON reports pod health per cluster. Health is the worst cluster
+// level and Summary reads like "2 clusters, 1 warning".
+type K8sJSON struct {
+	Health    string           `json:"health,omitempty"`
+	Summary   string           `json:"summary"`
+	Clusters  []K8sClusterJSON `json:"clusters"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// K8sClusterJSON holds pod counts and health for one kubeconfig context.
+type K8sClusterJSON struct {
+	Context       string   `json:"context"`
+	Connected     bool     `json:"connected"`
+	TotalPods     int      `json:"total_pods"`
+	RunningPods   int      `json:"running_pods"`
+	FailedPods    int      `json:"failed_pods"`
+	Health        string   `json:"health,omitempty"`
+	HealthReasons []string `json:"health_reasons,omitempty"`
+}
+
+// SystemJSON holds the headline host utilization figures.
+type SystemJSON struct {
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RenderJSON reads cached data and returns it as a JSON document following
+// the JSONOutput schema. Missing data never produces an error; the result
+// is simply "{}".
+func RenderJSON(cfg Config) ([]byte, error) {
+	return json.Marshal(Collect(cfg))
+}
+
+// Collect reads the cached data for every enabled segment and returns the
+// structured form used by RenderJSON.
+func Collect(cfg Config) JSONOutput {
+	var out JSONOutput
+
+	if cfg.ShowClaude {
+		if r, _ := ssLoadCachedData[claude.UsageReport](cfg, "claude"); r != nil {
+			out.Claude = ssClaudeJSON(r)
+		}
+	}
+	if cfg.ShowBilling {
+		if r, _ := ssLoadCachedData[billing.BillingReport](cfg, "billing"); r != nil {
+			out.Billing = ssBillingJSON(r)
+		}
+	}
+	if cfg.ShowTailscale {
+		if s, _ := ssLoadCachedData[tailscale.Status](cfg, "tailscale"); s != nil {
+			out.Infra = ssInfraJSON(s)
+		}
+	}
+	if cfg.ShowUptimeKuma {
+		if s, _ := ssLoadCachedData[uptimekuma.Status](cfg, "uptimekuma"); s != nil {
+			out.Infra = ssAppendUptimeKumaJSON(out.Infra, s)
+		}
+	}
+	if cfg.ShowChecks {
+		if s, _ := ssLoadCachedData[checks.Status](cfg, "checks"); s != nil {
+			out.Infra = ssAppendChecksJSON(out.Infra, s)
+		}
+	}
+	if cfg.ShowRemote {
+		if s, _ := ssLoadCachedData[remote.Status](cfg, "remote"); s != nil {
+			out.Infra = ssAppendRemoteJSON(out.Infra, s)
+		}
+	}
+	if cfg.ShowDocker {
+		if s, _ := ssLoadCachedData[docker.Status](cfg, "docker"); s != nil && s.Available {
+			out.Infra = ssAppendDockerJSON(out.Infra, s)
+		}
+	}
+	if cfg.ShowStorage {
+		if s, _ := ssLoadCachedData[storage.Status](cfg, "storage"); s != nil {
+			out.Infra = ssAppendStorageJSON(out.Infra, s)
+		}
+	}
+	if cfg.ShowK8s {
+		if s, _ := ssLoadCachedData[k8s.ClusterStatus](cfg, "k8s"); s != nil {
+			out.K8s = ssK8sJSON(s)
+		}
+	}
+	if cfg.ShowSystem {
+		if m, _ := ssLoadCachedData[sysmetrics.Metrics](cfg, "sysmetrics"); m != nil {
+			out.System = &SystemJSON{
+				CPUPercent:    m.CPU.Total,
+				MemoryPercent: m.Memory.UsedPercent,
+				UpdatedAt:     m.Timestamp,
+			}
+		}
+	}
+
+	return out
+}
+
+// ssClaudeJSON converts a usage report into its JSON summary. Usage limits
+// reset at the start of each calendar month.
+func ssClaudeJSON(r *claude.UsageReport) *ClaudeJSON {
+	out := &ClaudeJSON{
+		TotalCostUSD: r.TotalCostUSD,
+		Accounts:     make([]ClaudeAccountJSON, 0, len(r.Accounts)),
+		UpdatedAt:    r.Timestamp,
+	}
+	if models := ssAllModels(r); len(models) > 0 {
+		out.TopModel = models[0]
+	}
+	_, _, out.UnpricedModels = r.Spend()
+	if !r.Timestamp.IsZero() {
+		y, m, _ := r.Timestamp.Date()
+		out.ResetsAt = time.Date(y, m+1, 1, 0, 0, 0, 0, r.Timestamp.Location())
+	}
+	for _, a := range r.Accounts {
+		out.Accounts = append(out.Accounts, ClaudeAccountJSON{
+			Name:         a.Name,
+			Connected:    a.Connected,
+			Error:        a.Error,
+			InputTokens:  a.CurrentMonth.InputTokens,
+			OutputTokens: a.CurrentMonth.OutputTokens,
+			CostUSD:      a.CurrentMonth.CostUSD,
+
+			SessionsCostUSD: a.SessionsCostUSD,
+		})
+	}
+	return out
+}
+
+// ssBillingJSON converts a billing report into its JSON summary.
+func ssBillingJSON(r *billing.BillingReport) *BillingJSON {
+	out := &BillingJSON{
+		Currency:        r.DisplayCurrency(),
+		TotalMonthlyUSD: r.TotalMonthlyUSD,
+		Subtotals:       r.Subtotals,
+		BudgetUSD:       r.BudgetUSD,
+		BudgetPercent:   r.BudgetPercent,
+		Providers:       make([]BillingProviderJSON, 0, len(r.Providers)),
+		UpdatedAt:       r.Timestamp,
+	}
+	for _, p := range r.Providers {
+		status := "ok"
+		if !p.Connected {
+			status = "error"
+		}
+		currency := p.Currency
+		if currency == "" {
+			currency = out.Currency
+		}
+		out.Providers = append(out.Providers, BillingProviderJSON{
+			Name:              p.Name,
+			Status:            status,
+			Error:             p.Error,
+			Currency:          currency,
+			MonthToDate:       p.MonthToDate,
+			NativeCurrency:    p.NativeCurrency,
+			NativeMonthToDate: p.NativeMonthToDate,
+			BudgetUSD:         p.BudgetUSD,
+			BudgetPercent:     p.BudgetPercent,
+			BudgetStatus:      p.BudgetStatus,
+		})
+	}
+	return out
+}
+
+// ssInfraJSON converts Tailscale peer status into per-check infra status.
+func ssInfraJSON(s *tailscale.Status) *InfraJSON {
+	out := &InfraJSON{
+		Online:          s.OnlinePeers,
+		Total:           s.TotalPeers,
+		Checks:          make([]InfraCheckJSON, 0, len(s.Peers)),
+		UpdatedAt:       s.Timestamp,
+		KeyExpiry:       s.Self.KeyExpiry,
+		KeyExpiringSoon: s.KeyExpiringSoon,
+	}
+	if s.ExitNode != nil {
+		out.ExitNode = s.ExitNode.Hostname
+	}
+	for _, p := range s.Peers {
+		status := "down"
+		if p.Online {
+			status = "up"
+		}
+		out.Checks = append(out.Checks, InfraCheckJSON{
+			Name:   p.Hostname,
+			Source: "tailscale",
+			Status: status,
+		})
+	}
+	return out
+}
+
+// ssAppendUptimeKumaJSON adds Uptime Kuma monitors to the infra checks,
+// creating the infra section if Tailscale did not. Only monitors that are
+// up or down are counted toward Online/Total; pending and maintenance
+// monitors are listed with their own status.
+func ssAppendUptimeKumaJSON(out *InfraJSON, s *uptimekuma.Status) *InfraJSON {
+	if out == nil {
+		out = &InfraJSON{Checks: make([]InfraCheckJSON, 0, len(s.Monitors))}
+	}
+	if s.Timestamp.After(out.UpdatedAt) {
+		out.UpdatedAt = s.Timestamp
+	}
+	for _, m := range s.Monitors {
+		switch m.State {
+		case uptimekuma.StateUp:
+			out.Online++
+			out.Total++
+		case uptimekuma.StateDown:
+			out.Total++
+		}
+		out.Checks = append(out.Checks, InfraCheckJSON{
+			Name:   m.Name,
+			Source: "uptimekuma",
+			Status: m.State,
+		})
+	}
+	return out
+}
+
+// ssAppendChecksJSON adds the configured checks to the infra checks,
+// creating the infra section if no other source did. Pending checks are
+// listed but not counted toward Online/Total.
+func ssAppendChecksJSON(out *InfraJSON, s *checks.Status) *InfraJSON {
+	if out == nil {
+		out = &InfraJSON{Checks: make([]InfraCheckJSON, 0, len(s.Checks))}
+	}
+	if s.Timestamp.After(out.UpdatedAt) {
+		out.UpdatedAt = s.Timestamp
+	}
+	for _, r := range s.Checks {
+		switch r.State {
+		case checks.StateUp:
+			out.Online++
+			out.Total++
+		case checks.StateDown:
+			out.Total++
+		}
+		out.Checks = append(out.Checks, InfraCheckJSON{
+			Name:   r.Name,
+			Source: "checks",
+			Status: r.State,
+			Group:  r.Group,
+		})
+	}
+	if s.Grouped() {
+		for _, g := range s.Groups() {
+			out.Groups = append(out.Groups, InfraGroupJSON{Name: g.Name, Status: g.State, Up: g.Up, Total: g.Total})
+		}
+	}
+	return out
+}
+
+// ssAppendRemoteJSON adds the hosts queried over SSH to the infra checks.
+// Pending hosts are listed but not counted toward Online/Total.
+func ssAppendRemoteJSON(out *InfraJSON, s *remote.Status) *InfraJSON {
+	if out == nil {
+		out = &InfraJSON{Checks: make([]InfraCheckJSON, 0, len(s.Hosts))}
+	}
+	if s.Timestamp.After(out.UpdatedAt) {
+		out.UpdatedAt = s.Timestamp
+	}
+	for _, h := range s.Hosts {
+		switch h.State {
+		case remote.StateUp:
+			out.Online++
+			out.Total++
+		case remote.StateDown:
+			out.Total++
+		}
+		out.Checks = append(out.Checks, InfraCheckJSON{
+			Name:   h.Name,
+			Source: "remote",
+			Status: h.State,
+		})
+	}
+	return out
+}
+
+// ssAppendDockerJSON adds running Docker containers to the infra checks. A
+// container is "down" when its healthcheck reports unhealthy; stopped
+// containers are omitted since they are usually one-shot jobs.
+func ssAppendDockerJSON(out *InfraJSON, s *docker.Status) *InfraJSON {
+	if out == nil {
+		out = &InfraJSON{Checks: make([]InfraCheckJSON, 0, s.Running)}
+	}
+	if s.Timestamp.After(out.UpdatedAt) {
+		out.UpdatedAt = s.Timestamp
+	}
+	for _, c := range s.Containers {
+		if c.State != docker.StateRunning {
+			continue
+		}
+		status := "up"
+		if c.Health == docker.HealthUnhealthy {
+			status = "down"
+		} else {
+			out.Online++
+		}
+		out.Total++
+		out.Checks = append(out.Checks, InfraCheckJSON{
+			Name:   c.Name,
+			Source: "docker",
+			Status: status,
+		})
+	}
+	return out
+}
+
+// ssAppendStorageJSON adds mounts and drives to the infra checks. A mount
+// is "up" while below its warn threshold, "warn" above it, "down" above
+// its critical threshold, and "timeout" or "error" when it could not be
+// read; a drive is "down" when it fails SMART and "warn" with reallocated
+// sectors. Drives whose health could not be read are left out. Only up and
+// down checks count toward Online/Total.
+func ssAppendStorageJSON(out *InfraJSON, s *storage.Status) *InfraJSON {
+	if out == nil {
+		out = &InfraJSON{Checks: make([]InfraCheckJSON, 0, len(s.Mounts)+len(s.Disks))}
+	}
+	if s.Timestamp.After(out.UpdatedAt) {
+		out.UpdatedAt = s.Timestamp
+	}
+	add := func(name, status string) {
+		switch status {
+		case storage.StatusOK:
+			status = "up"
+			out.Online++
+			out.Total++
+		case storage.StatusCritical:
+			status = "down"
+			out.Total++
+		}
+		out.Checks = append(out.Checks, InfraCheckJSON{
+			Name:   name,
+			Source: "storage",
+			Status: status,
+		})
+	}
+	for _, m := range s.Mounts {
+		add(m.Mountpoint, m.Status)
+	}
+	for _, d := range s.Disks {
+		if d.Passed != nil {
+			add(d.Device, d.Level())
+		}
+	}
+	return out
+}
+
+// ssK8sJSON converts cluster status into per-cluster pod counts and health.
+func ssK8sJSON(s *k8s.ClusterStatus) *K8sJSON {
+	out := &K8sJSON{
+		Health:    string(s.Health),
+		Summary:   s.HealthSummary(),
+		Clusters:  make([]K8sClusterJSON, 0, len(s.Clusters)),
+		UpdatedAt: s.Timestamp,
+	}
+	for _, c := range s.Clusters {
+		out.Clusters = append(out.Clusters, K8sClusterJSON{
+			Context:       c.Context,
+			Connected:     c.Connected,
+			TotalPods:     c.TotalPods,
+			RunningPods:   c.RunningPods,
+			FailedPods:    c.FailedPods,
+			Health:        string(c.Health),
+			HealthReasons: c.HealthReasons,
+		})
+	}
+	return out
+}
@@ -4,15 +4,23 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/remote"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/storage"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/weather"
 )
 
-// ANSI color constants used for segment thresholds.
+// Basic ANSI colors used for segment statuses when Config.Palette does not
+// set them.
 const (
 	ssColorGreen  = "\033[32m"
 	ssColorYellow = "\033[33m"
@@ -24,47 +32,91 @@ const (
 const ssBudgetDefault = 500.0
 
 // ssClaudeSegment renders the Claude/Anthropic cost segment. It shows the
-// current month's total cost and the top model by spend.
-// Example: "🤖 $142.30 opus"
-func ssClaudeSegment(cacheDir string) *Segment {
-	report, err := ssReadCachedData[claude.UsageReport](cacheDir, "claude")
+// estimated cost, tokens, or both per cfg.ClaudeDisplay, and the top model
+// by spend, plus a warning when an account is forecast to reach its window
+// limit within cfg.ClaudeWarnWithin. A cost that includes models priced at
+// the default rate is marked with "*".
+// Example: "🤖 $142.30 opus ⚠ 23m", "🤖 $3.10* 1.2M sonnet"
+func ssClaudeSegment(cfg Config) *Segment {
+	report, err := ssLoadSummary(cfg, "claude", (*claude.Summary).Current, claude.NewSummary)
 	if err != nil || report == nil {
 		return nil
 	}
 
-	cost := report.TotalCostUSD
-
-	// Find the top model across all accounts.
-	topModel := ""
-	var topCost float64
-	for _, acct := range report.Accounts {
-		for _, m := range acct.Models {
-			if m.CostUSD > topCost {
-				topCost = m.CostUSD
-				topModel = m.Model
-			}
-		}
-	}
+	tokens, cost := report.Tokens, report.CostUSD
 
 	// Shorten model name: take the last segment after "claude-" prefix and
 	// strip version suffixes for brevity.
-	topModel = ssShortModelName(topModel)
+	topModel := ssShortModelName(report.TopModel)
 
-	text := fmt.Sprintf("$%.2f", cost)
+	costText := fmt.Sprintf("$%.2f", cost)
+	if len(report.UnpricedModels) > 0 {
+		costText += "*"
+	}
+	var text string
+	switch cfg.ClaudeDisplay {
+	case ClaudeDisplayTokens:
+		text = ssFormatTokens(tokens)
+	case ClaudeDisplayBoth:
+		text = costText + " " + ssFormatTokens(tokens)
+	default:
+		text = costText
+	}
+	var details []Detail
 	if topModel != "" {
-		text += " " + topModel
+		details = append(details, Detail{Text: topModel, Priority: ssPriorityInfo})
 	}
 
-	// Color based on percentage of budget.
-	color := ssThresholdColor(cost, ssBudgetDefault)
+	// Color by the fullest usage window, or by spend against the default
+	// budget when no account reports one.
+	pct, ok := report.WindowPercent, report.HasWindow
+	if !ok {
+		pct = cost / ssBudgetDefault * 100
+	}
+	level := cfg.ClaudeThresholds.level(pct)
+
+	if cfg.ClaudeWarnWithin > 0 {
+		if name, left, ok := report.SoonestLimit(time.Now()); ok && left < cfg.ClaudeWarnWithin {
+			details = append(details, Detail{Text: ssClaudeLimitWarning(name, left, report.Accounts > 1), Priority: ssPriorityWarn})
+			level = ssLevelCritical
+		}
+	}
 
 	return &Segment{
-		Icon:  "🤖",
-		Text:  text,
-		Color: color,
+		Icon:    "🤖",
+		Text:    text,
+		Color:   cfg.ssColor(level),
+		Details: details,
 	}
 }
 
+// ssFormatTokens formats a token count with an SI suffix.
+// Example: "950", "12.3K", "1.2M"
+func ssFormatTokens(tokens int64) string {
+	v := float64(tokens)
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("%.1fG", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+}
+
+// ssClaudeLimitWarning formats the time until an account's projected window
+// limit, naming the account only when there are several.
+// Example: "⚠ 23m", "⚠ work 23m"
+func ssClaudeLimitWarning(account string, left time.Duration, named bool) string {
+	warn := "⚠"
+	if named {
+		warn += " " + account
+	}
+	return fmt.Sprintf("%s %dm", warn, int(left.Minutes()))
+}
+
 // ssShortModelName shortens a Claude model identifier for display.
 // "claude-3-5-sonnet-20241022" -> "sonnet"
 // "claude-opus-4-20250514" -> "opus"
@@ -93,35 +145,46 @@ func ssShortModelName(model string) string {
 }
 
 // ssBillingSegment renders the cloud billing segment showing total monthly
-// spend across all configured providers.
-// Example: "☁️ $23.45/mo"
-func ssBillingSegment(cacheDir string) *Segment {
-	report, err := ssReadCachedData[billing.BillingReport](cacheDir, "billing")
+// spend across all configured providers, in the report currency, or as
+// per-currency subtotals when some spend could not be converted.
+// Example: "☁️ $23.45/mo", "☁️ $12.00 + €8.50/mo"
+func ssBillingSegment(cfg Config) *Segment {
+	report, err := ssLoadSummary(cfg, "billing", (*billing.Summary).Current, billing.NewSummary)
 	if err != nil || report == nil {
 		return nil
 	}
 
-	text := fmt.Sprintf("$%.2f/mo", report.TotalMonthlyUSD)
+	text := report.FormatTotal() + "/mo"
 
-	// Use budget-based color if budget is set, otherwise use absolute thresholds.
-	var color string
-	if report.BudgetUSD > 0 {
-		color = ssThresholdColor(report.TotalMonthlyUSD, report.BudgetUSD)
-	} else {
-		color = ssThresholdColor(report.TotalMonthlyUSD, 100.0)
+	// Color by spend as a percentage of the budget, or of $100 when no
+	// budget is set.
+	budget := report.BudgetUSD
+	if budget <= 0 {
+		budget = 100.0
+	}
+	level := cfg.BillingThresholds.level(report.TotalMonthlyUSD / budget * 100)
+
+	// Any provider over its own budget threshold raises the total level.
+	switch report.BudgetStatus {
+	case billing.BudgetCritical:
+		level = ssLevelCritical
+	case billing.BudgetWarn:
+		level = max(level, ssLevelWarn)
 	}
 
 	return &Segment{
 		Icon:  "☁️",
 		Text:  text,
-		Color: color,
+		Color: cfg.ssColor(level),
 	}
 }
 
-// ssTailscaleSegment renders the Tailscale peer connectivity segment.
-// Example: "🔗 3/5 peers"
-func ssTailscaleSegment(cacheDir string) *Segment {
-	status, err := ssReadCachedData[tailscale.Status](cacheDir, "tailscale")
+// ssTailscaleSegment renders the Tailscale peer connectivity segment. An
+// active exit node is appended, and an expiring node key turns an otherwise
+// green segment yellow.
+// Example: "🔗 3/5 peers → honey ⚠ key"
+func ssTailscaleSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[tailscale.Status](cfg, "tailscale")
 	if err != nil || status == nil {
 		return nil
 	}
@@ -131,36 +194,249 @@ func ssTailscaleSegment(cacheDir string) *Segment {
 
 	text := fmt.Sprintf("%d/%d peers", online, total)
 
-	var color string
+	var level ssLevel
 	if total == 0 {
-		color = ssColorYellow
+		level = ssLevelWarn
 	} else {
 		ratio := float64(online) / float64(total)
 		switch {
 		case ratio >= 1.0:
-			color = ssColorGreen
+			level = ssLevelOK
 		case ratio >= 0.5:
-			color = ssColorYellow
+			level = ssLevelWarn
 		default:
-			color = ssColorRed
+			level = ssLevelCritical
+		}
+	}
+
+	var details []Detail
+	if status.ExitNode != nil && status.ExitNode.Hostname != "" {
+		details = append(details, Detail{Text: "→ " + status.ExitNode.Hostname, Priority: ssPriorityInfo})
+	}
+	if status.KeyExpiringSoon {
+		details = append(details, Detail{Text: "⚠ key", Priority: ssPriorityWarn})
+		level = max(level, ssLevelWarn)
+	}
+
+	return &Segment{
+		Icon:    "🔗",
+		Text:    text,
+		Color:   cfg.ssColor(level),
+		Details: details,
+	}
+}
+
+// ssUptimeKumaSegment renders the Uptime Kuma monitor segment. Monitors in
+// maintenance or pending count toward the total but not as up.
+// Example: "🩺 12/13 up"
+func ssUptimeKumaSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[uptimekuma.Status](cfg, "uptimekuma")
+	if err != nil || status == nil || status.Total == 0 {
+		return nil
+	}
+
+	level := ssLevelOK
+	switch {
+	case status.Down > 0 && status.Up == 0:
+		level = ssLevelCritical
+	case status.Down > 0 || status.Up < status.Total:
+		level = ssLevelWarn
+	}
+
+	return &Segment{
+		Icon:  "🩺",
+		Text:  fmt.Sprintf("%d/%d up", status.Up, status.Total),
+		Color: cfg.ssColor(level),
+	}
+}
+
+// ssChecksSegment renders the configured checks segment. Checks that are
+// still pending count toward the total but not as up. When checks name
+// groups it counts services instead, with a detail for each one that is
+// not ok, so fourteen checks read as three services.
+// Example: "📡 5/6 up", "📡 2/3 ok website ⚠"
+func ssChecksSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[checks.Status](cfg, "checks")
+	if err != nil || status == nil || status.Total == 0 {
+		return nil
+	}
+	if status.Grouped() {
+		return ssChecksGroupSegment(cfg, status)
+	}
+
+	level := ssLevelOK
+	switch {
+	case status.Down > 0 && status.Up == 0:
+		level = ssLevelCritical
+	case status.Down > 0 || status.Up < status.Total:
+		level = ssLevelWarn
+	}
+
+	return &Segment{
+		Icon:  "📡",
+		Text:  fmt.Sprintf("%d/%d up", status.Up, status.Total),
+		Color: cfg.ssColor(level),
+	}
+}
+
+// ssChecksGroupSegment renders the checks segment rolled up by group.
+// Down groups are detailed with a higher priority than warning ones, so
+// they are the last dropped when the line is narrow.
+func ssChecksGroupSegment(cfg Config, status *checks.Status) *Segment {
+	groups := status.Groups()
+	ok := 0
+	var details []Detail
+	for _, g := range groups {
+		switch g.State {
+		case checks.GroupOK:
+			ok++
+		case checks.GroupWarn:
+			details = append(details, Detail{Text: g.Name + " ⚠", Priority: ssPriorityInfo})
+		case checks.GroupDown:
+			details = append(details, Detail{Text: g.Name + " ✗", Priority: ssPriorityWarn})
 		}
 	}
 
+	level := ssLevelOK
+	switch status.Overall() {
+	case checks.GroupDown:
+		level = ssLevelCritical
+	case checks.GroupWarn:
+		level = ssLevelWarn
+	}
+
+	return &Segment{
+		Icon:    "📡",
+		Text:    fmt.Sprintf("%d/%d ok", ok, len(groups)),
+		Color:   cfg.ssColor(level),
+		Details: details,
+	}
+}
+
+// ssRemoteSegment renders the segment for hosts queried over SSH. Hosts
+// that are still pending count toward the total but not as up.
+// Example: "🖥️ 2/3 up"
+func ssRemoteSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[remote.Status](cfg, "remote")
+	if err != nil || status == nil || status.Total == 0 {
+		return nil
+	}
+
+	level := ssLevelOK
+	switch {
+	case status.Down > 0 && status.Up == 0:
+		level = ssLevelCritical
+	case status.Down > 0 || status.Up < status.Total:
+		level = ssLevelWarn
+	}
+
 	return &Segment{
-		Icon:  "🔗",
+		Icon:  "🖥️",
+		Text:  fmt.Sprintf("%d/%d up", status.Up, status.Total),
+		Color: cfg.ssColor(level),
+	}
+}
+
+// ssDockerSegment renders the Docker container segment. It is hidden when
+// the daemon is not available or nothing is running.
+// Example: "🐳 4 running 1 unhealthy"
+func ssDockerSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[docker.Status](cfg, "docker")
+	if err != nil || status == nil || !status.Available || status.Running == 0 {
+		return nil
+	}
+
+	text := fmt.Sprintf("%d running", status.Running)
+	level := ssLevelOK
+	if status.Unhealthy > 0 {
+		text += fmt.Sprintf(" %d unhealthy", status.Unhealthy)
+		level = ssLevelCritical
+	}
+
+	return &Segment{
+		Icon:  "🐳",
 		Text:  text,
-		Color: color,
+		Color: cfg.ssColor(level),
 	}
 }
 
-// ssK8sSegment renders the Kubernetes pod health segment. It aggregates
-// pod counts across all clusters.
+// ssStorageSegment renders the mount that most needs attention: one over
+// its warn or critical threshold, or one that timed out, plus a warning
+// when a drive fails SMART or has reallocated sectors. It is hidden while
+// storage is ok, so the infra segment stays quiet until a disk fills up.
+// Example: "💾 / 92%", "💾 /mnt/nas timeout ⚠ SMART"
+func ssStorageSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[storage.Status](cfg, "storage")
+	if err != nil || status == nil || status.Level == "" || status.Level == storage.StatusOK {
+		return nil
+	}
+
+	var parts []string
+	if m, ok := ssWorstMount(status.Mounts); ok {
+		if m.Status == storage.StatusWarn || m.Status == storage.StatusCritical {
+			parts = append(parts, fmt.Sprintf("%s %d%%", m.Mountpoint, int(m.UsedPercent)))
+		} else {
+			parts = append(parts, m.Mountpoint+" "+m.Status)
+		}
+	}
+	for _, d := range status.Disks {
+		if d.Level() != storage.StatusOK {
+			parts = append(parts, "⚠ SMART")
+			break
+		}
+	}
+
+	level := ssLevelWarn
+	if status.Level == storage.StatusCritical {
+		level = ssLevelCritical
+	}
+
+	return &Segment{
+		Icon:  "💾",
+		Text:  strings.Join(parts, " "),
+		Color: cfg.ssColor(level),
+	}
+}
+
+// ssWorstMount returns the mount that most needs attention: critical over
+// warn over timed out or failed, the fuller first. It reports false when
+// every mount is ok.
+func ssWorstMount(mounts []storage.Mount) (storage.Mount, bool) {
+	rank := func(m storage.Mount) int {
+		switch m.Status {
+		case storage.StatusCritical:
+			return 3
+		case storage.StatusWarn:
+			return 2
+		case storage.StatusTimeout, storage.StatusError:
+			return 1
+		}
+		return 0
+	}
+	var worst storage.Mount
+	for _, m := range mounts {
+		if r, w := rank(m), rank(worst); r > w || (r == w && r > 0 && m.UsedPercent > worst.UsedPercent) {
+			worst = m
+		}
+	}
+	return worst, rank(worst) > 0
+}
+
+// ssK8sSegment renders the Kubernetes segment. With cfg.KubeContext set it
+// shows that context's cluster, see ssK8sContextSegment. Otherwise it
+// aggregates pod counts across all clusters and colors the glyph by the
+// worst of the pod counts and the collector's cluster health level, so a
+// NotReady node or under-replicated deployment turns it yellow even when
+// every pod runs.
 // Example: "⎈ 12/15 pods"
-func ssK8sSegment(cacheDir string) *Segment {
-	status, err := ssReadCachedData[k8s.ClusterStatus](cacheDir, "k8s")
+func ssK8sSegment(cfg Config) *Segment {
+	status, err := ssLoadCachedData[k8s.ClusterStatus](cfg, "k8s")
 	if err != nil || status == nil {
 		return nil
 	}
+	if cfg.KubeContext != "" {
+		return ssK8sContextSegment(cfg, status)
+	}
 
 	var totalPods, runningPods, failedPods int
 	for _, cluster := range status.Clusters {
@@ -172,34 +448,106 @@ func ssK8sSegment(cacheDir string) *Segment {
 		failedPods += cluster.FailedPods
 	}
 
-	if totalPods == 0 {
+	if totalPods == 0 && !status.Health.Worse(k8s.HealthOK) {
 		return nil
 	}
 
 	text := fmt.Sprintf("%d/%d pods", runningPods, totalPods)
+	if totalPods == 0 {
+		text = string(status.Health)
+	}
 
-	var color string
+	var level ssLevel
 	switch {
-	case failedPods > 0:
-		color = ssColorRed
-	case runningPods < totalPods:
-		color = ssColorYellow
+	case failedPods > 0, status.Health == k8s.HealthCritical:
+		level = ssLevelCritical
+	case runningPods < totalPods, status.Health == k8s.HealthWarning:
+		level = ssLevelWarn
 	default:
-		color = ssColorGreen
+		level = ssLevelOK
 	}
 
 	return &Segment{
 		Icon:  "⎈",
 		Text:  text,
-		Color: color,
+		Color: cfg.ssColor(level),
+	}
+}
+
+// ssK8sHealthGlyphs marks a cluster health level in the k8s segment.
+var ssK8sHealthGlyphs = map[k8s.HealthLevel]string{
+	k8s.HealthOK:       "✓",
+	k8s.HealthWarning:  "⚠",
+	k8s.HealthCritical: "✗",
+}
+
+// ssK8sContextSegment renders the cached health and ready/total node count
+// of the cluster for cfg.KubeContext. When the cache has no data for that
+// context, the context is dimmed and marked "?", and the glyph and counts
+// are those of the clusters the collector did see. An unreachable cluster
+// shows only a red ✗.
+// Example: "⎈ prod ✓ 5/5"
+func ssK8sContextSegment(cfg Config, status *k8s.ClusterStatus) *Segment {
+	name := cfg.KubeContext
+	health := status.Health
+	var clusters []k8s.ClusterInfo
+	for _, c := range status.Clusters {
+		if c.Context == cfg.KubeContext {
+			clusters = []k8s.ClusterInfo{c}
+			health = c.Health
+			break
+		}
+	}
+	if clusters == nil {
+		name = "\033[2m" + name + "?\033[22m"
+		clusters = status.Clusters
+	}
+	if len(clusters) == 1 && !clusters[0].Connected {
+		health = k8s.HealthCritical
+	}
+
+	var ready, total int
+	for _, c := range clusters {
+		if !c.Connected {
+			continue
+		}
+		for _, n := range c.Nodes {
+			total++
+			if n.Ready {
+				ready++
+			}
+		}
+	}
+
+	text := name
+	if glyph, ok := ssK8sHealthGlyphs[health]; ok {
+		text += " " + glyph
+	}
+	var details []Detail
+	if total > 0 {
+		details = append(details, Detail{Text: fmt.Sprintf("%d/%d", ready, total), Priority: ssPriorityInfo})
+	}
+
+	level := ssLevelOK
+	switch health {
+	case k8s.HealthCritical:
+		level = ssLevelCritical
+	case k8s.HealthWarning:
+		level = ssLevelWarn
+	}
+	return &Segment{
+		Icon:    "⎈",
+		Text:    text,
+		Color:   cfg.ssColor(level),
+		Details: details,
 	}
 }
 
 // ssSystemSegment renders the system metrics segment showing CPU and RAM
 // utilization percentages.
 // Example: "💻 CPU:45% RAM:62%"
-func ssSystemSegment(cacheDir string) *Segment {
-	metrics, err := ssReadCachedData[sysmetrics.Metrics](cacheDir, "sysmetrics")
+func ssSystemSegment(cfg Config) *Segment {
+	metrics, err := ssLoadCachedData[sysmetrics.Metrics](cfg, "sysmetrics")
 	if err != nil || metrics == nil {
 		return nil
 	}
@@ -215,37 +563,27 @@ func ssSystemSegment(cacheDir string) *Segment {
 		highest = ramPct
 	}
 
-	var color string
-	switch {
-	case highest >= 80:
-		color = ssColorRed
-	case highest >= 50:
-		color = ssColorYellow
-	default:
-		color = ssColorGreen
-	}
-
 	return &Segment{
 		Icon:  "💻",
 		Text:  text,
-		Color: color,
+		Color: cfg.ssColor(cfg.SystemThresholds.level(highest)),
 	}
 }
 
-// ssThresholdColor returns a color code based on the ratio of value to
-// budget. Green for <50%, yellow for 50-80%, red for >=80%.
-func ssThresholdColor(value, budget float64) string {
-	if budget <= 0 {
-		return ssColorGreen
+// ssWeatherSegment renders the current temperature with a condition glyph.
+// It reads the cache directly with weather.MaxAge and never triggers a
+// refresh, so a missing or failed fetch hides the segment and the prompt
+// never reaches the network.
+// Example: "🌧️ 13°C"
+func ssWeatherSegment(cfg Config) *Segment {
+	status, err := ssReadCachedDataMaxAge[weather.Status](ssCacheReader(cfg), cfg.CacheDir, "weather", weather.MaxAge)
+	if err != nil || status == nil || status.Unit == "" {
+		return nil
 	}
-	ratio := value / budget
-	switch {
-	case ratio >= 0.8:
-		return ssColorRed
-	case ratio >= 0.5:
-		return ssColorYellow
-	default:
-		return ssColorGreen
+
+	return &Segment{
+		Icon: status.Glyph,
+		Text: status.TemperatureText(),
 	}
 }
 
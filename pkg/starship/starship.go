@@ -1,21 +1,206 @@
 package starship
 
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
+)
+
 // Config controls which segments appear in the starship output.
 type Config struct {
-	ShowClaude    bool
-	ShowBilling   bool
-	ShowTailscale bool
-	ShowK8s       bool
-	ShowSystem    bool
-	CacheDir      string // where to read cached collector data
-	MaxWidth      int    // max visible width (default 60)
+	ShowClaude     bool
+	ShowBilling    bool
+	ShowTailscale  bool
+	ShowUptimeKuma bool
+	ShowChecks     bool
+	ShowRemote     bool
+	ShowDocker     bool
+	ShowStorage    bool
+	ShowK8s        bool
+	ShowSystem     bool
+	ShowWeather    bool
+	CacheDir       string // where to read cached collector data
+	MaxWidth       int    // max visible width in cells (default 60)
+
+	// ClaudeWarnWithin adds a warning to the Claude segment when an
+	// account is forecast to reach its window limit within this long.
+	// Zero disables the warning.
+	ClaudeWarnWithin time.Duration
+
+	// Staleness bounds the age of the cached data segments show. Segments
+	// older than StaleAfter get a ⟳ glyph; data older than ExpireAfter is
+	// not shown. The zero value ignores data older than ssMaxCacheAge.
+	Staleness cache.Staleness
+
+	// Refresh, if set, is called to repopulate a stale or missing cache
+	// entry. Concurrent prompts coordinate through a lease in CacheDir so
+	// only one of them runs Refresh; the rest wait up to RefreshWait and
+	// then serve the stale data. The context carries the RefreshBudget
+	// deadline and implementations must honor it.
+	Refresh func(ctx context.Context, key string) error
+
+	// RefreshBudget bounds how long the prompt holding the lease may spend
+	// in Refresh (default 20ms).
+	RefreshBudget time.Duration
+
+	// RefreshWait bounds how long other prompts wait for the lease holder
+	// to produce fresh data (default 5ms).
+	RefreshWait time.Duration
+
+	// SummarySegments lists the segments RenderSummary composes, in order
+	// (default DefaultSummarySegments).
+	SummarySegments []string
+
+	// SummarySeparator joins RenderSummary segments (default a dim "│"
+	// between spaces).
+	SummarySeparator string
+
+	// ClaudeThresholds apply to the highest Claude usage window
+	// percentage, BillingThresholds to spend as a percentage of budget,
+	// and SystemThresholds to the higher of CPU and RAM usage.
+	ClaudeThresholds  Thresholds
+	BillingThresholds Thresholds
+	SystemThresholds  Thresholds
+
+	// Palette colors segments by status, e.g. ThemePalette(theme.Current).
+	Palette Palette
+
+	// Wrap marks escape sequences as zero-width for WrapBash or WrapZsh,
+	// for output placed in a prompt directly. Empty emits them as is,
+	// which is what Starship expects.
+	Wrap string
+
+	// ClaudeDisplay is what the Claude segment shows: ClaudeDisplayCost
+	// (the default when empty), ClaudeDisplayTokens, or ClaudeDisplayBoth.
+	ClaudeDisplay string
+
+	// KubeContext is the current kubectl context, e.g. from
+	// k8s.CurrentContext. When set, the k8s segment shows that cluster's
+	// health and ready nodes instead of pod counts. KubeconfigFiles are
+	// the files it was read from; CacheFiles includes them so switching
+	// contexts re-renders the prompt.
+	KubeContext     string
+	KubeconfigFiles []string
+
+	// Budget bounds how long Render and RenderSummary take; zero waits
+	// for every segment. Segments whose cached data is not read in time
+	// are left out, or the line is a placeholder when none is ready. Each
+	// overrun is counted in OverrunFile when it is set.
+	Budget      time.Duration
+	OverrunFile string
+
+	// readFile reads a cache file; nil uses cache.ReadFile. Tests replace
+	// it with a slow one.
+	readFile func(path string) ([]byte, error)
 }
 
+// Modes of Config.ClaudeDisplay.
+const (
+	ClaudeDisplayCost   = "cost"
+	ClaudeDisplayTokens = "tokens"
+	ClaudeDisplayBoth   = "both"
+)
+
 // Segment represents a single piece of the status line.
 type Segment struct {
 	Icon  string // emoji or nerd font icon
 	Text  string // the actual content
 	Color string // ANSI color code
+
+	// Details follow Text, in order. A line over its width drops them,
+	// lowest Priority first, before it drops or truncates whole segments.
+	Details []Detail
+}
+
+// Detail is an optional part of a segment, such as the top Claude model or
+// the Tailscale exit node.
+type Detail struct {
+	Text     string
+	Priority int // higher is dropped later
+}
+
+// Detail priorities, from the first dropped to the last.
+const (
+	ssPriorityInfo  = iota + 1 // context such as a model or host name
+	ssPriorityWarn             // a warning, such as an expiring key
+	ssPriorityStale            // ssStaleGlyph
+)
+
+// fullText returns Text followed by the Details, separated by spaces.
+func (s *Segment) fullText() string {
+	if len(s.Details) == 0 {
+		return s.Text
+	}
+	parts := make([]string, 0, len(s.Details)+1)
+	parts = append(parts, s.Text)
+	for _, d := range s.Details {
+		parts = append(parts, d.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ssStaleGlyph marks a segment rendered from data older than
+// Config.Staleness.StaleAfter.
+const ssStaleGlyph = "⟳"
+
+// ssSegmentFuncs renders the segment for each cache key.
+var ssSegmentFuncs = map[string]func(Config) *Segment{
+	"claude":     ssClaudeSegment,
+	"billing":    ssBillingSegment,
+	"tailscale":  ssTailscaleSegment,
+	"uptimekuma": ssUptimeKumaSegment,
+	"checks":     ssChecksSegment,
+	"remote":     ssRemoteSegment,
+	"docker":     ssDockerSegment,
+	"storage":    ssStorageSegment,
+	"k8s":        ssK8sSegment,
+	"sysmetrics": ssSystemSegment,
+	"weather":    ssWeatherSegment,
+}
+
+// ssASCIIIcons replaces segment icons when render.Current disallows
+// Unicode, keyed like ssSegmentFuncs.
+var ssASCIIIcons = map[string]string{
+	"claude":     "AI",
+	"billing":    "$",
+	"tailscale":  "ts",
+	"uptimekuma": "up",
+	"checks":     "chk",
+	"remote":     "ssh",
+	"docker":     "docker",
+	"storage":    "disk",
+	"k8s":        "k8s",
+	"sysmetrics": "sys",
+	"weather":    "wx",
+}
+
+// ssRenderSegment renders the segment for the cache key, appending
+// ssStaleGlyph when its data is stale. Without Unicode in render.Current
+// the icon becomes a short label and glyphs in the text are spelled out.
+// It returns nil when there is nothing to show.
+func ssRenderSegment(cfg Config, key string) *Segment {
+	seg := ssSegmentFuncs[key](cfg)
+	if seg == nil {
+		return nil
+	}
+	if cfg.Staleness.StaleAfter > 0 {
+		_, freshness, ok := cache.EntryFreshness(cfg.CacheDir, key, cfg.Staleness, time.Now())
+		if ok && freshness == cache.Stale {
+			seg.Details = append(seg.Details, Detail{Text: ssStaleGlyph, Priority: ssPriorityStale})
+		}
+	}
+	if !render.Current.Unicode {
+		seg.Icon = ssASCIIIcons[key]
+		seg.Text = render.Current.Text(seg.Text)
+		for i := range seg.Details {
+			seg.Details[i].Text = render.Current.Text(seg.Details[i].Text)
+		}
+	}
+	return seg
 }
 
 // ssDefaultMaxWidth is the default maximum visible character width for the
@@ -24,44 +209,73 @@ const ssDefaultMaxWidth = 60
 
 // Render reads cached data and produces a single-line starship module string.
 // Returns an empty string if no data is available (starship hides empty
-// modules).
+// modules). It takes at most about cfg.Budget.
 func Render(cfg Config) string {
 	maxWidth := cfg.MaxWidth
 	if maxWidth <= 0 {
 		maxWidth = ssDefaultMaxWidth
 	}
 
-	var segments []*Segment
-
-	if cfg.ShowClaude {
-		if seg := ssClaudeSegment(cfg.CacheDir); seg != nil {
-			segments = append(segments, seg)
+	var keys []string
+	for _, s := range []struct {
+		on  bool
+		key string
+	}{
+		{cfg.ShowClaude, "claude"},
+		{cfg.ShowBilling, "billing"},
+		{cfg.ShowTailscale, "tailscale"},
+		{cfg.ShowUptimeKuma, "uptimekuma"},
+		{cfg.ShowChecks, "checks"},
+		{cfg.ShowRemote, "remote"},
+		{cfg.ShowDocker, "docker"},
+		{cfg.ShowStorage, "storage"},
+		{cfg.ShowK8s, "k8s"},
+		{cfg.ShowSystem, "sysmetrics"},
+		{cfg.ShowWeather, "weather"},
+	} {
+		if s.on {
+			keys = append(keys, s.key)
 		}
 	}
 
-	if cfg.ShowBilling {
-		if seg := ssBillingSegment(cfg.CacheDir); seg != nil {
+	rendered, late := ssRenderSegments(cfg, keys)
+	var segments []*Segment
+	for _, seg := range rendered {
+		if seg != nil {
 			segments = append(segments, seg)
 		}
 	}
-
-	if cfg.ShowTailscale {
-		if seg := ssTailscaleSegment(cfg.CacheDir); seg != nil {
-			segments = append(segments, seg)
-		}
+	if len(segments) == 0 && len(late) > 0 {
+		return ssPlaceholderLine(cfg)
 	}
+	return ssWrapEscapes(render.Current.Apply(ssFormatLine(segments, maxWidth)), cfg.Wrap)
+}
 
-	if cfg.ShowK8s {
-		if seg := ssK8sSegment(cfg.CacheDir); seg != nil {
-			segments = append(segments, seg)
-		}
-	}
+// WriteTo writes the line Render returns to w, or nothing when it is
+// empty. If ctx ends before the line is ready, nothing is written and
+// ctx's error is returned, so a caller embedding the module can bound it
+// tighter than cfg.Budget.
+func WriteTo(ctx context.Context, w io.Writer, cfg Config) (int64, error) {
+	return ssWriteLine(ctx, w, func() string { return Render(cfg) })
+}
 
-	if cfg.ShowSystem {
-		if seg := ssSystemSegment(cfg.CacheDir); seg != nil {
-			segments = append(segments, seg)
+// WriteSummaryTo is WriteTo for the line RenderSummary returns.
+func WriteSummaryTo(ctx context.Context, w io.Writer, cfg Config) (int64, error) {
+	return ssWriteLine(ctx, w, func() string { return RenderSummary(cfg) })
+}
+
+// ssWriteLine writes the line fn returns to w unless ctx ends first.
+func ssWriteLine(ctx context.Context, w io.Writer, fn func() string) (int64, error) {
+	done := make(chan string, 1)
+	go func() { done <- fn() }()
+	select {
+	case line := <-done:
+		if line == "" {
+			return 0, nil
 		}
+		n, err := io.WriteString(w, line)
+		return int64(n), err
+	case <-ctx.Done():
+		return 0, ctx.Err()
 	}
-
-	return ssFormatLine(segments, maxWidth)
 }
@@ -1,18 +1,31 @@
 package starship
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/remote"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/storage"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/weather"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
 )
 
 // ssWriteFixture writes a JSON fixture to the given cache directory under
@@ -158,6 +171,33 @@ func TestRenderAllSegmentsEnabled(t *testing.T) {
 	}
 }
 
+func TestRenderPlainProfile(t *testing.T) {
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	t.Cleanup(func() { render.SetCurrent(orig) })
+
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "billing", ssBillingFixture(23.45, 100))
+	ssWriteFixture(t, dir, "docker", ssDockerFixture(3, 1))
+	ssWriteFixture(t, dir, "sysmetrics", ssSysmetricsFixture(45, 62))
+
+	cfg := Config{ShowBilling: true, ShowDocker: true, ShowSystem: true, CacheDir: dir, MaxWidth: 200}
+	for name, got := range map[string]string{"Render": Render(cfg), "RenderSummary": RenderSummary(cfg)} {
+		if got == "" {
+			t.Fatalf("%s is empty", name)
+		}
+		for i := 0; i < len(got); i++ {
+			if got[i] > 0x7f || got[i] == 0x1b {
+				t.Errorf("%s: byte %#x at %d in %q", name, got[i], i, got)
+				break
+			}
+		}
+	}
+	if got, want := Render(cfg), "$ $23.45/mo | docker "; !strings.HasPrefix(got, want) {
+		t.Errorf("Render() = %q, want prefix %q", got, want)
+	}
+}
+
 func TestRenderNoCachedData(t *testing.T) {
 	dir := t.TempDir() // empty directory
 
@@ -246,7 +286,7 @@ func TestClaudeSegmentFormatting(t *testing.T) {
 		{Model: "claude-3-5-sonnet-20241022", CostUSD: 42.30},
 	}))
 
-	seg := ssClaudeSegment(dir)
+	seg := ssClaudeSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -256,8 +296,38 @@ func TestClaudeSegmentFormatting(t *testing.T) {
 	if !strings.Contains(seg.Text, "$142.30") {
 		t.Errorf("expected cost in text, got: %s", seg.Text)
 	}
-	if !strings.Contains(seg.Text, "opus") {
-		t.Errorf("expected top model 'opus' in text, got: %s", seg.Text)
+	if !strings.Contains(seg.fullText(), "opus") {
+		t.Errorf("expected top model 'opus' in text, got: %s", seg.fullText())
+	}
+}
+
+func TestClaudeSegmentDisplay(t *testing.T) {
+	dir := t.TempDir()
+	report := ssClaudeFixture(0, nil)
+	report.Accounts[0].Sessions = []claude.SessionUsage{
+		{Model: "claude-sonnet-4-5", InputTokens: 1_000_000, OutputTokens: 200_000, CostUSD: 3.10, UnpricedModel: "claude-next"},
+	}
+	report.Accounts[0].SessionsCostUSD = 3.10
+	report.Accounts[0].UnpricedModels = []string{"claude-next"}
+	ssWriteFixture(t, dir, "claude", report)
+
+	tests := []struct {
+		display string
+		want    string
+	}{
+		{"", "$3.10*"},
+		{ClaudeDisplayCost, "$3.10*"},
+		{ClaudeDisplayTokens, "1.2M"},
+		{ClaudeDisplayBoth, "$3.10* 1.2M"},
+	}
+	for _, tt := range tests {
+		seg := ssClaudeSegment(Config{CacheDir: dir, ClaudeDisplay: tt.display})
+		if seg == nil {
+			t.Fatalf("display %q: expected non-nil segment", tt.display)
+		}
+		if seg.Text != tt.want {
+			t.Errorf("display %q: text = %q, want %q", tt.display, seg.Text, tt.want)
+		}
 	}
 }
 
@@ -280,7 +350,7 @@ func TestClaudeSegmentColorThresholds(t *testing.T) {
 			ssWriteFixture(t, dir, "claude", ssClaudeFixture(tt.cost, []claude.ModelUsage{
 				{Model: "claude-opus-4-20250514", CostUSD: tt.cost},
 			}))
-			seg := ssClaudeSegment(dir)
+			seg := ssClaudeSegment(Config{CacheDir: dir})
 			if seg == nil {
 				t.Fatal("expected non-nil segment")
 			}
@@ -295,7 +365,7 @@ func TestBillingSegmentFormatting(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "billing", ssBillingFixture(23.45, 100))
 
-	seg := ssBillingSegment(dir)
+	seg := ssBillingSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -311,11 +381,340 @@ func TestBillingSegmentFormatting(t *testing.T) {
 	}
 }
 
+func TestBillingSegmentCurrency(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "billing", billing.BillingReport{Currency: "EUR", TotalMonthlyUSD: 21.70})
+	if seg := ssBillingSegment(Config{CacheDir: dir}); seg == nil || seg.Text != "€21.70/mo" {
+		t.Errorf("segment = %+v, want €21.70/mo", seg)
+	}
+
+	ssWriteFixture(t, dir, "billing", billing.BillingReport{
+		Currency:  "USD",
+		Subtotals: map[string]float64{"USD": 12, "EUR": 8.5},
+	})
+	if seg := ssBillingSegment(Config{CacheDir: dir}); seg == nil || seg.Text != "$12.00 + €8.50/mo" {
+		t.Errorf("segment = %+v, want $12.00 + €8.50/mo", seg)
+	}
+}
+
+func TestBillingSegmentProviderBudgetColor(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"", ssColorGreen},
+		{billing.BudgetOK, ssColorGreen},
+		{billing.BudgetWarn, ssColorYellow},
+		{billing.BudgetCritical, ssColorRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			dir := t.TempDir()
+			report := ssBillingFixture(10, 100)
+			report.Providers[0].BudgetStatus = tt.status
+			ssWriteFixture(t, dir, "billing", report)
+
+			seg := ssBillingSegment(Config{CacheDir: dir})
+			if seg == nil {
+				t.Fatal("expected non-nil segment")
+			}
+			if seg.Color != tt.want {
+				t.Errorf("status %q: color = %q, want %q", tt.status, seg.Color, tt.want)
+			}
+		})
+	}
+}
+
+// ssUptimeKumaFixture builds an uptimekuma.Status with the given counts of
+// up and down monitors.
+func ssUptimeKumaFixture(up, down int) uptimekuma.Status {
+	st := uptimekuma.Status{Up: up, Down: down, Total: up + down, Timestamp: time.Now()}
+	for i := 0; i < up+down; i++ {
+		state := uptimekuma.StateUp
+		if i >= up {
+			state = uptimekuma.StateDown
+		}
+		st.Monitors = append(st.Monitors, uptimekuma.Monitor{
+			Name:  "monitor-" + string(rune('a'+i)),
+			State: state,
+		})
+	}
+	return st
+}
+
+func TestUptimeKumaSegment(t *testing.T) {
+	tests := []struct {
+		up, down  int
+		wantText  string
+		wantColor string
+	}{
+		{3, 0, "3/3 up", ssColorGreen},
+		{2, 1, "2/3 up", ssColorYellow},
+		{0, 2, "0/2 up", ssColorRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantText, func(t *testing.T) {
+			dir := t.TempDir()
+			ssWriteFixture(t, dir, "uptimekuma", ssUptimeKumaFixture(tt.up, tt.down))
+
+			seg := ssUptimeKumaSegment(Config{CacheDir: dir})
+			if seg == nil {
+				t.Fatal("expected non-nil segment")
+			}
+			if seg.Text != tt.wantText || seg.Color != tt.wantColor {
+				t.Errorf("segment = %q %q, want %q %q", seg.Text, seg.Color, tt.wantText, tt.wantColor)
+			}
+		})
+	}
+}
+
+func TestRenderJSONInfraMergesUptimeKuma(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(2, 3))
+	ssWriteFixture(t, dir, "uptimekuma", ssUptimeKumaFixture(1, 1))
+
+	out := Collect(Config{CacheDir: dir, ShowTailscale: true, ShowUptimeKuma: true})
+	if out.Infra == nil {
+		t.Fatal("missing infra section")
+	}
+	if out.Infra.Online != 3 || out.Infra.Total != 5 || len(out.Infra.Checks) != 5 {
+		t.Errorf("infra = %d/%d with %d checks, want 3/5 with 5", out.Infra.Online, out.Infra.Total, len(out.Infra.Checks))
+	}
+	kuma := 0
+	for _, c := range out.Infra.Checks {
+		if c.Source == "uptimekuma" {
+			kuma++
+		}
+	}
+	if kuma != 2 {
+		t.Errorf("uptimekuma checks = %d, want 2", kuma)
+	}
+
+	// Uptime Kuma alone still produces an infra section.
+	out = Collect(Config{CacheDir: dir, ShowUptimeKuma: true})
+	if out.Infra == nil || out.Infra.Total != 2 {
+		t.Errorf("infra without tailscale = %+v, want 2 checks", out.Infra)
+	}
+}
+
+func TestChecksSegmentAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "checks", checks.Status{
+		Checks: []checks.Result{
+			{Name: "grafana", State: checks.StateUp},
+			{Name: "nas-ssh", State: checks.StatePending},
+			{Name: "router", State: checks.StateDown},
+		},
+		Up: 1, Down: 1, Total: 3,
+		Timestamp: time.Now(),
+	})
+
+	seg := ssChecksSegment(Config{CacheDir: dir})
+	if seg == nil || seg.Text != "1/3 up" || seg.Color != ssColorYellow {
+		t.Errorf("segment = %+v, want 1/3 up in yellow", seg)
+	}
+
+	out := Collect(Config{CacheDir: dir, ShowChecks: true})
+	if out.Infra == nil {
+		t.Fatal("missing infra section")
+	}
+	if out.Infra.Online != 1 || out.Infra.Total != 2 || len(out.Infra.Checks) != 3 {
+		t.Errorf("infra = %d/%d with %d checks, want 1/2 with 3 (pending not counted)", out.Infra.Online, out.Infra.Total, len(out.Infra.Checks))
+	}
+	if c := out.Infra.Checks[2]; c.Source != "checks" || c.Name != "router" || c.Status != "down" {
+		t.Errorf("check = %+v, want router down from checks", c)
+	}
+}
+
+func TestChecksSegmentGrouped(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "checks", checks.Status{
+		Checks: []checks.Result{
+			{Name: "api", Group: "website", State: checks.StateUp},
+			{Name: "cdn", Group: "website", State: checks.StateDown},
+			{Name: "db", Group: "website", State: checks.StateUp, Critical: true},
+			{Name: "primary", Group: "storage", State: checks.StateDown, Critical: true},
+			{Name: "grafana", Group: "metrics", State: checks.StateUp},
+			{Name: "router", State: checks.StateUp},
+		},
+		Up: 4, Down: 2, Total: 6,
+		Timestamp: time.Now(),
+	})
+
+	seg := ssChecksSegment(Config{CacheDir: dir})
+	if seg == nil || seg.fullText() != "2/4 ok storage ✗ website ⚠" || seg.Color != ssColorRed {
+		t.Errorf("segment = %+v, want 2/4 ok with the failing groups in red", seg)
+	}
+	if got := ssStripAnsi(ssFormatLine([]*Segment{seg}, 20)); got != "📡 2/4 ok storage ✗" {
+		t.Errorf("narrow line = %q, want the warning group dropped first", got)
+	}
+
+	out := Collect(Config{CacheDir: dir, ShowChecks: true})
+	if out.Infra == nil || len(out.Infra.Groups) != 4 {
+		t.Fatalf("infra = %+v, want 4 groups", out.Infra)
+	}
+	if g := out.Infra.Groups[3]; g.Name != checks.GroupOther || g.Status != "ok" || g.Up != 1 || g.Total != 1 {
+		t.Errorf("last group = %+v, want other ok 1/1", g)
+	}
+	if c := out.Infra.Checks[0]; c.Group != "website" {
+		t.Errorf("check = %+v, want its group", c)
+	}
+}
+
+func TestRemoteSegmentAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "remote", remote.Status{
+		Hosts: []remote.Result{
+			{Name: "backup", State: remote.StateDown, Error: "connection refused"},
+			{Name: "vps", State: remote.StateUp},
+		},
+		Up: 1, Down: 1, Total: 2,
+		Timestamp: time.Now(),
+	})
+
+	seg := ssRemoteSegment(Config{CacheDir: dir})
+	if seg == nil || seg.Text != "1/2 up" || seg.Color != ssColorYellow {
+		t.Errorf("segment = %+v, want 1/2 up in yellow", seg)
+	}
+
+	out := Collect(Config{CacheDir: dir, ShowRemote: true})
+	if out.Infra == nil || out.Infra.Online != 1 || out.Infra.Total != 2 {
+		t.Fatalf("infra = %+v, want 1/2 online", out.Infra)
+	}
+	if c := out.Infra.Checks[0]; c.Source != "remote" || c.Name != "backup" || c.Status != "down" {
+		t.Errorf("check = %+v, want backup down from remote", c)
+	}
+}
+
+// ssDockerFixture builds a docker.Status with running containers, the
+// first unhealthy of which are flagged, plus one exited container.
+func ssDockerFixture(running, unhealthy int) docker.Status {
+	st := docker.Status{Available: true, Running: running, Exited: 1, Unhealthy: unhealthy, Timestamp: time.Now()}
+	for i := 0; i < running; i++ {
+		c := docker.Container{Name: "ctr-" + string(rune('a'+i)), State: docker.StateRunning}
+		if i < unhealthy {
+			c.Health = docker.HealthUnhealthy
+		}
+		st.Containers = append(st.Containers, c)
+	}
+	st.Containers = append(st.Containers, docker.Container{Name: "job", State: docker.StateExited})
+	st.Total = len(st.Containers)
+	return st
+}
+
+func TestDockerSegment(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "docker", ssDockerFixture(3, 1))
+
+	seg := ssDockerSegment(Config{CacheDir: dir})
+	if seg == nil {
+		t.Fatal("expected non-nil segment")
+	}
+	if seg.Text != "3 running 1 unhealthy" || seg.Color != ssColorRed {
+		t.Errorf("segment = %q %q, want '3 running 1 unhealthy' red", seg.Text, seg.Color)
+	}
+
+	ssWriteFixture(t, dir, "docker", docker.Status{Error: "docker not available"})
+	if seg := ssDockerSegment(Config{CacheDir: dir}); seg != nil {
+		t.Errorf("expected nil segment when docker is not available, got %+v", seg)
+	}
+}
+
+func TestRenderJSONInfraMergesDocker(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "docker", ssDockerFixture(3, 1))
+
+	out := Collect(Config{CacheDir: dir, ShowDocker: true})
+	if out.Infra == nil {
+		t.Fatal("missing infra section")
+	}
+	// The exited container is not a check.
+	if out.Infra.Online != 2 || out.Infra.Total != 3 || len(out.Infra.Checks) != 3 {
+		t.Errorf("infra = %d/%d with %d checks, want 2/3 with 3", out.Infra.Online, out.Infra.Total, len(out.Infra.Checks))
+	}
+	if c := out.Infra.Checks[0]; c.Source != "docker" || c.Status != "down" {
+		t.Errorf("first check = %+v, want unhealthy docker container down", c)
+	}
+}
+
+// ssStorageFixture builds a storage.Status with a root mount at rootPct
+// percent full and a hung network mount.
+func ssStorageFixture(rootPct float64, rootStatus string) storage.Status {
+	passed := true
+	return storage.Status{
+		Level: storage.Worst(rootStatus, storage.StatusTimeout),
+		Mounts: []storage.Mount{
+			{Mountpoint: "/", UsedPercent: rootPct, Status: rootStatus},
+			{Mountpoint: "/boot", UsedPercent: 20, Status: storage.StatusOK},
+			{Mountpoint: "/mnt/nas", Status: storage.StatusTimeout},
+		},
+		Disks: []storage.Disk{
+			{Device: "/dev/sda", Passed: &passed},
+			{Device: "/dev/sdb", Error: "Permission denied"},
+		},
+	}
+}
+
+func TestStorageSegment(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "storage", ssStorageFixture(92.4, storage.StatusWarn))
+
+	seg := ssStorageSegment(Config{CacheDir: dir})
+	if seg == nil {
+		t.Fatal("expected non-nil segment")
+	}
+	if seg.Text != "/ 92%" || seg.Color != ssColorYellow {
+		t.Errorf("segment = %q %q, want '/ 92%%' yellow", seg.Text, seg.Color)
+	}
+
+	ssWriteFixture(t, dir, "storage", ssStorageFixture(40, storage.StatusOK))
+	if seg := ssStorageSegment(Config{CacheDir: dir}); seg == nil || seg.Text != "/mnt/nas timeout" {
+		t.Errorf("segment = %+v, want the timed out mount", seg)
+	}
+
+	ssWriteFixture(t, dir, "storage", storage.Status{Level: storage.StatusOK, Mounts: []storage.Mount{{Mountpoint: "/", Status: storage.StatusOK}}})
+	if seg := ssStorageSegment(Config{CacheDir: dir}); seg != nil {
+		t.Errorf("expected nil segment while storage is ok, got %+v", seg)
+	}
+}
+
+func TestRenderSummaryInfraIncludesStorage(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "storage", ssStorageFixture(97, storage.StatusCritical))
+
+	got := RenderSummary(Config{CacheDir: dir, ShowStorage: true, SummarySegments: []string{"infra"}})
+	if got != ssColorize("💾 / 97%", ssColorRed) {
+		t.Errorf("RenderSummary = %q, want the red storage segment", got)
+	}
+}
+
+func TestRenderJSONInfraMergesStorage(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "storage", ssStorageFixture(97, storage.StatusCritical))
+
+	out := Collect(Config{CacheDir: dir, ShowStorage: true})
+	if out.Infra == nil {
+		t.Fatal("missing infra section")
+	}
+	// The timed out mount is listed but not counted; the unreadable drive
+	// is left out.
+	var got []string
+	for _, c := range out.Infra.Checks {
+		got = append(got, c.Name+"="+c.Status)
+	}
+	if want := "/=down /boot=up /mnt/nas=timeout /dev/sda=up"; strings.Join(got, " ") != want {
+		t.Errorf("checks = %s, want %s", strings.Join(got, " "), want)
+	}
+	if out.Infra.Online != 2 || out.Infra.Total != 3 {
+		t.Errorf("infra = %d/%d, want 2/3", out.Infra.Online, out.Infra.Total)
+	}
+}
+
 func TestTailscaleSegmentAllOnline(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(5, 5))
 
-	seg := ssTailscaleSegment(dir)
+	seg := ssTailscaleSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -331,7 +730,7 @@ func TestTailscaleSegmentSomeOffline(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(3, 5))
 
-	seg := ssTailscaleSegment(dir)
+	seg := ssTailscaleSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -348,7 +747,7 @@ func TestTailscaleSegmentMostOffline(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(1, 5))
 
-	seg := ssTailscaleSegment(dir)
+	seg := ssTailscaleSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -358,11 +757,36 @@ func TestTailscaleSegmentMostOffline(t *testing.T) {
 	}
 }
 
+func TestTailscaleSegmentExitNodeAndKeyExpiry(t *testing.T) {
+	dir := t.TempDir()
+	st := ssTailscaleFixture(5, 5)
+	st.ExitNode = &st.Peers[0]
+	st.KeyExpiringSoon = true
+	ssWriteFixture(t, dir, "tailscale", st)
+
+	seg := ssTailscaleSegment(Config{CacheDir: dir})
+	if seg == nil {
+		t.Fatal("expected non-nil segment")
+	}
+	want := "5/5 peers → " + st.Peers[0].Hostname + " ⚠ key"
+	if seg.fullText() != want {
+		t.Errorf("expected %q, got: %s", want, seg.fullText())
+	}
+	if seg.Color != ssColorYellow {
+		t.Errorf("expected yellow for expiring key, got %q", seg.Color)
+	}
+
+	out := Collect(Config{CacheDir: dir, ShowTailscale: true})
+	if out.Infra == nil || out.Infra.ExitNode != st.Peers[0].Hostname || !out.Infra.KeyExpiringSoon {
+		t.Errorf("infra = %+v, want exit node and key_expiring_soon", out.Infra)
+	}
+}
+
 func TestK8sSegmentHealthyPods(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "k8s", ssK8sFixture(15, 15, 0))
 
-	seg := ssK8sSegment(dir)
+	seg := ssK8sSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -378,7 +802,7 @@ func TestK8sSegmentFailedPods(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "k8s", ssK8sFixture(15, 10, 3))
 
-	seg := ssK8sSegment(dir)
+	seg := ssK8sSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -394,7 +818,7 @@ func TestK8sSegmentPendingPods(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "k8s", ssK8sFixture(15, 12, 0))
 
-	seg := ssK8sSegment(dir)
+	seg := ssK8sSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -404,11 +828,109 @@ func TestK8sSegmentPendingPods(t *testing.T) {
 	}
 }
 
+func TestK8sSegmentHealthLevel(t *testing.T) {
+	dir := t.TempDir()
+	fixture := ssK8sFixture(15, 15, 0)
+	fixture.Health = k8s.HealthWarning
+	fixture.Clusters[0].Health = k8s.HealthWarning
+	fixture.Clusters[0].HealthReasons = []string{"node n2 NotReady"}
+	ssWriteFixture(t, dir, "k8s", fixture)
+
+	seg := ssK8sSegment(Config{CacheDir: dir})
+	if seg == nil {
+		t.Fatal("expected non-nil segment")
+	}
+	if seg.Color != ssColorYellow {
+		t.Errorf("expected yellow for warning health with all pods running, got %q", seg.Color)
+	}
+
+	out := ssK8sJSON(&fixture)
+	if out.Health != "warning" || out.Summary != "1 cluster, 1 warning" {
+		t.Errorf("health/summary = %q/%q, want warning/\"1 cluster, 1 warning\"", out.Health, out.Summary)
+	}
+	if got := out.Clusters[0].HealthReasons; len(got) != 1 || got[0] != "node n2 NotReady" {
+		t.Errorf("HealthReasons = %v, want the NotReady node", got)
+	}
+}
+
+func TestK8sSegmentUnreachableCluster(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "k8s", k8s.ClusterStatus{
+		Clusters: []k8s.ClusterInfo{{Context: "prod", Error: "timeout", Health: k8s.HealthCritical}},
+		Health:   k8s.HealthCritical,
+	})
+
+	seg := ssK8sSegment(Config{CacheDir: dir})
+	if seg == nil {
+		t.Fatal("expected a segment for a critical cluster with no pods")
+	}
+	if seg.Text != "critical" || seg.Color != ssColorRed {
+		t.Errorf("segment = %q/%q, want red critical", seg.Text, seg.Color)
+	}
+}
+
+// ssK8sContextsFixture caches a healthy prod cluster with five ready
+// nodes, a staging cluster with one of two nodes NotReady, and an
+// unreachable lab cluster.
+func ssK8sContextsFixture(t *testing.T, dir string) {
+	t.Helper()
+	ready := []k8s.NodeInfo{{Ready: true}, {Ready: true}, {Ready: true}, {Ready: true}, {Ready: true}}
+	ssWriteFixture(t, dir, "k8s", k8s.ClusterStatus{
+		Clusters: []k8s.ClusterInfo{
+			{Context: "prod", Connected: true, Nodes: ready, Health: k8s.HealthOK},
+			{Context: "staging", Connected: true, Nodes: []k8s.NodeInfo{{Ready: true}, {}}, Health: k8s.HealthWarning},
+			{Context: "lab", Error: "timeout", Health: k8s.HealthCritical},
+		},
+		Health:    k8s.HealthCritical,
+		Timestamp: time.Now(),
+	})
+}
+
+func TestK8sSegmentContext(t *testing.T) {
+	dir := t.TempDir()
+	ssK8sContextsFixture(t, dir)
+
+	tests := []struct {
+		context   string
+		wantText  string
+		wantColor string
+	}{
+		{"prod", "prod ✓ 5/5", ssColorGreen},
+		{"staging", "staging ⚠ 1/2", ssColorYellow},
+		{"lab", "lab ✗", ssColorRed},
+		// Not collected: dimmed, with the health and nodes of all clusters.
+		{"dev", "\033[2mdev?\033[22m ✗ 6/7", ssColorRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			seg := ssK8sSegment(Config{CacheDir: dir, KubeContext: tt.context})
+			if seg == nil {
+				t.Fatal("expected non-nil segment")
+			}
+			if seg.Icon != "⎈" || seg.fullText() != tt.wantText || seg.Color != tt.wantColor {
+				t.Errorf("segment = %q %q/%q, want ⎈ %q/%q", seg.Icon, seg.fullText(), seg.Color, tt.wantText, tt.wantColor)
+			}
+		})
+	}
+}
+
+func TestCacheFilesIncludesKubeconfig(t *testing.T) {
+	cfg := Config{CacheDir: "/cache", KubeconfigFiles: []string{"/home/u/.kube/config"}}
+	if files := CacheFiles(cfg); len(files) != 0 {
+		t.Errorf("CacheFiles() = %v without the k8s segment, want none", files)
+	}
+	cfg.ShowK8s = true
+	files := CacheFiles(cfg)
+	if len(files) != 2 || files[1] != "/home/u/.kube/config" {
+		t.Errorf("CacheFiles() = %v, want the k8s cache and the kubeconfig", files)
+	}
+}
+
 func TestSystemSegmentNormalValues(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "sysmetrics", ssSysmetricsFixture(30, 40))
 
-	seg := ssSystemSegment(dir)
+	seg := ssSystemSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -424,7 +946,7 @@ func TestSystemSegmentHighCPU(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "sysmetrics", ssSysmetricsFixture(92, 40))
 
-	seg := ssSystemSegment(dir)
+	seg := ssSystemSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -437,7 +959,7 @@ func TestSystemSegmentHighRAM(t *testing.T) {
 	dir := t.TempDir()
 	ssWriteFixture(t, dir, "sysmetrics", ssSysmetricsFixture(30, 85))
 
-	seg := ssSystemSegment(dir)
+	seg := ssSystemSegment(Config{CacheDir: dir})
 	if seg == nil {
 		t.Fatal("expected non-nil segment")
 	}
@@ -489,6 +1011,80 @@ func TestFormatLineDropsSegmentsExceedingMaxWidth(t *testing.T) {
 	}
 }
 
+func TestFormatLineTruncationPriorities(t *testing.T) {
+	segments := func() []*Segment {
+		return []*Segment{
+			{Icon: "🤖", Text: "$142.30", Details: []Detail{
+				{Text: "opus", Priority: ssPriorityInfo},
+				{Text: "⚠ work 23m", Priority: ssPriorityWarn},
+			}},
+			{Icon: "🔗", Text: "3/5 peers", Details: []Detail{
+				{Text: "→ honey", Priority: ssPriorityInfo},
+			}},
+		}
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{49, "🤖 $142.30 opus ⚠ work 23m │ 🔗 3/5 peers → honey"},
+		// The rightmost of equal details goes first, then the next
+		// priority, and warnings only after every info detail.
+		{48, "🤖 $142.30 opus ⚠ work 23m │ 🔗 3/5 peers"},
+		{40, "🤖 $142.30 ⚠ work 23m │ 🔗 3/5 peers"},
+		{35, "🤖 $142.30 │ 🔗 3/5 peers"},
+		// Without details left, the last segment that fits is ellipsized,
+		// counting the emoji as two cells.
+		{20, "🤖 $142.30 │ 🔗 3/5…"},
+		{15, "🤖 $142.30"},
+		{8, "🤖 $142…"},
+		{4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.width), func(t *testing.T) {
+			segs := segments()
+			got := ssStripAnsi(ssFormatLine(segs, tt.width))
+			if got != tt.want {
+				t.Errorf("ssFormatLine(%d) = %q, want %q", tt.width, got, tt.want)
+			}
+			if w := ssVisibleWidth(got); w > tt.width {
+				t.Errorf("ssFormatLine(%d) is %d cells wide", tt.width, w)
+			}
+			if len(segs[0].Details) != 2 || len(segs[1].Details) != 1 {
+				t.Errorf("ssFormatLine(%d) changed the segments' details", tt.width)
+			}
+		})
+	}
+}
+
+func TestFormatLineTruncatesWideCharacters(t *testing.T) {
+	orig := render.Current
+	render.Current = render.Full
+	defer func() { render.Current = orig }()
+
+	segments := []*Segment{{Icon: "⎈", Text: "本番クラスタ", Color: ssColorGreen}}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{14, "⎈ 本番クラスタ"},
+		{8, "⎈ 本番…"},
+		{7, "⎈ 本番…"},
+		{6, "⎈ 本…"},
+		{3, ""},
+	}
+	for _, tt := range tests {
+		got := ssFormatLine(segments, tt.width)
+		if plain := ssStripAnsi(got); plain != tt.want {
+			t.Errorf("ssFormatLine(%d) = %q, want %q", tt.width, plain, tt.want)
+		}
+		// A cut segment keeps its color and the reset after it whole.
+		if tt.want != "" && (!strings.HasPrefix(got, ssColorGreen) || !strings.HasSuffix(got, ssAnsiReset)) {
+			t.Errorf("ssFormatLine(%d) = %q, want it colored and reset", tt.width, got)
+		}
+	}
+}
+
 func TestFormatLineEmptySegments(t *testing.T) {
 	result := ssFormatLine(nil, 60)
 	if result != "" {
@@ -565,13 +1161,101 @@ func TestCacheReaderStaleFile(t *testing.T) {
 	}
 }
 
-func TestCacheReaderMissingFile(t *testing.T) {
+func TestClaudeSegmentReadsSummary(t *testing.T) {
 	dir := t.TempDir()
-	result, err := ssReadCachedData[claude.UsageReport](dir, "nonexistent")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(10, nil))
+	summary := claude.NewSummary(&claude.UsageReport{
+		Accounts:  []claude.AccountUsage{{CurrentMonth: claude.MonthUsage{CostUSD: 99}}},
+		Timestamp: time.Now(),
+	})
+	ssWriteFixture(t, dir, "claude.summary", summary)
+	cfg := Config{CacheDir: dir}
+
+	text := func() string {
+		t.Helper()
+		seg := ssClaudeSegment(cfg)
+		if seg == nil {
+			t.Fatal("ssClaudeSegment() = nil")
+		}
+		return seg.Text
 	}
-	if result != nil {
+	if got := text(); got != "$99.00" {
+		t.Errorf("with summary: text = %q, want the summary's $99.00", got)
+	}
+
+	// A summary older than the entry is from an earlier collection.
+	path := filepath.Join(dir, "claude.summary.json")
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if got := text(); got != "$10.00" {
+		t.Errorf("older summary: text = %q, want the entry's $10.00", got)
+	}
+
+	summary.Version++
+	ssWriteFixture(t, dir, "claude.summary", summary)
+	if got := text(); got != "$10.00" {
+		t.Errorf("summary of another version: text = %q, want the entry's $10.00", got)
+	}
+
+	os.Remove(path)
+	if got := text(); got != "$10.00" {
+		t.Errorf("without summary: text = %q, want the entry's $10.00", got)
+	}
+
+	// The entry's age decides staleness, whatever the summary's.
+	summary.Version--
+	ssWriteFixture(t, dir, "claude.summary", summary)
+	if err := os.Chtimes(filepath.Join(dir, "claude.json"), old.Add(-time.Hour), old.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if seg := ssClaudeSegment(cfg); seg != nil {
+		t.Errorf("stale entry with fresh summary rendered %q", seg.Text)
+	}
+}
+
+func TestCacheMTime(t *testing.T) {
+	dir := t.TempDir()
+	if got := CacheMTime(Config{CacheDir: dir, ShowClaude: true}); !got.IsZero() {
+		t.Errorf("CacheMTime() without cache files = %v, want zero", got)
+	}
+
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(10, nil))
+	ssWriteFixture(t, dir, "billing", map[string]string{})
+	older := time.Now().Add(-time.Hour).Truncate(time.Second)
+	newer := older.Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "claude.json"), older, older); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "billing.json"), newer, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := CacheMTime(Config{CacheDir: dir, ShowClaude: true}); !got.Equal(older) {
+		t.Errorf("CacheMTime(claude) = %v, want %v", got, older)
+	}
+	if got := CacheMTime(Config{CacheDir: dir, ShowClaude: true, ShowBilling: true}); !got.Equal(newer) {
+		t.Errorf("CacheMTime(claude, billing) = %v, want newest %v", got, newer)
+	}
+	if got := CacheMTime(Config{CacheDir: dir, ShowDocker: true}); !got.IsZero() {
+		t.Errorf("CacheMTime(docker) = %v, want zero for a segment without cache", got)
+	}
+
+	files := CacheFiles(Config{CacheDir: dir, ShowSystem: true, ShowWeather: true})
+	want := []string{filepath.Join(dir, "sysmetrics.json"), filepath.Join(dir, "weather.json")}
+	if strings.Join(files, " ") != strings.Join(want, " ") {
+		t.Errorf("CacheFiles() = %v, want %v", files, want)
+	}
+}
+
+func TestCacheReaderMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	result, err := ssReadCachedData[claude.UsageReport](dir, "nonexistent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil {
 		t.Error("expected nil for missing file, got non-nil")
 	}
 }
@@ -631,3 +1315,580 @@ func TestVisibleWidth(t *testing.T) {
 		t.Errorf("expected 5 for colored text, got %d", w)
 	}
 }
+
+func TestLoadCachedDataCoalescesRefresh(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(10, nil))
+
+	path := filepath.Join(dir, "claude.json")
+	old := time.Now().Add(-10 * time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	var calls atomic.Int32
+	cfg := Config{
+		CacheDir:      dir,
+		RefreshBudget: 50 * time.Millisecond,
+		RefreshWait:   time.Millisecond,
+		Refresh: func(ctx context.Context, key string) error {
+			calls.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			ssWriteFixture(t, dir, key, ssClaudeFixture(20, nil))
+			return nil
+		},
+	}
+
+	const prompts = 24
+	var wg sync.WaitGroup
+	costs := make([]float64, prompts)
+	for i := 0; i < prompts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := ssLoadCachedData[claude.UsageReport](cfg, "claude")
+			if err != nil || r == nil {
+				t.Errorf("prompt %d: r=%v err=%v", i, r, err)
+				return
+			}
+			costs[i] = r.TotalCostUSD
+		}(i)
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("Refresh called %d times, want 1", n)
+	}
+	// Every prompt renders something: either the refreshed data or the
+	// stale data it fell back to.
+	for i, c := range costs {
+		if c != 10 && c != 20 {
+			t.Errorf("prompt %d got cost %v, want stale (10) or fresh (20)", i, c)
+		}
+	}
+}
+
+func TestLoadCachedDataWithoutRefreshRejectsStale(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(10, nil))
+	path := filepath.Join(dir, "claude.json")
+	old := time.Now().Add(-10 * time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	r, err := ssLoadCachedData[claude.UsageReport](Config{CacheDir: dir}, "claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != nil {
+		t.Error("stale data must be hidden when no Refresh hook is configured")
+	}
+}
+
+func TestRenderMarksStaleAndHidesExpired(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(10, nil))
+	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(3, 3))
+	ssWriteFixture(t, dir, "docker", ssDockerFixture(2, 0))
+	age := func(key string, d time.Duration) {
+		t.Helper()
+		at := time.Now().Add(-d)
+		if err := os.Chtimes(filepath.Join(dir, key+".json"), at, at); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	age("claude", 3*time.Hour)
+	age("docker", 48*time.Hour)
+
+	cfg := Config{
+		CacheDir:      dir,
+		ShowClaude:    true,
+		ShowTailscale: true,
+		ShowDocker:    true,
+		MaxWidth:      200,
+		Staleness:     cache.Staleness{StaleAfter: time.Hour, ExpireAfter: 24 * time.Hour},
+	}
+	got := ssStripAnsi(Render(cfg))
+	if !strings.Contains(got, "$10.00 "+ssStaleGlyph) {
+		t.Errorf("Render = %q, want the 3h old claude segment marked stale", got)
+	}
+	if strings.Count(got, ssStaleGlyph) != 1 {
+		t.Errorf("Render = %q, want only the claude segment marked", got)
+	}
+	if strings.Contains(got, "running") {
+		t.Errorf("Render = %q, want the expired docker segment hidden", got)
+	}
+
+	cfg.SummarySegments = []string{"infra", "claude"}
+	if got := ssStripAnsi(RenderSummary(cfg)); !strings.HasSuffix(got, ssStaleGlyph) {
+		t.Errorf("RenderSummary = %q, want the claude segment marked stale", got)
+	}
+}
+
+func TestRenderJSONEmptyCache(t *testing.T) {
+	cfg := Config{
+		CacheDir:      t.TempDir(),
+		ShowClaude:    true,
+		ShowBilling:   true,
+		ShowTailscale: true,
+		ShowK8s:       true,
+		ShowSystem:    true,
+	}
+	data, err := RenderJSON(cfg)
+	if err != nil {
+		t.Fatalf("RenderJSON: %v", err)
+	}
+	if string(data) != "{}" {
+		t.Errorf("empty cache JSON = %s, want {}", data)
+	}
+}
+
+func TestRenderJSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	report := ssClaudeFixture(42.5, []claude.ModelUsage{
+		{Model: "claude-opus-4-20250514", CostUSD: 40},
+		{Model: "claude-3-haiku-20240307", CostUSD: 2.5},
+	})
+	report.Accounts[0].CurrentMonth.InputTokens = 1000
+	report.Accounts[0].CurrentMonth.OutputTokens = 500
+	report.Timestamp = time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	ssWriteFixture(t, dir, "claude", report)
+	ssWriteFixture(t, dir, "billing", ssBillingFixture(23.45, 100))
+	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(2, 3))
+
+	cfg := Config{CacheDir: dir, ShowClaude: true, ShowBilling: true, ShowTailscale: true, ShowK8s: true}
+	data, err := RenderJSON(cfg)
+	if err != nil {
+		t.Fatalf("RenderJSON: %v", err)
+	}
+
+	var out JSONOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, data)
+	}
+
+	if out.Claude == nil {
+		t.Fatal("missing claude section")
+	}
+	if len(out.Claude.Accounts) != 1 || out.Claude.Accounts[0].Name != "test" {
+		t.Errorf("claude accounts = %+v", out.Claude.Accounts)
+	}
+	if out.Claude.Accounts[0].InputTokens != 1000 || out.Claude.Accounts[0].OutputTokens != 500 {
+		t.Errorf("token counts = %+v", out.Claude.Accounts[0])
+	}
+	if out.Claude.TopModel != "claude-opus-4-20250514" {
+		t.Errorf("top model = %q", out.Claude.TopModel)
+	}
+	if want := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC); !out.Claude.ResetsAt.Equal(want) {
+		t.Errorf("resets_at = %v, want %v", out.Claude.ResetsAt, want)
+	}
+
+	if out.Billing == nil || len(out.Billing.Providers) != 1 {
+		t.Fatalf("billing section = %+v", out.Billing)
+	}
+	if p := out.Billing.Providers[0]; p.Name != "civo" || p.Status != "ok" || p.MonthToDate != 23.45 {
+		t.Errorf("billing provider = %+v", p)
+	}
+
+	if out.Infra == nil || len(out.Infra.Checks) != 3 {
+		t.Fatalf("infra section = %+v", out.Infra)
+	}
+	up := 0
+	for _, c := range out.Infra.Checks {
+		if c.Status == "up" {
+			up++
+		}
+	}
+	if up != 2 {
+		t.Errorf("infra up checks = %d, want 2", up)
+	}
+
+	// k8s was requested but has no cache file, so it is omitted.
+	if out.K8s != nil {
+		t.Errorf("k8s section should be omitted, got %+v", out.K8s)
+	}
+	if strings.Contains(string(data), `"k8s"`) {
+		t.Errorf("raw JSON should not mention k8s: %s", data)
+	}
+}
+
+func TestWeatherSegment(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "weather", weather.Status{
+		Temperature: 12.6, Unit: "°C", Code: 61, Condition: "rain", Glyph: "🌧️", Timestamp: time.Now(),
+	})
+
+	seg := ssWeatherSegment(Config{CacheDir: dir})
+	if seg == nil {
+		t.Fatal("expected non-nil segment")
+	}
+	if seg.Icon != "🌧️" || seg.Text != "13°C" {
+		t.Errorf("segment = %q %q, want rain glyph and 13°C", seg.Icon, seg.Text)
+	}
+
+	// Weather is fetched rarely, so data older than the usual cache age
+	// is still shown.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "weather.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if ssWeatherSegment(Config{CacheDir: dir}) == nil {
+		t.Error("expected 1h-old weather to be shown")
+	}
+}
+
+func TestWeatherSegment_MissingOrStaleHidden(t *testing.T) {
+	dir := t.TempDir()
+	refreshed := false
+	cfg := Config{
+		CacheDir:    dir,
+		ShowWeather: true,
+		Refresh: func(ctx context.Context, key string) error {
+			refreshed = true
+			return nil
+		},
+	}
+	if got := Render(cfg); got != "" {
+		t.Errorf("Render() = %q, want empty without weather data", got)
+	}
+	if refreshed {
+		t.Error("weather segment must not trigger a refresh from the prompt")
+	}
+
+	ssWriteFixture(t, dir, "weather", weather.Status{Temperature: 5, Unit: "°C", Glyph: "☁️"})
+	stale := time.Now().Add(-2 * weather.MaxAge)
+	if err := os.Chtimes(filepath.Join(dir, "weather.json"), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if got := Render(cfg); got != "" {
+		t.Errorf("Render() = %q, want empty for stale weather", got)
+	}
+}
+
+func TestClaudeSegmentLimitWarning(t *testing.T) {
+	report := ssClaudeFixture(10, []claude.ModelUsage{{Model: "claude-opus-4-20250514", CostUSD: 10}})
+	report.Accounts[0].Forecast = &claude.Forecast{LimitAt: time.Now().Add(20*time.Minute + 30*time.Second)}
+
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", report)
+
+	seg := ssClaudeSegment(Config{CacheDir: dir, ClaudeWarnWithin: 30 * time.Minute})
+	if seg == nil || !strings.HasSuffix(seg.fullText(), "opus ⚠ 20m") || seg.Color != ssColorRed {
+		t.Errorf("segment = %+v, want a red 20m warning", seg)
+	}
+
+	seg = ssClaudeSegment(Config{CacheDir: dir, ClaudeWarnWithin: 10 * time.Minute})
+	if seg == nil || strings.Contains(seg.fullText(), "⚠") {
+		t.Errorf("segment = %+v, want no warning outside the threshold", seg)
+	}
+	if seg := ssClaudeSegment(Config{CacheDir: dir}); seg == nil || strings.Contains(seg.fullText(), "⚠") {
+		t.Errorf("segment = %+v, want no warning when disabled", seg)
+	}
+}
+
+func TestClaudeSegmentLimitWarningNamesAccount(t *testing.T) {
+	report := ssClaudeFixture(10, nil)
+	report.Accounts = append(report.Accounts, claude.AccountUsage{
+		Name:      "work",
+		Connected: true,
+		Forecast:  &claude.Forecast{LimitAt: time.Now().Add(5*time.Minute + 30*time.Second)},
+	})
+
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", report)
+
+	seg := ssClaudeSegment(Config{CacheDir: dir, ClaudeWarnWithin: 30 * time.Minute})
+	if seg == nil || !strings.HasSuffix(seg.fullText(), "⚠ work 5m") {
+		t.Errorf("segment = %+v, want the near-limit account named", seg)
+	}
+}
+
+func TestRenderSummaryOrderAndSeparator(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(50.0, nil))
+	ssWriteFixture(t, dir, "tailscale", ssTailscaleFixture(3, 5))
+	ssWriteFixture(t, dir, "docker", ssDockerFixture(4, 0))
+	// No billing data: its segment and separator collapse.
+
+	got := ssStripAnsi(RenderSummary(Config{
+		CacheDir:         dir,
+		SummarySegments:  []string{"infra", "billing", "claude"},
+		SummarySeparator: " · ",
+		MaxWidth:         200,
+	}))
+	want := "🔗 3/5 peers 🐳 4 running · 🤖 $50.00"
+	if got != want {
+		t.Errorf("RenderSummary = %q, want %q", got, want)
+	}
+}
+
+func TestRenderSummaryDefaults(t *testing.T) {
+	dir := t.TempDir()
+	if got := RenderSummary(Config{CacheDir: dir}); got != "" {
+		t.Errorf("RenderSummary with no data = %q, want empty", got)
+	}
+
+	ssWriteFixture(t, dir, "billing", ssBillingFixture(23.45, 100))
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(50.0, nil))
+	got := ssStripAnsi(RenderSummary(Config{CacheDir: dir}))
+	if want := "🤖 $50.00 │ ☁️ $23.45/mo"; got != want {
+		t.Errorf("RenderSummary = %q, want %q", got, want)
+	}
+}
+
+func TestRenderSummaryTruncatesSegmentsIndependently(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(142.30, []claude.ModelUsage{
+		{Model: "claude-opus-4-20250514", CostUSD: 142.30},
+	}))
+	ssWriteFixture(t, dir, "billing", ssBillingFixture(23.45, 100))
+	ts := ssTailscaleFixture(3, 5)
+	ts.ExitNode = &tailscale.PeerInfo{Hostname: "a-very-long-exit-node-hostname"}
+	ssWriteFixture(t, dir, "tailscale", ts)
+
+	got := ssStripAnsi(RenderSummary(Config{
+		CacheDir:         dir,
+		SummarySegments:  []string{"tailscale", "claude", "billing"},
+		SummarySeparator: " | ",
+		MaxWidth:         50,
+	}))
+	if w := ssVisibleWidth(got); w != 50 {
+		t.Errorf("width %d, want 50: %q", w, got)
+	}
+	parts := strings.Split(got, " | ")
+	if len(parts) != 3 {
+		t.Fatalf("got %d segments, want 3: %q", len(parts), got)
+	}
+	// The long Tailscale segment is cut to what the others leave; the
+	// short ones are kept whole rather than pushed off the line.
+	if !strings.HasPrefix(parts[0], "🔗 3/5 peers → a") || !strings.HasSuffix(parts[0], "…") {
+		t.Errorf("tailscale segment = %q, want it truncated", parts[0])
+	}
+	if parts[1] != "🤖 $142.30 opus" || parts[2] != "☁️ $23.45/mo" {
+		t.Errorf("claude, billing segments = %q, %q; want them whole", parts[1], parts[2])
+	}
+}
+
+func TestShareWidth(t *testing.T) {
+	tests := []struct {
+		widths []int
+		budget int
+		want   []int
+		ok     bool
+	}{
+		{[]int{10, 10}, 30, []int{10, 10}, true},
+		{[]int{5, 40, 40}, 35, []int{5, 15, 15}, true},
+		{[]int{40, 3, 40}, 30, []int{14, 3, 13}, true},
+		{[]int{10, 10, 10}, 5, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := ssShareWidth(tt.widths, tt.budget)
+		if ok != tt.ok || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ssShareWidth(%v, %d) = %v, %v; want %v, %v", tt.widths, tt.budget, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestThresholdColorsAcrossLevels(t *testing.T) {
+	palette := Palette{OK: "<ok>", Warn: "<warn>", Critical: "<crit>"}
+	th := Thresholds{Warn: 60, Critical: 90}
+	tests := []struct {
+		name string
+		seg  func(dir string, pct float64) *Segment
+	}{
+		{"claude", func(dir string, pct float64) *Segment {
+			report := ssClaudeFixture(1, nil)
+			report.Accounts[0].Plan = &claude.PlanUsage{FiveHour: &claude.PlanWindow{Utilization: pct}}
+			ssWriteFixture(t, dir, "claude", report)
+			return ssClaudeSegment(Config{CacheDir: dir, ClaudeThresholds: th, Palette: palette})
+		}},
+		{"billing", func(dir string, pct float64) *Segment {
+			ssWriteFixture(t, dir, "billing", ssBillingFixture(pct*2, 200))
+			return ssBillingSegment(Config{CacheDir: dir, BillingThresholds: th, Palette: palette})
+		}},
+		{"system", func(dir string, pct float64) *Segment {
+			ssWriteFixture(t, dir, "sysmetrics", ssSysmetricsFixture(pct, 10))
+			return ssSystemSegment(Config{CacheDir: dir, SystemThresholds: th, Palette: palette})
+		}},
+	}
+	levels := []struct {
+		pct  float64
+		want string
+	}{
+		{0, "<ok>"},
+		{59.9, "<ok>"},
+		{60, "<warn>"},
+		{89.9, "<warn>"},
+		{90, "<crit>"},
+		{120, "<crit>"},
+	}
+	for _, tt := range tests {
+		for _, l := range levels {
+			t.Run(fmt.Sprintf("%s/%g", tt.name, l.pct), func(t *testing.T) {
+				seg := tt.seg(t.TempDir(), l.pct)
+				if seg == nil {
+					t.Fatal("expected non-nil segment")
+				}
+				if seg.Color != l.want {
+					t.Errorf("pct=%g: color = %q, want %q", l.pct, seg.Color, l.want)
+				}
+			})
+		}
+	}
+}
+
+func TestClaudeWindowPercent(t *testing.T) {
+	report := ssClaudeFixture(1, nil)
+	if _, ok := report.WindowPercent(); ok {
+		t.Error("expected no window percentage without plan or forecast")
+	}
+
+	report.Accounts = append(report.Accounts,
+		claude.AccountUsage{
+			Name:     "work",
+			Window:   &claude.WindowUsage{Tokens: 700},
+			Forecast: &claude.Forecast{Limit: 1000},
+		},
+		claude.AccountUsage{
+			Name: "personal",
+			Plan: &claude.PlanUsage{FiveHour: &claude.PlanWindow{Utilization: 35}},
+		},
+	)
+	if pct, ok := report.WindowPercent(); !ok || pct != 70 {
+		t.Errorf("pct = %g, %v; want 70, true", pct, ok)
+	}
+}
+
+func TestThemePaletteColorsSegments(t *testing.T) {
+	th := theme.Get("nord")
+	palette := ThemePalette(th)
+	if palette.OK != theme.Foreground(th.StatusOK) || palette.Critical != theme.Foreground(th.StatusError) {
+		t.Fatalf("palette = %q, want the theme's status colors", palette)
+	}
+
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "docker", ssDockerFixture(3, 1))
+	seg := ssDockerSegment(Config{CacheDir: dir, Palette: palette})
+	if seg == nil || seg.Color != palette.Critical {
+		t.Errorf("docker segment = %+v, want critical color %q", seg, palette.Critical)
+	}
+}
+
+func TestWrapEscapes(t *testing.T) {
+	in := "\033[32m🤖 $5 (50%)\033[0m"
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{"", in},
+		{"fish", in},
+		{WrapBash, "\x01\033[32m\x02🤖 $5 (50%)\x01\033[0m\x02"},
+		{WrapZsh, "%{\033[32m%}🤖 $5 (50%%)%{\033[0m%}"},
+	}
+	for _, tt := range tests {
+		if got := ssWrapEscapes(in, tt.shell); got != tt.want {
+			t.Errorf("ssWrapEscapes(%q) = %q, want %q", tt.shell, got, tt.want)
+		}
+	}
+
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "sysmetrics", ssSysmetricsFixture(10, 10))
+	out := Render(Config{CacheDir: dir, ShowSystem: true, Wrap: WrapBash})
+	if !strings.HasPrefix(out, "\x01\033[") {
+		t.Errorf("Render with Wrap = %q, want escapes wrapped for bash", out)
+	}
+}
+
+// ssSlowStore returns a cache reader that blocks reads of the slow keys
+// until the test ends, as a cold cache on a slow disk would.
+func ssSlowStore(t *testing.T, slow ...string) func(string) ([]byte, error) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	return func(path string) ([]byte, error) {
+		for _, key := range slow {
+			if filepath.Base(path) == key+".json" {
+				<-release
+			}
+		}
+		return cache.ReadFile(path)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(50.0, nil))
+	cfg := Config{CacheDir: dir, ShowClaude: true}
+
+	var b strings.Builder
+	if n, err := WriteTo(context.Background(), &b, cfg); err != nil || n != int64(b.Len()) || b.String() != Render(cfg) {
+		t.Errorf("WriteTo() = %d, %v, wrote %q; want Render's line", n, err, b.String())
+	}
+
+	b.Reset()
+	if n, err := WriteTo(context.Background(), &b, Config{CacheDir: t.TempDir(), ShowClaude: true}); err != nil || n != 0 {
+		t.Errorf("WriteTo(no data) = %d, %v, want nothing written", n, err)
+	}
+
+	// A context that ends before the line is ready writes nothing.
+	cfg.readFile = ssSlowStore(t, "claude")
+	cfg.Budget = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	b.Reset()
+	if _, err := WriteSummaryTo(ctx, &b, cfg); err != context.DeadlineExceeded || b.Len() != 0 {
+		t.Errorf("WriteSummaryTo(ended ctx) = %v, wrote %q", err, b.String())
+	}
+}
+
+func TestRenderBudgetWithSlowCache(t *testing.T) {
+	dir := t.TempDir()
+	ssWriteFixture(t, dir, "claude", ssClaudeFixture(50.0, nil))
+	ssWriteFixture(t, dir, "billing", ssBillingFixture(23.45, 100))
+	overruns := filepath.Join(dir, OverrunFileName)
+	cfg := Config{
+		CacheDir:    dir,
+		ShowClaude:  true,
+		ShowBilling: true,
+		Budget:      50 * time.Millisecond,
+		OverrunFile: overruns,
+		readFile:    ssSlowStore(t, "billing"),
+	}
+
+	start := time.Now()
+	got := ssStripAnsi(Render(cfg))
+	// The slow read never returns; allow some slack over the budget for
+	// a loaded machine.
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Render() took %v, want about the 50ms budget", elapsed)
+	}
+	if got != "🤖 $50.00" {
+		t.Errorf("Render() = %q, want the claude segment without the slow billing one", got)
+	}
+	if o := ReadOverruns(overruns); o.Count != 1 || len(o.LastLate) != 1 || o.LastLate[0] != "billing" {
+		t.Errorf("overruns = %+v, want 1 leaving out billing", o)
+	}
+
+	// With every read slow, the summary is a placeholder.
+	cfg.readFile = ssSlowStore(t, "claude", "billing")
+	start = time.Now()
+	if got := RenderSummary(cfg); got != ssPlaceholder {
+		t.Errorf("RenderSummary() = %q, want the placeholder %q", got, ssPlaceholder)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("RenderSummary() took %v, want about the 50ms budget", elapsed)
+	}
+	if o := ReadOverruns(overruns); o.Count != 2 || len(o.LastLate) != 2 {
+		t.Errorf("overruns = %+v, want 2, the last leaving out both segments", o)
+	}
+
+	// A fast cache renders everything and records nothing.
+	cfg.readFile = nil
+	cfg.Budget = time.Second
+	if got := ssStripAnsi(Render(cfg)); got != "🤖 $50.00 │ ☁️ $23.45/mo" {
+		t.Errorf("Render() = %q, want both segments", got)
+	}
+	if o := ReadOverruns(overruns); o.Count != 2 {
+		t.Errorf("overruns = %+v, want still 2", o)
+	}
+}
@@ -0,0 +1,163 @@
+package starship
+
+import (
+	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
+)
+
+// ssMinSummaryShare is the narrowest a summary segment is truncated to: its
+// icon and the ellipsis. Segments that would get less are dropped.
+const ssMinSummaryShare = 2
+
+// DefaultSummarySegments is the segment order RenderSummary uses when
+// Config.SummarySegments is empty.
+var DefaultSummarySegments = []string{"claude", "billing", "infra"}
+
+// ssSummaryParts maps each summary segment name to the cache keys of the
+// segments it combines. Infra combines Tailscale, Uptime Kuma, the
+// configured checks, remote hosts, Docker, and storage.
+var ssSummaryParts = map[string][]string{
+	"claude":     {"claude"},
+	"billing":    {"billing"},
+	"infra":      {"tailscale", "uptimekuma", "checks", "remote", "docker", "storage"},
+	"tailscale":  {"tailscale"},
+	"uptimekuma": {"uptimekuma"},
+	"checks":     {"checks"},
+	"remote":     {"remote"},
+	"docker":     {"docker"},
+	"storage":    {"storage"},
+	"k8s":        {"k8s"},
+	"kubernetes": {"k8s"},
+	"system":     {"sysmetrics"},
+	"sys":        {"sysmetrics"},
+	"weather":    {"weather"},
+}
+
+// IsSummarySegment reports whether name can be listed in
+// Config.SummarySegments.
+func IsSummarySegment(name string) bool {
+	_, ok := ssSummaryParts[name]
+	return ok
+}
+
+// RenderSummary composes the segments named in cfg.SummarySegments into one
+// line, in order, joined by cfg.SummarySeparator. Segments without data are
+// left out along with their separator. When the line is wider than
+// cfg.MaxWidth each segment is truncated independently to a share of the
+// width, so one long segment cannot push the others off the line; segments
+// narrower than their share give the rest to the others. Like Render, it
+// takes at most about cfg.Budget.
+func RenderSummary(cfg Config) string {
+	names := cfg.SummarySegments
+	if len(names) == 0 {
+		names = DefaultSummarySegments
+	}
+	sep := cfg.SummarySeparator
+	if sep == "" {
+		sep = " " + ssSeparator + " "
+	}
+	maxWidth := cfg.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = ssDefaultMaxWidth
+	}
+
+	var keys []string
+	for _, name := range names {
+		keys = append(keys, ssSummaryParts[name]...)
+	}
+	rendered, late := ssRenderSegments(cfg, keys)
+
+	var groups [][]*Segment
+	for _, name := range names {
+		var segs []*Segment
+		for range ssSummaryParts[name] {
+			if seg := rendered[0]; seg != nil {
+				segs = append(segs, seg)
+			}
+			rendered = rendered[1:]
+		}
+		if len(segs) > 0 {
+			groups = append(groups, segs)
+		}
+	}
+	if len(groups) == 0 && len(late) > 0 {
+		return ssPlaceholderLine(cfg)
+	}
+
+	sepWidth := ssVisibleWidth(sep)
+	for len(groups) > 0 {
+		widths := make([]int, len(groups))
+		for i, g := range groups {
+			widths[i] = ssVisibleWidth(ssRenderGroup(g, -1))
+		}
+		shares, ok := ssShareWidth(widths, maxWidth-sepWidth*(len(groups)-1))
+		if !ok {
+			groups = groups[:len(groups)-1]
+			continue
+		}
+		parts := make([]string, len(groups))
+		for i, g := range groups {
+			parts[i] = ssRenderGroup(g, shares[i])
+		}
+		return ssWrapEscapes(render.Current.Apply(strings.Join(parts, sep)), cfg.Wrap)
+	}
+	return ""
+}
+
+// ssShareWidth divides budget among segments of the given widths. Segments
+// that fit within an equal share keep their width and leave the remainder
+// to the others. It reports false when a segment's share would be below
+// ssMinSummaryShare.
+func ssShareWidth(widths []int, budget int) ([]int, bool) {
+	shares := make([]int, len(widths))
+	open := make([]int, 0, len(widths))
+	for i := range widths {
+		open = append(open, i)
+	}
+	for len(open) > 0 {
+		share := budget / len(open)
+		if share < ssMinSummaryShare {
+			return nil, false
+		}
+		var rest []int
+		for _, i := range open {
+			if widths[i] <= share {
+				shares[i] = widths[i]
+				budget -= widths[i]
+			} else {
+				rest = append(rest, i)
+			}
+		}
+		if len(rest) == len(open) {
+			// Every remaining segment is too wide: split what is left,
+			// giving the remainder to the leftmost.
+			extra := budget - share*len(rest)
+			for k, i := range rest {
+				shares[i] = share
+				if k < extra {
+					shares[i]++
+				}
+			}
+			return shares, true
+		}
+		open = rest
+	}
+	return shares, true
+}
+
+// ssRenderGroup renders the segments of one summary segment separated by
+// spaces, each in its color, truncated to width visible cells with an
+// ellipsis. A negative width renders them in full.
+func ssRenderGroup(segs []*Segment, width int) string {
+	parts := make([]string, len(segs))
+	for i, seg := range segs {
+		parts[i] = ssColorize(seg.Icon+" "+seg.fullText(), seg.Color)
+	}
+	line := strings.Join(parts, " ")
+	if width < 0 {
+		return line
+	}
+	return components.TruncateWithTail(line, width, ssEllipsis)
+}
@@ -0,0 +1,144 @@
+// Package statuspage renders the cached infrastructure status, Kubernetes
+// cluster summary, and billing totals as one self-contained HTML page,
+// with inline CSS and no scripts, for serving from any web server or
+// bucket.
+package statuspage
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/starship"
+)
+
+// spDefaultTemplate is the page template used unless one is configured.
+//
+//go:embed template.html
+var spDefaultTemplate string
+
+// Page is the data a status page template is executed with. A section is
+// nil when its collectors are disabled or have no usable cached data, and
+// the default template leaves it out.
+type Page struct {
+	Title       string
+	GeneratedAt time.Time
+
+	Infra   *starship.InfraJSON
+	K8s     *starship.K8sJSON
+	Billing *starship.BillingJSON
+}
+
+// Build reads the cached data of the collectors cfg enables into a Page
+// generated at now. Data older than general.expire_after is left out.
+func Build(cfg *config.Config, now time.Time) Page {
+	c := cfg.Collectors
+	out := starship.Collect(starship.Config{
+		CacheDir:       cfg.General.CacheDir,
+		ShowBilling:    c.Billing.Enabled,
+		ShowTailscale:  c.Tailscale.Enabled,
+		ShowUptimeKuma: c.UptimeKuma.Enabled,
+		ShowChecks:     c.Checks.Enabled,
+		ShowRemote:     c.Remote.Enabled,
+		ShowDocker:     c.Docker.Enabled,
+		ShowStorage:    c.Storage.Enabled,
+		ShowK8s:        c.Kubernetes.Enabled,
+		Staleness:      cache.Staleness{ExpireAfter: cfg.General.ExpireAfter.Duration},
+	})
+	return Page{
+		Title:       cfg.StatusPage.Title,
+		GeneratedAt: now,
+		Infra:       out.Infra,
+		K8s:         out.K8s,
+		Billing:     out.Billing,
+	}
+}
+
+// Render executes the template at templatePath, or the embedded default
+// when it is empty, on p and writes the HTML to w. Nothing is written if
+// the template fails.
+func Render(w io.Writer, p Page, templatePath string) error {
+	tmpl, err := spTemplate(templatePath)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return fmt.Errorf("status page: %w", err)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// WriteFile renders p as Render does and replaces path with it through a
+// temporary file in the same directory, so a web server never serves a
+// partial page.
+func WriteFile(path string, p Page, templatePath string) error {
+	var buf bytes.Buffer
+	if err := Render(&buf, p, templatePath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".status-*.html.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// spFuncs are the functions templates can use besides the builtins.
+var spFuncs = template.FuncMap{
+	"usd":   func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"money": billing.FormatAmount,
+	"total": func(b *starship.BillingJSON) string {
+		r := billing.BillingReport{Currency: b.Currency, TotalMonthlyUSD: b.TotalMonthlyUSD, Subtotals: b.Subtotals}
+		return r.FormatTotal()
+	},
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Format("2006-01-02 15:04 MST")
+	},
+}
+
+// spTemplate parses the template at path, or the embedded default when
+// path is empty.
+func spTemplate(path string) (*template.Template, error) {
+	text := spDefaultTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("status page template: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("status").Funcs(spFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("status page template: %w", err)
+	}
+	return tmpl, nil
+}
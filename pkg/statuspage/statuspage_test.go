@@ -0,0 +1,237 @@
+package statuspage
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+)
+
+var testNow = time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+// testConfig returns a config with the checks, k8s, and billing
+// collectors enabled. With data, their cache holds one check down, one
+// cluster unreachable, and one billing provider failing.
+func testConfig(t *testing.T, data bool) *config.Config {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.General.CacheDir = t.TempDir()
+	cfg.Collectors.Checks.Enabled = true
+	cfg.Collectors.Kubernetes.Enabled = true
+	cfg.Collectors.Billing.Enabled = true
+	if !data {
+		return cfg
+	}
+
+	now := time.Now()
+	writeCache(t, cfg.General.CacheDir, "checks", checks.Status{
+		Checks: []checks.Result{
+			{Name: "router", State: "down"},
+			{Name: "nas", State: "up"},
+		},
+		Up: 1, Down: 1, Total: 2, Timestamp: now,
+	})
+	writeCache(t, cfg.General.CacheDir, "k8s", k8s.ClusterStatus{
+		Clusters: []k8s.ClusterInfo{
+			{Context: "homelab", Connected: true, TotalPods: 24, RunningPods: 24, Health: k8s.HealthOK},
+			{Context: "staging", Error: "connection refused"},
+		},
+		Timestamp: now,
+	})
+	writeCache(t, cfg.General.CacheDir, "billing", billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{Name: "civo", Connected: true, MonthToDate: 12.50},
+			{Name: "digitalocean", Error: "401 Unauthorized"},
+		},
+		TotalMonthlyUSD: 12.50,
+		Timestamp:       now,
+	})
+	return cfg
+}
+
+// writeCache writes v as the cached data of the collector key.
+func writeCache(t *testing.T, dir, key string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, key+".json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// parsedPage is what the tests read back from a rendered page: the title,
+// and the text of each section's table rows by section id.
+type parsedPage struct {
+	title    string
+	sections map[string][]string
+}
+
+// parse reads html as HTML, failing the test if it does not parse.
+func parse(t *testing.T, html []byte) parsedPage {
+	t.Helper()
+	d := xml.NewDecoder(bytes.NewReader(html))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	p := parsedPage{sections: make(map[string][]string)}
+	var section, row string
+	var inTitle, inRow bool
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return p
+		}
+		if err != nil {
+			t.Fatalf("page does not parse: %v\n%s", err, html)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "title":
+				inTitle = true
+			case "section":
+				for _, a := range tok.Attr {
+					if a.Name.Local == "id" {
+						section = a.Value
+						p.sections[section] = []string{}
+					}
+				}
+			case "tr":
+				inRow, row = true, ""
+			case "td", "th":
+				if row != "" {
+					row += " | "
+				}
+			}
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "title":
+				inTitle = false
+			case "section":
+				section = ""
+			case "tr":
+				if section != "" {
+					p.sections[section] = append(p.sections[section], row)
+				}
+				inRow = false
+			}
+		case xml.CharData:
+			if inTitle {
+				p.title += string(tok)
+			}
+			if inRow {
+				row += strings.TrimSpace(string(tok))
+			}
+		}
+	}
+}
+
+func render(t *testing.T, cfg *config.Config) parsedPage {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Render(&buf, Build(cfg, testNow), ""); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	return parse(t, buf.Bytes())
+}
+
+func TestRender_Sections(t *testing.T) {
+	p := render(t, testConfig(t, true))
+
+	if p.title != "prompt-pulse status" {
+		t.Errorf("title = %q", p.title)
+	}
+	want := map[string][]string{
+		"infra":   {"Check | Source | Status", "router | checks | down", "nas | checks | up"},
+		"k8s":     {"Cluster | Pods running | Failed | Health", "homelab | 24/24 | 0 | healthy", "staging | unreachable"},
+		"billing": {"Provider | Month to date | Status", "civo | $12.50 | ok", "digitalocean | – | 401 Unauthorized"},
+	}
+	for id, rows := range want {
+		got, ok := p.sections[id]
+		if !ok {
+			t.Errorf("no %s section", id)
+			continue
+		}
+		if strings.Join(got, "\n") != strings.Join(rows, "\n") {
+			t.Errorf("%s rows = %q, want %q", id, got, rows)
+		}
+	}
+}
+
+// TestRender_MissingCollectors checks sections without data, or whose
+// collector is disabled, are left out rather than rendered empty.
+func TestRender_MissingCollectors(t *testing.T) {
+	p := render(t, testConfig(t, false))
+	if len(p.sections) != 0 {
+		t.Errorf("sections = %v, want none without cached data", p.sections)
+	}
+
+	cfg := testConfig(t, true)
+	cfg.Collectors.Billing.Enabled = false
+	cfg.Collectors.Kubernetes.Enabled = false
+	p = render(t, cfg)
+	if _, ok := p.sections["infra"]; !ok || len(p.sections) != 1 {
+		t.Errorf("sections = %v, want only infra", p.sections)
+	}
+}
+
+func TestRender_Template(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.tmpl")
+	tmpl := `{{.Title}}:{{with .Billing}}{{usd .TotalMonthlyUSD}}{{end}}{{if .K8s}} k8s{{end}}`
+	if err := os.WriteFile(path, []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig(t, true)
+	cfg.StatusPage.Title = "<lab>"
+	cfg.Collectors.Kubernetes.Enabled = false
+
+	var buf bytes.Buffer
+	if err := Render(&buf, Build(cfg, testNow), path); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if got := buf.String(); got != "&lt;lab&gt;:$12.50" {
+		t.Errorf("page = %q, want the escaped title and billing total", got)
+	}
+
+	if err := Render(&buf, Page{}, filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("Render() with a missing template succeeded")
+	}
+	if err := os.WriteFile(path, []byte(`{{.Nope}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := Render(&buf, Page{}, path); err == nil || buf.Len() != 0 {
+		t.Errorf("Render() with a failing template = %v, wrote %q; want an error and nothing written", err, buf.String())
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "www")
+	path := filepath.Join(dir, "index.html")
+	if err := WriteFile(path, Build(testConfig(t, true), testNow), ""); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := parse(t, data); len(p.sections) != 3 {
+		t.Errorf("sections = %v, want infra, k8s, and billing", p.sections)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the page", len(entries))
+	}
+}
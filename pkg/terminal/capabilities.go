@@ -2,6 +2,8 @@ package terminal
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -15,7 +17,29 @@ type Capabilities struct {
 	TrueColor bool             // 24-bit color support
 	SSH       bool             // Running over SSH
 	Tmux      bool             // Inside tmux
+	Screen    bool             // Inside GNU screen
 	Mux       bool             // Inside any multiplexer (tmux, screen, zellij)
+	Probed    bool             // Refined by querying the terminal (Layer 2)
+
+	// Passthrough reports that the multiplexer forwards passthrough
+	// sequences to the outer terminal: tmux with allow-passthrough on,
+	// or screen, which always does. See GraphicsPassthrough.
+	Passthrough bool
+
+	// Background is the terminal background color as "#rrggbb", or ""
+	// when unknown. It comes from the OSC 11 reply when probed, otherwise
+	// from COLORFGBG as black or white.
+	Background string
+
+	// Sixel reports sixel support: listed in the DA1 reply when probed,
+	// otherwise known from the terminal.
+	Sixel bool
+
+	// KittyPlaceholders reports that Kitty images can be shown with
+	// Unicode placeholders rather than only placed at the cursor. Only
+	// placeholders survive a multiplexer, which moves and clips them like
+	// text.
+	KittyPlaceholders bool
 }
 
 var (
@@ -70,12 +94,36 @@ func detect() *Capabilities {
 	}
 
 	return &Capabilities{
-		Term:      term,
-		Protocol:  SelectProtocol(term),
-		Size:      GetSize(),
-		TrueColor: trueColor,
-		SSH:       ssh,
-		Tmux:      tmux,
-		Mux:       tmux || screen,
+		Term:        term,
+		Protocol:    SelectProtocol(term),
+		Size:        GetSize(),
+		TrueColor:   trueColor,
+		SSH:         ssh,
+		Tmux:        tmux,
+		Screen:      screen,
+		Mux:         tmux || screen,
+		Passthrough: (tmux && tmuxPassthrough()) || (!tmux && screen),
+		Background:  colorFGBGBackground(os.Getenv("COLORFGBG")),
+
+		Sixel:             term.SupportsSixel(),
+		KittyPlaceholders: term.SupportsKittyPlaceholders(),
+	}
+}
+
+// colorFGBGBackground interprets COLORFGBG ("fg;bg", set by rxvt, Konsole,
+// and others): background colors 7 and 9-15 of the 16-color palette are
+// light, the rest dark. It returns "" when the variable is unset or
+// unparseable.
+func colorFGBGBackground(v string) string {
+	if v == "" {
+		return ""
+	}
+	bg, err := strconv.Atoi(v[strings.LastIndexByte(v, ';')+1:])
+	if err != nil || bg < 0 || bg > 15 {
+		return ""
+	}
+	if bg == 7 || bg >= 9 {
+		return "#ffffff"
 	}
+	return "#000000"
 }
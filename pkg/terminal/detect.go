@@ -5,7 +5,8 @@
 //
 // Detection is split into two layers:
 //   - Layer 1 (Detect): Environment variable inspection, 0ms, no I/O.
-//   - Layer 2 (future): Terminal query sequences for definitive detection.
+//   - Layer 2 (LoadCapabilities): Terminal query sequences for definitive
+//     detection, cached per TTY.
 package terminal
 
 import (
@@ -68,6 +69,19 @@ func (t Terminal) SupportsKittyGraphics() bool {
 	}
 }
 
+// SupportsKittyPlaceholders reports whether the terminal draws Kitty
+// images through Unicode placeholders (virtual placements, U=1) as well as
+// placing them at the cursor. WezTerm speaks the graphics protocol without
+// them.
+func (t Terminal) SupportsKittyPlaceholders() bool {
+	switch t {
+	case TermGhostty, TermKitty:
+		return true
+	default:
+		return false
+	}
+}
+
 // SupportsSixel reports whether the terminal supports the Sixel graphics
 // protocol. WezTerm has native sixel support; most other modern terminals
 // do not.
@@ -1,8 +1,12 @@
 package terminal
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // termEnvVars lists all environment variables inspected during detection.
@@ -13,7 +17,7 @@ var termEnvVars = []string{
 	"TILIX_ID", "VTE_VERSION", "LC_TERMINAL",
 	"INSIDE_EMACS", "TMUX", "STY",
 	"SSH_TTY", "SSH_CONNECTION", "SSH_CLIENT",
-	"COLUMNS", "LINES",
+	"COLUMNS", "LINES", "COLORFGBG",
 }
 
 // clearTermEnv unsets all terminal-related env vars for test isolation.
@@ -595,9 +599,18 @@ func TestDetectCapabilities_SSH(t *testing.T) {
 	}
 }
 
+// stubTmuxPassthrough makes detection see tmux's allow-passthrough as on.
+func stubTmuxPassthrough(t *testing.T, on bool) {
+	t.Helper()
+	prev := tmuxPassthrough
+	tmuxPassthrough = func() bool { return on }
+	t.Cleanup(func() { tmuxPassthrough = prev })
+}
+
 func TestDetectCapabilities_Tmux(t *testing.T) {
 	clearTermEnv(t)
 	t.Setenv("TMUX", "/tmp/tmux-501/default,12345,0")
+	stubTmuxPassthrough(t, true)
 
 	caps := ForceRefresh()
 
@@ -607,6 +620,14 @@ func TestDetectCapabilities_Tmux(t *testing.T) {
 	if !caps.Mux {
 		t.Error("caps.Mux = false, want true")
 	}
+	if !caps.Passthrough {
+		t.Error("caps.Passthrough = false with allow-passthrough on")
+	}
+
+	stubTmuxPassthrough(t, false)
+	if caps := ForceRefresh(); caps.Passthrough {
+		t.Error("caps.Passthrough = true with allow-passthrough off")
+	}
 }
 
 func TestDetectCapabilities_Screen(t *testing.T) {
@@ -619,6 +640,96 @@ func TestDetectCapabilities_Screen(t *testing.T) {
 	if !caps.Mux {
 		t.Error("caps.Mux = false, want true (screen)")
 	}
+	if !caps.Screen || caps.Tmux || !caps.Passthrough {
+		t.Errorf("caps = %+v, want screen with passthrough", caps)
+	}
+}
+
+func TestGraphicsPassthrough(t *testing.T) {
+	tmuxOn := &Capabilities{Tmux: true, Mux: true, Passthrough: true, KittyPlaceholders: true}
+	tmuxOff := &Capabilities{Tmux: true, Mux: true}
+	screen := &Capabilities{Screen: true, Mux: true, Passthrough: true}
+	bare := &Capabilities{}
+
+	tests := []struct {
+		name   string
+		caps   *Capabilities
+		proto  GraphicsProtocol
+		want   Passthrough
+		wantOK bool
+	}{
+		{"bare kitty", bare, ProtocolKitty, PassthroughNone, true},
+		{"tmux kitty", tmuxOn, ProtocolKitty, PassthroughTmux, true},
+		{"tmux iterm2", tmuxOn, ProtocolITerm2, PassthroughTmux, true},
+		{"tmux passthrough off", tmuxOff, ProtocolKitty, PassthroughTmux, false},
+		{"tmux sixel unwrapped", tmuxOff, ProtocolSixel, PassthroughNone, true},
+		{"tmux halfblocks unwrapped", tmuxOff, ProtocolHalfblocks, PassthroughNone, true},
+		{"screen iterm2", screen, ProtocolITerm2, PassthroughScreen, true},
+		{"screen kitty", screen, ProtocolKitty, PassthroughScreen, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.caps.GraphicsPassthrough(tt.proto)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("GraphicsPassthrough(%v) = %v, %v, want %v, %v", tt.proto, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// Protocols that cannot pass through fall back to halfblocks.
+	tmuxOff.Protocol = ProtocolKitty
+	if got := tmuxOff.ProtocolWithOverride(""); got != ProtocolHalfblocks {
+		t.Errorf("detected kitty with passthrough off = %v, want halfblocks", got)
+	}
+	if got := tmuxOff.ProtocolWithOverride("iterm2"); got != ProtocolHalfblocks {
+		t.Errorf("iterm2 override with passthrough off = %v, want halfblocks", got)
+	}
+	if got := tmuxOff.ProtocolWithOverride("sixel"); got != ProtocolSixel {
+		t.Errorf("sixel override with passthrough off = %v, want sixel", got)
+	}
+	if got := tmuxOn.ProtocolWithOverride("kitty"); got != ProtocolKitty {
+		t.Errorf("kitty override with passthrough on = %v, want kitty", got)
+	}
+	noPlaceholders := &Capabilities{Term: TermWezTerm, Tmux: true, Mux: true, Passthrough: true}
+	if got := noPlaceholders.ProtocolWithOverride("kitty"); got != ProtocolITerm2 {
+		t.Errorf("kitty through tmux without placeholders = %v, want iterm2", got)
+	}
+}
+
+func TestFallbackProtocol(t *testing.T) {
+	tests := []struct {
+		caps  Capabilities
+		proto GraphicsProtocol
+		want  GraphicsProtocol
+	}{
+		{Capabilities{Term: TermWezTerm}, ProtocolKitty, ProtocolITerm2},
+		{Capabilities{Term: TermGhostty, Sixel: true}, ProtocolKitty, ProtocolSixel},
+		{Capabilities{Term: TermGhostty}, ProtocolKitty, ProtocolHalfblocks},
+		{Capabilities{Sixel: true}, ProtocolITerm2, ProtocolSixel},
+		{Capabilities{Sixel: true}, ProtocolSixel, ProtocolHalfblocks},
+		{Capabilities{}, ProtocolHalfblocks, ProtocolHalfblocks},
+		{Capabilities{}, ProtocolNone, ProtocolNone},
+	}
+	for _, tt := range tests {
+		if got := tt.caps.FallbackProtocol(tt.proto); got != tt.want {
+			t.Errorf("%v with %+v: FallbackProtocol = %v, want %v", tt.proto, tt.caps, got, tt.want)
+		}
+	}
+
+	// Kitty without placeholders only falls back where it must pass
+	// through a multiplexer.
+	bare := &Capabilities{Term: TermWezTerm}
+	if got := bare.PlaceableProtocol(ProtocolKitty); got != ProtocolKitty {
+		t.Errorf("PlaceableProtocol outside a multiplexer = %v, want kitty", got)
+	}
+	tmux := &Capabilities{Term: TermWezTerm, Tmux: true, Mux: true, Passthrough: true}
+	if got := tmux.PlaceableProtocol(ProtocolKitty); got != ProtocolITerm2 {
+		t.Errorf("PlaceableProtocol in tmux = %v, want iterm2", got)
+	}
+	tmux.KittyPlaceholders = true
+	if got := tmux.PlaceableProtocol(ProtocolKitty); got != ProtocolKitty {
+		t.Errorf("PlaceableProtocol in tmux with placeholders = %v, want kitty", got)
+	}
 }
 
 func TestDetectCapabilities_TrueColor_COLORTERM(t *testing.T) {
@@ -720,3 +831,209 @@ func TestDetect_to_Protocol_Generic(t *testing.T) {
 		t.Errorf("SelectProtocol(Generic) = %v, want ProtocolHalfblocks", proto)
 	}
 }
+
+// --- Probe Tests ---
+
+func TestParseProbe(t *testing.T) {
+	replies := "\x1b[6;18;9t" + "\x1b_Gi=31;OK\x1b\\" + "\x1b[?62;4;22c"
+
+	r, complete := parseProbe([]byte(replies[:len(replies)-3]))
+	if complete {
+		t.Error("parseProbe without the DA1 reply reported complete")
+	}
+	if r.CellW != 9 || r.CellH != 18 || !r.Kitty {
+		t.Errorf("partial parse = %+v, want 9x18 cells with kitty", r)
+	}
+
+	r, complete = parseProbe([]byte(replies))
+	if !complete {
+		t.Fatal("parseProbe with the DA1 reply reported incomplete")
+	}
+	if !r.Sixel {
+		t.Error("DA1 listing 4 did not report sixel")
+	}
+
+	r, _ = parseProbe([]byte("\x1b]11;rgb:fdfd/f6f6/e3e3\x1b\\\x1b[?1c"))
+	if r.Background != "#fdf6e3" {
+		t.Errorf("OSC 11 background = %q, want #fdf6e3", r.Background)
+	}
+	r, _ = parseProbe([]byte("\x1b]11;rgb:f/8/0\x07\x1b[?1c"))
+	if r.Background != "#ff8800" {
+		t.Errorf("short OSC 11 background = %q, want #ff8800", r.Background)
+	}
+
+	r, complete = parseProbe([]byte("\x1b[?1;2c"))
+	if !complete || r.Kitty || r.Sixel || r.CellW != 0 || r.Tmux {
+		t.Errorf("DA1-only parse = %+v (complete %v), want nothing supported", r, complete)
+	}
+
+	r, _ = parseProbe([]byte("\x1bP>|tmux 3.4\x1b\\\x1b[?1;2c"))
+	if !r.Tmux {
+		t.Error("XTVERSION reply naming tmux did not report tmux")
+	}
+	r, _ = parseProbe([]byte("\x1bP>|kitty(0.35.2)\x1b\\\x1b[?62;4c"))
+	if r.Tmux || r.Version != "kitty(0.35.2)" {
+		t.Errorf("XTVERSION reply naming kitty parsed as %+v", r)
+	}
+
+	r, _ = parseProbe([]byte("\x1b_Gi=31;OK\x1b\\\x1b_Gi=32;OK\x1b\\\x1b[?62c"))
+	if !r.Kitty || !r.KittyPlaceholders {
+		t.Errorf("parse = %+v, want kitty with placeholders", r)
+	}
+	r, _ = parseProbe([]byte("\x1b_Gi=31;OK\x1b\\\x1b_Gi=32;EINVAL:unknown key\x1b\\\x1b[?62c"))
+	if !r.Kitty || r.KittyPlaceholders {
+		t.Errorf("parse = %+v, want kitty without placeholders", r)
+	}
+}
+
+func TestProbeQueries(t *testing.T) {
+	bare := probeQueries(&Capabilities{})
+	if !strings.Contains(bare, queryKitty+queryKittyPlaceholder) || !strings.Contains(bare, queryXTVersion) || !strings.HasSuffix(bare, queryDA1) {
+		t.Errorf("queries = %q, want the kitty and XTVERSION queries, ending with DA1", bare)
+	}
+	if q := probeQueries(&Capabilities{Tmux: true}); q != bare {
+		t.Errorf("tmux without passthrough queries = %q, want them unwrapped", q)
+	}
+	q := probeQueries(&Capabilities{Tmux: true, Passthrough: true})
+	if !strings.Contains(q, "\x1bPtmux;\x1b\x1b_Gi=31,") || !strings.Contains(q, "\x1bPtmux;\x1b\x1b_Gi=32,") || strings.Contains(q, "\x1b[16t\x1b_G") {
+		t.Errorf("tmux with passthrough queries = %q, want the kitty queries wrapped", q)
+	}
+}
+
+func TestProbeApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		term  Terminal
+		from  GraphicsProtocol
+		probe probeResult
+		want  GraphicsProtocol
+	}{
+		{"kitty reply upgrades", TermGeneric, ProtocolHalfblocks, probeResult{Kitty: true}, ProtocolKitty},
+		{"no kitty reply downgrades", TermGeneric, ProtocolKitty, probeResult{}, ProtocolHalfblocks},
+		{"no kitty reply falls back to sixel", TermGeneric, ProtocolKitty, probeResult{Sixel: true}, ProtocolSixel},
+		{"no kitty reply falls back to iterm2", TermWezTerm, ProtocolKitty, probeResult{Sixel: true}, ProtocolITerm2},
+		{"sixel preferred over halfblocks", TermGeneric, ProtocolHalfblocks, probeResult{Sixel: true}, ProtocolSixel},
+		{"iterm2 kept", TermGeneric, ProtocolITerm2, probeResult{}, ProtocolITerm2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Capabilities{Term: tt.term, Protocol: tt.from, Size: Size{Cols: 80, Rows: 24}}
+			tt.probe.apply(c)
+			if c.Protocol != tt.want {
+				t.Errorf("Protocol = %v, want %v", c.Protocol, tt.want)
+			}
+			if !c.Probed {
+				t.Error("Probed = false after apply")
+			}
+		})
+	}
+
+	// tmux known only from its reply cannot be asked about passthrough.
+	c := &Capabilities{Protocol: ProtocolKitty}
+	probeResult{Kitty: true, Tmux: true}.apply(c)
+	if !c.Tmux || !c.Mux || c.Passthrough || c.ProtocolWithOverride("") != ProtocolHalfblocks {
+		t.Errorf("caps = %+v, want tmux without passthrough, drawing halfblocks", c)
+	}
+
+	// Placeholders need the OK and a terminal known to draw them.
+	for _, tt := range []struct {
+		term  Terminal
+		probe probeResult
+		want  bool
+	}{
+		{TermGeneric, probeResult{Kitty: true, KittyPlaceholders: true, Version: "kitty(0.35.2)"}, true},
+		{TermGeneric, probeResult{Kitty: true, KittyPlaceholders: true, Version: "kitty(0.26.5)"}, false},
+		{TermGeneric, probeResult{Kitty: true, KittyPlaceholders: true, Version: "ghostty 1.1.0"}, true},
+		{TermGeneric, probeResult{Kitty: true, KittyPlaceholders: true, Version: "WezTerm 20240203"}, false},
+		{TermGhostty, probeResult{Kitty: true, KittyPlaceholders: true}, true},
+		{TermKitty, probeResult{Kitty: true, KittyPlaceholders: true, Version: "tmux 3.4"}, true},
+		{TermWezTerm, probeResult{Kitty: true, KittyPlaceholders: true}, false},
+		{TermKitty, probeResult{Kitty: true, Version: "kitty(0.35.2)"}, false},
+	} {
+		c := &Capabilities{Term: tt.term, KittyPlaceholders: true}
+		tt.probe.apply(c)
+		if c.KittyPlaceholders != tt.want {
+			t.Errorf("%v with %+v: KittyPlaceholders = %v, want %v", tt.term, tt.probe, c.KittyPlaceholders, tt.want)
+		}
+	}
+
+	c = &Capabilities{Size: Size{Cols: 80, Rows: 24}}
+	probeResult{CellW: 10, CellH: 20}.apply(c)
+	if c.Size.CellW != 10 || c.Size.CellH != 20 || c.Size.PixelW != 800 || c.Size.PixelH != 480 {
+		t.Errorf("Size = %+v, want 10x20 cells, 800x480 pixels", c.Size)
+	}
+}
+
+func TestCapsCacheEntry(t *testing.T) {
+	clearTermEnv(t)
+	t.Setenv("TERM", "xterm-256color")
+	dir := t.TempDir()
+	path := capsCachePath(dir, "34816")
+	size := Size{Cols: 120, Rows: 40}
+	caps := &Capabilities{Term: TermGeneric, Protocol: ProtocolKitty, Size: size, Probed: true}
+	writeCapsEntry(path, caps, size)
+
+	if filepath.Dir(path) != filepath.Join(dir, "terminal") {
+		t.Errorf("cache path %s is not under %s/terminal", path, dir)
+	}
+	got, ok := readCapsEntry(path, size, time.Hour)
+	if !ok {
+		t.Fatal("fresh entry at the same size was not used")
+	}
+	if got.Protocol != ProtocolKitty || !got.Probed {
+		t.Errorf("cached caps = %+v, want the probed Kitty entry", got)
+	}
+	if _, ok := readCapsEntry(path, Size{Cols: 100, Rows: 40}, time.Hour); ok {
+		t.Error("entry was used after the terminal was resized")
+	}
+	if _, ok := readCapsEntry(path, size, time.Nanosecond); ok {
+		t.Error("expired entry was used")
+	}
+	old, _ := json.Marshal(capsEntry{Caps: *caps, Size: size, ProbedAt: time.Now()})
+	if err := os.WriteFile(path, old, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readCapsEntry(path, size, time.Hour); ok {
+		t.Error("entry written by an older probe was used")
+	}
+
+	if capsCachePath(dir, "34817") == path {
+		t.Error("different TTYs share a cache entry")
+	}
+	t.Setenv("TERM_PROGRAM", "WezTerm")
+	if capsCachePath(dir, "34816") == path {
+		t.Error("different TERM_PROGRAM values share a cache entry")
+	}
+}
+
+func TestProtocolWithOverride(t *testing.T) {
+	c := &Capabilities{Term: TermGeneric, Protocol: ProtocolKitty}
+	for override, want := range map[string]GraphicsProtocol{
+		"":        ProtocolKitty,
+		"auto":    ProtocolKitty,
+		"bogus":   ProtocolKitty,
+		"sixel":   ProtocolSixel,
+		"none":    ProtocolNone,
+		"Unicode": ProtocolHalfblocks,
+	} {
+		if got := c.ProtocolWithOverride(override); got != want {
+			t.Errorf("ProtocolWithOverride(%q) = %v, want %v", override, got, want)
+		}
+	}
+}
+
+func TestColorFGBGBackground(t *testing.T) {
+	for v, want := range map[string]string{
+		"":            "",
+		"15;0":        "#000000",
+		"0;15":        "#ffffff",
+		"0;7":         "#ffffff",
+		"7;default;0": "#000000",
+		"15;x":        "",
+		"0;42":        "",
+	} {
+		if got := colorFGBGBackground(v); got != want {
+			t.Errorf("colorFGBGBackground(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package terminal
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Passthrough is how graphics escape sequences must be wrapped to reach
+// the outer terminal through a multiplexer.
+type Passthrough int
+
+const (
+	PassthroughNone   Passthrough = iota // Written as is
+	PassthroughTmux                      // DCS tmux; ... ST, ESC doubled
+	PassthroughScreen                    // DCS ... ST, in chunks
+)
+
+// String returns the name used in cache keys: "" for none, "tmux", or
+// "screen".
+func (p Passthrough) String() string {
+	switch p {
+	case PassthroughTmux:
+		return "tmux"
+	case PassthroughScreen:
+		return "screen"
+	default:
+		return ""
+	}
+}
+
+// tmuxPassthrough reports whether the current tmux pane forwards
+// passthrough sequences; replaced in tests.
+var tmuxPassthrough = tmuxAllowsPassthrough
+
+// tmuxAllowsPassthrough asks tmux for the pane's allow-passthrough option.
+// tmux before 3.3 has no such option and always forwards passthrough; any
+// other failure, including no answer within 250ms, counts as off.
+func tmuxAllowsPassthrough() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "tmux", "show-options", "-Apv", "allow-passthrough").CombinedOutput()
+	v := strings.TrimSpace(string(out))
+	if err != nil {
+		return strings.Contains(v, "invalid option") || strings.Contains(v, "unknown option")
+	}
+	return v == "on" || v == "all"
+}
+
+// GraphicsPassthrough returns how output in protocol p must be wrapped to
+// reach the outer terminal, and false when it cannot get there at all:
+// tmux with allow-passthrough off, or Kitty graphics under screen, whose
+// cursor and placeholder handling breaks them. Sixel and the character
+// cell protocols are written as is; multiplexers that understand sixel
+// draw it themselves.
+func (c *Capabilities) GraphicsPassthrough(p GraphicsProtocol) (Passthrough, bool) {
+	if p != ProtocolKitty && p != ProtocolITerm2 {
+		return PassthroughNone, true
+	}
+	switch {
+	case c.Tmux:
+		return PassthroughTmux, c.Passthrough
+	case c.Screen:
+		return PassthroughScreen, c.Passthrough && p == ProtocolITerm2
+	default:
+		return PassthroughNone, true
+	}
+}
@@ -0,0 +1,316 @@
+package terminal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCapabilitiesTTL is how long a probed capability entry is reused
+// before the terminal is queried again.
+const DefaultCapabilitiesTTL = 6 * time.Hour
+
+// DefaultProbeTimeout is the hard deadline for a terminal to answer the
+// capability queries.
+const DefaultProbeTimeout = 200 * time.Millisecond
+
+// Layer 2 queries, written in this order. The terminal answers in order,
+// so the primary device attributes (DA1) reply, which every terminal
+// sends, marks the end of the responses. The Kitty queries transmit a
+// one-pixel image with a=q, which checks it without storing it; terminals
+// that do not speak the protocol discard the APC without drawing it.
+const (
+	queryCellSize         = "\x1b[16t"                                       // reply: CSI 6 ; height ; width t
+	queryKitty            = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"     // reply: APC G i=31 ; OK ST
+	queryKittyPlaceholder = "\x1b_Gi=32,s=1,v=1,a=q,U=1,t=d,f=24;AAAA\x1b\\" // reply: APC G i=32 ; OK ST
+	queryBackground       = "\x1b]11;?\x1b\\"                                // reply: OSC 11 ; rgb:RRRR/GGGG/BBBB ST
+	queryXTVersion        = "\x1b[>0q"                                       // reply: DCS > | name version ST
+	queryDA1              = "\x1b[c"                                         // reply: CSI ? params c
+)
+
+// probeQueries returns the queries to write. Inside tmux with passthrough
+// on, the Kitty queries are wrapped so the outer terminal answers them
+// rather than tmux swallowing them.
+func probeQueries(c *Capabilities) string {
+	kitty := queryKitty + queryKittyPlaceholder
+	if c.Tmux && c.Passthrough {
+		kitty = probeWrapTmux(queryKitty) + probeWrapTmux(queryKittyPlaceholder)
+	}
+	return queryCellSize + kitty + queryBackground + queryXTVersion + queryDA1
+}
+
+// probeWrapTmux wraps one query in a tmux passthrough sequence.
+func probeWrapTmux(q string) string {
+	return "\x1bPtmux;" + strings.ReplaceAll(q, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+var (
+	reCellSize = regexp.MustCompile(`\x1b\[6;(\d+);(\d+)t`)
+	reDA1      = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+	reOSC11    = regexp.MustCompile(`\x1b\]11;rgb:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})`)
+	reXTVer    = regexp.MustCompile(`\x1bP>\|([^\x1b]*)\x1b\\`)
+	kittyOK    = []byte("\x1b_Gi=31;OK")
+	kittyUOK   = []byte("\x1b_Gi=32;OK")
+
+	// reKittyVersion matches the XTVERSION reply of kitty, which added
+	// Unicode placeholders in 0.28.
+	reKittyVersion = regexp.MustCompile(`^kitty\((\d+)\.(\d+)`)
+)
+
+// probeResult is what the terminal reported in answer to the queries.
+type probeResult struct {
+	CellW, CellH int    // cell size in pixels, 0 if unanswered
+	Kitty        bool   // answered the Kitty graphics query with OK
+	Sixel        bool   // lists sixel (4) in its device attributes
+	Background   string // background color as "#rrggbb", "" if unanswered
+	Tmux         bool   // the XTVERSION reply names tmux
+	Version      string // the XTVERSION reply, "" if unanswered
+
+	// KittyPlaceholders reports an OK to the Kitty query that asks for a
+	// Unicode placeholder placement.
+	KittyPlaceholders bool
+}
+
+// parseProbe parses the replies read so far. It reports whether the DA1
+// reply has arrived, after which nothing more is expected.
+func parseProbe(buf []byte) (probeResult, bool) {
+	var r probeResult
+	if m := reCellSize.FindSubmatch(buf); m != nil {
+		fmt.Sscan(string(m[1]), &r.CellH)
+		fmt.Sscan(string(m[2]), &r.CellW)
+	}
+	r.Kitty = bytes.Contains(buf, kittyOK)
+	r.KittyPlaceholders = bytes.Contains(buf, kittyUOK)
+	if m := reOSC11.FindSubmatch(buf); m != nil {
+		r.Background = "#" + scaleHex(m[1]) + scaleHex(m[2]) + scaleHex(m[3])
+	}
+	if m := reXTVer.FindSubmatch(buf); m != nil {
+		r.Version = string(m[1])
+		r.Tmux = strings.HasPrefix(r.Version, "tmux")
+	}
+	m := reDA1.FindSubmatch(buf)
+	if m == nil {
+		return r, false
+	}
+	for _, p := range strings.Split(string(m[1]), ";") {
+		if p == "4" {
+			r.Sixel = true
+		}
+	}
+	return r, true
+}
+
+// scaleHex converts an X11 color component of 1-4 hex digits to two.
+func scaleHex(h []byte) string {
+	var v uint64
+	for _, c := range h {
+		v = v<<4 | uint64(hexDigit(c))
+	}
+	max := uint64(1)<<(4*len(h)) - 1
+	return fmt.Sprintf("%02x", (v*255+max/2)/max)
+}
+
+// hexDigit returns the value of the hex digit c.
+func hexDigit(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
+
+// apply refines environment-based capabilities with a probe result. A
+// terminal that answers the Kitty query gets the Kitty protocol even if it
+// was not recognized from the environment; one that answers DA1 without it
+// does not, which catches multiplexers that swallow the graphics query,
+// and falls back a level (see FallbackProtocol). Unicode placeholders are
+// taken as supported when the terminal accepted the placeholder query and
+// is one known to draw them (see kittyPlaceholderSupport), since a
+// terminal that ignores the key answers OK all the same.
+// tmux found only by its XTVERSION reply, as when it runs on the far side
+// of SSH, cannot be asked about passthrough, so it is taken to be off.
+func (r probeResult) apply(c *Capabilities) {
+	c.Probed = true
+	if r.Tmux && !c.Tmux {
+		c.Tmux, c.Mux, c.Passthrough = true, true, false
+	}
+	if r.Background != "" {
+		c.Background = r.Background
+	}
+	if c.Size.CellW == 0 && c.Size.CellH == 0 && r.CellW > 0 && r.CellH > 0 {
+		c.Size.CellW, c.Size.CellH = r.CellW, r.CellH
+		c.Size.PixelW, c.Size.PixelH = r.CellW*c.Size.Cols, r.CellH*c.Size.Rows
+	}
+	c.Sixel = c.Sixel || r.Sixel
+	switch {
+	case r.Kitty:
+		c.Protocol = ProtocolKitty
+		c.KittyPlaceholders = r.KittyPlaceholders && kittyPlaceholderSupport(r.Version, c.Term)
+	case c.Protocol == ProtocolKitty:
+		c.Protocol = c.FallbackProtocol(ProtocolKitty)
+		c.KittyPlaceholders = false
+	case c.Protocol == ProtocolHalfblocks && c.Sixel:
+		c.Protocol = ProtocolSixel
+	}
+}
+
+// kittyPlaceholderSupport reports whether the terminal that sent the
+// XTVERSION reply version draws Kitty Unicode placeholders: kitty from
+// 0.28 and Ghostty. Without a reply, or with one from tmux, which answers
+// for itself rather than the outer terminal, the terminal detected from
+// the environment decides.
+func kittyPlaceholderSupport(version string, term Terminal) bool {
+	if version == "" || strings.HasPrefix(version, "tmux") {
+		return term.SupportsKittyPlaceholders()
+	}
+	if m := reKittyVersion.FindStringSubmatch(version); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		return major > 0 || minor >= 28
+	}
+	return strings.HasPrefix(strings.ToLower(version), "ghostty")
+}
+
+// CacheOptions controls LoadCapabilities.
+type CacheOptions struct {
+	// Dir is the cache directory. Probed capabilities are kept in its
+	// "terminal" subdirectory. Empty disables the on-disk cache.
+	Dir string
+
+	// TTL is how long a cached entry is trusted. Default:
+	// DefaultCapabilitiesTTL.
+	TTL time.Duration
+
+	// ProbeTimeout bounds the terminal queries. Default:
+	// DefaultProbeTimeout.
+	ProbeTimeout time.Duration
+
+	// Redetect ignores any cached entry and probes again.
+	Redetect bool
+
+	// CacheOnly never queries the terminal: without a fresh cached entry
+	// the environment-only detection is returned. Use it where a query
+	// could interleave with other output, such as inside a prompt.
+	CacheOnly bool
+}
+
+// capsEntryVersion is bumped when the probe learns something new, so
+// entries cached by an older probe are probed again rather than trusted.
+const capsEntryVersion = 2
+
+// capsEntry is a cached probe result.
+type capsEntry struct {
+	Version  int          `json:"version,omitempty"`
+	Caps     Capabilities `json:"caps"`
+	Size     Size         `json:"size"`
+	ProbedAt time.Time    `json:"probed_at"`
+}
+
+// LoadCapabilities returns the terminal capabilities, querying the
+// terminal only when no fresh cached entry exists for it. Entries are keyed
+// by TERM, TERM_PROGRAM, and the controlling TTY, and are discarded when
+// the terminal now reports a different size than when it was probed. The
+// result also becomes the in-process cached value. Without a controlling
+// TTY the environment-only detection is returned.
+func LoadCapabilities(opts CacheOptions) *Capabilities {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCapabilitiesTTL
+	}
+	if opts.ProbeTimeout <= 0 {
+		opts.ProbeTimeout = DefaultProbeTimeout
+	}
+
+	caps := loadCapabilities(opts)
+	mu.Lock()
+	defer mu.Unlock()
+	cached = caps
+	detectOnce.Do(func() {})
+	return caps
+}
+
+func loadCapabilities(opts CacheOptions) *Capabilities {
+	caps := detect()
+	tty := ttyDevice()
+	if tty == "" {
+		return caps
+	}
+
+	path := ""
+	if opts.Dir != "" {
+		path = capsCachePath(opts.Dir, tty)
+		if !opts.Redetect {
+			if c, ok := readCapsEntry(path, caps.Size, opts.TTL); ok {
+				return c
+			}
+		}
+	}
+	if opts.CacheOnly {
+		return caps
+	}
+
+	r, err := probeTTY(opts.ProbeTimeout, probeQueries(caps))
+	if err != nil {
+		// A terminal that does not answer in time is not cached, so a
+		// slow attach is retried next time.
+		return caps
+	}
+	size := caps.Size
+	r.apply(caps)
+	if path != "" {
+		writeCapsEntry(path, caps, size)
+	}
+	return caps
+}
+
+// capsCachePath returns the cache file for the current terminal on tty.
+func capsCachePath(dir, tty string) string {
+	sum := sha256.Sum256([]byte(os.Getenv("TERM") + "\x00" + os.Getenv("TERM_PROGRAM") + "\x00" + tty))
+	return filepath.Join(dir, "terminal", fmt.Sprintf("caps-%x.json", sum[:8]))
+}
+
+// readCapsEntry returns the capabilities cached at path if the entry is
+// younger than ttl, was probed at the current terminal size, and was
+// written by the current probe.
+func readCapsEntry(path string, size Size, ttl time.Duration) (*Capabilities, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var e capsEntry
+	if json.Unmarshal(data, &e) != nil {
+		return nil, false
+	}
+	if e.Version != capsEntryVersion || time.Since(e.ProbedAt) > ttl || e.Size != size {
+		return nil, false
+	}
+	return &e.Caps, true
+}
+
+// writeCapsEntry caches caps, probed at the given size as reported by the
+// terminal, at path. Failures are ignored; the next run probes again.
+func writeCapsEntry(path string, caps *Capabilities, size Size) {
+	data, err := json.Marshal(capsEntry{Version: capsEntryVersion, Caps: *caps, Size: size, ProbedAt: time.Now()})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
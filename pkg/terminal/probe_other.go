@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package terminal
+
+import (
+	"errors"
+	"time"
+)
+
+// ttyDevice is a stub for platforms without termios; an empty device
+// disables probing.
+func ttyDevice() string {
+	return ""
+}
+
+// probeTTY is a stub for platforms without termios.
+func probeTTY(time.Duration, string) (probeResult, error) {
+	return probeResult{}, errors.New("terminal probe: unsupported platform")
+}
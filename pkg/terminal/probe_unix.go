@@ -0,0 +1,106 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// ttyDevice identifies the terminal device of the first standard stream
+// that is a TTY, or returns "" when none is.
+func ttyDevice() string {
+	for fd := 0; fd <= 2; fd++ {
+		var st unix.Stat_t
+		if err := unix.Fstat(fd, &st); err != nil {
+			continue
+		}
+		if st.Mode&unix.S_IFMT != unix.S_IFCHR {
+			continue
+		}
+		if _, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err != nil {
+			continue
+		}
+		return fmt.Sprintf("%d", uint64(st.Rdev))
+	}
+	return ""
+}
+
+// probeTTY writes the capability queries to the controlling terminal and
+// reads the replies until the DA1 reply arrives or timeout passes. The
+// terminal is switched to non-canonical mode without echo for the
+// duration, and its previous mode is restored on return and on SIGINT,
+// SIGTERM, or SIGHUP, after which the signal is re-raised.
+func probeTTY(timeout time.Duration, queries string) (probeResult, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return probeResult{}, fmt.Errorf("terminal probe: %w", err)
+	}
+	defer tty.Close()
+	fd := int(tty.Fd())
+
+	old, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return probeResult{}, fmt.Errorf("terminal probe: get termios: %w", err)
+	}
+	raw := *old
+	raw.Lflag &^= unix.ECHO | unix.ICANON
+	// Reads return after 100ms without input, so the deadline is checked
+	// even when the terminal never answers.
+	raw.Cc[unix.VMIN] = 0
+	raw.Cc[unix.VTIME] = 1
+
+	restore := sync.OnceFunc(func() {
+		_ = unix.IoctlSetTermios(fd, ioctlRestoreTermios, old)
+	})
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+	defer func() {
+		signal.Stop(sigs)
+		close(done)
+		restore()
+	}()
+	go func() {
+		select {
+		case sig := <-sigs:
+			restore()
+			signal.Stop(sigs)
+			_ = syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+		case <-done:
+		}
+	}()
+
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return probeResult{}, fmt.Errorf("terminal probe: set termios: %w", err)
+	}
+	if _, err := tty.WriteString(queries); err != nil {
+		return probeResult{}, fmt.Errorf("terminal probe: write: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	var buf []byte
+	chunk := make([]byte, 256)
+	for time.Now().Before(deadline) {
+		// Read the descriptor directly: Fd put it in blocking mode, where
+		// VTIME applies, and the runtime poller is bypassed.
+		n, err := unix.Read(fd, chunk)
+		if err != nil && !errors.Is(err, unix.EINTR) && !errors.Is(err, unix.EAGAIN) {
+			return probeResult{}, fmt.Errorf("terminal probe: read: %w", err)
+		}
+		if n > 0 {
+			buf = append(buf, chunk[:n]...)
+			if r, complete := parseProbe(buf); complete {
+				return r, nil
+			}
+		}
+	}
+	return probeResult{}, errors.New("terminal probe: no reply before deadline")
+}
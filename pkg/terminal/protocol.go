@@ -78,23 +78,84 @@ func selectBaseProtocol(term Terminal) GraphicsProtocol {
 // graphics protocol. If override is empty, detection proceeds normally.
 // Valid override values: "kitty", "iterm2", "sixel", "halfblocks", "none".
 func SelectProtocolWithOverride(term Terminal, override string) GraphicsProtocol {
-	if override == "" {
-		return SelectProtocol(term)
+	if p, ok := parseProtocol(override); ok {
+		return p
 	}
+	// Empty or unknown override, fall back to detection.
+	return SelectProtocol(term)
+}
+
+// ProtocolWithOverride is SelectProtocolWithOverride for detected
+// capabilities: without a valid override it returns c.Protocol, which
+// includes any refinement from probing the terminal. Kitty without a
+// placement that works here falls back a level (see PlaceableProtocol),
+// and a protocol that cannot pass through the multiplexer (see
+// GraphicsPassthrough) falls back to halfblocks.
+func (c *Capabilities) ProtocolWithOverride(override string) GraphicsProtocol {
+	p, ok := parseProtocol(override)
+	if !ok {
+		p = c.Protocol
+	}
+	p = c.PlaceableProtocol(p)
+	if _, ok := c.GraphicsPassthrough(p); !ok {
+		return ProtocolHalfblocks
+	}
+	return p
+}
+
+// FallbackProtocol returns the protocol one level below p that the
+// terminal supports, following Kitty, iTerm2, sixel, halfblocks. It is
+// used when p turns out not to work.
+func (c *Capabilities) FallbackProtocol(p GraphicsProtocol) GraphicsProtocol {
+	switch p {
+	case ProtocolKitty:
+		if c.Term.SupportsITerm2Images() {
+			return ProtocolITerm2
+		}
+		fallthrough
+	case ProtocolITerm2:
+		if c.Sixel {
+			return ProtocolSixel
+		}
+		fallthrough
+	case ProtocolSixel:
+		return ProtocolHalfblocks
+	default:
+		return p
+	}
+}
+
+// PlaceableProtocol returns p unless it is Kitty and no Kitty placement
+// works here, in which case it returns FallbackProtocol(p). Inside a
+// multiplexer that passes graphics through, images must be drawn with
+// Unicode placeholders, since the multiplexer knows nothing of an image
+// placed at the cursor.
+func (c *Capabilities) PlaceableProtocol(p GraphicsProtocol) GraphicsProtocol {
+	if p != ProtocolKitty || c.KittyPlaceholders {
+		return p
+	}
+	if pt, ok := c.GraphicsPassthrough(p); ok && pt != PassthroughNone {
+		return c.FallbackProtocol(p)
+	}
+	return p
+}
+
+// parseProtocol parses a protocol override. It reports false for empty,
+// "auto", and unknown values.
+func parseProtocol(override string) (GraphicsProtocol, bool) {
 	switch strings.ToLower(override) {
 	case "kitty":
-		return ProtocolKitty
+		return ProtocolKitty, true
 	case "iterm2":
-		return ProtocolITerm2
+		return ProtocolITerm2, true
 	case "sixel":
-		return ProtocolSixel
+		return ProtocolSixel, true
 	case "halfblocks", "unicode", "half-blocks":
-		return ProtocolHalfblocks
+		return ProtocolHalfblocks, true
 	case "none", "off", "disabled":
-		return ProtocolNone
+		return ProtocolNone, true
 	default:
-		// Unknown override, fall back to detection.
-		return SelectProtocol(term)
+		return ProtocolNone, false
 	}
 }
 
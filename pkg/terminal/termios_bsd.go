@@ -0,0 +1,14 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package terminal
+
+import "golang.org/x/sys/unix"
+
+// Termios requests for the probe. Restoring with TIOCSETAF discards input
+// the terminal sent after the probe gave up, so late replies never reach
+// the shell.
+const (
+	ioctlGetTermios     = unix.TIOCGETA
+	ioctlSetTermios     = unix.TIOCSETA
+	ioctlRestoreTermios = unix.TIOCSETAF
+)
@@ -0,0 +1,12 @@
+package terminal
+
+import "golang.org/x/sys/unix"
+
+// Termios requests for the probe. Restoring with TCSETSF discards input
+// the terminal sent after the probe gave up, so late replies never reach
+// the shell.
+const (
+	ioctlGetTermios     = unix.TCGETS
+	ioctlSetTermios     = unix.TCSETS
+	ioctlRestoreTermios = unix.TCSETSF
+)
@@ -3,6 +3,8 @@ package theme
 import (
 	"fmt"
 	"strings"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // thApplyBorder colors border text based on whether the widget is focused.
@@ -46,10 +48,22 @@ func thApplyGauge(ratio float64, t Theme) (filled, empty string) {
 	return filled, empty
 }
 
+// Foreground returns the ANSI true-color escape sequence that sets the
+// foreground to hexColor, or "" if hexColor is empty or invalid or
+// render.Current disallows color.
+func Foreground(hexColor string) string {
+	r, g, b, ok := thParseHex(hexColor)
+	if !ok || !render.Current.Color {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b)
+}
+
 // thColorize wraps text in ANSI true-color foreground escape sequences using
-// the given hex color. Returns text unchanged if hexColor is empty or invalid.
+// the given hex color. Returns text unchanged if hexColor is empty or invalid,
+// or if render.Current disallows color.
 func thColorize(text, hexColor string) string {
-	if hexColor == "" {
+	if hexColor == "" || !render.Current.Color {
 		return text
 	}
 	r, g, b, ok := thParseHex(hexColor)
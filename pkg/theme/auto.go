@@ -0,0 +1,90 @@
+package theme
+
+import "strings"
+
+// Auto is the theme name that picks a light or dark theme from the
+// terminal background.
+const Auto = "auto"
+
+// Default variants used by Selection when Light or Dark is empty.
+const (
+	DefaultLight = "solarized-light"
+	DefaultDark  = "default"
+)
+
+// Selection describes how the active theme is chosen for a terminal.
+type Selection struct {
+	// Name is a theme name, or Auto.
+	Name string
+
+	// Light and Dark are the themes Auto picks between. Empty uses
+	// DefaultLight and DefaultDark.
+	Light string
+	Dark  string
+
+	// Terminals overrides Name per terminal, keyed by TERM_PROGRAM (e.g.
+	// "Apple_Terminal"). A value is a theme name, "light" or "dark" for
+	// the Light or Dark variant, or Auto.
+	Terminals map[string]string
+}
+
+// Resolve returns the theme name for the terminal identified by
+// termProgram, whose background is the "#rrggbb" color background. An
+// unknown background resolves Auto to the dark variant.
+func (s Selection) Resolve(termProgram, background string) string {
+	name := s.thName(termProgram)
+	switch strings.ToLower(name) {
+	case Auto:
+		if light, ok := IsLight(background); ok && light {
+			return s.thLight()
+		}
+		return s.thDark()
+	case "light":
+		return s.thLight()
+	case "dark":
+		return s.thDark()
+	}
+	return name
+}
+
+// NeedsBackground reports whether Resolve consults the background for the
+// terminal identified by termProgram, so callers can skip detecting it.
+func (s Selection) NeedsBackground(termProgram string) bool {
+	return strings.EqualFold(s.thName(termProgram), Auto)
+}
+
+// thName returns the configured name for termProgram before variants are
+// applied.
+func (s Selection) thName(termProgram string) string {
+	if name, ok := s.Terminals[termProgram]; ok && termProgram != "" && name != "" {
+		return name
+	}
+	return s.Name
+}
+
+func (s Selection) thLight() string {
+	if s.Light != "" {
+		return s.Light
+	}
+	return DefaultLight
+}
+
+func (s Selection) thDark() string {
+	if s.Dark != "" {
+		return s.Dark
+	}
+	return DefaultDark
+}
+
+// IsLight reports whether the hex color is light, by its relative
+// luminance. ok is false when the color does not parse.
+func IsLight(hex string) (light, ok bool) {
+	r, g, b, ok := thParseHex(hex)
+	if !ok {
+		return false, false
+	}
+	// Rec. 709 luma on the gamma-encoded values is close enough to
+	// separate light from dark backgrounds.
+	luma := 0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)
+	return luma >= 128, true
+}
@@ -9,6 +9,7 @@ func thRegisterBuiltins() {
 		thCatppuccinTheme(),
 		thDraculaTheme(),
 		thTokyoNightTheme(),
+		thSolarizedLightTheme(),
 	} {
 		thRegister(t)
 	}
@@ -211,3 +212,37 @@ func thTokyoNightTheme() Theme {
 		HelpDesc:        "#565f89",
 	}
 }
+
+// thSolarizedLightTheme returns the Solarized Light theme, for terminals
+// with a light background.
+func thSolarizedLightTheme() Theme {
+	return Theme{
+		Name:       "solarized-light",
+		Background: "#fdf6e3",
+		Foreground: "#586e75",
+		Dim:        "#93a1a1",
+		Accent:     "#268bd2",
+
+		Border:      "#eee8d5",
+		BorderFocus: "#268bd2",
+		Title:       "#073642",
+
+		StatusOK:      "#859900",
+		StatusWarn:    "#b58900",
+		StatusError:   "#dc322f",
+		StatusUnknown: "#93a1a1",
+
+		GaugeFilled: "#859900",
+		GaugeEmpty:  "#eee8d5",
+		GaugeWarn:   "#b58900",
+		GaugeCrit:   "#dc322f",
+
+		ChartLine: "#268bd2",
+		ChartFill: "#2aa198",
+		ChartGrid: "#eee8d5",
+
+		SearchHighlight: "#b58900",
+		HelpKey:         "#268bd2",
+		HelpDesc:        "#93a1a1",
+	}
+}
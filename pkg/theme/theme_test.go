@@ -48,11 +48,11 @@ func TestGetUnknownFallsBackToDefault(t *testing.T) {
 
 func TestNames(t *testing.T) {
 	names := Names()
-	if len(names) != 6 {
-		t.Fatalf("Names() returned %d themes, want 6", len(names))
+	if len(names) != 7 {
+		t.Fatalf("Names() returned %d themes, want 7", len(names))
 	}
 
-	expected := []string{"catppuccin", "default", "dracula", "gruvbox", "nord", "tokyo-night"}
+	expected := []string{"catppuccin", "default", "dracula", "gruvbox", "nord", "solarized-light", "tokyo-night"}
 	sort.Strings(expected)
 	for i, name := range expected {
 		if names[i] != name {
@@ -74,6 +74,64 @@ func TestSetCurrent(t *testing.T) {
 	SetCurrent("default")
 }
 
+// --- Automatic selection ---
+
+func TestIsLight(t *testing.T) {
+	tests := []struct {
+		hex       string
+		light, ok bool
+	}{
+		{"#ffffff", true, true},
+		{"#fdf6e3", true, true},
+		{"#000000", false, true},
+		{"#1e1e1e", false, true},
+		{"", false, false},
+		{"#zzzzzz", false, false},
+	}
+	for _, tt := range tests {
+		light, ok := IsLight(tt.hex)
+		if light != tt.light || ok != tt.ok {
+			t.Errorf("IsLight(%q) = %v, %v; want %v, %v", tt.hex, light, ok, tt.light, tt.ok)
+		}
+	}
+}
+
+func TestSelectionResolve(t *testing.T) {
+	sel := Selection{
+		Name: Auto,
+		Dark: "nord",
+		Terminals: map[string]string{
+			"Apple_Terminal": "light",
+			"WezTerm":        "dracula",
+		},
+	}
+	tests := []struct {
+		termProgram, background, want string
+	}{
+		{"", "#ffffff", DefaultLight},
+		{"", "#000000", "nord"},
+		{"", "", "nord"}, // unknown background: dark
+		{"Apple_Terminal", "#000000", DefaultLight},
+		{"WezTerm", "#ffffff", "dracula"},
+		{"iTerm.app", "#fdf6e3", DefaultLight},
+	}
+	for _, tt := range tests {
+		if got := sel.Resolve(tt.termProgram, tt.background); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.termProgram, tt.background, got, tt.want)
+		}
+	}
+
+	if sel.NeedsBackground("Apple_Terminal") {
+		t.Error("NeedsBackground(Apple_Terminal) = true, want false for a fixed override")
+	}
+	if !sel.NeedsBackground("iTerm.app") {
+		t.Error("NeedsBackground(iTerm.app) = false, want true under auto")
+	}
+	if got := (Selection{Name: "gruvbox"}).Resolve("", "#ffffff"); got != "gruvbox" {
+		t.Errorf("named theme resolved to %q, want gruvbox", got)
+	}
+}
+
 // --- Built-in theme completeness ---
 
 func TestAllThemesHaveRequiredFields(t *testing.T) {
@@ -1,43 +1,68 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
 )
 
 // tuiHelpWidth is the fixed width of the help panel.
 const tuiHelpWidth = 60
 
-// tuiRenderHelp renders a centered help panel listing all keybindings.
-// The panel is 60 characters wide and approximately 20 lines tall,
-// centered within the given width and height.
-func tuiRenderHelp(width, height int) string {
+// tuiHelpActions describes each rebindable action in the help overlay.
+var tuiHelpActions = map[string]string{
+	config.KeyNextTab: "Focus next widget",
+	config.KeyPrevTab: "Focus previous widget",
+	config.KeyExpand:  "Expand / collapse widget",
+	config.KeyBack:    "Collapse expanded widget",
+	config.KeyRefresh: "Refresh data now",
+	config.KeySearch:  "Search the focused table or widgets",
+	config.KeyHelp:    "Show this help",
+	config.KeyQuit:    "Quit",
+}
+
+// tuiHelpLine formats one "keys  description" row.
+func tuiHelpLine(keys, desc string) string {
+	return fmt.Sprintf("  %-20s%s", keys, desc)
+}
+
+// tuiRenderHelp renders a centered help panel listing the active bindings
+// in km, grouped by pane. The panel is 60 characters wide, sized to its
+// content, and centered within the given width and height.
+func tuiRenderHelp(km Keymap, width, height int) string {
 	if width <= 0 || height <= 0 {
 		return ""
 	}
 
-	helpLines := []string{
+	helpLines := []string{"", components.Bold("  Dashboard"), ""}
+	for _, action := range config.TUIKeyActions {
+		labels := make([]string, 0, len(km.Keys(action)))
+		for _, k := range km.Keys(action) {
+			labels = append(labels, tuiKeyLabel(k))
+		}
+		helpLines = append(helpLines, tuiHelpLine(strings.Join(labels, " / "), tuiHelpActions[action]))
+	}
+	helpLines = append(helpLines,
+		tuiHelpLine("Ctrl+C", "Force quit"),
 		"",
-		components.Bold("  Keybindings"),
+		components.Bold("  Focused Widget"),
 		"",
-		"  Tab / Shift+Tab     Cycle widget focus",
-		"  h / l               Navigate left / right",
-		"  j / k               Navigate down / up",
-		"  Enter               Expand / collapse widget",
-		"  Escape              Close overlay / collapse",
-		"  ?                   Toggle this help",
-		"  /                   Enter search mode",
-		"  q                   Quit",
-		"  Ctrl+C              Force quit",
+		tuiHelpLine("\u2191 / \u2193", "Move selection"),
+		tuiHelpLine("\u2192 / \u2190", "Drill into / back out of widget"),
+		tuiHelpLine("Other keys", "Widget-specific, e.g. s to sort"),
 		"",
 		components.Bold("  Search Mode"),
 		"",
-		"  Type to filter      Matches widget ID and title",
-		"  Enter               Confirm search filter",
-		"  Escape              Cancel search",
+		tuiHelpLine("Type to filter", "Table cells, or widget ID and title"),
+		tuiHelpLine("Enter", "Confirm search filter"),
+		tuiHelpLine("Escape", "Cancel search"),
 		"",
-	}
+		components.Dim("  Press any key to close"),
+		"",
+	)
 
 	helpContent := strings.Join(helpLines, "\n")
 
@@ -55,7 +80,7 @@ func tuiRenderHelp(width, height int) string {
 		Border:     components.BorderRounded,
 		Title:      "Help",
 		TitleAlign: components.AlignCenter,
-		FG:         "#7C3AED",
+		FG:         theme.Current.Accent,
 	}
 
 	panel := components.RenderBox(helpContent, panelW, panelH, style)
@@ -1,45 +1,132 @@
 package tui
 
 import (
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
 )
 
-// tuiHandleKey processes all keyboard input for the TUI model.
-// It handles global keys (quit, help, search, navigation) and delegates
-// arrow keys to the focused widget's HandleKey method.
+// Keymap resolves key names, as reported by tea.KeyMsg.String, to TUI
+// actions (config.KeyQuit, config.KeyHelp, ...). It is the single source
+// for key dispatch, the help overlay, and the status bar hints.
+type Keymap struct {
+	keys    map[string][]string // action -> keys
+	actions map[string]string   // key -> action
+}
+
+// NewKeymap builds a Keymap from action bindings such as
+// config.TUIConfig.Keys. Actions missing from keys keep their defaults.
+// Bindings are assumed valid; config.Config.Validate rejects conflicts.
+func NewKeymap(keys map[string][]string) Keymap {
+	km := Keymap{
+		keys:    config.DefaultTUIKeys(),
+		actions: make(map[string]string),
+	}
+	for action, k := range keys {
+		if len(k) > 0 {
+			km.keys[action] = k
+		}
+	}
+	for action, k := range km.keys {
+		for _, key := range k {
+			km.actions[key] = action
+		}
+	}
+	return km
+}
+
+// Action returns the action bound to key, or "" if none is.
+func (km Keymap) Action(key string) string {
+	return km.actions[key]
+}
+
+// Keys returns the keys bound to action.
+func (km Keymap) Keys(action string) []string {
+	return km.keys[action]
+}
+
+// label returns the first key bound to action for compact hints.
+func (km Keymap) label(action string) string {
+	if k := km.keys[action]; len(k) > 0 {
+		return tuiKeyLabel(k[0])
+	}
+	return ""
+}
+
+// tuiKeyLabel formats a key name for display: named keys and modifiers are
+// capitalized ("shift+tab" -> "Shift+Tab", "ctrl+c" -> "Ctrl+C"), while
+// plain characters are shown as typed.
+func tuiKeyLabel(key string) string {
+	if key == " " {
+		return "Space"
+	}
+	parts := strings.Split(key, "+")
+	for i, p := range parts {
+		if len(p) > 1 || (len(parts) > 1 && i == len(parts)-1) {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// tuiHandleKey processes all keyboard input for the TUI model. It resolves
+// keys to actions through the model's Keymap and delegates every unbound
+// key, including the arrows, to the focused widget's HandleKey method.
 func tuiHandleKey(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Ctrl+C always quits, regardless of mode.
+	// Ctrl+C always quits, regardless of mode or keymap.
 	if msg.Type == tea.KeyCtrlC {
 		return m, tea.Quit
 	}
 
-	// When in search mode, most keys are captured as search input.
+	// While searching, most keys are captured as search input.
+	if m.tableSearch {
+		return tuiHandleTableSearchKey(m, msg)
+	}
 	if m.searchMode {
 		return tuiHandleSearchKey(m, msg)
 	}
 
-	switch msg.String() {
-	case "q":
+	// The help overlay is dismissed by any key.
+	if m.showHelp {
+		m.showHelp = false
+		return m, nil
+	}
+
+	switch m.keymap.Action(msg.String()) {
+	case config.KeyQuit:
 		return m, tea.Quit
 
-	case "?":
-		m.showHelp = !m.showHelp
+	case config.KeyHelp:
+		m.showHelp = true
 		return m, nil
 
-	case "/":
+	case config.KeySearch:
+		// A focused pane showing a table searches its rows instead.
+		if f := tuiFocusedTable(m); f != nil {
+			return tuiOpenTableSearch(m, f)
+		}
 		m.searchMode = true
 		m.searchQuery = ""
 		return m, nil
 
-	case "tab":
+	case config.KeyRefresh:
+		if m.loader == nil {
+			return m, nil
+		}
+		m.statusMsg = "refreshing..."
+		return m, tuiLoadCmd(m.loader)
+
+	case config.KeyNextTab:
 		m = tuiCycleFocus(m, 1)
 		return m, nil
 
-	case "shift+tab":
+	case config.KeyPrevTab:
 		m = tuiCycleFocus(m, -1)
 		return m, nil
 
-	case "enter":
+	case config.KeyExpand:
 		if m.expanded >= 0 {
 			// Collapse if already expanded.
 			m.expanded = -1
@@ -48,48 +135,17 @@ func tuiHandleKey(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "esc":
-		if m.showHelp {
-			m.showHelp = false
-			return m, nil
-		}
+	case config.KeyBack:
 		if m.expanded >= 0 {
 			m.expanded = -1
-			return m, nil
-		}
-		return m, nil
-
-	// Vim-style navigation between widgets (only when not expanded).
-	case "h", "l":
-		if m.expanded < 0 {
-			if msg.String() == "l" {
-				m = tuiCycleFocus(m, 1)
-			} else {
-				m = tuiCycleFocus(m, -1)
-			}
-		}
-		return m, nil
-
-	case "j":
-		if m.expanded < 0 {
-			m = tuiCycleFocus(m, 1)
-		}
-		return m, nil
-
-	case "k":
-		if m.expanded < 0 {
-			m = tuiCycleFocus(m, -1)
 		}
 		return m, nil
 	}
 
-	// Arrow keys: pass to the focused widget's HandleKey.
-	switch msg.Type {
-	case tea.KeyUp, tea.KeyDown, tea.KeyLeft, tea.KeyRight:
-		if m.focused >= 0 && m.focused < len(m.widgets) {
-			cmd := m.widgets[m.focused].HandleKey(msg)
-			return m, cmd
-		}
+	// Unbound keys: pass to the focused widget's HandleKey.
+	if m.focused >= 0 && m.focused < len(m.widgets) {
+		cmd := m.widgets[m.focused].HandleKey(msg)
+		return m, cmd
 	}
 
 	return m, nil
@@ -1,12 +1,18 @@
 // Package tui implements the fullscreen interactive TUI dashboard using
 // Bubbletea's Elm architecture. It manages a widget grid, keyboard
-// navigation, widget expansion, search filtering, and a help overlay.
+// navigation, widget expansion, widget and table search filtering, and a
+// help overlay.
 package tui
 
 import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // Model is the root Bubbletea model for the fullscreen TUI dashboard.
@@ -21,21 +27,57 @@ type Model struct {
 	height      int          // terminal height
 	statusMsg   string       // bottom status bar message
 	ready       bool         // initial size received
+	keymap      Keymap       // key -> action bindings
+	mouse       bool         // mouse input handled
+	dragTarget  int          // index of the widget a drag began in (-1 = none)
+
+	tableSearch bool            // table search input open on the focused pane
+	tableInput  textinput.Model // table search query being typed
+
+	loader       Loader              // live data source (nil = static)
+	refreshEvery time.Duration       // periodic refresh interval
+	dataHashes   map[string][32]byte // last applied data hash per source
+	lastUpdated  time.Time           // last successful load
+
+	staleDir  string          // cache dir whose file ages are checked
+	staleness cache.Staleness // policy for the status bar age note
+	staleNote string          // "billing 3h old" etc, "" when all fresh
 }
 
 // New creates a new TUI Model with the given widgets. The first widget
-// receives initial focus, no widget is expanded, and help is hidden.
+// receives initial focus, no widget is expanded, help is hidden, and mouse
+// input is handled.
 func New(widgets []app.Widget) Model {
 	return Model{
-		widgets:  widgets,
-		focused:  0,
-		expanded: -1,
+		widgets:    widgets,
+		focused:    0,
+		expanded:   -1,
+		keymap:     NewKeymap(nil),
+		mouse:      true,
+		dragTarget: -1,
 	}
 }
 
-// Init implements tea.Model. No initial commands are needed.
+// WithKeymap returns a copy of m that dispatches keys through km.
+func (m Model) WithKeymap(km Keymap) Model {
+	m.keymap = km
+	return m
+}
+
+// WithMouse returns a copy of m that handles mouse input if enabled, and
+// ignores it otherwise.
+func (m Model) WithMouse(enabled bool) Model {
+	m.mouse = enabled
+	return m
+}
+
+// Init implements tea.Model. With a Loader configured it loads data
+// immediately and starts the periodic refresh.
 func (m Model) Init() tea.Cmd {
-	return nil
+	if m.loader == nil {
+		return nil
+	}
+	return tea.Batch(tuiLoadCmd(m.loader), tuiRefreshTick(m.refreshEvery))
 }
 
 // Update implements tea.Model. It routes messages to the appropriate handler.
@@ -47,14 +89,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 		return m, nil
 
+	case app.DataUpdateEvent:
+		// Every widget filters on Source, so broadcast collector data.
+		var cmds []tea.Cmd
+		for _, w := range m.widgets {
+			if cmd := w.Update(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+		return m, tea.Batch(cmds...)
+
+	case tuiRefreshTickMsg:
+		if m.loader == nil {
+			return m, nil
+		}
+		return m, tea.Batch(tuiLoadCmd(m.loader), tuiRefreshTick(m.refreshEvery))
+
+	case tuiRefreshMsg:
+		return tuiApplyRefresh(m, msg)
+
 	case tea.KeyMsg:
 		// Clamp focused index before handling keys.
 		if len(m.widgets) > 0 && m.focused >= len(m.widgets) {
 			m.focused = len(m.widgets) - 1
 		}
 		return tuiHandleKey(m, msg)
+
+	case tea.MouseMsg:
+		return tuiHandleMouse(m, msg)
 	}
 
+	// The table search input's cursor blinks on its own messages.
+	if m.tableSearch {
+		var cmd tea.Cmd
+		m.tableInput, cmd = m.tableInput.Update(msg)
+		return m, cmd
+	}
 	return m, nil
 }
 
@@ -70,34 +140,59 @@ func (m Model) View() string {
 		m.focused = len(m.widgets) - 1
 	}
 
-	// Determine which widgets are visible (search filtering).
-	visibleIndices := tuiVisibleIndices(m)
-
 	var content string
 
+	panes := tuiPanes(m)
 	if m.expanded >= 0 && m.expanded < len(m.widgets) {
 		// Render the expanded widget fullscreen (minus status bar row).
-		content = tuiRenderExpanded(m.widgets[m.expanded], m.width, m.height-1)
-	} else if len(m.widgets) > 0 {
-		// Compute grid layout for visible widgets.
-		cells := tuiComputeGrid(m.widgets, m.width, m.height, visibleIndices, m.focused)
-		content = tuiRenderGrid(cells, m.width, m.height-1)
+		content = tuiRenderExpanded(panes[0].Widget, m.width, m.height-1)
+	} else {
+		content = tuiRenderGrid(panes, m.width, m.height-1)
 	}
 
-	// Render the bottom bar: search bar or status bar.
+	// Render the bottom bar: a search input or the status bar.
 	var bottomBar string
-	if m.searchMode {
+	if m.tableSearch {
+		bottomBar = tuiRenderTableSearchBar(m.tableInput, m.width)
+	} else if m.searchMode {
 		bottomBar = tuiRenderSearchBar(m.searchQuery, m.width)
 	} else {
-		bottomBar = tuiRenderStatusBar(m.statusMsg, m.width)
+		status := m.statusMsg
+		if !m.lastUpdated.IsZero() && status == "" {
+			status = "updated " + m.lastUpdated.Format("15:04:05")
+			if m.staleNote != "" {
+				status += " · " + m.staleNote
+			}
+		}
+		bottomBar = tuiRenderStatusBar(m.keymap, status, m.width)
 	}
 
 	// Help overlay replaces content if visible.
 	if m.showHelp {
-		content = tuiRenderHelp(m.width, m.height-1)
+		content = tuiRenderHelp(m.keymap, m.width, m.height-1)
 	}
 
-	return content + "\n" + bottomBar
+	// Fit the frame to the render profile last, so every widget's
+	// colors and glyphs are covered.
+	return render.Current.Apply(content + "\n" + bottomBar)
+}
+
+// tuiPanes returns the panes View draws: the expanded widget over the
+// whole area above the status bar, or the grid of visible widgets.
+func tuiPanes(m Model) []tuiCell {
+	if m.expanded >= 0 && m.expanded < len(m.widgets) {
+		return []tuiCell{{
+			Widget:  m.widgets[m.expanded],
+			Index:   m.expanded,
+			W:       m.width,
+			H:       m.height - 1,
+			Focused: true,
+		}}
+	}
+	if len(m.widgets) == 0 {
+		return nil
+	}
+	return tuiComputeGrid(m.widgets, m.width, m.height, tuiVisibleIndices(m), m.focused)
 }
 
 // tuiVisibleIndices returns the indices of widgets that should be displayed,
@@ -138,6 +233,11 @@ func (m Model) SearchQuery() string {
 	return m.searchQuery
 }
 
+// TableSearch returns whether the table search input is open.
+func (m Model) TableSearch() bool {
+	return m.tableSearch
+}
+
 // Width returns the current terminal width.
 func (m Model) Width() int {
 	return m.width
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+)
+
+// tuiHandleMouse processes mouse input for the TUI model. A left click on
+// a pane focuses its widget, and one on the widget's content is passed to
+// the widget along with the rest of the drag it begins. The wheel goes to
+// the focused widget. Hit-testing uses the panes View draws, so it follows
+// resizes, search filtering, and the expanded widget.
+func tuiHandleMouse(m Model, msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if !m.mouse {
+		return m, nil
+	}
+
+	// The help overlay is dismissed by any click, like any key.
+	if m.showHelp {
+		if msg.Action == tea.MouseActionPress {
+			m.showHelp = false
+		}
+		return m, nil
+	}
+
+	panes := tuiPanes(m)
+
+	// A drag stays with the widget it began in until the button is
+	// released, wherever the pointer goes.
+	if m.dragTarget >= 0 && msg.Action != tea.MouseActionPress {
+		target := m.dragTarget
+		if msg.Action == tea.MouseActionRelease {
+			m.dragTarget = -1
+		}
+		if p, ok := tuiPaneOf(panes, target); ok {
+			return m, tuiSendMouse(p, msg)
+		}
+		return m, nil
+	}
+
+	if tea.MouseEvent(msg).IsWheel() {
+		target := m.focused
+		if m.expanded >= 0 {
+			target = m.expanded
+		}
+		p, ok := tuiPaneOf(panes, target)
+		if !ok {
+			return m, nil
+		}
+		if _, ok := p.Widget.(app.MouseHandler); ok {
+			return m, tuiSendMouse(p, msg)
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			return m, p.Widget.HandleKey(tea.KeyMsg{Type: tea.KeyUp})
+		case tea.MouseButtonWheelDown:
+			return m, p.Widget.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+		}
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft || msg.Y >= m.height-1 {
+		return m, nil
+	}
+	p, ok := tuiPaneAt(panes, msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+	m.focused = p.Index
+	if _, ok := p.Widget.(app.MouseHandler); !ok || !tuiInContent(p, msg.X, msg.Y) {
+		return m, nil
+	}
+	m.dragTarget = p.Index
+	return m, tuiSendMouse(p, msg)
+}
+
+// tuiPaneAt returns the pane drawn at (x, y). Panes drawn later are on top.
+func tuiPaneAt(panes []tuiCell, x, y int) (tuiCell, bool) {
+	for i := len(panes) - 1; i >= 0; i-- {
+		p := panes[i]
+		if x >= p.X && x < p.X+p.W && y >= p.Y && y < p.Y+p.H {
+			return p, true
+		}
+	}
+	return tuiCell{}, false
+}
+
+// tuiPaneOf returns the pane showing the widget at index.
+func tuiPaneOf(panes []tuiCell, index int) (tuiCell, bool) {
+	for _, p := range panes {
+		if p.Index == index {
+			return p, true
+		}
+	}
+	return tuiCell{}, false
+}
+
+// tuiInContent reports whether (x, y) is inside the pane's border.
+func tuiInContent(p tuiCell, x, y int) bool {
+	return x > p.X && x < p.X+p.W-1 && y > p.Y && y < p.Y+p.H-1
+}
+
+// tuiSendMouse passes msg to the pane's widget, if it takes mouse input,
+// with coordinates relative to the pane's content.
+func tuiSendMouse(p tuiCell, msg tea.MouseMsg) tea.Cmd {
+	h, ok := p.Widget.(app.MouseHandler)
+	if !ok {
+		return nil
+	}
+	msg.X -= p.X + 1
+	msg.Y -= p.Y + 1
+	return h.HandleMouse(msg)
+}
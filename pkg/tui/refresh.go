@@ -0,0 +1,281 @@
+package tui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/gpu"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/ollama"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/tailscale"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/data"
+)
+
+// tuiDefaultRefreshInterval is used when WithRefresh is given a
+// non-positive interval.
+const tuiDefaultRefreshInterval = 5 * time.Second
+
+// tuiLoadTimeout bounds a single Loader call.
+const tuiLoadTimeout = 10 * time.Second
+
+// Loader returns the latest data for each collector, keyed by collector
+// name. Values have the types the widgets expect in app.DataUpdateEvent.
+// Sources with no data are omitted.
+type Loader func(ctx context.Context) (map[string]interface{}, error)
+
+// tuiRefreshTickMsg fires every refresh interval.
+type tuiRefreshTickMsg struct{}
+
+// tuiRefreshMsg carries the result of one Loader call.
+type tuiRefreshMsg struct {
+	data map[string]interface{}
+	err  error
+	at   time.Time
+}
+
+// WithRefresh returns a copy of m that reloads data through loader at
+// startup, every interval, and when r is pressed. Only sources whose data
+// changed since the previous load are sent to the widgets, so unchanged
+// panes render identically and do not flicker.
+func (m Model) WithRefresh(loader Loader, interval time.Duration) Model {
+	if interval <= 0 {
+		interval = tuiDefaultRefreshInterval
+	}
+	m.loader = loader
+	m.refreshEvery = interval
+	return m
+}
+
+// WithStaleness returns a copy of m whose status bar notes the sources
+// whose cache files in dir are stale or expired under s, such as
+// "billing 3h old".
+func (m Model) WithStaleness(dir string, s cache.Staleness) Model {
+	m.staleDir = dir
+	m.staleness = s
+	return m
+}
+
+// LastUpdated returns when data was last loaded successfully, or the zero
+// time if it never has been.
+func (m Model) LastUpdated() time.Time {
+	return m.lastUpdated
+}
+
+// tuiLoadCmd runs loader in the background and delivers a tuiRefreshMsg.
+func tuiLoadCmd(loader Loader) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), tuiLoadTimeout)
+		defer cancel()
+		data, err := loader(ctx)
+		return tuiRefreshMsg{data: data, err: err, at: time.Now()}
+	}
+}
+
+// tuiRefreshTick schedules the next periodic refresh.
+func tuiRefreshTick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return tuiRefreshTickMsg{}
+	})
+}
+
+// tuiApplyRefresh hashes each source in msg and forwards only the changed
+// ones to the widgets as app.DataUpdateEvents, in source-name order.
+func tuiApplyRefresh(m Model, msg tuiRefreshMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.statusMsg = "refresh failed: " + msg.err.Error()
+		return m, nil
+	}
+
+	sources := make([]string, 0, len(msg.data))
+	for source := range msg.data {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	// Copy rather than mutate: earlier Model values share the map.
+	hashes := make(map[string][32]byte, len(m.dataHashes)+len(sources))
+	for k, v := range m.dataHashes {
+		hashes[k] = v
+	}
+
+	var cmds []tea.Cmd
+	for _, source := range sources {
+		data := msg.data[source]
+		h := tuiDataHash(data)
+		if prev, ok := hashes[source]; ok && prev == h {
+			continue
+		}
+		hashes[source] = h
+		ev := app.DataUpdateEvent{Source: source, Data: data, Timestamp: msg.at}
+		for _, w := range m.widgets {
+			if cmd := w.Update(ev); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+
+	m.dataHashes = hashes
+	m.lastUpdated = msg.at
+	m.statusMsg = ""
+	if m.staleDir != "" {
+		m.staleNote = tuiStaleNote(m.staleDir, m.staleness, hashes, msg.at)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// tuiStaleNote describes the sources seen so far whose cache files in dir
+// are stale or expired under s (see cache.EntryFreshness), in source-name
+// order. Expired sources are
+// no longer loaded, so their widgets keep showing the last data they got.
+func tuiStaleNote(dir string, s cache.Staleness, seen map[string][32]byte, now time.Time) string {
+	sources := make([]string, 0, len(seen))
+	for source := range seen {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var notes []string
+	for _, source := range sources {
+		if source == tuiEventsSource || source == tuiSysHistorySource {
+			continue
+		}
+		age, freshness, ok := cache.EntryFreshness(dir, source, s, now)
+		if !ok {
+			continue
+		}
+		switch freshness {
+		case cache.Stale:
+			notes = append(notes, source+" "+cache.FormatAge(age)+" old")
+		case cache.Expired:
+			notes = append(notes, source+" no data")
+		}
+	}
+	return strings.Join(notes, ", ")
+}
+
+// tuiDataHash fingerprints collector data by its JSON encoding, falling
+// back to the Go syntax representation for values JSON cannot encode.
+func tuiDataHash(data interface{}) [32]byte {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%#v", data))
+	}
+	return sha256.Sum256(b)
+}
+
+// tuiCacheDecoders maps each cached collector key to a decoder producing
+// the type its widget expects.
+var tuiCacheDecoders = map[string]func([]byte) (interface{}, error){
+	"claude":     tuiDecode[claude.UsageReport],
+	"billing":    tuiDecode[billing.BillingReport],
+	"tailscale":  tuiDecode[tailscale.Status],
+	"uptimekuma": tuiDecode[uptimekuma.Status],
+	"checks":     tuiDecode[checks.Status],
+	"docker":     tuiDecode[docker.Status],
+	"ollama":     tuiDecode[ollama.Status],
+	"k8s":        tuiDecode[k8s.ClusterStatus],
+	"sysmetrics": tuiDecode[sysmetrics.Metrics],
+	"gpu":        tuiDecode[gpu.Status],
+	"shell":      tuiDecode[shellactivity.Activity],
+}
+
+// tuiEventsSource is the source of the daemon's event log, loaded as a
+// []changelog.Event whatever its age: a log that has not grown lately
+// means nothing changed, not that it is stale.
+const tuiEventsSource = "events"
+
+// tuiSysHistorySource is the source of the daemon's sysmetrics history,
+// loaded as a *data.Ring. Its age is that of the sysmetrics data, whose
+// own file is checked.
+const tuiSysHistorySource = "sysmetrics-history"
+
+// tuiDecode unmarshals b into a new T.
+func tuiDecode[T any](b []byte) (interface{}, error) {
+	v := new(T)
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// CacheLoader returns a Loader that reads the daemon's cached collector
+// data from dir ({name}.json per collector), its event log, and its
+// sysmetrics history. Missing or unparsable files are skipped so one bad
+// entry does not blank the dashboard.
+func CacheLoader(dir string) Loader {
+	return CacheLoaderWithStaleness(dir, cache.Staleness{})
+}
+
+// CacheLoaderWithStaleness is CacheLoader that also skips files expired
+// under s.
+func CacheLoaderWithStaleness(dir string, s cache.Staleness) Loader {
+	return CacheLoaderWithState(dir, dir, s)
+}
+
+// CacheLoaderWithState is CacheLoaderWithStaleness for a daemon that keeps
+// its event log and sysmetrics history in stateDir rather than with its
+// collector data.
+func CacheLoaderWithState(dir, stateDir string, s cache.Staleness) Loader {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		out := make(map[string]interface{})
+		now := time.Now()
+		for name, decode := range tuiCacheDecoders {
+			path := filepath.Join(dir, name+".json")
+			if age, ok := cache.FileAge(path, now); ok && s.Classify(age) == cache.Expired {
+				continue
+			}
+			b, err := cache.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			v, err := decode(b)
+			if err != nil {
+				continue
+			}
+			out[name] = v
+		}
+		if events, err := changelog.Read(filepath.Join(stateDir, changelog.FileName)); err == nil && len(events) > 0 {
+			out[tuiEventsSource] = events
+		}
+		if ring, err := data.ReadRing(filepath.Join(stateDir, sysmetrics.HistoryFileName)); err == nil && ring.Len() > 0 {
+			out[tuiSysHistorySource] = ring
+		}
+		return out, nil
+	}
+}
+
+// CollectorLoader returns a Loader that runs each collector once per
+// refresh. Collectors that fail are omitted, leaving their widgets on the
+// previous data.
+func CollectorLoader(cs ...collectors.Collector) Loader {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		out := make(map[string]interface{}, len(cs))
+		for _, c := range cs {
+			data, err := c.Collect(ctx)
+			if err != nil || data == nil {
+				continue
+			}
+			out[c.Name()] = data
+		}
+		return out, nil
+	}
+}
@@ -1,10 +1,13 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
 )
 
 // tuiRenderGrid renders all widget cells into a single string that
@@ -19,9 +22,9 @@ func tuiRenderGrid(cells []tuiCell, width, height int) string {
 	buf := tuiNewBuffer(width, height)
 
 	for _, cell := range cells {
-		borderColor := "#6B7280" // dim gray
+		borderColor := theme.Current.Border
 		if cell.Focused {
-			borderColor = "#7C3AED" // purple accent
+			borderColor = theme.Current.BorderFocus
 		}
 
 		// Inner dimensions after removing the border (2 chars per axis).
@@ -40,6 +43,7 @@ func tuiRenderGrid(cells []tuiCell, width, height int) string {
 			Border:     components.BorderRounded,
 			Title:      cell.Widget.Title(),
 			TitleAlign: components.AlignLeft,
+			Footer:     tuiTableFooter(cell.Widget),
 			FG:         borderColor,
 		}
 
@@ -72,16 +76,20 @@ func tuiRenderExpanded(widget app.Widget, width, height int) string {
 		Border:     components.BorderRounded,
 		Title:      widget.Title(),
 		TitleAlign: components.AlignLeft,
-		FG:         "#7C3AED", // always accent colored when expanded
+		Footer:     tuiTableFooter(widget),
+		FG:         theme.Current.BorderFocus, // always accent colored when expanded
 	}
 
 	return components.RenderBox(content, width, height, style)
 }
 
 // tuiRenderStatusBar renders a one-line status bar at the bottom of the
-// terminal with key hints. It pads or truncates to exactly width characters.
-func tuiRenderStatusBar(msg string, width int) string {
-	hints := "Tab:focus  Enter:expand  ?:help  /:search  q:quit"
+// terminal with key hints from km. It pads or truncates to exactly width
+// characters.
+func tuiRenderStatusBar(km Keymap, msg string, width int) string {
+	hints := fmt.Sprintf("%s:focus  %s:expand  %s:refresh  %s:help  %s:search  %s:quit",
+		km.label(config.KeyNextTab), km.label(config.KeyExpand), km.label(config.KeyRefresh),
+		km.label(config.KeyHelp), km.label(config.KeySearch), km.label(config.KeyQuit))
 	if msg != "" {
 		hints = msg + "  |  " + hints
 	}
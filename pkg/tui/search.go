@@ -1,8 +1,12 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
 )
@@ -46,3 +50,80 @@ func tuiFilterWidgets(widgets []app.Widget, query string) []int {
 
 	return result
 }
+
+// tuiFocusedTable returns the focused widget when it implements
+// app.TableFilterer and its last View drew a table, and nil otherwise.
+func tuiFocusedTable(m Model) app.TableFilterer {
+	if m.focused < 0 || m.focused >= len(m.widgets) {
+		return nil
+	}
+	f, ok := m.widgets[m.focused].(app.TableFilterer)
+	if !ok {
+		return nil
+	}
+	if _, _, _, drawn := f.TableFilter(); !drawn {
+		return nil
+	}
+	return f
+}
+
+// tuiOpenTableSearch opens the table search input on f, starting from its
+// active query so a locked filter can be refined.
+func tuiOpenTableSearch(m Model, f app.TableFilterer) (tea.Model, tea.Cmd) {
+	query, _, _, _ := f.TableFilter()
+	m.tableInput = textinput.New()
+	m.tableInput.Prompt = "/"
+	m.tableInput.SetValue(query)
+	m.tableSearch = true
+	return m, m.tableInput.Focus()
+}
+
+// tuiHandleTableSearchKey processes key events while the table search
+// input is open. Every edit filters the focused table as typed; Enter
+// closes the input keeping the filter, and Escape clears it.
+func tuiHandleTableSearchKey(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f, _ := m.widgets[m.focused].(app.TableFilterer)
+	switch msg.Type {
+	case tea.KeyEscape:
+		m.tableSearch = false
+		if f != nil {
+			f.FilterTable("")
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		m.tableSearch = false
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.tableInput, cmd = m.tableInput.Update(msg)
+	if f != nil {
+		f.FilterTable(m.tableInput.Value())
+	}
+	return m, cmd
+}
+
+// tuiRenderTableSearchBar renders the table search input in place of the
+// status bar.
+func tuiRenderTableSearchBar(input textinput.Model, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return components.PadRight(components.Truncate(input.View(), width), width)
+}
+
+// tuiTableFooter returns the footer of a pane whose table is filtered: the
+// query and how many of the table's rows match it, e.g. "/web 3/40". It
+// is "" for other panes.
+func tuiTableFooter(w app.Widget) string {
+	f, ok := w.(app.TableFilterer)
+	if !ok {
+		return ""
+	}
+	query, matches, total, drawn := f.TableFilter()
+	if !drawn || query == "" {
+		return ""
+	}
+	return fmt.Sprintf("/%s %d/%d", query, matches, total)
+}
@@ -1,12 +1,25 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/render"
 )
 
 // mockWidget implements app.Widget with minimal stubs for testing.
@@ -16,6 +29,8 @@ type mockWidget struct {
 	minW, minH int
 	lastKey    tea.KeyMsg // records the last key passed to HandleKey
 	keyCalled  bool
+	lastMsg    tea.Msg // records the last message passed to Update
+	updates    int     // number of DataUpdateEvents received
 }
 
 func newMockWidget(id, title string) *mockWidget {
@@ -25,7 +40,13 @@ func newMockWidget(id, title string) *mockWidget {
 func (w *mockWidget) ID() string    { return w.id }
 func (w *mockWidget) Title() string { return w.title }
 
-func (w *mockWidget) Update(_ tea.Msg) tea.Cmd { return nil }
+func (w *mockWidget) Update(msg tea.Msg) tea.Cmd {
+	w.lastMsg = msg
+	if _, ok := msg.(app.DataUpdateEvent); ok {
+		w.updates++
+	}
+	return nil
+}
 
 func (w *mockWidget) View(width, height int) string {
 	if width <= 0 || height <= 0 {
@@ -334,7 +355,37 @@ func TestArrowKeysPassedToFocusedWidget(t *testing.T) {
 	}
 }
 
+func TestUnboundKeysPassedToFocusedWidget(t *testing.T) {
+	m, mocks := newTestTuiModel()
+
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if !mocks[0].keyCalled || mocks[0].lastKey.String() != "s" {
+		t.Errorf("focused widget got %q, want unbound key s", mocks[0].lastKey.String())
+	}
+
+	mocks[0].keyCalled = false
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	if mocks[0].keyCalled {
+		t.Error("bound key ? should not reach the widget")
+	}
+}
+
 // --- Test 17: tuiComputeGrid produces correct cell count ---
+func TestDataUpdateEventBroadcastToWidgets(t *testing.T) {
+	w1 := newMockWidget("k8s", "Kubernetes")
+	w2 := newMockWidget("claude", "Claude")
+	m := New([]app.Widget{w1, w2})
+
+	evt := app.DataUpdateEvent{Source: "k8s", Data: "status"}
+	tuiUpdate(m, evt)
+
+	for _, w := range []*mockWidget{w1, w2} {
+		if got, ok := w.lastMsg.(app.DataUpdateEvent); !ok || got.Source != "k8s" {
+			t.Errorf("widget %s lastMsg = %#v, want the k8s DataUpdateEvent", w.id, w.lastMsg)
+		}
+	}
+}
+
 func TestComputeGridCorrectCellCount(t *testing.T) {
 	w1 := newMockWidget("a", "Alpha")
 	w2 := newMockWidget("b", "Beta")
@@ -443,7 +494,7 @@ func TestFilterWidgetsEmptyQueryReturnsAll(t *testing.T) {
 
 // --- Test 23: tuiRenderStatusBar fits within width ---
 func TestRenderStatusBarFitsWithinWidth(t *testing.T) {
-	bar := tuiRenderStatusBar("", 80)
+	bar := tuiRenderStatusBar(NewKeymap(nil), "", 80)
 
 	// The visible length should not exceed 80.
 	// Note: bar contains ANSI codes, so we check the raw rune length is
@@ -453,7 +504,7 @@ func TestRenderStatusBarFitsWithinWidth(t *testing.T) {
 	}
 
 	// For a very narrow terminal.
-	bar = tuiRenderStatusBar("", 10)
+	bar = tuiRenderStatusBar(NewKeymap(nil), "", 10)
 	if len(bar) == 0 {
 		t.Error("expected non-empty status bar at width=10")
 	}
@@ -461,7 +512,7 @@ func TestRenderStatusBarFitsWithinWidth(t *testing.T) {
 
 // --- Test 24: tuiRenderHelp is centered ---
 func TestRenderHelpIsCentered(t *testing.T) {
-	help := tuiRenderHelp(120, 40)
+	help := tuiRenderHelp(NewKeymap(nil), 120, 40)
 
 	if help == "" {
 		t.Fatal("expected non-empty help output")
@@ -510,6 +561,27 @@ func TestViewProducesOutputAfterResize(t *testing.T) {
 	}
 }
 
+// View under the plain render profile is ASCII without escape sequences.
+func TestViewPlainProfile(t *testing.T) {
+	orig := render.Current
+	render.SetCurrent(render.Plain)
+	t.Cleanup(func() { render.SetCurrent(orig) })
+
+	m, ws := newTestTuiModel()
+	ws[0].title = "\x1b[32m● CPU ▁▃▅█\x1b[0m"
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	output := m.View()
+	for i := 0; i < len(output); i++ {
+		if output[i] > 0x7f || output[i] == 0x1b {
+			t.Fatalf("byte %#x at %d in plain view:\n%s", output[i], i, output)
+		}
+	}
+	if !strings.Contains(output, "* CPU ####") {
+		t.Errorf("plain view missing widget content:\n%s", output)
+	}
+}
+
 // Test 27: Expanded widget view produces output.
 func TestExpandedWidgetView(t *testing.T) {
 	m, _ := newTestTuiModel()
@@ -553,3 +625,547 @@ func TestInitReturnsNil(t *testing.T) {
 		t.Error("expected Init() to return nil")
 	}
 }
+
+// --- Live refresh ---
+
+// tuiRunLoad executes a load command and applies its result.
+func tuiRunLoad(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("expected a load command")
+	}
+	msg, ok := cmd().(tuiRefreshMsg)
+	if !ok {
+		t.Fatalf("load command returned %T, want tuiRefreshMsg", msg)
+	}
+	m, _ = tuiUpdate(m, msg)
+	return m
+}
+
+// tuiSequenceLoader returns a Loader backed by mock collectors that yield
+// the given claude and billing datasets in turn, repeating the last one.
+func tuiSequenceLoader(claudeData, billingData []interface{}) Loader {
+	seq := func(data []interface{}) func(context.Context) (interface{}, error) {
+		i := 0
+		return func(context.Context) (interface{}, error) {
+			v := data[min(i, len(data)-1)]
+			i++
+			return v, nil
+		}
+	}
+	return CollectorLoader(
+		collectors.NewMockCollector("claude", time.Second, collectors.WithCollectFunc(seq(claudeData))),
+		collectors.NewMockCollector("billing", time.Second, collectors.WithCollectFunc(seq(billingData))),
+	)
+}
+
+func TestRefreshOnlyForwardsChangedSources(t *testing.T) {
+	m, mocks := newTestTuiModel()
+	first := &claude.UsageReport{TotalCostUSD: 10}
+	second := &claude.UsageReport{TotalCostUSD: 12.5}
+	loader := tuiSequenceLoader(
+		[]interface{}{first, second},
+		[]interface{}{map[string]float64{"civo": 4}},
+	)
+	m = m.WithRefresh(loader, time.Minute)
+
+	// Initial load: both sources are new.
+	m = tuiRunLoad(t, m, tuiLoadCmd(m.loader))
+	if mocks[0].updates != 2 {
+		t.Fatalf("updates after first load = %d, want 2", mocks[0].updates)
+	}
+	if m.LastUpdated().IsZero() {
+		t.Error("LastUpdated should be set after a load")
+	}
+
+	// Second load: only claude changed.
+	m = tuiRunLoad(t, m, tuiLoadCmd(m.loader))
+	if mocks[0].updates != 3 {
+		t.Fatalf("updates after second load = %d, want 3", mocks[0].updates)
+	}
+	ev, ok := mocks[0].lastMsg.(app.DataUpdateEvent)
+	if !ok || ev.Source != "claude" || ev.Data.(*claude.UsageReport).TotalCostUSD != 12.5 {
+		t.Errorf("last event = %+v, want claude with the second dataset", mocks[0].lastMsg)
+	}
+
+	// Third load: nothing changed, so no widget is touched.
+	m = tuiRunLoad(t, m, tuiLoadCmd(m.loader))
+	if mocks[0].updates != 3 {
+		t.Errorf("updates after unchanged load = %d, want 3", mocks[0].updates)
+	}
+}
+
+func TestRefreshInitAndTick(t *testing.T) {
+	m, _ := newTestTuiModel()
+	if m.Init() != nil {
+		t.Error("Init without a loader should return nil")
+	}
+	if _, cmd := tuiUpdate(m, tuiRefreshTickMsg{}); cmd != nil {
+		t.Error("tick without a loader should be ignored")
+	}
+
+	m = m.WithRefresh(tuiSequenceLoader([]interface{}{1}, []interface{}{2}), 0)
+	if m.refreshEvery != tuiDefaultRefreshInterval {
+		t.Errorf("refreshEvery = %v, want default", m.refreshEvery)
+	}
+	if m.Init() == nil {
+		t.Error("Init with a loader should start loading")
+	}
+	if _, cmd := tuiUpdate(m, tuiRefreshTickMsg{}); cmd == nil {
+		t.Error("tick should reload and reschedule")
+	}
+}
+
+func TestRefreshKeyAndStatusBar(t *testing.T) {
+	m, mocks := newTestTuiModel()
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	// Without a loader r does nothing.
+	if _, cmd := tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}); cmd != nil {
+		t.Error("r without a loader should not return a command")
+	}
+
+	m = m.WithRefresh(tuiSequenceLoader([]interface{}{1}, []interface{}{2}), time.Minute)
+	m, cmd := tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if !strings.Contains(m.View(), "refreshing...") {
+		t.Error("status bar should show refreshing while loading")
+	}
+	m = tuiRunLoad(t, m, cmd)
+	if mocks[1].updates != 2 {
+		t.Errorf("updates after manual refresh = %d, want 2", mocks[1].updates)
+	}
+	want := "updated " + m.LastUpdated().Format("15:04:05")
+	if !strings.Contains(m.View(), want) {
+		t.Errorf("status bar should contain %q", want)
+	}
+}
+
+func TestRefreshErrorKeepsData(t *testing.T) {
+	m, mocks := newTestTuiModel()
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	m, _ = tuiUpdate(m, tuiRefreshMsg{err: errors.New("disk gone"), at: time.Now()})
+	if mocks[0].updates != 0 {
+		t.Error("failed refresh should not update widgets")
+	}
+	if !strings.Contains(m.View(), "refresh failed: disk gone") {
+		t.Error("status bar should report the refresh error")
+	}
+}
+
+func TestCacheLoader(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "claude.json"), []byte(`{"total_cost_usd": 3.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "billing.json"), []byte(`not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := CacheLoader(dir)(context.Background())
+	if err != nil {
+		t.Fatalf("CacheLoader error: %v", err)
+	}
+	if len(data) != 1 {
+		t.Fatalf("loaded %d sources, want only claude", len(data))
+	}
+	if _, ok := data["claude"].(*claude.UsageReport); !ok {
+		t.Errorf("claude data = %T, want *claude.UsageReport", data["claude"])
+	}
+
+	// The event log is loaded however old it is, and is never noted stale.
+	if err := os.WriteFile(filepath.Join(dir, "events.json"), []byte(`[{"source":"checks","text":"checks api up→down"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "events.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	s := cache.Staleness{StaleAfter: time.Hour, ExpireAfter: 2 * time.Hour}
+	data, err = CacheLoaderWithStaleness(dir, s)(context.Background())
+	if err != nil {
+		t.Fatalf("CacheLoaderWithStaleness error: %v", err)
+	}
+	if events, ok := data["events"].([]changelog.Event); !ok || len(events) != 1 || events[0].Text != "checks api up→down" {
+		t.Errorf("events data = %#v, want the logged event", data["events"])
+	}
+	if note := tuiStaleNote(dir, s, map[string][32]byte{"events": {}}, time.Now()); note != "" {
+		t.Errorf("stale note = %q, want the event log left out", note)
+	}
+
+	// So is the sysmetrics history.
+	h := sysmetrics.NewHistory(time.Hour, time.Minute)
+	h.Record(sysmetrics.Metrics{Timestamp: time.Now(), CPU: sysmetrics.CPUMetrics{Total: 42}})
+	if err := h.Save(filepath.Join(dir, sysmetrics.HistoryFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, sysmetrics.HistoryFileName), old, old); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = CacheLoaderWithStaleness(dir, s)(context.Background())
+	if ring, ok := data["sysmetrics-history"].(interface{ Len() int }); !ok || ring.Len() != 1 {
+		t.Errorf("sysmetrics-history data = %T, want the saved ring of one sample", data["sysmetrics-history"])
+	}
+	if note := tuiStaleNote(dir, s, map[string][32]byte{"sysmetrics-history": {}}, time.Now()); note != "" {
+		t.Errorf("stale note = %q, want the history left out", note)
+	}
+}
+
+func TestCacheLoaderWithState(t *testing.T) {
+	dir, stateDir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "claude.json"), []byte(`{"total_cost_usd": 3.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, "events.json"), []byte(`[{"source":"checks","text":"checks api up→down"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := CacheLoaderWithState(dir, stateDir, cache.Staleness{})(context.Background())
+	if err != nil {
+		t.Fatalf("CacheLoaderWithState error: %v", err)
+	}
+	if data["claude"] == nil {
+		t.Error("claude data not loaded from the cache directory")
+	}
+	if events, ok := data["events"].([]changelog.Event); !ok || len(events) != 1 {
+		t.Errorf("events data = %#v, want the event log from the state directory", data["events"])
+	}
+}
+
+func TestStalenessNoteAndExpiredSkipped(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"claude", "billing"} {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(`{}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-3*time.Hour - time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "billing.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	s := cache.Staleness{StaleAfter: time.Hour, ExpireAfter: 2 * time.Hour}
+	data, err := CacheLoaderWithStaleness(dir, s)(context.Background())
+	if err != nil {
+		t.Fatalf("CacheLoaderWithStaleness error: %v", err)
+	}
+	if _, ok := data["billing"]; ok || data["claude"] == nil {
+		t.Errorf("loaded %v, want claude only: billing is expired", data)
+	}
+
+	m, _ := newTestTuiModel()
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = m.WithStaleness(dir, cache.Staleness{StaleAfter: time.Hour})
+	m, _ = tuiUpdate(m, tuiRefreshMsg{data: map[string]interface{}{"claude": 1, "billing": 2}, at: time.Now()})
+	view := m.View()
+	bar := view[strings.LastIndex(view, "\n")+1:]
+	if !strings.Contains(bar, "· billing 3h old") {
+		t.Errorf("status bar = %q, want a note on the stale billing data", bar)
+	}
+	if strings.Contains(bar, "claude") {
+		t.Errorf("status bar = %q, should not mention fresh sources", bar)
+	}
+}
+
+// --- Keymap ---
+
+func TestKeymapOverridesReplaceDefaults(t *testing.T) {
+	km := NewKeymap(map[string][]string{config.KeyQuit: {"x", "ctrl+q"}})
+	if km.Action("x") != config.KeyQuit || km.Action("ctrl+q") != config.KeyQuit {
+		t.Error("overridden quit keys should resolve to quit")
+	}
+	if km.Action("q") != "" {
+		t.Error("q should be unbound after overriding quit")
+	}
+	if km.Action("?") != config.KeyHelp {
+		t.Error("unlisted actions should keep their defaults")
+	}
+}
+
+func TestRemappedKeysDispatch(t *testing.T) {
+	m, _ := newTestTuiModel()
+	m = m.WithKeymap(NewKeymap(map[string][]string{
+		config.KeyQuit:    {"x"},
+		config.KeyNextTab: {"tab", "n"},
+	}))
+
+	if _, cmd := tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd != nil {
+		t.Error("q should no longer quit")
+	}
+	_, cmd := tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if cmd == nil {
+		t.Fatal("x should quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("x should produce tea.QuitMsg")
+	}
+
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.Focused() != 1 {
+		t.Errorf("focused = %d after n, want 1", m.Focused())
+	}
+}
+
+func TestHelpOverlayListsActiveBindings(t *testing.T) {
+	km := NewKeymap(map[string][]string{config.KeyRefresh: {"f5", "ctrl+r"}})
+	help := tuiRenderHelp(km, 120, 40)
+	for _, want := range []string{"F5 / Ctrl+R", "Refresh data now", "Shift+Tab / h / k", "Focused Widget", "Search Mode"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help overlay missing %q", want)
+		}
+	}
+}
+
+func TestHelpOverlayDismissedByAnyKey(t *testing.T) {
+	m, _ := newTestTuiModel()
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	if !m.ShowHelp() {
+		t.Fatal("expected help visible after ?")
+	}
+
+	// q closes the overlay instead of quitting, and focus is unchanged.
+	m, cmd := tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if m.ShowHelp() {
+		t.Error("any key should dismiss the help overlay")
+	}
+	if cmd != nil {
+		t.Error("dismissing help should not run the key's action")
+	}
+}
+
+func TestStatusBarUsesKeymap(t *testing.T) {
+	km := NewKeymap(map[string][]string{config.KeyHelp: {"f1"}})
+	bar := tuiRenderStatusBar(km, "", 120)
+	if !strings.Contains(bar, "F1:help") {
+		t.Errorf("status bar = %q, want F1:help hint", bar)
+	}
+}
+
+// mouseWidget is a mockWidget that takes mouse input, recording each
+// event it is passed.
+type mouseWidget struct {
+	*mockWidget
+	events []tea.MouseMsg
+}
+
+func (w *mouseWidget) HandleMouse(msg tea.MouseMsg) tea.Cmd {
+	w.events = append(w.events, msg)
+	return nil
+}
+
+func leftPress(x, y int) tea.MouseMsg {
+	return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+}
+
+func TestMouseClickFocusesPane(t *testing.T) {
+	m, _ := newTestTuiModel()
+
+	// Two columns 50 wide, two rows 15 high above the status bar.
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 100, Height: 31})
+	for _, tt := range []struct {
+		x, y, want int
+	}{
+		{70, 3, 1},
+		{10, 20, 2},
+		{50, 0, 1},  // a border belongs to its pane
+		{10, 30, 1}, // the status bar is not a pane
+		{70, 20, 1}, // nor is the empty cell
+	} {
+		m, _ = tuiUpdate(m, leftPress(tt.x, tt.y))
+		if m.Focused() != tt.want {
+			t.Errorf("click at (%d,%d): focused = %d, want %d", tt.x, tt.y, m.Focused(), tt.want)
+		}
+	}
+
+	// Narrowed to one column of three 10-row panes, the layout moves
+	// and hit-testing follows it.
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 60, Height: 31})
+	m, _ = tuiUpdate(m, leftPress(40, 22))
+	if m.Focused() != 2 {
+		t.Errorf("after resize: focused = %d, want 2", m.Focused())
+	}
+
+	// Searching for "net" leaves only Network, drawn full size.
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("net")})
+	m.focused = 0
+	m, _ = tuiUpdate(m, leftPress(5, 5))
+	if m.Focused() != 2 {
+		t.Errorf("while filtered: focused = %d, want 2", m.Focused())
+	}
+}
+
+func TestMouseEventsReachWidget(t *testing.T) {
+	plain := newMockWidget("cpu", "CPU Usage")
+	mw := &mouseWidget{mockWidget: newMockWidget("k8s", "Kubernetes")}
+	m := New([]app.Widget{plain, mw})
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 100, Height: 31})
+
+	// The mouse widget's pane is at (50,0), 50x30; its content starts
+	// inside the border at (51,1).
+	m, _ = tuiUpdate(m, leftPress(60, 5))
+	if m.Focused() != 1 || len(mw.events) != 1 || mw.events[0].X != 9 || mw.events[0].Y != 4 {
+		t.Fatalf("focused = %d, events = %+v; want focus and one event at (9,4)", m.Focused(), mw.events)
+	}
+
+	// The rest of the drag follows the widget wherever the pointer goes,
+	// up to the release.
+	m, _ = tuiUpdate(m, tea.MouseMsg{X: 5, Y: 40, Action: tea.MouseActionMotion, Button: tea.MouseButtonLeft})
+	m, _ = tuiUpdate(m, tea.MouseMsg{X: 5, Y: 40, Action: tea.MouseActionRelease})
+	if len(mw.events) != 3 || mw.events[1].X != -46 || mw.events[1].Y != 39 {
+		t.Fatalf("events = %+v, want the motion and release relative to the content", mw.events)
+	}
+	m, _ = tuiUpdate(m, tea.MouseMsg{X: 60, Y: 5, Action: tea.MouseActionMotion})
+	if len(mw.events) != 3 {
+		t.Errorf("motion after the release reached the widget: %+v", mw.events[3:])
+	}
+
+	// A click on the border focuses without reaching the widget, and a
+	// widget without HandleMouse gets the wheel as keys.
+	m, _ = tuiUpdate(m, leftPress(0, 5))
+	m, _ = tuiUpdate(m, leftPress(50, 5))
+	if m.Focused() != 1 || len(mw.events) != 3 {
+		t.Errorf("focused = %d, events = %d after a border click; want 1, 3", m.Focused(), len(mw.events))
+	}
+	m, _ = tuiUpdate(m, tea.MouseMsg{X: 60, Y: 5, Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	if len(mw.events) != 4 || mw.events[3].Button != tea.MouseButtonWheelDown {
+		t.Errorf("wheel did not reach the focused mouse widget: %+v", mw.events)
+	}
+	m.focused = 0
+	m, _ = tuiUpdate(m, tea.MouseMsg{X: 60, Y: 5, Action: tea.MouseActionPress, Button: tea.MouseButtonWheelUp})
+	if !plain.keyCalled || plain.lastKey.String() != "up" {
+		t.Errorf("wheel up on a plain widget gave key %q, want up", plain.lastKey.String())
+	}
+
+	// Expanded, the widget fills the screen.
+	m.focused = 1
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = tuiUpdate(m, leftPress(10, 10))
+	if got := mw.events[len(mw.events)-1]; got.X != 9 || got.Y != 9 {
+		t.Errorf("expanded click at (%d,%d), want (9,9)", got.X, got.Y)
+	}
+}
+
+func TestMouseDisabledAndHelp(t *testing.T) {
+	m, _ := newTestTuiModel()
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 100, Height: 31})
+
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	m, _ = tuiUpdate(m, leftPress(70, 3))
+	if m.ShowHelp() || m.Focused() != 0 {
+		t.Errorf("help/focused = %v/%d; a click should only close the help", m.ShowHelp(), m.Focused())
+	}
+
+	m = m.WithMouse(false)
+	m, _ = tuiUpdate(m, leftPress(70, 3))
+	if m.Focused() != 0 {
+		t.Errorf("focused = %d with the mouse disabled, want 0", m.Focused())
+	}
+}
+
+// tableWidget is a mockWidget whose View draws a table of names, filtered
+// by the query the TUI's table search sets.
+type tableWidget struct {
+	*mockWidget
+	names []string
+	query string
+	shown []string // names the last View drew
+}
+
+func (w *tableWidget) View(width, height int) string {
+	rows := make([]components.Row, len(w.names))
+	for i, name := range w.names {
+		rows[i] = components.Row{Cells: []string{name}, ID: name}
+	}
+	filter := components.QueryFilter(w.query)
+	dt := components.NewDataTable(components.DataTableConfig{
+		Columns: []components.Column{{Title: "Name", Sizing: components.SizingFill()}},
+	})
+	dt.SetRows(rows)
+	dt.SetFilter(filter)
+	w.shown = nil
+	for _, r := range rows {
+		if filter == nil || filter(r) {
+			w.shown = append(w.shown, r.ID)
+		}
+	}
+	return dt.Render(width, height)
+}
+
+func (w *tableWidget) FilterTable(query string) { w.query = query }
+
+func (w *tableWidget) TableFilter() (string, int, int, bool) {
+	return w.query, len(w.shown), len(w.names), true
+}
+
+func TestTableSearch(t *testing.T) {
+	pods := &tableWidget{
+		mockWidget: newMockWidget("k8s", "Pods"),
+		names:      []string{"web-1", "api-1", "Web-2", "worker"},
+	}
+	m := New([]app.Widget{newMockWidget("cpu", "CPU Usage"), pods})
+	m, _ = tuiUpdate(m, tea.WindowSizeMsg{Width: 100, Height: 31})
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyTab})
+	m.View()
+
+	typeKey := func(r rune) {
+		m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m.View()
+	}
+	shown := func() string { return strings.Join(pods.shown, ",") }
+
+	// "/" on a pane showing a table opens the table search, not the
+	// widget search.
+	typeKey('/')
+	if !m.TableSearch() || m.SearchMode() {
+		t.Fatalf("TableSearch/SearchMode = %v/%v after /, want true/false", m.TableSearch(), m.SearchMode())
+	}
+
+	// The filter applies as each key is typed, ignoring case.
+	typeKey('w')
+	if got := shown(); got != "web-1,Web-2,worker" {
+		t.Errorf("rows after w = %s", got)
+	}
+	typeKey('e')
+	if got := shown(); got != "web-1,Web-2" {
+		t.Errorf("rows after we = %s", got)
+	}
+	if box := tuiRenderExpanded(pods, 60, 10); !strings.Contains(box, " /we 2/4 ") {
+		t.Errorf("pane footer should show the filter and match count:\n%s", box)
+	}
+
+	// Backspace widens the filter again.
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	m.View()
+	if got := shown(); got != "web-1,Web-2,worker" {
+		t.Errorf("rows after backspace = %s", got)
+	}
+
+	// Enter locks the filter and returns keys to the table.
+	typeKey('e')
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m.View()
+	if m.TableSearch() || shown() != "web-1,Web-2" {
+		t.Errorf("after Enter: TableSearch = %v, rows = %s; want false, web-1,Web-2", m.TableSearch(), shown())
+	}
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if !pods.keyCalled || pods.lastKey.String() != "x" || pods.query != "we" {
+		t.Errorf("x after Enter should reach the widget, query = %q", pods.query)
+	}
+
+	// Reopened, the search starts from the locked filter; Esc clears it.
+	typeKey('/')
+	if m.tableInput.Value() != "we" {
+		t.Errorf("reopened input = %q, want we", m.tableInput.Value())
+	}
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyEscape})
+	m.View()
+	if m.TableSearch() || shown() != "web-1,api-1,Web-2,worker" || tuiTableFooter(pods) != "" {
+		t.Errorf("after Esc: TableSearch = %v, rows = %s; want the filter cleared", m.TableSearch(), shown())
+	}
+
+	// A pane without a table keeps the widget search.
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyShiftTab})
+	m, _ = tuiUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	if m.TableSearch() || !m.SearchMode() {
+		t.Errorf("TableSearch/SearchMode = %v/%v on a plain pane, want false/true", m.TableSearch(), m.SearchMode())
+	}
+}
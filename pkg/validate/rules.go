@@ -0,0 +1,125 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+)
+
+// valMinBaseline is the smallest month-to-date spend the billing factor
+// is measured from; early in a period a few cents can rise many times
+// over.
+const valMinBaseline = 1.0
+
+func init() {
+	Register((*billing.BillingReport)(nil), valBilling)
+	Register((*claude.UsageReport)(nil), valClaude)
+	Register((*k8s.ClusterStatus)(nil), valK8s)
+}
+
+// valBilling rejects negative or non-finite spend, and a connected
+// provider's month-to-date spend moving more than BillingMaxFactor times
+// up or down from its last accepted value in the same billing period and
+// currency, such as a total of zero decoded from an error page.
+func valBilling(prev, next interface{}, tol Tolerances) error {
+	n := next.(*billing.BillingReport)
+	if n == nil {
+		return nil
+	}
+	if !valFinite(n.TotalMonthlyUSD) || n.TotalMonthlyUSD < 0 {
+		return fmt.Errorf("total %v is negative or not a number", n.TotalMonthlyUSD)
+	}
+	old := map[string]billing.ProviderBilling{}
+	if p, _ := prev.(*billing.BillingReport); p != nil {
+		for _, pb := range p.Providers {
+			old[pb.Name] = pb
+		}
+	}
+	for _, pb := range n.Providers {
+		if !pb.Connected {
+			continue
+		}
+		if !valFinite(pb.MonthToDate) || pb.MonthToDate < 0 {
+			return fmt.Errorf("%s month-to-date %v is negative or not a number", pb.Name, pb.MonthToDate)
+		}
+		o, ok := old[pb.Name]
+		if !ok || !o.Connected || o.Currency != pb.Currency || !o.PeriodStart.Equal(pb.PeriodStart) {
+			continue
+		}
+		if o.MonthToDate < valMinBaseline || tol.BillingMaxFactor <= 1 {
+			continue
+		}
+		if pb.MonthToDate > o.MonthToDate*tol.BillingMaxFactor || pb.MonthToDate < o.MonthToDate/tol.BillingMaxFactor {
+			return fmt.Errorf("%s month-to-date %.2f is more than %gx away from %.2f", pb.Name, pb.MonthToDate, tol.BillingMaxFactor, o.MonthToDate)
+		}
+	}
+	return nil
+}
+
+// valClaude rejects a connected account's monthly token count going down
+// within ClaudeWindow of the last accepted report in the same month.
+// Counts only grow until the month rolls over.
+func valClaude(prev, next interface{}, tol Tolerances) error {
+	n := next.(*claude.UsageReport)
+	p, _ := prev.(*claude.UsageReport)
+	if n == nil || p == nil || tol.ClaudeWindow <= 0 {
+		return nil
+	}
+	if n.Timestamp.Sub(p.Timestamp) > tol.ClaudeWindow {
+		return nil
+	}
+	py, pm, _ := p.Timestamp.UTC().Date()
+	if ny, nm, _ := n.Timestamp.UTC().Date(); ny != py || nm != pm {
+		return nil
+	}
+	old := map[string]claude.AccountUsage{}
+	for _, a := range p.Accounts {
+		old[a.Name] = a
+	}
+	for _, a := range n.Accounts {
+		o, ok := old[a.Name]
+		if !a.Connected || !ok || !o.Connected {
+			continue
+		}
+		if got, was := valTokens(a.CurrentMonth), valTokens(o.CurrentMonth); got < was {
+			return fmt.Errorf("%s monthly tokens fell from %d to %d", a.Name, was, got)
+		}
+	}
+	return nil
+}
+
+// valTokens returns the total tokens of m.
+func valTokens(m claude.MonthUsage) int64 {
+	return m.InputTokens + m.OutputTokens + m.CacheCreationTokens + m.CacheReadTokens
+}
+
+// valK8s rejects a connected cluster that reports no nodes after it had
+// some, unless AllowZeroNodes is set. A cluster the API could not reach
+// is reported disconnected instead, which passes.
+func valK8s(prev, next interface{}, tol Tolerances) error {
+	n := next.(*k8s.ClusterStatus)
+	p, _ := prev.(*k8s.ClusterStatus)
+	if n == nil || p == nil || tol.AllowZeroNodes {
+		return nil
+	}
+	had := map[string]int{}
+	for _, c := range p.Clusters {
+		if c.Connected {
+			had[c.Context] = len(c.Nodes)
+		}
+	}
+	for _, c := range n.Clusters {
+		if c.Connected && len(c.Nodes) == 0 && had[c.Context] > 0 {
+			return fmt.Errorf("cluster %s reports no nodes, down from %d", c.Context, had[c.Context])
+		}
+	}
+	return nil
+}
+
+// valFinite reports whether f is neither NaN nor infinite.
+func valFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,213 @@
+// Package validate keeps implausible collector results out of the cache.
+// An upstream API that answers an outage with an error page and a 200
+// status can decode into a report of zeroes, which would then show in
+// every prompt until the next poll. The daemon checks each result against
+// the last one it accepted with the rules registered for its type, and on
+// a failure keeps the cached data, marks the collector degraded, and
+// writes the rejected payload to a debug log.
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RejectedFileName is the debug log of rejected results in the daemon's
+// state directory.
+const RejectedFileName = "rejected.json"
+
+// Default tolerances.
+const (
+	DefaultBillingMaxFactor = 10.0
+	DefaultClaudeWindow     = time.Hour
+	DefaultMaxRejected      = 20
+)
+
+// Tolerances tune the rules.
+type Tolerances struct {
+	// BillingMaxFactor is how many times larger or smaller than the last
+	// accepted value a provider's month-to-date spend may be within one
+	// billing period.
+	BillingMaxFactor float64
+
+	// ClaudeWindow is how long after the last accepted report an
+	// account's monthly token counts must not go down.
+	ClaudeWindow time.Duration
+
+	// AllowZeroNodes accepts a connected cluster reporting no nodes after
+	// it had some.
+	AllowZeroNodes bool
+}
+
+// DefaultTolerances returns the tolerances used when none are configured.
+func DefaultTolerances() Tolerances {
+	return Tolerances{
+		BillingMaxFactor: DefaultBillingMaxFactor,
+		ClaudeWindow:     DefaultClaudeWindow,
+	}
+}
+
+// Rule checks next, a collector result, against prev, the last result of
+// the same type that was accepted, or nil when there is none. It returns
+// an error describing why next is implausible.
+type Rule func(prev, next interface{}, tol Tolerances) error
+
+var (
+	rulesMu sync.RWMutex
+	rules   = make(map[reflect.Type][]Rule)
+)
+
+// Register adds rule for results of the same type as sample, e.g.
+// (*billing.BillingReport)(nil).
+func Register(sample interface{}, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	t := reflect.TypeOf(sample)
+	rules[t] = append(rules[t], rule)
+}
+
+// rulesFor returns the rules registered for data's type.
+func rulesFor(data interface{}) []Rule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return rules[reflect.TypeOf(data)]
+}
+
+// Rejection is the error Check returns for an implausible result.
+type Rejection struct {
+	Source string
+	Reason string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%s result rejected: %s", r.Source, r.Reason)
+}
+
+// Validator checks each collector's results against the last it accepted.
+// It is safe for concurrent use.
+type Validator struct {
+	mu         sync.Mutex
+	tolerances Tolerances
+	// accepted holds each source's last accepted result.
+	accepted map[string]interface{}
+	// acceptNext marks the sources whose next result is taken unchecked.
+	acceptNext map[string]bool
+}
+
+// New returns a Validator applying tol.
+func New(tol Tolerances) *Validator {
+	return &Validator{
+		tolerances: tol,
+		accepted:   make(map[string]interface{}),
+		acceptNext: make(map[string]bool),
+	}
+}
+
+// SetTolerances replaces the tolerances, as on a configuration reload.
+// The accepted results are kept.
+func (v *Validator) SetTolerances(tol Tolerances) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tolerances = tol
+}
+
+// AcceptNext makes the source's next result pass unchecked and become the
+// baseline, for a legitimate large change such as deleting a cluster.
+func (v *Validator) AcceptNext(source string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.acceptNext[source] = true
+}
+
+// Check runs the rules for data's type against the source's last accepted
+// result, and returns a *Rejection if one fails. Otherwise data becomes
+// the source's accepted result. The first result of a source is checked
+// on its own, with a nil prev. Results of a type without rules always
+// pass.
+func (v *Validator) Check(source string, data interface{}) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.acceptNext[source] {
+		delete(v.acceptNext, source)
+		v.accepted[source] = data
+		return nil
+	}
+	prev := v.accepted[source]
+	if prev != nil && reflect.TypeOf(prev) != reflect.TypeOf(data) {
+		prev = nil
+	}
+	for _, rule := range rulesFor(data) {
+		if err := rule(prev, data, v.tolerances); err != nil {
+			return &Rejection{Source: source, Reason: err.Error()}
+		}
+	}
+	v.accepted[source] = data
+	return nil
+}
+
+// Entry is one rejected result in the debug log.
+type Entry struct {
+	Time    time.Time       `json:"time"`
+	Source  string          `json:"source"`
+	Reason  string          `json:"reason"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Read returns the rejected results logged at path, oldest first, or none
+// if nothing was logged.
+func Read(path string) ([]Entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("validate: parse %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// LogRejected appends a rejected result and why to the debug log at
+// path, keeping the newest max entries, and rewrites it atomically. The
+// file is readable by its owner only, as payloads may hold account
+// details. Callers serialize appends to the same path.
+func LogRejected(path string, r *Rejection, payload interface{}, now time.Time, max int) error {
+	if max <= 0 {
+		max = DefaultMaxRejected
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("validate: marshal payload: %w", err)
+	}
+	entries, _ := Read(path)
+	entries = append(entries, Entry{Time: now, Source: r.Source, Reason: r.Reason, Payload: raw})
+	if len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("validate: marshal: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("validate: write: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("validate: write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package validate
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/claude"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+)
+
+func spend(mtd float64) *billing.BillingReport {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	return &billing.BillingReport{
+		TotalMonthlyUSD: mtd,
+		Providers:       []billing.ProviderBilling{{Name: "civo", Connected: true, Currency: "USD", MonthToDate: mtd, PeriodStart: start}},
+	}
+}
+
+func TestCheck_Billing(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      *billing.BillingReport
+		next      *billing.BillingReport
+		wantError string
+	}{
+		{"first result", nil, spend(42), ""},
+		{"within factor", spend(40), spend(120), ""},
+		{"jump up", spend(40), spend(401), "more than 10x"},
+		{"drop to zero", spend(40), spend(0), "more than 10x"},
+		{"small baseline", spend(0.20), spend(30), ""},
+		{"negative", nil, spend(-1), "negative"},
+		{"not a number", nil, spend(math.NaN()), "not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New(DefaultTolerances())
+			if tt.prev != nil {
+				if err := v.Check("billing", tt.prev); err != nil {
+					t.Fatalf("Check(prev) error: %v", err)
+				}
+			}
+			err := v.Check("billing", tt.next)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Check() error: %v", err)
+				}
+				return
+			}
+			var rej *Rejection
+			if !errors.As(err, &rej) || rej.Source != "billing" || !strings.Contains(rej.Reason, tt.wantError) {
+				t.Errorf("Check() = %v, want a rejection containing %q", err, tt.wantError)
+			}
+		})
+	}
+
+	// A new billing period starts again from zero.
+	v := New(DefaultTolerances())
+	v.Check("billing", spend(400))
+	next := spend(0)
+	next.Providers[0].PeriodStart = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	if err := v.Check("billing", next); err != nil {
+		t.Errorf("new period: %v", err)
+	}
+}
+
+func TestCheck_ClaudeMonotonic(t *testing.T) {
+	t0 := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	usage := func(at time.Time, tokens int64) *claude.UsageReport {
+		return &claude.UsageReport{
+			Timestamp: at,
+			Accounts:  []claude.AccountUsage{{Name: "work", Connected: true, CurrentMonth: claude.MonthUsage{InputTokens: tokens}}},
+		}
+	}
+
+	v := New(DefaultTolerances())
+	if err := v.Check("claude", usage(t0, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Check("claude", usage(t0.Add(time.Minute), 900)); err == nil {
+		t.Error("tokens falling within the window were accepted")
+	}
+	// The rejected report is not the baseline; the next one is compared
+	// against 1000 still.
+	if err := v.Check("claude", usage(t0.Add(2*time.Minute), 1100)); err != nil {
+		t.Errorf("tokens rising: %v", err)
+	}
+	if err := v.Check("claude", usage(t0.Add(2*time.Hour), 10)); err != nil {
+		t.Errorf("tokens falling outside the window: %v", err)
+	}
+}
+
+func TestCheck_K8sZeroNodes(t *testing.T) {
+	cluster := func(connected bool, nodes int) *k8s.ClusterStatus {
+		c := k8s.ClusterInfo{Context: "prod", Connected: connected}
+		for i := 0; i < nodes; i++ {
+			c.Nodes = append(c.Nodes, k8s.NodeInfo{Name: "n", Ready: true})
+		}
+		return &k8s.ClusterStatus{Clusters: []k8s.ClusterInfo{c}}
+	}
+
+	v := New(DefaultTolerances())
+	v.Check("k8s", cluster(true, 3))
+	if err := v.Check("k8s", cluster(true, 0)); err == nil {
+		t.Error("a reachable cluster losing every node was accepted")
+	}
+	if err := v.Check("k8s", cluster(false, 0)); err != nil {
+		t.Errorf("unreachable cluster: %v", err)
+	}
+
+	tol := DefaultTolerances()
+	tol.AllowZeroNodes = true
+	v = New(tol)
+	v.Check("k8s", cluster(true, 3))
+	if err := v.Check("k8s", cluster(true, 0)); err != nil {
+		t.Errorf("AllowZeroNodes: %v", err)
+	}
+}
+
+func TestAcceptNext(t *testing.T) {
+	v := New(DefaultTolerances())
+	v.Check("billing", spend(40))
+	v.AcceptNext("billing")
+	if err := v.Check("billing", spend(1000)); err != nil {
+		t.Fatalf("accepted result rejected: %v", err)
+	}
+	// 1000 is now the baseline, and the override is used up.
+	if err := v.Check("billing", spend(2000)); err != nil {
+		t.Errorf("within factor of the new baseline: %v", err)
+	}
+	if err := v.Check("billing", spend(40000)); err == nil {
+		t.Error("override applied twice")
+	}
+}
+
+func TestCheck_UnknownType(t *testing.T) {
+	v := New(DefaultTolerances())
+	if err := v.Check("tailscale", map[string]int{"peers": 0}); err != nil {
+		t.Errorf("type without rules: %v", err)
+	}
+}
+
+func TestLogRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", RejectedFileName)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		r := &Rejection{Source: "billing", Reason: string(rune('a' + i))}
+		if err := LogRejected(path, r, spend(float64(i)), now.Add(time.Duration(i)*time.Minute), 2); err != nil {
+			t.Fatalf("LogRejected() error: %v", err)
+		}
+	}
+	entries, err := Read(path)
+	if err != nil || len(entries) != 2 || entries[0].Reason != "b" || entries[1].Reason != "c" {
+		t.Fatalf("Read() = %+v, %v; want the newest two", entries, err)
+	}
+	if !strings.Contains(string(entries[1].Payload), `"month_to_date": 2`) {
+		t.Errorf("payload = %s", entries[1].Payload)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+	if entries, err := Read(filepath.Join(t.TempDir(), "missing.json")); err != nil || entries != nil {
+		t.Errorf("Read(missing) = %v, %v", entries, err)
+	}
+}
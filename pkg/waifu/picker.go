@@ -1,7 +1,10 @@
 package waifu
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"io/fs"
 	"math/rand/v2"
 	"os"
 	"path/filepath"
@@ -63,3 +66,128 @@ func ListImages(dir string) ([]string, error) {
 
 	return images, nil
 }
+
+// PickOptions configures Pick.
+type PickOptions struct {
+	// Category restricts selection to the subdirectory of that name. If the
+	// subdirectory does not exist the whole directory is used, so a flat
+	// image directory works with any category.
+	Category string
+
+	// Weights maps subdirectory paths, relative to the image directory and
+	// slash-separated (e.g. "favorites" or "sfw/seasonal"), to selection
+	// weights. An image takes the weight of its nearest listed ancestor and
+	// 1 otherwise, so with {"favorites": 3} each favorite is three times as
+	// likely as any other image. A weight of 0 excludes a subdirectory.
+	Weights map[string]float64
+
+	// Seed makes selection deterministic: the same seed over the same files
+	// picks the same image. Empty picks at random.
+	Seed string
+}
+
+// Pick selects an image from dir and its subdirectories according to opts.
+// Hidden files and directories are skipped. If every candidate has a zero
+// weight, it falls back to uniform selection over all candidates.
+func Pick(dir string, opts PickOptions) (string, error) {
+	root := dir
+	if opts.Category != "" {
+		sub := filepath.Join(dir, opts.Category)
+		if info, err := os.Stat(sub); err == nil && info.IsDir() {
+			root = sub
+		}
+	}
+
+	images, err := ListImagesRecursive(root)
+	if err != nil {
+		return "", err
+	}
+	if len(images) == 0 {
+		return "", fmt.Errorf("no image files found in %s", root)
+	}
+
+	rng := rand.IntN
+	if opts.Seed != "" {
+		sum := sha256.Sum256([]byte(opts.Seed))
+		r := rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+		rng = r.IntN
+	}
+
+	weights := make([]float64, len(images))
+	var total float64
+	for i, img := range images {
+		weights[i] = imageWeight(dir, img, opts.Weights)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return images[rng(len(images))], nil
+	}
+
+	// Draw in fixed-point so a seeded pick does not depend on float
+	// rounding of the running sum.
+	const scale = 1 << 20
+	target := float64(rng(scale)) / scale * total
+	for i, w := range weights {
+		if target < w {
+			return images[i], nil
+		}
+		target -= w
+	}
+	// Rounding left target just past the end: take the last weighted image.
+	for i := len(images) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return images[i], nil
+		}
+	}
+	return images[len(images)-1], nil
+}
+
+// imageWeight returns the weight of the image at path under dir: that of
+// its nearest ancestor directory listed in weights, or 1.
+func imageWeight(dir, path string, weights map[string]float64) float64 {
+	rel, err := filepath.Rel(dir, filepath.Dir(path))
+	if err != nil || len(weights) == 0 {
+		return 1
+	}
+	for rel = filepath.ToSlash(rel); rel != "." && rel != ""; {
+		if w, ok := weights[rel]; ok {
+			return w
+		}
+		i := strings.LastIndexByte(rel, '/')
+		if i < 0 {
+			break
+		}
+		rel = rel[:i]
+	}
+	return 1
+}
+
+// ListImagesRecursive returns absolute paths for all valid image files in
+// dir and its subdirectories, sorted. Hidden files and directories are
+// skipped.
+func ListImagesRecursive(dir string) ([]string, error) {
+	var images []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // unreadable subdirectory: skip it
+		}
+		name := d.Name()
+		if path != dir && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && supportedExtensions[strings.ToLower(filepath.Ext(name))] {
+			images = append(images, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read image directory: %w", err)
+	}
+	return images, nil
+}
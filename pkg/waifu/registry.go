@@ -0,0 +1,137 @@
+package waifu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// registryFile is the session index kept in the state or cache directory,
+// shared by every process using that directory.
+const registryFile = "sessions.json"
+
+// registry is the on-disk session index. Every access holds an flock on a
+// sibling lock file, so concurrent shells see each other's sessions.
+type registry struct {
+	path string
+}
+
+// lock takes an exclusive flock on the registry lock file and returns a
+// function that releases it.
+func (r registry) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return nil, fmt.Errorf("session registry: create directory: %w", err)
+	}
+	f, err := os.OpenFile(r.path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("session registry: open lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("session registry: lock: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// update loads the registry, applies fn, and writes the result back if fn
+// reports a change, all under the lock. A corrupt index is treated as
+// empty, since sessions are cheap to recreate.
+func (r registry) update(fn func(sessions map[string]*Session) bool) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sessions := make(map[string]*Session)
+	if data, err := os.ReadFile(r.path); err == nil {
+		if json.Unmarshal(data, &sessions) != nil {
+			sessions = make(map[string]*Session)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("session registry: read: %w", err)
+	}
+
+	if !fn(sessions) {
+		return nil
+	}
+
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("session registry: encode: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("session registry: write: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("session registry: write: %w", err)
+	}
+	return nil
+}
+
+// stale reports whether s should be reaped: its owning process has exited,
+// or it was created before cutoff.
+func (s *Session) stale(cutoff time.Time) bool {
+	return s.CreatedAt.Before(cutoff) || (s.PID > 0 && !pidAlive(s.PID))
+}
+
+// evictDead removes the oldest sessions whose process has exited until at
+// most max remain, deleting their rendered images from cacheDir unless a
+// remaining session shows the same image. Live sessions are never evicted.
+func evictDead(sessions map[string]*Session, max int, cacheDir string) {
+	if max <= 0 || len(sessions) <= max {
+		return
+	}
+	var dead []*Session
+	for _, s := range sessions {
+		if s.PID > 0 && !pidAlive(s.PID) {
+			dead = append(dead, s)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].CreatedAt.Before(dead[j].CreatedAt)
+	})
+	for _, s := range dead {
+		if len(sessions) <= max {
+			break
+		}
+		delete(sessions, s.ID)
+		removeRenders(sessions, s.ContentHash, cacheDir)
+	}
+}
+
+// removeRenders deletes the cached renders of the image with contentHash
+// unless a session in sessions still shows it.
+func removeRenders(sessions map[string]*Session, contentHash, cacheDir string) {
+	if contentHash == "" || cacheDir == "" {
+		return
+	}
+	for _, s := range sessions {
+		if s.ContentHash == contentHash {
+			return
+		}
+	}
+	os.RemoveAll(filepath.Join(cacheDir, CacheKey{ContentHash: contentHash}.subdir()))
+}
+
+// pidAlive reports whether pid refers to a running process, using signal 0.
+// A process owned by another user is alive even though it cannot be
+// signalled.
+func pidAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,230 @@
+package waifu
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRemoteTimeout bounds a remote fetch, including the image download
+// when the provider answers with a JSON pointer to it.
+const DefaultRemoteTimeout = 5 * time.Second
+
+// remotePrefix names downloaded images in the cache directory so pruning
+// never touches other files there.
+const remotePrefix = "remote-"
+
+// maxRemoteImage caps a single download.
+const maxRemoteImage = 20 * 1024 * 1024
+
+// imageTypes maps accepted Content-Types to the extension the download is
+// stored with.
+var imageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// RemoteConfig configures a Remote image source.
+type RemoteConfig struct {
+	// URL is the provider endpoint. "{category}" is replaced with the
+	// escaped Category, e.g. "https://api.waifu.pics/sfw/{category}". The
+	// endpoint may return an image or a JSON object whose "url" field
+	// points to one, as waifu.pics does.
+	URL string
+
+	// Category is substituted into URL.
+	Category string
+
+	// CacheDir is where downloads are kept.
+	CacheDir string
+
+	// MaxCacheSize caps the bytes of downloads kept in CacheDir. The least
+	// recently used are removed beyond it. Default: 50MB.
+	MaxCacheSize int64
+
+	// Timeout bounds each fetch. Default: DefaultRemoteTimeout.
+	Timeout time.Duration
+}
+
+// Remote downloads images from an HTTP provider into a local cache. It is
+// safe for concurrent use; concurrent fetches are serialized.
+type Remote struct {
+	cfg    RemoteConfig
+	client *http.Client
+	mu     sync.Mutex
+}
+
+// NewRemote returns a Remote for cfg.
+func NewRemote(cfg RemoteConfig) *Remote {
+	if cfg.MaxCacheSize <= 0 {
+		cfg.MaxCacheSize = 50 * 1024 * 1024
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultRemoteTimeout
+	}
+	return &Remote{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Fetch downloads one image and returns its path in the cache directory.
+// Responses that are not images are rejected before anything is written.
+// The cache is pruned to MaxCacheSize afterwards, keeping the new image.
+func (r *Remote) Fetch(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+
+	endpoint := strings.ReplaceAll(r.cfg.URL, "{category}", url.PathEscape(r.cfg.Category))
+	data, ext, err := r.get(ctx, endpoint, true)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	path := filepath.Join(r.cfg.CacheDir, fmt.Sprintf("%s%x%s", remotePrefix, sum[:8], ext))
+	if err := os.MkdirAll(r.cfg.CacheDir, 0o755); err != nil {
+		return "", fmt.Errorf("create waifu cache dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("write remote image: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("write remote image: %w", err)
+	}
+
+	r.prune(path)
+	return path, nil
+}
+
+// Cached returns the downloaded images in the cache directory, most
+// recently used first.
+func (r *Remote) Cached() []string {
+	files := r.cachedFiles()
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// get fetches endpoint and returns an image body and its extension. When
+// follow is set, a JSON response is read as {"url": ...} and that URL is
+// fetched instead.
+func (r *Remote) get(ctx context.Context, endpoint string, follow bool) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("remote image request: %w", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch remote image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetch remote image: %s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if ext, ok := imageTypes[mediaType]; ok {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteImage+1))
+		if err != nil {
+			return nil, "", fmt.Errorf("read remote image: %w", err)
+		}
+		if len(data) > maxRemoteImage {
+			return nil, "", fmt.Errorf("remote image exceeds %d bytes", maxRemoteImage)
+		}
+		return data, ext, nil
+	}
+
+	if mediaType == "application/json" && follow {
+		var pointer struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&pointer); err != nil {
+			return nil, "", fmt.Errorf("decode remote image response: %w", err)
+		}
+		if pointer.URL == "" {
+			return nil, "", errors.New("remote image response has no url")
+		}
+		return r.get(ctx, pointer.URL, false)
+	}
+
+	return nil, "", fmt.Errorf("remote image: unexpected content type %q", mediaType)
+}
+
+// remoteFile is a downloaded image in the cache directory.
+type remoteFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// cachedFiles lists downloads in the cache directory, most recently used
+// first. Use is tracked by modification time.
+func (r *Remote) cachedFiles() []remoteFile {
+	entries, err := os.ReadDir(r.cfg.CacheDir)
+	if err != nil {
+		return nil
+	}
+	var files []remoteFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, remotePrefix) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, remoteFile{
+			path:    filepath.Join(r.cfg.CacheDir, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	return files
+}
+
+// prune marks keep as used and removes the least recently used downloads
+// until the cache fits in MaxCacheSize. keep is never removed.
+func (r *Remote) prune(keep string) {
+	touch(keep)
+
+	var total int64
+	for _, f := range r.cachedFiles() {
+		total += f.size
+		if total > r.cfg.MaxCacheSize && f.path != keep {
+			os.Remove(f.path)
+			total -= f.size
+		}
+	}
+}
+
+// touch marks the file at path as recently used, so pruning keeps it
+// longer.
+func touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
@@ -10,10 +10,12 @@
 package waifu
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
@@ -23,35 +25,67 @@ type SessionConfig struct {
 	// ImageDir is the directory containing waifu images.
 	ImageDir string
 
-	// CacheDir is the directory for rendered cache files.
+	// Category and Weights narrow and bias image selection; see
+	// PickOptions.
+	Category string
+	Weights  map[string]float64
+
+	// Remote, if set, supplies a freshly downloaded image for each new
+	// session. When it fails (e.g. offline) the image is picked from
+	// ImageDir, then from earlier downloads.
+	Remote *Remote
+
+	// CacheDir is the directory for rendered cache files. It also holds
+	// the session registry shared by every process using it, unless
+	// StateDir is set; with neither, sessions are kept in memory only.
 	CacheDir string
 
+	// StateDir, if set, holds the session registry in place of CacheDir.
+	StateDir string
+
 	// MaxCacheSize is the max cache size in bytes. Default: 100MB.
 	MaxCacheSize int64
+
+	// MaxSessions caps the sessions in the registry. Beyond it the oldest
+	// sessions whose process has exited are evicted along with their
+	// cached renders. Zero means no limit.
+	MaxSessions int
+
+	// PID owns the sessions this manager creates. Default: os.Getpid().
+	PID int
 }
 
 // Session represents an active waifu image session tied to a process.
 type Session struct {
 	// ID is the stable session identifier, format: "ppulse-{PID}".
-	ID string
+	ID string `json:"id"`
+
+	// PID is the process that created the session.
+	PID int `json:"pid"`
 
 	// ImagePath is the absolute path to the selected image file.
-	ImagePath string
+	ImagePath string `json:"image_path"`
 
 	// ContentHash is the first 16 hex chars of the SHA-256 of the image content.
-	ContentHash string
+	ContentHash string `json:"content_hash"`
 
 	// CreatedAt is when the session was created.
-	CreatedAt time.Time
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // SessionManager manages waifu image sessions. Sessions are keyed by a
 // PID-based identifier so that the same terminal process always gets the
-// same cached image.
+// same cached image. With a StateDir or CacheDir, sessions are also
+// recorded in an on-disk registry, so short-lived banner processes see
+// each other's sessions and MaxSessions and CleanStale apply across
+// shells. Registry
+// errors are not fatal: the manager then works from memory alone.
 type SessionManager struct {
+	createMu sync.Mutex // serializes session creation, and so remote fetches
 	mu       sync.RWMutex
 	sessions map[string]*Session
 	cfg      SessionConfig
+	reg      *registry
 }
 
 // NewSessionManager creates a SessionManager with the given configuration.
@@ -59,17 +93,29 @@ func NewSessionManager(cfg SessionConfig) *SessionManager {
 	if cfg.MaxCacheSize <= 0 {
 		cfg.MaxCacheSize = 100 * 1024 * 1024 // 100 MB
 	}
-	return &SessionManager{
+	if cfg.PID <= 0 {
+		cfg.PID = os.Getpid()
+	}
+	sm := &SessionManager{
 		sessions: make(map[string]*Session),
 		cfg:      cfg,
 	}
+	switch {
+	case cfg.StateDir != "":
+		sm.reg = &registry{path: filepath.Join(cfg.StateDir, registryFile)}
+	case cfg.CacheDir != "":
+		sm.reg = &registry{path: filepath.Join(cfg.CacheDir, registryFile)}
+	}
+	return sm
 }
 
-// GetOrCreate returns an existing session for the current PID, or creates a
-// new one by selecting a random image from ImageDir and computing its content
-// hash.
+// GetOrCreate returns an existing session for the manager's PID, from
+// memory or the registry, or creates a new one by selecting an image and
+// computing its content hash. Creation is serialized, so each new session
+// costs at most one remote fetch. Local selection is seeded by the session
+// ID, so a session keeps its image even after the manager forgets it.
 func (sm *SessionManager) GetOrCreate() (*Session, error) {
-	id := fmt.Sprintf("ppulse-%d", os.Getpid())
+	id := fmt.Sprintf("ppulse-%d", sm.cfg.PID)
 
 	sm.mu.RLock()
 	if s, ok := sm.sessions[id]; ok {
@@ -78,10 +124,20 @@ func (sm *SessionManager) GetOrCreate() (*Session, error) {
 	}
 	sm.mu.RUnlock()
 
-	// Select a random image.
-	imgPath, err := PickRandom(sm.cfg.ImageDir)
+	sm.createMu.Lock()
+	defer sm.createMu.Unlock()
+
+	// Another goroutine may have created it while we waited, or another
+	// manager may have registered it.
+	if s, ok := sm.Get(id); ok {
+		return sm.adopt(s), nil
+	}
+
+	// Select the image outside the registry lock: a remote fetch must not
+	// stall other shells.
+	imgPath, err := sm.selectImage(id)
 	if err != nil {
-		return nil, fmt.Errorf("pick random image: %w", err)
+		return nil, err
 	}
 
 	// Compute content hash.
@@ -92,58 +148,148 @@ func (sm *SessionManager) GetOrCreate() (*Session, error) {
 
 	s := &Session{
 		ID:          id,
+		PID:         sm.cfg.PID,
 		ImagePath:   imgPath,
 		ContentHash: hash,
 		CreatedAt:   time.Now(),
 	}
 
+	if sm.reg != nil {
+		_ = sm.reg.update(func(sessions map[string]*Session) bool {
+			if existing, ok := sessions[id]; ok {
+				s = existing
+				return false
+			}
+			sessions[id] = s
+			evictDead(sessions, sm.cfg.MaxSessions, sm.cfg.CacheDir)
+			return true
+		})
+	}
+
+	return sm.adopt(s), nil
+}
+
+// adopt records s in memory and returns it.
+func (sm *SessionManager) adopt(s *Session) *Session {
 	sm.mu.Lock()
-	// Double-check: another goroutine may have created it while we were hashing.
-	if existing, ok := sm.sessions[id]; ok {
-		sm.mu.Unlock()
-		return existing, nil
+	defer sm.mu.Unlock()
+	if existing, ok := sm.sessions[s.ID]; ok {
+		return existing
 	}
-	sm.sessions[id] = s
-	sm.mu.Unlock()
+	sm.sessions[s.ID] = s
+	return s
+}
 
-	return s, nil
+// selectImage returns the image for a new session: a remote download if a
+// Remote is configured and reachable, otherwise one picked from ImageDir
+// deterministically for id, otherwise the most recent download.
+func (sm *SessionManager) selectImage(id string) (string, error) {
+	var remoteErr error
+	if sm.cfg.Remote != nil {
+		path, err := sm.cfg.Remote.Fetch(context.Background())
+		if err == nil {
+			return path, nil
+		}
+		remoteErr = err
+	}
+
+	path, err := Pick(sm.cfg.ImageDir, PickOptions{
+		Category: sm.cfg.Category,
+		Weights:  sm.cfg.Weights,
+		Seed:     id,
+	})
+	if err == nil {
+		return path, nil
+	}
+	if remoteErr != nil {
+		if cached := sm.cfg.Remote.Cached(); len(cached) > 0 {
+			touch(cached[0])
+			return cached[0], nil
+		}
+		return "", fmt.Errorf("pick image: %w (remote: %v)", err, remoteErr)
+	}
+	return "", fmt.Errorf("pick random image: %w", err)
 }
 
-// Get looks up a session by ID. Returns the session and true if found,
-// or nil and false otherwise.
+// Get looks up a session by ID in memory, then in the registry. Returns
+// the session and true if found, or nil and false otherwise.
 func (sm *SessionManager) Get(id string) (*Session, bool) {
 	sm.mu.RLock()
-	defer sm.mu.RUnlock()
 	s, ok := sm.sessions[id]
+	sm.mu.RUnlock()
+	if ok || sm.reg == nil {
+		return s, ok
+	}
+	_ = sm.reg.update(func(sessions map[string]*Session) bool {
+		s, ok = sessions[id]
+		return false
+	})
 	return s, ok
 }
 
-// Close removes a session by ID and cleans up its resources.
+// Close removes a session by ID, from memory and the registry.
 func (sm *SessionManager) Close(id string) {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
 	delete(sm.sessions, id)
+	sm.mu.Unlock()
+
+	if sm.reg != nil {
+		_ = sm.reg.update(func(sessions map[string]*Session) bool {
+			if _, ok := sessions[id]; !ok {
+				return false
+			}
+			delete(sessions, id)
+			return true
+		})
+	}
 }
 
-// CleanStale removes all sessions older than maxAge.
+// CleanStale removes all sessions older than maxAge or whose process has
+// exited, from memory and the registry.
 func (sm *SessionManager) CleanStale(maxAge time.Duration) {
 	cutoff := time.Now().Add(-maxAge)
 
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	for id, s := range sm.sessions {
-		if s.CreatedAt.Before(cutoff) {
+		if s.stale(cutoff) {
 			delete(sm.sessions, id)
 		}
 	}
+	sm.mu.Unlock()
+
+	if sm.reg != nil {
+		_ = sm.reg.update(func(sessions map[string]*Session) bool {
+			changed := false
+			for id, s := range sessions {
+				if s.stale(cutoff) {
+					delete(sessions, id)
+					changed = true
+				}
+			}
+			return changed
+		})
+	}
 }
 
-// ActiveCount returns the number of currently active sessions.
+// ActiveCount returns the number of currently active sessions across
+// memory and the registry.
 func (sm *SessionManager) ActiveCount() int {
 	sm.mu.RLock()
-	defer sm.mu.RUnlock()
-	return len(sm.sessions)
+	ids := make(map[string]bool, len(sm.sessions))
+	for id := range sm.sessions {
+		ids[id] = true
+	}
+	sm.mu.RUnlock()
+
+	if sm.reg != nil {
+		_ = sm.reg.update(func(sessions map[string]*Session) bool {
+			for id := range sessions {
+				ids[id] = true
+			}
+			return false
+		})
+	}
+	return len(ids)
 }
 
 // contentHash reads the first 64KB of a file and returns the first 16 hex
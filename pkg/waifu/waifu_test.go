@@ -1,12 +1,17 @@
 package waifu
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -327,6 +332,163 @@ func TestSessionErrorOnEmptyDir(t *testing.T) {
 	}
 }
 
+// deadPID returns the PID of a child process that has exited and been
+// reaped.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run a child process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestSessionRegistryInStateDir(t *testing.T) {
+	imageDir := t.TempDir()
+	createTestImage(t, imageDir, "img.png", []byte("img"))
+	cacheDir, stateDir := t.TempDir(), t.TempDir()
+
+	if _, err := NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir, StateDir: stateDir, PID: 900100}).GetOrCreate(); err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, registryFile)); err != nil {
+		t.Errorf("registry not in the state directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, registryFile)); !os.IsNotExist(err) {
+		t.Error("registry should not be in the cache directory")
+	}
+}
+
+func TestSessionRegistrySharedAcrossProcesses(t *testing.T) {
+	imageDir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		createTestImage(t, imageDir, fmt.Sprintf("img%d.png", i), []byte(fmt.Sprintf("img-%d", i)))
+	}
+	cacheDir := t.TempDir()
+
+	// Each manager stands in for a separate shell process; each hammers
+	// GetOrCreate from several goroutines.
+	const procs, callers = 8, 6
+	managers := make([]*SessionManager, procs)
+	for p := range managers {
+		managers[p] = NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir, PID: 900000 + p})
+	}
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for p := range managers {
+		for c := 0; c < callers; c++ {
+			wg.Add(1)
+			go func(sm *SessionManager, pid int) {
+				defer wg.Done()
+				s, err := sm.GetOrCreate()
+				if err != nil || s.ID != fmt.Sprintf("ppulse-%d", pid) || s.PID != pid {
+					failures.Add(1)
+				}
+			}(managers[p], 900000+p)
+		}
+	}
+	wg.Wait()
+	if n := failures.Load(); n != 0 {
+		t.Fatalf("%d GetOrCreate calls failed or returned the wrong session", n)
+	}
+
+	// A fresh process sees every session, and gets the registered one for
+	// its PID.
+	other := NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir, PID: 900003})
+	if got := other.ActiveCount(); got != procs {
+		t.Errorf("ActiveCount = %d, want %d", got, procs)
+	}
+	want, _ := managers[3].GetOrCreate()
+	got, err := other.GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if got.ImagePath != want.ImagePath || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("second process got %+v, want registered %+v", got, want)
+	}
+}
+
+func TestCleanStaleReapsDeadProcesses(t *testing.T) {
+	imageDir := t.TempDir()
+	createTestImage(t, imageDir, "a.png", []byte("img"))
+	cacheDir := t.TempDir()
+
+	dead := NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir, PID: deadPID(t)})
+	deadSession, err := dead.GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	live := NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir})
+	liveSession, err := live.GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+
+	live.CleanStale(time.Hour)
+
+	if _, ok := live.Get(deadSession.ID); ok {
+		t.Error("session of exited process survived CleanStale")
+	}
+	if _, ok := live.Get(liveSession.ID); !ok {
+		t.Error("live session was reaped")
+	}
+	if got := NewSessionManager(SessionConfig{CacheDir: cacheDir}).ActiveCount(); got != 1 {
+		t.Errorf("registry ActiveCount = %d, want 1", got)
+	}
+}
+
+func TestMaxSessionsEvictsOldestDeadSession(t *testing.T) {
+	imageDir := t.TempDir()
+	createTestImage(t, imageDir, "a.png", []byte("img-a"))
+	createTestImage(t, imageDir, "b.png", []byte("img-b"))
+	cacheDir := t.TempDir()
+	cache := NewImageCache(cacheDir, 0)
+
+	// Two sessions of exited processes, oldest first.
+	var old []*Session
+	for i := 0; i < 2; i++ {
+		s, err := NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir, PID: deadPID(t)}).GetOrCreate()
+		if err != nil {
+			t.Fatalf("GetOrCreate: %v", err)
+		}
+		old = append(old, s)
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give the sessions distinct images so their renders are not shared.
+	for i, s := range old {
+		s.ContentHash = fmt.Sprintf("%d%015d", i+1, 0)
+		cache.Put(CacheKey{ContentHash: s.ContentHash, Protocol: "kitty", Width: 10, Height: 10}, "render")
+	}
+	reg := registry{path: filepath.Join(cacheDir, registryFile)}
+	reg.update(func(sessions map[string]*Session) bool {
+		for _, s := range old {
+			sessions[s.ID] = s
+		}
+		return true
+	})
+
+	sm := NewSessionManager(SessionConfig{ImageDir: imageDir, CacheDir: cacheDir, MaxSessions: 2})
+	if _, err := sm.GetOrCreate(); err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+
+	if sm.ActiveCount() != 2 {
+		t.Errorf("ActiveCount = %d, want 2", sm.ActiveCount())
+	}
+	if _, ok := sm.Get(old[0].ID); ok {
+		t.Error("oldest dead session was not evicted")
+	}
+	if _, ok := sm.Get(old[1].ID); !ok {
+		t.Error("newer dead session was evicted")
+	}
+	if cache.Has(CacheKey{ContentHash: old[0].ContentHash, Protocol: "kitty", Width: 10, Height: 10}) {
+		t.Error("evicted session's renders were kept")
+	}
+	if !cache.Has(CacheKey{ContentHash: old[1].ContentHash, Protocol: "kitty", Width: 10, Height: 10}) {
+		t.Error("remaining session's renders were removed")
+	}
+}
+
 // --- Cache Tests ---
 
 func TestCachePutGet(t *testing.T) {
@@ -670,6 +832,294 @@ func TestListImagesAllExtensions(t *testing.T) {
 	}
 }
 
+// createImageTree creates dir/<rel> for each rel, making parent
+// directories as needed.
+func createImageTree(t *testing.T, dir string, rels ...string) {
+	t.Helper()
+	for _, rel := range rels {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		createTestImage(t, filepath.Dir(path), filepath.Base(path), []byte(rel))
+	}
+}
+
+func TestListImagesRecursiveSkipsHidden(t *testing.T) {
+	dir := t.TempDir()
+	createImageTree(t, dir, "a.png", "sfw/b.jpg", "sfw/deep/c.gif", ".trash/d.png", "sfw/.e.png", "notes.txt")
+
+	images, err := ListImagesRecursive(dir)
+	if err != nil {
+		t.Fatalf("ListImagesRecursive: %v", err)
+	}
+	want := []string{"a.png", "sfw/b.jpg", "sfw/deep/c.gif"}
+	if len(images) != len(want) {
+		t.Fatalf("got %v, want %v", images, want)
+	}
+	for i, rel := range want {
+		if images[i] != filepath.Join(dir, filepath.FromSlash(rel)) {
+			t.Errorf("images[%d] = %s, want %s", i, images[i], rel)
+		}
+	}
+}
+
+func TestPickDeterministicPerSeed(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		createImageTree(t, dir, fmt.Sprintf("sub%d/img.png", i%4), fmt.Sprintf("img%02d.png", i))
+	}
+
+	first, err := Pick(dir, PickOptions{Seed: "ppulse-42"})
+	if err != nil {
+		t.Fatalf("Pick: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		got, _ := Pick(dir, PickOptions{Seed: "ppulse-42"})
+		if got != first {
+			t.Fatalf("seeded pick %d = %s, want %s", i, got, first)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		got, _ := Pick(dir, PickOptions{Seed: fmt.Sprintf("ppulse-%d", i)})
+		seen[got] = true
+	}
+	if len(seen) < 5 {
+		t.Errorf("50 seeds picked only %d distinct images", len(seen))
+	}
+}
+
+func TestPickCategory(t *testing.T) {
+	dir := t.TempDir()
+	createImageTree(t, dir, "a.png", "neko/b.png", "neko/c.png")
+
+	for i := 0; i < 20; i++ {
+		got, err := Pick(dir, PickOptions{Category: "neko", Seed: fmt.Sprint(i)})
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		if filepath.Base(filepath.Dir(got)) != "neko" {
+			t.Fatalf("Pick with category neko = %s", got)
+		}
+	}
+
+	// A category without a subdirectory uses the whole directory.
+	if _, err := Pick(dir, PickOptions{Category: "waifu"}); err != nil {
+		t.Errorf("Pick with missing category dir: %v", err)
+	}
+}
+
+func TestPickWeights(t *testing.T) {
+	dir := t.TempDir()
+	createImageTree(t, dir, "favorites/a.png", "seasonal/b.png", "sfw/c.png", "sfw/nope/d.png")
+	weights := map[string]float64{"favorites": 3, "seasonal": 1, "sfw": 0}
+
+	counts := make(map[string]int)
+	const n = 4000
+	for i := 0; i < n; i++ {
+		got, err := Pick(dir, PickOptions{Weights: weights, Seed: fmt.Sprint(i)})
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		rel, _ := filepath.Rel(dir, got)
+		counts[filepath.ToSlash(rel)]++
+	}
+	if counts["sfw/c.png"] != 0 || counts["sfw/nope/d.png"] != 0 {
+		t.Errorf("zero-weight subdirectory was picked: %v", counts)
+	}
+	// favorites should be picked about 3 times as often as seasonal.
+	if ratio := float64(counts["favorites/a.png"]) / float64(counts["seasonal/b.png"]); ratio < 2.5 || ratio > 3.5 {
+		t.Errorf("favorites/seasonal ratio = %.2f, want about 3 (%v)", ratio, counts)
+	}
+
+	// A nearer listed ancestor overrides a farther one.
+	weights["sfw/nope"] = 1
+	if w := imageWeight(dir, filepath.Join(dir, "sfw", "nope", "d.png"), weights); w != 1 {
+		t.Errorf("imageWeight(sfw/nope/d.png) = %g, want 1", w)
+	}
+}
+
+func TestPickAllZeroWeightsFallsBackToUniform(t *testing.T) {
+	dir := t.TempDir()
+	createImageTree(t, dir, "favorites/a.png", "seasonal/b.png")
+
+	got, err := Pick(dir, PickOptions{Weights: map[string]float64{"favorites": 0, "seasonal": 0}})
+	if err != nil {
+		t.Fatalf("Pick: %v", err)
+	}
+	if !strings.HasPrefix(got, dir) {
+		t.Errorf("Pick = %s, want an image under %s", got, dir)
+	}
+}
+
+func TestSessionImageStableAcrossManagers(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		createImageTree(t, dir, fmt.Sprintf("img%d.png", i))
+	}
+	cfg := SessionConfig{ImageDir: dir, CacheDir: t.TempDir()}
+
+	a, err := NewSessionManager(cfg).GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	b, err := NewSessionManager(cfg).GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if a.ImagePath != b.ImagePath {
+		t.Errorf("same session picked %s then %s", a.ImagePath, b.ImagePath)
+	}
+}
+
+// --- Remote Source Tests ---
+
+// newImageServer serves a distinct PNG per request at /img and a
+// waifu.pics-style JSON pointer to it at /sfw/{category}.
+func newImageServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		switch {
+		case r.URL.Path == "/img":
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprintf(w, "png-%d-%s", n, strings.Repeat("x", 100))
+		case strings.HasPrefix(r.URL.Path, "/sfw/"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"url": %q}`, srv.URL+"/img")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html>not an image</html>")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRemoteFetchFollowsJSONPointer(t *testing.T) {
+	srv, hits := newImageServer(t)
+	cacheDir := t.TempDir()
+	r := NewRemote(RemoteConfig{URL: srv.URL + "/sfw/{category}", Category: "neko", CacheDir: cacheDir})
+
+	path, err := r.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if filepath.Dir(path) != cacheDir || filepath.Ext(path) != ".png" {
+		t.Errorf("Fetch = %s, want a .png in %s", path, cacheDir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "png-2-") {
+		t.Errorf("downloaded %q, %v", data, err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("hits = %d, want 2 (pointer and image)", hits.Load())
+	}
+}
+
+func TestRemoteRejectsNonImage(t *testing.T) {
+	srv, _ := newImageServer(t)
+	cacheDir := t.TempDir()
+	r := NewRemote(RemoteConfig{URL: srv.URL + "/page", CacheDir: cacheDir})
+
+	if _, err := r.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "unexpected content type") {
+		t.Fatalf("Fetch err = %v, want content type error", err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("cache dir has %d entries after rejected fetch, want 0", len(entries))
+	}
+}
+
+func TestRemotePrunesLeastRecentlyUsed(t *testing.T) {
+	srv, _ := newImageServer(t)
+	cacheDir := t.TempDir()
+	// Each image is about 106 bytes; room for three.
+	r := NewRemote(RemoteConfig{URL: srv.URL + "/img", CacheDir: cacheDir, MaxCacheSize: 350})
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path, err := r.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Fetch %d: %v", i, err)
+		}
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(path, old, old)
+		paths = append(paths, path)
+	}
+	// Use the first download again: the second is now least recent.
+	touch(paths[0])
+	path, err := r.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	cached := r.Cached()
+	if len(cached) != 3 || cached[0] != path || cached[1] != paths[0] || cached[2] != paths[2] {
+		t.Errorf("Cached = %v, want [%s %s %s]", cached, path, paths[0], paths[2])
+	}
+}
+
+func TestSessionUsesRemoteOncePerSession(t *testing.T) {
+	srv, hits := newImageServer(t)
+	cacheDir := t.TempDir()
+	sm := NewSessionManager(SessionConfig{
+		ImageDir: t.TempDir(),
+		CacheDir: cacheDir,
+		Remote:   NewRemote(RemoteConfig{URL: srv.URL + "/img", CacheDir: cacheDir}),
+	})
+
+	var wg sync.WaitGroup
+	sessions := make([]*Session, 8)
+	for i := range sessions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessions[i], _ = sm.GetOrCreate()
+		}(i)
+	}
+	wg.Wait()
+
+	if hits.Load() != 1 {
+		t.Errorf("remote hits = %d, want 1", hits.Load())
+	}
+	for i, s := range sessions {
+		if s == nil || s.ImagePath != sessions[0].ImagePath || filepath.Dir(s.ImagePath) != cacheDir {
+			t.Fatalf("session %d = %+v, want the downloaded image", i, s)
+		}
+	}
+}
+
+func TestSessionFallsBackWhenRemoteOffline(t *testing.T) {
+	srv, _ := newImageServer(t)
+	srv.Close()
+	cacheDir := t.TempDir()
+	remote := NewRemote(RemoteConfig{URL: srv.URL + "/img", CacheDir: cacheDir, Timeout: time.Second})
+
+	imageDir := t.TempDir()
+	local := createTestImage(t, imageDir, "local.png", []byte("local"))
+	s, err := NewSessionManager(SessionConfig{ImageDir: imageDir, Remote: remote}).GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if s.ImagePath != local {
+		t.Errorf("ImagePath = %s, want local image %s", s.ImagePath, local)
+	}
+
+	// Without local images, an earlier download is used.
+	earlier := createTestImage(t, cacheDir, remotePrefix+"0011223344556677.png", []byte("cached"))
+	s, err = NewSessionManager(SessionConfig{ImageDir: t.TempDir(), Remote: remote}).GetOrCreate()
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if s.ImagePath != earlier {
+		t.Errorf("ImagePath = %s, want earlier download %s", s.ImagePath, earlier)
+	}
+}
+
 // --- Prefetcher Tests ---
 
 func TestPrefetchCacheHit(t *testing.T) {
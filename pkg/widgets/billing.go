@@ -26,6 +26,7 @@ type BillingWidget struct {
 	report           *billing.BillingReport
 	expanded         bool
 	costHistory      []float64
+	history          []billing.Snapshot
 	selectedProvider int
 }
 
@@ -50,14 +51,21 @@ func (w *BillingWidget) MinSize() (int, int) {
 }
 
 // Update handles messages directed at this widget. It processes
-// DataUpdateEvent messages with Source "billing".
+// DataUpdateEvent messages with Source "billing" (a *billing.BillingReport)
+// and "billing-history" (a []billing.Snapshot loaded from disk).
 func (w *BillingWidget) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case app.DataUpdateEvent:
-		if msg.Source != "billing" {
+		if msg.Err != nil {
 			return nil
 		}
-		if msg.Err != nil {
+		if msg.Source == "billing-history" {
+			if snaps, ok := msg.Data.([]billing.Snapshot); ok {
+				w.history = snaps
+			}
+			return nil
+		}
+		if msg.Source != "billing" {
 			return nil
 		}
 		report, ok := msg.Data.(*billing.BillingReport)
@@ -65,7 +73,9 @@ func (w *BillingWidget) Update(msg tea.Msg) tea.Cmd {
 			return nil
 		}
 		w.report = report
-		w.costHistory = append(w.costHistory, report.TotalMonthlyUSD)
+		if len(report.Subtotals) == 0 {
+			w.costHistory = append(w.costHistory, report.TotalMonthlyUSD)
+		}
 	}
 	return nil
 }
@@ -135,9 +145,10 @@ func (w *BillingWidget) billingViewCompact(width, height int) string {
 	}
 
 	// Total spend line.
-	totalLine := fmt.Sprintf("Total: $%.2f", w.report.TotalMonthlyUSD)
+	currency := w.report.DisplayCurrency()
+	totalLine := "Total: " + w.report.FormatTotal()
 	if w.report.BudgetUSD > 0 {
-		totalLine += fmt.Sprintf(" / $%.2f budget", w.report.BudgetUSD)
+		totalLine += " / " + billing.FormatAmount(w.report.BudgetUSD, currency) + " budget"
 	}
 	if len(totalLine) > width {
 		totalLine = totalLine[:width]
@@ -147,10 +158,17 @@ func (w *BillingWidget) billingViewCompact(width, height int) string {
 	// Provider summary lines.
 	for _, p := range w.report.Providers {
 		dot := billingStatusDot(p.Connected)
-		provLine := fmt.Sprintf("%s %s: $%.2f", dot, p.Name, p.MonthToDate)
-		if len(provLine) > width {
-			provLine = provLine[:width]
+		provLine := fmt.Sprintf("%s %s: %s", dot, p.Name, billing.FormatAmount(p.MonthToDate, p.Currency))
+		if p.BudgetUSD > 0 {
+			provLine = fmt.Sprintf("%s %s: %s", dot, p.Name, billingBudgetSummary(p))
+		}
+		if label := p.PeriodLabel(); label != "" {
+			provLine += " (" + label + ")"
+		}
+		if p.Anomaly != nil {
+			provLine += " " + billingAnomalyMarker(p, false)
 		}
+		provLine = components.Truncate(provLine, width)
 		lines = append(lines, provLine)
 		if len(lines) >= height {
 			break
@@ -186,38 +204,65 @@ func (w *BillingWidget) billingViewExpanded(width, height int) string {
 
 		// Provider header.
 		dot := billingStatusDot(p.Connected)
-		header := fmt.Sprintf("%s %s  MTD: $%.2f", dot, components.Bold(p.Name), p.MonthToDate)
+		header := fmt.Sprintf("%s %s  MTD: %s", dot, components.Bold(p.Name), p.FormatNative())
+		if p.Anomaly != nil {
+			header += "  " + billingAnomalyMarker(p, true)
+		}
+		header = components.Truncate(header, width)
 		lines = append(lines, header)
 
-		// Resource table (only for selected provider or all if there is room).
-		if len(p.Resources) > 0 && (i == w.selectedProvider || len(w.report.Providers) == 1) {
-			tableLines := w.billingRenderResourceTable(p.Resources, width, height-len(lines)-3)
+		// Resource table and cost breakdown (only for selected provider or
+		// all if there is room).
+		if i != w.selectedProvider && len(w.report.Providers) != 1 {
+			continue
+		}
+		if len(p.Resources) > 0 {
+			tableLines := w.billingRenderResourceTable(p.Resources, p.BillingCurrency(), width, height-len(lines)-3)
 			lines = append(lines, tableLines...)
 		}
+		if len(p.Breakdown) > 0 {
+			lines = append(lines, billingBreakdownLines(p.Breakdown, p.BillingCurrency(), width, height-len(lines)-3)...)
+		}
 	}
 
-	// Sparkline of cost history.
-	if len(w.costHistory) > 0 && len(lines) < height-2 {
+	// Sparkline of cost history. Persisted history is preferred over the
+	// values seen since the TUI started.
+	trend := w.costHistory
+	if len(w.history) > 0 {
+		trend = make([]float64, len(w.history))
+		for i, s := range w.history {
+			trend[i] = s.TotalUSD
+		}
+	}
+	if len(trend) > 0 && len(lines) < height-2 {
 		sparkStyle := components.SparklineStyle{
 			Width:      width - 8,
 			Color:      billingColorBlue,
 			ShowMinMax: true,
 		}
 		spark := components.NewSparkline(sparkStyle)
-		sparkLine := "Trend: " + spark.Render(w.costHistory, width-8)
+		sparkLine := "Trend: " + spark.Render(trend, width-8)
 		lines = append(lines, sparkLine)
 	}
 
-	// Projected cost.
-	if len(lines) < height {
-		projected := billingProjectedCost(w.report.TotalMonthlyUSD)
-		projLine := fmt.Sprintf("Projected: $%.2f", projected)
-		lines = append(lines, projLine)
+	// Projected cost: spend velocity over the last week when history is
+	// available, otherwise a linear extrapolation of month-to-date spend.
+	// Spend split across currencies has no total to project.
+	if len(lines) < height && len(w.report.Subtotals) == 0 {
+		currency := w.report.DisplayCurrency()
+		var projLine string
+		if perDay, projected, ok := billing.Velocity(w.history, time.Now()); ok {
+			projLine = fmt.Sprintf("Velocity: %s/day  Projected: %s (7d)",
+				billing.FormatAmount(perDay, currency), billing.FormatAmount(projected, currency))
+		} else {
+			projLine = "Projected: " + billing.FormatAmount(billingProjectedCost(w.report.TotalMonthlyUSD), currency)
+		}
+		lines = append(lines, components.Truncate(projLine, width))
 	}
 
 	// Total.
 	if len(lines) < height {
-		totalLine := fmt.Sprintf("Total MTD: $%.2f", w.report.TotalMonthlyUSD)
+		totalLine := "Total MTD: " + w.report.FormatTotal()
 		lines = append(lines, totalLine)
 	}
 
@@ -255,18 +300,40 @@ func (w *BillingWidget) billingRenderBudgetGauge(width int) string {
 }
 
 // billingRenderResourceTable renders a resource cost table for a provider's
-// resources. Returns a slice of lines.
-func (w *BillingWidget) billingRenderResourceTable(resources []billing.ResourceCost, width, maxLines int) []string {
+// resources, costing currency. Returns a slice of lines.
+func (w *BillingWidget) billingRenderResourceTable(resources []billing.ResourceCost, currency string, width, maxLines int) []string {
 	if maxLines <= 0 {
 		maxLines = 5
 	}
 
+	// Providers with a per-resource breakdown get a month-to-date column,
+	// and the plan replaces the generic resource type.
+	breakdown, plans := false, false
+	for _, r := range resources {
+		if r.Plan != "" || r.Accrued > 0 {
+			breakdown = true
+		}
+		if r.Plan != "" {
+			plans = true
+		}
+	}
+
+	columns := []components.Column{
+		{Title: "Name", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 8},
+		{Title: "Type", Sizing: components.SizingFixed(12), Align: components.ColAlignLeft},
+		{Title: "Cost", Sizing: components.SizingFixed(10), Align: components.ColAlignRight},
+	}
+	if breakdown {
+		if plans {
+			columns[1].Title = "Plan"
+		}
+		columns = append(columns, components.Column{
+			Title: "MTD", Sizing: components.SizingFixed(10), Align: components.ColAlignRight,
+		})
+	}
+
 	dt := components.NewDataTable(components.DataTableConfig{
-		Columns: []components.Column{
-			{Title: "Name", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 8},
-			{Title: "Type", Sizing: components.SizingFixed(12), Align: components.ColAlignLeft},
-			{Title: "Cost", Sizing: components.SizingFixed(10), Align: components.ColAlignRight},
-		},
+		Columns: columns,
 		HeaderStyle: components.HeaderStyleConfig{
 			Bold:    true,
 			FgColor: ColorAccent,
@@ -277,9 +344,14 @@ func (w *BillingWidget) billingRenderResourceTable(resources []billing.ResourceC
 
 	rows := make([]components.Row, 0, len(resources))
 	for _, r := range resources {
-		rows = append(rows, components.Row{
-			Cells: []string{r.Name, r.Type, fmt.Sprintf("$%.2f", r.MonthlyCost)},
-		})
+		cells := []string{r.Name, r.Type, billing.FormatAmount(r.MonthlyCost, currency)}
+		if breakdown {
+			if r.Plan != "" {
+				cells[1] = r.Plan
+			}
+			cells = append(cells, billing.FormatAmount(r.Accrued, currency))
+		}
+		rows = append(rows, components.Row{Cells: cells})
 	}
 	dt.SetRows(rows)
 
@@ -295,6 +367,52 @@ func (w *BillingWidget) billingRenderResourceTable(resources []billing.ResourceC
 	return strings.Split(rendered, "\n")
 }
 
+// billingBreakdownLines renders a provider's month-to-date cost by resource
+// type as one "type  count  cost" line each, under a header, in at most
+// maxLines lines. Costs are in currency.
+func billingBreakdownLines(items []billing.CostItem, currency string, width, maxLines int) []string {
+	if maxLines < 2 {
+		return nil
+	}
+	lines := []string{components.Bold("Cost by type")}
+	for _, it := range items {
+		if len(lines) >= maxLines {
+			break
+		}
+		line := fmt.Sprintf("  %-14s %3d  %s%8.2f", it.Type, it.Count, billing.CurrencySymbol(currency), it.Cost)
+		lines = append(lines, components.Truncate(line, width))
+	}
+	return lines
+}
+
+// billingBudgetSummary formats a provider's spend against its budget as
+// "$42 / $50 (84%)", colored by budget status.
+func billingBudgetSummary(p billing.ProviderBilling) string {
+	sym := billing.CurrencySymbol(p.Currency)
+	text := fmt.Sprintf("%s%.0f / %s%.0f (%.0f%%)", sym, p.MonthToDate, sym, p.BudgetUSD, p.BudgetPercent)
+	switch p.BudgetStatus {
+	case billing.BudgetCritical:
+		return components.Color(billingColorRed) + text + components.Reset()
+	case billing.BudgetWarn:
+		return components.Color(billingColorYellow) + text + components.Reset()
+	}
+	return text
+}
+
+// billingAnomalyMarker returns the warning shown on a provider with unusual
+// spend today: a yellow ⚠, followed by how unusual when detailed.
+func billingAnomalyMarker(p billing.ProviderBilling, detailed bool) string {
+	text := "\u26a0"
+	if detailed {
+		if p.Anomaly.Factor > 0 {
+			text += fmt.Sprintf(" %.1f× usual", p.Anomaly.Factor)
+		} else {
+			text += " " + billing.FormatAmount(p.Anomaly.Spend, p.Currency) + " today"
+		}
+	}
+	return components.Color(billingColorYellow) + text + components.Reset()
+}
+
 // billingStatusDot returns a colored status indicator dot.
 // Green for connected, red for disconnected.
 func billingStatusDot(connected bool) string {
@@ -90,6 +90,55 @@ func TestBillingWidget_View_Compact_WithBudget(t *testing.T) {
 	}
 }
 
+func TestBillingWidget_View_Compact_PeriodLabel(t *testing.T) {
+	w := NewBillingWidget()
+	w.report = &billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{Name: "civo", Connected: true, MonthToDate: 12,
+				PeriodStart: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "vultr", Connected: true, MonthToDate: 8,
+				PeriodStart: time.Date(2026, 2, 12, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)},
+		},
+		TotalMonthlyUSD: 20,
+	}
+
+	view := w.View(60, 10)
+	if !strings.Contains(view, "vultr: $8.00 (Feb 12 – Mar 11)") {
+		t.Errorf("view should label vultr's billing period, got:\n%s", view)
+	}
+	if strings.Contains(view, "Mar 1 –") {
+		t.Errorf("view labels a calendar month, got:\n%s", view)
+	}
+}
+
+func TestBillingWidget_View_Anomaly(t *testing.T) {
+	w := NewBillingWidget()
+	w.report = &billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{Name: "digitalocean", Connected: true, Currency: "USD", MonthToDate: 30,
+				Anomaly: &billing.SpendAnomaly{Spend: 6.2, Baseline: 2, Factor: 3.1}},
+			{Name: "civo", Connected: true, Currency: "USD", MonthToDate: 12},
+		},
+		TotalMonthlyUSD: 42,
+	}
+
+	lines := strings.Split(stripANSI(w.View(60, 10)), "\n")
+	var marked []string
+	for _, line := range lines {
+		if strings.Contains(line, "\u26a0") {
+			marked = append(marked, line)
+		}
+	}
+	if len(marked) != 1 || !strings.Contains(marked[0], "digitalocean") {
+		t.Errorf("compact view should mark only digitalocean, got %q", marked)
+	}
+
+	w.expanded = true
+	if view := stripANSI(w.View(60, 20)); !strings.Contains(view, "\u26a0 3.1× usual") {
+		t.Errorf("expanded view should say how unusual the spend is, got:\n%s", view)
+	}
+}
+
 func TestBillingWidget_View_Expanded_WithResourceTable(t *testing.T) {
 	w := NewBillingWidget()
 	w.expanded = true
@@ -134,6 +183,66 @@ func TestBillingWidget_View_Expanded_WithResourceTable(t *testing.T) {
 	}
 }
 
+func TestBillingWidget_View_Expanded_PerInstanceBreakdown(t *testing.T) {
+	w := NewBillingWidget()
+	w.expanded = true
+	w.report = &billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{
+				Name:        "vultr",
+				Connected:   true,
+				MonthToDate: 7.25,
+				Resources: []billing.ResourceCost{
+					{Name: "edge-1", Type: "instance", Plan: "vc2-1c-1gb", MonthlyCost: 5.00, Accrued: 2.50},
+					{Name: "edge-2", Type: "instance", Plan: "vc2-2c-4gb", MonthlyCost: 20.00, Accrued: 4.75},
+				},
+			},
+		},
+		TotalMonthlyUSD: 7.25,
+	}
+
+	view := w.View(80, 20)
+
+	for _, want := range []string{"Plan", "MTD", "vc2-1c-1gb", "edge-2", "$4.75"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expanded view should contain %q, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestBillingWidget_View_Expanded_CostBreakdown(t *testing.T) {
+	w := NewBillingWidget()
+	w.expanded = true
+	w.report = &billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{
+				Name:        "civo",
+				Connected:   true,
+				MonthToDate: 24.60,
+				Resources: []billing.ResourceCost{
+					{Name: "k3s-prod", Type: "kubernetes", MonthlyCost: 36.00, Accrued: 20.20},
+				},
+				Breakdown: []billing.CostItem{
+					{Type: "instance", Count: 3, Cost: 21.00},
+					{Type: "volume", Count: 2, Cost: 1.60},
+				},
+			},
+		},
+		TotalMonthlyUSD: 24.60,
+	}
+
+	view := stripANSI(w.View(80, 20))
+
+	for _, want := range []string{"Cost by type", "instance", "$   21.00", "volume", "MTD", "$20.20"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expanded view should contain %q, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Plan") {
+		t.Errorf("resources without plans should keep the Type column, got:\n%s", view)
+	}
+}
+
 func TestBillingWidget_Update_WithBillingReport(t *testing.T) {
 	w := NewBillingWidget()
 
@@ -452,6 +561,59 @@ func TestBillingWidget_Update_MultipleSamples(t *testing.T) {
 	}
 }
 
+func TestBillingWidget_View_Compact_ProviderBudget(t *testing.T) {
+	w := NewBillingWidget()
+	w.report = &billing.BillingReport{
+		Providers: []billing.ProviderBilling{
+			{
+				Name:          "digitalocean",
+				Connected:     true,
+				MonthToDate:   42.00,
+				BudgetUSD:     50.00,
+				BudgetPercent: 84.0,
+				BudgetStatus:  billing.BudgetWarn,
+			},
+			{Name: "civo", Connected: true, MonthToDate: 10.00},
+		},
+		TotalMonthlyUSD: 52.00,
+	}
+
+	view := w.View(60, 5)
+
+	if !strings.Contains(view, "$42 / $50 (84%)") {
+		t.Errorf("Compact view should contain provider budget summary, got:\n%s", view)
+	}
+	if !strings.Contains(view, "civo: $10.00") {
+		t.Errorf("Providers without a budget should render as before, got:\n%s", view)
+	}
+}
+
+func TestBillingWidget_Update_BillingHistory(t *testing.T) {
+	w := NewBillingWidget()
+	w.expanded = true
+	w.report = &billing.BillingReport{TotalMonthlyUSD: 52}
+
+	now := time.Now()
+	w.Update(app.DataUpdateEvent{
+		Source: "billing-history",
+		Data: []billing.Snapshot{
+			{Timestamp: now.Add(-2 * time.Minute), TotalUSD: 51},
+			{Timestamp: now.Add(-time.Minute), TotalUSD: 52},
+		},
+	})
+	if len(w.history) != 2 {
+		t.Fatalf("history length = %d, want 2", len(w.history))
+	}
+
+	view := w.View(70, 10)
+	if !strings.Contains(view, "Trend:") {
+		t.Errorf("Expanded view should contain trend sparkline, got:\n%s", view)
+	}
+	if !strings.Contains(view, "Velocity:") {
+		t.Errorf("Expanded view should contain spend velocity, got:\n%s", view)
+	}
+}
+
 func TestBillingWidget_View_CompactNoBudget(t *testing.T) {
 	w := NewBillingWidget()
 	w.report = &billing.BillingReport{
@@ -0,0 +1,235 @@
+package widgets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// ChecksWidget displays the HTTP, TCP, and ping checks defined in the
+// config. Down checks are listed first, with the reason they failed. When
+// checks name groups it lists the groups, rolled up, and expands the
+// selected one to its members; 'g' switches to the flat per-check list.
+type ChecksWidget struct {
+	status       *checks.Status
+	scrollOffset int
+
+	flat     bool            // per-check list even when checks are grouped
+	selected int             // selected group in the grouped view
+	expanded map[string]bool // expanded groups by name
+}
+
+// NewChecksWidget creates a new ChecksWidget with default state.
+func NewChecksWidget() *ChecksWidget {
+	return &ChecksWidget{expanded: make(map[string]bool)}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *ChecksWidget) ID() string {
+	return "checks"
+}
+
+// Title returns the human-readable display name.
+func (w *ChecksWidget) Title() string {
+	return "Checks"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *ChecksWidget) MinSize() (int, int) {
+	return 25, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "checks".
+func (w *ChecksWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "checks" || msg.Err != nil {
+			return nil
+		}
+		if st, ok := msg.Data.(*checks.Status); ok {
+			w.status = st
+			if w.scrollOffset >= len(st.Checks) {
+				w.scrollOffset = 0
+			}
+			if w.selected >= len(st.Groups()) {
+				w.selected = 0
+			}
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. Up/down (or
+// k/j) scroll the check list, or select a group in the grouped view, where
+// enter or space expands and collapses the selected group. 'g' toggles
+// between the grouped and flat views.
+func (w *ChecksWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	if w.chkGroupedView() {
+		groups := w.status.Groups()
+		switch key.String() {
+		case "up", "k":
+			if w.selected > 0 {
+				w.selected--
+			}
+		case "down", "j":
+			if w.selected < len(groups)-1 {
+				w.selected++
+			}
+		case "enter", " ":
+			if w.selected < len(groups) {
+				name := groups[w.selected].Name
+				w.expanded[name] = !w.expanded[name]
+			}
+		case "g":
+			w.flat = true
+		}
+		return nil
+	}
+
+	switch key.String() {
+	case "g":
+		w.flat = false
+	case "up", "k":
+		if w.scrollOffset > 0 {
+			w.scrollOffset--
+		}
+	case "down", "j":
+		if w.status != nil && w.scrollOffset < len(w.status.Checks)-1 {
+			w.scrollOffset++
+		}
+	}
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *ChecksWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, height)
+	if w.status == nil {
+		lines = append(lines, components.Dim("No data"))
+	} else {
+		header := fmt.Sprintf("%d/%d up", w.status.Up, w.status.Total)
+		if w.status.Down > 0 {
+			header += components.Color(ukColorDown) + fmt.Sprintf("  %d down", w.status.Down) + components.Reset()
+		}
+		lines = append(lines, header)
+
+		if w.chkGroupedView() {
+			lines = append(lines, w.chkGroupLines(width, height-1)...)
+		} else {
+			results := chkSortedResults(w.status.Checks)
+			for i := w.scrollOffset; i < len(results) && len(lines) < height; i++ {
+				lines = append(lines, chkResultLine(results[i], width))
+			}
+		}
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// chkGroupedView reports whether the widget lists groups rather than
+// checks: the checks name groups and the flat view was not chosen.
+func (w *ChecksWidget) chkGroupedView() bool {
+	return w.status != nil && !w.flat && w.status.Grouped()
+}
+
+// chkGroupLines renders up to height lines of the grouped view: a line per
+// group with its rolled-up state, followed by its members when expanded.
+// The list scrolls to keep the selected group in view.
+func (w *ChecksWidget) chkGroupLines(width, height int) []string {
+	var lines []string
+	selectedLine := 0
+	for i, g := range w.status.Groups() {
+		marker := "▸"
+		if w.expanded[g.Name] {
+			marker = "▾"
+		}
+		color := ukColorUp
+		switch g.State {
+		case checks.GroupWarn:
+			color = ukColorPending
+		case checks.GroupDown:
+			color = ukColorDown
+		}
+		line := marker + " " + components.Color(color) + g.Label() + components.Reset()
+		if i == w.selected {
+			line = components.Bold(line)
+			selectedLine = len(lines)
+		}
+		lines = append(lines, line)
+		if w.expanded[g.Name] {
+			for _, r := range chkSortedResults(g.Members) {
+				lines = append(lines, "  "+chkResultLine(r, width-2))
+			}
+		}
+	}
+
+	if start := selectedLine - height + 1; start > 0 {
+		lines = lines[start:]
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// chkResultLine renders one check: a colored state dot, the name, and the
+// latency right-aligned when it is up, or the error when it is not.
+func chkResultLine(r checks.Result, width int) string {
+	color := ukColorPending
+	switch r.State {
+	case checks.StateUp:
+		color = ukColorUp
+	case checks.StateDown:
+		color = ukColorDown
+	}
+	line := components.Color(color) + "●" + components.Reset() + " " + r.Name
+
+	if r.State == checks.StateUp {
+		lat := fmt.Sprintf("%.0fms", r.LatencyMs)
+		if gap := width - components.VisibleLen(line) - len(lat); gap > 0 {
+			line += strings.Repeat(" ", gap) + components.Dim(lat)
+		}
+	} else if r.Error != "" {
+		line += " " + components.Dim(r.Error)
+	}
+	return line
+}
+
+// chkSortedResults returns results ordered down, pending, up, then by name.
+func chkSortedResults(results []checks.Result) []checks.Result {
+	rank := map[string]int{
+		checks.StateDown:    0,
+		checks.StatePending: 1,
+		checks.StateUp:      2,
+	}
+	sorted := make([]checks.Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank[sorted[i].State], rank[sorted[j].State]
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
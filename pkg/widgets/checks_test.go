@@ -0,0 +1,130 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/checks"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+func TestChecksWidget_NoData(t *testing.T) {
+	w := NewChecksWidget()
+	view := w.View(30, 3)
+	if !strings.Contains(view, "No data") {
+		t.Errorf("view should contain 'No data', got:\n%s", view)
+	}
+}
+
+func TestChecksWidget_View_DownFirst(t *testing.T) {
+	w := NewChecksWidget()
+	w.Update(app.DataUpdateEvent{Source: "uptimekuma", Data: &checks.Status{}})
+	if w.status != nil {
+		t.Error("widget should ignore other sources")
+	}
+	w.Update(app.DataUpdateEvent{Source: "checks", Data: &checks.Status{
+		Checks: []checks.Result{
+			{Name: "grafana", State: checks.StateUp, LatencyMs: 38.4},
+			{Name: "nas-ssh", State: checks.StatePending, Error: "connection refused"},
+			{Name: "router", State: checks.StateDown, Error: "100% packet loss"},
+		},
+		Up:    1,
+		Down:  1,
+		Total: 2,
+	}})
+
+	lines := strings.Split(w.View(50, 5), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("view has %d lines, want 5", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 50 {
+			t.Errorf("line %d width = %d, want 50", i, got)
+		}
+	}
+	if !strings.Contains(lines[0], "1/2 up") || !strings.Contains(lines[0], "1 down") {
+		t.Errorf("header = %q, want up/down counts", lines[0])
+	}
+	if !strings.Contains(lines[1], "router") || !strings.Contains(lines[1], "packet loss") {
+		t.Errorf("first check = %q, want the down check with its error", lines[1])
+	}
+	if !strings.Contains(lines[2], "nas-ssh") {
+		t.Errorf("second check = %q, want the pending check", lines[2])
+	}
+	if !strings.Contains(lines[3], "grafana") || !strings.Contains(lines[3], "38ms") {
+		t.Errorf("last check = %q, want grafana with latency", lines[3])
+	}
+}
+
+func TestChecksWidget_Groups(t *testing.T) {
+	w := NewChecksWidget()
+	w.Update(app.DataUpdateEvent{Source: "checks", Data: &checks.Status{
+		Checks: []checks.Result{
+			{Name: "api", Group: "website", State: checks.StateUp, LatencyMs: 12},
+			{Name: "cdn", Group: "website", State: checks.StateDown, Error: "timeout"},
+			{Name: "db", Group: "website", State: checks.StateUp, Critical: true},
+			{Name: "router", State: checks.StateUp},
+		},
+		Up:    3,
+		Down:  1,
+		Total: 4,
+	}})
+	view := func() []string {
+		return strings.Split(stripANSI(w.View(40, 6)), "\n")
+	}
+	key := func(k tea.KeyMsg) []string {
+		w.HandleKey(k)
+		return view()
+	}
+
+	lines := view()
+	if !strings.Contains(lines[1], "▸ website ⚠ 2/3") || !strings.Contains(lines[2], "▸ other ✓ 1/1") {
+		t.Errorf("grouped view = %q, want collapsed website and other groups", lines)
+	}
+
+	lines = key(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(lines[1], "▾ website") {
+		t.Errorf("group line = %q, want website expanded", lines[1])
+	}
+	if !strings.Contains(lines[2], "  ") || !strings.Contains(lines[2], "cdn") || !strings.Contains(lines[2], "timeout") {
+		t.Errorf("first member = %q, want the down cdn check indented", lines[2])
+	}
+	if !strings.Contains(lines[5], "▸ other") {
+		t.Errorf("line after members = %q, want the other group", lines[5])
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyDown})
+	lines = key(tea.KeyMsg{Type: tea.KeySpace})
+	if !strings.Contains(lines[5], "▾ other") {
+		t.Errorf("view = %q, want other expanded", lines)
+	}
+
+	lines = key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if !strings.Contains(lines[1], "cdn") || !strings.Contains(lines[2], "api") {
+		t.Errorf("flat view = %q, want checks down first", lines)
+	}
+	lines = key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if !strings.Contains(lines[1], "website") {
+		t.Errorf("view = %q, want the grouped view back", lines)
+	}
+}
+
+func TestChecksWidget_GroupsScrollToSelection(t *testing.T) {
+	w := NewChecksWidget()
+	var results []checks.Result
+	for _, g := range []string{"a", "b", "c", "d", "e"} {
+		results = append(results, checks.Result{Name: g + "1", Group: g, State: checks.StateUp})
+	}
+	w.Update(app.DataUpdateEvent{Source: "checks", Data: &checks.Status{Checks: results, Up: 5, Total: 5}})
+
+	for i := 0; i < 4; i++ {
+		w.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	lines := strings.Split(stripANSI(w.View(30, 3)), "\n")
+	if !strings.Contains(lines[2], "e ✓ 1/1") {
+		t.Errorf("view = %q, want the selected last group in view", lines)
+	}
+}
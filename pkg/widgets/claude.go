@@ -2,7 +2,9 @@ package widgets
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -33,17 +35,39 @@ const (
 )
 
 // ClaudeWidget displays multi-account Anthropic/Claude token usage,
-// per-model breakdowns, cost gauges, and a sparkline cost trend.
+// per-model breakdowns, cost gauges, and a sparkline cost trend. An
+// arrow-key drill-down leads from an account list into an account's Claude
+// Code sessions and from there to one session's share of the usage window.
 type ClaudeWidget struct {
 	report          *claude.UsageReport
 	expanded        bool
 	selectedAccount int
 	costHistory     []float64
+
+	level           claudeLevel
+	selectedRow     int  // selected row in the current drill-down table
+	selectedSession int  // index into claudeSortedSessions
+	sortByRecent    bool // sessions table order; token count by default
+	mouse           tableMouse
+	filter          tableFilter
+
+	// nowFunc allows tests to override time.Now for deterministic output.
+	nowFunc func() time.Time
 }
 
+// claudeLevel is the drill-down depth of the Claude widget.
+type claudeLevel int
+
+const (
+	claudeLevelOverview claudeLevel = iota // compact/expanded summary
+	claudeLevelAccounts                    // table of all accounts
+	claudeLevelSessions                    // sessions of one account
+	claudeLevelSession                     // window burn-down of one session
+)
+
 // NewClaudeWidget creates a new ClaudeWidget in compact mode.
 func NewClaudeWidget() *ClaudeWidget {
-	return &ClaudeWidget{}
+	return &ClaudeWidget{nowFunc: time.Now}
 }
 
 // ID returns the widget's unique identifier.
@@ -82,6 +106,11 @@ func (w *ClaudeWidget) Update(msg tea.Msg) tea.Cmd {
 			return nil
 		}
 		w.report = report
+		if w.selectedAccount >= len(report.Accounts) {
+			w.selectedAccount = 0
+			w.level = claudeLevelOverview
+		}
+		w.claudeClampSelection()
 
 		// Append the total cost to the sparkline history.
 		w.costHistory = append(w.costHistory, report.TotalCostUSD)
@@ -95,7 +124,37 @@ func (w *ClaudeWidget) Update(msg tea.Msg) tea.Cmd {
 // HandleKey processes key events when this widget has focus.
 // 'e' toggles between compact and expanded mode.
 // 'm' cycles through accounts (when multiple are present).
+// Right drills from the overview into the account list, an account's
+// sessions, and a single session; left backs out. While drilled in, up/down
+// move the row selection and 's' switches the sessions table between token
+// count and recency order.
 func (w *ClaudeWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	switch key.String() {
+	case "right":
+		w.claudeDrillIn()
+		return nil
+	case "left":
+		w.claudeDrillOut()
+		return nil
+	}
+
+	if w.level != claudeLevelOverview {
+		switch key.String() {
+		case "up":
+			w.selectedRow = w.filter.step(w.selectedRow, -1)
+			w.claudeClampSelection()
+		case "down":
+			w.selectedRow = w.filter.step(w.selectedRow, 1)
+			w.claudeClampSelection()
+		case "s":
+			if w.level == claudeLevelSessions {
+				w.sortByRecent = !w.sortByRecent
+				w.selectedRow = 0
+			}
+		}
+		return nil
+	}
+
 	switch key.String() {
 	case "e":
 		w.expanded = !w.expanded
@@ -109,16 +168,55 @@ func (w *ClaudeWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
+// HandleMouse processes mouse events over the widget. While drilled into
+// a table, a click selects a row, pressing or dragging on the scrollbar
+// jumps the selection to that point, and the wheel moves the selection as
+// up and down do.
+func (w *ClaudeWidget) HandleMouse(msg tea.MouseMsg) tea.Cmd {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return w.HandleKey(tea.KeyMsg{Type: tea.KeyUp})
+	case tea.MouseButtonWheelDown:
+		return w.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if row := w.filter.source(w.mouse.row(msg)); row >= 0 {
+		w.selectedRow = row
+		w.claudeClampSelection()
+	}
+	return nil
+}
+
+// FilterTable implements app.TableFilterer for the account and session
+// tables.
+func (w *ClaudeWidget) FilterTable(query string) {
+	w.filter.query = query
+}
+
+// TableFilter implements app.TableFilterer.
+func (w *ClaudeWidget) TableFilter() (string, int, int, bool) {
+	return w.filter.state(w.mouse.table != nil)
+}
+
 // View renders the widget content into the given width x height area.
 func (w *ClaudeWidget) View(width, height int) string {
 	if width <= 0 || height <= 0 {
 		return ""
 	}
 
+	w.mouse.draw(nil, 0)
 	if w.report == nil || len(w.report.Accounts) == 0 {
 		return claudeCenterMessage("No data", width, height)
 	}
 
+	switch w.level {
+	case claudeLevelAccounts:
+		return w.claudeRenderAccountList(width, height)
+	case claudeLevelSessions:
+		return w.claudeRenderSessionList(width, height)
+	case claudeLevelSession:
+		return w.claudeRenderSessionDetail(width, height)
+	}
+
 	var lines []string
 
 	if w.expanded {
@@ -137,8 +235,14 @@ func (w *ClaudeWidget) viewCompact(width int) []string {
 
 	for _, acct := range w.report.Accounts {
 		if !acct.Connected {
+			// A rejected login is the one failure the user must fix
+			// by hand, so say how instead of just "disconnected".
+			status := "disconnected"
+			if acct.NeedsLogin {
+				status = acct.Error
+			}
 			lines = append(lines, claudeTruncLine(
-				components.Color(ColorError)+acct.Name+": disconnected"+components.Reset(), width))
+				components.Color(ColorError)+acct.Name+": "+status+components.Reset(), width))
 			continue
 		}
 
@@ -198,6 +302,9 @@ func (w *ClaudeWidget) viewExpanded(width int) []string {
 
 		// Account header.
 		header := fmt.Sprintf("%s  $%.2f this month", components.Bold(acct.Name), acct.CurrentMonth.CostUSD)
+		if acct.Subscription != "" {
+			header += components.Dim("  " + acct.Subscription)
+		}
 		lines = append(lines, claudeTruncLine(header, width))
 
 		// Input token gauge.
@@ -217,6 +324,24 @@ func (w *ClaudeWidget) viewExpanded(width int) []string {
 			fmt.Sprintf(" %s", claudeFormatTokens(acct.CurrentMonth.OutputTokens)))
 		lines = append(lines, claudeTruncLine(outputLine, width))
 
+		// Subscription plan limits, for credential-backed accounts.
+		if p := acct.Plan; p != nil {
+			for _, pw := range []struct {
+				label  string
+				window *claude.PlanWindow
+			}{{"5h plan", p.FiveHour}, {"7d plan", p.SevenDay}} {
+				if pw.window == nil {
+					continue
+				}
+				suffix := ""
+				if !pw.window.ResetsAt.IsZero() {
+					suffix = " resets in " + claudeFormatRemaining(pw.window.ResetsAt.Sub(w.nowFunc()))
+				}
+				planLine := claudeRenderGauge(pw.label, pw.window.Utilization/100, gaugeWidth, suffix)
+				lines = append(lines, claudeTruncLine(planLine, width))
+			}
+		}
+
 		// Per-model breakdown.
 		for _, m := range acct.Models {
 			modelTokens := m.InputTokens + m.OutputTokens
@@ -224,6 +349,9 @@ func (w *ClaudeWidget) viewExpanded(width int) []string {
 			modelName := claudeShortModelName(m.Model)
 			label := fmt.Sprintf("  %s", modelName)
 			costLabel := fmt.Sprintf(" %s $%.2f", claudeFormatTokens(modelTokens), m.CostUSD)
+			if m.Unpriced {
+				costLabel += "*"
+			}
 			mLine := claudeRenderGauge(label, modelRatio, gaugeWidth, costLabel)
 			lines = append(lines, claudeTruncLine(mLine, width))
 		}
@@ -269,8 +397,300 @@ func (w *ClaudeWidget) viewExpanded(width int) []string {
 	return lines
 }
 
+// --- drill-down ---
+
+// claudeDrillIn descends one level, carrying the selected row into the next
+// level's context and clearing the table filter. Accounts without sessions
+// cannot be entered.
+func (w *ClaudeWidget) claudeDrillIn() {
+	if w.report == nil || len(w.report.Accounts) == 0 {
+		return
+	}
+	w.filter.query = ""
+	switch w.level {
+	case claudeLevelOverview:
+		w.level = claudeLevelAccounts
+		w.selectedRow = w.selectedAccount
+	case claudeLevelAccounts:
+		if len(w.report.Accounts[w.selectedRow].Sessions) == 0 {
+			return
+		}
+		w.selectedAccount = w.selectedRow
+		w.level = claudeLevelSessions
+		w.selectedRow = 0
+	case claudeLevelSessions:
+		w.selectedSession = w.selectedRow
+		w.level = claudeLevelSession
+	}
+}
+
+// claudeDrillOut ascends one level, restoring the selection that led here
+// and clearing the table filter.
+func (w *ClaudeWidget) claudeDrillOut() {
+	w.filter.query = ""
+	switch w.level {
+	case claudeLevelAccounts:
+		w.level = claudeLevelOverview
+		w.selectedRow = 0
+	case claudeLevelSessions:
+		w.level = claudeLevelAccounts
+		w.selectedRow = w.selectedAccount
+	case claudeLevelSession:
+		w.level = claudeLevelSessions
+		w.selectedRow = w.selectedSession
+	}
+}
+
+// claudeClampSelection keeps the drill-down selection within the rows of
+// the current level after a key press or data update.
+func (w *ClaudeWidget) claudeClampSelection() {
+	if w.report == nil || len(w.report.Accounts) == 0 {
+		return
+	}
+	rows := 0
+	switch w.level {
+	case claudeLevelAccounts:
+		rows = len(w.report.Accounts)
+	case claudeLevelSessions:
+		rows = len(w.report.Accounts[w.selectedAccount].Sessions)
+	case claudeLevelSession:
+		if w.selectedSession >= len(w.report.Accounts[w.selectedAccount].Sessions) {
+			w.level = claudeLevelSessions
+			w.selectedSession = 0
+			w.claudeClampSelection()
+		}
+		return
+	}
+	if w.selectedRow >= rows {
+		w.selectedRow = rows - 1
+	}
+	if w.selectedRow < 0 {
+		w.selectedRow = 0
+	}
+}
+
+// claudeSortedSessions returns the selected account's sessions in table
+// order: by input plus output tokens, heaviest first, or by last activity
+// when sortByRecent is set.
+func (w *ClaudeWidget) claudeSortedSessions() []claude.SessionUsage {
+	sessions := append([]claude.SessionUsage(nil), w.report.Accounts[w.selectedAccount].Sessions...)
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if w.sortByRecent {
+			return sessions[i].LastActivity.After(sessions[j].LastActivity)
+		}
+		return claudeSessionTokens(sessions[i]) > claudeSessionTokens(sessions[j])
+	})
+	return sessions
+}
+
+// claudeRenderAccountList renders every account as a selectable table row.
+func (w *ClaudeWidget) claudeRenderAccountList(width, height int) string {
+	lines := []string{components.Bold("Accounts") + components.Dim("  \u2192 sessions  \u2190 back")}
+
+	rows := make([]components.Row, 0, len(w.report.Accounts))
+	for _, acct := range w.report.Accounts {
+		window := components.Dim("-")
+		if acct.Window != nil {
+			window = claudeFormatTokens(acct.Window.Tokens)
+		}
+		name := acct.Name
+		if !acct.Connected {
+			name = components.Color(ColorError) + name + components.Reset()
+		}
+		_, cost := acct.Spend()
+		rows = append(rows, components.Row{
+			Cells: []string{
+				name,
+				claudeFormatCost(cost, len(acct.UnpricedModels) > 0),
+				fmt.Sprintf("%d", len(acct.Sessions)),
+				window,
+			},
+			ID: acct.Name,
+		})
+	}
+
+	filter, sel, pos := w.filter.apply(rows, w.selectedRow)
+	w.selectedRow = sel
+	table, dt := claudeRenderTable([]components.Column{
+		{Title: "Account", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 6},
+		{Title: "Cost", Sizing: components.SizingFixed(9), Align: components.ColAlignRight},
+		{Title: "Sessions", Sizing: components.SizingFixed(8), Align: components.ColAlignRight},
+		{Title: "Window", Sizing: components.SizingFixed(7), Align: components.ColAlignRight},
+	}, rows, filter, pos, width, height-len(lines))
+	w.mouse.draw(dt, len(lines))
+	return claudeFitLines(append(lines, table...), width, height)
+}
+
+// claudeRenderSessionList renders the selected account's sessions.
+func (w *ClaudeWidget) claudeRenderSessionList(width, height int) string {
+	acct := w.report.Accounts[w.selectedAccount]
+	order := "tokens"
+	if w.sortByRecent {
+		order = "recent"
+	}
+	lines := []string{claudeTruncLine(components.Bold(acct.Name+" sessions")+
+		components.Dim(fmt.Sprintf("  by %s  s sort  \u2192 detail  \u2190 back", order)), width)}
+
+	sessions := w.claudeSortedSessions()
+	rows := make([]components.Row, 0, len(sessions))
+	for _, s := range sessions {
+		rows = append(rows, components.Row{
+			Cells: []string{
+				claudeShortSessionID(s.ID),
+				claudeShortModelName(s.Model),
+				claudeFormatTokens(s.InputTokens),
+				claudeFormatTokens(s.OutputTokens),
+				claudeFormatCost(s.CostUSD, s.UnpricedModel != ""),
+				w.claudeFormatAgo(s.LastActivity),
+			},
+			ID: s.ID,
+		})
+	}
+
+	filter, sel, pos := w.filter.apply(rows, w.selectedRow)
+	w.selectedRow = sel
+	table, dt := claudeRenderTable([]components.Column{
+		{Title: "Session", Sizing: components.SizingFixed(8), Align: components.ColAlignLeft},
+		{Title: "Model", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 6},
+		{Title: "In", Sizing: components.SizingFixed(6), Align: components.ColAlignRight},
+		{Title: "Out", Sizing: components.SizingFixed(6), Align: components.ColAlignRight},
+		{Title: "Cost", Sizing: components.SizingFixed(8), Align: components.ColAlignRight},
+		{Title: "Last", Sizing: components.SizingFixed(7), Align: components.ColAlignRight},
+	}, rows, filter, pos, width, height-len(lines))
+	w.mouse.draw(dt, len(lines))
+	return claudeFitLines(append(lines, table...), width, height)
+}
+
+// claudeRenderSessionDetail renders one session's totals and a burn-down
+// of the current usage window: the session's share of the window's tokens
+// against the time left until the window resets.
+func (w *ClaudeWidget) claudeRenderSessionDetail(width, height int) string {
+	acct := w.report.Accounts[w.selectedAccount]
+	s := w.claudeSortedSessions()[w.selectedSession]
+
+	header := components.Bold("Session "+claudeShortSessionID(s.ID)) + "  " + claudeShortModelName(s.Model)
+	if s.Project != "" {
+		header += components.Dim("  " + s.Project)
+	}
+	lines := []string{
+		claudeTruncLine(header, width),
+		claudeTruncLine(fmt.Sprintf("  In %s  Out %s  Cache %s/%s  %s",
+			claudeFormatTokens(s.InputTokens), claudeFormatTokens(s.OutputTokens),
+			claudeFormatTokens(s.CacheCreationTokens), claudeFormatTokens(s.CacheReadTokens),
+			claudeFormatCost(s.CostUSD, s.UnpricedModel != "")), width),
+		claudeTruncLine(components.Dim("  last active "+w.claudeFormatAgo(s.LastActivity)), width),
+	}
+	if s.UnpricedModel != "" {
+		lines = append(lines, claudeTruncLine(components.Dim("  * "+s.UnpricedModel+" priced at the default rate"), width))
+	}
+	lines = append(lines, "")
+
+	gaugeWidth := width - 30
+	if gaugeWidth < 5 {
+		gaugeWidth = 5
+	}
+	win := acct.Window
+	if win == nil {
+		lines = append(lines, components.Dim("No usage window in progress"))
+		return claudeFitLines(lines, width, height)
+	}
+
+	share := claudeTokenRatio(s.WindowTokens, win.Tokens)
+	lines = append(lines, claudeTruncLine(claudeRenderGauge("Window", share, gaugeWidth,
+		fmt.Sprintf(" %s of %s", claudeFormatTokens(s.WindowTokens), claudeFormatTokens(win.Tokens))), width))
+
+	elapsed := w.nowFunc().Sub(win.Start)
+	timeRatio := float64(elapsed) / float64(claude.WindowDuration)
+	if timeRatio > 1 {
+		timeRatio = 1
+	}
+	if timeRatio < 0 {
+		timeRatio = 0
+	}
+	lines = append(lines, claudeTruncLine(claudeRenderGauge("Elapsed", timeRatio, gaugeWidth,
+		" resets in "+claudeFormatRemaining(win.Reset.Sub(w.nowFunc()))), width))
+
+	lines = append(lines, claudeTruncLine(components.Dim(fmt.Sprintf("  window %s\u2013%s  $%.2f",
+		win.Start.Local().Format("15:04"), win.Reset.Local().Format("15:04"), win.CostUSD)), width))
+	return claudeFitLines(lines, width, height)
+}
+
+// claudeRenderTable renders the rows passing filter (nil for all) in a
+// DataTable with the selected-th of them selected, scrolling so the
+// selection stays visible. It returns the table's lines and the table,
+// for hit-testing mouse events.
+func claudeRenderTable(cols []components.Column, rows []components.Row, filter func(components.Row) bool, selected, width, height int) ([]string, *components.DataTable) {
+	if height <= 0 {
+		return nil, nil
+	}
+	dt := components.NewDataTable(components.DataTableConfig{
+		Columns: cols,
+		HeaderStyle: components.HeaderStyleConfig{
+			Bold:    true,
+			FgColor: ColorAccent,
+		},
+		ShowHeader: true,
+		ShowBorder: true,
+		Selectable: true,
+		Scrollbar:  true,
+	})
+	dt.SetRows(rows)
+	dt.SetFilter(filter)
+	for i := 0; i <= selected && i < len(rows); i++ {
+		dt.SelectNext()
+	}
+	// Reserve the header, separator, and both scroll indicators.
+	if over := selected - (height - 4) + 1; over > 0 {
+		dt.ScrollDown(over)
+	}
+	return strings.Split(dt.Render(width, height), "\n"), dt
+}
+
+// claudeFormatAgo formats t relative to now, e.g. "5m ago".
+func (w *ClaudeWidget) claudeFormatAgo(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := w.nowFunc().Sub(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
 // --- private helpers (all prefixed with "claude" to avoid conflicts) ---
 
+// claudeSessionTokens is the input plus output token count of a session.
+func claudeSessionTokens(s claude.SessionUsage) int64 {
+	return s.InputTokens + s.OutputTokens
+}
+
+// claudeShortSessionID returns the first 8 characters of a session UUID.
+func claudeShortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// claudeFormatRemaining formats a time until reset as "2h13m" or "45m".
+func claudeFormatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "now"
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
 // claudeTokenRatio computes a 0..1 ratio of used tokens against a budget.
 func claudeTokenRatio(tokens int64, budget int64) float64 {
 	if budget <= 0 {
@@ -318,6 +738,16 @@ func claudeRatioColor(ratio float64) string {
 	return claudeColorGreen
 }
 
+// claudeFormatCost formats an estimated cost, marking one that includes
+// models priced at the default rate with "*".
+func claudeFormatCost(cost float64, unpriced bool) string {
+	s := fmt.Sprintf("$%.2f", cost)
+	if unpriced {
+		s += "*"
+	}
+	return s
+}
+
 // claudeFormatTokens formats token counts with SI suffixes.
 func claudeFormatTokens(tokens int64) string {
 	v := float64(tokens)
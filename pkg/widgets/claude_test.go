@@ -1,6 +1,7 @@
 package widgets
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -495,3 +496,264 @@ func TestClaudeWidget_ExpandedDisconnectedShowsError(t *testing.T) {
 		t.Errorf("expanded disconnected view should show error message")
 	}
 }
+
+// claudeSessionReport returns a disconnected account followed by one with
+// two sessions in an open usage window that resets at 14:00.
+func claudeSessionReport() *claude.UsageReport {
+	acct := claudeTestAccount("work", 1_000_000, 500_000, 40.0, nil)
+	acct.Sessions = []claude.SessionUsage{
+		{ID: "aaaaaaaa-1111", Model: "claude-sonnet-4-5", InputTokens: 1_000, OutputTokens: 2_000,
+			CostUSD: 0.05, LastActivity: time.Date(2026, 2, 9, 11, 55, 0, 0, time.UTC), WindowTokens: 3_000},
+		{ID: "bbbbbbbb-2222", Model: "claude-opus-4-6", InputTokens: 40_000, OutputTokens: 80_000,
+			CostUSD: 6.60, LastActivity: time.Date(2026, 2, 9, 10, 0, 0, 0, time.UTC), WindowTokens: 9_000},
+	}
+	acct.Window = &claude.WindowUsage{
+		Start:  time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC),
+		Reset:  time.Date(2026, 2, 9, 14, 0, 0, 0, time.UTC),
+		Tokens: 12_000,
+	}
+	return claudeTestReport(claudeTestDisconnectedAccount("personal"), acct)
+}
+
+func claudeSessionWidget() *ClaudeWidget {
+	w := NewClaudeWidget()
+	w.nowFunc = func() time.Time { return time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC) }
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: claudeSessionReport()})
+	return w
+}
+
+func TestClaudeWidget_DrillDown(t *testing.T) {
+	w := claudeSessionWidget()
+
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	// Overview -> account list.
+	w.HandleKey(right)
+	view := stripANSI(w.View(60, 10))
+	if w.level != claudeLevelAccounts || !strings.Contains(view, "personal") || !strings.Contains(view, "work") {
+		t.Fatalf("level %d, want account list with both accounts, got:\n%s", w.level, view)
+	}
+
+	// An account without sessions cannot be entered.
+	w.HandleKey(right)
+	if w.level != claudeLevelAccounts {
+		t.Errorf("level = %d after entering an account without sessions, want account list", w.level)
+	}
+
+	// Account list -> sessions, heaviest first.
+	w.HandleKey(down)
+	w.HandleKey(right)
+	view = stripANSI(w.View(70, 10))
+	if w.level != claudeLevelSessions || w.selectedAccount != 1 {
+		t.Fatalf("level/account = %d/%d, want work sessions", w.level, w.selectedAccount)
+	}
+	if strings.Index(view, "bbbbbbbb") > strings.Index(view, "aaaaaaaa") {
+		t.Errorf("sessions should be sorted by tokens, got:\n%s", view)
+	}
+	for _, want := range []string{"Opus 4.6", "40.0K", "80.0K", "$6.60", "2h ago", "5m ago"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("sessions view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Sessions -> detail of the heaviest session.
+	w.HandleKey(right)
+	view = stripANSI(w.View(70, 10))
+	if w.level != claudeLevelSession {
+		t.Fatalf("level = %d, want session detail", w.level)
+	}
+	for _, want := range []string{"Session bbbbbbbb", "9.0K of 12.0K", "resets in 2h00m"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("session view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Back out restores the previous selections.
+	w.HandleKey(left)
+	w.HandleKey(left)
+	if w.level != claudeLevelAccounts || w.selectedRow != 1 {
+		t.Errorf("level/row = %d/%d after left, want accounts with work selected", w.level, w.selectedRow)
+	}
+	w.HandleKey(left)
+	if w.level != claudeLevelOverview {
+		t.Errorf("level = %d, want overview", w.level)
+	}
+}
+
+func TestClaudeWidget_SessionSortToggle(t *testing.T) {
+	w := claudeSessionWidget()
+	w.level, w.selectedAccount = claudeLevelSessions, 1
+
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if got := w.claudeSortedSessions()[0].ID; got != "aaaaaaaa-1111" {
+		t.Errorf("first session by recency = %s, want aaaaaaaa-1111", got)
+	}
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+	if view := stripANSI(w.View(70, 10)); !strings.Contains(view, "Session aaaaaaaa") {
+		t.Errorf("detail should follow the sorted order, got:\n%s", view)
+	}
+}
+
+func TestClaudeWidget_UnpricedSessionCost(t *testing.T) {
+	w := claudeSessionWidget()
+	report := claudeSessionReport()
+	report.Accounts[1].Sessions[0].UnpricedModel = "claude-next"
+	report.Accounts[1].UnpricedModels = []string{"claude-next"}
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: report})
+
+	w.level = claudeLevelAccounts
+	if view := stripANSI(w.View(70, 10)); !strings.Contains(view, "*") {
+		t.Errorf("account list should flag the default-rate cost, got:\n%s", view)
+	}
+
+	w.level, w.selectedAccount = claudeLevelSessions, 1
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+	view := stripANSI(w.View(70, 12))
+	for _, want := range []string{"Session aaaaaaaa", "* claude-next priced at the default rate"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("session view should contain %q, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestClaudeWidget_Mouse(t *testing.T) {
+	acct := claudeTestAccount("work", 1, 1, 1, nil)
+	for i := range 20 {
+		acct.Sessions = append(acct.Sessions, claude.SessionUsage{
+			ID: fmt.Sprintf("sess%04d-x", i), Model: "claude-sonnet-4-5", InputTokens: int64(100 - i),
+		})
+	}
+	w := NewClaudeWidget()
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: claudeTestReport(acct)})
+	w.level = claudeLevelSessions
+
+	press := func(x, y int) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	}
+
+	// Title on line 0, then the table header and separator; rows from line 3.
+	w.View(70, 10)
+	w.HandleMouse(press(10, 4))
+	if w.selectedRow != 1 {
+		t.Errorf("selectedRow = %d after clicking the second row, want 1", w.selectedRow)
+	}
+	w.HandleMouse(press(10, 1)) // the table header selects nothing
+	if w.selectedRow != 1 {
+		t.Errorf("selectedRow = %d after clicking the header, want 1", w.selectedRow)
+	}
+
+	w.HandleMouse(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	if w.selectedRow != 2 {
+		t.Errorf("selectedRow = %d after wheel down, want 2", w.selectedRow)
+	}
+
+	// The scrollbar is the last column beside the rows: dragging it to
+	// the bottom of the track, and past it, selects the last session and
+	// scrolls it into view.
+	view := stripANSI(w.View(70, 10))
+	if !strings.HasSuffix(strings.Split(view, "\n")[3], "█") {
+		t.Fatalf("no scrollbar thumb at the top of the track:\n%s", view)
+	}
+	w.HandleMouse(press(69, 3))
+	if w.selectedRow != 0 {
+		t.Errorf("selectedRow = %d after pressing the top of the scrollbar, want 0", w.selectedRow)
+	}
+	w.HandleMouse(tea.MouseMsg{X: 40, Y: 15, Action: tea.MouseActionMotion, Button: tea.MouseButtonLeft})
+	if w.selectedRow != 19 {
+		t.Errorf("selectedRow = %d after dragging past the bottom, want 19", w.selectedRow)
+	}
+	w.HandleMouse(tea.MouseMsg{X: 40, Y: 15, Action: tea.MouseActionRelease})
+	if view := stripANSI(w.View(70, 10)); !strings.Contains(view, "sess0019") {
+		t.Errorf("the selected last session should be scrolled into view:\n%s", view)
+	}
+	w.HandleMouse(tea.MouseMsg{X: 40, Y: 3, Action: tea.MouseActionMotion, Button: tea.MouseButtonLeft})
+	if w.selectedRow != 19 {
+		t.Errorf("selectedRow = %d after motion once released, want 19", w.selectedRow)
+	}
+
+	// The overview has no table to click.
+	w.level = claudeLevelOverview
+	w.View(70, 10)
+	w.HandleMouse(press(10, 4))
+	if w.selectedRow != 19 {
+		t.Errorf("selectedRow = %d after clicking the overview", w.selectedRow)
+	}
+}
+
+func TestClaudeWidget_DrillDownResetOnShrink(t *testing.T) {
+	w := claudeSessionWidget()
+	w.level, w.selectedAccount, w.selectedSession = claudeLevelSession, 1, 1
+
+	report := claudeSessionReport()
+	report.Accounts[1].Sessions = report.Accounts[1].Sessions[:1]
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: report})
+	if w.level != claudeLevelSessions {
+		t.Errorf("level = %d after the selected session vanished, want sessions", w.level)
+	}
+
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: claudeTestReport(claudeTestAccount("solo", 1, 1, 1, nil))})
+	if w.level != claudeLevelOverview || w.selectedAccount != 0 {
+		t.Errorf("level/account = %d/%d after the account vanished, want overview", w.level, w.selectedAccount)
+	}
+}
+
+func TestClaudeWidget_FormatRemaining(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{-time.Minute, "now"},
+		{45 * time.Minute, "45m"},
+		{2*time.Hour + 13*time.Minute, "2h13m"},
+	}
+	for _, tt := range tests {
+		if got := claudeFormatRemaining(tt.in); got != tt.want {
+			t.Errorf("claudeFormatRemaining(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClaudeWidget_ExpandedShowsSubscription(t *testing.T) {
+	w := NewClaudeWidget()
+	w.expanded = true
+	acct := claudeTestAccount("work", 1, 1, 1.0, nil)
+	acct.Subscription = "max"
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: claudeTestReport(acct)})
+
+	if view := stripANSI(w.View(60, 15)); !strings.Contains(view, "work  $1.00 this month  max") {
+		t.Errorf("expanded header should show the plan, got:\n%s", view)
+	}
+}
+
+func TestClaudeWidget_CompactShowsNeedsLogin(t *testing.T) {
+	w := NewClaudeWidget()
+	acct := claudeTestDisconnectedAccount("work")
+	acct.Error = claude.ErrRefreshRejected.Error()
+	acct.NeedsLogin = true
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: claudeTestReport(acct)})
+
+	if view := stripANSI(w.View(80, 10)); !strings.Contains(view, "work: refresh token rejected — run claude login") {
+		t.Errorf("compact view should tell the user to log in, got:\n%s", view)
+	}
+}
+
+func TestClaudeWidget_ExpandedShowsPlanUsage(t *testing.T) {
+	now := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	w := NewClaudeWidget()
+	w.expanded = true
+	w.nowFunc = func() time.Time { return now }
+	acct := claudeTestAccount("work", 1, 1, 1.0, nil)
+	acct.Plan = &claude.PlanUsage{FiveHour: &claude.PlanWindow{Utilization: 42, ResetsAt: now.Add(90 * time.Minute)}}
+	w.Update(app.DataUpdateEvent{Source: "claude", Data: claudeTestReport(acct)})
+
+	view := stripANSI(w.View(80, 15))
+	if !strings.Contains(view, "5h plan") || !strings.Contains(view, "42%") || !strings.Contains(view, "resets in 1h30m") {
+		t.Errorf("expanded view should show the 5h plan gauge, got:\n%s", view)
+	}
+	if strings.Contains(view, "7d plan") {
+		t.Errorf("expanded view should skip the missing 7d window, got:\n%s", view)
+	}
+}
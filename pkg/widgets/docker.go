@@ -0,0 +1,170 @@
+package widgets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// Color constants for Docker widget elements.
+const (
+	dkColorRunning   = "#10B981"
+	dkColorUnhealthy = "#EF4444"
+	dkColorStarting  = "#F59E0B"
+)
+
+// DockerWidget displays local Docker container status. Unhealthy containers
+// are listed first, then running, then everything else.
+type DockerWidget struct {
+	status       *docker.Status
+	scrollOffset int
+}
+
+// NewDockerWidget creates a new DockerWidget with default state.
+func NewDockerWidget() *DockerWidget {
+	return &DockerWidget{}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *DockerWidget) ID() string {
+	return "docker"
+}
+
+// Title returns the human-readable display name.
+func (w *DockerWidget) Title() string {
+	return "Docker"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *DockerWidget) MinSize() (int, int) {
+	return 25, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "docker".
+func (w *DockerWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "docker" || msg.Err != nil {
+			return nil
+		}
+		if st, ok := msg.Data.(*docker.Status); ok {
+			w.status = st
+			if w.scrollOffset >= len(st.Containers) {
+				w.scrollOffset = 0
+			}
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. Up/down (or
+// k/j) scroll the container list.
+func (w *DockerWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	switch key.String() {
+	case "up", "k":
+		if w.scrollOffset > 0 {
+			w.scrollOffset--
+		}
+	case "down", "j":
+		if w.status != nil && w.scrollOffset < len(w.status.Containers)-1 {
+			w.scrollOffset++
+		}
+	}
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *DockerWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, height)
+	switch {
+	case w.status == nil:
+		lines = append(lines, components.Dim("No data"))
+	case !w.status.Available:
+		lines = append(lines, components.Dim("Docker not available"))
+	default:
+		header := fmt.Sprintf("%d running  %d exited", w.status.Running, w.status.Exited)
+		if w.status.Unhealthy > 0 {
+			header += components.Color(dkColorUnhealthy) + fmt.Sprintf("  %d unhealthy", w.status.Unhealthy) + components.Reset()
+		}
+		lines = append(lines, header)
+
+		containers := dkSortedContainers(w.status.Containers)
+		for i := w.scrollOffset; i < len(containers) && len(lines) < height; i++ {
+			lines = append(lines, dkContainerLine(containers[i], width))
+		}
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dkContainerLine renders one container: a state dot, the name, and the
+// image right-aligned when it fits.
+func dkContainerLine(c docker.Container, width int) string {
+	var dot string
+	switch {
+	case c.Health == docker.HealthUnhealthy:
+		dot = components.Color(dkColorUnhealthy) + "●" + components.Reset()
+	case c.Health == docker.HealthStarting:
+		dot = components.Color(dkColorStarting) + "●" + components.Reset()
+	case c.State == docker.StateRunning:
+		dot = components.Color(dkColorRunning) + "●" + components.Reset()
+	default:
+		dot = components.Dim("○")
+	}
+
+	line := dot + " " + c.Name
+	if c.State != docker.StateRunning {
+		line += " " + components.Dim(c.State)
+	} else if c.Health == docker.HealthUnhealthy {
+		line += " " + components.Dim(docker.HealthUnhealthy)
+	}
+	if gap := width - components.VisibleLen(line) - len(c.Image); gap > 1 {
+		line += strings.Repeat(" ", gap) + components.Dim(c.Image)
+	}
+	return line
+}
+
+// dkSortedContainers returns containers ordered unhealthy, running, then
+// the rest, and by name within each group.
+func dkSortedContainers(containers []docker.Container) []docker.Container {
+	rank := func(c docker.Container) int {
+		switch {
+		case c.Health == docker.HealthUnhealthy:
+			return 0
+		case c.State == docker.StateRunning:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sorted := make([]docker.Container, len(containers))
+	copy(sorted, containers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank(sorted[i]), rank(sorted[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,98 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/docker"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// dkBuildTestStatus returns two running containers (one unhealthy) and one
+// exited container.
+func dkBuildTestStatus() *docker.Status {
+	return &docker.Status{
+		Available: true,
+		Containers: []docker.Container{
+			{Name: "db", Image: "postgres:16", State: docker.StateRunning, Health: docker.HealthUnhealthy},
+			{Name: "migrate", Image: "app:latest", State: docker.StateExited},
+			{Name: "web", Image: "nginx:1.27", State: docker.StateRunning, Health: docker.HealthHealthy},
+		},
+		Running:        2,
+		Exited:         1,
+		Unhealthy:      1,
+		Total:          3,
+		UnhealthyNames: []string{"db"},
+	}
+}
+
+func TestDockerWidget_NoData(t *testing.T) {
+	w := NewDockerWidget()
+	view := w.View(30, 3)
+	if !strings.Contains(view, "No data") {
+		t.Errorf("view should contain 'No data', got:\n%s", view)
+	}
+	if n := len(strings.Split(view, "\n")); n != 3 {
+		t.Errorf("view has %d lines, want 3", n)
+	}
+}
+
+func TestDockerWidget_NotAvailable(t *testing.T) {
+	w := NewDockerWidget()
+	w.Update(app.DataUpdateEvent{Source: "docker", Data: &docker.Status{Error: "docker not available"}})
+	if view := w.View(30, 3); !strings.Contains(view, "Docker not available") {
+		t.Errorf("view should say Docker is not available, got:\n%s", view)
+	}
+}
+
+func TestDockerWidget_View_UnhealthyFirst(t *testing.T) {
+	w := NewDockerWidget()
+	w.Update(app.DataUpdateEvent{Source: "uptimekuma", Data: dkBuildTestStatus()})
+	if w.status != nil {
+		t.Fatal("widget should ignore other sources")
+	}
+	w.Update(app.DataUpdateEvent{Source: "docker", Data: dkBuildTestStatus()})
+
+	view := w.View(40, 5)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("view has %d lines, want 5", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 40 {
+			t.Errorf("line %d width = %d, want 40", i, got)
+		}
+	}
+	if !strings.Contains(lines[0], "2 running") || !strings.Contains(lines[0], "1 unhealthy") {
+		t.Errorf("header = %q, want running/unhealthy counts", lines[0])
+	}
+	if !strings.Contains(lines[1], "db") || !strings.Contains(lines[1], "unhealthy") {
+		t.Errorf("first container = %q, want the unhealthy db", lines[1])
+	}
+	if !strings.Contains(lines[2], "web") || !strings.Contains(lines[2], "nginx:1.27") {
+		t.Errorf("second container = %q, want web with image", lines[2])
+	}
+	if !strings.Contains(lines[3], "migrate") || !strings.Contains(lines[3], "exited") {
+		t.Errorf("last container = %q, want exited migrate", lines[3])
+	}
+}
+
+func TestDockerWidget_Scroll(t *testing.T) {
+	w := NewDockerWidget()
+	w.Update(app.DataUpdateEvent{Source: "docker", Data: dkBuildTestStatus()})
+
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if w.scrollOffset != 1 {
+		t.Errorf("scrollOffset = %d after j, want 1", w.scrollOffset)
+	}
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	if w.scrollOffset != 0 {
+		t.Errorf("scrollOffset = %d, want clamped at 0", w.scrollOffset)
+	}
+}
+
+var _ app.Widget = (*DockerWidget)(nil)
@@ -0,0 +1,109 @@
+package widgets
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// EventsWidget displays the daemon's log of changes in collector data,
+// most recent first, each with the time it was logged.
+type EventsWidget struct {
+	events       []changelog.Event
+	scrollOffset int
+}
+
+// NewEventsWidget creates a new EventsWidget with default state.
+func NewEventsWidget() *EventsWidget {
+	return &EventsWidget{}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *EventsWidget) ID() string {
+	return "events"
+}
+
+// Title returns the human-readable display name.
+func (w *EventsWidget) Title() string {
+	return "Events"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *EventsWidget) MinSize() (int, int) {
+	return 30, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "events", carrying the log oldest
+// first.
+func (w *EventsWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "events" || msg.Err != nil {
+			return nil
+		}
+		if events, ok := msg.Data.([]changelog.Event); ok {
+			w.events = events
+			if w.scrollOffset >= len(events) {
+				w.scrollOffset = 0
+			}
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. Up/down (or
+// k/j) scroll back through older events.
+func (w *EventsWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	switch key.String() {
+	case "up", "k":
+		if w.scrollOffset > 0 {
+			w.scrollOffset--
+		}
+	case "down", "j":
+		if w.scrollOffset < len(w.events)-1 {
+			w.scrollOffset++
+		}
+	}
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *EventsWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, height)
+	if len(w.events) == 0 {
+		lines = append(lines, components.Dim("No changes"))
+	}
+	now := time.Now()
+	for i := len(w.events) - 1 - w.scrollOffset; i >= 0 && len(lines) < height; i-- {
+		lines = append(lines, evLine(w.events[i], now))
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// evLine renders one event: the time it was logged, with the date when it
+// was not today, and what changed.
+func evLine(ev changelog.Event, now time.Time) string {
+	t := ev.Time.Local()
+	layout := "15:04"
+	if y, m, d := t.Date(); y != now.Year() || m != now.Month() || d != now.Day() {
+		layout = "Jan 2 15:04"
+	}
+	return components.Dim(t.Format(layout)) + " " + ev.Text
+}
@@ -0,0 +1,59 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/changelog"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+func TestEventsWidget_NoData(t *testing.T) {
+	w := NewEventsWidget()
+	if view := w.View(30, 3); !strings.Contains(view, "No changes") {
+		t.Errorf("view should contain 'No changes', got:\n%s", view)
+	}
+}
+
+func TestEventsWidget_View_NewestFirst(t *testing.T) {
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	w := NewEventsWidget()
+	w.Update(app.DataUpdateEvent{Source: "checks", Data: []changelog.Event{{Text: "ignored"}}})
+	if w.events != nil {
+		t.Error("widget should ignore other sources")
+	}
+	w.Update(app.DataUpdateEvent{Source: "events", Data: []changelog.Event{
+		{Time: yesterday, Source: "billing", Text: "billing.digitalocean +$3.20"},
+		{Time: now.Add(-time.Minute), Source: "checks", Text: "checks api up→down"},
+		{Time: now, Source: "k8s", Text: "k8s prod pods running 41→39"},
+	}})
+
+	lines := strings.Split(w.View(50, 4), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("view has %d lines, want 4", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 50 {
+			t.Errorf("line %d width = %d, want 50", i, got)
+		}
+	}
+	if !strings.Contains(lines[0], now.Local().Format("15:04")) || !strings.Contains(lines[0], "k8s prod pods running 41→39") {
+		t.Errorf("first line = %q, want the newest event with its time", lines[0])
+	}
+	if !strings.Contains(lines[1], "checks api up→down") {
+		t.Errorf("second line = %q, want the check event", lines[1])
+	}
+	if !strings.Contains(lines[2], yesterday.Local().Format("Jan 2 15:04")) || !strings.Contains(lines[2], "billing.digitalocean +$3.20") {
+		t.Errorf("third line = %q, want yesterday's event with its date", lines[2])
+	}
+
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	if first := strings.Split(w.View(50, 4), "\n")[0]; !strings.Contains(first, "checks api") {
+		t.Errorf("after scrolling, first line = %q, want the check event", first)
+	}
+}
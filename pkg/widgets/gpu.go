@@ -0,0 +1,158 @@
+package widgets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/gpu"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// GPU thresholds (percentage 0-100 for utilization and memory, degrees
+// Celsius for temperature).
+const (
+	gpWarnThreshold = 70.0
+	gpCritThreshold = 90.0
+	gpTempWarn      = 75.0
+	gpTempCrit      = 85.0
+)
+
+// GPUWidget displays a table of GPUs with utilization, memory,
+// temperature, and power.
+type GPUWidget struct {
+	status *gpu.Status
+}
+
+// NewGPUWidget creates a new GPUWidget with default state.
+func NewGPUWidget() *GPUWidget {
+	return &GPUWidget{}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *GPUWidget) ID() string {
+	return "gpu"
+}
+
+// Title returns the human-readable display name.
+func (w *GPUWidget) Title() string {
+	return "GPU"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *GPUWidget) MinSize() (int, int) {
+	return 30, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "gpu".
+func (w *GPUWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "gpu" || msg.Err != nil {
+			return nil
+		}
+		if st, ok := msg.Data.(*gpu.Status); ok {
+			w.status = st
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. The table
+// has no interaction.
+func (w *GPUWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *GPUWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	var lines []string
+	switch {
+	case w.status == nil:
+		lines = []string{components.Dim("No data")}
+	case !w.status.Available:
+		lines = []string{components.Dim("No GPU available")}
+	default:
+		dt := components.NewDataTable(components.DataTableConfig{
+			Columns: []components.Column{
+				{Title: "GPU", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 8},
+				{Title: "Util", Sizing: components.SizingFixed(5), Align: components.ColAlignRight},
+				{Title: "Memory", Sizing: components.SizingFixed(10), Align: components.ColAlignRight},
+				{Title: "Temp", Sizing: components.SizingFixed(5), Align: components.ColAlignRight, Priority: 2},
+				{Title: "Power", Sizing: components.SizingFixed(6), Align: components.ColAlignRight, Priority: 1},
+			},
+			HeaderStyle: components.HeaderStyleConfig{
+				Bold:    true,
+				FgColor: ColorAccent,
+			},
+			ShowHeader: true,
+		})
+		rows := make([]components.Row, 0, len(w.status.GPUs))
+		for _, d := range w.status.GPUs {
+			rows = append(rows, components.Row{
+				Cells: gpCells(d),
+				ID:    strconv.Itoa(d.Index),
+			})
+		}
+		dt.SetRows(rows)
+		lines = strings.Split(dt.Render(width, height), "\n")
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gpCells returns the table cells for one GPU, colored by threshold.
+// Readings the GPU does not report are shown as "-".
+func gpCells(d gpu.Device) []string {
+	util := gpColored(fmt.Sprintf("%.0f%%", d.UtilizationPercent), d.UtilizationPercent, gpWarnThreshold, gpCritThreshold)
+
+	mem := d.MemoryText()
+	switch pct, ok := d.MemoryPercent(); {
+	case mem == "":
+		mem = "-"
+	case ok:
+		mem = gpColored(mem, pct, gpWarnThreshold, gpCritThreshold)
+	}
+
+	temp := "-"
+	if d.TemperatureC != nil {
+		temp = gpColored(fmt.Sprintf("%.0f°C", *d.TemperatureC), *d.TemperatureC, gpTempWarn, gpTempCrit)
+	}
+
+	power := "-"
+	if d.PowerWatts != nil {
+		power = fmt.Sprintf("%.0fW", *d.PowerWatts)
+	}
+
+	return []string{d.Name, util, mem, temp, power}
+}
+
+// gpColored colors text green, yellow, or red by value against the warn
+// and critical thresholds.
+func gpColored(text string, value, warn, crit float64) string {
+	color := smColorGreen
+	switch {
+	case value >= crit:
+		color = smColorRed
+	case value >= warn:
+		color = smColorYellow
+	}
+	return components.Color(color) + text + components.Reset()
+}
@@ -0,0 +1,64 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/gpu"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// gpBuildTestStatus returns a busy NVIDIA GPU and an Apple GPU that
+// reports neither temperature nor power.
+func gpBuildTestStatus() *gpu.Status {
+	temp, power := 88.0, 215.0
+	return &gpu.Status{
+		Available: true,
+		GPUs: []gpu.Device{
+			{Index: 0, Name: "RTX 3090", Vendor: gpu.VendorNVIDIA, UtilizationPercent: 67,
+				MemoryUsedBytes: 8396 << 20, MemoryTotalBytes: 24576 << 20, TemperatureC: &temp, PowerWatts: &power},
+			{Index: 1, Name: "Apple M2 Pro", Vendor: gpu.VendorApple, UtilizationPercent: 12, MemoryUsedBytes: 1395802112},
+		},
+	}
+}
+
+func TestGPUWidget_NoData(t *testing.T) {
+	w := NewGPUWidget()
+	view := w.View(30, 3)
+	if !strings.Contains(view, "No data") {
+		t.Errorf("view should contain 'No data', got:\n%s", view)
+	}
+	w.Update(app.DataUpdateEvent{Source: "gpu", Data: &gpu.Status{Error: "no supported GPU found"}})
+	if view := w.View(30, 3); !strings.Contains(view, "No GPU available") {
+		t.Errorf("view should say no GPU is available, got:\n%s", view)
+	}
+}
+
+func TestGPUWidget_View(t *testing.T) {
+	w := NewGPUWidget()
+	w.Update(app.DataUpdateEvent{Source: "docker", Data: gpBuildTestStatus()})
+	if w.status != nil {
+		t.Fatal("widget should ignore other sources")
+	}
+	w.Update(app.DataUpdateEvent{Source: "gpu", Data: gpBuildTestStatus()})
+
+	view := w.View(50, 5)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("view has %d lines, want 5", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 50 {
+			t.Errorf("line %d width = %d, want 50", i, got)
+		}
+	}
+	for _, want := range []string{"Util", "RTX 3090", "67%", "8.2/24GB", "88°C", "215W", "Apple M2 Pro", "1.3GB"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if !strings.Contains(view, components.Color(smColorRed)+"88°C") {
+		t.Error("temperature over the critical threshold should be red")
+	}
+}
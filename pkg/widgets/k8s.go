@@ -9,20 +9,51 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/theme"
 )
 
 // K8sWidget displays Kubernetes cluster status including pod counts, node
 // health, deployment progress, and resource utilization. It supports
-// multi-cluster views and compact/expanded display modes.
+// multi-cluster views and compact/expanded display modes, plus an
+// arrow-key drill-down from a cluster list into nodes and namespaces.
 type K8sWidget struct {
 	clusterStatus   *k8s.ClusterStatus
 	expanded        bool
 	selectedCluster int
 	scrollOffset    int
+
+	level             k8wLevel
+	selectedRow       int // selected row in the current drill-down table
+	selectedNamespace int
+
+	mouse     tableMouse
+	filter    tableFilter
+	tabsShown bool // the last View drew the cluster tab bar on its first line
+
+	// clusterCosts maps a lower-cased cluster name to its month-to-date
+	// cost, from the billing collector's Kubernetes resources.
+	clusterCosts map[string]k8wClusterCost
+}
+
+// k8wClusterCost is the billed month-to-date cost of a cluster.
+type k8wClusterCost struct {
+	provider string
+	accrued  float64
 }
 
+// k8wLevel is the drill-down depth of the K8s widget.
+type k8wLevel int
+
+const (
+	k8wLevelOverview  k8wLevel = iota // compact/expanded summary
+	k8wLevelClusters                  // table of all clusters
+	k8wLevelCluster                   // nodes and namespaces of one cluster
+	k8wLevelNamespace                 // deployments of one namespace
+)
+
 // NewK8sWidget creates a K8sWidget with default state.
 func NewK8sWidget() *K8sWidget {
 	return &K8sWidget{}
@@ -38,14 +69,22 @@ func (w *K8sWidget) Title() string { return "Kubernetes" }
 func (w *K8sWidget) MinSize() (int, int) { return 30, 4 }
 
 // Update handles messages directed at this widget. It processes
-// DataUpdateEvent with Source "k8s".
+// DataUpdateEvent with Source "k8s", and with Source "billing" (a
+// *billing.BillingReport) for the cost of clusters the billing collector
+// also sees.
 func (w *K8sWidget) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case app.DataUpdateEvent:
-		if msg.Source != "k8s" {
+		if msg.Err != nil {
 			return nil
 		}
-		if msg.Err != nil {
+		if msg.Source == "billing" {
+			if report, ok := msg.Data.(*billing.BillingReport); ok {
+				w.clusterCosts = k8wClusterCosts(report)
+			}
+			return nil
+		}
+		if msg.Source != "k8s" {
 			return nil
 		}
 		if cs, ok := msg.Data.(*k8s.ClusterStatus); ok {
@@ -53,7 +92,9 @@ func (w *K8sWidget) Update(msg tea.Msg) tea.Cmd {
 			// Clamp selectedCluster to valid range.
 			if w.selectedCluster >= len(cs.Clusters) {
 				w.selectedCluster = 0
+				w.level = k8wLevelOverview
 			}
+			w.k8wClampSelection()
 		}
 	}
 	return nil
@@ -61,8 +102,31 @@ func (w *K8sWidget) Update(msg tea.Msg) tea.Cmd {
 
 // HandleKey processes key events when the widget has focus.
 // 'e' toggles expanded mode, 'c' cycles through clusters,
-// up/down arrows scroll in expanded mode.
+// up/down arrows scroll in expanded mode. Right drills from the overview
+// into the cluster list, a cluster, and a namespace; left backs out. While
+// drilled in, up/down move the row selection.
 func (w *K8sWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	switch key.String() {
+	case "right":
+		w.k8wDrillIn()
+		return nil
+	case "left":
+		w.k8wDrillOut()
+		return nil
+	}
+
+	if w.level != k8wLevelOverview {
+		switch key.String() {
+		case "up":
+			w.selectedRow = w.filter.step(w.selectedRow, -1)
+			w.k8wClampSelection()
+		case "down":
+			w.selectedRow = w.filter.step(w.selectedRow, 1)
+			w.k8wClampSelection()
+		}
+		return nil
+	}
+
 	switch key.String() {
 	case "e":
 		w.expanded = !w.expanded
@@ -86,28 +150,80 @@ func (w *K8sWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
+// HandleMouse processes mouse events over the widget. In the overview a
+// click on a cluster tab selects that cluster. While drilled into a table,
+// a click selects a row, pressing or dragging on the scrollbar jumps the
+// selection to that point, and the wheel moves the selection as up and
+// down do.
+func (w *K8sWidget) HandleMouse(msg tea.MouseMsg) tea.Cmd {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return w.HandleKey(tea.KeyMsg{Type: tea.KeyUp})
+	case tea.MouseButtonWheelDown:
+		return w.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if w.tabsShown && msg.Y == 0 && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+		if i := k8wClusterTabAt(w.clusterStatus.Clusters, msg.X); i >= 0 && i != w.selectedCluster {
+			w.selectedCluster = i
+			w.scrollOffset = 0
+		}
+		return nil
+	}
+	if row := w.filter.source(w.mouse.row(msg)); row >= 0 {
+		w.selectedRow = row
+		w.k8wClampSelection()
+	}
+	return nil
+}
+
+// FilterTable implements app.TableFilterer for the drill-down tables.
+func (w *K8sWidget) FilterTable(query string) {
+	w.filter.query = query
+}
+
+// TableFilter implements app.TableFilterer.
+func (w *K8sWidget) TableFilter() (string, int, int, bool) {
+	return w.filter.state(w.mouse.table != nil)
+}
+
 // View renders the widget content into the given area dimensions.
 func (w *K8sWidget) View(width, height int) string {
 	if width <= 0 || height <= 0 {
 		return ""
 	}
 
+	w.mouse.draw(nil, 0)
+	w.tabsShown = false
 	if w.clusterStatus == nil || len(w.clusterStatus.Clusters) == 0 {
 		return centerText("No data", width, height)
 	}
 
+	switch w.level {
+	case k8wLevelClusters:
+		return w.k8wRenderClusterList(width, height)
+	case k8wLevelCluster:
+		return w.k8wRenderClusterDetail(width, height)
+	case k8wLevelNamespace:
+		return w.k8wRenderNamespaceDetail(width, height)
+	}
+
 	var lines []string
 
 	// Multi-cluster tab bar.
 	if len(w.clusterStatus.Clusters) > 1 {
 		lines = append(lines, k8wRenderClusterTabs(w.clusterStatus.Clusters, w.selectedCluster, width))
+		w.tabsShown = w.scrollOffset == 0
 	}
 
 	// Render the selected cluster.
 	cluster := w.clusterStatus.Clusters[w.selectedCluster]
 
 	if w.expanded {
-		lines = append(lines, k8wRenderExpanded(cluster, width)...)
+		// The cost line goes under the connection line.
+		expanded := k8wRenderExpanded(cluster, width)
+		lines = append(lines, expanded[0])
+		lines = append(lines, w.k8wCostLines(cluster, width)...)
+		lines = append(lines, expanded[1:]...)
 	} else {
 		lines = append(lines, k8wRenderCompact(cluster, width)...)
 	}
@@ -167,6 +283,7 @@ func k8wRenderExpanded(c k8s.ClusterInfo, width int) []string {
 		summary = components.TruncateWithTail(summary, width, "...")
 	}
 	lines = append(lines, components.PadRight(summary, width))
+	lines = append(lines, k8wHealthLines(c, width)...)
 	lines = append(lines, "")
 
 	// Namespace sections.
@@ -196,6 +313,45 @@ func k8wRenderExpanded(c k8s.ClusterInfo, width int) []string {
 	return lines
 }
 
+// ---------- Cluster cost ----------
+
+// k8wClusterCosts collects the month-to-date cost of every Kubernetes
+// cluster in report that has one.
+func k8wClusterCosts(report *billing.BillingReport) map[string]k8wClusterCost {
+	costs := make(map[string]k8wClusterCost)
+	for _, p := range report.Providers {
+		for _, r := range p.Resources {
+			if r.Type == "kubernetes" && r.Accrued > 0 {
+				costs[strings.ToLower(r.Name)] = k8wClusterCost{provider: p.Name, accrued: r.Accrued}
+			}
+		}
+	}
+	return costs
+}
+
+// k8wCostLines returns the "est. cluster cost MTD" line for c, or nothing
+// when billing has no cost for it. A cluster matches a billed cluster of
+// the same name, or, since kubeconfig contexts are often the cluster name
+// with a prefix or suffix, the longest billed name its context contains.
+func (w *K8sWidget) k8wCostLines(c k8s.ClusterInfo, width int) []string {
+	ctx := strings.ToLower(c.Context)
+	cost, ok := w.clusterCosts[ctx]
+	if !ok {
+		best := ""
+		for name, cc := range w.clusterCosts {
+			longer := len(name) > len(best) || (len(name) == len(best) && name < best)
+			if longer && strings.Contains(ctx, name) {
+				cost, ok, best = cc, true, name
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	line := fmt.Sprintf("Est. cluster cost MTD: $%.2f (%s)", cost.accrued, cost.provider)
+	return []string{components.PadRight(components.Truncate(line, width), width)}
+}
+
 // ---------- Connection and node status ----------
 
 // k8wConnectionLine renders the cluster connection status indicator.
@@ -242,6 +398,32 @@ func k8wNodeHealthLine(nodes []k8s.NodeInfo, width int) string {
 	return components.PadRight(line, width)
 }
 
+// k8wHealthLines lists the reasons behind a warning or critical cluster
+// health level, one per line, in the level's color. Healthy clusters and
+// caches written before health evaluation produce no lines.
+func k8wHealthLines(c k8s.ClusterInfo, width int) []string {
+	var color string
+	switch c.Health {
+	case k8s.HealthCritical:
+		color = k8wErrorColor()
+	case k8s.HealthWarning:
+		color = "#EAB308"
+	default:
+		return nil
+	}
+
+	lines := []string{components.PadRight(
+		components.Color(color)+"Health: "+string(c.Health)+components.Reset(), width)}
+	for _, r := range c.HealthReasons {
+		line := "  " + components.Color(color) + "\u26a0" + components.Reset() + " " + r
+		if components.VisibleLen(line) > width {
+			line = components.TruncateWithTail(line, width, "...")
+		}
+		lines = append(lines, components.PadRight(line, width))
+	}
+	return lines
+}
+
 // k8wNodeCounts returns (ready, total) node counts for a cluster.
 func k8wNodeCounts(c k8s.ClusterInfo) (int, int) {
 	ready := 0
@@ -255,7 +437,8 @@ func k8wNodeCounts(c k8s.ClusterInfo) (int, int) {
 
 // ---------- Pod phase formatting ----------
 
-// k8wPodPhaseString formats pod counts by phase with colors.
+// k8wPodPhaseString formats pod counts by phase with colors. Failed pods
+// use the active theme's error color.
 func k8wPodPhaseString(pc k8s.PodCounts) string {
 	var parts []string
 	if pc.Running > 0 {
@@ -265,7 +448,7 @@ func k8wPodPhaseString(pc k8s.PodCounts) string {
 		parts = append(parts, components.Color("#EAB308")+fmt.Sprintf("%d pending", pc.Pending)+components.Reset())
 	}
 	if pc.Failed > 0 {
-		parts = append(parts, components.Color("#EF4444")+fmt.Sprintf("%d failed", pc.Failed)+components.Reset())
+		parts = append(parts, components.Color(k8wErrorColor())+fmt.Sprintf("%d failed", pc.Failed)+components.Reset())
 	}
 	if pc.Succeeded > 0 {
 		parts = append(parts, components.Dim(fmt.Sprintf("%d succeeded", pc.Succeeded)))
@@ -392,16 +575,55 @@ func k8wNodeResourceLines(node k8s.NodeInfo, width int) []string {
 	return lines
 }
 
+// k8wNamespaceUsageLines renders CPU and memory gauge lines for a
+// namespace's requests against its quota or the cluster's allocatable,
+// omitting resources without a limit.
+func k8wNamespaceUsageLines(ns k8s.NamespaceInfo, width int) []string {
+	var lines []string
+	for _, r := range []struct {
+		label  string
+		usage  k8s.ResourceUsage
+		format func(int64) string
+	}{
+		{"CPU", ns.CPU, k8wFormatCPU},
+		{"Mem", ns.Memory, k8wFormatMemory},
+	} {
+		if r.usage.Limit <= 0 {
+			continue
+		}
+		of := "allocatable"
+		if r.usage.Quota {
+			of = "quota"
+		}
+		label := fmt.Sprintf("  %s: %s / %s %s", r.label, r.format(r.usage.Requested), r.format(r.usage.Limit), of)
+
+		gaugeWidth := min(max(width-components.VisibleLen(label)-2, 5), 20)
+		g := components.NewGauge(components.GaugeStyle{
+			Width:             gaugeWidth,
+			ShowPercent:       true,
+			FilledColor:       "#4CAF50",
+			EmptyColor:        "#333333",
+			WarningThreshold:  0.7,
+			CriticalThreshold: 0.9,
+			WarningColor:      "#FF9800",
+			CriticalColor:     "#F44336",
+		})
+		line := label + " " + g.Render(float64(r.usage.Requested), float64(r.usage.Limit), gaugeWidth)
+		if components.VisibleLen(line) > width {
+			line = components.TruncateWithTail(line, width, "...")
+		}
+		lines = append(lines, components.PadRight(line, width))
+	}
+	return lines
+}
+
 // ---------- Multi-cluster tabs ----------
 
 // k8wRenderClusterTabs renders a tab bar showing all cluster contexts.
 func k8wRenderClusterTabs(clusters []k8s.ClusterInfo, selected int, width int) string {
 	var parts []string
 	for i, c := range clusters {
-		ctx := c.Context
-		if ctx == "" {
-			ctx = "default"
-		}
+		ctx := k8wTabLabel(c)
 		if i == selected {
 			parts = append(parts, components.Bold("["+ctx+"]"))
 		} else {
@@ -415,6 +637,300 @@ func k8wRenderClusterTabs(clusters []k8s.ClusterInfo, selected int, width int) s
 	return components.PadRight(line, width)
 }
 
+// k8wTabLabel is the name a cluster's tab shows.
+func k8wTabLabel(c k8s.ClusterInfo) string {
+	if c.Context == "" {
+		return "default"
+	}
+	return c.Context
+}
+
+// k8wClusterTabAt returns the index of the cluster whose tab
+// k8wRenderClusterTabs draws at column x, or -1 if none is. Each tab is
+// its label plus a bracket or space on either side, one space apart.
+func k8wClusterTabAt(clusters []k8s.ClusterInfo, x int) int {
+	start := 0
+	for i, c := range clusters {
+		end := start + components.VisibleLen(k8wTabLabel(c)) + 2
+		if x >= start && x < end {
+			return i
+		}
+		start = end + 1
+	}
+	return -1
+}
+
+// ---------- Drill-down ----------
+
+// k8wDrillIn descends one level, carrying the selected row into the next
+// level's context and clearing the table filter. Disconnected clusters and clusters without namespaces
+// cannot be entered further.
+func (w *K8sWidget) k8wDrillIn() {
+	if w.clusterStatus == nil || len(w.clusterStatus.Clusters) == 0 {
+		return
+	}
+	w.filter.query = ""
+	switch w.level {
+	case k8wLevelOverview:
+		w.level = k8wLevelClusters
+		w.selectedRow = w.selectedCluster
+	case k8wLevelClusters:
+		if !w.clusterStatus.Clusters[w.selectedRow].Connected {
+			return
+		}
+		w.selectedCluster = w.selectedRow
+		w.level = k8wLevelCluster
+		w.selectedRow = 0
+	case k8wLevelCluster:
+		if len(w.clusterStatus.Clusters[w.selectedCluster].Namespaces) == 0 {
+			return
+		}
+		w.selectedNamespace = w.selectedRow
+		w.level = k8wLevelNamespace
+		w.selectedRow = 0
+	}
+}
+
+// k8wDrillOut ascends one level, restoring the selection that led here
+// and clearing the table filter.
+func (w *K8sWidget) k8wDrillOut() {
+	w.filter.query = ""
+	switch w.level {
+	case k8wLevelClusters:
+		w.level = k8wLevelOverview
+		w.selectedRow = 0
+	case k8wLevelCluster:
+		w.level = k8wLevelClusters
+		w.selectedRow = w.selectedCluster
+	case k8wLevelNamespace:
+		w.level = k8wLevelCluster
+		w.selectedRow = w.selectedNamespace
+	}
+}
+
+// k8wClampSelection keeps the drill-down selection within the rows of the
+// current level after a key press or data update.
+func (w *K8sWidget) k8wClampSelection() {
+	if w.clusterStatus == nil {
+		return
+	}
+	clusters := w.clusterStatus.Clusters
+	rows := 0
+	switch w.level {
+	case k8wLevelClusters:
+		rows = len(clusters)
+	case k8wLevelCluster:
+		rows = len(clusters[w.selectedCluster].Namespaces)
+	case k8wLevelNamespace:
+		namespaces := clusters[w.selectedCluster].Namespaces
+		if w.selectedNamespace >= len(namespaces) {
+			w.level = k8wLevelCluster
+			w.selectedNamespace = 0
+			w.k8wClampSelection()
+			return
+		}
+		rows = len(namespaces[w.selectedNamespace].Deployments)
+	}
+	if w.selectedRow >= rows {
+		w.selectedRow = rows - 1
+	}
+	if w.selectedRow < 0 {
+		w.selectedRow = 0
+	}
+}
+
+// k8wRenderClusterList renders every cluster as a selectable table row.
+func (w *K8sWidget) k8wRenderClusterList(width, height int) string {
+	lines := []string{components.Bold("Clusters") + components.Dim("  \u2192 open  \u2190 back")}
+
+	rows := make([]components.Row, 0, len(w.clusterStatus.Clusters))
+	for _, c := range w.clusterStatus.Clusters {
+		ctx := c.Context
+		if ctx == "" {
+			ctx = "default"
+		}
+		dot := components.Color("#22C55E") + "\u25cf" + components.Reset()
+		if !c.Connected {
+			dot = components.Color("#EF4444") + "\u25cb" + components.Reset()
+		}
+		ready, total := k8wNodeCounts(c)
+		rows = append(rows, components.Row{
+			Cells: []string{
+				dot,
+				ctx,
+				fmt.Sprintf("%d/%d", ready, total),
+				fmt.Sprintf("%d/%d", c.RunningPods, c.TotalPods),
+				k8wFailedCell(c.FailedPods),
+			},
+			ID: ctx,
+		})
+	}
+
+	filter, sel, pos := w.filter.apply(rows, w.selectedRow)
+	w.selectedRow = sel
+	table, dt := k8wRenderTable([]components.Column{
+		{Title: "St", Sizing: components.SizingFixed(2), Align: components.ColAlignCenter},
+		{Title: "Context", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 6},
+		{Title: "Nodes", Sizing: components.SizingFixed(7), Align: components.ColAlignRight, Priority: 1},
+		{Title: "Pods", Sizing: components.SizingFixed(9), Align: components.ColAlignRight},
+		{Title: "Failed", Sizing: components.SizingFixed(6), Align: components.ColAlignRight},
+	}, rows, filter, pos, width, height-len(lines))
+	w.mouse.draw(dt, len(lines))
+	lines = append(lines, table...)
+	return k8wFitToArea(lines, width, height, 0)
+}
+
+// k8wRenderClusterDetail renders the selected cluster's nodes followed by
+// its namespaces; the namespace table carries the selection.
+func (w *K8sWidget) k8wRenderClusterDetail(width, height int) string {
+	c := w.clusterStatus.Clusters[w.selectedCluster]
+	lines := append([]string{k8wConnectionLine(c, width)}, w.k8wCostLines(c, width)...)
+
+	nodeRows := make([]components.Row, 0, len(c.Nodes))
+	for _, n := range c.Nodes {
+		ready := components.Color("#22C55E") + "Ready" + components.Reset()
+		if !n.Ready {
+			ready = components.Color(k8wErrorColor()) + "NotReady" + components.Reset()
+		}
+		nodeRows = append(nodeRows, components.Row{
+			Cells: []string{n.Name, ready, strconv.Itoa(n.PodCount), strings.Join(n.Conditions, ",")},
+			ID:    n.Name,
+		})
+	}
+	// Nodes get at most half the remaining space (header + separator + rows).
+	nodeHeight := len(nodeRows) + 2
+	if half := (height - len(lines)) / 2; nodeHeight > half {
+		nodeHeight = half
+	}
+	if nodeHeight > 0 {
+		nodes, _ := k8wRenderTable([]components.Column{
+			{Title: "Node", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 6},
+			{Title: "Status", Sizing: components.SizingFixed(8), Align: components.ColAlignLeft},
+			{Title: "Pods", Sizing: components.SizingFixed(5), Align: components.ColAlignRight, Priority: 2},
+			{Title: "Conditions", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 10, Priority: 1},
+		}, nodeRows, nil, -1, width, nodeHeight)
+		lines = append(lines, nodes...)
+	}
+
+	nsRows := make([]components.Row, 0, len(c.Namespaces))
+	for _, ns := range c.Namespaces {
+		nsRows = append(nsRows, components.Row{
+			Cells: []string{
+				ns.Name,
+				fmt.Sprintf("%d/%d", ns.PodCounts.Running, ns.PodCounts.Total),
+				strconv.Itoa(ns.PodCounts.Pending),
+				k8wFailedCell(ns.PodCounts.Failed),
+				strconv.Itoa(len(ns.Deployments)),
+			},
+			ID: ns.Name,
+		})
+	}
+	filter, sel, pos := w.filter.apply(nsRows, w.selectedRow)
+	w.selectedRow = sel
+	table, dt := k8wRenderTable([]components.Column{
+		{Title: "Namespace", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 6},
+		{Title: "Running", Sizing: components.SizingFixed(9), Align: components.ColAlignRight},
+		{Title: "Pending", Sizing: components.SizingFixed(7), Align: components.ColAlignRight, Priority: 2},
+		{Title: "Failed", Sizing: components.SizingFixed(6), Align: components.ColAlignRight},
+		{Title: "Deploys", Sizing: components.SizingFixed(7), Align: components.ColAlignRight, Priority: 1},
+	}, nsRows, filter, pos, width, height-len(lines))
+	w.mouse.draw(dt, len(lines))
+	lines = append(lines, table...)
+
+	return k8wFitToArea(lines, width, height, 0)
+}
+
+// k8wRenderNamespaceDetail renders the resource requests and deployments
+// of the selected namespace, with ready/desired replica counts.
+func (w *K8sWidget) k8wRenderNamespaceDetail(width, height int) string {
+	c := w.clusterStatus.Clusters[w.selectedCluster]
+	ns := c.Namespaces[w.selectedNamespace]
+
+	ctx := c.Context
+	if ctx == "" {
+		ctx = "default"
+	}
+	lines := []string{
+		components.Bold(ctx+" / "+ns.Name) + "  " + k8wPodPhaseString(ns.PodCounts),
+	}
+	lines = append(lines, k8wNamespaceUsageLines(ns, width)...)
+
+	rows := make([]components.Row, 0, len(ns.Deployments))
+	for _, d := range ns.Deployments {
+		rows = append(rows, components.Row{
+			Cells: []string{
+				d.Name,
+				fmt.Sprintf("%d/%d", d.ReadyReplicas, d.Replicas),
+				strconv.Itoa(int(d.UpdatedReplicas)),
+				strconv.Itoa(int(d.AvailableReplicas)),
+				k8wDeploymentStatus(d),
+			},
+			ID: d.Name,
+		})
+	}
+	filter, sel, pos := w.filter.apply(rows, w.selectedRow)
+	w.selectedRow = sel
+	table, dt := k8wRenderTable([]components.Column{
+		{Title: "Deployment", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 6},
+		{Title: "Ready", Sizing: components.SizingFixed(7), Align: components.ColAlignRight},
+		{Title: "Updated", Sizing: components.SizingFixed(7), Align: components.ColAlignRight, Priority: 1},
+		{Title: "Avail", Sizing: components.SizingFixed(5), Align: components.ColAlignRight, Priority: 2},
+		{Title: "Status", Sizing: components.SizingFixed(11), Align: components.ColAlignLeft},
+	}, rows, filter, pos, width, height-len(lines))
+	w.mouse.draw(dt, len(lines))
+	lines = append(lines, table...)
+
+	return k8wFitToArea(lines, width, height, 0)
+}
+
+// k8wRenderTable renders the rows passing filter (nil for all) in a
+// DataTable with the selected-th of them selected (-1 for a non-selectable
+// table), scrolling so the selection stays visible. Selectable tables get
+// a scrollbar. It returns the table's lines and the table, for
+// hit-testing mouse events.
+func k8wRenderTable(cols []components.Column, rows []components.Row, filter func(components.Row) bool, selected, width, height int) ([]string, *components.DataTable) {
+	if height <= 0 {
+		return nil, nil
+	}
+	dt := components.NewDataTable(components.DataTableConfig{
+		Columns: cols,
+		HeaderStyle: components.HeaderStyleConfig{
+			Bold:    true,
+			FgColor: ColorAccent,
+		},
+		ShowHeader: true,
+		ShowBorder: true,
+		Selectable: selected >= 0,
+		Scrollbar:  selected >= 0,
+	})
+	dt.SetRows(rows)
+	dt.SetFilter(filter)
+	for i := 0; i <= selected && i < len(rows); i++ {
+		dt.SelectNext()
+	}
+	// Reserve the header, separator, and both scroll indicators.
+	if over := selected - (height - 4) + 1; over > 0 {
+		dt.ScrollDown(over)
+	}
+	return strings.Split(dt.Render(width, height), "\n"), dt
+}
+
+// k8wErrorColor returns the active theme's error color.
+func k8wErrorColor() string {
+	if c := theme.Current.StatusError; c != "" {
+		return c
+	}
+	return ColorError
+}
+
+// k8wFailedCell formats a failed pod count, highlighted when non-zero.
+func k8wFailedCell(n int) string {
+	if n == 0 {
+		return components.Dim("0")
+	}
+	return components.Color(k8wErrorColor()) + strconv.Itoa(n) + components.Reset()
+}
+
 // ---------- Resource quantity parsing ----------
 
 // k8wParseMilliCPU parses a CPU quantity string and returns millicores.
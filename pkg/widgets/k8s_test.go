@@ -8,7 +8,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/billing"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/k8s"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
 )
 
 // ---------- Helper constructors ----------
@@ -663,3 +665,310 @@ func TestK8sWidget_DefaultContextName(t *testing.T) {
 		t.Errorf("empty context should display as 'default', got:\n%s", stripped)
 	}
 }
+
+// drillDownStatus returns a disconnected cluster followed by a connected
+// cluster with two nodes and two namespaces.
+func drillDownStatus() *k8s.ClusterStatus {
+	nodeA := readyNode("node-a", "4", "1000m", "8Gi", "2Gi")
+	nodeA.PodCount = 7
+	nodeB := notReadyNode("node-b")
+	nodeB.Conditions = []string{"MemoryPressure"}
+	return multiClusterStatus(
+		disconnectedCluster("staging", "timeout"),
+		connectedCluster("prod", 9, 0, 2,
+			[]k8s.NodeInfo{nodeA, nodeB},
+			[]k8s.NamespaceInfo{
+				{
+					Name:        "default",
+					PodCounts:   k8s.PodCounts{Total: 4, Running: 4},
+					Deployments: []k8s.DeploymentInfo{healthyDeployment("web", 3)},
+				},
+				{
+					Name:      "jobs",
+					PodCounts: k8s.PodCounts{Total: 7, Running: 5, Failed: 2},
+					Deployments: []k8s.DeploymentInfo{
+						rollingDeployment("api", 4, 2, 3),
+						healthyDeployment("worker", 2),
+					},
+					CPU:    k8s.ResourceUsage{Requested: 1800, Limit: 2000, Percent: 90, Quota: true},
+					Memory: k8s.ResourceUsage{Requested: 1 << 30, Limit: 8 << 30, Percent: 12.5},
+				},
+			}),
+	)
+}
+
+func TestK8sWidget_DrillDown(t *testing.T) {
+	w := NewK8sWidget()
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: drillDownStatus()})
+
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	// Overview -> cluster list.
+	w.HandleKey(right)
+	view := stripANSI(w.View(60, 10))
+	if w.level != k8wLevelClusters || !strings.Contains(view, "staging") || !strings.Contains(view, "prod") {
+		t.Fatalf("level %d, want cluster list with both clusters, got:\n%s", w.level, view)
+	}
+
+	// The disconnected cluster cannot be entered.
+	w.HandleKey(right)
+	if w.level != k8wLevelClusters {
+		t.Errorf("level = %d after entering a disconnected cluster, want cluster list", w.level)
+	}
+
+	// Cluster list -> prod nodes and namespaces.
+	w.HandleKey(down)
+	w.HandleKey(right)
+	view = stripANSI(w.View(70, 14))
+	if w.level != k8wLevelCluster || w.selectedCluster != 1 {
+		t.Fatalf("level/cluster = %d/%d, want prod detail", w.level, w.selectedCluster)
+	}
+	for _, want := range []string{"node-a", "NotReady", "MemoryPressure", "default", "jobs"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("cluster view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Cluster -> jobs namespace deployments.
+	w.HandleKey(down)
+	w.HandleKey(down) // clamped at the last namespace
+	w.HandleKey(right)
+	view = stripANSI(w.View(70, 12))
+	if w.level != k8wLevelNamespace || w.selectedNamespace != 1 {
+		t.Fatalf("level/namespace = %d/%d, want jobs detail", w.level, w.selectedNamespace)
+	}
+	for _, want := range []string{"prod / jobs", "CPU: 1.8 cores / 2.0 cores quota", "90%", "Mem: 1.0 GB / 8.0 GB allocatable", "api", "2/4", "worker", "2 failed"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("namespace view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Back out restores the previous selections.
+	w.HandleKey(left)
+	if w.level != k8wLevelCluster || w.selectedRow != 1 {
+		t.Errorf("level/row = %d/%d after left, want cluster with jobs selected", w.level, w.selectedRow)
+	}
+	w.HandleKey(left)
+	w.HandleKey(left)
+	if w.level != k8wLevelOverview {
+		t.Errorf("level = %d, want overview", w.level)
+	}
+}
+
+func TestK8sWidget_DrillDownFailedPodsHighlighted(t *testing.T) {
+	w := NewK8sWidget()
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: drillDownStatus()})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+
+	view := w.View(60, 10)
+	if !strings.Contains(view, components.Color(k8wErrorColor())+"2") {
+		t.Errorf("failed pod count should use the theme error color, got:\n%q", view)
+	}
+}
+
+func TestK8sWidget_DrillDownViewDimensions(t *testing.T) {
+	w := NewK8sWidget()
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: drillDownStatus()})
+
+	for _, keys := range [][]tea.KeyType{
+		{tea.KeyRight},
+		{tea.KeyRight, tea.KeyDown, tea.KeyRight},
+		{tea.KeyRight, tea.KeyDown, tea.KeyRight, tea.KeyRight},
+	} {
+		w.level = k8wLevelOverview
+		w.selectedRow = 0
+		for _, k := range keys {
+			w.HandleKey(tea.KeyMsg{Type: k})
+		}
+		lines := strings.Split(w.View(50, 8), "\n")
+		if len(lines) != 8 {
+			t.Errorf("level %d: %d lines, want 8", w.level, len(lines))
+		}
+		for i, l := range lines {
+			if got := components.VisibleLen(l); got != 50 {
+				t.Errorf("level %d line %d: width %d, want 50", w.level, i, got)
+			}
+		}
+	}
+}
+
+func TestK8sWidget_ExpandedHealthReasons(t *testing.T) {
+	c := connectedCluster("prod", 3, 0, 0, []k8s.NodeInfo{readyNode("node-a", "4", "1000m", "8Gi", "2Gi")}, nil)
+	c.Health = k8s.HealthWarning
+	c.HealthReasons = []string{"node node-a cordoned", "deployment default/web 1/2 ready"}
+
+	w := NewK8sWidget()
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: singleClusterStatus(c)})
+	w.expanded = true
+
+	view := stripANSI(w.View(60, 10))
+	for _, want := range []string{"Health: warning", "node node-a cordoned", "deployment default/web 1/2 ready"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expanded view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	c.Health = k8s.HealthOK
+	c.HealthReasons = nil
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: singleClusterStatus(c)})
+	if view := stripANSI(w.View(60, 10)); strings.Contains(view, "Health:") {
+		t.Errorf("healthy cluster should not show a health line, got:\n%s", view)
+	}
+}
+
+func TestK8sWidget_ClusterCostFromBilling(t *testing.T) {
+	report := &billing.BillingReport{Providers: []billing.ProviderBilling{{
+		Name: "civo",
+		Resources: []billing.ResourceCost{
+			{Name: "k3s-prod", Type: "kubernetes", MonthlyCost: 36, Accrued: 20.20},
+			{Name: "k3s-prod-2", Type: "kubernetes", MonthlyCost: 12, Accrued: 0.40},
+			{Name: "web-01", Type: "instance", MonthlyCost: 10, Accrued: 5},
+		},
+	}}}
+	tests := []struct {
+		context string
+		want    string // "" means no cost line
+	}{
+		{"k3s-prod", "Est. cluster cost MTD: $20.20 (civo)"},
+		{"civo-K3S-PROD-2", "Est. cluster cost MTD: $0.40 (civo)"},
+		{"homelab", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			w := NewK8sWidget()
+			w.Update(app.DataUpdateEvent{Source: "k8s", Data: singleClusterStatus(connectedCluster(tt.context, 3, 0, 0, nil, nil))})
+			w.Update(app.DataUpdateEvent{Source: "billing", Data: report})
+			w.expanded = true
+
+			view := stripANSI(w.View(60, 10))
+			if tt.want == "" {
+				if strings.Contains(view, "cluster cost") {
+					t.Errorf("unbilled cluster should have no cost line, got:\n%s", view)
+				}
+				return
+			}
+			if !strings.Contains(view, tt.want) {
+				t.Errorf("expanded view should contain %q, got:\n%s", tt.want, view)
+			}
+			w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+			w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+			if view := stripANSI(w.View(60, 10)); !strings.Contains(view, tt.want) {
+				t.Errorf("cluster drill-down should contain %q, got:\n%s", tt.want, view)
+			}
+		})
+	}
+}
+
+func TestK8sWidget_Mouse(t *testing.T) {
+	w := NewK8sWidget()
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: multiClusterStatus(
+		connectedCluster("prod", 10, 0, 0, nil, nil),
+		connectedCluster("staging", 5, 1, 0, nil, nil),
+		disconnectedCluster("dev", "timeout"),
+	)})
+	press := func(x, y int) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	}
+
+	// The tab bar reads "[prod]  staging   dev ".
+	w.View(60, 10)
+	w.HandleMouse(press(10, 0))
+	if w.selectedCluster != 1 {
+		t.Errorf("selectedCluster = %d after clicking the staging tab, want 1", w.selectedCluster)
+	}
+	w.View(60, 10)
+	w.HandleMouse(press(6, 0)) // the gap between tabs
+	if w.selectedCluster != 1 {
+		t.Errorf("selectedCluster = %d after clicking between tabs, want 1", w.selectedCluster)
+	}
+	w.HandleMouse(press(19, 0))
+	if w.selectedCluster != 2 {
+		t.Errorf("selectedCluster = %d after clicking the dev tab, want 2", w.selectedCluster)
+	}
+
+	// Scrolled down, the tab bar is off screen and line 0 is not a tab.
+	w.HandleMouse(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	w.View(60, 10)
+	w.HandleMouse(press(2, 0))
+	if w.selectedCluster != 2 || w.scrollOffset != 1 {
+		t.Errorf("cluster/scroll = %d/%d after clicking line 0 scrolled, want 2/1", w.selectedCluster, w.scrollOffset)
+	}
+
+	// In the cluster list, rows start below the title, header, and
+	// separator.
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+	w.View(60, 10)
+	w.HandleMouse(press(20, 4))
+	if w.level != k8wLevelClusters || w.selectedRow != 1 {
+		t.Errorf("level/row = %d/%d after clicking staging, want clusters/1", w.level, w.selectedRow)
+	}
+	w.HandleMouse(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	if w.selectedRow != 2 {
+		t.Errorf("selectedRow = %d after wheel down, want 2", w.selectedRow)
+	}
+}
+
+func TestK8sWidget_TableFilter(t *testing.T) {
+	w := NewK8sWidget()
+	w.Update(app.DataUpdateEvent{Source: "k8s", Data: multiClusterStatus(
+		connectedCluster("prod-east", 10, 0, 0, nil, nil),
+		connectedCluster("staging", 5, 1, 0, nil, nil),
+		connectedCluster("prod-west", 8, 0, 0, nil, nil),
+		disconnectedCluster("dev", "timeout"),
+	)})
+	var _ app.TableFilterer = w
+
+	w.View(60, 10)
+	if _, _, _, ok := w.TableFilter(); ok {
+		t.Error("TableFilter ok in the overview, want no table")
+	}
+
+	// Select prod-west, then filter: the selection survives.
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyDown})
+	w.View(60, 10)
+	w.FilterTable("PROD")
+	view := stripANSI(w.View(60, 10))
+	if strings.Contains(view, "staging") || !strings.Contains(view, "prod-east") || !strings.Contains(view, "prod-west") {
+		t.Errorf("filtered view should list only the prod clusters, got:\n%s", view)
+	}
+	if w.selectedRow != 2 {
+		t.Errorf("selectedRow = %d after filtering, want prod-west (2)", w.selectedRow)
+	}
+	if q, n, total, ok := w.TableFilter(); q != "PROD" || n != 2 || total != 4 || !ok {
+		t.Errorf("TableFilter() = %q, %d, %d, %v; want PROD, 2, 4, true", q, n, total, ok)
+	}
+
+	// Up steps over the hidden staging row.
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyUp})
+	if w.selectedRow != 0 {
+		t.Errorf("selectedRow = %d after up, want prod-east (0)", w.selectedRow)
+	}
+
+	// A filter hiding the selection moves it to the first match.
+	w.FilterTable("dev")
+	w.View(60, 10)
+	if w.selectedRow != 3 {
+		t.Errorf("selectedRow = %d filtered to dev, want 3", w.selectedRow)
+	}
+
+	// A click selects the row shown, not the row at that index.
+	w.FilterTable("prod")
+	w.View(60, 10)
+	w.HandleMouse(tea.MouseMsg{X: 20, Y: 4, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if w.selectedRow != 2 {
+		t.Errorf("selectedRow = %d after clicking the second match, want prod-west (2)", w.selectedRow)
+	}
+
+	// Drilling in opens prod-west unfiltered.
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRight})
+	if w.level != k8wLevelCluster || w.selectedCluster != 2 {
+		t.Errorf("level/cluster = %d/%d, want prod-west detail", w.level, w.selectedCluster)
+	}
+	if q, _, _, _ := w.TableFilter(); q != "" {
+		t.Errorf("query = %q after drilling in, want it cleared", q)
+	}
+}
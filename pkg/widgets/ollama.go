@@ -0,0 +1,162 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/ollama"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// OllamaWidget displays the models a local Ollama server has loaded, with
+// their size, quantization, memory use, and time until they are unloaded.
+type OllamaWidget struct {
+	status *ollama.Status
+}
+
+// NewOllamaWidget creates a new OllamaWidget with default state.
+func NewOllamaWidget() *OllamaWidget {
+	return &OllamaWidget{}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *OllamaWidget) ID() string {
+	return "ollama"
+}
+
+// Title returns the human-readable display name.
+func (w *OllamaWidget) Title() string {
+	return "Ollama"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *OllamaWidget) MinSize() (int, int) {
+	return 30, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "ollama".
+func (w *OllamaWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "ollama" || msg.Err != nil {
+			return nil
+		}
+		if st, ok := msg.Data.(*ollama.Status); ok {
+			w.status = st
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. The table
+// has no interaction.
+func (w *OllamaWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *OllamaWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	var lines []string
+	switch {
+	case w.status == nil:
+		lines = []string{components.Dim("No data")}
+	case !w.status.Available:
+		lines = []string{components.Dim("Ollama inactive")}
+	default:
+		lines = []string{olHeader(w.status)}
+		if len(w.status.Models) == 0 {
+			lines = append(lines, components.Dim("No models loaded"))
+			break
+		}
+		dt := components.NewDataTable(components.DataTableConfig{
+			Columns: []components.Column{
+				{Title: "Model", Sizing: components.SizingFill(), Align: components.ColAlignLeft, MinWidth: 10},
+				{Title: "Params", Sizing: components.SizingFixed(6), Align: components.ColAlignRight, Priority: 1},
+				{Title: "Quant", Sizing: components.SizingFixed(7), Align: components.ColAlignLeft, Priority: 1},
+				{Title: "VRAM", Sizing: components.SizingFixed(8), Align: components.ColAlignRight},
+				{Title: "RAM", Sizing: components.SizingFixed(8), Align: components.ColAlignRight},
+				{Title: "Unload", Sizing: components.SizingFixed(6), Align: components.ColAlignRight, Priority: 2},
+			},
+			HeaderStyle: components.HeaderStyleConfig{
+				Bold:    true,
+				FgColor: ColorAccent,
+			},
+			ShowHeader: true,
+		})
+		now := time.Now()
+		rows := make([]components.Row, 0, len(w.status.Models))
+		for _, m := range w.status.Models {
+			rows = append(rows, components.Row{
+				Cells: olCells(m, now),
+				ID:    m.Name,
+			})
+		}
+		dt.SetRows(rows)
+		lines = append(lines, strings.Split(dt.Render(width, height-1), "\n")...)
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// olHeader summarizes the server: its version, total memory in use, and
+// requests served when it reports them.
+// Example: "v0.6.2  9.0 GB VRAM  2.0 GB RAM  128 requests"
+func olHeader(s *ollama.Status) string {
+	var parts []string
+	if s.Version != "" {
+		parts = append(parts, "v"+s.Version)
+	}
+	parts = append(parts, smFormatBytes(s.VRAMBytes)+" VRAM")
+	if s.RAMBytes > 0 {
+		parts = append(parts, smFormatBytes(s.RAMBytes)+" RAM")
+	}
+	if s.Requests != nil {
+		parts = append(parts, fmt.Sprintf("%d requests", *s.Requests))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// olCells returns the table cells for one model. A model partly in system
+// RAM runs slower, so its RAM is shown in yellow.
+func olCells(m ollama.Model, now time.Time) []string {
+	ram := "-"
+	if r := m.RAMBytes(); r > 0 {
+		ram = components.Color(smColorYellow) + smFormatBytes(r) + components.Reset()
+	}
+	// A model kept loaded indefinitely expires centuries from now.
+	unload := "-"
+	switch left := m.ExpiresAt.Sub(now); {
+	case m.ExpiresAt.IsZero():
+	case left > 24*time.Hour:
+		unload = "never"
+	default:
+		unload = fmt.Sprintf("%dm", int(max(left, 0).Minutes()))
+	}
+	return []string{m.Name, olOrDash(m.ParameterSize), olOrDash(m.Quantization), smFormatBytes(m.VRAMBytes), ram, unload}
+}
+
+// olOrDash returns s, or "-" when it is empty.
+func olOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/ollama"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// olBuildTestStatus returns a server with one model on the GPU and one
+// split between VRAM and RAM and kept loaded indefinitely.
+func olBuildTestStatus() *ollama.Status {
+	requests := int64(128)
+	return &ollama.Status{
+		Available: true,
+		Version:   "0.6.2",
+		Models: []ollama.Model{
+			{Name: "llama3.1:8b", ParameterSize: "8.0B", Quantization: "Q4_0", SizeBytes: 5 << 30, VRAMBytes: 5 << 30,
+				ExpiresAt: time.Now().Add(4*time.Minute + 30*time.Second)},
+			{Name: "qwen2.5:32b", ParameterSize: "32.8B", Quantization: "Q4_K_M", SizeBytes: 20 << 30, VRAMBytes: 16 << 30,
+				ExpiresAt: time.Now().AddDate(290, 0, 0)},
+		},
+		VRAMBytes: 21 << 30,
+		RAMBytes:  4 << 30,
+		Requests:  &requests,
+	}
+}
+
+func TestOllamaWidget_NoData(t *testing.T) {
+	w := NewOllamaWidget()
+	if view := w.View(30, 3); !strings.Contains(view, "No data") {
+		t.Errorf("view should contain 'No data', got:\n%s", view)
+	}
+	w.Update(app.DataUpdateEvent{Source: "ollama", Data: &ollama.Status{Error: "ollama not running"}})
+	if view := w.View(30, 3); !strings.Contains(view, "Ollama inactive") {
+		t.Errorf("view should say Ollama is inactive, got:\n%s", view)
+	}
+	w.Update(app.DataUpdateEvent{Source: "ollama", Data: &ollama.Status{Available: true}})
+	if view := w.View(30, 3); !strings.Contains(view, "No models loaded") {
+		t.Errorf("view should say no models are loaded, got:\n%s", view)
+	}
+}
+
+func TestOllamaWidget_View(t *testing.T) {
+	w := NewOllamaWidget()
+	w.Update(app.DataUpdateEvent{Source: "gpu", Data: olBuildTestStatus()})
+	if w.status != nil {
+		t.Fatal("widget should ignore other sources")
+	}
+	w.Update(app.DataUpdateEvent{Source: "ollama", Data: olBuildTestStatus()})
+
+	view := w.View(70, 5)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("view has %d lines, want 5", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 70 {
+			t.Errorf("line %d width = %d, want 70", i, got)
+		}
+	}
+	for _, want := range []string{"v0.6.2", "21.0 GB VRAM", "128 requests", "llama3.1:8b", "Q4_K_M", "4m", "never", "4.0 GB"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if !strings.Contains(view, components.Color(smColorYellow)+"4.0 GB") {
+		t.Error("a model partly in RAM should show its RAM in yellow")
+	}
+}
@@ -0,0 +1,141 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// Color constants for shell activity widget elements.
+const (
+	saColorActive = "#10B981"
+	saColorFailed = "#EF4444"
+)
+
+// ShellActivityWidget displays today's commands across the shells running
+// the integration: the totals, then a line per shell, most recently used
+// first.
+type ShellActivityWidget struct {
+	activity     *shellactivity.Activity
+	scrollOffset int
+}
+
+// NewShellActivityWidget creates a new ShellActivityWidget with default
+// state.
+func NewShellActivityWidget() *ShellActivityWidget {
+	return &ShellActivityWidget{}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *ShellActivityWidget) ID() string {
+	return "shell"
+}
+
+// Title returns the human-readable display name.
+func (w *ShellActivityWidget) Title() string {
+	return "Shell Activity"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *ShellActivityWidget) MinSize() (int, int) {
+	return 25, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "shell".
+func (w *ShellActivityWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "shell" || msg.Err != nil {
+			return nil
+		}
+		if a, ok := msg.Data.(*shellactivity.Activity); ok {
+			w.activity = a
+			if w.scrollOffset >= len(a.Sessions) {
+				w.scrollOffset = 0
+			}
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. Up/down (or
+// k/j) scroll the shell list.
+func (w *ShellActivityWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	switch key.String() {
+	case "up", "k":
+		if w.scrollOffset > 0 {
+			w.scrollOffset--
+		}
+	case "down", "j":
+		if w.activity != nil && w.scrollOffset < len(w.activity.Sessions)-1 {
+			w.scrollOffset++
+		}
+	}
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *ShellActivityWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, height)
+	switch {
+	case w.activity == nil:
+		lines = append(lines, components.Dim("No data"))
+	case len(w.activity.Sessions) == 0:
+		lines = append(lines, components.Dim("No shells reporting"))
+	default:
+		a := w.activity
+		header := fmt.Sprintf("%d today", a.Commands)
+		if a.Failures > 0 {
+			header += components.Color(saColorFailed) + fmt.Sprintf("  %.0f%% failed", a.FailureRate()*100) + components.Reset()
+		}
+		if a.Longest != nil {
+			header += "  longest " + a.Longest.Label()
+		}
+		lines = append(lines, header)
+		for i := w.scrollOffset; i < len(a.Sessions) && len(lines) < height; i++ {
+			lines = append(lines, saSessionLine(a.Sessions[i], width))
+		}
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// saSessionLine renders one shell: a dot that is filled while it runs,
+// the shell and PID, its command and failure counts, and its longest
+// command right-aligned when it fits.
+func saSessionLine(s shellactivity.Session, width int) string {
+	dot := components.Dim("○")
+	if s.Active {
+		dot = components.Color(saColorActive) + "●" + components.Reset()
+	}
+	line := fmt.Sprintf("%s %s %d  %d cmds", dot, s.Shell, s.PID, s.Commands)
+	if s.Failures > 0 {
+		line += components.Color(saColorFailed) + fmt.Sprintf(" %d failed", s.Failures) + components.Reset()
+	}
+	if s.Longest != nil {
+		longest := s.Longest.Label()
+		if gap := width - components.VisibleLen(line) - components.VisibleLen(longest); gap > 1 {
+			line += strings.Repeat(" ", gap) + components.Dim(longest)
+		}
+	}
+	return line
+}
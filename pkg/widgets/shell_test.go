@@ -0,0 +1,86 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/shellactivity"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// saBuildTestActivity returns a running zsh with failures and an exited
+// bash.
+func saBuildTestActivity() *shellactivity.Activity {
+	longest := &shellactivity.Command{Name: "make", Exit: 2, Duration: 4*time.Minute + 12*time.Second}
+	return &shellactivity.Activity{
+		Sessions: []shellactivity.Session{
+			{Shell: "zsh", PID: 4242, Active: true, Commands: 30, Failures: 3, Longest: longest},
+			{Shell: "bash", PID: 777, Commands: 10, Longest: &shellactivity.Command{Duration: 12 * time.Second}},
+		},
+		Commands: 40,
+		Failures: 3,
+		Longest:  longest,
+	}
+}
+
+func TestShellActivityWidget_NoData(t *testing.T) {
+	w := NewShellActivityWidget()
+	view := w.View(30, 3)
+	if !strings.Contains(view, "No data") {
+		t.Errorf("view should contain 'No data', got:\n%s", view)
+	}
+	w.Update(app.DataUpdateEvent{Source: "shell", Data: &shellactivity.Activity{}})
+	if view := w.View(30, 3); !strings.Contains(view, "No shells reporting") {
+		t.Errorf("view should say no shells report, got:\n%s", view)
+	}
+}
+
+func TestShellActivityWidget_View(t *testing.T) {
+	w := NewShellActivityWidget()
+	w.Update(app.DataUpdateEvent{Source: "docker", Data: saBuildTestActivity()})
+	if w.activity != nil {
+		t.Fatal("widget should ignore other sources")
+	}
+	w.Update(app.DataUpdateEvent{Source: "shell", Data: saBuildTestActivity()})
+
+	view := w.View(50, 4)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("view has %d lines, want 4", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 50 {
+			t.Errorf("line %d width = %d, want 50", i, got)
+		}
+	}
+	if header := stripANSI(lines[0]); !strings.Contains(header, "40 today  8% failed  longest make 4m12s") {
+		t.Errorf("header = %q, want totals", header)
+	}
+	if line := stripANSI(lines[1]); !strings.Contains(line, "● zsh 4242  30 cmds 3 failed") || !strings.HasSuffix(strings.TrimSpace(line), "make 4m12s") {
+		t.Errorf("first shell = %q, want running zsh with its longest command", line)
+	}
+	if line := stripANSI(lines[2]); !strings.Contains(line, "○ bash 777  10 cmds") || strings.Contains(line, "failed") || !strings.HasSuffix(strings.TrimSpace(line), "12s") {
+		t.Errorf("second shell = %q, want exited bash without failures", line)
+	}
+}
+
+func TestShellActivityWidget_Scroll(t *testing.T) {
+	w := NewShellActivityWidget()
+	w.Update(app.DataUpdateEvent{Source: "shell", Data: saBuildTestActivity()})
+
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if w.scrollOffset != 1 {
+		t.Errorf("scrollOffset = %d after j j, want clamped at 1", w.scrollOffset)
+	}
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	if w.scrollOffset != 0 {
+		t.Errorf("scrollOffset = %d after k, want 0", w.scrollOffset)
+	}
+}
+
+var _ app.Widget = (*ShellActivityWidget)(nil)
@@ -11,6 +11,7 @@ import (
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/data"
 )
 
 // System metrics color constants.
@@ -34,16 +35,41 @@ const (
 
 	// Maximum history length for sparkline rolling buffers.
 	smMaxHistory = 60
+
+	// smHistoryWindow is how far back the daemon history graphs reach,
+	// counted from the newest sample.
+	smHistoryWindow = time.Hour
 )
 
+// smHistorySeries are the daemon history graphs, in display order. Spiky
+// series keep each cell's peak; the rest its mean.
+var smHistorySeries = []struct {
+	name    string
+	label   string
+	agg     data.Aggregation
+	percent bool
+	format  func(float64) string
+}{
+	{sysmetrics.HistoryCPU, "CPU", data.AggregateMax, true, smFormatPercent},
+	{sysmetrics.HistoryMemory, "Mem", data.AggregateAvg, true, smFormatPercent},
+	{sysmetrics.HistoryLoad, "Load", data.AggregateAvg, false, func(v float64) string { return fmt.Sprintf("%.2f", v) }},
+	{sysmetrics.HistoryNetRx, "Rx", data.AggregateMax, false, smFormatRate},
+	{sysmetrics.HistoryNetTx, "Tx", data.AggregateMax, false, smFormatRate},
+}
+
 // SysMetricsWidget displays system metrics including CPU, memory, disk,
 // load averages, and uptime. It supports compact and expanded display modes.
+// With the daemon's sysmetrics history it also graphs the last hour.
 type SysMetricsWidget struct {
 	metrics     *sysmetrics.Metrics
 	expanded    bool
 	perCore     bool
 	cpuHistory  []float64
 	loadHistory []float64
+
+	// history is the daemon's sysmetrics history, from DataUpdateEvents
+	// with Source "sysmetrics-history".
+	history *data.Ring
 }
 
 // NewSysMetricsWidget creates a new SysMetricsWidget in compact mode.
@@ -67,10 +93,17 @@ func (w *SysMetricsWidget) MinSize() (int, int) {
 }
 
 // Update handles messages directed at this widget. It processes
-// DataUpdateEvent messages with Source "sysmetrics".
+// DataUpdateEvent messages with Source "sysmetrics" and
+// "sysmetrics-history".
 func (w *SysMetricsWidget) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case app.DataUpdateEvent:
+		if msg.Source == "sysmetrics-history" {
+			if r, ok := msg.Data.(*data.Ring); ok && msg.Err == nil {
+				w.history = r
+			}
+			return nil
+		}
 		if msg.Source != "sysmetrics" {
 			return nil
 		}
@@ -174,7 +207,7 @@ func (w *SysMetricsWidget) smViewCompact(width int) []string {
 	uptimeLine := "Uptime: " + smFormatUptime(m.Uptime)
 	lines = append(lines, smTruncLine(uptimeLine, width))
 
-	return lines
+	return append(lines, w.smHistoryLines(width)...)
 }
 
 // smViewExpanded renders the expanded view: per-core CPU sparklines (or
@@ -278,6 +311,37 @@ func (w *SysMetricsWidget) smViewExpanded(width int) []string {
 	uptimeLine := "Uptime: " + smFormatUptime(m.Uptime)
 	lines = append(lines, smTruncLine(uptimeLine, width))
 
+	return append(lines, w.smHistoryLines(width)...)
+}
+
+// smHistoryLines renders the last hour of the daemon history as one
+// labeled sparkline per series, each sample bucket downsampled into a
+// cell, followed by the newest value. It returns nothing without history.
+func (w *SysMetricsWidget) smHistoryLines(width int) []string {
+	if w.history == nil || w.history.Len() == 0 {
+		return nil
+	}
+	since := w.history.Last().Add(-smHistoryWindow)
+	sparkWidth := width - 5 - 11 // label, then " " and the value
+	if sparkWidth < 5 {
+		sparkWidth = 5
+	}
+
+	lines := []string{"", components.Bold("Last hour")}
+	for _, s := range smHistorySeries {
+		snap, ok := w.history.Since(s.name, since)
+		if !ok || snap.Len() == 0 {
+			continue
+		}
+		style := components.SparklineStyle{Width: sparkWidth, Color: smColorBlue}
+		if s.percent {
+			minY, maxY := 0.0, 100.0
+			style.MinY, style.MaxY = &minY, &maxY
+		}
+		values := data.Downsample(snap.Values, sparkWidth, s.agg)
+		line := fmt.Sprintf("%-5s", s.label) + components.NewSparkline(style).Render(values, sparkWidth) + " " + s.format(snap.Last())
+		lines = append(lines, smTruncLine(line, width))
+	}
 	return lines
 }
 
@@ -331,6 +395,11 @@ func smFormatUptime(d time.Duration) string {
 	return strings.Join(parts, " ")
 }
 
+// smFormatRate formats a throughput in bytes per second, e.g. "1.2 MB/s".
+func smFormatRate(bytesPerSec float64) string {
+	return smFormatBytes(uint64(max(bytesPerSec, 0))) + "/s"
+}
+
 // smFormatPercent formats a float64 percentage value into a string like "73%".
 func smFormatPercent(pct float64) string {
 	return fmt.Sprintf("%d%%", int(math.Round(pct)))
@@ -5,11 +5,13 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/sysmetrics"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/data"
 )
 
 // --- helpers ---
@@ -580,3 +582,38 @@ func TestSysMetricsWidgetCompileTimeInterface(t *testing.T) {
 	var _ app.Widget = (*SysMetricsWidget)(nil)
 }
 
+func TestSysMetricsWidgetHistoryGraphs(t *testing.T) {
+	w := NewSysMetricsWidget()
+	w.Update(app.DataUpdateEvent{Source: "sysmetrics", Data: smTestMetrics()})
+	if strings.Contains(strings.Join(w.smViewCompact(60), "\n"), "Last hour") {
+		t.Fatal("history section shown without history")
+	}
+
+	// Series in sorted order: cpu, load1, memory, net_rx, net_tx.
+	ring := data.NewRing(100, "cpu", "load1", "memory", "net_rx", "net_tx")
+	end := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ring.Add(end.Add(-2*time.Hour), 99, 9, 99, 9e9, 9e9) // outside the hour
+	for i := 0; i < 10; i++ {
+		ring.Add(end.Add(time.Duration(i-9)*time.Minute), float64(i*10), 1.5, 40, 2048, 1024)
+	}
+	w.Update(app.DataUpdateEvent{Source: "sysmetrics-history", Data: ring})
+
+	for _, view := range [][]string{w.smViewCompact(60), w.smViewExpanded(60)} {
+		out := stripANSI(strings.Join(view, "\n"))
+		for _, want := range []string{"Last hour", "CPU", "90%", "40%", "1.50", "2.0 KB/s", "1.0 KB/s"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("view missing %q:\n%s", want, out)
+			}
+		}
+		// A steady in-window rate graphs flat; the old spike would not.
+		for _, line := range strings.Split(out, "\n") {
+			if !strings.HasPrefix(line, "Rx   ") {
+				continue
+			}
+			spark := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "Rx   "), " 2.0 KB/s"))
+			if first, _ := utf8.DecodeRuneInString(spark); strings.Trim(spark, string(first)) != "" {
+				t.Errorf("Rx graph not flat, sample older than an hour included: %q", spark)
+			}
+		}
+	}
+}
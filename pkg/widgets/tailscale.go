@@ -341,9 +341,25 @@ func (w *TailscaleWidget) tsSelfLine(width int) string {
 	} else {
 		line = fmt.Sprintf("%s %s %s  %s", dot, self.Hostname, selfLabel, self.OS)
 	}
+	if w.status.KeyExpiringSoon && self.KeyExpiry != nil {
+		line += "  " + components.Color(tsColorYellow) + w.tsKeyExpiryText(*self.KeyExpiry) + components.Reset()
+	}
 	return components.Truncate(line, width)
 }
 
+// tsKeyExpiryText describes how long until this node's key expires.
+func (w *TailscaleWidget) tsKeyExpiryText(expiry time.Time) string {
+	left := expiry.Sub(w.nowFunc())
+	switch {
+	case left <= 0:
+		return "key expired"
+	case left < 24*time.Hour:
+		return fmt.Sprintf("key expires in %dh", int(left.Hours())+1)
+	default:
+		return fmt.Sprintf("key expires in %dd", int(left.Hours()/24))
+	}
+}
+
 // tsPeerLineCompact builds a single peer line for compact view.
 func (w *TailscaleWidget) tsPeerLineCompact(p tailscale.PeerInfo, width int) string {
 	var dot string
@@ -325,6 +325,30 @@ func TestSelfNodeDisplay(t *testing.T) {
 	}
 }
 
+func TestSelfNodeKeyExpiry(t *testing.T) {
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	w := NewTailscaleWidget()
+	w.nowFunc = tsFixedNow(now)
+	w.status = tsBuildTestStatus(now)
+	expiry := now.Add(3*24*time.Hour + time.Hour)
+	w.status.Self.KeyExpiry = &expiry
+
+	if view := w.View(80, 10); strings.Contains(view, "key expires") {
+		t.Errorf("view should not warn before KeyExpiringSoon is set, got: %q", view)
+	}
+
+	w.status.KeyExpiringSoon = true
+	if view := w.View(80, 10); !strings.Contains(view, "key expires in 3d") {
+		t.Errorf("view should contain 'key expires in 3d', got: %q", view)
+	}
+
+	expired := now.Add(-time.Minute)
+	w.status.Self.KeyExpiry = &expired
+	if view := w.View(80, 10); !strings.Contains(view, "key expired") {
+		t.Errorf("view should contain 'key expired', got: %q", view)
+	}
+}
+
 func TestTimeFormatting(t *testing.T) {
 	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
 	w := NewTailscaleWidget()
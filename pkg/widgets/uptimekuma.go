@@ -0,0 +1,168 @@
+package widgets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// Color constants for Uptime Kuma widget elements.
+const (
+	ukColorUp          = "#10B981"
+	ukColorDown        = "#EF4444"
+	ukColorPending     = "#F59E0B"
+	ukColorMaintenance = "#3B82F6"
+)
+
+// UptimeKumaWidget displays Uptime Kuma monitor status. Down monitors are
+// listed first so failures are visible even in a small pane.
+type UptimeKumaWidget struct {
+	status       *uptimekuma.Status
+	scrollOffset int
+}
+
+// NewUptimeKumaWidget creates a new UptimeKumaWidget with default state.
+func NewUptimeKumaWidget() *UptimeKumaWidget {
+	return &UptimeKumaWidget{}
+}
+
+// ID returns the unique identifier for this widget.
+func (w *UptimeKumaWidget) ID() string {
+	return "uptimekuma"
+}
+
+// Title returns the human-readable display name.
+func (w *UptimeKumaWidget) Title() string {
+	return "Uptime Kuma"
+}
+
+// MinSize returns the minimum width and height this widget requires.
+func (w *UptimeKumaWidget) MinSize() (int, int) {
+	return 25, 3
+}
+
+// Update handles messages directed at this widget. It processes
+// DataUpdateEvent messages with Source "uptimekuma".
+func (w *UptimeKumaWidget) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.DataUpdateEvent:
+		if msg.Source != "uptimekuma" || msg.Err != nil {
+			return nil
+		}
+		if st, ok := msg.Data.(*uptimekuma.Status); ok {
+			w.status = st
+			if w.scrollOffset >= len(st.Monitors) {
+				w.scrollOffset = 0
+			}
+		}
+	}
+	return nil
+}
+
+// HandleKey processes a key event when this widget has focus. Up/down (or
+// k/j) scroll the monitor list.
+func (w *UptimeKumaWidget) HandleKey(key tea.KeyMsg) tea.Cmd {
+	switch key.String() {
+	case "up", "k":
+		if w.scrollOffset > 0 {
+			w.scrollOffset--
+		}
+	case "down", "j":
+		if w.status != nil && w.scrollOffset < len(w.status.Monitors)-1 {
+			w.scrollOffset++
+		}
+	}
+	return nil
+}
+
+// View renders the widget content into the given area dimensions.
+func (w *UptimeKumaWidget) View(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, height)
+	if w.status == nil {
+		lines = append(lines, components.Dim("No data"))
+	} else {
+		header := fmt.Sprintf("%d/%d up", w.status.Up, w.status.Total)
+		if w.status.Down > 0 {
+			header += components.Color(ukColorDown) + fmt.Sprintf("  %d down", w.status.Down) + components.Reset()
+		}
+		lines = append(lines, header)
+
+		monitors := ukSortedMonitors(w.status.Monitors)
+		for i := w.scrollOffset; i < len(monitors) && len(lines) < height; i++ {
+			lines = append(lines, ukMonitorLine(monitors[i], width))
+		}
+	}
+
+	for i := range lines {
+		lines[i] = components.PadRight(components.Truncate(lines[i], width), width)
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ukMonitorLine renders one monitor: a colored state dot, the name, and the
+// last response time right-aligned when it fits.
+func ukMonitorLine(m uptimekuma.Monitor, width int) string {
+	dot := components.Color(ukStateColor(m.State)) + "●" + components.Reset()
+	line := dot + " " + m.Name
+
+	if m.State == uptimekuma.StateUp && m.ResponseTimeMs > 0 {
+		rt := fmt.Sprintf("%.0fms", m.ResponseTimeMs)
+		if gap := width - components.VisibleLen(line) - len(rt); gap > 0 {
+			line += strings.Repeat(" ", gap) + components.Dim(rt)
+		}
+	} else if m.State != uptimekuma.StateUp {
+		line += " " + components.Dim(m.State)
+	}
+	return line
+}
+
+// ukStateColor returns the indicator color for a monitor state.
+func ukStateColor(state string) string {
+	switch state {
+	case uptimekuma.StateUp:
+		return ukColorUp
+	case uptimekuma.StateDown:
+		return ukColorDown
+	case uptimekuma.StateMaintenance:
+		return ukColorMaintenance
+	default:
+		return ukColorPending
+	}
+}
+
+// ukSortedMonitors returns monitors ordered down, pending, maintenance, up,
+// then by name.
+func ukSortedMonitors(monitors []uptimekuma.Monitor) []uptimekuma.Monitor {
+	rank := map[string]int{
+		uptimekuma.StateDown:        0,
+		uptimekuma.StatePending:     1,
+		uptimekuma.StateMaintenance: 2,
+		uptimekuma.StateUp:          3,
+	}
+	sorted := make([]uptimekuma.Monitor, len(monitors))
+	copy(sorted, monitors)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank[sorted[i].State], rank[sorted[j].State]
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
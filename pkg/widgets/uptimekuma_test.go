@@ -0,0 +1,94 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/collectors/uptimekuma"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
+// ukBuildTestStatus returns three monitors: one up, one down, one in
+// maintenance.
+func ukBuildTestStatus() *uptimekuma.Status {
+	return &uptimekuma.Status{
+		Monitors: []uptimekuma.Monitor{
+			{Name: "api", State: uptimekuma.StateUp, ResponseTimeMs: 142},
+			{Name: "dns", State: uptimekuma.StateMaintenance},
+			{Name: "staging", State: uptimekuma.StateDown},
+		},
+		Up:    1,
+		Down:  1,
+		Total: 3,
+	}
+}
+
+func TestUptimeKumaWidget_NoData(t *testing.T) {
+	w := NewUptimeKumaWidget()
+	view := w.View(30, 3)
+	if !strings.Contains(view, "No data") {
+		t.Errorf("view should contain 'No data', got:\n%s", view)
+	}
+	if n := len(strings.Split(view, "\n")); n != 3 {
+		t.Errorf("view has %d lines, want 3", n)
+	}
+}
+
+func TestUptimeKumaWidget_Update(t *testing.T) {
+	w := NewUptimeKumaWidget()
+
+	w.Update(app.DataUpdateEvent{Source: "tailscale", Data: ukBuildTestStatus()})
+	if w.status != nil {
+		t.Error("widget should ignore other sources")
+	}
+
+	w.Update(app.DataUpdateEvent{Source: "uptimekuma", Data: ukBuildTestStatus()})
+	if w.status == nil || w.status.Total != 3 {
+		t.Fatalf("status = %+v, want 3 monitors", w.status)
+	}
+}
+
+func TestUptimeKumaWidget_View_DownFirst(t *testing.T) {
+	w := NewUptimeKumaWidget()
+	w.Update(app.DataUpdateEvent{Source: "uptimekuma", Data: ukBuildTestStatus()})
+
+	view := w.View(40, 5)
+	lines := strings.Split(view, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("view has %d lines, want 5", len(lines))
+	}
+	for i, line := range lines {
+		if got := components.VisibleLen(line); got != 40 {
+			t.Errorf("line %d width = %d, want 40", i, got)
+		}
+	}
+	if !strings.Contains(lines[0], "1/3 up") || !strings.Contains(lines[0], "1 down") {
+		t.Errorf("header = %q, want up/down counts", lines[0])
+	}
+	if !strings.Contains(lines[1], "staging") {
+		t.Errorf("first monitor = %q, want the down monitor", lines[1])
+	}
+	if !strings.Contains(lines[3], "api") || !strings.Contains(lines[3], "142ms") {
+		t.Errorf("last monitor = %q, want api with response time", lines[3])
+	}
+}
+
+func TestUptimeKumaWidget_Scroll(t *testing.T) {
+	w := NewUptimeKumaWidget()
+	w.Update(app.DataUpdateEvent{Source: "uptimekuma", Data: ukBuildTestStatus()})
+
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if w.scrollOffset != 1 {
+		t.Errorf("scrollOffset = %d after j, want 1", w.scrollOffset)
+	}
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	w.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	if w.scrollOffset != 0 {
+		t.Errorf("scrollOffset = %d, want clamped at 0", w.scrollOffset)
+	}
+}
+
+var _ app.Widget = (*UptimeKumaWidget)(nil)
@@ -9,6 +9,7 @@ import (
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+	ppimage "gitlab.com/tinyland/lab/prompt-pulse/pkg/image"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/waifu"
 )
 
@@ -19,6 +20,14 @@ type ImageRenderer interface {
 	RenderFile(path string, width, height int) (string, error)
 }
 
+// PlaceholderRenderer is implemented by renderers that can draw a cheap
+// stand-in for an image while its real render is in flight, as
+// pkg/image.Renderer does. The widget shows it instead of a loading
+// message.
+type PlaceholderRenderer interface {
+	RenderFilePlaceholder(path string, width, height int) (string, error)
+}
+
 // WaifuRefreshMsg is sent when the user presses 'r' to request a new image.
 type WaifuRefreshMsg struct{}
 
@@ -36,6 +45,14 @@ type WaifuWidget struct {
 	overlayText string // character name / source
 	showInfo    bool   // whether the info overlay is active
 	loading     bool
+
+	// placeholder is shown while loading, drawn for phWidth x phHeight.
+	placeholder string
+	phWidth     int
+	phHeight    int
+	// clearRegion is set when a render replaces the placeholder, so the
+	// next View blanks the area first.
+	clearRegion bool
 	err         error
 }
 
@@ -96,9 +113,17 @@ func (w *WaifuWidget) Update(msg tea.Msg) tea.Cmd {
 			w.rendered = data
 			w.loading = false
 			w.err = nil
+			if w.placeholder != "" {
+				// The render was made for the area the placeholder
+				// fills; swap it in without rendering again.
+				w.lastWidth, w.lastHeight = w.phWidth, w.phHeight
+				w.clearRegion = true
+				w.placeholder = ""
+			}
 		case *waifu.Session:
 			w.session = data
 			w.overlayText = formatImageName(data.ImagePath)
+			w.placeholder = ""
 			// Invalidate cache so the next View call re-renders.
 			w.lastWidth = 0
 			w.lastHeight = 0
@@ -132,15 +157,27 @@ func (w *WaifuWidget) View(width, height int) string {
 		return w.renderError(width, height)
 	}
 
-	// Loading state: show a centered loading indicator.
+	// Loading state: show the image's placeholder, or a centered loading
+	// indicator without one.
 	if w.loading || w.session == nil {
+		if ph := w.renderPlaceholder(width, height); ph != "" {
+			return w.applyOverlay(ph, width, height)
+		}
 		return w.renderLoading(width, height)
 	}
 
-	// If the cached render matches the current dimensions, reuse it.
+	// If the cached render matches the current dimensions, reuse it. The
+	// first frame after a placeholder clears the area, as the render may
+	// not cover all of it.
 	if w.rendered != "" && w.lastWidth == width && w.lastHeight == height {
-		return w.applyOverlay(w.rendered, width, height)
+		out := w.applyOverlay(w.rendered, width, height)
+		if w.clearRegion {
+			w.clearRegion = false
+			out = ppimage.ClearRegion(width, height) + out
+		}
+		return out
 	}
+	w.clearRegion = false
 
 	// Render the image at the available interior size.
 	imgW := width
@@ -206,6 +243,7 @@ func (w *WaifuWidget) refresh() tea.Cmd {
 	w.session = session
 	w.overlayText = formatImageName(session.ImagePath)
 	w.rendered = ""
+	w.placeholder = ""
 	w.lastWidth = 0
 	w.lastHeight = 0
 	w.loading = true
@@ -214,6 +252,25 @@ func (w *WaifuWidget) refresh() tea.Cmd {
 	return nil
 }
 
+// renderPlaceholder returns the session image's placeholder for a width x
+// height area, or "" when the renderer cannot draw one. It is kept until
+// the area changes.
+func (w *WaifuWidget) renderPlaceholder(width, height int) string {
+	pr, ok := w.renderer.(PlaceholderRenderer)
+	if !ok || w.session == nil {
+		return ""
+	}
+	if w.placeholder != "" && w.phWidth == width && w.phHeight == height {
+		return w.placeholder
+	}
+	ph, err := pr.RenderFilePlaceholder(w.session.ImagePath, width, height)
+	if err != nil {
+		return ""
+	}
+	w.placeholder, w.phWidth, w.phHeight = ph, width, height
+	return ph
+}
+
 // renderLoading creates a centered loading indicator.
 func (w *WaifuWidget) renderLoading(width, height int) string {
 	msg := "Loading..."
@@ -366,6 +423,9 @@ var _ app.Widget = (*WaifuWidget)(nil)
 // interface shape (both have RenderFile(string, int, int) (string, error)).
 var _ ImageRenderer = (waifu.ImageRenderer)(nil)
 
+// compile-time check that pkg/image.Renderer draws placeholders.
+var _ PlaceholderRenderer = (*ppimage.Renderer)(nil)
+
 // formatOverlayText is an alias kept for documentation; the actual
 // formatting is done by formatImageName. This comment exists to note
 // that the overlay text is the formatted image filename.
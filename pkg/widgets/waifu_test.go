@@ -9,6 +9,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/app"
+	ppimage "gitlab.com/tinyland/lab/prompt-pulse/pkg/image"
 	"gitlab.com/tinyland/lab/prompt-pulse/pkg/waifu"
 )
 
@@ -480,3 +481,51 @@ func TestRenderErrorDimensions(t *testing.T) {
 		t.Errorf("RenderError should produce %d lines, got %d", 10, len(lines))
 	}
 }
+
+// placeholderRenderer is a mockRenderer that also draws placeholders.
+type placeholderRenderer struct {
+	mockRenderer
+	placeholders int
+}
+
+func (p *placeholderRenderer) RenderFilePlaceholder(path string, width, height int) (string, error) {
+	p.placeholders++
+	return strings.Repeat("~", width), nil
+}
+
+func TestViewLoadingShowsPlaceholder(t *testing.T) {
+	r := &placeholderRenderer{}
+	w := &WaifuWidget{id: "waifu", renderer: r, loading: true, session: newTestSession("/images/a.png")}
+
+	if got := w.View(12, 4); got != strings.Repeat("~", 12) {
+		t.Errorf("loading view = %q, want the placeholder", got)
+	}
+	w.View(12, 4)
+	if r.placeholders != 1 {
+		t.Errorf("placeholder drawn %d times, want 1 for an unchanged area", r.placeholders)
+	}
+	if r.calls != 0 {
+		t.Error("the real render should not run while loading")
+	}
+
+	// The async render swaps in, clearing the area once.
+	w.Update(app.DataUpdateEvent{Source: "waifu", Data: "REAL", Timestamp: time.Now()})
+	got := w.View(12, 4)
+	if want := ppimage.ClearRegion(12, 4) + "REAL"; got != want {
+		t.Errorf("first view after the render = %q, want %q", got, want)
+	}
+	if got := w.View(12, 4); got != "REAL" {
+		t.Errorf("later views = %q, want the render alone", got)
+	}
+	if r.calls != 0 {
+		t.Error("the swapped-in render should be reused, not rendered again")
+	}
+}
+
+func TestViewLoadingWithoutPlaceholderRenderer(t *testing.T) {
+	w := newTestWidget(&mockRenderer{}, newTestSession("/images/a.png"))
+	w.SetLoading(true)
+	if got := w.View(20, 5); !strings.Contains(got, "Loading...") {
+		t.Errorf("view = %q, want the loading indicator", got)
+	}
+}
@@ -3,6 +3,14 @@
 // interface and receives data via the Elm-architecture Update loop.
 package widgets
 
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/components"
+)
+
 // Common color constants for widget border and accent styling.
 const (
 	// ColorBorderDefault is the muted gray used for unfocused widget borders.
@@ -20,3 +28,112 @@ const (
 	// ColorError is used for error message text.
 	ColorError = "#EF4444"
 )
+
+// tableMouse hit-tests mouse events against the selectable DataTable a
+// widget's View last drew, and tracks a drag on its scrollbar.
+type tableMouse struct {
+	table    *components.DataTable // nil when the last View drew none
+	y        int                   // line of the view the table starts on
+	dragging bool
+}
+
+// draw records that View drew table starting at line y; a nil table
+// records that it drew none.
+func (t *tableMouse) draw(table *components.DataTable, y int) {
+	t.table, t.y = table, y
+}
+
+// row returns the row msg selects, or -1 if it selects none: the row a
+// left press lands on, or for a press on the scrollbar and the drag that
+// follows it, the row at that point of the track.
+func (t *tableMouse) row(msg tea.MouseMsg) int {
+	if t.table == nil {
+		t.dragging = false
+		return -1
+	}
+	y := msg.Y - t.y
+	switch msg.Action {
+	case tea.MouseActionPress:
+		if msg.Button != tea.MouseButtonLeft {
+			return -1
+		}
+		if t.table.OnScrollbar(msg.X, y) {
+			t.dragging = true
+			return t.table.ScrollbarRow(y)
+		}
+		return t.table.RowAt(y)
+	case tea.MouseActionMotion:
+		if t.dragging {
+			return t.table.ScrollbarRow(y)
+		}
+	case tea.MouseActionRelease:
+		t.dragging = false
+	}
+	return -1
+}
+
+// tableFilter narrows the selectable DataTable a widget's View draws to
+// the rows matching the TUI's search query. Widgets keep their selection
+// as an index into all of the table's rows; tableFilter maps it to and
+// from the rows shown.
+type tableFilter struct {
+	query   string
+	matches []int // indices of the rows the last View showed
+	total   int   // rows of the last View's table
+}
+
+// apply records which of rows pass the query as View draws them, and
+// returns the filter to install on the table, the row to select, and its
+// position among those shown. The selection stays on selected if it
+// passes and otherwise moves to the first match.
+func (f *tableFilter) apply(rows []components.Row, selected int) (filter func(components.Row) bool, sel, pos int) {
+	filter = components.QueryFilter(f.query)
+	f.matches, f.total = f.matches[:0], len(rows)
+	for i, r := range rows {
+		if filter == nil || filter(r) {
+			f.matches = append(f.matches, i)
+		}
+	}
+	if pos = slices.Index(f.matches, selected); pos >= 0 {
+		return filter, selected, pos
+	}
+	if len(f.matches) == 0 || filter == nil {
+		return filter, selected, max(selected, 0)
+	}
+	return filter, f.matches[0], 0
+}
+
+// step returns the row delta rows away from selected among those the last
+// View showed, stopping at the first and last. Without a query it is
+// selected+delta, left for the widget to clamp.
+func (f *tableFilter) step(selected, delta int) int {
+	if f.query == "" || len(f.matches) == 0 {
+		return selected + delta
+	}
+	pos := slices.Index(f.matches, selected)
+	if pos < 0 {
+		return f.matches[0]
+	}
+	return f.matches[min(max(pos+delta, 0), len(f.matches)-1)]
+}
+
+// source returns the row shown at position pos, as returned by
+// tableMouse.row, or -1 for none.
+func (f *tableFilter) source(pos int) int {
+	if f.query == "" {
+		return pos
+	}
+	if pos < 0 || pos >= len(f.matches) {
+		return -1
+	}
+	return f.matches[pos]
+}
+
+// state returns the values of app.TableFilterer.TableFilter for a widget
+// whose last View drew a filterable table if drawn.
+func (f *tableFilter) state(drawn bool) (query string, matches, total int, ok bool) {
+	if !drawn {
+		return f.query, 0, 0, false
+	}
+	return f.query, len(f.matches), f.total, true
+}
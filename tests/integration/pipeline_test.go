@@ -0,0 +1,143 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/starship"
+)
+
+// TestPipeline runs the collectors once against a healthy world and checks
+// the fake data reaches the banner and the prompt.
+func TestPipeline(t *testing.T) {
+	w := newWorld(t)
+	cfg := w.config(t)
+	d := w.newDaemon(t, cfg)
+
+	if err := runOnce(t, d); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	out := renderBanner(t, d, cfg)
+	for _, want := range []string{
+		"prompt-pulse vtest",
+		"Claude $6.00", // 1M in at $3/M + 200K out at $15/M of Sonnet
+		"civo $24.60: instance $21.00, volume $3.60", // from the Civo charges
+		"fakehost",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("banner should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "old)") || strings.Contains(out, "stale:") {
+		t.Errorf("fresh banner marks data as old:\n%s", out)
+	}
+
+	prompt := renderStarship(cfg)
+	for _, want := range []string{"🤖 $6.00", "$24.60/mo", "2/3 up", "1/1 up"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should contain %q, got: %s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "⟳") {
+		t.Errorf("fresh prompt marks data as stale: %s", prompt)
+	}
+}
+
+// TestPipeline_ProviderFailure fails one billing provider and checks the
+// others are still shown while the failure is reported.
+func TestPipeline_ProviderFailure(t *testing.T) {
+	w := newWorld(t)
+	w.hetzner.failWith(http.StatusInternalServerError)
+	cfg := w.config(t)
+	d := w.newDaemon(t, cfg)
+
+	// A provider failure is part of the report, not a collector failure.
+	if err := runOnce(t, d); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	if out := renderBanner(t, d, cfg); !strings.Contains(out, "civo $24.60") {
+		t.Errorf("banner should still show Civo, got:\n%s", out)
+	}
+	if prompt := renderStarship(cfg); !strings.Contains(prompt, "$24.60/mo") {
+		t.Errorf("prompt should still total the healthy providers, got: %s", prompt)
+	}
+
+	out := starship.Collect(starshipConfig(cfg))
+	if out.Billing == nil {
+		t.Fatal("JSON output has no billing section")
+	}
+	status := make(map[string]starship.BillingProviderJSON)
+	for _, p := range out.Billing.Providers {
+		status[p.Name] = p
+	}
+	if p := status["civo"]; p.Status != "ok" || p.MonthToDate != 24.60 {
+		t.Errorf("civo = %+v, want ok at $24.60", p)
+	}
+	if p := status["hetzner"]; p.Status != "error" || !strings.Contains(p.Error, "500") {
+		t.Errorf("hetzner = %+v, want an error naming the 500", p)
+	}
+}
+
+// TestPipeline_StaleAfterFailure lets the infra endpoints fail after a
+// good run and checks the last good data is still shown, marked stale,
+// and the timed-out collector is named in the banner.
+func TestPipeline_StaleAfterFailure(t *testing.T) {
+	w := newWorld(t)
+	cfg := w.config(t)
+	d := w.newDaemon(t, cfg)
+
+	if err := runOnce(t, d); err != nil {
+		t.Fatalf("first collect: %v", err)
+	}
+
+	// Uptime Kuma now hangs past the collect timeout, and the billing
+	// APIs fail outright.
+	w.kuma.stallFor(5 * time.Second)
+	w.civo.failWith(http.StatusInternalServerError)
+	err := runOnce(t, d)
+	if err == nil || !strings.Contains(err.Error(), "uptimekuma") {
+		t.Fatalf("second collect error = %v, want uptimekuma to fail", err)
+	}
+
+	health, herr := d.Health()
+	if herr != nil {
+		t.Fatalf("Health() error: %v", herr)
+	}
+	if got := health.TimedOut(); len(got) != 1 || got[0] != "uptimekuma" {
+		t.Errorf("TimedOut() = %v, want [uptimekuma]", got)
+	}
+	if kuma := health.Collectors["uptimekuma"]; kuma.Healthy || kuma.LastError == "" {
+		t.Errorf("uptimekuma health = %+v, want unhealthy with an error", kuma)
+	}
+
+	// The failed run left the previous Uptime Kuma data in the cache;
+	// once it is older than StaleAfter it is shown marked.
+	w.age(t, "uptimekuma", 10*time.Minute)
+	w.age(t, "claude", 10*time.Minute)
+
+	prompt := renderStarship(cfg)
+	if !strings.Contains(prompt, "2/3 up ⟳") {
+		t.Errorf("prompt should show the old monitor counts marked stale, got: %s", prompt)
+	}
+	if strings.Contains(prompt, "1/1 up ⟳") {
+		t.Errorf("prompt marks the fresh checks as stale: %s", prompt)
+	}
+
+	out := renderBanner(t, d, cfg)
+	for _, want := range []string{"⏱ stale: uptimekuma", "Claude $6.00 (10m old)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("banner should contain %q, got:\n%s", want, out)
+		}
+	}
+	// Civo's failure replaced its breakdown with an error, so there is
+	// no breakdown line to show.
+	if strings.Contains(out, "civo $") {
+		t.Errorf("banner still shows the failed Civo breakdown:\n%s", out)
+	}
+}
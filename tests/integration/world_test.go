@@ -0,0 +1,322 @@
+//go:build integration
+
+// Package integration runs the whole pipeline, daemon to collectors to
+// cache to banner and starship, against httptest servers standing in for
+// the billing APIs and infra endpoints. Run it with
+//
+//	go test -tags integration ./tests/integration/
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/banner"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/cache"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/config"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/daemon"
+	"gitlab.com/tinyland/lab/prompt-pulse/pkg/starship"
+)
+
+// fakeAPI is an httptest server answering GET requests from a fixed table
+// of paths. It can be switched to fail every request with a status code,
+// or to stall each one, to inject failures mid-test.
+type fakeAPI struct {
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	status int           // nonzero fails every request with this status
+	stall  time.Duration // delays every response
+}
+
+// newFakeAPI starts a server answering each path in routes with its body,
+// and 404 otherwise. It is closed when t ends.
+func newFakeAPI(t *testing.T, routes map[string]string) *fakeAPI {
+	t.Helper()
+	f := &fakeAPI{}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		status, stall := f.status, f.stall
+		f.mu.Unlock()
+
+		if stall > 0 {
+			select {
+			case <-time.After(stall):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status != 0 {
+			http.Error(w, "injected failure", status)
+			return
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// URL returns the server's base URL.
+func (f *fakeAPI) URL() string { return f.srv.URL }
+
+// failWith makes every later request fail with status; zero heals it.
+func (f *fakeAPI) failWith(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+// stallFor delays every later response by d; zero removes the delay.
+func (f *fakeAPI) stallFor(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stall = d
+}
+
+// world is the fake outside world one test runs against, with a cache
+// directory and a config generated to point every collector at it.
+type world struct {
+	civo, hetzner, kuma, site *fakeAPI
+
+	cacheDir   string
+	configPath string
+}
+
+// Fake data the rendered output is checked for.
+const (
+	// civoCharges totals $24.60: $21.00 of instances and $3.60 of volumes.
+	civoCharges = `{"items":[
+		{"code":"instance-g3.small","label":"web-1","total_cost":12.00},
+		{"code":"instance-g3.small","label":"web-2","total_cost":9.00},
+		{"code":"volume-10gb","label":"data","total_cost":3.60}]}`
+	civoInstances = `{"items":[
+		{"id":"i-1","hostname":"web-1","status":"ACTIVE","monthly_cost":20.00},
+		{"id":"i-2","hostname":"web-2","status":"ACTIVE","monthly_cost":20.00}]}`
+
+	// kumaMetrics has two monitors up and one down.
+	kumaMetrics = `# TYPE monitor_status gauge
+monitor_status{monitor_name="api",monitor_type="http",monitor_url="https://api.example.com",monitor_hostname="null",monitor_port="null"} 1
+monitor_status{monitor_name="web",monitor_type="http",monitor_url="https://www.example.com",monitor_hostname="null",monitor_port="null"} 1
+monitor_status{monitor_name="mail",monitor_type="port",monitor_url="",monitor_hostname="mail.example.com",monitor_port="25"} 0
+`
+)
+
+// newWorld starts the fake servers, writes a Claude Code session for the
+// claude collector to read, and generates a config using them all.
+func newWorld(t *testing.T) *world {
+	t.Helper()
+	w := &world{
+		civo: newFakeAPI(t, map[string]string{
+			"/charges":    civoCharges,
+			"/kubernetes": `{"items":[]}`,
+			"/instances":  civoInstances,
+		}),
+		hetzner: newFakeAPI(t, map[string]string{
+			"/servers": `{"servers":[],"meta":{"pagination":{"next_page":null}}}`,
+			"/volumes": `{"volumes":[],"meta":{"pagination":{"next_page":null}}}`,
+		}),
+		kuma: newFakeAPI(t, map[string]string{"/metrics": kumaMetrics}),
+		site: newFakeAPI(t, map[string]string{"/healthz": "ok"}),
+	}
+
+	root := t.TempDir()
+	w.cacheDir = filepath.Join(root, "cache")
+	sessions := filepath.Join(root, "claude", "projects")
+	writeSession(t, sessions, "-home-dev-pp", "5e55104d-0001", "claude-sonnet-4-5-20250929", 1_000_000, 200_000)
+
+	// Every collector that would reach the real machine or network is
+	// off; the rest talk only to the fake servers.
+	toml := fmt.Sprintf(`
+[general]
+cache_dir = %q
+collect_timeout = "500ms"
+
+# Injected failures are still retried, but well within the timeout.
+[http]
+retry_base_delay = "1ms"
+max_retry_delay = "5ms"
+
+[banner]
+billing_breakdown = true
+
+[banner.fastfetch]
+mode = "native"
+
+[collectors.sysmetrics]
+enabled = false
+
+[collectors.tailscale]
+enabled = false
+
+[collectors.claude]
+enabled = true
+
+[[collectors.claude.account]]
+name = "personal"
+sessions_dir = %q
+
+[collectors.billing]
+enabled = true
+
+[collectors.billing.civo]
+enabled = true
+api_key = "civo-test"
+base_url = %q
+
+[collectors.billing.hetzner]
+enabled = true
+api_key = "hetzner-test"
+base_url = %q
+
+[collectors.uptimekuma]
+enabled = true
+url = %q
+
+[collectors.checks]
+enabled = true
+
+[[collectors.checks.check]]
+name = "site"
+type = "http"
+target = %q
+`, w.cacheDir, sessions, w.civo.URL(), w.hetzner.URL(), w.kuma.URL(), w.site.URL()+"/healthz")
+
+	w.configPath = filepath.Join(root, "config.toml")
+	if err := os.WriteFile(w.configPath, []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// writeSession writes a Claude Code transcript with one assistant message.
+func writeSession(t *testing.T, dir, project, id, model string, in, out int64) {
+	t.Helper()
+	line := fmt.Sprintf(`{"type":"assistant","sessionId":%q,"timestamp":%q,"message":{"id":"msg-1","model":%q,"usage":{"input_tokens":%d,"output_tokens":%d}}}`+"\n",
+		id, time.Now().Add(-10*time.Minute).Format(time.RFC3339), model, in, out)
+	path := filepath.Join(dir, project, id+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// config loads the generated config the way the binary does, so the
+// environment overrides apply. Provider tokens in the environment are
+// cleared so the config's fakes are used.
+func (w *world) config(t *testing.T) *config.Config {
+	t.Helper()
+	for _, env := range []string{"CIVO_TOKEN", "CIVO_TOKEN_FILE", "HCLOUD_TOKEN", "HCLOUD_TOKEN_FILE", "UPTIME_KUMA_API_KEY", "UPTIME_KUMA_API_KEY_FILE"} {
+		t.Setenv(env, "")
+	}
+	cfg, err := config.LoadFromFile(w.configPath)
+	if err != nil {
+		t.Fatalf("load generated config: %v", err)
+	}
+	return cfg
+}
+
+// newDaemon returns a daemon for cfg with its files under the world's
+// cache directory, as the binary sets it up. It is not started.
+func (w *world) newDaemon(t *testing.T, cfg *config.Config) *daemon.Daemon {
+	t.Helper()
+	d, err := daemon.New(daemon.Config{
+		PIDFile:         filepath.Join(w.cacheDir, "prompt-pulse.pid"),
+		HealthFile:      filepath.Join(w.cacheDir, "prompt-pulse-health.json"),
+		SocketPath:      filepath.Join(w.cacheDir, daemon.ControlSocketName),
+		DataDir:         w.cacheDir,
+		BannerCacheFile: filepath.Join(w.cacheDir, "prompt-pulse-banner.json"),
+	})
+	if err != nil {
+		t.Fatalf("daemon.New() error: %v", err)
+	}
+	d.SetConfig(cfg, w.configPath)
+	return d
+}
+
+// runOnce runs every collector once, as a daemon poll does, and writes
+// the daemon's health file. It returns the collect error, if any.
+func runOnce(t *testing.T, d *daemon.Daemon) error {
+	t.Helper()
+	_, err := d.Collect(context.Background(), "")
+	if werr := d.WriteHealth(); werr != nil {
+		t.Fatalf("WriteHealth() error: %v", werr)
+	}
+	return err
+}
+
+// staleness is the staleness the tests render with: data older than a
+// minute is marked.
+var staleness = cache.Staleness{StaleAfter: time.Minute}
+
+// renderBanner renders the banner from the cache as "prompt-pulse
+// -banner" does, without escape codes. It renders for a narrow terminal,
+// whose stacked layout sizes each section to its content, so no status
+// line is cut off.
+func renderBanner(t *testing.T, d *daemon.Daemon, cfg *config.Config) string {
+	t.Helper()
+	opts := banner.GenerateOptions{
+		Header:    "prompt-pulse vtest",
+		Staleness: staleness,
+		SysInfo: func(context.Context) (banner.SysInfo, error) {
+			return banner.SysInfo{Hostname: "fakehost"}, nil
+		},
+	}
+	if health, err := d.Health(); err == nil {
+		opts.TimedOut = health.TimedOut()
+	}
+	preset := banner.SelectPresetWithConfig(64, 40, cfg.Banner)
+	data := banner.Generate(context.Background(), cfg, preset, opts)
+	return stripANSI(banner.RenderWithConfig(data, preset, cfg.Banner))
+}
+
+// starshipConfig is the starship config for the world's cache with the
+// segments the fakes feed enabled.
+func starshipConfig(cfg *config.Config) starship.Config {
+	return starship.Config{
+		CacheDir:       cfg.General.CacheDir,
+		ShowClaude:     true,
+		ShowBilling:    true,
+		ShowUptimeKuma: true,
+		ShowChecks:     true,
+		MaxWidth:       200,
+		Staleness:      staleness,
+	}
+}
+
+// renderStarship renders the starship module as "prompt-pulse -starship"
+// does, without escape codes.
+func renderStarship(cfg *config.Config) string {
+	return stripANSI(starship.Render(starshipConfig(cfg)))
+}
+
+var ansiRE = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07]*\x07`)
+
+// stripANSI removes escape sequences from s.
+func stripANSI(s string) string {
+	return ansiRE.ReplaceAllString(s, "")
+}
+
+// age backdates the cache file for key by d.
+func (w *world) age(t *testing.T, key string, d time.Duration) {
+	t.Helper()
+	path := filepath.Join(w.cacheDir, key+".json")
+	then := time.Now().Add(-d)
+	if err := os.Chtimes(path, then, then); err != nil {
+		t.Fatal(err)
+	}
+}